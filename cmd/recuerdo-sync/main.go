@@ -0,0 +1,147 @@
+// Command recuerdo-sync is a small self-hosted sync server. It serves only
+// what devices sync through: the shared lessons, the lessons and review
+// history of every account, and encrypted vaults. Unlike "recuerdo serve"
+// it does not link against Qt, so it runs on machines without a desktop,
+// and it leaves out the classroom.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	restapi "github.com/LaPingvino/recuerdo/internal/modules/logic/restApi"
+	"github.com/LaPingvino/recuerdo/internal/paths"
+)
+
+// defaultRateLimit is how many requests a minute one address may send
+// unless -rate-limit says otherwise
+const defaultRateLimit = 600
+
+// usage describes the command
+const usage = `Usage:
+  %[1]s [-addr 127.0.0.1:8080] [-data DIR] [-tokens TOKEN,...] [-admin-tokens TOKEN,...]
+        [-account-tokens ACCOUNT=TOKEN,...]
+        [-rate-limit N] [-tls-cert FILE -tls-key FILE | -acme-domains DOMAIN,...]
+        [-cors-origins ORIGIN,...]
+
+Serves lessons and review history to the recuerdo devices of every account,
+logging to stdout until it receives SIGINT or SIGTERM. It only listens on
+localhost unless -addr says otherwise, such as -addr :8080 for every
+interface. Devices point
+sync.server at it and set sync.account to keep their lessons and review
+history apart from the shared lessons, or sync.passphrase to keep them
+encrypted. With tokens, every request but /healthz needs an
+"Authorization: Bearer TOKEN" header carrying one of them; an address
+sending ten wrong ones is locked out for a quarter of an hour. An account
+is then only reached with one of -account-tokens bound to it, which its
+devices set as sync.token, or with an admin token.
+
+To expose the server beyond the local network, serve HTTPS with -tls-cert
+and -tls-key, or with certificates from Let's Encrypt for -acme-domains,
+which needs the server reachable on port 443 of them. Flags default to the
+RECUERDO_ADDR, RECUERDO_LESSONS, RECUERDO_API_TOKENS,
+RECUERDO_ADMIN_TOKENS, RECUERDO_ACCOUNT_TOKENS, RECUERDO_RATE_LIMIT, RECUERDO_TLS_CERT,
+RECUERDO_TLS_KEY, RECUERDO_ACME_DOMAINS, RECUERDO_ACME_EMAIL and
+RECUERDO_CORS_ORIGINS environment variables when those are set.
+
+Options:
+`
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run starts the server and returns the process exit code once it has been
+// shut down
+func run(args []string) int {
+	flags := flag.NewFlagSet("recuerdo-sync", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), usage, os.Args[0])
+		flags.PrintDefaults()
+	}
+	addr := flags.String("addr", envOrDefault("RECUERDO_ADDR", restapi.DefaultAddr), "address the server listens on")
+	dataDir := flags.String("data", paths.LessonDir(), "directory keeping the lessons, accounts and vaults")
+	tokens := flags.String("tokens", envOrDefault("RECUERDO_API_TOKENS", ""), "comma separated tokens devices must send; none leaves the server open")
+	adminTokens := flags.String("admin-tokens", envOrDefault("RECUERDO_ADMIN_TOKENS", ""), "comma separated tokens of the admins, who may also read the audit log")
+	accountTokens := flags.String("account-tokens", envOrDefault("RECUERDO_ACCOUNT_TOKENS", ""), "comma separated ACCOUNT=TOKEN pairs binding tokens to the one account they reach")
+	rateLimit := flags.Int("rate-limit", envIntOrDefault("RECUERDO_RATE_LIMIT", defaultRateLimit), "requests a minute one address may send; 0 for any number")
+	certFile := flags.String("tls-cert", envOrDefault("RECUERDO_TLS_CERT", ""), "PEM file with the TLS certificate to serve HTTPS with")
+	keyFile := flags.String("tls-key", envOrDefault("RECUERDO_TLS_KEY", ""), "PEM file with the key of the TLS certificate")
+	acmeDomains := flags.String("acme-domains", envOrDefault("RECUERDO_ACME_DOMAINS", ""), "comma separated domains to serve HTTPS for with certificates from Let's Encrypt")
+	acmeEmail := flags.String("acme-email", envOrDefault("RECUERDO_ACME_EMAIL", ""), "email address Let's Encrypt sends notices about the certificates to")
+	acmeCache := flags.String("acme-cache", filepath.Join(paths.DataDir(), "acme"), "directory the certificates from Let's Encrypt are kept in")
+	corsOrigins := flags.String("cors-origins", envOrDefault("RECUERDO_CORS_ORIGINS", ""), "comma separated origins of web pages that may call the API, or * for any")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	// Service managers collect stdout, so log there without file names
+	log.SetOutput(os.Stdout)
+	log.SetFlags(log.LstdFlags)
+
+	if err := os.MkdirAll(*dataDir, 0755); err != nil {
+		log.Printf("[ERROR] Failed to create data directory: %v", err)
+		return 1
+	}
+
+	restAPIModule := restapi.NewRestAPIModule()
+	restAPIModule.SetAddr(*addr)
+	restAPIModule.SetLessonDir(*dataDir)
+	restAPIModule.SetTokens(strings.Split(*tokens, ","))
+	restAPIModule.SetAdminTokens(strings.Split(*adminTokens, ","))
+	restAPIModule.SetAccountTokens(strings.Split(*accountTokens, ","))
+	restAPIModule.SetClassroomEnabled(false)
+	restAPIModule.SetRateLimit(*rateLimit)
+	restAPIModule.SetTLSFiles(*certFile, *keyFile)
+	restAPIModule.SetACME(strings.Split(*acmeDomains, ","), *acmeCache, *acmeEmail)
+	restAPIModule.SetCORSOrigins(strings.Split(*corsOrigins, ","))
+	manager := core.NewManager()
+	if err := manager.Register(restAPIModule); err != nil {
+		log.Printf("[ERROR] Failed to register REST API module: %v", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := manager.EnableAll(ctx); err != nil {
+		log.Printf("[ERROR] Failed to start the sync server: %v", err)
+		return 1
+	}
+	log.Printf("[INFO] recuerdo-sync serving %s on %s", *dataDir, *addr)
+
+	<-ctx.Done()
+	log.Printf("[INFO] Shutting down")
+
+	if err := manager.DisableAll(context.Background()); err != nil {
+		log.Printf("[ERROR] Error during shutdown: %v", err)
+		return 1
+	}
+	return 0
+}
+
+// envIntOrDefault returns the environment variable key as a number, or
+// fallback when unset or not a number
+func envIntOrDefault(key string, fallback int) int {
+	if value, err := strconv.Atoi(envOrDefault(key, "")); err == nil {
+		return value
+	}
+	return fallback
+}
+
+// envOrDefault returns the environment variable key, or fallback when unset
+func envOrDefault(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
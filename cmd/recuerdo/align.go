@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// alignUsage describes "recuerdo align"
+const alignUsage = `Usage:
+  %[1]s align [-o OUTPUT] [-title TITLE] ORIGINAL TRANSLATION
+
+Pairs the sentences of a plain text file with those of its translation into
+a lesson of sentence pairs, for sentence mining. Sentences the translation
+joins or splits are kept together; those without a counterpart are left
+out. OUTPUT defaults to ORIGINAL with a .ot ending.
+
+Options:
+`
+
+// runAlignCommand writes a lesson of the aligned sentences of two texts
+// and returns the process exit code
+func runAlignCommand(args []string) int {
+	flags := flag.NewFlagSet("align", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), alignUsage, os.Args[0])
+		flags.PrintDefaults()
+	}
+	output := flags.String("o", "", "file to write the lesson to")
+	title := flags.String("title", "", "title of the lesson (default the name of ORIGINAL)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return 2
+	}
+
+	originalPath, translationPath := flags.Arg(0), flags.Arg(1)
+	stem := strings.TrimSuffix(originalPath, filepath.Ext(originalPath))
+	if *output == "" {
+		*output = stem + lesson.NativeExtension("words")
+	}
+	if *title == "" {
+		*title = filepath.Base(stem)
+	}
+	original, err := os.ReadFile(originalPath)
+	if err != nil {
+		printCommandError("align", err)
+		return 1
+	}
+	translation, err := os.ReadFile(translationPath)
+	if err != nil {
+		printCommandError("align", err)
+		return 1
+	}
+	draft, err := lesson.AlignTexts(string(original), string(translation), *title)
+	if err != nil {
+		printCommandError("align", err)
+		return 1
+	}
+	lessonData := draft.ToLessonData()
+	if err := lesson.NewFileSaver().SaveFile(lessonData, *output); err != nil {
+		printCommandError("align", err)
+		return 1
+	}
+	fmt.Printf("Wrote %d sentence pairs to %s, leaving out %d sentences without a counterpart\n",
+		len(lessonData.List.Items), *output, len(draft.Rows)-len(lessonData.List.Items))
+	return 0
+}
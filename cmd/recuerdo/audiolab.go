@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	audiolab "github.com/LaPingvino/recuerdo/internal/modules/logic/savers/audioLab"
+	"github.com/LaPingvino/recuerdo/internal/modules/tts"
+)
+
+// audioLabUsage describes "recuerdo audiolab"
+const audioLabUsage = `Usage:
+  %[1]s audiolab [-o DIR] [-pause SECONDS] [-encoder ENCODER] FILE
+
+Exports a lesson as an audio language lab: one numbered MP3 per item saying
+the question, pausing, then saying the answer, plus an M3U playlist to review
+with while commuting. The questions and answers are spoken by the
+text-to-speech engine of the system and encoded with ffmpeg or lame; without
+either the files are kept as WAV. DIR defaults to FILE without its extension
+and with an -audio ending.
+
+Options:
+`
+
+// runAudioLabCommand exports a lesson as audio files and returns the process
+// exit code
+func runAudioLabCommand(args []string) int {
+	flags := flag.NewFlagSet("audiolab", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), audioLabUsage, os.Args[0])
+		flags.PrintDefaults()
+	}
+	output := flags.String("o", "", "directory to write the audio files and playlist to")
+	pause := flags.Float64("pause", audiolab.DefaultOptions().Pause.Seconds(), "seconds of silence between question and answer")
+	encoder := flags.String("encoder", "", `MP3 encoder: "ffmpeg", "lame" or "none" for WAV files (default the first one installed)`)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	input := flags.Arg(0)
+	if *output == "" {
+		*output = strings.TrimSuffix(input, filepath.Ext(input)) + "-audio"
+	}
+	lessonData, err := lesson.NewFileLoader().LoadFile(input)
+	if err != nil {
+		printCommandError("audiolab", err)
+		return 1
+	}
+	speech := tts.NewTTSModule()
+	if !speech.IsAvailable() {
+		printCommandError("audiolab", fmt.Errorf("no text-to-speech backend available on this system"))
+		return 1
+	}
+	saver := audiolab.NewAudioLabSaverModule()
+	saver.SetSynthesizer(speech)
+	options := audiolab.Options{Pause: time.Duration(*pause * float64(time.Second)), Encoder: *encoder}
+	playlist, err := saver.Export(lessonData, *output, options)
+	if err != nil {
+		printCommandError("audiolab", err)
+		return 1
+	}
+	fmt.Printf("Wrote audio for %s to %s\n", filepath.Base(input), playlist)
+	return 0
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// convertUsage describes "recuerdo convert"
+const convertUsage = `Usage:
+  %[1]s convert [options] INPUT OUTPUT
+  %[1]s convert [options] -to EXT [-dir DIR] INPUT ...
+
+Converts lessons from any format Recuerdo opens to any format it saves, as
+told by the extensions, without starting the GUI. The second form converts
+many lessons at once, writing each next to its input or into DIR; inputs may
+be patterns such as 'lessons/*.apkg', quoted so the shell leaves them alone.
+Inputs that would convert to the same file are reported before any is
+converted.
+OUTPUT may also end in .pdf or .odt for a study sheet.
+
+Existing files are not overwritten unless -force is given. Encrypted .otsec
+lessons are read and written with the passphrase in $RECUERDO_PASSPHRASE.
+Text inputs are read in the encoding detected, or in the one given with
+-encoding when that guess garbles their letters:
+
+  %[1]s convert -encoding windows-1252 old.csv words.ot
+
+The options of the output format are set with -set, repeated as needed:
+
+  %[1]s convert -set csv.delimiter=';' -set encoding=utf-8-bom words.ot words.csv
+
+Options:
+`
+
+// saveOptionFlags collects -set KEY=VALUE flags into save options
+type saveOptionFlags struct {
+	options *lesson.SaveOptions
+	set     []string
+}
+
+func (f *saveOptionFlags) String() string {
+	return strings.Join(f.set, " ")
+}
+
+func (f *saveOptionFlags) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("expected KEY=VALUE, got %q", value)
+	}
+	// Shells make typing a tab awkward
+	if key == "csv.delimiter" && val == `\t` {
+		val = "\t"
+	}
+	if err := f.options.SetValue(key, val); err != nil {
+		return err
+	}
+	f.set = append(f.set, value)
+	return nil
+}
+
+// runConvertCommand converts lessons between formats and returns the process
+// exit code
+func runConvertCommand(args []string) int {
+	flags := flag.NewFlagSet("convert", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), convertUsage, os.Args[0])
+		flags.PrintDefaults()
+	}
+	to := flags.String("to", "", "extension to convert every input to, such as .csv")
+	dir := flags.String("dir", "", "directory to write converted lessons to (with -to)")
+	force := flags.Bool("force", false, "overwrite existing files")
+	keepGoing := flags.Bool("keep-going", false, "go on with the other lessons when one fails")
+	encoding := flags.String("encoding", "", "encoding to read text inputs in, such as windows-1252 (default detect it)")
+	saver := lesson.NewFileSaver()
+	saver.Options.Passphrase = os.Getenv("RECUERDO_PASSPHRASE")
+	flags.Var(&saveOptionFlags{options: &saver.Options}, "set", "save option KEY=VALUE of the output format, such as csv.delimiter=;")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	conversions, err := convertTargets(flags.Args(), *to, *dir)
+	if err != nil {
+		printCommandError("convert", err)
+		flags.Usage()
+		return 2
+	}
+
+	loader := lesson.NewFileLoader()
+	loader.SetPassphrase(os.Getenv("RECUERDO_PASSPHRASE"))
+	for _, conversion := range conversions {
+		if err := loader.SetEncodingOverride(conversion[0], *encoding); err != nil {
+			printCommandError("convert", err)
+			return 2
+		}
+	}
+	failed := 0
+	for _, conversion := range conversions {
+		input, output := conversion[0], conversion[1]
+		if err := convertLesson(loader, saver, input, output, *force); err != nil {
+			failed++
+			printCommandError("convert", fmt.Errorf("%s: %w", input, err))
+			if !*keepGoing {
+				return 1
+			}
+			continue
+		}
+		fmt.Printf("%s -> %s\n", input, output)
+	}
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d of %d lessons could not be converted\n", failed, len(conversions))
+		return 1
+	}
+	return 0
+}
+
+// convertTargets pairs every input with the file it is converted to. With
+// to empty, args are one input and its output.
+func convertTargets(args []string, to, dir string) ([][2]string, error) {
+	if to == "" {
+		if dir != "" {
+			return nil, fmt.Errorf("-dir needs -to")
+		}
+		if len(args) != 2 {
+			return nil, fmt.Errorf("expected an input and an output file, or -to with inputs")
+		}
+		return [][2]string{{args[0], args[1]}}, nil
+	}
+
+	if !strings.HasPrefix(to, ".") {
+		to = "." + to
+	}
+	var inputs []string
+	for _, arg := range args {
+		if !strings.ContainsAny(arg, "*?[") {
+			inputs = append(inputs, arg)
+			continue
+		}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %s", arg)
+		}
+		inputs = append(inputs, matches...)
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("no lessons to convert")
+	}
+
+	conversions := make([][2]string, 0, len(inputs))
+	for _, input := range inputs {
+		name := filepath.Base(input)
+		if strings.HasSuffix(strings.ToLower(name), ".pau.gz") {
+			name = name[:len(name)-len(".pau.gz")]
+		} else {
+			name = strings.TrimSuffix(name, filepath.Ext(name))
+		}
+		outputDir := dir
+		if outputDir == "" {
+			outputDir = filepath.Dir(input)
+		}
+		conversions = append(conversions, [2]string{input, filepath.Join(outputDir, name+to)})
+	}
+
+	// Inputs sharing a base name, say words.csv and words.apkg or two
+	// folders gathered by -dir, would overwrite each other's output
+	sources := make(map[string][]string)
+	var outputs []string
+	for _, conversion := range conversions {
+		output := filepath.Clean(conversion[1])
+		if sources[output] == nil {
+			outputs = append(outputs, output)
+		}
+		sources[output] = append(sources[output], conversion[0])
+	}
+	var clashes []string
+	for _, output := range outputs {
+		if len(sources[output]) > 1 {
+			clashes = append(clashes, fmt.Sprintf("%s would be written from %s", output, strings.Join(sources[output], ", ")))
+		}
+	}
+	if len(clashes) > 0 {
+		return nil, fmt.Errorf("several lessons convert to the same file:\n  %s", strings.Join(clashes, "\n  "))
+	}
+	return conversions, nil
+}
+
+// convertLesson loads input and saves it as output, in the formats of
+// their extensions
+func convertLesson(loader *lesson.FileLoader, saver *lesson.FileSaver, input, output string, force bool) error {
+	if filepath.Clean(input) == filepath.Clean(output) {
+		return fmt.Errorf("the output is the input")
+	}
+	if _, err := os.Stat(output); err == nil && !force {
+		return fmt.Errorf("%s already exists (use -force to overwrite it)", output)
+	}
+
+	lessonData, err := loader.LoadFile(input)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(filepath.Ext(output)) {
+	case ".pdf", ".odt":
+		return saver.SaveStudySheet(lessonData, output)
+	default:
+		return saver.SaveFile(lessonData, output)
+	}
+}
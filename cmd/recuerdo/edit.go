@@ -0,0 +1,163 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// editUsage describes the "recuerdo edit" subcommands
+const editUsage = `Usage:
+  %[1]s edit merge   -o out.ot a.csv b.kvtml ...   Merge lessons into one file
+  %[1]s edit split   -size 20 [-o part.csv] in.ot  Split into files of at most N items
+  %[1]s edit split   -by-tag [-o part.csv] in.ot   Split by #tags in item comments
+  %[1]s edit dedupe  [-o out.ot] in.ot             Remove duplicate items
+  %[1]s edit reverse [-o out.ot] in.ot             Swap questions and answers
+  %[1]s edit shuffle [-seed N] [-o out.ot] in.ot   Shuffle item order
+
+The output format follows the extension of -o. Without -o, dedupe, reverse
+and shuffle overwrite the input file and split writes next to it.
+`
+
+// runEditCommand runs a bulk edit subcommand without starting the GUI and
+// returns the process exit code
+func runEditCommand(args []string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		fmt.Fprintf(os.Stderr, editUsage, os.Args[0])
+		return 2
+	}
+
+	if err := runEdit(args[0], args[1:]); err != nil {
+		printCommandError("edit "+args[0], err)
+		return 1
+	}
+	return 0
+}
+
+// printCommandError reports the error a subcommand failed with, followed by
+// what the user can do about it when the error is one the lesson package
+// knows, such as a password-protected or damaged file
+func printCommandError(command string, err error) {
+	fmt.Fprintf(os.Stderr, "recuerdo %s: %v\n", command, err)
+	if message := lesson.UserMessage(err); message != err.Error() {
+		fmt.Fprintln(os.Stderr, message)
+	}
+}
+
+// runEdit parses the flags of one subcommand and performs it
+func runEdit(operation string, args []string) error {
+	switch operation {
+	case "merge", "split", "dedupe", "reverse", "shuffle":
+	default:
+		return fmt.Errorf("unknown operation %q (use merge, split, dedupe, reverse or shuffle)", operation)
+	}
+
+	flags := flag.NewFlagSet("edit "+operation, flag.ContinueOnError)
+	output := flags.String("o", "", "output file")
+	size := flags.Int("size", 0, "maximum number of items per file (split)")
+	byTag := flags.Bool("by-tag", false, "split by #tags in item comments (split)")
+	seed := flags.Int64("seed", time.Now().UnixNano(), "random seed (shuffle)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	inputs := flags.Args()
+	if len(inputs) == 0 {
+		return fmt.Errorf("no input files given")
+	}
+	if operation != "merge" && len(inputs) != 1 {
+		return fmt.Errorf("expected one input file, got %d", len(inputs))
+	}
+
+	loader := lesson.NewFileLoader()
+	lessons := make([]*lesson.LessonData, 0, len(inputs))
+	for _, input := range inputs {
+		lessonData, err := loader.LoadFile(input)
+		if err != nil {
+			return fmt.Errorf("loading %s: %w", input, err)
+		}
+		lessons = append(lessons, lessonData)
+	}
+
+	target := *output
+	if target == "" {
+		target = inputs[0]
+	}
+
+	switch operation {
+	case "merge":
+		if *output == "" {
+			return fmt.Errorf("merge needs an output file (-o)")
+		}
+		merged, err := lesson.MergeLessons(lessons...)
+		if err != nil {
+			return err
+		}
+		return saveEdited(merged, target)
+
+	case "split":
+		return splitEdited(lessons[0], target, *size, *byTag)
+
+	case "dedupe":
+		deduped, removed := lesson.DedupeLesson(lessons[0])
+		fmt.Printf("Removed %d duplicate items\n", removed)
+		return saveEdited(deduped, target)
+
+	case "reverse":
+		return saveEdited(lesson.ReverseLesson(lessons[0]), target)
+
+	default: // shuffle
+		return saveEdited(lesson.ShuffleLesson(lessons[0], *seed), target)
+	}
+}
+
+// splitEdited saves the parts of a split next to target, numbered or named by tag
+func splitEdited(lessonData *lesson.LessonData, target string, size int, byTag bool) error {
+	if (size > 0) == byTag {
+		return fmt.Errorf("split needs exactly one of -size or -by-tag")
+	}
+
+	ext := filepath.Ext(target)
+	base := strings.TrimSuffix(target, ext)
+
+	if byTag {
+		parts := lesson.SplitLessonByTag(lessonData)
+		tags := make([]string, 0, len(parts))
+		for tag := range parts {
+			tags = append(tags, tag)
+		}
+		sort.Strings(tags)
+		for _, tag := range tags {
+			if err := saveEdited(parts[tag], fmt.Sprintf("%s-%s%s", base, tag, ext)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	parts, err := lesson.SplitLesson(lessonData, size)
+	if err != nil {
+		return err
+	}
+	for i, part := range parts {
+		if err := saveEdited(part, fmt.Sprintf("%s-%d%s", base, i+1, ext)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveEdited writes lesson data in the format given by the file extension
+func saveEdited(lessonData *lesson.LessonData, filePath string) error {
+	if err := lesson.NewFileSaver().SaveFile(lessonData, filePath); err != nil {
+		return fmt.Errorf("saving %s: %w", filePath, err)
+	}
+	fmt.Printf("Wrote %d items to %s\n", len(lessonData.List.Items), filePath)
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// exportUsage describes "recuerdo export"
+const exportUsage = `Usage:
+  %[1]s export -list
+  %[1]s export [-preset NAME] [-o BASE] FILE
+
+Exports a lesson with an export preset: a named set of formats written in one
+go, such as "Share with class" for the lesson with its media inside, "Archive"
+for JSON, CSV and a study sheet in a ZIP file, or "Print" for a study sheet.
+Your own presets are read from %[2]s.
+
+BASE is the path of the exported files without extension (default FILE
+without extension, with -export added when that would overwrite FILE).
+
+Options:
+`
+
+// runExportCommand exports a lesson with a preset and returns the process
+// exit code
+func runExportCommand(args []string) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), exportUsage, os.Args[0], lesson.DefaultExportPresetsPath())
+		flags.PrintDefaults()
+	}
+	presetName := flags.String("preset", "Share with class", "name of the export preset")
+	output := flags.String("o", "", "path of the exported files, without extension")
+	list := flags.Bool("list", false, "list the export presets and exit")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	presets, err := lesson.LoadExportPresets(lesson.DefaultExportPresetsPath())
+	if err != nil {
+		printCommandError("export", err)
+		return 1
+	}
+	if *list {
+		for _, preset := range presets {
+			kind := "files"
+			if preset.Zip {
+				kind = "zip"
+			}
+			fmt.Printf("%-20s %s (%s: %s)\n", preset.Name, preset.Description, kind, strings.Join(preset.Formats, ", "))
+		}
+		return 0
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	index := lesson.FindExportPreset(presets, *presetName)
+	if index < 0 {
+		printCommandError("export", fmt.Errorf("unknown export preset %q, see 'export -list'", *presetName))
+		return 2
+	}
+	preset := presets[index]
+
+	input := flags.Arg(0)
+	loader := lesson.NewFileLoader()
+	base := *output
+	if base == "" {
+		base = strings.TrimSuffix(input, filepath.Ext(input))
+		for _, file := range preset.ExportPaths(base, loader.GetFileType(input)) {
+			if file == input {
+				base += "-export"
+				break
+			}
+		}
+	}
+
+	lessonData, err := loader.LoadFile(input)
+	if err != nil {
+		printCommandError("export", err)
+		return 1
+	}
+	files, err := lesson.NewFileSaver().ExportWithPreset(lessonData, loader.GetFileType(input), preset, base)
+	if err != nil {
+		printCommandError("export", err)
+		return 1
+	}
+	for _, file := range files {
+		fmt.Printf("Wrote %s\n", file)
+	}
+	return 0
+}
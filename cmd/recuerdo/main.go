@@ -4,6 +4,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/signal"
@@ -57,13 +58,18 @@ import (
 	wordsonly "github.com/LaPingvino/recuerdo/internal/modules/data/profileDescriptions/wordsOnly"
 	"github.com/LaPingvino/recuerdo/internal/modules/data/profiledescriptions"
 	userdocumentation "github.com/LaPingvino/recuerdo/internal/modules/data/userDocumentation"
+	translationupdater "github.com/LaPingvino/recuerdo/internal/modules/profileRunners/translationUpdater"
 
 	// ALL Qt imports temporarily disabled to get core system working first
 	// TODO: Re-enable Qt modules incrementally once basic system is validated
 
+	"github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/accessibility"
 	"github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/dialogs/about"
+	compareDialog "github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/dialogs/compare"
+	duplicatesDialog "github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/dialogs/duplicates"
 	"github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/dialogs/file"
 	settingsDialog "github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/dialogs/settings"
+	statisticsDialog "github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/dialogs/statistics"
 	"github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/lessonDialogs"
 
 	// "github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/dialogs/documentation" // Disabled due to build constraints
@@ -97,8 +103,11 @@ import (
 	shorttext "github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/settingsWidget/shortText"
 	settingswidgets "github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/settingsWidgets"
 	startwidget "github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/startWidget"
+	"github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/teachTypes/cloze"
+	"github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/teachTypes/dictation"
 	"github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/teachTypes/hangman"
 	inmind "github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/teachTypes/inMind"
+	multiplechoice "github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/teachTypes/multipleChoice"
 	repeatanswer "github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/teachTypes/repeatAnswer"
 	shuffleanswer "github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/teachTypes/shuffleAnswer"
 	"github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/teachTypes/typing"
@@ -107,8 +116,11 @@ import (
 	teacherpanel "github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/testMode/teacherPanel"
 	"github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/theme"
 	topomaps "github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/topoMaps"
+	touchmode "github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/touchMode"
 	"github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/typingTutor/keyboard"
+	ankiconnect "github.com/LaPingvino/recuerdo/internal/modules/logic/ankiConnect"
 	"github.com/LaPingvino/recuerdo/internal/modules/logic/authors"
+	dailyprogress "github.com/LaPingvino/recuerdo/internal/modules/logic/dailyProgress"
 	"github.com/LaPingvino/recuerdo/internal/modules/logic/settings"
 
 	logicevent "github.com/LaPingvino/recuerdo/internal/modules/logic/event"
@@ -119,12 +131,17 @@ import (
 	htmltopo "github.com/LaPingvino/recuerdo/internal/modules/logic/htmlGenerator/topo"
 	htmlwords "github.com/LaPingvino/recuerdo/internal/modules/logic/htmlGenerator/words"
 
+	"github.com/LaPingvino/recuerdo/internal/modules/logic/autosave"
+	duplicatefinder "github.com/LaPingvino/recuerdo/internal/modules/logic/duplicateFinder"
+	featureflags "github.com/LaPingvino/recuerdo/internal/modules/logic/featureFlags"
+	filewatcher "github.com/LaPingvino/recuerdo/internal/modules/logic/fileWatcher"
 	buttonregister "github.com/LaPingvino/recuerdo/internal/modules/logic/interfaces/buttonRegister"
 	inputtypinglogic "github.com/LaPingvino/recuerdo/internal/modules/logic/interfaces/inputTypingLogic"
 	javascriptinputtypinglogic "github.com/LaPingvino/recuerdo/internal/modules/logic/interfaces/javaScriptInputTypingLogic"
 	lessontracker "github.com/LaPingvino/recuerdo/internal/modules/logic/interfaces/lessonTracker"
 	typingtutormodel "github.com/LaPingvino/recuerdo/internal/modules/logic/interfaces/typingTutorModel"
 	foreignknown "github.com/LaPingvino/recuerdo/internal/modules/logic/itemModifiers/foreignKnown"
+	"github.com/LaPingvino/recuerdo/internal/modules/logic/itemModifiers/transliteration"
 	"github.com/LaPingvino/recuerdo/internal/modules/logic/javaScript/bisect"
 	"github.com/LaPingvino/recuerdo/internal/modules/logic/javaScript/evaluator"
 	jsevent "github.com/LaPingvino/recuerdo/internal/modules/logic/javaScript/event"
@@ -137,11 +154,15 @@ import (
 	weblogicgenerator "github.com/LaPingvino/recuerdo/internal/modules/logic/javaScript/webLogicGenerator"
 	javascriptpercentscalculator "github.com/LaPingvino/recuerdo/internal/modules/logic/javaScriptPercentsCalculator"
 	languagecodeguesser "github.com/LaPingvino/recuerdo/internal/modules/logic/languageCodeGuesser"
+	lessonlibrary "github.com/LaPingvino/recuerdo/internal/modules/logic/lessonLibrary"
 	allonce "github.com/LaPingvino/recuerdo/internal/modules/logic/lessonTypes/allOnce"
 	"github.com/LaPingvino/recuerdo/internal/modules/logic/lessonTypes/smart"
+	bychapter "github.com/LaPingvino/recuerdo/internal/modules/logic/listModifiers/byChapter"
+	bytag "github.com/LaPingvino/recuerdo/internal/modules/logic/listModifiers/byTag"
 	hardwords "github.com/LaPingvino/recuerdo/internal/modules/logic/listModifiers/hardWords"
 	random "github.com/LaPingvino/recuerdo/internal/modules/logic/listModifiers/random_"
 	"github.com/LaPingvino/recuerdo/internal/modules/logic/listModifiers/reverse"
+	"github.com/LaPingvino/recuerdo/internal/modules/logic/listModifiers/slowest"
 	"github.com/LaPingvino/recuerdo/internal/modules/logic/listModifiers/sort"
 	wordsneveransweredcorrectly "github.com/LaPingvino/recuerdo/internal/modules/logic/listModifiers/wordsNeverAnsweredCorrectly"
 	"github.com/LaPingvino/recuerdo/internal/modules/logic/loader"
@@ -170,6 +191,7 @@ import (
 	"github.com/LaPingvino/recuerdo/internal/modules/logic/loaders/overhoor"
 	"github.com/LaPingvino/recuerdo/internal/modules/logic/loaders/ovr"
 	"github.com/LaPingvino/recuerdo/internal/modules/logic/loaders/pauker"
+	"github.com/LaPingvino/recuerdo/internal/modules/logic/loaders/quizlet"
 	"github.com/LaPingvino/recuerdo/internal/modules/logic/loaders/t2k"
 	"github.com/LaPingvino/recuerdo/internal/modules/logic/loaders/teachmaster"
 	"github.com/LaPingvino/recuerdo/internal/modules/logic/loaders/voca"
@@ -177,6 +199,7 @@ import (
 	vokabeltrainer "github.com/LaPingvino/recuerdo/internal/modules/logic/loaders/vokabelTrainer"
 	vtraintxt "github.com/LaPingvino/recuerdo/internal/modules/logic/loaders/vtrainTxt"
 	"github.com/LaPingvino/recuerdo/internal/modules/logic/loaders/wrts"
+	"github.com/LaPingvino/recuerdo/internal/modules/logic/maintenance"
 	mergerwords "github.com/LaPingvino/recuerdo/internal/modules/logic/mergers/words"
 	mimicrytypefaceconverter "github.com/LaPingvino/recuerdo/internal/modules/logic/mimicryTypefaceConverter"
 	logicmodules "github.com/LaPingvino/recuerdo/internal/modules/logic/modules"
@@ -186,29 +209,38 @@ import (
 	"github.com/LaPingvino/recuerdo/internal/modules/logic/noteCalculators/ects"
 	"github.com/LaPingvino/recuerdo/internal/modules/logic/noteCalculators/french"
 	"github.com/LaPingvino/recuerdo/internal/modules/logic/noteCalculators/german"
-	"github.com/LaPingvino/recuerdo/internal/modules/logic/noteCalculators/javaScript/percents"
+	"github.com/LaPingvino/recuerdo/internal/modules/logic/noteCalculators/percents"
 	cuneiformrecognizer "github.com/LaPingvino/recuerdo/internal/modules/logic/ocr/cuneiformRecognizer"
 	tesseractrecognizer "github.com/LaPingvino/recuerdo/internal/modules/logic/ocr/tesseractRecognizer"
 	wordlistloader "github.com/LaPingvino/recuerdo/internal/modules/logic/ocr/wordListLoader"
 	"github.com/LaPingvino/recuerdo/internal/modules/logic/odtsaver"
+	optionpresets "github.com/LaPingvino/recuerdo/internal/modules/logic/optionPresets"
 	"github.com/LaPingvino/recuerdo/internal/modules/logic/otxxloader"
 	"github.com/LaPingvino/recuerdo/internal/modules/logic/otxxsaver"
 	percentscalculator "github.com/LaPingvino/recuerdo/internal/modules/logic/percentsCalculator"
 	pyinstallerinterface "github.com/LaPingvino/recuerdo/internal/modules/logic/pyinstallerInterface"
 	recentlyopened "github.com/LaPingvino/recuerdo/internal/modules/logic/recentlyOpened"
+	reviewhistory "github.com/LaPingvino/recuerdo/internal/modules/logic/reviewHistory"
+	"github.com/LaPingvino/recuerdo/internal/modules/logic/subscriptions"
+	syncclient "github.com/LaPingvino/recuerdo/internal/modules/logic/syncClient"
+	"github.com/LaPingvino/recuerdo/internal/modules/logic/transfers"
 
 	"github.com/LaPingvino/recuerdo/internal/modules/logic/reversers/words"
 	safehtmlchecker "github.com/LaPingvino/recuerdo/internal/modules/logic/safeHtmlChecker"
 	"github.com/LaPingvino/recuerdo/internal/modules/logic/saver"
+	audiolab "github.com/LaPingvino/recuerdo/internal/modules/logic/savers/audioLab"
 	"github.com/LaPingvino/recuerdo/internal/modules/logic/savers/latex"
 	libreofficeformats "github.com/LaPingvino/recuerdo/internal/modules/logic/savers/libreofficeFormats"
 	mediahtml "github.com/LaPingvino/recuerdo/internal/modules/logic/savers/mediaHtml"
+	mnemosynesaver "github.com/LaPingvino/recuerdo/internal/modules/logic/savers/mnemosyne"
 	"github.com/LaPingvino/recuerdo/internal/modules/logic/savers/odt"
 	"github.com/LaPingvino/recuerdo/internal/modules/logic/savers/pdf"
 	"github.com/LaPingvino/recuerdo/internal/modules/logic/savers/png"
 	"github.com/LaPingvino/recuerdo/internal/modules/logic/savers/sylk"
 	topohtml "github.com/LaPingvino/recuerdo/internal/modules/logic/savers/topoHtml"
 	wordshtml "github.com/LaPingvino/recuerdo/internal/modules/logic/savers/wordsHtml"
+	"github.com/LaPingvino/recuerdo/internal/modules/logic/savers/xlsx"
+	sessionrestore "github.com/LaPingvino/recuerdo/internal/modules/logic/sessionRestore"
 
 	testtypesmedia "github.com/LaPingvino/recuerdo/internal/modules/logic/testTypes/media"
 	testtypestopo "github.com/LaPingvino/recuerdo/internal/modules/logic/testTypes/topo"
@@ -229,9 +261,61 @@ var (
 	listCmds         = flag.Bool("list-commands", false, "List available commands and exit")
 	helpFlag         = flag.Bool("help", false, "Show help message")
 	strictValidation = flag.Bool("strict-validation", false, "Enable strict UI layout validation (fail on overlaps)")
+	a11yAudit        = flag.Bool("a11y-audit", false, "Report controls screen readers cannot name or the Tab key cannot reach, then exit")
+	touchFlag        = flag.Bool("touch", false, "Start in touch mode for interactive whiteboards, whatever the settings say")
 )
 
 func main() {
+	// Headless subcommands run without registering modules or starting Qt
+	if len(os.Args) > 1 && os.Args[1] == "edit" {
+		os.Exit(runEditCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		os.Exit(runServeCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "audiolab" {
+		os.Exit(runAudioLabCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "align" {
+		os.Exit(runAlignCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "convert" {
+		os.Exit(runConvertCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		os.Exit(runExportCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "healthcheck" {
+		os.Exit(runHealthcheckCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "package" {
+		os.Exit(runPackageCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "pack" {
+		os.Exit(runPackCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "papertest" {
+		os.Exit(runPaperTestCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "repair" {
+		os.Exit(runRepairCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "media" {
+		os.Exit(runMediaCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "schema" {
+		os.Exit(runSchemaCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "studysheet" {
+		os.Exit(runStudySheetCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "thumbnail" {
+		os.Exit(runThumbnailCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "update-translations" {
+		os.Exit(runUpdateTranslationsCommand(os.Args[2:]))
+	}
+
 	// Parse command-line arguments
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "%s %s - Language Learning Application\n\n", appName, appVersion)
@@ -241,7 +325,24 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  %s                              # Start normally\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s lesson.ot                    # Load lesson file\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s --commands=show-properties   # Execute command\n", os.Args[0])
-		fmt.Fprintf(os.Stderr, "  %s lesson.ot --commands=show-properties  # Load file and show properties\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s lesson.ot --a11y-audit       # Check the lesson tabs for accessibility problems\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s --touch                      # Large buttons, swipes and an on-screen keyboard for smartboards\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s lesson.ot --commands=show-properties  # Load file and show properties\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s edit merge -o all.ot a.csv b.csv     # Bulk edit without the GUI (see 'edit help')\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s serve -addr :8080 -lessons ./lessons # Run the server modules without the GUI\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s package docker -o .                  # Write a Dockerfile for serve mode\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s pack verify words.otpack            # Check a lesson pack is signed by a trusted school\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s papertest print lesson.ot           # Print a test with a scannable answer sheet\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s studysheet -o words.pdf words.ot    # Print questions and answers on a sheet to fold\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s audiolab -o audio words.ot          # Speak questions and answers into MP3s and a playlist\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s align novel-en.txt novel-es.txt     # Pair the sentences of a text and its translation\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s convert deck.apkg deck.csv           # Convert a lesson to another format\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s convert -to .ot -dir out '*.csv'     # Convert many lessons at once\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s export -preset Archive words.ot     # Export in several formats at once (see 'export -list')\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s schema validate words.json          # Check a lesson made by another program\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s repair map.ottp                     # Salvage a lesson damaged on a USB stick\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s media relink birds.otmd ~/Sounds    # Find media files moved with a lesson folder\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s thumbnail -o map.png europe.ottp    # Draw a picture of a lesson without the GUI\n\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flag.PrintDefaults()
 	}
@@ -304,6 +405,13 @@ func main() {
 		os.Setenv("RECUERDO_STRICT_LAYOUT", "1")
 		log.Println("Strict layout validation enabled")
 	}
+	if *touchFlag {
+		if module, ok := manager.GetDefaultModule("touchMode"); ok {
+			if touchMode, ok := module.(interface{ Force() }); ok {
+				touchMode.Force()
+			}
+		}
+	}
 
 	if err := manager.EnableAll(ctx); err != nil {
 		log.Fatalf("Failed to enable modules: %v", err)
@@ -311,7 +419,7 @@ func main() {
 	fmt.Println("All modules enabled successfully")
 
 	// Start the main application
-	if err := runApplication(ctx, manager, lessonFile, *commands); err != nil {
+	if err := runApplication(ctx, manager, lessonFile, *commands, *a11yAudit); err != nil {
 		log.Fatalf("Application error: %v", err)
 	}
 
@@ -341,6 +449,11 @@ func registerAllModules(manager *core.Manager) error {
 		return fmt.Errorf("failed to register settings module: %w", err)
 	}
 
+	featureFlagsModule := featureflags.NewFeatureFlagsModule()
+	if err := manager.Register(featureFlagsModule); err != nil {
+		return fmt.Errorf("failed to register feature flags module: %w", err)
+	}
+
 	// Skip duplicate modules.NewMetadataModule - using real metadata module instead
 
 	buttonRegisterModule := modules.NewButtonRegisterModule()
@@ -381,6 +494,21 @@ func registerAllModules(manager *core.Manager) error {
 		return fmt.Errorf("failed to register settings dialog module: %w", err)
 	}
 
+	compareDialogModule := compareDialog.NewCompareDialogModule()
+	if err := manager.Register(compareDialogModule); err != nil {
+		return fmt.Errorf("failed to register compare dialog module: %w", err)
+	}
+
+	duplicatesDialogModule := duplicatesDialog.NewDuplicatesDialogModule()
+	if err := manager.Register(duplicatesDialogModule); err != nil {
+		return fmt.Errorf("failed to register duplicates dialog module: %w", err)
+	}
+
+	statisticsDialogModule := statisticsDialog.NewStatisticsDialogModule()
+	if err := manager.Register(statisticsDialogModule); err != nil {
+		return fmt.Errorf("failed to register statistics dialog module: %w", err)
+	}
+
 	lessonDialogsModule := lessonDialogs.NewLessonDialogsModule()
 	if err := manager.Register(lessonDialogsModule); err != nil {
 		return fmt.Errorf("failed to register lesson dialogs module: %w", err)
@@ -750,6 +878,24 @@ func registerAllModules(manager *core.Manager) error {
 		return fmt.Errorf("failed to register typing module: %w", err)
 	}
 
+	// Register cloze module
+	clozeModule := cloze.NewClozeTeachTypeModule()
+	if err := manager.Register(clozeModule); err != nil {
+		return fmt.Errorf("failed to register cloze module: %w", err)
+	}
+
+	// Register multiple choice module
+	multiplechoiceModule := multiplechoice.NewMultipleChoiceTeachTypeModule()
+	if err := manager.Register(multiplechoiceModule); err != nil {
+		return fmt.Errorf("failed to register multiple choice module: %w", err)
+	}
+
+	// Register dictation module
+	dictationModule := dictation.NewDictationTeachTypeModule()
+	if err := manager.Register(dictationModule); err != nil {
+		return fmt.Errorf("failed to register dictation module: %w", err)
+	}
+
 	// Register media module - DISABLED (duplicate module name conflict)
 	// teachermediaModule := testtypesmedia.NewMediaTestTypeModule()
 	// if err := manager.Register(teachermediaModule); err != nil {
@@ -792,6 +938,18 @@ func registerAllModules(manager *core.Manager) error {
 		return fmt.Errorf("failed to register teacherpanel module: %w", err)
 	}
 
+	// Register accessibility module
+	accessibilityModule := accessibility.NewAccessibilityModule()
+	if err := manager.Register(accessibilityModule); err != nil {
+		return fmt.Errorf("failed to register accessibility module: %w", err)
+	}
+
+	// Register touchmode module
+	touchModeModule := touchmode.NewTouchModeModule()
+	if err := manager.Register(touchModeModule); err != nil {
+		return fmt.Errorf("failed to register touchmode module: %w", err)
+	}
+
 	// Register theme module
 	themeModule := theme.NewThemeModule()
 	if err := manager.Register(themeModule); err != nil {
@@ -1056,6 +1214,12 @@ func registerAllModules(manager *core.Manager) error {
 		return fmt.Errorf("failed to register updaterosetta module: %w", err)
 	}
 
+	// Register translationupdater module
+	translationupdaterModule := translationupdater.NewTranslationupdaterModule()
+	if err := manager.Register(translationupdaterModule); err != nil {
+		return fmt.Errorf("failed to register translationupdater module: %w", err)
+	}
+
 	// Register updatetranslations module
 	updatetranslationsModule := updatetranslations.NewProfileDescriptionModule()
 	if err := manager.Register(updatetranslationsModule); err != nil {
@@ -1136,6 +1300,12 @@ func registerAllModules(manager *core.Manager) error {
 		return fmt.Errorf("failed to register foreignknown module: %w", err)
 	}
 
+	// Register transliteration module
+	transliterationModule := transliteration.NewTransliterationModule()
+	if err := manager.Register(transliterationModule); err != nil {
+		return fmt.Errorf("failed to register transliteration module: %w", err)
+	}
+
 	// Register bisect module
 	bisectModule := bisect.NewJSBisectModule()
 	if err := manager.Register(bisectModule); err != nil {
@@ -1232,6 +1402,24 @@ func registerAllModules(manager *core.Manager) error {
 		return fmt.Errorf("failed to register hardwords module: %w", err)
 	}
 
+	// Register bytag module
+	bytagModule := bytag.NewByTagModule()
+	if err := manager.Register(bytagModule); err != nil {
+		return fmt.Errorf("failed to register bytag module: %w", err)
+	}
+
+	// Register bychapter module
+	bychapterModule := bychapter.NewByChapterModule()
+	if err := manager.Register(bychapterModule); err != nil {
+		return fmt.Errorf("failed to register bychapter module: %w", err)
+	}
+
+	// Register slowest module
+	slowestModule := slowest.NewSlowestModule()
+	if err := manager.Register(slowestModule); err != nil {
+		return fmt.Errorf("failed to register slowest module: %w", err)
+	}
+
 	// Register random module
 	randomModule := random.NewRandomModule()
 	if err := manager.Register(randomModule); err != nil {
@@ -1412,6 +1600,12 @@ func registerAllModules(manager *core.Manager) error {
 		return fmt.Errorf("failed to register pauker module: %w", err)
 	}
 
+	// Register quizlet module
+	quizletModule := quizlet.NewQuizletLoaderModule()
+	if err := manager.Register(quizletModule); err != nil {
+		return fmt.Errorf("failed to register quizlet module: %w", err)
+	}
+
 	// Register t2k module
 	t2kModule := t2k.NewTeach2000LoaderModule()
 	if err := manager.Register(t2kModule); err != nil {
@@ -1460,6 +1654,16 @@ func registerAllModules(manager *core.Manager) error {
 		return fmt.Errorf("failed to register words module: %w", err)
 	}
 
+	duplicateFinderModule := duplicatefinder.NewDuplicateFinderModule()
+	if err := manager.Register(duplicateFinderModule); err != nil {
+		return fmt.Errorf("failed to register duplicate finder module: %w", err)
+	}
+
+	lessonLibraryModule := lessonlibrary.NewLessonLibraryModule()
+	if err := manager.Register(lessonLibraryModule); err != nil {
+		return fmt.Errorf("failed to register lesson library module: %w", err)
+	}
+
 	// Register mimicrytypefaceconverter module
 	mimicrytypefaceconverterModule := mimicrytypefaceconverter.NewMimicryTypefaceConverterModule()
 	if err := manager.Register(mimicrytypefaceconverterModule); err != nil {
@@ -1610,6 +1814,42 @@ func registerAllModules(manager *core.Manager) error {
 		return fmt.Errorf("failed to register recentlyopened module: %w", err)
 	}
 
+	// Register dailyprogress module
+	dailyprogressModule := dailyprogress.NewDailyProgressModule()
+	if err := manager.Register(dailyprogressModule); err != nil {
+		return fmt.Errorf("failed to register dailyprogress module: %w", err)
+	}
+
+	// Register reviewhistory module
+	reviewhistoryModule := reviewhistory.NewReviewHistoryModule()
+	if err := manager.Register(reviewhistoryModule); err != nil {
+		return fmt.Errorf("failed to register reviewhistory module: %w", err)
+	}
+
+	// Register autosave module
+	autosaveModule := autosave.NewAutosaveModule()
+	if err := manager.Register(autosaveModule); err != nil {
+		return fmt.Errorf("failed to register autosave module: %w", err)
+	}
+
+	// Register maintenance module
+	maintenanceModule := maintenance.NewMaintenanceModule()
+	if err := manager.Register(maintenanceModule); err != nil {
+		return fmt.Errorf("failed to register maintenance module: %w", err)
+	}
+
+	// Register session restore module
+	sessionRestoreModule := sessionrestore.NewSessionRestoreModule()
+	if err := manager.Register(sessionRestoreModule); err != nil {
+		return fmt.Errorf("failed to register session restore module: %w", err)
+	}
+
+	// Register file watcher module
+	fileWatcherModule := filewatcher.NewFileWatcherModule()
+	if err := manager.Register(fileWatcherModule); err != nil {
+		return fmt.Errorf("failed to register file watcher module: %w", err)
+	}
+
 	// Register media module - DISABLED (import removed)
 	// reversermediaModule := reversermedia.NewMediaReverserModule()
 	// if err := manager.Register(reversermediaModule); err != nil {
@@ -1724,6 +1964,54 @@ func registerAllModules(manager *core.Manager) error {
 		return fmt.Errorf("failed to register wordshtml module: %w", err)
 	}
 
+	// Register audiolab module
+	audiolabModule := audiolab.NewAudioLabSaverModule()
+	if err := manager.Register(audiolabModule); err != nil {
+		return fmt.Errorf("failed to register audiolab module: %w", err)
+	}
+
+	// Register xlsx module
+	xlsxModule := xlsx.NewXlsxSaverModule()
+	if err := manager.Register(xlsxModule); err != nil {
+		return fmt.Errorf("failed to register xlsx module: %w", err)
+	}
+
+	// Register mnemosyne saver module
+	mnemosyneSaverModule := mnemosynesaver.NewMnemosyneSaverModule()
+	if err := manager.Register(mnemosyneSaverModule); err != nil {
+		return fmt.Errorf("failed to register mnemosyne saver module: %w", err)
+	}
+
+	// Register option presets module
+	optionPresetsModule := optionpresets.NewOptionPresetsModule()
+	if err := manager.Register(optionPresetsModule); err != nil {
+		return fmt.Errorf("failed to register option presets module: %w", err)
+	}
+
+	// Register sync client module
+	syncClientModule := syncclient.NewSyncClientModule()
+	if err := manager.Register(syncClientModule); err != nil {
+		return fmt.Errorf("failed to register sync client module: %w", err)
+	}
+
+	// Register transfers module
+	transfersModule := transfers.NewTransfersModule()
+	if err := manager.Register(transfersModule); err != nil {
+		return fmt.Errorf("failed to register transfers module: %w", err)
+	}
+
+	// Register AnkiConnect module
+	ankiConnectModule := ankiconnect.NewAnkiConnectModule()
+	if err := manager.Register(ankiConnectModule); err != nil {
+		return fmt.Errorf("failed to register AnkiConnect module: %w", err)
+	}
+
+	// Register subscriptions module
+	subscriptionsModule := subscriptions.NewSubscriptionsModule()
+	if err := manager.Register(subscriptionsModule); err != nil {
+		return fmt.Errorf("failed to register subscriptions module: %w", err)
+	}
+
 	// Register wrts module - DISABLED (module doesn't exist)
 	// wrtsModule := wrts.NewWrtsSaverModule()
 	// if err := manager.Register(wrtsModule); err != nil {
@@ -1821,7 +2109,7 @@ func registerAllModules(manager *core.Manager) error {
 	return nil
 }
 
-func runApplication(ctx context.Context, manager *core.Manager, lessonFile, commands string) error {
+func runApplication(ctx context.Context, manager *core.Manager, lessonFile, commands string, audit bool) error {
 	// Get the GUI module and show the main window
 	guiModule, exists := manager.GetDefaultModule("ui")
 	if exists {
@@ -1848,6 +2136,15 @@ func runApplication(ctx context.Context, manager *core.Manager, lessonFile, comm
 					executeCommands(manager, commands)
 				}()
 			}
+
+			// Audit the window once it is shown, then quit
+			if audit {
+				if guiMod, ok := guiModule.(interface{ RunAccessibilityAudit(io.Writer) }); ok {
+					guiMod.RunAccessibilityAudit(os.Stdout)
+				} else {
+					return fmt.Errorf("GUI module does not support the accessibility audit")
+				}
+			}
 		} else {
 			fmt.Println("Warning: GUI module does not support ShowMainWindow()")
 		}
@@ -1859,6 +2156,9 @@ func runApplication(ctx context.Context, manager *core.Manager, lessonFile, comm
 			// Qt event loop must run on main thread - call directly
 			exitCode := guiMod.RunEventLoop()
 			fmt.Printf("Qt event loop finished with exit code: %d\n", exitCode)
+			if audit && exitCode != 0 {
+				return fmt.Errorf("accessibility audit found problems")
+			}
 			return nil
 		} else {
 			fmt.Println("Warning: GUI module does not support RunEventLoop()")
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// mediaUsage describes the "recuerdo media" subcommands
+const mediaUsage = `Usage:
+  %[1]s media relink FILE [DIR...]   Find moved media files in DIR (default the lesson's folder)
+  %[1]s media collect FILE           Copy linked media into a media folder next to the lesson
+  %[1]s media embed FILE             Store the media files inside the lesson
+  %[1]s media link FILE              Store the media files next to the lesson, linked by path
+  %[1]s media shrink FILE PIXELS     Embed the pictures scaled down to at most PIXELS wide and high
+
+Media lessons link to their pictures and sound by a path relative to the
+lesson, or embed them in the .otmd archive. After moving a lesson folder
+without its media, relink finds the files again; collect keeps them
+together with the lesson from then on. Shrinking keeps a lesson small
+enough to email, with the original pictures kept in an originals folder
+next to it. The lesson is saved in place.
+`
+
+// runMediaCommand runs a media subcommand without starting the GUI and
+// returns the process exit code
+func runMediaCommand(args []string) int {
+	if len(args) < 2 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		fmt.Fprintf(os.Stderr, mediaUsage, os.Args[0])
+		return 2
+	}
+	operation, filePath := args[0], args[1]
+	if err := runMedia(operation, filePath, args[2:]); err != nil {
+		printCommandError("media "+operation, err)
+		return 1
+	}
+	return 0
+}
+
+// runMedia relinks, collects, embeds, links or shrinks the media of the
+// lesson at filePath and saves it
+func runMedia(operation, filePath string, dirs []string) error {
+	maxSize := 0
+	switch operation {
+	case "relink", "collect", "embed", "link":
+	case "shrink":
+		if len(dirs) != 1 {
+			return fmt.Errorf("shrink needs the largest width and height in pixels")
+		}
+		size, err := strconv.Atoi(dirs[0])
+		if err != nil || size <= 0 {
+			return fmt.Errorf("invalid size %q, expected a number of pixels", dirs[0])
+		}
+		maxSize = size
+	default:
+		return fmt.Errorf("unknown operation %q (use relink, collect, embed, link or shrink)", operation)
+	}
+	lessonData, err := lesson.NewFileLoader().LoadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	// The media keeps the storage it has unless told otherwise
+	saver := lesson.NewFileSaver()
+	saver.Options.MediaStorage = lessonData.MediaStorage()
+	switch operation {
+	case "relink":
+		if len(dirs) == 0 {
+			dirs = []string{filepath.Dir(filePath)}
+		}
+		fmt.Print(lessonData.RelinkMedia(filePath, dirs))
+	case "collect":
+		report, err := lessonData.CollectMedia(filePath)
+		if err != nil {
+			return err
+		}
+		fmt.Print(report)
+	case "embed":
+		saver.Options.MediaStorage = lesson.MediaEmbed
+	case "link":
+		saver.Options.MediaStorage = lesson.MediaLink
+	case "shrink":
+		saver.Options.MediaStorage = lesson.MediaEmbed
+		saver.Options.ImageCompression.MaxSize = maxSize
+		saver.Options.ImageCompression.KeepOriginals = true
+	}
+	if err := saver.SaveFile(lessonData, filePath); err != nil {
+		return err
+	}
+	fmt.Printf("Saved %s\n", filePath)
+	return nil
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// packUsage describes the "recuerdo pack" subcommands
+const packUsage = `Usage:
+  %[1]s pack keygen -name "School" -o school.key     Create a signing key
+  %[1]s pack create [-sign school.key] -o words.otpack a.ot b.csv ...
+                                                       Bundle lessons into a lesson pack
+  %[1]s pack verify [-keys trusted.json] words.otpack  Check who signed a lesson pack
+  %[1]s pack trust  -name "School" PUBLIC-KEY          Trust packs signed with a key
+
+Schools sign the lesson packs they hand out; students trust the public key
+printed by keygen once, after which verify tells whether a pack is official
+and unchanged. Keep the signing key itself private.
+`
+
+// runPackCommand runs a lesson pack subcommand without starting the GUI and
+// returns the process exit code
+func runPackCommand(args []string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		fmt.Fprintf(os.Stderr, packUsage, os.Args[0])
+		return 2
+	}
+
+	var err error
+	switch args[0] {
+	case "keygen":
+		err = runPackKeygen(args[1:])
+	case "create":
+		err = runPackCreate(args[1:])
+	case "verify":
+		err = runPackVerify(args[1:])
+	case "trust":
+		err = runPackTrust(args[1:])
+	default:
+		err = fmt.Errorf("unknown operation %q (use keygen, create, verify or trust)", args[0])
+	}
+	if err != nil {
+		printCommandError("pack "+args[0], err)
+		return 1
+	}
+	return 0
+}
+
+// runPackKeygen creates a signing key and prints its public key
+func runPackKeygen(args []string) error {
+	flags := flag.NewFlagSet("pack keygen", flag.ContinueOnError)
+	name := flags.String("name", "", "name lesson packs are signed under")
+	output := flags.String("o", "", "file to write the signing key to")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" || *output == "" {
+		return fmt.Errorf("both -name and -o are needed")
+	}
+	if _, err := os.Stat(*output); err == nil {
+		return fmt.Errorf("%s already exists", *output)
+	}
+
+	key, err := lesson.GenerateSigningKey(*name)
+	if err != nil {
+		return err
+	}
+	if err := key.Save(*output); err != nil {
+		return err
+	}
+	public := key.Public()
+	fmt.Printf("Wrote the signing key of %s to %s\n", *name, *output)
+	fmt.Printf("Public key:  %s\n", public)
+	fmt.Printf("Fingerprint: %s\n", public.Fingerprint())
+	return nil
+}
+
+// runPackCreate bundles lessons and the presets they use into a lesson
+// pack, signed when a key is given
+func runPackCreate(args []string) error {
+	flags := flag.NewFlagSet("pack create", flag.ContinueOnError)
+	output := flags.String("o", "", "lesson pack to write (.otpack)")
+	keyFile := flags.String("sign", "", "signing key to sign the pack with")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *output == "" {
+		return fmt.Errorf("no output file given")
+	}
+	inputs := flags.Args()
+	if len(inputs) == 0 {
+		return fmt.Errorf("no input files given")
+	}
+
+	loader := lesson.NewFileLoader()
+	lessons := make([]*lesson.LessonData, 0, len(inputs))
+	for _, input := range inputs {
+		lessonData, err := loader.LoadFile(input)
+		if err != nil {
+			return fmt.Errorf("loading %s: %w", input, err)
+		}
+		lessons = append(lessons, lessonData)
+	}
+
+	store := lesson.NewPresetStore(lesson.DefaultPresetsPath())
+	if err := store.Load(); err != nil {
+		return err
+	}
+	saver := lesson.NewFileSaver()
+	if *keyFile != "" {
+		key, err := lesson.LoadSigningKey(*keyFile)
+		if err != nil {
+			return err
+		}
+		saver.Options.PackSigningKey = key
+	}
+	if err := saver.SaveLessonPack(lessons, store, *output); err != nil {
+		return err
+	}
+
+	if saver.Options.PackSigningKey != nil {
+		fmt.Printf("Wrote %d lessons to %s, signed by %s\n", len(lessons), *output, saver.Options.PackSigningKey.Name)
+	} else {
+		fmt.Printf("Wrote %d lessons to %s\n", len(lessons), *output)
+	}
+	return nil
+}
+
+// runPackVerify tells who signed a lesson pack. It fails for packs that
+// were changed after signing and for packs not signed by a trusted key.
+func runPackVerify(args []string) error {
+	flags := flag.NewFlagSet("pack verify", flag.ContinueOnError)
+	keysFile := flags.String("keys", lesson.DefaultTrustedKeysPath(), "trusted keys file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected one lesson pack, got %d", flags.NArg())
+	}
+
+	trusted, err := lesson.LoadTrustedKeys(*keysFile)
+	if err != nil {
+		return err
+	}
+	verification, err := lesson.NewFileLoader().VerifyLessonPack(flags.Arg(0), trusted)
+	if errors.Is(err, lesson.ErrPackTampered) {
+		return fmt.Errorf("%s: %w", flags.Arg(0), err)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !verification.Signed:
+		return fmt.Errorf("%s is not signed", flags.Arg(0))
+	case !verification.Trusted:
+		return fmt.Errorf("%s is signed by %q (key %s), which is not a trusted key",
+			flags.Arg(0), verification.Signer, verification.Fingerprint)
+	}
+	fmt.Printf("%s is signed by %s (key %s) and unchanged\n", flags.Arg(0), verification.TrustedAs, verification.Fingerprint)
+	return nil
+}
+
+// runPackTrust adds a public key to the trusted keys
+func runPackTrust(args []string) error {
+	flags := flag.NewFlagSet("pack trust", flag.ContinueOnError)
+	name := flags.String("name", "", "name to trust the key under")
+	keysFile := flags.String("keys", lesson.DefaultTrustedKeysPath(), "trusted keys file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" || flags.NArg() != 1 {
+		return fmt.Errorf("expected -name and one public key")
+	}
+
+	key, err := lesson.ParseTrustedKey(*name, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	trusted, err := lesson.LoadTrustedKeys(*keysFile)
+	if err != nil {
+		return err
+	}
+	for _, existing := range trusted {
+		if existing.PublicKey.Equal(key.PublicKey) {
+			return fmt.Errorf("the key is already trusted as %s", existing.Name)
+		}
+	}
+	if err := lesson.SaveTrustedKeys(*keysFile, append(trusted, key)); err != nil {
+		return err
+	}
+	fmt.Printf("Trusting lesson packs signed by %s (key %s)\n", *name, key.Fingerprint())
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/LaPingvino/recuerdo/internal/modules/profileRunners/packagers/docker"
+)
+
+// packageUsage describes "recuerdo package"
+const packageUsage = `Usage:
+  %[1]s package docker [-o DIR] [-port 8080] [-base debian:bookworm-slim]
+
+Writes a Dockerfile and .dockerignore for an image running "%[1]s serve".
+Run it from the root of the source tree, then build with "docker build .".
+
+Options:
+`
+
+// runPackageCommand runs a packaging profile and returns the process exit code
+func runPackageCommand(args []string) int {
+	if len(args) == 0 || args[0] != "docker" {
+		fmt.Fprintf(os.Stderr, packageUsage, os.Args[0])
+		return 2
+	}
+
+	defaults := docker.DefaultDockerfileOptions()
+	flags := flag.NewFlagSet("package docker", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), packageUsage, os.Args[0])
+		flags.PrintDefaults()
+	}
+	output := flags.String("o", ".", "directory to write the Dockerfile to")
+	port := flags.Int("port", defaults.Port, "port the REST API listens on in the container")
+	baseImage := flags.String("base", defaults.BaseImage, "runtime base image (Debian based)")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	options := defaults
+	options.Port = *port
+	options.BaseImage = *baseImage
+
+	packager := docker.NewDockerPackagerModule()
+	packager.SetOptions(options)
+	if err := packager.WritePackage(*output); err != nil {
+		fmt.Fprintf(os.Stderr, "recuerdo package docker: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Wrote Dockerfile and .dockerignore to %s\n", *output)
+	return 0
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// paperTestUsage describes the "recuerdo papertest" subcommands
+const paperTestUsage = `Usage:
+  %[1]s papertest print  [-o test.html] lesson.ot              Write a printable test with answer sheets
+  %[1]s papertest import [-o out.ot] lesson.ot scan.png ...    Import the results of graded answer sheets
+
+Grade a printed test by ticking the right or wrong box of every item on the
+answer sheet, then scan the sheets as PNG or JPEG. Import adds the marks as a
+new test to the lesson; without -o the lesson file is updated in place.
+`
+
+// runPaperTestCommand runs a paper test subcommand and returns the process
+// exit code
+func runPaperTestCommand(args []string) int {
+	if len(args) == 0 || (args[0] != "print" && args[0] != "import") {
+		fmt.Fprintf(os.Stderr, paperTestUsage, os.Args[0])
+		return 2
+	}
+
+	if err := runPaperTest(args[0], args[1:]); err != nil {
+		printCommandError("papertest "+args[0], err)
+		return 1
+	}
+	return 0
+}
+
+// runPaperTest parses the flags of one subcommand and performs it
+func runPaperTest(operation string, args []string) error {
+	flags := flag.NewFlagSet("papertest "+operation, flag.ContinueOnError)
+	output := flags.String("o", "", "output file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	inputs := flags.Args()
+	if len(inputs) == 0 {
+		return fmt.Errorf("no lesson file given")
+	}
+	lessonData, err := lesson.NewFileLoader().LoadFile(inputs[0])
+	if err != nil {
+		return fmt.Errorf("loading %s: %w", inputs[0], err)
+	}
+
+	if operation == "print" {
+		target := *output
+		if target == "" {
+			target = strings.TrimSuffix(inputs[0], filepath.Ext(inputs[0])) + "-test.html"
+		}
+		if err := lesson.NewFileSaver().SavePaperTest(lessonData, target); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote paper test with %d questions to %s\n", len(lessonData.List.Items), target)
+		return nil
+	}
+
+	if len(inputs) < 2 {
+		return fmt.Errorf("no scanned answer sheets given")
+	}
+	scans := make([]*lesson.PaperTestScan, 0, len(inputs)-1)
+	for _, scanFile := range inputs[1:] {
+		scan, err := lesson.NewFileLoader().LoadPaperTestScan(scanFile)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", scanFile, err)
+		}
+		scans = append(scans, scan)
+	}
+
+	test, unmarked, err := lesson.ImportPaperTestScans(lessonData, scans...)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d results\n", len(test.Results))
+	for _, index := range unmarked {
+		fmt.Printf("No clear mark for item %d (%s)\n", index+1, strings.Join(lessonData.List.Items[index].Questions, "; "))
+	}
+
+	target := *output
+	if target == "" {
+		target = inputs[0]
+	}
+	return saveEdited(lessonData, target)
+}
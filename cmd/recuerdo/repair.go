@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// repairUsage describes "recuerdo repair"
+const repairUsage = `Usage:
+  %[1]s repair [-o OUTPUT] FILE
+
+Salvages what can be read from a damaged .otwd, .ottp, .otmd, .otio or
+.json lesson, such as one cut short on a USB stick, and writes it to
+OUTPUT (default FILE-recovered with the same extension) with a report of
+what was lost next to it. The damaged file is left alone.
+
+Options:
+`
+
+// runRepairCommand recovers a damaged lesson and returns the process exit
+// code
+func runRepairCommand(args []string) int {
+	flags := flag.NewFlagSet("repair", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), repairUsage, os.Args[0])
+		flags.PrintDefaults()
+	}
+	output := flags.String("o", "", "file to write the recovered lesson to")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	input := flags.Arg(0)
+	if !lesson.CanRecover(input) {
+		fmt.Fprintf(os.Stderr, "recuerdo repair: cannot repair %s (use an .otwd, .ottp, .otmd, .otio or .json lesson)\n", input)
+		return 2
+	}
+	if *output == "" {
+		*output = lesson.RecoveredPath(input)
+	}
+
+	report, err := lesson.NewFileLoader().RecoverFile(input, *output)
+	if err != nil {
+		printCommandError("repair", err)
+		return 1
+	}
+	fmt.Print(report)
+	fmt.Printf("Wrote %s and %s\n", *output, lesson.ReportPath(*output))
+	return 0
+}
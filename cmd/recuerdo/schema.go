@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// schemaUsage describes the "recuerdo schema" subcommands
+const schemaUsage = `Usage:
+  %[1]s schema print                        Print the JSON Schema of .json lessons
+  %[1]s schema validate FILE ...            Check .json lessons against the schema
+  %[1]s schema migrate [-o OUTPUT] FILE     Rewrite a version 1 .json lesson as version 2
+
+Recuerdo saves .json lessons in version 2 of its canonical format, described
+by a JSON Schema, so other programs can generate lessons it reads. Lessons
+saved by older versions, without "version" in them, still load; migrate
+rewrites them in place, keeping the old file as a .bak copy, or to OUTPUT.
+`
+
+// runSchemaCommand runs a lesson schema subcommand without starting the GUI
+// and returns the process exit code
+func runSchemaCommand(args []string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		fmt.Fprintf(os.Stderr, schemaUsage, os.Args[0])
+		return 2
+	}
+
+	var err error
+	switch args[0] {
+	case "print":
+		_, err = os.Stdout.Write(lesson.LessonSchema())
+	case "validate":
+		err = runSchemaValidate(args[1:])
+	case "migrate":
+		err = runSchemaMigrate(args[1:])
+	default:
+		err = fmt.Errorf("unknown operation %q (use print, validate or migrate)", args[0])
+	}
+	if err != nil {
+		printCommandError("schema "+args[0], err)
+		return 1
+	}
+	return 0
+}
+
+// runSchemaValidate checks lessons against the schema, printing the
+// problems of each
+func runSchemaValidate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no lessons to validate")
+	}
+
+	invalid := 0
+	for _, file := range args {
+		data, err := os.ReadFile(file)
+		if err == nil {
+			err = lesson.ValidateLessonJSON(data)
+		}
+		var schemaErr *lesson.ErrSchema
+		if errors.As(err, &schemaErr) {
+			invalid++
+			fmt.Printf("%s: %d problems\n", file, len(schemaErr.Problems))
+			for _, problem := range schemaErr.Problems {
+				fmt.Printf("  %s\n", problem)
+			}
+			continue
+		}
+		if err != nil {
+			invalid++
+			fmt.Printf("%s: %v\n", file, err)
+			continue
+		}
+		fmt.Printf("%s: valid\n", file)
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d lessons are not valid", invalid, len(args))
+	}
+	return nil
+}
+
+// runSchemaMigrate rewrites a lesson as version 2
+func runSchemaMigrate(args []string) error {
+	flags := flag.NewFlagSet("schema migrate", flag.ContinueOnError)
+	output := flags.String("o", "", "file to write the migrated lesson to (default FILE)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected one lesson to migrate")
+	}
+	input := flags.Arg(0)
+	if *output == "" {
+		*output = input
+	}
+
+	if !strings.EqualFold(filepath.Ext(*output), ".json") {
+		return fmt.Errorf("%s is not a .json file", *output)
+	}
+
+	data, err := os.ReadFile(input)
+	if err != nil {
+		return err
+	}
+	lessonData, err := lesson.DecodeLessonJSON(data)
+	if err != nil {
+		return err
+	}
+	// Saving as .json always writes the current version, keeping the old
+	// file as a .bak copy
+	if err := lesson.NewFileSaver().SaveFile(lessonData, *output); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s as lesson format version %d\n", *output, lesson.LessonFormatVersion)
+	return nil
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	featureflags "github.com/LaPingvino/recuerdo/internal/modules/logic/featureFlags"
+	restapi "github.com/LaPingvino/recuerdo/internal/modules/logic/restApi"
+	"github.com/LaPingvino/recuerdo/internal/paths"
+)
+
+// serveUsage describes "recuerdo serve"
+const serveUsage = `Usage:
+  %[1]s serve [-addr 127.0.0.1:8080] [-lessons DIR] [-tokens TOKEN,...]
+        [-admin-tokens TOKEN,...] [-teacher-tokens TOKEN,...]
+        [-account-tokens ACCOUNT=TOKEN,...] [-name NAME] [-rate-limit N]
+        [-tls-cert FILE -tls-key FILE | -acme-domains DOMAIN,...]
+        [-cors-origins ORIGIN,...]
+
+Runs only the server-side modules, without a GUI, logging to stdout until it
+receives SIGINT or SIGTERM. The REST API module serves the lessons, the
+sync routes devices push and pull through, and the classroom with its
+live test WebSocket; there is no separate sync or classroom server. It
+only listens on localhost unless -addr says otherwise, such as -addr :8080
+for every interface, which should come with -tokens. Flags default to the
+RECUERDO_ADDR, RECUERDO_LESSONS, RECUERDO_API_TOKENS and
+RECUERDO_ADMIN_TOKENS environment variables when those are set; lessons are
+otherwise served from the lessons directory below RECUERDO_DATA_DIR or
+$XDG_DATA_HOME/recuerdo. The classroom routes (roster, join codes, item
+discussions and live tests) are experimental and only served with
+RECUERDO_FEATURES=classroomServer; the classroom is then announced on the
+local network over mDNS under -name (RECUERDO_CLASSROOM_NAME, or the host
+name), so students find it from Take Live Test. An empty -name does not
+announce it. With tokens, every request but /healthz needs an
+"Authorization: Bearer TOKEN" header carrying one of them. Roster changes,
+results, live tests and other administrative actions are recorded in an
+audit log, which only admin tokens may read (/api/audit); admin tokens are
+access tokens too. Students share the access tokens, so the roster and
+gradebook need one of -teacher-tokens (RECUERDO_TEACHER_TOKENS) or an
+admin token, and a student hands in results with their join code. Synced
+accounts are only reached with one of
+-account-tokens (RECUERDO_ACCOUNT_TOKENS) bound to them, or an admin
+token. An address sending ten wrong tokens is locked out for a
+quarter of an hour, and one sending more than -rate-limit requests a
+minute is slowed down.
+
+To expose the server beyond the local network, serve HTTPS with
+-tls-cert and -tls-key, or with certificates from Let's Encrypt for
+-acme-domains, which needs the server reachable on port 443 of them; and
+name the origins of the web pages that may call it from a browser with
+-cors-origins. The TLS, ACME, rate limit and CORS flags default to
+RECUERDO_TLS_CERT, RECUERDO_TLS_KEY, RECUERDO_ACME_DOMAINS,
+RECUERDO_ACME_EMAIL, RECUERDO_RATE_LIMIT and RECUERDO_CORS_ORIGINS.
+
+Options:
+`
+
+// runServeCommand starts the server-side modules headless and returns the
+// process exit code once they have been shut down
+func runServeCommand(args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), serveUsage, os.Args[0])
+		flags.PrintDefaults()
+	}
+	addr := flags.String("addr", envOrDefault("RECUERDO_ADDR", restapi.DefaultAddr), "address the REST API listens on")
+	lessonDir := flags.String("lessons", paths.LessonDir(), "directory with the lessons to serve")
+	tokens := flags.String("tokens", envOrDefault("RECUERDO_API_TOKENS", ""), "comma separated tokens clients must send; none leaves the API open")
+	adminTokens := flags.String("admin-tokens", envOrDefault("RECUERDO_ADMIN_TOKENS", ""), "comma separated tokens of the admins, who may also read the audit log")
+	teacherTokens := flags.String("teacher-tokens", envOrDefault("RECUERDO_TEACHER_TOKENS", ""), "comma separated tokens of the teachers, who may also read and change the class roster")
+	accountTokens := flags.String("account-tokens", envOrDefault("RECUERDO_ACCOUNT_TOKENS", ""), "comma separated ACCOUNT=TOKEN pairs binding tokens to the one account they reach")
+	hostname, _ := os.Hostname()
+	name := flags.String("name", envOrDefault("RECUERDO_CLASSROOM_NAME", hostname), "name the classroom is announced under on the local network; empty not to announce it")
+	rateLimit := flags.Int("rate-limit", envIntOrDefault("RECUERDO_RATE_LIMIT", defaultRateLimit), "requests a minute one address may send; 0 for any number")
+	certFile := flags.String("tls-cert", envOrDefault("RECUERDO_TLS_CERT", ""), "PEM file with the TLS certificate to serve HTTPS with")
+	keyFile := flags.String("tls-key", envOrDefault("RECUERDO_TLS_KEY", ""), "PEM file with the key of the TLS certificate")
+	acmeDomains := flags.String("acme-domains", envOrDefault("RECUERDO_ACME_DOMAINS", ""), "comma separated domains to serve HTTPS for with certificates from Let's Encrypt")
+	acmeEmail := flags.String("acme-email", envOrDefault("RECUERDO_ACME_EMAIL", ""), "email address Let's Encrypt sends notices about the certificates to")
+	acmeCache := flags.String("acme-cache", filepath.Join(paths.DataDir(), "acme"), "directory the certificates from Let's Encrypt are kept in")
+	corsOrigins := flags.String("cors-origins", envOrDefault("RECUERDO_CORS_ORIGINS", ""), "comma separated origins of web pages that may call the API, or * for any")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	// Service managers collect stdout, so log there without file names
+	log.SetOutput(os.Stdout)
+	log.SetFlags(log.LstdFlags)
+
+	if err := os.MkdirAll(*lessonDir, 0755); err != nil {
+		log.Printf("[ERROR] Failed to create lesson directory: %v", err)
+		return 1
+	}
+
+	manager := core.NewManager()
+	options := serverOptions{
+		addr:          *addr,
+		lessonDir:     *lessonDir,
+		tokens:        strings.Split(*tokens, ","),
+		adminTokens:   strings.Split(*adminTokens, ","),
+		teacherTokens: strings.Split(*teacherTokens, ","),
+		accountTokens: strings.Split(*accountTokens, ","),
+		name:          *name,
+		rateLimit:     *rateLimit,
+		certFile:      *certFile,
+		keyFile:       *keyFile,
+		acmeDomains:   strings.Split(*acmeDomains, ","),
+		acmeEmail:     *acmeEmail,
+		acmeCache:     *acmeCache,
+		corsOrigins:   strings.Split(*corsOrigins, ","),
+	}
+	if err := registerServerModules(manager, options); err != nil {
+		log.Printf("[ERROR] Failed to register server modules: %v", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := manager.EnableAll(ctx); err != nil {
+		log.Printf("[ERROR] Failed to enable server modules: %v", err)
+		return 1
+	}
+	log.Printf("[INFO] %s %s serving with %d modules", appName, appVersion, manager.ModuleCount())
+
+	<-ctx.Done()
+	log.Printf("[INFO] Shutting down")
+
+	if err := manager.DisableAll(context.Background()); err != nil {
+		log.Printf("[ERROR] Error during shutdown: %v", err)
+		return 1
+	}
+	return 0
+}
+
+// defaultRateLimit is how many requests a minute one address may send
+// unless -rate-limit says otherwise
+const defaultRateLimit = 600
+
+// serverOptions are the flags of "recuerdo serve"
+type serverOptions struct {
+	addr, lessonDir     string
+	tokens, adminTokens []string
+	teacherTokens       []string
+	accountTokens       []string
+	name                string
+	rateLimit           int
+	certFile, keyFile   string
+	acmeDomains         []string
+	acmeEmail           string
+	acmeCache           string
+	corsOrigins         []string
+}
+
+// registerServerModules registers the modules that make sense without a
+// GUI. Nothing registered here may depend on Qt.
+func registerServerModules(manager *core.Manager, options serverOptions) error {
+	// Register feature flags module; without settings only RECUERDO_FEATURES
+	// turns flags on
+	featureFlagsModule := featureflags.NewFeatureFlagsModule()
+	if err := manager.Register(featureFlagsModule); err != nil {
+		return fmt.Errorf("failed to register feature flags module: %w", err)
+	}
+
+	// Register REST API module
+	restAPIModule := restapi.NewRestAPIModule()
+	restAPIModule.SetAddr(options.addr)
+	restAPIModule.SetLessonDir(options.lessonDir)
+	restAPIModule.SetTokens(options.tokens)
+	restAPIModule.SetAdminTokens(options.adminTokens)
+	restAPIModule.SetTeacherTokens(options.teacherTokens)
+	restAPIModule.SetAccountTokens(options.accountTokens)
+	restAPIModule.SetAdvertisedName(options.name)
+	restAPIModule.SetRateLimit(options.rateLimit)
+	restAPIModule.SetTLSFiles(options.certFile, options.keyFile)
+	restAPIModule.SetACME(options.acmeDomains, options.acmeCache, options.acmeEmail)
+	restAPIModule.SetCORSOrigins(options.corsOrigins)
+	restAPIModule.SetClassroomEnabled(featureFlagsModule.Enabled(featureflags.ClassroomServer))
+	if !featureFlagsModule.Enabled(featureflags.ClassroomServer) {
+		log.Printf("[INFO] Classroom routes off; set %s=%s to serve them", featureflags.EnvVar, featureflags.ClassroomServer)
+	}
+	if err := manager.Register(restAPIModule); err != nil {
+		return fmt.Errorf("failed to register REST API module: %w", err)
+	}
+
+	return nil
+}
+
+// runHealthcheckCommand asks a running "recuerdo serve" whether it is
+// healthy, for container health checks, and returns the process exit code
+func runHealthcheckCommand(args []string) int {
+	flags := flag.NewFlagSet("healthcheck", flag.ContinueOnError)
+	addr := flags.String("addr", envOrDefault("RECUERDO_ADDR", restapi.DefaultAddr), "address the REST API listens on")
+	acmeDomains := envOrDefault("RECUERDO_ACME_DOMAINS", "")
+	secure := flags.Bool("tls", envOrDefault("RECUERDO_TLS_CERT", "") != "" || acmeDomains != "", "ask over HTTPS")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	host, port, err := net.SplitHostPort(*addr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid address %q: %v\n", *addr, err)
+		return 2
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+
+	client := http.Client{Timeout: 3 * time.Second}
+	scheme := "http://"
+	if *secure {
+		scheme = "https://"
+		// The certificate is for the server's domain, not for localhost; an
+		// ACME server only answers for its domains
+		serverName, _, _ := strings.Cut(acmeDomains, ",")
+		client.Transport = &http.Transport{TLSClientConfig: &tls.Config{ServerName: strings.TrimSpace(serverName), InsecureSkipVerify: true}}
+	}
+	resp, err := client.Get(scheme + net.JoinHostPort(host, port) + "/healthz")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unhealthy: %v\n", err)
+		return 1
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintf(os.Stderr, "unhealthy: %s\n", resp.Status)
+		return 1
+	}
+	return 0
+}
+
+// envOrDefault returns the environment variable key, or fallback when unset
+func envOrDefault(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+// envIntOrDefault returns the environment variable key as a number, or
+// fallback when unset or not a number
+func envIntOrDefault(key string, fallback int) int {
+	if value, err := strconv.Atoi(envOrDefault(key, "")); err == nil {
+		return value
+	}
+	return fallback
+}
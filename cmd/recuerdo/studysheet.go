@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// studySheetUsage describes "recuerdo studysheet"
+const studySheetUsage = `Usage:
+  %[1]s studysheet [-o OUTPUT] FILE
+
+Writes a fold-over study sheet of a lesson: the questions in the left half of
+the page and the answers on the same lines in the right half. Folding the
+printed sheet along the dashed line in the middle hides every answer behind
+its question for self-testing. OUTPUT ends in .pdf or .odt (default FILE with
+a -sheet.pdf ending).
+
+Options:
+`
+
+// runStudySheetCommand writes a study sheet of a lesson and returns the
+// process exit code
+func runStudySheetCommand(args []string) int {
+	flags := flag.NewFlagSet("studysheet", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), studySheetUsage, os.Args[0])
+		flags.PrintDefaults()
+	}
+	output := flags.String("o", "", "file to write the study sheet to, .pdf or .odt")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	input := flags.Arg(0)
+	if *output == "" {
+		*output = strings.TrimSuffix(input, filepath.Ext(input)) + "-sheet.pdf"
+	}
+	lessonData, err := lesson.NewFileLoader().LoadFile(input)
+	if err != nil {
+		printCommandError("studysheet", err)
+		return 1
+	}
+	if err := lesson.NewFileSaver().SaveStudySheet(lessonData, *output); err != nil {
+		printCommandError("studysheet", err)
+		return 1
+	}
+	fmt.Printf("Wrote study sheet with %d items to %s\n", len(lessonData.List.Items), *output)
+	return 0
+}
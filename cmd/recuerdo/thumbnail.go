@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/LaPingvino/recuerdo/internal/thumbnail"
+)
+
+// thumbnailUsage describes "recuerdo thumbnail"
+const thumbnailUsage = `Usage:
+  %[1]s thumbnail [-o OUTPUT] [-width N] [-height N] FILE
+
+Draws a PNG picture of a lesson without starting the GUI: the first items
+for word and media lessons, the base map with the places marked for topo
+lessons and the picture with its masks for image occlusion lessons. It is
+written to OUTPUT (default FILE with a .png extension).
+
+Options:
+`
+
+// runThumbnailCommand draws a thumbnail of a lesson and returns the process
+// exit code
+func runThumbnailCommand(args []string) int {
+	flags := flag.NewFlagSet("thumbnail", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), thumbnailUsage, os.Args[0])
+		flags.PrintDefaults()
+	}
+	output := flags.String("o", "", "file to write the thumbnail to")
+	width := flags.Int("width", thumbnail.DefaultWidth, "width of the thumbnail in pixels")
+	height := flags.Int("height", thumbnail.DefaultHeight, "height of the thumbnail in pixels")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 || *width <= 0 || *height <= 0 {
+		flags.Usage()
+		return 2
+	}
+
+	input := flags.Arg(0)
+	if *output == "" {
+		*output = strings.TrimSuffix(input, filepath.Ext(input)) + ".png"
+	}
+	lessonData, err := lesson.NewFileLoader().LoadFile(input)
+	if err != nil {
+		printCommandError("thumbnail", err)
+		return 1
+	}
+	renderer := &thumbnail.Renderer{Width: *width, Height: *height, Maps: thumbnail.LocalMaps()}
+	if err := renderer.WriteFile(*output, lessonData); err != nil {
+		printCommandError("thumbnail", err)
+		return 1
+	}
+	fmt.Printf("Wrote %s\n", *output)
+	return 0
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	translationupdater "github.com/LaPingvino/recuerdo/internal/modules/profileRunners/translationUpdater"
+)
+
+// updateTranslationsUsage describes "recuerdo update-translations"
+const updateTranslationsUsage = `Usage:
+  %[1]s update-translations [-o DIR] [-legacy DIR] [-init nl,pt_BR]
+
+Extracts the strings shown to the user from the Go sources into DIR/recuerdo.pot
+and merges it into every DIR/*.po catalog, reusing the translations of the
+Python version where a catalog has none. Run it from the root of the source
+tree. Prints how much of every language is translated.
+
+Options:
+`
+
+// runUpdateTranslationsCommand runs the update-translations profile and
+// returns the process exit code
+func runUpdateTranslationsCommand(args []string) int {
+	defaults := translationupdater.DefaultOptions()
+	flags := flag.NewFlagSet("update-translations", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), updateTranslationsUsage, os.Args[0])
+		flags.PrintDefaults()
+	}
+	source := flags.String("src", defaults.SourceDir, "root of the Go sources")
+	output := flags.String("o", defaults.TranslationsDir, "directory of the template and .po catalogs")
+	legacy := flags.String("legacy", defaults.LegacyDir, "Python version to reuse translations from; empty to not reuse them")
+	languages := flags.String("init", "", "comma-separated languages to start a catalog for")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	options := defaults
+	options.SourceDir = *source
+	options.TranslationsDir = *output
+	options.LegacyDir = *legacy
+	options.PackageVersion = appVersion
+	for _, language := range strings.Split(*languages, ",") {
+		if language = strings.TrimSpace(language); language != "" {
+			options.Languages = append(options.Languages, language)
+		}
+	}
+
+	updater := translationupdater.NewTranslationupdaterModule()
+	updater.SetOptions(options)
+	stats, err := updater.Update()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "recuerdo update-translations: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Wrote %s/%s\n", options.TranslationsDir, translationupdater.TemplateName)
+	translationupdater.WriteReport(os.Stdout, stats)
+	return 0
+}
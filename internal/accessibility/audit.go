@@ -0,0 +1,207 @@
+// Package accessibility checks that screen readers and keyboard users can
+// use every control of a window.
+//
+// The widget tree is described with Node values, so the checks do not
+// depend on Qt: the accessibility module under interfaces/qt builds the
+// tree from the widgets and labels them before they are audited.
+package accessibility
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Role is what a control does, as far as the audit is concerned
+type Role string
+
+// Roles of the widgets in a tree
+const (
+	RoleButton Role = "button"
+	RoleCheck  Role = "check"
+	RoleText   Role = "text"
+	RoleCombo  Role = "combo"
+	RoleSlider Role = "slider"
+	RoleList   Role = "list"
+	RoleTabs   Role = "tabs"
+	RoleLabel  Role = "label"
+	RoleGroup  Role = "group"
+	// RoleOther is any widget that is neither a control nor names one,
+	// such as containers and progress bars
+	RoleOther Role = ""
+)
+
+// Interactive reports whether widgets of the role can be operated, and so
+// need a name and keyboard focus
+func (r Role) Interactive() bool {
+	switch r {
+	case RoleButton, RoleCheck, RoleText, RoleCombo, RoleSlider, RoleList, RoleTabs:
+		return true
+	}
+	return false
+}
+
+// Node is a widget in the tree being audited
+type Node struct {
+	// Class is the Qt class, such as QPushButton
+	Class string
+	// ObjectName identifies the widget in reports when set
+	ObjectName string
+	Role       Role
+	// Name and Description are what a screen reader announces
+	Name        string
+	Description string
+	// Visible is false for widgets hidden themselves; pages of tab
+	// widgets that are not shown count as visible
+	Visible bool
+	Enabled bool
+	// TabFocus reports whether the focus policy accepts the Tab key
+	TabFocus bool
+	// FocusOrder is the position in the Tab order, or -1 when the widget
+	// is not in the focus chain
+	FocusOrder int
+	Children   []*Node
+}
+
+// Problem is something keeping a control from being used
+type Problem string
+
+// Problems the audit reports
+const (
+	// Unlabeled controls have nothing for a screen reader to announce
+	Unlabeled Problem = "unlabeled"
+	// Unreachable controls cannot be reached with the Tab key
+	Unreachable Problem = "unreachable"
+)
+
+// Finding is a problem with a control
+type Finding struct {
+	// Path locates the control in the tree, such as
+	// QMainWindow/QTabWidget/QLineEdit[2]
+	Path    string
+	Class   string
+	Problem Problem
+}
+
+// String formats the finding for reports
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s", f.Problem, f.Path)
+}
+
+// Audit returns the problems of the visible controls below root, in tree
+// order. Disabled controls only need a name: they become reachable when
+// they are enabled.
+func Audit(root *Node) []Finding {
+	var findings []Finding
+	walk(root, func(node *Node, path string) {
+		if !node.Role.Interactive() {
+			return
+		}
+		if strings.TrimSpace(node.Name) == "" {
+			findings = append(findings, Finding{Path: path, Class: node.Class, Problem: Unlabeled})
+		}
+		if node.Enabled && (!node.TabFocus || node.FocusOrder < 0) {
+			findings = append(findings, Finding{Path: path, Class: node.Class, Problem: Unreachable})
+		}
+	})
+	return findings
+}
+
+// FocusStop is a control in the Tab order
+type FocusStop struct {
+	Path string
+	Name string
+}
+
+// FocusOrder returns the visible, enabled controls the Tab key moves
+// through, in that order
+func FocusOrder(root *Node) []FocusStop {
+	type stop struct {
+		FocusStop
+		order int
+	}
+	var stops []stop
+	walk(root, func(node *Node, path string) {
+		if node.Role.Interactive() && node.Enabled && node.TabFocus && node.FocusOrder >= 0 {
+			stops = append(stops, stop{FocusStop{Path: path, Name: node.Name}, node.FocusOrder})
+		}
+	})
+	sort.SliceStable(stops, func(i, j int) bool { return stops[i].order < stops[j].order })
+
+	order := make([]FocusStop, len(stops))
+	for i, s := range stops {
+		order[i] = s.FocusStop
+	}
+	return order
+}
+
+// Report writes the problems found and the Tab order for people to read,
+// and returns the number of problems
+func Report(w io.Writer, root *Node) int {
+	findings := Audit(root)
+	for _, finding := range findings {
+		fmt.Fprintln(w, finding)
+	}
+	fmt.Fprintln(w, "Tab order:")
+	for i, stop := range FocusOrder(root) {
+		fmt.Fprintf(w, "%3d. %s (%q)\n", i+1, stop.Path, stop.Name)
+	}
+	fmt.Fprintf(w, "%d accessibility problems found\n", len(findings))
+	return len(findings)
+}
+
+// CleanLabel turns the text of a label or button into an accessible name,
+// dropping mnemonic ampersands and a trailing colon
+func CleanLabel(text string) string {
+	text = strings.ReplaceAll(text, "&&", "\x00")
+	text = strings.ReplaceAll(text, "&", "")
+	text = strings.ReplaceAll(text, "\x00", "&")
+	text = strings.TrimSpace(text)
+	return strings.TrimSpace(strings.TrimSuffix(text, ":"))
+}
+
+// walk calls visit for root and the visible widgets below it. Controls are
+// not descended into: their children, such as the scroll bars of a list,
+// are part of the control.
+func walk(root *Node, visit func(node *Node, path string)) {
+	if root != nil {
+		walkPath(root, segment(root, 0), visit)
+	}
+}
+
+// segment names a widget in a path; index numbers siblings of the same
+// class, and is 0 for a widget without those
+func segment(node *Node, index int) string {
+	switch {
+	case node.ObjectName != "":
+		return node.Class + "#" + node.ObjectName
+	case index > 0:
+		return fmt.Sprintf("%s[%d]", node.Class, index)
+	}
+	return node.Class
+}
+
+func walkPath(node *Node, path string, visit func(node *Node, path string)) {
+	if !node.Visible {
+		return
+	}
+	visit(node, path)
+	if node.Role.Interactive() {
+		return
+	}
+
+	count := map[string]int{}
+	for _, child := range node.Children {
+		count[child.Class]++
+	}
+	seen := map[string]int{}
+	for _, child := range node.Children {
+		index := 0
+		if count[child.Class] > 1 {
+			seen[child.Class]++
+			index = seen[child.Class]
+		}
+		walkPath(child, path+"/"+segment(child, index), visit)
+	}
+}
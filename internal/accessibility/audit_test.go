@@ -0,0 +1,81 @@
+package accessibility
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// control returns a visible, enabled control in the Tab order at order
+func control(class string, role Role, name string, order int) *Node {
+	return &Node{Class: class, Role: role, Name: name, Visible: true, Enabled: true, TabFocus: true, FocusOrder: order}
+}
+
+func testWindow() *Node {
+	answer := control("QLineEdit", RoleText, "Your Answer", 1)
+	start := control("QPushButton", RoleButton, "Start Teaching", 0)
+	unicode := control("QPushButton", RoleButton, "", 2)
+	disabled := control("QPushButton", RoleButton, "Next", -1)
+	disabled.Enabled = false
+	noTab := control("QTableWidget", RoleList, "Word Pairs", -1)
+	noTab.TabFocus = false
+	// The scroll bars of a control are part of it
+	noTab.Children = []*Node{control("QScrollBar", RoleSlider, "", -1)}
+	hidden := control("QLineEdit", RoleText, "", -1)
+	hidden.Visible = false
+
+	return &Node{Class: "QMainWindow", Visible: true, Children: []*Node{
+		{Class: "QWidget", ObjectName: "teach", Visible: true, Children: []*Node{
+			{Class: "QLabel", Role: RoleLabel, Name: "Your Answer:", Visible: true},
+			answer, start, unicode, disabled, noTab, hidden,
+		}},
+	}}
+}
+
+func TestAudit(t *testing.T) {
+	var got []string
+	for _, finding := range Audit(testWindow()) {
+		got = append(got, finding.String())
+	}
+	want := []string{
+		"unlabeled: QMainWindow/QWidget#teach/QPushButton[2]",
+		"unreachable: QMainWindow/QWidget#teach/QTableWidget",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Audit =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestFocusOrder(t *testing.T) {
+	var got []string
+	for _, stop := range FocusOrder(testWindow()) {
+		got = append(got, stop.Name)
+	}
+	if want := []string{"Start Teaching", "Your Answer", ""}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FocusOrder = %q, want %q", got, want)
+	}
+}
+
+func TestReport(t *testing.T) {
+	var out bytes.Buffer
+	if problems := Report(&out, testWindow()); problems != 2 {
+		t.Errorf("Report found %d problems", problems)
+	}
+	if !strings.Contains(out.String(), `  1. QMainWindow/QWidget#teach/QPushButton[1] ("Start Teaching")`) {
+		t.Errorf("report lacks the Tab order:\n%s", out.String())
+	}
+}
+
+func TestCleanLabel(t *testing.T) {
+	for text, want := range map[string]string{
+		"&Start Teaching": "Start Teaching",
+		"Your Answer:":    "Your Answer",
+		" Rock && Roll ":  "Rock & Roll",
+		"":                "",
+	} {
+		if got := CleanLabel(text); got != want {
+			t.Errorf("CleanLabel(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+// Package audit keeps an append-only log of administrative and grading
+// actions on the server, such as a live test being started or a result
+// being recorded, so disputes about exams can be settled afterwards.
+//
+// The log is a file of JSON lines. Every entry carries an HMAC over the
+// previous entry's hash and its own fields, keyed with a secret kept in a
+// state file away from the log, so an entry edited or removed afterwards
+// breaks the chain, which Entries reports as ErrTampered, and a log
+// written anew without the secret does not pass either. The state file
+// also keeps the hash of the last entry, so dropping entries from the end
+// is noticed too.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Actions recorded by the server
+const (
+	StudentAdded     = "student.add"
+	StudentsImported = "student.import"
+	StudentUpdated   = "student.update"
+	StudentRemoved   = "student.remove"
+	StudentExported  = "student.export"
+	JoinCodeRenewed  = "student.joincode"
+	ResultRecorded   = "result.record"
+	LessonAssigned   = "assignment.add"
+	LessonUnassigned = "assignment.remove"
+	LiveTestStarted  = "live.start"
+	LiveTestLocked   = "live.lock"
+	LessonUploaded   = "lesson.upload"
+	ReviewsRecorded  = "reviews.record"
+	VaultExported    = "vault.export"
+	VaultDeleted     = "vault.delete"
+	AccountExported  = "account.export"
+	AccountDeleted   = "account.delete"
+)
+
+// ErrTampered is returned for a log whose entries were changed, removed or
+// reordered after they were recorded
+var ErrTampered = errors.New("audit log was tampered with")
+
+// Entry is one recorded action
+type Entry struct {
+	Time time.Time `json:"time"`
+	// Actor tells who acted, such as the token used or a student
+	Actor string `json:"actor,omitempty"`
+	// Address is the network address the request came from
+	Address string `json:"address,omitempty"`
+	Action  string `json:"action"`
+	// Subject is what was acted on, such as a student or a lesson
+	Subject string `json:"subject,omitempty"`
+	Details string `json:"details,omitempty"`
+	// Hash chains the entry to the one before it
+	Hash string `json:"hash"`
+}
+
+// Report is the audit log as the server sends it to admins
+type Report struct {
+	Entries []Entry `json:"entries"`
+	// Intact is false when entries were changed or removed after they
+	// were recorded; Problem then tells where
+	Intact  bool   `json:"intact"`
+	Problem string `json:"problem,omitempty"`
+}
+
+// Log is an audit log kept in a file. All methods are safe for concurrent
+// use.
+type Log struct {
+	path      string
+	statePath string
+	state     logState
+	mu        sync.Mutex
+}
+
+// logState is what the state file of a log keeps
+type logState struct {
+	// Key is the secret the entries are chained with
+	Key []byte `json:"key"`
+	// Count and Last are the number of entries and the hash of the last,
+	// empty for an empty log
+	Count int    `json:"count"`
+	Last  string `json:"last"`
+}
+
+// Open opens the audit log at path, creating it on the first entry. The
+// secret and the head of the chain are kept in the file at statePath,
+// created when missing; keep it where those able to change the log cannot
+// reach. A last line torn by a crash while it was written is removed.
+func Open(path, statePath string) (*Log, error) {
+	l := &Log{path: path, statePath: statePath}
+	entries, err := l.read()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(statePath)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &l.state); err != nil || len(l.state.Key) == 0 {
+			return nil, fmt.Errorf("invalid audit log state %s", statePath)
+		}
+		// Entries recorded after the state was last written, as when
+		// the server stopped in between, are chained with the key and
+		// can be trusted
+		if len(entries) > l.state.Count && l.chained(entries) == nil {
+			l.state.Count, l.state.Last = len(entries), entries[len(entries)-1].Hash
+		}
+		return l, nil
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("failed to read audit log state: %w", err)
+	}
+
+	// A new log. Entries found without a state file were not chained with
+	// the key made here, whoever wrote them, so Entries reports them as
+	// tampered with; those recorded from now on follow them.
+	l.state.Key = make([]byte, 32)
+	if _, err := rand.Read(l.state.Key); err != nil {
+		return nil, err
+	}
+	l.state.Count = len(entries)
+	if len(entries) > 0 {
+		l.state.Last = entries[len(entries)-1].Hash
+	}
+	if err := l.saveState(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Record appends an entry to the log. An entry without a time is recorded
+// at the current time.
+func (l *Log) Record(entry Entry) error {
+	if entry.Action == "" {
+		return fmt.Errorf("audit entry has no action")
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	entry.Time = entry.Time.UTC()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry.Hash = l.hash(l.state.Count, l.state.Last, entry)
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	file, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	// An entry that is lost in a crash cannot settle a dispute
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	l.state.Count++
+	l.state.Last = entry.Hash
+	return l.saveState()
+}
+
+// Entries returns the entries of the log, oldest first. When the hash
+// chain is broken it still returns them all, with an error wrapping
+// ErrTampered that tells where.
+func (l *Log) Entries() ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries, err := l.read()
+	if err != nil {
+		return nil, err
+	}
+	if err := l.chained(entries); err != nil {
+		return entries, err
+	}
+	if len(entries) < l.state.Count || l.state.Count > 0 && entries[l.state.Count-1].Hash != l.state.Last {
+		return entries, fmt.Errorf("entries after the last of %d were removed: %w", len(entries), ErrTampered)
+	}
+	return entries, nil
+}
+
+// chained returns an error wrapping ErrTampered unless every entry is
+// chained to the one before it
+func (l *Log) chained(entries []Entry) error {
+	previous := ""
+	for i, entry := range entries {
+		if !hmac.Equal([]byte(entry.Hash), []byte(l.hash(i, previous, entry))) {
+			return fmt.Errorf("entry %d of %d: %w", i+1, len(entries), ErrTampered)
+		}
+		previous = entry.Hash
+	}
+	return nil
+}
+
+// read reads every entry in the file. A last line without its newline was
+// torn by a crash while it was written, before it counted as recorded, so
+// it is cut off.
+func (l *Log) read() ([]Entry, error) {
+	file, err := os.Open(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	var complete int64
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				file.Close()
+				if err := os.Truncate(l.path, complete); err != nil {
+					return nil, fmt.Errorf("failed to repair audit log: %w", err)
+				}
+			}
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read audit log: %w", err)
+		}
+		complete += int64(len(line))
+		if line = bytes.TrimSpace(line); len(line) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse audit log line %d: %w", len(entries)+1, err)
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// saveState writes the state file, replacing it in one go. The caller
+// holds mu, or is Open.
+func (l *Log) saveState() error {
+	data, err := json.Marshal(l.state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(l.statePath), 0700); err != nil {
+		return fmt.Errorf("failed to create audit log state directory: %w", err)
+	}
+	temp := l.statePath + ".tmp"
+	if err := os.WriteFile(temp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write audit log state: %w", err)
+	}
+	if err := os.Rename(temp, l.statePath); err != nil {
+		return fmt.Errorf("failed to write audit log state: %w", err)
+	}
+	return nil
+}
+
+// hash returns the hash of entry number index, counting from 0, following
+// the entry with hash previous
+func (l *Log) hash(index int, previous string, entry Entry) string {
+	entry.Hash = previous
+	data, _ := json.Marshal(entry)
+	mac := hmac.New(sha256.New, l.state.Key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,124 @@
+package audit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLog(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "classroom", "audit.log")
+	statePath := filepath.Join(dir, "state", "audit.json")
+	log, err := Open(path, statePath)
+	if err != nil {
+		t.Fatalf("Failed to open new log: %v", err)
+	}
+	start := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	log.Record(Entry{Time: start, Actor: "token 1a2b3c4d", Action: LiveTestStarted, Subject: "Animals"})
+	log.Record(Entry{Time: start.Add(time.Hour), Action: ResultRecorded, Subject: "Anna", Details: "9/10"})
+	if err := log.Record(Entry{}); err == nil {
+		t.Error("Expected an entry without action to be refused")
+	}
+
+	reopened, err := Open(path, statePath)
+	if err != nil {
+		t.Fatalf("Failed to reopen log: %v", err)
+	}
+	reopened.Record(Entry{Action: LiveTestLocked, Subject: "Animals"})
+	entries, err := reopened.Entries()
+	if err != nil {
+		t.Fatalf("Expected an intact log, got %v", err)
+	}
+	if len(entries) != 3 || entries[0].Actor != "token 1a2b3c4d" || entries[1].Details != "9/10" || entries[2].Time.IsZero() {
+		t.Errorf("Unexpected entries: %+v", entries)
+	}
+
+	// Changing a grade afterwards breaks the chain
+	data, _ := os.ReadFile(path)
+	os.WriteFile(path, []byte(strings.Replace(string(data), "9/10", "10/10", 1)), 0600)
+	if _, err := reopened.Entries(); !errors.Is(err, ErrTampered) || !strings.Contains(err.Error(), "entry 2 of 3") {
+		t.Errorf("Expected the changed entry to be found, got %v", err)
+	}
+
+	// So does dropping the last entry
+	lines := strings.SplitAfter(string(data), "\n")
+	os.WriteFile(path, []byte(strings.Join(lines[:2], "")), 0600)
+	if entries, err := reopened.Entries(); !errors.Is(err, ErrTampered) || len(entries) != 2 {
+		t.Errorf("Expected the removed entry to be noticed, got %d entries, %v", len(entries), err)
+	}
+	if reopened, err := Open(path, statePath); err != nil {
+		t.Fatal(err)
+	} else if _, err := reopened.Entries(); !errors.Is(err, ErrTampered) {
+		t.Errorf("Expected the removed entry to be noticed after reopening, got %v", err)
+	}
+
+	// A log written anew without the key does not pass either
+	forged, err := Open(filepath.Join(dir, "forged.log"), filepath.Join(dir, "forged.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged.Record(Entry{Time: start, Action: ResultRecorded, Subject: "Anna", Details: "10/10"})
+	data, _ = os.ReadFile(filepath.Join(dir, "forged.log"))
+	os.WriteFile(path, data, 0600)
+	if _, err := reopened.Entries(); !errors.Is(err, ErrTampered) {
+		t.Errorf("Expected a log chained with another key to be refused, got %v", err)
+	}
+}
+
+func TestTornLastLine(t *testing.T) {
+	dir := t.TempDir()
+	path, statePath := filepath.Join(dir, "audit.log"), filepath.Join(dir, "audit.json")
+	log, err := Open(path, statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.Record(Entry{Action: LiveTestStarted, Subject: "Animals"})
+
+	// The server stopped while writing the next entry
+	file, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	file.WriteString(`{"time":"2026-06-01T09:00:00Z","act`)
+	file.Close()
+
+	reopened, err := Open(path, statePath)
+	if err != nil {
+		t.Fatalf("Expected a torn last line to be tolerated, got %v", err)
+	}
+	if err := reopened.Record(Entry{Action: LiveTestLocked, Subject: "Animals"}); err != nil {
+		t.Fatal(err)
+	}
+	if entries, err := reopened.Entries(); err != nil || len(entries) != 2 {
+		t.Errorf("Expected 2 intact entries, got %+v, %v", entries, err)
+	}
+}
+
+func TestLogWithoutState(t *testing.T) {
+	dir := t.TempDir()
+	path, statePath := filepath.Join(dir, "audit.log"), filepath.Join(dir, "audit.json")
+	log, err := Open(path, statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.Record(Entry{Action: StudentAdded, Subject: "Anna"})
+	log.Record(Entry{Action: StudentAdded, Subject: "Bram"})
+
+	// Without its state file the key is gone, so the entries cannot be
+	// trusted, even after new ones are recorded
+	os.Remove(statePath)
+	reopened, err := Open(path, statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reopened.Record(Entry{Action: StudentRemoved, Subject: "Bram"})
+	if entries, err := reopened.Entries(); !errors.Is(err, ErrTampered) || len(entries) != 3 {
+		t.Errorf("Expected a log without its state to be reported as tampered with, got %d entries, %v", len(entries), err)
+	}
+	if reopened, err := Open(path, statePath); err != nil {
+		t.Fatal(err)
+	} else if _, err := reopened.Entries(); !errors.Is(err, ErrTampered) {
+		t.Errorf("Expected the log to stay tampered with after reopening, got %v", err)
+	}
+}
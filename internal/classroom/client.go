@@ -0,0 +1,348 @@
+package classroom
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/audit"
+	"golang.org/x/net/websocket"
+)
+
+// requestTimeout bounds every request to the server
+const requestTimeout = 30 * time.Second
+
+// StudentRecord is a student with their result history, as the server sends
+// it to the teacher
+type StudentRecord struct {
+	Student
+	Results []Result `json:"results"`
+}
+
+// JoinRequest is sent by a student joining a class
+type JoinRequest struct {
+	Code string `json:"code"`
+}
+
+// ResultSubmission is a result handed in for a student. A student hands
+// it in with their join code, which the server checks is theirs; the
+// teacher needs none.
+type ResultSubmission struct {
+	Result
+	Code string `json:"code,omitempty"`
+}
+
+// ThreadPost is a message posted to the thread about an item. A student
+// posts with their join code, which the server replaces by their name.
+type ThreadPost struct {
+	Message
+	Code string `json:"code,omitempty"`
+	// Question is the question of the item, kept with a new thread
+	Question string `json:"question,omitempty"`
+}
+
+// ResolveRequest marks a thread as resolved or open
+type ResolveRequest struct {
+	Resolved bool `json:"resolved"`
+}
+
+// Client talks to the roster served by "recuerdo serve"
+type Client struct {
+	server string
+	token  string
+	client *http.Client
+}
+
+// NewClient creates a client for the server at the given base URL, e.g.
+// "http://school.example:8080"
+func NewClient(server string) *Client {
+	return &Client{
+		server: strings.TrimSuffix(server, "/"),
+		client: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// SetToken sets the token sent to the server, one of those given to
+// "recuerdo serve -tokens"; empty sends none
+func (c *Client) SetToken(token string) {
+	c.token = strings.TrimSpace(token)
+}
+
+// Students returns the students on the roster
+func (c *Client) Students() ([]Student, error) {
+	var students []Student
+	err := c.do(http.MethodGet, "/api/roster", "", nil, &students)
+	return students, err
+}
+
+// Student returns a student with their result history
+func (c *Client) Student(id int) (*StudentRecord, error) {
+	var record StudentRecord
+	if err := c.do(http.MethodGet, fmt.Sprintf("/api/roster/%d", id), "", nil, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Add puts a new student on the roster
+func (c *Client) Add(name, group string) (Student, error) {
+	var student Student
+	err := c.doJSON(http.MethodPost, "/api/roster", Student{Name: name, Group: group}, &student)
+	return student, err
+}
+
+// Update changes the name and group of a student
+func (c *Client) Update(student Student) (Student, error) {
+	var updated Student
+	err := c.doJSON(http.MethodPut, fmt.Sprintf("/api/roster/%d", student.ID), student, &updated)
+	return updated, err
+}
+
+// Remove takes a student off the roster, erasing their results and the
+// questions they posted
+func (c *Client) Remove(id int) error {
+	return c.do(http.MethodDelete, fmt.Sprintf("/api/roster/%d", id), "", nil, nil)
+}
+
+// Export returns a zip of everything the server stores about a student:
+// their roster entry and results in student.json and the questions they
+// posted in questions.json
+func (c *Client) Export(id int) ([]byte, error) {
+	response, err := c.send(http.MethodGet, fmt.Sprintf("/api/roster/%d/export", id), "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download export: %w", err)
+	}
+	return data, nil
+}
+
+// NewJoinCode gives a student a new join code
+func (c *Client) NewJoinCode(id int) (Student, error) {
+	var student Student
+	err := c.do(http.MethodPost, fmt.Sprintf("/api/roster/%d/code", id), "", nil, &student)
+	return student, err
+}
+
+// ImportCSV adds the students in a CSV file; see Roster.ImportCSV
+func (c *Client) ImportCSV(data []byte) ([]Student, error) {
+	var students []Student
+	err := c.do(http.MethodPost, "/api/roster/import", "text/csv", bytes.NewReader(data), &students)
+	return students, err
+}
+
+// Join returns the student a join code belongs to
+func (c *Client) Join(code string) (Student, error) {
+	var student Student
+	err := c.doJSON(http.MethodPost, "/api/join", JoinRequest{Code: code}, &student)
+	return student, err
+}
+
+// RecordResult hands in a result for a student, as the teacher
+func (c *Client) RecordResult(id int, result Result) error {
+	return c.doJSON(http.MethodPost, fmt.Sprintf("/api/roster/%d/results", id), ResultSubmission{Result: result}, nil)
+}
+
+// HandIn hands in a result as the student with the join code
+func (c *Client) HandIn(code string, result Result) error {
+	student, err := c.Join(code)
+	if err != nil {
+		return err
+	}
+	submission := ResultSubmission{Result: result, Code: code}
+	return c.doJSON(http.MethodPost, fmt.Sprintf("/api/roster/%d/results", student.ID), submission, nil)
+}
+
+// Assignments returns the lessons assigned to a group
+func (c *Client) Assignments(group string) ([]string, error) {
+	var lessons []string
+	err := c.do(http.MethodGet, "/api/assignments?"+url.Values{"group": {group}}.Encode(), "", nil, &lessons)
+	return lessons, err
+}
+
+// Assign assigns a lesson to a group
+func (c *Client) Assign(group, lesson string) error {
+	return c.doJSON(http.MethodPost, "/api/assignments", Assignment{Group: group, Lesson: lesson}, nil)
+}
+
+// Unassign takes a lesson off the assignments of a group
+func (c *Client) Unassign(group, lesson string) error {
+	query := url.Values{"group": {group}, "lesson": {lesson}}.Encode()
+	return c.do(http.MethodDelete, "/api/assignments?"+query, "", nil, nil)
+}
+
+// Gradebook returns the gradebook of a group
+func (c *Client) Gradebook(group string) (*Gradebook, error) {
+	var gradebook Gradebook
+	if err := c.do(http.MethodGet, "/api/gradebook?"+url.Values{"group": {group}}.Encode(), "", nil, &gradebook); err != nil {
+		return nil, err
+	}
+	return &gradebook, nil
+}
+
+// AuditLog returns the entries of the audit log whose action starts with
+// action, recorded since the given time; a zero time returns all. Only
+// admin tokens may read it.
+func (c *Client) AuditLog(action string, since time.Time) (*audit.Report, error) {
+	query := url.Values{"action": {action}}
+	if !since.IsZero() {
+		query.Set("since", since.Format(time.RFC3339))
+	}
+	var report audit.Report
+	if err := c.do(http.MethodGet, "/api/audit?"+query.Encode(), "", nil, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Threads returns the threads about the items of all lessons, those waiting
+// for the teacher first
+func (c *Client) Threads() ([]Thread, error) {
+	var threads []Thread
+	err := c.do(http.MethodGet, "/api/threads", "", nil, &threads)
+	return threads, err
+}
+
+// LessonThreads returns the threads about the items of a lesson
+func (c *Client) LessonThreads(lesson string) ([]Thread, error) {
+	var threads []Thread
+	err := c.do(http.MethodGet, "/api/threads/"+url.PathEscape(lesson), "", nil, &threads)
+	return threads, err
+}
+
+// Post adds a message to the thread about an item
+func (c *Client) Post(lesson string, itemID int, post ThreadPost) (Thread, error) {
+	var thread Thread
+	err := c.doJSON(http.MethodPost, fmt.Sprintf("/api/threads/%s/%d", url.PathEscape(lesson), itemID), post, &thread)
+	return thread, err
+}
+
+// Resolve marks the thread about an item as resolved, or open again
+func (c *Client) Resolve(lesson string, itemID int, resolved bool) (Thread, error) {
+	var thread Thread
+	err := c.doJSON(http.MethodPut, fmt.Sprintf("/api/threads/%s/%d", url.PathEscape(lesson), itemID), ResolveRequest{Resolved: resolved}, &thread)
+	return thread, err
+}
+
+func (c *Client) doJSON(method, path string, body, result interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	return c.do(method, path, "application/json", bytes.NewReader(data), result)
+}
+
+// do sends a request to the server and decodes the response into result,
+// if it is not nil
+func (c *Client) do(method, path, contentType string, body io.Reader, result interface{}) error {
+	response, err := c.send(method, path, contentType, body)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(response.Body).Decode(result); err != nil {
+		return fmt.Errorf("invalid response from classroom server: %w", err)
+	}
+	return nil
+}
+
+// send sends a request to the server, returning the response when it
+// succeeded
+func (c *Client) send(method, path, contentType string, body io.Reader) (*http.Response, error) {
+	if c.server == "" {
+		return nil, fmt.Errorf("no classroom server configured")
+	}
+	request, err := http.NewRequest(method, c.server+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	if c.token != "" {
+		request.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	response, err := c.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach classroom server: %w", err)
+	}
+
+	if response.StatusCode >= 300 {
+		defer response.Body.Close()
+		var failure struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(response.Body).Decode(&failure)
+		return nil, fmt.Errorf("classroom server refused request: %s (%s)", failure.Error, response.Status)
+	}
+	return response, nil
+}
+
+// LiveConn is a connection to the live test channel of the server
+type LiveConn struct {
+	ws *websocket.Conn
+}
+
+// Live connects to the live test channel, as the student the join code
+// belongs to with the session PIN the teacher gave, or as the teacher when
+// code is empty
+func (c *Client) Live(code, pin string) (*LiveConn, error) {
+	if c.server == "" {
+		return nil, fmt.Errorf("no classroom server configured")
+	}
+	location, err := url.Parse(c.server + "/api/live")
+	if err != nil {
+		return nil, err
+	}
+	origin := *location
+	switch location.Scheme {
+	case "https":
+		location.Scheme = "wss"
+	default:
+		location.Scheme = "ws"
+	}
+	if code != "" {
+		location.RawQuery = url.Values{"code": {code}, "pin": {pin}}.Encode()
+	}
+	config, err := websocket.NewConfig(location.String(), origin.String())
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		config.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	ws, err := websocket.DialConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach the live test channel: %w", err)
+	}
+	return &LiveConn{ws: ws}, nil
+}
+
+// Send sends a message to the server
+func (c *LiveConn) Send(message LiveMessage) error {
+	return websocket.JSON.Send(c.ws, message)
+}
+
+// Receive waits for the next message from the server
+func (c *LiveConn) Receive() (LiveMessage, error) {
+	var message LiveMessage
+	err := websocket.JSON.Receive(c.ws, &message)
+	return message, err
+}
+
+// Close closes the connection
+func (c *LiveConn) Close() error {
+	return c.ws.Close()
+}
@@ -0,0 +1,111 @@
+package classroom
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// NoteCalculator turns percentages of right answers into notes, such as
+// "7.5" or "B+"; the note calculator modules implement it
+type NoteCalculator interface {
+	Calculatenote(percentage float64) string
+	Calculateaveragenote(percentages []float64) string
+}
+
+// Gradebook holds the latest result of every student of a group for every
+// lesson
+type Gradebook struct {
+	Group string `json:"group"`
+	// Lessons are the lessons assigned to the group, followed by the other
+	// lessons the group handed in results for
+	Lessons  []string       `json:"lessons"`
+	Students []GradebookRow `json:"students"`
+}
+
+// GradebookRow is a student with their latest result for each lesson
+type GradebookRow struct {
+	Student Student `json:"student"`
+	// Results holds a result per lesson title; a lesson the student has no
+	// result for is missing
+	Results map[string]Result `json:"results"`
+}
+
+// NewGradebook builds the gradebook of a group from the lessons assigned to
+// it and the records of the students, leaving out students of other groups
+func NewGradebook(group string, assignments []string, records []StudentRecord) *Gradebook {
+	gradebook := &Gradebook{Group: group, Lessons: append([]string{}, assignments...), Students: []GradebookRow{}}
+	assigned := make(map[string]bool)
+	for _, lessonTitle := range assignments {
+		assigned[lessonTitle] = true
+	}
+	var others []string
+	for _, record := range records {
+		if record.Group != group {
+			continue
+		}
+		row := GradebookRow{Student: record.Student, Results: make(map[string]Result)}
+		for _, result := range record.Results {
+			if latest, ok := row.Results[result.Lesson]; ok && latest.Time.After(result.Time) {
+				continue
+			}
+			row.Results[result.Lesson] = result
+			if !assigned[result.Lesson] {
+				assigned[result.Lesson] = true
+				others = append(others, result.Lesson)
+			}
+		}
+		gradebook.Students = append(gradebook.Students, row)
+	}
+	sort.Strings(others)
+	gradebook.Lessons = append(gradebook.Lessons, others...)
+	sort.SliceStable(gradebook.Students, func(i, j int) bool {
+		return strings.ToLower(gradebook.Students[i].Student.Name) < strings.ToLower(gradebook.Students[j].Student.Name)
+	})
+	return gradebook
+}
+
+// Table returns the gradebook as a header row and a row per student: the
+// name, the note for every lesson and the average note. A lesson without a
+// result has an empty cell and does not count towards the average.
+func (g *Gradebook) Table(notes NoteCalculator) (header []string, rows [][]string) {
+	header = append(append([]string{"Name"}, g.Lessons...), "Average")
+	for _, student := range g.Students {
+		row := []string{student.Student.Name}
+		var percentages []float64
+		for _, lessonTitle := range g.Lessons {
+			result, ok := student.Results[lessonTitle]
+			if !ok {
+				row = append(row, "")
+				continue
+			}
+			percentages = append(percentages, result.Percentage())
+			row = append(row, notes.Calculatenote(result.Percentage()))
+		}
+		rows = append(rows, append(row, notes.Calculateaveragenote(percentages)))
+	}
+	return header, rows
+}
+
+// WriteCSV writes the table of the gradebook as CSV
+func (g *Gradebook) WriteCSV(w io.Writer, notes NoteCalculator) error {
+	header, rows := g.Table(notes)
+	writer := csv.NewWriter(w)
+	writer.Write(header)
+	writer.WriteAll(rows)
+	return writer.Error()
+}
+
+// WriteODS writes the table of the gradebook as an OpenDocument
+// spreadsheet with a sheet named after the group
+func (g *Gradebook) WriteODS(w io.Writer, notes NoteCalculator) error {
+	header, rows := g.Table(notes)
+	sheet := g.Group
+	if sheet == "" {
+		sheet = "Class"
+	}
+	return lesson.WriteSpreadsheet(w, sheet, header, rows)
+}
@@ -0,0 +1,111 @@
+package classroom
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// percentNotes writes notes as whole percentages
+type percentNotes struct{}
+
+func (percentNotes) Calculatenote(percentage float64) string {
+	return fmt.Sprintf("%.0f", percentage)
+}
+
+func (percentNotes) Calculateaveragenote(percentages []float64) string {
+	if len(percentages) == 0 {
+		return ""
+	}
+	sum := 0.0
+	for _, percentage := range percentages {
+		sum += percentage
+	}
+	return fmt.Sprintf("%.0f", sum/float64(len(percentages)))
+}
+
+func TestRosterAssignments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "roster.json")
+	roster, _ := Open(path)
+	roster.Assign("3A", "animals")
+	roster.Assign("3A", "colours")
+	roster.Assign("3A", "animals")
+	roster.Assign("3B", "animals")
+	if err := roster.Assign("3A", " "); err == nil {
+		t.Error("Expected an error for an empty lesson title")
+	}
+	if err := roster.Unassign("3B", "colours"); err == nil {
+		t.Error("Expected an error for a lesson that is not assigned")
+	}
+	roster.Unassign("3B", "animals")
+
+	reopened, _ := Open(path)
+	if lessons := reopened.Assignments("3A"); strings.Join(lessons, ",") != "animals,colours" {
+		t.Errorf("Expected animals and colours assigned to 3A, got %v", lessons)
+	}
+	if lessons := reopened.Assignments("3B"); len(lessons) != 0 {
+		t.Errorf("Expected nothing assigned to 3B, got %v", lessons)
+	}
+}
+
+func TestGradebook(t *testing.T) {
+	roster, _ := Open(filepath.Join(t.TempDir(), "roster.json"))
+	bram, _ := roster.Add("Bram", "3A")
+	anna, _ := roster.Add("Anna", "3A")
+	cas, _ := roster.Add("Cas", "3B")
+	roster.Assign("3A", "colours")
+	roster.Assign("3A", "animals")
+
+	monday := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	roster.RecordResult(anna.ID, Result{Lesson: "animals", Right: 5, Wrong: 5, Time: monday.Add(time.Hour)})
+	roster.RecordResult(anna.ID, Result{Lesson: "animals", Right: 3, Wrong: 1, Time: monday})
+	roster.RecordResult(anna.ID, Result{Lesson: "colours", Right: 9, Wrong: 1, Time: monday})
+	roster.RecordResult(bram.ID, Result{Lesson: "weather", Right: 1, Wrong: 1, Time: monday})
+	roster.RecordResult(cas.ID, Result{Lesson: "numbers", Right: 1, Wrong: 0, Time: monday})
+
+	gradebook := roster.Gradebook("3A")
+	header, rows := gradebook.Table(percentNotes{})
+	if strings.Join(header, ",") != "Name,colours,animals,weather,Average" {
+		t.Errorf("Expected the assigned lessons first, got %v", header)
+	}
+	want := [][]string{
+		{"Anna", "90", "50", "", "70"},
+		{"Bram", "", "", "50", "50"},
+	}
+	if fmt.Sprint(rows) != fmt.Sprint(want) {
+		t.Errorf("Expected rows %v, got %v", want, rows)
+	}
+
+	var csvData bytes.Buffer
+	if err := gradebook.WriteCSV(&csvData, percentNotes{}); err != nil {
+		t.Fatalf("Failed to write CSV: %v", err)
+	}
+	if !strings.HasPrefix(csvData.String(), "Name,colours,animals,weather,Average\nAnna,90,50,,70\n") {
+		t.Errorf("Unexpected CSV:\n%s", csvData.String())
+	}
+
+	var odsData bytes.Buffer
+	if err := gradebook.WriteODS(&odsData, percentNotes{}); err != nil {
+		t.Fatalf("Failed to write ODS: %v", err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(odsData.Bytes()), int64(odsData.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range archive.File {
+		if file.Name != "content.xml" {
+			continue
+		}
+		reader, _ := file.Open()
+		content, _ := io.ReadAll(reader)
+		reader.Close()
+		if !strings.Contains(string(content), `table:name="3A"`) || !strings.Contains(string(content), "<text:p>Anna</text:p>") {
+			t.Errorf("Expected a 3A sheet listing Anna, got %s", content)
+		}
+	}
+}
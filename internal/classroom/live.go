@@ -0,0 +1,377 @@
+package classroom
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// The types of the messages of the live test channel
+const (
+	// LiveStart is sent by the teacher to push a lesson as a test to the
+	// students
+	LiveStart = "start"
+	// LiveLock is sent by the teacher to stop taking answers
+	LiveLock = "lock"
+	// LiveTest carries the questions of the test to a student
+	LiveTest = "test"
+	// LiveAnswer is an answer sent by a student, passed on to the teacher
+	// once checked
+	LiveAnswer = "answer"
+	// LiveJoined and LiveLeft tell the teacher a student connected or
+	// went away
+	LiveJoined = "joined"
+	LiveLeft   = "left"
+	// LiveLocked tells everyone no more answers are taken
+	LiveLocked = "locked"
+	// LiveError tells the sender why its message was refused
+	LiveError = "error"
+	// LiveSession tells a teacher the PIN students join the session with
+	LiveSession = "session"
+)
+
+// PINLength is the number of digits in a session PIN
+const PINLength = 6
+
+// ErrNoTest is returned for answers while no test is running
+var ErrNoTest = errors.New("no test is running")
+
+// ErrLocked is returned for answers after the test was locked
+var ErrLocked = errors.New("the test is locked")
+
+// ErrWrongPIN is returned when a student joins with another session PIN
+// than the one the teacher shows
+var ErrWrongPIN = errors.New("wrong session PIN")
+
+// liveBuffer is how many messages wait for a slow client before it misses
+// some
+const liveBuffer = 256
+
+// LiveItem is a question of a live test, without its answers
+type LiveItem struct {
+	ID        int      `json:"id"`
+	Questions []string `json:"questions"`
+}
+
+// LiveTestInfo is a live test as students see it
+type LiveTestInfo struct {
+	Title string     `json:"title"`
+	Items []LiveItem `json:"items"`
+	// Deadline is when the test locks, nil when the teacher locks it
+	Deadline *time.Time `json:"deadline,omitempty"`
+}
+
+// LiveAnswerInfo is an answer given in a live test
+type LiveAnswerInfo struct {
+	StudentID int    `json:"studentId"`
+	Student   string `json:"student,omitempty"`
+	ItemID    int    `json:"itemId"`
+	Answer    string `json:"answer"`
+	// Right is set by the server, which checks the answer
+	Right bool      `json:"right"`
+	Time  time.Time `json:"time"`
+}
+
+// LiveMessage is a message on the live test channel; Type tells which of
+// the other fields are set
+type LiveMessage struct {
+	Type string `json:"type"`
+	// Lesson and Minutes start a test; 0 minutes runs until locked
+	Lesson  *lesson.LessonData `json:"lesson,omitempty"`
+	Minutes int                `json:"minutes,omitempty"`
+	Test    *LiveTestInfo      `json:"test,omitempty"`
+	Answer  *LiveAnswerInfo    `json:"answer,omitempty"`
+	Student *Student           `json:"student,omitempty"`
+	PIN     string             `json:"pin,omitempty"`
+	Error   string             `json:"error,omitempty"`
+}
+
+// Live runs the live tests of a class: the teacher pushes a test, the
+// students connected get its questions and their answers reach the teacher
+// as they are given, until the test locks. It does not care how messages
+// travel; "recuerdo serve" carries them over a WebSocket. All methods are
+// safe for concurrent use.
+type Live struct {
+	// record keeps the result of every student who answered when a test
+	// locks
+	record func(studentID int, result Result) error
+	// pin is what students join with, so only the class in the room does
+	pin string
+
+	mu         sync.Mutex
+	lessonData *lesson.LessonData
+	test       *LiveTestInfo
+	checker    *lesson.AnswerChecker
+	locked     bool
+	timer      *time.Timer
+	// answers holds the last answer of every student to every item
+	answers  map[int]map[int]LiveAnswerInfo
+	teachers map[chan LiveMessage]bool
+	students map[chan LiveMessage]Student
+}
+
+// NewLive creates a live test hub with a new session PIN; record, if not
+// nil, keeps the results of a test once it locks, such as
+// Roster.RecordResult does
+func NewLive(record func(studentID int, result Result) error) *Live {
+	n, _ := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	return &Live{
+		record:   record,
+		pin:      fmt.Sprintf("%0*d", PINLength, n.Int64()),
+		answers:  make(map[int]map[int]LiveAnswerInfo),
+		teachers: make(map[chan LiveMessage]bool),
+		students: make(map[chan LiveMessage]Student),
+	}
+}
+
+// PIN returns the session PIN the teacher gives the students
+func (l *Live) PIN() string {
+	return l.pin
+}
+
+// CheckPIN returns ErrWrongPIN unless pin is the session PIN
+func (l *Live) CheckPIN(pin string) error {
+	if subtle.ConstantTimeCompare([]byte(strings.TrimSpace(pin)), []byte(l.pin)) != 1 {
+		return ErrWrongPIN
+	}
+	return nil
+}
+
+// Start pushes a lesson as a test to the students, replacing the running
+// one. It locks by itself after duration, or when Lock is called if
+// duration is 0.
+func (l *Live) Start(lessonData *lesson.LessonData, duration time.Duration) error {
+	if lessonData == nil || len(lessonData.List.Items) == 0 {
+		return fmt.Errorf("the test has no items")
+	}
+	test := &LiveTestInfo{Title: lessonData.List.Title}
+	for _, item := range lessonData.List.Items {
+		test.Items = append(test.Items, LiveItem{ID: item.ID, Questions: item.Questions})
+	}
+	checker := lesson.LessonAnswerChecker(lessonData)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+	if duration > 0 {
+		deadline := time.Now().Add(duration)
+		test.Deadline = &deadline
+		l.timer = time.AfterFunc(duration, func() { l.lock(test) })
+	}
+	l.lessonData, l.test, l.checker = lessonData, test, checker
+	l.locked = false
+	l.answers = make(map[int]map[int]LiveAnswerInfo)
+
+	message := LiveMessage{Type: LiveTest, Test: test}
+	l.broadcast(message)
+	return nil
+}
+
+// Lock stops taking answers and records the result of every student who
+// answered, counting the items they left open as wrong
+func (l *Live) Lock() {
+	l.lock(nil)
+}
+
+// lock locks the running test, or only test if not nil, which a test
+// pushed later has replaced when its timer fires late
+func (l *Live) lock(only *LiveTestInfo) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.test == nil || l.locked || (only != nil && only != l.test) {
+		return
+	}
+	l.locked = true
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+	for studentID, answers := range l.answers {
+		if l.record == nil {
+			break
+		}
+		result := Result{Lesson: l.test.Title, Time: time.Now()}
+		for _, answer := range answers {
+			if answer.Right {
+				result.Right++
+			}
+		}
+		result.Wrong = len(l.test.Items) - result.Right
+		if err := l.record(studentID, result); err != nil {
+			fmt.Printf("Warning: failed to record live test result: %v\n", err)
+		}
+	}
+	l.broadcast(LiveMessage{Type: LiveLocked})
+}
+
+// Answer checks the answer of a student to an item of the running test
+// and passes it on to the teacher. A later answer to the same item
+// replaces the earlier one.
+func (l *Live) Answer(student Student, itemID int, given string) (LiveAnswerInfo, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.test == nil {
+		return LiveAnswerInfo{}, ErrNoTest
+	}
+	if l.locked {
+		return LiveAnswerInfo{}, ErrLocked
+	}
+	var item *lesson.WordItem
+	for i := range l.lessonData.List.Items {
+		if l.lessonData.List.Items[i].ID == itemID {
+			item = &l.lessonData.List.Items[i]
+		}
+	}
+	if item == nil {
+		return LiveAnswerInfo{}, fmt.Errorf("the test has no item %d", itemID)
+	}
+
+	answer := LiveAnswerInfo{
+		StudentID: student.ID,
+		Student:   student.Name,
+		ItemID:    itemID,
+		Answer:    given,
+		Right:     l.checker.Check(given, item.Answers),
+		Time:      time.Now(),
+	}
+	if l.answers[student.ID] == nil {
+		l.answers[student.ID] = make(map[int]LiveAnswerInfo)
+	}
+	l.answers[student.ID][itemID] = answer
+	l.sendTeachers(LiveMessage{Type: LiveAnswer, Answer: &answer})
+	return answer, nil
+}
+
+// Answers returns the last answer of every student to every item of the
+// running test, oldest first
+func (l *Live) Answers() []LiveAnswerInfo {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.sortedAnswers()
+}
+
+// Teach connects a teacher, who is told the session PIN, the running test,
+// the students connected and the answers given so far, and then about
+// every change. Calling leave disconnects the teacher.
+func (l *Live) Teach() (messages <-chan LiveMessage, leave func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ch := make(chan LiveMessage, liveBuffer)
+	ch <- LiveMessage{Type: LiveSession, PIN: l.pin}
+	if l.test != nil {
+		ch <- LiveMessage{Type: LiveTest, Test: l.test}
+	}
+	for _, student := range l.students {
+		student := student
+		l.trySend(ch, LiveMessage{Type: LiveJoined, Student: &student})
+	}
+	for _, answer := range l.sortedAnswers() {
+		answer := answer
+		l.trySend(ch, LiveMessage{Type: LiveAnswer, Answer: &answer})
+	}
+	if l.locked {
+		l.trySend(ch, LiveMessage{Type: LiveLocked})
+	}
+	l.teachers[ch] = true
+	return ch, func() { l.leave(ch) }
+}
+
+// Join connects a student, who gets the running test, if any, and every
+// test pushed later. Calling leave disconnects the student.
+func (l *Live) Join(student Student) (messages <-chan LiveMessage, leave func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ch := make(chan LiveMessage, liveBuffer)
+	if l.test != nil {
+		ch <- LiveMessage{Type: LiveTest, Test: l.test}
+		if l.locked {
+			ch <- LiveMessage{Type: LiveLocked}
+		}
+	}
+	l.students[ch] = student
+	l.sendTeachers(LiveMessage{Type: LiveJoined, Student: &student})
+	return ch, func() { l.leave(ch) }
+}
+
+// Close disconnects everyone and stops the timer of the running test
+func (l *Live) Close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+	for ch := range l.teachers {
+		close(ch)
+	}
+	for ch := range l.students {
+		close(ch)
+	}
+	l.teachers = make(map[chan LiveMessage]bool)
+	l.students = make(map[chan LiveMessage]Student)
+}
+
+// leave disconnects a teacher or student
+func (l *Live) leave(ch chan LiveMessage) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.teachers[ch] {
+		delete(l.teachers, ch)
+		close(ch)
+		return
+	}
+	if student, ok := l.students[ch]; ok {
+		delete(l.students, ch)
+		close(ch)
+		l.sendTeachers(LiveMessage{Type: LiveLeft, Student: &student})
+	}
+}
+
+// broadcast sends a message to the teachers and students; the lock is held
+func (l *Live) broadcast(message LiveMessage) {
+	l.sendTeachers(message)
+	for ch := range l.students {
+		l.trySend(ch, message)
+	}
+}
+
+// sendTeachers sends a message to every teacher; the lock is held
+func (l *Live) sendTeachers(message LiveMessage) {
+	for ch := range l.teachers {
+		l.trySend(ch, message)
+	}
+}
+
+// trySend sends a message unless the client fell too far behind, which
+// then misses it
+func (l *Live) trySend(ch chan LiveMessage, message LiveMessage) {
+	select {
+	case ch <- message:
+	default:
+	}
+}
+
+// sortedAnswers returns the answers oldest first; the lock is held
+func (l *Live) sortedAnswers() []LiveAnswerInfo {
+	var answers []LiveAnswerInfo
+	for _, byItem := range l.answers {
+		for _, answer := range byItem {
+			answers = append(answers, answer)
+		}
+	}
+	sort.Slice(answers, func(i, j int) bool {
+		return answers[i].Time.Before(answers[j].Time)
+	})
+	return answers
+}
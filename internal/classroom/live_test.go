@@ -0,0 +1,105 @@
+package classroom
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+func TestLive(t *testing.T) {
+	var mu sync.Mutex
+	recorded := make(map[int]Result)
+	live := NewLive(func(studentID int, result Result) error {
+		mu.Lock()
+		defer mu.Unlock()
+		recorded[studentID] = result
+		return nil
+	})
+	anna, bram := Student{ID: 1, Name: "Anna"}, Student{ID: 2, Name: "Bram"}
+	if _, err := live.Answer(anna, 0, "gato"); !errors.Is(err, ErrNoTest) {
+		t.Errorf("Expected no test running, got %v", err)
+	}
+
+	teacher, leaveTeacher := live.Teach()
+	defer leaveTeacher()
+	if message := <-teacher; message.Type != LiveSession || len(message.PIN) != PINLength || message.PIN != live.PIN() {
+		t.Errorf("Expected the teacher to be told the session PIN first, got %+v", message)
+	}
+	if err := live.CheckPIN(live.PIN()); err != nil {
+		t.Errorf("The session PIN was refused: %v", err)
+	}
+	if err := live.CheckPIN("x"); !errors.Is(err, ErrWrongPIN) {
+		t.Errorf("Expected a wrong PIN to be refused, got %v", err)
+	}
+	student, leaveStudent := live.Join(anna)
+	if message := <-teacher; message.Type != LiveJoined || message.Student.Name != "Anna" {
+		t.Errorf("Expected the teacher to see Anna join, got %+v", message)
+	}
+
+	lessonData := &lesson.LessonData{List: lesson.WordList{Title: "Animals", Items: []lesson.WordItem{
+		{ID: 0, Questions: []string{"cat"}, Answers: []string{"gato"}},
+		{ID: 1, Questions: []string{"dog"}, Answers: []string{"perro"}},
+	}}}
+	if err := live.Start(lessonData, 0); err != nil {
+		t.Fatal(err)
+	}
+	message := <-student
+	if message.Type != LiveTest || len(message.Test.Items) != 2 || message.Test.Deadline != nil {
+		t.Fatalf("Expected the test to reach the student, got %+v", message)
+	}
+	<-teacher
+
+	if _, err := live.Answer(anna, 0, "perro"); err != nil {
+		t.Fatal(err)
+	}
+	if answer, err := live.Answer(anna, 0, "gato"); err != nil || !answer.Right {
+		t.Fatalf("Expected a right answer, got %+v, %v", answer, err)
+	}
+	if _, err := live.Answer(bram, 7, "gato"); err == nil {
+		t.Error("Expected an answer to an unknown item to be refused")
+	}
+	if message := <-teacher; message.Type != LiveAnswer || message.Answer.Right {
+		t.Errorf("Expected the wrong answer first, got %+v", message)
+	}
+	if message := <-teacher; message.Type != LiveAnswer || !message.Answer.Right || message.Answer.Student != "Anna" {
+		t.Errorf("Expected the right answer next, got %+v", message)
+	}
+	if answers := live.Answers(); len(answers) != 1 || answers[0].Answer != "gato" {
+		t.Errorf("Expected only the last answer kept, got %+v", answers)
+	}
+
+	leaveStudent()
+	if message := <-teacher; message.Type != LiveLeft {
+		t.Errorf("Expected the teacher to see Anna leave, got %+v", message)
+	}
+	if _, ok := <-student; ok {
+		t.Error("Expected the student's messages to end")
+	}
+
+	// A test with a deadline locks by itself
+	if err := live.Start(lessonData, 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := live.Answer(bram, 1, "perro"); err != nil {
+		t.Fatal(err)
+	}
+	for message := range teacher {
+		if message.Type == LiveLocked {
+			break
+		}
+	}
+	if _, err := live.Answer(bram, 0, "gato"); !errors.Is(err, ErrLocked) {
+		t.Errorf("Expected answers to be refused once locked, got %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if result, ok := recorded[bram.ID]; !ok || result.Right != 1 || result.Wrong != 1 || result.Lesson != "Animals" {
+		t.Errorf("Expected Bram's result recorded, got %+v", recorded)
+	}
+	if _, ok := recorded[anna.ID]; ok {
+		t.Error("Expected only the students of the locked test to be recorded")
+	}
+}
@@ -0,0 +1,420 @@
+// Package classroom keeps the class roster of a teacher: the students, the
+// codes they join a class with, the lessons assigned to each group and the
+// results they handed in, which a Gradebook turns into notes. The roster is
+// served to teachers and students by "recuerdo serve".
+package classroom
+
+import (
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// joinCodeAlphabet leaves out letters and digits that are easily mixed up
+// when a code is read aloud or copied from the board
+const joinCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// JoinCodeLength is the number of characters in a join code
+const JoinCodeLength = 6
+
+// ErrNotFound is returned for students that are not on the roster
+var ErrNotFound = errors.New("student not found")
+
+// Student is a student on the roster
+type Student struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Group    string `json:"group,omitempty"`
+	JoinCode string `json:"joinCode"`
+}
+
+// Result is a test result handed in by a student
+type Result struct {
+	Lesson string    `json:"lesson"`
+	Right  int       `json:"right"`
+	Wrong  int       `json:"wrong"`
+	Time   time.Time `json:"time"`
+}
+
+// Percentage returns the share of right answers, 0 for an empty result
+func (r Result) Percentage() float64 {
+	if r.Right+r.Wrong == 0 {
+		return 0
+	}
+	return float64(r.Right) / float64(r.Right+r.Wrong) * 100
+}
+
+// Assignment is a lesson assigned to a group of students, named by the
+// title results are recorded under
+type Assignment struct {
+	Group  string `json:"group"`
+	Lesson string `json:"lesson"`
+}
+
+// Roster is a class roster kept in a JSON file. All methods are safe for
+// concurrent use and save the roster after a change.
+type Roster struct {
+	path        string
+	students    []Student
+	results     map[int][]Result
+	assignments []Assignment
+	nextID      int
+	mu          sync.Mutex
+}
+
+// rosterFile is the stored form of a roster
+type rosterFile struct {
+	Students []Student        `json:"students"`
+	Results  map[int][]Result `json:"results,omitempty"`
+	// Assignments are kept in the order they were made
+	Assignments []Assignment `json:"assignments,omitempty"`
+	// NextID keeps ids of removed students from being given out again
+	NextID int `json:"nextId"`
+}
+
+// Open reads the roster at path. A missing file gives an empty roster that
+// is created on the first change.
+func Open(path string) (*Roster, error) {
+	roster := &Roster{path: path, results: make(map[int][]Result)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return roster, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read roster: %w", err)
+	}
+	var file rosterFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse roster: %w", err)
+	}
+	roster.students = file.Students
+	roster.assignments = file.Assignments
+	roster.nextID = file.NextID
+	for id, results := range file.Results {
+		roster.results[id] = results
+	}
+	for _, student := range roster.students {
+		roster.nextID = max(roster.nextID, student.ID+1)
+	}
+	return roster, nil
+}
+
+// Students returns the students sorted by group and name
+func (r *Roster) Students() []Student {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	students := append([]Student(nil), r.students...)
+	sort.SliceStable(students, func(i, j int) bool {
+		if students[i].Group != students[j].Group {
+			return students[i].Group < students[j].Group
+		}
+		return strings.ToLower(students[i].Name) < strings.ToLower(students[j].Name)
+	})
+	return students
+}
+
+// Student returns the student with the given id
+func (r *Roster) Student(id int) (Student, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if i := r.index(id); i >= 0 {
+		return r.students[i], nil
+	}
+	return Student{}, ErrNotFound
+}
+
+// Join returns the student a join code belongs to. Codes are not case
+// sensitive.
+func (r *Roster) Join(code string) (Student, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	code = strings.ToUpper(strings.TrimSpace(code))
+	for _, student := range r.students {
+		if student.JoinCode == code {
+			return student, nil
+		}
+	}
+	return Student{}, fmt.Errorf("unknown join code %q", code)
+}
+
+// Add puts a new student on the roster with a fresh join code
+func (r *Roster) Add(name, group string) (Student, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	student, err := r.add(name, group)
+	if err != nil {
+		return Student{}, err
+	}
+	return student, r.save()
+}
+
+// Update changes the name and group of a student; the join code is kept
+func (r *Roster) Update(student Student) (Student, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.index(student.ID)
+	if i < 0 {
+		return Student{}, ErrNotFound
+	}
+	name := strings.TrimSpace(student.Name)
+	if name == "" {
+		return Student{}, fmt.Errorf("student name is empty")
+	}
+	r.students[i].Name = name
+	r.students[i].Group = strings.TrimSpace(student.Group)
+	return r.students[i], r.save()
+}
+
+// Remove takes a student and their results off the roster
+func (r *Roster) Remove(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.index(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	r.students = append(r.students[:i], r.students[i+1:]...)
+	delete(r.results, id)
+	return r.save()
+}
+
+// NewJoinCode gives a student a new join code, so the old one stops working
+func (r *Roster) NewJoinCode(id int) (Student, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.index(id)
+	if i < 0 {
+		return Student{}, ErrNotFound
+	}
+	code, err := r.joinCode()
+	if err != nil {
+		return Student{}, err
+	}
+	r.students[i].JoinCode = code
+	return r.students[i], r.save()
+}
+
+// ImportCSV adds the students in a CSV file with a name column and an
+// optional group column. A header row naming the columns is skipped, and so
+// are students already on the roster. It returns the added students.
+func (r *Roster) ImportCSV(reader io.Reader) ([]Student, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	if len(records) > 0 && len(records[0]) > 0 && strings.EqualFold(strings.TrimSpace(records[0][0]), "name") {
+		records = records[1:]
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var added []Student
+	for _, record := range records {
+		if len(record) == 0 || strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		name, group := strings.TrimSpace(record[0]), ""
+		if len(record) > 1 {
+			group = strings.TrimSpace(record[1])
+		}
+		if r.has(name, group) {
+			continue
+		}
+		student, err := r.add(name, group)
+		if err != nil {
+			return nil, err
+		}
+		added = append(added, student)
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+	return added, r.save()
+}
+
+// RecordResult adds a result to the history of a student. A result without
+// a time is recorded at the current time.
+func (r *Roster) RecordResult(id int, result Result) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.index(id) < 0 {
+		return ErrNotFound
+	}
+	if result.Time.IsZero() {
+		result.Time = time.Now()
+	}
+	r.results[id] = append(r.results[id], result)
+	return r.save()
+}
+
+// History returns the results of a student, oldest first
+func (r *Roster) History(id int) ([]Result, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.index(id) < 0 {
+		return nil, ErrNotFound
+	}
+	history := append([]Result(nil), r.results[id]...)
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].Time.Before(history[j].Time)
+	})
+	return history, nil
+}
+
+// Assign assigns a lesson to a group; assigning it again changes nothing
+func (r *Roster) Assign(group, lesson string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	assignment := Assignment{Group: strings.TrimSpace(group), Lesson: strings.TrimSpace(lesson)}
+	if assignment.Lesson == "" {
+		return fmt.Errorf("lesson title is empty")
+	}
+	for _, existing := range r.assignments {
+		if existing == assignment {
+			return nil
+		}
+	}
+	r.assignments = append(r.assignments, assignment)
+	return r.save()
+}
+
+// Unassign takes a lesson off the assignments of a group. Results already
+// handed in for it are kept.
+func (r *Roster) Unassign(group, lesson string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	assignment := Assignment{Group: strings.TrimSpace(group), Lesson: strings.TrimSpace(lesson)}
+	for i, existing := range r.assignments {
+		if existing == assignment {
+			r.assignments = append(r.assignments[:i], r.assignments[i+1:]...)
+			return r.save()
+		}
+	}
+	return fmt.Errorf("lesson %q is not assigned to group %q", assignment.Lesson, assignment.Group)
+}
+
+// Assignments returns the lessons assigned to a group, in the order they
+// were assigned
+func (r *Roster) Assignments(group string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var lessons []string
+	for _, assignment := range r.assignments {
+		if assignment.Group == strings.TrimSpace(group) {
+			lessons = append(lessons, assignment.Lesson)
+		}
+	}
+	return lessons
+}
+
+// Gradebook returns the gradebook of a group
+func (r *Roster) Gradebook(group string) *Gradebook {
+	group = strings.TrimSpace(group)
+	var records []StudentRecord
+	for _, student := range r.Students() {
+		if student.Group != group {
+			continue
+		}
+		results, err := r.History(student.ID)
+		if err != nil {
+			continue
+		}
+		records = append(records, StudentRecord{Student: student, Results: results})
+	}
+	return NewGradebook(group, r.Assignments(group), records)
+}
+
+func (r *Roster) add(name, group string) (Student, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return Student{}, fmt.Errorf("student name is empty")
+	}
+	code, err := r.joinCode()
+	if err != nil {
+		return Student{}, err
+	}
+	student := Student{ID: r.nextID, Name: name, Group: strings.TrimSpace(group), JoinCode: code}
+	r.nextID++
+	r.students = append(r.students, student)
+	return student, nil
+}
+
+func (r *Roster) has(name, group string) bool {
+	for _, student := range r.students {
+		if strings.EqualFold(student.Name, name) && strings.EqualFold(student.Group, group) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Roster) index(id int) int {
+	for i, student := range r.students {
+		if student.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// joinCode returns a random join code no student on the roster has
+func (r *Roster) joinCode() (string, error) {
+	for {
+		code := make([]byte, JoinCodeLength)
+		for i := range code {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(joinCodeAlphabet))))
+			if err != nil {
+				return "", fmt.Errorf("failed to generate join code: %w", err)
+			}
+			code[i] = joinCodeAlphabet[n.Int64()]
+		}
+		taken := false
+		for _, student := range r.students {
+			taken = taken || student.JoinCode == string(code)
+		}
+		if !taken {
+			return string(code), nil
+		}
+	}
+}
+
+func (r *Roster) save() error {
+	data, err := json.MarshalIndent(rosterFile{Students: r.students, Results: r.results, Assignments: r.assignments, NextID: r.nextID}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode roster: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("failed to create roster directory: %w", err)
+	}
+	if err := os.WriteFile(r.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to save roster: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package classroom
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRosterCRUD(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "classroom", "roster.json")
+	roster, err := Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open new roster: %v", err)
+	}
+
+	anna, err := roster.Add("Anna", "3B")
+	if err != nil {
+		t.Fatalf("Failed to add student: %v", err)
+	}
+	bram, _ := roster.Add("Bram", "3A")
+	if len(anna.JoinCode) != JoinCodeLength || anna.JoinCode == bram.JoinCode {
+		t.Errorf("Expected two different join codes, got %q and %q", anna.JoinCode, bram.JoinCode)
+	}
+	if _, err := roster.Add("  ", "3A"); err == nil {
+		t.Error("Expected an error for an empty name")
+	}
+
+	anna.Name = "Anna de Vries"
+	if _, err := roster.Update(anna); err != nil {
+		t.Fatalf("Failed to update student: %v", err)
+	}
+	students := roster.Students()
+	if len(students) != 2 || students[0].Name != "Bram" || students[1].Name != "Anna de Vries" {
+		t.Errorf("Expected students sorted by group, got %+v", students)
+	}
+
+	joined, err := roster.Join(strings.ToLower(bram.JoinCode))
+	if err != nil || joined.ID != bram.ID {
+		t.Errorf("Expected join code to find Bram, got %+v, %v", joined, err)
+	}
+	renewed, _ := roster.NewJoinCode(bram.ID)
+	if _, err := roster.Join(bram.JoinCode); err == nil {
+		t.Error("Expected the old join code to stop working")
+	}
+	if joined, _ := roster.Join(renewed.JoinCode); joined.ID != bram.ID {
+		t.Errorf("Expected the new join code to find Bram, got %+v", joined)
+	}
+
+	if err := roster.Remove(bram.ID); err != nil {
+		t.Fatalf("Failed to remove student: %v", err)
+	}
+	if _, err := roster.Student(bram.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected removed student to be gone, got %v", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen roster: %v", err)
+	}
+	if students := reopened.Students(); len(students) != 1 || students[0].Name != "Anna de Vries" {
+		t.Errorf("Expected the roster to be saved, got %+v", students)
+	}
+	if added, _ := reopened.Add("Cas", ""); added.ID == anna.ID || added.ID == bram.ID {
+		t.Errorf("Expected a new id after reopening, got %d", added.ID)
+	}
+}
+
+func TestRosterImportCSV(t *testing.T) {
+	roster, _ := Open(filepath.Join(t.TempDir(), "roster.json"))
+	roster.Add("Anna", "3B")
+
+	csvData := "Name,Group\nAnna,3B\nBram, 3A\n\n\"Vries, Cas de\"\nDaan,3A,extra\n"
+	added, err := roster.ImportCSV(strings.NewReader(csvData))
+	if err != nil {
+		t.Fatalf("Failed to import CSV: %v", err)
+	}
+	if len(added) != 3 {
+		t.Fatalf("Expected 3 new students, got %+v", added)
+	}
+	if added[0].Name != "Bram" || added[0].Group != "3A" || added[1].Name != "Vries, Cas de" || added[1].Group != "" {
+		t.Errorf("Unexpected imported students: %+v", added)
+	}
+	if len(roster.Students()) != 4 {
+		t.Errorf("Expected 4 students on the roster, got %d", len(roster.Students()))
+	}
+}
+
+func TestRosterHistory(t *testing.T) {
+	roster, _ := Open(filepath.Join(t.TempDir(), "roster.json"))
+	anna, _ := roster.Add("Anna", "")
+
+	later := time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC)
+	earlier := later.Add(-24 * time.Hour)
+	roster.RecordResult(anna.ID, Result{Lesson: "animals", Right: 9, Wrong: 1, Time: later})
+	roster.RecordResult(anna.ID, Result{Lesson: "colours", Right: 3, Wrong: 1, Time: earlier})
+	if err := roster.RecordResult(42, Result{Lesson: "animals"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an unknown student, got %v", err)
+	}
+
+	history, err := roster.History(anna.ID)
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	if len(history) != 2 || history[0].Lesson != "colours" || history[1].Percentage() != 90 {
+		t.Errorf("Unexpected history: %+v", history)
+	}
+
+	roster.Remove(anna.ID)
+	readded, _ := roster.Add("Anna", "")
+	if history, _ := roster.History(readded.ID); len(history) != 0 {
+		t.Errorf("Expected a new student to start without results, got %+v", history)
+	}
+}
@@ -0,0 +1,258 @@
+package classroom
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JoinCodeSetting is the settings key holding the join code a student asks
+// their questions with
+const JoinCodeSetting = "classroom.joinCode"
+
+// ErrThreadNotFound is returned for items nobody asked about yet
+var ErrThreadNotFound = errors.New("thread not found")
+
+// Message is one post in the discussion about an item
+type Message struct {
+	Author string `json:"author"`
+	// Teacher marks the answers of the teacher
+	Teacher bool `json:"teacher,omitempty"`
+	// StudentID is the roster id of the student who posted the message,
+	// so their posts can be exported and erased with them
+	StudentID int       `json:"studentId,omitempty"`
+	Text      string    `json:"text"`
+	Time      time.Time `json:"time"`
+}
+
+// Thread is the discussion about one item of a lesson, such as a student
+// asking what a confusing word means
+type Thread struct {
+	Lesson string `json:"lesson"`
+	ItemID int    `json:"itemId"`
+	// Question is the question of the item when the thread was started, so
+	// the teacher sees what it was about without opening the lesson
+	Question string    `json:"question,omitempty"`
+	Messages []Message `json:"messages"`
+	Resolved bool      `json:"resolved,omitempty"`
+}
+
+// Answered reports whether the teacher posted the last message
+func (t Thread) Answered() bool {
+	return len(t.Messages) > 0 && t.Messages[len(t.Messages)-1].Teacher
+}
+
+// LastActivity returns the time of the last message
+func (t Thread) LastActivity() time.Time {
+	if len(t.Messages) == 0 {
+		return time.Time{}
+	}
+	return t.Messages[len(t.Messages)-1].Time
+}
+
+// Transcript returns the messages as plain text, one paragraph each, for
+// showing the discussion
+func (t Thread) Transcript() string {
+	var transcript strings.Builder
+	for i, message := range t.Messages {
+		if i > 0 {
+			transcript.WriteString("\n\n")
+		}
+		author := message.Author
+		if message.Teacher {
+			author += " (teacher)"
+		}
+		fmt.Fprintf(&transcript, "%s, %s:\n%s", author, message.Time.Local().Format("2006-01-02 15:04"), message.Text)
+	}
+	return transcript.String()
+}
+
+// Threads keeps the discussions about lesson items in a JSON file. All
+// methods are safe for concurrent use and save the threads after a change.
+type Threads struct {
+	path    string
+	threads []Thread
+	mu      sync.Mutex
+}
+
+// OpenThreads reads the threads at path. A missing file gives no threads
+// and is created on the first post.
+func OpenThreads(path string) (*Threads, error) {
+	threads := &Threads{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return threads, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read threads: %w", err)
+	}
+	if err := json.Unmarshal(data, &threads.threads); err != nil {
+		return nil, fmt.Errorf("failed to parse threads: %w", err)
+	}
+	return threads, nil
+}
+
+// All returns every thread for the teacher: open threads the teacher has
+// not answered first, then the rest, each with the latest activity first
+func (t *Threads) All() []Thread {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	threads := append([]Thread(nil), t.threads...)
+	sort.SliceStable(threads, func(i, j int) bool {
+		if waiting(threads[i]) != waiting(threads[j]) {
+			return waiting(threads[i])
+		}
+		return threads[i].LastActivity().After(threads[j].LastActivity())
+	})
+	return threads
+}
+
+// waiting reports whether a thread waits for the teacher
+func waiting(thread Thread) bool {
+	return !thread.Resolved && !thread.Answered()
+}
+
+// Lesson returns the threads about the items of a lesson, by item id
+func (t *Threads) Lesson(lesson string) []Thread {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var threads []Thread
+	for _, thread := range t.threads {
+		if thread.Lesson == lesson {
+			threads = append(threads, thread)
+		}
+	}
+	sort.SliceStable(threads, func(i, j int) bool { return threads[i].ItemID < threads[j].ItemID })
+	return threads
+}
+
+// Post adds a message to the thread about an item, starting the thread if
+// needed. question is only used for a new thread. A student posting to a
+// resolved thread opens it again. A message without a time is posted at the
+// current time.
+func (t *Threads) Post(lesson string, itemID int, question string, message Message) (Thread, error) {
+	message.Author = strings.TrimSpace(message.Author)
+	message.Text = strings.TrimSpace(message.Text)
+	if lesson == "" {
+		return Thread{}, fmt.Errorf("lesson name is empty")
+	}
+	if message.Author == "" {
+		return Thread{}, fmt.Errorf("message author is empty")
+	}
+	if message.Text == "" {
+		return Thread{}, fmt.Errorf("message text is empty")
+	}
+	if message.Time.IsZero() {
+		message.Time = time.Now()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	i := t.index(lesson, itemID)
+	if i < 0 {
+		t.threads = append(t.threads, Thread{Lesson: lesson, ItemID: itemID, Question: strings.TrimSpace(question)})
+		i = len(t.threads) - 1
+	}
+	t.threads[i].Messages = append(t.threads[i].Messages, message)
+	if !message.Teacher {
+		t.threads[i].Resolved = false
+	}
+	return t.threads[i], t.save()
+}
+
+// Resolve marks the thread about an item as resolved, or open again
+func (t *Threads) Resolve(lesson string, itemID int, resolved bool) (Thread, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	i := t.index(lesson, itemID)
+	if i < 0 {
+		return Thread{}, ErrThreadNotFound
+	}
+	t.threads[i].Resolved = resolved
+	return t.threads[i], t.save()
+}
+
+// ByStudent returns the threads a student posted in, holding only their
+// own messages
+func (t *Threads) ByStudent(studentID int) []Thread {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var threads []Thread
+	for _, thread := range t.threads {
+		var messages []Message
+		for _, message := range thread.Messages {
+			if message.StudentID == studentID {
+				messages = append(messages, message)
+			}
+		}
+		if len(messages) > 0 {
+			thread.Messages = messages
+			threads = append(threads, thread)
+		}
+	}
+	return threads
+}
+
+// Erase removes the messages a student posted, and the threads left
+// without messages. It returns the number of messages removed.
+func (t *Threads) Erase(studentID int) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	erased := 0
+	threads := t.threads[:0]
+	for _, thread := range t.threads {
+		messages := thread.Messages[:0]
+		for _, message := range thread.Messages {
+			if message.StudentID == studentID {
+				erased++
+				continue
+			}
+			messages = append(messages, message)
+		}
+		thread.Messages = messages
+		if len(messages) > 0 {
+			threads = append(threads, thread)
+		}
+	}
+	t.threads = threads
+	if erased == 0 {
+		return 0, nil
+	}
+	return erased, t.save()
+}
+
+func (t *Threads) index(lesson string, itemID int) int {
+	for i, thread := range t.threads {
+		if thread.Lesson == lesson && thread.ItemID == itemID {
+			return i
+		}
+	}
+	return -1
+}
+
+func (t *Threads) save() error {
+	data, err := json.MarshalIndent(t.threads, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode threads: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0755); err != nil {
+		return fmt.Errorf("failed to create threads directory: %w", err)
+	}
+	if err := os.WriteFile(t.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to save threads: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package classroom
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestThreads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "classroom", "threads.json")
+	threads, err := OpenThreads(path)
+	if err != nil {
+		t.Fatalf("Failed to open new threads: %v", err)
+	}
+
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	if _, err := threads.Post("animals.ot", 3, "kat", Message{Author: "Anna", Text: "Is this a cat or a tomcat?", Time: start}); err != nil {
+		t.Fatalf("Failed to post: %v", err)
+	}
+	if _, err := threads.Post("animals.ot", 1, "hond", Message{Author: "Bram", Text: "Why not 'hound'?", Time: start.Add(time.Minute)}); err != nil {
+		t.Fatalf("Failed to post: %v", err)
+	}
+	answered, err := threads.Post("animals.ot", 1, "", Message{Author: "Teacher", Teacher: true, Text: "Both are fine.", Time: start.Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("Failed to answer: %v", err)
+	}
+	if !answered.Answered() || answered.Question != "hond" || len(answered.Messages) != 2 {
+		t.Errorf("Expected the answer in the existing thread, got %+v", answered)
+	}
+	if transcript := answered.Transcript(); !strings.Contains(transcript, "Why not 'hound'?") || !strings.Contains(transcript, "Teacher (teacher), ") {
+		t.Errorf("Unexpected transcript %q", transcript)
+	}
+	if _, err := threads.Post("animals.ot", 1, "", Message{Author: "Bram", Text: "  "}); err == nil {
+		t.Error("Expected an error for an empty message")
+	}
+
+	// The unanswered question comes first for the teacher
+	all := threads.All()
+	if len(all) != 2 || all[0].ItemID != 3 || all[1].ItemID != 1 {
+		t.Errorf("Expected the waiting thread first, got %+v", all)
+	}
+
+	if _, err := threads.Resolve("animals.ot", 3, true); err != nil {
+		t.Fatalf("Failed to resolve: %v", err)
+	}
+	if _, err := threads.Resolve("animals.ot", 7, true); !errors.Is(err, ErrThreadNotFound) {
+		t.Errorf("Expected ErrThreadNotFound, got %v", err)
+	}
+
+	reopened, err := OpenThreads(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen threads: %v", err)
+	}
+	lesson := reopened.Lesson("animals.ot")
+	if len(lesson) != 2 || lesson[0].ItemID != 1 || !lesson[1].Resolved {
+		t.Fatalf("Expected both threads by item id after reopening, got %+v", lesson)
+	}
+	if len(reopened.Lesson("plants.ot")) != 0 {
+		t.Error("Expected no threads for another lesson")
+	}
+
+	// A new question from a student opens a resolved thread again
+	thread, _ := reopened.Post("animals.ot", 3, "", Message{Author: "Anna", Text: "And a kitten?"})
+	if thread.Resolved {
+		t.Error("Expected a student post to reopen the thread")
+	}
+}
+
+func TestThreadsErase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "threads.json")
+	threads, _ := OpenThreads(path)
+	threads.Post("animals.ot", 1, "hond", Message{Author: "Anna", StudentID: 1, Text: "Why not 'hound'?"})
+	threads.Post("animals.ot", 1, "", Message{Author: "Teacher", Teacher: true, Text: "Both are fine."})
+	threads.Post("animals.ot", 1, "", Message{Author: "Bram", StudentID: 2, Text: "Thanks!"})
+	threads.Post("animals.ot", 3, "kat", Message{Author: "Anna", StudentID: 1, Text: "A cat?"})
+
+	mine := threads.ByStudent(1)
+	if len(mine) != 2 || len(mine[0].Messages) != 1 || mine[0].Messages[0].Text != "Why not 'hound'?" {
+		t.Errorf("Expected Anna's two questions only, got %+v", mine)
+	}
+
+	erased, err := threads.Erase(1)
+	if err != nil || erased != 2 {
+		t.Fatalf("Expected 2 messages erased, got %d, %v", erased, err)
+	}
+	reopened, _ := OpenThreads(path)
+	all := reopened.All()
+	if len(all) != 1 || len(all[0].Messages) != 2 || all[0].Messages[0].Author != "Teacher" {
+		t.Errorf("Expected one thread without Anna's messages, got %+v", all)
+	}
+	if erased, err := reopened.Erase(1); erased != 0 || err != nil {
+		t.Errorf("Expected nothing left to erase, got %d, %v", erased, err)
+	}
+}
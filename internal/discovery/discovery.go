@@ -0,0 +1,493 @@
+// Package discovery announces classroom servers on the local network over
+// multicast DNS (DNS-SD, as Bonjour and Avahi do) and finds them, so
+// students pick their teacher's session from a list instead of typing an
+// address.
+//
+// Servers are announced as instances of ServiceType. Browse asks with
+// one-shot ("legacy unicast") queries, which responders answer directly,
+// so browsing needs no access to port 5353.
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// ServiceType is the DNS-SD service type classroom servers are announced as
+const ServiceType = "_recuerdo._tcp"
+
+const (
+	// domain is the domain of multicast DNS names
+	domain = "local."
+	// recordTTL is how long announced records may be cached, in seconds
+	recordTTL = 120
+	// unicastTTL bounds the TTL of answers to one-shot queries, as RFC 6762
+	// asks
+	unicastTTL = 10
+	// queryInterval is how often Browse asks again while it runs
+	queryInterval = time.Second
+	// maxLabel is the longest DNS label
+	maxLabel = 63
+)
+
+// mdnsAddr is the multicast DNS group
+var mdnsAddr = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// Service is a classroom server announced on the local network
+type Service struct {
+	// Name is the instance name shown to students, such as "Room 12"
+	Name string
+	// Host is the host name the server runs on, without ".local"
+	Host string
+	Port int
+	// Addrs are the addresses the server was found at, the one it answered
+	// from first
+	Addrs []net.IP
+	// Text holds the key=value pairs of the TXT record
+	Text map[string]string
+}
+
+// URL returns the base URL of the server, "" when it has no address. A
+// server announcing "tls=1" in its TXT record speaks HTTPS.
+func (s Service) URL() string {
+	if len(s.Addrs) == 0 {
+		return ""
+	}
+	scheme := "http://"
+	if s.Text["tls"] == "1" {
+		scheme = "https://"
+	}
+	return scheme + net.JoinHostPort(s.Addrs[0].String(), strconv.Itoa(s.Port))
+}
+
+// Advertiser announces a service on the local network and answers queries
+// for it until closed
+type Advertiser struct {
+	conn *net.UDPConn
+	// group is where announcements and answers to multicast queries go
+	group *net.UDPAddr
+
+	mu      sync.Mutex
+	service Service
+	closed  bool
+	done    chan struct{}
+}
+
+// Advertise announces service on the local network. The host name defaults
+// to the name of this machine and the addresses to those of its network
+// interfaces.
+func Advertise(service Service) (*Advertiser, error) {
+	conn, err := net.ListenMulticastUDP("udp4", nil, mdnsAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to join the multicast DNS group: %w", err)
+	}
+	return newAdvertiser(conn, mdnsAddr, service), nil
+}
+
+// newAdvertiser answers the queries arriving on conn, sending announcements
+// and answers to multicast queries to group
+func newAdvertiser(conn *net.UDPConn, group *net.UDPAddr, service Service) *Advertiser {
+	if service.Host == "" {
+		service.Host, _ = os.Hostname()
+	}
+	service.Name = label(service.Name)
+	service.Host = label(strings.TrimSuffix(strings.SplitN(service.Host, ".", 2)[0], "."))
+	if service.Host == "" {
+		service.Host = "recuerdo"
+	}
+	if service.Name == "" {
+		service.Name = service.Host
+	}
+	a := &Advertiser{conn: conn, group: group, service: service, done: make(chan struct{})}
+	go a.serve()
+	a.announce(recordTTL)
+	return a
+}
+
+// SetText replaces the TXT record of the service and announces the change
+func (a *Advertiser) SetText(text map[string]string) {
+	a.mu.Lock()
+	a.service.Text = text
+	a.mu.Unlock()
+	a.announce(recordTTL)
+}
+
+// Close says goodbye, so browsers forget the service, and stops answering
+func (a *Advertiser) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.mu.Unlock()
+	a.announce(0)
+	a.mu.Lock()
+	a.closed = true
+	a.mu.Unlock()
+	err := a.conn.Close()
+	<-a.done
+	return err
+}
+
+// serve answers the queries for the service
+func (a *Advertiser) serve() {
+	defer close(a.done)
+	buffer := make([]byte, 9000)
+	for {
+		n, from, err := a.conn.ReadFromUDP(buffer)
+		if err != nil {
+			a.mu.Lock()
+			closed := a.closed
+			a.mu.Unlock()
+			if closed {
+				return
+			}
+			// Reading fails for good once the socket is gone
+			if ne, ok := err.(net.Error); ok && !ne.Timeout() {
+				return
+			}
+			continue
+		}
+		var query dnsmessage.Message
+		if err := query.Unpack(buffer[:n]); err != nil || query.Response {
+			continue
+		}
+		// Queries from another port than 5353 are one-shot and answered
+		// directly; the others for everyone to cache
+		unicast := from.Port != mdnsAddr.Port
+		a.mu.Lock()
+		response := a.service.answer(query, localAddrs(), unicast)
+		a.mu.Unlock()
+		if response == nil {
+			continue
+		}
+		packet, err := response.Pack()
+		if err != nil {
+			continue
+		}
+		to := a.group
+		if unicast {
+			to = from
+		}
+		a.conn.WriteToUDP(packet, to)
+	}
+}
+
+// announce sends the records of the service unasked, with ttl 0 to say
+// goodbye
+func (a *Advertiser) announce(ttl uint32) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return
+	}
+	response := &dnsmessage.Message{Header: dnsmessage.Header{Response: true, Authoritative: true}}
+	a.service.addRecords(response, localAddrs(), ttl)
+	if packet, err := response.Pack(); err == nil {
+		a.conn.WriteToUDP(packet, a.group)
+	}
+}
+
+// answer returns the response to a query, nil when it asks nothing about
+// the service
+func (s Service) answer(query dnsmessage.Message, addrs []net.IP, unicast bool) *dnsmessage.Message {
+	asked := false
+	for _, question := range query.Questions {
+		name := strings.ToLower(question.Name.String())
+		switch {
+		case name == strings.ToLower(typeName()) && (question.Type == dnsmessage.TypePTR || question.Type == dnsmessage.TypeALL):
+			asked = true
+		case name == strings.ToLower(s.instanceName()) || name == strings.ToLower(s.hostName()):
+			asked = true
+		}
+	}
+	if !asked {
+		return nil
+	}
+
+	response := &dnsmessage.Message{Header: dnsmessage.Header{Response: true, Authoritative: true}}
+	ttl := uint32(recordTTL)
+	if unicast {
+		// One-shot queriers match the answer by ID and question
+		response.ID = query.ID
+		response.Questions = query.Questions
+		ttl = unicastTTL
+	}
+	s.addRecords(response, addrs, ttl)
+	return response
+}
+
+// addRecords adds the PTR, SRV, TXT and A records of the service
+func (s Service) addRecords(response *dnsmessage.Message, addrs []net.IP, ttl uint32) {
+	instance := dnsmessage.MustNewName(s.instanceName())
+	host := dnsmessage.MustNewName(s.hostName())
+	header := func(name dnsmessage.Name, class dnsmessage.Class) dnsmessage.ResourceHeader {
+		return dnsmessage.ResourceHeader{Name: name, Class: class, TTL: ttl}
+	}
+	// The top bit of the class tells caches to replace what they hold
+	flush := dnsmessage.ClassINET | 1<<15
+
+	response.Answers = append(response.Answers, dnsmessage.Resource{
+		Header: header(dnsmessage.MustNewName(typeName()), dnsmessage.ClassINET),
+		Body:   &dnsmessage.PTRResource{PTR: instance},
+	})
+	response.Additionals = append(response.Additionals,
+		dnsmessage.Resource{
+			Header: header(instance, flush),
+			Body:   &dnsmessage.SRVResource{Port: uint16(s.Port), Target: host},
+		},
+		dnsmessage.Resource{
+			Header: header(instance, flush),
+			Body:   &dnsmessage.TXTResource{TXT: s.txt()},
+		},
+	)
+	for _, addr := range addrs {
+		if ip4 := addr.To4(); ip4 != nil {
+			var a [4]byte
+			copy(a[:], ip4)
+			response.Additionals = append(response.Additionals, dnsmessage.Resource{
+				Header: header(host, flush),
+				Body:   &dnsmessage.AResource{A: a},
+			})
+		}
+	}
+}
+
+// txt returns the strings of the TXT record, sorted by key; a TXT record
+// holds at least one string
+func (s Service) txt() []string {
+	keys := make([]string, 0, len(s.Text))
+	for key := range s.Text {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	txt := make([]string, 0, len(keys))
+	for _, key := range keys {
+		txt = append(txt, key+"="+s.Text[key])
+	}
+	if len(txt) == 0 {
+		txt = append(txt, "")
+	}
+	return txt
+}
+
+func (s Service) instanceName() string {
+	return s.Name + "." + typeName()
+}
+
+func (s Service) hostName() string {
+	return s.Host + "." + domain
+}
+
+func typeName() string {
+	return ServiceType + "." + domain
+}
+
+// label makes a name fit in one DNS label
+func label(name string) string {
+	name = strings.ReplaceAll(strings.TrimSpace(name), ".", "-")
+	if len(name) > maxLabel {
+		name = name[:maxLabel]
+	}
+	return name
+}
+
+// localAddrs returns the IPv4 addresses of this machine, loopback only if
+// there are no others
+func localAddrs() []net.IP {
+	var addrs, loopback []net.IP
+	interfaceAddrs, _ := net.InterfaceAddrs()
+	for _, addr := range interfaceAddrs {
+		network, ok := addr.(*net.IPNet)
+		if !ok || network.IP.To4() == nil {
+			continue
+		}
+		if network.IP.IsLoopback() {
+			loopback = append(loopback, network.IP)
+		} else {
+			addrs = append(addrs, network.IP)
+		}
+	}
+	if len(addrs) == 0 {
+		return loopback
+	}
+	return addrs
+}
+
+// Browse asks the local network for classroom servers until ctx is done
+// and returns those that answered, by name
+func Browse(ctx context.Context) ([]Service, error) {
+	return browse(ctx, mdnsAddr)
+}
+
+// browse sends one-shot queries to target until ctx is done
+func browse(ctx context.Context, target *net.UDPAddr) ([]Service, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a socket to browse with: %w", err)
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		ticker := time.NewTicker(queryInterval)
+		defer ticker.Stop()
+		for {
+			query, err := newQuery().Pack()
+			if err == nil {
+				conn.WriteToUDP(query, target)
+			}
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			case <-ctx.Done():
+				conn.Close()
+				return
+			}
+		}
+	}()
+
+	found := newRecords()
+	buffer := make([]byte, 9000)
+	for {
+		n, from, err := conn.ReadFromUDP(buffer)
+		if err != nil {
+			if ctx.Err() != nil {
+				return found.services(), nil
+			}
+			conn.Close()
+			return found.services(), err
+		}
+		var response dnsmessage.Message
+		if err := response.Unpack(buffer[:n]); err != nil || !response.Response {
+			continue
+		}
+		found.add(response, from.IP)
+	}
+}
+
+// newQuery returns a query for the instances of ServiceType
+func newQuery() *dnsmessage.Message {
+	return &dnsmessage.Message{
+		Header: dnsmessage.Header{ID: uint16(time.Now().UnixNano())},
+		Questions: []dnsmessage.Question{{
+			Name:  dnsmessage.MustNewName(typeName()),
+			Type:  dnsmessage.TypePTR,
+			Class: dnsmessage.ClassINET,
+		}},
+	}
+}
+
+// records collects the records of the responses to Browse
+type records struct {
+	// instances are the instances of ServiceType by lower case name
+	instances map[string]instance
+	srv       map[string]dnsmessage.SRVResource
+	txt       map[string][]string
+	hosts     map[string][]net.IP
+	// gone are the instances that said goodbye
+	gone map[string]bool
+}
+
+// instance is an instance of ServiceType found, with the address that told
+// about it
+type instance struct {
+	name   string
+	source net.IP
+}
+
+func newRecords() *records {
+	return &records{
+		instances: make(map[string]instance),
+		srv:       make(map[string]dnsmessage.SRVResource),
+		txt:       make(map[string][]string),
+		hosts:     make(map[string][]net.IP),
+		gone:      make(map[string]bool),
+	}
+}
+
+// add takes in the records of a response that came from source
+func (r *records) add(response dnsmessage.Message, source net.IP) {
+	resources := append(append([]dnsmessage.Resource(nil), response.Answers...), response.Additionals...)
+	for _, resource := range resources {
+		name := strings.ToLower(resource.Header.Name.String())
+		switch body := resource.Body.(type) {
+		case *dnsmessage.PTRResource:
+			if name != strings.ToLower(typeName()) {
+				continue
+			}
+			key := strings.ToLower(body.PTR.String())
+			if resource.Header.TTL == 0 {
+				r.gone[key] = true
+				continue
+			}
+			delete(r.gone, key)
+			if _, ok := r.instances[key]; !ok {
+				name := strings.TrimSuffix(body.PTR.String(), "."+typeName())
+				r.instances[key] = instance{name: name, source: source}
+			}
+		case *dnsmessage.SRVResource:
+			r.srv[name] = *body
+		case *dnsmessage.TXTResource:
+			r.txt[name] = body.TXT
+		case *dnsmessage.AResource:
+			ip := net.IP(append([]byte(nil), body.A[:]...))
+			if !containsIP(r.hosts[name], ip) {
+				r.hosts[name] = append(r.hosts[name], ip)
+			}
+		}
+	}
+}
+
+// services returns the services complete enough to connect to
+func (r *records) services() []Service {
+	var services []Service
+	for key, found := range r.instances {
+		srv, ok := r.srv[key]
+		if !ok || r.gone[key] {
+			continue
+		}
+		host := strings.ToLower(srv.Target.String())
+		service := Service{
+			Name: found.name,
+			Host: strings.TrimSuffix(host, "."+domain),
+			Port: int(srv.Port),
+			Text: make(map[string]string),
+		}
+		// The address that answered is reachable from here for sure
+		if found.source != nil {
+			service.Addrs = append(service.Addrs, found.source)
+		}
+		for _, ip := range r.hosts[host] {
+			if !containsIP(service.Addrs, ip) {
+				service.Addrs = append(service.Addrs, ip)
+			}
+		}
+		for _, pair := range r.txt[key] {
+			if key, value, ok := strings.Cut(pair, "="); ok && key != "" {
+				service.Text[strings.ToLower(key)] = value
+			}
+		}
+		services = append(services, service)
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	return services
+}
+
+func containsIP(ips []net.IP, ip net.IP) bool {
+	for _, other := range ips {
+		if other.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package discovery
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestAdvertiseAndBrowse(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Skipf("No UDP on loopback: %v", err)
+	}
+	// Announcements go nowhere in particular
+	sink, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	advertiser := newAdvertiser(conn, sink.LocalAddr().(*net.UDPAddr), Service{
+		Name: "Room 12. Spanish",
+		Host: "teacher-laptop.local",
+		Port: 8080,
+		Text: map[string]string{"live": "0"},
+	})
+	defer advertiser.Close()
+
+	browseFor := func() []Service {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+		defer cancel()
+		services, err := browse(ctx, conn.LocalAddr().(*net.UDPAddr))
+		if err != nil {
+			t.Fatalf("Browse failed: %v", err)
+		}
+		return services
+	}
+	services := browseFor()
+	if len(services) != 1 {
+		t.Fatalf("Expected one service, got %+v", services)
+	}
+	service := services[0]
+	if service.Name != "Room 12- Spanish" || service.Host != "teacher-laptop" || service.Port != 8080 || service.Text["live"] != "0" {
+		t.Errorf("Unexpected service %+v", service)
+	}
+	if service.URL() != "http://127.0.0.1:8080" {
+		t.Errorf("URL = %q; want the address that answered", service.URL())
+	}
+
+	advertiser.SetText(map[string]string{"live": "1"})
+	if services := browseFor(); len(services) != 1 || services[0].Text["live"] != "1" {
+		t.Errorf("Expected the new TXT record, got %+v", services)
+	}
+}
+
+func TestGoodbye(t *testing.T) {
+	found := newRecords()
+	service := Service{Name: "Room 12", Host: "laptop", Port: 8080}
+	source := net.IPv4(192, 168, 1, 20)
+	found.add(*service.answer(*newQuery(), []net.IP{net.IPv4(10, 0, 0, 2)}, true), source)
+	services := found.services()
+	if len(services) != 1 || len(services[0].Addrs) != 2 || !services[0].Addrs[0].Equal(source) {
+		t.Fatalf("Expected the service at both addresses, got %+v", services)
+	}
+
+	goodbye := newQuery()
+	goodbye.Questions = nil
+	goodbye.Response = true
+	service.addRecords(goodbye, nil, 0)
+	found.add(*goodbye, source)
+	if services := found.services(); len(services) != 0 {
+		t.Errorf("Expected the service gone after its goodbye, got %+v", services)
+	}
+}
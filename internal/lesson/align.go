@@ -0,0 +1,212 @@
+package lesson
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Sentence mining works from a text and its translation: AlignTexts splits
+// both into sentences and pairs them up by length, after Gale and Church
+// (1993). Translations mostly keep the sentences of the original, but now
+// and then join two into one or split one in two, so besides single pairs
+// a sentence may go with two, or be left without a counterpart. The
+// lengths are compared after scaling by how much longer the translation
+// is overall, as some languages take more characters than others.
+
+// SentencePair is a sentence of a text and its translation. Either side
+// holds two sentences when the other joined them into one, and is empty
+// when the other has nothing for it.
+type SentencePair struct {
+	Source string
+	Target string
+}
+
+// paragraphBreak separates paragraphs, which always end a sentence
+var paragraphBreak = regexp.MustCompile(`\n[ \t\r]*\n`)
+
+// abbreviations end in a full stop without ending the sentence
+var abbreviations = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "dr": true, "prof": true, "st": true,
+	"sr": true, "sra": true, "srta": true, "jr": true, "vs": true, "no": true,
+	"e.g": true, "i.e": true, "z.b": true, "bzw": true, "ca": true, "p": true,
+}
+
+// SplitSentences splits a text into its sentences, at full stops,
+// question and exclamation marks followed by a new sentence, and at blank
+// lines
+func SplitSentences(text string) []string {
+	var sentences []string
+	for _, paragraph := range paragraphBreak.Split(strings.ReplaceAll(text, "\r\n", "\n"), -1) {
+		runes := []rune(strings.Join(strings.Fields(paragraph), " "))
+		start := 0
+		for i := 0; i < len(runes); i++ {
+			if !strings.ContainsRune(".!?…。！？", runes[i]) {
+				continue
+			}
+			end := i + 1
+			for end < len(runes) && strings.ContainsRune(".!?…\"'”’)]»」』", runes[end]) {
+				end++
+			}
+			i = end - 1
+			// Sentences of Chinese and Japanese are not followed by a space
+			cjk := strings.ContainsRune("。！？", runes[end-1])
+			if !cjk && (end < len(runes) && runes[end] != ' ' || !startsSentence(runes[end:]) || isAbbreviation(runes[start:end])) {
+				continue
+			}
+			if sentence := strings.TrimSpace(string(runes[start:end])); sentence != "" {
+				sentences = append(sentences, sentence)
+			}
+			start = end
+		}
+		if sentence := strings.TrimSpace(string(runes[start:])); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+	}
+	return sentences
+}
+
+// startsSentence tells whether rest, the text after a full stop, can start
+// a new sentence: it is empty or starts with something else than a small
+// letter
+func startsSentence(rest []rune) bool {
+	for _, r := range rest {
+		if r == ' ' || strings.ContainsRune("\"'“‘(¿¡«", r) {
+			continue
+		}
+		return !unicode.IsLower(r)
+	}
+	return true
+}
+
+// isAbbreviation tells whether sentence ends in an abbreviation or an
+// initial instead of a full stop
+func isAbbreviation(sentence []rune) bool {
+	text := string(sentence)
+	if !strings.HasSuffix(text, ".") {
+		return false
+	}
+	words := strings.Fields(strings.TrimSuffix(text, "."))
+	if len(words) == 0 {
+		return false
+	}
+	word := strings.ToLower(words[len(words)-1])
+	return abbreviations[word] || len([]rune(word)) == 1 && unicode.IsLetter([]rune(word)[0])
+}
+
+// alignmentBead is a way sentences go together, with how often
+// translations do that
+type alignmentBead struct {
+	source, target int
+	prior          float64
+}
+
+var alignmentBeads = []alignmentBead{
+	{1, 1, 0.89},
+	{1, 0, 0.0099},
+	{0, 1, 0.0099},
+	{2, 1, 0.089},
+	{1, 2, 0.089},
+	{2, 2, 0.011},
+}
+
+// AlignSentences pairs the sentences of a text with those of its
+// translation, keeping their order
+func AlignSentences(source, target []string) []SentencePair {
+	sourceLengths, sourceTotal := sentenceLengths(source)
+	targetLengths, targetTotal := sentenceLengths(target)
+	ratio := 1.0
+	if sourceTotal > 0 && targetTotal > 0 {
+		ratio = float64(targetTotal) / float64(sourceTotal)
+	}
+
+	// cost[i][j] is the cost of the best alignment of the first i source
+	// and j target sentences, reached through bead[i][j]
+	cost := make([][]float64, len(source)+1)
+	bead := make([][]int, len(source)+1)
+	for i := range cost {
+		cost[i] = make([]float64, len(target)+1)
+		bead[i] = make([]int, len(target)+1)
+		for j := range cost[i] {
+			cost[i][j] = math.Inf(1)
+		}
+	}
+	cost[0][0] = 0
+	for i := 0; i <= len(source); i++ {
+		for j := 0; j <= len(target); j++ {
+			for b, candidate := range alignmentBeads {
+				fromI, fromJ := i-candidate.source, j-candidate.target
+				if fromI < 0 || fromJ < 0 || math.IsInf(cost[fromI][fromJ], 1) {
+					continue
+				}
+				sourceLength := sourceLengths[i] - sourceLengths[fromI]
+				targetLength := targetLengths[j] - targetLengths[fromJ]
+				total := cost[fromI][fromJ] - math.Log(candidate.prior) + lengthCost(sourceLength, targetLength, ratio)
+				if total < cost[i][j] {
+					cost[i][j], bead[i][j] = total, b
+				}
+			}
+		}
+	}
+
+	var pairs []SentencePair
+	for i, j := len(source), len(target); i > 0 || j > 0; {
+		candidate := alignmentBeads[bead[i][j]]
+		pairs = append(pairs, SentencePair{
+			Source: strings.Join(source[i-candidate.source:i], " "),
+			Target: strings.Join(target[j-candidate.target:j], " "),
+		})
+		i, j = i-candidate.source, j-candidate.target
+	}
+	for left, right := 0, len(pairs)-1; left < right; left, right = left+1, right-1 {
+		pairs[left], pairs[right] = pairs[right], pairs[left]
+	}
+	return pairs
+}
+
+// sentenceLengths returns the running total of the lengths of sentences,
+// starting from 0, and their total
+func sentenceLengths(sentences []string) ([]int, int) {
+	lengths := make([]int, len(sentences)+1)
+	for i, sentence := range sentences {
+		lengths[i+1] = lengths[i] + len([]rune(sentence))
+	}
+	return lengths, lengths[len(sentences)]
+}
+
+// lengthCost is how unlikely a text of sourceLength characters translates
+// to one of targetLength, when translations are ratio times as long with a
+// variance growing with the length
+func lengthCost(sourceLength, targetLength int, ratio float64) float64 {
+	if sourceLength == 0 && targetLength == 0 {
+		return 0
+	}
+	mean := (float64(sourceLength) + float64(targetLength)/ratio) / 2
+	delta := (float64(targetLength) - float64(sourceLength)*ratio) / math.Sqrt(6.8*mean)
+	probability := math.Erfc(math.Abs(delta) / math.Sqrt2)
+	return -math.Log(math.Max(probability, 1e-300))
+}
+
+// AlignTexts pairs the sentences of original with those of translation
+// into a draft of a lesson of sentence pairs, to review before accepting.
+// Sentences without a counterpart are not included by default.
+func AlignTexts(original, translation, title string) (*ImportDraft, error) {
+	source, target := SplitSentences(original), SplitSentences(translation)
+	if len(source) == 0 {
+		return nil, fmt.Errorf("the original text has no sentences")
+	}
+	if len(target) == 0 {
+		return nil, fmt.Errorf("the translation has no sentences")
+	}
+	draft := &ImportDraft{Title: title, Source: "align", Sentences: true}
+	for _, pair := range AlignSentences(source, target) {
+		draft.Rows = append(draft.Rows, ImportRow{
+			Question: pair.Source,
+			Answer:   pair.Target,
+			Include:  pair.Source != "" && pair.Target != "",
+		})
+	}
+	return draft, nil
+}
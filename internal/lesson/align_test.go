@@ -0,0 +1,69 @@
+package lesson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitSentences(t *testing.T) {
+	text := "Mr. Smith lives in London. He works at 3.5 km from home, e.g. in an office!\n" +
+		"Does he like it? \"Yes.\" he says…\n\nA new paragraph without a full stop\n\n" +
+		"我很好。你呢？"
+	want := []string{
+		"Mr. Smith lives in London.",
+		"He works at 3.5 km from home, e.g. in an office!",
+		"Does he like it?",
+		"\"Yes.\" he says…",
+		"A new paragraph without a full stop",
+		"我很好。",
+		"你呢？",
+	}
+	if got := SplitSentences(text); !reflect.DeepEqual(got, want) {
+		t.Errorf("SplitSentences() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestAlignTexts(t *testing.T) {
+	// The translation joins the second and third sentence and splits the
+	// last one
+	original := "The old man lived alone by the sea. Every morning he went out. He took his boat and his nets. " +
+		"He came back in the evening with fish for the village, which waited for him on the beach."
+	translation := "El viejo vivía solo junto al mar. Cada mañana salía con su barca y sus redes. " +
+		"Volvía por la tarde con pescado para el pueblo. Este lo esperaba en la playa."
+
+	draft, err := AlignTexts(original, translation, "The Old Man")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []SentencePair{
+		{"The old man lived alone by the sea.", "El viejo vivía solo junto al mar."},
+		{"Every morning he went out. He took his boat and his nets.", "Cada mañana salía con su barca y sus redes."},
+		{"He came back in the evening with fish for the village, which waited for him on the beach.", "Volvía por la tarde con pescado para el pueblo. Este lo esperaba en la playa."},
+	}
+	if len(draft.Rows) != len(want) {
+		t.Fatalf("Expected %d pairs, got %+v", len(want), draft.Rows)
+	}
+	for i, row := range draft.Rows {
+		if row.Question != want[i].Source || row.Answer != want[i].Target {
+			t.Errorf("Pair %d = %q / %q; want %q / %q", i, row.Question, row.Answer, want[i].Source, want[i].Target)
+		}
+	}
+
+	// Sentences are kept whole, commas and all
+	lessonData := draft.ToLessonData()
+	if len(lessonData.List.Items) != 3 || lessonData.List.Title != "The Old Man" {
+		t.Fatalf("Expected three sentence pairs, got %+v", lessonData.List)
+	}
+	if answers := lessonData.List.Items[2].Questions; len(answers) != 1 {
+		t.Errorf("Expected the sentence to stay whole, got %q", answers)
+	}
+
+	if _, err := AlignTexts(original, " \n ", "Empty"); err == nil {
+		t.Error("Expected an empty translation to be refused")
+	}
+
+	// A sentence without a counterpart is paired with nothing
+	if pairs := AlignSentences([]string{"Hello."}, nil); len(pairs) != 1 || pairs[0].Target != "" {
+		t.Errorf("Expected the sentence alone, got %+v", pairs)
+	}
+}
@@ -0,0 +1,280 @@
+package lesson
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// StemmerResourceKey is the LessonData.Resources key that sets the language
+// whose stemmer checks answers, for lessons whose answer language name is not
+// recognised. "none" turns stemming off for the lesson.
+const StemmerResourceKey = "answerStemmer"
+
+// Settings keys holding the answer tolerance of lessons without an option
+// preset
+const (
+	SettingIgnoreCase        = "answers.ignoreCase"
+	SettingIgnoreAccents     = "answers.ignoreAccents"
+	SettingIgnorePunctuation = "answers.ignorePunctuation"
+	SettingMaxTypos          = "answers.maxTypos"
+	SettingSwapsAsOneTypo    = "answers.swapsAsOneTypo"
+	SettingArticles          = "answers.articles"
+	SettingRequiredAnswers   = "answers.required"
+)
+
+// ToleranceSettings is the part of the settings module the default answer
+// tolerance is read from and written to
+type ToleranceSettings interface {
+	GetSettingWithDefault(key string, defaultValue interface{}) interface{}
+	SetSetting(key string, value interface{}) error
+}
+
+var (
+	defaultTolerance      = AnswerTolerance{IgnoreCase: true}
+	defaultToleranceMutex sync.RWMutex
+)
+
+// DefaultTolerance returns the answer tolerance of lessons without an option
+// preset
+func DefaultTolerance() AnswerTolerance {
+	defaultToleranceMutex.RLock()
+	defer defaultToleranceMutex.RUnlock()
+	return defaultTolerance
+}
+
+// SetDefaultTolerance changes the answer tolerance of lessons without an
+// option preset
+func SetDefaultTolerance(tolerance AnswerTolerance) {
+	defaultToleranceMutex.Lock()
+	defer defaultToleranceMutex.Unlock()
+	tolerance.MaxTypos = max(0, tolerance.MaxTypos)
+	defaultTolerance = tolerance
+}
+
+// LoadDefaultTolerance makes the tolerance stored in settings the default,
+// keeping the current default for settings that are missing
+func LoadDefaultTolerance(settings ToleranceSettings) AnswerTolerance {
+	tolerance := DefaultTolerance()
+	tolerance.IgnoreCase = settingBool(settings, SettingIgnoreCase, tolerance.IgnoreCase)
+	tolerance.IgnoreAccents = settingBool(settings, SettingIgnoreAccents, tolerance.IgnoreAccents)
+	tolerance.IgnorePunctuation = settingBool(settings, SettingIgnorePunctuation, tolerance.IgnorePunctuation)
+	tolerance.SwapsAsOneTypo = settingBool(settings, SettingSwapsAsOneTypo, tolerance.SwapsAsOneTypo)
+	if articles, ok := settings.GetSettingWithDefault(SettingArticles, tolerance.Articles).(string); ok {
+		tolerance.Articles = articles
+	}
+	if required, ok := settings.GetSettingWithDefault(SettingRequiredAnswers, tolerance.Required).(string); ok {
+		tolerance.Required = required
+	}
+	switch typos := settings.GetSettingWithDefault(SettingMaxTypos, tolerance.MaxTypos).(type) {
+	case int:
+		tolerance.MaxTypos = typos
+	case float64:
+		// JSON unmarshaling creates float64 for numbers
+		tolerance.MaxTypos = int(typos)
+	}
+	SetDefaultTolerance(tolerance)
+	return DefaultTolerance()
+}
+
+// SaveDefaultTolerance makes tolerance the default and stores it in settings
+func SaveDefaultTolerance(settings ToleranceSettings, tolerance AnswerTolerance) error {
+	SetDefaultTolerance(tolerance)
+	tolerance = DefaultTolerance()
+	values := map[string]interface{}{
+		SettingIgnoreCase:        tolerance.IgnoreCase,
+		SettingIgnoreAccents:     tolerance.IgnoreAccents,
+		SettingIgnorePunctuation: tolerance.IgnorePunctuation,
+		SettingMaxTypos:          tolerance.MaxTypos,
+		SettingSwapsAsOneTypo:    tolerance.SwapsAsOneTypo,
+		SettingArticles:          tolerance.Articles,
+		SettingRequiredAnswers:   tolerance.Required,
+	}
+	for key, value := range values {
+		if err := settings.SetSetting(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func settingBool(settings ToleranceSettings, key string, defaultValue bool) bool {
+	if value, ok := settings.GetSettingWithDefault(key, defaultValue).(bool); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// AnswerChecker compares typed answers with the expected ones as leniently
+// as an AnswerTolerance allows
+type AnswerChecker struct {
+	Tolerance AnswerTolerance
+	// Stemmer is used when Tolerance.VerbForms is on; nil compares words as
+	// typed
+	Stemmer Stemmer
+	// Transliterator, if set, writes both the given and the expected answer
+	// in Latin script before they are compared
+	Transliterator Transliterator
+	// Articles are the articles of the answer language, used when
+	// Tolerance.Articles grades them specially
+	Articles []string
+}
+
+// NewAnswerChecker creates a checker for answers in the given language
+func NewAnswerChecker(tolerance AnswerTolerance, language string) *AnswerChecker {
+	checker := &AnswerChecker{Tolerance: tolerance}
+	if tolerance.VerbForms {
+		if stemmer, ok := StemmerFor(language); ok {
+			checker.Stemmer = stemmer
+		} else if language != "none" {
+			log.Printf("[WARNING] No stemmer for answer language %q, verb forms must match exactly", language)
+		}
+	}
+	if tolerance.Articles != ArticlesAsTyped {
+		if words, ok := ArticlesFor(language); ok {
+			checker.Articles = words
+		} else {
+			log.Printf("[WARNING] No articles known for answer language %q, articles are compared as typed", language)
+		}
+	}
+	return checker
+}
+
+// AnswerCheckerFor creates a checker for the answers of a lesson using the
+// options of preset
+func AnswerCheckerFor(lessonData *LessonData, preset OptionPreset) *AnswerChecker {
+	language := lessonData.List.AnswerLanguage
+	if preset.Direction == DirectionReverse {
+		language = lessonData.List.QuestionLanguage
+	}
+	if override, ok := lessonData.Resources[StemmerResourceKey].(string); ok && override != "" {
+		language = override
+	}
+	checker := NewAnswerChecker(preset.Tolerance, language)
+	if name := lessonData.Transliteration(); name != "" {
+		if transliterator, ok := TransliteratorFor(name); ok {
+			checker.Transliterator = transliterator
+		} else {
+			log.Printf("[WARNING] Lesson refers to unknown transliteration %q, answers must match as written", name)
+		}
+	}
+	return checker
+}
+
+// LessonAnswerChecker creates a checker for the answers of a lesson using
+// the options of its preset, or the default options if it has none. Every
+// teach type checks typed answers this way.
+func LessonAnswerChecker(lessonData *LessonData) *AnswerChecker {
+	return AnswerCheckerFor(lessonData, LessonPreset(lessonData))
+}
+
+// LessonPreset returns the options of the preset of a lesson, or the
+// default options if it has none
+func LessonPreset(lessonData *LessonData) OptionPreset {
+	presets := NewPresetStore(DefaultPresetsPath())
+	if err := presets.Load(); err != nil {
+		log.Printf("[WARNING] Failed to load option presets: %v", err)
+	}
+	return presets.PresetFor(lessonData)
+}
+
+// Check reports whether given matches any of the expected answers
+func (c *AnswerChecker) Check(given string, expected []string) bool {
+	return c.Grade(given, expected).Correct
+}
+
+// matches reports whether given matches answer, ignoring the article
+// grading mode
+func (c *AnswerChecker) matches(given, answer string) bool {
+	normalized := c.normalize(given)
+	if normalized == "" {
+		return false
+	}
+	want := c.normalize(answer)
+	return normalized == want || (c.Tolerance.MaxTypos > 0 && c.withinTypos(normalized, want))
+}
+
+// withinTypos allows up to MaxTypos edits, but never so many that a short
+// answer could be replaced by a different word altogether
+func (c *AnswerChecker) withinTypos(given, want string) bool {
+	a, b := []rune(given), []rune(want)
+	var distance int
+	if c.Tolerance.SwapsAsOneTypo {
+		distance = swapDistance(a, b)
+	} else {
+		distance = editDistance(a, b)
+	}
+	return distance <= c.Tolerance.MaxTypos && distance*4 <= len(b)
+}
+
+// swapDistance is the edit distance in which swapping two neighbouring
+// letters is a single edit
+func swapDistance(a, b []rune) int {
+	rows := make([][]int, len(a)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(b)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(a)][len(b)]
+}
+
+// normalize applies the tolerance options and the stemmer to text
+func (c *AnswerChecker) normalize(text string) string {
+	if c.Tolerance.ChineseScripts {
+		text = ToSimplified(text)
+	}
+	if !c.Tolerance.StrictVowelPoints {
+		text = StripVowelPoints(text)
+	}
+	if c.Transliterator != nil {
+		text = c.Transliterator.Transliterate(text)
+	}
+	pinyin := c.Tolerance.Pinyin || c.Tolerance.IgnoreTones
+	if c.Tolerance.IgnoreTones {
+		text = StripPinyinTones(text)
+	} else if pinyin {
+		text = PinyinNumbersToMarks(text)
+	}
+	if c.Tolerance.IgnoreCase {
+		text = strings.ToLower(text)
+	}
+	if c.Tolerance.IgnoreAccents {
+		text = foldAccents(text)
+	}
+	if c.Tolerance.IgnorePunctuation {
+		text = strings.Map(func(r rune) rune {
+			if unicode.IsPunct(r) {
+				return -1
+			}
+			return r
+		}, text)
+	}
+
+	words := strings.Fields(text)
+	if c.Tolerance.VerbForms && c.Stemmer != nil {
+		for i, word := range words {
+			words[i] = c.Stemmer.Stem(word)
+		}
+	}
+	if pinyin {
+		// "nǐhǎo", "nǐ hǎo" and "nǐ'hǎo" are the same answer
+		return strings.ReplaceAll(strings.Join(words, ""), "'", "")
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,148 @@
+package lesson
+
+import "testing"
+
+func TestAnswerCheckerTolerance(t *testing.T) {
+	tests := []struct {
+		name      string
+		tolerance AnswerTolerance
+		given     string
+		expected  string
+		want      bool
+	}{
+		{"exact", AnswerTolerance{}, "huis", "huis", true},
+		{"case", AnswerTolerance{}, "Huis", "huis", false},
+		{"ignore case", AnswerTolerance{IgnoreCase: true}, "Huis", "huis", true},
+		{"accents", AnswerTolerance{}, "cafe", "café", false},
+		{"ignore accents", AnswerTolerance{IgnoreAccents: true}, "cafe", "café", true},
+		{"ignore punctuation", AnswerTolerance{IgnorePunctuation: true}, "hello world", "hello, world!", true},
+		{"two typos", AnswerTolerance{MaxTypos: 1}, "elefant", "elephant", false},
+		{"swap counts twice", AnswerTolerance{MaxTypos: 1}, "elephnat", "elephant", false},
+		{"two typos allowed", AnswerTolerance{MaxTypos: 2}, "elephnat", "elephant", true},
+		{"short words need to match", AnswerTolerance{MaxTypos: 1}, "cat", "car", false},
+		{"swap as one typo", AnswerTolerance{MaxTypos: 1, SwapsAsOneTypo: true}, "recieve", "receive", true},
+		{"swap and a typo", AnswerTolerance{MaxTypos: 1, SwapsAsOneTypo: true}, "recieva", "receive", false},
+		{"everything at once", AnswerTolerance{IgnoreCase: true, IgnoreAccents: true, IgnorePunctuation: true, MaxTypos: 1, SwapsAsOneTypo: true}, "Cafe Recieve!", "café receive", true},
+		{"spaces", AnswerTolerance{}, "  de   kat ", "de kat", true},
+		{"empty", AnswerTolerance{}, "", "", false},
+	}
+	for _, tt := range tests {
+		checker := NewAnswerChecker(tt.tolerance, "")
+		if got := checker.Check(tt.given, []string{tt.expected}); got != tt.want {
+			t.Errorf("%s: Check(%q, %q) = %v; want %v", tt.name, tt.given, tt.expected, got, tt.want)
+		}
+	}
+}
+
+func TestAnswerCheckerVerbForms(t *testing.T) {
+	lessonData := NewLessonData()
+	lessonData.List.AnswerLanguage = "Dutch"
+	preset := DefaultOptionPreset()
+
+	if AnswerCheckerFor(lessonData, preset).Check("ging", []string{"gaan"}) {
+		t.Error("Verb forms should not match when the option is off")
+	}
+
+	preset.Tolerance.VerbForms = true
+	checker := AnswerCheckerFor(lessonData, preset)
+	if !checker.Check("ik ging", []string{"ik gaan"}) {
+		t.Error("Expected 'ik ging' to match 'ik gaan'")
+	}
+	if checker.Check("ik liep", []string{"ik gaan"}) {
+		t.Error("A different verb should not match")
+	}
+
+	// A lesson can pick or turn off the stemmer itself
+	lessonData.List.AnswerLanguage = "Vlaams"
+	lessonData.Resources[StemmerResourceKey] = "nl"
+	if !AnswerCheckerFor(lessonData, preset).Check("werkte", []string{"werken"}) {
+		t.Error("Expected the stemmer picked by the lesson to be used")
+	}
+	lessonData.Resources[StemmerResourceKey] = "none"
+	if AnswerCheckerFor(lessonData, preset).Check("werkte", []string{"werken"}) {
+		t.Error("Expected no stemming when turned off for the lesson")
+	}
+}
+
+// mapSettings stores settings like the settings module does after loading
+// them from JSON
+type mapSettings map[string]interface{}
+
+func (m mapSettings) GetSettingWithDefault(key string, defaultValue interface{}) interface{} {
+	if value, ok := m[key]; ok {
+		return value
+	}
+	return defaultValue
+}
+
+func (m mapSettings) SetSetting(key string, value interface{}) error {
+	m[key] = value
+	return nil
+}
+
+func TestDefaultToleranceSettings(t *testing.T) {
+	defer SetDefaultTolerance(DefaultTolerance())
+
+	settings := mapSettings{SettingMaxTypos: float64(2), SettingIgnoreAccents: true}
+	tolerance := LoadDefaultTolerance(settings)
+	if !tolerance.IgnoreCase || !tolerance.IgnoreAccents || tolerance.MaxTypos != 2 {
+		t.Errorf("LoadDefaultTolerance() = %+v; want case and accents ignored and 2 typos", tolerance)
+	}
+	if DefaultOptionPreset().Tolerance != tolerance {
+		t.Error("Lessons without a preset should use the tolerance from the settings")
+	}
+
+	if err := SaveDefaultTolerance(settings, AnswerTolerance{MaxTypos: -1, SwapsAsOneTypo: true}); err != nil {
+		t.Fatal(err)
+	}
+	if settings[SettingMaxTypos] != 0 || settings[SettingIgnoreCase] != false || settings[SettingSwapsAsOneTypo] != true {
+		t.Errorf("SaveDefaultTolerance() stored %v", settings)
+	}
+}
+
+func TestAnswerCheckerArticles(t *testing.T) {
+	tests := []struct {
+		mode     string
+		language string
+		given    string
+		expected string
+		credit   float64
+	}{
+		{ArticlesAsTyped, "nl", "kat", "de kat", 0},
+		{ArticlesOptional, "nl", "kat", "de kat", 1},
+		{ArticlesOptional, "nl", "de kat", "de kat", 1},
+		{ArticlesOptional, "nl", "het kat", "de kat", 0},
+		{ArticlesRequired, "nl", "kat", "de kat", 0},
+		{ArticlesRequired, "Dutch", "De kat", "de kat", 1},
+		{ArticlesRequired, "de", "das Haus", "das Haus", 1},
+		{ArticlesRequired, "de", "die Haus", "das Haus", 0},
+		{ArticlesSeparate, "de", "der Haus", "das Haus", ArticleCredit},
+		{ArticlesSeparate, "de", "Haus", "das Haus", ArticleCredit},
+		{ArticlesSeparate, "de", "das Maus", "das Haus", 0},
+		{ArticlesSeparate, "fr", "l'homme", "l’homme", 1},
+		{ArticlesSeparate, "fr", "la homme", "l'homme", ArticleCredit},
+		{ArticlesRequired, "nl", "lopen", "lopen", 1},
+	}
+	for _, tt := range tests {
+		checker := NewAnswerChecker(AnswerTolerance{IgnoreCase: true, Articles: tt.mode}, tt.language)
+		grade := checker.Grade(tt.given, []string{tt.expected})
+		if grade.Credit != tt.credit {
+			t.Errorf("%q/%s: Grade(%q, %q) = %+v; want credit %v", tt.mode, tt.language, tt.given, tt.expected, grade, tt.credit)
+		}
+		if checker.Check(tt.given, []string{tt.expected}) != (tt.credit == 1) {
+			t.Errorf("%q/%s: Check(%q, %q) disagrees with the grade", tt.mode, tt.language, tt.given, tt.expected)
+		}
+	}
+
+	checker := NewAnswerChecker(AnswerTolerance{Articles: ArticlesSeparate}, "nl")
+	if grade := checker.Grade("het kat", []string{"de kat"}); grade.Article != "de" {
+		t.Errorf("Grade should point out the article, got %+v", grade)
+	}
+
+	list := NewWordList()
+	list.AddWordItem([]string{"cat"}, []string{"de kat"}, "")
+	list.AddGradedResult(0, checker.Grade("kat", list.Items[0].Answers))
+	if result := list.Tests[0].Results[0]; result.Result != "wrong" || result.Credit != ArticleCredit {
+		t.Errorf("AddGradedResult() recorded %+v; want a wrong answer with partial credit", result)
+	}
+}
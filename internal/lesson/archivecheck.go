@@ -0,0 +1,157 @@
+package lesson
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// The OpenTeaching archives written by Recuerdo carry a manifest listing
+// the SHA-256 checksum and size of every other entry, so a damaged archive
+// tells which of its parts is damaged instead of failing as a whole.
+// Archives without a manifest, such as those of OpenTeacher, still load;
+// their entries are only checked against the CRC-32 of the ZIP format.
+
+// ArchiveManifestFile is the entry of an OpenTeaching archive holding the
+// checksums of the others
+const ArchiveManifestFile = "manifest.json"
+
+// archiveManifest is the content of ArchiveManifestFile
+type archiveManifest struct {
+	Algorithm string                 `json:"algorithm"`
+	Files     []archiveManifestEntry `json:"files"`
+}
+
+// archiveManifestEntry is the checksum of one entry of an archive
+type archiveManifestEntry struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// archiveWriter writes the entries of an OpenTeaching archive, adding the
+// manifest of their checksums when closed
+type archiveWriter struct {
+	zip     *zip.Writer
+	entries []*checksumWriter
+}
+
+// checksumWriter writes an entry of an archive while taking its checksum
+type checksumWriter struct {
+	name string
+	w    io.Writer
+	hash hash.Hash
+	size int64
+}
+
+func (cw *checksumWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.hash.Write(p[:n])
+	cw.size += int64(n)
+	return n, err
+}
+
+// newArchiveWriter returns an archiveWriter writing the archive to w
+func newArchiveWriter(w io.Writer) *archiveWriter {
+	return &archiveWriter{zip: zip.NewWriter(w)}
+}
+
+// Create adds an entry to the archive, compressed
+func (aw *archiveWriter) Create(name string) (io.Writer, error) {
+	return aw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate})
+}
+
+// CreateHeader adds an entry to the archive as described by header
+func (aw *archiveWriter) CreateHeader(header *zip.FileHeader) (io.Writer, error) {
+	w, err := aw.zip.CreateHeader(header)
+	if err != nil {
+		return nil, err
+	}
+	entry := &checksumWriter{name: header.Name, w: w, hash: sha256.New()}
+	aw.entries = append(aw.entries, entry)
+	return entry, nil
+}
+
+// Close writes the manifest and finishes the archive
+func (aw *archiveWriter) Close() error {
+	manifest := archiveManifest{Algorithm: "sha256", Files: make([]archiveManifestEntry, 0, len(aw.entries))}
+	for _, entry := range aw.entries {
+		manifest.Files = append(manifest.Files, archiveManifestEntry{
+			Name:   entry.name,
+			Size:   entry.size,
+			SHA256: hex.EncodeToString(entry.hash.Sum(nil)),
+		})
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	w, err := aw.zip.Create(ArchiveManifestFile)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	return aw.zip.Close()
+}
+
+// readArchive reads every entry of an OpenTeaching archive in format and
+// checks them against its manifest, if it has one. It returns an
+// ErrCorruptArchive naming the entry when one is damaged or missing.
+func readArchive(format string, reader *zip.Reader) (map[string][]byte, error) {
+	files := make(map[string][]byte, len(reader.File))
+	for _, file := range reader.File {
+		data, err := readZipFile(file)
+		if err != nil {
+			return nil, damagedEntry(format, file.Name, "is damaged", err)
+		}
+		files[file.Name] = data
+	}
+
+	manifestData, ok := files[ArchiveManifestFile]
+	if !ok {
+		return files, nil
+	}
+	delete(files, ArchiveManifestFile)
+	var manifest archiveManifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return nil, damagedEntry(format, ArchiveManifestFile, "is damaged", err)
+	}
+	if manifest.Algorithm != "sha256" {
+		return nil, damagedEntry(format, ArchiveManifestFile, fmt.Sprintf("uses unknown checksum algorithm %q", manifest.Algorithm), nil)
+	}
+	for _, entry := range manifest.Files {
+		data, ok := files[entry.Name]
+		if !ok {
+			return nil, damagedEntry(format, entry.Name, "is missing", nil)
+		}
+		if problem := checkManifestEntry(entry, data); problem != "" {
+			return nil, damagedEntry(format, entry.Name, "is damaged: "+problem, nil)
+		}
+	}
+	return files, nil
+}
+
+// checkManifestEntry returns what is wrong with the content of an entry
+// given its checksum in the manifest; empty when it is intact
+func checkManifestEntry(entry archiveManifestEntry, data []byte) string {
+	sum := sha256.Sum256(data)
+	switch {
+	case int64(len(data)) != entry.Size:
+		return fmt.Sprintf("%d bytes instead of %d", len(data), entry.Size)
+	case hex.EncodeToString(sum[:]) != entry.SHA256:
+		return "checksum mismatch"
+	}
+	return ""
+}
+
+// damagedEntry returns an ErrCorruptArchive for an entry of an archive in
+// format, such as "list.json is damaged"
+func damagedEntry(format, name, problem string, err error) error {
+	return &ErrCorruptArchive{Format: format, Detail: name + " " + problem, Entry: name, Err: err}
+}
@@ -0,0 +1,100 @@
+package lesson
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// rewriteArchive copies the archive at path, letting change alter or drop
+// (by returning nil) the content of each entry
+func rewriteArchive(t *testing.T, path string, change func(name string, data []byte) []byte) {
+	t.Helper()
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	writer := zip.NewWriter(&out)
+	for _, file := range reader.File {
+		data, err := readZipFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data = change(file.Name, data); data == nil {
+			continue
+		}
+		w, err := writer.Create(file.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+	}
+	reader.Close()
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, out.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestArchiveChecksums(t *testing.T) {
+	lessonData := NewLessonData()
+	lessonData.SetOcclusionImage("heart.png", []byte("\x89PNG fake image data"))
+	lessonData.List.AddOcclusionItem(image.Rect(0, 0, 10, 10), []string{"aorta"})
+	path := filepath.Join(t.TempDir(), "heart.otio")
+	if err := NewFileSaver().SaveFile(lessonData, path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFileLoader().LoadFile(path); err != nil {
+		t.Fatalf("intact archive: %v", err)
+	}
+
+	// A byte changed in the image, with the CRC-32 of the ZIP made to match
+	rewriteArchive(t, path, func(name string, data []byte) []byte {
+		if name == "resources/heart.png" {
+			data[len(data)-1] ^= 1
+		}
+		return data
+	})
+	_, err := NewFileLoader().LoadFile(path)
+	var corrupt *ErrCorruptArchive
+	if !errors.As(err, &corrupt) || corrupt.Entry != "resources/heart.png" || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("damaged image: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recovered, report, err := RecoverLesson(data, ".otio")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(report.String(), "resources/heart.png (20 bytes): checksum mismatch") {
+		t.Errorf("report does not name the damaged image:\n%s", report)
+	}
+	// The recovered archive has a manifest of what it holds
+	if err := os.WriteFile(path, recovered, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFileLoader().LoadFile(path); err != nil {
+		t.Errorf("recovered archive: %v", err)
+	}
+
+	rewriteArchive(t, path, func(name string, data []byte) []byte {
+		if name == "list.json" {
+			return nil
+		}
+		return data
+	})
+	if _, err := NewFileLoader().LoadFile(path); !errors.As(err, &corrupt) || corrupt.Entry != "list.json" {
+		t.Errorf("missing list.json: %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package lesson
+
+import (
+	"strings"
+	"sync"
+)
+
+// Article grading modes for AnswerTolerance.Articles. They only apply to
+// expected answers that start with an article of the answer language.
+const (
+	// ArticlesAsTyped compares the article like any other word
+	ArticlesAsTyped = ""
+	// ArticlesOptional accepts the answer without its article, but not
+	// with a wrong one
+	ArticlesOptional = "optional"
+	// ArticlesRequired only accepts the answer with the right article
+	ArticlesRequired = "required"
+	// ArticlesSeparate grades the article on its own: the right word with
+	// a wrong or missing article earns partial credit
+	ArticlesSeparate = "separate"
+)
+
+// ArticleCredit is the credit for the right word with a wrong or missing
+// article when articles are graded separately
+const ArticleCredit = 0.5
+
+var (
+	articles     = make(map[string][]string)
+	articleMutex sync.RWMutex
+)
+
+func init() {
+	RegisterArticles("nl", "de", "het", "een")
+	RegisterArticles("de", "der", "die", "das", "den", "dem", "des", "ein", "eine", "einen", "einem", "einer", "eines")
+	RegisterArticles("fr", "le", "la", "les", "l'", "un", "une", "des")
+	RegisterArticles("es", "el", "la", "los", "las", "un", "una", "unos", "unas")
+	RegisterArticles("it", "il", "lo", "la", "i", "gli", "le", "l'", "un", "uno", "una", "un'")
+	RegisterArticles("pt", "o", "a", "os", "as", "um", "uma", "uns", "umas")
+	RegisterArticles("en", "the", "a", "an")
+}
+
+// RegisterArticles sets the articles of a language, replacing the built-in
+// ones if there are any. Elided articles such as French "l'" end in an
+// apostrophe.
+func RegisterArticles(language string, words ...string) {
+	articleMutex.Lock()
+	defer articleMutex.Unlock()
+	articles[normalizeLanguage(language)] = words
+}
+
+// ArticlesFor returns the articles of a language given as code or name
+func ArticlesFor(language string) ([]string, bool) {
+	articleMutex.RLock()
+	defer articleMutex.RUnlock()
+	words, ok := articles[normalizeLanguage(language)]
+	return words, ok
+}
+
+// SplitArticle splits a leading article off text: "de kat" gives "de" and
+// "kat", "l'homme" gives "l'" and "homme". The article is returned in lower
+// case; text without one gives "" and text itself.
+func SplitArticle(text string, articles []string) (string, string) {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "’", "'"))
+	lower := strings.ToLower(text)
+	for _, article := range articles {
+		if strings.HasSuffix(article, "'") {
+			if strings.HasPrefix(lower, article) && len(text) > len(article) {
+				return article, strings.TrimSpace(text[len(article):])
+			}
+			continue
+		}
+		if strings.HasPrefix(lower, article+" ") {
+			return article, strings.TrimSpace(text[len(article):])
+		}
+	}
+	return "", text
+}
+
+// Grade is the outcome of checking an answer
+type Grade struct {
+	// Correct is set when the answer is fully right
+	Correct bool
+	// Credit is 1 for a right answer, 0 for a wrong one and ArticleCredit
+	// for the right word with the wrong article
+	Credit float64
+	// Article is the expected article when the given one was wrong or
+	// missing, so it can be pointed out
+	Article string
+}
+
+// Grade checks given against the expected answers like Check, applying the
+// article grading mode, and returns the best grade of all answers
+func (c *AnswerChecker) Grade(given string, expected []string) Grade {
+	best := Grade{}
+	for _, answer := range expected {
+		grade := c.gradeOne(given, answer)
+		if grade.Credit > best.Credit || (grade.Credit == best.Credit && best.Article == "") {
+			best = grade
+		}
+		if best.Correct {
+			break
+		}
+	}
+	return best
+}
+
+func (c *AnswerChecker) gradeOne(given, answer string) Grade {
+	wantArticle, wantWord := "", answer
+	if c.Tolerance.Articles != ArticlesAsTyped {
+		wantArticle, wantWord = SplitArticle(answer, c.Articles)
+	}
+	if wantArticle == "" {
+		if c.matches(given, answer) {
+			return Grade{Correct: true, Credit: 1}
+		}
+		return Grade{}
+	}
+	givenArticle, givenWord := SplitArticle(given, c.Articles)
+	if !c.matches(givenWord, wantWord) {
+		return Grade{}
+	}
+
+	switch {
+	case givenArticle == wantArticle:
+		return Grade{Correct: true, Credit: 1}
+	case givenArticle == "" && c.Tolerance.Articles == ArticlesOptional:
+		return Grade{Correct: true, Credit: 1}
+	case c.Tolerance.Articles == ArticlesSeparate:
+		return Grade{Credit: ArticleCredit, Article: wantArticle}
+	default:
+		return Grade{Article: wantArticle}
+	}
+}
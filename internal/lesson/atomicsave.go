@@ -0,0 +1,208 @@
+package lesson
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// SettingBackups is the settings key holding how many earlier versions of a
+// file are kept as .bak copies when saving over it
+const SettingBackups = "saving.backups"
+
+var (
+	defaultBackups      = 2
+	defaultBackupsMutex sync.RWMutex
+)
+
+// DefaultBackups returns how many .bak copies savers keep by default
+func DefaultBackups() int {
+	defaultBackupsMutex.RLock()
+	defer defaultBackupsMutex.RUnlock()
+	return defaultBackups
+}
+
+// SetDefaultBackups changes how many .bak copies savers keep by default
+func SetDefaultBackups(backups int) {
+	defaultBackupsMutex.Lock()
+	defer defaultBackupsMutex.Unlock()
+	defaultBackups = max(0, backups)
+}
+
+// LoadDefaultBackups makes the number of .bak copies stored in settings the
+// default, keeping the current default when it is missing
+func LoadDefaultBackups(settings ToleranceSettings) int {
+	switch backups := settings.GetSettingWithDefault(SettingBackups, DefaultBackups()).(type) {
+	case int:
+		SetDefaultBackups(backups)
+	case float64:
+		SetDefaultBackups(int(backups))
+	}
+	return DefaultBackups()
+}
+
+// SaveDefaultBackups makes backups the default number of .bak copies and
+// stores it in settings
+func SaveDefaultBackups(settings ToleranceSettings, backups int) error {
+	SetDefaultBackups(backups)
+	return settings.SetSetting(SettingBackups, DefaultBackups())
+}
+
+// BackupPath returns where the nth most recent earlier version of a file is
+// kept, counting from 1
+func BackupPath(filePath string, n int) string {
+	return fmt.Sprintf("%s.%d.bak", filePath, n)
+}
+
+// saveAtomically writes lesson data to a temporary file next to filePath
+// and renames it over filePath, so a crash while saving leaves the earlier
+// version intact. The earlier version is kept as the first of
+// fs.Options.Backups .bak copies.
+func (fs *FileSaver) saveAtomically(lessonData *LessonData, filePath string) error {
+	return writeAtomically(filePath, fs.Options.Backups, saveMode(filePath), func(tempPath string) error {
+		return fs.saveFile(lessonData, tempPath)
+	})
+}
+
+// saveMode returns the permissions a lesson saved as filePath gets;
+// encrypted lessons are only readable by their owner
+func saveMode(filePath string) os.FileMode {
+	if strings.EqualFold(filepath.Ext(filePath), ".otsec") {
+		return 0600
+	}
+	return 0644
+}
+
+// WriteFileAtomically writes data to filePath through a temporary file
+// renamed over it, like savers do, keeping no .bak copies. A new file gets
+// perm, like with os.WriteFile.
+func WriteFileAtomically(filePath string, data []byte, perm os.FileMode) error {
+	return writeAtomically(filePath, 0, perm, func(tempPath string) error {
+		return os.WriteFile(tempPath, data, perm)
+	})
+}
+
+// writeAtomically has write fill a temporary file next to filePath and
+// renames it over filePath once that succeeded, keeping the earlier version
+// as the first of backups .bak copies. A new file gets perm; an existing one
+// keeps its permissions, except that a private perm (no group or other
+// bits) takes those away. When filePath is a symbolic link, the file it
+// points to is replaced and the link kept.
+func writeAtomically(filePath string, backups int, perm os.FileMode, write func(tempPath string) error) error {
+	if target, err := filepath.EvalSymlinks(filePath); err == nil {
+		filePath = target
+	}
+	dir, name := filepath.Split(filePath)
+	if dir == "" {
+		dir = "."
+	}
+	// The name ends like filePath, so it is saved in the same format
+	temp, err := os.CreateTemp(dir, ".recuerdo-*-"+name)
+	if err != nil {
+		return err
+	}
+	tempPath := temp.Name()
+	temp.Close()
+	defer os.Remove(tempPath)
+
+	if err := write(tempPath); err != nil {
+		return err
+	}
+	if err := syncFile(tempPath); err != nil {
+		return err
+	}
+
+	// Temporary files are only readable by their owner
+	mode := perm
+	existing, err := os.Stat(filePath)
+	if err == nil {
+		mode = existing.Mode().Perm()
+		if perm&0077 == 0 {
+			mode &= 0700
+		}
+	}
+	if err := os.Chmod(tempPath, mode); err != nil {
+		return err
+	}
+	if existing != nil && existing.Mode().IsRegular() {
+		if err := rotateBackups(filePath, backups); err != nil {
+			return fmt.Errorf("failed to keep a backup of %s: %w", filePath, err)
+		}
+	}
+	if err := os.Rename(tempPath, filePath); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// syncDir makes sure a rename in dir is on disk, so a crash right after
+// saving cannot bring the earlier version back
+func syncDir(dir string) error {
+	// Windows cannot open directories to sync them
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return file.Sync()
+}
+
+// syncFile makes sure a written file is on disk before it replaces another
+func syncFile(filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return file.Sync()
+}
+
+// rotateBackups moves every .bak copy of filePath one place back, dropping
+// the oldest, and keeps filePath itself as the first
+func rotateBackups(filePath string, backups int) error {
+	if backups <= 0 {
+		return nil
+	}
+	if err := os.Remove(BackupPath(filePath, backups)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for n := backups - 1; n >= 1; n-- {
+		if err := os.Rename(BackupPath(filePath, n), BackupPath(filePath, n+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	// A hard link keeps filePath in place until the new version replaces it
+	if err := os.Link(filePath, BackupPath(filePath, 1)); err == nil {
+		return nil
+	}
+	return copyFile(filePath, BackupPath(filePath, 1))
+}
+
+// copyFile copies a file for file systems without hard links
+func copyFile(from, to string) error {
+	source, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	info, err := source.Stat()
+	if err != nil {
+		return err
+	}
+	target, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(target, source); err != nil {
+		target.Close()
+		return err
+	}
+	return target.Close()
+}
@@ -0,0 +1,171 @@
+package lesson
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// savedTitle returns the title of a saved JSON lesson; backups end in .bak,
+// so they are loaded from a copy with the original extension
+func savedTitle(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copied := filepath.Join(t.TempDir(), "lesson.json")
+	if err := os.WriteFile(copied, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	lessonData, err := NewFileLoader().LoadFile(copied)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lessonData.List.Title
+}
+
+func TestSaveKeepsBackups(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "french.json")
+	saver := NewFileSaver()
+	saver.Options.Backups = 2
+
+	for _, title := range []string{"first", "second", "third", "fourth"} {
+		lessonData := NewLessonData()
+		lessonData.List.Title = title
+		if err := saver.SaveFile(lessonData, filePath); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for path, want := range map[string]string{
+		filePath:                "fourth",
+		BackupPath(filePath, 1): "third",
+		BackupPath(filePath, 2): "second",
+	} {
+		if title := savedTitle(t, path); title != want {
+			t.Errorf("%s has %q, want %q", filepath.Base(path), title, want)
+		}
+	}
+	if _, err := os.Stat(BackupPath(filePath, 3)); err == nil {
+		t.Error("kept more backups than asked for")
+	}
+	if temps, _ := filepath.Glob(filepath.Join(dir, ".recuerdo-*")); len(temps) != 0 {
+		t.Errorf("temporary files left behind: %v", temps)
+	}
+}
+
+func TestSaveAtomically(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "french.csv")
+	if err := os.WriteFile(filePath, []byte("Question,Answer\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	saver := NewFileSaver()
+	saver.Options.Backups = 0
+	if err := saver.SaveFile(NewLessonData(), filePath); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(filePath); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("saving changed the permissions: %v, %v", info.Mode(), err)
+	}
+	if _, err := os.Stat(BackupPath(filePath, 1)); err == nil {
+		t.Error("kept a backup with backups turned off")
+	}
+
+	// A failed save leaves the file and the directory as they were
+	if err := saver.SaveFile(NewLessonData(), filepath.Join(dir, "french.unknown")); err == nil {
+		t.Fatal("saving in an unknown format should fail")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("directory holds %d files after a failed save", len(entries))
+	}
+}
+
+func TestSaveEncryptedAtomically(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "french.otsec")
+	if err := os.WriteFile(filePath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	saver := NewFileSaver()
+	saver.Options.Passphrase = "correct horse"
+	if err := saver.SaveFile(NewLessonData(), filePath); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(filePath); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("an encrypted lesson is readable by others: %v, %v", info.Mode(), err)
+	}
+
+	// Saving through a symbolic link replaces the file it points to
+	link := filepath.Join(dir, "link.json")
+	target := filepath.Join(dir, "target.json")
+	if err := os.WriteFile(target, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Skip("no symbolic links:", err)
+	}
+	lessonData := NewLessonData()
+	lessonData.List.Title = "through a link"
+	if err := saver.SaveFile(lessonData, link); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("saving replaced the link: %v", err)
+	}
+	if title := savedTitle(t, target); title != "through a link" {
+		t.Errorf("the linked file has %q", title)
+	}
+}
+
+func TestSaveLessonPackAtomically(t *testing.T) {
+	dir := t.TempDir()
+	packFile := filepath.Join(dir, "course.otpack")
+	saver := NewFileSaver()
+	saver.Options.Backups = 1
+
+	for _, title := range []string{"first", "second"} {
+		lessonData := NewLessonData()
+		lessonData.List.Title = title
+		lessonData.List.Items = []WordItem{{ID: 0, Questions: []string{"q"}, Answers: []string{"a"}}}
+		if err := saver.SaveLessonPack([]*LessonData{lessonData}, nil, packFile); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for path, want := range map[string]string{packFile: "second", BackupPath(packFile, 1): "first"} {
+		lessons, _, err := NewFileLoader().LoadLessonPack(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(lessons) != 1 || lessons[0].List.Title != want {
+			t.Errorf("%s holds %d lessons, want %q", filepath.Base(path), len(lessons), want)
+		}
+	}
+
+	revision := filepath.Join(dir, "revision.json")
+	if err := WriteFileAtomically(revision, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if temps, _ := filepath.Glob(filepath.Join(dir, ".recuerdo-*")); len(temps) != 0 {
+		t.Errorf("temporary files left behind: %v", temps)
+	}
+}
+
+func TestDefaultBackups(t *testing.T) {
+	defer SetDefaultBackups(DefaultBackups())
+
+	// JSON settings files hold numbers as float64
+	settings := mapSettings{SettingBackups: float64(5)}
+	if backups := LoadDefaultBackups(settings); backups != 5 || NewFileSaver().Options.Backups != 5 {
+		t.Errorf("loaded %d backups, savers keep %d", backups, NewFileSaver().Options.Backups)
+	}
+	if err := SaveDefaultBackups(settings, -1); err != nil {
+		t.Fatal(err)
+	}
+	if settings[SettingBackups] != 0 || DefaultBackups() != 0 {
+		t.Errorf("negative backups stored as %v", settings[SettingBackups])
+	}
+}
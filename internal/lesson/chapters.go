@@ -0,0 +1,92 @@
+package lesson
+
+import (
+	"slices"
+	"strings"
+)
+
+// ChapterSeparator separates the levels of a chapter path written as text,
+// as in "Part 1 / Unit 3"
+const ChapterSeparator = " / "
+
+// ankiDeckSeparator separates the levels of the name of an Anki subdeck
+const ankiDeckSeparator = "::"
+
+// ChapterName returns a chapter path as text, such as "Part 1 / Unit 3"
+func ChapterName(chapter []string) string {
+	return strings.Join(chapter, ChapterSeparator)
+}
+
+// ParseChapter splits a chapter written as text, such as "Part 1 / Unit 3",
+// into its path. An empty text is no chapter.
+func ParseChapter(text string) []string {
+	var chapter []string
+	for _, name := range strings.Split(text, "/") {
+		if name = strings.TrimSpace(name); name != "" {
+			chapter = append(chapter, name)
+		}
+	}
+	return chapter
+}
+
+// InChapter reports whether the item is in chapter or one of its
+// sub-chapters
+func (wi *WordItem) InChapter(chapter []string) bool {
+	if len(chapter) == 0 || len(wi.Chapter) < len(chapter) {
+		return false
+	}
+	return slices.Equal(wi.Chapter[:len(chapter)], chapter)
+}
+
+// Chapters returns the chapters of the items of the list, each once and in
+// the order they first hold an item, a chapter before its sub-chapters
+func (wl *WordList) Chapters() [][]string {
+	var chapters [][]string
+	seen := make(map[string]bool)
+	for _, item := range wl.Items {
+		for depth := 1; depth <= len(item.Chapter); depth++ {
+			chapter := item.Chapter[:depth]
+			// Chapter names hold no NUL characters
+			key := strings.Join(chapter, "\x00")
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			chapters = append(chapters, append([]string(nil), chapter...))
+		}
+	}
+	return chapters
+}
+
+// ChapterItems keeps the items of indexes in any of chapters or their
+// sub-chapters
+func ChapterItems(list *WordList, indexes []int, chapters [][]string) []int {
+	return filterItems(list, indexes, func(item *WordItem) bool {
+		for _, chapter := range chapters {
+			if item.InChapter(chapter) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// trimCommonChapter removes the outermost chapter when all items are in
+// it, as that is the lesson itself, such as the parent deck of the Anki
+// subdecks a lesson was imported from
+func trimCommonChapter(items []WordItem) {
+	if len(items) == 0 {
+		return
+	}
+	for _, item := range items {
+		if len(item.Chapter) == 0 || item.Chapter[0] != items[0].Chapter[0] {
+			return
+		}
+	}
+	for i := range items {
+		items[i].Chapter = items[i].Chapter[1:]
+		if len(items[i].Chapter) == 0 {
+			items[i].Chapter = nil
+		}
+	}
+}
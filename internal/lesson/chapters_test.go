@@ -0,0 +1,100 @@
+package lesson
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// chapterList returns a list of items in the given chapters
+func chapterList(chapters ...string) *WordList {
+	list := NewWordList()
+	for i, chapter := range chapters {
+		list.Items = append(list.Items, WordItem{ID: i, Questions: []string{"q"}, Answers: []string{"a"}, Chapter: ParseChapter(chapter)})
+	}
+	return list
+}
+
+func TestChapters(t *testing.T) {
+	if chapter := ParseChapter(" Part 1 /Unit 3/ "); !reflect.DeepEqual(chapter, []string{"Part 1", "Unit 3"}) || ChapterName(chapter) != "Part 1 / Unit 3" {
+		t.Errorf("ParseChapter = %q", chapter)
+	}
+
+	list := chapterList("Part 2 / Unit 4", "Part 1 / Unit 1", "", "Part 1 / Unit 2", "Part 1")
+	want := [][]string{{"Part 2"}, {"Part 2", "Unit 4"}, {"Part 1"}, {"Part 1", "Unit 1"}, {"Part 1", "Unit 2"}}
+	if chapters := list.Chapters(); !reflect.DeepEqual(chapters, want) {
+		t.Errorf("Chapters = %q, want %q", chapters, want)
+	}
+
+	list.Items[2].Difficulty = DifficultyAlwaysAsk
+	got := ChapterItems(list, AllItems(list), [][]string{{"Part 1"}})
+	if !slices.Equal(got, []int{1, 3, 4, 2}) {
+		t.Errorf("Part 1 with its units and the always-ask word = %v", got)
+	}
+	if got := ChapterItems(list, AllItems(list), [][]string{{"Part 1", "Unit 2"}, {"Part 2", "Unit 4"}}); !slices.Equal(got, []int{0, 3, 2}) {
+		t.Errorf("two units = %v", got)
+	}
+}
+
+func TestKVTMLChapters(t *testing.T) {
+	lessonData := NewLessonData()
+	lessonData.List = *chapterList("Part 1 / Unit 1", "Part 1 / Unit 2", "Part 2", "", "Part 1 / Unit 1")
+	filePath := filepath.Join(t.TempDir(), "chapters.kvtml")
+	if err := NewFileSaver().SaveFile(lessonData, filePath); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := NewFileLoader().LoadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.List.Items) != 5 {
+		t.Fatalf("loaded %d items", len(loaded.List.Items))
+	}
+	for i, item := range loaded.List.Items {
+		if !slices.Equal(item.Chapter, lessonData.List.Items[i].Chapter) {
+			t.Errorf("item %d in chapter %q, want %q", i, item.Chapter, lessonData.List.Items[i].Chapter)
+		}
+	}
+}
+
+func TestLoadAnkiSubdecks(t *testing.T) {
+	ankiFile := filepath.Join(t.TempDir(), "spanish.anki2")
+	db, err := sql.Open("sqlite3", ankiFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statements := []string{
+		`CREATE TABLE col (decks TEXT)`,
+		`INSERT INTO col VALUES ('{"1": {"name": "Spanish"}, "2": {"name": "Spanish::Unit 1"}, "3": {"name": "Spanish::Unit 2::Verbs"}}')`,
+		`CREATE TABLE notes (id INTEGER PRIMARY KEY, guid TEXT, flds TEXT, tags TEXT)`,
+		`CREATE TABLE cards (id INTEGER PRIMARY KEY, nid INTEGER, did INTEGER, queue INTEGER, ivl INTEGER, factor INTEGER, reps INTEGER, lapses INTEGER)`,
+		`INSERT INTO notes VALUES (1, 'g1', 'hola' || char(31) || 'hello', '')`,
+		`INSERT INTO notes VALUES (2, 'g2', 'ser' || char(31) || 'to be', '')`,
+		`INSERT INTO notes VALUES (3, 'g3', 'adiós' || char(31) || 'goodbye', '')`,
+		`INSERT INTO cards VALUES (1, 1, 2, 0, 0, 0, 0, 0)`,
+		`INSERT INTO cards VALUES (2, 2, 3, 0, 0, 0, 0, 0)`,
+		`INSERT INTO cards VALUES (3, 3, 1, 0, 0, 0, 0, 0)`,
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("Failed to set up database: %v", err)
+		}
+	}
+	db.Close()
+
+	lessonData, err := NewFileLoader().LoadFile(ankiFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"Unit 1"}, {"Unit 2", "Verbs"}, nil}
+	if len(lessonData.List.Items) != len(want) {
+		t.Fatalf("loaded %d items", len(lessonData.List.Items))
+	}
+	for i, chapter := range want {
+		if got := lessonData.List.Items[i].Chapter; !slices.Equal(got, chapter) {
+			t.Errorf("note %d in chapter %q, want %q", i+1, got, chapter)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package lesson
+
+import (
+	"strings"
+	"unicode"
+)
+
+// traditionalSimplified lists pairs of a traditional Chinese character
+// followed by its simplified form, covering the characters of common
+// vocabulary lists
+const traditionalSimplified = "" +
+	"們们個个這这來来說说時时會会國国學学對对為为後后動动見见發发現现當当沒没還还開开長长問问間间電电" +
+	"車车東东點点話话體体關关從从氣气麼么樣样頭头進进過过種种實实機机將将讓让業业經经書书門门聽听買买" +
+	"賣卖貓猫鳥鸟馬马魚鱼龍龙雞鸡豬猪歡欢愛爱樂乐語语漢汉寫写讀读認认識识謝谢請请誰谁難难幾几錢钱飯饭" +
+	"館馆醫医園园場场腦脑藥药熱热週周歲岁員员師师號号紅红綠绿藍蓝黃黄顏颜飛飞鐘钟錶表髮发麵面裡里裏里" +
+	"邊边遠远親亲爺爷媽妈孫孙兒儿風风雲云陽阳陰阴華华貝贝財财貨货質质錯错聲声聞闻報报紙纸筆笔畫画圖图" +
+	"級级練练習习題题詞词課课該该試试記记議议計计設设許许論论訴诉調调談谈變变應应歷历曆历驗验觀观覺觉" +
+	"視视務务辦办廣广莊庄慶庆廳厅樓楼燈灯燒烧煙烟豐丰農农隻只雙双寶宝審审導导層层屬属歸归殺杀憶忆懷怀" +
+	"態态總总戰战戲戏擁拥據据擔担選选遲迟運运達达適适連连節节範范藝艺蘋苹葉叶萬万蘭兰衛卫術术補补裝装" +
+	"複复復复規规貴贵費费資资賽赛趕赶軍军輕轻輪轮轉转鄉乡釣钓銀银鋼钢鐵铁鏡镜陳陈陸陆險险隊队際际雖虽" +
+	"雜杂離离靜静頁页順顺須须領领頓顿願愿類类顯显飲饮餓饿餅饼養养驚惊髒脏臟脏鬥斗鬧闹鮮鲜鹽盐麥麦黨党" +
+	"齊齐齒齿龜龟嗎吗單单嚴严團团圓圆壞坏壓压處处備备傳传僅仅價价優优億亿倆俩側侧偉伟傷伤衝冲準准幫帮" +
+	"張张強强彈弹徑径慣惯憂忧戶户換换擇择數数斷断於于暫暂條条極极樹树橋桥權权滿满漁渔濟济灣湾無无爾尔" +
+	"牆墙狀状獨独獲获產产畢毕異异療疗盡尽監监眾众確确礎础禮礼積积穩稳窮穷競竞簡简糧粮係系紀纪約约紹绍" +
+	"終终組组結结給给統统絲丝維维網网緊紧線线編编縣县繼继續续罰罚義义聯联聰聪職职腳脚與与興兴舊旧蟲虫" +
+	"製制覽览觸触訂订討讨訓训證证評评詢询護护負负責责貿贸賓宾購购較较輸输遊游遺遗郵邮釋释針针錄录鍵键" +
+	"閱阅隨随隱隐靈灵響响頂顶項项預预頻频額额飄飘飽饱騎骑鬆松麗丽臺台颱台韓韩臉脸聖圣濕湿溫温潔洁澤泽" +
+	"滅灭災灾爐炉壯壮夢梦夠够奪夺奮奋婦妇寧宁寬宽專专尋寻屆届島岛帶带幣币幹干廠厂廢废慮虑憑凭戀恋擊击" +
+	"擴扩攝摄敗败敵敌曉晓檢检櫃柜歐欧殘残況况淚泪淺浅測测湯汤漸渐潛潜濱滨灑洒灘滩烏乌煩烦營营爭争猶犹" +
+	"獎奖獸兽環环瘋疯盜盗碼码磚砖禍祸簽签納纳純纯細细織织繩绳繪绘羅罗膽胆膚肤艦舰蘇苏蝦虾襪袜訪访詩诗" +
+	"誠诚誤误諾诺講讲譯译讚赞貧贫貼贴賀贺賴赖贏赢軟软載载輩辈辭辞辯辩遞递遙遥鄰邻醜丑銷销鋪铺鍋锅鎖锁" +
+	"鎮镇閃闪閉闭閒闲闊阔陣阵霧雾顧顾飼饲飾饰餘余駐驻騙骗驅驱鬍胡鳳凤鴨鸭鵝鹅鷹鹰齡龄兩两嗚呜橫横決决" +
+	"涼凉減减湊凑牽牵碩硕紛纷綜综緣缘聳耸膠胶臨临艱艰蔥葱蘿萝蟬蝉訊讯詳详誇夸諸诸謹谨豎竖賬账賺赚贊赞" +
+	"趨趋躍跃轟轰遜逊邁迈鄭郑醬酱鈴铃銅铜鋁铝鍛锻鏈链閣阁隸隶雛雏韻韵頸颈頒颁頗颇顆颗颳刮飢饥饒饶馳驰" +
+	"駕驾騷骚驟骤骯肮鬱郁鯨鲸鳴鸣鴿鸽鶴鹤黴霉鼴鼹"
+
+var toSimplified = func() map[rune]rune {
+	pairs := []rune(traditionalSimplified)
+	table := make(map[rune]rune, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		table[pairs[i]] = pairs[i+1]
+	}
+	return table
+}()
+
+// ToSimplified converts traditional Chinese characters to their simplified
+// forms. Several traditional characters can share one simplified form, so
+// comparing both sides in simplified form accepts either script.
+func ToSimplified(text string) string {
+	return strings.Map(func(r rune) rune {
+		if simplified, ok := toSimplified[r]; ok {
+			return simplified
+		}
+		return r
+	}, text)
+}
+
+// pinyinToneMarks holds each vowel with the marks of tones 1 to 4
+var pinyinToneMarks = map[rune][]rune{
+	'a': []rune("āáǎà"),
+	'e': []rune("ēéěè"),
+	'i': []rune("īíǐì"),
+	'o': []rune("ōóǒò"),
+	'u': []rune("ūúǔù"),
+	'ü': []rune("ǖǘǚǜ"),
+}
+
+// pinyinUnmarked maps vowels with tone marks back to the plain vowel
+var pinyinUnmarked = func() map[rune]rune {
+	table := make(map[rune]rune)
+	for vowel, marked := range pinyinToneMarks {
+		for _, r := range marked {
+			table[r] = vowel
+		}
+	}
+	return table
+}()
+
+// PinyinNumbersToMarks writes pinyin with tone numbers, such as "ni3 hao3"
+// or "lv4", with tone marks instead: "nǐ hǎo", "lǜ". Text that already has
+// tone marks is left as it is, so both spellings can be compared.
+func PinyinNumbersToMarks(text string) string {
+	text = strings.ReplaceAll(strings.ToLower(text), "u:", "ü")
+
+	var result, syllable []rune
+	flush := func(tone int) {
+		result = append(result, markPinyinSyllable(syllable, tone)...)
+		syllable = syllable[:0]
+	}
+	for _, r := range text {
+		switch {
+		case r >= '1' && r <= '5' && len(syllable) > 0:
+			flush(int(r - '0'))
+		case unicode.IsLetter(r):
+			syllable = append(syllable, r)
+		default:
+			flush(0)
+			result = append(result, r)
+		}
+	}
+	flush(0)
+	return string(result)
+}
+
+// markPinyinSyllable puts the mark of tone on the vowel that carries it: a
+// or e if present, the o of ou, and the last vowel otherwise. Tone 5 and 0
+// (neutral or unknown) leave the syllable unmarked.
+func markPinyinSyllable(syllable []rune, tone int) []rune {
+	marked := append([]rune(nil), syllable...)
+	// v is typed for ü after n and l
+	for i := 1; i < len(marked); i++ {
+		if marked[i] == 'v' && (marked[i-1] == 'n' || marked[i-1] == 'l') {
+			marked[i] = 'ü'
+		}
+	}
+	if tone < 1 || tone > 4 {
+		return marked
+	}
+
+	target := -1
+	for i, r := range marked {
+		if r == 'a' || r == 'e' {
+			target = i
+			break
+		}
+		if r == 'o' && i+1 < len(marked) && marked[i+1] == 'u' {
+			target = i
+			break
+		}
+		if _, vowel := pinyinToneMarks[r]; vowel {
+			target = i
+		}
+	}
+	if target >= 0 {
+		marked[target] = pinyinToneMarks[marked[target]][tone-1]
+	}
+	return marked
+}
+
+// StripPinyinTones removes tone marks and tone numbers from pinyin, keeping
+// the ü
+func StripPinyinTones(text string) string {
+	return strings.Map(func(r rune) rune {
+		if plain, ok := pinyinUnmarked[r]; ok {
+			return plain
+		}
+		return r
+	}, PinyinNumbersToMarks(text))
+}
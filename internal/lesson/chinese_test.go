@@ -0,0 +1,57 @@
+package lesson
+
+import "testing"
+
+func TestToSimplified(t *testing.T) {
+	if got := ToSimplified("我們學習漢語"); got != "我们学习汉语" {
+		t.Errorf("ToSimplified() = %q", got)
+	}
+	if got := ToSimplified("我们 hello"); got != "我们 hello" {
+		t.Errorf("Simplified text should be kept, got %q", got)
+	}
+}
+
+func TestPinyinNumbersToMarks(t *testing.T) {
+	tests := []struct{ given, want string }{
+		{"ni3 hao3", "nǐ hǎo"},
+		{"Zhong1guo2", "zhōngguó"},
+		{"xie4xie5", "xièxie"},
+		{"lv4", "lǜ"},
+		{"nu:3", "nǚ"},
+		{"gou3", "gǒu"},
+		{"liu2", "liú"},
+		{"gui4", "guì"},
+		{"nǐ hǎo", "nǐ hǎo"},
+	}
+	for _, tt := range tests {
+		if got := PinyinNumbersToMarks(tt.given); got != tt.want {
+			t.Errorf("PinyinNumbersToMarks(%q) = %q; want %q", tt.given, got, tt.want)
+		}
+	}
+	if got := StripPinyinTones("nǚ ren2"); got != "nü ren" {
+		t.Errorf("StripPinyinTones() = %q", got)
+	}
+}
+
+func TestAnswerCheckerChinese(t *testing.T) {
+	scripts := NewAnswerChecker(AnswerTolerance{ChineseScripts: true}, "")
+	if !scripts.Check("學習", []string{"学习"}) || !scripts.Check("学习", []string{"學習"}) {
+		t.Error("Either script should be accepted")
+	}
+	if NewAnswerChecker(AnswerTolerance{}, "").Check("學習", []string{"学习"}) {
+		t.Error("Scripts should differ without the option")
+	}
+
+	pinyin := NewAnswerChecker(AnswerTolerance{Pinyin: true}, "")
+	if !pinyin.Check("ni3hao3", []string{"nǐ hǎo"}) {
+		t.Error("Tone numbers should match tone marks")
+	}
+	if pinyin.Check("ni hao", []string{"nǐ hǎo"}) || pinyin.Check("ni2 hao3", []string{"nǐ hǎo"}) {
+		t.Error("Tones should be graded unless ignored")
+	}
+
+	toneless := NewAnswerChecker(AnswerTolerance{IgnoreTones: true}, "")
+	if !toneless.Check("ni hao", []string{"nǐ hǎo"}) || !toneless.Check("ni2 hao", []string{"ni3 hao3"}) {
+		t.Error("Pinyin without tones should be accepted")
+	}
+}
@@ -0,0 +1,472 @@
+package lesson
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// Settings for the cloud drives the file dialogs browse. The tokens are
+// kept as JSON, so the user stays signed in.
+const (
+	GoogleDriveClientIDSetting     = "storage.googleDrive.clientID"
+	GoogleDriveClientSecretSetting = "storage.googleDrive.clientSecret"
+	GoogleDriveTokenSetting        = "storage.googleDrive.token"
+	OneDriveClientIDSetting        = "storage.oneDrive.clientID"
+	OneDriveTenantSetting          = "storage.oneDrive.tenant"
+	OneDriveTokenSetting           = "storage.oneDrive.token"
+)
+
+// GoogleDriveConfig returns how to sign in to Google Drive with the OAuth
+// client of the school. Google gives desktop clients a secret, which it
+// does not treat as one.
+func GoogleDriveConfig(clientID, clientSecret string) OAuthConfig {
+	return OAuthConfig{
+		AuthURL:      "https://accounts.google.com/o/oauth2/v2/auth",
+		TokenURL:     "https://oauth2.googleapis.com/token",
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Scopes:       []string{"https://www.googleapis.com/auth/drive"},
+		// Without these Google gives no refresh token
+		Params: map[string]string{"access_type": "offline", "prompt": "consent"},
+	}
+}
+
+// OneDriveConfig returns how to sign in to OneDrive with the app the
+// school registered in its tenant. Tenant may be the ID or domain of the
+// tenant, "organizations" for any work or school account, or "common" for
+// personal accounts too.
+func OneDriveConfig(clientID, tenant string) OAuthConfig {
+	if tenant == "" {
+		tenant = "common"
+	}
+	return OAuthConfig{
+		AuthURL:  "https://login.microsoftonline.com/" + url.PathEscape(tenant) + "/oauth2/v2.0/authorize",
+		TokenURL: "https://login.microsoftonline.com/" + url.PathEscape(tenant) + "/oauth2/v2.0/token",
+		ClientID: clientID,
+		Scopes:   []string{"Files.ReadWrite", "offline_access"},
+	}
+}
+
+// cloudError turns a failed answer of a cloud drive about name into an
+// error
+func cloudError(name string, response *http.Response) error {
+	switch response.StatusCode {
+	case http.StatusUnauthorized:
+		return ErrSignInNeeded
+	case http.StatusNotFound:
+		return fmt.Errorf("%s: %w", name, fs.ErrNotExist)
+	case http.StatusForbidden:
+		return fmt.Errorf("%s: %w", name, fs.ErrPermission)
+	}
+	detail, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+	return fmt.Errorf("request for %s failed: %s %s", name, response.Status, strings.TrimSpace(string(detail)))
+}
+
+// getJSON sends a request through session and decodes its JSON answer
+func getJSON(session *oauthSession, request *http.Request, name string, value any) error {
+	response, err := session.do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusCreated {
+		return cloudError(name, response)
+	}
+	if err := json.NewDecoder(io.LimitReader(response.Body, 16<<20)).Decode(value); err != nil {
+		return fmt.Errorf("invalid answer about %s: %w", name, err)
+	}
+	return nil
+}
+
+// googleFolderType is the MIME type of folders on Google Drive. Other
+// types of Google's own, such as Docs, cannot be downloaded as they are
+// and are not listed.
+const googleFolderType = "application/vnd.google-apps.folder"
+
+// GoogleDriveStorage keeps lessons on the Google Drive of the user signed
+// in. Drive finds files by ID rather than path, so paths are looked up a
+// folder at a time. Drive does not check versions when uploading, so the
+// version is checked just before.
+type GoogleDriveStorage struct {
+	// APIURL and UploadURL are those of the Drive API, changed in tests
+	APIURL    string
+	UploadURL string
+	session   *oauthSession
+}
+
+// googleFile is a file on Google Drive, with the fields asked for
+type googleFile struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	MimeType     string    `json:"mimeType"`
+	Size         string    `json:"size"`
+	ModifiedTime time.Time `json:"modifiedTime"`
+	Version      string    `json:"version"`
+}
+
+// googleFileFields are the fields of googleFile
+const googleFileFields = "id,name,mimeType,size,modifiedTime,version"
+
+// NewGoogleDriveStorage returns the Google Drive signed in to with token.
+// onRefresh, which may be nil, is given the tokens refreshed while using
+// it, to be kept instead, and an empty token once the user has to sign in
+// again.
+func NewGoogleDriveStorage(config OAuthConfig, token OAuthToken, onRefresh func(OAuthToken)) *GoogleDriveStorage {
+	return &GoogleDriveStorage{
+		APIURL:    "https://www.googleapis.com/drive/v3",
+		UploadURL: "https://www.googleapis.com/upload/drive/v3",
+		session:   &oauthSession{config: config, token: token, onRefresh: onRefresh},
+	}
+}
+
+// Location returns the name of the drive
+func (s *GoogleDriveStorage) Location() string {
+	return "Google Drive"
+}
+
+// List returns the files and folders in dir
+func (s *GoogleDriveStorage) List(ctx context.Context, dir string) ([]StorageEntry, error) {
+	dir, err := cleanStoragePath(dir)
+	if err != nil {
+		return nil, err
+	}
+	folder, err := s.lookup(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	if folder.MimeType != googleFolderType {
+		return nil, fmt.Errorf("%s is not a folder", dir)
+	}
+	files, err := s.children(ctx, folder.ID, "")
+	if err != nil {
+		return nil, err
+	}
+	var entries []StorageEntry
+	for _, file := range files {
+		isDir := file.MimeType == googleFolderType
+		if !isDir && strings.HasPrefix(file.MimeType, "application/vnd.google-apps.") {
+			continue
+		}
+		entry := StorageEntry{Name: file.Name, Path: path.Join(dir, file.Name), Dir: isDir, Modified: file.ModifiedTime, Version: file.Version}
+		fmt.Sscan(file.Size, &entry.Size)
+		entries = append(entries, entry)
+	}
+	sortEntries(entries)
+	return entries, nil
+}
+
+// Open downloads the file name
+func (s *GoogleDriveStorage) Open(ctx context.Context, name string) (io.ReadCloser, string, error) {
+	name, err := cleanStoragePath(name)
+	if err != nil {
+		return nil, "", err
+	}
+	file, err := s.lookup(ctx, name)
+	if err != nil {
+		return nil, "", err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, s.APIURL+"/files/"+url.PathEscape(file.ID)+"?alt=media", nil)
+	if err != nil {
+		return nil, "", err
+	}
+	response, err := s.session.do(request)
+	if err != nil {
+		return nil, "", err
+	}
+	if response.StatusCode != http.StatusOK {
+		defer response.Body.Close()
+		return nil, "", cloudError(name, response)
+	}
+	return response.Body, file.Version, nil
+}
+
+// Save uploads the file name when it is still at version
+func (s *GoogleDriveStorage) Save(ctx context.Context, name string, content io.Reader, version string) (string, error) {
+	name, err := cleanStoragePath(name)
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		return "", errors.New("no file name given")
+	}
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return "", err
+	}
+	folder, err := s.lookup(ctx, path.Dir(name))
+	if err != nil {
+		return "", err
+	}
+	files, err := s.children(ctx, folder.ID, path.Base(name))
+	if err != nil {
+		return "", err
+	}
+	var existing *googleFile
+	if len(files) > 0 {
+		existing = &files[0]
+	}
+	switch {
+	case version == AnyVersion:
+	case existing == nil && version != "", existing != nil && existing.Version != version:
+		return "", ErrConflict
+	}
+
+	if existing == nil {
+		metadata, _ := json.Marshal(map[string]any{"name": path.Base(name), "parents": []string{folder.ID}})
+		request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.APIURL+"/files?fields="+googleFileFields, bytes.NewReader(metadata))
+		if err != nil {
+			return "", err
+		}
+		request.Header.Set("Content-Type", "application/json")
+		existing = &googleFile{}
+		if err := getJSON(s.session, request, name, existing); err != nil {
+			return "", err
+		}
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPatch, s.UploadURL+"/files/"+url.PathEscape(existing.ID)+"?uploadType=media&fields="+googleFileFields, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Content-Type", "application/octet-stream")
+	var uploaded googleFile
+	if err := getJSON(s.session, request, name, &uploaded); err != nil {
+		return "", err
+	}
+	return uploaded.Version, nil
+}
+
+// lookup finds the file or folder at name, a folder at a time from the
+// root of the drive
+func (s *GoogleDriveStorage) lookup(ctx context.Context, name string) (*googleFile, error) {
+	file := &googleFile{ID: "root", MimeType: googleFolderType}
+	for _, part := range strings.Split(name, "/") {
+		if part == "" || part == "." {
+			continue
+		}
+		files, err := s.children(ctx, file.ID, part)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("%s: %w", name, fs.ErrNotExist)
+		}
+		file = &files[0]
+	}
+	return file, nil
+}
+
+// children returns the files in the folder with the ID parent, only those
+// called name unless that is empty
+func (s *GoogleDriveStorage) children(ctx context.Context, parent, name string) ([]googleFile, error) {
+	query := fmt.Sprintf("'%s' in parents and trashed = false", googleQuoted(parent))
+	if name != "" {
+		query += fmt.Sprintf(" and name = '%s'", googleQuoted(name))
+	}
+	var files []googleFile
+	pageToken := ""
+	for {
+		values := url.Values{
+			"q":        {query},
+			"fields":   {"nextPageToken,files(" + googleFileFields + ")"},
+			"pageSize": {"1000"},
+			"orderBy":  {"folder,name"},
+		}
+		if pageToken != "" {
+			values.Set("pageToken", pageToken)
+		}
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, s.APIURL+"/files?"+values.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			NextPageToken string       `json:"nextPageToken"`
+			Files         []googleFile `json:"files"`
+		}
+		if err := getJSON(s.session, request, name, &page); err != nil {
+			return nil, err
+		}
+		files = append(files, page.Files...)
+		if pageToken = page.NextPageToken; pageToken == "" {
+			return files, nil
+		}
+	}
+}
+
+// googleQuoted escapes value to be quoted in a Drive query
+func googleQuoted(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value)
+}
+
+// OneDriveStorage keeps lessons on the OneDrive of the user signed in,
+// through Microsoft Graph, which checks the version itself when saving
+type OneDriveStorage struct {
+	// APIURL is that of the drive of the user in Graph, changed in tests
+	APIURL  string
+	session *oauthSession
+}
+
+// oneDriveItem is a file or folder on OneDrive
+type oneDriveItem struct {
+	Name         string    `json:"name"`
+	Size         int64     `json:"size"`
+	ETag         string    `json:"eTag"`
+	LastModified time.Time `json:"lastModifiedDateTime"`
+	Folder       *struct{} `json:"folder"`
+}
+
+// NewOneDriveStorage returns the OneDrive signed in to with token.
+// onRefresh, which may be nil, is given the tokens refreshed while using
+// it, to be kept instead, and an empty token once the user has to sign in
+// again.
+func NewOneDriveStorage(config OAuthConfig, token OAuthToken, onRefresh func(OAuthToken)) *OneDriveStorage {
+	return &OneDriveStorage{
+		APIURL:  "https://graph.microsoft.com/v1.0/me/drive",
+		session: &oauthSession{config: config, token: token, onRefresh: onRefresh},
+	}
+}
+
+// Location returns the name of the drive
+func (s *OneDriveStorage) Location() string {
+	return "OneDrive"
+}
+
+// List returns the files and folders in dir
+func (s *OneDriveStorage) List(ctx context.Context, dir string) ([]StorageEntry, error) {
+	dir, err := cleanStoragePath(dir)
+	if err != nil {
+		return nil, err
+	}
+	var entries []StorageEntry
+	next := s.itemURL(dir, "children") + "?$top=1000"
+	for next != "" {
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, next, nil)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Value    []oneDriveItem `json:"value"`
+			NextLink string         `json:"@odata.nextLink"`
+		}
+		if err := getJSON(s.session, request, dir, &page); err != nil {
+			return nil, err
+		}
+		for _, item := range page.Value {
+			entries = append(entries, StorageEntry{
+				Name:     item.Name,
+				Path:     path.Join(dir, item.Name),
+				Dir:      item.Folder != nil,
+				Size:     item.Size,
+				Modified: item.LastModified,
+				Version:  item.ETag,
+			})
+		}
+		next = page.NextLink
+	}
+	sortEntries(entries)
+	return entries, nil
+}
+
+// Open downloads the file name. The version is read first, so a change
+// made while downloading makes the next save conflict rather than be lost.
+func (s *OneDriveStorage) Open(ctx context.Context, name string) (io.ReadCloser, string, error) {
+	name, err := cleanStoragePath(name)
+	if err != nil {
+		return nil, "", err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, s.itemURL(name, ""), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	var item oneDriveItem
+	if err := getJSON(s.session, request, name, &item); err != nil {
+		return nil, "", err
+	}
+	if request, err = http.NewRequestWithContext(ctx, http.MethodGet, s.itemURL(name, "content"), nil); err != nil {
+		return nil, "", err
+	}
+	response, err := s.session.do(request)
+	if err != nil {
+		return nil, "", err
+	}
+	if response.StatusCode != http.StatusOK {
+		defer response.Body.Close()
+		return nil, "", cloudError(name, response)
+	}
+	return response.Body, item.ETag, nil
+}
+
+// Save uploads the file name, letting Graph refuse it unless the file is
+// still at version
+func (s *OneDriveStorage) Save(ctx context.Context, name string, content io.Reader, version string) (string, error) {
+	name, err := cleanStoragePath(name)
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		return "", errors.New("no file name given")
+	}
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return "", err
+	}
+	location := s.itemURL(name, "content")
+	if version == "" {
+		location += "?@microsoft.graph.conflictBehavior=fail"
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPut, location, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Content-Type", "application/octet-stream")
+	if version != "" && version != AnyVersion {
+		request.Header.Set("If-Match", version)
+	}
+	response, err := s.session.do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	switch response.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+	case http.StatusPreconditionFailed, http.StatusConflict:
+		return "", ErrConflict
+	default:
+		return "", cloudError(name, response)
+	}
+	var item oneDriveItem
+	if err := json.NewDecoder(io.LimitReader(response.Body, 1<<20)).Decode(&item); err != nil {
+		return "", fmt.Errorf("invalid answer about %s: %w", name, err)
+	}
+	return item.ETag, nil
+}
+
+// itemURL returns the Graph URL of the item at name, or of its relation
+// such as "children" or "content"
+func (s *OneDriveStorage) itemURL(name, relation string) string {
+	if name == "" {
+		location := s.APIURL + "/root"
+		if relation != "" {
+			location += "/" + relation
+		}
+		return location
+	}
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	location := s.APIURL + "/root:/" + strings.Join(parts, "/") + ":"
+	if relation != "" {
+		location += "/" + relation
+	}
+	return location
+}
@@ -0,0 +1,300 @@
+package lesson
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTokenServer answers sign-ins with the access token "first" and
+// refreshes with "fresh", checking the PKCE verifier against the
+// challenge sent to the browser
+func newTokenServer(t *testing.T, challenge *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Form.Get("grant_type") {
+		case "authorization_code":
+			sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+			if r.Form.Get("code") != "code-from-browser" || base64.RawURLEncoding.EncodeToString(sum[:]) != *challenge {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"error":"invalid_request"}`)
+				return
+			}
+			fmt.Fprint(w, `{"access_token":"first","refresh_token":"refresh","expires_in":3600}`)
+		case "refresh_token":
+			if r.Form.Get("refresh_token") != "refresh" {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"error":"invalid_grant"}`)
+				return
+			}
+			fmt.Fprint(w, `{"access_token":"fresh","expires_in":3600}`)
+		}
+	}))
+}
+
+func TestOAuth(t *testing.T) {
+	var challenge string
+	tokens := newTokenServer(t, &challenge)
+	defer tokens.Close()
+
+	flow, err := StartOAuth(OAuthConfig{AuthURL: "https://login.example/authorize", TokenURL: tokens.URL, ClientID: "school-app", Scopes: []string{"files"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	authorize, err := url.Parse(flow.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := authorize.Query()
+	challenge = query.Get("code_challenge")
+	if query.Get("client_id") != "school-app" || query.Get("code_challenge_method") != "S256" || !strings.HasPrefix(query.Get("redirect_uri"), "http://127.0.0.1:") {
+		t.Fatalf("Unexpected authorization URL %s", flow.URL)
+	}
+
+	// The browser comes back, first from a page that does not know the state
+	if resp, err := http.Get(query.Get("redirect_uri") + "?code=forged&state=guess"); err != nil || resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected a wrong state to be ignored, got %v, %v", resp, err)
+	}
+	if _, err := http.Get(query.Get("redirect_uri") + "?code=code-from-browser&state=" + url.QueryEscape(query.Get("state"))); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	token, err := flow.Wait(ctx, nil)
+	if err != nil || token.AccessToken != "first" || token.RefreshToken != "refresh" || time.Until(token.Expiry) < time.Hour-time.Minute {
+		t.Fatalf("Expected the code to be swapped for tokens, got %+v, %v", token, err)
+	}
+
+	config := OAuthConfig{TokenURL: tokens.URL}
+	refreshed, err := config.Refresh(ctx, nil, *token)
+	if err != nil || refreshed.AccessToken != "fresh" || refreshed.RefreshToken != "refresh" {
+		t.Errorf("Expected the refresh token to be kept, got %+v, %v", refreshed, err)
+	}
+	if _, err := config.Refresh(ctx, nil, OAuthToken{RefreshToken: "revoked"}); !errors.Is(err, ErrSignInNeeded) {
+		t.Errorf("Expected a revoked refresh token to need signing in, got %v", err)
+	}
+}
+
+// fakeDrive is Google Drive as far as GoogleDriveStorage uses it
+type fakeDrive struct {
+	mu    sync.Mutex
+	files map[string]*fakeDriveFile
+	next  int
+}
+
+type fakeDriveFile struct {
+	name, parent, mimeType string
+	data                   []byte
+	version                int
+}
+
+var driveQuery = regexp.MustCompile(`^'(.*?)' in parents and trashed = false(?: and name = '(.*)')?$`)
+
+func (d *fakeDrive) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer fresh" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	describe := func(id string) map[string]any {
+		file := d.files[id]
+		return map[string]any{"id": id, "name": file.name, "mimeType": file.mimeType, "size": strconv.Itoa(len(file.data)), "version": strconv.Itoa(file.version)}
+	}
+	id := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/upload"), "/drive/v3/files/")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/drive/v3/files":
+		match := driveQuery.FindStringSubmatch(r.URL.Query().Get("q"))
+		name := strings.ReplaceAll(match[2], `\'`, `'`)
+		files := []map[string]any{}
+		for id, file := range d.files {
+			if file.parent == match[1] && (name == "" || file.name == name) {
+				files = append(files, describe(id))
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"files": files})
+	case r.Method == http.MethodPost && r.URL.Path == "/drive/v3/files":
+		var metadata struct {
+			Name    string   `json:"name"`
+			Parents []string `json:"parents"`
+		}
+		json.NewDecoder(r.Body).Decode(&metadata)
+		d.next++
+		id := fmt.Sprint("file", d.next)
+		d.files[id] = &fakeDriveFile{name: metadata.Name, parent: metadata.Parents[0], mimeType: "application/octet-stream", version: 1}
+		json.NewEncoder(w).Encode(describe(id))
+	case r.Method == http.MethodGet && r.URL.Query().Get("alt") == "media" && d.files[id] != nil:
+		w.Write(d.files[id].data)
+	case r.Method == http.MethodPatch && d.files[id] != nil:
+		d.files[id].data, _ = io.ReadAll(r.Body)
+		d.files[id].version++
+		json.NewEncoder(w).Encode(describe(id))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestGoogleDriveStorage(t *testing.T) {
+	var challenge string
+	tokens := newTokenServer(t, &challenge)
+	defer tokens.Close()
+	drive := &fakeDrive{files: map[string]*fakeDriveFile{
+		"lessons": {name: "Lessons", parent: "root", mimeType: googleFolderType},
+		"doc":     {name: "Notes", parent: "root", mimeType: "application/vnd.google-apps.document"},
+	}}
+	server := httptest.NewServer(drive)
+	defer server.Close()
+
+	var kept OAuthToken
+	// The access token ran out, so the first request refreshes it
+	storage := NewGoogleDriveStorage(OAuthConfig{TokenURL: tokens.URL}, OAuthToken{AccessToken: "stale", RefreshToken: "refresh"}, func(token OAuthToken) { kept = token })
+	storage.APIURL, storage.UploadURL = server.URL+"/drive/v3", server.URL+"/upload/drive/v3"
+	ctx := context.Background()
+
+	entries, err := storage.List(ctx, "")
+	if err != nil || len(entries) != 1 || entries[0].Name != "Lessons" || !entries[0].Dir {
+		t.Fatalf("Expected only the Lessons folder, without the Google document, got %+v, %v", entries, err)
+	}
+	if kept.AccessToken != "fresh" || kept.RefreshToken != "refresh" {
+		t.Errorf("Expected the refreshed token to be kept, got %+v", kept)
+	}
+
+	version, err := storage.Save(ctx, "Lessons/Teacher's words.otwd", strings.NewReader("bonjour"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storage.Save(ctx, "Lessons/Teacher's words.otwd", strings.NewReader("salut"), ""); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected creating an existing file to conflict, got %v", err)
+	}
+	localPath := t.TempDir() + "/words.otwd"
+	downloaded, err := DownloadFromStorage(ctx, storage, "Lessons/Teacher's words.otwd", localPath)
+	if data, _ := os.ReadFile(localPath); err != nil || downloaded != version || string(data) != "bonjour" {
+		t.Fatalf("Expected to download the saved version, got %q %q, %v", downloaded, data, err)
+	}
+	if _, err := storage.Save(ctx, "Lessons/Teacher's words.otwd", strings.NewReader("bonsoir"), version); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UploadToStorage(ctx, storage, localPath, "Lessons/Teacher's words.otwd", downloaded); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected saving over a changed file to conflict, got %v", err)
+	}
+	if _, _, err := storage.Open(ctx, "Lessons/missing.otwd"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a missing file to not exist, got %v", err)
+	}
+}
+
+// fakeOneDrive is Microsoft Graph as far as OneDriveStorage uses it,
+// keeping files by path
+type fakeOneDrive struct {
+	mu    sync.Mutex
+	files map[string][]byte
+	etags map[string]int
+}
+
+func (d *fakeOneDrive) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer fresh" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	rest := strings.TrimPrefix(r.URL.Path, "/me/drive/root")
+	name, relation := "", strings.TrimPrefix(rest, "/")
+	if strings.HasPrefix(rest, ":/") {
+		end := strings.LastIndex(rest, ":")
+		name, relation = rest[2:end], strings.TrimPrefix(rest[end+1:], "/")
+	}
+	item := func(name string) map[string]any {
+		return map[string]any{"name": name[strings.LastIndex(name, "/")+1:], "size": len(d.files[name]), "eTag": fmt.Sprintf(`"{%s},%d"`, name, d.etags[name])}
+	}
+	_, exists := d.files[name]
+	switch {
+	case r.Method == http.MethodGet && relation == "children":
+		value := []map[string]any{}
+		for file := range d.files {
+			if strings.TrimSuffix(file, "/"+item(file)["name"].(string)) == name || name == "" && !strings.Contains(file, "/") {
+				value = append(value, item(file))
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"value": value})
+	case r.Method == http.MethodGet && relation == "" && exists:
+		json.NewEncoder(w).Encode(item(name))
+	case r.Method == http.MethodGet && relation == "content" && exists:
+		w.Write(d.files[name])
+	case r.Method == http.MethodPut && relation == "content":
+		if exists && r.URL.Query().Get("@microsoft.graph.conflictBehavior") == "fail" {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		if match := r.Header.Get("If-Match"); match != "" && (!exists || match != item(name)["eTag"]) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		d.files[name], _ = io.ReadAll(r.Body)
+		d.etags[name]++
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(item(name))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestOneDriveStorage(t *testing.T) {
+	var challenge string
+	tokens := newTokenServer(t, &challenge)
+	defer tokens.Close()
+	server := httptest.NewServer(&fakeOneDrive{files: map[string][]byte{}, etags: map[string]int{}})
+	defer server.Close()
+
+	storage := NewOneDriveStorage(OAuthConfig{TokenURL: tokens.URL}, OAuthToken{AccessToken: "fresh", RefreshToken: "refresh", Expiry: time.Now().Add(time.Hour)}, nil)
+	storage.APIURL = server.URL + "/me/drive"
+	ctx := context.Background()
+
+	version, err := storage.Save(ctx, "Class 3B/words #1.otwd", strings.NewReader("bonjour"), "")
+	if err != nil || version == "" {
+		t.Fatalf("Expected a new file to be saved with a version, got %q, %v", version, err)
+	}
+	if _, err := storage.Save(ctx, "Class 3B/words #1.otwd", strings.NewReader("salut"), ""); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected creating an existing file to conflict, got %v", err)
+	}
+	entries, err := storage.List(ctx, "Class 3B")
+	if err != nil || len(entries) != 1 || entries[0].Path != "Class 3B/words #1.otwd" || entries[0].Version != version || entries[0].Size != 7 {
+		t.Fatalf("Expected the folder to hold the lesson, got %+v, %v", entries, err)
+	}
+
+	localPath := t.TempDir() + "/words.otwd"
+	downloaded, err := DownloadFromStorage(ctx, storage, "Class 3B/words #1.otwd", localPath)
+	if data, _ := os.ReadFile(localPath); err != nil || downloaded != version || string(data) != "bonjour" {
+		t.Fatalf("Expected to download the saved version, got %q %q, %v", downloaded, data, err)
+	}
+	if _, err := storage.Save(ctx, "Class 3B/words #1.otwd", strings.NewReader("bonsoir"), version); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UploadToStorage(ctx, storage, localPath, "Class 3B/words #1.otwd", downloaded); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected saving over a changed file to conflict, got %v", err)
+	}
+	if _, err := UploadToStorage(ctx, storage, localPath, "Class 3B/words #1.otwd", AnyVersion); err != nil {
+		t.Errorf("Expected overwriting to pass, got %v", err)
+	}
+
+	kept := OAuthToken{AccessToken: "stale", RefreshToken: "revoked"}
+	signedOut := NewOneDriveStorage(OAuthConfig{TokenURL: tokens.URL}, kept, func(token OAuthToken) { kept = token })
+	signedOut.APIURL = storage.APIURL
+	if _, err := signedOut.List(ctx, ""); !errors.Is(err, ErrSignInNeeded) || kept != (OAuthToken{}) {
+		t.Errorf("Expected a revoked sign-in to be forgotten and need signing in again, got %+v, %v", kept, err)
+	}
+}
@@ -0,0 +1,178 @@
+package lesson
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ClozeBlank replaces a deletion without a hint in a cloze prompt
+const ClozeBlank = "[...]"
+
+// clozePattern matches the Anki style masks {{c1::answer}} and
+// {{c1::answer::hint}}
+var clozePattern = regexp.MustCompile(`(?s)\{\{c(\d+)::(.*?)(?:::(.*?))?\}\}`)
+
+// ClozeDeletion is one mask in a cloze text
+type ClozeDeletion struct {
+	Number int
+	Answer string
+	Hint   string
+}
+
+// ClozeCard is what is asked for one cloze number: the text with the
+// deletions of that number blanked and all others filled in
+type ClozeCard struct {
+	Number int
+	// Prompt is the text with the asked deletions shown as [...] or [hint]
+	Prompt string
+	// Answers holds the answer of each blank in Prompt, in order
+	Answers []string
+	// Text is the complete text without masks
+	Text string
+	// Source is the text with masks the card was made from
+	Source string
+}
+
+// IsClozeText reports whether text contains cloze masks
+func IsClozeText(text string) bool {
+	return clozePattern.MatchString(text)
+}
+
+// ParseCloze returns the deletions in a cloze text in order of appearance
+func ParseCloze(text string) []ClozeDeletion {
+	var deletions []ClozeDeletion
+	for _, match := range clozePattern.FindAllStringSubmatch(text, -1) {
+		number, _ := strconv.Atoi(match[1])
+		deletions = append(deletions, ClozeDeletion{
+			Number: number,
+			Answer: strings.TrimSpace(match[2]),
+			Hint:   strings.TrimSpace(match[3]),
+		})
+	}
+	return deletions
+}
+
+// ClozeCards returns one card per cloze number in the text, lowest first
+func ClozeCards(text string) []ClozeCard {
+	seen := make(map[int]bool)
+	var numbers []int
+	for _, deletion := range ParseCloze(text) {
+		if !seen[deletion.Number] {
+			seen[deletion.Number] = true
+			numbers = append(numbers, deletion.Number)
+		}
+	}
+	sort.Ints(numbers)
+
+	cards := make([]ClozeCard, 0, len(numbers))
+	for _, number := range numbers {
+		card := ClozeCard{Number: number, Text: ClozePlainText(text), Source: text}
+		card.Prompt = card.Render(text, func(deletion ClozeDeletion) string {
+			card.Answers = append(card.Answers, deletion.Answer)
+			return clozeBlank(deletion)
+		})
+		cards = append(cards, card)
+	}
+	return cards
+}
+
+// Render returns text, usually the card's Source or an escaped copy of it,
+// with the deletions asked by the card replaced by what blank returns for
+// them and all other deletions filled in
+func (c ClozeCard) Render(text string, blank func(ClozeDeletion) string) string {
+	return renderCloze(text, func(deletion ClozeDeletion) string {
+		if deletion.Number != c.Number {
+			return deletion.Answer
+		}
+		return blank(deletion)
+	})
+}
+
+// ClozePlainText returns the text with every mask replaced by its answer
+func ClozePlainText(text string) string {
+	return renderCloze(text, func(deletion ClozeDeletion) string {
+		return deletion.Answer
+	})
+}
+
+// ClozeQuestionText returns the text with every mask blanked
+func ClozeQuestionText(text string) string {
+	return renderCloze(text, clozeBlank)
+}
+
+// Check reports whether given fills the blanks of the card. Several blanks
+// are answered in order, separated by semicolons or commas. Like the other
+// teach types, case and surrounding space are ignored.
+func (c ClozeCard) Check(given string) bool {
+	parts := []string{given}
+	if len(c.Answers) > 1 {
+		separator := ","
+		if strings.Contains(given, ";") {
+			separator = ";"
+		}
+		parts = strings.Split(given, separator)
+	}
+	if len(parts) != len(c.Answers) {
+		return false
+	}
+	for i, part := range parts {
+		if !strings.EqualFold(strings.TrimSpace(part), c.Answers[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewClozeItem creates a cloze item. Questions and Answers are filled with
+// the blanked text and the deletions, so formats without cloze support still
+// export something sensible.
+func NewClozeItem(id int, text, comment string) WordItem {
+	item := WordItem{
+		ID:        id,
+		Questions: []string{ClozeQuestionText(text)},
+		Comment:   comment,
+		Cloze:     text,
+	}
+	for _, deletion := range ParseCloze(text) {
+		item.Answers = append(item.Answers, deletion.Answer)
+	}
+	return item
+}
+
+// AddClozeItem adds a cloze item to the word list
+func (wl *WordList) AddClozeItem(text, comment string) error {
+	if !IsClozeText(text) {
+		return fmt.Errorf("no {{c1::...}} masks in %q", text)
+	}
+	wl.Items = append(wl.Items, NewClozeItem(len(wl.Items), text, comment))
+	return nil
+}
+
+// IsCloze reports whether the item is a cloze deletion rather than a
+// question/answer pair
+func (wi *WordItem) IsCloze() bool {
+	return wi.Cloze != ""
+}
+
+// renderCloze replaces every mask in text by what replace returns for it
+func renderCloze(text string, replace func(ClozeDeletion) string) string {
+	return clozePattern.ReplaceAllStringFunc(text, func(mask string) string {
+		match := clozePattern.FindStringSubmatch(mask)
+		number, _ := strconv.Atoi(match[1])
+		return replace(ClozeDeletion{
+			Number: number,
+			Answer: strings.TrimSpace(match[2]),
+			Hint:   strings.TrimSpace(match[3]),
+		})
+	})
+}
+
+func clozeBlank(deletion ClozeDeletion) string {
+	if deletion.Hint != "" {
+		return "[" + deletion.Hint + "]"
+	}
+	return ClozeBlank
+}
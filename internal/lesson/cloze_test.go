@@ -0,0 +1,107 @@
+package lesson
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func TestClozeCards(t *testing.T) {
+	text := "{{c1::Paris}} is the capital of {{c2::France::country}}, {{c1::Berlin}} of Germany"
+
+	cards := ClozeCards(text)
+	if len(cards) != 2 {
+		t.Fatalf("Expected 2 cards, got %d", len(cards))
+	}
+	if cards[0].Prompt != "[...] is the capital of France, [...] of Germany" {
+		t.Errorf("Unexpected first prompt %q", cards[0].Prompt)
+	}
+	if !equalStringSlices(cards[0].Answers, []string{"Paris", "Berlin"}) {
+		t.Errorf("Unexpected first answers %v", cards[0].Answers)
+	}
+	if cards[1].Prompt != "Paris is the capital of [country], Berlin of Germany" {
+		t.Errorf("Unexpected second prompt %q", cards[1].Prompt)
+	}
+	if cards[1].Text != "Paris is the capital of France, Berlin of Germany" {
+		t.Errorf("Unexpected text %q", cards[1].Text)
+	}
+}
+
+func TestClozeCardCheck(t *testing.T) {
+	card := ClozeCards("{{c1::gato}} y {{c1::perro}}")[0]
+	tests := []struct {
+		given string
+		want  bool
+	}{
+		{"Gato, perro", true},
+		{"gato; perro ", true},
+		{"perro, gato", false},
+		{"gato", false},
+	}
+	for _, tt := range tests {
+		if got := card.Check(tt.given); got != tt.want {
+			t.Errorf("Check(%q) = %v; want %v", tt.given, got, tt.want)
+		}
+	}
+
+	single := ClozeCards("El {{c1::gato, negro}} duerme")[0]
+	if !single.Check("gato, negro") {
+		t.Error("A single blank should accept commas")
+	}
+}
+
+func TestAddClozeItem(t *testing.T) {
+	var list WordList
+	if err := list.AddClozeItem("Water boils at {{c1::100}} degrees", ""); err != nil {
+		t.Fatalf("AddClozeItem failed: %v", err)
+	}
+	if err := list.AddClozeItem("No masks here", ""); err == nil {
+		t.Error("Expected an error for text without masks")
+	}
+
+	item := list.Items[0]
+	if !item.IsCloze() || item.Questions[0] != "Water boils at [...] degrees" || item.Answers[0] != "100" {
+		t.Errorf("Unexpected cloze item %+v", item)
+	}
+}
+
+func TestLoadAnkiCloze(t *testing.T) {
+	ankiFile := filepath.Join(t.TempDir(), "cloze.anki2")
+	db, err := sql.Open("sqlite3", ankiFile)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	for _, statement := range []string{
+		`CREATE TABLE notes (id INTEGER PRIMARY KEY, guid TEXT, flds TEXT, tags TEXT)`,
+		`CREATE TABLE cards (id INTEGER PRIMARY KEY, nid INTEGER, queue INTEGER, ivl INTEGER, factor INTEGER, reps INTEGER, lapses INTEGER)`,
+		`INSERT INTO notes VALUES (1, 'a1', 'The <b>{{c1::mitochondria}}</b> is the powerhouse of the {{c2::cell}}' || char(31) || 'biology', ' science ')`,
+		`INSERT INTO notes VALUES (2, 'b2', 'hello' || char(31) || 'hallo', '')`,
+		`INSERT INTO cards VALUES (1, 1, 2, 10, 2500, 4, 1)`,
+		`INSERT INTO cards VALUES (2, 1, 2, 3, 2300, 2, 0)`,
+		`INSERT INTO cards VALUES (3, 2, 0, 0, 0, 0, 0)`,
+	} {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("Failed to set up database: %v", err)
+		}
+	}
+	db.Close()
+
+	lessonData, err := NewFileLoader().LoadFile(ankiFile)
+	if err != nil {
+		t.Fatalf("Failed to load Anki file: %v", err)
+	}
+	if len(lessonData.List.Items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(lessonData.List.Items))
+	}
+
+	cloze := lessonData.List.Items[0]
+	if cloze.Cloze != "The {{c1::mitochondria}} is the powerhouse of the {{c2::cell}}" || cloze.Comment != "biology" {
+		t.Errorf("Unexpected cloze item %+v", cloze)
+	}
+	if reviews, _ := cloze.ExtraInt(ExtraAnkiReviews); reviews != 6 {
+		t.Errorf("Expected the reviews of both cards, got %d", reviews)
+	}
+	if lessonData.List.Items[1].IsCloze() {
+		t.Error("A plain note should not become a cloze item")
+	}
+}
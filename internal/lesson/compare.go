@@ -0,0 +1,117 @@
+package lesson
+
+// LessonComparison tells which items two lessons have in common, by index in
+// their item lists
+type LessonComparison struct {
+	// Same maps items of the left lesson to right items with the same
+	// questions and answers, ignoring case, spacing and order
+	Same map[int]int
+	// Changed maps items of the left lesson to right items with the same
+	// questions but other answers
+	Changed map[int]int
+}
+
+// RightSame returns Same the other way round, from right to left items
+func (c *LessonComparison) RightSame() map[int]int {
+	return invertIndexes(c.Same)
+}
+
+// RightChanged returns Changed the other way round, from right to left
+// items
+func (c *LessonComparison) RightChanged() map[int]int {
+	return invertIndexes(c.Changed)
+}
+
+// CompareLessons matches the items of two lessons, such as an old and a new
+// version of the same course. Every item is matched at most once.
+func CompareLessons(left, right *LessonData) *LessonComparison {
+	comparison := &LessonComparison{Same: make(map[int]int), Changed: make(map[int]int)}
+
+	byPair := make(map[string][]int)
+	byQuestion := make(map[string][]int)
+	for i, item := range right.List.Items {
+		question := normalizeForDedupe(item.Questions)
+		pair := question + "\x01" + normalizeForDedupe(item.Answers)
+		byPair[pair] = append(byPair[pair], i)
+		byQuestion[question] = append(byQuestion[question], i)
+	}
+
+	matched := make(map[int]bool)
+	take := func(candidates []int) (int, bool) {
+		for _, i := range candidates {
+			if !matched[i] {
+				matched[i] = true
+				return i, true
+			}
+		}
+		return 0, false
+	}
+
+	// Exact matches first, so a changed item does not take the place of
+	// an identical one further down
+	for i, item := range left.List.Items {
+		if j, ok := take(byPair[normalizeForDedupe(item.Questions)+"\x01"+normalizeForDedupe(item.Answers)]); ok {
+			comparison.Same[i] = j
+		}
+	}
+	for i, item := range left.List.Items {
+		if _, same := comparison.Same[i]; same {
+			continue
+		}
+		if j, ok := take(byQuestion[normalizeForDedupe(item.Questions)]); ok {
+			comparison.Changed[i] = j
+		}
+	}
+	return comparison
+}
+
+// CopyItems appends copies of the items of source at indexes to target,
+// with new ids, and copies their test results along. Unlike the bulk edit
+// operations it changes target in place. It returns the indexes of the
+// copies in target.
+func CopyItems(target, source *LessonData, indexes []int) []int {
+	nextID := 0
+	for _, item := range target.List.Items {
+		nextID = max(nextID, item.ID+1)
+	}
+
+	idMap := make(map[int]int)
+	var copied []int
+	for _, i := range indexes {
+		if i < 0 || i >= len(source.List.Items) {
+			continue
+		}
+		item := source.List.Items[i]
+		idMap[item.ID] = nextID
+		item.ID = nextID
+		nextID++
+		copied = append(copied, len(target.List.Items))
+		target.List.Items = append(target.List.Items, item)
+	}
+
+	for _, test := range source.List.Tests {
+		results := Test{Date: test.Date}
+		for _, result := range test.Results {
+			if newID, ok := idMap[result.ItemID]; ok {
+				result.ItemID = newID
+				results.Results = append(results.Results, result)
+			}
+		}
+		if len(results.Results) > 0 {
+			target.List.Tests = append(target.List.Tests, results)
+		}
+	}
+
+	if len(copied) > 0 {
+		target.Changed = true
+	}
+	return copied
+}
+
+func invertIndexes(indexes map[int]int) map[int]int {
+	inverted := make(map[int]int, len(indexes))
+	for from, to := range indexes {
+		inverted[to] = from
+	}
+	return inverted
+}
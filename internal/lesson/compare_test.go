@@ -0,0 +1,67 @@
+package lesson
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCompareLessons(t *testing.T) {
+	left := NewLessonData()
+	left.List.Items = []WordItem{
+		{ID: 0, Questions: []string{"cat"}, Answers: []string{"gato"}},
+		{ID: 1, Questions: []string{"dog"}, Answers: []string{"perro"}},
+		{ID: 2, Questions: []string{"bird"}, Answers: []string{"pájaro"}},
+	}
+	right := NewLessonData()
+	right.List.Items = []WordItem{
+		{ID: 0, Questions: []string{"Dog"}, Answers: []string{"perro", "can"}},
+		{ID: 1, Questions: []string{"dog"}, Answers: []string{"perro"}},
+		{ID: 2, Questions: []string{" cat "}, Answers: []string{"Gato"}},
+		{ID: 3, Questions: []string{"horse"}, Answers: []string{"caballo"}},
+	}
+
+	comparison := CompareLessons(left, right)
+	if !reflect.DeepEqual(comparison.Same, map[int]int{0: 2, 1: 1}) {
+		t.Errorf("Unexpected same items: %v", comparison.Same)
+	}
+	if len(comparison.Changed) != 0 {
+		t.Errorf("Expected the changed dog to lose to the identical one, got %v", comparison.Changed)
+	}
+	if !reflect.DeepEqual(comparison.RightSame(), map[int]int{2: 0, 1: 1}) {
+		t.Errorf("Unexpected right same items: %v", comparison.RightSame())
+	}
+
+	left.List.Items[1].Answers = []string{"chucho"}
+	comparison = CompareLessons(left, right)
+	if comparison.Changed[1] != 0 || len(comparison.Changed) != 1 {
+		t.Errorf("Expected the dog to be changed, got %v", comparison.Changed)
+	}
+}
+
+func TestCopyItems(t *testing.T) {
+	when := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	source := NewLessonData()
+	source.List.Items = []WordItem{
+		{ID: 4, Questions: []string{"cat"}, Answers: []string{"gato"}},
+		{ID: 7, Questions: []string{"dog"}, Answers: []string{"perro"}},
+	}
+	source.List.Tests = []Test{{Date: &when, Results: []TestResult{{Result: "right", ItemID: 4}, {Result: "wrong", ItemID: 7}}}}
+
+	target := NewLessonData()
+	target.List.Items = []WordItem{{ID: 0, Questions: []string{"bird"}, Answers: []string{"pájaro"}}}
+
+	copied := CopyItems(target, source, []int{1, 5})
+	if !reflect.DeepEqual(copied, []int{1}) {
+		t.Fatalf("Expected one copy at index 1, got %v", copied)
+	}
+	if target.List.Items[1].ID != 1 || target.List.Items[1].Questions[0] != "dog" || !target.Changed {
+		t.Errorf("Unexpected copy: %+v", target.List.Items[1])
+	}
+	if len(target.List.Tests) != 1 || !reflect.DeepEqual(target.List.Tests[0].Results, []TestResult{{Result: "wrong", ItemID: 1}}) {
+		t.Errorf("Expected the copy's results to come along, got %+v", target.List.Tests)
+	}
+	if source.List.Items[1].ID != 7 {
+		t.Error("Expected the source to be left alone")
+	}
+}
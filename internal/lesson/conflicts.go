@@ -0,0 +1,120 @@
+package lesson
+
+import "slices"
+
+// ConflictChoice tells which version of a conflicting edit to keep
+type ConflictChoice int
+
+const (
+	// KeepIncoming keeps the version of the edit being saved, as
+	// MergeEdits does
+	KeepIncoming ConflictChoice = iota
+	// KeepCurrent keeps the version that was already saved
+	KeepCurrent
+	// KeepBoth keeps both versions of an item, the current one as a new
+	// item after the incoming one. List fields hold one value, so for them
+	// it is KeepIncoming.
+	KeepBoth
+)
+
+// Versions returns the incoming and the current version of a conflicting
+// item, nil for the side that deleted it
+func (c EditConflict) Versions() (incoming, current *WordItem) {
+	if c.KeptCurrent {
+		return c.Lost, c.Kept
+	}
+	return c.Kept, c.Lost
+}
+
+// ResolveConflicts returns merged, as MergeEdits returned it, with the
+// version of every conflict chosen in choices, in step with conflicts.
+// Conflicts without a choice keep the incoming version.
+func ResolveConflicts(merged *LessonData, conflicts []EditConflict, choices []ConflictChoice) *LessonData {
+	resolved := *merged
+	resolved.List.Items = slices.Clone(merged.List.Items)
+	resolved.List.Tags = slices.Clone(merged.List.Tags)
+	nextID := 0
+	for _, item := range resolved.List.Items {
+		nextID = max(nextID, item.ID+1)
+	}
+	for _, conflict := range conflicts {
+		for _, item := range []*WordItem{conflict.Kept, conflict.Lost} {
+			if item != nil {
+				nextID = max(nextID, item.ID+1)
+			}
+		}
+	}
+
+	for i, conflict := range conflicts {
+		choice := KeepIncoming
+		if i < len(choices) {
+			choice = choices[i]
+		}
+		if conflict.ItemID < 0 {
+			if choice == KeepCurrent {
+				setListField(&resolved.List, conflict.Field, conflict.LostValue)
+			}
+			continue
+		}
+
+		incoming, current := conflict.Versions()
+		var keep []*WordItem
+		switch choice {
+		case KeepIncoming:
+			keep = []*WordItem{incoming}
+		case KeepCurrent:
+			keep = []*WordItem{current}
+		case KeepBoth:
+			keep = []*WordItem{incoming, current}
+		}
+		var replacement []WordItem
+		for _, item := range keep {
+			if item == nil {
+				continue
+			}
+			kept := *item
+			if len(replacement) > 0 {
+				if itemsEqual(kept, replacement[0]) {
+					continue
+				}
+				kept.ID = nextID
+				nextID++
+			}
+			replacement = append(replacement, kept)
+		}
+		index := slices.IndexFunc(resolved.List.Items, func(item WordItem) bool { return item.ID == conflict.ItemID })
+		if index < 0 {
+			resolved.List.Items = append(resolved.List.Items, replacement...)
+		} else {
+			resolved.List.Items = slices.Replace(resolved.List.Items, index, index+1, replacement...)
+		}
+	}
+	resolved.Changed = true
+	return &resolved
+}
+
+// setListField sets the list field MergeEdits names field
+func setListField(list *WordList, field, value string) {
+	switch field {
+	case "title":
+		list.Title = value
+	case "questionLanguage":
+		list.QuestionLanguage = value
+	case "answerLanguage":
+		list.AnswerLanguage = value
+	case "author":
+		list.Author = value
+	case "description":
+		list.Description = value
+	case "license":
+		list.License = value
+	case "level":
+		list.Level = value
+	case "uuid":
+		list.UUID = value
+	case "sync":
+		list.Sync = value
+	case "tags":
+		list.Tags = ParseTags(value)
+	}
+}
@@ -0,0 +1,353 @@
+package lesson
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+)
+
+// CSVDialect describes how a CSV file is written
+type CSVDialect struct {
+	Delimiter rune
+	Quote     rune
+	HasHeader bool
+	Encoding  string
+}
+
+// DefaultCSVDialect is plain comma separated UTF-8 without a header
+func DefaultCSVDialect() CSVDialect {
+	return CSVDialect{Delimiter: ',', Quote: '"', Encoding: EncodingUTF8}
+}
+
+// csvDelimiterCandidates are the delimiters the sniffer considers, in order
+// of preference when several fit equally well
+var csvDelimiterCandidates = []rune{',', ';', '\t', '|'}
+
+// csvHeaderWords are column names that mark the first row as a header
+var csvHeaderWords = map[string]bool{
+	"question": true, "questions": true, "answer": true, "answers": true,
+	"front": true, "back": true, "term": true, "definition": true,
+	"word": true, "words": true, "translation": true, "comment": true,
+}
+
+// csvLanguageNames holds English and native language names, since the saver
+// writes the lesson languages as column headers
+var csvLanguageNames = func() map[string]bool {
+	names := make(map[string]bool)
+	codes := []string{
+		"ar", "bg", "ca", "cs", "cy", "da", "de", "el", "en", "eo", "es", "et",
+		"eu", "fa", "fi", "fr", "fy", "ga", "gl", "he", "hi", "hr", "hu", "id",
+		"is", "it", "ja", "ko", "la", "lt", "lv", "nl", "no", "pl", "pt", "ro",
+		"ru", "sk", "sl", "sr", "sv", "sw", "th", "tr", "uk", "vi", "zh",
+	}
+	for _, code := range codes {
+		tag := language.Make(code)
+		names[strings.ToLower(display.English.Languages().Name(tag))] = true
+		names[strings.ToLower(display.Self.Name(tag))] = true
+	}
+	return names
+}()
+
+// SniffCSVDialect guesses the delimiter, quote character, header presence and
+// encoding of CSV data
+func SniffCSVDialect(data []byte) CSVDialect {
+	dialect := DefaultCSVDialect()
+	dialect.Encoding = detectTextEncoding(data)
+
+	text, err := decodeText(data, dialect.Encoding)
+	if err != nil {
+		return dialect
+	}
+
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimRight(line, "\r"); strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+		if len(lines) == 50 {
+			break
+		}
+	}
+	if len(lines) == 0 {
+		return dialect
+	}
+
+	dialect.Delimiter = sniffDelimiter(lines)
+	dialect.Quote = sniffQuote(lines, dialect.Delimiter)
+
+	if records := parseCSVRecords(strings.Join(lines, "\n"), dialect.Delimiter, dialect.Quote); len(records) > 1 {
+		dialect.HasHeader = isCSVHeader(records[0])
+	}
+	return dialect
+}
+
+// sniffDelimiter picks the candidate that splits most lines into the same
+// number of columns
+func sniffDelimiter(lines []string) rune {
+	best, bestScore := csvDelimiterCandidates[0], 0
+	for _, candidate := range csvDelimiterCandidates {
+		counts := make(map[int]int)
+		for _, line := range lines {
+			counts[countOutsideQuotes(line, candidate)]++
+		}
+
+		mode, modeLines := 0, 0
+		for count, n := range counts {
+			if count > 0 && (n > modeLines || (n == modeLines && count > mode)) {
+				mode, modeLines = count, n
+			}
+		}
+		if modeLines > bestScore {
+			best, bestScore = candidate, modeLines
+		}
+	}
+	return best
+}
+
+// countOutsideQuotes counts delimiter occurrences that are not inside a
+// double-quoted field
+func countOutsideQuotes(line string, delimiter rune) int {
+	count := 0
+	inQuotes := false
+	for _, r := range line {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case r == delimiter && !inQuotes:
+			count++
+		}
+	}
+	return count
+}
+
+// sniffQuote returns '\” when fields are quoted with single quotes
+func sniffQuote(lines []string, delimiter rune) rune {
+	single, double := 0, 0
+	for _, line := range lines {
+		for _, field := range strings.Split(line, string(delimiter)) {
+			field = strings.TrimSpace(field)
+			if len(field) < 2 {
+				continue
+			}
+			switch {
+			case field[0] == '"' && field[len(field)-1] == '"':
+				double++
+			case field[0] == '\'' && field[len(field)-1] == '\'':
+				single++
+			}
+		}
+	}
+	if single > double {
+		return '\''
+	}
+	return '"'
+}
+
+// isCSVHeader reports whether the first record contains column names
+func isCSVHeader(record []string) bool {
+	if len(record) >= 3 && strings.EqualFold(strings.TrimSpace(record[2]), "Comment") {
+		return true
+	}
+	if len(record) < 2 {
+		return false
+	}
+	for _, field := range record[:2] {
+		name := strings.ToLower(strings.TrimSpace(field))
+		if !csvHeaderWords[name] && !csvLanguageNames[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// parseCSVRecords splits CSV text into records. Quoted fields may contain
+// delimiters, newlines and doubled quote characters; a quote inside an
+// unquoted field is kept literally.
+func parseCSVRecords(text string, delimiter, quote rune) [][]string {
+	var records [][]string
+	var record []string
+	var field strings.Builder
+	inQuotes, quoted := false, false
+
+	runes := []rune(strings.ReplaceAll(text, "\r\n", "\n"))
+	endField := func() {
+		record = append(record, field.String())
+		field.Reset()
+		quoted = false
+	}
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case inQuotes && r == quote:
+			if i+1 < len(runes) && runes[i+1] == quote {
+				field.WriteRune(quote)
+				i++
+			} else {
+				inQuotes = false
+			}
+		case inQuotes:
+			field.WriteRune(r)
+		case r == quote && field.Len() == 0 && !quoted:
+			inQuotes, quoted = true, true
+		case r == delimiter:
+			endField()
+		case r == '\n':
+			endField()
+			records = append(records, record)
+			record = nil
+		default:
+			field.WriteRune(r)
+		}
+	}
+	if field.Len() > 0 || quoted || len(record) > 0 {
+		endField()
+		records = append(records, record)
+	}
+	return records
+}
+
+// SniffCSVFile guesses the dialect of a CSV file
+func (fl *FileLoader) SniffCSVFile(filePath string) (CSVDialect, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return CSVDialect{}, err
+	}
+	dialect := SniffCSVDialect(data)
+	if name := fl.EncodingOverride(filePath); name != "" {
+		dialect.Encoding = name
+	}
+	return dialect, nil
+}
+
+// LoadCSVWithDialect loads a CSV file written in the given dialect. A header
+// row, when present, provides the question and answer languages.
+func (fl *FileLoader) LoadCSVWithDialect(filePath string, dialect CSVDialect) (*LessonData, error) {
+	log.Printf("[ACTION] FileLoader.LoadCSVWithDialect() - parsing CSV with delimiter %q", dialect.Delimiter)
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		log.Printf("[ERROR] Failed to open CSV file: %v", err)
+		return nil, err
+	}
+
+	text, err := decodeText(data, dialect.Encoding)
+	if err != nil {
+		log.Printf("[ERROR] Failed to decode CSV file: %v", err)
+		return nil, err
+	}
+
+	if dialect.Delimiter == 0 {
+		dialect.Delimiter = ','
+	}
+	if dialect.Quote == 0 {
+		dialect.Quote = '"'
+	}
+	records := parseCSVRecords(text, dialect.Delimiter, dialect.Quote)
+
+	lessonData := NewLessonData()
+	lessonData.List.Title = filepath.Base(filePath)
+
+	if dialect.HasHeader && len(records) > 0 {
+		header := records[0]
+		records = records[1:]
+		if len(header) >= 2 {
+			if name := strings.TrimSpace(header[0]); !csvHeaderWords[strings.ToLower(name)] {
+				lessonData.List.QuestionLanguage = name
+			}
+			if name := strings.TrimSpace(header[1]); !csvHeaderWords[strings.ToLower(name)] {
+				lessonData.List.AnswerLanguage = name
+			}
+		}
+	}
+
+	for _, record := range records {
+		if len(record) < 2 {
+			continue // Skip lines with insufficient data
+		}
+
+		// Parse questions and answers (may be comma-separated within cells)
+		questions := fl.parseWordString(strings.TrimSpace(record[0]))
+		answers := fl.parseWordString(strings.TrimSpace(record[1]))
+
+		comment := ""
+		if len(record) > 2 {
+			comment = strings.TrimSpace(record[2])
+		}
+
+		if len(questions) > 0 && len(answers) > 0 {
+			lessonData.List.Items = append(lessonData.List.Items, WordItem{
+				ID:        len(lessonData.List.Items),
+				Questions: questions,
+				Answers:   answers,
+				Comment:   comment,
+			})
+		}
+	}
+
+	log.Printf("[SUCCESS] FileLoader.LoadCSVWithDialect() - loaded %d word pairs", len(lessonData.List.Items))
+	return lessonData, nil
+}
+
+// SaveOptions configures how the FileSaver writes formats that have choices
+type SaveOptions struct {
+	// CSVDelimiter separates CSV columns; zero means a comma
+	CSVDelimiter rune
+	// CSVQuote quotes CSV fields; zero means a double quote
+	CSVQuote rune
+	// CSVOmitHeader leaves out the header row with the language names
+	CSVOmitHeader bool
+	// CSVUseCRLF ends CSV lines with \r\n instead of \n
+	CSVUseCRLF bool
+	// Encoding of text exports such as CSV; empty means UTF-8
+	Encoding string
+	// Backups is the number of earlier versions kept as .bak copies when
+	// saving over a file
+	Backups int
+	// Passphrase encrypts lessons saved as .otsec
+	Passphrase string
+	// PackSigningKey signs lesson packs, when set
+	PackSigningKey *SigningKey
+	// MediaStorage is MediaEmbed to store the media of .otmd lessons in
+	// the archive, or MediaLink to refer to it by path; empty means
+	// DefaultMediaStorage
+	MediaStorage string
+	// ImageCompression shrinks the pictures embedded in .otmd and .otio
+	// lessons
+	ImageCompression ImageCompression
+	// HTMLTheme styles HTML exports instead of the lesson's own theme
+	HTMLTheme string
+	// HTMLInteractive hides the answers of HTML exports until clicked
+	HTMLInteractive bool
+}
+
+// DefaultSaveOptions returns comma separated UTF-8 CSV with a header, and
+// the default number of .bak copies and image compression
+func DefaultSaveOptions() SaveOptions {
+	return SaveOptions{CSVDelimiter: ',', CSVQuote: '"', Encoding: EncodingUTF8, Backups: DefaultBackups(), ImageCompression: DefaultImageCompression()}
+}
+
+// ExcelEuropeSaveOptions returns CSV options that Excel opens correctly in
+// locales using a decimal comma, where it expects semicolons
+func ExcelEuropeSaveOptions() SaveOptions {
+	return SaveOptions{CSVDelimiter: ';', CSVQuote: '"', CSVUseCRLF: true, Encoding: EncodingUTF8BOM, Backups: DefaultBackups(), ImageCompression: DefaultImageCompression()}
+}
+
+// formatCSVRecord joins fields into one CSV line without line ending
+func formatCSVRecord(fields []string, delimiter, quote rune) string {
+	formatted := make([]string, len(fields))
+	for i, field := range fields {
+		needsQuotes := strings.ContainsRune(field, delimiter) || strings.ContainsRune(field, quote) ||
+			strings.ContainsAny(field, "\r\n") || strings.TrimSpace(field) != field
+		if needsQuotes {
+			q := string(quote)
+			field = q + strings.ReplaceAll(field, q, q+q) + q
+		}
+		formatted[i] = field
+	}
+	return strings.Join(formatted, string(delimiter))
+}
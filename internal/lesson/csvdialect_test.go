@@ -0,0 +1,100 @@
+package lesson
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSniffCSVDialect(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		delimiter rune
+		quote     rune
+		hasHeader bool
+	}{
+		{"comma", "house,huis\ntree,boom\n", ',', '"', false},
+		{"semicolon", "Dutch;English\nhuis;house\n\"boom, groot\";tree\n", ';', '"', true},
+		{"tab", "question\tanswer\ncat\tkat\n", '\t', '"', true},
+		{"single quotes", "'a, b';c\n'd';'e'\n", ';', '\'', false},
+		{"comment header", "English,Spanish,Comment\ncat,gato,\n", ',', '"', true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialect := SniffCSVDialect([]byte(tt.data))
+			if dialect.Delimiter != tt.delimiter || dialect.Quote != tt.quote || dialect.HasHeader != tt.hasHeader {
+				t.Errorf("SniffCSVDialect() = %+v; want delimiter %q, quote %q, header %v",
+					dialect, tt.delimiter, tt.quote, tt.hasHeader)
+			}
+		})
+	}
+}
+
+func TestLoadCSVDetectsEncoding(t *testing.T) {
+	dir := t.TempDir()
+	loader := NewFileLoader()
+
+	// "café;koffie" in windows-1252
+	ansiFile := filepath.Join(dir, "ansi.csv")
+	if err := os.WriteFile(ansiFile, []byte("caf\xe9;koffie\r\nth\xe9;thee\r\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	lessonData, err := loader.LoadFile(ansiFile)
+	if err != nil {
+		t.Fatalf("Failed to load windows-1252 CSV: %v", err)
+	}
+	if len(lessonData.List.Items) != 2 || lessonData.List.Items[0].Questions[0] != "café" {
+		t.Errorf("Unexpected items: %+v", lessonData.List.Items)
+	}
+
+	bomFile := filepath.Join(dir, "bom.csv")
+	if err := os.WriteFile(bomFile, []byte("\xef\xbb\xbfDutch,English\nhuis,house\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	lessonData, err = loader.LoadFile(bomFile)
+	if err != nil {
+		t.Fatalf("Failed to load CSV with BOM: %v", err)
+	}
+	if lessonData.List.QuestionLanguage != "Dutch" || len(lessonData.List.Items) != 1 {
+		t.Errorf("Header not detected after BOM: %+v", lessonData.List)
+	}
+}
+
+func TestSaveCSVWithExcelEuropeOptions(t *testing.T) {
+	lessonData := NewLessonData()
+	lessonData.List.QuestionLanguage = "Français"
+	lessonData.List.AnswerLanguage = "Nederlands"
+	lessonData.List.AddWordItem([]string{"pomme"}, []string{"appel"}, "fruit; rood")
+	lessonData.List.AddWordItem([]string{"été"}, []string{"zomer"}, "")
+
+	csvFile := filepath.Join(t.TempDir(), "europe.csv")
+	saver := NewFileSaverWithOptions(ExcelEuropeSaveOptions())
+	if err := saver.SaveFile(lessonData, csvFile); err != nil {
+		t.Fatalf("Failed to save CSV: %v", err)
+	}
+
+	content, err := os.ReadFile(csvFile)
+	if err != nil {
+		t.Fatalf("Failed to read saved file: %v", err)
+	}
+	if !bytes.HasPrefix(content, []byte("\xef\xbb\xbfFrançais;Nederlands;Comment;")) {
+		t.Errorf("Expected BOM and semicolon header, got %q", content[:40])
+	}
+	if !bytes.Contains(content, []byte("pomme;appel;\"fruit; rood\";\r\n")) {
+		t.Errorf("Expected quoted comment and CRLF line ending in %q", content)
+	}
+
+	reloaded, err := NewFileLoader().LoadFile(csvFile)
+	if err != nil {
+		t.Fatalf("Failed to reload CSV: %v", err)
+	}
+	if reloaded.List.QuestionLanguage != "Français" || len(reloaded.List.Items) != 2 {
+		t.Fatalf("Unexpected reloaded lesson: %+v", reloaded.List)
+	}
+	if reloaded.List.Items[0].Comment != "fruit; rood" || reloaded.List.Items[1].Questions[0] != "été" {
+		t.Errorf("Round trip changed items: %+v", reloaded.List.Items)
+	}
+}
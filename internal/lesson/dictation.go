@@ -0,0 +1,158 @@
+package lesson
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// DictationResourceKey is the LessonData.Resources key holding the lesson's
+// dictation settings
+const DictationResourceKey = "dictation"
+
+// ExtraDictationAttempts is the WordItem.Extras key overriding the number of
+// attempts for one item, for instance a long sentence
+const ExtraDictationAttempts = "dictation.attempts"
+
+// Defaults of the dictation settings
+const (
+	DefaultDictationAttempts = 3
+	DefaultDictationSlowRate = 0.7
+)
+
+// audioExtensions are the media file extensions dictated
+var audioExtensions = map[string]bool{
+	".mp3": true, ".wav": true, ".ogg": true, ".oga": true, ".opus": true,
+	".flac": true, ".m4a": true, ".aac": true, ".wma": true,
+}
+
+// DictationSettings are the per-lesson options of the dictation teach type
+type DictationSettings struct {
+	// Attempts is how often an item may be answered before the answer is
+	// shown
+	Attempts int `json:"attempts"`
+	// SlowRate is the playback rate of the slow-down button
+	SlowRate float64 `json:"slowRate"`
+}
+
+// DefaultDictationSettings returns the settings of lessons that have none
+// stored
+func DefaultDictationSettings() DictationSettings {
+	return DictationSettings{Attempts: DefaultDictationAttempts, SlowRate: DefaultDictationSlowRate}
+}
+
+// DictationSettings returns the lesson's dictation settings. They are stored
+// as a plain map, so they look the same after a JSON round trip.
+func (ld *LessonData) DictationSettings() DictationSettings {
+	settings := DefaultDictationSettings()
+	stored, ok := ld.Resources[DictationResourceKey].(map[string]interface{})
+	if !ok {
+		return settings
+	}
+	switch attempts := stored["attempts"].(type) {
+	case int:
+		settings.Attempts = attempts
+	case float64:
+		settings.Attempts = int(attempts)
+	}
+	if rate, ok := stored["slowRate"].(float64); ok && rate > 0 && rate <= 1 {
+		settings.SlowRate = rate
+	}
+	settings.Attempts = max(1, settings.Attempts)
+	return settings
+}
+
+// SetDictationSettings stores the lesson's dictation settings
+func (ld *LessonData) SetDictationSettings(settings DictationSettings) {
+	if ld.Resources == nil {
+		ld.Resources = make(map[string]interface{})
+	}
+	ld.Resources[DictationResourceKey] = map[string]interface{}{
+		"attempts": max(1, settings.Attempts),
+		"slowRate": settings.SlowRate,
+	}
+	ld.Changed = true
+}
+
+// DictationAttempts returns the number of attempts allowed for the item at
+// itemIndex: its own limit if it has one, the lesson's otherwise
+func (ld *LessonData) DictationAttempts(itemIndex int) int {
+	if itemIndex >= 0 && itemIndex < len(ld.List.Items) {
+		if attempts, ok := ld.List.Items[itemIndex].ExtraInt(ExtraDictationAttempts); ok && attempts > 0 {
+			return attempts
+		}
+	}
+	return ld.DictationSettings().Attempts
+}
+
+// IsAudioFile reports whether a media file name or URL points to audio
+func IsAudioFile(name string) bool {
+	if parsed, err := url.Parse(name); err == nil && parsed.Scheme != "" && len(parsed.Scheme) > 1 {
+		name = parsed.Path
+	}
+	return audioExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// DictationItems returns the indices of the items whose media is audio and
+// that have an answer to type, leaving out suspended items
+func DictationItems(lessonData *LessonData) []int {
+	var indices []int
+	for i, item := range lessonData.List.Items {
+		if item.IsSuspended() {
+			continue
+		}
+		if filename, _, ok := item.GetMediaInfo(); ok && IsAudioFile(filename) && len(item.Answers) > 0 {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// ResolveMediaPath returns where the media of an item is found: remote
+// media and absolute paths as they are, relative paths next to the lesson
+func ResolveMediaPath(lessonPath, filename string, remote bool) string {
+	if remote || filepath.IsAbs(filename) || lessonPath == "" {
+		return filename
+	}
+	return filepath.Join(filepath.Dir(lessonPath), filename)
+}
+
+// Dictation tracks the attempts at one dictated item
+type Dictation struct {
+	Answers     []string
+	MaxAttempts int
+	Attempts    int
+	Correct     bool
+}
+
+// NewDictation starts the dictation of the item at itemIndex
+func NewDictation(lessonData *LessonData, itemIndex int) *Dictation {
+	return &Dictation{
+		Answers:     lessonData.List.Items[itemIndex].Answers,
+		MaxAttempts: lessonData.DictationAttempts(itemIndex),
+	}
+}
+
+// Try checks an attempt and reports whether it was right. Attempts after
+// the item is done are ignored.
+func (d *Dictation) Try(checker *AnswerChecker, given string) bool {
+	if d.Done() {
+		return false
+	}
+	d.Attempts++
+	d.Correct = checker.Check(given, d.Answers)
+	return d.Correct
+}
+
+// Done reports whether the item was answered right or has no attempts left
+func (d *Dictation) Done() bool {
+	return d.Correct || d.Attempts >= d.MaxAttempts
+}
+
+// AttemptsLeft returns how many more attempts may be made
+func (d *Dictation) AttemptsLeft() int {
+	if d.Correct {
+		return 0
+	}
+	return max(0, d.MaxAttempts-d.Attempts)
+}
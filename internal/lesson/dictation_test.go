@@ -0,0 +1,72 @@
+package lesson
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func dictationLesson() *LessonData {
+	lessonData := NewLessonData()
+	lessonData.List.AddMediaItem("greeting", []string{"greeting"}, []string{"Good morning"}, "audio/greeting.mp3", false)
+	lessonData.List.AddMediaItem("photo", []string{"photo"}, []string{"a cat"}, "cat.jpg", false)
+	lessonData.List.AddMediaItem("remote", []string{"remote"}, []string{"See you"}, "https://example.com/bye.ogg?raw=1", true)
+	lessonData.List.AddWordItem([]string{"plain"}, []string{"word"}, "")
+	return lessonData
+}
+
+func TestDictationItems(t *testing.T) {
+	got := DictationItems(dictationLesson())
+	if len(got) != 2 || got[0] != 0 || got[1] != 2 {
+		t.Errorf("DictationItems() = %v; want [0 2]", got)
+	}
+}
+
+func TestDictationAttempts(t *testing.T) {
+	lessonData := dictationLesson()
+	if got := lessonData.DictationAttempts(0); got != DefaultDictationAttempts {
+		t.Errorf("Expected the default of %d attempts, got %d", DefaultDictationAttempts, got)
+	}
+
+	lessonData.SetDictationSettings(DictationSettings{Attempts: 2, SlowRate: 0.5})
+	lessonData.List.Items[2].SetExtra(ExtraDictationAttempts, float64(5))
+	if got := lessonData.DictationAttempts(0); got != 2 {
+		t.Errorf("Expected the lesson limit of 2, got %d", got)
+	}
+	if got := lessonData.DictationAttempts(2); got != 5 {
+		t.Errorf("Expected the item limit of 5, got %d", got)
+	}
+	if got := lessonData.DictationSettings().SlowRate; got != 0.5 {
+		t.Errorf("Expected slow rate 0.5, got %v", got)
+	}
+}
+
+func TestDictationTry(t *testing.T) {
+	lessonData := dictationLesson()
+	lessonData.SetDictationSettings(DictationSettings{Attempts: 2})
+	checker := NewAnswerChecker(AnswerTolerance{IgnoreCase: true, IgnorePunctuation: true}, "")
+
+	dictation := NewDictation(lessonData, 0)
+	if dictation.Try(checker, "good evening") || dictation.Done() || dictation.AttemptsLeft() != 1 {
+		t.Errorf("A wrong first attempt should leave one more: %+v", dictation)
+	}
+	if !dictation.Try(checker, "Good morning!") || !dictation.Done() {
+		t.Errorf("The second attempt should be right: %+v", dictation)
+	}
+
+	dictation = NewDictation(lessonData, 0)
+	dictation.Try(checker, "one")
+	dictation.Try(checker, "two")
+	if !dictation.Done() || dictation.Try(checker, "good morning") {
+		t.Errorf("No attempts should be taken after the limit: %+v", dictation)
+	}
+}
+
+func TestResolveMediaPath(t *testing.T) {
+	lessonPath := filepath.Join("/lessons", "french.otmd")
+	if got := ResolveMediaPath(lessonPath, "audio/a.mp3", false); got != filepath.Join("/lessons", "audio", "a.mp3") {
+		t.Errorf("Relative media should resolve next to the lesson, got %s", got)
+	}
+	if got := ResolveMediaPath(lessonPath, "https://example.com/a.mp3", true); got != "https://example.com/a.mp3" {
+		t.Errorf("Remote media should be kept, got %s", got)
+	}
+}
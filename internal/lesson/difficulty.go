@@ -0,0 +1,211 @@
+package lesson
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Difficulty is a manual override of how often an item is asked
+type Difficulty string
+
+// Difficulties an item can be set to
+const (
+	// DifficultyNormal leaves the item to the lesson type and list modifiers
+	DifficultyNormal Difficulty = ""
+	// DifficultyAlwaysAsk asks the item even when a list modifier or lesson
+	// type would leave it out
+	DifficultyAlwaysAsk Difficulty = "always-ask"
+	// DifficultySuspended never asks the item until it is set back
+	DifficultySuspended Difficulty = "suspended"
+	// DifficultyBuried is an Anki card buried when the lesson was imported.
+	// Anki unburies cards the next day; Recuerdo keeps them out of lessons
+	// like suspended items until they are set back.
+	DifficultyBuried Difficulty = "buried"
+)
+
+// ParseDifficulty returns the difficulty named s; "normal" and "" are both
+// DifficultyNormal
+func ParseDifficulty(s string) (Difficulty, error) {
+	switch Difficulty(s) {
+	case DifficultyNormal, "normal":
+		return DifficultyNormal, nil
+	case DifficultyAlwaysAsk, DifficultySuspended, DifficultyBuried:
+		return Difficulty(s), nil
+	}
+	return DifficultyNormal, fmt.Errorf("unknown difficulty %q", s)
+}
+
+// String returns the name shown to users
+func (d Difficulty) String() string {
+	switch d {
+	case DifficultyAlwaysAsk:
+		return "Always ask"
+	case DifficultySuspended:
+		return "Suspended"
+	case DifficultyBuried:
+		return "Buried"
+	}
+	return "Normal"
+}
+
+// IsSuspended reports whether the item is left out of lessons, being
+// suspended or buried
+func (wi *WordItem) IsSuspended() bool {
+	return wi.Difficulty == DifficultySuspended || wi.Difficulty == DifficultyBuried
+}
+
+// IsAlwaysAsked reports whether the item is asked whatever the lesson type
+// and list modifiers pick
+func (wi *WordItem) IsAlwaysAsked() bool {
+	return wi.Difficulty == DifficultyAlwaysAsk
+}
+
+// ApplyDifficulty adjusts the item indexes picked by a lesson type or list
+// modifier to the items' difficulty: suspended items are dropped and
+// always-ask items that were left out are added, in list order.
+func ApplyDifficulty(list *WordList, indexes []int) []int {
+	result := make([]int, 0, len(indexes))
+	for _, index := range indexes {
+		if !list.Items[index].IsSuspended() {
+			result = append(result, index)
+		}
+	}
+	for i := range list.Items {
+		if list.Items[i].IsAlwaysAsked() && !slices.Contains(result, i) {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// NextAskedItem returns the index of the first item from index on that is
+// not suspended, wrapping around to the start of the list, or
+// len(list.Items) if every item is suspended. Lessons stepping through the
+// list in order use it to skip suspended items.
+func NextAskedItem(list *WordList, index int) int {
+	for offset := range list.Items {
+		next := (index + offset) % len(list.Items)
+		if !list.Items[next].IsSuspended() {
+			return next
+		}
+	}
+	return len(list.Items)
+}
+
+// AllItems returns the indexes of every item of the list, the selection of
+// lessons asking everything once
+func AllItems(list *WordList) []int {
+	indexes := make([]int, len(list.Items))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	return indexes
+}
+
+// StarredItems keeps the starred items of indexes
+func StarredItems(list *WordList, indexes []int) []int {
+	return filterItems(list, indexes, func(item *WordItem) bool {
+		return item.Starred
+	})
+}
+
+// TaggedItems keeps the items of indexes tagged with any of tags, ignoring
+// case
+func TaggedItems(list *WordList, indexes []int, tags []string) []int {
+	return filterItems(list, indexes, func(item *WordItem) bool {
+		for _, tag := range tags {
+			if item.HasTag(tag) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// HardItems keeps the items of indexes answered wrong more often than right,
+// and those never asked
+func HardItems(list *WordList, indexes []int) []int {
+	return filterItems(list, indexes, func(item *WordItem) bool {
+		right, wrong := list.GetRightAnswersCount(item.ID), list.GetWrongAnswersCount(item.ID)
+		return right+wrong == 0 || float64(wrong) > float64(right+wrong)/2
+	})
+}
+
+// NeverAnsweredCorrectlyItems keeps the items of indexes that were never
+// answered right
+func NeverAnsweredCorrectlyItems(list *WordList, indexes []int) []int {
+	return filterItems(list, indexes, func(item *WordItem) bool {
+		return list.GetRightAnswersCount(item.ID) == 0
+	})
+}
+
+// filterItems keeps the items of indexes keep returns true for, then
+// applies their difficulty
+func filterItems(list *WordList, indexes []int, keep func(item *WordItem) bool) []int {
+	var kept []int
+	for _, index := range indexes {
+		if keep(&list.Items[index]) {
+			kept = append(kept, index)
+		}
+	}
+	return ApplyDifficulty(list, kept)
+}
+
+// ankiDifficulty returns the difficulty of an imported Anki note from the
+// number of its cards in a review queue and of those buried; the cards
+// left are suspended. Notes with a card to review are normal.
+func ankiDifficulty(activeCards, buriedCards int) Difficulty {
+	switch {
+	case activeCards > 0:
+		return DifficultyNormal
+	case buriedCards > 0:
+		return DifficultyBuried
+	}
+	return DifficultySuspended
+}
+
+// readItemState reads the starred flag, difficulty, tags and chapter of an
+// item from an item object of an OpenTeaching list.json
+func (wi *WordItem) readItemState(itemMap map[string]interface{}) {
+	if starred, ok := itemMap["starred"].(bool); ok {
+		wi.Starred = starred
+	}
+	if name, ok := itemMap["difficulty"].(string); ok {
+		if difficulty, err := ParseDifficulty(name); err == nil {
+			wi.Difficulty = difficulty
+		}
+	}
+	if tags, ok := itemMap["tags"].([]interface{}); ok {
+		wi.Tags = nil
+		for _, tag := range tags {
+			if text, ok := tag.(string); ok && text != "" {
+				wi.Tags = append(wi.Tags, text)
+			}
+		}
+	}
+	if chapter, ok := itemMap["chapter"].([]interface{}); ok {
+		wi.Chapter = nil
+		for _, name := range chapter {
+			if text, ok := name.(string); ok && text != "" {
+				wi.Chapter = append(wi.Chapter, text)
+			}
+		}
+	}
+}
+
+// writeItemState adds the starred flag, difficulty, tags and chapter of an
+// item, when set, to an item object of an OpenTeaching list.json
+func (wi *WordItem) writeItemState(itemMap map[string]interface{}) {
+	if wi.Starred {
+		itemMap["starred"] = true
+	}
+	if wi.Difficulty != DifficultyNormal {
+		itemMap["difficulty"] = string(wi.Difficulty)
+	}
+	if len(wi.Tags) > 0 {
+		itemMap["tags"] = wi.Tags
+	}
+	if len(wi.Chapter) > 0 {
+		itemMap["chapter"] = wi.Chapter
+	}
+}
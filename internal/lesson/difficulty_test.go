@@ -0,0 +1,165 @@
+package lesson
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func difficultyList() *WordList {
+	list := NewWordList()
+	for _, word := range []string{"een", "twee", "drie", "vier"} {
+		list.AddWordItem([]string{word}, []string{word}, "")
+	}
+	list.AddTestResult(0, "right")
+	list.AddTestResult(1, "wrong")
+	list.AddTestResult(2, "right")
+	list.AddTestResult(3, "wrong")
+	return list
+}
+
+func TestApplyDifficulty(t *testing.T) {
+	list := difficultyList()
+	list.Items[1].Difficulty = DifficultySuspended
+	list.Items[2].Difficulty = DifficultyAlwaysAsk
+
+	if got := ApplyDifficulty(list, AllItems(list)); !slices.Equal(got, []int{0, 2, 3}) {
+		t.Errorf("ApplyDifficulty(all) = %v; want [0 2 3]", got)
+	}
+	if got := ApplyDifficulty(list, []int{3, 1}); !slices.Equal(got, []int{3, 2}) {
+		t.Errorf("ApplyDifficulty([3 1]) = %v; want [3 2]", got)
+	}
+}
+
+func TestListModifiersRespectDifficulty(t *testing.T) {
+	list := difficultyList()
+	if got := HardItems(list, AllItems(list)); !slices.Equal(got, []int{1, 3}) {
+		t.Errorf("HardItems() = %v; want [1 3]", got)
+	}
+
+	list.Items[3].Difficulty = DifficultySuspended
+	list.Items[0].Difficulty = DifficultyAlwaysAsk
+	if got := HardItems(list, AllItems(list)); !slices.Equal(got, []int{1, 0}) {
+		t.Errorf("HardItems() = %v; want [1 0]", got)
+	}
+	if got := NeverAnsweredCorrectlyItems(list, AllItems(list)); !slices.Equal(got, []int{1, 0}) {
+		t.Errorf("NeverAnsweredCorrectlyItems() = %v; want [1 0]", got)
+	}
+
+	list.Items[2].Starred = true
+	list.Items[3].Starred = true
+	if got := StarredItems(list, AllItems(list)); !slices.Equal(got, []int{2, 0}) {
+		t.Errorf("StarredItems() = %v; want [2 0]", got)
+	}
+}
+
+func TestParseDifficulty(t *testing.T) {
+	for _, name := range []string{"", "normal"} {
+		if got, err := ParseDifficulty(name); err != nil || got != DifficultyNormal {
+			t.Errorf("ParseDifficulty(%q) = %q, %v", name, got, err)
+		}
+	}
+	if got, err := ParseDifficulty("suspended"); err != nil || got != DifficultySuspended {
+		t.Errorf("ParseDifficulty(suspended) = %q, %v", got, err)
+	}
+	if _, err := ParseDifficulty("sometimes"); err == nil {
+		t.Error("Expected an error for an unknown difficulty")
+	}
+}
+
+func TestItemStatePersisted(t *testing.T) {
+	lessonData := NewLessonData()
+	lessonData.List.AddMediaItem("cat", []string{"cat"}, []string{"kat"}, "cat.jpg", false)
+	lessonData.List.AddMediaItem("dog", []string{"dog"}, []string{"hond"}, "dog.jpg", false)
+	lessonData.List.Items[0].Starred = true
+	lessonData.List.Items[1].Difficulty = DifficultySuspended
+
+	for _, ext := range []string{".otmd", ".json"} {
+		path := filepath.Join(t.TempDir(), "lesson"+ext)
+		if err := NewFileSaver().SaveFile(lessonData, path); err != nil {
+			t.Fatalf("Failed to save %s: %v", ext, err)
+		}
+		loaded, err := NewFileLoader().LoadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to load %s: %v", ext, err)
+		}
+		if len(loaded.List.Items) != 2 {
+			t.Fatalf("%s: expected 2 items, got %d", ext, len(loaded.List.Items))
+		}
+		if !loaded.List.Items[0].Starred || loaded.List.Items[0].Difficulty != DifficultyNormal {
+			t.Errorf("%s: first item state lost: %+v", ext, loaded.List.Items[0])
+		}
+		if loaded.List.Items[1].Starred || !loaded.List.Items[1].IsSuspended() {
+			t.Errorf("%s: suspension lost: %+v", ext, loaded.List.Items[1])
+		}
+	}
+}
+
+func TestNextAskedItem(t *testing.T) {
+	list := difficultyList()
+	list.Items[1].Difficulty = DifficultySuspended
+	list.Items[3].Difficulty = DifficultySuspended
+
+	if got := NextAskedItem(list, 1); got != 2 {
+		t.Errorf("NextAskedItem(1) = %d; want 2", got)
+	}
+	if got := NextAskedItem(list, 3); got != 0 {
+		t.Errorf("NextAskedItem(3) = %d; want 0 after wrapping around", got)
+	}
+
+	list.Items[0].Difficulty = DifficultySuspended
+	list.Items[2].Difficulty = DifficultySuspended
+	if got := NextAskedItem(list, 0); got != len(list.Items) {
+		t.Errorf("NextAskedItem() = %d; want %d when all are suspended", got, len(list.Items))
+	}
+	if got := NextAskedItem(NewWordList(), 0); got != 0 {
+		t.Errorf("NextAskedItem() on an empty list = %d; want 0", got)
+	}
+}
+
+func TestLoadAnkiSuspendedAndBuried(t *testing.T) {
+	ankiFile := filepath.Join(t.TempDir(), "queues.anki2")
+	db, err := sql.Open("sqlite3", ankiFile)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	statements := []string{
+		`CREATE TABLE notes (id INTEGER PRIMARY KEY, guid TEXT, flds TEXT, tags TEXT)`,
+		`CREATE TABLE cards (id INTEGER PRIMARY KEY, nid INTEGER, queue INTEGER, ivl INTEGER, factor INTEGER, reps INTEGER, lapses INTEGER)`,
+	}
+	// One note per case: its cards' queues
+	queues := [][]int{{2}, {-1}, {-3}, {-1, -2}, {0, -1}}
+	card := 0
+	for i, noteQueues := range queues {
+		statements = append(statements, fmt.Sprintf(`INSERT INTO notes VALUES (%d, 'g%d', 'q%d' || char(31) || 'a%d', '')`, i+1, i, i, i))
+		for _, queue := range noteQueues {
+			card++
+			statements = append(statements, fmt.Sprintf(`INSERT INTO cards VALUES (%d, %d, %d, 0, 0, 0, 0)`, card, i+1, queue))
+		}
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("Failed to set up database: %v", err)
+		}
+	}
+	db.Close()
+
+	lessonData, err := NewFileLoader().LoadFile(ankiFile)
+	if err != nil {
+		t.Fatalf("Failed to load Anki file: %v", err)
+	}
+	want := []Difficulty{DifficultyNormal, DifficultySuspended, DifficultyBuried, DifficultyBuried, DifficultyNormal}
+	if len(lessonData.List.Items) != len(want) {
+		t.Fatalf("Expected %d items, suspended ones included, got %d", len(want), len(lessonData.List.Items))
+	}
+	for i, difficulty := range want {
+		if got := lessonData.List.Items[i].Difficulty; got != difficulty {
+			t.Errorf("Note %d: difficulty %q; want %q", i+1, got, difficulty)
+		}
+	}
+	if got := ApplyDifficulty(&lessonData.List, AllItems(&lessonData.List)); !slices.Equal(got, []int{0, 4}) {
+		t.Errorf("Only the notes with cards to review should be asked, got %v", got)
+	}
+}
@@ -0,0 +1,116 @@
+package lesson
+
+import (
+	"slices"
+	"sort"
+	"time"
+)
+
+// smartRepeatDistance is how many questions after a wrong answer the smart
+// lesson type asks the card again
+const smartRepeatDistance = 2
+
+// Card is an item asked in one direction: its questions, or its answers
+// when Reversed
+type Card struct {
+	// Index is the index of the item in the list
+	Index    int
+	Reversed bool
+}
+
+// Cards returns the cards asking the items of indexes in direction, one of
+// the Direction constants: every item forward, reversed, or both ways
+func Cards(indexes []int, direction string) []Card {
+	cards := make([]Card, 0, len(indexes))
+	for _, index := range indexes {
+		switch direction {
+		case DirectionReverse:
+			cards = append(cards, Card{Index: index, Reversed: true})
+		case DirectionBoth:
+			cards = append(cards, Card{Index: index}, Card{Index: index, Reversed: true})
+		default:
+			cards = append(cards, Card{Index: index})
+		}
+	}
+	return cards
+}
+
+// Item returns the item of the card as it is asked: with its questions and
+// answers swapped when the card is reversed
+func (c Card) Item(list *WordList) WordItem {
+	item := list.Items[c.Index]
+	if c.Reversed {
+		item.Questions, item.Answers = item.Answers, item.Questions
+		item.AnswerGroups = nil
+	}
+	return item
+}
+
+// AddDirectedResult adds the result of a graded answer to a card, keeping
+// the direction it was asked in and how long the answer took; 0 means it
+// was not timed
+func (wl *WordList) AddDirectedResult(card Card, grade Grade, answerTime time.Duration) {
+	result := gradedResult(wl.Items[card.Index].ID, grade)
+	result.Reversed = card.Reversed
+	result.AnswerTime = answerTime.Seconds()
+	now := time.Now()
+	result.Time = &now
+	wl.addResult(result)
+}
+
+// DirectionCounts returns how often the item with itemID was answered right
+// and wrong when asked in one direction
+func (wl *WordList) DirectionCounts(itemID int, reversed bool) (right, wrong int) {
+	for _, test := range wl.Tests {
+		for _, result := range test.Results {
+			if result.ItemID != itemID || result.Reversed != reversed {
+				continue
+			}
+			switch result.Result {
+			case "right":
+				right++
+			case "wrong":
+				wrong++
+			}
+		}
+	}
+	return right, wrong
+}
+
+// SmartOrder orders cards for the smart lesson type: the cards answered
+// wrongly most often in their own direction first, then those never asked
+// in it, keeping the order of cards that did equally well. Slow answers
+// count against a card too, so a word that takes long to come up resurfaces
+// sooner even when it is answered right. The two directions of an item are
+// scheduled apart, so a word known one way but not the other is asked early
+// only the way it is not known.
+func SmartOrder(list *WordList, cards []Card) []Card {
+	weakness := func(card Card) float64 {
+		id := list.Items[card.Index].ID
+		right, wrong := list.DirectionCounts(id, card.Reversed)
+		if right+wrong == 0 {
+			return newItemErrorRate
+		}
+		answerTime, _ := list.directionAnswerTime(id, &card.Reversed)
+		return float64(wrong)/float64(right+wrong) + slowness(answerTime)
+	}
+	ordered := append([]Card(nil), cards...)
+	sort.SliceStable(ordered, func(a, b int) bool {
+		return weakness(ordered[a]) > weakness(ordered[b])
+	})
+	return ordered
+}
+
+// RequeueWrong returns queue with the question at position, answered
+// wrongly, asked again a few questions later and at the end, as the smart
+// lesson type does
+func RequeueWrong[T comparable](queue []T, position int) []T {
+	wrong := queue[position]
+	if soon := position + 1 + smartRepeatDistance; soon < len(queue) {
+		queue = slices.Insert(queue, soon, wrong)
+	}
+	if queue[len(queue)-1] != wrong || len(queue) == position+1 {
+		queue = append(queue, wrong)
+	}
+	return queue
+}
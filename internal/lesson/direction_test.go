@@ -0,0 +1,71 @@
+package lesson
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCards(t *testing.T) {
+	list := NewWordList()
+	list.AddWordItem([]string{"hond"}, []string{"dog"}, "")
+	list.AddWordItem([]string{"kat"}, []string{"cat"}, "")
+
+	cards := Cards([]int{0, 1}, DirectionBoth)
+	want := []Card{{0, false}, {0, true}, {1, false}, {1, true}}
+	if !slices.Equal(cards, want) {
+		t.Errorf("Cards(both) = %v, want %v", cards, want)
+	}
+	if cards := Cards([]int{1}, ""); !slices.Equal(cards, []Card{{1, false}}) {
+		t.Errorf("Cards(no direction) = %v", cards)
+	}
+
+	item := Card{Index: 1, Reversed: true}.Item(list)
+	if item.Questions[0] != "cat" || item.Answers[0] != "kat" || list.Items[1].Questions[0] != "kat" {
+		t.Errorf("reversed item asks %q for %q", item.Questions, item.Answers)
+	}
+}
+
+func TestDirectionStatistics(t *testing.T) {
+	list := NewWordList()
+	list.AddWordItem([]string{"hond"}, []string{"dog"}, "")
+	list.AddWordItem([]string{"kat"}, []string{"cat"}, "")
+
+	// Both words are known forward; only "kat" reversed
+	list.AddDirectedResult(Card{Index: 0}, Grade{Correct: true, Credit: 1}, 0)
+	list.AddDirectedResult(Card{Index: 1}, Grade{Correct: true, Credit: 1}, 0)
+	list.AddDirectedResult(Card{Index: 0, Reversed: true}, Grade{}, 0)
+	list.AddDirectedResult(Card{Index: 1, Reversed: true}, Grade{Correct: true, Credit: 1}, 0)
+
+	if right, wrong := list.DirectionCounts(0, false); right != 1 || wrong != 0 {
+		t.Errorf("hond forward: %d right, %d wrong", right, wrong)
+	}
+	if right, wrong := list.DirectionCounts(0, true); right != 0 || wrong != 1 {
+		t.Errorf("hond reversed: %d right, %d wrong", right, wrong)
+	}
+	if list.GetWrongAnswersCount(0) != 1 {
+		t.Error("directed results are left out of the overall statistics")
+	}
+
+	ordered := SmartOrder(list, Cards([]int{0, 1}, DirectionBoth))
+	if ordered[0] != (Card{Index: 0, Reversed: true}) {
+		t.Errorf("SmartOrder = %v; want hond reversed first", ordered)
+	}
+}
+
+func TestRequeueWrong(t *testing.T) {
+	for _, test := range []struct {
+		queue    []int
+		position int
+		want     []int
+	}{
+		{[]int{1, 2, 3, 4, 5}, 0, []int{1, 2, 3, 1, 4, 5, 1}},
+		{[]int{1, 2, 3}, 1, []int{1, 2, 3, 2}},
+		{[]int{1, 2}, 1, []int{1, 2, 2}},
+		{[]int{1, 2, 3, 2}, 1, []int{1, 2, 3, 2}},
+	} {
+		queue := append([]int(nil), test.queue...)
+		if got := RequeueWrong(queue, test.position); !slices.Equal(got, test.want) {
+			t.Errorf("RequeueWrong(%v, %d) = %v, want %v", test.queue, test.position, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package lesson
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// DefaultDistractorCount is the number of wrong choices shown next to the
+// right answer in a multiple choice question
+const DefaultDistractorCount = 3
+
+// Embedder turns texts into vectors whose cosine similarity reflects how
+// related their meanings are, for instance by wrapping a local sentence
+// embedding model
+type Embedder interface {
+	Embed(texts []string) ([][]float32, error)
+}
+
+// DistractorGenerator picks wrong choices for multiple choice questions from
+// the other answers in a lesson. Instead of random answers it prefers those
+// that look like the right answer (small edit distance) and, when an
+// Embedder is set, those that mean something similar, so the choices can not
+// be told apart by length or spelling alone.
+type DistractorGenerator struct {
+	Count int
+	// Embedder is optional; without it only spelling is compared
+	Embedder Embedder
+	// SemanticWeight is the share of meaning in the similarity, between 0
+	// and 1, when an Embedder is set
+	SemanticWeight float64
+
+	vectors map[string][]float32
+}
+
+// NewDistractorGenerator creates a generator picking count distractors by
+// edit distance
+func NewDistractorGenerator(count int) *DistractorGenerator {
+	if count <= 0 {
+		count = DefaultDistractorCount
+	}
+	return &DistractorGenerator{Count: count, SemanticWeight: 0.5}
+}
+
+// distractorCandidate is another item's answer and how close it is
+type distractorCandidate struct {
+	text       string
+	similarity float64
+}
+
+// Distractors returns up to Count wrong answers for the item at itemIndex,
+// the most confusable first
+func (g *DistractorGenerator) Distractors(lessonData *LessonData, itemIndex int) ([]string, error) {
+	items := lessonData.List.Items
+	if itemIndex < 0 || itemIndex >= len(items) {
+		return nil, fmt.Errorf("item index %d out of range", itemIndex)
+	}
+	if len(items[itemIndex].Answers) == 0 {
+		return nil, fmt.Errorf("item %d has no answer", itemIndex)
+	}
+
+	correct := items[itemIndex].Answers[0]
+	excluded := make(map[string]bool)
+	for _, answer := range items[itemIndex].Answers {
+		excluded[normalizeChoice(answer)] = true
+	}
+
+	var candidates []distractorCandidate
+	for i, item := range items {
+		if i == itemIndex || len(item.Answers) == 0 {
+			continue
+		}
+		text := strings.TrimSpace(item.Answers[0])
+		key := normalizeChoice(text)
+		if text == "" || excluded[key] {
+			continue // an empty choice, or one that would also be right
+		}
+		excluded[key] = true
+		candidates = append(candidates, distractorCandidate{
+			text:       text,
+			similarity: spellingSimilarity(correct, text),
+		})
+	}
+
+	if g.Embedder != nil && len(candidates) > 0 {
+		if err := g.addMeaning(correct, candidates); err != nil {
+			log.Printf("[WARNING] DistractorGenerator - embedding failed, comparing spelling only: %v", err)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].similarity > candidates[j].similarity
+	})
+
+	count := g.Count
+	if count <= 0 {
+		count = DefaultDistractorCount
+	}
+	distractors := make([]string, 0, count)
+	for _, candidate := range candidates {
+		if len(distractors) == count {
+			break
+		}
+		distractors = append(distractors, candidate.text)
+	}
+	return distractors, nil
+}
+
+// Choices returns the shuffled choices for the item at itemIndex and the
+// index of the right one among them
+func (g *DistractorGenerator) Choices(lessonData *LessonData, itemIndex int, rng *rand.Rand) ([]string, int, error) {
+	distractors, err := g.Distractors(lessonData, itemIndex)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	choices := append([]string{lessonData.List.Items[itemIndex].Answers[0]}, distractors...)
+	rng.Shuffle(len(choices), func(i, j int) { choices[i], choices[j] = choices[j], choices[i] })
+	for i, choice := range choices {
+		if choice == lessonData.List.Items[itemIndex].Answers[0] {
+			return choices, i, nil
+		}
+	}
+	return choices, 0, nil
+}
+
+// addMeaning blends the cosine similarity of the embeddings into the
+// candidates' spelling similarity
+func (g *DistractorGenerator) addMeaning(correct string, candidates []distractorCandidate) error {
+	if g.vectors == nil {
+		g.vectors = make(map[string][]float32)
+	}
+
+	var missing []string
+	for _, text := range append([]string{correct}, candidateTexts(candidates)...) {
+		if _, ok := g.vectors[text]; !ok {
+			missing = append(missing, text)
+		}
+	}
+	if len(missing) > 0 {
+		vectors, err := g.Embedder.Embed(missing)
+		if err != nil {
+			return err
+		}
+		if len(vectors) != len(missing) {
+			return fmt.Errorf("embedder returned %d vectors for %d texts", len(vectors), len(missing))
+		}
+		for i, text := range missing {
+			g.vectors[text] = vectors[i]
+		}
+	}
+
+	weight := math.Max(0, math.Min(1, g.SemanticWeight))
+	for i := range candidates {
+		meaning := cosineSimilarity(g.vectors[correct], g.vectors[candidates[i].text])
+		candidates[i].similarity = (1-weight)*candidates[i].similarity + weight*meaning
+	}
+	return nil
+}
+
+func candidateTexts(candidates []distractorCandidate) []string {
+	texts := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		texts[i] = candidate.text
+	}
+	return texts
+}
+
+// normalizeChoice folds case, accents and surrounding space, so choices
+// differing only in those count as the same
+func normalizeChoice(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(foldAccents(text)), " "))
+}
+
+// foldAccents removes diacritics, turning "café" into "cafe"
+func foldAccents(text string) string {
+	folded, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), text)
+	if err != nil {
+		return text
+	}
+	return folded
+}
+
+// spellingSimilarity is one minus the edit distance of the normalized texts
+// divided by the length of the longer one
+func spellingSimilarity(a, b string) float64 {
+	ra, rb := []rune(normalizeChoice(a)), []rune(normalizeChoice(b))
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(editDistance(ra, rb))/float64(longest)
+}
+
+// editDistance is the Levenshtein distance between two rune slices
+func editDistance(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// cosineSimilarity of two vectors, scaled from [-1, 1] to [0, 1] so it
+// blends with the spelling similarity
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return (dot/math.Sqrt(normA*normB) + 1) / 2
+}
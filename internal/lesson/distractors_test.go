@@ -0,0 +1,116 @@
+package lesson
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func distractorLesson(answers ...string) *LessonData {
+	lessonData := NewLessonData()
+	for i, answer := range answers {
+		lessonData.List.AddWordItem([]string{string(rune('a' + i))}, []string{answer}, "")
+	}
+	return lessonData
+}
+
+func TestDistractorsPreferSimilarSpelling(t *testing.T) {
+	lessonData := distractorLesson("house", "horse", "mouse", "elephant", "hose", "House", "encyclopedia")
+
+	distractors, err := NewDistractorGenerator(3).Distractors(lessonData, 0)
+	if err != nil {
+		t.Fatalf("Distractors failed: %v", err)
+	}
+	want := []string{"horse", "mouse", "hose"}
+	if !equalStringSlices(distractors, want) {
+		t.Errorf("Distractors() = %v; want %v", distractors, want)
+	}
+}
+
+func TestDistractorsSkipOtherCorrectAnswers(t *testing.T) {
+	lessonData := distractorLesson("café", "cafe", "Café ", "cage")
+	lessonData.List.Items[0].Answers = append(lessonData.List.Items[0].Answers, "cage")
+
+	distractors, err := NewDistractorGenerator(3).Distractors(lessonData, 0)
+	if err != nil {
+		t.Fatalf("Distractors failed: %v", err)
+	}
+	if len(distractors) != 0 {
+		t.Errorf("Expected no distractors that would also be right, got %v", distractors)
+	}
+}
+
+// fakeEmbedder puts animals and furniture on different axes
+type fakeEmbedder struct{ err error }
+
+func (e fakeEmbedder) Embed(texts []string) ([][]float32, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	animals := map[string]bool{"cat": true, "dog": true, "cow": true}
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		if animals[text] {
+			vectors[i] = []float32{1, 0}
+		} else {
+			vectors[i] = []float32{0, 1}
+		}
+	}
+	return vectors, nil
+}
+
+func TestDistractorsWithEmbedder(t *testing.T) {
+	lessonData := distractorLesson("cat", "car", "cap", "dog", "cow")
+
+	generator := NewDistractorGenerator(2)
+	generator.Embedder = fakeEmbedder{}
+	generator.SemanticWeight = 0.8
+	distractors, err := generator.Distractors(lessonData, 0)
+	if err != nil {
+		t.Fatalf("Distractors failed: %v", err)
+	}
+	if !equalStringSlices(distractors, []string{"cow", "dog"}) {
+		t.Errorf("Expected related animals, got %v", distractors)
+	}
+
+	// A failing model falls back to spelling
+	generator = NewDistractorGenerator(2)
+	generator.Embedder = fakeEmbedder{err: errors.New("model not found")}
+	distractors, err = generator.Distractors(lessonData, 0)
+	if err != nil || !equalStringSlices(distractors, []string{"car", "cap"}) {
+		t.Errorf("Fallback gave %v, %v", distractors, err)
+	}
+}
+
+func TestChoices(t *testing.T) {
+	lessonData := distractorLesson("uno", "dos", "tres", "cuatro")
+
+	choices, correct, err := NewDistractorGenerator(3).Choices(lessonData, 1, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatalf("Choices failed: %v", err)
+	}
+	if len(choices) != 4 || choices[correct] != "dos" {
+		t.Errorf("Choices() = %v with right answer at %d", choices, correct)
+	}
+	if _, _, err := NewDistractorGenerator(3).Choices(lessonData, 9, rand.New(rand.NewSource(1))); err == nil {
+		t.Error("Expected an error for an invalid item")
+	}
+}
+
+func TestMultipleChoiceSettings(t *testing.T) {
+	lessonData := distractorLesson("one", "two", "three", "four", "five", "six")
+	if settings := lessonData.MultipleChoiceSettings(); settings.Enabled || settings.Choices != DefaultDistractorCount+1 {
+		t.Errorf("Unexpected default settings %+v", settings)
+	}
+
+	lessonData.SetMultipleChoiceSettings(MultipleChoiceSettings{Enabled: true, Choices: 3})
+	if got := DistractorGeneratorFor(lessonData).Count; got != 2 {
+		t.Errorf("Expected 2 distractors for 3 choices, got %d", got)
+	}
+
+	// Numbers read back from JSON are float64
+	lessonData.Resources[MultipleChoiceResourceKey] = map[string]interface{}{"enabled": true, "choices": float64(50)}
+	if settings := lessonData.MultipleChoiceSettings(); !settings.Enabled || settings.Choices != MaxChoices {
+		t.Errorf("Expected choices clamped to %d, got %+v", MaxChoices, settings)
+	}
+}
@@ -0,0 +1,227 @@
+package lesson
+
+import "strings"
+
+// DefaultDuplicateSimilarity is the spelling similarity from which two items
+// count as near-duplicates in FindDuplicates
+const DefaultDuplicateSimilarity = 0.85
+
+// What to do with a group of duplicates in ResolveDuplicates
+const (
+	// DuplicateMerge turns the group into its kept item, taking over the
+	// answers, comments and test results of the others
+	DuplicateMerge = "merge"
+	// DuplicateKeepBoth leaves the group as it is
+	DuplicateKeepBoth = "keep"
+	// DuplicateDelete keeps one item and removes the others with their
+	// test results
+	DuplicateDelete = "delete"
+)
+
+// DuplicateGroup is a set of items that look like the same word, by index
+// in the item list
+type DuplicateGroup struct {
+	Items []int
+	// Exact is set when all items have the same questions and answers,
+	// ignoring case, spacing and order
+	Exact bool
+}
+
+// DuplicateResolution is the choice made for one duplicate group
+type DuplicateResolution struct {
+	Group  DuplicateGroup
+	Action string
+	// Keep is the index of the item that stays for DuplicateMerge and
+	// DuplicateDelete; it must be one of Group.Items
+	Keep int
+}
+
+// FindDuplicates groups the items of a lesson that are duplicates or
+// near-duplicates: the same questions with other answers, or questions and
+// answers spelled alike with a similarity of at least minSimilarity (0 means
+// DefaultDuplicateSimilarity). Groups are in the order of their first item.
+func FindDuplicates(lessonData *LessonData, minSimilarity float64) []DuplicateGroup {
+	if minSimilarity <= 0 {
+		minSimilarity = DefaultDuplicateSimilarity
+	}
+	items := lessonData.List.Items
+
+	questions := make([]string, len(items))
+	answers := make([]string, len(items))
+	for i, item := range items {
+		questions[i] = normalizeForDedupe(item.Questions)
+		answers[i] = normalizeForDedupe(item.Answers)
+	}
+
+	parent := make([]int, len(items))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i := range items {
+		for j := i + 1; j < len(items); j++ {
+			if find(i) == find(j) {
+				continue
+			}
+			if questions[i] == questions[j] ||
+				(similarKeys(questions[i], questions[j], minSimilarity) && similarKeys(answers[i], answers[j], minSimilarity)) {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	byRoot := make(map[int]*DuplicateGroup)
+	var roots []int
+	for i := range items {
+		root := find(i)
+		group, ok := byRoot[root]
+		if !ok {
+			group = &DuplicateGroup{Exact: true}
+			byRoot[root] = group
+			roots = append(roots, root)
+		}
+		first := i
+		if len(group.Items) > 0 {
+			first = group.Items[0]
+		}
+		if questions[i] != questions[first] || answers[i] != answers[first] {
+			group.Exact = false
+		}
+		group.Items = append(group.Items, i)
+	}
+
+	var groups []DuplicateGroup
+	for _, root := range roots {
+		if group := byRoot[root]; len(group.Items) > 1 {
+			groups = append(groups, *group)
+		}
+	}
+	return groups
+}
+
+// similarKeys compares two normalized word lists by spelling
+func similarKeys(a, b string, minSimilarity float64) bool {
+	if a == b {
+		return true
+	}
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	// The length difference alone can rule out a match
+	if float64(longest-min(len(ra), len(rb))) > (1-minSimilarity)*float64(longest) {
+		return false
+	}
+	return 1-float64(editDistance(ra, rb))/float64(longest) >= minSimilarity
+}
+
+// ResolveDuplicates applies the choices made for duplicate groups. Like the
+// bulk edit operations it returns new lesson data; test results of merged
+// items move to the kept item, those of deleted items are dropped.
+func ResolveDuplicates(lessonData *LessonData, resolutions []DuplicateResolution) *LessonData {
+	items := make([]WordItem, len(lessonData.List.Items))
+	copy(items, lessonData.List.Items)
+	removed := make(map[int]bool)
+	// mergedInto maps the ids of merged items to the index of their kept item
+	mergedInto := make(map[int]int)
+
+	for _, resolution := range resolutions {
+		if resolution.Action != DuplicateMerge && resolution.Action != DuplicateDelete {
+			continue
+		}
+		keep := resolution.Keep
+		if keep < 0 || keep >= len(items) || removed[keep] {
+			continue
+		}
+		for _, i := range resolution.Group.Items {
+			if i == keep || i < 0 || i >= len(items) || removed[i] {
+				continue
+			}
+			removed[i] = true
+			if resolution.Action == DuplicateMerge {
+				items[keep] = mergeItem(items[keep], items[i])
+				mergedInto[items[i].ID] = keep
+			}
+		}
+	}
+
+	result := cloneLessonMeta(lessonData)
+	idMap := make(map[int]int)
+	newIndex := make(map[int]int)
+	for i, item := range items {
+		if removed[i] {
+			continue
+		}
+		newIndex[i] = len(result.List.Items)
+		idMap[item.ID] = len(result.List.Items)
+		item.ID = len(result.List.Items)
+		result.List.Items = append(result.List.Items, item)
+	}
+	for id, keep := range mergedInto {
+		idMap[id] = newIndex[keep]
+	}
+	// Items are already renumbered; only remap the test results
+	appendItems(result, lessonData, nil, idMap)
+	return result
+}
+
+// mergeItem adds the questions, answers and comment of other to item;
+// #tags in the comment come along with it
+func mergeItem(item, other WordItem) WordItem {
+	item.Questions = appendMissingFold(append([]string(nil), item.Questions...), other.Questions...)
+
+	groups := item.SynonymGroups()
+	otherGroups := other.SynonymGroups()
+	if len(groups) <= 1 && len(otherGroups) <= 1 {
+		item.Answers = appendMissingFold(append([]string(nil), item.Answers...), other.Answers...)
+	} else {
+		// Meanings the item already has are not repeated
+		for _, group := range otherGroups {
+			known := false
+			for _, answer := range group {
+				if containsString(item.Answers, answer) {
+					known = true
+					break
+				}
+			}
+			if !known {
+				groups = append(groups, group)
+			}
+		}
+		item.SetSynonymGroups(groups)
+	}
+
+	comment := strings.TrimSpace(other.Comment)
+	if comment != "" && !strings.Contains(item.Comment, comment) {
+		if item.Comment != "" {
+			item.Comment += "; "
+		}
+		item.Comment += comment
+	}
+	item.Starred = item.Starred || other.Starred
+	item.Tags = ParseTags(strings.Join(append(append([]string(nil), item.Tags...), other.Tags...), ","))
+	return item
+}
+
+// appendMissingFold appends the words not in list yet, ignoring case and
+// spacing
+func appendMissingFold(list []string, words ...string) []string {
+	for _, word := range words {
+		known := false
+		for _, existing := range list {
+			if normalizeForDedupe([]string{existing}) == normalizeForDedupe([]string{word}) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			list = append(list, word)
+		}
+	}
+	return list
+}
@@ -0,0 +1,78 @@
+package lesson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func duplicatesLesson() *LessonData {
+	lessonData := NewLessonData()
+	lessonData.List.Items = []WordItem{
+		{ID: 0, Questions: []string{"huis"}, Answers: []string{"house"}},
+		{ID: 1, Questions: []string{"boom"}, Answers: []string{"tree"}},
+		{ID: 2, Questions: []string{"Huis"}, Answers: []string{"home"}, Comment: "#home"},
+		{ID: 3, Questions: []string{"kat"}, Answers: []string{"cat"}},
+		{ID: 4, Questions: []string{"boom "}, Answers: []string{"Tree"}},
+		{ID: 5, Questions: []string{"appelboom"}, Answers: []string{"apple tree"}},
+		{ID: 6, Questions: []string{"appelbom"}, Answers: []string{"apple tree"}},
+	}
+	lessonData.List.Tests = []Test{{Results: []TestResult{
+		{Result: "right", ItemID: 0},
+		{Result: "wrong", ItemID: 2},
+		{Result: "right", ItemID: 4},
+		{Result: "wrong", ItemID: 3},
+	}}}
+	return lessonData
+}
+
+func TestFindDuplicates(t *testing.T) {
+	groups := FindDuplicates(duplicatesLesson(), 0)
+	want := []DuplicateGroup{
+		{Items: []int{0, 2}},
+		{Items: []int{1, 4}, Exact: true},
+		{Items: []int{5, 6}},
+	}
+	if !reflect.DeepEqual(groups, want) {
+		t.Fatalf("FindDuplicates() = %+v, want %+v", groups, want)
+	}
+
+	if groups := FindDuplicates(duplicatesLesson(), 0.95); len(groups) != 2 {
+		t.Errorf("Expected a stricter similarity to leave out the misspelling, got %+v", groups)
+	}
+}
+
+func TestResolveDuplicates(t *testing.T) {
+	lessonData := duplicatesLesson()
+	groups := FindDuplicates(lessonData, 0)
+	resolved := ResolveDuplicates(lessonData, []DuplicateResolution{
+		{Group: groups[0], Action: DuplicateMerge, Keep: 0},
+		{Group: groups[1], Action: DuplicateDelete, Keep: 1},
+		{Group: groups[2], Action: DuplicateKeepBoth},
+	})
+
+	items := resolved.List.Items
+	if len(items) != 5 || len(lessonData.List.Items) != 7 {
+		t.Fatalf("Expected 5 items and an untouched input, got %d and %d", len(items), len(lessonData.List.Items))
+	}
+	if !reflect.DeepEqual(items[0].Questions, []string{"huis"}) || !reflect.DeepEqual(items[0].Answers, []string{"house", "home"}) || items[0].Comment != "#home" {
+		t.Errorf("Expected the merged item to take over answers and comment, got %+v", items[0])
+	}
+	for i, item := range items {
+		if item.ID != i {
+			t.Errorf("Expected item %d to have id %d, got %d", i, i, item.ID)
+		}
+	}
+
+	var results []TestResult
+	for _, test := range resolved.List.Tests {
+		results = append(results, test.Results...)
+	}
+	want := []TestResult{
+		{Result: "right", ItemID: 0},
+		{Result: "wrong", ItemID: 0},
+		{Result: "wrong", ItemID: 2},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("Expected merged results to move and deleted ones to go, got %+v", results)
+	}
+}
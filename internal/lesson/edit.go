@@ -0,0 +1,234 @@
+package lesson
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Bulk edit operations used by the "recuerdo edit" command. They never modify
+// their input; each returns new lesson data with item ids renumbered from zero
+// and test results remapped, so results keep pointing at the right items.
+
+// UntaggedGroup is the name SplitLessonByTag uses for items without tags
+const UntaggedGroup = "untagged"
+
+// cloneLessonMeta returns empty lesson data with the metadata of lessonData
+func cloneLessonMeta(lessonData *LessonData) *LessonData {
+	result := NewLessonData()
+	result.List.Title = lessonData.List.Title
+	result.List.QuestionLanguage = lessonData.List.QuestionLanguage
+	result.List.AnswerLanguage = lessonData.List.AnswerLanguage
+	result.List.Tags = append([]string(nil), lessonData.List.Tags...)
+	result.List.Author = lessonData.List.Author
+	result.List.Description = lessonData.List.Description
+	result.List.License = lessonData.List.License
+	result.List.Level = lessonData.List.Level
+	// Derived lessons keep the UUID, so the review history of their items,
+	// which is found by item hash within the lesson, follows them
+	result.List.UUID = lessonData.List.UUID
+	result.List.Sync = lessonData.List.Sync
+	for key, value := range lessonData.Resources {
+		result.Resources[key] = value
+	}
+	result.Changed = true
+	return result
+}
+
+// appendItems copies items into target, renumbering them, and copies the
+// test results that refer to them. idMap maps old ids to new ids when several
+// source ids collapse into one item (deduplication); it may be nil.
+func appendItems(target *LessonData, source *LessonData, items []WordItem, idMap map[int]int) {
+	if idMap == nil {
+		idMap = make(map[int]int)
+	}
+	for _, item := range items {
+		if _, mapped := idMap[item.ID]; mapped {
+			continue
+		}
+		idMap[item.ID] = len(target.List.Items)
+		item.ID = len(target.List.Items)
+		target.List.Items = append(target.List.Items, item)
+	}
+
+	for _, test := range source.List.Tests {
+		copied := Test{Date: test.Date}
+		for _, result := range test.Results {
+			if newID, ok := idMap[result.ItemID]; ok {
+				result.ItemID = newID
+				copied.Results = append(copied.Results, result)
+			}
+		}
+		if len(copied.Results) > 0 {
+			target.List.Tests = append(target.List.Tests, copied)
+		}
+	}
+}
+
+// MergeLessons combines lessons into one, keeping the first lesson's languages
+func MergeLessons(lessons ...*LessonData) (*LessonData, error) {
+	if len(lessons) == 0 {
+		return nil, fmt.Errorf("no lessons to merge")
+	}
+
+	merged := cloneLessonMeta(lessons[0])
+	var titles []string
+	for _, lessonData := range lessons {
+		if title := strings.TrimSpace(lessonData.List.Title); title != "" && !containsString(titles, title) {
+			titles = append(titles, title)
+		}
+		appendItems(merged, lessonData, lessonData.List.Items, nil)
+	}
+	merged.List.Title = strings.Join(titles, " + ")
+	return merged, nil
+}
+
+// SplitLesson splits a lesson into parts of at most size items
+func SplitLesson(lessonData *LessonData, size int) ([]*LessonData, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("split size must be positive, got %d", size)
+	}
+
+	var parts []*LessonData
+	items := lessonData.List.Items
+	for start := 0; start < len(items); start += size {
+		end := start + size
+		if end > len(items) {
+			end = len(items)
+		}
+		part := cloneLessonMeta(lessonData)
+		part.List.Title = fmt.Sprintf("%s (%d)", lessonData.List.Title, len(parts)+1)
+		appendItems(part, lessonData, items[start:end], nil)
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+// ItemTags returns the #tags written in an item's comment, in lower case
+func ItemTags(item WordItem) []string {
+	var tags []string
+	for _, field := range strings.Fields(item.Comment) {
+		if !strings.HasPrefix(field, "#") {
+			continue
+		}
+		tag := strings.ToLower(strings.TrimRightFunc(field[1:], unicode.IsPunct))
+		if tag != "" && !containsString(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// SplitLessonByTag splits a lesson by the #tags in item comments. Items with
+// several tags end up in each of those lessons; items without tags are
+// grouped under UntaggedGroup.
+func SplitLessonByTag(lessonData *LessonData) map[string]*LessonData {
+	groups := make(map[string][]WordItem)
+	for _, item := range lessonData.List.Items {
+		tags := ItemTags(item)
+		if len(tags) == 0 {
+			tags = []string{UntaggedGroup}
+		}
+		for _, tag := range tags {
+			groups[tag] = append(groups[tag], item)
+		}
+	}
+
+	parts := make(map[string]*LessonData, len(groups))
+	for tag, items := range groups {
+		part := cloneLessonMeta(lessonData)
+		part.List.Title = fmt.Sprintf("%s (%s)", lessonData.List.Title, tag)
+		appendItems(part, lessonData, items, nil)
+		parts[tag] = part
+	}
+	return parts
+}
+
+// normalizeForDedupe returns a comparison key ignoring case, spacing and order
+func normalizeForDedupe(words []string) string {
+	normalized := make([]string, 0, len(words))
+	for _, word := range words {
+		normalized = append(normalized, strings.ToLower(strings.Join(strings.Fields(word), " ")))
+	}
+	sort.Strings(normalized)
+	return strings.Join(normalized, "\x00")
+}
+
+// DedupeLesson removes items with the same questions and answers, ignoring
+// case and spacing. The first occurrence is kept, comments of duplicates are
+// added to it and their test results are moved to it. It returns the new
+// lesson and the number of removed items.
+func DedupeLesson(lessonData *LessonData) (*LessonData, int) {
+	firstByKey := make(map[string]int)
+	var kept []WordItem
+	idMap := make(map[int]int)
+
+	for _, item := range lessonData.List.Items {
+		key := normalizeForDedupe(item.Questions) + "\x01" + normalizeForDedupe(item.Answers)
+		if index, exists := firstByKey[key]; exists {
+			idMap[item.ID] = index
+			comment := strings.TrimSpace(item.Comment)
+			if comment != "" && !strings.Contains(kept[index].Comment, comment) {
+				if kept[index].Comment != "" {
+					kept[index].Comment += "; "
+				}
+				kept[index].Comment += comment
+			}
+			continue
+		}
+		firstByKey[key] = len(kept)
+		idMap[item.ID] = len(kept)
+		kept = append(kept, item)
+	}
+
+	result := cloneLessonMeta(lessonData)
+	for i := range kept {
+		kept[i].ID = i
+	}
+	result.List.Items = kept
+	// Items are already renumbered; only remap the test results
+	appendItems(result, lessonData, nil, idMap)
+
+	return result, len(lessonData.List.Items) - len(kept)
+}
+
+// ReverseLesson swaps questions and answers, and the lesson languages
+func ReverseLesson(lessonData *LessonData) *LessonData {
+	reversed := cloneLessonMeta(lessonData)
+	reversed.List.QuestionLanguage = lessonData.List.AnswerLanguage
+	reversed.List.AnswerLanguage = lessonData.List.QuestionLanguage
+
+	items := make([]WordItem, len(lessonData.List.Items))
+	for i, item := range lessonData.List.Items {
+		item.Questions, item.Answers = item.Answers, item.Questions
+		items[i] = item
+	}
+	appendItems(reversed, lessonData, items, nil)
+	return reversed
+}
+
+// ShuffleLesson returns the lesson with its items in random order. Passing
+// the same seed gives the same order, which keeps scripted runs repeatable.
+func ShuffleLesson(lessonData *LessonData, seed int64) *LessonData {
+	items := make([]WordItem, len(lessonData.List.Items))
+	copy(items, lessonData.List.Items)
+
+	rng := rand.New(rand.NewSource(seed))
+	rng.Shuffle(len(items), func(i, j int) { items[i], items[j] = items[j], items[i] })
+
+	shuffled := cloneLessonMeta(lessonData)
+	appendItems(shuffled, lessonData, items, nil)
+	return shuffled
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
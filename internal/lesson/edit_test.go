@@ -0,0 +1,108 @@
+package lesson
+
+import (
+	"testing"
+)
+
+func editTestLesson(title string, pairs ...string) *LessonData {
+	lessonData := NewLessonData()
+	lessonData.List.Title = title
+	lessonData.List.QuestionLanguage = "English"
+	lessonData.List.AnswerLanguage = "Spanish"
+	for i := 0; i+2 < len(pairs); i += 3 {
+		lessonData.List.AddWordItem([]string{pairs[i]}, []string{pairs[i+1]}, pairs[i+2])
+	}
+	return lessonData
+}
+
+func TestMergeLessonsRemapsResults(t *testing.T) {
+	first := editTestLesson("Week 1", "cat", "gato", "", "dog", "perro", "")
+	second := editTestLesson("Week 2", "red", "rojo", "")
+	second.List.AddTestResult(0, "wrong")
+
+	merged, err := MergeLessons(first, second)
+	if err != nil {
+		t.Fatalf("MergeLessons failed: %v", err)
+	}
+
+	if merged.List.Title != "Week 1 + Week 2" || len(merged.List.Items) != 3 {
+		t.Fatalf("Unexpected merge result: %q with %d items", merged.List.Title, len(merged.List.Items))
+	}
+	if merged.List.Items[2].ID != 2 || merged.List.Items[2].Questions[0] != "red" {
+		t.Errorf("Items not renumbered: %+v", merged.List.Items[2])
+	}
+	if merged.List.GetWrongAnswersCount(2) != 1 || merged.List.GetWrongAnswersCount(0) != 0 {
+		t.Error("Test result did not follow its item")
+	}
+	if len(first.List.Items) != 2 {
+		t.Error("MergeLessons modified its input")
+	}
+}
+
+func TestSplitLesson(t *testing.T) {
+	lessonData := editTestLesson("Colours",
+		"red", "rojo", "#basic", "blue", "azul", "#basic #sky", "teal", "verde azulado", "")
+	lessonData.List.AddTestResult(2, "right")
+
+	parts, err := SplitLesson(lessonData, 2)
+	if err != nil {
+		t.Fatalf("SplitLesson failed: %v", err)
+	}
+	if len(parts) != 2 || len(parts[0].List.Items) != 2 || len(parts[1].List.Items) != 1 {
+		t.Fatalf("Unexpected split sizes: %d parts", len(parts))
+	}
+	if parts[1].List.Title != "Colours (2)" || parts[1].List.GetRightAnswersCount(0) != 1 {
+		t.Errorf("Second part lost title or results: %+v", parts[1].List)
+	}
+	if _, err := SplitLesson(lessonData, 0); err == nil {
+		t.Error("Expected an error for size 0")
+	}
+
+	byTag := SplitLessonByTag(lessonData)
+	if len(byTag) != 3 {
+		t.Fatalf("Expected basic, sky and untagged groups, got %d", len(byTag))
+	}
+	if len(byTag["basic"].List.Items) != 2 || len(byTag["sky"].List.Items) != 1 || len(byTag[UntaggedGroup].List.Items) != 1 {
+		t.Error("Items grouped incorrectly by tag")
+	}
+}
+
+func TestDedupeLesson(t *testing.T) {
+	lessonData := editTestLesson("Animals",
+		"cat", "gato", "feline", "dog", "perro", "", "Cat ", "gato", "pet")
+	lessonData.List.AddTestResult(2, "wrong")
+
+	deduped, removed := DedupeLesson(lessonData)
+	if removed != 1 || len(deduped.List.Items) != 2 {
+		t.Fatalf("Expected one duplicate removed, got %d (%d items)", removed, len(deduped.List.Items))
+	}
+	if deduped.List.Items[0].Comment != "feline; pet" {
+		t.Errorf("Comments not combined: %q", deduped.List.Items[0].Comment)
+	}
+	if deduped.List.GetWrongAnswersCount(0) != 1 {
+		t.Error("Result of removed duplicate not moved to the kept item")
+	}
+}
+
+func TestReverseAndShuffleLesson(t *testing.T) {
+	lessonData := editTestLesson("Numbers", "one", "uno", "", "two", "dos", "", "three", "tres", "")
+
+	reversed := ReverseLesson(lessonData)
+	if reversed.List.QuestionLanguage != "Spanish" || reversed.List.Items[1].Questions[0] != "dos" {
+		t.Errorf("Lesson not reversed: %+v", reversed.List)
+	}
+
+	shuffled := ShuffleLesson(lessonData, 42)
+	again := ShuffleLesson(lessonData, 42)
+	if len(shuffled.List.Items) != 3 {
+		t.Fatalf("Shuffle lost items")
+	}
+	for i := range shuffled.List.Items {
+		if shuffled.List.Items[i].ID != i {
+			t.Errorf("Shuffled item %d not renumbered", i)
+		}
+		if shuffled.List.Items[i].Questions[0] != again.List.Items[i].Questions[0] {
+			t.Error("Same seed produced a different order")
+		}
+	}
+}
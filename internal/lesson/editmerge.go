@@ -0,0 +1,192 @@
+package lesson
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// EditConflict records an item or list field that was changed by both
+// sides of a merge. The incoming edit is the last writer and wins; the
+// current version is kept here so it can be shown to the user.
+type EditConflict struct {
+	// ItemID is the id of the item, -1 for list fields
+	ItemID int `json:"itemId"`
+	// Field names the list field, "" for items
+	Field string `json:"field,omitempty"`
+	// Kept is the winning version of the item, nil if it was deleted
+	Kept *WordItem `json:"kept,omitempty"`
+	// Lost is the overwritten version of the item, nil if it was deleted
+	Lost *WordItem `json:"lost,omitempty"`
+	// KeptValue and LostValue hold both versions of a list field
+	KeptValue string `json:"keptValue,omitempty"`
+	LostValue string `json:"lostValue,omitempty"`
+	// KeptCurrent is set when Kept is the current version, as when the
+	// incoming edit deleted an item the current one changed
+	KeptCurrent bool `json:"keptCurrent,omitempty"`
+}
+
+// MergeEdits merges two edits of the same lesson made from base: current,
+// the version already saved, and incoming, the one being saved now. Items
+// are matched by id; an item changed on one side only takes that change,
+// and an item changed on both sides takes the incoming version, with the
+// current one reported as a conflict. Items added on both sides are all
+// kept, renumbering the incoming ones whose id is taken.
+func MergeEdits(base, current, incoming *LessonData) (*LessonData, []EditConflict) {
+	merged := cloneLessonMeta(current)
+	var conflicts []EditConflict
+
+	mergeField := func(field string, base, current, incoming string) string {
+		value, conflict := mergeValue(base, current, incoming)
+		if conflict {
+			conflicts = append(conflicts, EditConflict{ItemID: -1, Field: field, KeptValue: incoming, LostValue: current})
+		}
+		return value
+	}
+	merged.List.Title = mergeField("title", base.List.Title, current.List.Title, incoming.List.Title)
+	merged.List.QuestionLanguage = mergeField("questionLanguage", base.List.QuestionLanguage, current.List.QuestionLanguage, incoming.List.QuestionLanguage)
+	merged.List.AnswerLanguage = mergeField("answerLanguage", base.List.AnswerLanguage, current.List.AnswerLanguage, incoming.List.AnswerLanguage)
+	merged.List.Author = mergeField("author", base.List.Author, current.List.Author, incoming.List.Author)
+	merged.List.Description = mergeField("description", base.List.Description, current.List.Description, incoming.List.Description)
+	merged.List.License = mergeField("license", base.List.License, current.List.License, incoming.List.License)
+	merged.List.Level = mergeField("level", base.List.Level, current.List.Level, incoming.List.Level)
+	merged.List.UUID = mergeField("uuid", base.List.UUID, current.List.UUID, incoming.List.UUID)
+	merged.List.Sync = mergeField("sync", base.List.Sync, current.List.Sync, incoming.List.Sync)
+	merged.List.Tags = ParseTags(mergeField("tags", strings.Join(base.List.Tags, ", "), strings.Join(current.List.Tags, ", "), strings.Join(incoming.List.Tags, ", ")))
+
+	baseItems := itemsByID(base)
+	incomingItems := itemsByID(incoming)
+	nextID := 0
+	for _, lessonData := range []*LessonData{base, current, incoming} {
+		for _, item := range lessonData.List.Items {
+			nextID = max(nextID, item.ID+1)
+		}
+	}
+
+	// Items in the current version, in its order
+	taken := make(map[int]bool)
+	for _, item := range current.List.Items {
+		taken[item.ID] = true
+		baseItem, inBase := baseItems[item.ID]
+		incomingItem, inIncoming := incomingItems[item.ID]
+		switch {
+		case !inBase && inIncoming && !itemsEqual(item, incomingItem):
+			// Both sides added an item under the same id; the incoming
+			// one is given a new id below
+			merged.List.Items = append(merged.List.Items, item)
+		case !inBase:
+			merged.List.Items = append(merged.List.Items, item)
+		case !inIncoming:
+			// Deleted by the incoming edit, unless changed since
+			if !itemsEqual(item, baseItem) {
+				kept := item
+				conflicts = append(conflicts, EditConflict{ItemID: item.ID, Kept: &kept, KeptCurrent: true})
+				merged.List.Items = append(merged.List.Items, item)
+			}
+		case itemsEqual(item, baseItem) || itemsEqual(item, incomingItem):
+			merged.List.Items = append(merged.List.Items, incomingItem)
+		case itemsEqual(incomingItem, baseItem):
+			merged.List.Items = append(merged.List.Items, item)
+		default:
+			kept, lost := incomingItem, item
+			conflicts = append(conflicts, EditConflict{ItemID: item.ID, Kept: &kept, Lost: &lost})
+			merged.List.Items = append(merged.List.Items, incomingItem)
+		}
+	}
+
+	// Items only in the incoming version
+	for _, item := range incoming.List.Items {
+		baseItem, inBase := baseItems[item.ID]
+		switch {
+		case inBase && !taken[item.ID]:
+			// Deleted by the current edit, unless changed since
+			if !itemsEqual(item, baseItem) {
+				kept := item
+				conflicts = append(conflicts, EditConflict{ItemID: item.ID, Kept: &kept})
+				merged.List.Items = append(merged.List.Items, item)
+			}
+		case !inBase && taken[item.ID]:
+			if currentItem, _ := findItem(current, item.ID); !itemsEqual(item, currentItem) {
+				item.ID = nextID
+				nextID++
+				merged.List.Items = append(merged.List.Items, item)
+			}
+		case !inBase:
+			merged.List.Items = append(merged.List.Items, item)
+		}
+	}
+
+	// Test results are only ever added, so both sides' new ones are kept
+	merged.List.Tests = append([]Test(nil), current.List.Tests...)
+	if len(incoming.List.Tests) > len(base.List.Tests) {
+		merged.List.Tests = append(merged.List.Tests, incoming.List.Tests[len(base.List.Tests):]...)
+	}
+
+	// Resources are merged per key like items; a missing key counts as nil
+	keys := make(map[string]bool)
+	for _, lessonData := range []*LessonData{base, current, incoming} {
+		for key := range lessonData.Resources {
+			keys[key] = true
+		}
+	}
+	for key := range keys {
+		baseValue, currentValue, incomingValue := base.Resources[key], current.Resources[key], incoming.Resources[key]
+		value := incomingValue
+		if !valuesEqual(currentValue, baseValue) && valuesEqual(incomingValue, baseValue) {
+			value = currentValue
+		}
+		if value == nil {
+			delete(merged.Resources, key)
+		} else {
+			merged.Resources[key] = value
+		}
+	}
+
+	merged.Changed = true
+	return merged, conflicts
+}
+
+// mergeValue merges one field, reporting a conflict when both sides changed
+// it differently
+func mergeValue(base, current, incoming string) (string, bool) {
+	switch {
+	case current == incoming || current == base:
+		return incoming, false
+	case incoming == base:
+		return current, false
+	default:
+		return incoming, true
+	}
+}
+
+func itemsByID(lessonData *LessonData) map[int]WordItem {
+	items := make(map[int]WordItem, len(lessonData.List.Items))
+	for _, item := range lessonData.List.Items {
+		items[item.ID] = item
+	}
+	return items
+}
+
+func findItem(lessonData *LessonData, id int) (WordItem, bool) {
+	for _, item := range lessonData.List.Items {
+		if item.ID == id {
+			return item, true
+		}
+	}
+	return WordItem{}, false
+}
+
+// itemsEqual compares items as they are stored, so numbers in Extras
+// compare equal whether or not they went through JSON
+func itemsEqual(a, b WordItem) bool {
+	return valuesEqual(a, b)
+}
+
+func valuesEqual(a, b interface{}) bool {
+	dataA, errA := json.Marshal(a)
+	dataB, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	return string(dataA) == string(dataB)
+}
@@ -0,0 +1,130 @@
+package lesson
+
+import "testing"
+
+func syncedLesson() *LessonData {
+	lessonData := NewLessonData()
+	lessonData.List.Title = "Animals"
+	lessonData.List.AddWordItem([]string{"cat"}, []string{"gato"}, "")
+	lessonData.List.AddWordItem([]string{"dog"}, []string{"perro"}, "")
+	lessonData.List.AddWordItem([]string{"bird"}, []string{"pájaro"}, "")
+	return lessonData
+}
+
+func TestMergeEdits(t *testing.T) {
+	base := syncedLesson()
+
+	// One teacher fixes "dog", deletes "bird" and adds "fish"
+	current := syncedLesson()
+	current.List.Items[1].Answers = []string{"el perro"}
+	current.List.Items = current.List.Items[:2]
+	current.List.AddWordItem([]string{"fish"}, []string{"pez"}, "")
+
+	// The other renames the lesson, changes "cat" and adds "horse"
+	incoming := syncedLesson()
+	incoming.List.Title = "Animales"
+	incoming.List.Items[0].Answers = []string{"el gato"}
+	incoming.List.AddWordItem([]string{"horse"}, []string{"caballo"}, "")
+
+	merged, conflicts := MergeEdits(base, current, incoming)
+	if len(conflicts) != 0 {
+		t.Errorf("Expected no conflicts, got %+v", conflicts)
+	}
+	if merged.List.Title != "Animales" {
+		t.Errorf("Title = %q; want Animales", merged.List.Title)
+	}
+	want := map[string]string{"cat": "el gato", "dog": "el perro", "fish": "pez", "horse": "caballo"}
+	if len(merged.List.Items) != len(want) {
+		t.Fatalf("Expected %d items, got %+v", len(want), merged.List.Items)
+	}
+	ids := make(map[int]bool)
+	for _, item := range merged.List.Items {
+		if want[item.Questions[0]] != item.Answers[0] {
+			t.Errorf("Item %q has answer %q; want %q", item.Questions[0], item.Answers[0], want[item.Questions[0]])
+		}
+		if ids[item.ID] {
+			t.Errorf("Id %d is used twice", item.ID)
+		}
+		ids[item.ID] = true
+	}
+}
+
+func TestMergeEditsConflicts(t *testing.T) {
+	base := syncedLesson()
+
+	current := syncedLesson()
+	current.List.Title = "Pets"
+	current.List.Items[0].Answers = []string{"gata"}
+	current.List.Items[2].Comment = "flies"
+
+	incoming := syncedLesson()
+	incoming.List.Title = "Mascotas"
+	incoming.List.Items[0].Answers = []string{"minino"}
+	incoming.List.Items = incoming.List.Items[:2]
+
+	merged, conflicts := MergeEdits(base, current, incoming)
+	if len(conflicts) != 3 {
+		t.Fatalf("Expected 3 conflicts, got %+v", conflicts)
+	}
+	if merged.List.Title != "Mascotas" || merged.List.Items[0].Answers[0] != "minino" {
+		t.Errorf("The last writer should win, got %q and %q", merged.List.Title, merged.List.Items[0].Answers[0])
+	}
+	if len(merged.List.Items) != 3 || merged.List.Items[2].Comment != "flies" {
+		t.Errorf("An item changed by one side should survive its deletion by the other, got %+v", merged.List.Items)
+	}
+	for _, conflict := range conflicts {
+		if conflict.ItemID == 0 && (conflict.Lost == nil || conflict.Lost.Answers[0] != "gata") {
+			t.Errorf("Conflict should keep the overwritten version, got %+v", conflict)
+		}
+	}
+}
+
+func TestResolveConflicts(t *testing.T) {
+	base := syncedLesson()
+
+	// Both devices edit "cat" and the title; one deletes "bird" the other
+	// changed
+	current := syncedLesson()
+	current.List.Title = "Pets"
+	current.List.Items[0].Answers = []string{"gata"}
+	current.List.Items[2].Comment = "flies"
+
+	incoming := syncedLesson()
+	incoming.List.Title = "Mascotas"
+	incoming.List.Items[0].Answers = []string{"minino"}
+	incoming.List.Items = incoming.List.Items[:2]
+
+	merged, conflicts := MergeEdits(base, current, incoming)
+	choices := make([]ConflictChoice, len(conflicts))
+	for i, conflict := range conflicts {
+		switch {
+		case conflict.ItemID < 0:
+			choices[i] = KeepCurrent
+		case conflict.ItemID == 0:
+			choices[i] = KeepBoth
+		default:
+			// The incoming edit deleted it, so keeping incoming deletes it
+			if incoming, current := conflict.Versions(); incoming != nil || current == nil || current.Comment != "flies" {
+				t.Errorf("Expected the deleted item to have only a current version, got %+v, %+v", incoming, current)
+			}
+			choices[i] = KeepIncoming
+		}
+	}
+	resolved := ResolveConflicts(merged, conflicts, choices)
+	if resolved.List.Title != "Pets" {
+		t.Errorf("Title = %q; want the current Pets", resolved.List.Title)
+	}
+	var answers []string
+	for _, item := range resolved.List.Items {
+		answers = append(answers, item.Answers[0])
+	}
+	if len(resolved.List.Items) != 3 || answers[0] != "minino" || answers[1] != "gata" || answers[2] != "perro" {
+		t.Errorf("Expected both cats and no bird, got %v", answers)
+	}
+	if id := resolved.List.Items[1].ID; id == 0 || id == 1 {
+		t.Errorf("Expected the kept current version to get a new id, got %d", id)
+	}
+	if len(merged.List.Items) != 3 || merged.List.Title != "Mascotas" {
+		t.Error("Expected the merged lesson to be left as it was")
+	}
+}
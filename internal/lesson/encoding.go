@@ -0,0 +1,250 @@
+package lesson
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// Text encodings understood by the loaders and savers. Other IANA names
+// such as "iso-8859-15" or "macintosh" are accepted too.
+const (
+	EncodingUTF8        = "utf-8"
+	EncodingUTF8BOM     = "utf-8-bom" // UTF-8 with byte order mark, needed by Excel
+	EncodingUTF16LE     = "utf-16le"
+	EncodingUTF16BE     = "utf-16be"
+	EncodingWindows1252 = "windows-1252"
+	EncodingLatin1      = "iso-8859-1"
+)
+
+// encodingSniffLength is how much of a file is inspected to recognise UTF-16
+// without a byte order mark
+const encodingSniffLength = 1024
+
+// SetEncodingOverride makes the loader read filePath in the given encoding
+// instead of detecting it. An empty name removes the override.
+func (fl *FileLoader) SetEncodingOverride(filePath, name string) error {
+	if name != "" {
+		if _, err := textEncoding(name); err != nil {
+			return err
+		}
+	}
+
+	fl.encodingMutex.Lock()
+	defer fl.encodingMutex.Unlock()
+	if fl.encodingOverrides == nil {
+		fl.encodingOverrides = make(map[string]string)
+	}
+	key := encodingOverrideKey(filePath)
+	if name == "" {
+		delete(fl.encodingOverrides, key)
+	} else {
+		fl.encodingOverrides[key] = name
+	}
+	return nil
+}
+
+// EncodingOverride returns the encoding set for filePath, or "" to detect it
+func (fl *FileLoader) EncodingOverride(filePath string) string {
+	fl.encodingMutex.RLock()
+	defer fl.encodingMutex.RUnlock()
+	return fl.encodingOverrides[encodingOverrideKey(filePath)]
+}
+
+func encodingOverrideKey(filePath string) string {
+	if absPath, err := filepath.Abs(filePath); err == nil {
+		return absPath
+	}
+	return filepath.Clean(filePath)
+}
+
+// DetectFileEncoding returns the encoding a text file would be read in
+func (fl *FileLoader) DetectFileEncoding(filePath string) (string, error) {
+	if name := fl.EncodingOverride(filePath); name != "" {
+		return name, nil
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	return detectTextEncoding(data), nil
+}
+
+// readText reads a text file and transcodes it to UTF-8, using the override
+// for the file or else the detected encoding
+func (fl *FileLoader) readText(filePath string) (string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	name := fl.EncodingOverride(filePath)
+	if name == "" {
+		name = detectTextEncoding(data)
+	}
+	if name != EncodingUTF8 && name != EncodingUTF8BOM {
+		log.Printf("[INFO] FileLoader.readText() - reading %s as %s", filepath.Base(filePath), name)
+	}
+	return decodeText(data, name)
+}
+
+// detectTextEncoding recognises byte order marks and UTF-16 text without
+// one, and falls back to Windows-1252 for data that is not valid UTF-8.
+// Windows-1252 is a superset of the printable part of ISO-8859-1, so it
+// reads Latin-1 files correctly as well.
+func detectTextEncoding(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return EncodingUTF8BOM
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return EncodingUTF16LE
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return EncodingUTF16BE
+	}
+
+	if name := sniffUTF16(data); name != "" {
+		return name
+	}
+	if !utf8.Valid(data) {
+		return EncodingWindows1252
+	}
+	return EncodingUTF8
+}
+
+// sniffUTF16 recognises UTF-16 without byte order mark by the zero bytes
+// that ASCII characters leave in every other position
+func sniffUTF16(data []byte) string {
+	if len(data) > encodingSniffLength {
+		data = data[:encodingSniffLength]
+	}
+	if len(data) < 4 {
+		return ""
+	}
+
+	var evenZeros, oddZeros int
+	for i, b := range data {
+		if b != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			evenZeros++
+		} else {
+			oddZeros++
+		}
+	}
+
+	half := len(data) / 2
+	switch {
+	case oddZeros > half*2/3 && evenZeros == 0:
+		return EncodingUTF16LE
+	case evenZeros > half*2/3 && oddZeros == 0:
+		return EncodingUTF16BE
+	default:
+		return ""
+	}
+}
+
+// textEncoding returns the x/text encoding for an encoding name
+func textEncoding(name string) (encoding.Encoding, error) {
+	switch strings.ToLower(name) {
+	case "", EncodingUTF8, "utf8":
+		return unicode.UTF8, nil
+	case EncodingUTF8BOM:
+		return unicode.UTF8BOM, nil
+	case EncodingUTF16LE:
+		return unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), nil
+	case EncodingUTF16BE:
+		return unicode.UTF16(unicode.BigEndian, unicode.UseBOM), nil
+	case EncodingWindows1252, "cp1252":
+		return charmap.Windows1252, nil
+	case EncodingLatin1, "latin1", "latin-1":
+		return charmap.ISO8859_1, nil
+	}
+
+	if enc, err := ianaindex.IANA.Encoding(name); err == nil && enc != nil {
+		return enc, nil
+	}
+	return nil, fmt.Errorf("%w: unsupported encoding %s", ErrEncoding, name)
+}
+
+// decodeText converts data in the given encoding to a string, dropping a BOM
+func decodeText(data []byte, name string) (string, error) {
+	enc, err := textEncoding(name)
+	if err != nil {
+		return "", err
+	}
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s: %w", ErrEncoding, name, err)
+	}
+	return strings.TrimPrefix(string(decoded), "\ufeff"), nil
+}
+
+// xmlCharsetReader lets encoding/xml read any charset named in an XML
+// declaration
+func xmlCharsetReader(charset string, input io.Reader) (io.Reader, error) {
+	enc, err := textEncoding(charset)
+	if err != nil {
+		return nil, err
+	}
+	return transform.NewReader(input, enc.NewDecoder()), nil
+}
+
+// newXMLDecoder returns an XML decoder for a lesson file. A charset named in
+// the XML declaration is honoured; without one, or with an encoding
+// override for the file, the content is transcoded to UTF-8 first.
+func (fl *FileLoader) newXMLDecoder(filePath string, r io.Reader) *xml.Decoder {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return xml.NewDecoder(failingReader{err})
+	}
+
+	override := fl.EncodingOverride(filePath)
+	name := override
+	if name == "" && !xmlDeclaresEncoding(data) {
+		name = detectTextEncoding(data)
+	}
+
+	if override != "" || (name != "" && name != EncodingUTF8) {
+		if text, err := decodeText(data, name); err == nil {
+			decoder := xml.NewDecoder(strings.NewReader(text))
+			// The text is UTF-8 now, whatever the declaration says
+			decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+				return input, nil
+			}
+			return decoder
+		}
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = xmlCharsetReader
+	return decoder
+}
+
+// xmlDeclaresEncoding reports whether data starts with an XML declaration
+// that names an encoding
+func xmlDeclaresEncoding(data []byte) bool {
+	data = bytes.TrimPrefix(data, []byte{0xEF, 0xBB, 0xBF})
+	if !bytes.HasPrefix(data, []byte("<?xml")) {
+		return false
+	}
+	end := bytes.Index(data, []byte("?>"))
+	return end > 0 && bytes.Contains(data[:end], []byte("encoding="))
+}
+
+// failingReader returns err on every read
+type failingReader struct{ err error }
+
+func (r failingReader) Read([]byte) (int, error) { return 0, r.err }
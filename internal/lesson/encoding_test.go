@@ -0,0 +1,107 @@
+package lesson
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func TestDetectTextEncoding(t *testing.T) {
+	utf16le, _ := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewEncoder().String("huis\thouse\n")
+	utf16be, _ := unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM).NewEncoder().String("huis\thouse\n")
+
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"ascii", "huis\thouse\n", EncodingUTF8},
+		{"utf-8", "café\tkoffie\n", EncodingUTF8},
+		{"utf-8 bom", "\xef\xbb\xbfhuis\n", EncodingUTF8BOM},
+		{"utf-16le bom", "\xff\xfeh\x00", EncodingUTF16LE},
+		{"utf-16le without bom", utf16le, EncodingUTF16LE},
+		{"utf-16be without bom", utf16be, EncodingUTF16BE},
+		{"latin-1", "caf\xe9\tkoffie\n", EncodingWindows1252},
+	}
+
+	for _, tt := range tests {
+		if got := detectTextEncoding([]byte(tt.data)); got != tt.want {
+			t.Errorf("%s: detectTextEncoding() = %s; want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLoadTextFileTranscodes(t *testing.T) {
+	dir := t.TempDir()
+	loader := NewFileLoader()
+
+	utf16, _ := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder().String("Straße = street\nÄpfel = apples\n")
+	files := map[string]string{
+		"latin1.txt": "Stra\xdfe = street\n\xc4pfel = apples\n",
+		"utf16.txt":  utf16,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		lessonData, err := loader.LoadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to load %s: %v", name, err)
+		}
+		if len(lessonData.List.Items) != 2 || lessonData.List.Items[0].Questions[0] != "Straße" ||
+			lessonData.List.Items[1].Questions[0] != "Äpfel" {
+			t.Errorf("%s: unexpected items %+v", name, lessonData.List.Items)
+		}
+	}
+}
+
+func TestEncodingOverride(t *testing.T) {
+	// "дом = house" in KOI8-R is valid Latin-1 too, so only an override
+	// reads it correctly
+	koi8, _ := charmap.KOI8R.NewEncoder().String("дом = house\n")
+	path := filepath.Join(t.TempDir(), "russian.txt")
+	if err := os.WriteFile(path, []byte(koi8), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	loader := NewFileLoader()
+	if err := loader.SetEncodingOverride(path, "no-such-encoding"); err == nil {
+		t.Error("Expected an error for an unknown encoding")
+	}
+	if err := loader.SetEncodingOverride(path, "KOI8-R"); err != nil {
+		t.Fatalf("SetEncodingOverride failed: %v", err)
+	}
+	lessonData, err := loader.LoadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to load file: %v", err)
+	}
+	if lessonData.List.Items[0].Questions[0] != "дом" {
+		t.Errorf("Override not applied: %q", lessonData.List.Items[0].Questions[0])
+	}
+
+	loader.SetEncodingOverride(path, "")
+	if name, _ := loader.DetectFileEncoding(path); name != EncodingWindows1252 {
+		t.Errorf("Override not removed, encoding is %s", name)
+	}
+}
+
+func TestXMLWithoutDeclaredEncoding(t *testing.T) {
+	// A Windows-1252 CueCard file without encoding in its declaration
+	content := "<?xml version=\"1.0\"?>\n<CueCards><Card Question=\"caf\xe9\" Answer=\"koffie\"/></CueCards>\n"
+	path := filepath.Join(t.TempDir(), "cards.wcu")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	lessonData, err := NewFileLoader().LoadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to load file: %v", err)
+	}
+	if len(lessonData.List.Items) != 1 || lessonData.List.Items[0].Questions[0] != "café" {
+		t.Errorf("Unexpected items: %+v", lessonData.List.Items)
+	}
+}
@@ -0,0 +1,118 @@
+package lesson
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// Errors returned when loading or saving files. Check for them with
+// errors.Is, or with errors.As for ErrCorruptArchive; UserMessage turns
+// them into text to show the user.
+var (
+	// ErrUnsupportedFormat is returned for files no loader or saver handles
+	ErrUnsupportedFormat = errors.New("unsupported file format")
+	// ErrEncoding is returned when the text of a file cannot be decoded
+	ErrEncoding = errors.New("unreadable text encoding")
+	// ErrPasswordProtected is returned for files locked with a password by
+	// the program that wrote them
+	ErrPasswordProtected = errors.New("file is password-protected")
+)
+
+// ErrCorruptArchive is returned for a file that has the extension of a
+// format but not its content, such as a ZIP archive without a list.json or
+// a database without the tables of an Anki collection
+type ErrCorruptArchive struct {
+	// Format is the format the file should be in, such as "Anki database"
+	Format string
+	// Detail tells what is wrong with the file
+	Detail string
+	// Entry names the damaged file inside the archive, such as list.json
+	// or an image, when it is known
+	Entry string
+	// Err is the error of the parser, if any
+	Err error
+}
+
+func (e *ErrCorruptArchive) Error() string {
+	msg := "not a valid " + e.Format
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *ErrCorruptArchive) Unwrap() error {
+	return e.Err
+}
+
+// corruptArchive returns an ErrCorruptArchive for a file in format
+func corruptArchive(format, detail string, err error) error {
+	return &ErrCorruptArchive{Format: format, Detail: detail, Err: err}
+}
+
+// formatError is an ErrUnsupportedFormat for the extension of a file
+type formatError struct {
+	action string // "load" or "save"
+	ext    string
+}
+
+func (e *formatError) Error() string {
+	return fmt.Sprintf("unsupported %s format: %s", e.action, e.ext)
+}
+
+func (e *formatError) Is(target error) bool {
+	return target == ErrUnsupportedFormat
+}
+
+// UserMessage returns the message to show the user for an error returned
+// by FileLoader or FileSaver. Errors this package does not know are
+// returned as they are.
+func UserMessage(err error) string {
+	var corrupt *ErrCorruptArchive
+	var locked *ErrLocked
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrPassphraseRequired):
+		return "The file is encrypted. Enter its passphrase to open it."
+	case errors.Is(err, ErrWrongPassphrase):
+		return "The passphrase is wrong, or the file is damaged."
+	case errors.Is(err, ErrPasswordProtected):
+		return "The file is password-protected. Remove the password in the program that made it and try again."
+	case errors.Is(err, ErrPackTampered):
+		return "The lesson pack was changed after it was signed. Ask its author for a new copy."
+	case errors.As(err, &locked):
+		return fmt.Sprintf("The lesson is in use by %s. Save it under another name, or wait until they close it.", locked.Lock)
+	case errors.Is(err, ErrSignInNeeded):
+		return "You are no longer signed in to the cloud drive. Sign in again and try once more."
+	case errors.Is(err, ErrConflict):
+		return "The file was changed by somebody else since you opened it. Open it again to see their changes, or save yours under another name."
+	case errors.As(err, &corrupt):
+		return fmt.Sprintf("The file is not a valid %s (%s). It may be damaged or only partly downloaded.",
+			corrupt.Format, corruptDetail(corrupt))
+	case errors.Is(err, ErrEncoding):
+		return "The text encoding of the file cannot be read. Choose its encoding by hand and try again."
+	case errors.Is(err, ErrUnsupportedFormat):
+		return "Recuerdo cannot read or write files of this kind. Try saving it as CSV in the program that made it."
+	case errors.Is(err, fs.ErrNotExist):
+		return "The file does not exist. It may have been moved or deleted."
+	case errors.Is(err, fs.ErrPermission):
+		return "You do not have permission to open this file."
+	}
+	return err.Error()
+}
+
+// corruptDetail returns what is wrong with a corrupt file, for UserMessage
+func corruptDetail(e *ErrCorruptArchive) string {
+	if e.Detail != "" {
+		return e.Detail
+	}
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return "unknown content"
+}
@@ -0,0 +1,82 @@
+package lesson
+
+import (
+	"archive/zip"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	notAnki := filepath.Join(dir, "collection.anki2")
+	if err := os.WriteFile(notAnki, []byte("question,answer\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := NewFileLoader().LoadFile(notAnki)
+	var corrupt *ErrCorruptArchive
+	if !errors.As(err, &corrupt) || corrupt.Format != "Anki database" {
+		t.Fatalf("loading a text file as Anki: %v", err)
+	}
+	if message := UserMessage(err); !strings.Contains(message, "not a valid Anki database") {
+		t.Errorf("UserMessage = %q", message)
+	}
+
+	noList := filepath.Join(dir, "map.ottp")
+	file, err := os.Create(noList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := zip.NewWriter(file)
+	if _, err := archive.Create("map.png"); err != nil {
+		t.Fatal(err)
+	}
+	archive.Close()
+	file.Close()
+	_, err = NewFileLoader().LoadFile(noList)
+	if !errors.As(err, &corrupt) || corrupt.Detail != "no list.json file found" {
+		t.Errorf("loading a topography archive without list.json: %v", err)
+	}
+
+	// Excel keeps password-protected workbooks in an OLE container
+	locked := filepath.Join(dir, "locked.xlsx")
+	ole := append([]byte{0xd0, 0xcf, 0x11, 0xe0, 0xa1, 0xb1, 0x1a, 0xe1}, make([]byte, 504)...)
+	ole = append(ole, encryptedPackageName...)
+	if err := os.WriteFile(locked, ole, 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewFileLoader().LoadFile(locked)
+	if !errors.Is(err, ErrPasswordProtected) {
+		t.Errorf("loading a password-protected workbook: %v", err)
+	}
+	if message := UserMessage(err); !strings.Contains(message, "password-protected") {
+		t.Errorf("UserMessage = %q", message)
+	}
+
+	if _, err := decodeText([]byte("hello"), "klingon"); !errors.Is(err, ErrEncoding) {
+		t.Errorf("decoding an unknown encoding: %v", err)
+	}
+}
+
+func TestSaveUnsupportedFormat(t *testing.T) {
+	err := NewFileSaver().SaveFile(NewLessonData(), filepath.Join(t.TempDir(), "words.unknown"))
+	if !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("saving an unknown format: %v", err)
+	}
+	if message := UserMessage(err); message == err.Error() {
+		t.Errorf("no message for the user: %q", message)
+	}
+}
+
+func TestUserMessageUnknownError(t *testing.T) {
+	err := errors.New("disk on fire")
+	if message := UserMessage(err); message != err.Error() {
+		t.Errorf("UserMessage = %q, want the error as is", message)
+	}
+	if message := UserMessage(nil); message != "" {
+		t.Errorf("UserMessage(nil) = %q", message)
+	}
+}
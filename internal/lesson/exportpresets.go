@@ -0,0 +1,274 @@
+package lesson
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/paths"
+)
+
+// Export presets bundle one or more formats and the options to save them
+// with under a name, so exporting a lesson for a common purpose is one
+// click in the File menu or one "recuerdo export" command instead of
+// several trips through the save dialog.
+
+// ExportNative in ExportPreset.Formats stands for the OpenTeaching format of
+// the lesson: .ot for words, .otmd for media, .ottp for topography and .otio
+// for image occlusion lessons
+const ExportNative = "native"
+
+// ExportPreset is a named set of formats a lesson is exported to at once
+type ExportPreset struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Formats are the extensions to save, such as ".csv", or ExportNative.
+	// ".pdf" and ".odt" are study sheets.
+	Formats []string `json:"formats"`
+	// Zip puts all formats in one ZIP archive instead of files side by side
+	Zip bool `json:"zip,omitempty"`
+	// MediaStorage overrides SaveOptions.MediaStorage for .otmd lessons
+	MediaStorage string `json:"mediaStorage,omitempty"`
+}
+
+// BuiltinExportPresets returns the presets that are always available
+func BuiltinExportPresets() []ExportPreset {
+	return []ExportPreset{
+		{
+			Name:         "Share with class",
+			Description:  "The lesson in its own format with all media inside, ready to hand out",
+			Formats:      []string{ExportNative},
+			MediaStorage: MediaEmbed,
+		},
+		{
+			Name:         "Archive",
+			Description:  "JSON, CSV and a printable study sheet together in a ZIP file",
+			Formats:      []string{".json", ".csv", ".pdf"},
+			Zip:          true,
+			MediaStorage: MediaEmbed,
+		},
+		{
+			Name:        "Print",
+			Description: "A fold-over study sheet as PDF",
+			Formats:     []string{".pdf"},
+		},
+	}
+}
+
+// DefaultExportPresetsPath returns the file with the user's own export
+// presets, next to the settings file
+func DefaultExportPresetsPath() string {
+	return paths.ConfigFile("export-presets.json")
+}
+
+// LoadExportPresets returns the built-in presets followed by those in the
+// JSON file at filePath. A user preset with the name of a built-in one
+// replaces it; a missing file just means there are no user presets.
+func LoadExportPresets(filePath string) ([]ExportPreset, error) {
+	presets := BuiltinExportPresets()
+	if filePath == "" {
+		return presets, nil
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return presets, nil
+		}
+		return presets, err
+	}
+	var own []ExportPreset
+	if err := json.Unmarshal(data, &own); err != nil {
+		return presets, fmt.Errorf("failed to parse export presets file: %w", err)
+	}
+
+	for _, preset := range own {
+		if err := preset.Validate(); err != nil {
+			return presets, err
+		}
+		if i := FindExportPreset(presets, preset.Name); i >= 0 {
+			presets[i] = preset
+		} else {
+			presets = append(presets, preset)
+		}
+	}
+	return presets, nil
+}
+
+// FindExportPreset returns the index of the preset called name, ignoring
+// case, or -1
+func FindExportPreset(presets []ExportPreset, name string) int {
+	for i, preset := range presets {
+		if strings.EqualFold(preset.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Validate checks that the preset names at least one format that can be
+// saved
+func (p ExportPreset) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("export preset name cannot be empty")
+	}
+	if len(p.Formats) == 0 {
+		return fmt.Errorf("export preset %q has no formats", p.Name)
+	}
+	known := append(NewFileSaver().GetSupportedSaveExtensions(), ".otmd", ".ottp", ".otio", ".pdf", ".odt")
+	for _, format := range p.Formats {
+		if !strings.EqualFold(format, ExportNative) && !slices.Contains(known, strings.ToLower(format)) {
+			return fmt.Errorf("export preset %q has unknown format %q", p.Name, format)
+		}
+	}
+	switch p.MediaStorage {
+	case "", MediaEmbed, MediaLink:
+	default:
+		return fmt.Errorf("export preset %q has unknown media storage %q", p.Name, p.MediaStorage)
+	}
+	return nil
+}
+
+// NativeExtension returns the OpenTeaching extension for lessons of
+// dataType, as returned by FileLoader.GetFileType
+func NativeExtension(dataType string) string {
+	switch dataType {
+	case "media":
+		return ".otmd"
+	case "topo":
+		return ".ottp"
+	case "occlusion":
+		return ".otio"
+	default:
+		return ".ot"
+	}
+}
+
+// ExportPaths returns the files ExportWithPreset writes for basePath, a
+// path without extension: one archive when the preset zips, or one file per
+// format otherwise
+func (p ExportPreset) ExportPaths(basePath, dataType string) []string {
+	if p.Zip {
+		return []string{basePath + ".zip"}
+	}
+	files := make([]string, 0, len(p.Formats))
+	for _, format := range p.Formats {
+		files = append(files, basePath+p.extension(format, dataType))
+	}
+	return files
+}
+
+// extension resolves ExportNative for lessons of dataType
+func (p ExportPreset) extension(format, dataType string) string {
+	if strings.EqualFold(format, ExportNative) {
+		return NativeExtension(dataType)
+	}
+	return strings.ToLower(format)
+}
+
+// ExportWithPreset saves a lesson of dataType in every format of the preset.
+// basePath is the path of the files without extension; see ExportPaths for
+// what is written. It returns the files written.
+func (fs *FileSaver) ExportWithPreset(lessonData *LessonData, dataType string, preset ExportPreset, basePath string) ([]string, error) {
+	log.Printf("[ACTION] FileSaver.ExportWithPreset() - exporting with preset %q", preset.Name)
+
+	if err := preset.Validate(); err != nil {
+		return nil, err
+	}
+	if err := fs.ValidateLessonData(lessonData); err != nil {
+		return nil, err
+	}
+
+	saver := *fs
+	if preset.MediaStorage != "" {
+		saver.Options.MediaStorage = preset.MediaStorage
+	}
+
+	if !preset.Zip {
+		files := preset.ExportPaths(basePath, dataType)
+		for _, file := range files {
+			if err := saver.exportOne(lessonData, file); err != nil {
+				log.Printf("[ERROR] Failed to export %s: %v", file, err)
+				return nil, err
+			}
+		}
+		log.Printf("[SUCCESS] FileSaver.ExportWithPreset() - wrote %d files", len(files))
+		return files, nil
+	}
+
+	// Each format is saved in a scratch directory first and then copied
+	// into the archive under the name of the lesson
+	scratch, err := os.MkdirTemp("", "recuerdo-export")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(scratch)
+
+	name := filepath.Base(basePath)
+	var entries []string
+	for _, format := range preset.Formats {
+		entry := name + preset.extension(format, dataType)
+		if err := saver.exportOne(lessonData, filepath.Join(scratch, entry)); err != nil {
+			log.Printf("[ERROR] Failed to export %s: %v", entry, err)
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	archivePath := basePath + ".zip"
+	err = writeAtomically(archivePath, fs.Options.Backups, 0644, func(tempPath string) error {
+		archive, err := os.Create(tempPath)
+		if err != nil {
+			return err
+		}
+		defer archive.Close()
+
+		writer := zip.NewWriter(archive)
+		for _, entry := range entries {
+			if err := addFileToZip(writer, filepath.Join(scratch, entry), entry); err != nil {
+				return err
+			}
+		}
+		return writer.Close()
+	})
+	if err != nil {
+		log.Printf("[ERROR] Failed to write export archive: %v", err)
+		return nil, err
+	}
+
+	log.Printf("[SUCCESS] FileSaver.ExportWithPreset() - wrote %d formats to %s", len(entries), archivePath)
+	return []string{archivePath}, nil
+}
+
+// exportOne saves a single format of an export preset; .pdf and .odt are
+// study sheets
+func (fs *FileSaver) exportOne(lessonData *LessonData, filePath string) error {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".pdf", ".odt":
+		return fs.SaveStudySheet(lessonData, filePath)
+	default:
+		return fs.SaveFile(lessonData, filePath)
+	}
+}
+
+// addFileToZip copies the file at path into the archive as name
+func addFileToZip(writer *zip.Writer, path, name string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	entry, err := writer.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, file)
+	return err
+}
@@ -0,0 +1,90 @@
+package lesson
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExportWithPreset(t *testing.T) {
+	lessonData := NewLessonData()
+	lessonData.List.Title = "Animals"
+	lessonData.List.AddWordItem([]string{"chat"}, []string{"cat"}, "")
+	lessonData.List.AddWordItem([]string{"chien"}, []string{"dog"}, "")
+
+	presets := BuiltinExportPresets()
+	dir := t.TempDir()
+	fs := NewFileSaver()
+
+	share := presets[FindExportPreset(presets, "share with class")]
+	files, err := fs.ExportWithPreset(lessonData, "words", share, filepath.Join(dir, "animals"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || filepath.Ext(files[0]) != ".ot" {
+		t.Fatalf("expected an .ot file, got %v", files)
+	}
+	loaded, err := NewFileLoader().LoadFile(files[0])
+	if err != nil || len(loaded.List.Items) != 2 {
+		t.Fatalf("expected the shared lesson to load, got %v", err)
+	}
+
+	archive := presets[FindExportPreset(presets, "Archive")]
+	files, err = fs.ExportWithPreset(lessonData, "words", archive, filepath.Join(dir, "archive"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := zip.OpenReader(filepath.Join(dir, "archive.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	var names []string
+	for _, file := range reader.File {
+		names = append(names, file.Name)
+	}
+	if len(names) != 3 || names[0] != "archive.json" || names[1] != "archive.csv" || names[2] != "archive.pdf" {
+		t.Errorf("expected JSON, CSV and PDF in the archive, got %v", names)
+	}
+	if len(files) != 1 || files[0] != filepath.Join(dir, "archive.zip") {
+		t.Errorf("expected only the archive to be written, got %v", files)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(dir, "archive.*")); len(leftovers) != 1 {
+		t.Errorf("expected no files next to the archive, got %v", leftovers)
+	}
+}
+
+func TestLoadExportPresets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export-presets.json")
+	if err := os.WriteFile(path, []byte(`[
+		{"name": "print", "formats": [".odt"]},
+		{"name": "Spreadsheets", "formats": [".ods", ".xlsx"], "zip": true}
+	]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	presets, err := LoadExportPresets(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(presets) != 4 {
+		t.Fatalf("expected the built-in presets and one more, got %d", len(presets))
+	}
+	if printing := presets[FindExportPreset(presets, "Print")]; printing.Formats[0] != ".odt" {
+		t.Errorf("expected the user preset to replace the built-in one, got %v", printing.Formats)
+	}
+	if FindExportPreset(presets, "spreadsheets") < 0 {
+		t.Error("expected the user preset to be added")
+	}
+
+	if err := os.WriteFile(path, []byte(`[{"name": "Bad", "formats": [".doc"]}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadExportPresets(path); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+	if presets, err := LoadExportPresets(filepath.Join(t.TempDir(), "missing.json")); err != nil || len(presets) != 3 {
+		t.Errorf("expected the built-in presets without a file, got %d, %v", len(presets), err)
+	}
+}
@@ -0,0 +1,117 @@
+package lesson
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"log"
+	"strings"
+)
+
+// Keys of WordItem.Extras set by the loaders for per-item data Recuerdo does
+// not model itself. The saver of a format only writes back its own keys, so
+// re-exporting to the format a lesson came from keeps tags, scheduling and
+// styling, while other formats ignore them.
+const (
+	// ExtraKVTMLEntry holds the raw XML of unknown <entry> children, such as
+	// <inactive> and <sizehint>
+	ExtraKVTMLEntry = "kvtml.entry"
+	// ExtraKVTMLQuestion and ExtraKVTMLAnswer hold the raw XML of unknown
+	// <translation> children, such as <grade> and <pronunciation>
+	ExtraKVTMLQuestion = "kvtml.translation0"
+	ExtraKVTMLAnswer   = "kvtml.translation1"
+
+	// ExtraT2KItem holds the raw XML of unknown Teach2000 <item> children
+	ExtraT2KItem = "t2k.item"
+	// ExtraT2KErrors, ExtraT2KTestCount and ExtraT2KCorrectCount hold the
+	// statistics of an item as read from the file
+	ExtraT2KErrors       = "t2k.errors"
+	ExtraT2KTestCount    = "t2k.testcount"
+	ExtraT2KCorrectCount = "t2k.correctcount"
+
+	// ExtraAnkiGUID is the globally unique id of the Anki note
+	ExtraAnkiGUID = "anki.guid"
+	// ExtraAnkiTags holds the space separated tags of the Anki note
+	ExtraAnkiTags = "anki.tags"
+	// ExtraAnkiFields holds the raw note fields, HTML included, separated by
+	// \x1f, when they differ from the question and answer
+	ExtraAnkiFields = "anki.fields"
+	// ExtraAnkiInterval, ExtraAnkiEase, ExtraAnkiReviews and ExtraAnkiLapses
+	// hold the scheduling state of the note's cards
+	ExtraAnkiInterval = "anki.interval"
+	ExtraAnkiEase     = "anki.ease"
+	ExtraAnkiReviews  = "anki.reviews"
+	ExtraAnkiLapses   = "anki.lapses"
+)
+
+// SetExtra stores a value in the item's Extras, creating the map if needed.
+// Empty values are not stored.
+func (wi *WordItem) SetExtra(key string, value any) {
+	if value == nil || value == "" {
+		return
+	}
+	if wi.Extras == nil {
+		wi.Extras = make(map[string]any)
+	}
+	wi.Extras[key] = value
+}
+
+// ExtraString returns a string stored in the item's Extras
+func (wi *WordItem) ExtraString(key string) (string, bool) {
+	value, ok := wi.Extras[key].(string)
+	return value, ok
+}
+
+// ExtraInt returns a number stored in the item's Extras. Numbers read back
+// from JSON are float64, so those are accepted too.
+func (wi *WordItem) ExtraInt(key string) (int, bool) {
+	switch value := wi.Extras[key].(type) {
+	case int:
+		return value, true
+	case int64:
+		return int(value), true
+	case float64:
+		return int(value), true
+	case json.Number:
+		n, err := value.Int64()
+		return int(n), err == nil
+	}
+	return 0, false
+}
+
+// rawXMLElement keeps an XML element a loader does not understand, so a
+// saver can write it back unchanged
+type rawXMLElement struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Inner   string     `xml:",innerxml"`
+}
+
+// rawXMLString serializes unknown elements for storing in Extras
+func rawXMLString(elements []rawXMLElement) string {
+	var buffer bytes.Buffer
+	encoder := xml.NewEncoder(&buffer)
+	for _, element := range elements {
+		if err := encoder.Encode(element); err != nil {
+			log.Printf("[WARNING] Failed to keep unknown <%s> element: %v", element.XMLName.Local, err)
+		}
+	}
+	return buffer.String()
+}
+
+// rawXMLElements parses elements stored in Extras by rawXMLString
+func rawXMLElements(extras map[string]any, key string) []rawXMLElement {
+	raw, ok := extras[key].(string)
+	if !ok || strings.TrimSpace(raw) == "" {
+		return nil
+	}
+
+	var wrapper struct {
+		Elements []rawXMLElement `xml:",any"`
+	}
+	if err := xml.Unmarshal([]byte("<extras>"+raw+"</extras>"), &wrapper); err != nil {
+		log.Printf("[WARNING] Ignoring malformed %s extras: %v", key, err)
+		return nil
+	}
+	return wrapper.Elements
+}
@@ -0,0 +1,172 @@
+package lesson
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKVTMLRoundTripKeepsExtras(t *testing.T) {
+	tmpDir := t.TempDir()
+	source := filepath.Join(tmpDir, "source.kvtml")
+	content := `<?xml version="1.0" encoding="UTF-8"?>
+<kvtml version="2.0">
+  <identifiers>
+    <identifier id="0"><name>English</name></identifier>
+    <identifier id="1"><name>German</name></identifier>
+  </identifiers>
+  <entries>
+    <entry id="0">
+      <inactive>false</inactive>
+      <translation id="0">
+        <text>hello</text>
+        <comment>greeting</comment>
+        <pronunciation>həˈləʊ</pronunciation>
+      </translation>
+      <translation id="1">
+        <text>hallo</text>
+        <grade fromid="0"><currentgrade>3</currentgrade><count>5</count></grade>
+      </translation>
+    </entry>
+  </entries>
+</kvtml>`
+	if err := os.WriteFile(source, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write KVTML file: %v", err)
+	}
+
+	lessonData, err := NewFileLoader().LoadFile(source)
+	if err != nil {
+		t.Fatalf("Failed to load KVTML file: %v", err)
+	}
+	item := lessonData.List.Items[0]
+	if item.Comment != "greeting" {
+		t.Errorf("Comment = %q; want greeting", item.Comment)
+	}
+	if grade, _ := item.ExtraString(ExtraKVTMLAnswer); !strings.Contains(grade, "<currentgrade>3</currentgrade>") {
+		t.Errorf("Grade not kept: %q", grade)
+	}
+
+	// Through JSON and back to KVTML
+	jsonFile := filepath.Join(tmpDir, "lesson.json")
+	if err := NewFileSaver().SaveFile(lessonData, jsonFile); err != nil {
+		t.Fatalf("Failed to save JSON file: %v", err)
+	}
+	lessonData, err = NewFileLoader().LoadFile(jsonFile)
+	if err != nil {
+		t.Fatalf("Failed to load JSON file: %v", err)
+	}
+	target := filepath.Join(tmpDir, "target.kvtml")
+	if err := NewFileSaver().SaveFile(lessonData, target); err != nil {
+		t.Fatalf("Failed to save KVTML file: %v", err)
+	}
+
+	saved, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatalf("Failed to read saved KVTML file: %v", err)
+	}
+	for _, want := range []string{
+		"<inactive>false</inactive>",
+		"<pronunciation>həˈləʊ</pronunciation>",
+		`<grade fromid="0"><currentgrade>3</currentgrade><count>5</count></grade>`,
+	} {
+		if !strings.Contains(string(saved), want) {
+			t.Errorf("Saved KVTML lacks %s:\n%s", want, saved)
+		}
+	}
+
+	reloaded, err := NewFileLoader().LoadFile(target)
+	if err != nil {
+		t.Fatalf("Failed to reload KVTML file: %v", err)
+	}
+	if len(reloaded.List.Items) != 1 || reloaded.List.Items[0].Comment != "greeting" {
+		t.Errorf("Unexpected reloaded items: %+v", reloaded.List.Items)
+	}
+}
+
+func TestTeach2000RoundTripKeepsExtras(t *testing.T) {
+	tmpDir := t.TempDir()
+	source := filepath.Join(tmpDir, "source.t2k")
+	content := `<?xml version="1.0" encoding="UTF-8"?>
+<teach2000>
+  <version>831</version>
+  <description>Normal</description>
+  <message_data>
+    <items>
+      <item id="0">
+        <questions><question id="0">dog</question></questions>
+        <answers type="0"><answer id="0">hond</answer></answers>
+        <errors>2</errors>
+        <testcount>7</testcount>
+        <correctcount>5</correctcount>
+        <remarks>irregular plural</remarks>
+      </item>
+    </items>
+  </message_data>
+</teach2000>`
+	if err := os.WriteFile(source, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write Teach2000 file: %v", err)
+	}
+
+	lessonData, err := NewFileLoader().LoadFile(source)
+	if err != nil {
+		t.Fatalf("Failed to load Teach2000 file: %v", err)
+	}
+	// One more wrong answer practiced in Recuerdo
+	lessonData.List.AddTestResult(0, "wrong")
+
+	target := filepath.Join(tmpDir, "target.t2k")
+	if err := NewFileSaver().SaveFile(lessonData, target); err != nil {
+		t.Fatalf("Failed to save Teach2000 file: %v", err)
+	}
+	saved, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatalf("Failed to read saved Teach2000 file: %v", err)
+	}
+	for _, want := range []string{
+		"<remarks>irregular plural</remarks>",
+		"<errors>3</errors>",
+		"<testcount>8</testcount>",
+		"<correctcount>5</correctcount>",
+	} {
+		if !strings.Contains(string(saved), want) {
+			t.Errorf("Saved Teach2000 file lacks %s:\n%s", want, saved)
+		}
+	}
+}
+
+func TestAnkiExtras(t *testing.T) {
+	filePath := filepath.Join("../../testdata", "legacy_files", "application_x-anki2.anki.anki2")
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		t.Skip("Legacy test file not available")
+	}
+
+	lessonData, err := NewFileLoader().LoadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to load Anki file: %v", err)
+	}
+	for _, item := range lessonData.List.Items {
+		if guid, ok := item.ExtraString(ExtraAnkiGUID); !ok || guid == "" {
+			t.Errorf("Item %d lacks its Anki GUID: %v", item.ID, item.Extras)
+		}
+	}
+}
+
+func TestExtraInt(t *testing.T) {
+	item := WordItem{}
+	item.SetExtra("empty", "")
+	if item.Extras != nil {
+		t.Errorf("Empty values should not be stored: %v", item.Extras)
+	}
+	item.SetExtra("int", 3)
+	item.Extras["float"] = float64(4) // as decoded from JSON
+	if n, ok := item.ExtraInt("int"); !ok || n != 3 {
+		t.Errorf("ExtraInt(int) = %d, %v", n, ok)
+	}
+	if n, ok := item.ExtraInt("float"); !ok || n != 4 {
+		t.Errorf("ExtraInt(float) = %d, %v", n, ok)
+	}
+	if _, ok := item.ExtraInt("missing"); ok {
+		t.Error("ExtraInt(missing) should not be found")
+	}
+}
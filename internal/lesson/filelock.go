@@ -0,0 +1,255 @@
+package lesson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Lock files keep two programs from writing the same lesson at once: two
+// Recuerdo windows, or the GUI and "recuerdo serve" sharing a lesson
+// directory. They are advisory, named like those of LibreOffice, and hold
+// who took them. A lock left behind by a program that crashed is taken over
+// when its process is gone.
+
+// unreadableLockAge is how old a lock file that cannot be read has to be
+// before it is taken for one left half-written by a crash
+const unreadableLockAge = 10 * time.Second
+
+// LockInfo tells who holds the lock on a lesson file
+type LockInfo struct {
+	User  string    `json:"user"`
+	Host  string    `json:"host"`
+	PID   int       `json:"pid"`
+	Since time.Time `json:"since"`
+}
+
+// String returns who holds the lock, such as "alice on classroom-pc"
+func (li LockInfo) String() string {
+	switch {
+	case li.User != "" && li.Host != "":
+		return li.User + " on " + li.Host
+	case li.Host != "":
+		return "someone on " + li.Host
+	}
+	return "another program"
+}
+
+// ErrLocked is returned when a lesson file is locked by another program
+type ErrLocked struct {
+	Path string
+	Lock LockInfo
+}
+
+func (e *ErrLocked) Error() string {
+	msg := fmt.Sprintf("%s is locked by %s", filepath.Base(e.Path), e.Lock)
+	if !e.Lock.Since.IsZero() {
+		msg += " since " + e.Lock.Since.Format("2006-01-02 15:04")
+	}
+	return msg
+}
+
+// FileLock is a lock on a lesson file held by this program
+type FileLock struct {
+	path  string
+	count int
+}
+
+// heldLocks are the locks this program holds, by absolute path of the
+// lesson file. A file locked twice, such as by the GUI and while saving it,
+// stays locked until both let go.
+var (
+	heldLocksMu sync.Mutex
+	heldLocks   = make(map[string]*FileLock)
+)
+
+// LockPath returns the path of the lock file of a lesson file
+func LockPath(filePath string) string {
+	dir, name := filepath.Split(filePath)
+	return filepath.Join(dir, ".~lock."+name+"#")
+}
+
+// LockFile locks a lesson file for this program. It returns an *ErrLocked
+// when another program holds the lock.
+func LockFile(filePath string) (*FileLock, error) {
+	path, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+	heldLocksMu.Lock()
+	defer heldLocksMu.Unlock()
+	if lock, ok := heldLocks[path]; ok {
+		lock.count++
+		return lock, nil
+	}
+
+	data, err := json.Marshal(currentLockInfo())
+	if err != nil {
+		return nil, err
+	}
+	lockPath := LockPath(path)
+	for attempt := 0; attempt < 2; attempt++ {
+		file, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, fs.ErrExist) {
+			if holder, held := readLock(lockPath); held {
+				return nil, &ErrLocked{Path: path, Lock: holder}
+			}
+			// Left behind by a program that is gone
+			if err := takeOverLock(path, lockPath); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		_, err = file.Write(data)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(lockPath)
+			return nil, err
+		}
+		lock := &FileLock{path: path, count: 1}
+		heldLocks[path] = lock
+		return lock, nil
+	}
+	return nil, fmt.Errorf("cannot lock %s", filePath)
+}
+
+// takeOverLock removes the stale lock file at lockPath. Two programs may
+// find it stale at once, and the first may already have put its own lock
+// in its place by the time the second removes it, so it is moved aside
+// under a name of its own first, which only one of them manages, and
+// checked again there. A lock that turned out to be held goes back and
+// is reported as an *ErrLocked.
+func takeOverLock(path, lockPath string) error {
+	aside := fmt.Sprintf("%s.%d-%d", lockPath, os.Getpid(), time.Now().UnixNano())
+	if err := os.Rename(lockPath, aside); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			// Another program took it over first
+			return nil
+		}
+		return err
+	}
+	if holder, held := readLock(aside); held {
+		// Fails when yet another lock was taken meanwhile, which holds
+		// the file just as well
+		os.Link(aside, lockPath)
+		os.Remove(aside)
+		return &ErrLocked{Path: path, Lock: holder}
+	}
+	return os.Remove(aside)
+}
+
+// Unlock lets go of the lock, removing the lock file once nothing in this
+// program holds it any more
+func (l *FileLock) Unlock() error {
+	heldLocksMu.Lock()
+	defer heldLocksMu.Unlock()
+	if l.count == 0 {
+		return nil
+	}
+	l.count--
+	if l.count > 0 {
+		return nil
+	}
+	delete(heldLocks, l.path)
+	err := os.Remove(LockPath(l.path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// LockedBy returns who holds the lock on a lesson file when another program
+// does
+func LockedBy(filePath string) (LockInfo, bool) {
+	path, err := filepath.Abs(filePath)
+	if err != nil {
+		return LockInfo{}, false
+	}
+	heldLocksMu.Lock()
+	_, ours := heldLocks[path]
+	heldLocksMu.Unlock()
+	if ours {
+		return LockInfo{}, false
+	}
+	return readLock(LockPath(path))
+}
+
+// BreakLock removes the lock another program holds on a lesson file, for
+// when the user knows that program is no longer running
+func BreakLock(filePath string) error {
+	err := os.Remove(LockPath(filePath))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// readLock returns who holds the lock file at lockPath, and whether it is
+// held at all: missing and stale lock files are not
+func readLock(lockPath string) (LockInfo, bool) {
+	stat, err := os.Stat(lockPath)
+	if err != nil {
+		return LockInfo{}, false
+	}
+	data, err := os.ReadFile(lockPath)
+	var holder LockInfo
+	if err != nil || json.Unmarshal(data, &holder) != nil {
+		// Another program may be writing it right now
+		return LockInfo{}, time.Since(stat.ModTime()) < unreadableLockAge
+	}
+
+	me := currentLockInfo()
+	if holder.Host != me.Host {
+		// Whether a program on another computer runs cannot be told
+		return holder, true
+	}
+	if holder.PID == me.PID {
+		// Locks this program holds are in heldLocks; this one was left
+		// behind by an earlier run that got the same process id
+		return holder, false
+	}
+	return holder, processRunning(holder.PID)
+}
+
+// currentLockInfo returns the lock information of this program
+func currentLockInfo() LockInfo {
+	info := LockInfo{PID: os.Getpid(), Since: time.Now().Truncate(time.Second)}
+	info.Host, _ = os.Hostname()
+	if current, err := user.Current(); err == nil {
+		info.User = current.Username
+	}
+	return info
+}
+
+// processRunning reports whether a process with id pid runs on this
+// computer
+func processRunning(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// Finding a process on Windows opens it, which fails once it has
+	// exited; elsewhere signal 0 checks it exists without disturbing it
+	if runtime.GOOS == "windows" {
+		process.Release()
+		return true
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,109 @@
+package lesson
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeLock makes it look like another program holds the lock on filePath
+func writeLock(t *testing.T, filePath string, holder LockInfo) {
+	t.Helper()
+	data, err := json.Marshal(holder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(LockPath(filePath), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLockFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "verbs.json")
+	lessonData := NewLessonData()
+	lessonData.List.AddWordItem([]string{"to be"}, []string{"zijn"}, "")
+
+	lock, err := LockFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// This program may still save a file it has locked
+	if err := NewFileSaver().SaveFile(lessonData, filePath); err != nil {
+		t.Fatalf("saving a file this program locked: %v", err)
+	}
+	if _, locked := LockedBy(filePath); locked {
+		t.Error("this program's own lock is reported as another's")
+	}
+	if err := lock.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(LockPath(filePath)); !os.IsNotExist(err) {
+		t.Errorf("the lock file is left behind: %v", err)
+	}
+
+	// A program on another computer sharing the lesson directory
+	other := LockInfo{User: "alice", Host: "classroom-pc", PID: os.Getpid(), Since: time.Now()}
+	writeLock(t, filePath, other)
+	err = NewFileSaver().SaveFile(lessonData, filePath)
+	var locked *ErrLocked
+	if !errors.As(err, &locked) || locked.Lock.User != "alice" {
+		t.Fatalf("saving a file locked by another program: %v", err)
+	}
+	if message := UserMessage(err); !strings.Contains(message, "alice on classroom-pc") {
+		t.Errorf("UserMessage = %q", message)
+	}
+	if holder, locked := LockedBy(filePath); !locked || holder.Host != "classroom-pc" {
+		t.Errorf("LockedBy = %v, %v", holder, locked)
+	}
+	if err := BreakLock(filePath); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewFileSaver().SaveFile(lessonData, filePath); err != nil {
+		t.Errorf("saving after breaking the lock: %v", err)
+	}
+}
+
+func TestStaleLock(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "verbs.json")
+	me := currentLockInfo()
+
+	// A program on this computer that crashed, and one that still runs
+	gone := LockInfo{User: me.User, Host: me.Host, PID: 1 << 30}
+	writeLock(t, filePath, gone)
+	lock, err := LockFile(filePath)
+	if err != nil {
+		t.Fatalf("a lock of a process that is gone is not taken over: %v", err)
+	}
+	lock.Unlock()
+
+	running := LockInfo{User: me.User, Host: me.Host, PID: os.Getppid()}
+	writeLock(t, filePath, running)
+	if _, err := LockFile(filePath); err == nil {
+		t.Error("took over the lock of a running process")
+	}
+}
+
+func TestStaleLockTakenOverMeanwhile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "verbs.json")
+	me := currentLockInfo()
+
+	// This program found the lock stale, but before it took it over
+	// another program did and holds it now
+	running := LockInfo{User: me.User, Host: "classroom-pc", PID: os.Getppid()}
+	writeLock(t, filePath, running)
+	var locked *ErrLocked
+	if err := takeOverLock(filePath, LockPath(filePath)); !errors.As(err, &locked) || locked.Lock.Host != "classroom-pc" {
+		t.Fatalf("took over a lock taken meanwhile: %v", err)
+	}
+	if holder, held := LockedBy(filePath); !held || holder.Host != "classroom-pc" {
+		t.Errorf("the lock taken meanwhile was not put back: %+v, %v", holder, held)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(filePath))
+	if len(entries) != 1 {
+		t.Errorf("files left behind: %v", entries)
+	}
+}
@@ -0,0 +1,228 @@
+package lesson
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// HTMLThemeResourceKey is the key in LessonData.Resources holding the name
+// of the theme HTML exports of the lesson are styled with. Lessons without
+// it use DefaultHTMLTheme.
+const HTMLThemeResourceKey = "htmlTheme"
+
+// HTMLStyleSheetResourceKey is the key in LessonData.Resources holding CSS
+// added after the theme in HTML exports of the lesson, so schools can match
+// their own branding
+const HTMLStyleSheetResourceKey = "htmlStyleSheet"
+
+// DefaultHTMLTheme is the theme of lessons that have none set
+const DefaultHTMLTheme = "classic"
+
+// htmlLayoutCSS lays out every HTML export, whatever its theme
+const htmlLayoutCSS = `
+        body {
+            max-width: 800px;
+            margin: 0 auto;
+            padding: 20px;
+            line-height: 1.6;
+        }
+        .header {
+            text-align: center;
+            margin-bottom: 30px;
+            padding-bottom: 20px;
+        }
+        .title {
+            font-size: 2.5em;
+            margin-bottom: 10px;
+        }
+        .languages {
+            font-size: 1.2em;
+            font-style: italic;
+        }
+        .vocabulary-table {
+            width: 100%;
+            border-collapse: collapse;
+            margin-top: 20px;
+        }
+        .vocabulary-table th {
+            padding: 15px;
+            text-align: left;
+            font-weight: 600;
+        }
+        .vocabulary-table td {
+            padding: 12px 15px;
+        }
+        .question {
+            font-weight: 500;
+        }
+        .comment {
+            font-style: italic;
+            font-size: 0.9em;
+        }
+        .answer summary {
+            cursor: pointer;
+            opacity: 0.6;
+        }
+        .stats {
+            margin-top: 30px;
+            text-align: center;
+        }
+        @media (max-width: 600px) {
+            body { padding: 10px; }
+            .vocabulary-table th,
+            .vocabulary-table td {
+                padding: 8px;
+                font-size: 0.9em;
+            }
+            .title { font-size: 2em; }
+        }
+        @media print {
+            body { max-width: none; }
+            .vocabulary-table tr:hover { background-color: transparent !important; }
+        }`
+
+// htmlThemes holds the colours and fonts of the bundled themes
+var htmlThemes = map[string]string{
+	// classic is the look HTML exports always had
+	"classic": `
+        body {
+            font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif;
+            color: #333;
+        }
+        .header { border-bottom: 2px solid #eee; }
+        .title { color: #2c3e50; }
+        .languages, .comment, .stats { color: #7f8c8d; }
+        .vocabulary-table { box-shadow: 0 2px 10px rgba(0,0,0,0.1); }
+        .vocabulary-table th {
+            background-color: #3498db;
+            color: white;
+        }
+        .vocabulary-table td { border-bottom: 1px solid #eee; }
+        .vocabulary-table tr:nth-child(even) { background-color: #f8f9fa; }
+        .vocabulary-table tr:hover { background-color: #e3f2fd; }
+        .question { color: #2c3e50; }
+        .answer { color: #27ae60; }`,
+
+	// print is black on white with ruled lines, for copying on paper
+	"print": `
+        body {
+            font-family: Georgia, 'Times New Roman', serif;
+            color: #000;
+            background-color: #fff;
+        }
+        .header { border-bottom: 3px double #000; }
+        .vocabulary-table th {
+            border-bottom: 2px solid #000;
+            text-transform: uppercase;
+            letter-spacing: 0.05em;
+        }
+        .vocabulary-table td { border-bottom: 1px solid #999; }
+        .comment, .stats, .languages { color: #444; }`,
+
+	// chalkboard is light text on green, for showing on a classroom screen
+	"chalkboard": `
+        body {
+            font-family: 'Comic Neue', 'Chalkboard SE', 'Segoe Print', cursive, sans-serif;
+            color: #f4f1e8;
+            background-color: #2f4f3a;
+        }
+        .header { border-bottom: 2px dashed #c9d6c3; }
+        .title { color: #fff8d6; }
+        .languages, .comment, .stats { color: #c9d6c3; }
+        .vocabulary-table th {
+            color: #fff8d6;
+            border-bottom: 2px solid #f4f1e8;
+        }
+        .vocabulary-table td { border-bottom: 1px dashed #6f8f7a; }
+        .vocabulary-table tr:hover { background-color: #3c6249; }
+        .answer { color: #ffe27a; }`,
+}
+
+// HTMLThemes returns the names of the bundled HTML export themes
+func HTMLThemes() []string {
+	names := make([]string, 0, len(htmlThemes))
+	for name := range htmlThemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HTMLTheme returns the name of the theme HTML exports of the lesson are
+// styled with
+func (ld *LessonData) HTMLTheme() string {
+	if name, ok := ld.Resources[HTMLThemeResourceKey].(string); ok {
+		if _, known := htmlThemes[name]; known {
+			return name
+		}
+	}
+	return DefaultHTMLTheme
+}
+
+// SetHTMLTheme styles HTML exports of the lesson with the named theme; ""
+// goes back to DefaultHTMLTheme
+func (ld *LessonData) SetHTMLTheme(name string) error {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		if _, set := ld.Resources[HTMLThemeResourceKey]; set {
+			delete(ld.Resources, HTMLThemeResourceKey)
+			ld.Changed = true
+		}
+		return nil
+	}
+	if _, ok := htmlThemes[name]; !ok {
+		return fmt.Errorf("unknown HTML theme: %s", name)
+	}
+	if ld.Resources == nil {
+		ld.Resources = make(map[string]interface{})
+	}
+	ld.Resources[HTMLThemeResourceKey] = name
+	ld.Changed = true
+	return nil
+}
+
+// HTMLStyleSheet returns the CSS added to HTML exports of the lesson, ""
+// if there is none
+func (ld *LessonData) HTMLStyleSheet() string {
+	css, _ := ld.Resources[HTMLStyleSheetResourceKey].(string)
+	return css
+}
+
+// SetHTMLStyleSheet adds css after the theme in HTML exports of the lesson;
+// "" removes it again
+func (ld *LessonData) SetHTMLStyleSheet(css string) {
+	if strings.TrimSpace(css) == "" {
+		if _, set := ld.Resources[HTMLStyleSheetResourceKey]; set {
+			delete(ld.Resources, HTMLStyleSheetResourceKey)
+			ld.Changed = true
+		}
+		return
+	}
+	if ld.Resources == nil {
+		ld.Resources = make(map[string]interface{})
+	}
+	ld.Resources[HTMLStyleSheetResourceKey] = css
+	ld.Changed = true
+}
+
+// htmlStyle returns the content of the <style> element of HTML exports of
+// the lesson: the layout, the theme and the lesson's own CSS, in that
+// order so each can override the one before. An empty theme is the
+// lesson's own.
+func htmlStyle(lessonData *LessonData, theme string) string {
+	if theme == "" {
+		theme = lessonData.HTMLTheme()
+	}
+	var style strings.Builder
+	style.WriteString(htmlLayoutCSS)
+	style.WriteString(htmlThemes[theme])
+	if css := lessonData.HTMLStyleSheet(); css != "" {
+		// The lesson's CSS must not be able to end the <style> element and
+		// add markup to the page; "<\/" means the same in CSS
+		style.WriteString("\n")
+		style.WriteString(strings.ReplaceAll(css, "</", `<\/`))
+	}
+	style.WriteString("\n")
+	return style.String()
+}
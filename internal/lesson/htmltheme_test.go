@@ -0,0 +1,65 @@
+package lesson
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHTMLTheme(t *testing.T) {
+	lessonData := NewLessonData()
+	lessonData.List.Title = "Colours"
+	lessonData.List.AddWordItem([]string{"red"}, []string{"rouge"}, "")
+
+	if got := lessonData.HTMLTheme(); got != DefaultHTMLTheme {
+		t.Errorf("theme without one set = %q", got)
+	}
+	if err := lessonData.SetHTMLTheme("neon"); err == nil {
+		t.Error("set an unknown theme")
+	}
+	if err := lessonData.SetHTMLTheme(" Chalkboard "); err != nil {
+		t.Fatal(err)
+	}
+	if got := lessonData.HTMLTheme(); got != "chalkboard" {
+		t.Errorf("theme = %q, want chalkboard", got)
+	}
+	lessonData.SetHTMLStyleSheet(".title { color: #004b87; }")
+
+	var page bytes.Buffer
+	if err := WriteHTML(&page, lessonData); err != nil {
+		t.Fatal(err)
+	}
+	html := page.String()
+	if !strings.Contains(html, "#2f4f3a") || strings.Contains(html, "#3498db") {
+		t.Error("the page is not styled with the chalkboard theme")
+	}
+	theme := strings.Index(html, "#2f4f3a")
+	custom := strings.Index(html, "#004b87")
+	if custom < theme {
+		t.Error("the lesson's stylesheet does not come after the theme")
+	}
+
+	if err := lessonData.SetHTMLTheme(""); err != nil {
+		t.Fatal(err)
+	}
+	lessonData.SetHTMLStyleSheet("")
+	if _, set := lessonData.Resources[HTMLThemeResourceKey]; set {
+		t.Error("clearing the theme left it in the resources")
+	}
+	if _, set := lessonData.Resources[HTMLStyleSheetResourceKey]; set {
+		t.Error("clearing the stylesheet left it in the resources")
+	}
+}
+
+func TestHTMLStyleSheetCannotEndStyle(t *testing.T) {
+	lessonData := NewLessonData()
+	lessonData.SetHTMLStyleSheet("body { color: red; }</style><script>alert(1)</script>")
+
+	var page bytes.Buffer
+	if err := WriteHTML(&page, lessonData); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(page.String(), "</script>") || strings.Count(page.String(), "</style>") != 1 {
+		t.Errorf("the stylesheet escaped the style element:\n%s", page.String())
+	}
+}
@@ -0,0 +1,58 @@
+package lesson
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// NewUUID returns a random (version 4) UUID
+func NewUUID() string {
+	var b [16]byte
+	// crypto/rand.Read never fails
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// EnsureUUID returns the UUID of the list, giving it a new one first if it
+// has none. The UUID is saved with the rest of the list metadata, so the
+// review history of a lesson stays linked to it after renaming or moving
+// the file. It does not mark the lesson changed; it is kept the next time
+// the lesson is saved.
+func (wl *WordList) EnsureUUID() string {
+	if wl.UUID == "" {
+		wl.UUID = NewUUID()
+	}
+	return wl.UUID
+}
+
+// ItemHash identifies an item by what it asks rather than by its ID, which
+// changes when a lesson goes through a format that numbers items itself.
+// Items with the same questions, answers and, for topo and occlusion
+// items, place get the same hash.
+func ItemHash(item *WordItem) string {
+	var key strings.Builder
+	field := func(values ...string) {
+		for _, value := range values {
+			key.WriteString(strings.TrimSpace(value))
+			key.WriteByte(0x1f)
+		}
+		key.WriteByte(0x1e)
+	}
+	field(item.Questions...)
+	field(item.Answers...)
+	field(item.Name, item.Cloze)
+	if x, y, ok := item.GetTopoCoordinates(); ok {
+		field(strconv.Itoa(x), strconv.Itoa(y))
+	}
+	if rect, ok := item.OcclusionRect(); ok {
+		field(strconv.Itoa(rect.Dx()), strconv.Itoa(rect.Dy()))
+	}
+	sum := sha256.Sum256([]byte(key.String()))
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,46 @@
+package lesson
+
+import (
+	"image"
+	"regexp"
+	"testing"
+)
+
+func TestLessonUUID(t *testing.T) {
+	if uuid := NewUUID(); !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(uuid) {
+		t.Errorf("invalid UUID %q", uuid)
+	}
+	if NewUUID() == NewUUID() {
+		t.Error("expected different UUIDs")
+	}
+
+	var list WordList
+	uuid := list.EnsureUUID()
+	if uuid == "" || list.UUID != uuid || list.EnsureUUID() != uuid {
+		t.Errorf("expected the list to keep its UUID, got %q then %q", uuid, list.UUID)
+	}
+}
+
+func TestItemHash(t *testing.T) {
+	house := WordItem{ID: 0, Questions: []string{"house"}, Answers: []string{"maison"}}
+	renumbered := WordItem{ID: 7, Questions: []string{" house"}, Answers: []string{"maison "}, Starred: true}
+	if ItemHash(&house) != ItemHash(&renumbered) {
+		t.Error("expected the hash to ignore the ID, spacing and item state")
+	}
+
+	x, y := 10, 20
+	different := []WordItem{
+		{Questions: []string{"house"}, Answers: []string{"maison", "demeure"}},
+		{Questions: []string{"house", "maison"}},
+		{Questions: []string{"house"}, Answers: []string{"maison"}, X: &x, Y: &y},
+		NewOcclusionItem(0, image.Rect(10, 20, 30, 40), []string{"maison"}),
+	}
+	seen := map[string]bool{ItemHash(&house): true}
+	for i := range different {
+		hash := ItemHash(&different[i])
+		if seen[hash] {
+			t.Errorf("item %d got the hash of another item", i)
+		}
+		seen[hash] = true
+	}
+}
@@ -0,0 +1,219 @@
+package lesson
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"path"
+	"sync"
+)
+
+// Pictures embedded in lessons, the pictures of media lessons and the image
+// of image occlusion lessons, can be shrunk when saving so that lessons stay
+// small enough to email. Only the saved file is affected: the lesson being
+// edited keeps the pictures as they were imported, and the originals can be
+// kept next to the saved lesson.
+
+// Settings keys of how embedded pictures are shrunk by default
+const (
+	// SettingMaxImageSize holds the largest width or height of embedded
+	// pictures in pixels, 0 to keep their size
+	SettingMaxImageSize = "saving.maxImageSize"
+	// SettingImageQuality holds the JPEG quality pictures are
+	// recompressed with, from 1 to 100
+	SettingImageQuality = "saving.imageQuality"
+	// SettingKeepOriginalImages holds whether the originals of shrunk
+	// pictures are kept next to the lesson
+	SettingKeepOriginalImages = "saving.keepOriginalImages"
+)
+
+// DefaultImageQuality is the JPEG quality when none is set
+const DefaultImageQuality = 85
+
+// originalsFolder is the folder next to a saved lesson the originals of
+// shrunk pictures are kept in
+const originalsFolder = "originals"
+
+// ImageCompression is how pictures embedded in saved lessons are shrunk
+type ImageCompression struct {
+	// MaxSize is the largest width or height in pixels; 0 keeps pictures
+	// as they are
+	MaxSize int
+	// Quality is the JPEG quality photos are recompressed with
+	Quality int
+	// KeepOriginals writes the originals of shrunk pictures to the
+	// originals folder next to the saved lesson
+	KeepOriginals bool
+}
+
+var (
+	defaultImageCompression      = ImageCompression{Quality: DefaultImageQuality}
+	defaultImageCompressionMutex sync.RWMutex
+)
+
+// DefaultImageCompression returns how savers shrink embedded pictures by
+// default
+func DefaultImageCompression() ImageCompression {
+	defaultImageCompressionMutex.RLock()
+	defer defaultImageCompressionMutex.RUnlock()
+	return defaultImageCompression
+}
+
+// SetDefaultImageCompression changes how savers shrink embedded pictures by
+// default
+func SetDefaultImageCompression(compression ImageCompression) {
+	defaultImageCompressionMutex.Lock()
+	defer defaultImageCompressionMutex.Unlock()
+	defaultImageCompression = compression.normalized()
+}
+
+// LoadDefaultImageCompression makes the image compression stored in
+// settings the default, keeping the current default for settings that are
+// missing
+func LoadDefaultImageCompression(settings ToleranceSettings) ImageCompression {
+	compression := DefaultImageCompression()
+	compression.MaxSize = settingInt(settings, SettingMaxImageSize, compression.MaxSize)
+	compression.Quality = settingInt(settings, SettingImageQuality, compression.Quality)
+	compression.KeepOriginals = settingBool(settings, SettingKeepOriginalImages, compression.KeepOriginals)
+	SetDefaultImageCompression(compression)
+	return DefaultImageCompression()
+}
+
+// SaveDefaultImageCompression makes compression the default and stores it
+// in settings
+func SaveDefaultImageCompression(settings ToleranceSettings, compression ImageCompression) error {
+	SetDefaultImageCompression(compression)
+	compression = DefaultImageCompression()
+	values := map[string]interface{}{
+		SettingMaxImageSize:       compression.MaxSize,
+		SettingImageQuality:       compression.Quality,
+		SettingKeepOriginalImages: compression.KeepOriginals,
+	}
+	for key, value := range values {
+		if err := settings.SetSetting(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func settingInt(settings ToleranceSettings, key string, defaultValue int) int {
+	switch value := settings.GetSettingWithDefault(key, defaultValue).(type) {
+	case int:
+		return value
+	case float64:
+		// JSON unmarshaling creates float64 for numbers
+		return int(value)
+	}
+	return defaultValue
+}
+
+// normalized returns the compression with sizes and quality in range
+func (c ImageCompression) normalized() ImageCompression {
+	c.MaxSize = max(0, c.MaxSize)
+	if c.Quality <= 0 {
+		c.Quality = DefaultImageQuality
+	}
+	c.Quality = min(100, c.Quality)
+	return c
+}
+
+// shrink returns the picture called name, about to be embedded in a lesson
+// saved into dir, shrunk to fit MaxSize, and the factors it was scaled by
+// horizontally and vertically. When it was shrunk and KeepOriginals is set
+// the original is written to the originals folder in dir.
+func (c ImageCompression) shrink(dir, name string, data []byte) ([]byte, float64, float64, error) {
+	shrunk, scaleX, scaleY := c.compress(data)
+	if c.KeepOriginals && len(shrunk) != len(data) {
+		if _, err := writeMediaFile(dir, originalsFolder, path.Base(name), data); err != nil {
+			return nil, 0, 0, err
+		}
+	}
+	return shrunk, scaleX, scaleY, nil
+}
+
+// compress returns the JPEG or PNG picture in data scaled down to fit
+// MaxSize, and the factors it was scaled by. Pictures that fit already,
+// other formats and pictures that would not get any smaller are returned as
+// they are, with factors of 1.
+func (c ImageCompression) compress(data []byte) ([]byte, float64, float64) {
+	c = c.normalized()
+	if c.MaxSize == 0 {
+		return data, 1, 1
+	}
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || (format != "jpeg" && format != "png") {
+		return data, 1, 1
+	}
+	longest := max(config.Width, config.Height)
+	if longest <= c.MaxSize {
+		return data, 1, 1
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return data, 1, 1
+	}
+
+	scale := float64(c.MaxSize) / float64(longest)
+	width := max(1, int(math.Round(float64(config.Width)*scale)))
+	height := max(1, int(math.Round(float64(config.Height)*scale)))
+	shrunk := downscale(src, width, height)
+
+	var buf bytes.Buffer
+	if format == "jpeg" {
+		err = jpeg.Encode(&buf, shrunk, &jpeg.Options{Quality: c.Quality})
+	} else {
+		encoder := png.Encoder{CompressionLevel: png.BestCompression}
+		err = encoder.Encode(&buf, shrunk)
+	}
+	if err != nil || buf.Len() >= len(data) {
+		return data, 1, 1
+	}
+	return buf.Bytes(), float64(width) / float64(config.Width), float64(height) / float64(config.Height)
+}
+
+// downscale returns src scaled down to width by height, each pixel the
+// average of the pixels of src it covers
+func downscale(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	rgba := image.NewRGBA(image.Rect(0, 0, srcWidth, srcHeight))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := y * srcHeight / height
+		y1 := max(y0+1, (y+1)*srcHeight/height)
+		for x := 0; x < width; x++ {
+			x0 := x * srcWidth / width
+			x1 := max(x0+1, (x+1)*srcWidth/width)
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := rgba.Pix[sy*rgba.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					for c := range sum {
+						sum[c] += int(row[sx*4+c])
+					}
+				}
+			}
+			pixels := (y1 - y0) * (x1 - x0)
+			pixel := dst.Pix[y*dst.Stride+x*4:]
+			for c := range sum {
+				pixel[c] = uint8(sum[c] / pixels)
+			}
+		}
+	}
+	return dst
+}
+
+// scaleRect returns rect scaled by scaleX and scaleY, such as a mask over a
+// picture that was shrunk
+func scaleRect(rect image.Rectangle, scaleX, scaleY float64) image.Rectangle {
+	scale := func(v int, factor float64) int {
+		return int(math.Round(float64(v) * factor))
+	}
+	return image.Rect(scale(rect.Min.X, scaleX), scale(rect.Min.Y, scaleY), scale(rect.Max.X, scaleX), scale(rect.Max.Y, scaleY))
+}
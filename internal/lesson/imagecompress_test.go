@@ -0,0 +1,99 @@
+package lesson
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testPicture returns a width by height gradient
+func testPicture(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), uint8(x ^ y), 255})
+		}
+	}
+	return img
+}
+
+func TestImageCompression(t *testing.T) {
+	var pngData, jpegData bytes.Buffer
+	if err := png.Encode(&pngData, testPicture(400, 200)); err != nil {
+		t.Fatal(err)
+	}
+	if err := jpeg.Encode(&jpegData, testPicture(400, 200), &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+
+	compression := ImageCompression{MaxSize: 100}
+	for _, data := range [][]byte{pngData.Bytes(), jpegData.Bytes()} {
+		shrunk, scaleX, scaleY := compression.compress(data)
+		config, _, err := image.DecodeConfig(bytes.NewReader(shrunk))
+		if err != nil {
+			t.Fatalf("shrunk picture unreadable: %v", err)
+		}
+		if config.Width != 100 || config.Height != 50 || scaleX != 0.25 || scaleY != 0.25 {
+			t.Errorf("expected 100x50 at a quarter of the size, got %dx%d at %v, %v", config.Width, config.Height, scaleX, scaleY)
+		}
+		if len(shrunk) >= len(data) {
+			t.Errorf("expected a smaller file than %d bytes, got %d", len(data), len(shrunk))
+		}
+	}
+
+	// Pictures that fit and files that are no picture stay as they are
+	for _, data := range [][]byte{pngData.Bytes(), []byte("\x89PNG fake image data")} {
+		kept, scaleX, scaleY := ImageCompression{MaxSize: 400}.compress(data)
+		if !bytes.Equal(kept, data) || scaleX != 1 || scaleY != 1 {
+			t.Errorf("expected the picture to be kept as it is")
+		}
+	}
+}
+
+func TestShrunkOcclusionImage(t *testing.T) {
+	var data bytes.Buffer
+	if err := png.Encode(&data, testPicture(400, 200)); err != nil {
+		t.Fatal(err)
+	}
+	lessonData := NewLessonData()
+	lessonData.SetOcclusionImage("/tmp/heart.png", data.Bytes())
+	lessonData.List.AddOcclusionItem(image.Rect(40, 20, 200, 100), []string{"aorta"})
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "heart.otio")
+	options := DefaultSaveOptions()
+	options.ImageCompression = ImageCompression{MaxSize: 200, KeepOriginals: true}
+	if err := NewFileSaverWithOptions(options).SaveFile(lessonData, path); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+
+	loaded, err := NewFileLoader().LoadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	img, ok := loaded.OcclusionImage()
+	if !ok {
+		t.Fatal("image not saved")
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(img.Data))
+	if err != nil || config.Width != 200 || config.Height != 100 {
+		t.Errorf("expected a 200x100 image, got %dx%d (%v)", config.Width, config.Height, err)
+	}
+	if rect, _ := loaded.List.Items[0].OcclusionRect(); rect != image.Rect(20, 10, 100, 50) {
+		t.Errorf("expected the mask to shrink with the image, got %v", rect)
+	}
+
+	original, err := os.ReadFile(filepath.Join(dir, originalsFolder, "heart.png"))
+	if err != nil || !bytes.Equal(original, data.Bytes()) {
+		t.Errorf("expected the original to be kept next to the lesson (%v)", err)
+	}
+	// The lesson being edited keeps its original
+	if img, _ := lessonData.OcclusionImage(); !bytes.Equal(img.Data, data.Bytes()) {
+		t.Error("expected the lesson to keep its original image")
+	}
+}
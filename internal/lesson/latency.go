@@ -0,0 +1,66 @@
+package lesson
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	// slowAnswer is how long an answer takes to weigh fully as slow in the
+	// smart order
+	slowAnswer = 2 * DefaultSecondsPerQuestion * time.Second
+	// slownessWeight is how much a slow answer weighs in the smart order,
+	// where a wrong one weighs 1
+	slownessWeight = 0.5
+)
+
+// AnswerTime returns how long answering the item with itemID took on
+// average, over the timed answers in either direction, and whether any
+// answer was timed
+func (wl *WordList) AnswerTime(itemID int) (time.Duration, bool) {
+	return wl.directionAnswerTime(itemID, nil)
+}
+
+// directionAnswerTime returns the average time of the timed answers to the
+// item with itemID, of one direction unless reversed is nil
+func (wl *WordList) directionAnswerTime(itemID int, reversed *bool) (time.Duration, bool) {
+	total, count := 0.0, 0
+	for _, test := range wl.Tests {
+		for _, result := range test.Results {
+			if result.ItemID != itemID || result.AnswerTime <= 0 || (reversed != nil && result.Reversed != *reversed) {
+				continue
+			}
+			total += result.AnswerTime
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, false
+	}
+	return time.Duration(total / float64(count) * float64(time.Second)), true
+}
+
+// slowness returns how much an average answer time weighs in the smart
+// order, growing with the time up to slownessWeight for slow answers
+func slowness(answerTime time.Duration) float64 {
+	return slownessWeight * min(float64(answerTime)/float64(slowAnswer), 1)
+}
+
+// SlowestItems orders the items of indexes by how long answering them took
+// on average, the slowest first and those never timed last, then applies
+// their difficulty
+func SlowestItems(list *WordList, indexes []int) []int {
+	times := make(map[int]time.Duration, len(indexes))
+	for _, index := range indexes {
+		if answerTime, ok := list.AnswerTime(list.Items[index].ID); ok {
+			times[index] = answerTime
+		} else {
+			times[index] = -1
+		}
+	}
+	ordered := append([]int(nil), indexes...)
+	sort.SliceStable(ordered, func(a, b int) bool {
+		return times[ordered[a]] > times[ordered[b]]
+	})
+	return ApplyDifficulty(list, ordered)
+}
@@ -0,0 +1,31 @@
+package lesson
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestAnswerTime(t *testing.T) {
+	list := chapterList("", "", "", "")
+	list.AddDirectedResult(Card{Index: 0}, Grade{Correct: true, Credit: 1}, 2*time.Second)
+	list.AddDirectedResult(Card{Index: 0}, Grade{Correct: true, Credit: 1}, 4*time.Second)
+	list.AddDirectedResult(Card{Index: 1}, Grade{Correct: true, Credit: 1}, 15*time.Second)
+	list.AddDirectedResult(Card{Index: 2}, Grade{Correct: true, Credit: 1}, 0)
+
+	if answerTime, ok := list.AnswerTime(0); !ok || answerTime != 3*time.Second {
+		t.Errorf("AnswerTime(0) = %v, %v, want 3s", answerTime, ok)
+	}
+	if _, ok := list.AnswerTime(2); ok {
+		t.Error("an untimed answer has an answer time")
+	}
+	if got := SlowestItems(list, AllItems(list)); !slices.Equal(got, []int{1, 0, 2, 3}) {
+		t.Errorf("SlowestItems = %v, want [1 0 2 3]", got)
+	}
+
+	// Both answered right once, but the slow one comes first
+	cards := []Card{{Index: 0}, {Index: 1}}
+	if got := SmartOrder(list, cards); got[0].Index != 1 {
+		t.Errorf("SmartOrder = %v, want the slow card first", got)
+	}
+}
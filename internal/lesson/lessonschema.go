@@ -0,0 +1,321 @@
+package lesson
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The canonical JSON lesson format is what the .json extension is saved
+// as. Version 2 wraps the list in a header naming the format, its version
+// and the kind of lesson, and is described by a JSON Schema so other
+// programs can generate lessons and check them before handing them out.
+// Version 1 was LessonData marshalled as it was, without a header; such
+// files still load and are saved as version 2.
+
+// Header values of the canonical JSON lesson format
+const (
+	LessonFormatName    = "recuerdo-lesson"
+	LessonFormatVersion = 2
+	LessonSchemaID      = "https://github.com/LaPingvino/recuerdo/raw/main/internal/lesson/lesson-v2.schema.json"
+)
+
+//go:embed lesson-v2.schema.json
+var lessonSchemaJSON []byte
+
+// LessonSchema returns the JSON Schema of version 2 of the lesson format
+func LessonSchema() []byte {
+	return slices.Clone(lessonSchemaJSON)
+}
+
+// LessonDocument is a lesson in version 2 of the canonical JSON format
+type LessonDocument struct {
+	Schema    string                 `json:"$schema,omitempty"`
+	Format    string                 `json:"format"`
+	Version   int                    `json:"version"`
+	Type      string                 `json:"type"`
+	List      WordList               `json:"list"`
+	Resources map[string]interface{} `json:"resources,omitempty"`
+}
+
+// NewLessonDocument returns the canonical document of lessonData. Lists
+// left nil are written as empty arrays, as the schema requires.
+func NewLessonDocument(lessonData *LessonData) *LessonDocument {
+	list := lessonData.List
+	list.Items = make([]WordItem, len(lessonData.List.Items))
+	for i, item := range lessonData.List.Items {
+		if item.Questions == nil {
+			item.Questions = []string{}
+		}
+		if item.Answers == nil {
+			item.Answers = []string{}
+		}
+		list.Items[i] = item
+	}
+	list.Tests = make([]Test, len(lessonData.List.Tests))
+	for i, test := range lessonData.List.Tests {
+		if test.Results == nil {
+			test.Results = []TestResult{}
+		}
+		list.Tests[i] = test
+	}
+
+	var resources map[string]interface{}
+	if len(lessonData.Resources) > 0 {
+		resources = lessonData.Resources
+	}
+	return &LessonDocument{
+		Schema:    LessonSchemaID,
+		Format:    LessonFormatName,
+		Version:   LessonFormatVersion,
+		Type:      LessonDataType(lessonData),
+		List:      list,
+		Resources: resources,
+	}
+}
+
+// LessonData returns the lesson held by the document
+func (d *LessonDocument) LessonData() *LessonData {
+	lessonData := NewLessonData()
+	lessonData.List = d.List
+	if d.Resources != nil {
+		lessonData.Resources = d.Resources
+	}
+	return lessonData
+}
+
+// LessonDataType tells the kind of lesson from the fields its items use:
+// "occlusion" for masks, "topo" for places, "media" for files and "words"
+// otherwise, as FileLoader.GetFileType names them
+func LessonDataType(lessonData *LessonData) string {
+	dataType := "words"
+	for _, item := range lessonData.List.Items {
+		switch {
+		case item.Width != nil || item.Height != nil:
+			return "occlusion"
+		case item.X != nil || item.Y != nil:
+			dataType = "topo"
+		case item.Filename != nil && dataType == "words":
+			dataType = "media"
+		}
+	}
+	return dataType
+}
+
+// ErrSchema is returned for a lesson that does not match the schema of
+// its format version; Problems tells where and how, such as
+// "list.items[2].answers: expected array, got string"
+type ErrSchema struct {
+	Problems []string
+}
+
+func (e *ErrSchema) Error() string {
+	const shown = 5
+	if len(e.Problems) > shown {
+		return fmt.Sprintf("%s and %d more problems", strings.Join(e.Problems[:shown], "; "), len(e.Problems)-shown)
+	}
+	return strings.Join(e.Problems, "; ")
+}
+
+// ValidateLessonJSON checks a lesson in version 2 of the canonical JSON
+// format against its schema. It returns an *ErrSchema listing every
+// problem found.
+func ValidateLessonJSON(data []byte) error {
+	var document any
+	if err := json.Unmarshal(data, &document); err != nil {
+		return err
+	}
+	var schema jsonSchema
+	if err := json.Unmarshal(lessonSchemaJSON, &schema); err != nil {
+		return fmt.Errorf("invalid lesson schema: %w", err)
+	}
+
+	var problems []string
+	schema.validate(&schema, document, "", &problems)
+	if len(problems) > 0 {
+		return &ErrSchema{Problems: problems}
+	}
+	return nil
+}
+
+// DecodeLessonJSON reads a lesson in the canonical JSON format. Version 2
+// is checked against the schema first; files without a version are read
+// as version 1.
+func DecodeLessonJSON(data []byte) (*LessonData, error) {
+	var header struct {
+		Version *int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+
+	if header.Version == nil {
+		log.Printf("[INFO] Reading lesson in JSON format version 1, it will be saved as version %d", LessonFormatVersion)
+		lessonData := NewLessonData()
+		if err := json.Unmarshal(data, lessonData); err != nil {
+			return nil, err
+		}
+		if lessonData.Resources == nil {
+			lessonData.Resources = make(map[string]interface{})
+		}
+		return lessonData, nil
+	}
+	if *header.Version > LessonFormatVersion {
+		return nil, fmt.Errorf("%w: lesson format version %d is newer than this version of Recuerdo reads (%d)",
+			ErrUnsupportedFormat, *header.Version, LessonFormatVersion)
+	}
+
+	if err := ValidateLessonJSON(data); err != nil {
+		return nil, &ErrCorruptArchive{Format: "Recuerdo lesson", Detail: "it does not match the lesson schema", Err: err}
+	}
+	var document LessonDocument
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	return document.LessonData(), nil
+}
+
+// jsonSchema is the part of JSON Schema the lesson schema uses
+type jsonSchema struct {
+	Ref                  string                 `json:"$ref"`
+	Defs                 map[string]*jsonSchema `json:"$defs"`
+	Type                 string                 `json:"type"`
+	Required             []string               `json:"required"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	Enum                 []json.RawMessage      `json:"enum"`
+	Const                json.RawMessage        `json:"const"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+	Format               string                 `json:"format"`
+}
+
+// validate adds the problems of value at path to problems; root holds the
+// definitions references point at
+func (s *jsonSchema) validate(root *jsonSchema, value any, path string, problems *[]string) {
+	problem := func(format string, args ...any) {
+		where := path
+		if where == "" {
+			where = "lesson"
+		}
+		*problems = append(*problems, where+": "+fmt.Sprintf(format, args...))
+	}
+
+	if s.Ref != "" {
+		def, ok := root.Defs[strings.TrimPrefix(s.Ref, "#/$defs/")]
+		if !ok {
+			problem("unknown schema reference %s", s.Ref)
+			return
+		}
+		def.validate(root, value, path, problems)
+		return
+	}
+
+	if s.Const != nil && !jsonEqual(s.Const, value) {
+		problem("expected %s", s.Const)
+		return
+	}
+	if s.Enum != nil && !slices.ContainsFunc(s.Enum, func(allowed json.RawMessage) bool {
+		return jsonEqual(allowed, value)
+	}) {
+		allowed := make([]string, len(s.Enum))
+		for i, raw := range s.Enum {
+			allowed[i] = string(raw)
+		}
+		problem("expected one of %s", strings.Join(allowed, ", "))
+		return
+	}
+	if s.Type != "" && jsonType(value, s.Type) != s.Type {
+		problem("expected %s, got %s", s.Type, jsonType(value, s.Type))
+		return
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := value[name]; !ok {
+				problem("missing %s", name)
+			}
+		}
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property, ok := s.Properties[name]
+			switch {
+			case ok:
+				property.validate(root, value[name], joinSchemaPath(path, name), problems)
+			case s.AdditionalProperties != nil && !*s.AdditionalProperties:
+				problem("unknown property %s", name)
+			}
+		}
+	case []any:
+		if s.Items != nil {
+			for i, element := range value {
+				s.Items.validate(root, element, fmt.Sprintf("%s[%d]", path, i), problems)
+			}
+		}
+	case float64:
+		if s.Minimum != nil && value < *s.Minimum {
+			problem("%v is less than %v", value, *s.Minimum)
+		}
+		if s.Maximum != nil && value > *s.Maximum {
+			problem("%v is more than %v", value, *s.Maximum)
+		}
+	case string:
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, value); err != nil {
+				problem("%q is not an RFC 3339 date and time", value)
+			}
+		}
+	}
+}
+
+// jsonType names the JSON type of a decoded value. Whole numbers are
+// "integer" when want is, and "number" otherwise.
+func jsonType(value any, want string) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if want == "integer" && value == math.Trunc(value) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// jsonEqual tells whether raw JSON decodes to value
+func jsonEqual(raw json.RawMessage, value any) bool {
+	var decoded any
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(decoded, value)
+}
+
+// joinSchemaPath appends a property name to a path such as list.items[0]
+func joinSchemaPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
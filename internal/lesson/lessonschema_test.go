@@ -0,0 +1,103 @@
+package lesson
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLessonJSONMatchesSchema(t *testing.T) {
+	lessonData := NewLessonData()
+	lessonData.List.Title = "Animals"
+	lessonData.List.AddWordItem([]string{"chat"}, []string{"cat"}, "")
+	lessonData.List.AddWordItem([]string{"chien"}, nil, "")
+	lessonData.List.Items[0].SetExtra(ExtraAnkiInterval, 4)
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	lessonData.List.Tests = append(lessonData.List.Tests, Test{
+		Results: []TestResult{{Result: "wrong", ItemID: 1, Time: &now, Credit: 0.5}},
+		Date:    &now,
+	})
+	lessonData.Resources[PresetResourceKey] = "Year 7"
+
+	path := filepath.Join(t.TempDir(), "animals.json")
+	if err := NewFileSaver().SaveFile(lessonData, path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateLessonJSON(data); err != nil {
+		t.Fatalf("saved lesson does not match the schema: %v", err)
+	}
+	if !strings.Contains(string(data), `"version": 2`) || !strings.Contains(string(data), `"type": "words"`) {
+		t.Errorf("expected a version 2 header:\n%s", data)
+	}
+
+	loaded, err := NewFileLoader().LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.List.Items) != 2 || len(loaded.List.Tests) != 1 || AssignedPreset(loaded) != "Year 7" {
+		t.Errorf("lesson changed in the round trip: %+v", loaded.List)
+	}
+}
+
+func TestLessonJSONVersion1(t *testing.T) {
+	legacy := []byte(`{"list": {"title": "Old", "items": [{"id": 0, "questions": ["a"], "answers": ["b"]}], "tests": null}, "resources": null, "changed": true}`)
+
+	lessonData, err := DecodeLessonJSON(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lessonData.List.Title != "Old" || len(lessonData.List.Items) != 1 || lessonData.Resources == nil {
+		t.Errorf("unexpected lesson %+v", lessonData)
+	}
+
+	path := filepath.Join(t.TempDir(), "old.json")
+	if err := NewFileSaver().SaveFile(lessonData, path); err != nil {
+		t.Fatal(err)
+	}
+	migrated, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateLessonJSON(migrated); err != nil {
+		t.Errorf("migrated lesson does not match the schema: %v\n%s", err, migrated)
+	}
+}
+
+func TestLessonJSONInvalid(t *testing.T) {
+	invalid := []byte(`{"format": "recuerdo-lesson", "version": 2, "type": "words", "list": {
+		"items": [{"id": -1, "questions": "a", "answers": ["b"], "colour": "red"}],
+		"tests": [{"results": [{"result": "maybe", "itemId": 0, "time": "yesterday"}]}]
+	}}`)
+
+	err := ValidateLessonJSON(invalid)
+	var schemaErr *ErrSchema
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("expected a schema error, got %v", err)
+	}
+	for _, want := range []string{
+		"list.items[0].id: -1 is less than 0",
+		"list.items[0].questions: expected array, got string",
+		"list.items[0]: unknown property colour",
+		`list.tests[0].results[0].result: expected one of "right", "wrong"`,
+		`list.tests[0].results[0].time: "yesterday" is not an RFC 3339 date and time`,
+	} {
+		if !strings.Contains(strings.Join(schemaErr.Problems, "\n"), want) {
+			t.Errorf("expected problem %q in %q", want, schemaErr.Problems)
+		}
+	}
+
+	var corrupt *ErrCorruptArchive
+	if _, err := DecodeLessonJSON(invalid); !errors.As(err, &corrupt) {
+		t.Errorf("expected an ErrCorruptArchive when loading, got %v", err)
+	}
+	if _, err := DecodeLessonJSON([]byte(`{"version": 3, "list": {}}`)); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("expected newer versions to be unsupported, got %v", err)
+	}
+}
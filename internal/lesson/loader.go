@@ -4,24 +4,31 @@ import (
 	"archive/zip"
 	"bufio"
 	"database/sql"
-	"encoding/csv"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
+	"image"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 
 	_ "github.com/mattn/go-sqlite3" // SQLite driver
-	"golang.org/x/text/encoding/charmap"
-	"golang.org/x/text/transform"
 )
 
 // FileLoader provides file loading functionality for various lesson formats
-type FileLoader struct{}
+type FileLoader struct {
+	// encodingOverrides maps absolute file paths to the encoding they are
+	// read in, for files whose encoding is detected wrongly
+	encodingOverrides map[string]string
+	// passphrase decrypts encrypted lessons; it is guarded by
+	// encodingMutex too
+	passphrase    string
+	encodingMutex sync.RWMutex
+}
 
 // NewFileLoader creates a new file loader instance
 func NewFileLoader() *FileLoader {
@@ -67,6 +74,24 @@ func (fl *FileLoader) LoadFile(filePath string) (*LessonData, error) {
 		return fl.loadOpenTeachingTopoFile(filePath)
 	case ".otmd":
 		return fl.loadOpenTeachingMediaFile(filePath)
+	case ".otio":
+		return fl.loadOpenTeachingOcclusionFile(filePath)
+	case ".quizlet":
+		return fl.loadQuizletFile(filePath)
+	case ".pdf":
+		return fl.loadPDFFile(filePath)
+	case ".xlsx":
+		return fl.loadXLSXFile(filePath)
+	case ".md", ".markdown":
+		return fl.loadMarkdownFile(filePath)
+	case ".pau":
+		return fl.loadPaukerFile(filePath)
+	case ".wrts":
+		return fl.loadWRTSFile(filePath)
+	case ".gz":
+		return fl.loadGzipFile(filePath)
+	case ".otsec":
+		return fl.loadEncryptedFile(filePath)
 	default:
 		// Try to auto-detect format by content
 		return fl.loadAutoDetect(filePath)
@@ -86,96 +111,54 @@ func (fl *FileLoader) GetFileType(filePath string) string {
 		return "words"
 	case ".ovr", ".pau", ".vok2", ".wdl", ".vtl3", ".wrts", ".xml", ".otwd":
 		return "words"
+	case ".quizlet", ".pdf", ".xlsx", ".md", ".markdown":
+		return "words"
 	case ".kgm", ".ottp":
 		return "topo"
 	case ".t2k":
 		return "words" // Can be both words and topo, defaulting to words
 	case ".otmd":
 		return "media"
+	case ".otio":
+		return "occlusion"
 	default:
 		return "words" // Default to words for unknown formats
 	}
 }
 
-// loadCSV loads CSV or TSV files
+// loadCSV loads CSV or TSV files, detecting the delimiter, quote character,
+// header row and encoding from the content
 func (fl *FileLoader) loadCSV(filePath string) (*LessonData, error) {
 	log.Printf("[ACTION] FileLoader.loadCSV() - parsing CSV file")
 
-	file, err := os.Open(filePath)
+	dialect, err := fl.SniffCSVFile(filePath)
 	if err != nil {
 		log.Printf("[ERROR] Failed to open CSV file: %v", err)
 		return nil, err
 	}
-	defer file.Close()
 
-	// Determine delimiter
-	delimiter := ','
+	// A .tsv file is tab separated even when a single column makes that ambiguous
 	if strings.HasSuffix(strings.ToLower(filePath), ".tsv") {
-		delimiter = '\t'
+		dialect.Delimiter = '\t'
 	}
 
-	reader := csv.NewReader(file)
-	reader.Comma = delimiter
-	reader.FieldsPerRecord = -1 // Allow variable number of fields
-
-	lessonData := NewLessonData()
-	lessonData.List.Title = filepath.Base(filePath)
-
-	itemID := 0
-	for {
-		record, err := reader.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			log.Printf("[WARNING] Error reading CSV line: %v", err)
-			continue
-		}
-
-		if len(record) < 2 {
-			continue // Skip lines with insufficient data
-		}
-
-		// Parse questions and answers (may be comma-separated within cells)
-		questions := fl.parseWordString(strings.TrimSpace(record[0]))
-		answers := fl.parseWordString(strings.TrimSpace(record[1]))
-
-		comment := ""
-		if len(record) > 2 {
-			comment = strings.TrimSpace(record[2])
-		}
-
-		if len(questions) > 0 && len(answers) > 0 {
-			item := WordItem{
-				ID:        itemID,
-				Questions: questions,
-				Answers:   answers,
-				Comment:   comment,
-			}
-			lessonData.List.Items = append(lessonData.List.Items, item)
-			itemID++
-		}
-	}
-
-	log.Printf("[SUCCESS] FileLoader.loadCSV() - loaded %d word pairs", len(lessonData.List.Items))
-	return lessonData, nil
+	return fl.LoadCSVWithDialect(filePath, dialect)
 }
 
 // loadTextFile loads simple text files with word pairs
 func (fl *FileLoader) loadTextFile(filePath string) (*LessonData, error) {
 	log.Printf("[ACTION] FileLoader.loadTextFile() - parsing text file")
 
-	file, err := os.Open(filePath)
+	text, err := fl.readText(filePath)
 	if err != nil {
 		log.Printf("[ERROR] Failed to open text file: %v", err)
 		return nil, err
 	}
-	defer file.Close()
 
 	lessonData := NewLessonData()
 	lessonData.List.Title = filepath.Base(filePath)
 
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(strings.NewReader(text))
 	itemID := 0
 
 	for scanner.Scan() {
@@ -285,12 +268,12 @@ func (fl *FileLoader) loadOpenTeacherFile(filePath string) (*LessonData, error)
 	}
 
 	var root OTRoot
-	decoder := xml.NewDecoder(file)
+	decoder := fl.newXMLDecoder(filePath, file)
 	if err := decoder.Decode(&root); err != nil {
 		// Try OpenTeacher 2.x format with <root> element
 		file.Seek(0, 0) // Reset file position
 		var root2x OTRoot2x
-		decoder2x := xml.NewDecoder(file)
+		decoder2x := fl.newXMLDecoder(filePath, file)
 		if err2 := decoder2x.Decode(&root2x); err2 != nil {
 			log.Printf("[ERROR] Failed to parse .ot XML in both 3.x and 2.x formats: %v, %v", err, err2)
 			return nil, err
@@ -371,22 +354,21 @@ func (fl *FileLoader) loadOpenTeacherFile(filePath string) (*LessonData, error)
 func (fl *FileLoader) loadJSONFile(filePath string) (*LessonData, error) {
 	log.Printf("[ACTION] FileLoader.loadJSONFile() - parsing JSON file")
 
-	file, err := os.Open(filePath)
+	data, err := os.ReadFile(filePath)
 	if err != nil {
 		log.Printf("[ERROR] Failed to open JSON file: %v", err)
 		return nil, err
 	}
-	defer file.Close()
 
-	var lessonData LessonData
-	decoder := json.NewDecoder(file)
-	if err := decoder.Decode(&lessonData); err != nil {
+	lessonData, err := DecodeLessonJSON(data)
+	if err != nil {
 		log.Printf("[ERROR] Failed to parse JSON: %v", err)
 		return nil, err
 	}
+	lessonData.SetMediaDir(filepath.Dir(filePath))
 
 	log.Printf("[SUCCESS] FileLoader.loadJSONFile() - loaded %d word pairs", len(lessonData.List.Items))
-	return &lessonData, nil
+	return lessonData, nil
 }
 
 // loadAutoDetect attempts to auto-detect file format and load accordingly
@@ -410,7 +392,7 @@ func (fl *FileLoader) loadAutoDetect(filePath string) (*LessonData, error) {
 	}
 
 	log.Printf("[ERROR] FileLoader.loadAutoDetect() - all format detection failed")
-	return nil, fmt.Errorf("unable to detect file format: %v", lastErr)
+	return nil, fmt.Errorf("%w (%w)", &formatError{action: "load", ext: filepath.Ext(filePath)}, lastErr)
 }
 
 // parseWordString parses a string containing potentially multiple words/phrases
@@ -464,13 +446,16 @@ func (fl *FileLoader) loadKVTMLFile(filePath string) (*LessonData, error) {
 
 	// KVTML XML structure
 	type KVTMLTranslation struct {
-		ID   string `xml:"id,attr"`
-		Text string `xml:"text"`
+		ID      string          `xml:"id,attr"`
+		Text    string          `xml:"text"`
+		Comment string          `xml:"comment"`
+		Other   []rawXMLElement `xml:",any"`
 	}
 
 	type KVTMLEntry struct {
 		ID           string             `xml:"id,attr"`
 		Translations []KVTMLTranslation `xml:"translation"`
+		Other        []rawXMLElement    `xml:",any"`
 	}
 
 	type KVTMLIdentifier struct {
@@ -484,16 +469,39 @@ func (fl *FileLoader) loadKVTMLFile(filePath string) (*LessonData, error) {
 		Comment string `xml:"comment"`
 	}
 
+	// A synonym pair links the translations of two entries
+	type KVTMLPairEntry struct {
+		ID          string `xml:"id,attr"`
+		Translation struct {
+			ID string `xml:"id,attr"`
+		} `xml:"translation"`
+	}
+
+	type KVTMLPair struct {
+		Entries []KVTMLPairEntry `xml:"entry"`
+	}
+
+	// Lessons are containers of entries and of sub-lessons
+	type KVTMLContainer struct {
+		Name    string `xml:"name"`
+		Entries []struct {
+			ID string `xml:"id,attr"`
+		} `xml:"entry"`
+		Containers []KVTMLContainer `xml:"container"`
+	}
+
 	type KVTMLRoot struct {
 		XMLName     xml.Name          `xml:"kvtml"`
 		Version     string            `xml:"version,attr"`
 		Information KVTMLInformation  `xml:"information"`
 		Identifiers []KVTMLIdentifier `xml:"identifiers>identifier"`
 		Entries     []KVTMLEntry      `xml:"entries>entry"`
+		Synonyms    []KVTMLPair       `xml:"synonyms>pair"`
+		Lessons     []KVTMLContainer  `xml:"lessons>container"`
 	}
 
 	var root KVTMLRoot
-	decoder := xml.NewDecoder(file)
+	decoder := fl.newXMLDecoder(filePath, file)
 	if err := decoder.Decode(&root); err != nil {
 		log.Printf("[ERROR] Failed to parse KVTML XML: %v", err)
 		return nil, err
@@ -512,29 +520,70 @@ func (fl *FileLoader) loadKVTMLFile(filePath string) (*LessonData, error) {
 	}
 
 	// Process entries
+	entryItems := make(map[string]int)
 	for i, entry := range root.Entries {
 		var questions, answers []string
+		item := WordItem{ID: i}
 
 		// Find question and answer translations
 		for _, translation := range entry.Translations {
 			if translation.ID == "0" && translation.Text != "" {
 				questions = fl.parseWordString(translation.Text)
+				item.Comment = strings.TrimSpace(translation.Comment)
+				item.SetExtra(ExtraKVTMLQuestion, rawXMLString(translation.Other))
 			} else if translation.ID == "1" && translation.Text != "" {
-				answers = fl.parseWordString(translation.Text)
+				// Numbered meanings ("1. bank 2. shore") are all required
+				item.SetSynonymGroups(fl.parseSynonymGroups(translation.Text))
+				answers = item.Answers
+				item.SetExtra(ExtraKVTMLAnswer, rawXMLString(translation.Other))
 			}
 		}
 
 		if len(questions) > 0 && len(answers) > 0 {
-			item := WordItem{
-				ID:        i,
-				Questions: questions,
-				Answers:   answers,
-				Comment:   "",
-			}
+			item.Questions = questions
+			item.SetExtra(ExtraKVTMLEntry, rawXMLString(entry.Other))
+			entryItems[entry.ID] = len(lessonData.List.Items)
 			lessonData.List.Items = append(lessonData.List.Items, item)
 		}
 	}
 
+	// Synonym pairs of answers make each answer accepted for the other
+	// entry. Entries with several meanings are left alone, as it is not
+	// known which meaning the synonym belongs to.
+	for _, pair := range root.Synonyms {
+		if len(pair.Entries) != 2 || pair.Entries[0].Translation.ID != "1" || pair.Entries[1].Translation.ID != "1" {
+			continue
+		}
+		a, okA := entryItems[pair.Entries[0].ID]
+		b, okB := entryItems[pair.Entries[1].ID]
+		if !okA || !okB {
+			continue
+		}
+		itemA, itemB := &lessonData.List.Items[a], &lessonData.List.Items[b]
+		if len(itemA.AnswerGroups) > 0 || len(itemB.AnswerGroups) > 0 {
+			continue
+		}
+		answersA := append([]string(nil), itemA.Answers...)
+		itemA.Answers = appendMissing(itemA.Answers, itemB.Answers...)
+		itemB.Answers = appendMissing(itemB.Answers, answersA...)
+	}
+
+	// Lessons become the chapters of their entries; an entry in several
+	// lessons is in the first one
+	var addChapters func(containers []KVTMLContainer, parent []string)
+	addChapters = func(containers []KVTMLContainer, parent []string) {
+		for _, container := range containers {
+			chapter := append(append([]string(nil), parent...), strings.TrimSpace(container.Name))
+			for _, entry := range container.Entries {
+				if i, ok := entryItems[entry.ID]; ok && lessonData.List.Items[i].Chapter == nil {
+					lessonData.List.Items[i].Chapter = chapter
+				}
+			}
+			addChapters(container.Containers, chapter)
+		}
+	}
+	addChapters(root.Lessons, nil)
+
 	log.Printf("[SUCCESS] FileLoader.loadKVTMLFile() - loaded %d word pairs", len(lessonData.List.Items))
 	return lessonData, nil
 }
@@ -544,6 +593,11 @@ func (fl *FileLoader) loadKVTMLFile(filePath string) (*LessonData, error) {
 func (fl *FileLoader) loadSQLiteFile(filePath string) (*LessonData, error) {
 	log.Printf("[ACTION] FileLoader.loadSQLiteFile() - parsing SQLite database file")
 
+	format := "Anki or Mnemosyne database"
+	if ext := strings.ToLower(filepath.Ext(filePath)); ext == ".anki" || ext == ".anki2" {
+		format = "Anki database"
+	}
+
 	db, err := sql.Open("sqlite3", filePath)
 	if err != nil {
 		log.Printf("[ERROR] Failed to open SQLite database: %v", err)
@@ -551,6 +605,13 @@ func (fl *FileLoader) loadSQLiteFile(filePath string) (*LessonData, error) {
 	}
 	defer db.Close()
 
+	// sql.Open does not read the file, so make sure it is a database at all
+	var tables int
+	if err := db.QueryRow(`SELECT count(*) FROM sqlite_master`).Scan(&tables); err != nil {
+		log.Printf("[ERROR] Failed to read SQLite database: %v", err)
+		return nil, corruptArchive(format, "not an SQLite database", err)
+	}
+
 	// Check if this is an Anki database (has notes and cards tables)
 	if fl.isAnkiDatabase(db) {
 		return fl.loadAnkiDatabase(db, filePath)
@@ -648,8 +709,19 @@ func (fl *FileLoader) loadAnkiDatabase(db *sql.DB, filePath string) (*LessonData
 	var err error
 
 	if hasNotes {
-		// Anki 2.x format
-		query := `SELECT DISTINCT n.flds FROM notes n JOIN cards c ON n.id = c.nid WHERE c.queue != -1 LIMIT 1000`
+		// Anki 2.x format, with the tags and scheduling kept as extras.
+		// Suspended cards are in queue -1, buried ones in -2 (buried by the
+		// scheduler) or -3 (by the user).
+		query := `
+			SELECT
+				n.flds, n.guid, n.tags,
+				MAX(c.ivl), MAX(c.factor), SUM(c.reps), SUM(c.lapses),
+				SUM(CASE WHEN c.queue >= 0 THEN 1 ELSE 0 END),
+				SUM(CASE WHEN c.queue IN (-2, -3) THEN 1 ELSE 0 END)
+			FROM notes n
+			JOIN cards c ON n.id = c.nid
+			GROUP BY n.id
+			LIMIT 1000`
 		rows, err = db.Query(query)
 	} else {
 		// Anki 1.x format - get question/answer pairs from fields table
@@ -674,8 +746,9 @@ func (fl *FileLoader) loadAnkiDatabase(db *sql.DB, filePath string) (*LessonData
 	for rows.Next() {
 		if hasNotes {
 			// Anki 2.x format - fields are tab-separated
-			var fields string
-			if err := rows.Scan(&fields); err != nil {
+			var fields, guid, tags string
+			var interval, ease, reviews, lapses, activeCards, buriedCards int
+			if err := rows.Scan(&fields, &guid, &tags, &interval, &ease, &reviews, &lapses, &activeCards, &buriedCards); err != nil {
 				log.Printf("[WARNING] Error scanning Anki 2.x row: %v", err)
 				continue
 			}
@@ -686,17 +759,46 @@ func (fl *FileLoader) loadAnkiDatabase(db *sql.DB, filePath string) (*LessonData
 				fieldList = strings.Split(fields, "\t")
 			}
 
+			// Cloze notes keep the masked text in their first field and an
+			// optional extra remark in the second
+			if cleanText := fl.stripHTMLTags(strings.TrimSpace(fieldList[0])); IsClozeText(cleanText) {
+				extra := ""
+				if len(fieldList) >= 2 {
+					extra = fl.stripHTMLTags(strings.TrimSpace(fieldList[1]))
+				}
+				fieldList = []string{cleanText, extra}
+			}
+
 			if len(fieldList) >= 2 {
 				cleanQuestion := fl.stripHTMLTags(strings.TrimSpace(fieldList[0]))
 				cleanAnswer := fl.stripHTMLTags(strings.TrimSpace(fieldList[1]))
 
-				if len(cleanQuestion) > 0 && len(cleanAnswer) > 0 {
-					item := WordItem{
+				var item WordItem
+				if IsClozeText(cleanQuestion) {
+					item = NewClozeItem(itemID, cleanQuestion, cleanAnswer)
+				} else if len(cleanQuestion) > 0 && len(cleanAnswer) > 0 {
+					item = WordItem{
 						ID:        itemID,
 						Questions: []string{cleanQuestion},
 						Answers:   []string{cleanAnswer},
 						Comment:   "",
 					}
+				}
+
+				if len(item.Questions) > 0 {
+					item.Difficulty = ankiDifficulty(activeCards, buriedCards)
+					item.SetExtra(ExtraAnkiGUID, guid)
+					item.SetExtra(ExtraAnkiTags, strings.TrimSpace(tags))
+					item.Tags = strings.Fields(tags)
+					if fields != cleanQuestion+"\x1f"+cleanAnswer {
+						item.SetExtra(ExtraAnkiFields, fields)
+					}
+					if reviews > 0 {
+						item.SetExtra(ExtraAnkiInterval, interval)
+						item.SetExtra(ExtraAnkiEase, ease)
+						item.SetExtra(ExtraAnkiReviews, reviews)
+						item.SetExtra(ExtraAnkiLapses, lapses)
+					}
 					lessonData.List.Items = append(lessonData.List.Items, item)
 					itemID++
 				}
@@ -725,10 +827,70 @@ func (fl *FileLoader) loadAnkiDatabase(db *sql.DB, filePath string) (*LessonData
 		}
 	}
 
+	if hasNotes {
+		fl.readAnkiChapters(db, lessonData.List.Items)
+	}
+
 	log.Printf("[SUCCESS] FileLoader.loadAnkiDatabase() - loaded %d word pairs from Anki database", len(lessonData.List.Items))
 	return lessonData, nil
 }
 
+// readAnkiChapters puts the notes of an Anki 2.x database in the chapters
+// of the subdecks holding their cards, found by the guid of the note
+func (fl *FileLoader) readAnkiChapters(db *sql.DB, items []WordItem) {
+	deckNames := make(map[int64]string)
+	var decksJSON string
+	if err := db.QueryRow(`SELECT decks FROM col LIMIT 1`).Scan(&decksJSON); err == nil {
+		var decks map[string]struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal([]byte(decksJSON), &decks); err == nil {
+			for id, deck := range decks {
+				if deckID, err := strconv.ParseInt(id, 10, 64); err == nil {
+					deckNames[deckID] = deck.Name
+				}
+			}
+		}
+	}
+	// Anki 2.1.28 and later keep the decks in a table of their own,
+	// separating the levels of subdeck names with \x1f
+	if rows, err := db.Query(`SELECT id, name FROM decks`); err == nil {
+		for rows.Next() {
+			var id int64
+			var name string
+			if rows.Scan(&id, &name) == nil {
+				deckNames[id] = strings.ReplaceAll(name, "\x1f", ankiDeckSeparator)
+			}
+		}
+		rows.Close()
+	}
+	if len(deckNames) == 0 {
+		return
+	}
+
+	rows, err := db.Query(`SELECT n.guid, MIN(c.did) FROM notes n JOIN cards c ON n.id = c.nid GROUP BY n.id`)
+	if err != nil {
+		log.Printf("[WARNING] FileLoader.readAnkiChapters() - no decks of cards: %v", err)
+		return
+	}
+	defer rows.Close()
+	noteDecks := make(map[string]string)
+	for rows.Next() {
+		var guid string
+		var deckID int64
+		if rows.Scan(&guid, &deckID) == nil {
+			noteDecks[guid] = deckNames[deckID]
+		}
+	}
+	for i := range items {
+		guid, _ := items[i].ExtraString(ExtraAnkiGUID)
+		if name := noteDecks[guid]; name != "" {
+			items[i].Chapter = strings.Split(name, ankiDeckSeparator)
+		}
+	}
+	trimCommonChapter(items)
+}
+
 // extractAnkiDeckName extracts the deck name from Anki database
 func (fl *FileLoader) extractAnkiDeckName(db *sql.DB, fallbackName string) string {
 	// Check if this is Anki 2.x (has col table with JSON data)
@@ -916,7 +1078,7 @@ func (fl *FileLoader) loadXMLFile(filePath string) (*LessonData, error) {
 
 	// Try to parse as simple XML word list
 	var root XMLRoot
-	decoder := xml.NewDecoder(file)
+	decoder := fl.newXMLDecoder(filePath, file)
 	if err := decoder.Decode(&root); err != nil {
 		// If XML parsing fails, try as text file
 		log.Printf("[WARNING] XML parsing failed, trying as text file: %v", err)
@@ -995,6 +1157,7 @@ func (fl *FileLoader) loadTeach2000File(filePath string) (*LessonData, error) {
 		Errors       int                `xml:"errors"`
 		TestCount    int                `xml:"testcount"`
 		CorrectCount int                `xml:"correctcount"`
+		Other        []rawXMLElement    `xml:",any"`
 	}
 
 	type Teach2000Items struct {
@@ -1013,7 +1176,7 @@ func (fl *FileLoader) loadTeach2000File(filePath string) (*LessonData, error) {
 	}
 
 	var root Teach2000Root
-	decoder := xml.NewDecoder(file)
+	decoder := fl.newXMLDecoder(filePath, file)
 	if err := decoder.Decode(&root); err != nil {
 		log.Printf("[ERROR] Failed to parse Teach2000 XML: %v", err)
 		return nil, err
@@ -1051,6 +1214,12 @@ func (fl *FileLoader) loadTeach2000File(filePath string) (*LessonData, error) {
 					Answers:   answers,
 					Comment:   fmt.Sprintf("TestCount: %d, Correct: %d, Errors: %d", item.TestCount, item.CorrectCount, item.Errors),
 				}
+				if item.TestCount > 0 {
+					wordItem.SetExtra(ExtraT2KErrors, item.Errors)
+					wordItem.SetExtra(ExtraT2KTestCount, item.TestCount)
+					wordItem.SetExtra(ExtraT2KCorrectCount, item.CorrectCount)
+				}
+				wordItem.SetExtra(ExtraT2KItem, rawXMLString(item.Other))
 				lessonData.List.Items = append(lessonData.List.Items, wordItem)
 				itemID++
 			}
@@ -1069,7 +1238,7 @@ func (fl *FileLoader) loadJVLTFile(filePath string) (*LessonData, error) {
 	reader, err := zip.OpenReader(filePath)
 	if err != nil {
 		log.Printf("[ERROR] Failed to open JVLT ZIP file: %v", err)
-		return nil, err
+		return nil, corruptArchive("JVLT archive", "", err)
 	}
 	defer reader.Close()
 
@@ -1084,7 +1253,7 @@ func (fl *FileLoader) loadJVLTFile(filePath string) (*LessonData, error) {
 
 	if dictFile == nil {
 		log.Printf("[ERROR] No XML file found in JVLT ZIP")
-		return nil, fmt.Errorf("no dictionary XML file found in JVLT archive")
+		return nil, corruptArchive("JVLT archive", "no dictionary XML file found", nil)
 	}
 
 	// Open and read the XML file
@@ -1117,7 +1286,7 @@ func (fl *FileLoader) loadJVLTFile(filePath string) (*LessonData, error) {
 	decoder := xml.NewDecoder(xmlReader)
 	if err := decoder.Decode(&dict); err != nil {
 		log.Printf("[ERROR] Failed to parse JVLT XML: %v", err)
-		return nil, err
+		return nil, corruptArchive("JVLT archive", "invalid dictionary XML", err)
 	}
 
 	lessonData := NewLessonData()
@@ -1190,7 +1359,7 @@ func (fl *FileLoader) loadFlashQardFile(filePath string) (*LessonData, error) {
 	}
 
 	var root FlashQardRoot
-	decoder := xml.NewDecoder(file)
+	decoder := fl.newXMLDecoder(filePath, file)
 	if err := decoder.Decode(&root); err != nil {
 		log.Printf("[ERROR] Failed to parse FlashQard XML: %v", err)
 		return nil, err
@@ -1253,17 +1422,7 @@ func (fl *FileLoader) loadTeachMasterFile(filePath string) (*LessonData, error)
 	}
 
 	var root TeachMasterRoot
-	decoder := xml.NewDecoder(file)
-
-	// Add charset reader for ISO-8859-1 encoding
-	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
-		switch charset {
-		case "ISO-8859-1":
-			return transform.NewReader(input, charmap.ISO8859_1.NewDecoder()), nil
-		default:
-			return nil, fmt.Errorf("unsupported charset: %s", charset)
-		}
-	}
+	decoder := fl.newXMLDecoder(filePath, file)
 
 	if err := decoder.Decode(&root); err != nil {
 		log.Printf("[ERROR] Failed to parse TeachMaster XML: %v", err)
@@ -1329,7 +1488,7 @@ func (fl *FileLoader) loadCueCardFile(filePath string) (*LessonData, error) {
 	}
 
 	var root CueCardRoot
-	decoder := xml.NewDecoder(file)
+	decoder := fl.newXMLDecoder(filePath, file)
 	if err := decoder.Decode(&root); err != nil {
 		log.Printf("[ERROR] Failed to parse CueCard XML: %v", err)
 		return nil, err
@@ -1378,17 +1537,16 @@ func (fl *FileLoader) loadCueCardFile(filePath string) (*LessonData, error) {
 func (fl *FileLoader) loadBackpackFile(filePath string) (*LessonData, error) {
 	log.Printf("[ACTION] FileLoader.loadBackpackFile() - parsing Backpack text file")
 
-	file, err := os.Open(filePath)
+	text, err := fl.readText(filePath)
 	if err != nil {
 		log.Printf("[ERROR] Failed to open Backpack file: %v", err)
 		return nil, err
 	}
-	defer file.Close()
 
 	lessonData := NewLessonData()
 	lessonData.List.Title = filepath.Base(filePath)
 
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(strings.NewReader(text))
 	itemID := 0
 
 	for scanner.Scan() {
@@ -1473,7 +1631,8 @@ func (fl *FileLoader) GetSupportedExtensions() []string {
 		".apkg", ".backpack", ".wcu", ".voc", ".fq", ".fmd", ".dkf", ".jml",
 		".jvlt", ".stp", ".db", ".oh", ".ohw", ".oh4", ".ovr", ".pau",
 		".t2k", ".vok2", ".wdl", ".vtl3", ".wrts", ".xml", ".kgm", ".ottp",
-		".otmd", ".otwd",
+		".otmd", ".otwd", ".quizlet", ".pdf", ".xlsx", ".md", ".markdown",
+		".pau.gz", ".xml.gz", ".otio", ".otsec",
 	}
 }
 
@@ -1522,7 +1681,7 @@ func (fl *FileLoader) GetFormatName(ext string) string {
 		return "Overhoor File"
 	case ".ovr":
 		return "Overhoringsprogramma Talen"
-	case ".pau":
+	case ".pau", ".pau.gz", ".xml.gz":
 		return "Pauker File"
 	case ".t2k":
 		return "Teach2000 File"
@@ -1542,8 +1701,20 @@ func (fl *FileLoader) GetFormatName(ext string) string {
 		return "OpenTeaching Topography"
 	case ".otmd":
 		return "OpenTeaching Media"
+	case ".otio":
+		return "OpenTeaching Image Occlusion"
 	case ".otwd":
 		return "OpenTeaching Words"
+	case ".otsec":
+		return "Encrypted Lesson"
+	case ".quizlet":
+		return "Quizlet Export"
+	case ".pdf":
+		return "PDF Document"
+	case ".xlsx":
+		return "Excel Workbook"
+	case ".md", ".markdown":
+		return "Markdown Flashcards"
 	default:
 		return "Unknown Format"
 	}
@@ -1578,7 +1749,7 @@ func (fl *FileLoader) loadKGeographyMapFile(filePath string) (*LessonData, error
 	}
 
 	var kgmMap KGMMap
-	decoder := xml.NewDecoder(file)
+	decoder := fl.newXMLDecoder(filePath, file)
 	if err := decoder.Decode(&kgmMap); err != nil {
 		log.Printf("[ERROR] Failed to parse KGeography XML: %v", err)
 		return nil, err
@@ -1632,47 +1803,33 @@ func (fl *FileLoader) loadOpenTeachingTopoFile(filePath string) (*LessonData, er
 	reader, err := zip.OpenReader(filePath)
 	if err != nil {
 		log.Printf("[ERROR] Failed to open OpenTeaching Topo ZIP file: %v", err)
-		return nil, err
+		return nil, corruptArchive("OpenTeaching Topography archive", "", err)
 	}
 	defer reader.Close()
 
-	// Look for list.json file inside the ZIP
-	var listFile *zip.File
-	for _, file := range reader.File {
-		if file.Name == "list.json" {
-			listFile = file
-			break
-		}
-	}
-
-	if listFile == nil {
-		log.Printf("[ERROR] No list.json file found in OpenTeaching Topo ZIP")
-		return nil, fmt.Errorf("no list.json file found in OpenTeaching Topography archive")
-	}
-
-	// Open and read the JSON file
-	jsonReader, err := listFile.Open()
+	// Read every entry, checking them against the manifest
+	files, err := readArchive("OpenTeaching Topography archive", &reader.Reader)
 	if err != nil {
-		log.Printf("[ERROR] Failed to open list.json file in ZIP: %v", err)
+		log.Printf("[ERROR] Damaged OpenTeaching Topo ZIP: %v", err)
 		return nil, err
 	}
-	defer jsonReader.Close()
 
-	// Read JSON content
-	jsonData, err := io.ReadAll(jsonReader)
-	if err != nil {
-		log.Printf("[ERROR] Failed to read list.json content: %v", err)
-		return nil, err
+	// Look for list.json file inside the ZIP
+	jsonData, ok := files["list.json"]
+	if !ok {
+		log.Printf("[ERROR] No list.json file found in OpenTeaching Topo ZIP")
+		return nil, corruptArchive("OpenTeaching Topography archive", "no list.json file found", nil)
 	}
 
 	// Parse OpenTeacher format JSON
 	var otData map[string]interface{}
 	if err := json.Unmarshal(jsonData, &otData); err != nil {
 		log.Printf("[ERROR] Failed to parse OpenTeaching Topo JSON: %v", err)
-		return nil, err
+		return nil, corruptArchive("OpenTeaching Topography archive", "invalid list.json", err)
 	}
 
 	lessonData := NewLessonData()
+	lessonData.List.readMetadata(otData)
 
 	// Extract title
 	if title, ok := otData["title"].(string); ok {
@@ -1715,14 +1872,16 @@ func (fl *FileLoader) loadOpenTeachingTopoFile(filePath string) (*LessonData, er
 				}
 
 				if name != "" {
-					lessonData.List.Items = append(lessonData.List.Items, WordItem{
+					topoItem := WordItem{
 						ID:        itemID,
 						Name:      name,
 						Questions: []string{name},
 						Answers:   []string{name},
 						X:         &x,
 						Y:         &y,
-					})
+					}
+					topoItem.readItemState(itemMap)
+					lessonData.List.Items = append(lessonData.List.Items, topoItem)
 					itemID++
 				}
 			}
@@ -1741,47 +1900,33 @@ func (fl *FileLoader) loadOpenTeachingMediaFile(filePath string) (*LessonData, e
 	reader, err := zip.OpenReader(filePath)
 	if err != nil {
 		log.Printf("[ERROR] Failed to open OpenTeaching Media ZIP file: %v", err)
-		return nil, err
+		return nil, corruptArchive("OpenTeaching Media archive", "", err)
 	}
 	defer reader.Close()
 
-	// Look for list.json file inside the ZIP
-	var listFile *zip.File
-	for _, file := range reader.File {
-		if file.Name == "list.json" {
-			listFile = file
-			break
-		}
-	}
-
-	if listFile == nil {
-		log.Printf("[ERROR] No list.json file found in OpenTeaching Media ZIP")
-		return nil, fmt.Errorf("no list.json file found in OpenTeaching Media archive")
-	}
-
-	// Open and read the JSON file
-	jsonReader, err := listFile.Open()
+	// Read every entry, checking them against the manifest
+	files, err := readArchive("OpenTeaching Media archive", &reader.Reader)
 	if err != nil {
-		log.Printf("[ERROR] Failed to open list.json file in ZIP: %v", err)
+		log.Printf("[ERROR] Damaged OpenTeaching Media ZIP: %v", err)
 		return nil, err
 	}
-	defer jsonReader.Close()
 
-	// Read JSON content
-	jsonData, err := io.ReadAll(jsonReader)
-	if err != nil {
-		log.Printf("[ERROR] Failed to read list.json content: %v", err)
-		return nil, err
+	// Look for list.json file inside the ZIP
+	jsonData, ok := files["list.json"]
+	if !ok {
+		log.Printf("[ERROR] No list.json file found in OpenTeaching Media ZIP")
+		return nil, corruptArchive("OpenTeaching Media archive", "no list.json file found", nil)
 	}
 
 	// Parse OpenTeacher format JSON
 	var otData map[string]interface{}
 	if err := json.Unmarshal(jsonData, &otData); err != nil {
 		log.Printf("[ERROR] Failed to parse OpenTeaching Media JSON: %v", err)
-		return nil, err
+		return nil, corruptArchive("OpenTeaching Media archive", "invalid list.json", err)
 	}
 
 	lessonData := NewLessonData()
+	lessonData.List.readMetadata(otData)
 
 	// Extract title
 	if title, ok := otData["title"].(string); ok {
@@ -1838,20 +1983,118 @@ func (fl *FileLoader) loadOpenTeachingMediaFile(filePath string) (*LessonData, e
 				}
 
 				if name != "" {
-					lessonData.List.Items = append(lessonData.List.Items, WordItem{
+					mediaItem := WordItem{
 						ID:        itemID,
 						Name:      name,
 						Questions: questions,
 						Answers:   answers,
 						Filename:  &filename,
 						Remote:    &remote,
-					})
+					}
+					mediaItem.readItemState(itemMap)
+					lessonData.List.Items = append(lessonData.List.Items, mediaItem)
 					itemID++
 				}
 			}
 		}
 	}
 
+	// Media stored in the archive is embedded, other media is linked
+	// relative to it
+	for _, item := range lessonData.List.Items {
+		if data, ok := files[*item.Filename]; ok && *item.Filename != "list.json" {
+			lessonData.SetEmbeddedMedia(*item.Filename, data)
+		}
+	}
+	lessonData.SetMediaDir(filepath.Dir(filePath))
+
 	log.Printf("[SUCCESS] FileLoader.loadOpenTeachingMediaFile() - loaded %d media items", len(lessonData.List.Items))
 	return lessonData, nil
 }
+
+// loadOpenTeachingOcclusionFile parses OpenTeaching Image Occlusion (.otio)
+// ZIP files: a list.json with the masks and the image they cover
+func (fl *FileLoader) loadOpenTeachingOcclusionFile(filePath string) (*LessonData, error) {
+	log.Printf("[ACTION] FileLoader.loadOpenTeachingOcclusionFile() - parsing OpenTeaching Image Occlusion ZIP file")
+
+	reader, err := zip.OpenReader(filePath)
+	if err != nil {
+		log.Printf("[ERROR] Failed to open OpenTeaching Occlusion ZIP file: %v", err)
+		return nil, corruptArchive("OpenTeaching Image Occlusion archive", "", err)
+	}
+	defer reader.Close()
+
+	files, err := readArchive("OpenTeaching Image Occlusion archive", &reader.Reader)
+	if err != nil {
+		log.Printf("[ERROR] Damaged OpenTeaching Occlusion ZIP: %v", err)
+		return nil, err
+	}
+
+	jsonData, ok := files["list.json"]
+	if !ok {
+		log.Printf("[ERROR] No list.json file found in OpenTeaching Occlusion ZIP")
+		return nil, corruptArchive("OpenTeaching Image Occlusion archive", "no list.json file found", nil)
+	}
+
+	var otData occlusionList
+	if err := json.Unmarshal(jsonData, &otData); err != nil {
+		log.Printf("[ERROR] Failed to parse OpenTeaching Occlusion JSON: %v", err)
+		return nil, corruptArchive("OpenTeaching Image Occlusion archive", "invalid list.json", err)
+	}
+
+	lessonData := NewLessonData()
+	lessonData.List.Title = otData.Title
+	if lessonData.List.Title == "" {
+		lessonData.List.Title = fmt.Sprintf("Image Occlusion Lesson (%d masks)", len(otData.Items))
+	}
+	lessonData.List.AnswerLanguage = otData.AnswerLanguage
+	lessonData.List.Tags = otData.Tags
+	lessonData.List.Author = otData.Author
+	lessonData.List.Description = otData.Description
+	lessonData.List.License = otData.License
+	lessonData.List.UUID = otData.UUID
+	lessonData.List.Sync = ParseSyncScope(otData.Sync)
+	if level, err := ParseCEFRLevel(otData.Level); err == nil {
+		lessonData.List.Level = level
+	}
+	if otData.Tests != nil {
+		lessonData.List.Tests = otData.Tests
+	}
+
+	if otData.Image != "" {
+		imageData, ok := files[otData.Image]
+		if !ok {
+			log.Printf("[ERROR] Image %s missing from OpenTeaching Occlusion ZIP", otData.Image)
+			return nil, corruptArchive("OpenTeaching Image Occlusion archive", fmt.Sprintf("image %s not found", otData.Image), nil)
+		}
+		lessonData.SetOcclusionImage(otData.Image, imageData)
+	}
+
+	for _, mask := range otData.Items {
+		answers := mask.Answers
+		if len(answers) == 0 && mask.Name != "" {
+			answers = []string{mask.Name}
+		}
+		rect := image.Rect(mask.X, mask.Y, mask.X+mask.Width, mask.Y+mask.Height)
+		item := NewOcclusionItem(mask.ID, rect, answers)
+		item.Comment = mask.Comment
+		item.Starred = mask.Starred
+		item.Difficulty = mask.Difficulty
+		item.Tags = mask.Tags
+		item.Chapter = mask.Chapter
+		lessonData.List.Items = append(lessonData.List.Items, item)
+	}
+
+	log.Printf("[SUCCESS] FileLoader.loadOpenTeachingOcclusionFile() - loaded %d masks", len(lessonData.List.Items))
+	return lessonData, nil
+}
+
+// readZipFile returns the contents of a file in a ZIP archive
+func readZipFile(file *zip.File) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
@@ -696,6 +696,8 @@ func TestComprehensiveFormatSupport(t *testing.T) {
 		{"application_x-flashqard.flashqard.fq", "FlashQard", true, 2}, // FlashQard XML format
 		{"application_x-jvlt.jvlt.jvlt", "JVLT", true, 2},              // JVLT ZIP format
 		{"application_x-teachmaster.vok2", "TeachMaster", true, 3},     // TeachMaster XML format
+		{"application_x-pauker.pauker.pau.gz", "Pauker (gzipped)", true, 2},
+		{"application_x-pauker.pauker-modified.pau", "Pauker", true, 2},
 
 		// XML variants
 		{"application_xml.abbyylingvotutor_x3-modified.xml", "ABBYY Lingvo (modified)", true, 1},
@@ -0,0 +1,194 @@
+package lesson
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Markdown decks are plain text files meant to be edited by hand and kept in
+// version control. Two card styles are supported and may be mixed:
+//
+//	## Question
+//
+//	Answer paragraph
+//
+//	> optional comment
+//
+// and one-line cards written as "Question :: Answer". An optional front
+// matter block between "---" lines carries the lesson languages, and the
+// first "# " heading is used as the lesson title.
+
+// markdownCardSeparator separates question and answer on one-line cards
+const markdownCardSeparator = " :: "
+
+// loadMarkdownFile loads a Markdown flashcard deck
+func (fl *FileLoader) loadMarkdownFile(filePath string) (*LessonData, error) {
+	log.Printf("[ACTION] FileLoader.loadMarkdownFile() - parsing Markdown deck")
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		log.Printf("[ERROR] Failed to open Markdown file: %v", err)
+		return nil, err
+	}
+	defer file.Close()
+
+	lessonData := NewLessonData()
+
+	var question string
+	var answerLines, commentLines []string
+	inCard := false
+
+	flush := func() {
+		if inCard {
+			questions := fl.parseWordString(question)
+			answers := fl.parseWordString(strings.TrimSpace(strings.Join(answerLines, " ")))
+			if len(questions) > 0 && len(answers) > 0 {
+				lessonData.List.Items = append(lessonData.List.Items, WordItem{
+					ID:        len(lessonData.List.Items),
+					Questions: questions,
+					Answers:   answers,
+					Comment:   strings.TrimSpace(strings.Join(commentLines, " ")),
+				})
+			}
+		}
+		inCard = false
+		question = ""
+		answerLines = nil
+		commentLines = nil
+	}
+
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	inFrontMatter := false
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t")
+		trimmed := strings.TrimSpace(line)
+		lineNumber++
+
+		if lineNumber == 1 {
+			trimmed = strings.TrimPrefix(trimmed, "\ufeff")
+			if trimmed == "---" {
+				inFrontMatter = true
+				continue
+			}
+		}
+
+		if inFrontMatter {
+			if trimmed == "---" {
+				inFrontMatter = false
+				continue
+			}
+			if key, value, ok := strings.Cut(trimmed, ":"); ok {
+				value = strings.TrimSpace(value)
+				switch strings.TrimSpace(key) {
+				case "title":
+					lessonData.List.Title = value
+				case "questionLanguage":
+					lessonData.List.QuestionLanguage = value
+				case "answerLanguage":
+					lessonData.List.AnswerLanguage = value
+				}
+			}
+			continue
+		}
+
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "<!--"):
+			continue
+
+		case strings.HasPrefix(trimmed, "## "):
+			flush()
+			question = strings.TrimSpace(strings.TrimPrefix(trimmed, "## "))
+			inCard = true
+
+		case strings.HasPrefix(trimmed, "# "):
+			flush()
+			if lessonData.List.Title == "" {
+				lessonData.List.Title = strings.TrimSpace(strings.TrimPrefix(trimmed, "# "))
+			}
+
+		case strings.Contains(trimmed, markdownCardSeparator) && (!inCard || len(answerLines) > 0):
+			flush()
+			parts := strings.SplitN(trimmed, markdownCardSeparator, 2)
+			question = strings.TrimSpace(strings.TrimLeft(parts[0], "-* "))
+			answerLines = []string{parts[1]}
+			inCard = true
+			flush()
+
+		case strings.HasPrefix(trimmed, ">") && inCard:
+			commentLines = append(commentLines, strings.TrimSpace(strings.TrimPrefix(trimmed, ">")))
+
+		case inCard:
+			answerLines = append(answerLines, trimmed)
+		}
+	}
+	flush()
+
+	if err := scanner.Err(); err != nil {
+		log.Printf("[ERROR] Error reading Markdown file: %v", err)
+		return nil, err
+	}
+
+	if lessonData.List.Title == "" {
+		lessonData.List.Title = strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	}
+
+	log.Printf("[SUCCESS] FileLoader.loadMarkdownFile() - loaded %d word pairs", len(lessonData.List.Items))
+	return lessonData, nil
+}
+
+// saveMarkdownFile saves lesson data as a Markdown flashcard deck
+func (fs *FileSaver) saveMarkdownFile(lessonData *LessonData, filePath string) error {
+	log.Printf("[ACTION] FileSaver.saveMarkdownFile() - saving Markdown deck")
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		log.Printf("[ERROR] Failed to create Markdown file: %v", err)
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+
+	if lessonData.List.QuestionLanguage != "" || lessonData.List.AnswerLanguage != "" {
+		writer.WriteString("---\n")
+		if lessonData.List.QuestionLanguage != "" {
+			fmt.Fprintf(writer, "questionLanguage: %s\n", lessonData.List.QuestionLanguage)
+		}
+		if lessonData.List.AnswerLanguage != "" {
+			fmt.Fprintf(writer, "answerLanguage: %s\n", lessonData.List.AnswerLanguage)
+		}
+		writer.WriteString("---\n\n")
+	}
+
+	title := lessonData.List.Title
+	if title == "" {
+		title = "Lesson"
+	}
+	fmt.Fprintf(writer, "# %s\n", markdownLine(title))
+
+	for _, item := range lessonData.List.Items {
+		fmt.Fprintf(writer, "\n## %s\n\n%s\n", markdownLine(strings.Join(item.Questions, "; ")),
+			markdownLine(strings.Join(item.Answers, "; ")))
+		if item.Comment != "" {
+			fmt.Fprintf(writer, "\n> %s\n", markdownLine(item.Comment))
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		log.Printf("[ERROR] Failed to write Markdown file: %v", err)
+		return err
+	}
+
+	log.Printf("[SUCCESS] FileSaver.saveMarkdownFile() - saved %d items to Markdown deck", len(lessonData.List.Items))
+	return nil
+}
+
+// markdownLine flattens text to a single line so it cannot break the deck structure
+func markdownLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
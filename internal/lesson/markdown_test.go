@@ -0,0 +1,106 @@
+package lesson
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadMarkdownFile(t *testing.T) {
+	loader := NewFileLoader()
+
+	mdFile := filepath.Join(t.TempDir(), "deck.md")
+	mdContent := `---
+questionLanguage: English
+answerLanguage: German
+---
+
+# Kitchen words
+
+## the spoon
+
+der Löffel
+
+> masculine
+
+## the fork
+
+die Gabel
+
+- the knife :: das Messer
+the plate :: der Teller
+<!-- the cup :: ignored because this is an HTML comment -->
+`
+
+	if err := os.WriteFile(mdFile, []byte(mdContent), 0644); err != nil {
+		t.Fatalf("Failed to create test Markdown file: %v", err)
+	}
+
+	lessonData, err := loader.LoadFile(mdFile)
+	if err != nil {
+		t.Fatalf("Failed to load Markdown file: %v", err)
+	}
+
+	if lessonData.List.Title != "Kitchen words" {
+		t.Errorf("Expected title 'Kitchen words', got '%s'", lessonData.List.Title)
+	}
+	if lessonData.List.QuestionLanguage != "English" || lessonData.List.AnswerLanguage != "German" {
+		t.Errorf("Languages not read from front matter: %q/%q", lessonData.List.QuestionLanguage, lessonData.List.AnswerLanguage)
+	}
+
+	if len(lessonData.List.Items) != 4 {
+		t.Fatalf("Expected 4 items, got %d: %+v", len(lessonData.List.Items), lessonData.List.Items)
+	}
+
+	first := lessonData.List.Items[0]
+	if first.Questions[0] != "the spoon" || first.Answers[0] != "der Löffel" || first.Comment != "masculine" {
+		t.Errorf("Unexpected first item: %+v", first)
+	}
+	if lessonData.List.Items[1].Answers[0] != "die Gabel" {
+		t.Errorf("Unexpected second item: %+v", lessonData.List.Items[1])
+	}
+	if lessonData.List.Items[2].Questions[0] != "the knife" || lessonData.List.Items[3].Answers[0] != "der Teller" {
+		t.Errorf("Unexpected one-line cards: %+v", lessonData.List.Items[2:])
+	}
+}
+
+func TestFileSaver_SaveMarkdownRoundTrip(t *testing.T) {
+	lessonData := &LessonData{
+		List: WordList{
+			Title:            "Greetings",
+			QuestionLanguage: "English",
+			Items: []WordItem{
+				{ID: 0, Questions: []string{"hello"}, Answers: []string{"hallo"}, Comment: "informal\ngreeting"},
+				{ID: 1, Questions: []string{"goodbye", "bye"}, Answers: []string{"dag", "tot ziens"}},
+			},
+		},
+	}
+
+	mdFile := filepath.Join(t.TempDir(), "greetings.md")
+	if err := NewFileSaver().SaveFile(lessonData, mdFile); err != nil {
+		t.Fatalf("Failed to save Markdown file: %v", err)
+	}
+
+	content, err := os.ReadFile(mdFile)
+	if err != nil {
+		t.Fatalf("Failed to read saved file: %v", err)
+	}
+	if !strings.Contains(string(content), "## goodbye; bye\n\ndag; tot ziens\n") {
+		t.Errorf("Unexpected Markdown output:\n%s", content)
+	}
+
+	loaded, err := NewFileLoader().LoadFile(mdFile)
+	if err != nil {
+		t.Fatalf("Failed to reload Markdown file: %v", err)
+	}
+	if loaded.List.Title != "Greetings" || loaded.List.QuestionLanguage != "English" {
+		t.Errorf("Metadata not preserved: %+v", loaded.List)
+	}
+	if len(loaded.List.Items) != 2 || loaded.List.Items[0].Comment != "informal greeting" {
+		t.Fatalf("Unexpected items after round trip: %+v", loaded.List.Items)
+	}
+	if !equalStringSlices(loaded.List.Items[1].Questions, []string{"goodbye", "bye"}) {
+		t.Errorf("Questions not preserved: %v", loaded.List.Items[1].Questions)
+	}
+}
@@ -0,0 +1,472 @@
+package lesson
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/LaPingvino/recuerdo/internal/paths"
+)
+
+// Media lessons refer to their pictures, sound and video by file name. The
+// files can be embedded in the .otmd archive, so the lesson is a single file
+// to hand out, or linked by a path relative to the lesson, so large media is
+// not copied into every lesson using it. Links break when a lesson folder is
+// moved without its media: RelinkMedia finds the files again, and
+// CollectMedia copies them next to the lesson so the folder moves as a
+// whole.
+
+// How the media of a lesson is stored, in SaveOptions.MediaStorage
+const (
+	// MediaEmbed stores media files inside the lesson archive
+	MediaEmbed = "embed"
+	// MediaLink stores the path of media files relative to the lesson
+	MediaLink = "link"
+)
+
+// SettingMediaStorage is the settings key holding whether media files are
+// embedded in lessons or linked by default
+const SettingMediaStorage = "saving.mediaStorage"
+
+// EmbeddedMediaResource is the LessonData.Resources key holding the media
+// files embedded in a lesson, by the file name items refer to them by
+const EmbeddedMediaResource = "embeddedMedia"
+
+// mediaFolder is the folder media is stored in, inside an archive when it
+// is embedded and next to the lesson when collected
+const mediaFolder = "media"
+
+var (
+	defaultMediaStorage      = MediaLink
+	defaultMediaStorageMutex sync.RWMutex
+)
+
+// DefaultMediaStorage returns how savers store media by default, MediaEmbed
+// or MediaLink
+func DefaultMediaStorage() string {
+	defaultMediaStorageMutex.RLock()
+	defer defaultMediaStorageMutex.RUnlock()
+	return defaultMediaStorage
+}
+
+// SetDefaultMediaStorage changes how savers store media by default; other
+// values than MediaEmbed link media
+func SetDefaultMediaStorage(storage string) {
+	defaultMediaStorageMutex.Lock()
+	defer defaultMediaStorageMutex.Unlock()
+	defaultMediaStorage = MediaLink
+	if storage == MediaEmbed {
+		defaultMediaStorage = MediaEmbed
+	}
+}
+
+// LoadDefaultMediaStorage makes the media storage stored in settings the
+// default, keeping the current default when it is missing
+func LoadDefaultMediaStorage(settings ToleranceSettings) string {
+	if storage, ok := settings.GetSettingWithDefault(SettingMediaStorage, DefaultMediaStorage()).(string); ok {
+		SetDefaultMediaStorage(storage)
+	}
+	return DefaultMediaStorage()
+}
+
+// SaveDefaultMediaStorage makes storage the default media storage and
+// stores it in settings
+func SaveDefaultMediaStorage(settings ToleranceSettings, storage string) error {
+	SetDefaultMediaStorage(storage)
+	return settings.SetSetting(SettingMediaStorage, DefaultMediaStorage())
+}
+
+// SetEmbeddedMedia embeds the content of a media file in the lesson, under
+// the file name items refer to it by
+func (ld *LessonData) SetEmbeddedMedia(name string, data []byte) {
+	media := ld.embeddedMedia()
+	if media == nil {
+		if ld.Resources == nil {
+			ld.Resources = make(map[string]interface{})
+		}
+		media = make(map[string][]byte)
+		ld.Resources[EmbeddedMediaResource] = media
+	}
+	media[name] = data
+}
+
+// EmbeddedMedia returns the content of a media file embedded in the lesson
+func (ld *LessonData) EmbeddedMedia(name string) ([]byte, bool) {
+	data, ok := ld.embeddedMedia()[name]
+	return data, ok
+}
+
+// embeddedMedia returns the media embedded in the lesson, nil when there
+// is none. Lessons read back from JSON hold it as a plain map with base64
+// data, which is converted.
+func (ld *LessonData) embeddedMedia() map[string][]byte {
+	switch media := ld.Resources[EmbeddedMediaResource].(type) {
+	case map[string][]byte:
+		return media
+	case map[string]interface{}:
+		converted := make(map[string][]byte, len(media))
+		for name, value := range media {
+			encoded, _ := value.(string)
+			if data, err := base64.StdEncoding.DecodeString(encoded); err == nil {
+				converted[name] = data
+			}
+		}
+		ld.Resources[EmbeddedMediaResource] = converted
+		return converted
+	}
+	return nil
+}
+
+// MediaStorage returns how the media of the lesson is stored now:
+// MediaEmbed when any of it is embedded, else MediaLink
+func (ld *LessonData) MediaStorage() string {
+	if len(ld.embeddedMedia()) > 0 {
+		return MediaEmbed
+	}
+	return MediaLink
+}
+
+// MediaDir returns the folder the linked media of the lesson is relative
+// to: that of the file it was loaded from, until CollectMedia moves it
+func (ld *LessonData) MediaDir() string {
+	return ld.mediaDir
+}
+
+// SetMediaDir sets the folder the linked media of the lesson is relative to
+func (ld *LessonData) SetMediaDir(dir string) {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	ld.mediaDir = dir
+}
+
+// mediaBase returns the folder linked media is relative to, falling back
+// to that of lessonPath for lessons that were not loaded from a file
+func (ld *LessonData) mediaBase(lessonPath string) string {
+	if ld.mediaDir == "" && lessonPath != "" {
+		return filepath.Dir(lessonPath)
+	}
+	return ld.mediaDir
+}
+
+// ResolveMedia returns where the media of an item can be opened. Remote
+// media and absolute paths are returned as they are and linked media next
+// to the lesson at lessonPath; embedded media is written to the cache
+// first, as players open files.
+func (ld *LessonData) ResolveMedia(lessonPath, filename string, remote bool) (string, error) {
+	if remote {
+		return filename, nil
+	}
+	data, ok := ld.EmbeddedMedia(filename)
+	if !ok {
+		return resolveLink(ld.mediaBase(lessonPath), filename), nil
+	}
+	sum := sha256.Sum256(data)
+	cached := filepath.Join(paths.CacheDir(), mediaFolder, hex.EncodeToString(sum[:8]), filepath.Base(filename))
+	if stat, err := os.Stat(cached); err == nil && stat.Size() == int64(len(data)) {
+		return cached, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(cached), 0755); err != nil {
+		return "", err
+	}
+	if err := WriteFileAtomically(cached, data, 0644); err != nil {
+		return "", err
+	}
+	return cached, nil
+}
+
+// resolveLink returns the path of a linked media file relative to dir
+func resolveLink(dir, filename string) string {
+	if filepath.IsAbs(filename) || dir == "" {
+		return filename
+	}
+	return filepath.Join(dir, filepath.FromSlash(filename))
+}
+
+// linkTo returns the file name to link the media file at path by from a
+// lesson in dir: relative when it can be, with forward slashes so the
+// lesson works on every system
+func linkTo(dir, path string) string {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || dir == "" {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// mediaPlan is how the media of a lesson is stored when it is saved
+type mediaPlan struct {
+	// filenames are the file names items refer to their media by in the
+	// saved lesson, by the one they have now
+	filenames map[string]string
+	// embedded are the media files to store in the archive, by entry name
+	embedded map[string][]byte
+}
+
+// planMedia works out how to store the local media of the lesson in a file
+// saved into dir, as storage says. Linked media is embedded when it can be
+// read and keeps its link, made relative to dir, when it cannot. Embedded
+// media that is to be linked is written to the media folder in dir.
+func (ld *LessonData) planMedia(storage, dir string) (*mediaPlan, error) {
+	plan := &mediaPlan{filenames: make(map[string]string), embedded: make(map[string][]byte)}
+	base := ld.mediaBase("")
+	for _, item := range ld.List.Items {
+		filename, remote, ok := item.GetMediaInfo()
+		if !ok || remote || filename == "" {
+			continue
+		}
+		if _, planned := plan.filenames[filename]; planned {
+			continue
+		}
+
+		data, embedded := ld.EmbeddedMedia(filename)
+		if storage == MediaEmbed {
+			if !embedded {
+				var err error
+				if data, err = os.ReadFile(resolveLink(base, filename)); err != nil {
+					log.Printf("[WARNING] Linking %s instead of embedding it: %v", filename, err)
+					plan.filenames[filename] = ld.relink(base, dir, filename)
+					continue
+				}
+			}
+			name := uniqueMediaName(mediaFolder, filepath.Base(filename), func(name string) bool {
+				_, used := plan.embedded[name]
+				return !used
+			})
+			plan.embedded[name] = data
+			plan.filenames[filename] = name
+			continue
+		}
+
+		if !embedded {
+			plan.filenames[filename] = ld.relink(base, dir, filename)
+			continue
+		}
+		path, err := writeMediaFile(dir, mediaFolder, filepath.Base(filename), data)
+		if err != nil {
+			return nil, err
+		}
+		plan.filenames[filename] = linkTo(dir, path)
+	}
+	return plan, nil
+}
+
+// relink returns the file name a file linked as filename relative to base
+// is linked by from dir
+func (ld *LessonData) relink(base, dir, filename string) string {
+	if base == "" || filepath.IsAbs(filename) {
+		return filename
+	}
+	return linkTo(dir, resolveLink(base, filename))
+}
+
+// uniqueMediaName returns the name in folder to store a file called base
+// under: base itself when free says so, else base with a number added
+func uniqueMediaName(folder, base string, free func(name string) bool) string {
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	name := folder + "/" + base
+	for n := 2; !free(name); n++ {
+		name = fmt.Sprintf("%s/%s-%d%s", folder, stem, n, ext)
+	}
+	return name
+}
+
+// writeMediaFile writes a media file called base into folder in dir,
+// reusing a file that already holds the same content, and returns its path
+func writeMediaFile(dir, folder, base string, data []byte) (string, error) {
+	if err := os.MkdirAll(filepath.Join(dir, folder), 0755); err != nil {
+		return "", err
+	}
+	var path string
+	uniqueMediaName(folder, base, func(name string) bool {
+		path = filepath.Join(dir, filepath.FromSlash(name))
+		existing, err := os.ReadFile(path)
+		return errors.Is(err, fs.ErrNotExist) || (err == nil && bytes.Equal(existing, data))
+	})
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	return path, WriteFileAtomically(path, data, 0644)
+}
+
+// MediaReport tells what RelinkMedia or CollectMedia did with the linked
+// media of a lesson
+type MediaReport struct {
+	// Relinked are the new file names of media files, by their old one
+	Relinked map[string]string
+	// Missing are the file names of media files that were not found
+	Missing []string
+}
+
+// String returns the report as text for the user
+func (r *MediaReport) String() string {
+	var b strings.Builder
+	olds := make([]string, 0, len(r.Relinked))
+	for old := range r.Relinked {
+		olds = append(olds, old)
+	}
+	sort.Strings(olds)
+	for _, old := range olds {
+		fmt.Fprintf(&b, "  relinked  %s -> %s\n", old, r.Relinked[old])
+	}
+	for _, name := range r.Missing {
+		fmt.Fprintf(&b, "  missing   %s\n", name)
+	}
+	if b.Len() == 0 {
+		return "All media files were found.\n"
+	}
+	return b.String()
+}
+
+// linkedMedia returns the file names of the local media the lesson links
+// to, each once, in the order of the items
+func (ld *LessonData) linkedMedia() []string {
+	var filenames []string
+	seen := make(map[string]bool)
+	for _, item := range ld.List.Items {
+		filename, remote, ok := item.GetMediaInfo()
+		if !ok || remote || filename == "" || seen[filename] {
+			continue
+		}
+		seen[filename] = true
+		if _, embedded := ld.EmbeddedMedia(filename); !embedded {
+			filenames = append(filenames, filename)
+		}
+	}
+	return filenames
+}
+
+// renameMedia makes every item linking to old link to filename instead
+func (ld *LessonData) renameMedia(old, filename string) {
+	for i := range ld.List.Items {
+		if item := &ld.List.Items[i]; item.Filename != nil && *item.Filename == old {
+			renamed := filename
+			item.Filename = &renamed
+		}
+	}
+	ld.Changed = true
+}
+
+// RelinkMedia repairs the broken links of the lesson at lessonPath by
+// searching searchDirs and their subfolders for the missing media files.
+// Of several files with the name of a missing one, the one whose folders
+// match most of its old path is chosen.
+func (ld *LessonData) RelinkMedia(lessonPath string, searchDirs []string) *MediaReport {
+	report := &MediaReport{Relinked: make(map[string]string)}
+	base := ld.mediaBase(lessonPath)
+	var candidates map[string][]string
+	for _, filename := range ld.linkedMedia() {
+		if _, err := os.Stat(resolveLink(base, filename)); err == nil {
+			continue
+		}
+		if candidates == nil {
+			candidates = findMediaFiles(searchDirs)
+		}
+		found := bestMediaMatch(filename, candidates[filepath.Base(filepath.FromSlash(filename))])
+		if found == "" {
+			report.Missing = append(report.Missing, filename)
+			continue
+		}
+		relinked := linkTo(base, found)
+		ld.renameMedia(filename, relinked)
+		report.Relinked[filename] = relinked
+	}
+	return report
+}
+
+// findMediaFiles returns the paths of the files in dirs and their
+// subfolders by file name
+func findMediaFiles(dirs []string) map[string][]string {
+	files := make(map[string][]string)
+	for _, dir := range dirs {
+		filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				// Unreadable folders are skipped
+				return nil
+			}
+			if entry.Type().IsRegular() {
+				if abs, err := filepath.Abs(path); err == nil {
+					path = abs
+				}
+				files[entry.Name()] = append(files[entry.Name()], path)
+			}
+			return nil
+		})
+	}
+	return files
+}
+
+// bestMediaMatch returns the path of paths that ends in most of the
+// folders of filename, the first of equally good ones
+func bestMediaMatch(filename string, paths []string) string {
+	want := strings.Split(filepath.ToSlash(filename), "/")
+	best, bestScore := "", -1
+	for _, path := range paths {
+		have := strings.Split(filepath.ToSlash(path), "/")
+		score := 0
+		for score < len(want) && score < len(have) && want[len(want)-1-score] == have[len(have)-1-score] {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = path, score
+		}
+	}
+	return best
+}
+
+// CollectMedia copies the media files the lesson at lessonPath links to
+// into the media folder next to it and links them there, so the lesson
+// folder can be moved or shared as a whole. Links are made relative to the
+// lesson from then on.
+func (ld *LessonData) CollectMedia(lessonPath string) (*MediaReport, error) {
+	if lessonPath == "" {
+		return nil, errors.New("save the lesson before collecting its media")
+	}
+	dir, err := filepath.Abs(filepath.Dir(lessonPath))
+	if err != nil {
+		return nil, err
+	}
+	report := &MediaReport{Relinked: make(map[string]string)}
+	base := ld.mediaBase(lessonPath)
+	mediaDir := filepath.Join(dir, mediaFolder) + string(filepath.Separator)
+	for _, filename := range ld.linkedMedia() {
+		path := resolveLink(base, filename)
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		var relinked string
+		if strings.HasPrefix(path, mediaDir) {
+			relinked = linkTo(dir, path)
+		} else if data, err := os.ReadFile(path); err != nil {
+			report.Missing = append(report.Missing, filename)
+			// Still linked to where it was, from the new folder
+			if relinked = ld.relink(base, dir, filename); relinked != filename {
+				ld.renameMedia(filename, relinked)
+			}
+			continue
+		} else {
+			copied, err := writeMediaFile(dir, mediaFolder, filepath.Base(path), data)
+			if err != nil {
+				return report, err
+			}
+			relinked = linkTo(dir, copied)
+		}
+		if relinked != filename {
+			ld.renameMedia(filename, relinked)
+			report.Relinked[filename] = relinked
+		}
+	}
+	ld.mediaDir = dir
+	return report, nil
+}
@@ -0,0 +1,113 @@
+package lesson
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LaPingvino/recuerdo/internal/paths"
+)
+
+// mediaLesson returns a media lesson linking to filename from dir
+func mediaLesson(dir, filename string) *LessonData {
+	lessonData := NewLessonData()
+	remote := false
+	lessonData.List.Items = append(lessonData.List.Items, WordItem{ID: 0, Name: "hello", Questions: []string{"hello"}, Answers: []string{"hola"}, Filename: &filename, Remote: &remote})
+	lessonData.SetMediaDir(dir)
+	return lessonData
+}
+
+// writeTestFile writes data to path, making its folder
+func writeTestFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMediaStorage(t *testing.T) {
+	t.Setenv(paths.EnvCacheDir, t.TempDir())
+	root := t.TempDir()
+	sound := []byte("OggS fake sound")
+	writeTestFile(t, filepath.Join(root, "course", "sounds", "hello.ogg"), sound)
+	lessonData := mediaLesson(filepath.Join(root, "course"), "sounds/hello.ogg")
+
+	// Saved elsewhere, the link is made relative to the new place
+	linked := filepath.Join(root, "lessons", "linked.otmd")
+	os.MkdirAll(filepath.Dir(linked), 0755)
+	if err := NewFileSaverWithOptions(SaveOptions{MediaStorage: MediaLink}).SaveFile(lessonData, linked); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := NewFileLoader().LoadFile(linked)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filename := *loaded.List.Items[0].Filename; filename != "../course/sounds/hello.ogg" {
+		t.Errorf("linked as %q", filename)
+	}
+	if path, _ := loaded.ResolveMedia(linked, *loaded.List.Items[0].Filename, false); path != filepath.Join(root, "course", "sounds", "hello.ogg") {
+		t.Errorf("linked media resolved to %s", path)
+	}
+
+	// Embedded, it travels with the lesson
+	embedded := filepath.Join(root, "lessons", "embedded.otmd")
+	if err := NewFileSaverWithOptions(SaveOptions{MediaStorage: MediaEmbed}).SaveFile(lessonData, embedded); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, err = NewFileLoader().LoadFile(embedded); err != nil {
+		t.Fatal(err)
+	}
+	filename := *loaded.List.Items[0].Filename
+	if data, ok := loaded.EmbeddedMedia(filename); filename != "media/hello.ogg" || !ok || !bytes.Equal(data, sound) {
+		t.Fatalf("embedded as %q: %v", filename, ok)
+	}
+	path, err := loaded.ResolveMedia(embedded, filename, false)
+	if data, _ := os.ReadFile(path); err != nil || !bytes.Equal(data, sound) {
+		t.Errorf("embedded media resolved to %s: %v", path, err)
+	}
+
+	// Linked again, embedded media is written next to the lesson
+	unpacked := filepath.Join(root, "shared", "unpacked.otmd")
+	os.MkdirAll(filepath.Dir(unpacked), 0755)
+	if err := NewFileSaverWithOptions(SaveOptions{MediaStorage: MediaLink}).SaveFile(loaded, unpacked); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "shared", "media", "hello.ogg")); err != nil || !bytes.Equal(data, sound) {
+		t.Errorf("embedded media not written next to the lesson: %v", err)
+	}
+}
+
+func TestRelinkMedia(t *testing.T) {
+	root := t.TempDir()
+	sound := []byte("OggS fake sound")
+	writeTestFile(t, filepath.Join(root, "moved", "unit1", "sounds", "hello.ogg"), sound)
+	writeTestFile(t, filepath.Join(root, "moved", "unit2", "hello.ogg"), []byte("another"))
+	lessonPath := filepath.Join(root, "lessons", "unit1.otmd")
+	lessonData := mediaLesson(filepath.Dir(lessonPath), "../course/unit1/sounds/hello.ogg")
+
+	report := lessonData.RelinkMedia(lessonPath, []string{filepath.Join(root, "moved")})
+	want := "../moved/unit1/sounds/hello.ogg"
+	if filename := *lessonData.List.Items[0].Filename; filename != want || len(report.Missing) != 0 || !lessonData.Changed {
+		t.Fatalf("relinked to %q:\n%s", filename, report)
+	}
+
+	report, err := lessonData.CollectMedia(lessonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filename := *lessonData.List.Items[0].Filename; filename != "media/hello.ogg" || report.Relinked[want] != filename {
+		t.Errorf("collected as %q:\n%s", filename, report)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "lessons", "media", "hello.ogg")); err != nil || !bytes.Equal(data, sound) {
+		t.Errorf("media not copied next to the lesson: %v", err)
+	}
+
+	os.Remove(filepath.Join(root, "lessons", "media", "hello.ogg"))
+	if report := lessonData.RelinkMedia(lessonPath, nil); len(report.Missing) != 1 {
+		t.Errorf("missing media not reported:\n%s", report)
+	}
+}
@@ -0,0 +1,143 @@
+package lesson
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// cefrLevels are the levels of the Common European Framework of Reference
+// for Languages, from beginner to mastery
+var cefrLevels = []string{"A1", "A2", "B1", "B2", "C1", "C2"}
+
+// CEFRLevels returns the CEFR levels a lesson can be marked with
+func CEFRLevels() []string {
+	return append([]string(nil), cefrLevels...)
+}
+
+// ParseCEFRLevel returns the CEFR level in text, such as "b1" for B1. An
+// empty text means the lesson has no level.
+func ParseCEFRLevel(text string) (string, error) {
+	text = strings.ToUpper(strings.TrimSpace(text))
+	if text == "" {
+		return "", nil
+	}
+	for _, level := range cefrLevels {
+		if text == level {
+			return level, nil
+		}
+	}
+	return "", fmt.Errorf("unknown CEFR level: %s", text)
+}
+
+// ParseTags splits a comma separated list of tags. Tags differing only in
+// case are kept once, as first written.
+func ParseTags(text string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, tag := range strings.Split(text, ",") {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// HasTag reports whether the list is tagged with tag, ignoring case
+func (wl *WordList) HasTag(tag string) bool {
+	for _, t := range wl.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasTag reports whether the item is tagged with tag, ignoring case
+func (wi *WordItem) HasTag(tag string) bool {
+	for _, t := range wi.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// ItemTags returns the tags used by the items of the list, sorted and
+// each once, ignoring case
+func (wl *WordList) ItemTags() []string {
+	var all []string
+	for _, item := range wl.Items {
+		all = append(all, item.Tags...)
+	}
+	tags := ParseTags(strings.Join(all, ","))
+	sort.Slice(tags, func(i, j int) bool {
+		return strings.ToLower(tags[i]) < strings.ToLower(tags[j])
+	})
+	return tags
+}
+
+// MetadataText returns the tags, author, description, license and level of
+// the list as one text, for searching
+func (wl *WordList) MetadataText() string {
+	parts := append([]string(nil), wl.Tags...)
+	for _, field := range []string{wl.Author, wl.Description, wl.License, wl.Level} {
+		if field != "" {
+			parts = append(parts, field)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// readMetadata reads the tags, author, description, license, level, UUID
+// and sync scope of a list from the list object of an OpenTeaching list.json
+func (wl *WordList) readMetadata(listMap map[string]interface{}) {
+	if tags, ok := listMap["tags"].([]interface{}); ok {
+		wl.Tags = nil
+		for _, tag := range tags {
+			if text, ok := tag.(string); ok && text != "" {
+				wl.Tags = append(wl.Tags, text)
+			}
+		}
+	}
+	for key, field := range map[string]*string{
+		"author":      &wl.Author,
+		"description": &wl.Description,
+		"license":     &wl.License,
+		"uuid":        &wl.UUID,
+		"sync":        &wl.Sync,
+	} {
+		if text, ok := listMap[key].(string); ok {
+			*field = text
+		}
+	}
+	if text, ok := listMap["level"].(string); ok {
+		if level, err := ParseCEFRLevel(text); err == nil {
+			wl.Level = level
+		}
+	}
+}
+
+// writeMetadata adds the tags, author, description, license, level, UUID
+// and sync scope of a list, when set, to the list object of an OpenTeaching list.json
+func (wl *WordList) writeMetadata(listMap map[string]interface{}) {
+	if len(wl.Tags) > 0 {
+		listMap["tags"] = wl.Tags
+	}
+	for key, field := range map[string]string{
+		"author":      wl.Author,
+		"description": wl.Description,
+		"license":     wl.License,
+		"level":       wl.Level,
+		"uuid":        wl.UUID,
+		"sync":        wl.Sync,
+	} {
+		if field != "" {
+			listMap[key] = field
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package lesson
+
+import (
+	"image"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseMetadata(t *testing.T) {
+	if level, err := ParseCEFRLevel(" b1 "); err != nil || level != "B1" {
+		t.Errorf("ParseCEFRLevel(b1) = %q, %v", level, err)
+	}
+	if level, err := ParseCEFRLevel(""); err != nil || level != "" {
+		t.Errorf("ParseCEFRLevel(\"\") = %q, %v", level, err)
+	}
+	if _, err := ParseCEFRLevel("D1"); err == nil {
+		t.Error("parsed an unknown level")
+	}
+
+	tags := ParseTags("verbs, Irregular,, verbs ,irregular, week 3")
+	if want := []string{"verbs", "Irregular", "week 3"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("ParseTags = %q, want %q", tags, want)
+	}
+	list := WordList{Tags: tags}
+	if !list.HasTag("IRREGULAR") || list.HasTag("nouns") {
+		t.Error("HasTag does not ignore case or matches a missing tag")
+	}
+}
+
+func TestMetadataRoundTrip(t *testing.T) {
+	x, y := 10, 20
+	items := map[string]WordItem{
+		".json": {ID: 0, Questions: []string{"house"}, Answers: []string{"maison"}},
+		".ottp": {ID: 0, Name: "Paris", Questions: []string{"Paris"}, Answers: []string{"Paris"}, X: &x, Y: &y},
+		".otmd": {ID: 0, Name: "cat", Questions: []string{"cat"}, Answers: []string{"chat"}},
+		".otio": NewOcclusionItem(0, image.Rect(1, 2, 30, 40), []string{"nose"}),
+	}
+	want := WordList{
+		Tags:        []string{"official", "week 3"},
+		Author:      "Ms. Janssen",
+		Description: "Words for the third week",
+		License:     "CC BY-SA 4.0",
+		Level:       "A2",
+		UUID:        NewUUID(),
+		Sync:        "desktop, tablet",
+	}
+
+	for ext, item := range items {
+		lessonData := NewLessonData()
+		lessonData.List.Title = "Week 3"
+		lessonData.List.Items = []WordItem{item}
+		lessonData.List.Tags = want.Tags
+		lessonData.List.Author = want.Author
+		lessonData.List.Description = want.Description
+		lessonData.List.License = want.License
+		lessonData.List.Level = want.Level
+		lessonData.List.UUID = want.UUID
+		lessonData.List.Sync = want.Sync
+
+		filePath := filepath.Join(t.TempDir(), "week3"+ext)
+		if err := NewFileSaver().SaveFile(lessonData, filePath); err != nil {
+			t.Fatalf("%s: %v", ext, err)
+		}
+		loaded, err := NewFileLoader().LoadFile(filePath)
+		if err != nil {
+			t.Fatalf("%s: %v", ext, err)
+		}
+		got := loaded.List
+		if !reflect.DeepEqual(got.Tags, want.Tags) || got.Author != want.Author || got.Description != want.Description ||
+			got.License != want.License || got.Level != want.Level || got.UUID != want.UUID || got.Sync != want.Sync {
+			t.Errorf("%s lost metadata: %q %q %q %q %q %q %q", ext, got.Tags, got.Author, got.Description, got.License, got.Level, got.UUID, got.Sync)
+		}
+	}
+}
+
+func TestItemTags(t *testing.T) {
+	list := WordList{Items: []WordItem{
+		{ID: 0, Questions: []string{"to be"}, Answers: []string{"zijn"}, Tags: []string{"irregular-verbs", "chapter-3"}},
+		{ID: 1, Questions: []string{"to walk"}, Answers: []string{"lopen"}, Tags: []string{"Chapter-3"}},
+		{ID: 2, Questions: []string{"house"}, Answers: []string{"huis"}},
+		{ID: 3, Questions: []string{"to go"}, Answers: []string{"gaan"}, Tags: []string{"irregular-verbs"}, Difficulty: DifficultySuspended},
+	}}
+
+	if got, want := list.ItemTags(), []string{"chapter-3", "irregular-verbs"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ItemTags = %q, want %q", got, want)
+	}
+	if got, want := TaggedItems(&list, AllItems(&list), []string{"CHAPTER-3"}), []int{0, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TaggedItems(chapter-3) = %v, want %v", got, want)
+	}
+	if got, want := TaggedItems(&list, AllItems(&list), []string{"irregular-verbs"}), []int{0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TaggedItems(irregular-verbs) = %v, want %v, without the suspended word", got, want)
+	}
+
+	x, y := 10, 20
+	items := map[string]WordItem{
+		".json": list.Items[0],
+		".ottp": {ID: 0, Name: "Paris", Questions: []string{"Paris"}, Answers: []string{"Paris"}, X: &x, Y: &y, Tags: list.Items[0].Tags},
+		".otmd": {ID: 0, Name: "cat", Questions: []string{"cat"}, Answers: []string{"chat"}, Tags: list.Items[0].Tags},
+		".otio": NewOcclusionItem(0, image.Rect(1, 2, 30, 40), []string{"nose"}),
+	}
+	for ext, item := range items {
+		if ext == ".otio" {
+			item.Tags = list.Items[0].Tags
+		}
+		lessonData := NewLessonData()
+		lessonData.List.Items = []WordItem{item}
+		filePath := filepath.Join(t.TempDir(), "tags"+ext)
+		if err := NewFileSaver().SaveFile(lessonData, filePath); err != nil {
+			t.Fatalf("%s: %v", ext, err)
+		}
+		loaded, err := NewFileLoader().LoadFile(filePath)
+		if err != nil {
+			t.Fatalf("%s: %v", ext, err)
+		}
+		if got := loaded.List.Items[0].Tags; !reflect.DeepEqual(got, item.Tags) {
+			t.Errorf("%s: tags = %q, want %q", ext, got, item.Tags)
+		}
+	}
+}
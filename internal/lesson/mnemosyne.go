@@ -0,0 +1,203 @@
+package lesson
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// Mnemosyne 2.x log entry types used in cards.xml
+const (
+	mnemosyneAddedTag  = 10
+	mnemosyneAddedFact = 16
+	mnemosyneAddedCard = 6
+)
+
+// mnemosyneID returns a stable identifier for an item, so exporting the same
+// lesson twice lets Mnemosyne recognise cards it already imported
+func mnemosyneID(title string, parts ...interface{}) string {
+	sum := sha1.Sum([]byte(fmt.Sprint(append([]interface{}{title}, parts...)...)))
+	return hex.EncodeToString(sum[:])[:22]
+}
+
+// mnemosyneSides returns the front and back of a card in Mnemosyne's HTML
+// flavoured text. Comments go below the answer since the standard
+// front-to-back card type has no separate field for them.
+func mnemosyneSides(item WordItem) (string, string) {
+	toHTML := func(s string) string {
+		return strings.ReplaceAll(strings.TrimSpace(s), "\n", "<br>")
+	}
+
+	front := toHTML(strings.Join(item.Questions, ", "))
+	back := toHTML(strings.Join(item.Answers, ", "))
+	if comment := toHTML(item.Comment); comment != "" {
+		back += "<br><br>" + comment
+	}
+	return front, back
+}
+
+// mnemosyneTag returns the category/tag name used for the lesson
+func mnemosyneTag(lessonData *LessonData) string {
+	if title := strings.TrimSpace(lessonData.List.Title); title != "" {
+		return title
+	}
+	return "Recuerdo"
+}
+
+// saveMnemosyneMemFile saves lesson data as a Mnemosyne 1.x XML export (.mem)
+func (fs *FileSaver) saveMnemosyneMemFile(lessonData *LessonData, filePath string) error {
+	log.Printf("[ACTION] FileSaver.saveMnemosyneMemFile() - saving Mnemosyne 1.x export")
+
+	type memCategory struct {
+		Active string `xml:"active,attr"`
+		Name   string `xml:"name"`
+	}
+	type memItem struct {
+		ID       string `xml:"id,attr"`
+		Unseen   string `xml:"u,attr"`
+		Grade    string `xml:"gr,attr"`
+		Easiness string `xml:"e,attr"`
+		Category string `xml:"cat"`
+		Question string `xml:"Q"`
+		Answer   string `xml:"A"`
+	}
+	type memRoot struct {
+		XMLName     xml.Name      `xml:"mnemosyne"`
+		CoreVersion string        `xml:"core_version,attr"`
+		TimeOfStart int64         `xml:"time_of_start,attr"`
+		Categories  []memCategory `xml:"category"`
+		Items       []memItem     `xml:"item"`
+	}
+
+	tag := mnemosyneTag(lessonData)
+	root := memRoot{
+		CoreVersion: "1",
+		TimeOfStart: time.Now().Unix(),
+		Categories:  []memCategory{{Active: "1", Name: tag}},
+	}
+
+	for _, item := range lessonData.List.Items {
+		front, back := mnemosyneSides(item)
+		if front == "" || back == "" {
+			continue
+		}
+		root.Items = append(root.Items, memItem{
+			ID:       mnemosyneID(tag, item.ID)[:8],
+			Unseen:   "1",
+			Grade:    "0",
+			Easiness: "2.5",
+			Category: tag,
+			Question: front,
+			Answer:   back,
+		})
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		log.Printf("[ERROR] Failed to create Mnemosyne file: %v", err)
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(file)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(root); err != nil {
+		log.Printf("[ERROR] Failed to write Mnemosyne XML: %v", err)
+		return err
+	}
+
+	log.Printf("[SUCCESS] FileSaver.saveMnemosyneMemFile() - saved %d items to Mnemosyne 1.x export", len(root.Items))
+	return nil
+}
+
+// saveMnemosyneCardsFile saves lesson data as a Mnemosyne 2.x cards file
+// (.cards): a ZIP archive with the cards in openSM2sync XML plus METADATA
+func (fs *FileSaver) saveMnemosyneCardsFile(lessonData *LessonData, filePath string) error {
+	log.Printf("[ACTION] FileSaver.saveMnemosyneCardsFile() - saving Mnemosyne 2.x cards file")
+
+	tag := mnemosyneTag(lessonData)
+	tagID := mnemosyneID(tag, "tag")
+
+	var entries bytes.Buffer
+	count := 1
+	writeElement := func(name, value string) {
+		entries.WriteString("<" + name + ">")
+		xml.EscapeText(&entries, []byte(value))
+		entries.WriteString("</" + name + ">")
+	}
+
+	fmt.Fprintf(&entries, "<log type=\"%d\" o_id=\"%s\">", mnemosyneAddedTag, tagID)
+	writeElement("name", tag)
+	entries.WriteString("</log>\n")
+
+	cards := 0
+	for _, item := range lessonData.List.Items {
+		front, back := mnemosyneSides(item)
+		if front == "" || back == "" {
+			continue
+		}
+		factID := mnemosyneID(tag, "fact", item.ID)
+		cardID := mnemosyneID(tag, "card", item.ID)
+
+		fmt.Fprintf(&entries, "<log type=\"%d\" o_id=\"%s\">", mnemosyneAddedFact, factID)
+		writeElement("f", front)
+		writeElement("b", back)
+		entries.WriteString("</log>\n")
+
+		// A new, never reviewed card (grade -1) of the front-to-back type
+		fmt.Fprintf(&entries, "<log type=\"%d\" o_id=\"%s\" card_t=\"1\" fact=\"%s\" fact_v=\"1.1\" "+
+			"gr=\"-1\" e=\"2.5\" ac_rp=\"0\" rt_rp=\"0\" lps=\"0\" ac_rp_l=\"0\" rt_rp_l=\"0\" "+
+			"l_rp=\"-1\" n_rp=\"-1\" tags=\"%s\"/>\n",
+			mnemosyneAddedCard, cardID, factID, tagID)
+		count += 2
+		cards++
+	}
+
+	zipFile, err := os.Create(filePath)
+	if err != nil {
+		log.Printf("[ERROR] Failed to create Mnemosyne cards file: %v", err)
+		return err
+	}
+	defer zipFile.Close()
+
+	zipWriter := zip.NewWriter(zipFile)
+
+	cardsWriter, err := zipWriter.Create("cards.xml")
+	if err != nil {
+		log.Printf("[ERROR] Failed to create cards.xml in ZIP: %v", err)
+		return err
+	}
+	fmt.Fprintf(cardsWriter, "<openSM2sync number_of_entries=\"%d\">\n", count)
+	if _, err := cardsWriter.Write(entries.Bytes()); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprint(cardsWriter, "</openSM2sync>\n"); err != nil {
+		return err
+	}
+
+	metadataWriter, err := zipWriter.Create("METADATA")
+	if err != nil {
+		log.Printf("[ERROR] Failed to create METADATA in ZIP: %v", err)
+		return err
+	}
+	fmt.Fprintf(metadataWriter, "tags:%s\nnotes:Exported from Recuerdo\ndate:%s\n",
+		tag, time.Now().Format("Mon Jan 2 2006"))
+
+	if err := zipWriter.Close(); err != nil {
+		log.Printf("[ERROR] Failed to finish Mnemosyne cards file: %v", err)
+		return err
+	}
+
+	log.Printf("[SUCCESS] FileSaver.saveMnemosyneCardsFile() - saved %d cards to Mnemosyne cards file", cards)
+	return nil
+}
@@ -0,0 +1,115 @@
+package lesson
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func mnemosyneTestLesson() *LessonData {
+	lessonData := NewLessonData()
+	lessonData.List.Title = "Animals & pets"
+	lessonData.List.AddWordItem([]string{"cat"}, []string{"gato"}, "")
+	lessonData.List.AddWordItem([]string{"dog"}, []string{"perro", "can"}, "el can is formal")
+	return lessonData
+}
+
+func TestFileSaver_SaveMnemosyneMem(t *testing.T) {
+	memFile := filepath.Join(t.TempDir(), "animals.mem")
+	if err := NewFileSaver().SaveFile(mnemosyneTestLesson(), memFile); err != nil {
+		t.Fatalf("Failed to save Mnemosyne 1.x file: %v", err)
+	}
+
+	content, err := os.ReadFile(memFile)
+	if err != nil {
+		t.Fatalf("Failed to read saved file: %v", err)
+	}
+
+	var root struct {
+		Category string `xml:"category>name"`
+		Items    []struct {
+			ID  string `xml:"id,attr"`
+			Cat string `xml:"cat"`
+			Q   string `xml:"Q"`
+			A   string `xml:"A"`
+		} `xml:"item"`
+	}
+	if err := xml.Unmarshal(content, &root); err != nil {
+		t.Fatalf("Saved file is not valid XML: %v", err)
+	}
+
+	if root.Category != "Animals & pets" || len(root.Items) != 2 {
+		t.Fatalf("Unexpected Mnemosyne export: %+v", root)
+	}
+	if root.Items[1].Q != "dog" || root.Items[1].A != "perro, can<br><br>el can is formal" {
+		t.Errorf("Unexpected second item: %+v", root.Items[1])
+	}
+	if root.Items[0].ID == root.Items[1].ID || len(root.Items[0].ID) != 8 {
+		t.Errorf("Expected distinct 8 character ids, got %q and %q", root.Items[0].ID, root.Items[1].ID)
+	}
+}
+
+func TestFileSaver_SaveMnemosyneCards(t *testing.T) {
+	cardsFile := filepath.Join(t.TempDir(), "animals.cards")
+	if err := NewFileSaver().SaveFile(mnemosyneTestLesson(), cardsFile); err != nil {
+		t.Fatalf("Failed to save Mnemosyne 2.x file: %v", err)
+	}
+
+	reader, err := zip.OpenReader(cardsFile)
+	if err != nil {
+		t.Fatalf("Cards file is not a ZIP archive: %v", err)
+	}
+	defer reader.Close()
+
+	var cardsXML []byte
+	hasMetadata := false
+	for _, file := range reader.File {
+		switch file.Name {
+		case "cards.xml":
+			rc, err := file.Open()
+			if err != nil {
+				t.Fatalf("Failed to open cards.xml: %v", err)
+			}
+			cardsXML, _ = io.ReadAll(rc)
+			rc.Close()
+		case "METADATA":
+			hasMetadata = true
+		}
+	}
+	if cardsXML == nil || !hasMetadata {
+		t.Fatalf("Expected cards.xml and METADATA in the archive")
+	}
+
+	var root struct {
+		Entries string `xml:"number_of_entries,attr"`
+		Logs    []struct {
+			Type  int    `xml:"type,attr"`
+			ID    string `xml:"o_id,attr"`
+			Fact  string `xml:"fact,attr"`
+			Tags  string `xml:"tags,attr"`
+			Name  string `xml:"name"`
+			Front string `xml:"f"`
+			Back  string `xml:"b"`
+		} `xml:"log"`
+	}
+	if err := xml.Unmarshal(cardsXML, &root); err != nil {
+		t.Fatalf("cards.xml is not valid XML: %v", err)
+	}
+
+	if root.Entries != "5" || len(root.Logs) != 5 {
+		t.Fatalf("Expected a tag, two facts and two cards, got %+v", root)
+	}
+	tag, fact, card := root.Logs[0], root.Logs[1], root.Logs[2]
+	if tag.Type != mnemosyneAddedTag || tag.Name != "Animals & pets" {
+		t.Errorf("Unexpected tag entry: %+v", tag)
+	}
+	if fact.Type != mnemosyneAddedFact || fact.Front != "cat" || fact.Back != "gato" {
+		t.Errorf("Unexpected fact entry: %+v", fact)
+	}
+	if card.Type != mnemosyneAddedCard || card.Fact != fact.ID || card.Tags != tag.ID {
+		t.Errorf("Card does not refer to its fact and tag: %+v", card)
+	}
+}
@@ -0,0 +1,70 @@
+package lesson
+
+// MultipleChoiceResourceKey is the LessonData.Resources key holding the
+// lesson's multiple choice settings
+const MultipleChoiceResourceKey = "multipleChoice"
+
+// Limits on the number of choices shown, the right answer included
+const (
+	MinChoices = 2
+	MaxChoices = 8
+)
+
+// MultipleChoiceSettings are the per-lesson options of the multiple choice
+// teach type
+type MultipleChoiceSettings struct {
+	// Enabled asks questions with choices instead of a typed answer
+	Enabled bool `json:"enabled"`
+	// Choices is the number of choices shown, the right answer included
+	Choices int `json:"choices"`
+}
+
+// DefaultMultipleChoiceSettings returns the settings of lessons that have
+// none stored
+func DefaultMultipleChoiceSettings() MultipleChoiceSettings {
+	return MultipleChoiceSettings{Choices: DefaultDistractorCount + 1}
+}
+
+// MultipleChoiceSettings returns the lesson's multiple choice settings.
+// They are stored as a plain map, so they look the same after a JSON round
+// trip.
+func (ld *LessonData) MultipleChoiceSettings() MultipleChoiceSettings {
+	settings := DefaultMultipleChoiceSettings()
+	stored, ok := ld.Resources[MultipleChoiceResourceKey].(map[string]interface{})
+	if !ok {
+		return settings
+	}
+	if enabled, ok := stored["enabled"].(bool); ok {
+		settings.Enabled = enabled
+	}
+	switch choices := stored["choices"].(type) {
+	case int:
+		settings.Choices = choices
+	case float64:
+		settings.Choices = int(choices)
+	}
+	settings.Choices = clampChoices(settings.Choices)
+	return settings
+}
+
+// SetMultipleChoiceSettings stores the lesson's multiple choice settings
+func (ld *LessonData) SetMultipleChoiceSettings(settings MultipleChoiceSettings) {
+	if ld.Resources == nil {
+		ld.Resources = make(map[string]interface{})
+	}
+	ld.Resources[MultipleChoiceResourceKey] = map[string]interface{}{
+		"enabled": settings.Enabled,
+		"choices": clampChoices(settings.Choices),
+	}
+	ld.Changed = true
+}
+
+// DistractorGeneratorFor creates a generator picking as many distractors as
+// the lesson's settings leave room for next to the right answer
+func DistractorGeneratorFor(lessonData *LessonData) *DistractorGenerator {
+	return NewDistractorGenerator(lessonData.MultipleChoiceSettings().Choices - 1)
+}
+
+func clampChoices(choices int) int {
+	return max(MinChoices, min(MaxChoices, choices))
+}
@@ -0,0 +1,288 @@
+package lesson
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cloud drives are reached with OAuth 2.0 as a native app: the user signs
+// in in their browser, which sends them back to a port on this machine
+// with a code, swapped for tokens with PKCE. No secret has to be shipped
+// with Recuerdo; schools register an app with their own tenant and enter
+// its client ID.
+
+// OAuthConfig describes the app signing in to a provider
+type OAuthConfig struct {
+	AuthURL  string
+	TokenURL string
+	ClientID string
+	// ClientSecret is only needed by providers that want one even for
+	// native apps, such as Google
+	ClientSecret string
+	Scopes       []string
+	// Params are added to the authorization URL
+	Params map[string]string
+}
+
+// OAuthToken is what a provider gave for signing in. It is kept as JSON
+// in the settings, so the user stays signed in.
+type OAuthToken struct {
+	AccessToken  string    `json:"accessToken"`
+	RefreshToken string    `json:"refreshToken,omitempty"`
+	Expiry       time.Time `json:"expiry,omitempty"`
+}
+
+// ErrSignInNeeded is returned when the provider no longer accepts the
+// token, and the user has to sign in again
+var ErrSignInNeeded = errors.New("sign in to the cloud drive again")
+
+// OAuthFlow is a sign-in waiting for the browser to come back
+type OAuthFlow struct {
+	// URL is the page the user signs in on
+	URL      string
+	config   OAuthConfig
+	redirect string
+	verifier string
+	listener net.Listener
+	result   chan oauthResult
+}
+
+type oauthResult struct {
+	code string
+	err  error
+}
+
+// StartOAuth listens for the browser to come back and returns the flow,
+// whose URL is to be opened in the browser
+func StartOAuth(config OAuthConfig) (*OAuthFlow, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	flow := &OAuthFlow{
+		config:   config,
+		redirect: fmt.Sprintf("http://127.0.0.1:%d/", listener.Addr().(*net.TCPAddr).Port),
+		verifier: randomString(),
+		listener: listener,
+		result:   make(chan oauthResult, 1),
+	}
+	state := randomString()
+	challenge := sha256.Sum256([]byte(flow.verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {config.ClientID},
+		"redirect_uri":          {flow.redirect},
+		"scope":                 {strings.Join(config.Scopes, " ")},
+		"state":                 {state},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	for key, value := range config.Params {
+		query.Set(key, value)
+	}
+	flow.URL = config.AuthURL + "?" + query.Encode()
+
+	var once sync.Once
+	go http.Serve(listener, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/" || query.Get("state") != state {
+			http.NotFound(w, r)
+			return
+		}
+		result := oauthResult{code: query.Get("code")}
+		if problem := query.Get("error"); problem != "" || result.code == "" {
+			result.err = fmt.Errorf("signing in failed: %s %s", problem, query.Get("error_description"))
+			fmt.Fprintln(w, "Signing in failed. You can close this page and try again in Recuerdo.")
+		} else {
+			fmt.Fprintln(w, "You are signed in. You can close this page and go back to Recuerdo.")
+		}
+		once.Do(func() { flow.result <- result })
+	}))
+	return flow, nil
+}
+
+// Wait waits for the user to sign in and returns the tokens. It gives up
+// when ctx is done.
+func (f *OAuthFlow) Wait(ctx context.Context, client *http.Client) (*OAuthToken, error) {
+	defer f.listener.Close()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-f.result:
+		if result.err != nil {
+			return nil, result.err
+		}
+		return f.config.requestToken(ctx, client, url.Values{
+			"grant_type":    {"authorization_code"},
+			"code":          {result.code},
+			"redirect_uri":  {f.redirect},
+			"code_verifier": {f.verifier},
+		})
+	}
+}
+
+// Refresh swaps the refresh token of token for a new access token
+func (c OAuthConfig) Refresh(ctx context.Context, client *http.Client, token OAuthToken) (*OAuthToken, error) {
+	if token.RefreshToken == "" {
+		return nil, ErrSignInNeeded
+	}
+	refreshed, err := c.requestToken(ctx, client, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {token.RefreshToken},
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Providers may keep the refresh token as it is
+	if refreshed.RefreshToken == "" {
+		refreshed.RefreshToken = token.RefreshToken
+	}
+	return refreshed, nil
+}
+
+// requestToken asks the token endpoint for tokens
+func (c OAuthConfig) requestToken(ctx context.Context, client *http.Client, form url.Values) (*OAuthToken, error) {
+	form.Set("client_id", c.ClientID)
+	if c.ClientSecret != "" {
+		form.Set("client_secret", c.ClientSecret)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	var answer struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+		Error        string `json:"error"`
+		Description  string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(response.Body, 1<<20)).Decode(&answer); err != nil {
+		return nil, fmt.Errorf("invalid answer from %s: %w", c.TokenURL, err)
+	}
+	if answer.Error == "invalid_grant" {
+		return nil, ErrSignInNeeded
+	}
+	if response.StatusCode != http.StatusOK || answer.AccessToken == "" {
+		return nil, fmt.Errorf("signing in failed: %s %s", answer.Error, answer.Description)
+	}
+	token := &OAuthToken{AccessToken: answer.AccessToken, RefreshToken: answer.RefreshToken}
+	if answer.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(answer.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// randomString returns a random string for PKCE and the state of a flow
+func randomString() string {
+	data := make([]byte, 32)
+	rand.Read(data)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// oauthSession sends requests to a provider with an access token,
+// refreshing it when it runs out
+type oauthSession struct {
+	config OAuthConfig
+	client *http.Client
+	// onRefresh is told the new token, to keep it, and an empty one once
+	// the provider no longer accepts it
+	onRefresh func(OAuthToken)
+	token     OAuthToken
+	mu        sync.Mutex
+}
+
+// do sends request with the access token. A request the provider refuses
+// the token for is sent once more after refreshing it, as tokens can be
+// revoked before they expire; its body has to be replayable for that.
+func (s *oauthSession) do(request *http.Request) (*http.Response, error) {
+	token, err := s.accessToken(request.Context(), false)
+	if err != nil {
+		return nil, err
+	}
+	response, err := s.send(request, token)
+	if err != nil || response.StatusCode != http.StatusUnauthorized || request.GetBody == nil && request.Body != nil {
+		return response, err
+	}
+	response.Body.Close()
+	if token, err = s.accessToken(request.Context(), true); err != nil {
+		return nil, err
+	}
+	if request.GetBody != nil {
+		if request.Body, err = request.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	response, err = s.send(request, token)
+	if err == nil && response.StatusCode == http.StatusUnauthorized {
+		s.signedOut()
+	}
+	return response, err
+}
+
+// signedOut forgets the token the provider no longer accepts
+func (s *oauthSession) signedOut() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = OAuthToken{}
+	if s.onRefresh != nil {
+		s.onRefresh(s.token)
+	}
+}
+
+func (s *oauthSession) send(request *http.Request, token string) (*http.Response, error) {
+	request.Header.Set("Authorization", "Bearer "+token)
+	request.Header.Set("User-Agent", "Recuerdo")
+	client := s.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(request)
+}
+
+// accessToken returns an access token, refreshed when it runs out within
+// a minute or when force is set
+func (s *oauthSession) accessToken(ctx context.Context, force bool) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !force && s.token.AccessToken != "" && (s.token.Expiry.IsZero() || time.Until(s.token.Expiry) > time.Minute) {
+		return s.token.AccessToken, nil
+	}
+	refreshed, err := s.config.Refresh(ctx, s.client, s.token)
+	if errors.Is(err, ErrSignInNeeded) {
+		s.token = OAuthToken{}
+		if s.onRefresh != nil {
+			s.onRefresh(s.token)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	s.token = *refreshed
+	if s.onRefresh != nil {
+		s.onRefresh(s.token)
+	}
+	return s.token.AccessToken, nil
+}
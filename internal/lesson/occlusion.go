@@ -0,0 +1,135 @@
+package lesson
+
+import (
+	"encoding/base64"
+	"image"
+	"path/filepath"
+)
+
+// OcclusionImageResource is the LessonData.Resources key holding the
+// *OcclusionImage masks of an image occlusion lesson are drawn over
+const OcclusionImageResource = "occlusionImage"
+
+// OcclusionImage is the picture of an image occlusion lesson. Data holds the
+// encoded file (PNG, JPEG, ...) as it was imported.
+type OcclusionImage struct {
+	Name string `json:"name"`
+	Data []byte `json:"data"`
+}
+
+// SetOcclusionImage sets the image masks are drawn over
+func (ld *LessonData) SetOcclusionImage(name string, data []byte) {
+	if ld.Resources == nil {
+		ld.Resources = make(map[string]interface{})
+	}
+	ld.Resources[OcclusionImageResource] = &OcclusionImage{Name: filepath.Base(name), Data: data}
+}
+
+// OcclusionImage returns the image masks are drawn over. Lessons read back
+// from JSON hold it as a plain map with base64 data, which is accepted too.
+func (ld *LessonData) OcclusionImage() (*OcclusionImage, bool) {
+	switch img := ld.Resources[OcclusionImageResource].(type) {
+	case *OcclusionImage:
+		return img, img != nil && len(img.Data) > 0
+	case OcclusionImage:
+		return &img, len(img.Data) > 0
+	case map[string]interface{}:
+		name, _ := img["name"].(string)
+		encoded, _ := img["data"].(string)
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(data) == 0 {
+			return nil, false
+		}
+		return &OcclusionImage{Name: name, Data: data}, true
+	}
+	return nil, false
+}
+
+// AddOcclusionItem adds a mask covering rect, in image pixels, to the word
+// list. The first answer is used as the mask's name.
+func (wl *WordList) AddOcclusionItem(rect image.Rectangle, answers []string) {
+	wl.Items = append(wl.Items, NewOcclusionItem(len(wl.Items), rect, answers))
+}
+
+// NewOcclusionItem creates a mask item. Masks have no question text: the
+// question is the highlighted mask itself.
+func NewOcclusionItem(id int, rect image.Rectangle, answers []string) WordItem {
+	rect = rect.Canon()
+	x, y := rect.Min.X, rect.Min.Y
+	width, height := rect.Dx(), rect.Dy()
+	item := WordItem{
+		ID:        id,
+		Questions: []string{},
+		Answers:   answers,
+		X:         &x,
+		Y:         &y,
+		Width:     &width,
+		Height:    &height,
+	}
+	if len(answers) > 0 {
+		item.Name = answers[0]
+	}
+	return item
+}
+
+// IsOcclusionItem reports whether the item is a mask over an image rather
+// than a point on a map
+func (wi *WordItem) IsOcclusionItem() bool {
+	return wi.IsTopoItem() && wi.Width != nil && wi.Height != nil
+}
+
+// OcclusionRect returns the area covered by a mask item, in image pixels
+func (wi *WordItem) OcclusionRect() (image.Rectangle, bool) {
+	if !wi.IsOcclusionItem() {
+		return image.Rectangle{}, false
+	}
+	return image.Rect(*wi.X, *wi.Y, *wi.X+*wi.Width, *wi.Y+*wi.Height), true
+}
+
+// OcclusionAt returns the index of the last drawn mask containing the point
+// (x, y), which is the one shown on top, or -1 if there is none
+func OcclusionAt(items []WordItem, x, y int) int {
+	point := image.Pt(x, y)
+	for i := len(items) - 1; i >= 0; i-- {
+		if rect, ok := items[i].OcclusionRect(); ok && point.In(rect) {
+			return i
+		}
+	}
+	return -1
+}
+
+// occlusionList is the list.json of an .otio archive. Image names the
+// archive entry holding the picture.
+type occlusionList struct {
+	FileFormatVersion string          `json:"file-format-version"`
+	Title             string          `json:"title,omitempty"`
+	AnswerLanguage    string          `json:"answerLanguage,omitempty"`
+	Image             string          `json:"image,omitempty"`
+	Items             []occlusionMask `json:"items"`
+	Tests             []Test          `json:"tests"`
+	Tags              []string        `json:"tags,omitempty"`
+	Author            string          `json:"author,omitempty"`
+	Description       string          `json:"description,omitempty"`
+	License           string          `json:"license,omitempty"`
+	Level             string          `json:"level,omitempty"`
+	UUID              string          `json:"uuid,omitempty"`
+	Sync              string          `json:"sync,omitempty"`
+}
+
+// occlusionMask is one item of an .otio list; x, y, width and height are
+// in image pixels
+type occlusionMask struct {
+	ID      int      `json:"id"`
+	Name    string   `json:"name"`
+	Answers []string `json:"answers,omitempty"`
+	Comment string   `json:"comment,omitempty"`
+	X       int      `json:"x"`
+	Y       int      `json:"y"`
+	Width   int      `json:"width"`
+	Height  int      `json:"height"`
+	// Starred, Difficulty, Tags and Chapter are the user's marks on the mask
+	Starred    bool       `json:"starred,omitempty"`
+	Difficulty Difficulty `json:"difficulty,omitempty"`
+	Tags       []string   `json:"tags,omitempty"`
+	Chapter    []string   `json:"chapter,omitempty"`
+}
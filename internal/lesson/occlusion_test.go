@@ -0,0 +1,89 @@
+package lesson
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"path/filepath"
+	"testing"
+)
+
+func TestOcclusionRoundTrip(t *testing.T) {
+	lessonData := NewLessonData()
+	lessonData.List.Title = "Heart"
+	lessonData.SetOcclusionImage("/tmp/heart.png", []byte("\x89PNG fake image data"))
+	lessonData.List.AddOcclusionItem(image.Rect(40, 30, 10, 20), []string{"aorta", "aortic arch"})
+	lessonData.List.AddOcclusionItem(image.Rect(100, 100, 150, 120), []string{"left ventricle"})
+	lessonData.List.AddTestResult(1, "wrong")
+
+	path := filepath.Join(t.TempDir(), "heart.otio")
+	if err := NewFileSaver().SaveFile(lessonData, path); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+
+	loader := NewFileLoader()
+	if loader.GetFileType(path) != "occlusion" {
+		t.Errorf("Expected occlusion file type, got %s", loader.GetFileType(path))
+	}
+	loaded, err := loader.LoadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+
+	if loaded.List.Title != "Heart" {
+		t.Errorf("Unexpected title %q", loaded.List.Title)
+	}
+	img, ok := loaded.OcclusionImage()
+	if !ok || img.Name != "heart.png" || !bytes.Equal(img.Data, []byte("\x89PNG fake image data")) {
+		t.Errorf("Image not restored: %+v", img)
+	}
+	if len(loaded.List.Items) != 2 {
+		t.Fatalf("Expected 2 masks, got %d", len(loaded.List.Items))
+	}
+	rect, ok := loaded.List.Items[0].OcclusionRect()
+	if !ok || rect != image.Rect(10, 20, 40, 30) {
+		t.Errorf("Unexpected first mask %v", rect)
+	}
+	if !equalStringSlices(loaded.List.Items[0].Answers, []string{"aorta", "aortic arch"}) {
+		t.Errorf("Unexpected answers %v", loaded.List.Items[0].Answers)
+	}
+	if len(loaded.List.Tests) != 1 {
+		t.Errorf("Expected the test to be kept, got %d tests", len(loaded.List.Tests))
+	}
+}
+
+func TestOcclusionAt(t *testing.T) {
+	list := NewWordList()
+	list.AddOcclusionItem(image.Rect(0, 0, 100, 100), []string{"outer"})
+	list.AddOcclusionItem(image.Rect(10, 10, 20, 20), []string{"inner"})
+	list.AddTopoItem("point", 15, 15, []string{"point"}, []string{"point"})
+
+	if got := OcclusionAt(list.Items, 15, 15); got != 1 {
+		t.Errorf("Expected the mask drawn last, got %d", got)
+	}
+	if got := OcclusionAt(list.Items, 50, 50); got != 0 {
+		t.Errorf("Expected the outer mask, got %d", got)
+	}
+	if got := OcclusionAt(list.Items, 150, 50); got != -1 {
+		t.Errorf("Expected no mask, got %d", got)
+	}
+}
+
+func TestOcclusionImageFromJSON(t *testing.T) {
+	lessonData := NewLessonData()
+	lessonData.SetOcclusionImage("map.png", []byte{1, 2, 3})
+
+	encoded, err := json.Marshal(lessonData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := NewLessonData()
+	if err := json.Unmarshal(encoded, decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	img, ok := decoded.OcclusionImage()
+	if !ok || img.Name != "map.png" || !bytes.Equal(img.Data, []byte{1, 2, 3}) {
+		t.Errorf("Image not decoded from JSON: %+v", img)
+	}
+}
@@ -0,0 +1,122 @@
+package lesson
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+// saveODSFile saves lesson data as an OpenDocument spreadsheet with the
+// columns of the CSV saver: questions, answers, comment and comment after
+// answering, under a header row naming the languages. Every cell is typed
+// as text, so words such as "007" or "1/2" are not turned into numbers or
+// dates when the sheet is opened.
+func (fs *FileSaver) saveODSFile(lessonData *LessonData, filePath string) error {
+	log.Printf("[ACTION] FileSaver.saveODSFile() - saving ODS file")
+
+	var content strings.Builder
+	content.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	content.WriteString(`<office:document-content ` + odfNamespaces + `>
+<office:automatic-styles>
+<style:style style:name="Words" style:family="table-column"><style:table-column-properties style:column-width="6cm"/></style:style>
+<style:style style:name="Comments" style:family="table-column"><style:table-column-properties style:column-width="8cm"/></style:style>
+<style:style style:name="Header" style:family="table-cell"><style:text-properties fo:font-weight="bold"/></style:style>
+</office:automatic-styles>
+<office:body>
+<office:spreadsheet>
+`)
+	fmt.Fprintf(&content, `<table:table table:name="%s">`+"\n", odtText(xlsxSheetName(lessonData.List.Title)))
+	content.WriteString(`<table:table-column table:style-name="Words" table:number-columns-repeated="2"/>
+<table:table-column table:style-name="Comments" table:number-columns-repeated="2"/>
+`)
+
+	writeRow := func(cellStyle string, cells ...string) {
+		content.WriteString("<table:table-row>")
+		for _, cell := range cells {
+			if cell == "" {
+				content.WriteString("<table:table-cell/>")
+				continue
+			}
+			fmt.Fprintf(&content, `<table:table-cell%s office:value-type="string"><text:p>%s</text:p></table:table-cell>`,
+				cellStyle, odtText(cell))
+		}
+		content.WriteString("</table:table-row>\n")
+	}
+
+	content.WriteString("<table:table-header-rows>\n")
+	writeRow(` table:style-name="Header"`,
+		getColumnHeader(lessonData.List.QuestionLanguage, "Questions"),
+		getColumnHeader(lessonData.List.AnswerLanguage, "Answers"),
+		"Comment",
+		"Comment After Answering",
+	)
+	content.WriteString("</table:table-header-rows>\n")
+	for _, item := range lessonData.List.Items {
+		writeRow("",
+			strings.Join(item.Questions, "; "),
+			strings.Join(item.Answers, "; "),
+			item.Comment,
+			"",
+		)
+	}
+	content.WriteString("</table:table>\n</office:spreadsheet>\n</office:body>\n</office:document-content>\n")
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		log.Printf("[ERROR] Failed to create ODS file: %v", err)
+		return err
+	}
+	if err := writeOpenDocument(file, "application/vnd.oasis.opendocument.spreadsheet", content.String(), ""); err != nil {
+		file.Close()
+		log.Printf("[ERROR] Failed to write ODS file: %v", err)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		log.Printf("[ERROR] Failed to write ODS file: %v", err)
+		return err
+	}
+
+	log.Printf("[SUCCESS] FileSaver.saveODSFile() - saved %d items to ODS file", len(lessonData.List.Items))
+	return nil
+}
+
+// WriteSpreadsheet writes an OpenDocument spreadsheet with one sheet of the
+// given name: a bold header row followed by rows. Like saveODSFile it types
+// every cell as text, so notes such as "7.5" or "B+" appear as written.
+func WriteSpreadsheet(w io.Writer, sheet string, header []string, rows [][]string) error {
+	var content strings.Builder
+	content.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	content.WriteString(`<office:document-content ` + odfNamespaces + `>
+<office:automatic-styles>
+<style:style style:name="Header" style:family="table-cell"><style:text-properties fo:font-weight="bold"/></style:style>
+</office:automatic-styles>
+<office:body>
+<office:spreadsheet>
+`)
+	fmt.Fprintf(&content, `<table:table table:name="%s">`+"\n", odtText(xlsxSheetName(sheet)))
+
+	writeRow := func(cellStyle string, cells []string) {
+		content.WriteString("<table:table-row>")
+		for _, cell := range cells {
+			if cell == "" {
+				content.WriteString("<table:table-cell/>")
+				continue
+			}
+			fmt.Fprintf(&content, `<table:table-cell%s office:value-type="string"><text:p>%s</text:p></table:table-cell>`,
+				cellStyle, odtText(cell))
+		}
+		content.WriteString("</table:table-row>\n")
+	}
+
+	content.WriteString("<table:table-header-rows>\n")
+	writeRow(` table:style-name="Header"`, header)
+	content.WriteString("</table:table-header-rows>\n")
+	for _, row := range rows {
+		writeRow("", row)
+	}
+	content.WriteString("</table:table>\n</office:spreadsheet>\n</office:body>\n</office:document-content>\n")
+
+	return writeOpenDocument(w, "application/vnd.oasis.opendocument.spreadsheet", content.String(), "")
+}
@@ -0,0 +1,98 @@
+package lesson
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileSaver_SaveODS(t *testing.T) {
+	lessonData := &LessonData{
+		List: WordList{
+			Title:            "Numbers: 1/2",
+			QuestionLanguage: "English",
+			Items: []WordItem{
+				{ID: 0, Questions: []string{"double-oh-seven"}, Answers: []string{"007"}, Comment: "agent"},
+				{ID: 1, Questions: []string{"half", "one half"}, Answers: []string{"1/2"}},
+			},
+		},
+	}
+
+	testFile := filepath.Join(t.TempDir(), "numbers.ods")
+	if err := NewFileSaver().SaveFile(lessonData, testFile); err != nil {
+		t.Fatalf("Failed to save ODS file: %v", err)
+	}
+
+	archive, err := zip.OpenReader(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+	if archive.File[0].Name != "mimetype" || archive.File[0].Method != zip.Store {
+		t.Error("Expected an uncompressed mimetype first")
+	}
+
+	var document struct {
+		Table struct {
+			Name string `xml:"name,attr"`
+			Rows []struct {
+				Cells []struct {
+					Type string `xml:"value-type,attr"`
+					Text string `xml:"p"`
+				} `xml:"table-cell"`
+			} `xml:"table-row"`
+			Header struct {
+				Rows []struct {
+					Cells []struct {
+						Text string `xml:"p"`
+					} `xml:"table-cell"`
+				} `xml:"table-row"`
+			} `xml:"table-header-rows"`
+		} `xml:"body>spreadsheet>table"`
+	}
+	for _, file := range archive.File {
+		if file.Name != "content.xml" {
+			continue
+		}
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(reader)
+		reader.Close()
+		if err := xml.Unmarshal(data, &document); err != nil {
+			t.Fatalf("Invalid content.xml: %v", err)
+		}
+	}
+
+	table := document.Table
+	if table.Name != "Numbers_ 1_2" {
+		t.Errorf("Expected a sanitized sheet name, got %q", table.Name)
+	}
+	if len(table.Header.Rows) != 1 {
+		t.Fatalf("Expected a header row, got %d", len(table.Header.Rows))
+	}
+	var header []string
+	for _, cell := range table.Header.Rows[0].Cells {
+		header = append(header, cell.Text)
+	}
+	if !equalStringSlices(header, []string{"English", "Answers", "Comment", "Comment After Answering"}) {
+		t.Errorf("Unexpected header %v", header)
+	}
+
+	if len(table.Rows) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(table.Rows))
+	}
+	first := table.Rows[0].Cells
+	if first[1].Text != "007" || first[1].Type != "string" {
+		t.Errorf("Expected 007 as text, got %q of type %q", first[1].Text, first[1].Type)
+	}
+	if first[2].Text != "agent" {
+		t.Errorf("Expected comment 'agent', got %q", first[2].Text)
+	}
+	if second := table.Rows[1].Cells; second[0].Text != "half; one half" || second[2].Type != "" {
+		t.Errorf("Unexpected second row %+v", second)
+	}
+}
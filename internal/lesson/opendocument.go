@@ -0,0 +1,68 @@
+package lesson
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// odfNamespaces declares the OpenDocument namespaces used by the documents
+// written here, on their root element
+const odfNamespaces = `xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" ` +
+	`xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" ` +
+	`xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" ` +
+	`xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" ` +
+	`xmlns:fo="urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0" ` +
+	`xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0" office:version="1.2"`
+
+// writeOpenDocument packs an OpenDocument file of mediaType, such as
+// "application/vnd.oasis.opendocument.text", into w. styles may be empty
+// for a document without page styles.
+func writeOpenDocument(w io.Writer, mediaType, content, styles string) error {
+	var manifest strings.Builder
+	manifest.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	manifest.WriteString(`<manifest:manifest ` + odfNamespaces + ">\n")
+	manifest.WriteString(`<manifest:file-entry manifest:full-path="/" manifest:media-type="` + mediaType + `"/>` + "\n")
+	manifest.WriteString(`<manifest:file-entry manifest:full-path="content.xml" manifest:media-type="text/xml"/>` + "\n")
+	files := []struct{ name, data string }{{"content.xml", content}}
+	if styles != "" {
+		manifest.WriteString(`<manifest:file-entry manifest:full-path="styles.xml" manifest:media-type="text/xml"/>` + "\n")
+		files = append(files, struct{ name, data string }{"styles.xml", styles})
+	}
+	manifest.WriteString("</manifest:manifest>\n")
+	files = append(files, struct{ name, data string }{"META-INF/manifest.xml", manifest.String()})
+
+	archive := zip.NewWriter(w)
+	// The mimetype comes first and uncompressed, so the file type can be
+	// told from its first bytes
+	mimetype, err := archive.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(mimetype, mediaType); err != nil {
+		return err
+	}
+	for _, file := range files {
+		part, err := archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(part, file.data); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// odtText escapes text for an ODF paragraph, keeping line breaks
+func odtText(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		var escaped bytes.Buffer
+		xml.EscapeText(&escaped, []byte(line))
+		lines[i] = escaped.String()
+	}
+	return strings.Join(lines, "<text:line-break/>")
+}
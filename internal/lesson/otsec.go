@@ -0,0 +1,226 @@
+package lesson
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Encrypted lesson containers (.otsec) hold the same zip an .otxx file does,
+// with the lesson as list.json, encrypted with AES-256-GCM. The key is
+// derived from a passphrase with Argon2id. The zip is built and read in
+// memory, so the lesson never reaches the disk unencrypted.
+//
+// An .otsec file starts with a header the encrypted zip is authenticated
+// together with:
+//
+//	magic     "OTSEC" and format version 1
+//	time      uint32, Argon2 passes
+//	memory    uint32, Argon2 memory in KiB
+//	threads   uint8, Argon2 parallelism
+//	salt      16 bytes
+//	nonce     12 bytes
+const (
+	otsecMagic   = "OTSEC"
+	otsecVersion = 1
+	otsecSalt    = 16
+	otsecHeader  = len(otsecMagic) + 1 + 4 + 4 + 1 + otsecSalt + 12
+)
+
+// Argon2id parameters for new files, as recommended by RFC 9106 for
+// memory-constrained machines
+var (
+	otsecTime    uint32 = 3
+	otsecMemory  uint32 = 64 * 1024
+	otsecThreads uint8  = 4
+)
+
+// Limits on the Argon2id parameters read from a file, so a crafted file
+// cannot hang the program or exhaust its memory before the passphrase is
+// checked
+const (
+	otsecMaxTime    = 10
+	otsecMaxMemory  = 1024 * 1024
+	otsecMaxThreads = 16
+)
+
+var (
+	// ErrPassphraseRequired is returned when loading or saving an encrypted
+	// lesson without a passphrase
+	ErrPassphraseRequired = errors.New("a passphrase is needed for encrypted lessons")
+	// ErrWrongPassphrase is returned when an encrypted lesson cannot be
+	// decrypted, because the passphrase is wrong or the file was damaged
+	ErrWrongPassphrase = errors.New("wrong passphrase, or the file is damaged")
+)
+
+// SetPassphrase sets the passphrase encrypted lessons are decrypted with
+func (fl *FileLoader) SetPassphrase(passphrase string) {
+	fl.encodingMutex.Lock()
+	defer fl.encodingMutex.Unlock()
+	fl.passphrase = passphrase
+}
+
+// otsecKey derives the key of an encrypted lesson from its passphrase
+func otsecKey(passphrase string, salt []byte, time, memory uint32, threads uint8) []byte {
+	return argon2.IDKey([]byte(passphrase), salt, time, memory, threads, 32)
+}
+
+// EncryptLesson returns lessonData as the content of an .otsec file
+func EncryptLesson(lessonData *LessonData, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, ErrPassphraseRequired
+	}
+
+	var container bytes.Buffer
+	zipWriter := zip.NewWriter(&container)
+	listWriter, err := zipWriter.Create("list.json")
+	if err != nil {
+		return nil, err
+	}
+	if err := json.NewEncoder(listWriter).Encode(lessonData); err != nil {
+		return nil, err
+	}
+	if err := zipWriter.Close(); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, otsecHeader)
+	header = append(header, otsecMagic...)
+	header = append(header, otsecVersion)
+	header = binary.BigEndian.AppendUint32(header, otsecTime)
+	header = binary.BigEndian.AppendUint32(header, otsecMemory)
+	header = append(header, otsecThreads)
+	random := make([]byte, otsecSalt+12)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	header = append(header, random...)
+	salt, nonce := random[:otsecSalt], random[otsecSalt:]
+
+	aead, err := otsecCipher(otsecKey(passphrase, salt, otsecTime, otsecMemory, otsecThreads))
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(header, nonce, container.Bytes(), header), nil
+}
+
+// DecryptLesson returns the lesson in the content of an .otsec file
+func DecryptLesson(data []byte, passphrase string) (*LessonData, error) {
+	if len(data) < otsecHeader || string(data[:len(otsecMagic)]) != otsecMagic {
+		return nil, corruptArchive("encrypted lesson", "no .otsec header", nil)
+	}
+	if version := data[len(otsecMagic)]; version != otsecVersion {
+		return nil, corruptArchive("encrypted lesson", fmt.Sprintf("unsupported version %d", version), nil)
+	}
+	if passphrase == "" {
+		return nil, ErrPassphraseRequired
+	}
+
+	header := data[:otsecHeader]
+	params := header[len(otsecMagic)+1:]
+	time := binary.BigEndian.Uint32(params)
+	memory := binary.BigEndian.Uint32(params[4:])
+	threads := params[8]
+	salt := params[9 : 9+otsecSalt]
+	nonce := params[9+otsecSalt:]
+	if time == 0 || time > otsecMaxTime || threads == 0 || threads > otsecMaxThreads ||
+		memory < 8*uint32(threads) || memory > otsecMaxMemory {
+		return nil, corruptArchive("encrypted lesson", "invalid key derivation parameters", nil)
+	}
+
+	aead, err := otsecCipher(otsecKey(passphrase, salt, time, memory, threads))
+	if err != nil {
+		return nil, err
+	}
+	container, err := aead.Open(nil, nonce, data[otsecHeader:], header)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(container), int64(len(container)))
+	if err != nil {
+		return nil, corruptArchive("encrypted lesson", "invalid container", err)
+	}
+	for _, file := range reader.File {
+		if file.Name != "list.json" {
+			continue
+		}
+		jsonData, err := readZipFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var lessonData LessonData
+		if err := json.Unmarshal(jsonData, &lessonData); err != nil {
+			return nil, err
+		}
+		return &lessonData, nil
+	}
+	return nil, corruptArchive("encrypted lesson", "no list.json file found", nil)
+}
+
+// otsecCipher returns AES-256-GCM with key
+func otsecCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// loadEncryptedFile loads an encrypted lesson (.otsec) with the passphrase
+// set on the loader
+func (fl *FileLoader) loadEncryptedFile(filePath string) (*LessonData, error) {
+	log.Printf("[ACTION] FileLoader.loadEncryptedFile() - decrypting encrypted lesson")
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	fl.encodingMutex.RLock()
+	passphrase := fl.passphrase
+	fl.encodingMutex.RUnlock()
+	lessonData, err := DecryptLesson(data, passphrase)
+	if err != nil {
+		log.Printf("[ERROR] Failed to decrypt encrypted lesson: %v", err)
+		return nil, err
+	}
+
+	log.Printf("[SUCCESS] FileLoader.loadEncryptedFile() - loaded %d items", len(lessonData.List.Items))
+	return lessonData, nil
+}
+
+// saveEncryptedFile saves lesson data as an encrypted lesson (.otsec) with
+// fs.Options.Passphrase
+func (fs *FileSaver) saveEncryptedFile(lessonData *LessonData, filePath string) error {
+	log.Printf("[ACTION] FileSaver.saveEncryptedFile() - saving encrypted lesson")
+
+	data, err := EncryptLesson(lessonData, fs.Options.Passphrase)
+	if err != nil {
+		log.Printf("[ERROR] Failed to encrypt lesson: %v", err)
+		return err
+	}
+	if err := os.WriteFile(filePath, data, 0600); err != nil {
+		log.Printf("[ERROR] Failed to write encrypted lesson: %v", err)
+		return err
+	}
+
+	log.Printf("[SUCCESS] FileSaver.saveEncryptedFile() - saved %d items", len(lessonData.List.Items))
+	return nil
+}
@@ -0,0 +1,101 @@
+package lesson
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// cheapKeyDerivation makes deriving keys fast for the duration of a test
+func cheapKeyDerivation(t *testing.T) {
+	t.Helper()
+	time, memory, threads := otsecTime, otsecMemory, otsecThreads
+	otsecTime, otsecMemory, otsecThreads = 1, 64, 1
+	t.Cleanup(func() { otsecTime, otsecMemory, otsecThreads = time, memory, threads })
+}
+
+func TestEncryptedLesson(t *testing.T) {
+	cheapKeyDerivation(t)
+
+	lessonData := NewLessonData()
+	lessonData.List.Title = "Final exam"
+	lessonData.List.Items = append(lessonData.List.Items, WordItem{ID: 1, Questions: []string{"house"}, Answers: []string{"maison"}})
+	filePath := filepath.Join(t.TempDir(), "exam.otsec")
+
+	saver := NewFileSaver()
+	if err := saver.SaveFile(lessonData, filePath); !errors.Is(err, ErrPassphraseRequired) {
+		t.Fatalf("saving without a passphrase returned %v", err)
+	}
+	saver.Options.Passphrase = "correct horse"
+	if err := saver.SaveFile(lessonData, filePath); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("maison")) || bytes.Contains(data, []byte("list.json")) {
+		t.Error("the lesson is readable without the passphrase")
+	}
+
+	loader := NewFileLoader()
+	if _, err := loader.LoadFile(filePath); !errors.Is(err, ErrPassphraseRequired) {
+		t.Errorf("loading without a passphrase returned %v", err)
+	}
+	loader.SetPassphrase("wrong horse")
+	if _, err := loader.LoadFile(filePath); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("loading with the wrong passphrase returned %v", err)
+	}
+	loader.SetPassphrase("correct horse")
+	loaded, err := loader.LoadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.List.Title != "Final exam" || len(loaded.List.Items) != 1 || loaded.List.Items[0].Answers[0] != "maison" {
+		t.Errorf("loaded %+v", loaded.List)
+	}
+}
+
+func TestDecryptTampered(t *testing.T) {
+	cheapKeyDerivation(t)
+
+	data, err := EncryptLesson(NewLessonData(), "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The header is authenticated as well as the content
+	for _, i := range []int{len(otsecMagic) + 2, otsecHeader - 1, len(data) - 1} {
+		tampered := bytes.Clone(data)
+		tampered[i] ^= 1
+		if _, err := DecryptLesson(tampered, "secret"); err == nil {
+			t.Errorf("changing byte %d went unnoticed", i)
+		}
+	}
+	if _, err := DecryptLesson([]byte("PK\x03\x04 not encrypted"), "secret"); err == nil {
+		t.Error("decrypted a file that is not encrypted")
+	}
+}
+
+func TestDecryptCostlyKeyDerivation(t *testing.T) {
+	cheapKeyDerivation(t)
+
+	data, err := EncryptLesson(NewLessonData(), "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := len(otsecMagic) + 1
+	for name, change := range map[string]func([]byte){
+		"time":    func(d []byte) { binary.BigEndian.PutUint32(d[params:], otsecMaxTime+1) },
+		"memory":  func(d []byte) { binary.BigEndian.PutUint32(d[params+4:], otsecMaxMemory+1) },
+		"threads": func(d []byte) { d[params+8] = otsecMaxThreads + 1 },
+	} {
+		costly := bytes.Clone(data)
+		change(costly)
+		if _, err := DecryptLesson(costly, "secret"); err == nil || errors.Is(err, ErrWrongPassphrase) {
+			t.Errorf("a file asking for too much %s was not refused before deriving the key: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package lesson
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/LaPingvino/recuerdo/internal/paths"
+)
+
+// Lesson packs can be signed with Ed25519, so schools can hand out official
+// word lists that students can check were not changed on the way. The
+// signature covers a manifest listing the SHA-256 hash of every other file
+// in the pack, and is stored in the pack as PackSignatureFile.
+
+// PackSignatureFile is the file in a lesson pack holding its signature
+const PackSignatureFile = "signature.json"
+
+// maxPackSize bounds how much a lesson pack may hold once unpacked, so a
+// small archive cannot unpack into more than memory holds
+const maxPackSize = 256 << 20
+
+// ErrPackTampered is returned for a signed lesson pack whose content does
+// not match its signature
+var ErrPackTampered = errors.New("the lesson pack was changed after it was signed")
+
+// SigningKey signs lesson packs in the name of a school or teacher
+type SigningKey struct {
+	Name       string             `json:"name"`
+	PrivateKey ed25519.PrivateKey `json:"privateKey"`
+}
+
+// TrustedKey is the public key of someone whose lesson packs are trusted
+type TrustedKey struct {
+	Name      string            `json:"name"`
+	PublicKey ed25519.PublicKey `json:"publicKey"`
+}
+
+// PackVerification tells who signed a lesson pack
+type PackVerification struct {
+	// Signed is set when the pack carries a valid signature
+	Signed bool
+	// Signer is the name the pack was signed under
+	Signer string
+	// Fingerprint identifies the signing key
+	Fingerprint string
+	// Trusted is set when the signing key is one of the trusted keys, and
+	// TrustedAs is the name it was trusted under
+	Trusted   bool
+	TrustedAs string
+}
+
+// packSignature is the content of PackSignatureFile
+type packSignature struct {
+	Algorithm string            `json:"algorithm"`
+	Signer    string            `json:"signer"`
+	PublicKey ed25519.PublicKey `json:"publicKey"`
+	Signature []byte            `json:"signature"`
+}
+
+// GenerateSigningKey creates a new signing key for name
+func GenerateSigningKey(name string) (*SigningKey, error) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &SigningKey{Name: name, PrivateKey: privateKey}, nil
+}
+
+// LoadSigningKey reads a signing key saved with Save
+func LoadSigningKey(filePath string) (*SigningKey, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	var key SigningKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, fmt.Errorf("failed to parse signing key: %w", err)
+	}
+	if len(key.PrivateKey) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("%s holds no Ed25519 signing key", filePath)
+	}
+	return &key, nil
+}
+
+// Save writes the signing key to a file only its owner can read
+func (k *SigningKey) Save(filePath string) error {
+	data, err := json.MarshalIndent(k, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, data, 0600)
+}
+
+// Public returns the key others verify packs signed with k against
+func (k *SigningKey) Public() TrustedKey {
+	return TrustedKey{Name: k.Name, PublicKey: k.PrivateKey.Public().(ed25519.PublicKey)}
+}
+
+// String returns the public key as handed to others to trust
+func (k TrustedKey) String() string {
+	return base64.StdEncoding.EncodeToString(k.PublicKey)
+}
+
+// ParseTrustedKey reads a public key as written by TrustedKey.String
+func ParseTrustedKey(name, encoded string) (TrustedKey, error) {
+	publicKey, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return TrustedKey{}, fmt.Errorf("not an Ed25519 public key: %q", encoded)
+	}
+	return TrustedKey{Name: name, PublicKey: publicKey}, nil
+}
+
+// Fingerprint returns a short text identifying a public key, for people to
+// compare
+func (k TrustedKey) Fingerprint() string {
+	return fingerprint(k.PublicKey)
+}
+
+func fingerprint(publicKey ed25519.PublicKey) string {
+	sum := sha256.Sum256(publicKey)
+	groups := make([]string, 4)
+	for i := range groups {
+		groups[i] = fmt.Sprintf("%X", sum[2*i:2*i+2])
+	}
+	return strings.Join(groups, "-")
+}
+
+// DefaultTrustedKeysPath returns the trusted keys file next to the settings
+// file
+func DefaultTrustedKeysPath() string {
+	return paths.ConfigFile("trusted-keys.json")
+}
+
+// LoadTrustedKeys reads a trusted keys file; a missing file trusts nobody
+func LoadTrustedKeys(filePath string) ([]TrustedKey, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var keys []TrustedKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse trusted keys file: %w", err)
+	}
+	return keys, nil
+}
+
+// SaveTrustedKeys writes a trusted keys file
+func SaveTrustedKeys(filePath string, keys []TrustedKey) error {
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, data, 0644)
+}
+
+// packManifest lists the SHA-256 hash of every file but the signature, in
+// the order of their names; it is what a pack's signature covers
+func packManifest(files map[string][]byte) []byte {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		if name != PackSignatureFile {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var manifest bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&manifest, "%x  %s\n", sha256.Sum256(files[name]), name)
+	}
+	return manifest.Bytes()
+}
+
+// signPack returns the signature file of a pack holding files
+func signPack(files map[string][]byte, key *SigningKey) ([]byte, error) {
+	signature := packSignature{
+		Algorithm: "ed25519",
+		Signer:    key.Name,
+		PublicKey: key.Public().PublicKey,
+		Signature: ed25519.Sign(key.PrivateKey, packManifest(files)),
+	}
+	return json.MarshalIndent(signature, "", "  ")
+}
+
+// readPackFiles reads every file of a lesson pack. Packs holding a file
+// twice are refused, as the signature could only vouch for one of them,
+// and so are names with control characters, which could pass off several
+// lines of the manifest as one file.
+func readPackFiles(reader *zip.Reader) (map[string][]byte, error) {
+	files := make(map[string][]byte, len(reader.File))
+	remaining := int64(maxPackSize)
+	for _, file := range reader.File {
+		if strings.IndexFunc(file.Name, unicode.IsControl) >= 0 {
+			return nil, corruptArchive("lesson pack", fmt.Sprintf("invalid file name %q", file.Name), nil)
+		}
+		if _, ok := files[file.Name]; ok {
+			return nil, corruptArchive("lesson pack", fmt.Sprintf("%s is in it twice", file.Name), nil)
+		}
+		data, err := readLimitedZipFile(file, remaining)
+		if err != nil {
+			return nil, corruptArchive("lesson pack", "failed to read "+file.Name, err)
+		}
+		remaining -= int64(len(data))
+		files[file.Name] = data
+	}
+	return files, nil
+}
+
+// readLimitedZipFile reads a file from a zip archive, failing when it
+// unpacks into more than limit bytes
+func readLimitedZipFile(file *zip.File, limit int64) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("the lesson pack unpacks into more than %d MiB", maxPackSize>>20)
+	}
+	return data, nil
+}
+
+// verifyPackFiles checks the signature of a pack holding files against the
+// trusted keys. Unsigned packs are not signed, but not an error either.
+func verifyPackFiles(files map[string][]byte, trusted []TrustedKey) (PackVerification, error) {
+	data, ok := files[PackSignatureFile]
+	if !ok {
+		return PackVerification{}, nil
+	}
+	var signature packSignature
+	if err := json.Unmarshal(data, &signature); err != nil {
+		return PackVerification{}, corruptArchive("lesson pack", "invalid signature", err)
+	}
+	if signature.Algorithm != "ed25519" || len(signature.PublicKey) != ed25519.PublicKeySize {
+		return PackVerification{}, fmt.Errorf("unsupported pack signature algorithm %q", signature.Algorithm)
+	}
+	if !ed25519.Verify(signature.PublicKey, packManifest(files), signature.Signature) {
+		return PackVerification{}, ErrPackTampered
+	}
+
+	verification := PackVerification{
+		Signed:      true,
+		Signer:      signature.Signer,
+		Fingerprint: fingerprint(signature.PublicKey),
+	}
+	for _, key := range trusted {
+		if key.PublicKey.Equal(signature.PublicKey) {
+			verification.Trusted = true
+			verification.TrustedAs = key.Name
+			break
+		}
+	}
+	return verification, nil
+}
+
+// VerifyLessonPack tells who signed a lesson pack and whether their key is
+// among the trusted keys. It returns ErrPackTampered when the pack was
+// changed after signing.
+func (fl *FileLoader) VerifyLessonPack(filePath string, trusted []TrustedKey) (PackVerification, error) {
+	reader, err := zip.OpenReader(filePath)
+	if err != nil {
+		return PackVerification{}, corruptArchive("lesson pack", "", err)
+	}
+	defer reader.Close()
+
+	files, err := readPackFiles(&reader.Reader)
+	if err != nil {
+		return PackVerification{}, err
+	}
+	return verifyPackFiles(files, trusted)
+}
+
+// writePackFiles writes files into a new lesson pack in the order of their
+// names
+func writePackFiles(w io.Writer, files map[string][]byte) error {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	zipWriter := zip.NewWriter(w)
+	for _, name := range names {
+		writer, err := zipWriter.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := writer.Write(files[name]); err != nil {
+			return err
+		}
+	}
+	return zipWriter.Close()
+}
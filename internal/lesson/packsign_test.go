@@ -0,0 +1,167 @@
+package lesson
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// rewritePack copies a lesson pack, passing the content of every file
+// through change
+func rewritePack(t *testing.T, filePath string, change func(name string, data []byte) []byte) {
+	t.Helper()
+	reader, err := zip.OpenReader(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := readPackFiles(&reader.Reader)
+	reader.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range files {
+		files[name] = change(name, data)
+	}
+	out, err := os.Create(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	if err := writePackFiles(out, files); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSignedLessonPack(t *testing.T) {
+	dir := t.TempDir()
+	key, err := GenerateSigningKey("Ministry of Education")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(dir, "ministry.key")
+	if err := key.Save(keyFile); err != nil {
+		t.Fatal(err)
+	}
+	if key, err = LoadSigningKey(keyFile); err != nil {
+		t.Fatal(err)
+	}
+
+	lessonData := NewLessonData()
+	lessonData.List.Title = "Official list"
+	lessonData.List.AddWordItem([]string{"house"}, []string{"Haus"}, "")
+	packFile := filepath.Join(dir, "official.otpack")
+	saver := NewFileSaver()
+	saver.Options.PackSigningKey = key
+	if err := saver.SaveLessonPack([]*LessonData{lessonData}, nil, packFile); err != nil {
+		t.Fatal(err)
+	}
+
+	loader := NewFileLoader()
+	verification, err := loader.VerifyLessonPack(packFile, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verification.Signed || verification.Signer != "Ministry of Education" || verification.Trusted {
+		t.Errorf("without trusted keys got %+v", verification)
+	}
+	if verification.Fingerprint != key.Public().Fingerprint() {
+		t.Errorf("fingerprint %s, want %s", verification.Fingerprint, key.Public().Fingerprint())
+	}
+
+	trustedFile := filepath.Join(dir, "trusted-keys.json")
+	trusted, err := ParseTrustedKey("Ministry", key.Public().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveTrustedKeys(trustedFile, []TrustedKey{trusted}); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := LoadTrustedKeys(trustedFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verification, err = loader.VerifyLessonPack(packFile, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verification.Trusted || verification.TrustedAs != "Ministry" {
+		t.Errorf("with the key trusted got %+v", verification)
+	}
+	if _, _, err := loader.LoadLessonPack(packFile); err != nil {
+		t.Errorf("loading the signed pack failed: %v", err)
+	}
+
+	// Changing a lesson after signing is noticed on loading
+	rewritePack(t, packFile, func(name string, data []byte) []byte {
+		if name == "lessons/001.json" {
+			return []byte(`{"list": {"title": "Unofficial list"}}`)
+		}
+		return data
+	})
+	if _, err := loader.VerifyLessonPack(packFile, keys); !errors.Is(err, ErrPackTampered) {
+		t.Errorf("verifying the changed pack returned %v", err)
+	}
+	if _, _, err := loader.LoadLessonPack(packFile); !errors.Is(err, ErrPackTampered) {
+		t.Errorf("loading the changed pack returned %v", err)
+	}
+}
+
+func TestPackFileNamesCannotForgeManifest(t *testing.T) {
+	key, err := GenerateSigningKey("School")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := map[string][]byte{"a.json": []byte("first"), "b.json": []byte("second")}
+	signature, err := signPack(signed, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// One file whose name carries the manifest line of the other, so both
+	// packs list the same manifest
+	hash := sha256.Sum256(signed["b.json"])
+	forged := map[string][]byte{
+		"a.json\n" + hex.EncodeToString(hash[:]) + "  b.json": signed["a.json"],
+		PackSignatureFile: signature,
+	}
+	packFile := filepath.Join(t.TempDir(), "forged.otpack")
+	out, err := os.Create(packFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writePackFiles(out, forged); err != nil {
+		t.Fatal(err)
+	}
+	out.Close()
+
+	if verification, err := NewFileLoader().VerifyLessonPack(packFile, nil); err == nil {
+		t.Errorf("verified a pack with a newline in a file name as %+v", verification)
+	}
+	if _, _, err := NewFileLoader().LoadLessonPack(packFile); err == nil {
+		t.Error("loaded a pack with a newline in a file name")
+	}
+}
+
+func TestUnsignedLessonPack(t *testing.T) {
+	lessonData := NewLessonData()
+	lessonData.List.AddWordItem([]string{"tree"}, []string{"Baum"}, "")
+	packFile := filepath.Join(t.TempDir(), "unsigned.otpack")
+	if err := NewFileSaver().SaveLessonPack([]*LessonData{lessonData}, nil, packFile); err != nil {
+		t.Fatal(err)
+	}
+
+	verification, err := NewFileLoader().VerifyLessonPack(packFile, nil)
+	if err != nil || verification.Signed {
+		t.Errorf("unsigned pack verified as %+v, %v", verification, err)
+	}
+	if keys, err := LoadTrustedKeys(filepath.Join(t.TempDir(), "missing.json")); err != nil || len(keys) != 0 {
+		t.Errorf("missing trusted keys file gave %v, %v", keys, err)
+	}
+	if _, err := ParseTrustedKey("Nobody", "not a key"); err == nil {
+		t.Error("parsed an invalid public key")
+	}
+}
@@ -0,0 +1,558 @@
+package lesson
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"hash/crc32"
+	"html"
+	"image"
+	"image/color"
+	_ "image/jpeg" // Scanners often produce JPEG
+	"image/png"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// A paper test is printed with an answer sheet that can be scanned back in.
+// While grading, the teacher ticks the "right" or "wrong" box of every row.
+// The sheet has a black square in each corner to locate it in the scan and a
+// strip of bits between the top squares identifying the lesson and the page,
+// so reading it needs no OCR engine: only whether boxes are filled.
+//
+// Sheet coordinates are pixels of an A4 page at 150 DPI.
+const (
+	paperSheetWidth  = 1240
+	paperSheetHeight = 1754
+
+	paperFiducialSize   = 48
+	paperFiducialCenter = 84 // distance of the square centers from the edges
+
+	paperIDBits     = 40 // 32 bit lesson checksum and 8 bit page number
+	paperIDBitSize  = 18
+	paperIDBitPitch = 24
+
+	paperColumns       = 2
+	paperRowsPerColumn = 38
+	paperRowTop        = 200
+	paperRowPitch      = 36
+	paperBoxSize       = 26
+	paperColumnWidth   = 520
+	paperFirstColumn   = 140
+
+	// PaperTestRowsPerSheet is how many items fit on one answer sheet
+	PaperTestRowsPerSheet = paperColumns * paperRowsPerColumn
+
+	// paperMarkFill is the share of a box interior that must be dark for
+	// the box to count as ticked
+	paperMarkFill = 0.08
+)
+
+// PaperTestMark is the mark found for one item on a scanned sheet
+type PaperTestMark struct {
+	ItemIndex int    // Index into the lesson's items
+	Result    string // "right", "wrong" or "" when unmarked or unclear
+}
+
+// PaperTestScan is the content of one scanned answer sheet
+type PaperTestScan struct {
+	Checksum uint32
+	Page     int
+	Marks    []PaperTestMark
+}
+
+// PaperTestChecksum identifies a lesson on its answer sheets, so a sheet is
+// not imported into a lesson whose items changed since printing
+func PaperTestChecksum(lessonData *LessonData) uint32 {
+	hash := crc32.NewIEEE()
+	hash.Write([]byte(lessonData.List.Title))
+	for _, item := range lessonData.List.Items {
+		hash.Write([]byte{0})
+		hash.Write([]byte(strings.Join(item.Questions, "; ")))
+	}
+	return hash.Sum32()
+}
+
+// paperBox returns the box of a row on a sheet; wrong selects the second box
+func paperBox(row int, wrong bool) image.Rectangle {
+	column, line := row/paperRowsPerColumn, row%paperRowsPerColumn
+	x := paperFirstColumn + column*paperColumnWidth + 90
+	if wrong {
+		x += 50
+	}
+	y := paperRowTop + line*paperRowPitch
+	return image.Rect(x, y, x+paperBoxSize, y+paperBoxSize)
+}
+
+// paperIDBit returns the square of an identity bit
+func paperIDBit(bit int) image.Rectangle {
+	start := paperSheetWidth/2 - paperIDBits*paperIDBitPitch/2
+	x := start + bit*paperIDBitPitch + (paperIDBitPitch-paperIDBitSize)/2
+	y := paperFiducialCenter - paperIDBitSize/2
+	return image.Rect(x, y, x+paperIDBitSize, y+paperIDBitSize)
+}
+
+// paperFiducials returns the centers of the corner squares: top left, top
+// right, bottom left and bottom right
+func paperFiducials() [4]image.Point {
+	left, top := paperFiducialCenter, paperFiducialCenter
+	right, bottom := paperSheetWidth-paperFiducialCenter, paperSheetHeight-paperFiducialCenter
+	return [4]image.Point{{left, top}, {right, top}, {left, bottom}, {right, bottom}}
+}
+
+// PaperTestSheets draws the answer sheets for a lesson, one per
+// PaperTestRowsPerSheet items
+func PaperTestSheets(lessonData *LessonData) []*image.Gray {
+	checksum := PaperTestChecksum(lessonData)
+	pages := (len(lessonData.List.Items) + PaperTestRowsPerSheet - 1) / PaperTestRowsPerSheet
+	if pages == 0 {
+		pages = 1
+	}
+
+	sheets := make([]*image.Gray, 0, pages)
+	for page := 0; page < pages; page++ {
+		sheet := image.NewGray(image.Rect(0, 0, paperSheetWidth, paperSheetHeight))
+		fillRect(sheet, sheet.Bounds(), color.Gray{Y: 255})
+
+		for _, center := range paperFiducials() {
+			half := paperFiducialSize / 2
+			fillRect(sheet, image.Rect(center.X-half, center.Y-half, center.X+half, center.Y+half), color.Gray{})
+		}
+
+		id := uint64(checksum)<<8 | uint64(page&0xFF)
+		for bit := 0; bit < paperIDBits; bit++ {
+			square := paperIDBit(bit)
+			if id&(1<<(paperIDBits-1-bit)) != 0 {
+				fillRect(sheet, square, color.Gray{})
+			} else {
+				strokeRect(sheet, square, 1)
+			}
+		}
+
+		for column := 0; column < paperColumns; column++ {
+			right := paperBox(column*paperRowsPerColumn, false)
+			wrong := paperBox(column*paperRowsPerColumn, true)
+			drawTick(sheet, right.Min.X+4, paperRowTop-34)
+			drawCross(sheet, wrong.Min.X+4, paperRowTop-34)
+		}
+
+		for row := 0; row < PaperTestRowsPerSheet; row++ {
+			index := page*PaperTestRowsPerSheet + row
+			if index >= len(lessonData.List.Items) {
+				break
+			}
+			right := paperBox(row, false)
+			drawNumber(sheet, index+1, right.Min.X-20, right.Min.Y+5)
+			strokeRect(sheet, right, 2)
+			strokeRect(sheet, paperBox(row, true), 2)
+		}
+
+		sheets = append(sheets, sheet)
+	}
+	return sheets
+}
+
+// SavePaperTest writes a printable HTML test: the numbered questions with
+// room for the answers, followed by the answer sheets to grade on
+func (fs *FileSaver) SavePaperTest(lessonData *LessonData, filePath string) error {
+	log.Printf("[ACTION] FileSaver.SavePaperTest() - saving paper test")
+
+	var content strings.Builder
+	title := html.EscapeString(lessonData.List.Title)
+	content.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&content, "<title>%s</title>\n", title)
+	content.WriteString(`<style>
+body { font-family: sans-serif; margin: 2em; }
+li { margin: 0.8em 0; }
+.answer { display: inline-block; width: 50%; border-bottom: 1px solid #000; margin-left: 1em; }
+.sheet { page-break-before: always; width: 100%; }
+@page { size: A4; margin: 0; }
+</style>
+</head>
+<body>
+`)
+	fmt.Fprintf(&content, "<h1>%s</h1>\n<p>Name: <span class=\"answer\"></span></p>\n<ol>\n", title)
+	for _, item := range lessonData.List.Items {
+		fmt.Fprintf(&content, "<li>%s <span class=\"answer\"></span></li>\n", html.EscapeString(strings.Join(item.Questions, "; ")))
+	}
+	content.WriteString("</ol>\n")
+
+	for _, sheet := range PaperTestSheets(lessonData) {
+		var encoded bytes.Buffer
+		if err := png.Encode(&encoded, sheet); err != nil {
+			log.Printf("[ERROR] Failed to encode answer sheet: %v", err)
+			return err
+		}
+		fmt.Fprintf(&content, "<img class=\"sheet\" alt=\"Answer sheet\" src=\"data:image/png;base64,%s\">\n",
+			base64.StdEncoding.EncodeToString(encoded.Bytes()))
+	}
+	content.WriteString("</body>\n</html>\n")
+
+	err := writeAtomically(filePath, fs.Options.Backups, 0644, func(tempPath string) error {
+		return os.WriteFile(tempPath, []byte(content.String()), 0644)
+	})
+	if err != nil {
+		log.Printf("[ERROR] Failed to write paper test: %v", err)
+		return err
+	}
+
+	log.Printf("[SUCCESS] FileSaver.SavePaperTest() - saved %d questions", len(lessonData.List.Items))
+	return nil
+}
+
+// LoadPaperTestScan reads a scanned answer sheet from a PNG or JPEG file
+func (fl *FileLoader) LoadPaperTestScan(filePath string) (*PaperTestScan, error) {
+	log.Printf("[ACTION] FileLoader.LoadPaperTestScan() - reading scanned answer sheet")
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		log.Printf("[ERROR] Failed to open scan: %v", err)
+		return nil, err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		log.Printf("[ERROR] Failed to decode scan: %v", err)
+		return nil, err
+	}
+	return ReadPaperTestSheet(img)
+}
+
+// ReadPaperTestSheet finds the marks on an image of an answer sheet. The
+// sheet may be scaled, shifted and slightly rotated, but must fill most of
+// the image width, as it does when a whole page is scanned.
+func ReadPaperTestSheet(img image.Image) (*PaperTestScan, error) {
+	gray := toGray(img)
+	reader := paperSheetReader{img: gray, threshold: otsuThreshold(gray)}
+	if err := reader.locate(); err != nil {
+		return nil, err
+	}
+
+	var id uint64
+	for bit := 0; bit < paperIDBits; bit++ {
+		id <<= 1
+		if reader.fill(paperIDBit(bit), 0.25) > 0.5 {
+			id |= 1
+		}
+	}
+	scan := &PaperTestScan{Checksum: uint32(id >> 8), Page: int(id & 0xFF)}
+
+	for row := 0; row < PaperTestRowsPerSheet; row++ {
+		right := reader.fill(paperBox(row, false), 0.2)
+		wrong := reader.fill(paperBox(row, true), 0.2)
+		mark := PaperTestMark{ItemIndex: scan.Page*PaperTestRowsPerSheet + row}
+		switch {
+		case right >= paperMarkFill && wrong < paperMarkFill:
+			mark.Result = "right"
+		case wrong >= paperMarkFill && right < paperMarkFill:
+			mark.Result = "wrong"
+		case right >= paperMarkFill && right > 2*wrong:
+			mark.Result = "right" // a corrected tick next to a smudge
+		case wrong >= paperMarkFill && wrong > 2*right:
+			mark.Result = "wrong"
+		}
+		scan.Marks = append(scan.Marks, mark)
+	}
+	return scan, nil
+}
+
+// ImportPaperTestScans adds the marks of the scanned sheets of a lesson to
+// it as a new test. It fails when a sheet belongs to another lesson or a
+// different version of it. Items without a clear mark are left out of the
+// test; their indexes are returned so they can be graded by hand.
+func ImportPaperTestScans(lessonData *LessonData, scans ...*PaperTestScan) (*Test, []int, error) {
+	checksum := PaperTestChecksum(lessonData)
+	marks := make(map[int]string)
+	for _, scan := range scans {
+		if scan.Checksum != checksum {
+			return nil, nil, fmt.Errorf("answer sheet page %d belongs to a different lesson", scan.Page+1)
+		}
+		for _, mark := range scan.Marks {
+			if mark.ItemIndex < len(lessonData.List.Items) {
+				marks[mark.ItemIndex] = mark.Result
+			}
+		}
+	}
+
+	now := time.Now()
+	test := Test{Date: &now}
+	var unmarked []int
+	for index, item := range lessonData.List.Items {
+		result, scanned := marks[index]
+		if !scanned {
+			continue // on a page that was not scanned
+		}
+		if result == "" {
+			unmarked = append(unmarked, index)
+			continue
+		}
+		test.Results = append(test.Results, TestResult{Result: result, ItemID: item.ID, Time: &now})
+	}
+
+	if len(test.Results) > 0 {
+		lessonData.List.Tests = append(lessonData.List.Tests, test)
+		lessonData.Changed = true
+	}
+	return &test, unmarked, nil
+}
+
+// paperSheetReader maps sheet coordinates onto a scanned image
+type paperSheetReader struct {
+	img       *image.Gray
+	threshold uint8
+	corners   [4][2]float64 // image positions of the corner squares
+}
+
+func (r *paperSheetReader) dark(x, y int) bool {
+	if !(image.Point{x, y}.In(r.img.Rect)) {
+		return false
+	}
+	return r.img.GrayAt(x, y).Y < r.threshold
+}
+
+// locate finds the corner squares, each searched for in its own corner of
+// the image
+func (r *paperSheetReader) locate() error {
+	bounds := r.img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	size := paperFiducialSize * width / paperSheetWidth
+	if size < 4 {
+		return fmt.Errorf("scan too small to read (%dx%d)", width, height)
+	}
+
+	regionWidth, regionHeight := width*3/20, height/10
+	regions := [4]image.Rectangle{
+		image.Rect(0, 0, regionWidth, regionHeight),
+		image.Rect(width-regionWidth, 0, width, regionHeight),
+		image.Rect(0, height-regionHeight, regionWidth, height),
+		image.Rect(width-regionWidth, height-regionHeight, width, height),
+	}
+	names := [4]string{"top left", "top right", "bottom left", "bottom right"}
+
+	for i, region := range regions {
+		center, ok := r.findSquare(region.Add(bounds.Min), size)
+		if !ok {
+			return fmt.Errorf("corner square %s not found; is this an answer sheet?", names[i])
+		}
+		r.corners[i] = center
+	}
+	return nil
+}
+
+// findSquare returns the center of the darkest size×size area in a region
+// when it is mostly dark, refined to the centroid of the dark pixels there
+func (r *paperSheetReader) findSquare(region image.Rectangle, size int) ([2]float64, bool) {
+	w, h := region.Dx(), region.Dy()
+	if w < size || h < size {
+		return [2]float64{}, false
+	}
+
+	// Summed area table of dark pixels
+	sums := make([]int32, (w+1)*(h+1))
+	for y := 0; y < h; y++ {
+		var rowSum int32
+		for x := 0; x < w; x++ {
+			if r.dark(region.Min.X+x, region.Min.Y+y) {
+				rowSum++
+			}
+			sums[(y+1)*(w+1)+x+1] = sums[y*(w+1)+x+1] + rowSum
+		}
+	}
+	area := func(x0, y0, x1, y1 int) int32 {
+		return sums[y1*(w+1)+x1] - sums[y0*(w+1)+x1] - sums[y1*(w+1)+x0] + sums[y0*(w+1)+x0]
+	}
+
+	best, bestX, bestY := int32(-1), 0, 0
+	for y := 0; y+size <= h; y++ {
+		for x := 0; x+size <= w; x++ {
+			if count := area(x, y, x+size, y+size); count > best {
+				best, bestX, bestY = count, x, y
+			}
+		}
+	}
+	if float64(best) < 0.6*float64(size*size) {
+		return [2]float64{}, false
+	}
+
+	// The window may sit anywhere on a square bigger than expected, so take
+	// the centroid of the dark pixels around it
+	margin := size / 2
+	x0, y0 := max(bestX-margin, 0), max(bestY-margin, 0)
+	x1, y1 := min(bestX+size+margin, w), min(bestY+size+margin, h)
+	var sumX, sumY, count float64
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			if r.dark(region.Min.X+x, region.Min.Y+y) {
+				sumX += float64(x)
+				sumY += float64(y)
+				count++
+			}
+		}
+	}
+	return [2]float64{float64(region.Min.X) + sumX/count + 0.5, float64(region.Min.Y) + sumY/count + 0.5}, true
+}
+
+// toImage maps a sheet position into the scan by bilinear interpolation
+// between the corner squares, which also absorbs rotation and skew
+func (r *paperSheetReader) toImage(x, y float64) (int, int) {
+	fiducials := paperFiducials()
+	s := (x - float64(fiducials[0].X)) / float64(fiducials[1].X-fiducials[0].X)
+	t := (y - float64(fiducials[0].Y)) / float64(fiducials[2].Y-fiducials[0].Y)
+
+	var point [2]float64
+	weights := [4]float64{(1 - s) * (1 - t), s * (1 - t), (1 - s) * t, s * t}
+	for i, weight := range weights {
+		point[0] += weight * r.corners[i][0]
+		point[1] += weight * r.corners[i][1]
+	}
+	return int(point[0]), int(point[1])
+}
+
+// fill returns the dark share of a sheet rectangle, leaving out an inset on
+// every side so printed borders do not count
+func (r *paperSheetReader) fill(rect image.Rectangle, inset float64) float64 {
+	const samples = 10
+	width, height := float64(rect.Dx()), float64(rect.Dy())
+	dark := 0
+	for i := 0; i < samples; i++ {
+		for j := 0; j < samples; j++ {
+			x := float64(rect.Min.X) + width*(inset+(1-2*inset)*(float64(i)+0.5)/samples)
+			y := float64(rect.Min.Y) + height*(inset+(1-2*inset)*(float64(j)+0.5)/samples)
+			if r.dark(r.toImage(x, y)) {
+				dark++
+			}
+		}
+	}
+	return float64(dark) / (samples * samples)
+}
+
+// toGray converts any image to grayscale
+func toGray(img image.Image) *image.Gray {
+	if gray, ok := img.(*image.Gray); ok {
+		return gray
+	}
+	bounds := img.Bounds()
+	gray := image.NewGray(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			gray.Set(x, y, img.At(x, y))
+		}
+	}
+	return gray
+}
+
+// otsuThreshold picks the gray level separating ink from paper
+func otsuThreshold(img *image.Gray) uint8 {
+	var histogram [256]float64
+	for _, value := range img.Pix {
+		histogram[value]++
+	}
+
+	total := float64(len(img.Pix))
+	var sumAll float64
+	for level, count := range histogram {
+		sumAll += float64(level) * count
+	}
+
+	var sumBackground, weightBackground, bestVariance float64
+	threshold := 128
+	for level := 0; level < 256; level++ {
+		weightBackground += histogram[level]
+		if weightBackground == 0 {
+			continue
+		}
+		weightForeground := total - weightBackground
+		if weightForeground == 0 {
+			break
+		}
+		sumBackground += float64(level) * histogram[level]
+		meanBackground := sumBackground / weightBackground
+		meanForeground := (sumAll - sumBackground) / weightForeground
+		variance := weightBackground * weightForeground * (meanBackground - meanForeground) * (meanBackground - meanForeground)
+		if variance > bestVariance {
+			bestVariance = variance
+			threshold = level + 1
+		}
+	}
+	return uint8(min(threshold, 255))
+}
+
+func fillRect(img *image.Gray, rect image.Rectangle, c color.Gray) {
+	rect = rect.Intersect(img.Bounds())
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			img.SetGray(x, y, c)
+		}
+	}
+}
+
+func strokeRect(img *image.Gray, rect image.Rectangle, width int) {
+	black := color.Gray{}
+	fillRect(img, image.Rect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Min.Y+width), black)
+	fillRect(img, image.Rect(rect.Min.X, rect.Max.Y-width, rect.Max.X, rect.Max.Y), black)
+	fillRect(img, image.Rect(rect.Min.X, rect.Min.Y, rect.Min.X+width, rect.Max.Y), black)
+	fillRect(img, image.Rect(rect.Max.X-width, rect.Min.Y, rect.Max.X, rect.Max.Y), black)
+}
+
+// drawLine draws a line three pixels thick
+func drawLine(img *image.Gray, x0, y0, x1, y1 int) {
+	steps := max(abs(x1-x0), abs(y1-y0), 1)
+	for i := 0; i <= steps; i++ {
+		x := x0 + (x1-x0)*i/steps
+		y := y0 + (y1-y0)*i/steps
+		fillRect(img, image.Rect(x-1, y-1, x+2, y+2), color.Gray{})
+	}
+}
+
+func drawTick(img *image.Gray, x, y int) {
+	drawLine(img, x, y+10, x+6, y+18)
+	drawLine(img, x+6, y+18, x+18, y)
+}
+
+func drawCross(img *image.Gray, x, y int) {
+	drawLine(img, x, y, x+18, y+18)
+	drawLine(img, x+18, y, x, y+18)
+}
+
+// paperDigits is a 3×5 pixel font for row numbers, one string per row of
+// each digit
+var paperDigits = [10][5]string{
+	{"###", "#.#", "#.#", "#.#", "###"},
+	{".#.", "##.", ".#.", ".#.", "###"},
+	{"###", "..#", "###", "#..", "###"},
+	{"###", "..#", "###", "..#", "###"},
+	{"#.#", "#.#", "###", "..#", "..#"},
+	{"###", "#..", "###", "..#", "###"},
+	{"###", "#..", "###", "#.#", "###"},
+	{"###", "..#", ".#.", ".#.", ".#."},
+	{"###", "#.#", "###", "#.#", "###"},
+	{"###", "#.#", "###", "..#", "###"},
+}
+
+// drawNumber draws a number right aligned to x with 3 pixel font cells
+func drawNumber(img *image.Gray, number, x, y int) {
+	const cell = 3
+	digits := fmt.Sprint(number)
+	x -= len(digits) * 4 * cell
+	for _, digit := range digits {
+		for row, line := range paperDigits[digit-'0'] {
+			for col, pixel := range line {
+				if pixel == '#' {
+					px, py := x+col*cell, y+row*cell
+					fillRect(img, image.Rect(px, py, px+cell, py+cell), color.Gray{})
+				}
+			}
+		}
+		x += 4 * cell
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,125 @@
+package lesson
+
+import (
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func paperTestLesson(items int) *LessonData {
+	lessonData := NewLessonData()
+	lessonData.List.Title = "Capitals"
+	for i := 0; i < items; i++ {
+		lessonData.List.AddWordItem([]string{strings.Repeat("q", i+1)}, []string{"a"}, "")
+	}
+	return lessonData
+}
+
+// scanSheet simulates printing and scanning: the sheet is shrunk, rotated
+// and shifted onto a page of the given size
+func scanSheet(sheet *image.Gray, width, height int, angle float64) *image.Gray {
+	scan := image.NewGray(image.Rect(0, 0, width, height))
+	scale := float64(paperSheetWidth) / float64(width) * 1.04
+	sin, cos := math.Sin(angle), math.Cos(angle)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			dx, dy := float64(x-width/2)*scale, float64(y-height/2)*scale
+			sx := int(cos*dx-sin*dy) + paperSheetWidth/2
+			sy := int(sin*dx+cos*dy) + paperSheetHeight/2
+			value := uint8(235) // grayish scanner background
+			if (image.Point{sx, sy}.In(sheet.Rect)) && sheet.GrayAt(sx, sy).Y < 128 {
+				value = 20
+			}
+			scan.SetGray(x, y, color.Gray{Y: value})
+		}
+	}
+	return scan
+}
+
+func TestPaperTestRoundTrip(t *testing.T) {
+	lessonData := paperTestLesson(PaperTestRowsPerSheet + 3)
+
+	sheets := PaperTestSheets(lessonData)
+	if len(sheets) != 2 {
+		t.Fatalf("Expected 2 sheets, got %d", len(sheets))
+	}
+
+	// Grade the first sheet: tick item 1, cross item 2, leave item 3 open
+	// and cross item 40 in the second column
+	first := sheets[0]
+	box := paperBox(0, false)
+	drawTick(first, box.Min.X+4, box.Min.Y+4)
+	box = paperBox(1, true)
+	drawCross(first, box.Min.X+4, box.Min.Y+4)
+	box = paperBox(39, true)
+	drawCross(first, box.Min.X+4, box.Min.Y+4)
+
+	scan := scanSheet(first, 1100, 1556, 0.012)
+	scanFile := filepath.Join(t.TempDir(), "scan.jpg")
+	file, err := os.Create(scanFile)
+	if err != nil {
+		t.Fatalf("Failed to create scan: %v", err)
+	}
+	if err := jpeg.Encode(file, scan, &jpeg.Options{Quality: 80}); err != nil {
+		t.Fatalf("Failed to encode scan: %v", err)
+	}
+	file.Close()
+
+	result, err := NewFileLoader().LoadPaperTestScan(scanFile)
+	if err != nil {
+		t.Fatalf("Failed to read scan: %v", err)
+	}
+	if result.Checksum != PaperTestChecksum(lessonData) || result.Page != 0 {
+		t.Fatalf("Wrong sheet identity: checksum %x page %d", result.Checksum, result.Page)
+	}
+
+	test, unmarked, err := ImportPaperTestScans(lessonData, result)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if len(test.Results) != 3 || len(lessonData.List.Tests) != 1 {
+		t.Fatalf("Expected 3 results, got %+v", test.Results)
+	}
+	want := map[int]string{0: "right", 1: "wrong", 39: "wrong"}
+	for _, result := range test.Results {
+		if want[result.ItemID] != result.Result {
+			t.Errorf("Item %d read as %s; want %s", result.ItemID, result.Result, want[result.ItemID])
+		}
+	}
+	if len(unmarked) != PaperTestRowsPerSheet-3 || unmarked[0] != 2 {
+		t.Errorf("Unexpected unmarked items: %v", unmarked)
+	}
+
+	// A sheet of an edited lesson is refused
+	lessonData.List.Items[0].Questions = []string{"changed"}
+	if _, _, err := ImportPaperTestScans(lessonData, result); err == nil {
+		t.Error("Expected an error importing a sheet of a different lesson")
+	}
+}
+
+func TestReadPaperTestSheetRejectsOtherImages(t *testing.T) {
+	blank := image.NewGray(image.Rect(0, 0, 600, 800))
+	fillRect(blank, blank.Bounds(), color.Gray{Y: 255})
+	if _, err := ReadPaperTestSheet(blank); err == nil {
+		t.Error("Expected an error for an image without corner squares")
+	}
+}
+
+func TestSavePaperTest(t *testing.T) {
+	htmlFile := filepath.Join(t.TempDir(), "test.html")
+	if err := NewFileSaver().SavePaperTest(paperTestLesson(3), htmlFile); err != nil {
+		t.Fatalf("Failed to save paper test: %v", err)
+	}
+	content, err := os.ReadFile(htmlFile)
+	if err != nil {
+		t.Fatalf("Failed to read paper test: %v", err)
+	}
+	if strings.Count(string(content), "<li>") != 3 || !strings.Contains(string(content), "data:image/png;base64,") {
+		t.Error("Paper test lacks questions or answer sheet")
+	}
+}
@@ -0,0 +1,303 @@
+package lesson
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Pauker (http://pauker.sourceforge.net) stores a lesson as XML, normally
+// gzipped as .pau.gz. Cards live in batches: the first batch holds unlearned
+// cards, the next two are Pauker's ultra-short and short-term memory, and
+// every following batch is a long-term memory box with a longer interval.
+
+// paukerFirstLongTermBatch is the index of the first long-term memory batch
+const paukerFirstLongTermBatch = 3
+
+// gzipMagic starts every gzip stream
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// openMaybeGzip opens a file and transparently decompresses it when its
+// content is gzipped, regardless of the file extension
+func openMaybeGzip(filePath string) (io.ReadCloser, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(file)
+	magic, _ := reader.Peek(len(gzipMagic))
+	if !bytes.Equal(magic, gzipMagic) {
+		return struct {
+			io.Reader
+			io.Closer
+		}{reader, file}, nil
+	}
+
+	gzipReader, err := gzip.NewReader(reader)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{gzipReader, closerFunc(func() error {
+		gzipReader.Close()
+		return file.Close()
+	})}, nil
+}
+
+// closerFunc adapts a function to io.Closer
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+// loadGzipFile loads a gzipped lesson by decompressing it and loading the
+// result according to the extension before ".gz"
+func (fl *FileLoader) loadGzipFile(filePath string) (*LessonData, error) {
+	inner := strings.TrimSuffix(filePath, filepath.Ext(filePath))
+	innerExt := strings.ToLower(filepath.Ext(inner))
+
+	// Pauker writes both .pau.gz and .xml.gz
+	if innerExt == ".pau" || innerExt == ".xml" {
+		return fl.loadPaukerFile(filePath)
+	}
+
+	log.Printf("[ACTION] FileLoader.loadGzipFile() - decompressing %s file", innerExt)
+
+	reader, err := openMaybeGzip(filePath)
+	if err != nil {
+		log.Printf("[ERROR] Failed to open gzip file: %v", err)
+		return nil, err
+	}
+	defer reader.Close()
+
+	tmpFile, err := os.CreateTemp("", "recuerdo-*"+innerExt)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	_, err = io.Copy(tmpFile, reader)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to decompress gzip file: %v", err)
+		return nil, err
+	}
+
+	lessonData, err := fl.LoadFile(tmpFile.Name())
+	if err != nil {
+		return nil, err
+	}
+	if lessonData.List.Title == "" || lessonData.List.Title == filepath.Base(tmpFile.Name()) {
+		lessonData.List.Title = strings.TrimSuffix(filepath.Base(inner), innerExt)
+	}
+	return lessonData, nil
+}
+
+// paukerSide is the front or back of a Pauker card. Old files put the text
+// directly in the element, newer ones in a Text child.
+type paukerSide struct {
+	Text   string `xml:"Text"`
+	Inline string `xml:",chardata"`
+}
+
+func (s paukerSide) text() string {
+	if text := strings.TrimSpace(s.Text); text != "" {
+		return text
+	}
+	return strings.TrimSpace(s.Inline)
+}
+
+// loadPaukerFile loads a Pauker lesson (.pau, .pau.gz or .xml.gz)
+func (fl *FileLoader) loadPaukerFile(filePath string) (*LessonData, error) {
+	log.Printf("[ACTION] FileLoader.loadPaukerFile() - parsing Pauker lesson")
+
+	reader, err := openMaybeGzip(filePath)
+	if err != nil {
+		log.Printf("[ERROR] Failed to open Pauker file: %v", err)
+		return nil, err
+	}
+	defer reader.Close()
+
+	var root struct {
+		XMLName     xml.Name `xml:"Lesson"`
+		Description string   `xml:"Description"`
+		Batches     []struct {
+			Cards []struct {
+				FrontSide   paukerSide `xml:"FrontSide"`
+				BackSide    paukerSide `xml:"BackSide"`
+				ReverseSide paukerSide `xml:"ReverseSide"`
+			} `xml:"Card"`
+		} `xml:"Batch"`
+	}
+	if err := xml.NewDecoder(reader).Decode(&root); err != nil {
+		log.Printf("[ERROR] Failed to parse Pauker XML: %v", err)
+		return nil, err
+	}
+
+	lessonData := NewLessonData()
+	// Only the first line, because a description can be pretty long in Pauker
+	lessonData.List.Title = strings.TrimSpace(strings.SplitN(root.Description, "\n", 2)[0])
+	if lessonData.List.Title == "" {
+		name := filepath.Base(filePath)
+		lessonData.List.Title = strings.TrimSuffix(strings.TrimSuffix(name, filepath.Ext(name)), ".pau")
+	}
+
+	for _, batch := range root.Batches {
+		for _, card := range batch.Cards {
+			answer := card.BackSide.text()
+			if answer == "" {
+				answer = card.ReverseSide.text()
+			}
+			// Further lines on the back side are kept as the comment
+			answer, comment, _ := strings.Cut(answer, "\n")
+			questions := fl.parseWordString(card.FrontSide.text())
+			answers := fl.parseWordString(answer)
+			if len(questions) == 0 && len(answers) == 0 {
+				continue
+			}
+			lessonData.List.Items = append(lessonData.List.Items, WordItem{
+				ID:        len(lessonData.List.Items),
+				Questions: questions,
+				Answers:   answers,
+				Comment:   strings.TrimSpace(comment),
+			})
+		}
+	}
+
+	log.Printf("[SUCCESS] FileLoader.loadPaukerFile() - loaded %d cards", len(lessonData.List.Items))
+	return lessonData, nil
+}
+
+// paukerBox returns the long-term batch number (0 being the first long-term
+// box) an item has reached and when it was last answered correctly. Every
+// consecutive right answer moves a card one box further, like repeating it in
+// Pauker; a wrong answer sends it back to the unlearned batch (-1).
+func paukerBox(lessonData *LessonData, itemID int) (int, *time.Time) {
+	box := -1
+	var learned *time.Time
+	for _, test := range lessonData.List.Tests {
+		for _, result := range test.Results {
+			if result.ItemID != itemID {
+				continue
+			}
+			if result.Result == "right" {
+				box++
+				learned = result.Time
+				if learned == nil || learned.IsZero() {
+					learned = test.Date
+				}
+			} else {
+				box = -1
+				learned = nil
+			}
+		}
+	}
+	return box, learned
+}
+
+// savePaukerFile saves lesson data as a Pauker lesson, gzipped when the
+// file name ends in .gz. Test results decide which batch each card is in.
+// Pauker cards have no comment field, so comments are added below the
+// answer on the back side.
+func (fs *FileSaver) savePaukerFile(lessonData *LessonData, filePath string) error {
+	log.Printf("[ACTION] FileSaver.savePaukerFile() - saving Pauker lesson")
+
+	type paukerCardSide struct {
+		LearnedTimestamp string `xml:"LearnedTimestamp,attr,omitempty"`
+		Orientation      string `xml:"Orientation,attr"`
+		RepeatByTyping   string `xml:"RepeatByTyping,attr"`
+		Text             string `xml:"Text"`
+	}
+	type paukerCard struct {
+		FrontSide   paukerCardSide `xml:"FrontSide"`
+		ReverseSide paukerCardSide `xml:"ReverseSide"`
+	}
+	type paukerBatch struct {
+		Cards []paukerCard `xml:"Card"`
+	}
+	type paukerLesson struct {
+		XMLName      xml.Name      `xml:"Lesson"`
+		LessonFormat string        `xml:"LessonFormat,attr"`
+		Description  string        `xml:"Description"`
+		Batches      []paukerBatch `xml:"Batch"`
+	}
+
+	pauker := paukerLesson{
+		LessonFormat: "1.7",
+		Description:  lessonData.List.Title,
+		Batches:      make([]paukerBatch, paukerFirstLongTermBatch),
+	}
+
+	for _, item := range lessonData.List.Items {
+		back := strings.Join(item.Answers, "; ")
+		if comment := strings.TrimSpace(item.Comment); comment != "" {
+			back += "\n" + comment
+		}
+		card := paukerCard{
+			FrontSide:   paukerCardSide{Orientation: "LTR", RepeatByTyping: "false", Text: strings.Join(item.Questions, "; ")},
+			ReverseSide: paukerCardSide{Orientation: "LTR", RepeatByTyping: "false", Text: back},
+		}
+
+		batch := 0
+		if box, learned := paukerBox(lessonData, item.ID); box >= 0 {
+			batch = paukerFirstLongTermBatch + box
+			learnedAt := time.Now()
+			if learned != nil && !learned.IsZero() {
+				learnedAt = *learned
+			}
+			card.FrontSide.LearnedTimestamp = fmt.Sprint(learnedAt.UnixMilli())
+		}
+		for len(pauker.Batches) <= batch {
+			pauker.Batches = append(pauker.Batches, paukerBatch{})
+		}
+		pauker.Batches[batch].Cards = append(pauker.Batches[batch].Cards, card)
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		log.Printf("[ERROR] Failed to create Pauker file: %v", err)
+		return err
+	}
+	defer file.Close()
+
+	var writer io.Writer = file
+	var gzipWriter *gzip.Writer
+	if strings.HasSuffix(strings.ToLower(filePath), ".gz") {
+		gzipWriter = gzip.NewWriter(file)
+		writer = gzipWriter
+	}
+
+	header := `<?xml version="1.0" encoding="UTF-8" standalone="no"?>` + "\n" +
+		"<!--This is a lesson file for Pauker (http://pauker.sourceforge.net)-->\n"
+	if _, err := io.WriteString(writer, header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(writer)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(pauker); err != nil {
+		log.Printf("[ERROR] Failed to write Pauker XML: %v", err)
+		return err
+	}
+	if gzipWriter != nil {
+		if err := gzipWriter.Close(); err != nil {
+			log.Printf("[ERROR] Failed to compress Pauker file: %v", err)
+			return err
+		}
+	}
+
+	log.Printf("[SUCCESS] FileSaver.savePaukerFile() - saved %d cards in %d batches", len(lessonData.List.Items), len(pauker.Batches))
+	return nil
+}
@@ -0,0 +1,136 @@
+package lesson
+
+import (
+	"compress/gzip"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadPaukerFile(t *testing.T) {
+	loader := NewFileLoader()
+
+	lessonData, err := loader.LoadFile(filepath.Join("../../testdata", "legacy_files", "application_x-pauker.pauker.pau.gz"))
+	if err != nil {
+		t.Fatalf("Failed to load gzipped Pauker file: %v", err)
+	}
+	if len(lessonData.List.Items) != 2 {
+		t.Fatalf("Expected 2 cards, got %d", len(lessonData.List.Items))
+	}
+	if lessonData.List.Items[0].Questions[0] != "éen" || lessonData.List.Items[1].Answers[0] != "two" {
+		t.Errorf("Unexpected cards: %+v", lessonData.List.Items)
+	}
+
+	// Pauker files renamed to .pau are often still gzipped
+	renamed := filepath.Join(t.TempDir(), "renamed.pau")
+	content, err := os.ReadFile(filepath.Join("../../testdata", "legacy_files", "application_x-pauker.pauker.pau.gz"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	if err := os.WriteFile(renamed, content, 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	if lessonData, err := loader.LoadFile(renamed); err != nil || len(lessonData.List.Items) != 2 {
+		t.Errorf("Failed to load gzipped file with .pau extension: %v", err)
+	}
+}
+
+func TestLoadGzipFileUsesInnerExtension(t *testing.T) {
+	gzFile := filepath.Join(t.TempDir(), "words.csv.gz")
+	file, err := os.Create(gzFile)
+	if err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	writer := gzip.NewWriter(file)
+	io.WriteString(writer, "house,Haus\ntree,Baum\n")
+	writer.Close()
+	file.Close()
+
+	lessonData, err := NewFileLoader().LoadFile(gzFile)
+	if err != nil {
+		t.Fatalf("Failed to load gzipped CSV: %v", err)
+	}
+	if len(lessonData.List.Items) != 2 || lessonData.List.Title != "words" {
+		t.Errorf("Unexpected lesson: %q with %d items", lessonData.List.Title, len(lessonData.List.Items))
+	}
+}
+
+func TestFileSaver_SavePaukerFile(t *testing.T) {
+	lessonData := NewLessonData()
+	lessonData.List.Title = "Numbers"
+	lessonData.List.AddWordItem([]string{"one"}, []string{"een"}, "")
+	lessonData.List.AddWordItem([]string{"two"}, []string{"twee"}, "not 'to'")
+	lessonData.List.AddWordItem([]string{"three"}, []string{"drie"}, "")
+
+	answeredAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	lessonData.List.Tests = []Test{
+		{Results: []TestResult{
+			{Result: "right", ItemID: 0, Time: &answeredAt},
+			{Result: "right", ItemID: 1, Time: &answeredAt},
+			{Result: "right", ItemID: 2, Time: &answeredAt},
+		}},
+		{Results: []TestResult{
+			{Result: "right", ItemID: 0, Time: &answeredAt},
+			{Result: "wrong", ItemID: 2, Time: &answeredAt},
+		}},
+	}
+
+	paukerFile := filepath.Join(t.TempDir(), "numbers.pau.gz")
+	if err := NewFileSaver().SaveFile(lessonData, paukerFile); err != nil {
+		t.Fatalf("Failed to save Pauker file: %v", err)
+	}
+
+	file, err := os.Open(paukerFile)
+	if err != nil {
+		t.Fatalf("Failed to open saved file: %v", err)
+	}
+	defer file.Close()
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("Saved file is not gzipped: %v", err)
+	}
+
+	var root struct {
+		Batches []struct {
+			Cards []struct {
+				FrontSide struct {
+					Text    string `xml:"Text"`
+					Learned string `xml:"LearnedTimestamp,attr"`
+				}
+			} `xml:"Card"`
+		} `xml:"Batch"`
+	}
+	if err := xml.NewDecoder(gzipReader).Decode(&root); err != nil {
+		t.Fatalf("Saved file is not valid XML: %v", err)
+	}
+
+	// "three" was answered wrong last, "two" once right, "one" twice right
+	if len(root.Batches) != 5 {
+		t.Fatalf("Expected 5 batches, got %d", len(root.Batches))
+	}
+	if len(root.Batches[0].Cards) != 1 || root.Batches[0].Cards[0].FrontSide.Text != "three" {
+		t.Errorf("Expected 'three' in the unlearned batch: %+v", root.Batches[0])
+	}
+	if len(root.Batches[3].Cards) != 1 || root.Batches[3].Cards[0].FrontSide.Text != "two" {
+		t.Errorf("Expected 'two' in the first long-term batch: %+v", root.Batches[3])
+	}
+	if len(root.Batches[4].Cards) != 1 || root.Batches[4].Cards[0].FrontSide.Learned != "1709294400000" {
+		t.Errorf("Expected 'one' in the second long-term batch with its timestamp: %+v", root.Batches[4])
+	}
+
+	reloaded, err := NewFileLoader().LoadFile(paukerFile)
+	if err != nil {
+		t.Fatalf("Failed to reload Pauker file: %v", err)
+	}
+	if reloaded.List.Title != "Numbers" || len(reloaded.List.Items) != 3 {
+		t.Fatalf("Unexpected reloaded lesson: %+v", reloaded.List)
+	}
+	for _, item := range reloaded.List.Items {
+		if item.Questions[0] == "two" && item.Comment != "not 'to'" {
+			t.Errorf("Comment not preserved: %+v", item)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package lesson
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ImportDraft holds rows recovered from a document that still need to be
+// reviewed before they become a lesson. Rejected keeps the lines that could
+// not be split into columns so the preview can show what was left out.
+type ImportDraft struct {
+	Title    string
+	Source   string // "text" when read from the text layer, "ocr" otherwise, "align" for aligned texts
+	Rows     []ImportRow
+	Rejected []string
+	// Sentences keeps every question and answer whole, as for aligned
+	// sentences, instead of splitting it into synonyms at commas
+	Sentences bool
+}
+
+// ImportRow is a single question/answer candidate in an ImportDraft
+type ImportRow struct {
+	Question string
+	Answer   string
+	Comment  string
+	Include  bool
+}
+
+// PDFTextExtractor returns the layout-preserving text of a PDF's text layer
+type PDFTextExtractor func(filePath string) (string, error)
+
+// PDFOCRFunc recognizes text in a scanned PDF that has no text layer
+type PDFOCRFunc func(filePath string) (string, error)
+
+// ImageOCRFunc recognizes text in a picture, such as a screenshot
+type ImageOCRFunc func(imagePath string) (string, error)
+
+// columnGapPattern splits a layout line on runs of two or more spaces or tabs
+var columnGapPattern = regexp.MustCompile(`\t+| {2,}`)
+
+// PDFImporter turns tables in PDF files, and in pictures of them, into
+// import drafts
+type PDFImporter struct {
+	ExtractText PDFTextExtractor
+	OCR         PDFOCRFunc
+	OCRImage    ImageOCRFunc
+	fileLoader  *FileLoader
+}
+
+// NewPDFImporter creates an importer using pdftotext for the text layer,
+// pdftoppm plus tesseract for scanned documents and tesseract for pictures
+func NewPDFImporter() *PDFImporter {
+	return &PDFImporter{
+		ExtractText: pdfToText,
+		OCR:         ocrPDF,
+		OCRImage:    ocrImage,
+		fileLoader:  NewFileLoader(),
+	}
+}
+
+// Draft reads a PDF and detects two-column rows, falling back to OCR when
+// the text layer is empty
+func (pi *PDFImporter) Draft(filePath string) (*ImportDraft, error) {
+	log.Printf("[ACTION] PDFImporter.Draft() - extracting tables from %s", filePath)
+
+	draft := &ImportDraft{
+		Title:  strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath)),
+		Source: "text",
+	}
+
+	text, err := pi.ExtractText(filePath)
+	if err != nil {
+		log.Printf("[WARNING] PDF text extraction failed: %v", err)
+	}
+
+	if strings.TrimSpace(text) == "" {
+		if pi.OCR == nil {
+			return nil, fmt.Errorf("PDF has no text layer and no OCR is available")
+		}
+		log.Printf("[INFO] PDF has no text layer, falling back to OCR")
+		text, err = pi.OCR(filePath)
+		if err != nil {
+			log.Printf("[ERROR] OCR of PDF failed: %v", err)
+			return nil, err
+		}
+		draft.Source = "ocr"
+	}
+
+	draft.Rows, draft.Rejected = DetectTwoColumnRows(text)
+	if len(draft.Rows) == 0 {
+		return nil, fmt.Errorf("no two-column table found in %s", filepath.Base(filePath))
+	}
+
+	log.Printf("[SUCCESS] PDFImporter.Draft() - detected %d rows, rejected %d lines", len(draft.Rows), len(draft.Rejected))
+	return draft, nil
+}
+
+// DraftImage recognizes a table in a picture, such as a pasted screenshot
+// of a textbook page or of a word list on a website, and detects its
+// two-column rows like Draft does for scans
+func (pi *PDFImporter) DraftImage(imagePath, title string) (*ImportDraft, error) {
+	log.Printf("[ACTION] PDFImporter.DraftImage() - recognizing a table in %s", imagePath)
+	if pi.OCRImage == nil {
+		return nil, fmt.Errorf("no OCR is available to read pictures")
+	}
+	text, err := pi.OCRImage(imagePath)
+	if err != nil {
+		log.Printf("[ERROR] OCR of picture failed: %v", err)
+		return nil, err
+	}
+
+	draft := &ImportDraft{Title: title, Source: "ocr"}
+	draft.Rows, draft.Rejected = DetectTwoColumnRows(text)
+	if len(draft.Rows) == 0 {
+		return nil, fmt.Errorf("no two-column table found in the picture")
+	}
+
+	log.Printf("[SUCCESS] PDFImporter.DraftImage() - detected %d rows, rejected %d lines", len(draft.Rows), len(draft.Rejected))
+	return draft, nil
+}
+
+// DetectTwoColumnRows splits layout text into question/answer rows. Lines
+// with a third column keep it as the comment; all other lines are rejected.
+// Lines whose column split is far from the dominant one are not included by
+// default, since they are usually headings or running text.
+func DetectTwoColumnRows(text string) ([]ImportRow, []string) {
+	type candidate struct {
+		row    ImportRow
+		offset int
+	}
+
+	var candidates []candidate
+	var rejected []string
+	offsets := make(map[int]int)
+
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.TrimRight(line, " \t\f")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		trimmed := strings.TrimLeft(line, " \t")
+		indent := len(line) - len(trimmed)
+		cells := columnGapPattern.Split(trimmed, -1)
+		if len(cells) < 2 || len(cells) > 3 {
+			rejected = append(rejected, strings.TrimSpace(line))
+			continue
+		}
+
+		loc := columnGapPattern.FindStringIndex(trimmed)
+		offset := indent + loc[1]
+		offsets[offset]++
+
+		row := ImportRow{
+			Question: strings.TrimSpace(cells[0]),
+			Answer:   strings.TrimSpace(cells[1]),
+			Include:  true,
+		}
+		if len(cells) == 3 {
+			row.Comment = strings.TrimSpace(cells[2])
+		}
+		candidates = append(candidates, candidate{row: row, offset: offset})
+	}
+
+	// Find the dominant start position of the second column
+	dominant, best := 0, 0
+	keys := make([]int, 0, len(offsets))
+	for offset := range offsets {
+		keys = append(keys, offset)
+	}
+	sort.Ints(keys)
+	for _, offset := range keys {
+		if offsets[offset] > best {
+			dominant, best = offset, offsets[offset]
+		}
+	}
+
+	rows := make([]ImportRow, 0, len(candidates))
+	for _, c := range candidates {
+		diff := c.offset - dominant
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > 8 {
+			c.row.Include = false
+		}
+		rows = append(rows, c.row)
+	}
+
+	return rows, rejected
+}
+
+// ToLessonData converts the included rows of a reviewed draft into lesson data
+func (d *ImportDraft) ToLessonData() *LessonData {
+	split := NewFileLoader().parseWordString
+	if d.Sentences {
+		split = func(cell string) []string {
+			if cell = strings.TrimSpace(cell); cell != "" {
+				return []string{cell}
+			}
+			return nil
+		}
+	}
+	lessonData := NewLessonData()
+	lessonData.List.Title = d.Title
+
+	for _, row := range d.Rows {
+		if !row.Include {
+			continue
+		}
+		questions := split(row.Question)
+		answers := split(row.Answer)
+		if len(questions) == 0 || len(answers) == 0 {
+			continue
+		}
+		lessonData.List.Items = append(lessonData.List.Items, WordItem{
+			ID:        len(lessonData.List.Items),
+			Questions: questions,
+			Answers:   answers,
+			Comment:   row.Comment,
+		})
+	}
+
+	lessonData.Changed = true
+	return lessonData
+}
+
+// Preview renders the draft as aligned text for a review step, marking
+// excluded rows with a leading dash
+func (d *ImportDraft) Preview() string {
+	width := 0
+	for _, row := range d.Rows {
+		if len([]rune(row.Question)) > width {
+			width = len([]rune(row.Question))
+		}
+	}
+
+	var b strings.Builder
+	for i, row := range d.Rows {
+		mark := "+"
+		if !row.Include {
+			mark = "-"
+		}
+		padding := strings.Repeat(" ", width-len([]rune(row.Question)))
+		fmt.Fprintf(&b, "%s %3d  %s%s  %s", mark, i+1, row.Question, padding, row.Answer)
+		if row.Comment != "" {
+			fmt.Fprintf(&b, "  (%s)", row.Comment)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// loadPDFFile loads a PDF directly, accepting every row the detector
+// included. Interactive imports show PDFImporter.Draft for review instead.
+func (fl *FileLoader) loadPDFFile(filePath string) (*LessonData, error) {
+	log.Printf("[ACTION] FileLoader.loadPDFFile() - importing PDF table")
+
+	draft, err := NewPDFImporter().Draft(filePath)
+	if err != nil {
+		log.Printf("[ERROR] Failed to import PDF: %v", err)
+		return nil, err
+	}
+
+	lessonData := draft.ToLessonData()
+	lessonData.Changed = false
+
+	log.Printf("[SUCCESS] FileLoader.loadPDFFile() - loaded %d word pairs", len(lessonData.List.Items))
+	return lessonData, nil
+}
+
+// pdfToText runs pdftotext in layout mode so table columns stay aligned
+func pdfToText(filePath string) (string, error) {
+	var out bytes.Buffer
+	cmd := exec.Command("pdftotext", "-layout", "-enc", "UTF-8", filePath, "-")
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("pdftotext failed: %w", err)
+	}
+	return out.String(), nil
+}
+
+// ocrPDF renders every page with pdftoppm and recognizes it with tesseract
+func ocrPDF(filePath string) (string, error) {
+	tmpDir, err := os.MkdirTemp("", "recuerdo-pdf-ocr")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	prefix := filepath.Join(tmpDir, "page")
+	if err := exec.Command("pdftoppm", "-r", "300", "-png", filePath, prefix).Run(); err != nil {
+		return "", fmt.Errorf("pdftoppm failed: %w", err)
+	}
+
+	pages, err := filepath.Glob(prefix + "*.png")
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(pages)
+
+	var text strings.Builder
+	for _, page := range pages {
+		pageText, err := ocrImage(page)
+		if err != nil {
+			return "", err
+		}
+		text.WriteString(pageText)
+		text.WriteString("\n")
+	}
+	return text.String(), nil
+}
+
+// ocrImage recognizes the text in a picture with tesseract
+func ocrImage(imagePath string) (string, error) {
+	var out bytes.Buffer
+	// Page segmentation mode 6 keeps table rows on a single line
+	cmd := exec.Command("tesseract", imagePath, "stdout", "--psm", "6", "-c", "preserve_interword_spaces=1")
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("tesseract failed: %w", err)
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,128 @@
+package lesson
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const samplePDFLayout = `            Unit 3 - Animals
+
+   English          Spanish         Notes
+   dog              perro
+   cat              gato            feminine: gata
+   horse            caballo
+
+Page 1 of 1
+`
+
+func TestDetectTwoColumnRows(t *testing.T) {
+	rows, rejected := DetectTwoColumnRows(samplePDFLayout)
+
+	if len(rows) != 4 {
+		t.Fatalf("Expected 4 rows (header included), got %d: %+v", len(rows), rows)
+	}
+	if rows[2].Question != "cat" || rows[2].Answer != "gato" || rows[2].Comment != "feminine: gata" {
+		t.Errorf("Unexpected third row: %+v", rows[2])
+	}
+	for _, row := range rows {
+		if !row.Include {
+			t.Errorf("Expected row %q to be included", row.Question)
+		}
+	}
+
+	if len(rejected) != 2 {
+		t.Errorf("Expected 2 rejected lines, got %d: %v", len(rejected), rejected)
+	}
+}
+
+func TestDetectTwoColumnRowsExcludesOutliers(t *testing.T) {
+	text := "dog      perro\ncat      gato\nhorse    caballo\nA very long heading line                  1\n"
+
+	rows, _ := DetectTwoColumnRows(text)
+	if len(rows) != 4 {
+		t.Fatalf("Expected 4 rows, got %d", len(rows))
+	}
+	if rows[3].Include {
+		t.Error("Expected the misaligned row to be excluded by default")
+	}
+}
+
+func TestPDFImporterDraft(t *testing.T) {
+	importer := NewPDFImporter()
+	importer.ExtractText = func(string) (string, error) { return samplePDFLayout, nil }
+	importer.OCR = func(string) (string, error) {
+		t.Error("OCR should not be used when a text layer exists")
+		return "", nil
+	}
+
+	draft, err := importer.Draft("/tmp/animals.pdf")
+	if err != nil {
+		t.Fatalf("Draft failed: %v", err)
+	}
+	if draft.Title != "animals" || draft.Source != "text" {
+		t.Errorf("Unexpected draft metadata: title=%q source=%q", draft.Title, draft.Source)
+	}
+
+	// Reviewer drops the header row before accepting the draft
+	draft.Rows[0].Include = false
+	if !strings.HasPrefix(draft.Preview(), "-   1  English") {
+		t.Errorf("Preview should mark the excluded header row, got:\n%s", draft.Preview())
+	}
+
+	lessonData := draft.ToLessonData()
+	if len(lessonData.List.Items) != 3 {
+		t.Fatalf("Expected 3 items, got %d", len(lessonData.List.Items))
+	}
+	if lessonData.List.Items[0].Questions[0] != "dog" || lessonData.List.Items[1].Comment != "feminine: gata" {
+		t.Errorf("Unexpected items: %+v", lessonData.List.Items)
+	}
+}
+
+func TestPDFImporterFallsBackToOCR(t *testing.T) {
+	importer := NewPDFImporter()
+	importer.ExtractText = func(string) (string, error) { return "\f\n", fmt.Errorf("no text") }
+	importer.OCR = func(string) (string, error) { return "dog   perro\ncat   gato\n", nil }
+
+	draft, err := importer.Draft("scan.pdf")
+	if err != nil {
+		t.Fatalf("Draft failed: %v", err)
+	}
+	if draft.Source != "ocr" {
+		t.Errorf("Expected OCR source, got %q", draft.Source)
+	}
+	if len(draft.Rows) != 2 {
+		t.Errorf("Expected 2 rows, got %d", len(draft.Rows))
+	}
+
+	importer.OCR = func(string) (string, error) { return "nothing tabular", nil }
+	if _, err := importer.Draft("scan.pdf"); err == nil {
+		t.Error("Expected an error when no table can be detected")
+	}
+}
+
+func TestPDFImporterDraftImage(t *testing.T) {
+	importer := NewPDFImporter()
+	importer.OCRImage = func(path string) (string, error) {
+		if path != "screenshot.png" {
+			t.Errorf("Expected the picture to be read, got %s", path)
+		}
+		return "Unit 3\nhouse      casa\ntree       árbol     masculine\n", nil
+	}
+
+	draft, err := importer.DraftImage("screenshot.png", "Pasted")
+	if err != nil {
+		t.Fatalf("DraftImage failed: %v", err)
+	}
+	if draft.Title != "Pasted" || draft.Source != "ocr" || len(draft.Rows) != 2 || len(draft.Rejected) != 1 {
+		t.Errorf("Unexpected draft: %+v", draft)
+	}
+	if draft.Rows[1].Comment != "masculine" {
+		t.Errorf("Expected the third column as comment, got %+v", draft.Rows[1])
+	}
+
+	importer.OCRImage = func(string) (string, error) { return "just a sentence", nil }
+	if _, err := importer.DraftImage("screenshot.png", "Pasted"); err == nil {
+		t.Error("Expected an error when no table can be detected")
+	}
+}
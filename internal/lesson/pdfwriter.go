@@ -0,0 +1,224 @@
+package lesson
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// pdfDocument is a minimal PDF writer for printable exports. Text is set in
+// Go Regular, embedded as a CID font so any character the font has prints,
+// not only those of a single-byte encoding. Coordinates are PDF points with
+// the origin at the bottom left of the page.
+type pdfDocument struct {
+	width, height float64
+	font          *sfnt.Font
+	buf           sfnt.Buffer
+	glyphs        map[sfnt.GlyphIndex]rune // Glyphs used, with the character each shows
+	pages         []*bytes.Buffer
+}
+
+// pdfUnits is the size font metrics are read at: PDF measures glyphs in
+// thousandths of the font size
+const pdfUnits = 1000
+
+// newPDFDocument returns an empty document with pages of the given size
+func newPDFDocument(width, height float64) (*pdfDocument, error) {
+	f, err := sfnt.Parse(goregular.TTF)
+	if err != nil {
+		return nil, err
+	}
+	return &pdfDocument{width: width, height: height, font: f, glyphs: make(map[sfnt.GlyphIndex]rune)}, nil
+}
+
+// newPage starts a new page; drawing goes to the last page
+func (d *pdfDocument) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+// glyphIndex returns the glyph of r, 0 when the font lacks it
+func (d *pdfDocument) glyphIndex(r rune) sfnt.GlyphIndex {
+	index, err := d.font.GlyphIndex(&d.buf, r)
+	if err != nil {
+		return 0
+	}
+	return index
+}
+
+// advance returns the width of glyph in thousandths of the font size
+func (d *pdfDocument) advance(glyph sfnt.GlyphIndex) int {
+	advance, err := d.font.GlyphAdvance(&d.buf, glyph, fixed.I(pdfUnits), font.HintingNone)
+	if err != nil {
+		return 0
+	}
+	return advance.Round()
+}
+
+// textWidth returns the width of s set at size
+func (d *pdfDocument) textWidth(s string, size float64) float64 {
+	total := 0
+	for _, r := range s {
+		total += d.advance(d.glyphIndex(r))
+	}
+	return float64(total) * size / pdfUnits
+}
+
+// wrap breaks text into lines no wider than width at size, at spaces where
+// it can and inside words that are too long by themselves
+func (d *pdfDocument) wrap(text string, size, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if d.textWidth(candidate, size) <= width {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			line = ""
+			for _, r := range word {
+				if line != "" && d.textWidth(line+string(r), size) > width {
+					lines = append(lines, line)
+					line = ""
+				}
+				line += string(r)
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// text draws s with its baseline starting at x, y, in a grey level from 0
+// (black) to 1 (white)
+func (d *pdfDocument) text(x, y, size, gray float64, s string) {
+	var hex strings.Builder
+	for _, r := range s {
+		glyph := d.glyphIndex(r)
+		if _, ok := d.glyphs[glyph]; !ok {
+			d.glyphs[glyph] = r
+		}
+		fmt.Fprintf(&hex, "%04X", uint16(glyph))
+	}
+	fmt.Fprintf(d.pages[len(d.pages)-1], "BT %.3g g /F1 %.3g Tf %.2f %.2f Td <%s> Tj ET\n", gray, size, x, y, hex.String())
+}
+
+// line draws a thin line from x1, y1 to x2, y2, dashed if asked
+func (d *pdfDocument) line(x1, y1, x2, y2, gray float64, dashed bool) {
+	page := d.pages[len(d.pages)-1]
+	if dashed {
+		page.WriteString("[4 3] 0 d ")
+	} else {
+		page.WriteString("[] 0 d ")
+	}
+	fmt.Fprintf(page, "0.5 w %.3g G %.2f %.2f m %.2f %.2f l S\n", gray, x1, y1, x2, y2)
+}
+
+// pdfStream returns a PDF stream object holding data, compressed
+func pdfStream(data []byte, extra string) string {
+	var compressed bytes.Buffer
+	w := zlib.NewWriter(&compressed)
+	w.Write(data)
+	w.Close()
+	return fmt.Sprintf("<< /Length %d /Filter /FlateDecode%s >>\nstream\n%s\nendstream",
+		compressed.Len(), extra, compressed.String())
+}
+
+// write writes the document as a PDF file to w
+func (d *pdfDocument) write(w io.Writer) error {
+	glyphs := make([]sfnt.GlyphIndex, 0, len(d.glyphs))
+	for glyph := range d.glyphs {
+		glyphs = append(glyphs, glyph)
+	}
+	sort.Slice(glyphs, func(a, b int) bool { return glyphs[a] < glyphs[b] })
+
+	var widths, toUnicode strings.Builder
+	for _, glyph := range glyphs {
+		fmt.Fprintf(&widths, "%d [%d] ", glyph, d.advance(glyph))
+	}
+	toUnicode.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for start := 0; start < len(glyphs); start += 100 {
+		block := glyphs[start:min(start+100, len(glyphs))]
+		fmt.Fprintf(&toUnicode, "%d beginbfchar\n", len(block))
+		for _, glyph := range block {
+			fmt.Fprintf(&toUnicode, "<%04X> <", uint16(glyph))
+			for _, unit := range utf16.Encode([]rune{d.glyphs[glyph]}) {
+				fmt.Fprintf(&toUnicode, "%04X", unit)
+			}
+			toUnicode.WriteString(">\n")
+		}
+		toUnicode.WriteString("endbfchar\n")
+	}
+	toUnicode.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+
+	metrics, err := d.font.Metrics(&d.buf, fixed.I(pdfUnits), font.HintingNone)
+	if err != nil {
+		return err
+	}
+	bounds, err := d.font.Bounds(&d.buf, fixed.I(pdfUnits), font.HintingNone)
+	if err != nil {
+		return err
+	}
+
+	// Objects 1 to 7 are the catalog, the page tree and the font; each page
+	// follows as a page object and its content stream
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 8+2*i)
+	}
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %.2f %.2f] >>",
+			strings.Join(kids, " "), len(d.pages), d.width, d.height),
+		"<< /Type /Font /Subtype /Type0 /BaseFont /GoRegular /Encoding /Identity-H " +
+			"/DescendantFonts [4 0 R] /ToUnicode 7 0 R >>",
+		fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /GoRegular "+
+			"/CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> "+
+			"/FontDescriptor 5 0 R /CIDToGIDMap /Identity /DW 500 /W [%s] >>", strings.TrimSpace(widths.String())),
+		fmt.Sprintf("<< /Type /FontDescriptor /FontName /GoRegular /Flags 32 /FontBBox [%d %d %d %d] "+
+			"/ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 6 0 R >>",
+			bounds.Min.X.Round(), -bounds.Max.Y.Round(), bounds.Max.X.Round(), -bounds.Min.Y.Round(),
+			metrics.Ascent.Round(), -metrics.Descent.Round(), metrics.CapHeight.Round()),
+		pdfStream(goregular.TTF, fmt.Sprintf(" /Length1 %d", len(goregular.TTF))),
+		pdfStream([]byte(toUnicode.String()), ""),
+	}
+	for i, page := range d.pages {
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", 9+2*i),
+			pdfStream(page.Bytes(), ""))
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	_, err = w.Write(out.Bytes())
+	return err
+}
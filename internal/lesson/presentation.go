@@ -0,0 +1,94 @@
+package lesson
+
+// Presentation steps through a list for a whole-class drill: every item is
+// shown as its question first and revealed on the next step, without
+// recording any results
+type Presentation struct {
+	items    []WordItem
+	position int
+	revealed bool
+	// Reversed shows the answers as questions and the questions as answers
+	Reversed bool
+}
+
+// NewPresentation starts a presentation of items at the first question
+func NewPresentation(items []WordItem) *Presentation {
+	return &Presentation{items: items}
+}
+
+// Len returns the number of items presented
+func (p *Presentation) Len() int {
+	return len(p.items)
+}
+
+// Position returns the index of the current item
+func (p *Presentation) Position() int {
+	return p.position
+}
+
+// Revealed reports whether the answer of the current item is shown
+func (p *Presentation) Revealed() bool {
+	return p.revealed
+}
+
+// Question returns the words shown before the reveal
+func (p *Presentation) Question() []string {
+	if len(p.items) == 0 {
+		return nil
+	}
+	if p.Reversed {
+		return p.items[p.position].Answers
+	}
+	return p.items[p.position].Questions
+}
+
+// Answer returns the words shown on reveal, nil before it
+func (p *Presentation) Answer() []string {
+	if len(p.items) == 0 || !p.revealed {
+		return nil
+	}
+	if p.Reversed {
+		return p.items[p.position].Questions
+	}
+	return p.items[p.position].Answers
+}
+
+// Comment returns the comment of the current item, shown with the answer
+func (p *Presentation) Comment() string {
+	if len(p.items) == 0 || !p.revealed {
+		return ""
+	}
+	return p.items[p.position].Comment
+}
+
+// Next reveals the current answer or, once it is shown, moves on to the
+// next question. It returns false at the end of the list.
+func (p *Presentation) Next() bool {
+	switch {
+	case len(p.items) == 0:
+		return false
+	case !p.revealed:
+		p.revealed = true
+	case p.position+1 < len(p.items):
+		p.position++
+		p.revealed = false
+	default:
+		return false
+	}
+	return true
+}
+
+// Previous hides the current answer or, when it is hidden, goes back to the
+// previous item with its answer shown. It returns false at the start.
+func (p *Presentation) Previous() bool {
+	switch {
+	case p.revealed:
+		p.revealed = false
+	case p.position > 0:
+		p.position--
+		p.revealed = true
+	default:
+		return false
+	}
+	return true
+}
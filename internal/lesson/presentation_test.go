@@ -0,0 +1,55 @@
+package lesson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPresentationSteps(t *testing.T) {
+	items := []WordItem{
+		{ID: 0, Questions: []string{"cat"}, Answers: []string{"gato"}, Comment: "animal"},
+		{ID: 1, Questions: []string{"dog"}, Answers: []string{"perro"}},
+	}
+	p := NewPresentation(items)
+
+	if !reflect.DeepEqual(p.Question(), []string{"cat"}) || p.Answer() != nil || p.Comment() != "" {
+		t.Fatalf("Expected only the first question, got %v / %v / %q", p.Question(), p.Answer(), p.Comment())
+	}
+	if p.Previous() {
+		t.Error("Expected no step back from the first question")
+	}
+
+	p.Next()
+	if !reflect.DeepEqual(p.Answer(), []string{"gato"}) || p.Comment() != "animal" {
+		t.Errorf("Expected the first answer revealed, got %v / %q", p.Answer(), p.Comment())
+	}
+	p.Next()
+	if p.Position() != 1 || p.Revealed() {
+		t.Errorf("Expected the second question, got position %d revealed %v", p.Position(), p.Revealed())
+	}
+	p.Previous()
+	if p.Position() != 0 || !p.Revealed() {
+		t.Errorf("Expected to be back at the revealed first item, got position %d revealed %v", p.Position(), p.Revealed())
+	}
+
+	p.Next()
+	p.Next()
+	if p.Next() {
+		t.Error("Expected the end of the list")
+	}
+	if !reflect.DeepEqual(p.Answer(), []string{"perro"}) {
+		t.Errorf("Expected the last answer to stay shown, got %v", p.Answer())
+	}
+
+	p.Reversed = true
+	if !reflect.DeepEqual(p.Question(), []string{"perro"}) || !reflect.DeepEqual(p.Answer(), []string{"dog"}) {
+		t.Errorf("Expected reversed sides, got %v / %v", p.Question(), p.Answer())
+	}
+}
+
+func TestPresentationEmpty(t *testing.T) {
+	p := NewPresentation(nil)
+	if p.Next() || p.Previous() || p.Question() != nil {
+		t.Error("Expected an empty presentation to do nothing")
+	}
+}
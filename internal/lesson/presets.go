@@ -0,0 +1,401 @@
+package lesson
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/LaPingvino/recuerdo/internal/paths"
+)
+
+// Option presets are named groups of practice options that several lessons can
+// share, so a teacher configures a class once instead of per lesson. A lesson
+// only records the preset name in its resources; the preset definitions live
+// in a PresetStore and travel along with lessons inside a lesson pack.
+
+// PresetResourceKey is the LessonData.Resources key holding the preset name
+const PresetResourceKey = "optionPreset"
+
+// Practice directions
+const (
+	DirectionNormal  = "normal"  // ask questions, expect answers
+	DirectionReverse = "reverse" // ask answers, expect questions
+	DirectionBoth    = "both"    // alternate between both directions
+)
+
+// SchedulerOptions controls which items are asked and how often
+type SchedulerOptions struct {
+	LessonType     string  `json:"lessonType"` // "allOnce", "interval" or "smart"
+	NewPerSession  int     `json:"newPerSession,omitempty"`
+	MaxPerSession  int     `json:"maxPerSession,omitempty"`
+	RepeatWrong    bool    `json:"repeatWrong"`
+	IntervalFactor float64 `json:"intervalFactor,omitempty"`
+}
+
+// AnswerTolerance controls how strictly typed answers are compared
+type AnswerTolerance struct {
+	IgnoreCase        bool `json:"ignoreCase"`
+	IgnoreAccents     bool `json:"ignoreAccents"`
+	IgnorePunctuation bool `json:"ignorePunctuation"`
+	MaxTypos          int  `json:"maxTypos,omitempty"`
+	// SwapsAsOneTypo counts two swapped neighbouring letters, as in
+	// "recieve", as one typo instead of two
+	SwapsAsOneTypo bool `json:"swapsAsOneTypo,omitempty"`
+	// VerbForms accepts other inflections of the expected words, such as
+	// "ging" for "gaan", in languages with a registered Stemmer
+	VerbForms bool `json:"verbForms,omitempty"`
+	// ChineseScripts accepts traditional characters for simplified ones and
+	// the other way round
+	ChineseScripts bool `json:"chineseScripts,omitempty"`
+	// Pinyin accepts tone numbers for tone marks ("ni3" for "nǐ") and
+	// ignores the spacing between syllables
+	Pinyin bool `json:"pinyin,omitempty"`
+	// IgnoreTones accepts pinyin without tones; it implies Pinyin
+	IgnoreTones bool `json:"ignoreTones,omitempty"`
+	// StrictVowelPoints requires the Hebrew niqqud and Arabic harakat of
+	// the expected answer to be typed too; by default they are ignored
+	StrictVowelPoints bool `json:"strictVowelPoints,omitempty"`
+	// Articles is how articles such as de/het, der/die/das and le/la are
+	// graded: ArticlesAsTyped, ArticlesOptional, ArticlesRequired or
+	// ArticlesSeparate
+	Articles string `json:"articles,omitempty"`
+	// Required is how many answers of an item with several meanings must
+	// be given: RequireAnyAnswer or RequireAllAnswers
+	Required string `json:"required,omitempty"`
+}
+
+// OptionPreset is a named set of options assignable to multiple lessons
+type OptionPreset struct {
+	Name      string           `json:"name"`
+	Scheduler SchedulerOptions `json:"scheduler"`
+	Tolerance AnswerTolerance  `json:"tolerance"`
+	Direction string           `json:"direction"`
+}
+
+// DefaultOptionPreset returns the options used when a lesson has no preset
+func DefaultOptionPreset() OptionPreset {
+	return OptionPreset{
+		Name: "Default",
+		Scheduler: SchedulerOptions{
+			LessonType:  "smart",
+			RepeatWrong: true,
+		},
+		Tolerance: DefaultTolerance(),
+		Direction: DirectionNormal,
+	}
+}
+
+// Validate checks that the preset can be applied to a lesson
+func (p OptionPreset) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("preset name cannot be empty")
+	}
+	switch p.Direction {
+	case "", DirectionNormal, DirectionReverse, DirectionBoth:
+	default:
+		return fmt.Errorf("preset %q has unknown direction %q", p.Name, p.Direction)
+	}
+	switch p.Scheduler.LessonType {
+	case "", "allOnce", "interval", "smart":
+	default:
+		return fmt.Errorf("preset %q has unknown lesson type %q", p.Name, p.Scheduler.LessonType)
+	}
+	switch p.Tolerance.Articles {
+	case ArticlesAsTyped, ArticlesOptional, ArticlesRequired, ArticlesSeparate:
+	default:
+		return fmt.Errorf("preset %q has unknown article grading %q", p.Name, p.Tolerance.Articles)
+	}
+	switch p.Tolerance.Required {
+	case RequireAnyAnswer, RequireAllAnswers:
+	default:
+		return fmt.Errorf("preset %q has unknown required answers %q", p.Name, p.Tolerance.Required)
+	}
+	if p.Scheduler.NewPerSession < 0 || p.Scheduler.MaxPerSession < 0 || p.Tolerance.MaxTypos < 0 {
+		return fmt.Errorf("preset %q has negative limits", p.Name)
+	}
+	return nil
+}
+
+// PresetStore keeps option presets by name and persists them as JSON
+type PresetStore struct {
+	presets  map[string]OptionPreset
+	filePath string
+	mu       sync.RWMutex
+}
+
+// NewPresetStore creates a preset store backed by filePath. An empty path
+// keeps the presets in memory only.
+func NewPresetStore(filePath string) *PresetStore {
+	return &PresetStore{
+		presets:  make(map[string]OptionPreset),
+		filePath: filePath,
+	}
+}
+
+// DefaultPresetsPath returns the presets file next to the settings file
+func DefaultPresetsPath() string {
+	return paths.ConfigFile("presets.json")
+}
+
+// Load reads the presets file, replacing presets with the same name
+func (ps *PresetStore) Load() error {
+	if ps.filePath == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(ps.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var presets []OptionPreset
+	if err := json.Unmarshal(data, &presets); err != nil {
+		return fmt.Errorf("failed to parse presets file: %w", err)
+	}
+	return ps.Merge(presets, true)
+}
+
+// Save writes all presets to the presets file
+func (ps *PresetStore) Save() error {
+	if ps.filePath == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(ps.List(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(ps.filePath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(ps.filePath, data, 0644)
+}
+
+// Get returns the preset with the given name
+func (ps *PresetStore) Get(name string) (OptionPreset, bool) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	preset, exists := ps.presets[name]
+	return preset, exists
+}
+
+// Set adds or replaces a preset
+func (ps *PresetStore) Set(preset OptionPreset) error {
+	if err := preset.Validate(); err != nil {
+		return err
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.presets[preset.Name] = preset
+	return nil
+}
+
+// Delete removes a preset. Lessons referring to it fall back to the default.
+func (ps *PresetStore) Delete(name string) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	delete(ps.presets, name)
+}
+
+// List returns all presets sorted by name
+func (ps *PresetStore) List() []OptionPreset {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	presets := make([]OptionPreset, 0, len(ps.presets))
+	for _, preset := range ps.presets {
+		presets = append(presets, preset)
+	}
+	sort.Slice(presets, func(i, j int) bool { return presets[i].Name < presets[j].Name })
+	return presets
+}
+
+// Merge adds presets, e.g. those imported from a lesson pack. Existing
+// presets with the same name are only replaced when overwrite is set, so
+// importing a pack never silently changes a teacher's own configuration.
+func (ps *PresetStore) Merge(presets []OptionPreset, overwrite bool) error {
+	for _, preset := range presets {
+		if _, exists := ps.Get(preset.Name); exists && !overwrite {
+			log.Printf("[INFO] PresetStore.Merge() - keeping existing preset %q", preset.Name)
+			continue
+		}
+		if err := ps.Set(preset); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Assign makes the lesson use the named preset
+func (ps *PresetStore) Assign(lessonData *LessonData, name string) error {
+	if _, exists := ps.Get(name); !exists {
+		return fmt.Errorf("unknown preset: %s", name)
+	}
+	if lessonData.Resources == nil {
+		lessonData.Resources = make(map[string]interface{})
+	}
+	lessonData.Resources[PresetResourceKey] = name
+	lessonData.Changed = true
+	return nil
+}
+
+// Unassign makes the lesson use the default options again
+func (ps *PresetStore) Unassign(lessonData *LessonData) {
+	if _, assigned := lessonData.Resources[PresetResourceKey]; assigned {
+		delete(lessonData.Resources, PresetResourceKey)
+		lessonData.Changed = true
+	}
+}
+
+// PresetFor returns the options that apply to the lesson
+func (ps *PresetStore) PresetFor(lessonData *LessonData) OptionPreset {
+	if name := AssignedPreset(lessonData); name != "" {
+		if preset, exists := ps.Get(name); exists {
+			return preset
+		}
+		log.Printf("[WARNING] Lesson refers to unknown preset %q, using defaults", name)
+	}
+	return DefaultOptionPreset()
+}
+
+// AssignedPreset returns the name of the preset assigned to the lesson, if any
+func AssignedPreset(lessonData *LessonData) string {
+	if lessonData == nil {
+		return ""
+	}
+	name, _ := lessonData.Resources[PresetResourceKey].(string)
+	return name
+}
+
+// Lesson packs (.otpack) bundle several lessons with the presets they use,
+// stored as lessons/NNN.json and presets.json inside a ZIP file.
+
+// SaveLessonPack writes lessons and the presets they refer to into a lesson pack
+func (fs *FileSaver) SaveLessonPack(lessons []*LessonData, store *PresetStore, filePath string) error {
+	log.Printf("[ACTION] FileSaver.SaveLessonPack() - saving %d lessons", len(lessons))
+
+	var presets []OptionPreset
+	included := make(map[string]bool)
+	for _, lessonData := range lessons {
+		if err := fs.ValidateLessonData(lessonData); err != nil {
+			return err
+		}
+		name := AssignedPreset(lessonData)
+		if name == "" || included[name] || store == nil {
+			continue
+		}
+		preset, exists := store.Get(name)
+		if !exists {
+			return fmt.Errorf("lesson %q refers to unknown preset %q", lessonData.List.Title, name)
+		}
+		presets = append(presets, preset)
+		included[name] = true
+	}
+
+	files := make(map[string][]byte, len(lessons)+2)
+	for i, lessonData := range lessons {
+		data, err := json.MarshalIndent(lessonData, "", "  ")
+		if err != nil {
+			return err
+		}
+		files[fmt.Sprintf("lessons/%03d.json", i+1)] = data
+	}
+	if len(presets) > 0 {
+		data, err := json.MarshalIndent(presets, "", "  ")
+		if err != nil {
+			return err
+		}
+		files["presets.json"] = data
+	}
+	if fs.Options.PackSigningKey != nil {
+		signature, err := signPack(files, fs.Options.PackSigningKey)
+		if err != nil {
+			return err
+		}
+		files[PackSignatureFile] = signature
+	}
+
+	err := writeAtomically(filePath, fs.Options.Backups, 0644, func(tempPath string) error {
+		zipFile, err := os.Create(tempPath)
+		if err != nil {
+			return err
+		}
+		defer zipFile.Close()
+		return writePackFiles(zipFile, files)
+	})
+	if err != nil {
+		log.Printf("[ERROR] Failed to write lesson pack: %v", err)
+		return err
+	}
+
+	log.Printf("[SUCCESS] FileSaver.SaveLessonPack() - saved %d lessons and %d presets", len(lessons), len(presets))
+	return nil
+}
+
+// LoadLessonPack reads the lessons and presets of a lesson pack
+func (fl *FileLoader) LoadLessonPack(filePath string) ([]*LessonData, []OptionPreset, error) {
+	log.Printf("[ACTION] FileLoader.LoadLessonPack() - loading %s", filePath)
+
+	reader, err := zip.OpenReader(filePath)
+	if err != nil {
+		log.Printf("[ERROR] Failed to open lesson pack: %v", err)
+		return nil, nil, corruptArchive("lesson pack", "", err)
+	}
+	defer reader.Close()
+
+	files, err := readPackFiles(&reader.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Unsigned packs load as before, but a signed pack must be untouched
+	if _, err := verifyPackFiles(files, nil); err != nil {
+		log.Printf("[ERROR] Lesson pack signature check failed: %v", err)
+		return nil, nil, err
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var lessons []*LessonData
+	var presets []OptionPreset
+	for _, name := range names {
+		switch {
+		case name == "presets.json":
+			if err := json.Unmarshal(files[name], &presets); err != nil {
+				return nil, nil, corruptArchive("lesson pack", "invalid presets.json", err)
+			}
+		case strings.HasPrefix(name, "lessons/") && strings.HasSuffix(name, ".json"):
+			lessonData := NewLessonData()
+			if err := json.Unmarshal(files[name], lessonData); err != nil {
+				return nil, nil, corruptArchive("lesson pack", "invalid "+name, err)
+			}
+			if lessonData.Resources == nil {
+				lessonData.Resources = make(map[string]interface{})
+			}
+			lessons = append(lessons, lessonData)
+		}
+	}
+
+	if len(lessons) == 0 {
+		return nil, nil, corruptArchive("lesson pack", "no lessons found", nil)
+	}
+
+	log.Printf("[SUCCESS] FileLoader.LoadLessonPack() - loaded %d lessons and %d presets", len(lessons), len(presets))
+	return lessons, presets, nil
+}
@@ -0,0 +1,109 @@
+package lesson
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPresetStoreAssignAndPersist(t *testing.T) {
+	presetsFile := filepath.Join(t.TempDir(), "presets.json")
+	store := NewPresetStore(presetsFile)
+
+	classPreset := OptionPreset{
+		Name:      "Class 3B",
+		Scheduler: SchedulerOptions{LessonType: "interval", NewPerSession: 10},
+		Tolerance: AnswerTolerance{IgnoreCase: true, MaxTypos: 1},
+		Direction: DirectionBoth,
+	}
+	if err := store.Set(classPreset); err != nil {
+		t.Fatalf("Failed to add preset: %v", err)
+	}
+	if err := store.Set(OptionPreset{Name: "Broken", Direction: "sideways"}); err == nil {
+		t.Error("Expected an error for an unknown direction")
+	}
+
+	first := NewLessonData()
+	second := NewLessonData()
+	for _, lessonData := range []*LessonData{first, second} {
+		if err := store.Assign(lessonData, "Class 3B"); err != nil {
+			t.Fatalf("Failed to assign preset: %v", err)
+		}
+	}
+	if err := store.Assign(first, "Missing"); err == nil {
+		t.Error("Expected an error when assigning an unknown preset")
+	}
+
+	if got := store.PresetFor(second); got != classPreset {
+		t.Errorf("Expected shared preset, got %+v", got)
+	}
+	store.Unassign(second)
+	if got := store.PresetFor(second); got != DefaultOptionPreset() {
+		t.Errorf("Expected default preset after unassigning, got %+v", got)
+	}
+
+	if err := store.Save(); err != nil {
+		t.Fatalf("Failed to save presets: %v", err)
+	}
+	reloaded := NewPresetStore(presetsFile)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Failed to load presets: %v", err)
+	}
+	if got, exists := reloaded.Get("Class 3B"); !exists || got != classPreset {
+		t.Errorf("Preset not persisted: %+v", got)
+	}
+}
+
+func TestLessonPackRoundTrip(t *testing.T) {
+	store := NewPresetStore("")
+	classPreset := OptionPreset{Name: "Class 3B", Scheduler: SchedulerOptions{LessonType: "allOnce"}, Direction: DirectionReverse}
+	if err := store.Set(classPreset); err != nil {
+		t.Fatalf("Failed to add preset: %v", err)
+	}
+
+	var lessons []*LessonData
+	for _, title := range []string{"Week 1", "Week 2"} {
+		lessonData := NewLessonData()
+		lessonData.List.Title = title
+		lessonData.List.AddWordItem([]string{"house"}, []string{"Haus"}, "")
+		if err := store.Assign(lessonData, "Class 3B"); err != nil {
+			t.Fatalf("Failed to assign preset: %v", err)
+		}
+		lessons = append(lessons, lessonData)
+	}
+	unassigned := NewLessonData()
+	unassigned.List.Title = "Extra"
+	unassigned.List.AddWordItem([]string{"tree"}, []string{"Baum"}, "")
+	lessons = append(lessons, unassigned)
+
+	packFile := filepath.Join(t.TempDir(), "class.otpack")
+	if err := NewFileSaver().SaveLessonPack(lessons, store, packFile); err != nil {
+		t.Fatalf("Failed to save lesson pack: %v", err)
+	}
+
+	loaded, presets, err := NewFileLoader().LoadLessonPack(packFile)
+	if err != nil {
+		t.Fatalf("Failed to load lesson pack: %v", err)
+	}
+	if len(loaded) != 3 || loaded[0].List.Title != "Week 1" || loaded[2].List.Title != "Extra" {
+		t.Fatalf("Unexpected lessons: %+v", loaded)
+	}
+	if len(presets) != 1 || presets[0] != classPreset {
+		t.Fatalf("Expected the shared preset once, got %+v", presets)
+	}
+
+	// A teacher's own preset with the same name is kept unless overwriting
+	target := NewPresetStore("")
+	own := OptionPreset{Name: "Class 3B", Direction: DirectionNormal}
+	if err := target.Set(own); err != nil {
+		t.Fatalf("Failed to add preset: %v", err)
+	}
+	if err := target.Merge(presets, false); err != nil {
+		t.Fatalf("Failed to merge presets: %v", err)
+	}
+	if got := target.PresetFor(loaded[1]); got != own {
+		t.Errorf("Existing preset was replaced: %+v", got)
+	}
+	if got := target.PresetFor(loaded[2]); got != DefaultOptionPreset() {
+		t.Errorf("Expected default options for unassigned lesson, got %+v", got)
+	}
+}
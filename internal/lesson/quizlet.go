@@ -0,0 +1,199 @@
+package lesson
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// QuizletOptions describes the separators used in a Quizlet export. Quizlet
+// lets the user pick both when exporting a set, defaulting to a tab between
+// term and definition and a newline between rows.
+type QuizletOptions struct {
+	TermSeparator string
+	RowSeparator  string
+}
+
+// DefaultQuizletOptions returns the separators Quizlet uses by default
+func DefaultQuizletOptions() QuizletOptions {
+	return QuizletOptions{
+		TermSeparator: "\t",
+		RowSeparator:  "\n",
+	}
+}
+
+// quizletNextDataPattern matches the JSON blob Quizlet embeds in set pages
+var quizletNextDataPattern = regexp.MustCompile(`(?s)<script id="__NEXT_DATA__" type="application/json">(.*?)</script>`)
+
+// ParseQuizletExport converts text exported (or copy-pasted) from Quizlet into
+// lesson data. Empty separators fall back to the Quizlet defaults.
+func (fl *FileLoader) ParseQuizletExport(text string, opts QuizletOptions) (*LessonData, error) {
+	log.Printf("[ACTION] FileLoader.ParseQuizletExport() - parsing Quizlet export")
+
+	defaults := DefaultQuizletOptions()
+	if opts.TermSeparator == "" {
+		opts.TermSeparator = defaults.TermSeparator
+	}
+	if opts.RowSeparator == "" {
+		opts.RowSeparator = defaults.RowSeparator
+	}
+
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.TrimPrefix(text, "\ufeff")
+
+	lessonData := NewLessonData()
+	itemID := 0
+	for _, row := range strings.Split(text, opts.RowSeparator) {
+		row = strings.TrimSpace(row)
+		if row == "" {
+			continue
+		}
+
+		parts := strings.SplitN(row, opts.TermSeparator, 2)
+		if len(parts) < 2 {
+			continue
+		}
+
+		questions := fl.parseWordString(strings.TrimSpace(parts[0]))
+		answers := fl.parseWordString(strings.TrimSpace(parts[1]))
+		if len(questions) == 0 || len(answers) == 0 {
+			continue
+		}
+
+		lessonData.List.Items = append(lessonData.List.Items, WordItem{
+			ID:        itemID,
+			Questions: questions,
+			Answers:   answers,
+		})
+		itemID++
+	}
+
+	if len(lessonData.List.Items) == 0 {
+		return nil, fmt.Errorf("no term/definition pairs found in Quizlet export")
+	}
+
+	log.Printf("[SUCCESS] FileLoader.ParseQuizletExport() - loaded %d word pairs", len(lessonData.List.Items))
+	return lessonData, nil
+}
+
+// loadQuizletFile loads a Quizlet export saved to disk using default separators
+func (fl *FileLoader) loadQuizletFile(filePath string) (*LessonData, error) {
+	log.Printf("[ACTION] FileLoader.loadQuizletFile() - parsing Quizlet export file")
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		log.Printf("[ERROR] Failed to read Quizlet file: %v", err)
+		return nil, err
+	}
+
+	lessonData, err := fl.ParseQuizletExport(string(content), DefaultQuizletOptions())
+	if err != nil {
+		return nil, err
+	}
+	lessonData.List.Title = strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	return lessonData, nil
+}
+
+// FetchQuizletSet downloads a Quizlet set by URL. Plain-text responses are
+// treated as an export; HTML set pages are searched for the embedded card data.
+func (fl *FileLoader) FetchQuizletSet(ctx context.Context, client *http.Client, setURL string) (*LessonData, error) {
+	log.Printf("[ACTION] FileLoader.FetchQuizletSet() - fetching %s", setURL)
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, setURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Recuerdo")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Printf("[ERROR] Failed to fetch Quizlet set: %v", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching Quizlet set failed: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain") {
+		return fl.ParseQuizletExport(string(body), DefaultQuizletOptions())
+	}
+
+	return fl.parseQuizletPage(string(body))
+}
+
+// parseQuizletPage extracts term/definition pairs from a Quizlet set page
+func (fl *FileLoader) parseQuizletPage(page string) (*LessonData, error) {
+	match := quizletNextDataPattern.FindStringSubmatch(page)
+	if match == nil {
+		return nil, fmt.Errorf("no Quizlet set data found in page")
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(match[1]), &data); err != nil {
+		return nil, fmt.Errorf("invalid Quizlet set data: %w", err)
+	}
+
+	lessonData := NewLessonData()
+	seen := make(map[string]bool)
+	var walk func(node interface{})
+	walk = func(node interface{}) {
+		switch v := node.(type) {
+		case map[string]interface{}:
+			word, hasWord := v["word"].(string)
+			definition, hasDefinition := v["definition"].(string)
+			if hasWord && hasDefinition {
+				key := word + "\x00" + definition
+				if seen[key] {
+					return
+				}
+				seen[key] = true
+				questions := fl.parseWordString(word)
+				answers := fl.parseWordString(definition)
+				if len(questions) > 0 && len(answers) > 0 {
+					lessonData.List.Items = append(lessonData.List.Items, WordItem{
+						ID:        len(lessonData.List.Items),
+						Questions: questions,
+						Answers:   answers,
+					})
+				}
+				return
+			}
+			if title, ok := v["title"].(string); ok && lessonData.List.Title == "" {
+				lessonData.List.Title = title
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(data)
+
+	if len(lessonData.List.Items) == 0 {
+		return nil, fmt.Errorf("no term/definition pairs found in Quizlet set")
+	}
+
+	log.Printf("[SUCCESS] FileLoader.parseQuizletPage() - loaded %d word pairs", len(lessonData.List.Items))
+	return lessonData, nil
+}
@@ -0,0 +1,114 @@
+package lesson
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseQuizletExport(t *testing.T) {
+	loader := NewFileLoader()
+
+	lessonData, err := loader.ParseQuizletExport("hello\thallo\r\ngoodbye\tdag, tot ziens\n\nno separator\n", DefaultQuizletOptions())
+	if err != nil {
+		t.Fatalf("Failed to parse Quizlet export: %v", err)
+	}
+
+	if len(lessonData.List.Items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(lessonData.List.Items))
+	}
+
+	second := lessonData.List.Items[1]
+	if second.ID != 1 || second.Questions[0] != "goodbye" {
+		t.Errorf("Unexpected second item: %+v", second)
+	}
+	if !equalStringSlices(second.Answers, []string{"dag", "tot ziens"}) {
+		t.Errorf("Expected answers [dag tot ziens], got %v", second.Answers)
+	}
+}
+
+func TestParseQuizletExportCustomSeparators(t *testing.T) {
+	loader := NewFileLoader()
+
+	lessonData, err := loader.ParseQuizletExport("perro - dog;gato - cat;", QuizletOptions{
+		TermSeparator: " - ",
+		RowSeparator:  ";",
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse Quizlet export: %v", err)
+	}
+
+	if len(lessonData.List.Items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(lessonData.List.Items))
+	}
+	if lessonData.List.Items[1].Answers[0] != "cat" {
+		t.Errorf("Expected answer 'cat', got %v", lessonData.List.Items[1].Answers)
+	}
+
+	if _, err := loader.ParseQuizletExport("nothing useful here", DefaultQuizletOptions()); err == nil {
+		t.Error("Expected an error for an export without pairs")
+	}
+}
+
+func TestLoadQuizletFile(t *testing.T) {
+	loader := NewFileLoader()
+
+	quizletFile := filepath.Join(t.TempDir(), "spanish.quizlet")
+	if err := os.WriteFile(quizletFile, []byte("uno\tone\ndos\ttwo\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	lessonData, err := loader.LoadFile(quizletFile)
+	if err != nil {
+		t.Fatalf("Failed to load Quizlet file: %v", err)
+	}
+
+	if lessonData.List.Title != "spanish" {
+		t.Errorf("Expected title 'spanish', got '%s'", lessonData.List.Title)
+	}
+	if len(lessonData.List.Items) != 2 {
+		t.Errorf("Expected 2 items, got %d", len(lessonData.List.Items))
+	}
+}
+
+func TestFetchQuizletSet(t *testing.T) {
+	page := `<html><body><script id="__NEXT_DATA__" type="application/json">` +
+		`{"props":{"set":{"title":"Colours","terms":[` +
+		`{"word":"rojo","definition":"red"},{"word":"azul","definition":"blue"},` +
+		`{"word":"rojo","definition":"red"}]}}}</script></body></html>`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/export.txt" {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.Write([]byte("verde\tgreen\n"))
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	loader := NewFileLoader()
+
+	lessonData, err := loader.FetchQuizletSet(context.Background(), server.Client(), server.URL+"/12345/colours")
+	if err != nil {
+		t.Fatalf("Failed to fetch Quizlet set: %v", err)
+	}
+	if lessonData.List.Title != "Colours" {
+		t.Errorf("Expected title 'Colours', got '%s'", lessonData.List.Title)
+	}
+	if len(lessonData.List.Items) != 2 {
+		t.Errorf("Expected 2 deduplicated items, got %d", len(lessonData.List.Items))
+	}
+
+	lessonData, err = loader.FetchQuizletSet(context.Background(), server.Client(), server.URL+"/export.txt")
+	if err != nil {
+		t.Fatalf("Failed to fetch plain-text export: %v", err)
+	}
+	if len(lessonData.List.Items) != 1 || lessonData.List.Items[0].Answers[0] != "green" {
+		t.Errorf("Unexpected items from plain-text export: %+v", lessonData.List.Items)
+	}
+}
@@ -0,0 +1,408 @@
+package lesson
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// recoverableFormats are the formats RecoverLesson can repair, by the name
+// used in its report. The OpenTeaching archives hold their list in a
+// list.json next to the images and media it refers to.
+var recoverableFormats = map[string]string{
+	".otwd": "OpenTeaching Words archive",
+	".ottp": "OpenTeaching Topography archive",
+	".otmd": "OpenTeaching Media archive",
+	".otio": "OpenTeaching Image Occlusion archive",
+	".json": "JSON lesson",
+}
+
+// CanRecover reports whether RecoverLesson can repair files like filePath
+func CanRecover(filePath string) bool {
+	_, ok := recoverableFormats[strings.ToLower(filepath.Ext(filePath))]
+	return ok
+}
+
+// RecoveredEntry is a file found in a damaged archive
+type RecoveredEntry struct {
+	Name string
+	// Size is the number of bytes that could be read
+	Size int
+	// Problem tells what was wrong with the entry; empty when it was read
+	// intact
+	Problem string
+}
+
+// RecoveryReport tells what RecoverLesson salvaged from a damaged file
+type RecoveryReport struct {
+	Format string
+	// Entries are the files found in a damaged archive, in archive order
+	Entries []RecoveredEntry
+	// Repaired names the JSON files that were damaged and rebuilt from the
+	// part that could be read
+	Repaired []string
+	// Items is the number of items in the recovered lesson
+	Items int
+}
+
+// String returns the report as text for the user
+func (r *RecoveryReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Recovered %d items from a damaged %s.\n", r.Items, r.Format)
+	for _, name := range r.Repaired {
+		fmt.Fprintf(&b, "%s was damaged; the items after the damage are lost.\n", name)
+	}
+	for _, entry := range r.Entries {
+		switch {
+		case entry.Problem == "":
+			fmt.Fprintf(&b, "  ok       %s (%d bytes)\n", entry.Name, entry.Size)
+		case entry.Size > 0:
+			fmt.Fprintf(&b, "  partial  %s (%d bytes): %s\n", entry.Name, entry.Size, entry.Problem)
+		default:
+			fmt.Fprintf(&b, "  lost     %s: %s\n", entry.Name, entry.Problem)
+		}
+	}
+	return b.String()
+}
+
+// RecoverLesson salvages what can be read from the content of a damaged
+// file of the format of ext: the entries of a truncated or corrupt ZIP
+// archive and the values of partially valid JSON up to the damage. It
+// returns the content of a new, valid file holding what was salvaged.
+func RecoverLesson(data []byte, ext string) ([]byte, *RecoveryReport, error) {
+	ext = strings.ToLower(ext)
+	format, ok := recoverableFormats[ext]
+	if !ok {
+		return nil, nil, &formatError{action: "recover", ext: ext}
+	}
+	report := &RecoveryReport{Format: format}
+
+	if ext == ".json" {
+		lessonJSON, err := recoverJSON(data, "lesson", report)
+		if err != nil {
+			return nil, nil, corruptArchive(format, "nothing could be recovered", err)
+		}
+		return lessonJSON, report, nil
+	}
+
+	var listFound bool
+	var recovered bytes.Buffer
+	// The manifest is written anew for what was recovered
+	zipWriter := newArchiveWriter(&recovered)
+	for _, entry := range checkSalvagedEntries(salvageZipEntries(data)) {
+		if entry.Name == ArchiveManifestFile {
+			continue
+		}
+		report.Entries = append(report.Entries, entry.RecoveredEntry)
+		content := entry.content
+		if strings.HasSuffix(entry.Name, ".json") {
+			var err error
+			if content, err = recoverJSON(content, entry.Name, report); err != nil {
+				continue
+			}
+			listFound = listFound || entry.Name == "list.json"
+		}
+		if len(content) == 0 {
+			continue
+		}
+		w, err := zipWriter.Create(entry.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, err := w.Write(content); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		return nil, nil, err
+	}
+	if !listFound {
+		return nil, report, corruptArchive(format, "no list.json could be recovered", nil)
+	}
+	return recovered.Bytes(), report, nil
+}
+
+// RecoverFile repairs the damaged lesson at filePath, writing what could be
+// salvaged to outPath and the report next to it, as ReportPath(outPath)
+func (fl *FileLoader) RecoverFile(filePath, outPath string) (*RecoveryReport, error) {
+	log.Printf("[ACTION] FileLoader.RecoverFile() - recovering %s", filePath)
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	recovered, report, err := RecoverLesson(data, filepath.Ext(filePath))
+	if err != nil {
+		log.Printf("[ERROR] Failed to recover %s: %v", filePath, err)
+		return report, err
+	}
+	err = writeAtomically(outPath, DefaultBackups(), saveMode(outPath), func(tempPath string) error {
+		return os.WriteFile(tempPath, recovered, 0644)
+	})
+	if err != nil {
+		return report, err
+	}
+	text := fmt.Sprintf("Recovery of %s\n\n%s", filePath, report)
+	if err := WriteFileAtomically(ReportPath(outPath), []byte(text), 0644); err != nil {
+		return report, err
+	}
+	log.Printf("[SUCCESS] FileLoader.RecoverFile() - recovered %d items to %s", report.Items, outPath)
+	return report, nil
+}
+
+// RecoveredPath returns where a recovered copy of filePath is written by
+// default, next to it
+func RecoveredPath(filePath string) string {
+	ext := filepath.Ext(filePath)
+	return strings.TrimSuffix(filePath, ext) + "-recovered" + ext
+}
+
+// ReportPath returns where the report of recovering outPath is written
+func ReportPath(outPath string) string {
+	return strings.TrimSuffix(outPath, filepath.Ext(outPath)) + "-report.txt"
+}
+
+// recoverJSON returns data when it is valid JSON and else the JSON values
+// that could be read before the damage, counting the items of the lesson
+func recoverJSON(data []byte, name string, report *RecoveryReport) ([]byte, error) {
+	var value interface{}
+	if json.Valid(data) {
+		if err := json.Unmarshal(data, &value); err != nil {
+			return nil, err
+		}
+	} else {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var ok bool
+		if value, ok = salvageJSONValue(decoder); value == nil && !ok {
+			return nil, fmt.Errorf("%s holds no readable JSON", name)
+		}
+		report.Repaired = append(report.Repaired, name)
+		repaired, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		data = repaired
+	}
+	report.Items += countJSONItems(value)
+	return data, nil
+}
+
+// salvageJSONValue reads the next value from decoder, returning as much of
+// it as is valid and whether it was complete. Objects keep the members read
+// before the damage, including a damaged last one, but arrays only keep
+// their complete elements, so no half item ends up in a lesson.
+func salvageJSONValue(decoder *json.Decoder) (interface{}, bool) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, false
+	}
+	switch token {
+	case json.Delim('{'):
+		object := make(map[string]interface{})
+		for decoder.More() {
+			keyToken, err := decoder.Token()
+			if err != nil {
+				return object, false
+			}
+			key, _ := keyToken.(string)
+			value, complete := salvageJSONValue(decoder)
+			if complete || value != nil {
+				object[key] = value
+			}
+			if !complete {
+				return object, false
+			}
+		}
+		_, err := decoder.Token()
+		return object, err == nil
+	case json.Delim('['):
+		array := make([]interface{}, 0)
+		for decoder.More() {
+			value, complete := salvageJSONValue(decoder)
+			if !complete {
+				return array, false
+			}
+			array = append(array, value)
+		}
+		_, err := decoder.Token()
+		return array, err == nil
+	}
+	return token, true
+}
+
+// countJSONItems returns the number of items in a list.json or a JSON
+// lesson
+func countJSONItems(value interface{}) int {
+	object, _ := value.(map[string]interface{})
+	if list, ok := object["list"].(map[string]interface{}); ok {
+		object = list
+	}
+	items, _ := object["items"].([]interface{})
+	return len(items)
+}
+
+// salvagedEntry is an entry read from a damaged ZIP archive
+type salvagedEntry struct {
+	RecoveredEntry
+	content []byte
+}
+
+// salvageZipEntries returns the entries of a ZIP archive that can be read.
+// An archive whose central directory is intact is read through it, keeping
+// the part of damaged entries that can be decompressed; otherwise the local
+// file headers are searched for, which finds the entries of an archive cut
+// short as well.
+func salvageZipEntries(data []byte) []salvagedEntry {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return scanZipEntries(data)
+	}
+
+	var entries []salvagedEntry
+	for _, file := range reader.File {
+		entry := salvagedEntry{RecoveredEntry: RecoveredEntry{Name: file.Name}}
+		content, err := readZipFile(file)
+		entry.content = content
+		entry.Size = len(content)
+		if err != nil {
+			entry.Problem = err.Error()
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// checkSalvagedEntries marks the entries whose content does not match the
+// manifest of the archive, if it could be read, and adds those listed in it
+// that were not found
+func checkSalvagedEntries(entries []salvagedEntry) []salvagedEntry {
+	var manifest archiveManifest
+	for _, entry := range entries {
+		if entry.Name == ArchiveManifestFile && entry.Problem == "" {
+			if json.Unmarshal(entry.content, &manifest) != nil || manifest.Algorithm != "sha256" {
+				return entries
+			}
+		}
+	}
+	for _, checksum := range manifest.Files {
+		found := false
+		for i := range entries {
+			if entries[i].Name != checksum.Name {
+				continue
+			}
+			found = true
+			if entries[i].Problem == "" {
+				entries[i].Problem = checkManifestEntry(checksum, entries[i].content)
+			}
+		}
+		if !found {
+			entries = append(entries, salvagedEntry{RecoveredEntry: RecoveredEntry{Name: checksum.Name, Problem: "missing from the archive"}})
+		}
+	}
+	return entries
+}
+
+// Signatures of the records of a ZIP archive
+var (
+	zipLocalHeader   = []byte("PK\x03\x04")
+	zipCentralHeader = []byte("PK\x01\x02")
+	zipDataDesc      = []byte("PK\x07\x08")
+)
+
+// zipLocalHeaderSize is the size of a local file header without the name
+// and extra field
+const zipLocalHeaderSize = 30
+
+// scanZipEntries reads the entries of a ZIP archive from their local file
+// headers, for archives without a readable central directory
+func scanZipEntries(data []byte) []salvagedEntry {
+	var entries []salvagedEntry
+	seen := make(map[string]bool)
+	for offset := 0; ; {
+		start := bytes.Index(data[offset:], zipLocalHeader)
+		if start < 0 || offset+start+zipLocalHeaderSize > len(data) {
+			break
+		}
+		header := data[offset+start:]
+		flags := binary.LittleEndian.Uint16(header[6:])
+		method := binary.LittleEndian.Uint16(header[8:])
+		crc := binary.LittleEndian.Uint32(header[14:])
+		compressedSize := int(binary.LittleEndian.Uint32(header[18:]))
+		nameLength := int(binary.LittleEndian.Uint16(header[26:]))
+		extraLength := int(binary.LittleEndian.Uint16(header[28:]))
+		dataStart := offset + start + zipLocalHeaderSize + nameLength + extraLength
+		if dataStart > len(data) {
+			break
+		}
+		name := string(header[zipLocalHeaderSize : zipLocalHeaderSize+nameLength])
+
+		// Archives written in one go give the size in a data descriptor
+		// after the data instead of in the header
+		raw := data[dataStart:]
+		sizeKnown := flags&0x8 == 0 || compressedSize > 0
+		if sizeKnown && compressedSize < len(raw) {
+			raw = raw[:compressedSize]
+		}
+		if !sizeKnown && method == zip.Store {
+			raw = raw[:nextZipRecord(raw)]
+		}
+
+		entry := salvagedEntry{RecoveredEntry: RecoveredEntry{Name: name}}
+		consumed := len(raw)
+		switch {
+		case flags&0x1 != 0:
+			entry.Problem = "the entry is encrypted"
+		case method == zip.Store:
+			entry.content = raw
+		case method == zip.Deflate:
+			source := bytes.NewReader(raw)
+			content, err := io.ReadAll(flate.NewReader(source))
+			entry.content = content
+			if err != nil {
+				entry.Problem = "cut short: " + err.Error()
+			}
+			consumed = len(raw) - source.Len()
+		default:
+			entry.Problem = fmt.Sprintf("unsupported compression method %d", method)
+		}
+		if entry.Problem == "" && sizeKnown && compressedSize > len(raw) {
+			entry.Problem = "cut short"
+		}
+		if entry.Problem == "" && crc != 0 && crc32.ChecksumIEEE(entry.content) != crc {
+			entry.Problem = "checksum mismatch"
+		}
+		entry.Size = len(entry.content)
+
+		if !seen[name] {
+			seen[name] = true
+			entries = append(entries, entry)
+		}
+		offset = dataStart + consumed
+		if offset >= len(data) {
+			break
+		}
+	}
+	return entries
+}
+
+// nextZipRecord returns where the next ZIP record starts in data, or its
+// length when there is none
+func nextZipRecord(data []byte) int {
+	end := len(data)
+	for _, signature := range [][]byte{zipLocalHeader, zipCentralHeader, zipDataDesc} {
+		if i := bytes.Index(data, signature); i >= 0 && i < end {
+			end = i
+		}
+	}
+	return end
+}
@@ -0,0 +1,129 @@
+package lesson
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// topoLesson returns a topography lesson of n places
+func topoLesson(n int) *LessonData {
+	lessonData := NewLessonData()
+	lessonData.List.Title = "Capitals"
+	for i := 0; i < n; i++ {
+		x, y := i, 2*i
+		name := fmt.Sprintf("City %d", i)
+		lessonData.List.Items = append(lessonData.List.Items, WordItem{ID: i, Name: name, Questions: []string{name}, Answers: []string{name}, X: &x, Y: &y})
+	}
+	return lessonData
+}
+
+func TestRecoverArchiveWithoutCentralDirectory(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "capitals.ottp")
+	if err := NewFileSaver().SaveFile(topoLesson(20), filePath); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A USB stick pulled out too early loses the end of the file
+	damaged := filepath.Join(dir, "damaged.ottp")
+	if err := os.WriteFile(damaged, data[:bytes.LastIndex(data, zipCentralHeader)+10], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFileLoader().LoadFile(damaged); err == nil {
+		t.Fatal("the damaged archive loads as is")
+	}
+
+	outPath := RecoveredPath(damaged)
+	report, err := NewFileLoader().RecoverFile(damaged, outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Items != 20 || len(report.Repaired) != 0 {
+		t.Errorf("report = %+v", report)
+	}
+	recovered, err := NewFileLoader().LoadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recovered.List.Items) != 20 || recovered.List.Items[19].Name != "City 19" {
+		t.Errorf("recovered %d items", len(recovered.List.Items))
+	}
+	if text, err := os.ReadFile(ReportPath(outPath)); err != nil || !strings.Contains(string(text), "Recovered 20 items") {
+		t.Errorf("report file: %q, %v", text, err)
+	}
+}
+
+func TestRecoverTruncatedList(t *testing.T) {
+	var buf bytes.Buffer
+	zipWriter := zip.NewWriter(&buf)
+	w, err := zipWriter.CreateHeader(&zip.FileHeader{Name: "list.json", Method: zip.Store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte(`{"file-format-version": "3.1", "title": "Verbs", "items": [` +
+		`{"id": 0, "questions": [["to be"]], "answers": [["zijn"]]}, ` +
+		`{"id": 1, "questions": [["to go"]], "answers": [["gaan"]]}, ` +
+		`{"id": 2, "questions": [["to wa`))
+	zipWriter.Close()
+
+	recovered, report, err := RecoverLesson(buf.Bytes(), ".otwd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Items != 2 || len(report.Repaired) != 1 || report.Repaired[0] != "list.json" {
+		t.Errorf("report = %+v", report)
+	}
+	reader, err := zip.NewReader(bytes.NewReader(recovered), int64(len(recovered)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := readZipFile(reader.File[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(list), `"title":"Verbs"`) || strings.Contains(string(list), "to wa") {
+		t.Errorf("recovered list.json = %s", list)
+	}
+}
+
+func TestRecoverJSONLesson(t *testing.T) {
+	lessonData := topoLesson(5)
+	data, err := json.MarshalIndent(lessonData, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cut := bytes.Index(data, []byte(`"City 4"`))
+
+	recovered, report, err := RecoverLesson(data[:cut], ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Items != 4 {
+		t.Errorf("recovered %d items, want 4", report.Items)
+	}
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "recovered.json")
+	if err := os.WriteFile(filePath, recovered, 0644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := NewFileLoader().LoadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.List.Title != "Capitals" || len(loaded.List.Items) != 4 {
+		t.Errorf("loaded %q with %d items", loaded.List.Title, len(loaded.List.Items))
+	}
+
+	if _, _, err := RecoverLesson([]byte("garbage"), ".json"); err == nil {
+		t.Error("recovered a lesson from garbage")
+	}
+}
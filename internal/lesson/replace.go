@@ -0,0 +1,190 @@
+package lesson
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Fields of a word item that find and replace can change
+const (
+	FieldQuestions = "questions"
+	FieldAnswers   = "answers"
+	FieldComment   = "comment"
+)
+
+// ReplaceOptions says what to find and replace in a lesson
+type ReplaceOptions struct {
+	Find    string
+	Replace string
+	// Regexp treats Find as a regular expression, and lets Replace refer
+	// to its groups as $1 or ${name}
+	Regexp bool
+	// MatchCase makes the search case sensitive
+	MatchCase bool
+	// WholeWords only matches Find between word boundaries
+	WholeWords bool
+	// The fields to search; none set means all of them
+	Questions bool
+	Answers   bool
+	Comments  bool
+}
+
+// Replacement is one change find and replace would make: the word at Index
+// of Field of the item at Item, from Before to After. Index is 0 for
+// comments.
+type Replacement struct {
+	Item   int
+	Field  string
+	Index  int
+	Before string
+	After  string
+}
+
+// compile turns the options into a regular expression
+func (o ReplaceOptions) compile() (*regexp.Regexp, error) {
+	if o.Find == "" {
+		return nil, fmt.Errorf("nothing to find")
+	}
+	pattern := o.Find
+	if !o.Regexp {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if o.WholeWords {
+		pattern = `\b(?:` + pattern + `)\b`
+	}
+	if !o.MatchCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression: %w", err)
+	}
+	return re, nil
+}
+
+// FindReplacements returns the changes replacing would make, for a preview.
+// It does not change the lesson.
+func FindReplacements(lessonData *LessonData, options ReplaceOptions) ([]Replacement, error) {
+	re, err := options.compile()
+	if err != nil {
+		return nil, err
+	}
+	all := !options.Questions && !options.Answers && !options.Comments
+
+	replace := func(text string) string {
+		if options.Regexp {
+			return re.ReplaceAllString(text, options.Replace)
+		}
+		return re.ReplaceAllLiteralString(text, options.Replace)
+	}
+
+	var replacements []Replacement
+	add := func(item int, field string, index int, text string) {
+		if !re.MatchString(text) {
+			return
+		}
+		if after := replace(text); after != text {
+			replacements = append(replacements, Replacement{Item: item, Field: field, Index: index, Before: text, After: after})
+		}
+	}
+	for i, item := range lessonData.List.Items {
+		if all || options.Questions {
+			for j, word := range item.Questions {
+				add(i, FieldQuestions, j, word)
+			}
+		}
+		if all || options.Answers {
+			for j, word := range item.Answers {
+				add(i, FieldAnswers, j, word)
+			}
+		}
+		if all || options.Comments {
+			add(i, FieldComment, 0, item.Comment)
+		}
+	}
+	return replacements, nil
+}
+
+// ApplyReplacements makes the given changes to lessonData in place, so an
+// editor can push the lesson on its undo stack first. Replacements whose
+// Before no longer matches the lesson are skipped, and words replaced by
+// nothing are removed. It returns the number of changes made.
+func ApplyReplacements(lessonData *LessonData, replacements []Replacement) int {
+	items := lessonData.List.Items
+	applied := 0
+	// removed marks emptied words per item and field, dropped afterwards
+	// so the indexes of the other replacements stay valid
+	removed := make(map[int]map[string]map[int]bool)
+
+	for _, r := range replacements {
+		if r.Item < 0 || r.Item >= len(items) {
+			continue
+		}
+		item := &items[r.Item]
+		var words []string
+		switch r.Field {
+		case FieldQuestions:
+			words = item.Questions
+		case FieldAnswers:
+			words = item.Answers
+		case FieldComment:
+			if item.Comment == r.Before {
+				item.Comment = r.After
+				applied++
+			}
+			continue
+		default:
+			continue
+		}
+		if r.Index < 0 || r.Index >= len(words) || words[r.Index] != r.Before {
+			continue
+		}
+		words[r.Index] = r.After
+		applied++
+		if r.After == "" {
+			if removed[r.Item] == nil {
+				removed[r.Item] = make(map[string]map[int]bool)
+			}
+			if removed[r.Item][r.Field] == nil {
+				removed[r.Item][r.Field] = make(map[int]bool)
+			}
+			removed[r.Item][r.Field][r.Index] = true
+		}
+	}
+
+	for i, fields := range removed {
+		item := &items[i]
+		item.Questions = dropIndexes(item.Questions, fields[FieldQuestions])
+		if fields[FieldAnswers] != nil {
+			if len(item.AnswerGroups) == len(item.Answers) {
+				var groups []int
+				for j, group := range item.AnswerGroups {
+					if !fields[FieldAnswers][j] {
+						groups = append(groups, group)
+					}
+				}
+				item.AnswerGroups = groups
+			}
+			item.Answers = dropIndexes(item.Answers, fields[FieldAnswers])
+		}
+	}
+
+	if applied > 0 {
+		lessonData.Changed = true
+	}
+	return applied
+}
+
+// dropIndexes returns words without those at the marked indexes
+func dropIndexes(words []string, indexes map[int]bool) []string {
+	if len(indexes) == 0 {
+		return words
+	}
+	kept := make([]string, 0, len(words))
+	for i, word := range words {
+		if !indexes[i] {
+			kept = append(kept, word)
+		}
+	}
+	return kept
+}
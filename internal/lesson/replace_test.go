@@ -0,0 +1,108 @@
+package lesson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func replaceLesson() *LessonData {
+	lessonData := NewLessonData()
+	lessonData.List.Items = []WordItem{
+		{ID: 0, Questions: []string{"kleur"}, Answers: []string{"colour", "hue"}, Comment: "British colour"},
+		{ID: 1, Questions: []string{"kleurrijk"}, Answers: []string{"colourful"}},
+		{ID: 2, Questions: []string{"bank"}, Answers: []string{"bank", "(old) bench", "shore"}, AnswerGroups: []int{0, 0, 1}},
+	}
+	return lessonData
+}
+
+func TestFindReplacements(t *testing.T) {
+	lessonData := replaceLesson()
+
+	replacements, err := FindReplacements(lessonData, ReplaceOptions{Find: "Colour", Replace: "color"})
+	if err != nil {
+		t.Fatalf("FindReplacements failed: %v", err)
+	}
+	if len(replacements) != 3 || replacements[1].Field != FieldComment || replacements[1].After != "British color" {
+		t.Fatalf("Expected both answers and the comment, got %+v", replacements)
+	}
+	if lessonData.List.Items[0].Answers[0] != "colour" {
+		t.Error("Expected the preview to leave the lesson alone")
+	}
+
+	replacements, _ = FindReplacements(lessonData, ReplaceOptions{Find: "colour", Replace: "color", WholeWords: true, Answers: true})
+	if len(replacements) != 1 || replacements[0].Item != 0 {
+		t.Errorf("Expected only the whole word in answers, got %+v", replacements)
+	}
+
+	replacements, _ = FindReplacements(lessonData, ReplaceOptions{Find: "Colour", MatchCase: true})
+	if len(replacements) != 0 {
+		t.Errorf("Expected no case sensitive matches, got %+v", replacements)
+	}
+
+	replacements, _ = FindReplacements(lessonData, ReplaceOptions{Find: `^(\w+)rijk$`, Replace: "zeer $1", Regexp: true, Questions: true})
+	if len(replacements) != 1 || replacements[0].After != "zeer kleur" {
+		t.Errorf("Expected the regular expression group in the replacement, got %+v", replacements)
+	}
+
+	if _, err := FindReplacements(lessonData, ReplaceOptions{Find: "(", Regexp: true}); err == nil {
+		t.Error("Expected an invalid regular expression to fail")
+	}
+	if _, err := FindReplacements(lessonData, ReplaceOptions{}); err == nil {
+		t.Error("Expected an empty search to fail")
+	}
+}
+
+func TestApplyReplacements(t *testing.T) {
+	lessonData := replaceLesson()
+	undo := NewUndoStack(0)
+
+	replacements, _ := FindReplacements(lessonData, ReplaceOptions{Find: `\(old\) \w+`, Regexp: true})
+	replacements = append(replacements, Replacement{Item: 0, Field: FieldAnswers, Index: 0, Before: "stale", After: "x"})
+	undo.Push("Remove old words", lessonData)
+	if applied := ApplyReplacements(lessonData, replacements); applied != 1 {
+		t.Fatalf("Expected 1 change, got %d", applied)
+	}
+	item := lessonData.List.Items[2]
+	if !reflect.DeepEqual(item.Answers, []string{"bank", "shore"}) || !reflect.DeepEqual(item.AnswerGroups, []int{0, 1}) || !lessonData.Changed {
+		t.Fatalf("Expected the emptied answer to go with its group, got %v / %v", item.Answers, item.AnswerGroups)
+	}
+
+	if description, ok := undo.Undo(lessonData); !ok || description != "Remove old words" {
+		t.Fatalf("Undo = %q, %v", description, ok)
+	}
+	if len(lessonData.List.Items[2].Answers) != 3 || lessonData.Changed {
+		t.Errorf("Expected undo to bring back the answer, got %v", lessonData.List.Items[2].Answers)
+	}
+	if _, ok := undo.Redo(lessonData); !ok || len(lessonData.List.Items[2].Answers) != 2 {
+		t.Errorf("Expected redo to remove the answer again, got %v", lessonData.List.Items[2].Answers)
+	}
+	if _, ok := undo.Redo(lessonData); ok {
+		t.Error("Expected nothing more to redo")
+	}
+}
+
+func TestUndoStackLimit(t *testing.T) {
+	lessonData := replaceLesson()
+	undo := NewUndoStack(2)
+	for _, word := range []string{"a", "b", "c"} {
+		undo.Push("Set "+word, lessonData)
+		lessonData.List.Items[0].Questions[0] = word
+	}
+
+	if description, _ := undo.CanUndo(); description != "Set c" {
+		t.Errorf("Expected the last edit on top, got %q", description)
+	}
+	undo.Undo(lessonData)
+	undo.Undo(lessonData)
+	if _, ok := undo.Undo(lessonData); ok {
+		t.Error("Expected only 2 edits to be remembered")
+	}
+	if got := lessonData.List.Items[0].Questions[0]; got != "a" {
+		t.Errorf("Expected the oldest remembered state, got %q", got)
+	}
+
+	undo.Push("Set d", lessonData)
+	if _, ok := undo.CanRedo(); ok {
+		t.Error("Expected a new edit to clear the redo history")
+	}
+}
@@ -0,0 +1,43 @@
+package lesson
+
+import "sync"
+
+// ReviewObserver is told about every answer added to a word list, such as
+// to keep track of how much is practised each day
+type ReviewObserver interface {
+	// Reviewed is called once the result of an answer to an item of list
+	// was added. Results without a time were given just now.
+	Reviewed(list *WordList, result TestResult)
+}
+
+var (
+	reviewObserversMu sync.Mutex
+	reviewObservers   []ReviewObserver
+)
+
+// AddReviewObserver starts telling observer about answers
+func AddReviewObserver(observer ReviewObserver) {
+	reviewObserversMu.Lock()
+	defer reviewObserversMu.Unlock()
+	reviewObservers = append(reviewObservers, observer)
+}
+
+// RemoveReviewObserver stops telling observer about answers
+func RemoveReviewObserver(observer ReviewObserver) {
+	reviewObserversMu.Lock()
+	defer reviewObserversMu.Unlock()
+	for i, registered := range reviewObservers {
+		if registered == observer {
+			reviewObservers = append(reviewObservers[:i:i], reviewObservers[i+1:]...)
+			return
+		}
+	}
+}
+
+// currentReviewObservers returns the observers, so they are called without
+// holding the lock
+func currentReviewObservers() []ReviewObserver {
+	reviewObserversMu.Lock()
+	defer reviewObserversMu.Unlock()
+	return append([]ReviewObserver(nil), reviewObservers...)
+}
@@ -0,0 +1,196 @@
+package lesson
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Formats with choices describe them as save options, so the save dialog
+// can ask for them in an options step after the file name instead of every
+// format being written with the defaults. Each option reads and writes one
+// setting of SaveOptions through its Key.
+
+// Kinds of save options
+const (
+	// SaveOptionChoice is one of a list of values, shown as a drop-down
+	SaveOptionChoice = "choice"
+	// SaveOptionToggle is "true" or "false", shown as a check box
+	SaveOptionToggle = "toggle"
+	// SaveOptionSecret is text that is not shown, such as a passphrase
+	SaveOptionSecret = "secret"
+)
+
+// SaveOptionValue is a value a SaveOptionChoice can take
+type SaveOptionValue struct {
+	Value string
+	Label string
+}
+
+// SaveOption describes a choice a format offers when saving
+type SaveOption struct {
+	// Key names the setting of SaveOptions, such as "csv.delimiter"
+	Key   string
+	Label string
+	Kind  string
+	// Values are the values of a SaveOptionChoice
+	Values []SaveOptionValue
+}
+
+// GetSaveOptions returns the options saving as ext offers, none for
+// formats without choices
+func (fs *FileSaver) GetSaveOptions(ext string) []SaveOption {
+	imageOptions := []SaveOption{
+		{Key: "images.maxSize", Label: "Shrink pictures to", Kind: SaveOptionChoice, Values: []SaveOptionValue{
+			{"0", "Keep their size"}, {"1024", "1024 pixels"}, {"1600", "1600 pixels"}, {"2048", "2048 pixels"},
+		}},
+		{Key: "images.keepOriginals", Label: "Keep the originals of shrunk pictures", Kind: SaveOptionToggle},
+	}
+
+	switch strings.ToLower(ext) {
+	case ".csv":
+		return []SaveOption{
+			{Key: "csv.delimiter", Label: "Separate columns with", Kind: SaveOptionChoice, Values: []SaveOptionValue{
+				{",", "Commas"}, {";", "Semicolons"}, {"\t", "Tabs"}, {"|", "Vertical bars"},
+			}},
+			{Key: "csv.quote", Label: "Quote text with", Kind: SaveOptionChoice, Values: []SaveOptionValue{
+				{`"`, "Double quotes"}, {"'", "Single quotes"},
+			}},
+			{Key: "csv.header", Label: "Start with a row naming the languages", Kind: SaveOptionToggle},
+			{Key: "csv.lineEnding", Label: "Line endings", Kind: SaveOptionChoice, Values: []SaveOptionValue{
+				{"lf", "Linux and macOS (LF)"}, {"crlf", "Windows (CR LF)"},
+			}},
+			{Key: "encoding", Label: "Encoding", Kind: SaveOptionChoice, Values: []SaveOptionValue{
+				{EncodingUTF8, "UTF-8"},
+				{EncodingUTF8BOM, "UTF-8 with byte order mark (Excel)"},
+				{EncodingUTF16LE, "UTF-16"},
+				{EncodingWindows1252, "Western European (Windows-1252)"},
+				{EncodingLatin1, "Western European (ISO-8859-1)"},
+			}},
+		}
+	case ".html":
+		themes := []SaveOptionValue{{"", "The lesson's own"}}
+		for _, theme := range HTMLThemes() {
+			themes = append(themes, SaveOptionValue{theme, strings.ToUpper(theme[:1]) + theme[1:]})
+		}
+		return []SaveOption{
+			{Key: "html.theme", Label: "Theme", Kind: SaveOptionChoice, Values: themes},
+			{Key: "html.interactive", Label: "Hide the answers until they are clicked", Kind: SaveOptionToggle},
+		}
+	case ".otmd":
+		return append([]SaveOption{
+			{Key: "media.storage", Label: "Media files", Kind: SaveOptionChoice, Values: []SaveOptionValue{
+				{MediaEmbed, "Store them in the lesson"}, {MediaLink, "Refer to them where they are"},
+			}},
+		}, imageOptions...)
+	case ".otio":
+		return imageOptions
+	case ".otsec":
+		return []SaveOption{
+			{Key: "passphrase", Label: "Passphrase", Kind: SaveOptionSecret},
+		}
+	}
+	return nil
+}
+
+// Value returns the setting named key as a string, as SaveOption values
+// are
+func (o SaveOptions) Value(key string) string {
+	switch key {
+	case "csv.delimiter":
+		if o.CSVDelimiter == 0 {
+			return ","
+		}
+		return string(o.CSVDelimiter)
+	case "csv.quote":
+		if o.CSVQuote == 0 {
+			return `"`
+		}
+		return string(o.CSVQuote)
+	case "csv.header":
+		return strconv.FormatBool(!o.CSVOmitHeader)
+	case "csv.lineEnding":
+		if o.CSVUseCRLF {
+			return "crlf"
+		}
+		return "lf"
+	case "encoding":
+		if o.Encoding == "" {
+			return EncodingUTF8
+		}
+		return o.Encoding
+	case "html.theme":
+		return o.HTMLTheme
+	case "html.interactive":
+		return strconv.FormatBool(o.HTMLInteractive)
+	case "media.storage":
+		if o.MediaStorage == "" {
+			return DefaultMediaStorage()
+		}
+		return o.MediaStorage
+	case "images.maxSize":
+		return strconv.Itoa(o.ImageCompression.MaxSize)
+	case "images.keepOriginals":
+		return strconv.FormatBool(o.ImageCompression.KeepOriginals)
+	case "passphrase":
+		return o.Passphrase
+	}
+	return ""
+}
+
+// SetValue changes the setting named key to value, as given by a
+// SaveOption
+func (o *SaveOptions) SetValue(key, value string) error {
+	var err error
+	switch key {
+	case "csv.delimiter", "csv.quote":
+		runes := []rune(value)
+		if len(runes) != 1 {
+			return fmt.Errorf("%s must be a single character, not %q", key, value)
+		}
+		if key == "csv.delimiter" {
+			o.CSVDelimiter = runes[0]
+		} else {
+			o.CSVQuote = runes[0]
+		}
+	case "csv.header":
+		var header bool
+		header, err = strconv.ParseBool(value)
+		o.CSVOmitHeader = !header
+	case "csv.lineEnding":
+		if value != "lf" && value != "crlf" {
+			return fmt.Errorf("unknown line ending %q", value)
+		}
+		o.CSVUseCRLF = value == "crlf"
+	case "encoding":
+		if _, err := textEncoding(value); err != nil {
+			return err
+		}
+		o.Encoding = value
+	case "html.theme":
+		if value != "" && !slices.Contains(HTMLThemes(), value) {
+			return fmt.Errorf("unknown HTML theme %q", value)
+		}
+		o.HTMLTheme = value
+	case "html.interactive":
+		o.HTMLInteractive, err = strconv.ParseBool(value)
+	case "media.storage":
+		if value != MediaEmbed && value != MediaLink {
+			return fmt.Errorf("unknown media storage %q", value)
+		}
+		o.MediaStorage = value
+	case "images.maxSize":
+		o.ImageCompression.MaxSize, err = strconv.Atoi(value)
+	case "images.keepOriginals":
+		o.ImageCompression.KeepOriginals, err = strconv.ParseBool(value)
+	case "passphrase":
+		o.Passphrase = value
+	default:
+		return fmt.Errorf("unknown save option %q", key)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid value %q for %s: %w", value, key, err)
+	}
+	return nil
+}
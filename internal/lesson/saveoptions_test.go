@@ -0,0 +1,88 @@
+package lesson
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveOptionsRoundTrip(t *testing.T) {
+	fs := NewFileSaver()
+	for _, ext := range []string{".csv", ".html", ".otmd", ".otio", ".otsec"} {
+		options := fs.GetSaveOptions(ext)
+		if len(options) == 0 {
+			t.Errorf("expected save options for %s", ext)
+		}
+		for _, option := range options {
+			values := []string{"true", "false"}
+			switch option.Kind {
+			case SaveOptionChoice:
+				values = values[:0]
+				for _, value := range option.Values {
+					values = append(values, value.Value)
+				}
+			case SaveOptionSecret:
+				values = []string{"secret"}
+			}
+			for _, value := range values {
+				saveOptions := DefaultSaveOptions()
+				if err := saveOptions.SetValue(option.Key, value); err != nil {
+					t.Errorf("%s: %s = %q: %v", ext, option.Key, value, err)
+				}
+				if got := saveOptions.Value(option.Key); got != value {
+					t.Errorf("%s: %s = %q after setting %q", ext, option.Key, got, value)
+				}
+			}
+		}
+	}
+	if options := fs.GetSaveOptions(".ot"); options != nil {
+		t.Errorf("expected no options for .ot, got %v", options)
+	}
+
+	options := DefaultSaveOptions()
+	if err := options.SetValue("csv.delimiter", ";;"); err == nil {
+		t.Error("expected an error for a delimiter of two characters")
+	}
+	if err := options.SetValue("html.theme", "neon"); err == nil {
+		t.Error("expected an error for an unknown theme")
+	}
+	if err := options.SetValue("colour", "red"); err == nil {
+		t.Error("expected an error for an unknown option")
+	}
+}
+
+func TestSaveWithOptions(t *testing.T) {
+	lessonData := NewLessonData()
+	lessonData.List.AddWordItem([]string{"chat"}, []string{"cat"}, "")
+	dir := t.TempDir()
+
+	options := DefaultSaveOptions()
+	for key, value := range map[string]string{"csv.delimiter": "\t", "csv.header": "false", "csv.lineEnding": "crlf"} {
+		if err := options.SetValue(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(dir, "words.csv")
+	if err := NewFileSaverWithOptions(options).SaveFile(lessonData, path); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "chat\tcat\t\t\r\n" {
+		t.Errorf("unexpected CSV %q", data)
+	}
+
+	options = DefaultSaveOptions()
+	options.SetValue("html.interactive", "true")
+	options.SetValue("html.theme", "chalkboard")
+	path = filepath.Join(dir, "words.html")
+	if err := NewFileSaverWithOptions(options).SaveFile(lessonData, path); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "<details><summary>Show</summary>cat</details>") {
+		t.Error("expected the answer to be hidden until clicked")
+	}
+	if !strings.Contains(string(data), htmlThemes["chalkboard"]) {
+		t.Error("expected the chalkboard theme")
+	}
+}
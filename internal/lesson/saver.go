@@ -3,30 +3,108 @@ package lesson
 import (
 	"archive/zip"
 	"bufio"
-	"encoding/csv"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"golang.org/x/text/encoding"
 )
 
 // FileSaver provides file saving functionality for various lesson formats
 type FileSaver struct {
-	// Future: add configuration options, templates, etc.
+	Options SaveOptions
 }
 
 // NewFileSaver creates a new FileSaver instance
 func NewFileSaver() *FileSaver {
-	return &FileSaver{}
+	return &FileSaver{Options: DefaultSaveOptions()}
+}
+
+// NewFileSaverWithOptions creates a FileSaver using the given options
+func NewFileSaverWithOptions(options SaveOptions) *FileSaver {
+	return &FileSaver{Options: options}
 }
 
-// SaveFile saves lesson data to a file in the appropriate format based on extension
+// SaveObserver is told about every lesson file saved with a FileSaver,
+// such as to notice files changed by other programs in the meantime
+type SaveObserver interface {
+	// BeforeSave is called before a file is written; returning an error
+	// keeps it from being written and is returned by SaveFile
+	BeforeSave(filePath string) error
+	// AfterSave is called once a file was written
+	AfterSave(filePath string)
+}
+
+var (
+	saveObserversMu sync.Mutex
+	saveObservers   []SaveObserver
+)
+
+// AddSaveObserver starts telling observer about saved files
+func AddSaveObserver(observer SaveObserver) {
+	saveObserversMu.Lock()
+	defer saveObserversMu.Unlock()
+	saveObservers = append(saveObservers, observer)
+}
+
+// RemoveSaveObserver stops telling observer about saved files
+func RemoveSaveObserver(observer SaveObserver) {
+	saveObserversMu.Lock()
+	defer saveObserversMu.Unlock()
+	for i, registered := range saveObservers {
+		if registered == observer {
+			saveObservers = append(saveObservers[:i:i], saveObservers[i+1:]...)
+			return
+		}
+	}
+}
+
+// currentSaveObservers returns the observers, so they are called without
+// holding the lock: BeforeSave may ask the user what to do
+func currentSaveObservers() []SaveObserver {
+	saveObserversMu.Lock()
+	defer saveObserversMu.Unlock()
+	return append([]SaveObserver(nil), saveObservers...)
+}
+
+// SaveFile saves lesson data to a file in the appropriate format based on
+// extension, telling the save observers. The file is replaced at once, and
+// its earlier version kept as a .bak copy. It returns an *ErrLocked when
+// another program has locked the file.
 func (fs *FileSaver) SaveFile(lessonData *LessonData, filePath string) error {
+	lock, err := LockFile(filePath)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	observers := currentSaveObservers()
+	for _, observer := range observers {
+		if err := observer.BeforeSave(filePath); err != nil {
+			return err
+		}
+	}
+	if err := fs.saveAtomically(lessonData, filePath); err != nil {
+		return err
+	}
+	for _, observer := range observers {
+		observer.AfterSave(filePath)
+	}
+	return nil
+}
+
+// saveFile writes lesson data in the format of the extension of filePath
+func (fs *FileSaver) saveFile(lessonData *LessonData, filePath string) error {
 	ext := strings.ToLower(filepath.Ext(filePath))
 
 	log.Printf("[ACTION] FileSaver.SaveFile() - saving to %s format", ext)
@@ -52,74 +130,91 @@ func (fs *FileSaver) SaveFile(lessonData *LessonData, filePath string) error {
 		return fs.saveOpenTeachingTopoFile(lessonData, filePath)
 	case ".otmd":
 		return fs.saveOpenTeachingMediaFile(lessonData, filePath)
+	case ".otio":
+		return fs.saveOpenTeachingOcclusionFile(lessonData, filePath)
+	case ".xlsx":
+		return fs.saveXLSXFile(lessonData, filePath)
+	case ".ods":
+		return fs.saveODSFile(lessonData, filePath)
+	case ".md", ".markdown":
+		return fs.saveMarkdownFile(lessonData, filePath)
+	case ".mem":
+		return fs.saveMnemosyneMemFile(lessonData, filePath)
+	case ".cards":
+		return fs.saveMnemosyneCardsFile(lessonData, filePath)
+	case ".pau":
+		return fs.savePaukerFile(lessonData, filePath)
+	case ".otsec":
+		return fs.saveEncryptedFile(lessonData, filePath)
+	case ".gz":
+		lower := strings.ToLower(filePath)
+		if strings.HasSuffix(lower, ".pau.gz") || strings.HasSuffix(lower, ".xml.gz") {
+			return fs.savePaukerFile(lessonData, filePath)
+		}
+		return &formatError{action: "save", ext: ext}
 	default:
-		return fmt.Errorf("unsupported save format: %s", ext)
+		return &formatError{action: "save", ext: ext}
 	}
 }
 
-// saveCSVFile saves lesson data as CSV format with proper headers and encoding
+// saveCSVFile saves lesson data as CSV format with proper headers and encoding.
+// The delimiter, quoting, header and encoding follow fs.Options.
 func (fs *FileSaver) saveCSVFile(lessonData *LessonData, filePath string) error {
 	log.Printf("[ACTION] FileSaver.saveCSVFile() - saving CSV file")
 
-	file, err := os.Create(filePath)
-	if err != nil {
-		log.Printf("[ERROR] Failed to create CSV file: %v", err)
-		return err
+	delimiter := fs.Options.CSVDelimiter
+	if delimiter == 0 {
+		delimiter = ','
 	}
-	defer file.Close()
-
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
-
-	// Determine header names
-	questionHeader := lessonData.List.QuestionLanguage
-	if questionHeader == "" {
-		questionHeader = "Questions"
+	quote := fs.Options.CSVQuote
+	if quote == 0 {
+		quote = '"'
 	}
-
-	answerHeader := lessonData.List.AnswerLanguage
-	if answerHeader == "" {
-		answerHeader = "Answers"
+	lineEnding := "\n"
+	if fs.Options.CSVUseCRLF {
+		lineEnding = "\r\n"
 	}
 
-	// Write CSV header
-	headers := []string{
-		questionHeader,
-		answerHeader,
-		"Comment",
-		"Comment After Answering",
-	}
+	var content strings.Builder
 
-	if err := writer.Write(headers); err != nil {
-		log.Printf("[ERROR] Failed to write CSV header: %v", err)
-		return err
+	if !fs.Options.CSVOmitHeader {
+		// Write CSV header
+		headers := []string{
+			getColumnHeader(lessonData.List.QuestionLanguage, "Questions"),
+			getColumnHeader(lessonData.List.AnswerLanguage, "Answers"),
+			"Comment",
+			"Comment After Answering",
+		}
+		content.WriteString(formatCSVRecord(headers, delimiter, quote) + lineEnding)
 	}
 
 	// Write lesson items
 	for _, item := range lessonData.List.Items {
-		// Compose questions (join multiple questions with semicolon)
-		questions := strings.Join(item.Questions, "; ")
-
-		// Compose answers (join multiple answers with semicolon)
-		answers := strings.Join(item.Answers, "; ")
-
-		// Get comment
-		comment := item.Comment
-
-		// Comment after answering (placeholder - this field exists in OpenTeacher format)
-		commentAfterAnswering := ""
-
 		record := []string{
-			questions,
-			answers,
-			comment,
-			commentAfterAnswering,
+			// Join multiple questions and answers with semicolons
+			strings.Join(item.Questions, "; "),
+			strings.Join(item.Answers, "; "),
+			item.Comment,
+			// Comment after answering (placeholder - this field exists in OpenTeacher format)
+			"",
 		}
+		content.WriteString(formatCSVRecord(record, delimiter, quote) + lineEnding)
+	}
 
-		if err := writer.Write(record); err != nil {
-			log.Printf("[ERROR] Failed to write CSV record: %v", err)
-			return err
-		}
+	enc, err := textEncoding(fs.Options.Encoding)
+	if err != nil {
+		log.Printf("[ERROR] %v", err)
+		return err
+	}
+	encoded, err := encoding.ReplaceUnsupported(enc.NewEncoder()).Bytes([]byte(content.String()))
+	if err != nil {
+		log.Printf("[ERROR] Failed to encode CSV file: %v", err)
+		return err
+	}
+
+	if err := os.WriteFile(filePath, encoded, 0644); err != nil {
+		log.Printf("[ERROR] Failed to write CSV file: %v", err)
+		return err
 	}
 
 	log.Printf("[SUCCESS] FileSaver.saveCSVFile() - saved %d items to CSV file", len(lessonData.List.Items))
@@ -299,7 +394,7 @@ func (fs *FileSaver) saveTextFile(lessonData *LessonData, filePath string) error
 	return nil
 }
 
-// saveJSONFile saves lesson data in JSON format
+// saveJSONFile saves lesson data in version 2 of the canonical JSON format
 func (fs *FileSaver) saveJSONFile(lessonData *LessonData, filePath string) error {
 	log.Printf("[ACTION] FileSaver.saveJSONFile() - saving JSON file")
 
@@ -313,7 +408,7 @@ func (fs *FileSaver) saveJSONFile(lessonData *LessonData, filePath string) error
 	encoder := json.NewEncoder(file)
 	encoder.SetIndent("", "  ")
 
-	if err := encoder.Encode(lessonData); err != nil {
+	if err := encoder.Encode(NewLessonDocument(lessonData)); err != nil {
 		log.Printf("[ERROR] Failed to write JSON: %v", err)
 		return err
 	}
@@ -354,6 +449,8 @@ type Teach2000Item struct {
 	Errors       int                 `xml:"errors"`
 	TestCount    int                 `xml:"testcount"`
 	CorrectCount int                 `xml:"correctcount"`
+	// Extra holds elements kept from a loaded Teach2000 file
+	Extra []rawXMLElement `xml:",any"`
 }
 
 // Teach2000Question represents a question element
@@ -495,11 +592,15 @@ func (fs *FileSaver) saveTeach2000File(lessonData *LessonData, filePath string)
 			})
 		}
 
-		// Add statistics
+		// Add statistics, on top of those read from a Teach2000 file
 		stat := stats[item.ID]
-		t2kItem.Errors = stat.Wrong
-		t2kItem.TestCount = stat.Right + stat.Wrong
-		t2kItem.CorrectCount = stat.Right
+		wrong, _ := item.ExtraInt(ExtraT2KErrors)
+		tested, _ := item.ExtraInt(ExtraT2KTestCount)
+		right, _ := item.ExtraInt(ExtraT2KCorrectCount)
+		t2kItem.Errors = wrong + stat.Wrong
+		t2kItem.TestCount = tested + stat.Right + stat.Wrong
+		t2kItem.CorrectCount = right + stat.Right
+		t2kItem.Extra = rawXMLElements(item.Extras, ExtraT2KItem)
 
 		items = append(items, t2kItem)
 	}
@@ -594,6 +695,8 @@ type KVTMLIdentifier struct {
 type KVTMLEntry struct {
 	ID           string             `xml:"id,attr"`
 	Translations []KVTMLTranslation `xml:"translation"`
+	// Extra holds elements kept from a loaded KVTML file
+	Extra []rawXMLElement `xml:",any"`
 }
 
 // KVTMLTranslation represents a translation in an entry
@@ -601,6 +704,8 @@ type KVTMLTranslation struct {
 	ID      string `xml:"id,attr"`
 	Text    string `xml:"text"`
 	Comment string `xml:"comment,omitempty"`
+	// Extra holds elements kept from a loaded KVTML file
+	Extra []rawXMLElement `xml:",any"`
 }
 
 // KVTMLLesson represents a lesson container
@@ -608,6 +713,8 @@ type KVTMLLesson struct {
 	Name       string             `xml:"name"`
 	InPractice string             `xml:"inpractice"`
 	Entries    []KVTMLLessonEntry `xml:"entry"`
+	// Lessons are the sub-lessons of the lesson
+	Lessons []KVTMLLesson `xml:"container"`
 }
 
 // KVTMLLessonEntry represents an entry reference in a lesson
@@ -656,12 +763,15 @@ func (fs *FileSaver) saveKVTMLFile(lessonData *LessonData, filePath string) erro
 					ID:      "0",
 					Text:    strings.Join(item.Questions, ", "),
 					Comment: item.Comment,
+					Extra:   rawXMLElements(item.Extras, ExtraKVTMLQuestion),
 				},
 				{
-					ID:   "1",
-					Text: strings.Join(item.Answers, ", "),
+					ID:    "1",
+					Text:  ComposeSynonymGroups(item.SynonymGroups()),
+					Extra: rawXMLElements(item.Extras, ExtraKVTMLAnswer),
 				},
 			},
+			Extra: rawXMLElements(item.Extras, ExtraKVTMLEntry),
 		}
 		entries = append(entries, entry)
 	}
@@ -676,28 +786,12 @@ func (fs *FileSaver) saveKVTMLFile(lessonData *LessonData, filePath string) erro
 	})
 	kvtmlXML.Entries = entries
 
-	// Process lessons (tests)
-	lessons := make([]KVTMLLesson, 0, len(lessonData.List.Tests))
-	for i, test := range lessonData.List.Tests {
-		lesson := KVTMLLesson{
-			Name:       fmt.Sprintf("Lesson %d", i+1),
-			InPractice: "true",
-		}
-
-		// Add entries that were tested
-		testedItems := make(map[int]bool)
-		for _, result := range test.Results {
-			if !testedItems[result.ItemID] {
-				lesson.Entries = append(lesson.Entries, KVTMLLessonEntry{
-					ID: strconv.Itoa(result.ItemID),
-				})
-				testedItems[result.ItemID] = true
-			}
-		}
-
-		lessons = append(lessons, lesson)
+	// The chapters of the items are the lessons; lists without chapters
+	// keep a lesson of the words of every test
+	kvtmlXML.Lessons = kvtmlChapterLessons(lessonData.List.Items)
+	if len(kvtmlXML.Lessons) == 0 {
+		kvtmlXML.Lessons = kvtmlTestLessons(lessonData.List.Tests)
 	}
-	kvtmlXML.Lessons = lessons
 
 	// Create file and write XML
 	file, err := os.Create(filePath)
@@ -727,6 +821,55 @@ func (fs *FileSaver) saveKVTMLFile(lessonData *LessonData, filePath string) erro
 	return nil
 }
 
+// kvtmlChapterLessons returns the lessons of a KVTML file holding the
+// items in their chapters, nested like them
+func kvtmlChapterLessons(items []WordItem) []KVTMLLesson {
+	var lessons []KVTMLLesson
+	for _, item := range items {
+		siblings := &lessons
+		var lesson *KVTMLLesson
+		for _, name := range item.Chapter {
+			i := slices.IndexFunc(*siblings, func(l KVTMLLesson) bool { return l.Name == name })
+			if i < 0 {
+				*siblings = append(*siblings, KVTMLLesson{Name: name, InPractice: "true"})
+				i = len(*siblings) - 1
+			}
+			lesson = &(*siblings)[i]
+			siblings = &lesson.Lessons
+		}
+		if lesson != nil {
+			lesson.Entries = append(lesson.Entries, KVTMLLessonEntry{ID: strconv.Itoa(item.ID)})
+		}
+	}
+	return lessons
+}
+
+// kvtmlTestLessons returns the lessons of a KVTML file holding the items
+// asked in each test
+func kvtmlTestLessons(tests []Test) []KVTMLLesson {
+	lessons := make([]KVTMLLesson, 0, len(tests))
+	for i, test := range tests {
+		lesson := KVTMLLesson{
+			Name:       fmt.Sprintf("Lesson %d", i+1),
+			InPractice: "true",
+		}
+
+		// Add entries that were tested
+		testedItems := make(map[int]bool)
+		for _, result := range test.Results {
+			if !testedItems[result.ItemID] {
+				lesson.Entries = append(lesson.Entries, KVTMLLessonEntry{
+					ID: strconv.Itoa(result.ItemID),
+				})
+				testedItems[result.ItemID] = true
+			}
+		}
+
+		lessons = append(lessons, lesson)
+	}
+	return lessons
+}
+
 // saveHTMLFile saves lesson data in HTML format with modern styling
 func (fs *FileSaver) saveHTMLFile(lessonData *LessonData, filePath string) error {
 	log.Printf("[ACTION] FileSaver.saveHTMLFile() - saving HTML file")
@@ -738,105 +881,46 @@ func (fs *FileSaver) saveHTMLFile(lessonData *LessonData, filePath string) error
 	}
 	defer file.Close()
 
-	writer := bufio.NewWriter(file)
-	defer writer.Flush()
+	if err := writeHTML(file, lessonData, fs.Options.HTMLTheme, fs.Options.HTMLInteractive); err != nil {
+		log.Printf("[ERROR] Failed to write HTML file: %v", err)
+		return err
+	}
+
+	log.Printf("[SUCCESS] FileSaver.saveHTMLFile() - saved %d items to HTML file", len(lessonData.List.Items))
+	return nil
+}
+
+// WriteHTML renders lessonData as a styled, standalone HTML page. It is
+// what the HTML file format saves, and what other programs can use to show
+// a lesson without the GUI.
+func WriteHTML(w io.Writer, lessonData *LessonData) error {
+	return writeHTML(w, lessonData, "", false)
+}
 
-	// Write HTML header with modern CSS styling
+// writeHTML renders lessonData as WriteHTML does, with theme instead of the
+// lesson's own when it is set. An interactive page hides every answer until
+// it is clicked, so students can test themselves.
+func writeHTML(w io.Writer, lessonData *LessonData, theme string, interactive bool) error {
+	writer := bufio.NewWriter(w)
+
+	// Write HTML header styled with the lesson's theme
 	fmt.Fprintf(writer, `<!DOCTYPE html>
 <html lang="en">
 <head>
     <meta charset="UTF-8">
     <meta name="viewport" content="width=device-width, initial-scale=1.0">
     <title>%s</title>
-    <style>
-        body {
-            font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif;
-            max-width: 800px;
-            margin: 0 auto;
-            padding: 20px;
-            line-height: 1.6;
-            color: #333;
-        }
-        .header {
-            text-align: center;
-            margin-bottom: 30px;
-            padding-bottom: 20px;
-            border-bottom: 2px solid #eee;
-        }
-        .title {
-            font-size: 2.5em;
-            margin-bottom: 10px;
-            color: #2c3e50;
-        }
-        .languages {
-            font-size: 1.2em;
-            color: #7f8c8d;
-            font-style: italic;
-        }
-        .vocabulary-table {
-            width: 100%%;
-            border-collapse: collapse;
-            margin-top: 20px;
-            box-shadow: 0 2px 10px rgba(0,0,0,0.1);
-        }
-        .vocabulary-table th {
-            background-color: #3498db;
-            color: white;
-            padding: 15px;
-            text-align: left;
-            font-weight: 600;
-        }
-        .vocabulary-table td {
-            padding: 12px 15px;
-            border-bottom: 1px solid #eee;
-        }
-        .vocabulary-table tr:nth-child(even) {
-            background-color: #f8f9fa;
-        }
-        .vocabulary-table tr:hover {
-            background-color: #e3f2fd;
-        }
-        .question {
-            font-weight: 500;
-            color: #2c3e50;
-        }
-        .answer {
-            color: #27ae60;
-        }
-        .comment {
-            font-style: italic;
-            color: #7f8c8d;
-            font-size: 0.9em;
-        }
-        .stats {
-            margin-top: 30px;
-            text-align: center;
-            color: #7f8c8d;
-        }
-        @media (max-width: 600px) {
-            body { padding: 10px; }
-            .vocabulary-table th,
-            .vocabulary-table td {
-                padding: 8px;
-                font-size: 0.9em;
-            }
-            .title { font-size: 2em; }
-        }
-        @media print {
-            body { max-width: none; }
-            .vocabulary-table tr:hover { background-color: transparent !important; }
-        }
-    </style>
+    <style>%s    </style>
 </head>
 <body>
     <div class="header">
-        <h1 class="title">%s</h1>`, lessonData.List.Title, lessonData.List.Title)
+        <h1 class="title">%s</h1>`, htmlEscape(lessonData.List.Title), htmlStyle(lessonData, theme), htmlEscape(lessonData.List.Title))
 
 	// Add language information
 	if lessonData.List.QuestionLanguage != "" && lessonData.List.AnswerLanguage != "" {
 		fmt.Fprintf(writer, `
         <div class="languages">%s → %s</div>`,
-			lessonData.List.QuestionLanguage, lessonData.List.AnswerLanguage)
+			htmlEscape(lessonData.List.QuestionLanguage), htmlEscape(lessonData.List.AnswerLanguage))
 	}
 
 	fmt.Fprintf(writer, `
@@ -847,8 +931,8 @@ func (fs *FileSaver) saveHTMLFile(lessonData *LessonData, filePath string) error
             <tr>
                 <th>%s</th>
                 <th>%s</th>`,
-		getColumnHeader(lessonData.List.QuestionLanguage, "Questions"),
-		getColumnHeader(lessonData.List.AnswerLanguage, "Answers"))
+		htmlEscape(getColumnHeader(lessonData.List.QuestionLanguage, "Questions")),
+		htmlEscape(getColumnHeader(lessonData.List.AnswerLanguage, "Answers")))
 
 	// Add comment column if any items have comments
 	hasComments := false
@@ -870,12 +954,15 @@ func (fs *FileSaver) saveHTMLFile(lessonData *LessonData, filePath string) error
 
 	// Write vocabulary items
 	for _, item := range lessonData.List.Items {
+		answer := htmlEscape(strings.Join(item.Answers, ", "))
+		if interactive {
+			answer = "<details><summary>Show</summary>" + answer + "</details>"
+		}
 		fmt.Fprintf(writer, `
             <tr>
                 <td class="question">%s</td>
                 <td class="answer">%s</td>`,
-			htmlEscape(strings.Join(item.Questions, ", ")),
-			htmlEscape(strings.Join(item.Answers, ", ")))
+			htmlEscape(strings.Join(item.Questions, ", ")), answer)
 
 		if hasComments {
 			comment := ""
@@ -901,8 +988,7 @@ func (fs *FileSaver) saveHTMLFile(lessonData *LessonData, filePath string) error
 </body>
 </html>`, len(lessonData.List.Items))
 
-	log.Printf("[SUCCESS] FileSaver.saveHTMLFile() - saved %d items to HTML file", len(lessonData.List.Items))
-	return nil
+	return writer.Flush()
 }
 
 // htmlEscape escapes HTML special characters
@@ -1076,13 +1162,19 @@ func latexEscape(s string) string {
 func (fs *FileSaver) GetSupportedSaveExtensions() []string {
 	return []string{
 		".csv",
-		".ot",    // OpenTeacher format
-		".txt",   // Plain text
-		".json",  // JSON format
-		".t2k",   // Teach2000 format
-		".kvtml", // KDE Vocabulary Document
-		".html",  // HTML export
-		".tex",   // LaTeX export
+		".ot",     // OpenTeacher format
+		".txt",    // Plain text
+		".json",   // JSON format
+		".t2k",    // Teach2000 format
+		".kvtml",  // KDE Vocabulary Document
+		".html",   // HTML export
+		".tex",    // LaTeX export
+		".xlsx",   // Excel workbook
+		".ods",    // OpenDocument spreadsheet
+		".md",     // Markdown flashcards
+		".mem",    // Mnemosyne 1.x export
+		".cards",  // Mnemosyne 2.x cards file
+		".pau.gz", // Pauker lesson
 		// Future formats to be implemented:
 		// ".xml",   // Generic XML
 		// ".pdf",   // PDF export (requires additional libraries)
@@ -1112,6 +1204,18 @@ func (fs *FileSaver) GetSaveFormatName(ext string) string {
 		return "PDF Document"
 	case ".tex":
 		return "LaTeX Document"
+	case ".xlsx":
+		return "Excel Workbook"
+	case ".ods":
+		return "OpenDocument Spreadsheet"
+	case ".md", ".markdown":
+		return "Markdown Flashcards"
+	case ".mem":
+		return "Mnemosyne 1.x Export"
+	case ".cards":
+		return "Mnemosyne 2.x Cards"
+	case ".pau", ".pau.gz":
+		return "Pauker Lesson"
 	default:
 		return "Unknown Format"
 	}
@@ -1225,6 +1329,7 @@ func (fs *FileSaver) saveOpenTeachingTopoFile(lessonData *LessonData, filePath s
 		"items":               make([]map[string]interface{}, 0),
 		"tests":               make([]interface{}, 0),
 	}
+	lessonData.List.writeMetadata(otData)
 
 	// Convert items to OpenTeacher format
 	for _, item := range lessonData.List.Items {
@@ -1238,6 +1343,7 @@ func (fs *FileSaver) saveOpenTeachingTopoFile(lessonData *LessonData, filePath s
 			if item.Name == "" && len(item.Questions) > 0 {
 				otItem["name"] = item.Questions[0]
 			}
+			item.writeItemState(otItem)
 			otData["items"] = append(otData["items"].([]map[string]interface{}), otItem)
 		}
 	}
@@ -1250,7 +1356,7 @@ func (fs *FileSaver) saveOpenTeachingTopoFile(lessonData *LessonData, filePath s
 	}
 	defer zipFile.Close()
 
-	zipWriter := zip.NewWriter(zipFile)
+	zipWriter := newArchiveWriter(zipFile)
 	defer zipWriter.Close()
 
 	// Add list.json to ZIP
@@ -1286,6 +1392,26 @@ func (fs *FileSaver) saveOpenTeachingMediaFile(lessonData *LessonData, filePath
 		"items":               make([]map[string]interface{}, 0),
 		"tests":               make([]interface{}, 0),
 	}
+	lessonData.List.writeMetadata(otData)
+
+	// Media is embedded in the archive or linked relative to it
+	storage := fs.Options.MediaStorage
+	if storage == "" {
+		storage = DefaultMediaStorage()
+	}
+	media, err := lessonData.planMedia(storage, filepath.Dir(filePath))
+	if err != nil {
+		log.Printf("[ERROR] Failed to store media: %v", err)
+		return err
+	}
+	for name, data := range media.embedded {
+		shrunk, _, _, err := fs.Options.ImageCompression.shrink(filepath.Dir(filePath), name, data)
+		if err != nil {
+			log.Printf("[ERROR] Failed to keep the original of %s: %v", name, err)
+			return err
+		}
+		media.embedded[name] = shrunk
+	}
 
 	// Convert items to OpenTeacher format
 	for _, item := range lessonData.List.Items {
@@ -1297,8 +1423,12 @@ func (fs *FileSaver) saveOpenTeachingMediaFile(lessonData *LessonData, filePath
 			}
 
 			if hasMedia {
+				if stored, ok := media.filenames[filename]; ok {
+					filename = stored
+				}
 				otItem["filename"] = filename
 			}
+			item.writeItemState(otItem)
 
 			// Add questions and answers
 			if len(item.Questions) > 0 {
@@ -1325,7 +1455,7 @@ func (fs *FileSaver) saveOpenTeachingMediaFile(lessonData *LessonData, filePath
 	}
 	defer zipFile.Close()
 
-	zipWriter := zip.NewWriter(zipFile)
+	zipWriter := newArchiveWriter(zipFile)
 	defer zipWriter.Close()
 
 	// Add list.json to ZIP
@@ -1347,6 +1477,126 @@ func (fs *FileSaver) saveOpenTeachingMediaFile(lessonData *LessonData, filePath
 		return err
 	}
 
+	names := make([]string, 0, len(media.embedded))
+	for name := range media.embedded {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		// Media is already compressed, storing it saves time
+		mediaWriter, err := zipWriter.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+		if err != nil {
+			log.Printf("[ERROR] Failed to create %s in ZIP: %v", name, err)
+			return err
+		}
+		if _, err := mediaWriter.Write(media.embedded[name]); err != nil {
+			log.Printf("[ERROR] Failed to write media to ZIP: %v", err)
+			return err
+		}
+	}
+
 	log.Printf("[SUCCESS] FileSaver.saveOpenTeachingMediaFile() - saved %d media items", len(otData["items"].([]map[string]interface{})))
 	return nil
 }
+
+// saveOpenTeachingOcclusionFile saves lesson data as OpenTeaching Image
+// Occlusion (.otio) format, storing the image next to list.json
+func (fs *FileSaver) saveOpenTeachingOcclusionFile(lessonData *LessonData, filePath string) error {
+	log.Printf("[ACTION] FileSaver.saveOpenTeachingOcclusionFile() - saving OpenTeaching Image Occlusion file")
+
+	otData := occlusionList{
+		FileFormatVersion: "3.1",
+		Title:             lessonData.List.Title,
+		AnswerLanguage:    lessonData.List.AnswerLanguage,
+		Items:             make([]occlusionMask, 0, len(lessonData.List.Items)),
+		Tests:             lessonData.List.Tests,
+		Tags:              lessonData.List.Tags,
+		Author:            lessonData.List.Author,
+		Description:       lessonData.List.Description,
+		License:           lessonData.List.License,
+		Level:             lessonData.List.Level,
+		UUID:              lessonData.List.UUID,
+		Sync:              lessonData.List.Sync,
+	}
+	if otData.Tests == nil {
+		otData.Tests = make([]Test, 0)
+	}
+
+	img, hasImage := lessonData.OcclusionImage()
+	imageData, scaleX, scaleY := []byte(nil), 1.0, 1.0
+	if hasImage {
+		otData.Image = "resources/" + filepath.Base(img.Name)
+		// Masks shrink with the image they are drawn over
+		var err error
+		imageData, scaleX, scaleY, err = fs.Options.ImageCompression.shrink(filepath.Dir(filePath), img.Name, img.Data)
+		if err != nil {
+			log.Printf("[ERROR] Failed to keep the original of %s: %v", img.Name, err)
+			return err
+		}
+	}
+
+	for _, item := range lessonData.List.Items {
+		rect, ok := item.OcclusionRect()
+		if !ok {
+			continue
+		}
+		rect = scaleRect(rect, scaleX, scaleY)
+		otData.Items = append(otData.Items, occlusionMask{
+			ID:         item.ID,
+			Name:       item.Name,
+			Answers:    item.Answers,
+			Comment:    item.Comment,
+			X:          rect.Min.X,
+			Y:          rect.Min.Y,
+			Width:      rect.Dx(),
+			Height:     rect.Dy(),
+			Starred:    item.Starred,
+			Difficulty: item.Difficulty,
+			Tags:       item.Tags,
+			Chapter:    item.Chapter,
+		})
+	}
+
+	zipFile, err := os.Create(filePath)
+	if err != nil {
+		log.Printf("[ERROR] Failed to create OTIO file: %v", err)
+		return err
+	}
+	defer zipFile.Close()
+
+	zipWriter := newArchiveWriter(zipFile)
+	defer zipWriter.Close()
+
+	jsonWriter, err := zipWriter.Create("list.json")
+	if err != nil {
+		log.Printf("[ERROR] Failed to create list.json in ZIP: %v", err)
+		return err
+	}
+	jsonData, err := json.MarshalIndent(otData, "", "  ")
+	if err != nil {
+		log.Printf("[ERROR] Failed to marshal occlusion JSON: %v", err)
+		return err
+	}
+	if _, err := jsonWriter.Write(jsonData); err != nil {
+		log.Printf("[ERROR] Failed to write JSON to ZIP: %v", err)
+		return err
+	}
+
+	if hasImage {
+		// The image is already compressed, storing it saves time
+		imageWriter, err := zipWriter.CreateHeader(&zip.FileHeader{Name: otData.Image, Method: zip.Store})
+		if err != nil {
+			log.Printf("[ERROR] Failed to create %s in ZIP: %v", otData.Image, err)
+			return err
+		}
+		if _, err := imageWriter.Write(imageData); err != nil {
+			log.Printf("[ERROR] Failed to write image to ZIP: %v", err)
+			return err
+		}
+	} else {
+		log.Printf("[WARNING] Saving image occlusion lesson without an image")
+	}
+
+	log.Printf("[SUCCESS] FileSaver.saveOpenTeachingOcclusionFile() - saved %d masks", len(otData.Items))
+	return nil
+}
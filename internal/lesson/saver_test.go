@@ -1443,3 +1443,49 @@ func equalStringSlices(a, b []string) bool {
 	}
 	return true
 }
+
+// recordingObserver remembers the files it was told about, and refuses to
+// let refuse be written
+type recordingObserver struct {
+	refuse string
+	before []string
+	after  []string
+}
+
+func (o *recordingObserver) BeforeSave(filePath string) error {
+	o.before = append(o.before, filepath.Base(filePath))
+	if filepath.Base(filePath) == o.refuse {
+		return fmt.Errorf("refused")
+	}
+	return nil
+}
+
+func (o *recordingObserver) AfterSave(filePath string) {
+	o.after = append(o.after, filepath.Base(filePath))
+}
+
+func TestSaveObserver(t *testing.T) {
+	dir := t.TempDir()
+	observer := &recordingObserver{refuse: "changed.csv"}
+	AddSaveObserver(observer)
+	defer RemoveSaveObserver(observer)
+
+	lessonData := NewLessonData()
+	if err := NewFileSaver().SaveFile(lessonData, filepath.Join(dir, "lesson.csv")); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewFileSaver().SaveFile(lessonData, filepath.Join(dir, "changed.csv")); err == nil {
+		t.Error("the observer should have kept changed.csv from being saved")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "changed.csv")); err == nil {
+		t.Error("changed.csv was written anyway")
+	}
+	if strings.Join(observer.before, ",") != "lesson.csv,changed.csv" || strings.Join(observer.after, ",") != "lesson.csv" {
+		t.Errorf("observer told before %v, after %v", observer.before, observer.after)
+	}
+
+	RemoveSaveObserver(observer)
+	if err := NewFileSaver().SaveFile(lessonData, filepath.Join(dir, "changed.csv")); err != nil {
+		t.Errorf("a removed observer still refused: %v", err)
+	}
+}
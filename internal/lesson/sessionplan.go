@@ -0,0 +1,141 @@
+package lesson
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	// DefaultSecondsPerQuestion is how long answering a question is assumed
+	// to take when estimating the length of a session
+	DefaultSecondsPerQuestion = 10
+	// newItemErrorRate is the share of never asked items assumed to be
+	// answered wrongly the first time
+	newItemErrorRate = 0.5
+)
+
+// LessonTypeAllOnce is the lesson type that asks every question once, even
+// the ones answered wrongly. The other lesson types ask wrong answers again.
+const LessonTypeAllOnce = "allOnce"
+
+// LessonTypeSmart is the lesson type that asks the weakest cards first and
+// asks a card answered wrongly again soon after and at the end
+const LessonTypeSmart = "smart"
+
+// SessionPlan describes what a practice session will contain before it is
+// started: the new and the review items it asks and how long that is
+// expected to take. The counts can be lowered to make the session shorter.
+type SessionPlan struct {
+	// New lists the items that were never asked, in lesson order
+	New []int
+	// Reviews lists the items that were asked before, the ones answered
+	// wrongly most often first
+	Reviews []int
+	// NewCount and ReviewCount are how many of New and Reviews are asked
+	NewCount    int
+	ReviewCount int
+	// LessonType decides whether wrong answers are asked again
+	LessonType string
+	// SecondsPerQuestion is the time a question is expected to take
+	SecondsPerQuestion float64
+
+	list *WordList
+}
+
+// PlanSession plans a session over the items at indexes of list, as chosen
+// by the active list modifiers, for the given lesson type
+func PlanSession(list *WordList, indexes []int, lessonType string) *SessionPlan {
+	plan := &SessionPlan{
+		LessonType:         lessonType,
+		SecondsPerQuestion: DefaultSecondsPerQuestion,
+		list:               list,
+	}
+	for _, i := range indexes {
+		if list.GetRightAnswersCount(list.Items[i].ID)+list.GetWrongAnswersCount(list.Items[i].ID) == 0 {
+			plan.New = append(plan.New, i)
+		} else {
+			plan.Reviews = append(plan.Reviews, i)
+		}
+	}
+	sort.SliceStable(plan.Reviews, func(a, b int) bool {
+		return plan.errorRate(plan.Reviews[a]) > plan.errorRate(plan.Reviews[b])
+	})
+	plan.NewCount = len(plan.New)
+	plan.ReviewCount = len(plan.Reviews)
+	return plan
+}
+
+// SetCounts changes how many new and review items are asked, keeping both
+// between zero and the number available
+func (p *SessionPlan) SetCounts(newCount, reviewCount int) {
+	p.NewCount = min(max(newCount, 0), len(p.New))
+	p.ReviewCount = min(max(reviewCount, 0), len(p.Reviews))
+}
+
+// Items returns the indexes of the items the session asks, in lesson order
+func (p *SessionPlan) Items() []int {
+	items := make([]int, 0, p.NewCount+p.ReviewCount)
+	items = append(items, p.New[:p.NewCount]...)
+	items = append(items, p.Reviews[:p.ReviewCount]...)
+	sort.Ints(items)
+	return items
+}
+
+// Questions returns the number of questions the session asks at least. A
+// cloze item asks one question per cloze number.
+func (p *SessionPlan) Questions() int {
+	questions := 0
+	for _, i := range p.Items() {
+		questions += p.questionsFor(i)
+	}
+	return questions
+}
+
+// Duration estimates how long the session takes, counting the questions
+// the lesson type is expected to ask again after a wrong answer
+func (p *SessionPlan) Duration() time.Duration {
+	asked := 0.0
+	for _, i := range p.Items() {
+		repeats := 1.0
+		if p.LessonType != LessonTypeAllOnce {
+			repeats += p.errorRate(i)
+		}
+		asked += repeats * float64(p.questionsFor(i))
+	}
+	return time.Duration(asked * p.SecondsPerQuestion * float64(time.Second)).Round(time.Second)
+}
+
+// String summarizes the plan, e.g. "5 new, 12 reviews, about 3 minutes"
+func (p *SessionPlan) String() string {
+	minutes := int((p.Duration() + 30*time.Second) / time.Minute)
+	length := "less than a minute"
+	if minutes == 1 {
+		length = "about 1 minute"
+	} else if minutes > 1 {
+		length = fmt.Sprintf("about %d minutes", minutes)
+	}
+	reviews := "reviews"
+	if p.ReviewCount == 1 {
+		reviews = "review"
+	}
+	return fmt.Sprintf("%d new, %d %s, %s", p.NewCount, p.ReviewCount, reviews, length)
+}
+
+func (p *SessionPlan) questionsFor(index int) int {
+	item := &p.list.Items[index]
+	if item.IsCloze() {
+		return max(1, len(ClozeCards(item.Cloze)))
+	}
+	return 1
+}
+
+// errorRate returns the share of wrong answers given for the item
+func (p *SessionPlan) errorRate(index int) float64 {
+	id := p.list.Items[index].ID
+	right, wrong := p.list.GetRightAnswersCount(id), p.list.GetWrongAnswersCount(id)
+	if right+wrong == 0 {
+		return newItemErrorRate
+	}
+	return float64(wrong) / float64(right+wrong)
+}
@@ -0,0 +1,63 @@
+package lesson
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func sessionList() *WordList {
+	list := NewWordList()
+	for _, word := range []string{"een", "twee", "drie", "vier", "vijf"} {
+		list.AddWordItem([]string{word}, []string{word}, "")
+	}
+	list.AddTestResult(1, "right")
+	list.AddTestResult(2, "wrong")
+	list.AddTestResult(3, "right")
+	list.AddTestResult(3, "wrong")
+	return list
+}
+
+func TestPlanSession(t *testing.T) {
+	list := sessionList()
+	plan := PlanSession(list, AllItems(list), LessonTypeAllOnce)
+
+	if !slices.Equal(plan.New, []int{0, 4}) {
+		t.Errorf("New = %v; want [0 4]", plan.New)
+	}
+	if !slices.Equal(plan.Reviews, []int{2, 3, 1}) {
+		t.Errorf("Reviews = %v; want the most wrongly answered first: [2 3 1]", plan.Reviews)
+	}
+	if plan.Questions() != 5 {
+		t.Errorf("Questions() = %d; want 5", plan.Questions())
+	}
+	if want := 5 * DefaultSecondsPerQuestion * time.Second; plan.Duration() != want {
+		t.Errorf("Duration() = %v; want %v", plan.Duration(), want)
+	}
+	if got, want := plan.String(), "2 new, 3 reviews, about 1 minute"; got != want {
+		t.Errorf("String() = %q; want %q", got, want)
+	}
+
+	plan.SetCounts(1, 2)
+	if got := plan.Items(); !slices.Equal(got, []int{0, 2, 3}) {
+		t.Errorf("Items() = %v; want [0 2 3]", got)
+	}
+	plan.SetCounts(-1, 10)
+	if plan.NewCount != 0 || plan.ReviewCount != 3 {
+		t.Errorf("SetCounts(-1, 10) gave %d new and %d reviews; want 0 and 3", plan.NewCount, plan.ReviewCount)
+	}
+}
+
+func TestPlanSessionRepeatsWrongAnswers(t *testing.T) {
+	list := sessionList()
+	list.Items = append(list.Items, WordItem{ID: 5, Cloze: "{{c1::Amsterdam}} is de hoofdstad van {{c2::Nederland}}"})
+
+	allOnce := PlanSession(list, AllItems(list), LessonTypeAllOnce)
+	if allOnce.Questions() != 7 {
+		t.Errorf("Questions() = %d; want 7 with a cloze item of two cards", allOnce.Questions())
+	}
+	repeating := PlanSession(list, AllItems(list), "interval")
+	if repeating.Duration() <= allOnce.Duration() {
+		t.Errorf("Duration() = %v; want more than %v when wrong answers are asked again", repeating.Duration(), allOnce.Duration())
+	}
+}
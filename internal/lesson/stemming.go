@@ -0,0 +1,229 @@
+package lesson
+
+import (
+	"strings"
+	"sync"
+)
+
+// Stemmer reduces a word to a form shared by its inflections, so "ging" and
+// "gaan" or "walked" and "walking" compare equal. The result only has to be
+// consistent, not a real word.
+type Stemmer interface {
+	Stem(word string) string
+}
+
+// StemmerFunc adapts a function to the Stemmer interface
+type StemmerFunc func(word string) string
+
+// Stem calls f(word)
+func (f StemmerFunc) Stem(word string) string {
+	return f(word)
+}
+
+var (
+	stemmers     = make(map[string]Stemmer)
+	stemmerMutex sync.RWMutex
+)
+
+// languageAliases maps language names as used in lessons to the codes
+// stemmers are registered under
+var languageAliases = map[string]string{
+	"dutch":      "nl",
+	"nederlands": "nl",
+	"english":    "en",
+	"engels":     "en",
+	"german":     "de",
+	"deutsch":    "de",
+	"duits":      "de",
+	"french":     "fr",
+	"français":   "fr",
+	"frans":      "fr",
+	"spanish":    "es",
+	"español":    "es",
+	"spaans":     "es",
+	"italian":    "it",
+	"italiano":   "it",
+	"italiaans":  "it",
+	"portuguese": "pt",
+	"português":  "pt",
+	"portugees":  "pt",
+}
+
+func init() {
+	RegisterStemmer("nl", StemmerFunc(stemDutch))
+	RegisterStemmer("en", StemmerFunc(stemEnglish))
+	RegisterStemmer("de", StemmerFunc(stemGerman))
+}
+
+// RegisterStemmer makes a stemmer available for a language code, replacing
+// the built-in one if there is any. Snowball or dictionary based lemmatizers
+// can be plugged in this way.
+func RegisterStemmer(language string, stemmer Stemmer) {
+	stemmerMutex.Lock()
+	defer stemmerMutex.Unlock()
+	stemmers[normalizeLanguage(language)] = stemmer
+}
+
+// StemmerFor returns the stemmer for a language given as code ("nl",
+// "en_US") or English or native name ("Dutch", "Deutsch")
+func StemmerFor(language string) (Stemmer, bool) {
+	stemmerMutex.RLock()
+	defer stemmerMutex.RUnlock()
+	stemmer, ok := stemmers[normalizeLanguage(language)]
+	return stemmer, ok
+}
+
+func normalizeLanguage(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if code, ok := languageAliases[language]; ok {
+		return code
+	}
+	if i := strings.IndexAny(language, "_-"); i > 0 {
+		language = language[:i]
+	}
+	return language
+}
+
+// stripSuffix removes the first of suffixes word ends with, as long as at
+// least minStem letters remain
+func stripSuffix(word string, minStem int, suffixes ...string) string {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(word, suffix) && len([]rune(word))-len([]rune(suffix)) >= minStem {
+			return strings.TrimSuffix(word, suffix)
+		}
+	}
+	return word
+}
+
+// undouble collapses a doubled final consonant ("stopp" to "stop") and a
+// doubled vowel before the final consonant ("maak" to "mak")
+func undouble(word string) string {
+	runes := []rune(word)
+	n := len(runes)
+	if n >= 3 && runes[n-1] == runes[n-2] && !isVowel(runes[n-1]) {
+		return string(runes[:n-1])
+	}
+	if n >= 3 && !isVowel(runes[n-1]) && runes[n-2] == runes[n-3] && isVowel(runes[n-2]) {
+		return string(append(runes[:n-2], runes[n-1]))
+	}
+	return word
+}
+
+func isVowel(r rune) bool {
+	return strings.ContainsRune("aeiouy", r)
+}
+
+// dutchIrregular maps forms of common strong and irregular Dutch verbs to
+// their infinitive
+var dutchIrregular = map[string]string{
+	"ga": "gaan", "gaat": "gaan", "ging": "gaan", "gingen": "gaan", "gegaan": "gaan",
+	"ben": "zijn", "bent": "zijn", "is": "zijn", "was": "zijn", "waren": "zijn", "geweest": "zijn",
+	"heb": "hebben", "hebt": "hebben", "heeft": "hebben", "had": "hebben", "hadden": "hebben", "gehad": "hebben",
+	"kwam": "komen", "kwamen": "komen", "gekomen": "komen",
+	"zag": "zien", "zagen": "zien", "gezien": "zien",
+	"deed": "doen", "deden": "doen", "gedaan": "doen",
+	"eet": "eten", "at": "eten", "aten": "eten", "gegeten": "eten",
+	"liep": "lopen", "liepen": "lopen", "gelopen": "lopen",
+	"schreef": "schrijven", "schreven": "schrijven", "geschreven": "schrijven",
+	"sprak": "spreken", "spraken": "spreken", "gesproken": "spreken",
+	"kan": "kunnen", "kon": "kunnen", "konden": "kunnen", "gekund": "kunnen",
+	"wil": "willen", "wilde": "willen", "wilden": "willen", "gewild": "willen",
+	"zei": "zeggen", "zeiden": "zeggen", "gezegd": "zeggen",
+	"nam": "nemen", "namen": "nemen", "genomen": "nemen",
+	"gaf": "geven", "gaven": "geven", "gegeven": "geven",
+}
+
+// stemDutch maps irregular verb forms to the infinitive and strips the
+// endings of regular ones: werk, werkt, werkte, werkten, gewerkt and werken
+// all become "werk"
+func stemDutch(word string) string {
+	word = strings.ToLower(word)
+	if infinitive, ok := dutchIrregular[word]; ok {
+		word = infinitive
+	}
+	if strings.HasPrefix(word, "ge") && len(word) > 5 && strings.ContainsAny(word[len(word)-1:], "dt") {
+		word = word[2 : len(word)-1]
+	} else {
+		word = stripSuffix(word, 2, "ten", "den", "te", "de", "en", "t")
+	}
+	word = undouble(word)
+	// leven/leef and reizen/reis differ in spelling only
+	if strings.HasSuffix(word, "v") {
+		word = strings.TrimSuffix(word, "v") + "f"
+	} else if strings.HasSuffix(word, "z") {
+		word = strings.TrimSuffix(word, "z") + "s"
+	}
+	return word
+}
+
+// englishIrregular maps forms of common irregular English verbs to their
+// infinitive
+var englishIrregular = map[string]string{
+	"goes": "go", "went": "go", "gone": "go",
+	"am": "be", "is": "be", "are": "be", "was": "be", "were": "be", "been": "be",
+	"has": "have", "had": "have",
+	"does": "do", "did": "do", "done": "do",
+	"saw": "see", "seen": "see",
+	"came": "come",
+	"took": "take", "taken": "take",
+	"ate": "eat", "eaten": "eat",
+	"wrote": "write", "written": "write",
+	"spoke": "speak", "spoken": "speak",
+	"gave": "give", "given": "give",
+	"made": "make",
+	"said": "say",
+	"ran":  "run",
+	"knew": "know", "known": "know",
+	"thought": "think",
+	"bought":  "buy",
+	"brought": "bring",
+}
+
+// stemEnglish maps irregular verb forms to the infinitive and strips -ing,
+// -ed and -s: walk, walks, walked and walking all become "walk"
+func stemEnglish(word string) string {
+	word = strings.ToLower(word)
+	if infinitive, ok := englishIrregular[word]; ok {
+		word = infinitive
+	}
+	if !strings.HasSuffix(word, "ss") {
+		word = stripSuffix(word, 2, "ing", "ed", "es", "s")
+	}
+	word = undouble(word)
+	word = stripSuffix(word, 2, "e")
+	if strings.HasSuffix(word, "y") && len(word) > 2 {
+		word = strings.TrimSuffix(word, "y") + "i"
+	}
+	return word
+}
+
+// germanIrregular maps forms of common strong and irregular German verbs to
+// their infinitive
+var germanIrregular = map[string]string{
+	"geht": "gehen", "ging": "gehen", "gingen": "gehen", "gegangen": "gehen",
+	"bin": "sein", "bist": "sein", "ist": "sein", "sind": "sein", "seid": "sein", "war": "sein", "waren": "sein", "gewesen": "sein",
+	"hast": "haben", "hat": "haben", "hatte": "haben", "hatten": "haben", "gehabt": "haben",
+	"kam": "kommen", "kamen": "kommen", "gekommen": "kommen",
+	"sieht": "sehen", "sah": "sehen", "sahen": "sehen", "gesehen": "sehen",
+	"isst": "essen", "aß": "essen", "aßen": "essen", "gegessen": "essen",
+	"spricht": "sprechen", "sprach": "sprechen", "gesprochen": "sprechen",
+	"schrieb": "schreiben", "geschrieben": "schreiben",
+	"gibt": "geben", "gab": "geben", "gegeben": "geben",
+	"nimmt": "nehmen", "nahm": "nehmen", "genommen": "nehmen",
+	"fährt": "fahren", "fuhr": "fahren", "gefahren": "fahren",
+	"liest": "lesen", "las": "lesen", "gelesen": "lesen",
+}
+
+// stemGerman maps irregular verb forms to the infinitive and strips the
+// endings of regular ones: machen, macht, machst, machte and gemacht all
+// become "mach"
+func stemGerman(word string) string {
+	word = strings.ToLower(word)
+	if infinitive, ok := germanIrregular[word]; ok {
+		word = infinitive
+	}
+	if strings.HasPrefix(word, "ge") && strings.HasSuffix(word, "t") && len(word) > 5 {
+		return word[2 : len(word)-1]
+	}
+	return stripSuffix(word, 3, "test", "tet", "ten", "te", "est", "en", "st", "et", "e", "t")
+}
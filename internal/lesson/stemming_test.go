@@ -0,0 +1,59 @@
+package lesson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuiltinStemmers(t *testing.T) {
+	tests := []struct {
+		language string
+		forms    []string
+	}{
+		{"nl", []string{"gaan", "ging", "gingen", "gegaan", "gaat"}},
+		{"nl", []string{"werken", "werk", "werkt", "werkte", "werkten", "gewerkt"}},
+		{"nl", []string{"spelen", "speel", "speelde", "gespeeld"}},
+		{"nl", []string{"leven", "leef", "leefde"}},
+		{"en", []string{"go", "went", "gone", "going", "goes"}},
+		{"en", []string{"walk", "walks", "walked", "walking"}},
+		{"en", []string{"make", "makes", "made", "making"}},
+		{"en", []string{"stop", "stopped", "stopping"}},
+		{"en", []string{"try", "tries", "tried", "trying"}},
+		{"de", []string{"machen", "mache", "machst", "macht", "machte", "gemacht"}},
+		{"de", []string{"gehen", "ging", "gegangen", "geht"}},
+	}
+	for _, tt := range tests {
+		stemmer, ok := StemmerFor(tt.language)
+		if !ok {
+			t.Fatalf("No stemmer for %s", tt.language)
+		}
+		want := stemmer.Stem(tt.forms[0])
+		for _, form := range tt.forms[1:] {
+			if got := stemmer.Stem(form); got != want {
+				t.Errorf("%s: Stem(%q) = %q; want %q like %q", tt.language, form, got, want, tt.forms[0])
+			}
+		}
+	}
+
+	dutch, _ := StemmerFor("nl")
+	if dutch.Stem("lopen") == dutch.Stem("kopen") {
+		t.Error("Different verbs should not share a stem")
+	}
+}
+
+func TestStemmerFor(t *testing.T) {
+	for _, language := range []string{"Dutch", "nederlands", "nl_NL", "NL"} {
+		if _, ok := StemmerFor(language); !ok {
+			t.Errorf("StemmerFor(%q) found nothing", language)
+		}
+	}
+	if _, ok := StemmerFor("Klingon"); ok {
+		t.Error("Expected no stemmer for an unknown language")
+	}
+
+	RegisterStemmer("tlh", StemmerFunc(strings.ToUpper))
+	stemmer, ok := StemmerFor("tlh")
+	if !ok || stemmer.Stem("qapla") != "QAPLA" {
+		t.Error("A registered stemmer should be returned")
+	}
+}
@@ -0,0 +1,486 @@
+package lesson
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A storage is somewhere lessons are kept other than a path the file
+// dialogs can reach: a folder on this machine, or a WebDAV share such as
+// the files of a Nextcloud account. Lessons in a storage are downloaded to
+// a file to be opened, and the file is uploaded again when saved. Every
+// file has a version, so saving over a file somebody else changed since it
+// was downloaded fails with ErrConflict instead of losing their changes.
+
+// ErrConflict is returned when saving over a file that changed in the
+// storage since the version the save was based on
+var ErrConflict = errors.New("the file was changed by somebody else since it was opened")
+
+// AnyVersion passed to Storage.Save overwrites the file whatever its
+// version
+const AnyVersion = "*"
+
+// Settings for the WebDAV share the file dialogs browse
+const (
+	WebDAVURLSetting      = "storage.webdav.url"
+	WebDAVUserSetting     = "storage.webdav.user"
+	WebDAVPasswordSetting = "storage.webdav.password"
+)
+
+// StorageEntry is a file or folder in a storage
+type StorageEntry struct {
+	Name string
+	// Path is slash separated and relative to the root of the storage
+	Path     string
+	Dir      bool
+	Size     int64
+	Modified time.Time
+	Version  string
+}
+
+// Storage is a place lessons are kept in
+type Storage interface {
+	// List returns the files and folders in the folder dir, "" for the
+	// root, folders first and each sorted by name
+	List(ctx context.Context, dir string) ([]StorageEntry, error)
+	// Open returns the content of the file name with its version
+	Open(ctx context.Context, name string) (io.ReadCloser, string, error)
+	// Save writes the file name and returns its new version. It fails with
+	// ErrConflict unless the file is still at version, where "" means the
+	// file must not exist yet and AnyVersion accepts any.
+	Save(ctx context.Context, name string, content io.Reader, version string) (string, error)
+	// Location describes the storage to the user
+	Location() string
+}
+
+// DownloadFromStorage copies the file name of storage to localPath and
+// returns its version, to be given back when uploading it
+func DownloadFromStorage(ctx context.Context, storage Storage, name, localPath string) (string, error) {
+	content, version, err := storage.Open(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	defer content.Close()
+	data, err := io.ReadAll(io.LimitReader(content, 256<<20))
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return "", err
+	}
+	if err := WriteFileAtomically(localPath, data, 0644); err != nil {
+		return "", err
+	}
+	return version, nil
+}
+
+// UploadToStorage copies localPath to the file name of storage, as long as
+// that is still at version, and returns its new version
+func UploadToStorage(ctx context.Context, storage Storage, localPath, name, version string) (string, error) {
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		return "", err
+	}
+	return storage.Save(ctx, name, bytes.NewReader(data), version)
+}
+
+// sortEntries puts folders first, and each by name
+func sortEntries(entries []StorageEntry) {
+	slices.SortFunc(entries, func(a, b StorageEntry) int {
+		if a.Dir != b.Dir {
+			if a.Dir {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+}
+
+// cleanStoragePath checks a path in a storage, which may not leave its
+// root, and returns it without leading or trailing slashes
+func cleanStoragePath(name string) (string, error) {
+	name = strings.Trim(name, "/")
+	if name == "" {
+		return "", nil
+	}
+	if !fs.ValidPath(name) {
+		return "", fmt.Errorf("invalid path %q", name)
+	}
+	return name, nil
+}
+
+// LocalStorage keeps lessons in a folder on this machine. Versions are
+// made from the modification time and size of the files.
+type LocalStorage struct {
+	Root string
+}
+
+// NewLocalStorage returns a storage of the folder root
+func NewLocalStorage(root string) *LocalStorage {
+	return &LocalStorage{Root: root}
+}
+
+// Location returns the folder
+func (s *LocalStorage) Location() string {
+	return s.Root
+}
+
+// List returns the files and folders in dir
+func (s *LocalStorage) List(ctx context.Context, dir string) ([]StorageEntry, error) {
+	dir, err := cleanStoragePath(dir)
+	if err != nil {
+		return nil, err
+	}
+	files, err := os.ReadDir(filepath.Join(s.Root, filepath.FromSlash(dir)))
+	if err != nil {
+		return nil, err
+	}
+	var entries []StorageEntry
+	for _, file := range files {
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, StorageEntry{
+			Name:     file.Name(),
+			Path:     path.Join(dir, file.Name()),
+			Dir:      file.IsDir(),
+			Size:     info.Size(),
+			Modified: info.ModTime(),
+			Version:  localVersion(info),
+		})
+	}
+	sortEntries(entries)
+	return entries, nil
+}
+
+// Open opens the file name
+func (s *LocalStorage) Open(ctx context.Context, name string) (io.ReadCloser, string, error) {
+	filePath, err := s.path(name)
+	if err != nil {
+		return nil, "", err
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, "", err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, "", err
+	}
+	return file, localVersion(info), nil
+}
+
+// Save writes the file name when it is still at version
+func (s *LocalStorage) Save(ctx context.Context, name string, content io.Reader, version string) (string, error) {
+	filePath, err := s.path(name)
+	if err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return "", err
+	}
+	current := ""
+	if info, err := os.Stat(filePath); err == nil {
+		current = localVersion(info)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	if version != AnyVersion && version != current {
+		return "", ErrConflict
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return "", err
+	}
+	if err := WriteFileAtomically(filePath, data, 0644); err != nil {
+		return "", err
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return "", err
+	}
+	return localVersion(info), nil
+}
+
+// path returns where the file name is on disk
+func (s *LocalStorage) path(name string) (string, error) {
+	name, err := cleanStoragePath(name)
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		return "", errors.New("no file name given")
+	}
+	return filepath.Join(s.Root, filepath.FromSlash(name)), nil
+}
+
+// localVersion returns the version of a file on disk
+func localVersion(info fs.FileInfo) string {
+	return strconv.FormatInt(info.ModTime().UnixNano(), 36) + "-" + strconv.FormatInt(info.Size(), 36)
+}
+
+// WebDAVStorage keeps lessons on a WebDAV share, such as the files of a
+// Nextcloud account at https://cloud.example/remote.php/dav/files/USER/.
+// Versions are the ETags of the server, which it checks itself when
+// saving, so two people saving at once cannot overwrite each other.
+type WebDAVStorage struct {
+	// URL is that of the folder that is the root of the storage
+	URL      string
+	User     string
+	Password string
+	Client   *http.Client
+}
+
+// NewWebDAVStorage returns a storage of the WebDAV folder at rawURL,
+// logging in as user with password, which for Nextcloud is best an app
+// password
+func NewWebDAVStorage(rawURL, user, password string) (*WebDAVStorage, error) {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" || parsed.Host == "" {
+		return nil, fmt.Errorf("%q is not the address of a WebDAV share", rawURL)
+	}
+	if !strings.HasSuffix(parsed.Path, "/") {
+		parsed.Path += "/"
+	}
+	parsed.RawPath = ""
+	return &WebDAVStorage{URL: parsed.String(), User: user, Password: password}, nil
+}
+
+// Location returns the address of the share
+func (s *WebDAVStorage) Location() string {
+	return s.URL
+}
+
+// webDAVPropfind asks for what List shows of every entry
+const webDAVPropfind = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:"><d:prop><d:resourcetype/><d:getcontentlength/><d:getlastmodified/><d:getetag/></d:prop></d:propfind>`
+
+// webDAVMultistatus is the answer to a PROPFIND
+type webDAVMultistatus struct {
+	Responses []struct {
+		Href      string `xml:"href"`
+		Propstats []struct {
+			Status string `xml:"status"`
+			Prop   struct {
+				ResourceType struct {
+					Collection *struct{} `xml:"collection"`
+				} `xml:"resourcetype"`
+				ContentLength string `xml:"getcontentlength"`
+				LastModified  string `xml:"getlastmodified"`
+				ETag          string `xml:"getetag"`
+			} `xml:"prop"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+// List returns the files and folders in dir
+func (s *WebDAVStorage) List(ctx context.Context, dir string) ([]StorageEntry, error) {
+	dir, err := cleanStoragePath(dir)
+	if err != nil {
+		return nil, err
+	}
+	location := s.location(dir)
+	if dir != "" {
+		location += "/"
+	}
+	request, err := s.request(ctx, "PROPFIND", location, strings.NewReader(webDAVPropfind))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Depth", "1")
+	request.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	response, err := s.client().Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusMultiStatus {
+		return nil, s.responseError(dir, response)
+	}
+	var status webDAVMultistatus
+	if err := xml.NewDecoder(io.LimitReader(response.Body, 16<<20)).Decode(&status); err != nil {
+		return nil, fmt.Errorf("invalid answer from WebDAV server: %w", err)
+	}
+
+	base, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+	var entries []StorageEntry
+	for _, entry := range status.Responses {
+		href, err := url.Parse(entry.Href)
+		if err != nil {
+			continue
+		}
+		// Hrefs are paths on the server, or full URLs on some servers
+		name := strings.Trim(strings.TrimPrefix(base.ResolveReference(href).Path, base.Path), "/")
+		if name == "" || strings.Contains(name, "/") {
+			continue // the folder itself
+		}
+		item := StorageEntry{Name: name, Path: path.Join(dir, name)}
+		for _, propstat := range entry.Propstats {
+			if !strings.Contains(propstat.Status, " 200 ") {
+				continue
+			}
+			prop := propstat.Prop
+			item.Dir = item.Dir || prop.ResourceType.Collection != nil
+			if size, err := strconv.ParseInt(prop.ContentLength, 10, 64); err == nil {
+				item.Size = size
+			}
+			if modified, err := http.ParseTime(prop.LastModified); err == nil {
+				item.Modified = modified
+			}
+			if prop.ETag != "" {
+				item.Version = prop.ETag
+			}
+		}
+		entries = append(entries, item)
+	}
+	sortEntries(entries)
+	return entries, nil
+}
+
+// Open downloads the file name
+func (s *WebDAVStorage) Open(ctx context.Context, name string) (io.ReadCloser, string, error) {
+	name, err := cleanStoragePath(name)
+	if err != nil {
+		return nil, "", err
+	}
+	request, err := s.request(ctx, http.MethodGet, s.location(name), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	response, err := s.client().Do(request)
+	if err != nil {
+		return nil, "", err
+	}
+	if response.StatusCode != http.StatusOK {
+		defer response.Body.Close()
+		return nil, "", s.responseError(name, response)
+	}
+	return response.Body, response.Header.Get("ETag"), nil
+}
+
+// Save uploads the file name, letting the server refuse it unless the file
+// is still at version
+func (s *WebDAVStorage) Save(ctx context.Context, name string, content io.Reader, version string) (string, error) {
+	name, err := cleanStoragePath(name)
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		return "", errors.New("no file name given")
+	}
+	request, err := s.request(ctx, http.MethodPut, s.location(name), content)
+	if err != nil {
+		return "", err
+	}
+	switch version {
+	case AnyVersion:
+	case "":
+		request.Header.Set("If-None-Match", "*")
+	default:
+		request.Header.Set("If-Match", version)
+	}
+	response, err := s.client().Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	switch response.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+	case http.StatusPreconditionFailed:
+		return "", ErrConflict
+	default:
+		return "", s.responseError(name, response)
+	}
+	if etag := response.Header.Get("ETag"); etag != "" {
+		return etag, nil
+	}
+	// Not every server sends the new ETag with the upload
+	return s.version(ctx, name)
+}
+
+// version asks the server for the ETag of the file name
+func (s *WebDAVStorage) version(ctx context.Context, name string) (string, error) {
+	request, err := s.request(ctx, http.MethodHead, s.location(name), nil)
+	if err != nil {
+		return "", err
+	}
+	response, err := s.client().Do(request)
+	if err != nil {
+		return "", err
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", s.responseError(name, response)
+	}
+	return response.Header.Get("ETag"), nil
+}
+
+// location returns the URL of name, escaping every part of it
+func (s *WebDAVStorage) location(name string) string {
+	location := strings.TrimSuffix(s.URL, "/")
+	for _, part := range strings.Split(name, "/") {
+		if part != "" {
+			location += "/" + url.PathEscape(part)
+		}
+	}
+	if name == "" {
+		location += "/"
+	}
+	return location
+}
+
+// request makes a request to the server, logged in
+func (s *WebDAVStorage) request(ctx context.Context, method, location string, body io.Reader) (*http.Request, error) {
+	request, err := http.NewRequestWithContext(ctx, method, location, body)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("User-Agent", "Recuerdo")
+	if s.User != "" || s.Password != "" {
+		request.SetBasicAuth(s.User, s.Password)
+	}
+	return request, nil
+}
+
+func (s *WebDAVStorage) client() *http.Client {
+	if s.Client != nil {
+		return s.Client
+	}
+	return http.DefaultClient
+}
+
+// responseError turns a failed answer about name into an error
+func (s *WebDAVStorage) responseError(name string, response *http.Response) error {
+	switch response.StatusCode {
+	case http.StatusNotFound:
+		return fmt.Errorf("%s on %s: %w", name, s.URL, fs.ErrNotExist)
+	case http.StatusUnauthorized:
+		return fmt.Errorf("%s refused the user name or password", s.URL)
+	case http.StatusForbidden:
+		return fmt.Errorf("%s on %s: %w", name, s.URL, fs.ErrPermission)
+	}
+	return fmt.Errorf("WebDAV request for %s failed: %s", name, response.Status)
+}
@@ -0,0 +1,125 @@
+package lesson
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/webdav"
+)
+
+func TestWebDAVStorage(t *testing.T) {
+	files := webdav.NewMemFS()
+	dav := &webdav.Handler{Prefix: "/dav/files/alice", FileSystem: files, LockSystem: webdav.NewMemLS()}
+	// The webdav package leaves If-Match to the server, as Nextcloud checks it
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "alice" || password != "app-password" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodPut {
+			etag := ""
+			if info, err := files.Stat(r.Context(), strings.TrimPrefix(r.URL.Path, dav.Prefix)); err == nil {
+				etag = fmt.Sprintf(`"%x%x"`, info.ModTime().UnixNano(), info.Size())
+			}
+			if match := r.Header.Get("If-Match"); match != "" && match != etag ||
+				r.Header.Get("If-None-Match") == "*" && etag != "" {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+		}
+		dav.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	ctx := context.Background()
+	if err := files.Mkdir(ctx, "/Lessons", 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	storage, err := NewWebDAVStorage(server.URL+"/dav/files/alice", "alice", "app-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	version, err := storage.Save(ctx, "Lessons/French words.otwd", strings.NewReader("bonjour"), "")
+	if err != nil || version == "" {
+		t.Fatalf("Expected a new file to be saved with a version, got %q, %v", version, err)
+	}
+	if _, err := storage.Save(ctx, "Lessons/French words.otwd", strings.NewReader("salut"), ""); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected creating an existing file to conflict, got %v", err)
+	}
+
+	entries, err := storage.List(ctx, "")
+	if err != nil || len(entries) != 1 || !entries[0].Dir || entries[0].Path != "Lessons" {
+		t.Fatalf("Expected the root to hold the Lessons folder, got %+v, %v", entries, err)
+	}
+	entries, err = storage.List(ctx, "Lessons")
+	if err != nil || len(entries) != 1 || entries[0].Name != "French words.otwd" || entries[0].Size != 7 || entries[0].Version != version {
+		t.Fatalf("Expected the folder to hold the lesson, got %+v, %v", entries, err)
+	}
+
+	localPath := filepath.Join(t.TempDir(), "French words.otwd")
+	downloaded, err := DownloadFromStorage(ctx, storage, "Lessons/French words.otwd", localPath)
+	if err != nil || downloaded != version {
+		t.Fatalf("Expected the download to have the saved version, got %q, %v", downloaded, err)
+	}
+	if data, _ := os.ReadFile(localPath); string(data) != "bonjour" {
+		t.Errorf("Expected the downloaded content, got %q", data)
+	}
+
+	// Somebody else saves in between, so the version downloaded is stale
+	if _, err := storage.Save(ctx, "Lessons/French words.otwd", strings.NewReader("bonsoir!"), version); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UploadToStorage(ctx, storage, localPath, "Lessons/French words.otwd", downloaded); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected saving over a changed file to conflict, got %v", err)
+	}
+	if _, err := UploadToStorage(ctx, storage, localPath, "Lessons/French words.otwd", AnyVersion); err != nil {
+		t.Errorf("Expected overwriting to pass, got %v", err)
+	}
+
+	if _, _, err := storage.Open(ctx, "Lessons/missing.otwd"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a missing file to not exist, got %v", err)
+	}
+	wrong, _ := NewWebDAVStorage(server.URL+"/dav/files/alice/", "alice", "guess")
+	if _, err := wrong.List(ctx, ""); err == nil || !strings.Contains(err.Error(), "password") {
+		t.Errorf("Expected a wrong password to be reported, got %v", err)
+	}
+	if _, err := NewWebDAVStorage("cloud.example", "", ""); err == nil {
+		t.Error("Expected an address without scheme to be refused")
+	}
+}
+
+func TestLocalStorage(t *testing.T) {
+	ctx := context.Background()
+	storage := NewLocalStorage(t.TempDir())
+	version, err := storage.Save(ctx, "words/animals.csv", strings.NewReader("chat,cat\n"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, opened, err := storage.Open(ctx, "words/animals.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(content)
+	content.Close()
+	if opened != version || string(data) != "chat,cat\n" {
+		t.Errorf("Expected to open the saved version, got %q %q", opened, data)
+	}
+	if _, err := storage.Save(ctx, "words/animals.csv", strings.NewReader("x"), "stale"); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected a stale version to conflict, got %v", err)
+	}
+	if _, err := storage.Save(ctx, "../outside.csv", strings.NewReader("x"), AnyVersion); err == nil {
+		t.Error("Expected a path leaving the storage to be refused")
+	}
+	entries, err := storage.List(ctx, "")
+	if err != nil || len(entries) != 1 || !entries[0].Dir || entries[0].Name != "words" {
+		t.Errorf("Expected one folder, got %+v, %v", entries, err)
+	}
+}
@@ -0,0 +1,206 @@
+package lesson
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// A study sheet is the classic fold-over sheet for self-testing: questions
+// in the left half of the page, each answer on the same line in the right
+// half. Folding the page along the dashed line down the middle puts every
+// answer on the back of its question, so a student reads the question,
+// answers it and turns the fold over to check.
+//
+// PDF sizes are points of an A4 page.
+const (
+	studySheetWidth  = 595.28
+	studySheetHeight = 841.89
+	studySheetMargin = 50.0
+	studySheetGutter = 18.0 // space between the text and the fold
+
+	studySheetTitleSize = 16.0
+	studySheetHeadSize  = 9.0
+	studySheetTextSize  = 11.0
+	studySheetHintSize  = 8.0
+	studySheetLeading   = 14.0
+	studySheetRowGap    = 8.0
+)
+
+// studySheetHint is printed at the bottom of every page
+const studySheetHint = "Fold along the dashed line to hide the answers behind their questions"
+
+// studySheetRow is one item on a study sheet
+type studySheetRow struct {
+	Question, Answer string
+}
+
+// studySheetRows returns the rows of a study sheet for lessonData, numbered
+// on both sides so they still match up when folded
+func studySheetRows(lessonData *LessonData) []studySheetRow {
+	rows := make([]studySheetRow, 0, len(lessonData.List.Items))
+	for i, item := range lessonData.List.Items {
+		rows = append(rows, studySheetRow{
+			Question: fmt.Sprintf("%d. %s", i+1, strings.Join(item.Questions, "; ")),
+			Answer:   fmt.Sprintf("%d. %s", i+1, strings.Join(item.Answers, "; ")),
+		})
+	}
+	return rows
+}
+
+// studySheetHeadings returns the column headings: the languages of the
+// lesson when it has them
+func studySheetHeadings(lessonData *LessonData) (string, string) {
+	return getColumnHeader(lessonData.List.QuestionLanguage, "Questions"),
+		getColumnHeader(lessonData.List.AnswerLanguage, "Answers")
+}
+
+// SaveStudySheet writes a fold-over study sheet of the lesson, as PDF or
+// ODT depending on the extension of filePath
+func (fs *FileSaver) SaveStudySheet(lessonData *LessonData, filePath string) error {
+	log.Printf("[ACTION] FileSaver.SaveStudySheet() - saving study sheet")
+
+	var content bytes.Buffer
+	var err error
+	switch ext := strings.ToLower(filepath.Ext(filePath)); ext {
+	case ".pdf":
+		err = WriteStudySheetPDF(&content, lessonData)
+	case ".odt":
+		err = WriteStudySheetODT(&content, lessonData)
+	default:
+		err = fmt.Errorf("study sheets are saved as .pdf or .odt, not %q", ext)
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to create study sheet: %v", err)
+		return err
+	}
+
+	err = writeAtomically(filePath, fs.Options.Backups, 0644, func(tempPath string) error {
+		return os.WriteFile(tempPath, content.Bytes(), 0644)
+	})
+	if err != nil {
+		log.Printf("[ERROR] Failed to write study sheet: %v", err)
+		return err
+	}
+
+	log.Printf("[SUCCESS] FileSaver.SaveStudySheet() - saved %d items", len(lessonData.List.Items))
+	return nil
+}
+
+// WriteStudySheetPDF writes a study sheet of the lesson to w as an A4 PDF.
+// Rows that do not fit on a page continue on the next, never split over
+// two pages.
+func WriteStudySheetPDF(w io.Writer, lessonData *LessonData) error {
+	doc, err := newPDFDocument(studySheetWidth, studySheetHeight)
+	if err != nil {
+		return err
+	}
+
+	fold := studySheetWidth / 2
+	columnWidth := fold - studySheetMargin - studySheetGutter
+	answerX := fold + studySheetGutter
+	bottom := studySheetMargin + 2*studySheetHintSize
+	questionHeading, answerHeading := studySheetHeadings(lessonData)
+
+	var y float64
+	startPage := func(first bool) {
+		doc.newPage()
+		doc.line(fold, studySheetMargin/2, fold, studySheetHeight-studySheetMargin/2, 0.5, true)
+		doc.text(studySheetMargin, studySheetMargin, studySheetHintSize, 0.5, studySheetHint)
+		y = studySheetHeight - studySheetMargin
+		if first && lessonData.List.Title != "" {
+			y -= studySheetTitleSize
+			for _, line := range doc.wrap(lessonData.List.Title, studySheetTitleSize, columnWidth) {
+				doc.text(studySheetMargin, y, studySheetTitleSize, 0, line)
+				y -= studySheetTitleSize * 1.3
+			}
+			y -= studySheetRowGap
+		}
+		y -= studySheetHeadSize
+		doc.text(studySheetMargin, y, studySheetHeadSize, 0.4, questionHeading)
+		doc.text(answerX, y, studySheetHeadSize, 0.4, answerHeading)
+		y -= studySheetHeadSize
+		doc.line(studySheetMargin, y, fold-studySheetGutter, y, 0.6, false)
+		doc.line(answerX, y, studySheetWidth-studySheetMargin, y, 0.6, false)
+		y -= studySheetRowGap
+	}
+	startPage(true)
+
+	for _, row := range studySheetRows(lessonData) {
+		questionLines := doc.wrap(row.Question, studySheetTextSize, columnWidth)
+		answerLines := doc.wrap(row.Answer, studySheetTextSize, columnWidth)
+		height := float64(max(len(questionLines), len(answerLines))) * studySheetLeading
+		if y-height < bottom {
+			startPage(false)
+		}
+		for i, line := range questionLines {
+			doc.text(studySheetMargin, y-studySheetTextSize-float64(i)*studySheetLeading, studySheetTextSize, 0, line)
+		}
+		for i, line := range answerLines {
+			doc.text(answerX, y-studySheetTextSize-float64(i)*studySheetLeading, studySheetTextSize, 0, line)
+		}
+		y -= height + studySheetRowGap
+	}
+
+	return doc.write(w)
+}
+
+// WriteStudySheetODT writes a study sheet of the lesson to w as an
+// OpenDocument text file: a two-column table centred on an A4 page, whose
+// left column has a dashed right border where the page is folded
+func WriteStudySheetODT(w io.Writer, lessonData *LessonData) error {
+	var content strings.Builder
+	content.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	content.WriteString(`<office:document-content ` + odfNamespaces + `>
+<office:automatic-styles>
+<style:style style:name="Sheet" style:family="table"><style:table-properties style:width="17cm" table:align="center"/></style:style>
+<style:style style:name="Sheet.Column" style:family="table-column"><style:table-column-properties style:column-width="8.5cm"/></style:style>
+<style:style style:name="Sheet.Row" style:family="table-row"><style:table-row-properties fo:keep-together="always"/></style:style>
+<style:style style:name="Sheet.Question" style:family="table-cell"><style:table-cell-properties fo:padding-top="0.1cm" fo:padding-bottom="0.1cm" fo:padding-left="0cm" fo:padding-right="0.6cm" fo:border="none" fo:border-right="0.5pt dashed #808080"/></style:style>
+<style:style style:name="Sheet.Answer" style:family="table-cell"><style:table-cell-properties fo:padding-top="0.1cm" fo:padding-bottom="0.1cm" fo:padding-left="0.6cm" fo:padding-right="0cm" fo:border="none"/></style:style>
+<style:style style:name="Title" style:family="paragraph"><style:paragraph-properties fo:margin-bottom="0.4cm"/><style:text-properties fo:font-size="16pt" fo:font-weight="bold"/></style:style>
+<style:style style:name="Heading" style:family="paragraph"><style:text-properties fo:font-size="9pt" fo:color="#666666"/></style:style>
+<style:style style:name="Hint" style:family="paragraph"><style:paragraph-properties fo:margin-top="0.4cm"/><style:text-properties fo:font-size="8pt" fo:color="#808080"/></style:style>
+</office:automatic-styles>
+<office:body>
+<office:text>
+`)
+	if lessonData.List.Title != "" {
+		fmt.Fprintf(&content, `<text:p text:style-name="Title">%s</text:p>`+"\n", odtText(lessonData.List.Title))
+	}
+	questionHeading, answerHeading := studySheetHeadings(lessonData)
+	content.WriteString(`<table:table table:name="StudySheet" table:style-name="Sheet">
+<table:table-column table:style-name="Sheet.Column" table:number-columns-repeated="2"/>
+<table:table-header-rows>
+`)
+	writeRow := func(paragraphStyle, question, answer string) {
+		fmt.Fprintf(&content, `<table:table-row table:style-name="Sheet.Row">`+
+			`<table:table-cell table:style-name="Sheet.Question" office:value-type="string"><text:p%s>%s</text:p></table:table-cell>`+
+			`<table:table-cell table:style-name="Sheet.Answer" office:value-type="string"><text:p%s>%s</text:p></table:table-cell>`+
+			"</table:table-row>\n", paragraphStyle, odtText(question), paragraphStyle, odtText(answer))
+	}
+	writeRow(` text:style-name="Heading"`, questionHeading, answerHeading)
+	content.WriteString("</table:table-header-rows>\n")
+	for _, row := range studySheetRows(lessonData) {
+		writeRow("", row.Question, row.Answer)
+	}
+	content.WriteString("</table:table>\n")
+	fmt.Fprintf(&content, `<text:p text:style-name="Hint">%s</text:p>`+"\n", odtText(studySheetHint))
+	content.WriteString("</office:text>\n</office:body>\n</office:document-content>\n")
+
+	styles := `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-styles ` + odfNamespaces + `>
+<office:automatic-styles>
+<style:page-layout style:name="A4"><style:page-layout-properties fo:page-width="21cm" fo:page-height="29.7cm" style:print-orientation="portrait" fo:margin-top="1.8cm" fo:margin-bottom="1.8cm" fo:margin-left="2cm" fo:margin-right="2cm"/></style:page-layout>
+</office:automatic-styles>
+<office:master-styles>
+<style:master-page style:name="Standard" style:page-layout-name="A4"/>
+</office:master-styles>
+</office:document-styles>
+`
+	return writeOpenDocument(w, "application/vnd.oasis.opendocument.text", content.String(), styles)
+}
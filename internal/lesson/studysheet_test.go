@@ -0,0 +1,120 @@
+package lesson
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestStudySheetPDF(t *testing.T) {
+	lessonData := NewLessonData()
+	lessonData.List.Title = "Vocabulaire"
+	lessonData.List.AddWordItem([]string{"l'été"}, []string{"summer"}, "")
+	lessonData.List.AddWordItem([]string{strings.Repeat("très long ", 30)}, []string{"very long"}, "")
+	for i := 0; i < 60; i++ {
+		lessonData.List.AddWordItem([]string{"question"}, []string{"answer"}, "")
+	}
+
+	path := filepath.Join(t.TempDir(), "sheet.pdf")
+	if err := NewFileSaver().SaveStudySheet(lessonData, path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatal("expected a PDF file")
+	}
+
+	// The cross-reference table must point at the objects
+	match := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(data)
+	if match == nil {
+		t.Fatal("no startxref")
+	}
+	xref, _ := strconv.Atoi(string(match[1]))
+	if !bytes.HasPrefix(data[xref:], []byte("xref\n")) {
+		t.Fatal("startxref does not point at the cross-reference table")
+	}
+	for _, entry := range regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(data[xref:], -1) {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		if !regexp.MustCompile(`^\d+ 0 obj\n`).Match(data[offset:]) {
+			t.Fatalf("offset %d is not an object", offset)
+		}
+	}
+
+	// 62 rows, one of them long, do not fit on one page
+	count := regexp.MustCompile(`/Count (\d+)`).FindSubmatch(data)
+	if count == nil || string(count[1]) == "1" {
+		t.Errorf("expected several pages, got %q", count)
+	}
+
+	// The first page has the fold line and the answers right of it
+	first := regexp.MustCompile(`/Contents (\d+) 0 R`).FindSubmatch(data)
+	object := regexp.MustCompile(`(?s)\n` + string(first[1]) + ` 0 obj\n<<[^>]*>>\nstream\n(.*?)\nendstream`).FindSubmatch(data)
+	if object == nil {
+		t.Fatal("no content stream of the first page")
+	}
+	reader, err := zlib.NewReader(bytes.NewReader(object[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, _ := io.ReadAll(reader)
+	if !bytes.Contains(page, []byte("[4 3] 0 d 0.5 w 0.5 G 297.64 25.00 m 297.64 816.89 l S")) {
+		t.Errorf("expected a dashed fold line down the middle:\n%s", page)
+	}
+	if !bytes.Contains(page, []byte("Td")) || !bytes.Contains(page, []byte(" 315.64 ")) {
+		t.Errorf("expected answers right of the fold:\n%s", page)
+	}
+}
+
+func TestStudySheetODT(t *testing.T) {
+	lessonData := NewLessonData()
+	lessonData.List.Title = "Tom & Jerry"
+	lessonData.List.QuestionLanguage = "French"
+	lessonData.List.AddWordItem([]string{"chat", "matou"}, []string{"cat"}, "")
+	lessonData.List.AddWordItem([]string{"<souris>"}, []string{"mouse"}, "")
+
+	path := filepath.Join(t.TempDir(), "sheet.odt")
+	if err := NewFileSaver().SaveStudySheet(lessonData, path); err != nil {
+		t.Fatal(err)
+	}
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+	if archive.File[0].Name != "mimetype" || archive.File[0].Method != zip.Store {
+		t.Error("expected an uncompressed mimetype first")
+	}
+	var content []byte
+	for _, file := range archive.File {
+		if file.Name == "content.xml" {
+			reader, _ := file.Open()
+			content, _ = io.ReadAll(reader)
+			reader.Close()
+		}
+	}
+	for _, want := range []string{
+		"Tom &amp; Jerry",
+		">French<", ">Answers<",
+		">1. chat; matou<", ">1. cat<",
+		">2. &lt;souris&gt;<",
+		`fo:border-right="0.5pt dashed`,
+	} {
+		if !bytes.Contains(content, []byte(want)) {
+			t.Errorf("expected %q in content.xml", want)
+		}
+	}
+
+	if err := NewFileSaver().SaveStudySheet(lessonData, filepath.Join(t.TempDir(), "sheet.doc")); err == nil {
+		t.Error("expected an error for an unsupported extension")
+	}
+}
@@ -0,0 +1,317 @@
+package lesson
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/paths"
+)
+
+// A subscription keeps a lesson file in step with a lesson published at a
+// URL. Updates are merged into the file rather than replacing it, so the
+// items keep their ids, stars, difficulty, extras and test results, and
+// items the user added themselves stay.
+
+// Subscription is a lesson file following a lesson at a URL
+type Subscription struct {
+	URL  string `json:"url"`
+	Path string `json:"path"`
+	// ETag and LastModified are those of the version last merged, so an
+	// unchanged lesson is not downloaded again
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	Checked      time.Time `json:"checked,omitempty"`
+	// Known holds the keys of the items of the version last merged, telling
+	// items removed upstream from items the user added
+	Known []string `json:"known,omitempty"`
+}
+
+// DefaultSubscriptionsPath returns the file subscriptions are kept in, next
+// to the settings file
+func DefaultSubscriptionsPath() string {
+	return paths.ConfigFile("subscriptions.json")
+}
+
+// LoadSubscriptions reads the subscriptions in filePath; a missing file
+// holds none
+func LoadSubscriptions(filePath string) ([]Subscription, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var subscriptions []Subscription
+	if err := json.Unmarshal(data, &subscriptions); err != nil {
+		return nil, fmt.Errorf("failed to parse subscriptions file: %w", err)
+	}
+	return subscriptions, nil
+}
+
+// SaveSubscriptions writes subscriptions to filePath
+func SaveSubscriptions(filePath string, subscriptions []Subscription) error {
+	data, err := json.MarshalIndent(subscriptions, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return err
+	}
+	return WriteFileAtomically(filePath, append(data, '\n'), 0644)
+}
+
+// FindSubscription returns the index of the subscription of the lesson
+// file filePath, or -1
+func FindSubscription(subscriptions []Subscription, filePath string) int {
+	abs, _ := filepath.Abs(filePath)
+	for i, subscription := range subscriptions {
+		if other, _ := filepath.Abs(subscription.Path); other == abs {
+			return i
+		}
+	}
+	return -1
+}
+
+// UpstreamVersion is a version of a subscribed lesson as downloaded
+type UpstreamVersion struct {
+	Lesson       *LessonData
+	ETag         string
+	LastModified string
+}
+
+// FetchSubscription downloads the lesson a subscription follows, in the
+// format its URL ends in. It returns nil when the lesson did not change
+// since the version last merged.
+func (fl *FileLoader) FetchSubscription(ctx context.Context, client *http.Client, subscription Subscription) (*UpstreamVersion, error) {
+	log.Printf("[ACTION] FileLoader.FetchSubscription() - fetching %s", subscription.URL)
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	parsed, err := url.Parse(subscription.URL)
+	if err != nil {
+		return nil, err
+	}
+	name := path.Base(parsed.Path)
+	if !strings.Contains(name, ".") {
+		return nil, fmt.Errorf("%s does not end in a lesson file name", subscription.URL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, subscription.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Recuerdo")
+	if subscription.ETag != "" {
+		req.Header.Set("If-None-Match", subscription.ETag)
+	}
+	if subscription.LastModified != "" {
+		req.Header.Set("If-Modified-Since", subscription.LastModified)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Printf("[ERROR] Failed to fetch subscribed lesson: %v", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s failed: %s", subscription.URL, resp.Status)
+	}
+
+	// Loaders read files, told apart by their names
+	dir, err := os.MkdirTemp("", "recuerdo-subscription-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	tempPath := filepath.Join(dir, name)
+	file, err := os.Create(tempPath)
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.Copy(file, io.LimitReader(resp.Body, 256<<20))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	lessonData, err := fl.LoadFile(tempPath)
+	if err != nil {
+		return nil, err
+	}
+	return &UpstreamVersion{
+		Lesson:       lessonData,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}, nil
+}
+
+// ItemChange is an item of a subscribed lesson changed upstream
+type ItemChange struct {
+	// Old is the item in the lesson file, New the item upstream
+	Old WordItem
+	New WordItem
+}
+
+// UpstreamDiff tells how the upstream version of a subscribed lesson
+// differs from the lesson file
+type UpstreamDiff struct {
+	Added   []WordItem
+	Changed []ItemChange
+	Removed []WordItem
+	// Unchanged counts the items that are the same on both sides
+	Unchanged int
+}
+
+// Empty tells whether merging the upstream version changes no items
+func (d *UpstreamDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Changed) == 0 && len(d.Removed) == 0
+}
+
+// String summarizes the diff, such as "3 new, 1 changed, 0 removed items"
+func (d *UpstreamDiff) String() string {
+	return fmt.Sprintf("%d new, %d changed, %d removed items", len(d.Added), len(d.Changed), len(d.Removed))
+}
+
+// UpstreamKeys returns the keys of the items of an upstream version, to be
+// kept as Subscription.Known once it is merged
+func UpstreamKeys(upstream *LessonData) []string {
+	keys := make([]string, 0, len(upstream.List.Items))
+	for _, item := range upstream.List.Items {
+		keys = append(keys, upstreamKey(item))
+	}
+	return keys
+}
+
+// upstreamKey identifies an item by its questions and answers, ignoring
+// case, spacing and order like CompareLessons does
+func upstreamKey(item WordItem) string {
+	return normalizeForDedupe(item.Questions) + "\x01" + normalizeForDedupe(item.Answers)
+}
+
+// MergeUpstream merges the upstream version of a subscribed lesson into
+// local, the lesson file, and returns the result with what changed. Items
+// are matched like CompareLessons does; matched items keep their id, stars,
+// difficulty, extras and test results and take the questions, answers and
+// comment of upstream, unless only the user changed them. Local items
+// without a match are removed when known holds them, as they came from an
+// earlier upstream version, and kept otherwise. The list metadata is taken
+// from upstream, except the UUID the review history follows and the sync
+// scope.
+func MergeUpstream(local, upstream *LessonData, known []string) (*LessonData, *UpstreamDiff) {
+	diff := &UpstreamDiff{}
+	comparison := CompareLessons(local, upstream)
+	knownKeys := make(map[string]bool, len(known))
+	for _, key := range known {
+		knownKeys[key] = true
+	}
+
+	merged := cloneLessonMeta(upstream)
+	merged.List.UUID = local.List.UUID
+	merged.List.Sync = local.List.Sync
+	for key, value := range local.Resources {
+		if _, ok := merged.Resources[key]; !ok {
+			merged.Resources[key] = value
+		}
+	}
+
+	removed := make(map[int]bool)
+	nextID := 0
+	for i, item := range local.List.Items {
+		nextID = max(nextID, item.ID+1)
+		j, same := comparison.Same[i]
+		if !same {
+			j, same = comparison.Changed[i]
+		}
+		switch {
+		case same && upstreamKey(item) != upstreamKey(upstream.List.Items[j]) && knownKeys[upstreamKey(upstream.List.Items[j])]:
+			// The user changed the item and upstream did not
+			diff.Unchanged++
+			merged.List.Items = append(merged.List.Items, item)
+		case same:
+			updated := takeUpstreamContent(item, upstream.List.Items[j])
+			if itemsEqual(item, updated) {
+				diff.Unchanged++
+			} else {
+				diff.Changed = append(diff.Changed, ItemChange{Old: item, New: updated})
+			}
+			merged.List.Items = append(merged.List.Items, updated)
+		case knownKeys[upstreamKey(item)]:
+			removed[item.ID] = true
+			diff.Removed = append(diff.Removed, item)
+		default:
+			merged.List.Items = append(merged.List.Items, item)
+		}
+	}
+
+	matched := comparison.RightSame()
+	for j, i := range comparison.RightChanged() {
+		matched[j] = i
+	}
+	for j, item := range upstream.List.Items {
+		if _, ok := matched[j]; ok {
+			continue
+		}
+		item.ID = nextID
+		nextID++
+		diff.Added = append(diff.Added, item)
+		merged.List.Items = append(merged.List.Items, item)
+	}
+
+	for _, test := range local.List.Tests {
+		kept := test
+		kept.Results = nil
+		for _, result := range test.Results {
+			if !removed[result.ItemID] {
+				kept.Results = append(kept.Results, result)
+			}
+		}
+		if len(kept.Results) > 0 {
+			merged.List.Tests = append(merged.List.Tests, kept)
+		}
+	}
+
+	merged.Changed = !diff.Empty() || !listMetaEqual(local, merged)
+	return merged, diff
+}
+
+// takeUpstreamContent returns item with what it asks taken from the
+// upstream item, keeping what the user did with it
+func takeUpstreamContent(item, upstream WordItem) WordItem {
+	item.Questions = upstream.Questions
+	item.Answers = upstream.Answers
+	item.AnswerGroups = upstream.AnswerGroups
+	item.Comment = upstream.Comment
+	item.Name = upstream.Name
+	item.Cloze = upstream.Cloze
+	item.X, item.Y = upstream.X, upstream.Y
+	item.Width, item.Height = upstream.Width, upstream.Height
+	item.Filename, item.Remote = upstream.Filename, upstream.Remote
+	item.Chapter = upstream.Chapter
+	return item
+}
+
+// listMetaEqual tells whether two lessons have the same list metadata
+func listMetaEqual(a, b *LessonData) bool {
+	metaA, metaB := a.List, b.List
+	metaA.Items, metaA.Tests = nil, nil
+	metaB.Items, metaB.Tests = nil, nil
+	return valuesEqual(metaA, metaB)
+}
@@ -0,0 +1,122 @@
+package lesson
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestMergeUpstream(t *testing.T) {
+	first := NewLessonData()
+	first.List.AddWordItem([]string{"chat"}, []string{"cat"}, "")
+	first.List.AddWordItem([]string{"chien"}, []string{"dog"}, "")
+	first.List.AddWordItem([]string{"oiseau"}, []string{"bird"}, "")
+	known := UpstreamKeys(first)
+
+	local := NewLessonData()
+	local.List.UUID = "local-uuid"
+	local.List.AddWordItem([]string{"chat"}, []string{"cat"}, "")
+	local.List.AddWordItem([]string{"chien"}, []string{"dog"}, "")
+	local.List.AddWordItem([]string{"oiseau"}, []string{"bird"}, "")
+	local.List.AddWordItem([]string{"poisson"}, []string{"fish"}, "my own")
+	local.List.Items[1].Starred = true
+	local.List.Tests = []Test{{Results: []TestResult{{Result: "right", ItemID: 1}, {Result: "wrong", ItemID: 2}}}}
+
+	upstream := NewLessonData()
+	upstream.List.Title = "Animals"
+	upstream.List.UUID = "upstream-uuid"
+	upstream.List.AddWordItem([]string{"chat"}, []string{"cat"}, "")
+	upstream.List.AddWordItem([]string{"chien"}, []string{"dog", "hound"}, "")
+	upstream.List.AddWordItem([]string{"cheval"}, []string{"horse"}, "")
+
+	merged, diff := MergeUpstream(local, upstream, known)
+	if len(diff.Added) != 1 || len(diff.Changed) != 1 || len(diff.Removed) != 1 || diff.Unchanged != 1 {
+		t.Fatalf("unexpected diff %s, %d unchanged", diff, diff.Unchanged)
+	}
+	if merged.List.Title != "Animals" || merged.List.UUID != "local-uuid" {
+		t.Errorf("unexpected metadata %q %q", merged.List.Title, merged.List.UUID)
+	}
+	if len(merged.List.Items) != 4 {
+		t.Fatalf("expected 4 items, got %+v", merged.List.Items)
+	}
+	dog := merged.List.Items[1]
+	if dog.ID != 1 || !dog.Starred || len(dog.Answers) != 2 {
+		t.Errorf("expected the changed item to keep its id and star, got %+v", dog)
+	}
+	if merged.List.Items[2].Comment != "my own" {
+		t.Errorf("expected the user's own item to stay, got %+v", merged.List.Items[2])
+	}
+	if horse := merged.List.Items[3]; horse.ID != 4 || horse.Questions[0] != "cheval" {
+		t.Errorf("expected the new item to get a free id, got %+v", horse)
+	}
+	if results := merged.List.Tests[0].Results; len(results) != 1 || results[0].ItemID != 1 {
+		t.Errorf("expected only the results of the removed item to go, got %+v", results)
+	}
+
+	// Merging the same version again changes nothing, and an item only the
+	// user changed stays as they left it
+	merged.List.Items[0].Answers = []string{"kitty"}
+	again, diff := MergeUpstream(merged, upstream, UpstreamKeys(upstream))
+	if !diff.Empty() || again.Changed {
+		t.Errorf("expected no changes, got %s", diff)
+	}
+	if again.List.Items[0].Answers[0] != "kitty" {
+		t.Errorf("expected the user's change to stay, got %+v", again.List.Items[0])
+	}
+}
+
+func TestFetchSubscription(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("French,English\nchat,cat\nchien,dog\n"))
+	}))
+	defer server.Close()
+
+	loader := NewFileLoader()
+	subscription := Subscription{URL: server.URL + "/lessons/animals.csv", Path: filepath.Join(t.TempDir(), "animals.csv")}
+	version, err := loader.FetchSubscription(context.Background(), server.Client(), subscription)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version == nil || len(version.Lesson.List.Items) != 2 || version.ETag != `"v1"` {
+		t.Fatalf("unexpected version %+v", version)
+	}
+
+	subscription.ETag = version.ETag
+	version, err = loader.FetchSubscription(context.Background(), server.Client(), subscription)
+	if err != nil || version != nil || requests != 2 {
+		t.Errorf("expected an unchanged lesson, got %+v, %v", version, err)
+	}
+
+	subscription.URL = server.URL + "/lessons"
+	if _, err := loader.FetchSubscription(context.Background(), server.Client(), subscription); err == nil {
+		t.Error("expected an error for a URL without a file name")
+	}
+}
+
+func TestSubscriptionsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "subscriptions.json")
+	subscriptions, err := LoadSubscriptions(path)
+	if err != nil || len(subscriptions) != 0 {
+		t.Fatalf("expected no subscriptions, got %v, %v", subscriptions, err)
+	}
+	subscriptions = append(subscriptions, Subscription{URL: "https://example.org/a.csv", Path: "a.csv", Known: []string{"x"}})
+	if err := SaveSubscriptions(path, subscriptions); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadSubscriptions(path)
+	if err != nil || len(loaded) != 1 || loaded[0].Known[0] != "x" {
+		t.Fatalf("unexpected subscriptions %+v, %v", loaded, err)
+	}
+	if FindSubscription(loaded, "a.csv") != 0 || FindSubscription(loaded, "b.csv") != -1 {
+		t.Error("unexpected FindSubscription result")
+	}
+}
@@ -0,0 +1,67 @@
+package lesson
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A lesson's sync scope tells which devices it is synced to, so lessons
+// with a lot of media can stay off devices with little room: empty syncs
+// it to every device, SyncLocalOnly keeps it on the device it is on, and
+// anything else names the device groups it is synced to, comma separated,
+// such as "desktop, tablet". Every device says which groups it is in.
+
+// SyncLocalOnly is the sync scope of lessons that are never synced
+const SyncLocalOnly = "local"
+
+// ParseSyncScope returns the sync scope written in text, with the groups
+// in the form ParseTags gives them
+func ParseSyncScope(text string) string {
+	text = strings.TrimSpace(text)
+	if strings.EqualFold(text, SyncLocalOnly) {
+		return SyncLocalOnly
+	}
+	return strings.Join(ParseTags(text), ", ")
+}
+
+// SyncGroups returns the device groups a sync scope names, none for
+// lessons synced to every device or to none
+func SyncGroups(scope string) []string {
+	if scope == SyncLocalOnly {
+		return nil
+	}
+	return ParseTags(scope)
+}
+
+// SyncsTo reports whether a lesson with the sync scope is synced to a
+// device in the groups
+func SyncsTo(scope string, groups []string) bool {
+	if scope == SyncLocalOnly {
+		return false
+	}
+	lessonGroups := SyncGroups(scope)
+	if len(lessonGroups) == 0 {
+		return true
+	}
+	for _, lessonGroup := range lessonGroups {
+		for _, group := range groups {
+			if strings.EqualFold(lessonGroup, group) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SyncScopeLabel describes a sync scope for the user, such as the badge of
+// a lesson in the library; lessons synced to every device have none
+func SyncScopeLabel(scope string) string {
+	switch {
+	case scope == "":
+		return ""
+	case scope == SyncLocalOnly:
+		return "Local only"
+	default:
+		return fmt.Sprintf("Syncs to %s", scope)
+	}
+}
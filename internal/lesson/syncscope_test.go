@@ -0,0 +1,34 @@
+package lesson
+
+import "testing"
+
+func TestSyncScope(t *testing.T) {
+	for text, want := range map[string]string{
+		"":                  "",
+		" LOCAL ":           SyncLocalOnly,
+		"desktop,, Tablet ": "desktop, Tablet",
+		"desktop, DESKTOP":  "desktop",
+	} {
+		if got := ParseSyncScope(text); got != want {
+			t.Errorf("ParseSyncScope(%q) = %q, want %q", text, got, want)
+		}
+	}
+
+	laptop := []string{"laptop"}
+	if !SyncsTo("", laptop) || !SyncsTo("", nil) {
+		t.Error("expected lessons without a scope to sync to every device")
+	}
+	if SyncsTo(SyncLocalOnly, laptop) {
+		t.Error("expected local lessons not to sync")
+	}
+	if SyncsTo("desktop, tablet", laptop) || SyncsTo("desktop", nil) {
+		t.Error("expected lessons for other groups not to sync to the laptop")
+	}
+	if !SyncsTo("desktop, Laptop", laptop) {
+		t.Error("expected groups to match ignoring case")
+	}
+
+	if SyncScopeLabel("") != "" || SyncScopeLabel(SyncLocalOnly) != "Local only" || SyncScopeLabel("desktop") != "Syncs to desktop" {
+		t.Error("unexpected sync scope labels")
+	}
+}
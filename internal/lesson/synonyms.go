@@ -0,0 +1,168 @@
+package lesson
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Required answer modes for AnswerTolerance.Required. They only make a
+// difference for items whose answers have more than one synonym group.
+const (
+	// RequireAnyAnswer accepts any one of the answers of an item
+	RequireAnyAnswer = ""
+	// RequireAllAnswers asks one synonym of every group, as in "1. bank
+	// 2. shore" where both meanings must be given
+	RequireAllAnswers = "all"
+)
+
+// numberedSegment matches the "1. " starting every required meaning in a
+// words string such as "1. bank, bench 2. shore"
+var numberedSegment = regexp.MustCompile(`(?:^|\s)[0-9]+\.\s`)
+
+// answerSeparator splits the synonyms a user typed in one answer
+var answerSeparator = regexp.MustCompile(`[,;]`)
+
+// SynonymGroups returns the answers grouped by meaning: the answers in one
+// group are synonyms, and every group is a required meaning. An item
+// without AnswerGroups has one group holding all its answers.
+func (item WordItem) SynonymGroups() [][]string {
+	if len(item.Answers) == 0 {
+		return nil
+	}
+	if len(item.AnswerGroups) != len(item.Answers) {
+		return [][]string{item.Answers}
+	}
+	var groups [][]string
+	index := make(map[int]int)
+	for i, answer := range item.Answers {
+		g, ok := index[item.AnswerGroups[i]]
+		if !ok {
+			g = len(groups)
+			index[item.AnswerGroups[i]] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], answer)
+	}
+	return groups
+}
+
+// SetSynonymGroups replaces the answers by groups of synonyms. A single
+// group is stored as plain answers without AnswerGroups.
+func (item *WordItem) SetSynonymGroups(groups [][]string) {
+	item.Answers, item.AnswerGroups = nil, nil
+	for g, group := range groups {
+		for _, answer := range group {
+			item.Answers = append(item.Answers, answer)
+			item.AnswerGroups = append(item.AnswerGroups, g)
+		}
+	}
+	if len(groups) <= 1 {
+		item.AnswerGroups = nil
+	}
+}
+
+// ComposeSynonymGroups writes answer groups as a words string: synonyms
+// separated by commas, and every group numbered when there is more than
+// one, as in "1. bank, bench 2. shore"
+func ComposeSynonymGroups(groups [][]string) string {
+	if len(groups) == 1 {
+		return strings.Join(groups[0], ", ")
+	}
+	parts := make([]string, len(groups))
+	for g, group := range groups {
+		parts[g] = fmt.Sprintf("%d. %s", g+1, strings.Join(group, ", "))
+	}
+	return strings.Join(parts, " ")
+}
+
+// splitNumberedSegments splits a words string at its "1. ", "2. " numbers.
+// Text before the first number means the numbers are part of the words, so
+// the whole text is one segment.
+func splitNumberedSegments(text string) []string {
+	text = strings.TrimSpace(text)
+	locations := numberedSegment.FindAllStringIndex(text, -1)
+	if len(locations) == 0 || locations[0][0] != 0 {
+		return []string{text}
+	}
+	var segments []string
+	for i, location := range locations {
+		end := len(text)
+		if i+1 < len(locations) {
+			end = locations[i+1][0]
+		}
+		if segment := strings.TrimSpace(text[location[1]:end]); segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// GradeItem checks given against the answers of item. With
+// RequireAllAnswers and several synonym groups, given must name one synonym
+// of every group, separated by commas or semicolons; extra synonyms of a
+// group already named are not held against it. Otherwise any one answer is
+// enough, as with Grade.
+func (c *AnswerChecker) GradeItem(given string, item WordItem) Grade {
+	groups := item.SynonymGroups()
+	if c.Tolerance.Required != RequireAllAnswers || len(groups) < 2 {
+		return c.Grade(given, item.Answers)
+	}
+
+	var parts []string
+	for _, segment := range splitNumberedSegments(given) {
+		for _, part := range answerSeparator.Split(segment, -1) {
+			if part = strings.TrimSpace(part); part != "" {
+				parts = append(parts, part)
+			}
+		}
+	}
+
+	covered := make([]bool, len(groups))
+	result := Grade{Correct: true, Credit: 1}
+	for _, part := range parts {
+		best, bestGroup := Grade{}, -1
+		for g, synonyms := range groups {
+			grade := c.Grade(part, synonyms)
+			// A group not named yet is preferred over a repeated one
+			if grade.Credit > best.Credit || (grade.Credit == best.Credit && grade.Credit > 0 && covered[bestGroup] && !covered[g]) {
+				best, bestGroup = grade, g
+			}
+		}
+		if bestGroup < 0 {
+			return Grade{}
+		}
+		covered[bestGroup] = true
+		if best.Credit < result.Credit {
+			result = Grade{Credit: best.Credit, Article: best.Article}
+		}
+	}
+	for _, ok := range covered {
+		if !ok {
+			return Grade{}
+		}
+	}
+	return result
+}
+
+// parseSynonymGroups parses a words string into groups of synonyms, one
+// for every numbered meaning
+func (fl *FileLoader) parseSynonymGroups(text string) [][]string {
+	var groups [][]string
+	for _, segment := range splitNumberedSegments(text) {
+		if words := fl.parseWordString(segment); len(words) > 0 {
+			groups = append(groups, words)
+		}
+	}
+	return groups
+}
+
+// appendMissing appends the words not in list yet
+func appendMissing(list []string, words ...string) []string {
+	for _, word := range words {
+		if !containsString(list, word) {
+			list = append(list, word)
+		}
+	}
+	return list
+}
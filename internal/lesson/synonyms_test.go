@@ -0,0 +1,177 @@
+package lesson
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSynonymGroups(t *testing.T) {
+	var item WordItem
+	item.SetSynonymGroups([][]string{{"bank", "bench"}, {"shore"}})
+	if !reflect.DeepEqual(item.Answers, []string{"bank", "bench", "shore"}) || !reflect.DeepEqual(item.AnswerGroups, []int{0, 0, 1}) {
+		t.Fatalf("Unexpected answers %v, groups %v", item.Answers, item.AnswerGroups)
+	}
+	if got := ComposeSynonymGroups(item.SynonymGroups()); got != "1. bank, bench 2. shore" {
+		t.Errorf("Expected numbered meanings, got %q", got)
+	}
+
+	item.SetSynonymGroups([][]string{{"cat", "kitty"}})
+	if item.AnswerGroups != nil || ComposeSynonymGroups(item.SynonymGroups()) != "cat, kitty" {
+		t.Errorf("Expected one group to be stored as plain answers, got %v / %v", item.Answers, item.AnswerGroups)
+	}
+
+	fl := NewFileLoader()
+	tests := map[string][][]string{
+		"1. bank, bench 2. shore": {{"bank", "bench"}, {"shore"}},
+		"cat; kitty":              {{"cat", "kitty"}},
+		"chapter 1. intro":        {{"chapter 1. intro"}},
+	}
+	for text, want := range tests {
+		if got := fl.parseSynonymGroups(text); !reflect.DeepEqual(got, want) {
+			t.Errorf("parseSynonymGroups(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
+
+func TestGradeItemRequiredAnswers(t *testing.T) {
+	var item WordItem
+	item.SetSynonymGroups([][]string{{"bank", "bench"}, {"shore"}})
+
+	anyOne := NewAnswerChecker(AnswerTolerance{IgnoreCase: true}, "en")
+	all := NewAnswerChecker(AnswerTolerance{IgnoreCase: true, Required: RequireAllAnswers}, "en")
+
+	tests := []struct {
+		given   string
+		anyOne  bool
+		wantAll bool
+	}{
+		{"bench", true, false},
+		{"shore", true, false},
+		{"Bench, shore", false, true},
+		{"1. bank 2. shore", false, true},
+		{"shore; bank, bench", false, true},
+		{"bank, bench", false, false},
+		{"bank, shore, river", false, false},
+	}
+	for _, test := range tests {
+		if got := anyOne.GradeItem(test.given, item).Correct; got != test.anyOne {
+			t.Errorf("any one: GradeItem(%q) = %v, want %v", test.given, got, test.anyOne)
+		}
+		if got := all.GradeItem(test.given, item).Correct; got != test.wantAll {
+			t.Errorf("all required: GradeItem(%q) = %v, want %v", test.given, got, test.wantAll)
+		}
+	}
+
+	// Items with one meaning are graded as before
+	plain := WordItem{Answers: []string{"cat", "kitty"}}
+	if !all.GradeItem("kitty", plain).Correct {
+		t.Error("Expected any synonym of a single meaning to be enough")
+	}
+}
+
+func TestKVTMLSynonyms(t *testing.T) {
+	kvtml := `<?xml version="1.0" encoding="UTF-8"?>
+<kvtml version="2.0">
+  <information><title>Synonyms</title></information>
+  <identifiers>
+    <identifier id="0"><name>Dutch</name></identifier>
+    <identifier id="1"><name>English</name></identifier>
+  </identifiers>
+  <entries>
+    <entry id="0">
+      <translation id="0"><text>bank</text></translation>
+      <translation id="1"><text>1. bank, bench 2. couch</text></translation>
+    </entry>
+    <entry id="1">
+      <translation id="0"><text>hond</text></translation>
+      <translation id="1"><text>dog</text></translation>
+    </entry>
+    <entry id="2">
+      <translation id="0"><text>rekel</text></translation>
+      <translation id="1"><text>hound</text></translation>
+    </entry>
+  </entries>
+  <synonyms>
+    <pair>
+      <entry id="1"><translation id="1"/></entry>
+      <entry id="2"><translation id="1"/></entry>
+    </pair>
+  </synonyms>
+</kvtml>`
+	path := filepath.Join(t.TempDir(), "synonyms.kvtml")
+	if err := os.WriteFile(path, []byte(kvtml), 0644); err != nil {
+		t.Fatalf("Failed to write KVTML: %v", err)
+	}
+
+	lessonData, err := NewFileLoader().LoadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to load KVTML: %v", err)
+	}
+	items := lessonData.List.Items
+	if len(items) != 3 {
+		t.Fatalf("Expected 3 items, got %d", len(items))
+	}
+	if got := items[0].SynonymGroups(); !reflect.DeepEqual(got, [][]string{{"bank", "bench"}, {"couch"}}) {
+		t.Errorf("Expected two meanings, got %v", got)
+	}
+	if !reflect.DeepEqual(items[1].Answers, []string{"dog", "hound"}) || !reflect.DeepEqual(items[2].Answers, []string{"hound", "dog"}) {
+		t.Errorf("Expected the synonym pair to be shared, got %v and %v", items[1].Answers, items[2].Answers)
+	}
+
+	saved := filepath.Join(t.TempDir(), "saved.kvtml")
+	if err := NewFileSaver().SaveFile(lessonData, saved); err != nil {
+		t.Fatalf("Failed to save KVTML: %v", err)
+	}
+	reloaded, err := NewFileLoader().LoadFile(saved)
+	if err != nil {
+		t.Fatalf("Failed to reload KVTML: %v", err)
+	}
+	if got := reloaded.List.Items[0].SynonymGroups(); !reflect.DeepEqual(got, [][]string{{"bank", "bench"}, {"couch"}}) {
+		t.Errorf("Expected the meanings to survive saving, got %v", got)
+	}
+}
+
+func TestWRTSLoader(t *testing.T) {
+	wrts := `<?xml version="1.0" encoding="UTF-8"?>
+<wrts>
+  <lijst id="1">
+    <titel>Woorden</titel>
+    <taal><a>Nederlands</a><b>Engels</b></taal>
+    <woord><a>bank</a><b>1. bank, bench 2. couch</b></woord>
+    <woord><a>huis</a><b>house, home</b></woord>
+    <woord><a></a><b>empty</b></woord>
+  </lijst>
+</wrts>`
+	path := filepath.Join(t.TempDir(), "woorden.wrts")
+	if err := os.WriteFile(path, []byte(wrts), 0644); err != nil {
+		t.Fatalf("Failed to write WRTS: %v", err)
+	}
+
+	lessonData, err := NewFileLoader().LoadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to load WRTS: %v", err)
+	}
+	if lessonData.List.Title != "Woorden" || lessonData.List.QuestionLanguage != "Nederlands" || lessonData.List.AnswerLanguage != "Engels" {
+		t.Errorf("Unexpected list metadata: %+v", lessonData.List)
+	}
+	items := lessonData.List.Items
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(items))
+	}
+	if got := items[0].SynonymGroups(); len(got) != 2 {
+		t.Errorf("Expected two meanings, got %v", got)
+	}
+	if items[1].AnswerGroups != nil || !reflect.DeepEqual(items[1].Answers, []string{"house", "home"}) {
+		t.Errorf("Expected synonyms of one meaning, got %v / %v", items[1].Answers, items[1].AnswerGroups)
+	}
+
+	legacy := filepath.Join("../../testdata", "legacy_files", "application_x-wrts.wrts.wrts")
+	if _, err := os.Stat(legacy); err == nil {
+		lessonData, err := NewFileLoader().LoadFile(legacy)
+		if err != nil || len(lessonData.List.Items) != 3 || lessonData.List.AnswerLanguage != "English" {
+			t.Errorf("Expected the WRTS export to load with 3 words, got %v", err)
+		}
+	}
+}
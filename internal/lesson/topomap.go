@@ -0,0 +1,30 @@
+package lesson
+
+// TopoMapResourceKey is the LessonData.Resources key holding the ID of the
+// base map the places of a topo lesson are put on
+const TopoMapResourceKey = "topoMap"
+
+// TopoMap returns the ID of the base map of a topo lesson, "" if it has
+// none
+func (ld *LessonData) TopoMap() string {
+	id, _ := ld.Resources[TopoMapResourceKey].(string)
+	return id
+}
+
+// SetTopoMap puts the places of a topo lesson on the base map with the
+// given ID; "" forgets the map
+func (ld *LessonData) SetTopoMap(id string) {
+	if ld.TopoMap() == id {
+		return
+	}
+	if id == "" {
+		delete(ld.Resources, TopoMapResourceKey)
+		ld.Changed = true
+		return
+	}
+	if ld.Resources == nil {
+		ld.Resources = make(map[string]interface{})
+	}
+	ld.Resources[TopoMapResourceKey] = id
+	ld.Changed = true
+}
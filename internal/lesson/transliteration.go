@@ -0,0 +1,269 @@
+package lesson
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// TransliterationResourceKey is the LessonData.Resources key naming the
+// transliteration answers are compared in, e.g. "pinyin", "kana" or
+// "cyrillic". Lessons without it compare answers in the script they were
+// typed in.
+const TransliterationResourceKey = "transliteration"
+
+// Transliterator writes text in Latin script so answers typed in a
+// romanization match answers stored in the native script. The result only has
+// to be consistent for comparing, so transliterators return lower case and may
+// drop distinctions learners commonly do not type.
+type Transliterator interface {
+	Transliterate(text string) string
+}
+
+// TransliteratorFunc adapts a function to the Transliterator interface
+type TransliteratorFunc func(text string) string
+
+// Transliterate calls f(text)
+func (f TransliteratorFunc) Transliterate(text string) string {
+	return f(text)
+}
+
+var (
+	transliterators     = make(map[string]Transliterator)
+	transliteratorMutex sync.RWMutex
+)
+
+// transliterationAliases maps language and script names to the names
+// transliterators are registered under
+var transliterationAliases = map[string]string{
+	"mandarin": "pinyin",
+	"chinese":  "pinyin",
+	"zh":       "pinyin",
+	"japanese": "kana",
+	"ja":       "kana",
+	"romaji":   "kana",
+	"hiragana": "kana",
+	"katakana": "kana",
+	"russian":  "cyrillic",
+	"ru":       "cyrillic",
+}
+
+func init() {
+	RegisterTransliterator("pinyin", TransliteratorFunc(transliteratePinyin))
+	RegisterTransliterator("kana", TransliteratorFunc(transliterateKana))
+	RegisterTransliterator("cyrillic", TransliteratorFunc(transliterateCyrillic))
+}
+
+// RegisterTransliterator makes a transliterator available under name,
+// replacing the built-in one if there is any
+func RegisterTransliterator(name string, transliterator Transliterator) {
+	transliteratorMutex.Lock()
+	defer transliteratorMutex.Unlock()
+	transliterators[normalizeTransliteration(name)] = transliterator
+}
+
+// TransliteratorFor returns the transliterator registered under name, which
+// may also be given as a language ("Japanese") or script ("katakana")
+func TransliteratorFor(name string) (Transliterator, bool) {
+	transliteratorMutex.RLock()
+	defer transliteratorMutex.RUnlock()
+	transliterator, ok := transliterators[normalizeTransliteration(name)]
+	return transliterator, ok
+}
+
+// Transliterations returns the names of the registered transliterators
+func Transliterations() []string {
+	transliteratorMutex.RLock()
+	defer transliteratorMutex.RUnlock()
+	names := make([]string, 0, len(transliterators))
+	for name := range transliterators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func normalizeTransliteration(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := transliterationAliases[name]; ok {
+		return alias
+	}
+	return name
+}
+
+// Transliteration returns the name of the transliteration the lesson's
+// answers are compared in, "" if there is none
+func (ld *LessonData) Transliteration() string {
+	name, _ := ld.Resources[TransliterationResourceKey].(string)
+	return name
+}
+
+// SetTransliteration makes the lesson compare answers in the named
+// transliteration; "" compares them as typed again
+func (ld *LessonData) SetTransliteration(name string) error {
+	if name == "" {
+		if _, set := ld.Resources[TransliterationResourceKey]; set {
+			delete(ld.Resources, TransliterationResourceKey)
+			ld.Changed = true
+		}
+		return nil
+	}
+	if _, ok := TransliteratorFor(name); !ok {
+		return fmt.Errorf("unknown transliteration: %s", name)
+	}
+	if ld.Resources == nil {
+		ld.Resources = make(map[string]interface{})
+	}
+	ld.Resources[TransliterationResourceKey] = normalizeTransliteration(name)
+	ld.Changed = true
+	return nil
+}
+
+// transliteratePinyin makes "nǐ hǎo", "ni3 hao3", "ni hao" and "nǐ'hǎo"
+// compare equal. Tones are dropped, because answers typed without them have
+// to match too; "v" and "u:" are the usual ways to type ü.
+func transliteratePinyin(text string) string {
+	text = strings.ToLower(text)
+	text = strings.NewReplacer("u:", "ü", "v", "ü").Replace(text)
+	text = StripPinyinTones(text)
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '\'' || r == '’' || r == '-' {
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// kanaRomaji gives the Hepburn romanization of every hiragana; katakana are
+// looked up by their hiragana counterpart
+var kanaRomaji = map[rune]string{
+	'あ': "a", 'い': "i", 'う': "u", 'え': "e", 'お': "o",
+	'か': "ka", 'き': "ki", 'く': "ku", 'け': "ke", 'こ': "ko",
+	'さ': "sa", 'し': "shi", 'す': "su", 'せ': "se", 'そ': "so",
+	'た': "ta", 'ち': "chi", 'つ': "tsu", 'て': "te", 'と': "to",
+	'な': "na", 'に': "ni", 'ぬ': "nu", 'ね': "ne", 'の': "no",
+	'は': "ha", 'ひ': "hi", 'ふ': "fu", 'へ': "he", 'ほ': "ho",
+	'ま': "ma", 'み': "mi", 'む': "mu", 'め': "me", 'も': "mo",
+	'や': "ya", 'ゆ': "yu", 'よ': "yo",
+	'ら': "ra", 'り': "ri", 'る': "ru", 'れ': "re", 'ろ': "ro",
+	'わ': "wa", 'ゐ': "i", 'ゑ': "e", 'を': "o", 'ん': "n",
+	'が': "ga", 'ぎ': "gi", 'ぐ': "gu", 'げ': "ge", 'ご': "go",
+	'ざ': "za", 'じ': "ji", 'ず': "zu", 'ぜ': "ze", 'ぞ': "zo",
+	'だ': "da", 'ぢ': "ji", 'づ': "zu", 'で': "de", 'ど': "do",
+	'ば': "ba", 'び': "bi", 'ぶ': "bu", 'べ': "be", 'ぼ': "bo",
+	'ぱ': "pa", 'ぴ': "pi", 'ぷ': "pu", 'ぺ': "pe", 'ぽ': "po",
+	'ゔ': "vu",
+	'ぁ': "a", 'ぃ': "i", 'ぅ': "u", 'ぇ': "e", 'ぉ': "o", 'ゎ': "wa",
+}
+
+// smallKanaYoon are the small ya, yu and yo that combine with the kana
+// before them: き+ゃ is "kya", し+ゃ is "sha"
+var smallKanaYoon = map[rune]string{'ゃ': "a", 'ゅ': "u", 'ょ': "o"}
+
+// romajiLongVowels folds the ways long vowels are written in romaji onto a
+// short vowel: "tōkyō", "toukyou" and "tokyo" compare equal
+var romajiLongVowels = strings.NewReplacer(
+	"ā", "a", "ī", "i", "ū", "u", "ē", "e", "ō", "o",
+	"â", "a", "î", "i", "û", "u", "ê", "e", "ô", "o",
+	"aa", "a", "ii", "i", "uu", "u", "ee", "e", "oo", "o", "ou", "o",
+)
+
+// transliterateKana writes hiragana and katakana in Hepburn romaji. Spaces
+// are dropped, because Japanese is written without them and learners put
+// them in different places.
+func transliterateKana(text string) string {
+	var out strings.Builder
+	runes := []rune(strings.ToLower(text))
+	geminate := false
+	for _, r := range runes {
+		// katakana are the hiragana 0x60 further on
+		if r >= 'ァ' && r <= 'ヶ' {
+			r -= 0x60
+		}
+		switch {
+		case r == 'っ':
+			geminate = true
+			continue
+		case r == 'ー':
+			if last := lastRune(out.String()); strings.ContainsRune("aeiou", last) {
+				out.WriteRune(last)
+			}
+			continue
+		case unicode.IsSpace(r) || r == '\'' || r == '’' || r == '・':
+			continue
+		}
+		if vowel, ok := smallKanaYoon[r]; ok {
+			written := out.String()
+			if strings.HasSuffix(written, "i") {
+				stem := strings.TrimSuffix(written, "i")
+				out.Reset()
+				out.WriteString(stem)
+				if !strings.HasSuffix(stem, "sh") && !strings.HasSuffix(stem, "ch") && !strings.HasSuffix(stem, "j") {
+					out.WriteString("y")
+				}
+				out.WriteString(vowel)
+			} else {
+				out.WriteString("y" + vowel)
+			}
+			continue
+		}
+		romaji, ok := kanaRomaji[r]
+		if !ok {
+			romaji = string(r)
+		}
+		if geminate {
+			if strings.HasPrefix(romaji, "ch") {
+				out.WriteString("t")
+			} else if first := romaji[0]; !strings.ContainsRune("aeioun", rune(first)) {
+				out.WriteByte(first)
+			}
+			geminate = false
+		}
+		out.WriteString(romaji)
+	}
+	return romajiLongVowels.Replace(out.String())
+}
+
+func lastRune(text string) rune {
+	runes := []rune(text)
+	if len(runes) == 0 {
+		return 0
+	}
+	return runes[len(runes)-1]
+}
+
+// cyrillicLatin gives a romanization of the Cyrillic letters of Russian,
+// Ukrainian, Belarusian, Bulgarian, Serbian and Macedonian
+var cyrillicLatin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+	'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g", 'ў': "u",
+	'ђ': "dj", 'ј': "y", 'љ': "ly", 'њ': "ny", 'ћ': "ch", 'џ': "dzh",
+	'ѓ': "gy", 'ќ': "ky", 'ѕ': "dz",
+}
+
+// latinCyrillicVariants rewrites the scholarly and German-style spellings of
+// romanized Cyrillic to the ones cyrillicLatin produces, so "žena", "zhena"
+// and "жена" or "jabloko" and "яблоко" compare equal
+var latinCyrillicVariants = strings.NewReplacer(
+	"šč", "shch", "ŝ", "shch", "ž", "zh", "š", "sh", "č", "ch", "ć", "ch",
+	"x", "kh", "ja", "ya", "ju", "yu", "jo", "yo", "je", "ye", "j", "y",
+)
+
+// transliterateCyrillic writes Cyrillic text in Latin script
+func transliterateCyrillic(text string) string {
+	var out strings.Builder
+	for _, r := range strings.ToLower(text) {
+		if latin, ok := cyrillicLatin[r]; ok {
+			out.WriteString(latin)
+		} else if r != '\'' && r != '’' {
+			out.WriteRune(r)
+		}
+	}
+	return latinCyrillicVariants.Replace(out.String())
+}
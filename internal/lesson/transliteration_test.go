@@ -0,0 +1,85 @@
+package lesson
+
+import "testing"
+
+func TestBuiltinTransliterators(t *testing.T) {
+	tests := []struct {
+		name  string
+		forms []string
+	}{
+		{"pinyin", []string{"nǐ hǎo", "ni3 hao3", "ni hao", "nǐ'hǎo", "Ni Hao"}},
+		{"pinyin", []string{"lǜ", "lv4", "lu:4", "lü"}},
+		{"kana", []string{"こんにちは", "konnichiha", "kon nichi ha"}},
+		{"kana", []string{"とうきょう", "トウキョウ", "tōkyō", "tokyo", "toukyou"}},
+		{"kana", []string{"きって", "kitte"}},
+		{"kana", []string{"しゃしん", "shashin"}},
+		{"kana", []string{"まっちゃ", "matcha"}},
+		{"kana", []string{"コーヒー", "kōhī", "kohi"}},
+		{"cyrillic", []string{"Москва", "moskva"}},
+		{"cyrillic", []string{"жена", "zhena", "žena"}},
+		{"cyrillic", []string{"яблоко", "yabloko", "jabloko"}},
+		{"cyrillic", []string{"щука", "shchuka", "ščuka"}},
+		{"cyrillic", []string{"хорошо", "khorosho", "xorošo"}},
+	}
+	for _, tt := range tests {
+		transliterator, ok := TransliteratorFor(tt.name)
+		if !ok {
+			t.Fatalf("No transliterator for %s", tt.name)
+		}
+		want := transliterator.Transliterate(tt.forms[0])
+		for _, form := range tt.forms[1:] {
+			if got := transliterator.Transliterate(form); got != want {
+				t.Errorf("%s: Transliterate(%q) = %q; want %q like %q", tt.name, form, got, want, tt.forms[0])
+			}
+		}
+	}
+
+	pinyin, _ := TransliteratorFor("pinyin")
+	if pinyin.Transliterate("mǎi") != pinyin.Transliterate("mài") {
+		t.Error("Pinyin transliteration should ignore tones")
+	}
+}
+
+func TestTransliteratorFor(t *testing.T) {
+	for name, want := range map[string]string{"Mandarin": "pinyin", "katakana": "kana", "Russian": "cyrillic"} {
+		got, ok := TransliteratorFor(name)
+		expected, _ := TransliteratorFor(want)
+		if !ok || got.Transliterate("テスト") != expected.Transliterate("テスト") {
+			t.Errorf("TransliteratorFor(%q) did not find %s", name, want)
+		}
+	}
+	if _, ok := TransliteratorFor("klingon"); ok {
+		t.Error("Expected no transliterator for klingon")
+	}
+}
+
+func TestLessonTransliteration(t *testing.T) {
+	ld := &LessonData{}
+	if err := ld.SetTransliteration("elvish"); err == nil {
+		t.Error("Expected an error for an unknown transliteration")
+	}
+	if err := ld.SetTransliteration("Japanese"); err != nil {
+		t.Fatal(err)
+	}
+	if got := ld.Transliteration(); got != "kana" {
+		t.Errorf("Transliteration() = %q; want kana", got)
+	}
+
+	ld.List.Items = []WordItem{{Questions: []string{"tea"}, Answers: []string{"おちゃ"}}}
+	checker := AnswerCheckerFor(ld, OptionPreset{Tolerance: AnswerTolerance{IgnoreCase: true}})
+	for _, given := range []string{"ocha", "Ocha", "おちゃ", "オチャ"} {
+		if !checker.Check(given, ld.List.Items[0].Answers) {
+			t.Errorf("Check(%q) = false; want true", given)
+		}
+	}
+	if checker.Check("okashi", ld.List.Items[0].Answers) {
+		t.Error("A different word should not match")
+	}
+
+	if err := ld.SetTransliteration(""); err != nil {
+		t.Fatal(err)
+	}
+	if AnswerCheckerFor(ld, OptionPreset{}).Check("ocha", ld.List.Items[0].Answers) {
+		t.Error("Romaji should not match kana without a transliteration")
+	}
+}
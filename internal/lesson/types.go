@@ -9,14 +9,39 @@ type WordItem struct {
 	ID        int      `json:"id"`
 	Questions []string `json:"questions"`
 	Answers   []string `json:"answers"`
-	Comment   string   `json:"comment,omitempty"`
-	Name      string   `json:"name,omitempty"`
+	// AnswerGroups numbers the meaning of every answer, in step with
+	// Answers: answers of one group are synonyms and every group is a
+	// required meaning. Empty means all answers are synonyms.
+	AnswerGroups []int  `json:"answerGroups,omitempty"`
+	Comment      string `json:"comment,omitempty"`
+	Name         string `json:"name,omitempty"`
+	// Cloze holds a text with {{c1::...}} masks; set for cloze items only
+	Cloze string `json:"cloze,omitempty"`
 	// Topo-specific fields (optional)
 	X *int `json:"x,omitempty"`
 	Y *int `json:"y,omitempty"`
+	// Image occlusion fields: the size of the mask whose top left corner
+	// is X, Y (optional)
+	Width  *int `json:"width,omitempty"`
+	Height *int `json:"height,omitempty"`
 	// Media-specific fields (optional)
 	Filename *string `json:"filename,omitempty"`
 	Remote   *bool   `json:"remote,omitempty"`
+	// Starred marks items the user wants to keep an eye on
+	Starred bool `json:"starred,omitempty"`
+	// Difficulty overrides how lesson types and list modifiers treat the
+	// item; empty means normal
+	Difficulty Difficulty `json:"difficulty,omitempty"`
+	// Tags group items within the list, such as "chapter-3", so a session
+	// can ask only some of them
+	Tags []string `json:"tags,omitempty"`
+	// Chapter is the path of the chapter holding the item within the list,
+	// outermost first, such as ["Part 1", "Unit 3"]; empty when the list
+	// has no chapters
+	Chapter []string `json:"chapter,omitempty"`
+	// Extras keeps format-specific data such as tags and scheduling, so
+	// saving back to the original format does not lose it
+	Extras map[string]any `json:"extras,omitempty"`
 }
 
 // TopoItem represents a single topography item with coordinates
@@ -44,6 +69,15 @@ type TestResult struct {
 	Result string     `json:"result"` // "right" or "wrong"
 	ItemID int        `json:"itemId"`
 	Time   *time.Time `json:"time,omitempty"`
+	// Credit is the partial credit of a wrong answer that was partly right,
+	// such as the right word with the wrong article
+	Credit float64 `json:"credit,omitempty"`
+	// Reversed marks answers to the item asked the other way round, its
+	// answers shown and its questions expected
+	Reversed bool `json:"reversed,omitempty"`
+	// AnswerTime is how many seconds the answer took, from showing the
+	// question; 0 when it was not timed
+	AnswerTime float64 `json:"answerTime,omitempty"`
 }
 
 // Test represents a collection of test results
@@ -52,6 +86,23 @@ type Test struct {
 	Date    *time.Time   `json:"date,omitempty"`
 }
 
+// Percentage returns the score of the test from 0 to 100. Partly right
+// answers count for their partial credit.
+func (t Test) Percentage() int {
+	if len(t.Results) == 0 {
+		return 0
+	}
+	credit := 0.0
+	for _, result := range t.Results {
+		if result.Result == "right" {
+			credit++
+		} else {
+			credit += result.Credit
+		}
+	}
+	return int(credit / float64(len(t.Results)) * 100)
+}
+
 // WordList represents the core lesson data structure
 type WordList struct {
 	Title            string     `json:"title,omitempty"`
@@ -59,6 +110,20 @@ type WordList struct {
 	AnswerLanguage   string     `json:"answerLanguage,omitempty"`
 	Items            []WordItem `json:"items"`
 	Tests            []Test     `json:"tests"`
+
+	// Tags, Author, Description and License describe the lesson in the
+	// lesson library; Level is its CEFR level, such as "B1"
+	Tags        []string `json:"tags,omitempty"`
+	Author      string   `json:"author,omitempty"`
+	Description string   `json:"description,omitempty"`
+	License     string   `json:"license,omitempty"`
+	Level       string   `json:"level,omitempty"`
+	// UUID identifies the lesson in the review history whatever its file
+	// is called; see EnsureUUID
+	UUID string `json:"uuid,omitempty"`
+	// Sync is the sync scope of the lesson, telling which devices it is
+	// synced to; see ParseSyncScope
+	Sync string `json:"sync,omitempty"`
 }
 
 // LessonData represents the complete lesson data as returned by loaders
@@ -66,6 +131,9 @@ type LessonData struct {
 	List      WordList               `json:"list"`
 	Resources map[string]interface{} `json:"resources"`
 	Changed   bool                   `json:"changed,omitempty"`
+
+	// mediaDir is the folder linked media is relative to
+	mediaDir string
 }
 
 // Lesson represents a lesson instance in the application
@@ -179,13 +247,16 @@ func (wi *WordItem) GetMediaInfo() (string, bool, bool) {
 
 // AddTestResult adds a test result to the lesson
 func (wl *WordList) AddTestResult(itemID int, result string) {
-	testResult := TestResult{
+	wl.addResult(TestResult{
 		Result: result,
 		ItemID: itemID,
 		Time:   &time.Time{},
-	}
+	})
+}
 
-	// Add to the most recent test, or create a new one
+// addResult adds testResult to the most recent test, or creates a new one,
+// and tells the review observers about it
+func (wl *WordList) addResult(testResult TestResult) {
 	if len(wl.Tests) == 0 {
 		wl.Tests = append(wl.Tests, Test{
 			Results: []TestResult{testResult},
@@ -195,6 +266,25 @@ func (wl *WordList) AddTestResult(itemID int, result string) {
 		lastTest := &wl.Tests[len(wl.Tests)-1]
 		lastTest.Results = append(lastTest.Results, testResult)
 	}
+	for _, observer := range currentReviewObservers() {
+		observer.Reviewed(wl, testResult)
+	}
+}
+
+// AddGradedResult adds the result of a graded answer to the lesson. Answers
+// earning partial credit count as wrong, with their credit kept.
+func (wl *WordList) AddGradedResult(itemID int, grade Grade) {
+	wl.addResult(gradedResult(itemID, grade))
+}
+
+// gradedResult is the test result of a graded answer to the item with itemID
+func gradedResult(itemID int, grade Grade) TestResult {
+	result := TestResult{Result: "right", ItemID: itemID, Time: &time.Time{}}
+	if !grade.Correct {
+		result.Result = "wrong"
+		result.Credit = grade.Credit
+	}
+	return result
 }
 
 // GetRightAnswersCount returns the number of correct answers for an item
@@ -0,0 +1,123 @@
+package lesson
+
+// DefaultUndoLimit is how many edits an UndoStack remembers by default
+const DefaultUndoLimit = 100
+
+// undoState is a copy of the editable part of a lesson
+type undoState struct {
+	description string
+	items       []WordItem
+	tests       []Test
+	changed     bool
+}
+
+// UndoStack lets lesson editors undo and redo edits. Before changing a
+// lesson, an editor calls Push; Undo and Redo then swap the lesson's items
+// and tests with the remembered ones.
+type UndoStack struct {
+	undo  []undoState
+	redo  []undoState
+	limit int
+}
+
+// NewUndoStack creates an undo stack remembering at most limit edits; zero
+// means DefaultUndoLimit
+func NewUndoStack(limit int) *UndoStack {
+	if limit <= 0 {
+		limit = DefaultUndoLimit
+	}
+	return &UndoStack{limit: limit}
+}
+
+// Push remembers the state of lessonData before an edit described by
+// description, such as "Replace 'colour' with 'color'". It clears the redo
+// history.
+func (s *UndoStack) Push(description string, lessonData *LessonData) {
+	s.undo = append(s.undo, snapshot(description, lessonData))
+	if len(s.undo) > s.limit {
+		s.undo = s.undo[len(s.undo)-s.limit:]
+	}
+	s.redo = nil
+}
+
+// CanUndo reports whether there is an edit to undo, and describes it
+func (s *UndoStack) CanUndo() (string, bool) {
+	if len(s.undo) == 0 {
+		return "", false
+	}
+	return s.undo[len(s.undo)-1].description, true
+}
+
+// CanRedo reports whether there is an undone edit to redo, and describes it
+func (s *UndoStack) CanRedo() (string, bool) {
+	if len(s.redo) == 0 {
+		return "", false
+	}
+	return s.redo[len(s.redo)-1].description, true
+}
+
+// Undo restores lessonData to before the last edit. It returns the
+// description of the undone edit, or false when there is none.
+func (s *UndoStack) Undo(lessonData *LessonData) (string, bool) {
+	if len(s.undo) == 0 {
+		return "", false
+	}
+	state := s.undo[len(s.undo)-1]
+	s.undo = s.undo[:len(s.undo)-1]
+	s.redo = append(s.redo, snapshot(state.description, lessonData))
+	restore(state, lessonData)
+	return state.description, true
+}
+
+// Redo makes the last undone edit again. It returns the description of the
+// edit, or false when there is none.
+func (s *UndoStack) Redo(lessonData *LessonData) (string, bool) {
+	if len(s.redo) == 0 {
+		return "", false
+	}
+	state := s.redo[len(s.redo)-1]
+	s.redo = s.redo[:len(s.redo)-1]
+	s.undo = append(s.undo, snapshot(state.description, lessonData))
+	restore(state, lessonData)
+	return state.description, true
+}
+
+// Clear forgets all edits, for when another lesson is loaded
+func (s *UndoStack) Clear() {
+	s.undo, s.redo = nil, nil
+}
+
+func snapshot(description string, lessonData *LessonData) undoState {
+	return undoState{
+		description: description,
+		items:       cloneItems(lessonData.List.Items),
+		tests:       append([]Test(nil), lessonData.List.Tests...),
+		changed:     lessonData.Changed,
+	}
+}
+
+func restore(state undoState, lessonData *LessonData) {
+	lessonData.List.Items = cloneItems(state.items)
+	lessonData.List.Tests = append([]Test(nil), state.tests...)
+	// Undoing every edit since the lesson was saved makes it unchanged
+	// again only if it was unchanged then
+	lessonData.Changed = state.changed
+}
+
+// cloneItems copies items with their word lists, so editing the copy leaves
+// the original alone
+func cloneItems(items []WordItem) []WordItem {
+	if items == nil {
+		return nil
+	}
+	cloned := make([]WordItem, len(items))
+	for i, item := range items {
+		item.Questions = append([]string(nil), item.Questions...)
+		item.Answers = append([]string(nil), item.Answers...)
+		if item.AnswerGroups != nil {
+			item.AnswerGroups = append([]int(nil), item.AnswerGroups...)
+		}
+		cloned[i] = item
+	}
+	return cloned
+}
@@ -0,0 +1,53 @@
+package lesson
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Hebrew and Arabic are mostly written without vowels, so learners type
+// unvocalized text even when a lesson spells out the niqqud or harakat. The
+// answer checker therefore ignores them unless the tolerance asks for strict
+// vowel points.
+
+// vowelPoints holds the Hebrew points and cantillation marks and the Arabic
+// harakat, Quranic annotation signs and tatweel
+var vowelPoints = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x0591, Hi: 0x05bd, Stride: 1},
+		{Lo: 0x05bf, Hi: 0x05bf, Stride: 1},
+		{Lo: 0x05c1, Hi: 0x05c2, Stride: 1},
+		{Lo: 0x05c4, Hi: 0x05c5, Stride: 1},
+		{Lo: 0x05c7, Hi: 0x05c7, Stride: 1},
+		{Lo: 0x0610, Hi: 0x061a, Stride: 1},
+		{Lo: 0x0640, Hi: 0x0640, Stride: 1},
+		{Lo: 0x064b, Hi: 0x065f, Stride: 1},
+		{Lo: 0x0670, Hi: 0x0670, Stride: 1},
+		{Lo: 0x06d6, Hi: 0x06dc, Stride: 1},
+		{Lo: 0x06df, Hi: 0x06e4, Stride: 1},
+		{Lo: 0x06e7, Hi: 0x06e8, Stride: 1},
+		{Lo: 0x06ea, Hi: 0x06ed, Stride: 1},
+	},
+}
+
+// StripVowelPoints removes niqqud from Hebrew and harakat from Arabic text,
+// so "שָׁלוֹם" becomes "שלום" and "كَتَبَ" becomes "كتب". Other scripts,
+// including their accents, are left alone.
+func StripVowelPoints(text string) string {
+	if !strings.ContainsFunc(text, isHebrewOrArabic) {
+		return text
+	}
+	// Decomposing splits presentation forms such as "שׁ" into letter and point
+	return norm.NFC.String(strings.Map(func(r rune) rune {
+		if unicode.Is(vowelPoints, r) {
+			return -1
+		}
+		return r
+	}, norm.NFD.String(text)))
+}
+
+func isHebrewOrArabic(r rune) bool {
+	return unicode.In(r, unicode.Hebrew, unicode.Arabic)
+}
@@ -0,0 +1,36 @@
+package lesson
+
+import "testing"
+
+func TestStripVowelPoints(t *testing.T) {
+	tests := []struct{ given, want string }{
+		{"שָׁלוֹם", "שלום"},
+		{"שָׁלוֹם", "שלום"},
+		{"كَتَبَ", "كتب"},
+		{"مُـحَـمَّد", "محمد"},
+		{"café", "café"},
+	}
+	for _, tt := range tests {
+		if got := StripVowelPoints(tt.given); got != tt.want {
+			t.Errorf("StripVowelPoints(%q) = %q; want %q", tt.given, got, tt.want)
+		}
+	}
+}
+
+func TestAnswerCheckerVowelPoints(t *testing.T) {
+	lenient := NewAnswerChecker(AnswerTolerance{}, "")
+	if !lenient.Check("שלום", []string{"שָׁלוֹם"}) || !lenient.Check("كتب", []string{"كَتَبَ"}) {
+		t.Error("Unvocalized answers should be accepted")
+	}
+	if lenient.Check("cafe", []string{"café"}) {
+		t.Error("Accents of other scripts should still count")
+	}
+
+	strict := NewAnswerChecker(AnswerTolerance{StrictVowelPoints: true}, "")
+	if strict.Check("שלום", []string{"שָׁלוֹם"}) {
+		t.Error("Strict mode should require the niqqud")
+	}
+	if !strict.Check("שָׁלוֹם", []string{"שָׁלוֹם"}) {
+		t.Error("Vocalized answer should match in strict mode")
+	}
+}
@@ -0,0 +1,73 @@
+package lesson
+
+import (
+	"encoding/xml"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WRTS (http://www.wrts.nl) exports a list as XML with a <woord> element
+// for every word, holding the question in <a> and the answer in <b>.
+// Answers separated by commas are synonyms; numbered meanings such as
+// "1. bank 2. oever" are all required, as in OpenTeacher's words strings.
+
+type wrtsWord struct {
+	A string `xml:"a"`
+	B string `xml:"b"`
+}
+
+type wrtsList struct {
+	Title    string `xml:"titel"`
+	Language struct {
+		A string `xml:"a"`
+		B string `xml:"b"`
+	} `xml:"taal"`
+	Words []wrtsWord `xml:"woord"`
+}
+
+type wrtsRoot struct {
+	XMLName xml.Name `xml:"wrts"`
+	List    wrtsList `xml:"lijst"`
+}
+
+// loadWRTSFile loads a list exported from WRTS
+func (fl *FileLoader) loadWRTSFile(filePath string) (*LessonData, error) {
+	log.Printf("[ACTION] FileLoader.loadWRTSFile() - parsing WRTS file")
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		log.Printf("[ERROR] Failed to open WRTS file: %v", err)
+		return nil, err
+	}
+	defer file.Close()
+
+	var root wrtsRoot
+	if err := fl.newXMLDecoder(filePath, file).Decode(&root); err != nil {
+		log.Printf("[ERROR] Failed to parse WRTS XML: %v", err)
+		return nil, err
+	}
+
+	lessonData := NewLessonData()
+	lessonData.List.Title = strings.TrimSpace(root.List.Title)
+	if lessonData.List.Title == "" {
+		lessonData.List.Title = filepath.Base(filePath)
+	}
+	lessonData.List.QuestionLanguage = strings.TrimSpace(root.List.Language.A)
+	lessonData.List.AnswerLanguage = strings.TrimSpace(root.List.Language.B)
+
+	for _, word := range root.List.Words {
+		item := WordItem{ID: len(lessonData.List.Items)}
+		for _, group := range fl.parseSynonymGroups(word.A) {
+			item.Questions = append(item.Questions, group...)
+		}
+		item.SetSynonymGroups(fl.parseSynonymGroups(word.B))
+		if len(item.Questions) > 0 && len(item.Answers) > 0 {
+			lessonData.List.Items = append(lessonData.List.Items, item)
+		}
+	}
+
+	log.Printf("[SUCCESS] FileLoader.loadWRTSFile() - loaded %d word pairs", len(lessonData.List.Items))
+	return lessonData, nil
+}
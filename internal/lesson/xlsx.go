@@ -0,0 +1,204 @@
+package lesson
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// encryptedPackageName is the name of the stream holding the workbook in a
+// password-protected .xlsx file, as written in UTF-16LE in its directory
+var encryptedPackageName = []byte("E\x00n\x00c\x00r\x00y\x00p\x00t\x00e\x00d\x00P\x00a\x00c\x00k\x00a\x00g\x00e\x00")
+
+// openWorkbook opens an .xlsx file. Excel wraps password-protected
+// workbooks in an encrypted OLE container, which is told apart from a
+// damaged workbook.
+func openWorkbook(filePath string) (*excelize.File, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	workbook, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		if bytes.Contains(data, encryptedPackageName) {
+			return nil, ErrPasswordProtected
+		}
+		return nil, corruptArchive("Excel workbook", "", err)
+	}
+	return workbook, nil
+}
+
+// GetXLSXSheets returns the names of the worksheets in an .xlsx file, in
+// workbook order, so the user can pick one when there is more than one
+func (fl *FileLoader) GetXLSXSheets(filePath string) ([]string, error) {
+	workbook, err := openWorkbook(filePath)
+	if err != nil {
+		log.Printf("[ERROR] Failed to open XLSX file: %v", err)
+		return nil, err
+	}
+	defer workbook.Close()
+
+	return workbook.GetSheetList(), nil
+}
+
+// loadXLSXFile loads the first worksheet of an .xlsx file
+func (fl *FileLoader) loadXLSXFile(filePath string) (*LessonData, error) {
+	return fl.LoadXLSXSheet(filePath, "")
+}
+
+// LoadXLSXSheet loads a single worksheet of an .xlsx file. The first column
+// holds the questions, the second the answers and an optional third column
+// the comment. An empty sheet name selects the first worksheet.
+func (fl *FileLoader) LoadXLSXSheet(filePath, sheet string) (*LessonData, error) {
+	log.Printf("[ACTION] FileLoader.LoadXLSXSheet() - parsing XLSX file")
+
+	workbook, err := openWorkbook(filePath)
+	if err != nil {
+		log.Printf("[ERROR] Failed to open XLSX file: %v", err)
+		return nil, err
+	}
+	defer workbook.Close()
+
+	sheets := workbook.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, corruptArchive("Excel workbook", "no worksheets found", nil)
+	}
+	if sheet == "" {
+		sheet = sheets[0]
+	} else if idx, _ := workbook.GetSheetIndex(sheet); idx < 0 {
+		return nil, fmt.Errorf("worksheet %q not found", sheet)
+	}
+
+	rows, err := workbook.GetRows(sheet)
+	if err != nil {
+		log.Printf("[ERROR] Failed to read XLSX worksheet: %v", err)
+		return nil, err
+	}
+
+	lessonData := NewLessonData()
+	lessonData.List.Title = strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	if len(sheets) > 1 {
+		lessonData.List.Title += " - " + sheet
+	}
+
+	// A header row as written by saveXLSXFile carries the languages
+	if len(rows) > 0 && isXLSXHeader(rows[0]) {
+		if name := strings.TrimSpace(rows[0][0]); !csvHeaderWords[strings.ToLower(name)] {
+			lessonData.List.QuestionLanguage = name
+		}
+		if name := strings.TrimSpace(rows[0][1]); !csvHeaderWords[strings.ToLower(name)] {
+			lessonData.List.AnswerLanguage = name
+		}
+		rows = rows[1:]
+	}
+
+	for _, record := range rows {
+		if len(record) < 2 {
+			continue
+		}
+
+		questions := fl.parseWordString(strings.TrimSpace(record[0]))
+		answers := fl.parseWordString(strings.TrimSpace(record[1]))
+
+		comment := ""
+		if len(record) > 2 {
+			comment = strings.TrimSpace(record[2])
+		}
+
+		if len(questions) > 0 && len(answers) > 0 {
+			lessonData.List.Items = append(lessonData.List.Items, WordItem{
+				ID:        len(lessonData.List.Items),
+				Questions: questions,
+				Answers:   answers,
+				Comment:   comment,
+			})
+		}
+	}
+
+	log.Printf("[SUCCESS] FileLoader.LoadXLSXSheet() - loaded %d word pairs from sheet %q", len(lessonData.List.Items), sheet)
+	return lessonData, nil
+}
+
+// isXLSXHeader reports whether a row looks like the header written by the
+// saver, or a header of two columns such as "Question" and "Answer" or two
+// language names, like CSV headers
+func isXLSXHeader(row []string) bool {
+	return isCSVHeader(row)
+}
+
+// xlsxSheetName turns a lesson title into a valid worksheet name
+func xlsxSheetName(title string) string {
+	name := strings.Map(func(r rune) rune {
+		switch r {
+		case '[', ']', ':', '*', '?', '/', '\\':
+			return '_'
+		}
+		return r
+	}, strings.Trim(title, "' "))
+
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+	if name == "" {
+		name = "Lesson"
+	}
+	return name
+}
+
+// saveXLSXFile saves lesson data as an Excel workbook with a single worksheet
+func (fs *FileSaver) saveXLSXFile(lessonData *LessonData, filePath string) error {
+	log.Printf("[ACTION] FileSaver.saveXLSXFile() - saving XLSX file")
+
+	workbook := excelize.NewFile()
+	defer workbook.Close()
+
+	sheet := xlsxSheetName(lessonData.List.Title)
+	if err := workbook.SetSheetName("Sheet1", sheet); err != nil {
+		log.Printf("[ERROR] Failed to name XLSX worksheet: %v", err)
+		return err
+	}
+
+	header := []interface{}{
+		getColumnHeader(lessonData.List.QuestionLanguage, "Questions"),
+		getColumnHeader(lessonData.List.AnswerLanguage, "Answers"),
+		"Comment",
+	}
+	if err := workbook.SetSheetRow(sheet, "A1", &header); err != nil {
+		log.Printf("[ERROR] Failed to write XLSX header: %v", err)
+		return err
+	}
+
+	bold, err := workbook.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err == nil {
+		workbook.SetRowStyle(sheet, 1, 1, bold)
+	}
+
+	for i, item := range lessonData.List.Items {
+		row := []interface{}{
+			strings.Join(item.Questions, "; "),
+			strings.Join(item.Answers, "; "),
+			item.Comment,
+		}
+		cell, _ := excelize.CoordinatesToCellName(1, i+2)
+		if err := workbook.SetSheetRow(sheet, cell, &row); err != nil {
+			log.Printf("[ERROR] Failed to write XLSX row: %v", err)
+			return err
+		}
+	}
+
+	workbook.SetColWidth(sheet, "A", "B", 30)
+	workbook.SetColWidth(sheet, "C", "C", 40)
+
+	if err := workbook.SaveAs(filePath); err != nil {
+		log.Printf("[ERROR] Failed to write XLSX file: %v", err)
+		return err
+	}
+
+	log.Printf("[SUCCESS] FileSaver.saveXLSXFile() - saved %d items to XLSX file", len(lessonData.List.Items))
+	return nil
+}
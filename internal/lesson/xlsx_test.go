@@ -0,0 +1,122 @@
+package lesson
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestFileSaver_SaveXLSXRoundTrip(t *testing.T) {
+	lessonData := &LessonData{
+		List: WordList{
+			Title:            "Greetings: basics",
+			QuestionLanguage: "English",
+			AnswerLanguage:   "Dutch",
+			Items: []WordItem{
+				{ID: 0, Questions: []string{"hello"}, Answers: []string{"hallo"}, Comment: "greeting"},
+				{ID: 1, Questions: []string{"goodbye", "bye"}, Answers: []string{"dag", "tot ziens"}},
+			},
+		},
+	}
+
+	testFile := filepath.Join(t.TempDir(), "greetings.xlsx")
+	if err := NewFileSaver().SaveFile(lessonData, testFile); err != nil {
+		t.Fatalf("Failed to save XLSX file: %v", err)
+	}
+
+	loader := NewFileLoader()
+	sheets, err := loader.GetXLSXSheets(testFile)
+	if err != nil {
+		t.Fatalf("Failed to list sheets: %v", err)
+	}
+	if len(sheets) != 1 || sheets[0] != "Greetings_ basics" {
+		t.Errorf("Expected a single sanitized sheet name, got %v", sheets)
+	}
+
+	loaded, err := loader.LoadFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to load XLSX file: %v", err)
+	}
+
+	if loaded.List.QuestionLanguage != "English" || loaded.List.AnswerLanguage != "Dutch" {
+		t.Errorf("Languages not restored from header: %q/%q", loaded.List.QuestionLanguage, loaded.List.AnswerLanguage)
+	}
+	if len(loaded.List.Items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(loaded.List.Items))
+	}
+	if loaded.List.Items[0].Comment != "greeting" {
+		t.Errorf("Expected comment 'greeting', got %q", loaded.List.Items[0].Comment)
+	}
+	if !equalStringSlices(loaded.List.Items[1].Answers, []string{"dag", "tot ziens"}) {
+		t.Errorf("Unexpected answers: %v", loaded.List.Items[1].Answers)
+	}
+}
+
+func TestLoadXLSXSheetSelection(t *testing.T) {
+	workbook := excelize.NewFile()
+	workbook.SetSheetRow("Sheet1", "A1", &[]interface{}{"uno", "one"})
+	workbook.NewSheet("Week 2")
+	workbook.SetSheetRow("Week 2", "A1", &[]interface{}{"dos", "two", "number"})
+	workbook.SetSheetRow("Week 2", "A2", &[]interface{}{"tres", "three"})
+
+	testFile := filepath.Join(t.TempDir(), "numbers.xlsx")
+	if err := workbook.SaveAs(testFile); err != nil {
+		t.Fatalf("Failed to create test workbook: %v", err)
+	}
+
+	loader := NewFileLoader()
+
+	first, err := loader.LoadFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to load XLSX file: %v", err)
+	}
+	if len(first.List.Items) != 1 || first.List.Items[0].Questions[0] != "uno" {
+		t.Errorf("Expected the first sheet by default, got %+v", first.List.Items)
+	}
+
+	second, err := loader.LoadXLSXSheet(testFile, "Week 2")
+	if err != nil {
+		t.Fatalf("Failed to load selected sheet: %v", err)
+	}
+	if second.List.Title != "numbers - Week 2" {
+		t.Errorf("Unexpected title %q", second.List.Title)
+	}
+	if len(second.List.Items) != 2 || second.List.Items[0].Comment != "number" {
+		t.Errorf("Unexpected items from selected sheet: %+v", second.List.Items)
+	}
+
+	if _, err := loader.LoadXLSXSheet(testFile, "Missing"); err == nil {
+		t.Error("Expected an error for a missing worksheet")
+	}
+}
+
+func TestLoadXLSXTwoColumnHeader(t *testing.T) {
+	workbook := excelize.NewFile()
+	workbook.SetSheetRow("Sheet1", "A1", &[]interface{}{"Spanish", "English"})
+	workbook.SetSheetRow("Sheet1", "A2", &[]interface{}{"uno", "one"})
+	workbook.NewSheet("Words")
+	workbook.SetSheetRow("Words", "A1", &[]interface{}{"Question", "Answer"})
+	workbook.SetSheetRow("Words", "A2", &[]interface{}{"dos", "two"})
+
+	testFile := filepath.Join(t.TempDir(), "numbers.xlsx")
+	if err := workbook.SaveAs(testFile); err != nil {
+		t.Fatalf("Failed to create test workbook: %v", err)
+	}
+
+	loader := NewFileLoader()
+	languages, err := loader.LoadXLSXSheet(testFile, "Sheet1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(languages.List.Items) != 1 || languages.List.QuestionLanguage != "Spanish" || languages.List.AnswerLanguage != "English" {
+		t.Errorf("Language header not recognized: %q/%q, %+v", languages.List.QuestionLanguage, languages.List.AnswerLanguage, languages.List.Items)
+	}
+	words, err := loader.LoadXLSXSheet(testFile, "Words")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(words.List.Items) != 1 || words.List.QuestionLanguage != "" {
+		t.Errorf("Column name header not recognized: %q, %+v", words.List.QuestionLanguage, words.List.Items)
+	}
+}
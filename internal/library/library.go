@@ -0,0 +1,377 @@
+// Package library indexes every lesson in a directory in SQLite, so a lesson
+// can be found by any word it contains instead of by its file name.
+//
+// The index uses FTS5 when SQLite was built with it (go build -tags
+// sqlite_fts5) and FTS4, which is always available, otherwise.
+package library
+
+import (
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// DirectorySetting is the settings key holding the directory the library
+// indexes
+const DirectorySetting = "library.directory"
+
+// DefaultSearchLimit is how many lessons Search returns when no limit is
+// given
+const DefaultSearchLimit = 50
+
+// schemaVersion is stored as the index's user_version. Indexes made with
+// an older schema are dropped and built again, as they can be recreated
+// from the lesson files any time.
+const schemaVersion = 3
+
+// Result is a lesson found by Search
+type Result struct {
+	Path  string
+	Title string
+	Items int
+	// Author, Level and Tags are the lesson's metadata, when it has any
+	Author string
+	Level  string
+	Tags   []string
+	// Sync is the sync scope of the lesson, telling which devices it is
+	// synced to
+	Sync string
+	// Snippet shows where the lesson matched, with the matching words
+	// between [ and ]
+	Snippet string
+}
+
+// IndexStats tells what Index did
+type IndexStats struct {
+	Added     int
+	Updated   int
+	Removed   int
+	Unchanged int
+	// Failed counts lesson files that could not be loaded
+	Failed int
+}
+
+// Library is a full-text index of lesson files
+type Library struct {
+	db *sql.DB
+	// fts is the full-text search module of the index, "fts5" or "fts4"
+	fts string
+	// indexed is called for every lesson Index adds or updates
+	indexed func(path string, lessonData *lesson.LessonData)
+}
+
+// Open opens the library index at dbPath, creating it if needed
+func Open(dbPath string) (*Library, error) {
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create library directory: %w", err)
+	}
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open library: %w", err)
+	}
+	l := &Library{db: db}
+	if err := l.createSchema(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// OnIndexed makes Index call handler with every lesson it adds or updates,
+// so derived data such as thumbnails can be kept up to date without loading
+// the lesson again
+func (l *Library) OnIndexed(handler func(path string, lessonData *lesson.LessonData)) {
+	l.indexed = handler
+}
+
+// Close closes the index
+func (l *Library) Close() error {
+	return l.db.Close()
+}
+
+func (l *Library) createSchema() error {
+	var version int
+	if err := l.db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return fmt.Errorf("failed to read library: %w", err)
+	}
+	if version < schemaVersion {
+		for _, table := range []string{"lesson_text", "lessons"} {
+			if _, err := l.db.Exec(`DROP TABLE IF EXISTS ` + table); err != nil {
+				return fmt.Errorf("failed to upgrade library: %w", err)
+			}
+		}
+		if _, err := l.db.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, schemaVersion)); err != nil {
+			return fmt.Errorf("failed to upgrade library: %w", err)
+		}
+	}
+
+	if _, err := l.db.Exec(`CREATE TABLE IF NOT EXISTS lessons (
+		path TEXT PRIMARY KEY,
+		title TEXT NOT NULL,
+		items INTEGER NOT NULL,
+		modified INTEGER NOT NULL,
+		size INTEGER NOT NULL,
+		author TEXT NOT NULL DEFAULT '',
+		level TEXT NOT NULL DEFAULT '',
+		tags TEXT NOT NULL DEFAULT '',
+		sync TEXT NOT NULL DEFAULT '')`); err != nil {
+		return fmt.Errorf("failed to create library: %w", err)
+	}
+
+	// An existing index keeps the module it was created with
+	var schema string
+	err := l.db.QueryRow(`SELECT sql FROM sqlite_master WHERE name = 'lesson_text'`).Scan(&schema)
+	switch {
+	case err == nil:
+		l.fts = "fts4"
+		if strings.Contains(strings.ToLower(schema), "fts5") {
+			l.fts = "fts5"
+		}
+		return nil
+	case err != sql.ErrNoRows:
+		return fmt.Errorf("failed to read library: %w", err)
+	}
+
+	if _, err := l.db.Exec(`CREATE VIRTUAL TABLE lesson_text USING fts5(
+		path UNINDEXED, title, words, meta, tokenize = 'unicode61 remove_diacritics 2')`); err == nil {
+		l.fts = "fts5"
+		return nil
+	}
+	if _, err := l.db.Exec(`CREATE VIRTUAL TABLE lesson_text USING fts4(
+		path, title, words, meta, notindexed=path, tokenize=unicode61)`); err != nil {
+		return fmt.Errorf("failed to create library search index: %w", err)
+	}
+	l.fts = "fts4"
+	return nil
+}
+
+// Index brings the library up to date with the lessons in dir and its
+// subdirectories: new and changed lesson files are (re)indexed and lessons
+// that are gone are dropped. Hidden directories are skipped.
+func (l *Library) Index(dir string) (IndexStats, error) {
+	var stats IndexStats
+	known := make(map[string][2]int64)
+	rows, err := l.db.Query(`SELECT path, modified, size FROM lessons`)
+	if err != nil {
+		return stats, fmt.Errorf("failed to read library: %w", err)
+	}
+	for rows.Next() {
+		var path string
+		var modified, size int64
+		if err := rows.Scan(&path, &modified, &size); err != nil {
+			rows.Close()
+			return stats, fmt.Errorf("failed to read library: %w", err)
+		}
+		known[path] = [2]int64{modified, size}
+	}
+	rows.Close()
+
+	loader := lesson.NewFileLoader()
+	extensions := loader.GetSupportedExtensions()
+	seen := make(map[string]bool)
+	err = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !hasExtension(entry.Name(), extensions) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		seen[path] = true
+		modified, size := info.ModTime().UnixNano(), info.Size()
+		previous, indexed := known[path]
+		if indexed && previous == [2]int64{modified, size} {
+			stats.Unchanged++
+			return nil
+		}
+
+		lessonData, err := loader.LoadFile(path)
+		if err != nil {
+			log.Printf("[WARNING] Library.Index() - skipping %s: %v", path, err)
+			stats.Failed++
+			return nil
+		}
+		if err := l.put(path, modified, size, lessonData); err != nil {
+			return err
+		}
+		if l.indexed != nil {
+			l.indexed(path, lessonData)
+		}
+		if indexed {
+			stats.Updated++
+		} else {
+			stats.Added++
+		}
+		return nil
+	})
+	if err != nil {
+		return stats, fmt.Errorf("failed to index %s: %w", dir, err)
+	}
+
+	for path := range known {
+		if seen[path] {
+			continue
+		}
+		if err := l.remove(path); err != nil {
+			return stats, err
+		}
+		stats.Removed++
+	}
+	return stats, nil
+}
+
+// put stores a lesson in the index, replacing an older version
+func (l *Library) put(path string, modified, size int64, lessonData *lesson.LessonData) error {
+	title := lessonData.List.Title
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	tx, err := l.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to update library: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM lesson_text WHERE path = ?`, path); err != nil {
+		return fmt.Errorf("failed to update library: %w", err)
+	}
+	list := lessonData.List
+	if _, err := tx.Exec(`INSERT OR REPLACE INTO lessons (path, title, items, modified, size, author, level, tags, sync) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		path, title, len(list.Items), modified, size, list.Author, list.Level, strings.Join(list.Tags, ", "), list.Sync); err != nil {
+		return fmt.Errorf("failed to update library: %w", err)
+	}
+	if _, err := tx.Exec(`INSERT INTO lesson_text (path, title, words, meta) VALUES (?, ?, ?, ?)`,
+		path, title, lessonWords(lessonData), list.MetadataText()); err != nil {
+		return fmt.Errorf("failed to update library: %w", err)
+	}
+	return tx.Commit()
+}
+
+// remove drops a lesson from the index
+func (l *Library) remove(path string) error {
+	tx, err := l.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to update library: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM lesson_text WHERE path = ?`, path); err != nil {
+		return fmt.Errorf("failed to update library: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM lessons WHERE path = ?`, path); err != nil {
+		return fmt.Errorf("failed to update library: %w", err)
+	}
+	return tx.Commit()
+}
+
+// Count returns the number of indexed lessons
+func (l *Library) Count() (int, error) {
+	var count int
+	if err := l.db.QueryRow(`SELECT COUNT(*) FROM lessons`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to read library: %w", err)
+	}
+	return count, nil
+}
+
+// Search returns the lessons containing all words of query, each also
+// matching longer words it is the start of, best matches first. The words
+// may be in the lesson's items, title, or metadata such as its tags, author
+// and CEFR level. limit 0 means DefaultSearchLimit.
+func (l *Library) Search(query string, limit int) ([]Result, error) {
+	match := matchExpression(query)
+	if match == "" {
+		return nil, nil
+	}
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+
+	// The snippet is taken from whichever column matched best
+	statement := `SELECT lessons.path, lessons.title, lessons.items,
+		lessons.author, lessons.level, lessons.tags, lessons.sync,
+		snippet(lesson_text, -1, '[', ']', '…', 10)
+		FROM lesson_text JOIN lessons ON lessons.path = lesson_text.path
+		WHERE lesson_text MATCH ? ORDER BY rank LIMIT ?`
+	if l.fts == "fts4" {
+		// FTS4 has no ranking of its own
+		statement = `SELECT lessons.path, lessons.title, lessons.items,
+			lessons.author, lessons.level, lessons.tags, lessons.sync,
+			snippet(lesson_text, '[', ']', '…', -1, 10)
+			FROM lesson_text JOIN lessons ON lessons.path = lesson_text.path
+			WHERE lesson_text MATCH ? ORDER BY lessons.title LIMIT ?`
+	}
+	rows, err := l.db.Query(statement, match, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search library: %w", err)
+	}
+	defer rows.Close()
+
+	var results []Result
+	for rows.Next() {
+		var result Result
+		var tags string
+		if err := rows.Scan(&result.Path, &result.Title, &result.Items,
+			&result.Author, &result.Level, &tags, &result.Sync, &result.Snippet); err != nil {
+			return nil, fmt.Errorf("failed to search library: %w", err)
+		}
+		result.Tags = lesson.ParseTags(tags)
+		result.Snippet = strings.Join(strings.Fields(result.Snippet), " ")
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// matchExpression turns what the user typed into a full-text query that
+// cannot fail to parse: every word becomes a prefix search. Words are
+// lowercased so "OR" and "NOT" are not taken for operators.
+func matchExpression(query string) string {
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = strings.ToLower(word) + "*"
+	}
+	return strings.Join(terms, " ")
+}
+
+// lessonWords returns the searchable text of a lesson, one item per line
+func lessonWords(lessonData *lesson.LessonData) string {
+	var b strings.Builder
+	for _, item := range lessonData.List.Items {
+		b.WriteString(strings.Join(item.Questions, ", "))
+		b.WriteString(" = ")
+		b.WriteString(strings.Join(item.Answers, ", "))
+		if item.Comment != "" {
+			b.WriteString(" (" + item.Comment + ")")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func hasExtension(name string, extensions []string) bool {
+	lower := strings.ToLower(name)
+	for _, ext := range extensions {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
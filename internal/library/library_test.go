@@ -0,0 +1,155 @@
+package library
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeLesson(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIndexAndSearch(t *testing.T) {
+	dir := t.TempDir()
+	lessons := filepath.Join(dir, "lessons")
+	animals := filepath.Join(lessons, "animals.csv")
+	writeLesson(t, animals, "house,huis\nelephant,olifant\n")
+	writeLesson(t, filepath.Join(lessons, "food", "fruit.csv"), "apple,appel\npear,peer\n")
+	writeLesson(t, filepath.Join(lessons, ".hidden", "secret.csv"), "elephant,olifant\n")
+	writeLesson(t, filepath.Join(lessons, "notes.unknown"), "elephant")
+
+	l, err := Open(filepath.Join(dir, "library.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer l.Close()
+
+	stats, err := l.Index(lessons)
+	if err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+	if stats.Added != 2 || stats.Failed != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	results, err := l.Search("olif", 0)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 1 || results[0].Path != animals || !strings.HasPrefix(results[0].Title, "animals") || results[0].Items != 2 {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	if !strings.Contains(results[0].Snippet, "[olifant]") {
+		t.Errorf("snippet %q does not mark the match", results[0].Snippet)
+	}
+
+	// All words must match, and syntax characters are ignored
+	if results, _ := l.Search(`apple "house`, 0); len(results) != 0 {
+		t.Errorf("expected no lesson with both words, got %+v", results)
+	}
+	if results, _ := l.Search("elephant OR", 0); len(results) != 0 {
+		t.Errorf("expected OR to be searched as a word, got %+v", results)
+	}
+	if results, _ := l.Search("", 0); results != nil {
+		t.Errorf("expected no results for an empty query, got %+v", results)
+	}
+
+	// A second run only indexes what changed
+	writeLesson(t, animals, "house,huis\ndog,hond\n")
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(animals, later, later)
+	os.Remove(filepath.Join(lessons, "food", "fruit.csv"))
+	stats, err = l.Index(lessons)
+	if err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+	if stats.Updated != 1 || stats.Removed != 1 || stats.Added != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	if results, _ := l.Search("olifant", 0); len(results) != 0 {
+		t.Errorf("expected the old words to be gone, got %+v", results)
+	}
+	if results, _ := l.Search("hond", 0); len(results) != 1 {
+		t.Errorf("expected the new words to be found, got %+v", results)
+	}
+	if count, _ := l.Count(); count != 1 {
+		t.Errorf("expected 1 lesson, got %d", count)
+	}
+}
+
+func TestReopenKeepsIndex(t *testing.T) {
+	dir := t.TempDir()
+	writeLesson(t, filepath.Join(dir, "lessons", "a.csv"), "one,een\n")
+	dbPath := filepath.Join(dir, "library.db")
+	l, err := Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Index(filepath.Join(dir, "lessons")); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+
+	l, err = Open(dbPath)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer l.Close()
+	if results, err := l.Search("een", 0); err != nil || len(results) != 1 {
+		t.Errorf("expected the lesson after reopening, got %+v, %v", results, err)
+	}
+}
+
+func TestSearchMetadata(t *testing.T) {
+	dir := t.TempDir()
+	lessons := filepath.Join(dir, "lessons")
+	verbs := filepath.Join(lessons, "verbs.json")
+	writeLesson(t, verbs, `{"list": {"title": "Verbs", "items": [{"id": 0, "questions": ["to be"], "answers": ["être"]}],
+		"tags": ["irregular", "week 3"], "author": "Ms. Janssen", "license": "CC BY-SA 4.0", "level": "A2", "sync": "desktop"}}`)
+	writeLesson(t, filepath.Join(lessons, "fruit.csv"), "apple,appel\n")
+
+	dbPath := filepath.Join(dir, "library.db")
+	// An index made before metadata was indexed is built again
+	old, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := old.Exec(`CREATE TABLE lessons (path TEXT PRIMARY KEY, title TEXT NOT NULL, items INTEGER NOT NULL,
+		modified INTEGER NOT NULL, size INTEGER NOT NULL)`); err != nil {
+		t.Fatal(err)
+	}
+	old.Close()
+
+	l, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer l.Close()
+	if _, err := l.Index(lessons); err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+
+	for _, query := range []string{"irregular", "janssen", "a2", "week 3"} {
+		results, err := l.Search(query, 0)
+		if err != nil {
+			t.Fatalf("Search(%q): %v", query, err)
+		}
+		if len(results) != 1 || results[0].Path != verbs {
+			t.Errorf("Search(%q) = %+v, want the verbs lesson", query, results)
+			continue
+		}
+		if results[0].Author != "Ms. Janssen" || results[0].Level != "A2" || len(results[0].Tags) != 2 || results[0].Sync != "desktop" {
+			t.Errorf("Search(%q) returned metadata %+v", query, results[0])
+		}
+	}
+}
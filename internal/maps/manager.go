@@ -2,6 +2,7 @@
 package maps
 
 import (
+	"context"
 	"embed"
 	"encoding/json"
 	"fmt"
@@ -498,6 +499,16 @@ func (mm *MapManager) DownloadTilesForRegion(tileMapID string, north, south, eas
 	return mm.tileManager.DownloadTilesForRegion(tileMapID, north, south, east, west, zoom)
 }
 
+// DownloadTilesForRegionContext downloads tiles for a region and caches
+// them, stopping once ctx is done
+func (mm *MapManager) DownloadTilesForRegionContext(ctx context.Context, tileMapID string, north, south, east, west float64, zoom int) error {
+	if mm.tileManager == nil {
+		return fmt.Errorf("tile manager not initialized")
+	}
+
+	return mm.tileManager.DownloadTilesForRegionContext(ctx, tileMapID, north, south, east, west, zoom)
+}
+
 // GetAvailableTileMaps returns all available tile map configurations
 func (mm *MapManager) GetAvailableTileMaps() []*TileMap {
 	if mm.tileManager == nil {
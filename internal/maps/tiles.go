@@ -2,6 +2,7 @@
 package maps
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,9 +13,16 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/paths"
 )
 
+// TypicalTileSize is about the size in bytes of a downloaded map tile, to
+// estimate how much downloading a region takes
+const TypicalTileSize = 20 << 10
+
 // TileMapConfig represents configuration for a tile-based map
 type TileMapConfig struct {
 	ID          string   `json:"id"`
@@ -83,10 +91,11 @@ type TileManager struct {
 	mutex      sync.RWMutex
 }
 
-// NewTileManager creates a new tile manager
+// NewTileManager creates a new tile manager reading its configuration below
+// basePath. Downloaded tiles are kept in the user's cache directory, which
+// is only created once a tile is stored.
 func NewTileManager(basePath string) *TileManager {
-	cacheDir := filepath.Join(basePath, "cache", "tiles")
-	os.MkdirAll(cacheDir, 0755)
+	cacheDir := filepath.Join(paths.CacheDir(), "tiles")
 
 	return &TileManager{
 		basePath:   basePath,
@@ -576,6 +585,14 @@ func (tm *TileManager) CreateTileMap(config TileMapConfig, north, south, east, w
 
 // DownloadTilesForRegion downloads all tiles for a specific region and zoom level
 func (tm *TileManager) DownloadTilesForRegion(mapID string, north, south, east, west float64, zoom int) error {
+	return tm.DownloadTilesForRegionContext(context.Background(), mapID, north, south, east, west, zoom)
+}
+
+// DownloadTilesForRegionContext downloads the tiles of a region like
+// DownloadTilesForRegion, stopping once ctx is done. Cached tiles are not
+// downloaded again, so calling it again after it stopped or failed goes on
+// where it left off. It fails when any tile could not be downloaded.
+func (tm *TileManager) DownloadTilesForRegionContext(ctx context.Context, mapID string, north, south, east, west float64, zoom int) error {
 	tileMap, err := tm.GetTileMap(mapID)
 	if err != nil {
 		return err
@@ -588,6 +605,7 @@ func (tm *TileManager) DownloadTilesForRegion(mapID string, north, south, east,
 	// Download tiles concurrently (with rate limiting)
 	semaphore := make(chan struct{}, 5) // Limit to 5 concurrent downloads
 	var wg sync.WaitGroup
+	var failed atomic.Int64
 
 	for i, tile := range tiles {
 		wg.Add(1)
@@ -595,8 +613,12 @@ func (tm *TileManager) DownloadTilesForRegion(mapID string, north, south, east,
 			defer wg.Done()
 			semaphore <- struct{}{}        // Acquire
 			defer func() { <-semaphore }() // Release
+			if ctx.Err() != nil {
+				return
+			}
 
 			if _, err := tileMap.DownloadTile(tile); err != nil {
+				failed.Add(1)
 				fmt.Printf("Failed to download tile %d/%d (%d,%d,%d): %v\n", index+1, len(tiles), tile.Z, tile.X, tile.Y, err)
 			} else if (index+1)%10 == 0 {
 				fmt.Printf("Downloaded %d/%d tiles\n", index+1, len(tiles))
@@ -605,11 +627,28 @@ func (tm *TileManager) DownloadTilesForRegion(mapID string, north, south, east,
 	}
 
 	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if n := failed.Load(); n > 0 {
+		return fmt.Errorf("failed to download %d of %d tiles", n, len(tiles))
+	}
 	fmt.Printf("Download complete for %s\n", mapID)
 
 	return nil
 }
 
+// EstimateRegionSize returns how many tiles downloading a region takes and
+// about how many bytes they are
+func (tm *TileManager) EstimateRegionSize(mapID string, north, south, east, west float64, zoom int) (int, int64, error) {
+	tileMap, err := tm.GetTileMap(mapID)
+	if err != nil {
+		return 0, 0, err
+	}
+	tiles := len(tileMap.GetTilesForBounds(north, south, east, west, zoom))
+	return tiles, int64(tiles) * TypicalTileSize, nil
+}
+
 // GetCacheStats returns cache statistics for the tile manager
 func (tm *TileManager) GetCacheStats() (hits, misses, errors int64) {
 	return tm.cache.GetCacheStats()
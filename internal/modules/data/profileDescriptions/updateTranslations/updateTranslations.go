@@ -1,38 +1,58 @@
 // Package updatetranslations provides functionality ported from Python module
+// legacy/modules/org/openteacher/data/profileDescriptions/updateTranslations/updateTranslations.py
 //
-// This is an automated port - implementation may be incomplete.
+// It describes the update-translations profile, which regenerates the .pot
+// template and merges it into the .po catalogs of every language.
 package updatetranslations
 
 import (
 	"context"
 	"fmt"
+
 	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/modules/data/profiledescriptions"
 )
 
 // ProfileDescriptionModule is a Go port of the Python ProfileDescriptionModule class
 type ProfileDescriptionModule struct {
 	*core.BaseModule
 	manager *core.Manager
-	// TODO: Add module-specific fields
+	desc    *profiledescriptions.ProfileDescription
 }
 
 // NewProfileDescriptionModule creates a new ProfileDescriptionModule instance
 func NewProfileDescriptionModule() *ProfileDescriptionModule {
-	base := core.NewBaseModule("data", "updatetranslations-module")
+	base := core.NewBaseModule("profileDescription", "updatetranslations-module")
 
 	return &ProfileDescriptionModule{
 		BaseModule: base,
 	}
 }
 
-// Enable activates the module
-// This is the Go equivalent of the Python enable method
+// Description returns the profile description, nil while the module is
+// inactive
+func (mod *ProfileDescriptionModule) Description() *profiledescriptions.ProfileDescription {
+	return mod.desc
+}
+
+// Enable activates the module when there is a translation updater to run
+// the profile
 func (mod *ProfileDescriptionModule) Enable(ctx context.Context) error {
+	if mod.manager != nil && len(mod.manager.GetModulesByType("translation-updater")) == 0 {
+		// remain inactive, like the Python version
+		fmt.Println("UpdateTranslations profile description: no translation-updater module found, remaining inactive")
+		return nil
+	}
+
 	if err := mod.BaseModule.Enable(ctx); err != nil {
 		return err
 	}
 
-	// TODO: Port Python enable logic
+	mod.desc = &profiledescriptions.ProfileDescription{
+		Name:     "update-translations",
+		NiceName: "Updater for all .pot and .po files.",
+		Advanced: true,
+	}
 
 	fmt.Println("ProfileDescriptionModule enabled")
 	return nil
@@ -45,7 +65,7 @@ func (mod *ProfileDescriptionModule) Disable(ctx context.Context) error {
 		return err
 	}
 
-	// TODO: Port Python disable logic
+	mod.desc = nil
 
 	fmt.Println("ProfileDescriptionModule disabled")
 	return nil
@@ -60,4 +80,4 @@ func (mod *ProfileDescriptionModule) SetManager(manager *core.Manager) {
 // This is the Go equivalent of the Python init function
 func InitProfileDescriptionModule() core.Module {
 	return NewProfileDescriptionModule()
-}
\ No newline at end of file
+}
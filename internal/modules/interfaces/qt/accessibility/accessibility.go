@@ -0,0 +1,288 @@
+// Package accessibility gives the controls of the lesson widgets names and
+// descriptions screen readers announce, and audits a window for controls
+// left without a name or out of reach of the Tab key.
+package accessibility
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"unsafe"
+
+	"github.com/LaPingvino/recuerdo/internal/accessibility"
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/mappu/miqt/qt"
+)
+
+// maxFocusChain bounds the walk along the focus chain, which Qt keeps as a
+// ring but which is not guaranteed to lead back to where the walk started
+const maxFocusChain = 10000
+
+// AccessibilityModule labels widgets for screen readers and audits them
+type AccessibilityModule struct {
+	*core.BaseModule
+	manager *core.Manager
+}
+
+// NewAccessibilityModule creates a new AccessibilityModule instance
+func NewAccessibilityModule() *AccessibilityModule {
+	base := core.NewBaseModule("accessibility", "accessibility-module")
+
+	return &AccessibilityModule{
+		BaseModule: base,
+	}
+}
+
+// Label gives the controls below root without an accessible name one,
+// taken from their own text, the label they are the buddy of, a label
+// ending in a colon right before them, their placeholder text, tool tip or
+// the group box they are in. Tool tips become the description. It returns
+// the number of controls named.
+func (mod *AccessibilityModule) Label(root *qt.QWidget) int {
+	buddies := map[unsafe.Pointer]string{}
+	eachWidget(root, func(widget *qt.QWidget, _ string) {
+		if widget.QObject.Inherits("QLabel") {
+			label := qt.UnsafeNewQLabel(widget.UnsafePointer())
+			if buddy := label.Buddy(); buddy != nil {
+				buddies[buddy.UnsafePointer()] = label.Text()
+			}
+		}
+	})
+
+	named := 0
+	eachWidget(root, func(widget *qt.QWidget, group string) {
+		if !role(widget).Interactive() {
+			return
+		}
+		if widget.AccessibleName() == "" {
+			candidates := []string{
+				ownText(widget),
+				buddies[widget.UnsafePointer()],
+				precedingLabel(widget),
+				placeholder(widget),
+				widget.ToolTip(),
+				group,
+			}
+			for _, candidate := range candidates {
+				if name := accessibility.CleanLabel(candidate); name != "" {
+					widget.SetAccessibleName(name)
+					named++
+					break
+				}
+			}
+		}
+		if widget.AccessibleDescription() == "" {
+			for _, description := range []string{widget.ToolTip(), widget.WhatsThis()} {
+				if description != "" && description != widget.AccessibleName() {
+					widget.SetAccessibleDescription(description)
+					break
+				}
+			}
+		}
+	})
+	return named
+}
+
+// Snapshot describes the widgets below root for the audit
+func (mod *AccessibilityModule) Snapshot(root *qt.QWidget) *accessibility.Node {
+	order := map[unsafe.Pointer]int{}
+	next := root.NextInFocusChain()
+	for i := 0; next != nil && next.UnsafePointer() != root.UnsafePointer() && i < maxFocusChain; i++ {
+		if next.FocusPolicy()&qt.TabFocus != 0 {
+			if _, seen := order[next.UnsafePointer()]; seen {
+				break
+			}
+			order[next.UnsafePointer()] = len(order)
+		}
+		next = next.NextInFocusChain()
+	}
+	return snapshot(root, true, order)
+}
+
+func snapshot(widget *qt.QWidget, visible bool, order map[unsafe.Pointer]int) *accessibility.Node {
+	node := &accessibility.Node{
+		Class:       widget.MetaObject().ClassName(),
+		ObjectName:  widget.ObjectName(),
+		Role:        role(widget),
+		Name:        widget.AccessibleName(),
+		Description: widget.AccessibleDescription(),
+		Visible:     visible,
+		Enabled:     widget.IsEnabled(),
+		TabFocus:    widget.FocusPolicy()&qt.TabFocus != 0,
+		FocusOrder:  -1,
+	}
+	if position, ok := order[widget.UnsafePointer()]; ok {
+		node.FocusOrder = position
+	}
+	// Tab bars announce the titles of their tabs
+	if node.Role == accessibility.RoleTabs && node.Name == "" {
+		node.Name = ownText(widget)
+	}
+	// Pages of a tab widget not shown are hidden by it, not by the user
+	stacked := widget.QObject.Inherits("QStackedWidget")
+	for _, child := range childWidgets(widget) {
+		node.Children = append(node.Children, snapshot(child, stacked || !child.IsHidden(), order))
+	}
+	return node
+}
+
+// Audit labels the widgets below root and returns the problems left
+func (mod *AccessibilityModule) Audit(root *qt.QWidget) []accessibility.Finding {
+	mod.Label(root)
+	return accessibility.Audit(mod.Snapshot(root))
+}
+
+// Report labels the widgets below root, writes the problems left and the
+// Tab order to w, and returns the number of problems
+func (mod *AccessibilityModule) Report(w io.Writer, root *qt.QWidget) int {
+	mod.Label(root)
+	return accessibility.Report(w, mod.Snapshot(root))
+}
+
+// role returns what a widget does, from its class
+func role(widget *qt.QWidget) accessibility.Role {
+	object := widget.QObject
+	switch {
+	case object.Inherits("QCheckBox"), object.Inherits("QRadioButton"):
+		return accessibility.RoleCheck
+	case object.Inherits("QAbstractButton"):
+		return accessibility.RoleButton
+	case object.Inherits("QLineEdit"), object.Inherits("QTextEdit"), object.Inherits("QPlainTextEdit"), object.Inherits("QAbstractSpinBox"):
+		return accessibility.RoleText
+	case object.Inherits("QComboBox"):
+		return accessibility.RoleCombo
+	case object.Inherits("QScrollBar"):
+		// Scroll bars belong to the area they scroll
+		return accessibility.RoleOther
+	case object.Inherits("QAbstractSlider"):
+		return accessibility.RoleSlider
+	case object.Inherits("QAbstractItemView"):
+		return accessibility.RoleList
+	case object.Inherits("QTabBar"):
+		return accessibility.RoleTabs
+	case object.Inherits("QLabel"):
+		return accessibility.RoleLabel
+	case object.Inherits("QGroupBox"):
+		return accessibility.RoleGroup
+	}
+	return accessibility.RoleOther
+}
+
+// ownText returns the text a control shows itself
+func ownText(widget *qt.QWidget) string {
+	object := widget.QObject
+	switch {
+	case object.Inherits("QAbstractButton"):
+		return qt.UnsafeNewQAbstractButton(widget.UnsafePointer()).Text()
+	case object.Inherits("QTabBar"):
+		bar := qt.UnsafeNewQTabBar(widget.UnsafePointer())
+		var titles []string
+		for i := 0; i < bar.Count(); i++ {
+			titles = append(titles, accessibility.CleanLabel(bar.TabText(i)))
+		}
+		return strings.Join(titles, ", ")
+	}
+	return ""
+}
+
+// placeholder returns the grey text an empty field shows
+func placeholder(widget *qt.QWidget) string {
+	object := widget.QObject
+	switch {
+	case object.Inherits("QLineEdit"):
+		return qt.UnsafeNewQLineEdit(widget.UnsafePointer()).PlaceholderText()
+	case object.Inherits("QTextEdit"):
+		return qt.UnsafeNewQTextEdit(widget.UnsafePointer()).PlaceholderText()
+	case object.Inherits("QComboBox"):
+		return qt.UnsafeNewQComboBox(widget.UnsafePointer()).PlaceholderText()
+	}
+	return ""
+}
+
+// precedingLabel returns the text of the label created right before a
+// control when it ends in a colon, as in "Your Answer:"
+func precedingLabel(widget *qt.QWidget) string {
+	parent := widget.ParentWidget()
+	if parent == nil {
+		return ""
+	}
+	var previous *qt.QWidget
+	for _, sibling := range childWidgets(parent) {
+		if sibling.UnsafePointer() == widget.UnsafePointer() {
+			break
+		}
+		previous = sibling
+	}
+	if previous == nil || !previous.QObject.Inherits("QLabel") {
+		return ""
+	}
+	label := qt.UnsafeNewQLabel(previous.UnsafePointer())
+	if text := strings.TrimSpace(label.Text()); strings.HasSuffix(text, ":") && label.Buddy() == nil {
+		return text
+	}
+	return ""
+}
+
+// childWidgets returns the widgets directly below widget, leaving out
+// dialogs and other windows of their own
+func childWidgets(widget *qt.QWidget) []*qt.QWidget {
+	var children []*qt.QWidget
+	for _, child := range widget.QObject.Children() {
+		if !child.IsWidgetType() {
+			continue
+		}
+		childWidget := qt.UnsafeNewQWidget(child.UnsafePointer())
+		if !childWidget.IsWindow() {
+			children = append(children, childWidget)
+		}
+	}
+	return children
+}
+
+// eachWidget calls visit for root and the widgets below it, with the title
+// of the group box each is in
+func eachWidget(root *qt.QWidget, visit func(widget *qt.QWidget, group string)) {
+	var walk func(widget *qt.QWidget, group string)
+	walk = func(widget *qt.QWidget, group string) {
+		visit(widget, group)
+		if widget.QObject.Inherits("QGroupBox") {
+			group = qt.UnsafeNewQGroupBox(widget.UnsafePointer()).Title()
+		}
+		for _, child := range childWidgets(widget) {
+			walk(child, group)
+		}
+	}
+	walk(root, "")
+}
+
+// Enable activates the module
+func (mod *AccessibilityModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("AccessibilityModule enabled")
+	return nil
+}
+
+// Disable deactivates the module
+func (mod *AccessibilityModule) Disable(ctx context.Context) error {
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("AccessibilityModule disabled")
+	return nil
+}
+
+// SetManager sets the module manager
+func (mod *AccessibilityModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// InitAccessibilityModule creates and returns a new AccessibilityModule
+// instance
+func InitAccessibilityModule() core.Module {
+	return NewAccessibilityModule()
+}
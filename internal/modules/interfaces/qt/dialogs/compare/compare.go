@@ -0,0 +1,320 @@
+// Package compare provides a dialog showing two lessons side by side, for
+// comparing old and new course material and copying items between them.
+package compare
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/mappu/miqt/qt"
+)
+
+// CompareDialogModule shows two lessons in a split view
+type CompareDialogModule struct {
+	*core.BaseModule
+	manager *core.Manager
+}
+
+// NewCompareDialogModule creates a new CompareDialogModule instance
+func NewCompareDialogModule() *CompareDialogModule {
+	base := core.NewBaseModule("compareDialog", "compare-dialog-module")
+	base.SetRequires("qtApp")
+
+	return &CompareDialogModule{
+		BaseModule: base,
+	}
+}
+
+// fileDialog is the part of the file dialog module used to pick lessons
+type fileDialog interface {
+	OpenFile(parent interface{}, title string, filter string) string
+	SaveFile(parent *qt.QWidget, title string, filter string, defaultName string) string
+}
+
+// ShowCompareDialog opens an empty split view; both sides are opened from
+// the dialog itself
+func (mod *CompareDialogModule) ShowCompareDialog(parent *qt.QWidget) {
+	dialog := qt.NewQDialog(parent)
+	dialog.SetWindowTitle("Compare Lessons")
+	dialog.SetAttribute(qt.WA_DeleteOnClose)
+	dialog.Resize(1000, 600)
+
+	view := &compareView{module: mod, dialog: dialog}
+	view.left = view.newPane("Left")
+	view.right = view.newPane("Right")
+	view.left.other, view.right.other = view.right, view.left
+
+	legend := qt.NewQLabel3("Grey: in both lessons    Yellow: same question, other answers    Drag items or use the copy buttons to copy them across")
+	legend.SetWordWrap(true)
+
+	copyRight := qt.NewQPushButton3("Copy →")
+	copyRight.SetToolTip("Copy the selected items of the left lesson to the right one")
+	copyRight.OnClicked(func() { view.copySelected(view.left, view.right) })
+	copyLeft := qt.NewQPushButton3("← Copy")
+	copyLeft.SetToolTip("Copy the selected items of the right lesson to the left one")
+	copyLeft.OnClicked(func() { view.copySelected(view.right, view.left) })
+
+	buttons := qt.NewQVBoxLayout2()
+	buttons.AddStretch()
+	buttons.AddWidget(copyRight.QWidget)
+	buttons.AddWidget(copyLeft.QWidget)
+	buttons.AddStretch()
+
+	panes := qt.NewQHBoxLayout2()
+	panes.AddLayout(view.left.layout.QLayout)
+	panes.AddLayout(buttons.QLayout)
+	panes.AddLayout(view.right.layout.QLayout)
+
+	layout := qt.NewQVBoxLayout(dialog.QWidget)
+	layout.AddLayout(panes.QLayout)
+	layout.AddWidget(legend.QWidget)
+
+	dialog.Show()
+}
+
+// compareView holds the two panes of one compare dialog
+type compareView struct {
+	module *CompareDialogModule
+	dialog *qt.QDialog
+	left   *comparePane
+	right  *comparePane
+}
+
+// comparePane is one side of the split view
+type comparePane struct {
+	view   *compareView
+	other  *comparePane
+	name   string
+	path   string
+	lesson *lesson.LessonData
+	layout *qt.QVBoxLayout
+	label  *qt.QLabel
+	table  *qt.QTableWidget
+	save   *qt.QPushButton
+}
+
+func (view *compareView) newPane(name string) *comparePane {
+	pane := &comparePane{view: view, name: name}
+
+	open := qt.NewQPushButton3("Open...")
+	open.OnClicked(pane.open)
+	pane.save = qt.NewQPushButton3("Save As...")
+	pane.save.SetEnabled(false)
+	pane.save.OnClicked(pane.saveAs)
+	pane.label = qt.NewQLabel3(name + ": no lesson opened")
+
+	top := qt.NewQHBoxLayout2()
+	top.AddWidget(open.QWidget)
+	top.AddWidget(pane.label.QWidget)
+	top.AddStretch()
+	top.AddWidget(pane.save.QWidget)
+
+	pane.table = qt.NewQTableWidget(view.dialog.QWidget)
+	pane.table.SetColumnCount(3)
+	pane.table.SetHorizontalHeaderLabels([]string{"Questions", "Answers", "Comment"})
+	pane.table.HorizontalHeader().SetStretchLastSection(true)
+	pane.table.SetSelectionBehavior(qt.QAbstractItemView__SelectRows)
+	pane.table.SetSelectionMode(qt.QAbstractItemView__ExtendedSelection)
+	pane.table.SetEditTriggers(qt.QAbstractItemView__NoEditTriggers)
+	pane.table.SetDragDropMode(qt.QAbstractItemView__DragDrop)
+	pane.table.SetDefaultDropAction(qt.CopyAction)
+	// Items dropped from the other pane are copied with their test
+	// results instead of as bare table cells
+	pane.table.OnDropEvent(func(super func(event *qt.QDropEvent), event *qt.QDropEvent) {
+		source := event.Source()
+		if source == nil || source.UnsafePointer() != pane.other.table.QObject.UnsafePointer() {
+			event.Ignore()
+			return
+		}
+		view.copySelected(pane.other, pane)
+		event.SetDropAction(qt.CopyAction)
+		event.Accept()
+	})
+
+	pane.layout = qt.NewQVBoxLayout2()
+	pane.layout.AddLayout(top.QLayout)
+	pane.layout.AddWidget(pane.table.QWidget)
+	return pane
+}
+
+// open asks for a lesson file and shows it in the pane
+func (pane *comparePane) open() {
+	parent := pane.view.dialog.QWidget
+	var path string
+	if dialog, ok := pane.view.fileDialog(); ok {
+		path = dialog.OpenFile(parent, "Open "+strings.ToLower(pane.name)+" lesson", "")
+	} else {
+		path = qt.QFileDialog_GetOpenFileName4(parent, "Open "+strings.ToLower(pane.name)+" lesson", "", "All Files (*)")
+	}
+	if path == "" {
+		return
+	}
+
+	lessonData, err := lesson.NewFileLoader().LoadFile(path)
+	if err != nil {
+		qt.QMessageBox_Warning(parent, "Compare Lessons", fmt.Sprintf("Could not open %s: %v", filepath.Base(path), err))
+		return
+	}
+	pane.path, pane.lesson = path, lessonData
+	pane.save.SetEnabled(true)
+	pane.view.refresh()
+}
+
+// saveAs writes the lesson of the pane, with any items copied into it
+func (pane *comparePane) saveAs() {
+	if pane.lesson == nil {
+		return
+	}
+	parent := pane.view.dialog.QWidget
+	var path string
+	if dialog, ok := pane.view.fileDialog(); ok {
+		path = dialog.SaveFile(parent, "Save "+strings.ToLower(pane.name)+" lesson", "", filepath.Base(pane.path))
+	} else {
+		path = qt.QFileDialog_GetSaveFileName4(parent, "Save "+strings.ToLower(pane.name)+" lesson", pane.path, "All Files (*)")
+	}
+	if path == "" {
+		return
+	}
+
+	if err := lesson.NewFileSaver().SaveFile(pane.lesson, path); err != nil {
+		qt.QMessageBox_Warning(parent, "Compare Lessons", fmt.Sprintf("Could not save %s: %v", filepath.Base(path), err))
+		return
+	}
+	pane.path = path
+	pane.lesson.Changed = false
+	pane.view.refresh()
+}
+
+// selectedRows returns the selected item indexes, in table order
+func (pane *comparePane) selectedRows() []int {
+	seen := make(map[int]bool)
+	var rows []int
+	for _, item := range pane.table.SelectedItems() {
+		if row := item.Row(); !seen[row] {
+			seen[row] = true
+			rows = append(rows, row)
+		}
+	}
+	sort.Ints(rows)
+	return rows
+}
+
+func (view *compareView) fileDialog() (fileDialog, bool) {
+	if view.module.manager == nil {
+		return nil, false
+	}
+	modules := view.module.manager.GetModulesByType("fileDialog")
+	if len(modules) == 0 {
+		return nil, false
+	}
+	dialog, ok := modules[0].(fileDialog)
+	return dialog, ok
+}
+
+// copySelected copies the items selected in from to the lesson of to
+func (view *compareView) copySelected(from, to *comparePane) {
+	if from.lesson == nil || to.lesson == nil {
+		return
+	}
+	rows := from.selectedRows()
+	if len(rows) == 0 {
+		return
+	}
+	copied := lesson.CopyItems(to.lesson, from.lesson, rows)
+	view.refresh()
+
+	to.table.ClearSelection()
+	for _, row := range copied {
+		to.table.SelectRow(row)
+	}
+	if len(copied) > 0 {
+		to.table.ScrollToItem(to.table.Item(copied[len(copied)-1], 0))
+	}
+}
+
+// refresh fills both tables and colours the items the lessons share
+func (view *compareView) refresh() {
+	var leftSame, leftChanged, rightSame, rightChanged map[int]int
+	if view.left.lesson != nil && view.right.lesson != nil {
+		comparison := lesson.CompareLessons(view.left.lesson, view.right.lesson)
+		leftSame, leftChanged = comparison.Same, comparison.Changed
+		rightSame, rightChanged = comparison.RightSame(), comparison.RightChanged()
+	}
+	view.left.fill(leftSame, leftChanged)
+	view.right.fill(rightSame, rightChanged)
+}
+
+func (pane *comparePane) fill(same, changed map[int]int) {
+	pane.table.ClearContents()
+	if pane.lesson == nil {
+		pane.table.SetRowCount(0)
+		return
+	}
+
+	title := pane.lesson.List.Title
+	if title == "" {
+		title = filepath.Base(pane.path)
+	}
+	if pane.lesson.Changed {
+		title += " *"
+	}
+	pane.label.SetText(fmt.Sprintf("%s: %s (%d items, %d shared)", pane.name, title, len(pane.lesson.List.Items), len(same)+len(changed)))
+
+	sameColor := qt.NewQBrush3(qt.NewQColor6("#e0e0e0"))
+	changedColor := qt.NewQBrush3(qt.NewQColor6("#fff2a8"))
+
+	items := pane.lesson.List.Items
+	pane.table.SetRowCount(len(items))
+	for row, item := range items {
+		cells := []string{
+			strings.Join(item.Questions, ", "),
+			lesson.ComposeSynonymGroups(item.SynonymGroups()),
+			item.Comment,
+		}
+		for column, text := range cells {
+			cell := qt.NewQTableWidgetItem2(text)
+			if _, ok := same[row]; ok {
+				cell.SetBackground(sameColor)
+			} else if _, ok := changed[row]; ok {
+				cell.SetBackground(changedColor)
+			}
+			pane.table.SetItem(row, column, cell)
+		}
+	}
+}
+
+// Enable activates the module
+func (mod *CompareDialogModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("CompareDialogModule enabled")
+	return nil
+}
+
+// Disable deactivates the module
+func (mod *CompareDialogModule) Disable(ctx context.Context) error {
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("CompareDialogModule disabled")
+	return nil
+}
+
+// SetManager sets the module manager
+func (mod *CompareDialogModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// InitCompareDialogModule creates and returns a new CompareDialogModule
+// instance
+func InitCompareDialogModule() core.Module {
+	return NewCompareDialogModule()
+}
@@ -0,0 +1,347 @@
+// Package duplicates provides the merge assistant: a dialog that walks
+// through the duplicate items of one or two lessons and merges, keeps or
+// deletes them.
+package duplicates
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/mappu/miqt/qt"
+)
+
+// DuplicatesDialogModule shows the merge assistant
+type DuplicatesDialogModule struct {
+	*core.BaseModule
+	manager *core.Manager
+}
+
+// NewDuplicatesDialogModule creates a new DuplicatesDialogModule instance
+func NewDuplicatesDialogModule() *DuplicatesDialogModule {
+	base := core.NewBaseModule("duplicatesDialog", "duplicates-dialog-module")
+	base.SetRequires("qtApp", "duplicateFinder")
+
+	return &DuplicatesDialogModule{
+		BaseModule: base,
+	}
+}
+
+// finder is the part of the duplicate finder module the dialog uses
+type finder interface {
+	Scan(lessons ...*lesson.LessonData) (*lesson.LessonData, []lesson.DuplicateGroup, error)
+	Resolve(lessonData *lesson.LessonData, resolutions []lesson.DuplicateResolution) *lesson.LessonData
+}
+
+// fileDialog is the part of the file dialog module used to pick lessons
+type fileDialog interface {
+	OpenFiles(parent *qt.QWidget, title string, filter string) []string
+	SaveFile(parent *qt.QWidget, title string, filter string, defaultName string) string
+}
+
+// actionNames are shown in the groups table for every resolution action
+var actionNames = map[string]string{
+	lesson.DuplicateMerge:    "Merge",
+	lesson.DuplicateKeepBoth: "Keep both",
+	lesson.DuplicateDelete:   "Delete others",
+}
+
+// assistant holds the state of one merge assistant dialog
+type assistant struct {
+	module      *DuplicatesDialogModule
+	finder      finder
+	dialog      *qt.QDialog
+	path        string
+	lesson      *lesson.LessonData
+	resolutions []lesson.DuplicateResolution
+	summary     *qt.QLabel
+	groups      *qt.QTableWidget
+	items       *qt.QTableWidget
+	actions     []*qt.QPushButton
+	save        *qt.QPushButton
+}
+
+// ShowDuplicatesDialog asks for one or more lessons, merges them and walks
+// through their duplicates
+func (mod *DuplicatesDialogModule) ShowDuplicatesDialog(parent *qt.QWidget) {
+	var f finder
+	if mod.manager != nil {
+		for _, module := range mod.manager.GetModulesByType("duplicateFinder") {
+			if candidate, ok := module.(finder); ok {
+				f = candidate
+				break
+			}
+		}
+	}
+	if f == nil {
+		qt.QMessageBox_Warning(parent, "Find Duplicates", "The duplicate finder is not available.")
+		return
+	}
+
+	a := &assistant{module: mod, finder: f}
+	if !a.open(parent) {
+		return
+	}
+
+	a.dialog = qt.NewQDialog(parent)
+	a.dialog.SetWindowTitle("Find Duplicates")
+	a.dialog.SetAttribute(qt.WA_DeleteOnClose)
+	a.dialog.Resize(800, 550)
+
+	a.summary = qt.NewQLabel3("")
+	a.summary.SetWordWrap(true)
+
+	a.groups = qt.NewQTableWidget(a.dialog.QWidget)
+	a.groups.SetColumnCount(3)
+	a.groups.SetHorizontalHeaderLabels([]string{"Items", "Kind", "Decision"})
+	a.groups.HorizontalHeader().SetStretchLastSection(true)
+	a.groups.SetSelectionBehavior(qt.QAbstractItemView__SelectRows)
+	a.groups.SetSelectionMode(qt.QAbstractItemView__SingleSelection)
+	a.groups.SetEditTriggers(qt.QAbstractItemView__NoEditTriggers)
+	a.groups.OnItemSelectionChanged(a.showGroup)
+
+	a.items = qt.NewQTableWidget(a.dialog.QWidget)
+	a.items.SetColumnCount(4)
+	a.items.SetHorizontalHeaderLabels([]string{"Questions", "Answers", "Comment", "Results"})
+	a.items.HorizontalHeader().SetStretchLastSection(true)
+	a.items.SetSelectionBehavior(qt.QAbstractItemView__SelectRows)
+	a.items.SetSelectionMode(qt.QAbstractItemView__SingleSelection)
+	a.items.SetEditTriggers(qt.QAbstractItemView__NoEditTriggers)
+
+	help := qt.NewQLabel3("Select the item to keep, then choose what to do with the others. Merging keeps the test results of all items.")
+	help.SetWordWrap(true)
+
+	buttons := qt.NewQHBoxLayout2()
+	for _, action := range []string{lesson.DuplicateMerge, lesson.DuplicateKeepBoth, lesson.DuplicateDelete} {
+		button := qt.NewQPushButton3(actionNames[action])
+		button.OnClicked(func() { a.decide(action) })
+		buttons.AddWidget(button.QWidget)
+		a.actions = append(a.actions, button)
+	}
+	buttons.AddStretch()
+	a.save = qt.NewQPushButton3("Apply and Save As...")
+	a.save.OnClicked(a.apply)
+	buttons.AddWidget(a.save.QWidget)
+	closeButton := qt.NewQPushButton3("Close")
+	closeButton.OnClicked(func() { a.dialog.Close() })
+	buttons.AddWidget(closeButton.QWidget)
+
+	layout := qt.NewQVBoxLayout(a.dialog.QWidget)
+	layout.AddWidget(a.summary.QWidget)
+	layout.AddWidget(a.groups.QWidget)
+	layout.AddWidget(help.QWidget)
+	layout.AddWidget(a.items.QWidget)
+	layout.AddLayout(buttons.QLayout)
+
+	a.fillGroups()
+	if len(a.resolutions) > 0 {
+		a.groups.SelectRow(0)
+	}
+	a.dialog.Show()
+}
+
+// open asks for the lessons to scan; choosing two or more merges them
+func (a *assistant) open(parent *qt.QWidget) bool {
+	var paths []string
+	title := "Open lessons to check for duplicates"
+	if dialog, ok := a.fileDialog(); ok {
+		paths = dialog.OpenFiles(parent, title, "")
+	} else {
+		paths = qt.QFileDialog_GetOpenFileNames4(parent, title, "", "All Files (*)")
+	}
+	if len(paths) == 0 {
+		return false
+	}
+
+	var lessons []*lesson.LessonData
+	for _, path := range paths {
+		lessonData, err := lesson.NewFileLoader().LoadFile(path)
+		if err != nil {
+			qt.QMessageBox_Warning(parent, "Find Duplicates", fmt.Sprintf("Could not open %s: %v", filepath.Base(path), err))
+			return false
+		}
+		lessons = append(lessons, lessonData)
+	}
+
+	merged, groups, err := a.finder.Scan(lessons...)
+	if err != nil {
+		qt.QMessageBox_Warning(parent, "Find Duplicates", fmt.Sprintf("Could not check the lessons: %v", err))
+		return false
+	}
+	if len(groups) == 0 {
+		qt.QMessageBox_Information(parent, "Find Duplicates", "No duplicates found.")
+		return false
+	}
+
+	a.path = paths[0]
+	a.lesson = merged
+	for _, group := range groups {
+		// Exact duplicates are merged unless the user says otherwise;
+		// near-duplicates might be different words, so they are kept
+		action := lesson.DuplicateKeepBoth
+		if group.Exact {
+			action = lesson.DuplicateMerge
+		}
+		a.resolutions = append(a.resolutions, lesson.DuplicateResolution{Group: group, Action: action, Keep: group.Items[0]})
+	}
+	return true
+}
+
+func (a *assistant) fileDialog() (fileDialog, bool) {
+	if a.module.manager == nil {
+		return nil, false
+	}
+	modules := a.module.manager.GetModulesByType("fileDialog")
+	if len(modules) == 0 {
+		return nil, false
+	}
+	dialog, ok := modules[0].(fileDialog)
+	return dialog, ok
+}
+
+// fillGroups lists the duplicate groups with their current decision
+func (a *assistant) fillGroups() {
+	exact := 0
+	for _, resolution := range a.resolutions {
+		if resolution.Group.Exact {
+			exact++
+		}
+	}
+	a.summary.SetText(fmt.Sprintf("%d items, %d groups of duplicates (%d exact, %d similar)",
+		len(a.lesson.List.Items), len(a.resolutions), exact, len(a.resolutions)-exact))
+
+	a.groups.SetRowCount(len(a.resolutions))
+	for row, resolution := range a.resolutions {
+		var words []string
+		for _, i := range resolution.Group.Items {
+			words = append(words, strings.Join(a.lesson.List.Items[i].Questions, ", "))
+		}
+		kind := "Similar"
+		if resolution.Group.Exact {
+			kind = "Exact"
+		}
+		decision := actionNames[resolution.Action]
+		if resolution.Action != lesson.DuplicateKeepBoth {
+			decision += fmt.Sprintf(", keep %q", strings.Join(a.lesson.List.Items[resolution.Keep].Questions, ", "))
+		}
+		for column, text := range []string{strings.Join(words, " / "), kind, decision} {
+			a.groups.SetItem(row, column, qt.NewQTableWidgetItem2(text))
+		}
+	}
+}
+
+// showGroup lists the items of the selected group with their test history
+func (a *assistant) showGroup() {
+	row := a.groups.CurrentRow()
+	a.items.ClearContents()
+	if row < 0 || row >= len(a.resolutions) {
+		a.items.SetRowCount(0)
+		return
+	}
+	resolution := a.resolutions[row]
+
+	right, wrong := make(map[int]int), make(map[int]int)
+	for _, test := range a.lesson.List.Tests {
+		for _, result := range test.Results {
+			if result.Result == "right" {
+				right[result.ItemID]++
+			} else {
+				wrong[result.ItemID]++
+			}
+		}
+	}
+
+	a.items.SetRowCount(len(resolution.Group.Items))
+	for r, i := range resolution.Group.Items {
+		item := a.lesson.List.Items[i]
+		cells := []string{
+			strings.Join(item.Questions, ", "),
+			lesson.ComposeSynonymGroups(item.SynonymGroups()),
+			item.Comment,
+			fmt.Sprintf("%d right, %d wrong", right[item.ID], wrong[item.ID]),
+		}
+		for column, text := range cells {
+			a.items.SetItem(r, column, qt.NewQTableWidgetItem2(text))
+		}
+		if i == resolution.Keep {
+			a.items.SelectRow(r)
+		}
+	}
+}
+
+// decide records action for the selected group, keeping the selected item
+func (a *assistant) decide(action string) {
+	row := a.groups.CurrentRow()
+	if row < 0 || row >= len(a.resolutions) {
+		return
+	}
+	resolution := &a.resolutions[row]
+	resolution.Action = action
+	if r := a.items.CurrentRow(); r >= 0 && r < len(resolution.Group.Items) {
+		resolution.Keep = resolution.Group.Items[r]
+	}
+
+	a.fillGroups()
+	if row+1 < len(a.resolutions) {
+		a.groups.SelectRow(row + 1)
+	} else {
+		a.groups.SelectRow(row)
+	}
+}
+
+// apply resolves the duplicates and saves the result as a new file
+func (a *assistant) apply() {
+	var path string
+	defaultName := strings.TrimSuffix(filepath.Base(a.path), filepath.Ext(a.path)) + " (merged).ot"
+	if dialog, ok := a.fileDialog(); ok {
+		path = dialog.SaveFile(a.dialog.QWidget, "Save merged lesson", "", defaultName)
+	} else {
+		path = qt.QFileDialog_GetSaveFileName4(a.dialog.QWidget, "Save merged lesson", filepath.Join(filepath.Dir(a.path), defaultName), "All Files (*)")
+	}
+	if path == "" {
+		return
+	}
+
+	resolved := a.finder.Resolve(a.lesson, a.resolutions)
+	if err := lesson.NewFileSaver().SaveFile(resolved, path); err != nil {
+		qt.QMessageBox_Warning(a.dialog.QWidget, "Find Duplicates", fmt.Sprintf("Could not save %s: %v", filepath.Base(path), err))
+		return
+	}
+	qt.QMessageBox_Information(a.dialog.QWidget, "Find Duplicates",
+		fmt.Sprintf("Saved %d items to %s.", len(resolved.List.Items), filepath.Base(path)))
+	a.dialog.Close()
+}
+
+// Enable activates the module
+func (mod *DuplicatesDialogModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("DuplicatesDialogModule enabled")
+	return nil
+}
+
+// Disable deactivates the module
+func (mod *DuplicatesDialogModule) Disable(ctx context.Context) error {
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("DuplicatesDialogModule disabled")
+	return nil
+}
+
+// SetManager sets the module manager
+func (mod *DuplicatesDialogModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// InitDuplicatesDialogModule creates and returns a new
+// DuplicatesDialogModule instance
+func InitDuplicatesDialogModule() core.Module {
+	return NewDuplicatesDialogModule()
+}
@@ -30,7 +30,7 @@ func NewFileDialogModule() *FileDialogModule {
 	return &FileDialogModule{
 		BaseModule: base,
 		lastDir:    "",
-		fileFilter: "All Lesson Files (*.ot *.otwd *.csv *.tsv *.txt *.json *.kvtml *.anki *.anki2 *.apkg *.xml *.kgm *.ottp *.otmd);;OpenTeacher Files (*.ot *.otwd *.ottp *.otmd);;Text & CSV Files (*.txt *.csv *.tsv *.json);;Anki Files (*.anki *.anki2 *.apkg);;KDE/Educational Files (*.kvtml *.kgm);;Vocabulary Trainers (*.voc *.fq *.fmd *.dkf *.jml *.jvlt *.stp *.db);;Language Learning Apps (*.oh *.ohw *.oh4 *.ovr *.pau *.t2k *.vok2 *.wdl *.vtl3 *.wrts);;Other Formats (*.backpack *.wcu *.xml);;All Files (*.*)",
+		fileFilter: "All Lesson Files (*.ot *.otwd *.csv *.tsv *.txt *.json *.kvtml *.anki *.anki2 *.apkg *.xml *.kgm *.ottp *.otmd *.otio *.otsec);;OpenTeacher Files (*.ot *.otwd *.ottp *.otmd *.otio);;Encrypted Lessons (*.otsec);;Text & CSV Files (*.txt *.csv *.tsv *.json);;Anki Files (*.anki *.anki2 *.apkg);;KDE/Educational Files (*.kvtml *.kgm);;Vocabulary Trainers (*.voc *.fq *.fmd *.dkf *.jml *.jvlt *.stp *.db);;Language Learning Apps (*.oh *.ohw *.oh4 *.ovr *.pau *.t2k *.vok2 *.wdl *.vtl3 *.wrts);;Other Formats (*.backpack *.wcu *.xml);;All Files (*.*)",
 	}
 }
 
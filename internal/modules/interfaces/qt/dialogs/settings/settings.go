@@ -11,6 +11,12 @@ import (
 	"log"
 
 	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/theme"
+	dailyprogress "github.com/LaPingvino/recuerdo/internal/modules/logic/dailyProgress"
+	featureflags "github.com/LaPingvino/recuerdo/internal/modules/logic/featureFlags"
+	"github.com/LaPingvino/recuerdo/internal/studyday"
+	"github.com/LaPingvino/recuerdo/internal/touch"
 	"github.com/mappu/miqt/qt"
 )
 
@@ -21,6 +27,41 @@ type SettingsDialogModule struct {
 	dialog       *qt.QDialog
 	tabWidget    *qt.QTabWidget
 	settingsData map[string]interface{}
+
+	// Answer checking of lessons without an option preset
+	ignoreCaseCheck        *qt.QCheckBox
+	ignoreAccentsCheck     *qt.QCheckBox
+	ignorePunctuationCheck *qt.QCheckBox
+	maxTyposSpin           *qt.QSpinBox
+	swapsCheck             *qt.QCheckBox
+	articlesCombo          *qt.QComboBox
+	requiredCombo          *qt.QComboBox
+
+	// featureChecks turn the experimental features on, by flag name
+	featureChecks map[string]*qt.QCheckBox
+
+	// backupsSpin sets how many .bak copies saving keeps
+	backupsSpin *qt.QSpinBox
+
+	// mediaCombo picks whether media is embedded in lessons or linked
+	mediaCombo *qt.QComboBox
+
+	// imageSizeSpin and keepOriginalsCheck set how pictures embedded in
+	// lessons are shrunk
+	imageSizeSpin      *qt.QSpinBox
+	keepOriginalsCheck *qt.QCheckBox
+
+	// dailyCardsSpin and dailyMinutesSpin set the daily practice goals
+	dailyCardsSpin   *qt.QSpinBox
+	dailyMinutesSpin *qt.QSpinBox
+	// rolloverSpin sets the hour a new day of study starts
+	rolloverSpin *qt.QSpinBox
+
+	// paletteCombo picks the colours answers are marked right or wrong in
+	paletteCombo *qt.QComboBox
+
+	// touchCheck turns touch mode for interactive whiteboards on
+	touchCheck *qt.QCheckBox
 }
 
 // NewSettingsDialogModule creates a new SettingsDialogModule instance
@@ -66,6 +107,8 @@ func (mod *SettingsDialogModule) createDialog(parent *qt.QWidget) {
 	mod.createGeneralTab()
 	mod.createLanguageTab()
 	mod.createInterfaceTab()
+	mod.createAnswersTab()
+	mod.createExperimentalTab()
 
 	// Add button box
 	buttonBox := qt.NewQDialogButtonBox(mod.dialog.QWidget)
@@ -106,6 +149,73 @@ func (mod *SettingsDialogModule) createGeneralTab() {
 	saveIntervalSpin.SetSuffix(" minutes")
 	layout.AddRow3("Save interval:", saveIntervalSpin.QWidget)
 
+	// Backup copies kept when saving over a lesson
+	mod.backupsSpin = qt.NewQSpinBox(generalWidget)
+	mod.backupsSpin.SetRange(0, 20)
+	mod.backupsSpin.SetValue(lesson.DefaultBackups())
+	mod.backupsSpin.SetToolTip("Earlier versions of a lesson kept next to it as .bak files when saving")
+	layout.AddRow3("Backup copies:", mod.backupsSpin.QWidget)
+
+	// Media embedded in media lessons or linked next to them
+	mod.mediaCombo = qt.NewQComboBox(generalWidget)
+	mod.mediaCombo.AddItem("Embed in the lesson")
+	mod.mediaCombo.AddItem("Link by path")
+	mod.setMediaStorage(lesson.DefaultMediaStorage())
+	mod.mediaCombo.SetToolTip("Whether pictures and sound of media lessons are stored inside the lesson file, or referred to by their path relative to it")
+	layout.AddRow3("Media files:", mod.mediaCombo.QWidget)
+
+	// Pictures embedded in lessons shrunk to keep them small enough to email
+	mod.imageSizeSpin = qt.NewQSpinBox(generalWidget)
+	mod.imageSizeSpin.SetRange(0, 10000)
+	mod.imageSizeSpin.SetSingleStep(100)
+	mod.imageSizeSpin.SetSuffix(" pixels")
+	mod.imageSizeSpin.SetSpecialValueText("Keep their size")
+	mod.imageSizeSpin.SetValue(lesson.DefaultImageCompression().MaxSize)
+	mod.imageSizeSpin.SetToolTip("The largest width or height of pictures embedded in media and image occlusion lessons; larger ones are scaled down when saving")
+	layout.AddRow3("Embedded pictures:", mod.imageSizeSpin.QWidget)
+	mod.keepOriginalsCheck = qt.NewQCheckBox(generalWidget)
+	mod.keepOriginalsCheck.SetText("Keep the originals in a folder next to the lesson")
+	mod.keepOriginalsCheck.SetChecked(lesson.DefaultImageCompression().KeepOriginals)
+	layout.AddRow3("", mod.keepOriginalsCheck.QWidget)
+
+	// Daily goals counting towards the streak on the start screen
+	mod.dailyCardsSpin = qt.NewQSpinBox(generalWidget)
+	mod.dailyCardsSpin.SetRange(0, 1000)
+	mod.dailyCardsSpin.SetValue(dailyprogress.DefaultCards)
+	mod.dailyCardsSpin.SetSuffix(" cards")
+	mod.dailyCardsSpin.SetSpecialValueText("No goal")
+	layout.AddRow3("Daily goal:", mod.dailyCardsSpin.QWidget)
+	mod.dailyMinutesSpin = qt.NewQSpinBox(generalWidget)
+	mod.dailyMinutesSpin.SetRange(0, 240)
+	mod.dailyMinutesSpin.SetValue(dailyprogress.DefaultMinutes)
+	mod.dailyMinutesSpin.SetSuffix(" minutes")
+	mod.dailyMinutesSpin.SetSpecialValueText("No goal")
+	layout.AddRow3("", mod.dailyMinutesSpin.QWidget)
+	mod.rolloverSpin = qt.NewQSpinBox(generalWidget)
+	mod.rolloverSpin.SetRange(0, 23)
+	mod.rolloverSpin.SetValue(studyday.DefaultRollover)
+	mod.rolloverSpin.SetSuffix(":00")
+	mod.rolloverSpin.SetToolTip("Answers given after midnight but before this hour count to the day before, for the daily goal, the statistics and when words are due again")
+	layout.AddRow3("New day starts at:", mod.rolloverSpin.QWidget)
+
+	// Colours of right and wrong answers; icons and borders tell them
+	// apart too
+	mod.paletteCombo = qt.NewQComboBox(generalWidget)
+	for _, palette := range theme.Palettes {
+		mod.paletteCombo.AddItem(palette.Title)
+		if palette.Name == theme.CurrentPalette().Name {
+			mod.paletteCombo.SetCurrentIndex(mod.paletteCombo.Count() - 1)
+		}
+	}
+	mod.paletteCombo.SetToolTip("Blue and orange are told apart with any kind of colour blindness")
+	layout.AddRow3("Answer colors:", mod.paletteCombo.QWidget)
+
+	// Touch mode for smartboards, taking effect on the next start
+	mod.touchCheck = qt.NewQCheckBox(generalWidget)
+	mod.touchCheck.SetText("Large buttons, swiping and an on-screen keyboard (after restarting)")
+	mod.touchCheck.SetToolTip("For interactive whiteboards and other touch screens")
+	layout.AddRow3("Touch mode:", mod.touchCheck.QWidget)
+
 	// Check for updates
 	updateCheck := qt.NewQCheckBox(generalWidget)
 	updateCheck.SetText("Check for updates at startup")
@@ -210,16 +320,294 @@ func (mod *SettingsDialogModule) createInterfaceTab() {
 	mod.tabWidget.AddTab(interfaceWidget, "Interface")
 }
 
+// createAnswersTab creates the tab setting how tolerantly typed answers are
+// checked in lessons without an option preset
+func (mod *SettingsDialogModule) createAnswersTab() {
+	answersWidget := qt.NewQWidget2()
+	layout := qt.NewQFormLayout(answersWidget)
+
+	mod.ignoreCaseCheck = qt.NewQCheckBox3("Ignore upper and lower case")
+	layout.AddRow3("Case:", mod.ignoreCaseCheck.QWidget)
+
+	mod.ignoreAccentsCheck = qt.NewQCheckBox3("Accept \"cafe\" for \"café\"")
+	layout.AddRow3("Accents:", mod.ignoreAccentsCheck.QWidget)
+
+	mod.ignorePunctuationCheck = qt.NewQCheckBox3("Ignore punctuation")
+	layout.AddRow3("Punctuation:", mod.ignorePunctuationCheck.QWidget)
+
+	mod.maxTyposSpin = qt.NewQSpinBox(answersWidget)
+	mod.maxTyposSpin.SetRange(0, 5)
+	mod.maxTyposSpin.SetSpecialValueText("None")
+	mod.maxTyposSpin.SetToolTip("Letters that may be wrong, missing or extra; short answers still have to match")
+	layout.AddRow3("Typos allowed:", mod.maxTyposSpin.QWidget)
+
+	mod.swapsCheck = qt.NewQCheckBox3("Count swapped letters (\"recieve\") as one typo")
+	layout.AddRow3("", mod.swapsCheck.QWidget)
+
+	mod.articlesCombo = qt.NewQComboBox(answersWidget)
+	for _, mode := range articleModes {
+		mod.articlesCombo.AddItem(mode.label)
+	}
+	mod.articlesCombo.SetToolTip("How de/het, der/die/das, le/la and other articles are graded")
+	layout.AddRow3("Articles:", mod.articlesCombo.QWidget)
+
+	mod.requiredCombo = qt.NewQComboBox(answersWidget)
+	for _, mode := range requiredModes {
+		mod.requiredCombo.AddItem(mode.label)
+	}
+	mod.requiredCombo.SetToolTip("For words with several numbered meanings, such as \"1. bank 2. shore\"")
+	layout.AddRow3("Several meanings:", mod.requiredCombo.QWidget)
+
+	mod.setTolerance(lesson.DefaultTolerance())
+	mod.tabWidget.AddTab(answersWidget, "Answers")
+}
+
+// featureFlags is the part of the feature flags module the Experimental tab
+// uses
+type featureFlags interface {
+	States() []featureflags.State
+	SetEnabled(name string, enabled bool) error
+}
+
+// featureFlagsModule returns the feature flags module, nil if there is none
+func (mod *SettingsDialogModule) featureFlagsModule() featureFlags {
+	if mod.manager == nil {
+		return nil
+	}
+	module, ok := mod.manager.GetDefaultModule("featureFlags")
+	if !ok {
+		return nil
+	}
+	flags, _ := module.(featureFlags)
+	return flags
+}
+
+// createExperimentalTab creates the tab turning features that are still
+// experimental on or off
+func (mod *SettingsDialogModule) createExperimentalTab() {
+	flags := mod.featureFlagsModule()
+	if flags == nil {
+		return
+	}
+	experimentalWidget := qt.NewQWidget2()
+	layout := qt.NewQVBoxLayout(experimentalWidget)
+
+	label := qt.NewQLabel3("These features are not finished yet. Changes take effect after restarting.")
+	label.SetWordWrap(true)
+	layout.AddWidget(label.QWidget)
+
+	mod.featureChecks = make(map[string]*qt.QCheckBox)
+	for _, state := range flags.States() {
+		check := qt.NewQCheckBox3(state.Description)
+		if state.Description == "" {
+			check.SetText(state.Name)
+		}
+		if state.Source == featureflags.SourceEnvironment {
+			check.SetEnabled(false)
+			check.SetToolTip(fmt.Sprintf("Set by the %s environment variable", featureflags.EnvVar))
+		}
+		mod.featureChecks[state.Name] = check
+		layout.AddWidget(check.QWidget)
+	}
+	layout.AddStretch()
+
+	mod.tabWidget.AddTab(experimentalWidget, "Experimental")
+}
+
+// loadFeatures shows which experimental features are on
+func (mod *SettingsDialogModule) loadFeatures() {
+	flags := mod.featureFlagsModule()
+	if flags == nil {
+		return
+	}
+	for _, state := range flags.States() {
+		if check, ok := mod.featureChecks[state.Name]; ok {
+			check.SetChecked(state.Enabled)
+		}
+	}
+}
+
+// saveFeatures remembers the features the user turned on or off. Features
+// left alone keep following their default, so they can be turned on for
+// everyone later.
+func (mod *SettingsDialogModule) saveFeatures() {
+	flags := mod.featureFlagsModule()
+	if flags == nil {
+		return
+	}
+	for _, state := range flags.States() {
+		check, ok := mod.featureChecks[state.Name]
+		if !ok || state.Source == featureflags.SourceEnvironment || check.IsChecked() == state.Enabled {
+			continue
+		}
+		if err := flags.SetEnabled(state.Name, check.IsChecked()); err != nil {
+			log.Printf("[ERROR] SettingsDialogModule.saveFeatures() - %v", err)
+		}
+	}
+}
+
+// articleModes are the article grading modes offered in the Answers tab
+var articleModes = []struct {
+	mode  string
+	label string
+}{
+	{lesson.ArticlesAsTyped, "Compare like other words"},
+	{lesson.ArticlesOptional, "Accept answers without the article"},
+	{lesson.ArticlesRequired, "Require the right article"},
+	{lesson.ArticlesSeparate, "Give half credit for a wrong article"},
+}
+
+// requiredModes are the required answer modes offered in the Answers tab
+var requiredModes = []struct {
+	mode  string
+	label string
+}{
+	{lesson.RequireAnyAnswer, "Accept any one meaning"},
+	{lesson.RequireAllAnswers, "Require all meanings"},
+}
+
+// setMediaStorage shows how media is stored, lesson.MediaEmbed or
+// lesson.MediaLink
+func (mod *SettingsDialogModule) setMediaStorage(storage string) {
+	if storage == lesson.MediaEmbed {
+		mod.mediaCombo.SetCurrentIndex(0)
+	} else {
+		mod.mediaCombo.SetCurrentIndex(1)
+	}
+}
+
+// saveGoals stores the daily goals, through the dailyProgress module when
+// there is one so the start screen shows them at once
+func (mod *SettingsDialogModule) saveGoals(settings lesson.ToleranceSettings, goals dailyprogress.Goals) {
+	if module, ok := mod.manager.GetDefaultModule("dailyProgress"); ok {
+		if progress, ok := module.(interface {
+			SetGoals(goals dailyprogress.Goals) error
+		}); ok {
+			if err := progress.SetGoals(goals); err != nil {
+				log.Printf("[ERROR] SettingsDialogModule.saveSettings() - %v", err)
+			}
+			return
+		}
+	}
+	if err := dailyprogress.SaveGoals(settings, goals); err != nil {
+		log.Printf("[ERROR] SettingsDialogModule.saveSettings() - %v", err)
+	}
+}
+
+// setTolerance shows tolerance in the Answers tab
+func (mod *SettingsDialogModule) setTolerance(tolerance lesson.AnswerTolerance) {
+	mod.ignoreCaseCheck.SetChecked(tolerance.IgnoreCase)
+	mod.ignoreAccentsCheck.SetChecked(tolerance.IgnoreAccents)
+	mod.ignorePunctuationCheck.SetChecked(tolerance.IgnorePunctuation)
+	mod.maxTyposSpin.SetValue(tolerance.MaxTypos)
+	mod.swapsCheck.SetChecked(tolerance.SwapsAsOneTypo)
+	for i, mode := range articleModes {
+		if mode.mode == tolerance.Articles {
+			mod.articlesCombo.SetCurrentIndex(i)
+		}
+	}
+	for i, mode := range requiredModes {
+		if mode.mode == tolerance.Required {
+			mod.requiredCombo.SetCurrentIndex(i)
+		}
+	}
+}
+
+// settingsModule returns the settings module, nil if there is none
+func (mod *SettingsDialogModule) settingsModule() lesson.ToleranceSettings {
+	if mod.manager == nil {
+		return nil
+	}
+	module, ok := mod.manager.GetDefaultModule("settings")
+	if !ok {
+		return nil
+	}
+	settings, _ := module.(lesson.ToleranceSettings)
+	return settings
+}
+
 // loadSettings loads current settings into the dialog
 func (mod *SettingsDialogModule) loadSettings() {
-	// TODO: Load actual settings from settings module
-	fmt.Println("Loading settings...")
+	mod.loadFeatures()
+	settings := mod.settingsModule()
+	if settings == nil || mod.dialog == nil {
+		return
+	}
+	mod.setTolerance(lesson.LoadDefaultTolerance(settings))
+	mod.backupsSpin.SetValue(lesson.LoadDefaultBackups(settings))
+	mod.setMediaStorage(lesson.LoadDefaultMediaStorage(settings))
+	compression := lesson.LoadDefaultImageCompression(settings)
+	mod.imageSizeSpin.SetValue(compression.MaxSize)
+	mod.keepOriginalsCheck.SetChecked(compression.KeepOriginals)
+	goals := dailyprogress.LoadGoals(settings)
+	mod.dailyCardsSpin.SetValue(goals.Cards)
+	mod.dailyMinutesSpin.SetValue(goals.Minutes)
+	mod.rolloverSpin.SetValue(studyday.LoadRollover(settings))
+	name := theme.LoadPalette(settings)
+	for i, palette := range theme.Palettes {
+		if palette.Name == name {
+			mod.paletteCombo.SetCurrentIndex(i)
+		}
+	}
+	enabled, _ := settings.GetSettingWithDefault(touch.Setting, false).(bool)
+	mod.touchCheck.SetChecked(enabled)
 }
 
 // saveSettings saves the dialog settings
 func (mod *SettingsDialogModule) saveSettings() {
-	// TODO: Save settings to settings module
-	fmt.Println("Saving settings...")
+	settings := mod.settingsModule()
+	if settings == nil || mod.dialog == nil {
+		log.Printf("[WARNING] SettingsDialogModule.saveSettings() - no settings module, settings not saved")
+		return
+	}
+	tolerance := lesson.DefaultTolerance()
+	tolerance.IgnoreCase = mod.ignoreCaseCheck.IsChecked()
+	tolerance.IgnoreAccents = mod.ignoreAccentsCheck.IsChecked()
+	tolerance.IgnorePunctuation = mod.ignorePunctuationCheck.IsChecked()
+	tolerance.MaxTypos = mod.maxTyposSpin.Value()
+	tolerance.SwapsAsOneTypo = mod.swapsCheck.IsChecked()
+	tolerance.Articles = articleModes[max(0, mod.articlesCombo.CurrentIndex())].mode
+	tolerance.Required = requiredModes[max(0, mod.requiredCombo.CurrentIndex())].mode
+	if err := lesson.SaveDefaultTolerance(settings, tolerance); err != nil {
+		log.Printf("[ERROR] SettingsDialogModule.saveSettings() - %v", err)
+		return
+	}
+	if err := lesson.SaveDefaultBackups(settings, mod.backupsSpin.Value()); err != nil {
+		log.Printf("[ERROR] SettingsDialogModule.saveSettings() - %v", err)
+	}
+	storage := lesson.MediaLink
+	if mod.mediaCombo.CurrentIndex() == 0 {
+		storage = lesson.MediaEmbed
+	}
+	if err := lesson.SaveDefaultMediaStorage(settings, storage); err != nil {
+		log.Printf("[ERROR] SettingsDialogModule.saveSettings() - %v", err)
+	}
+	compression := lesson.DefaultImageCompression()
+	compression.MaxSize = mod.imageSizeSpin.Value()
+	compression.KeepOriginals = mod.keepOriginalsCheck.IsChecked()
+	if err := lesson.SaveDefaultImageCompression(settings, compression); err != nil {
+		log.Printf("[ERROR] SettingsDialogModule.saveSettings() - %v", err)
+	}
+	mod.saveGoals(settings, dailyprogress.Goals{
+		Cards:   mod.dailyCardsSpin.Value(),
+		Minutes: mod.dailyMinutesSpin.Value(),
+	})
+	if err := studyday.SaveRollover(settings, mod.rolloverSpin.Value()); err != nil {
+		log.Printf("[ERROR] SettingsDialogModule.saveSettings() - %v", err)
+	}
+	palette := theme.Palettes[max(0, mod.paletteCombo.CurrentIndex())]
+	if err := theme.SavePalette(settings, palette.Name); err != nil {
+		log.Printf("[ERROR] SettingsDialogModule.saveSettings() - %v", err)
+	}
+	if err := settings.SetSetting(touch.Setting, mod.touchCheck.IsChecked()); err != nil {
+		log.Printf("[ERROR] SettingsDialogModule.saveSettings() - %v", err)
+	}
+	mod.saveFeatures()
+	if saver, ok := settings.(interface{ SaveSettings() error }); ok {
+		if err := saver.SaveSettings(); err != nil {
+			log.Printf("[ERROR] SettingsDialogModule.saveSettings() - failed to write settings: %v", err)
+		}
+	}
 }
 
 // retranslate updates dialog text for localization
@@ -280,6 +668,7 @@ func (mod *SettingsDialogModule) ShowSettingsDialog() bool {
 	}
 
 	mod.createDialog(parentWidget)
+	mod.loadSettings()
 
 	if mod.dialog != nil {
 		log.Printf("[SUCCESS] SettingsDialogModule showing dialog")
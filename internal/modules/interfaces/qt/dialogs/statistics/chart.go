@@ -0,0 +1,116 @@
+package statistics
+
+import (
+	"fmt"
+
+	"github.com/mappu/miqt/qt"
+)
+
+// chart draws one series of values as bars or as a line, with the labels
+// of the first and the last value under it
+type chart struct {
+	widget *qt.QWidget
+	title  string
+	bars   bool
+	// labels name the values, such as the days they are of
+	labels []string
+	values []float64
+	// maximum is the value at the top of the chart; zero scales to the
+	// largest value
+	maximum float64
+	// format writes a value for the axis
+	format func(value float64) string
+	// color is the colour of the bars or the line
+	color string
+}
+
+// newChart creates an empty chart
+func newChart(title string, bars bool, maximum float64, format func(float64) string, color string) *chart {
+	c := &chart{title: title, bars: bars, maximum: maximum, format: format, color: color}
+	c.widget = qt.NewQWidget2()
+	c.widget.SetMinimumSize2(320, 180)
+	c.widget.OnPaintEvent(func(super func(event *qt.QPaintEvent), event *qt.QPaintEvent) {
+		c.paint()
+	})
+	return c
+}
+
+// setValues shows values, named by labels
+func (c *chart) setValues(labels []string, values []float64) {
+	c.labels, c.values = labels, values
+	c.widget.Update()
+}
+
+func (c *chart) paint() {
+	painter := qt.NewQPainter2(c.widget.QPaintDevice)
+	defer painter.End()
+	painter.SetRenderHint(qt.QPainter__Antialiasing)
+
+	const left, top, right, bottom = 44, 24, 12, 24
+	width, height := c.widget.Width(), c.widget.Height()
+	plotWidth, plotHeight := width-left-right, height-top-bottom
+	text := c.widget.Palette().WindowText().Color()
+	grid := qt.NewQColor6("#c0c0c0")
+
+	painter.SetPen(text)
+	painter.DrawText7(0, 0, width, top, int(qt.AlignHCenter|qt.AlignVCenter), c.title)
+	if len(c.values) == 0 || plotWidth <= 0 || plotHeight <= 0 {
+		painter.DrawText7(0, top, width, plotHeight, int(qt.AlignCenter), "No answers yet")
+		return
+	}
+
+	maximum := c.maximum
+	for _, value := range c.values {
+		maximum = max(maximum, value)
+	}
+	if maximum <= 0 {
+		maximum = 1
+	}
+	y := func(value float64) int {
+		return top + plotHeight - int(value/maximum*float64(plotHeight))
+	}
+
+	// Axis with the top, middle and bottom values
+	for _, value := range []float64{0, maximum / 2, maximum} {
+		painter.SetPen(grid)
+		painter.DrawLine2(left, y(value), left+plotWidth, y(value))
+		painter.SetPen(text)
+		painter.DrawText7(0, y(value)-8, left-4, 16, int(qt.AlignRight|qt.AlignVCenter), c.format(value))
+	}
+	painter.DrawText7(left, top+plotHeight+4, plotWidth, bottom-4, int(qt.AlignLeft|qt.AlignTop), c.labels[0])
+	if len(c.labels) > 1 {
+		painter.DrawText7(left, top+plotHeight+4, plotWidth, bottom-4, int(qt.AlignRight|qt.AlignTop), c.labels[len(c.labels)-1])
+	}
+
+	color := qt.NewQColor6(c.color)
+	step := float64(plotWidth) / float64(len(c.values))
+	if c.bars {
+		for i, value := range c.values {
+			x := left + int(float64(i)*step)
+			barWidth := max(1, int(step)-2)
+			painter.FillRect5(x+1, y(value), barWidth, top+plotHeight-y(value), color)
+		}
+		return
+	}
+
+	pen := qt.NewQPen3(color)
+	pen.SetWidth(2)
+	painter.SetPenWithPen(pen)
+	x := func(i int) int { return left + int((float64(i)+0.5)*step) }
+	for i := 1; i < len(c.values); i++ {
+		painter.DrawLine2(x(i-1), y(c.values[i-1]), x(i), y(c.values[i]))
+	}
+	for i, value := range c.values {
+		painter.FillRect5(x(i)-2, y(value)-2, 5, 5, color)
+	}
+}
+
+// percent writes a fraction as a percentage
+func percent(value float64) string {
+	return fmt.Sprintf("%.0f%%", value*100)
+}
+
+// count writes a number of items
+func count(value float64) string {
+	return fmt.Sprintf("%.0f", value)
+}
@@ -0,0 +1,338 @@
+// Package statistics provides the statistics dialog: charts of the answers
+// in the review log, of one lesson or of all of them, showing how much is
+// remembered over time, the scores of every day, how many reviews are due
+// in the coming days and which items are the hardest. The answers and the
+// statistics can be exported to CSV or JSON.
+package statistics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/theme"
+	"github.com/LaPingvino/recuerdo/internal/paths"
+	"github.com/LaPingvino/recuerdo/internal/reviewlog"
+	"github.com/LaPingvino/recuerdo/internal/studyday"
+	"github.com/mappu/miqt/qt"
+)
+
+// forecastDays is how many days ahead due reviews are forecast
+const forecastDays = 14
+
+// hardestItems is how many of the hardest items are listed
+const hardestItems = 20
+
+// periods are the time spans the charts can cover
+var periods = []struct {
+	title string
+	days  int
+}{
+	{"Last 4 weeks", 28},
+	{"Last 3 months", 91},
+	{"Last year", 365},
+}
+
+// reviewHistory is the part of the reviewHistory module the dialog charts
+type reviewHistory interface {
+	Lessons() ([]string, error)
+	Count(lesson string) (int, error)
+	Scores(lesson string, since time.Time) ([]reviewlog.Point, error)
+	Retention(lesson string, since time.Time) ([]reviewlog.Point, error)
+	Hardest(lesson string, limit int) ([]reviewlog.ItemStats, error)
+	Forecast(lesson string, days int) ([]int, error)
+	Today() (string, error)
+	Export(w io.Writer, table reviewlog.Table, format, lesson string, since time.Time) error
+}
+
+// exports are the tables the Export menu offers, each as CSV and JSON
+var exports = []struct {
+	title string
+	name  string
+	table reviewlog.Table
+}{
+	{"Answers", "answers", reviewlog.ReviewsTable},
+	{"Statistics per Day", "days", reviewlog.DaysTable},
+	{"Statistics per Item", "items", reviewlog.ItemsTable},
+}
+
+// StatisticsDialogModule shows the statistics dialog
+type StatisticsDialogModule struct {
+	*core.BaseModule
+	manager *core.Manager
+}
+
+// NewStatisticsDialogModule creates a new StatisticsDialogModule instance
+func NewStatisticsDialogModule() *StatisticsDialogModule {
+	base := core.NewBaseModule("statisticsDialog", "statistics-dialog-module")
+	base.SetRequires("qtApp", "reviewHistory")
+
+	return &StatisticsDialogModule{
+		BaseModule: base,
+	}
+}
+
+// dashboard holds the widgets of one statistics dialog
+type dashboard struct {
+	history   reviewHistory
+	lessons   []string
+	lesson    *qt.QComboBox
+	period    *qt.QComboBox
+	summary   *qt.QLabel
+	retention *chart
+	scores    *chart
+	forecast  *chart
+	hardest   *qt.QTableWidget
+}
+
+// ShowStatisticsDialog shows the statistics of all lessons, or of the
+// lesson called lesson when answers to it were logged
+func (mod *StatisticsDialogModule) ShowStatisticsDialog(parent *qt.QWidget, lesson string) {
+	var history reviewHistory
+	if mod.manager != nil {
+		if module, ok := mod.manager.GetDefaultModule("reviewHistory"); ok {
+			history, _ = module.(reviewHistory)
+		}
+	}
+	if history == nil {
+		qt.QMessageBox_Warning(parent, "Statistics", "The review log is not available.")
+		return
+	}
+	lessons, err := history.Lessons()
+	if err != nil {
+		qt.QMessageBox_Warning(parent, "Statistics", err.Error())
+		return
+	}
+
+	dialog := qt.NewQDialog(parent)
+	dialog.SetWindowTitle("Statistics")
+	dialog.SetAttribute(qt.WA_DeleteOnClose)
+	dialog.Resize(900, 650)
+
+	d := &dashboard{history: history, lessons: lessons}
+	d.lesson = qt.NewQComboBox(dialog.QWidget)
+	d.lesson.AddItem("All lessons")
+	for i, name := range lessons {
+		d.lesson.AddItem(name)
+		if name == lesson {
+			d.lesson.SetCurrentIndex(i + 1)
+		}
+	}
+	d.period = qt.NewQComboBox(dialog.QWidget)
+	for _, period := range periods {
+		d.period.AddItem(period.title)
+	}
+	d.summary = qt.NewQLabel(dialog.QWidget)
+
+	right := theme.CurrentPalette().Right.Text
+	d.retention = newChart("Retention of items asked before", false, 1, percent, right)
+	d.scores = newChart("Score per day", false, 1, percent, "#1565c0")
+	d.forecast = newChart(fmt.Sprintf("Reviews due in the next %d days", forecastDays), true, 0, count, "#6a1b9a")
+
+	d.hardest = qt.NewQTableWidget(dialog.QWidget)
+	d.hardest.SetColumnCount(5)
+	d.hardest.SetHorizontalHeaderLabels([]string{"Question", "Lesson", "Wrong", "Right", "Time to answer"})
+	d.hardest.HorizontalHeader().SetStretchLastSection(true)
+	d.hardest.SetEditTriggers(qt.QAbstractItemView__NoEditTriggers)
+	d.hardest.SetSelectionBehavior(qt.QAbstractItemView__SelectRows)
+
+	controls := qt.NewQHBoxLayout2()
+	controls.AddWidget(qt.NewQLabel3("Lesson:").QWidget)
+	controls.AddWidget(d.lesson.QWidget)
+	controls.AddWidget(d.period.QWidget)
+	controls.AddStretch()
+	controls.AddWidget(d.summary.QWidget)
+
+	hardestBox := qt.NewQGroupBox3("Hardest items")
+	hardestLayout := qt.NewQVBoxLayout(hardestBox.QWidget)
+	hardestLayout.AddWidget(d.hardest.QWidget)
+
+	charts := qt.NewQGridLayout2()
+	charts.AddWidget2(d.retention.widget, 0, 0)
+	charts.AddWidget2(d.scores.widget, 0, 1)
+	charts.AddWidget2(d.forecast.widget, 1, 0)
+	charts.AddWidget2(hardestBox.QWidget, 1, 1)
+
+	exportButton := qt.NewQPushButton3("Export")
+	exportButton.SetToolTip("Save the answers or statistics of the lesson and period shown, for a spreadsheet or R")
+	exportMenu := qt.NewQMenu(dialog.QWidget)
+	for _, export := range exports {
+		for _, format := range []string{reviewlog.CSV, reviewlog.JSON} {
+			action := exportMenu.AddAction(fmt.Sprintf("%s as %s...", export.title, strings.ToUpper(format)))
+			action.OnTriggered(func() { d.export(dialog.QWidget, export.name, export.table, format) })
+		}
+	}
+	exportButton.SetMenu(exportMenu)
+
+	closeButton := qt.NewQPushButton3("Close")
+	closeButton.OnClicked(func() { dialog.Close() })
+	buttons := qt.NewQHBoxLayout2()
+	buttons.AddWidget(exportButton.QWidget)
+	buttons.AddStretch()
+	buttons.AddWidget(closeButton.QWidget)
+
+	layout := qt.NewQVBoxLayout(dialog.QWidget)
+	layout.AddLayout(controls.QLayout)
+	layout.AddLayout(charts.QLayout)
+	layout.AddLayout(buttons.QLayout)
+
+	d.lesson.OnCurrentIndexChanged(func(int) { d.update(dialog.QWidget) })
+	d.period.OnCurrentIndexChanged(func(int) { d.update(dialog.QWidget) })
+	d.update(dialog.QWidget)
+
+	dialog.Show()
+}
+
+// selectedLesson returns the lesson picked, or "" for all lessons
+func (d *dashboard) selectedLesson() string {
+	if index := d.lesson.CurrentIndex(); index > 0 && index <= len(d.lessons) {
+		return d.lessons[index-1]
+	}
+	return ""
+}
+
+// since returns the first day of the period picked
+func (d *dashboard) since() time.Time {
+	return time.Now().AddDate(0, 0, -periods[max(0, d.period.CurrentIndex())].days+1)
+}
+
+// export asks where to save table for the lesson and period picked and
+// writes it there
+func (d *dashboard) export(parent *qt.QWidget, name string, table reviewlog.Table, format string) {
+	if lesson := d.selectedLesson(); lesson != "" {
+		name = strings.Map(func(r rune) rune {
+			if strings.ContainsRune(`/\:*?"<>|`, r) {
+				return '_'
+			}
+			return r
+		}, lesson) + " " + name
+	}
+	path := qt.QFileDialog_GetSaveFileName4(parent, "Export Statistics", filepath.Join(paths.LessonDir(), name+"."+format),
+		fmt.Sprintf("%s Files (*.%s)", strings.ToUpper(format), format))
+	if path == "" {
+		return
+	}
+	file, err := os.Create(path)
+	if err == nil {
+		err = d.history.Export(file, table, format, d.selectedLesson(), d.since())
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		qt.QMessageBox_Warning(parent, "Export Statistics", err.Error())
+	}
+}
+
+// update shows the statistics of the lesson and period picked
+func (d *dashboard) update(parent *qt.QWidget) {
+	if err := d.show(d.selectedLesson(), periods[max(0, d.period.CurrentIndex())].days); err != nil {
+		qt.QMessageBox_Warning(parent, "Statistics", err.Error())
+	}
+}
+
+func (d *dashboard) show(lesson string, days int) error {
+	answers, err := d.history.Count(lesson)
+	if err != nil {
+		return err
+	}
+	d.summary.SetText(fmt.Sprintf("%d answers logged", answers))
+
+	since := time.Now().AddDate(0, 0, -days+1)
+	retention, err := d.history.Retention(lesson, since)
+	if err != nil {
+		return err
+	}
+	d.retention.setValues(pointValues(retention))
+	scores, err := d.history.Scores(lesson, since)
+	if err != nil {
+		return err
+	}
+	d.scores.setValues(pointValues(scores))
+
+	forecast, err := d.history.Forecast(lesson, forecastDays)
+	if err != nil {
+		return err
+	}
+	today, err := d.history.Today()
+	if err != nil {
+		return err
+	}
+	labels := make([]string, len(forecast))
+	values := make([]float64, len(forecast))
+	for i, due := range forecast {
+		if day, err := time.Parse(studyday.Layout, studyday.Add(today, i)); err == nil {
+			labels[i] = day.Format("Jan 2")
+		}
+		values[i] = float64(due)
+	}
+	labels[0] = "Today"
+	d.forecast.setValues(labels, values)
+
+	hardest, err := d.history.Hardest(lesson, hardestItems)
+	if err != nil {
+		return err
+	}
+	d.hardest.SetRowCount(len(hardest))
+	for row, item := range hardest {
+		cells := []string{
+			item.Question,
+			item.Lesson,
+			fmt.Sprintf("%d", item.Wrong),
+			fmt.Sprintf("%d", item.Right),
+			fmt.Sprintf("%.1f s", item.AnswerTime.Seconds()),
+		}
+		for column, text := range cells {
+			d.hardest.SetItem(row, column, qt.NewQTableWidgetItem2(text))
+		}
+	}
+	d.hardest.ResizeColumnsToContents()
+	return nil
+}
+
+// pointValues returns the days and values of points for a chart
+func pointValues(points []reviewlog.Point) ([]string, []float64) {
+	labels := make([]string, len(points))
+	values := make([]float64, len(points))
+	for i, point := range points {
+		labels[i] = point.Day
+		values[i] = point.Value
+	}
+	return labels, values
+}
+
+// Enable activates the module
+func (mod *StatisticsDialogModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("StatisticsDialogModule enabled")
+	return nil
+}
+
+// Disable deactivates the module
+func (mod *StatisticsDialogModule) Disable(ctx context.Context) error {
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("StatisticsDialogModule disabled")
+	return nil
+}
+
+// SetManager sets the module manager
+func (mod *StatisticsDialogModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// InitStatisticsDialogModule creates and returns a new
+// StatisticsDialogModule instance
+func InitStatisticsDialogModule() core.Module {
+	return NewStatisticsDialogModule()
+}
@@ -0,0 +1,56 @@
+package gui
+
+import (
+	"io"
+
+	"github.com/mappu/miqt/qt"
+)
+
+// accessibilityLabeler is the part of the accessibility module the main
+// window names its controls for screen readers with
+type accessibilityLabeler interface {
+	Label(root *qt.QWidget) int
+	Report(w io.Writer, root *qt.QWidget) int
+}
+
+// getAccessibilityLabeler returns the accessibility module, or nil when
+// there is none
+func (mod *GuiModule) getAccessibilityLabeler() accessibilityLabeler {
+	module, ok := mod.manager.GetDefaultModule("accessibility")
+	if !ok {
+		return nil
+	}
+	labeler, _ := module.(accessibilityLabeler)
+	return labeler
+}
+
+// labelForAccessibility names the controls below widget that have no
+// accessible name yet
+func (mod *GuiModule) labelForAccessibility(widget *qt.QWidget) {
+	if labeler := mod.getAccessibilityLabeler(); labeler != nil {
+		named := labeler.Label(widget)
+		mod.logger.Info("Named %d controls for screen readers", named)
+	}
+}
+
+// RunAccessibilityAudit reports the controls of the main window screen
+// readers cannot name or the Tab key cannot reach once the event loop
+// runs, and then quits with exit code 1 when there are any
+func (mod *GuiModule) RunAccessibilityAudit(w io.Writer) {
+	timer := qt.NewQTimer2(mod.mainWindow.QObject)
+	timer.SetSingleShot(true)
+	timer.OnTimeout(func() {
+		labeler := mod.getAccessibilityLabeler()
+		if labeler == nil {
+			mod.logger.Error("No accessibility module to audit the window with")
+			qt.QCoreApplication_ExitWithRetcode(1)
+			return
+		}
+		if labeler.Report(w, mod.mainWindow.QWidget) > 0 {
+			qt.QCoreApplication_ExitWithRetcode(1)
+			return
+		}
+		qt.QCoreApplication_ExitWithRetcode(0)
+	})
+	timer.Start(0)
+}
@@ -0,0 +1,97 @@
+package gui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/mappu/miqt/qt"
+)
+
+// alignBilingualTexts pairs the sentences of a text and its translation,
+// pasted or opened from files, into a new lesson of sentence pairs once
+// the user reviewed them
+func (mod *GuiModule) alignBilingualTexts() {
+	const title = "Align Bilingual Texts"
+	dialog := qt.NewQDialog(mod.mainWindow.QWidget)
+	dialog.SetWindowTitle(title)
+	dialog.Resize(800, 520)
+	defer dialog.DeleteLater()
+	layout := qt.NewQVBoxLayout(dialog.QWidget)
+
+	explanation := qt.NewQLabel3("Paste or open a text and its translation. Their sentences are paired up into a lesson, with the original as the questions and the translation as the answers.")
+	explanation.SetWordWrap(true)
+	layout.AddWidget(explanation.QWidget)
+
+	form := qt.NewQFormLayout2()
+	titleEdit := qt.NewQLineEdit(dialog.QWidget)
+	titleLabel := qt.NewQLabel3("&Title:")
+	titleLabel.SetBuddy(titleEdit.QWidget)
+	form.AddRow(titleLabel.QWidget, titleEdit.QWidget)
+	layout.AddLayout(form.QLayout)
+
+	texts := qt.NewQHBoxLayout2()
+	textPane := func(label, openLabel string) *qt.QPlainTextEdit {
+		pane := qt.NewQVBoxLayout2()
+		header := qt.NewQHBoxLayout2()
+		textLabel := qt.NewQLabel3(label)
+		header.AddWidget(textLabel.QWidget)
+		header.AddStretch()
+		openButton := qt.NewQPushButton3(openLabel)
+		header.AddWidget(openButton.QWidget)
+		pane.AddLayout(header.QLayout)
+		edit := qt.NewQPlainTextEdit(dialog.QWidget)
+		textLabel.SetBuddy(edit.QWidget)
+		pane.AddWidget(edit.QWidget)
+		texts.AddLayout(pane.QLayout)
+
+		openButton.OnClicked(func() {
+			fileName := qt.QFileDialog_GetOpenFileName4(dialog.QWidget, title, "", "Text files (*.txt);;All files (*)")
+			if fileName == "" {
+				return
+			}
+			data, err := os.ReadFile(fileName)
+			if err != nil {
+				qt.QMessageBox_Warning(dialog.QWidget, title, fmt.Sprintf("Could not read %s: %v", fileName, err))
+				return
+			}
+			edit.SetPlainText(string(data))
+			if titleEdit.Text() == "" {
+				titleEdit.SetText(strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName)))
+			}
+		})
+		return edit
+	}
+	originalEdit := textPane("&Original:", "Open &Original...")
+	translationEdit := textPane("T&ranslation:", "Open Tra&nslation...")
+	layout.AddLayout(texts.QLayout)
+
+	buttons := qt.NewQDialogButtonBox(dialog.QWidget)
+	buttons.SetStandardButtons(qt.QDialogButtonBox__Ok | qt.QDialogButtonBox__Cancel)
+	buttons.Button(qt.QDialogButtonBox__Ok).SetText("&Align")
+	buttons.OnAccepted(dialog.Accept)
+	buttons.OnRejected(dialog.Reject)
+	layout.AddWidget(buttons.QWidget)
+
+	if dialog.Exec() != int(qt.QDialog__Accepted) {
+		return
+	}
+	lessonTitle := titleEdit.Text()
+	if lessonTitle == "" {
+		lessonTitle = "Sentence Pairs"
+	}
+	draft, err := lesson.AlignTexts(originalEdit.ToPlainText(), translationEdit.ToPlainText(), lessonTitle)
+	if err != nil {
+		qt.QMessageBox_Warning(mod.mainWindow.QWidget, title, fmt.Sprintf("Could not align the texts: %v", err))
+		return
+	}
+	if !mod.reviewImportDraft(title, draft) {
+		return
+	}
+	aligned := lesson.NewLesson("words")
+	aligned.Data = *draft.ToLessonData()
+	aligned.Path = "*" + draft.Title
+	mod.displayLessonInTab(aligned)
+}
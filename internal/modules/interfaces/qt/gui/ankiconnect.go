@@ -0,0 +1,142 @@
+package gui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	ankiconnect "github.com/LaPingvino/recuerdo/internal/modules/logic/ankiConnect"
+	"github.com/mappu/miqt/qt"
+)
+
+// sendToAnki adds the items of the lesson shown as notes to a deck of a
+// running Anki, through the AnkiConnect add-on
+func (mod *GuiModule) sendToAnki() {
+	const title = "Send to Anki"
+	index := mod.tabWidget.CurrentIndex()
+	if index < 0 || index >= len(mod.lessonTabs) {
+		mod.statusBar.ShowMessage("No lesson open to send")
+		return
+	}
+	module, ok := mod.manager.GetDefaultModule("ankiConnect")
+	if !ok {
+		qt.QMessageBox_Warning(mod.mainWindow.QWidget, title, "Sending to Anki is not available.")
+		return
+	}
+	client, ok := module.(*ankiconnect.AnkiConnectModule)
+	if !ok {
+		return
+	}
+	settings := mod.getStorageSettings()
+	setting := func(key string) string {
+		if settings == nil {
+			return ""
+		}
+		value, _ := settings.GetString(key)
+		return value
+	}
+	// The address may have been changed in the settings since starting
+	client.SetURL(setting(ankiconnect.URLSetting))
+	client.SetKey(setting(ankiconnect.KeySetting))
+
+	current := mod.lessonTabs[index].lesson
+	mod.statusBar.ShowMessage("Connecting to Anki...")
+	var decks, models []string
+	mod.inBackground(func() error {
+		var err error
+		if decks, err = client.Decks(context.Background()); err != nil {
+			return err
+		}
+		models, err = client.Models(context.Background())
+		return err
+	}, func(err error) {
+		mod.statusBar.ClearMessage()
+		if err != nil {
+			qt.QMessageBox_Warning(mod.mainWindow.QWidget, title, err.Error())
+			return
+		}
+		deck := setting(ankiconnect.DeckSetting)
+		if deck == "" {
+			deck = lessonTitle(current)
+		}
+		model := setting(ankiconnect.ModelSetting)
+		if model == "" {
+			model = "Basic"
+		}
+		deck, model, ok := mod.askAnkiDeck(title, decks, models, deck, model)
+		if !ok {
+			return
+		}
+		if settings != nil {
+			settings.SetSetting(ankiconnect.DeckSetting, deck)
+			settings.SetSetting(ankiconnect.ModelSetting, model)
+		}
+
+		// The lesson is sent off the GUI thread, so with its own items
+		lessonData := current.Data
+		lessonData.List.Items = slices.Clone(current.Data.List.Items)
+		mod.statusBar.ShowMessage(fmt.Sprintf("Sending %s to Anki...", lessonTitle(current)))
+		var result *ankiconnect.Result
+		mod.inBackground(func() error {
+			var err error
+			result, err = client.Send(context.Background(), &lessonData, current.Path, deck, model)
+			return err
+		}, func(err error) {
+			if err != nil {
+				mod.logger.Error("Failed to send '%s' to Anki: %v", lessonTitle(current), err)
+				qt.QMessageBox_Warning(mod.mainWindow.QWidget, title, lesson.UserMessage(err))
+				mod.statusBar.ClearMessage()
+				return
+			}
+			message := fmt.Sprintf("Sent %s to %s: %d notes added", lessonTitle(current), deck, result.Added)
+			if result.Skipped > 0 {
+				message += fmt.Sprintf(", %d already there or not fitting %s", result.Skipped, model)
+			}
+			mod.statusBar.ShowMessage(message)
+		})
+	})
+}
+
+// askAnkiDeck asks which deck, an existing one or a new one, and which
+// note type to send a lesson to
+func (mod *GuiModule) askAnkiDeck(title string, decks, models []string, deck, model string) (string, string, bool) {
+	dialog := qt.NewQDialog(mod.mainWindow.QWidget)
+	dialog.SetWindowTitle(title)
+	defer dialog.DeleteLater()
+	layout := qt.NewQVBoxLayout(dialog.QWidget)
+
+	explanation := qt.NewQLabel3("Every item becomes a note in the deck, with the questions in the first field of the note type and the answers in the second. Notes the deck already has are skipped. Type a name to make a new deck; use :: for a subdeck.")
+	explanation.SetWordWrap(true)
+	layout.AddWidget(explanation.QWidget)
+
+	form := qt.NewQFormLayout2()
+	deckBox := qt.NewQComboBox(dialog.QWidget)
+	deckBox.SetEditable(true)
+	deckBox.AddItems(decks)
+	deckBox.SetCurrentText(deck)
+	deckLabel := qt.NewQLabel3("&Deck:")
+	deckLabel.SetBuddy(deckBox.QWidget)
+	form.AddRow(deckLabel.QWidget, deckBox.QWidget)
+	modelBox := qt.NewQComboBox(dialog.QWidget)
+	modelBox.AddItems(models)
+	if i := slices.Index(models, model); i >= 0 {
+		modelBox.SetCurrentIndex(i)
+	}
+	modelLabel := qt.NewQLabel3("&Note type:")
+	modelLabel.SetBuddy(modelBox.QWidget)
+	form.AddRow(modelLabel.QWidget, modelBox.QWidget)
+	layout.AddLayout(form.QLayout)
+
+	buttons := qt.NewQDialogButtonBox(dialog.QWidget)
+	buttons.SetStandardButtons(qt.QDialogButtonBox__Ok | qt.QDialogButtonBox__Cancel)
+	buttons.Button(qt.QDialogButtonBox__Ok).SetText("&Send")
+	buttons.OnAccepted(dialog.Accept)
+	buttons.OnRejected(dialog.Reject)
+	layout.AddWidget(buttons.QWidget)
+
+	if dialog.Exec() != int(qt.QDialog__Accepted) {
+		return "", "", false
+	}
+	return deckBox.CurrentText(), modelBox.CurrentText(), true
+}
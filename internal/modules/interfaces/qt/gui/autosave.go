@@ -0,0 +1,89 @@
+package gui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/LaPingvino/recuerdo/internal/modules/logic/autosave"
+	"github.com/mappu/miqt/qt"
+)
+
+// autosaver is the part of the autosave module the main window keeps
+// recovery copies of open lessons with
+type autosaver interface {
+	Interval() time.Duration
+	Track(l *lesson.Lesson)
+	Untrack(l *lesson.Lesson)
+	SaveDirty() (int, error)
+	Recoverable() []autosave.Snapshot
+	Discard(snapshot autosave.Snapshot) error
+}
+
+// getAutosaver returns the autosave module, or nil when there is none
+func (mod *GuiModule) getAutosaver() autosaver {
+	module, ok := mod.manager.GetDefaultModule("autosave")
+	if !ok {
+		return nil
+	}
+	saver, _ := module.(autosaver)
+	return saver
+}
+
+// startAutosave writes recovery copies of changed lessons at the interval
+// of the autosave module. The timer runs on the GUI thread, as the
+// editors change lessons there.
+func (mod *GuiModule) startAutosave() {
+	saver := mod.getAutosaver()
+	if saver == nil || saver.Interval() <= 0 {
+		return
+	}
+	timer := qt.NewQTimer2(mod.mainWindow.QObject)
+	timer.OnTimeout(func() {
+		if _, err := saver.SaveDirty(); err != nil {
+			mod.logger.Warning("Autosave failed: %v", err)
+		}
+	})
+	timer.Start(int(saver.Interval() / time.Millisecond))
+}
+
+// trackForAutosave keeps recovery copies of a lesson shown in a tab
+func (mod *GuiModule) trackForAutosave(l *lesson.Lesson) {
+	if saver := mod.getAutosaver(); saver != nil {
+		saver.Track(l)
+	}
+}
+
+// offerRecovery asks whether to restore the lessons left unsaved when
+// Recuerdo did not close properly last time
+func (mod *GuiModule) offerRecovery() {
+	saver := mod.getAutosaver()
+	if saver == nil {
+		return
+	}
+	snapshots := saver.Recoverable()
+	if len(snapshots) == 0 {
+		return
+	}
+
+	var titles []string
+	for _, snapshot := range snapshots {
+		titles = append(titles, fmt.Sprintf("• %s (%s)", snapshot.Title(), snapshot.Saved.Local().Format("2006-01-02 15:04")))
+	}
+	question := "Recuerdo did not close properly. These lessons had unsaved changes:\n\n" +
+		strings.Join(titles, "\n") + "\n\nDo you want to restore them?"
+	restore := qt.QMessageBox_Question(mod.mainWindow.QWidget, "Restore Unsaved Lessons", question) == qt.QMessageBox__Yes
+
+	for _, snapshot := range snapshots {
+		if restore {
+			mod.displayLessonInTab(snapshot.Lesson())
+		}
+		if err := saver.Discard(snapshot); err != nil {
+			mod.logger.Warning("Failed to remove recovery file: %v", err)
+		}
+	}
+	if restore {
+		mod.statusBar.ShowMessage(fmt.Sprintf("Restored %d unsaved lessons", len(snapshots)))
+	}
+}
@@ -0,0 +1,172 @@
+package gui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/mappu/miqt/qt"
+)
+
+// importFromClipboard reads a word list from a picture on the clipboard,
+// such as a screenshot of a textbook page or a Quizlet set, and opens it
+// as a new lesson once the user reviewed the rows
+func (mod *GuiModule) importFromClipboard() {
+	const title = "Import from Clipboard"
+	image := qt.QGuiApplication_Clipboard().Image()
+	if image.IsNull() {
+		qt.QMessageBox_Information(mod.mainWindow.QWidget, title, "The clipboard holds no picture. Copy a screenshot of a word list, such as a page of a textbook, and try again.")
+		return
+	}
+	// Tesseract reads text the size of a screen better enlarged
+	if image.Width() < 1600 {
+		image = image.ScaledToWidth2(image.Width()*2, qt.SmoothTransformation)
+	}
+	dir, err := os.MkdirTemp("", "recuerdo-clipboard")
+	if err != nil {
+		qt.QMessageBox_Warning(mod.mainWindow.QWidget, title, err.Error())
+		return
+	}
+	imagePath := filepath.Join(dir, "clipboard.png")
+	if !image.Save(imagePath) {
+		os.RemoveAll(dir)
+		qt.QMessageBox_Warning(mod.mainWindow.QWidget, title, "Could not read the picture on the clipboard.")
+		return
+	}
+
+	mod.statusBar.ShowMessage("Reading the picture...")
+	var draft *lesson.ImportDraft
+	mod.inBackground(func() error {
+		defer os.RemoveAll(dir)
+		var err error
+		draft, err = lesson.NewPDFImporter().DraftImage(imagePath, "Pasted Word List")
+		return err
+	}, func(err error) {
+		mod.statusBar.ClearMessage()
+		if err != nil {
+			mod.logger.Error("Failed to read a word list from the clipboard: %v", err)
+			qt.QMessageBox_Warning(mod.mainWindow.QWidget, title, fmt.Sprintf("Could not read a word list from the picture: %v", err))
+			return
+		}
+		if !mod.reviewImportDraft(title, draft) {
+			return
+		}
+		imported := lesson.NewLesson("words")
+		imported.Data = *draft.ToLessonData()
+		imported.Path = "*" + draft.Title
+		mod.displayLessonInTab(imported)
+	})
+}
+
+// importPDF reads the table in a PDF and opens it as a new lesson once the
+// user reviewed the rows, as the detector can include headings and page
+// numbers or split rows wrongly
+func (mod *GuiModule) importPDF(fileName string) {
+	const title = "Import PDF"
+	mod.statusBar.ShowMessage("Reading the PDF...")
+	var draft *lesson.ImportDraft
+	mod.inBackground(func() error {
+		var err error
+		draft, err = lesson.NewPDFImporter().Draft(fileName)
+		return err
+	}, func(err error) {
+		mod.statusBar.ClearMessage()
+		if err != nil {
+			mod.logger.Error("Failed to read a word list from '%s': %v", fileName, err)
+			qt.QMessageBox_Warning(mod.mainWindow.QWidget, title, fmt.Sprintf("Could not read a word list from %s: %v", filepath.Base(fileName), err))
+			return
+		}
+		if !mod.reviewImportDraft(title, draft) {
+			mod.statusBar.ShowMessage("Open operation cancelled")
+			return
+		}
+		imported := lesson.NewLesson("words")
+		imported.Data = *draft.ToLessonData()
+		imported.Path = "*" + draft.Title
+		mod.displayLessonInTab(imported)
+	})
+}
+
+// reviewImportDraft shows the rows recognized in a document for the user
+// to correct, leave out or accept, and updates draft with their changes.
+// It returns false when they cancelled.
+func (mod *GuiModule) reviewImportDraft(title string, draft *lesson.ImportDraft) bool {
+	dialog := qt.NewQDialog(mod.mainWindow.QWidget)
+	dialog.SetWindowTitle(title)
+	dialog.Resize(640, 480)
+	defer dialog.DeleteLater()
+	layout := qt.NewQVBoxLayout(dialog.QWidget)
+
+	form := qt.NewQFormLayout2()
+	titleEdit := qt.NewQLineEdit(dialog.QWidget)
+	titleEdit.SetText(draft.Title)
+	titleLabel := qt.NewQLabel3("&Title:")
+	titleLabel.SetBuddy(titleEdit.QWidget)
+	form.AddRow(titleLabel.QWidget, titleEdit.QWidget)
+	layout.AddLayout(form.QLayout)
+
+	explanation := qt.NewQLabel3("Check the words that were read. Correct any that were misread, and uncheck rows that are not words, such as headings.")
+	if draft.Sentences {
+		explanation.SetText("Check the sentence pairs. Correct pairs that were put together wrongly; sentences without a counterpart are unchecked.")
+	}
+	explanation.SetWordWrap(true)
+	layout.AddWidget(explanation.QWidget)
+
+	table := qt.NewQTableWidget2()
+	table.SetObjectName("importRows")
+	table.SetColumnCount(3)
+	for i, header := range []string{"Question", "Answer", "Comment"} {
+		table.SetHorizontalHeaderItem(i, qt.NewQTableWidgetItem2(header))
+	}
+	table.SetRowCount(len(draft.Rows))
+	for row, importRow := range draft.Rows {
+		question := qt.NewQTableWidgetItem2(importRow.Question)
+		question.SetCheckState(qt.Unchecked)
+		if importRow.Include {
+			question.SetCheckState(qt.Checked)
+		}
+		table.SetItem(row, 0, question)
+		table.SetItem(row, 1, qt.NewQTableWidgetItem2(importRow.Answer))
+		table.SetItem(row, 2, qt.NewQTableWidgetItem2(importRow.Comment))
+	}
+	table.ResizeColumnsToContents()
+	table.HorizontalHeader().SetStretchLastSection(true)
+	layout.AddWidget(table.QWidget)
+
+	if len(draft.Rejected) > 0 {
+		rejectedLabel := qt.NewQLabel3(fmt.Sprintf("%d lines were not split into a question and an answer and are left out:", len(draft.Rejected)))
+		layout.AddWidget(rejectedLabel.QWidget)
+		rejected := qt.NewQPlainTextEdit(dialog.QWidget)
+		rejected.SetReadOnly(true)
+		rejected.SetMaximumHeight(80)
+		for _, line := range draft.Rejected {
+			rejected.AppendPlainText(line)
+		}
+		rejectedLabel.SetBuddy(rejected.QWidget)
+		layout.AddWidget(rejected.QWidget)
+	}
+
+	buttons := qt.NewQDialogButtonBox(dialog.QWidget)
+	buttons.SetStandardButtons(qt.QDialogButtonBox__Ok | qt.QDialogButtonBox__Cancel)
+	buttons.Button(qt.QDialogButtonBox__Ok).SetText("&Import")
+	buttons.OnAccepted(dialog.Accept)
+	buttons.OnRejected(dialog.Reject)
+	layout.AddWidget(buttons.QWidget)
+
+	if dialog.Exec() != int(qt.QDialog__Accepted) {
+		return false
+	}
+	if title := titleEdit.Text(); title != "" {
+		draft.Title = title
+	}
+	for row := range draft.Rows {
+		draft.Rows[row] = lesson.ImportRow{
+			Question: table.Item(row, 0).Text(),
+			Answer:   table.Item(row, 1).Text(),
+			Comment:  table.Item(row, 2).Text(),
+			Include:  table.Item(row, 0).CheckState() == qt.Checked,
+		}
+	}
+	return true
+}
@@ -0,0 +1,67 @@
+package gui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/mappu/miqt/qt"
+)
+
+// fillExportPresetMenu lists the export presets in menu. It is called each
+// time the menu opens, so presets added to the presets file show up without
+// a restart.
+func (mod *GuiModule) fillExportPresetMenu(menu *qt.QMenu) {
+	menu.Clear()
+	presets, err := lesson.LoadExportPresets(lesson.DefaultExportPresetsPath())
+	if err != nil {
+		mod.logger.Error("Failed to load export presets: %v", err)
+	}
+	for _, preset := range presets {
+		action := menu.AddAction(preset.Name + "...")
+		action.SetStatusTip(preset.Description)
+		action.OnTriggered(func() {
+			mod.logger.Event("Export preset %q menu action triggered", preset.Name)
+			mod.exportWithPreset(preset)
+		})
+	}
+}
+
+// exportWithPreset asks where to export the lesson shown and writes every
+// format of the preset there
+func (mod *GuiModule) exportWithPreset(preset lesson.ExportPreset) {
+	if mod.tabWidget == nil {
+		return
+	}
+	index := mod.tabWidget.CurrentIndex()
+	if index < 0 || index >= len(mod.lessonTabs) {
+		mod.statusBar.ShowMessage("No lesson open to export")
+		return
+	}
+	current := mod.lessonTabs[index].lesson
+
+	base := "lesson"
+	if current.Path != "" {
+		base = strings.TrimSuffix(current.Path, filepath.Ext(current.Path))
+	} else if current.Data.List.Title != "" {
+		base = current.Data.List.Title
+	}
+	// The dialog asks for the first file; the others are written next to it
+	// with the same name
+	suggested := preset.ExportPaths(base, current.DataType)[0]
+	ext := filepath.Ext(suggested)
+	filter := fmt.Sprintf("%s (*%s)", preset.Name, ext)
+	fileName := qt.QFileDialog_GetSaveFileName4(mod.mainWindow.QWidget, "Export: "+preset.Name, suggested, filter)
+	if fileName == "" {
+		return
+	}
+	base = strings.TrimSuffix(fileName, filepath.Ext(fileName))
+
+	files, err := lesson.NewFileSaver().ExportWithPreset(&current.Data, current.DataType, preset, base)
+	if err != nil {
+		qt.QMessageBox_Warning(mod.mainWindow.QWidget, "Export: "+preset.Name, fmt.Sprintf("Could not export the lesson: %v", err))
+		return
+	}
+	mod.statusBar.ShowMessage(fmt.Sprintf("Exported to %s", strings.Join(files, ", ")))
+}
@@ -0,0 +1,72 @@
+package gui
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/mappu/miqt/qt"
+)
+
+// showExportStyleDialog lets the user choose the theme HTML exports of the
+// lesson shown are styled with, and add a stylesheet of their own
+func (mod *GuiModule) showExportStyleDialog() {
+	if mod.tabWidget == nil {
+		return
+	}
+	index := mod.tabWidget.CurrentIndex()
+	if index < 0 || index >= len(mod.lessonTabs) {
+		mod.statusBar.ShowMessage("No lesson open to style")
+		return
+	}
+	lessonData := &mod.lessonTabs[index].lesson.Data
+
+	dialog := qt.NewQDialog(mod.mainWindow.QWidget)
+	dialog.SetWindowTitle("Export Style")
+	dialog.Resize(500, 400)
+	layout := qt.NewQVBoxLayout(dialog.QWidget)
+	form := qt.NewQFormLayout2()
+	layout.AddLayout(form.QLayout)
+
+	themeCombo := qt.NewQComboBox(dialog.QWidget)
+	themeCombo.AddItems(lesson.HTMLThemes())
+	themeCombo.SetCurrentText(lessonData.HTMLTheme())
+	form.AddRow3("Theme:", themeCombo.QWidget)
+
+	styleEdit := qt.NewQPlainTextEdit(dialog.QWidget)
+	styleEdit.SetPlainText(lessonData.HTMLStyleSheet())
+	styleEdit.SetPlaceholderText(".title { color: #004b87; }")
+	styleEdit.SetToolTip("CSS added after the theme, for example your school's colours and logo")
+	form.AddRow3("Own stylesheet:", styleEdit.QWidget)
+
+	loadButton := qt.NewQPushButton3("&Load Stylesheet...")
+	loadButton.OnClicked(func() {
+		fileName := qt.QFileDialog_GetOpenFileName4(dialog.QWidget, "Load Stylesheet", "", "Stylesheets (*.css);;All Files (*)")
+		if fileName == "" {
+			return
+		}
+		css, err := os.ReadFile(fileName)
+		if err != nil {
+			qt.QMessageBox_Warning(dialog.QWidget, "Load Stylesheet", fmt.Sprintf("Could not read %s: %v", fileName, err))
+			return
+		}
+		styleEdit.SetPlainText(string(css))
+	})
+	form.AddRow3("", loadButton.QWidget)
+
+	buttons := qt.NewQDialogButtonBox(dialog.QWidget)
+	buttons.SetStandardButtons(qt.QDialogButtonBox__Ok | qt.QDialogButtonBox__Cancel)
+	buttons.OnAccepted(dialog.Accept)
+	buttons.OnRejected(dialog.Reject)
+	layout.AddWidget(buttons.QWidget)
+
+	if dialog.Exec() != int(qt.QDialog__Accepted) {
+		return
+	}
+	if err := lessonData.SetHTMLTheme(themeCombo.CurrentText()); err != nil {
+		mod.logger.Error("Failed to set export theme: %v", err)
+		return
+	}
+	lessonData.SetHTMLStyleSheet(styleEdit.ToPlainText())
+	mod.statusBar.ShowMessage("HTML exports of this lesson use the " + lessonData.HTMLTheme() + " theme")
+}
@@ -0,0 +1,54 @@
+package gui
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// lockLessonTab locks the file of the lesson in the tab at index, so other
+// Recuerdo windows and "recuerdo serve" open it read-only. When one of them
+// holds the lock already, the tab is marked read-only instead.
+func (mod *GuiModule) lockLessonTab(index int) {
+	tab := &mod.lessonTabs[index]
+	if tab.lesson.Path == "" {
+		return
+	}
+	lock, err := lesson.LockFile(tab.lesson.Path)
+	var locked *lesson.ErrLocked
+	switch {
+	case errors.As(err, &locked):
+		tab.lockedBy = &locked.Lock
+		mod.tabWidget.SetTabText(index, tabTitle(*tab))
+		mod.tabWidget.SetTabToolTip(index, locked.Error())
+		mod.statusBar.ShowMessage(fmt.Sprintf("Opened read-only, locked by %s", locked.Lock))
+		mod.logger.Warning("Opened '%s' read-only: %v", tab.lesson.Path, err)
+	case err != nil:
+		// A lesson on a read-only medium cannot be locked, nor saved
+		mod.logger.Warning("Failed to lock '%s': %v", tab.lesson.Path, err)
+	default:
+		tab.lock = lock
+	}
+}
+
+// unlockLessonTabs lets go of the locks on the files of all tabs
+func (mod *GuiModule) unlockLessonTabs() {
+	for i := range mod.lessonTabs {
+		if lock := mod.lessonTabs[i].lock; lock != nil {
+			if err := lock.Unlock(); err != nil {
+				mod.logger.Warning("Failed to unlock '%s': %v", mod.lessonTabs[i].lesson.Path, err)
+			}
+			mod.lessonTabs[i].lock = nil
+		}
+	}
+}
+
+// tabTitle returns the text of the tab of a lesson, which says when another
+// program has the lesson locked
+func tabTitle(tab lessonTab) string {
+	if tab.lockedBy != nil {
+		return lessonTitle(tab.lesson) + " (read-only)"
+	}
+	return lessonTitle(tab.lesson)
+}
@@ -0,0 +1,149 @@
+package gui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/mappu/miqt/qt"
+)
+
+// fileWatcher is the part of the fileWatcher module the main window
+// notices lesson files changed by other programs with
+type fileWatcher interface {
+	Watch(filePath string) error
+	Pending() []string
+	SetConfirmOverwrite(confirm func(filePath string) bool)
+}
+
+// getFileWatcher returns the fileWatcher module, or nil when there is none
+func (mod *GuiModule) getFileWatcher() fileWatcher {
+	module, ok := mod.manager.GetDefaultModule("fileWatcher")
+	if !ok {
+		return nil
+	}
+	watcher, _ := module.(fileWatcher)
+	return watcher
+}
+
+// startFileWatching asks whether to reload lessons other programs changed,
+// and whether to overwrite those changes when saving. Changes are looked
+// for on a timer, so the questions are asked on the GUI thread.
+func (mod *GuiModule) startFileWatching() {
+	watcher := mod.getFileWatcher()
+	if watcher == nil {
+		return
+	}
+	watcher.SetConfirmOverwrite(func(filePath string) bool {
+		question := fmt.Sprintf("%s was changed by another program since it was opened.\n\nDo you want to overwrite those changes?", filepath.Base(filePath))
+		return qt.QMessageBox_Question(mod.mainWindow.QWidget, "File Changed", question) == qt.QMessageBox__Yes
+	})
+
+	timer := qt.NewQTimer2(mod.mainWindow.QObject)
+	timer.OnTimeout(func() {
+		for _, filePath := range watcher.Pending() {
+			mod.offerReload(filePath)
+		}
+	})
+	timer.Start(1000)
+}
+
+// watchLessonFile starts noticing changes other programs make to the file
+// of a lesson shown in a tab
+func (mod *GuiModule) watchLessonFile(l *lesson.Lesson) {
+	if l.Path == "" || strings.HasPrefix(l.Path, "*") {
+		return
+	}
+	if watcher := mod.getFileWatcher(); watcher != nil {
+		if err := watcher.Watch(l.Path); err != nil {
+			mod.logger.Warning("Cannot notice changes to %s: %v", l.Path, err)
+		}
+	}
+}
+
+// offerReload asks whether to reload the tabs showing a file another
+// program changed, or to keep the version in Recuerdo
+func (mod *GuiModule) offerReload(filePath string) {
+	for i, tab := range mod.lessonTabs {
+		if path, err := filepath.Abs(tab.lesson.Path); err != nil || path != filePath {
+			continue
+		}
+
+		text := fmt.Sprintf("%s was changed by another program.\n\nDo you want to reload it?", lessonTitle(tab.lesson))
+		if tab.lesson.Data.Changed {
+			text = fmt.Sprintf("%s was changed by another program, and you changed it too.\n\nReload it and lose your changes, or keep your version?", lessonTitle(tab.lesson))
+		}
+		box := qt.NewQMessageBox(mod.mainWindow.QWidget)
+		box.SetWindowTitle("File Changed")
+		box.SetIcon(qt.QMessageBox__Warning)
+		box.SetText(text)
+		reload := box.AddButton2("&Reload", qt.QMessageBox__AcceptRole)
+		keep := box.AddButton2("&Keep My Version", qt.QMessageBox__RejectRole)
+		box.SetDefaultButton(keep)
+		box.Exec()
+		if box.ClickedButton().UnsafePointer() == reload.UnsafePointer() {
+			mod.reloadLessonTab(i)
+		} else {
+			mod.statusBar.ShowMessage(fmt.Sprintf("Kept your version of %s", lessonTitle(tab.lesson)))
+		}
+	}
+}
+
+// reloadLessonTab loads the lesson of a tab from its file again, replacing
+// the widget showing it
+func (mod *GuiModule) reloadLessonTab(index int) {
+	old := mod.lessonTabs[index].lesson
+	lessonData, err := lesson.NewFileLoader().LoadFile(old.Path)
+	if err != nil {
+		mod.logger.Error("Failed to reload '%s': %v", old.Path, err)
+		mod.statusBar.ShowMessage(fmt.Sprintf("Error reloading file: %v", err))
+		return
+	}
+	reloaded := lesson.NewLesson(old.DataType)
+	reloaded.Data = *lessonData
+	reloaded.Path = old.Path
+	mod.replaceLessonTab(index, reloaded)
+	mod.statusBar.ShowMessage(fmt.Sprintf("Reloaded %s", lessonTitle(reloaded)))
+}
+
+// replaceLessonTab shows reloaded, a new version of the lesson of a tab,
+// in its place
+func (mod *GuiModule) replaceLessonTab(index int, reloaded *lesson.Lesson) {
+	old := mod.lessonTabs[index].lesson
+
+	// The tab keeps the lock on the file, or stays read-only
+	tab := mod.lessonTabs[index]
+	tab.lesson = reloaded
+	widget, session := mod.createLessonWidget(reloaded)
+	tab.session = session
+	current := mod.tabWidget.CurrentIndex()
+	oldWidget := mod.tabWidget.Widget(index)
+	mod.tabWidget.RemoveTab(index)
+	mod.tabWidget.InsertTab(index, widget, tabTitle(tab))
+	if tab.lockedBy != nil {
+		mod.tabWidget.SetTabToolTip(index, "Locked by "+tab.lockedBy.String())
+	}
+	mod.tabWidget.SetCurrentIndex(current)
+	oldWidget.DeleteLater()
+	mod.lessonTabs[index] = tab
+	mod.untrackLesson(old)
+	mod.trackLessonTab(index)
+	mod.currentTabChanged(mod.tabWidget.CurrentIndex())
+
+	if saver := mod.getAutosaver(); saver != nil {
+		saver.Untrack(old)
+		saver.Track(reloaded)
+	}
+	mod.watchLessonFile(reloaded)
+	mod.labelForAccessibility(widget)
+}
+
+// lessonTitle returns the title of a lesson, or the name of its file when
+// it has none
+func lessonTitle(l *lesson.Lesson) string {
+	if l.Data.List.Title != "" {
+		return l.Data.List.Title
+	}
+	return filepath.Base(l.Path)
+}
@@ -10,16 +10,23 @@ package gui
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"path/filepath"
+	"strings"
 
+	"github.com/LaPingvino/recuerdo/internal/classroom"
 	"github.com/LaPingvino/recuerdo/internal/core"
 	"github.com/LaPingvino/recuerdo/internal/lesson"
 	"github.com/LaPingvino/recuerdo/internal/logging"
 	"github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/lessons/media"
+	"github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/lessons/occlusion"
 	"github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/lessons/topo"
 	"github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/lessons/words"
+	featureflags "github.com/LaPingvino/recuerdo/internal/modules/logic/featureFlags"
+	reviewhistory "github.com/LaPingvino/recuerdo/internal/modules/logic/reviewHistory"
+	syncclient "github.com/LaPingvino/recuerdo/internal/modules/logic/syncClient"
 	"github.com/mappu/miqt/qt"
 )
 
@@ -37,6 +44,10 @@ type GuiModule struct {
 	logger         *logging.Logger
 	addingTab      bool
 	showingDialog  bool
+	lessonTabs     []lessonTab
+	// remoteFiles are the lessons opened from or saved to a storage, by
+	// the path of their local copy
+	remoteFiles map[string]remoteFile
 }
 
 // NewGuiModule creates a new GuiModule instance
@@ -45,8 +56,9 @@ func NewGuiModule() *GuiModule {
 	base.SetRequires("qtApp")
 
 	return &GuiModule{
-		BaseModule: base,
-		logger:     logging.GetModuleLogger("GUI"),
+		BaseModule:  base,
+		logger:      logging.GetModuleLogger("GUI"),
+		remoteFiles: make(map[string]remoteFile),
 	}
 }
 
@@ -73,6 +85,8 @@ func (mod *GuiModule) Enable(ctx context.Context) error {
 		return fmt.Errorf("qtApp module does not provide GetApplication method")
 	}
 
+	mod.setupClassroom()
+
 	// Create main window
 	mod.mainWindow = qt.NewQMainWindow(nil)
 	mod.mainWindow.SetWindowTitle("OpenTeacher 4.0")
@@ -100,6 +114,17 @@ func (mod *GuiModule) Enable(ctx context.Context) error {
 	// Show the window
 	mod.mainWindow.Show()
 
+	mod.startAutosave()
+	mod.startFileWatching()
+	mod.startSubscriptionChecks()
+	mod.startTransferStatus()
+	mod.startLessonTracking()
+	mod.offerRecovery()
+	mod.restoreSession()
+	mod.watchActivity()
+	mod.startTouchMode()
+	mod.labelForAccessibility(mod.mainWindow.QWidget)
+
 	mod.logger.Success("Qt main window created and shown")
 	fmt.Println("GuiModule enabled - Main window created")
 	return nil
@@ -125,7 +150,9 @@ func (mod *GuiModule) Disable(ctx context.Context) error {
 	}
 
 	// Clean up tab widget
+	mod.unlockLessonTabs()
 	mod.tabWidget = nil
+	mod.lessonTabs = nil
 
 	// Don't quit the app - that's managed by qtApp module
 	mod.app = nil
@@ -231,20 +258,56 @@ func (mod *GuiModule) createMenuBar() {
 		mod.showOpenDialogFrom("MENU")
 	})
 
+	openEncodingAction := fileMenu.AddAction("Open With E&ncoding...")
+	openEncodingAction.OnTriggered(mod.openWithEncoding)
+
 	fileMenu.AddSeparator()
 
 	saveAction := fileMenu.AddAction("&Save")
 	saveAction.SetShortcut(qt.NewQKeySequence2("Ctrl+S"))
-	saveAction.SetEnabled(false) // Enable when lesson is loaded
 	saveAction.OnTriggered(func() {
 		mod.logger.Event("Save menu action triggered")
+		mod.saveLesson()
 	})
 
 	saveAsAction := fileMenu.AddAction("Save &As...")
 	saveAsAction.SetShortcut(qt.NewQKeySequence2("Ctrl+Shift+S"))
-	saveAsAction.SetEnabled(false) // Enable when lesson is loaded
 	saveAsAction.OnTriggered(func() {
 		mod.logger.Event("Save As menu action triggered")
+		mod.saveLessonAs()
+	})
+
+	fileMenu.AddSeparator()
+
+	openFromMenu := fileMenu.AddMenuWithTitle("Open Fro&m")
+	saveToMenu := fileMenu.AddMenuWithTitle("Save &To")
+	setUpStorageMenu := fileMenu.AddMenuWithTitle("Set &Up Storage")
+	mod.fillStorageMenus(openFromMenu, saveToMenu, setUpStorageMenu)
+
+	fileMenu.AddSeparator()
+
+	studySheetAction := fileMenu.AddAction("Export Study S&heet...")
+	studySheetAction.OnTriggered(func() {
+		mod.logger.Event("Export Study Sheet menu action triggered")
+		mod.showStudySheetDialog()
+	})
+
+	exportPresetMenu := fileMenu.AddMenuWithTitle("Export &With Preset")
+	mod.fillExportPresetMenu(exportPresetMenu)
+	exportPresetMenu.OnAboutToShow(func() {
+		mod.fillExportPresetMenu(exportPresetMenu)
+	})
+
+	ankiAction := fileMenu.AddAction("Send to An&ki...")
+	ankiAction.OnTriggered(func() {
+		mod.logger.Event("Send to Anki menu action triggered")
+		mod.sendToAnki()
+	})
+
+	subscriptionsMenu := fileMenu.AddMenuWithTitle("Su&bscriptions")
+	mod.fillSubscriptionsMenu(subscriptionsMenu)
+	subscriptionsMenu.OnAboutToShow(func() {
+		mod.fillSubscriptionsMenu(subscriptionsMenu)
 	})
 
 	fileMenu.AddSeparator()
@@ -267,6 +330,12 @@ func (mod *GuiModule) createMenuBar() {
 		mod.showPropertiesDialog()
 	})
 
+	exportStyleAction := editMenu.AddAction("Export &Style...")
+	exportStyleAction.OnTriggered(func() {
+		mod.logger.Event("Export Style menu action triggered")
+		mod.showExportStyleDialog()
+	})
+
 	// Tools menu
 	toolsMenu := qt.NewQMenu2()
 	toolsMenu.SetTitle("&Tools")
@@ -286,6 +355,82 @@ func (mod *GuiModule) createMenuBar() {
 		mod.logger.Warning("Import functionality not yet implemented")
 	})
 
+	clipboardImportAction := toolsMenu.AddAction("Import from Clip&board")
+	clipboardImportAction.SetShortcut(qt.NewQKeySequence2("Ctrl+Shift+V"))
+	clipboardImportAction.OnTriggered(func() {
+		mod.logger.Event("Import from Clipboard menu action triggered")
+		mod.importFromClipboard()
+	})
+
+	alignAction := toolsMenu.AddAction("&Align Bilingual Texts...")
+	alignAction.OnTriggered(func() {
+		mod.logger.Event("Align Bilingual Texts menu action triggered")
+		mod.alignBilingualTexts()
+	})
+
+	if mod.featureEnabled(featureflags.ClassroomServer) {
+		rosterAction := toolsMenu.AddAction("Class &Roster...")
+		rosterAction.OnTriggered(func() {
+			mod.logger.Event("Class Roster menu action triggered")
+			mod.showTeacherPanel()
+		})
+		liveTestAction := toolsMenu.AddAction("Take &Live Test...")
+		liveTestAction.OnTriggered(func() {
+			mod.logger.Event("Take Live Test menu action triggered")
+			mod.showLiveTest()
+		})
+	}
+
+	compareAction := toolsMenu.AddAction("&Compare Lessons...")
+	compareAction.OnTriggered(func() {
+		mod.logger.Event("Compare Lessons menu action triggered")
+		mod.showCompareDialog()
+	})
+
+	duplicatesAction := toolsMenu.AddAction("Find &Duplicates...")
+	duplicatesAction.OnTriggered(func() {
+		mod.logger.Event("Find Duplicates menu action triggered")
+		mod.showDuplicatesDialog()
+	})
+
+	statisticsAction := toolsMenu.AddAction("S&tatistics...")
+	statisticsAction.OnTriggered(func() {
+		mod.logger.Event("Statistics menu action triggered")
+		mod.showStatisticsDialog()
+	})
+
+	transfersAction := toolsMenu.AddAction("Tra&nsfers...")
+	transfersAction.OnTriggered(func() {
+		mod.logger.Event("Transfers menu action triggered")
+		mod.showTransfersPanel()
+	})
+
+	toolsMenu.AddSeparator()
+
+	syncLessonAction := toolsMenu.AddAction("S&ync Lesson")
+	syncLessonAction.OnTriggered(func() {
+		mod.logger.Event("Sync Lesson menu action triggered")
+		mod.syncLesson()
+	})
+
+	syncHistoryAction := toolsMenu.AddAction("Sync &Review History")
+	syncHistoryAction.OnTriggered(func() {
+		mod.logger.Event("Sync Review History menu action triggered")
+		mod.syncReviewHistory()
+	})
+
+	exportSyncAction := toolsMenu.AddAction("&Export Sync Data...")
+	exportSyncAction.OnTriggered(func() {
+		mod.logger.Event("Export Sync Data menu action triggered")
+		mod.exportSyncData()
+	})
+
+	deleteSyncAction := toolsMenu.AddAction("Delete Sync &Data...")
+	deleteSyncAction.OnTriggered(func() {
+		mod.logger.Event("Delete Sync Data menu action triggered")
+		mod.deleteSyncData()
+	})
+
 	// Help menu
 	helpMenu := qt.NewQMenu2()
 	helpMenu.SetTitle("&Help")
@@ -360,6 +505,26 @@ func (mod *GuiModule) createWelcomeWidget() *qt.QWidget {
 
 	layout.AddWidget(buttonsWidget)
 
+	// Daily goals, the streak and a calendar of the practice before
+	if dailyProgress := mod.createDailyProgress(); dailyProgress != nil {
+		layout.AddSpacing(20)
+		layout.AddWidget3(dailyProgress, 0, qt.AlignHCenter)
+	}
+
+	// Recently opened lessons
+	if recentlyOpened := mod.createRecentlyOpened(); recentlyOpened != nil {
+		layout.AddSpacing(20)
+		recentlyOpened.SetMinimumSize2(520, 150)
+		recentlyOpened.SetMaximumSize2(720, 300)
+		layout.AddWidget3(recentlyOpened, 0, qt.AlignHCenter)
+	}
+
+	// Search the lesson library
+	if librarySearch := mod.createLibrarySearch(); librarySearch != nil {
+		layout.AddSpacing(20)
+		layout.AddWidget3(librarySearch, 0, qt.AlignHCenter)
+	}
+
 	// Status info
 	statusLabel := qt.NewQLabel(nil)
 	statusLabel.SetText("Module system initialized successfully")
@@ -465,6 +630,12 @@ func (mod *GuiModule) showOpenDialogFrom(source string) {
 
 // loadSelectedFile loads the file selected by the user
 func (mod *GuiModule) loadSelectedFile(fileName string) {
+	mod.loadSelectedFileIn(fileName, "")
+}
+
+// loadSelectedFileIn loads the file selected by the user, reading text in
+// encoding, or in the encoding detected when it is empty
+func (mod *GuiModule) loadSelectedFileIn(fileName, encoding string) {
 	mod.logger.Action("loadSelectedFile() - loading file: %s", fileName)
 
 	// Prevent duplicate loading of the same file within 2 seconds
@@ -476,14 +647,49 @@ func (mod *GuiModule) loadSelectedFile(fileName string) {
 	mod.lastLoadedFile = fileName
 	mod.lastLoadTime = currentTime
 
+	// The rows detected in a PDF are reviewed before they become a lesson
+	if strings.ToLower(filepath.Ext(fileName)) == ".pdf" {
+		mod.importPDF(fileName)
+		return
+	}
+
 	// Create file loader
 	fileLoader := lesson.NewFileLoader()
+	if err := fileLoader.SetEncodingOverride(fileName, encoding); err != nil {
+		qt.QMessageBox_Warning(mod.mainWindow.QWidget, "Open Lesson", err.Error())
+		return
+	}
 
-	// Load the lesson data
-	lessonData, err := fileLoader.LoadFile(fileName)
+	// Load the lesson data, asking which worksheet to use for multi-sheet workbooks
+	var lessonData *lesson.LessonData
+	var err error
+	sheet, cancelled := mod.chooseXLSXSheet(fileLoader, fileName)
+	if cancelled {
+		mod.statusBar.ShowMessage("Open operation cancelled")
+		return
+	}
+	if sheet != "" {
+		lessonData, err = fileLoader.LoadXLSXSheet(fileName, sheet)
+	} else {
+		lessonData, err = fileLoader.LoadFile(fileName)
+	}
+	for errors.Is(err, lesson.ErrPassphraseRequired) || errors.Is(err, lesson.ErrWrongPassphrase) {
+		passphrase, ok := mod.askPassphrase(fileName, errors.Is(err, lesson.ErrWrongPassphrase))
+		if !ok {
+			mod.statusBar.ShowMessage("Open operation cancelled")
+			return
+		}
+		fileLoader.SetPassphrase(passphrase)
+		lessonData, err = fileLoader.LoadFile(fileName)
+	}
 	if err != nil {
 		mod.logger.Error("Failed to load file '%s': %v", fileName, err)
-		mod.statusBar.ShowMessage(fmt.Sprintf("Error loading file: %v", err))
+		if mod.offerRepair(fileName, err) {
+			return
+		}
+		message := lesson.UserMessage(err)
+		mod.statusBar.ShowMessage("Error loading file: " + message)
+		qt.QMessageBox_Warning(mod.mainWindow.QWidget, "Open Lesson", fmt.Sprintf("Could not open %s.\n\n%s", filepath.Base(fileName), message))
 		return
 	}
 
@@ -534,10 +740,46 @@ func (mod *GuiModule) loadSelectedFile(fileName string) {
 		}
 	}
 
+	mod.addToRecentlyOpened(newLesson)
+
 	// Create lesson tab and display in main window
 	mod.displayLessonInTab(newLesson)
 }
 
+// askPassphrase asks for the passphrase of an encrypted lesson, saying so
+// when the one entered before was wrong
+func (mod *GuiModule) askPassphrase(fileName string, wrong bool) (string, bool) {
+	label := fmt.Sprintf("Passphrase for %s:", filepath.Base(fileName))
+	if wrong {
+		label = "Wrong passphrase. " + label
+	}
+	ok := false
+	passphrase := qt.QInputDialog_GetText4(mod.mainWindow.QWidget, "Encrypted Lesson", label, qt.QLineEdit__Password, "", &ok)
+	return passphrase, ok && passphrase != ""
+}
+
+// chooseXLSXSheet asks the user which worksheet to import when an .xlsx file
+// has more than one. It returns no sheet when no choice is needed, and
+// cancelled when the user cancelled.
+func (mod *GuiModule) chooseXLSXSheet(fileLoader *lesson.FileLoader, fileName string) (sheet string, cancelled bool) {
+	if strings.ToLower(filepath.Ext(fileName)) != ".xlsx" {
+		return "", false
+	}
+
+	sheets, err := fileLoader.GetXLSXSheets(fileName)
+	if err != nil || len(sheets) < 2 {
+		return "", false
+	}
+
+	ok := false
+	sheet = qt.QInputDialog_GetItem4(mod.mainWindow.QWidget, "Select Worksheet",
+		"This workbook contains several worksheets. Which one should be imported?", sheets, 0, false, &ok)
+	if !ok || sheet == "" {
+		return "", true
+	}
+	return sheet, false
+}
+
 // CreateLessonFromDialogData creates a new lesson from dialog data
 func (mod *GuiModule) CreateLessonFromDialogData(data map[string]interface{}) (*lesson.Lesson, error) {
 	mod.logger.Action("CreateLessonFromDialogData() - creating lesson from dialog data")
@@ -569,13 +811,7 @@ func (mod *GuiModule) CreateLessonFromDialogData(data map[string]interface{}) (*
 	newLesson.Data.List.QuestionLanguage = questionLang
 	newLesson.Data.List.AnswerLanguage = answerLang
 
-	// Add description as metadata if provided
-	if description != "" {
-		if newLesson.Data.Resources == nil {
-			newLesson.Data.Resources = make(map[string]interface{})
-		}
-		newLesson.Data.Resources["description"] = description
-	}
+	newLesson.Data.List.Description = description
 
 	// Set path for new lesson (unsaved initially)
 	newLesson.Path = fmt.Sprintf("*%s", name) // * indicates unsaved
@@ -602,6 +838,7 @@ func (mod *GuiModule) displayLessonInTab(lesson *lesson.Lesson) {
 	// Create tab widget if it doesn't exist
 	if mod.tabWidget == nil {
 		mod.tabWidget = qt.NewQTabWidget(nil)
+		mod.tabWidget.OnCurrentChanged(mod.currentTabChanged)
 		mod.mainWindow.SetCentralWidget(mod.tabWidget.QWidget)
 		mod.logger.Success("Created central tab widget")
 	} else {
@@ -613,34 +850,44 @@ func (mod *GuiModule) displayLessonInTab(lesson *lesson.Lesson) {
 	}
 
 	// Create lesson content widget
-	lessonWidget := mod.createLessonWidget(lesson)
+	lessonWidget, session := mod.createLessonWidget(lesson)
 
 	// Create tab title
-	title := lesson.Data.List.Title
-	if title == "" {
-		title = filepath.Base(lesson.Path)
-	}
+	title := lessonTitle(lesson)
 
-	// Add the tab
+	// Add the tab; the lesson tracker hears of it once it is in
+	// lessonTabs, as adding it makes it the current tab
 	tabIndex := mod.tabWidget.AddTab(lessonWidget, title)
+	mod.labelForAccessibility(mod.tabWidget.QWidget)
+	mod.trackForAutosave(lesson)
+	mod.watchLessonFile(lesson)
+	mod.lessonTabs = append(mod.lessonTabs, lessonTab{lesson: lesson, session: session})
+	mod.trackLessonTab(len(mod.lessonTabs) - 1)
 	mod.tabWidget.SetCurrentIndex(tabIndex)
 
 	// Update status bar
 	statusMsg := fmt.Sprintf("Opened '%s' - %d words", title, lesson.Data.List.GetWordCount())
 	mod.statusBar.ShowMessage(statusMsg)
 
+	mod.lockLessonTab(len(mod.lessonTabs) - 1)
+
 	mod.logger.Success("Lesson tab created: %s (%d words)", title, lesson.Data.List.GetWordCount())
 }
 
-// createLessonWidget creates a widget to display lesson content
-func (mod *GuiModule) createLessonWidget(lesson *lesson.Lesson) *qt.QWidget {
+// createLessonWidget creates a widget to display lesson content, and
+// returns the part of it that remembers where the user was, if any
+func (mod *GuiModule) createLessonWidget(lesson *lesson.Lesson) (*qt.QWidget, sessionWidget) {
 	// Determine lesson type and create appropriate widget
 	var lessonWidget *qt.QWidget
+	var session sessionWidget
 
 	switch lesson.DataType {
 	case "topo":
 		mod.logger.Info("Creating topography lesson widget for: %s", lesson.Path)
 		topoWidget := topo.NewTopoLessonWidget(lesson, mod.mainWindow.QWidget)
+		if queue := mod.getTransferQueue(); queue != nil {
+			topoWidget.SetTransferQueue(queue)
+		}
 		lessonWidget = topoWidget.QWidget
 
 		// Validate layout after creation (will check for overlaps in strict mode)
@@ -649,6 +896,10 @@ func (mod *GuiModule) createLessonWidget(lesson *lesson.Lesson) *qt.QWidget {
 		mod.logger.Info("Creating media lesson widget for: %s", lesson.Path)
 		mediaWidget := media.NewMediaLessonWidget(lesson, mod.mainWindow.QWidget)
 		lessonWidget = mediaWidget.QWidget
+	case "occlusion":
+		mod.logger.Info("Creating image occlusion lesson widget for: %s", lesson.Path)
+		occlusionWidget := occlusion.NewOcclusionLessonWidget(lesson, mod.mainWindow.QWidget)
+		lessonWidget = occlusionWidget.QWidget
 	case "words":
 		fallthrough
 	default:
@@ -656,6 +907,7 @@ func (mod *GuiModule) createLessonWidget(lesson *lesson.Lesson) *qt.QWidget {
 		mod.logger.Info("Creating words lesson widget for: %s (type: %s)", lesson.Path, lesson.DataType)
 		wordsWidget := words.NewWordsLessonWidget(lesson, mod.mainWindow.QWidget)
 		lessonWidget = wordsWidget.QWidget
+		session = wordsWidget
 	}
 
 	// TODO: Connect lesson change signal to update window title and status
@@ -664,7 +916,7 @@ func (mod *GuiModule) createLessonWidget(lesson *lesson.Lesson) *qt.QWidget {
 	mod.logger.Info("Created lesson widget for: %s", lesson.Path)
 
 	mod.logger.Success("Created lesson widget with Enter/Teach/Results tabs")
-	return lessonWidget
+	return lessonWidget, session
 }
 
 func (mod *GuiModule) showPropertiesDialog() {
@@ -739,6 +991,101 @@ func (mod *GuiModule) showSettingsDialog() {
 	}
 }
 
+// featureEnabled reports whether an experimental feature is turned on;
+// without the feature flags module none are
+func (mod *GuiModule) featureEnabled(name string) bool {
+	module, ok := mod.manager.GetDefaultModule("featureFlags")
+	if !ok {
+		return false
+	}
+	flags, ok := module.(interface{ Enabled(name string) bool })
+	return ok && flags.Enabled(name)
+}
+
+// setupClassroom lets students ask questions about words when a classroom
+// server is configured
+func (mod *GuiModule) setupClassroom() {
+	if !mod.featureEnabled(featureflags.ClassroomServer) {
+		return
+	}
+	module, ok := mod.manager.GetDefaultModule("settings")
+	if !ok {
+		return
+	}
+	settings, ok := module.(interface {
+		GetString(key string) (string, error)
+		SetSetting(key string, value interface{}) error
+	})
+	if !ok {
+		return
+	}
+	server, _ := settings.GetString(syncclient.ServerSetting)
+	if server == "" {
+		return
+	}
+	client := classroom.NewClient(server)
+	token, _ := settings.GetString(syncclient.TokenSetting)
+	client.SetToken(token)
+	code, _ := settings.GetString(classroom.JoinCodeSetting)
+	words.SetClassroom(client, code, func(code string) {
+		if err := settings.SetSetting(classroom.JoinCodeSetting, code); err != nil {
+			mod.logger.Warning("Failed to remember join code: %v", err)
+		}
+	})
+}
+
+func (mod *GuiModule) showTeacherPanel() {
+	panelModules := mod.manager.GetModulesByType("teacherPanel")
+	if len(panelModules) == 0 {
+		mod.statusBar.ShowMessage("Error: Class roster not available")
+		return
+	}
+	if panel, ok := panelModules[0].(interface{ Showpanel(parent *qt.QWidget) }); ok {
+		panel.Showpanel(mod.mainWindow.QWidget)
+	}
+}
+
+func (mod *GuiModule) showCompareDialog() {
+	compareModules := mod.manager.GetModulesByType("compareDialog")
+	if len(compareModules) == 0 {
+		mod.statusBar.ShowMessage("Error: Lesson comparison not available")
+		return
+	}
+	if compare, ok := compareModules[0].(interface{ ShowCompareDialog(parent *qt.QWidget) }); ok {
+		compare.ShowCompareDialog(mod.mainWindow.QWidget)
+	}
+}
+
+func (mod *GuiModule) showDuplicatesDialog() {
+	duplicatesModules := mod.manager.GetModulesByType("duplicatesDialog")
+	if len(duplicatesModules) == 0 {
+		mod.statusBar.ShowMessage("Error: Duplicate finder not available")
+		return
+	}
+	if duplicates, ok := duplicatesModules[0].(interface{ ShowDuplicatesDialog(parent *qt.QWidget) }); ok {
+		duplicates.ShowDuplicatesDialog(mod.mainWindow.QWidget)
+	}
+}
+
+// showStatisticsDialog shows the statistics of the lesson in the current
+// tab, or of all lessons on the start screen
+func (mod *GuiModule) showStatisticsDialog() {
+	statisticsModules := mod.manager.GetModulesByType("statisticsDialog")
+	if len(statisticsModules) == 0 {
+		mod.statusBar.ShowMessage("Error: Statistics not available")
+		return
+	}
+	name := ""
+	if index := mod.tabWidget.CurrentIndex(); index >= 0 && index < len(mod.lessonTabs) {
+		name = reviewhistory.LessonName(&mod.lessonTabs[index].lesson.Data.List)
+	}
+	if statistics, ok := statisticsModules[0].(interface {
+		ShowStatisticsDialog(parent *qt.QWidget, lesson string)
+	}); ok {
+		statistics.ShowStatisticsDialog(mod.mainWindow.QWidget, name)
+	}
+}
+
 func (mod *GuiModule) showAboutDialog() {
 	mod.logger.Action("showAboutDialog() - attempting to show about dialog")
 
@@ -770,22 +1117,22 @@ func (mod *GuiModule) getCurrentLessonData() map[string]interface{} {
 	}
 
 	currentIndex := mod.tabWidget.CurrentIndex()
-	if currentIndex < 0 {
+	if currentIndex < 0 || currentIndex >= len(mod.lessonTabs) {
 		return nil
 	}
 
-	tabText := mod.tabWidget.TabText(currentIndex)
-
-	// Extract lesson information from the current tab
-	// This is a simplified implementation - in a full version you'd get the actual lesson data
+	list := mod.lessonTabs[currentIndex].lesson.Data.List
 	lessonData := make(map[string]interface{})
-	lessonData["name"] = tabText
-	lessonData["description"] = ""
-	lessonData["author"] = ""
-	lessonData["version"] = "1.0"
-	lessonData["itemCount"] = 0 // TODO: Get actual count from lesson
+	lessonData["name"] = mod.tabWidget.TabText(currentIndex)
+	lessonData["description"] = list.Description
+	lessonData["author"] = list.Author
+	lessonData["tags"] = strings.Join(list.Tags, ", ")
+	lessonData["license"] = list.License
+	lessonData["level"] = list.Level
+	lessonData["sync"] = list.Sync
+	lessonData["itemCount"] = len(list.Items)
 
-	mod.logger.Debug("Retrieved current lesson data for: %s", tabText)
+	mod.logger.Debug("Retrieved current lesson data for: %s", lessonData["name"])
 
 	return lessonData
 }
@@ -797,17 +1144,35 @@ func (mod *GuiModule) updateCurrentLessonData(data map[string]interface{}) {
 	}
 
 	currentIndex := mod.tabWidget.CurrentIndex()
-	if currentIndex < 0 {
+	if currentIndex < 0 || currentIndex >= len(mod.lessonTabs) {
 		return
 	}
+	lessonData := &mod.lessonTabs[currentIndex].lesson.Data
 
 	// Update tab title if name changed
 	if name, ok := data["name"].(string); ok && name != "" {
 		mod.tabWidget.SetTabText(currentIndex, name)
+		lessonData.List.Title = name
 		mod.logger.Info("Updated lesson name to: %s", name)
 	}
 
-	// TODO: Update actual lesson data in the lesson widget
+	level, _ := data["level"].(string)
+	level, err := lesson.ParseCEFRLevel(level)
+	if err != nil {
+		mod.logger.Warning("Ignoring lesson level: %v", err)
+		level = lessonData.List.Level
+	}
+	tags, _ := data["tags"].(string)
+	lessonData.List.Tags = lesson.ParseTags(tags)
+	lessonData.List.Description, _ = data["description"].(string)
+	lessonData.List.Author, _ = data["author"].(string)
+	lessonData.List.License, _ = data["license"].(string)
+	lessonData.List.Level = level
+	if scope, ok := data["sync"].(string); ok {
+		lessonData.List.Sync = lesson.ParseSyncScope(scope)
+	}
+	lessonData.Changed = true
+
 	mod.logger.Info("Lesson properties updated successfully")
 }
 
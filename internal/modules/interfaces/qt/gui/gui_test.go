@@ -122,10 +122,8 @@ func TestCreateLessonFromDialogData(t *testing.T) {
 
 			// Check description metadata if provided
 			if desc, exists := tt.dialogData["description"]; exists && desc != "" {
-				if newLesson.Data.Resources == nil {
-					t.Error("Expected Resources map to be initialized")
-				} else if storedDesc, ok := newLesson.Data.Resources["description"]; !ok || storedDesc != desc {
-					t.Errorf("Expected description %q in resources, got %q", desc, storedDesc)
+				if newLesson.Data.List.Description != desc {
+					t.Errorf("Expected description %q, got %q", desc, newLesson.Data.List.Description)
 				}
 			}
 		})
@@ -0,0 +1,168 @@
+package gui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/LaPingvino/recuerdo/internal/library"
+	"github.com/LaPingvino/recuerdo/internal/thumbnail"
+	"github.com/mappu/miqt/qt"
+)
+
+// lessonLibrary is the part of the library module the start screen searches
+// lessons with
+type lessonLibrary interface {
+	Directory() string
+	SetDirectory(dir string) (library.IndexStats, error)
+	Reindex() (library.IndexStats, error)
+	Search(query string) ([]library.Result, error)
+	Count() (int, error)
+	Thumbnail(path string) (string, error)
+}
+
+// createLibrarySearch creates the start screen panel that opens a lesson by
+// searching for a word it contains. It returns nil when there is no library
+// module.
+func (mod *GuiModule) createLibrarySearch() *qt.QWidget {
+	libraryModules := mod.manager.GetModulesByType("library")
+	if len(libraryModules) == 0 {
+		return nil
+	}
+	lib, ok := libraryModules[0].(lessonLibrary)
+	if !ok {
+		return nil
+	}
+
+	widget := qt.NewQWidget(nil)
+	widget.SetMaximumWidth(640)
+	layout := qt.NewQVBoxLayout(widget)
+
+	searchEdit := qt.NewQLineEdit(widget)
+	searchEdit.SetPlaceholderText("Search your lessons by any word, tag, author or level")
+	searchEdit.SetClearButtonEnabled(true)
+	layout.AddWidget(searchEdit.QWidget)
+
+	resultsList := qt.NewQListWidget(widget)
+	resultsList.SetIconSize(qt.NewQSize2(thumbnail.IconWidth, thumbnail.IconHeight))
+	resultsList.SetVisible(false)
+	layout.AddWidget(resultsList.QWidget)
+
+	footer := qt.NewQHBoxLayout2()
+	statusLabel := qt.NewQLabel(widget)
+	statusLabel.SetStyleSheet("color: #888;")
+	footer.AddWidget(statusLabel.QWidget)
+	footer.AddStretch()
+	folderButton := qt.NewQPushButton3("Library Folder...")
+	footer.AddWidget(folderButton.QWidget)
+	rescanButton := qt.NewQPushButton3("Rescan")
+	footer.AddWidget(rescanButton.QWidget)
+	layout.AddLayout(footer.QLayout)
+
+	var results []library.Result
+	showCount := func() {
+		count, err := lib.Count()
+		if err != nil {
+			statusLabel.SetText(err.Error())
+			return
+		}
+		statusLabel.SetText(fmt.Sprintf("%d lessons in %s", count, lib.Directory()))
+	}
+	search := func(query string) {
+		resultsList.Clear()
+		found, err := lib.Search(query)
+		results = found
+		if err != nil {
+			statusLabel.SetText(err.Error())
+			return
+		}
+		resultsList.SetVisible(query != "")
+		for _, result := range results {
+			text := fmt.Sprintf("%s (%d words)", result.Title, result.Items)
+			if result.Level != "" {
+				text += " " + result.Level
+			}
+			if len(result.Tags) > 0 {
+				text += " #" + strings.Join(result.Tags, " #")
+			}
+			if badge := mod.syncBadge(result.Sync); badge != "" {
+				text += " [" + badge + "]"
+			}
+			if result.Snippet != "" {
+				text += " – " + result.Snippet
+			}
+			item := qt.NewQListWidgetItem7(text, resultsList)
+			tooltip := result.Path
+			if result.Author != "" {
+				tooltip += "\nBy " + result.Author
+			}
+			item.SetToolTip(tooltip)
+			if icon, err := lib.Thumbnail(result.Path); err == nil {
+				item.SetIcon(qt.NewQIcon4(icon))
+			}
+		}
+		if query == "" {
+			showCount()
+		} else {
+			statusLabel.SetText(fmt.Sprintf("%d lessons found", len(results)))
+		}
+	}
+	reindexed := func(stats library.IndexStats, err error) {
+		if err != nil {
+			mod.logger.Warning("Failed to index lesson library: %v", err)
+			statusLabel.SetText(err.Error())
+			return
+		}
+		mod.statusBar.ShowMessage(fmt.Sprintf("Library: %d added, %d updated, %d removed", stats.Added, stats.Updated, stats.Removed))
+		search(searchEdit.Text())
+	}
+
+	searchEdit.OnTextChanged(search)
+	searchEdit.OnReturnPressed(func() {
+		if len(results) > 0 {
+			mod.loadSelectedFile(results[0].Path)
+		}
+	})
+	resultsList.OnItemActivated(func(item *qt.QListWidgetItem) {
+		row := resultsList.Row(item)
+		if row < 0 || row >= len(results) {
+			return
+		}
+		mod.logger.Event("Library lesson opened: %s", filepath.Base(results[row].Path))
+		mod.loadSelectedFile(results[row].Path)
+	})
+	folderButton.OnClicked(func() {
+		dir := qt.QFileDialog_GetExistingDirectory3(widget, "Library Folder", lib.Directory())
+		if dir == "" {
+			return
+		}
+		reindexed(lib.SetDirectory(dir))
+	})
+	rescanButton.OnClicked(func() {
+		reindexed(lib.Reindex())
+	})
+
+	showCount()
+	return widget
+}
+
+// syncBadge describes which devices a lesson with the sync scope is synced
+// to, telling when this device is not one of them; lessons synced to every
+// device have no badge
+func (mod *GuiModule) syncBadge(scope string) string {
+	badge := lesson.SyncScopeLabel(scope)
+	if badge == "" || scope == lesson.SyncLocalOnly {
+		return badge
+	}
+	var groups []string
+	if module, ok := mod.manager.GetDefaultModule("syncClient"); ok {
+		if client, ok := module.(interface{ DeviceGroups() []string }); ok {
+			groups = client.DeviceGroups()
+		}
+	}
+	if !lesson.SyncsTo(scope, groups) {
+		badge += ", not this device"
+	}
+	return badge
+}
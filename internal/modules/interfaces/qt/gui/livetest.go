@@ -0,0 +1,364 @@
+package gui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/classroom"
+	"github.com/LaPingvino/recuerdo/internal/discovery"
+	syncclient "github.com/LaPingvino/recuerdo/internal/modules/logic/syncClient"
+	"github.com/mappu/miqt/qt"
+)
+
+// showLiveTest lets the student pick a teacher's session found on the
+// local network, or the configured classroom server, and join it with the
+// session PIN and their join code. The tests the teacher pushes are shown
+// until the dialog is closed.
+func (mod *GuiModule) showLiveTest() {
+	module, ok := mod.manager.GetDefaultModule("settings")
+	if !ok {
+		mod.statusBar.ShowMessage("Error: Settings not available")
+		return
+	}
+	settings, ok := module.(interface {
+		GetString(key string) (string, error)
+		SetSetting(key string, value interface{}) error
+	})
+	if !ok {
+		return
+	}
+	configured, _ := settings.GetString(syncclient.ServerSetting)
+	kept, _ := settings.GetString(classroom.JoinCodeSetting)
+
+	join := newJoinSessionDialog(mod.mainWindow.QWidget, configured, kept)
+	if join.Exec() != int(qt.QDialog__Accepted) {
+		return
+	}
+	server, pin, code := join.server(), join.pin(), join.code()
+	client := classroom.NewClient(server)
+	token, _ := settings.GetString(syncclient.TokenSetting)
+	client.SetToken(token)
+
+	if server != configured || code != kept {
+		if _, err := client.Join(code); err != nil {
+			qt.QMessageBox_Warning(mod.mainWindow.QWidget, "Live Test", fmt.Sprintf("The join code was not accepted: %v", err))
+			return
+		}
+		// The other classroom features use the session found too
+		if err := settings.SetSetting(syncclient.ServerSetting, server); err != nil {
+			mod.logger.Warning("Failed to remember classroom server: %v", err)
+		}
+		if err := settings.SetSetting(classroom.JoinCodeSetting, code); err != nil {
+			mod.logger.Warning("Failed to remember join code: %v", err)
+		}
+	}
+
+	conn, err := client.Live(code, pin)
+	if err != nil {
+		qt.QMessageBox_Warning(mod.mainWindow.QWidget, "Live Test", fmt.Sprintf("Could not join the session; check the PIN your teacher shows. (%v)", err))
+		return
+	}
+	newLiveTestDialog(mod.mainWindow.QWidget, conn).Exec()
+}
+
+// browseTime is how long the local network is searched for sessions
+const browseTime = 3 * time.Second
+
+// joinSessionDialog lists the classroom sessions on the local network and
+// asks for the session PIN and join code
+type joinSessionDialog struct {
+	*qt.QDialog
+	// servers are the URLs of the rows of sessions
+	servers  []string
+	sessions *qt.QListWidget
+	pinEdit  *qt.QLineEdit
+	codeEdit *qt.QLineEdit
+	status   *qt.QLabel
+	search   *qt.QPushButton
+	ok       *qt.QPushButton
+	found    chan []discovery.Service
+	cancel   context.CancelFunc
+	// configured is the classroom server in the settings, listed first
+	configured string
+}
+
+func newJoinSessionDialog(parent *qt.QWidget, configured, code string) *joinSessionDialog {
+	d := &joinSessionDialog{QDialog: qt.NewQDialog(parent), configured: configured}
+	d.SetWindowTitle("Join Live Test")
+	d.Resize(420, 380)
+	layout := qt.NewQVBoxLayout(d.QWidget)
+
+	sessionsLabel := qt.NewQLabel3("Sessions on this network:")
+	layout.AddWidget(sessionsLabel.QWidget)
+	d.sessions = qt.NewQListWidget(d.QWidget)
+	d.sessions.OnCurrentRowChanged(func(int) { d.updateOK() })
+	sessionsLabel.SetBuddy(d.sessions.QWidget)
+	layout.AddWidget(d.sessions.QWidget)
+
+	searchLayout := qt.NewQHBoxLayout2()
+	d.status = qt.NewQLabel3("")
+	searchLayout.AddWidget(d.status.QWidget)
+	searchLayout.AddStretch()
+	d.search = qt.NewQPushButton3("Search Again")
+	d.search.OnClicked(d.browse)
+	searchLayout.AddWidget(d.search.QWidget)
+	layout.AddLayout(searchLayout.QLayout)
+
+	form := qt.NewQFormLayout2()
+	d.pinEdit = qt.NewQLineEdit2()
+	d.pinEdit.SetMaxLength(classroom.PINLength)
+	d.pinEdit.SetPlaceholderText("The PIN your teacher shows")
+	d.pinEdit.OnTextChanged(func(string) { d.updateOK() })
+	form.AddRow3("Session PIN:", d.pinEdit.QWidget)
+	d.codeEdit = qt.NewQLineEdit2()
+	d.codeEdit.SetText(code)
+	d.codeEdit.SetPlaceholderText("Your join code")
+	d.codeEdit.OnTextChanged(func(string) { d.updateOK() })
+	form.AddRow3("Join code:", d.codeEdit.QWidget)
+	layout.AddLayout(form.QLayout)
+
+	buttons := qt.NewQDialogButtonBox(d.QWidget)
+	buttons.SetStandardButtons(qt.QDialogButtonBox__Ok | qt.QDialogButtonBox__Cancel)
+	d.ok = buttons.Button(qt.QDialogButtonBox__Ok)
+	d.ok.SetText("Join")
+	buttons.OnAccepted(d.Accept)
+	buttons.OnRejected(d.Reject)
+	layout.AddWidget(buttons.QWidget)
+
+	// Sessions are found on another goroutine, so they are taken in here
+	timer := qt.NewQTimer2(d.QObject)
+	timer.OnTimeout(d.poll)
+	timer.Start(200)
+	d.OnFinished(func(int) {
+		timer.Stop()
+		if d.cancel != nil {
+			d.cancel()
+		}
+	})
+	d.showSessions(nil)
+	d.browse()
+	d.updateOK()
+	return d
+}
+
+// browse searches the local network for sessions
+func (d *joinSessionDialog) browse() {
+	if d.cancel != nil {
+		d.cancel()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), browseTime)
+	found := make(chan []discovery.Service, 1)
+	d.cancel, d.found = cancel, found
+	d.search.SetEnabled(false)
+	d.status.SetText("Searching...")
+	go func() {
+		services, err := discovery.Browse(ctx)
+		if err != nil {
+			fmt.Printf("Warning: searching for classroom sessions failed: %v\n", err)
+		}
+		found <- services
+	}()
+}
+
+// poll shows the sessions once the search is done
+func (d *joinSessionDialog) poll() {
+	select {
+	case services := <-d.found:
+		d.found = nil
+		d.search.SetEnabled(true)
+		d.showSessions(services)
+	default:
+	}
+}
+
+// showSessions lists the configured server and the sessions found, those
+// with a live test open first
+func (d *joinSessionDialog) showSessions(services []discovery.Service) {
+	selected := ""
+	if row := d.sessions.CurrentRow(); row >= 0 && row < len(d.servers) {
+		selected = d.servers[row]
+	}
+	sort.SliceStable(services, func(i, j int) bool {
+		return services[i].Text["live"] == "1" && services[j].Text["live"] != "1"
+	})
+
+	d.sessions.Clear()
+	d.servers = d.servers[:0]
+	if d.configured != "" {
+		d.sessions.AddItem(fmt.Sprintf("Configured server (%s)", d.configured))
+		d.servers = append(d.servers, d.configured)
+	}
+	for _, service := range services {
+		server := service.URL()
+		if server == "" || server == d.configured {
+			continue
+		}
+		text := service.Name
+		if service.Text["live"] == "1" {
+			text += " - live test open"
+		}
+		d.sessions.AddItem(text)
+		d.servers = append(d.servers, server)
+	}
+	for row, server := range d.servers {
+		if server == selected || (selected == "" && row == 0) {
+			d.sessions.SetCurrentRow(row)
+		}
+	}
+
+	switch {
+	case d.found != nil:
+	case len(services) == 0:
+		d.status.SetText("No sessions found on this network")
+	default:
+		d.status.SetText(fmt.Sprintf("%d sessions found", len(services)))
+	}
+}
+
+func (d *joinSessionDialog) updateOK() {
+	row := d.sessions.CurrentRow()
+	d.ok.SetEnabled(row >= 0 && row < len(d.servers) && len(d.pin()) == classroom.PINLength && d.code() != "")
+}
+
+func (d *joinSessionDialog) server() string {
+	return d.servers[d.sessions.CurrentRow()]
+}
+
+func (d *joinSessionDialog) pin() string {
+	return strings.TrimSpace(d.pinEdit.Text())
+}
+
+func (d *joinSessionDialog) code() string {
+	return strings.ToUpper(strings.TrimSpace(d.codeEdit.Text()))
+}
+
+// liveTestDialog is where a student answers the test the teacher pushed.
+// Every answer is sent as soon as it is typed, until the test locks.
+type liveTestDialog struct {
+	*qt.QDialog
+	conn     *classroom.LiveConn
+	messages chan classroom.LiveMessage
+	deadline *time.Time
+	locked   bool
+	title    *qt.QLabel
+	scroll   *qt.QScrollArea
+	edits    []*qt.QLineEdit
+	status   *qt.QLabel
+}
+
+func newLiveTestDialog(parent *qt.QWidget, conn *classroom.LiveConn) *liveTestDialog {
+	d := &liveTestDialog{QDialog: qt.NewQDialog(parent), conn: conn, messages: make(chan classroom.LiveMessage, 64)}
+	d.SetWindowTitle("Live Test")
+	d.Resize(520, 560)
+	layout := qt.NewQVBoxLayout(d.QWidget)
+
+	d.title = qt.NewQLabel3("Waiting for your teacher to start the test...")
+	layout.AddWidget(d.title.QWidget)
+	d.scroll = qt.NewQScrollArea(d.QWidget)
+	d.scroll.SetWidgetResizable(true)
+	layout.AddWidget(d.scroll.QWidget)
+	d.status = qt.NewQLabel3("")
+	layout.AddWidget(d.status.QWidget)
+	closeBox := qt.NewQDialogButtonBox(d.QWidget)
+	closeBox.SetStandardButtons(qt.QDialogButtonBox__Close)
+	closeBox.OnRejected(d.Reject)
+	layout.AddWidget(closeBox.QWidget)
+
+	go func() {
+		defer close(d.messages)
+		for {
+			message, err := conn.Receive()
+			if err != nil {
+				return
+			}
+			d.messages <- message
+		}
+	}()
+	// Messages arrive on another goroutine, so they are taken in here
+	timer := qt.NewQTimer2(d.QObject)
+	timer.OnTimeout(d.poll)
+	timer.Start(200)
+	d.OnFinished(func(int) {
+		timer.Stop()
+		d.conn.Close()
+	})
+	return d
+}
+
+// poll takes in the messages that arrived and counts down to the deadline
+func (d *liveTestDialog) poll() {
+	for drained := false; !drained; {
+		select {
+		case message, ok := <-d.messages:
+			if !ok {
+				d.status.SetText("The connection to the classroom server was lost")
+				d.messages = nil
+				return
+			}
+			d.handle(message)
+		default:
+			drained = true
+		}
+	}
+	if d.deadline != nil && !d.locked {
+		left := time.Until(*d.deadline).Round(time.Second)
+		if left < 0 {
+			left = 0
+		}
+		d.status.SetText(fmt.Sprintf("%s left", left))
+	}
+}
+
+func (d *liveTestDialog) handle(message classroom.LiveMessage) {
+	switch message.Type {
+	case classroom.LiveTest:
+		d.showTest(message.Test)
+	case classroom.LiveLocked:
+		d.locked = true
+		for _, edit := range d.edits {
+			edit.SetReadOnly(true)
+		}
+		d.status.SetText("Time is up; your answers have been handed in.")
+	case classroom.LiveError:
+		d.status.SetText(message.Error)
+	}
+}
+
+// showTest shows the questions of a test, replacing the previous one
+func (d *liveTestDialog) showTest(test *classroom.LiveTestInfo) {
+	d.deadline = test.Deadline
+	d.locked = false
+	d.title.SetText(test.Title)
+	d.status.SetText("")
+
+	form := qt.NewQWidget2()
+	formLayout := qt.NewQFormLayout(form)
+	d.edits = d.edits[:0]
+	for _, item := range test.Items {
+		itemID := item.ID
+		edit := qt.NewQLineEdit2()
+		sent := ""
+		edit.OnEditingFinished(func() {
+			answer := strings.TrimSpace(edit.Text())
+			if d.locked || answer == "" || answer == sent {
+				return
+			}
+			err := d.conn.Send(classroom.LiveMessage{Type: classroom.LiveAnswer, Answer: &classroom.LiveAnswerInfo{ItemID: itemID, Answer: answer}})
+			if err != nil {
+				d.status.SetText(fmt.Sprintf("Your answer could not be sent: %v", err))
+				return
+			}
+			sent = answer
+		})
+		formLayout.AddRow3(strings.Join(item.Questions, ", "), edit.QWidget)
+		d.edits = append(d.edits, edit)
+	}
+	// The scroll area deletes the form of the previous test
+	d.scroll.SetWidget(form)
+	if len(d.edits) > 0 {
+		d.edits[0].SetFocus()
+	}
+}
@@ -0,0 +1,28 @@
+package gui
+
+import (
+	"github.com/mappu/miqt/qt"
+)
+
+// watchActivity tells the maintenance module about every key press, click
+// and scroll, so it only does its housekeeping while the user is away
+func (mod *GuiModule) watchActivity() {
+	module, ok := mod.manager.GetDefaultModule("maintenance")
+	if !ok {
+		return
+	}
+	maintenance, ok := module.(interface{ Touch() })
+	if !ok {
+		return
+	}
+
+	filter := qt.NewQObject2(mod.mainWindow.QObject)
+	filter.OnEventFilter(func(super func(watched *qt.QObject, event *qt.QEvent) bool, watched *qt.QObject, event *qt.QEvent) bool {
+		switch event.Type() {
+		case qt.QEvent__KeyPress, qt.QEvent__MouseButtonPress, qt.QEvent__Wheel:
+			maintenance.Touch()
+		}
+		return super(watched, event)
+	})
+	mod.app.InstallEventFilter(filter)
+}
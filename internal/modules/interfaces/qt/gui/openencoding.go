@@ -0,0 +1,43 @@
+package gui
+
+import (
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/mappu/miqt/qt"
+)
+
+// readEncodings are offered when opening a text lesson whose encoding is
+// detected wrongly; other IANA names can be typed in
+var readEncodings = []string{
+	lesson.EncodingUTF8,
+	lesson.EncodingUTF16LE,
+	lesson.EncodingUTF16BE,
+	lesson.EncodingWindows1252,
+	lesson.EncodingLatin1,
+	"iso-8859-15",
+	"koi8-r",
+	"shift_jis",
+}
+
+// openWithEncoding opens a text lesson, such as a CSV file, in the encoding
+// the user chooses instead of the one detected, for files that show garbled
+// letters when opened normally
+func (mod *GuiModule) openWithEncoding() {
+	const title = "Open With Encoding"
+	fileName := qt.QFileDialog_GetOpenFileName4(mod.mainWindow.QWidget, title, "", "Text lessons (*.csv *.tsv *.txt);;All files (*)")
+	if fileName == "" {
+		return
+	}
+	items := readEncodings
+	detected, err := lesson.NewFileLoader().DetectFileEncoding(fileName)
+	if err == nil {
+		items = append([]string{detected}, items...)
+	}
+	ok := false
+	encoding := qt.QInputDialog_GetItem4(mod.mainWindow.QWidget, title,
+		"Read the file as (the first is the encoding detected):", items, 0, true, &ok)
+	if !ok || encoding == "" {
+		mod.statusBar.ShowMessage("Open operation cancelled")
+		return
+	}
+	mod.loadSelectedFileIn(fileName, encoding)
+}
@@ -0,0 +1,138 @@
+package gui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/theme"
+	dailyprogress "github.com/LaPingvino/recuerdo/internal/modules/logic/dailyProgress"
+	"github.com/mappu/miqt/qt"
+)
+
+// calendarWeeks is how many weeks the practice calendar shows
+const calendarWeeks = 12
+
+// dailyProgress is the part of the dailyProgress module the start screen
+// shows
+type dailyProgress interface {
+	Today() dailyprogress.Day
+	Goals() dailyprogress.Goals
+	Streak() int
+	Calendar(weeks int) []dailyprogress.Day
+	OnUpdated(handler func())
+}
+
+// createDailyProgress creates the start screen panel with today's progress
+// towards the daily goals, the streak and a calendar of the last weeks
+// coloured by how much was practised. It returns nil when there is no
+// dailyProgress module.
+func (mod *GuiModule) createDailyProgress() *qt.QWidget {
+	module, ok := mod.manager.GetDefaultModule("dailyProgress")
+	if !ok {
+		return nil
+	}
+	progress, ok := module.(dailyProgress)
+	if !ok {
+		return nil
+	}
+
+	widget := qt.NewQWidget(nil)
+	layout := qt.NewQHBoxLayout(widget)
+
+	textLayout := qt.NewQVBoxLayout2()
+	streakLabel := qt.NewQLabel(widget)
+	streakFont := streakLabel.Font()
+	streakFont.SetPointSize(16)
+	streakFont.SetBold(true)
+	streakLabel.SetFont(streakFont)
+	textLayout.AddWidget(streakLabel.QWidget)
+	todayLabel := qt.NewQLabel(widget)
+	textLayout.AddWidget(todayLabel.QWidget)
+	textLayout.AddStretch()
+	layout.AddLayout(textLayout.QLayout)
+
+	layout.AddSpacing(20)
+
+	// One column per week, Monday at the top
+	grid := qt.NewQGridLayout2()
+	grid.SetSpacing(3)
+	squares := make([]*qt.QLabel, 7*calendarWeeks)
+	for i := range squares {
+		squares[i] = qt.NewQLabel(widget)
+		squares[i].SetFixedSize2(12, 12)
+		grid.AddWidget2(squares[i].QWidget, i%7, i/7)
+	}
+	layout.AddLayout(grid.QLayout)
+
+	update := func() {
+		goals := progress.Goals()
+		today := progress.Today()
+
+		streak := progress.Streak()
+		switch streak {
+		case 0:
+			streakLabel.SetText("No streak yet")
+		case 1:
+			streakLabel.SetText("1 day streak")
+		default:
+			streakLabel.SetText(fmt.Sprintf("%d day streak", streak))
+		}
+		todayLabel.SetText("Today: " + progressText(goals, today))
+
+		for i, day := range progress.Calendar(calendarWeeks) {
+			if day.Date == "" {
+				squares[i].SetStyleSheet("")
+				squares[i].SetToolTip("")
+				continue
+			}
+			squares[i].SetStyleSheet(fmt.Sprintf("background: %s; border-radius: 2px;", heatColor(goals.Fraction(day))))
+			squares[i].SetToolTip(day.Date + ": " + progressText(goals, day))
+		}
+	}
+	update()
+
+	destroyed := false
+	widget.OnDestroyed(func() { destroyed = true })
+	refresh := func() {
+		if !destroyed {
+			update()
+		}
+	}
+	progress.OnUpdated(refresh)
+	// Start a new day at midnight when Recuerdo is left open
+	timer := qt.NewQTimer2(widget.QObject)
+	timer.OnTimeout(refresh)
+	timer.Start(int(time.Minute / time.Millisecond))
+
+	return widget
+}
+
+// progressText describes what was practised on day next to the goals
+func progressText(goals dailyprogress.Goals, day dailyprogress.Day) string {
+	cards := fmt.Sprintf("%d cards", day.Cards)
+	if goals.Cards > 0 {
+		cards = fmt.Sprintf("%d/%d cards", day.Cards, goals.Cards)
+	}
+	minutes := fmt.Sprintf("%d minutes", day.Minutes())
+	if goals.Minutes > 0 {
+		minutes = fmt.Sprintf("%d/%d minutes", day.Minutes(), goals.Minutes)
+	}
+	return cards + ", " + minutes
+}
+
+// heatColor returns the colour of a calendar day on which fraction of the
+// goals was reached, from grey to the colour of right answers
+func heatColor(fraction float64) string {
+	const empty = "#ebedf0"
+	if fraction <= 0 {
+		return empty
+	}
+	full := qt.NewQColor6(theme.CurrentPalette().Right.Text)
+	blank := qt.NewQColor6(empty)
+	// Anything practised shows, however little
+	fraction = 0.25 + 0.75*min(fraction, 1)
+	mix := func(from, to int) int {
+		return from + int(float64(to-from)*fraction)
+	}
+	return qt.NewQColor3(mix(blank.Red(), full.Red()), mix(blank.Green(), full.Green()), mix(blank.Blue(), full.Blue())).Name()
+}
@@ -0,0 +1,42 @@
+package gui
+
+import (
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	recentlyopened "github.com/LaPingvino/recuerdo/internal/modules/logic/recentlyOpened"
+	"github.com/mappu/miqt/qt"
+)
+
+// createRecentlyOpened creates the start screen grid of recently opened
+// lessons. It returns nil when there is no viewer for them.
+func (mod *GuiModule) createRecentlyOpened() *qt.QWidget {
+	viewerModules := mod.manager.GetModulesByType("recentlyOpenedViewer")
+	if len(viewerModules) == 0 {
+		return nil
+	}
+	viewer, ok := viewerModules[0].(interface {
+		CreateViewer(open func(path string)) *qt.QWidget
+	})
+	if !ok {
+		return nil
+	}
+	return viewer.CreateViewer(mod.loadSelectedFile)
+}
+
+// addToRecentlyOpened puts a lesson that was just opened on the recently
+// opened list
+func (mod *GuiModule) addToRecentlyOpened(newLesson *lesson.Lesson) {
+	module, ok := mod.manager.GetDefaultModule("recentlyOpened")
+	if !ok {
+		return
+	}
+	recent, ok := module.(interface {
+		Add(entry recentlyopened.Entry, lessonData *lesson.LessonData) error
+	})
+	if !ok {
+		return
+	}
+	entry := recentlyopened.NewEntry(newLesson.Path, newLesson.DataType, &newLesson.Data)
+	if err := recent.Add(entry, &newLesson.Data); err != nil {
+		mod.logger.Warning("Failed to remember recently opened lesson: %v", err)
+	}
+}
@@ -0,0 +1,682 @@
+package gui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/LaPingvino/recuerdo/internal/paths"
+	"github.com/mappu/miqt/qt"
+)
+
+// signInTime is how long the browser is waited for when signing in to a
+// cloud drive
+const signInTime = 5 * time.Minute
+
+// remoteFile is where in a storage the lesson in a local copy is kept, and
+// the version it was based on
+type remoteFile struct {
+	storage lesson.Storage
+	name    string
+	version string
+}
+
+// storageSettings is the part of the settings module storages are set up
+// in
+type storageSettings interface {
+	GetString(key string) (string, error)
+	SetSetting(key string, value interface{}) error
+}
+
+// getStorageSettings returns the settings module, or nil when there is
+// none
+func (mod *GuiModule) getStorageSettings() storageSettings {
+	module, ok := mod.manager.GetDefaultModule("settings")
+	if !ok {
+		return nil
+	}
+	settings, _ := module.(storageSettings)
+	return settings
+}
+
+// storageField is a setting asked for when setting up a storage
+type storageField struct {
+	label  string
+	key    string
+	secret bool
+}
+
+// storageProvider is a kind of storage lessons can be opened from and
+// saved to
+type storageProvider struct {
+	name        string
+	explanation string
+	fields      []storageField
+	// tokenKey is the setting a cloud drive keeps the sign-in in, which is
+	// forgotten when the drive is set up anew
+	tokenKey string
+	// validate returns what is wrong with the values of the fields, by
+	// key, before they are kept; empty when nothing is
+	validate func(values map[string]string) string
+	// connect gets the storage as set up, signing in first when needed,
+	// and calls use with it on the GUI thread
+	connect func(settings storageSettings, use func(lesson.Storage))
+}
+
+// storageProviders returns the kinds of storage the File menu offers
+func (mod *GuiModule) storageProviders() []storageProvider {
+	return []storageProvider{
+		{
+			name:        "WebDAV",
+			explanation: "Lessons can be opened from and saved to a WebDAV share, such as your files on Nextcloud at https://cloud.example/remote.php/dav/files/USER/. For Nextcloud, create an app password under Settings, Security.",
+			fields: []storageField{
+				{"Address:", lesson.WebDAVURLSetting, false},
+				{"User name:", lesson.WebDAVUserSetting, false},
+				{"Password:", lesson.WebDAVPasswordSetting, true},
+			},
+			validate: func(values map[string]string) string {
+				if _, err := lesson.NewWebDAVStorage(values[lesson.WebDAVURLSetting], "", ""); err != nil {
+					return "Enter the http or https address of the share."
+				}
+				return ""
+			},
+			connect: mod.connectWebDAV,
+		},
+		{
+			name:        "Google Drive",
+			explanation: "Lessons can be opened from and saved to Google Drive. Your school's Google Workspace admin creates an OAuth client of the Desktop app kind in the Google Cloud console, with the Drive API enabled, and gives you its client ID and secret. You sign in in your browser.",
+			fields: []storageField{
+				{"Client ID:", lesson.GoogleDriveClientIDSetting, false},
+				{"Client secret:", lesson.GoogleDriveClientSecretSetting, true},
+			},
+			tokenKey: lesson.GoogleDriveTokenSetting,
+			connect: func(settings storageSettings, use func(lesson.Storage)) {
+				clientID, _ := settings.GetString(lesson.GoogleDriveClientIDSetting)
+				secret, _ := settings.GetString(lesson.GoogleDriveClientSecretSetting)
+				mod.connectCloudDrive("Google Drive", settings, lesson.GoogleDriveConfig(clientID, secret), lesson.GoogleDriveTokenSetting,
+					func(config lesson.OAuthConfig, token lesson.OAuthToken, onRefresh func(lesson.OAuthToken)) lesson.Storage {
+						return lesson.NewGoogleDriveStorage(config, token, onRefresh)
+					}, use)
+			},
+		},
+		{
+			name:        "OneDrive",
+			explanation: "Lessons can be opened from and saved to OneDrive. Your school's Microsoft 365 admin registers an app in Microsoft Entra with http://127.0.0.1 as a mobile and desktop redirect URI and the Files.ReadWrite permission, and gives you its client ID and your tenant, such as school.example; leave the tenant empty for personal accounts. You sign in in your browser.",
+			fields: []storageField{
+				{"Client ID:", lesson.OneDriveClientIDSetting, false},
+				{"Tenant:", lesson.OneDriveTenantSetting, false},
+			},
+			tokenKey: lesson.OneDriveTokenSetting,
+			connect: func(settings storageSettings, use func(lesson.Storage)) {
+				clientID, _ := settings.GetString(lesson.OneDriveClientIDSetting)
+				tenant, _ := settings.GetString(lesson.OneDriveTenantSetting)
+				mod.connectCloudDrive("OneDrive", settings, lesson.OneDriveConfig(clientID, tenant), lesson.OneDriveTokenSetting,
+					func(config lesson.OAuthConfig, token lesson.OAuthToken, onRefresh func(lesson.OAuthToken)) lesson.Storage {
+						return lesson.NewOneDriveStorage(config, token, onRefresh)
+					}, use)
+			},
+		},
+	}
+}
+
+// fillStorageMenus lists a storage provider in each of the Open From, Save
+// To and Set Up Storage menus
+func (mod *GuiModule) fillStorageMenus(openMenu, saveMenu, setupMenu *qt.QMenu) {
+	for _, provider := range mod.storageProviders() {
+		openAction := openMenu.AddAction(provider.name + "...")
+		openAction.OnTriggered(func() {
+			mod.logger.Event("Open from %s menu action triggered", provider.name)
+			mod.openFromStorage(provider)
+		})
+		saveAction := saveMenu.AddAction(provider.name + "...")
+		saveAction.OnTriggered(func() {
+			mod.logger.Event("Save to %s menu action triggered", provider.name)
+			mod.saveToStorage(provider)
+		})
+		setupAction := setupMenu.AddAction(provider.name + "...")
+		setupAction.OnTriggered(func() {
+			mod.logger.Event("Set up %s menu action triggered", provider.name)
+			mod.configureStorage(provider)
+		})
+	}
+}
+
+// connectStorage gets the storage of provider, asking to set it up first
+// when it is not, and calls use with it
+func (mod *GuiModule) connectStorage(title string, provider storageProvider, use func(lesson.Storage)) {
+	settings := mod.getStorageSettings()
+	if settings == nil {
+		qt.QMessageBox_Warning(mod.mainWindow.QWidget, title, "Settings are not available.")
+		return
+	}
+	if value, _ := settings.GetString(provider.fields[0].key); value == "" && !mod.configureStorage(provider) {
+		return
+	}
+	provider.connect(settings, func(storage lesson.Storage) {
+		if storage != nil {
+			use(storage)
+		}
+	})
+}
+
+// connectWebDAV calls use with the WebDAV share in the settings
+func (mod *GuiModule) connectWebDAV(settings storageSettings, use func(lesson.Storage)) {
+	address, _ := settings.GetString(lesson.WebDAVURLSetting)
+	user, _ := settings.GetString(lesson.WebDAVUserSetting)
+	password, _ := settings.GetString(lesson.WebDAVPasswordSetting)
+	storage, err := lesson.NewWebDAVStorage(address, user, password)
+	if err != nil {
+		qt.QMessageBox_Warning(mod.mainWindow.QWidget, "WebDAV", err.Error())
+		return
+	}
+	use(storage)
+}
+
+// connectCloudDrive returns the cloud drive signed in to, signing in in
+// the browser when the user is not yet. The sign-in is kept in tokenKey,
+// along with the tokens refreshed later.
+func (mod *GuiModule) connectCloudDrive(name string, settings storageSettings, config lesson.OAuthConfig, tokenKey string,
+	newStorage func(lesson.OAuthConfig, lesson.OAuthToken, func(lesson.OAuthToken)) lesson.Storage, use func(lesson.Storage)) {
+	// Tokens are refreshed while the drive is used in the background; the
+	// settings module locks itself
+	keep := func(token lesson.OAuthToken) {
+		value := ""
+		if token.AccessToken != "" {
+			data, _ := json.Marshal(token)
+			value = string(data)
+		}
+		if err := settings.SetSetting(tokenKey, value); err != nil {
+			mod.logger.Warning("Failed to keep the sign-in to %s: %v", name, err)
+		}
+	}
+	if kept, _ := settings.GetString(tokenKey); kept != "" {
+		var token lesson.OAuthToken
+		if json.Unmarshal([]byte(kept), &token) == nil && token.AccessToken != "" {
+			use(newStorage(config, token, keep))
+			return
+		}
+	}
+
+	title := "Sign In to " + name
+	flow, err := lesson.StartOAuth(config)
+	if err != nil {
+		qt.QMessageBox_Warning(mod.mainWindow.QWidget, title, err.Error())
+		return
+	}
+	if !qt.QDesktopServices_OpenUrl(qt.NewQUrl3(flow.URL)) {
+		qt.QMessageBox_Information(mod.mainWindow.QWidget, title, "Open this address in your browser to sign in:\n\n"+flow.URL)
+	}
+	mod.statusBar.ShowMessage(fmt.Sprintf("Sign in to %s in your browser...", name))
+	var token *lesson.OAuthToken
+	mod.inBackground(func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), signInTime)
+		defer cancel()
+		var err error
+		token, err = flow.Wait(ctx, nil)
+		return err
+	}, func(err error) {
+		if err != nil {
+			mod.logger.Error("Failed to sign in to %s: %v", name, err)
+			mod.statusBar.ClearMessage()
+			qt.QMessageBox_Warning(mod.mainWindow.QWidget, title, fmt.Sprintf("Signing in to %s failed.\n\n%v", name, err))
+			return
+		}
+		keep(*token)
+		mod.statusBar.ShowMessage("Signed in to " + name)
+		use(newStorage(config, *token, keep))
+	})
+}
+
+// configureStorage asks for the settings of provider and keeps them. It
+// returns false when cancelled.
+func (mod *GuiModule) configureStorage(provider storageProvider) bool {
+	settings := mod.getStorageSettings()
+	if settings == nil {
+		return false
+	}
+	title := "Set Up " + provider.name
+	dialog := qt.NewQDialog(mod.mainWindow.QWidget)
+	dialog.SetWindowTitle(title)
+	defer dialog.DeleteLater()
+	layout := qt.NewQVBoxLayout(dialog.QWidget)
+	explanation := qt.NewQLabel3(provider.explanation)
+	explanation.SetWordWrap(true)
+	layout.AddWidget(explanation.QWidget)
+
+	form := qt.NewQFormLayout2()
+	edits := make(map[string]*qt.QLineEdit)
+	for _, field := range provider.fields {
+		edit := qt.NewQLineEdit2()
+		value, _ := settings.GetString(field.key)
+		edit.SetText(value)
+		if field.secret {
+			edit.SetEchoMode(qt.QLineEdit__Password)
+		}
+		form.AddRow3(field.label, edit.QWidget)
+		edits[field.key] = edit
+	}
+	layout.AddLayout(form.QLayout)
+	values := func() map[string]string {
+		values := make(map[string]string)
+		for key, edit := range edits {
+			values[key] = strings.TrimSpace(edit.Text())
+		}
+		return values
+	}
+
+	buttons := qt.NewQDialogButtonBox(dialog.QWidget)
+	buttons.SetStandardButtons(qt.QDialogButtonBox__Ok | qt.QDialogButtonBox__Cancel)
+	buttons.OnAccepted(func() {
+		if values()[provider.fields[0].key] == "" {
+			qt.QMessageBox_Warning(dialog.QWidget, title, "Fill in "+strings.TrimSuffix(provider.fields[0].label, ":")+".")
+			return
+		}
+		if provider.validate != nil {
+			if problem := provider.validate(values()); problem != "" {
+				qt.QMessageBox_Warning(dialog.QWidget, title, problem)
+				return
+			}
+		}
+		dialog.Accept()
+	})
+	buttons.OnRejected(dialog.Reject)
+	layout.AddWidget(buttons.QWidget)
+
+	if dialog.Exec() != int(qt.QDialog__Accepted) {
+		return false
+	}
+	for key, value := range values() {
+		if err := settings.SetSetting(key, value); err != nil {
+			qt.QMessageBox_Warning(mod.mainWindow.QWidget, title, err.Error())
+			return false
+		}
+	}
+	// A sign-in belongs to the app it was made with, so it is made anew
+	if provider.tokenKey != "" {
+		if err := settings.SetSetting(provider.tokenKey, ""); err != nil {
+			mod.logger.Warning("Failed to sign out of %s: %v", provider.name, err)
+		}
+	}
+	return true
+}
+
+// remoteCachePath returns where the local copy of the file name of storage
+// is kept while it is open. It lives in the data directory rather than the
+// cache, as it holds edits not uploaded yet, which evicting the cache
+// would lose.
+func remoteCachePath(storage lesson.Storage, name string) string {
+	location := storage.Location()
+	if parsed, err := url.Parse(location); err == nil && parsed.Host != "" {
+		location = parsed.Host
+	}
+	folder := strings.Map(func(r rune) rune {
+		if r == '.' || r == '-' || r >= '0' && r <= '9' || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' {
+			return r
+		}
+		return '_'
+	}, location)
+	return filepath.Join(paths.DataDir(), "remote", folder, filepath.FromSlash(name))
+}
+
+// openFromStorage lets the user pick a lesson in a storage of provider and
+// opens a local copy of it, which is uploaded again whenever it is saved
+func (mod *GuiModule) openFromStorage(provider storageProvider) {
+	title := "Open from " + provider.name
+	mod.connectStorage(title, provider, func(storage lesson.Storage) {
+		browser := newStorageBrowser(mod.mainWindow.QWidget, title, storage, "")
+		defer browser.DeleteLater()
+		if browser.Exec() != int(qt.QDialog__Accepted) {
+			return
+		}
+		name := browser.selected()
+		localPath := remoteCachePath(storage, name)
+
+		mod.statusBar.ShowMessage("Downloading " + path.Base(name) + "...")
+		var version string
+		mod.inBackground(func() error {
+			var err error
+			version, err = lesson.DownloadFromStorage(context.Background(), storage, name, localPath)
+			return err
+		}, func(err error) {
+			if err != nil {
+				mod.logger.Error("Failed to download '%s': %v", name, err)
+				mod.statusBar.ClearMessage()
+				qt.QMessageBox_Warning(mod.mainWindow.QWidget, title, fmt.Sprintf("Could not download %s.\n\n%s", path.Base(name), lesson.UserMessage(err)))
+				return
+			}
+			mod.remoteFiles[localPath] = remoteFile{storage: storage, name: name, version: version}
+			mod.loadSelectedFile(localPath)
+		})
+	})
+}
+
+// saveToStorage asks where in a storage of provider to save the lesson
+// shown, and saves it there
+func (mod *GuiModule) saveToStorage(provider storageProvider) {
+	title := "Save to " + provider.name
+	index := mod.tabWidget.CurrentIndex()
+	if index < 0 || index >= len(mod.lessonTabs) {
+		mod.statusBar.ShowMessage("No lesson open to save")
+		return
+	}
+	current := mod.lessonTabs[index].lesson
+	mod.connectStorage(title, provider, func(storage lesson.Storage) {
+		// Signing in may have taken a while, so the tab is looked up again
+		index := slices.IndexFunc(mod.lessonTabs, func(tab lessonTab) bool { return tab.lesson == current })
+		if index < 0 {
+			return
+		}
+		saver := lesson.NewFileSaver()
+		native := lesson.NativeExtension(current.DataType)
+		suggested := saver.GetDefaultFilename(&current.Data, native)
+		if current.Path != "" && !strings.HasPrefix(current.Path, "*") && canSaveAs(current.Path) {
+			suggested = filepath.Base(current.Path)
+		}
+		browser := newStorageBrowser(mod.mainWindow.QWidget, title, storage, suggested)
+		defer browser.DeleteLater()
+		if browser.Exec() != int(qt.QDialog__Accepted) {
+			return
+		}
+		name := browser.selected()
+		if path.Ext(name) == "" {
+			name += native
+			question := fmt.Sprintf("%s already exists. Do you want to replace it?", path.Base(name))
+			if browser.version(name) != "" && qt.QMessageBox_Question(mod.mainWindow.QWidget, title, question) != qt.QMessageBox__Yes {
+				return
+			}
+		}
+		if !canSaveAs(name) {
+			qt.QMessageBox_Warning(mod.mainWindow.QWidget, title, fmt.Sprintf("Recuerdo cannot save lessons as %s files.", path.Ext(name)))
+			return
+		}
+		if options := saver.GetSaveOptions(path.Ext(name)); len(options) > 0 {
+			if !mod.askSaveOptions(path.Base(name), options, &saver.Options) {
+				return
+			}
+		}
+
+		// The user was asked before replacing a file, so the version listed
+		// is the one replaced
+		localPath := remoteCachePath(storage, name)
+		mod.remoteFiles[localPath] = remoteFile{storage: storage, name: name, version: browser.version(name)}
+		mod.writeLesson(index, localPath, saver)
+	})
+}
+
+// uploadRemoteLesson uploads the local copy of a lesson after it was
+// saved. When somebody else changed the file since, the user chooses to
+// overwrite their version or keep both.
+func (mod *GuiModule) uploadRemoteLesson(localPath string) {
+	remote, ok := mod.remoteFiles[localPath]
+	if !ok {
+		return
+	}
+	const title = "Save Lesson"
+	mod.statusBar.ShowMessage("Uploading " + path.Base(remote.name) + "...")
+	var version string
+	mod.inBackground(func() error {
+		var err error
+		version, err = lesson.UploadToStorage(context.Background(), remote.storage, localPath, remote.name, remote.version)
+		return err
+	}, func(err error) {
+		if errors.Is(err, lesson.ErrConflict) {
+			switch mod.resolveRemoteConflict(remote) {
+			case qt.QMessageBox__AcceptRole:
+				remote.version = lesson.AnyVersion
+			case qt.QMessageBox__ActionRole:
+				remote.name, remote.version = conflictedCopyName(remote.name), ""
+			default:
+				mod.markRemoteUnsaved(localPath)
+				mod.statusBar.ShowMessage(fmt.Sprintf("%s was saved on this computer only", path.Base(remote.name)))
+				return
+			}
+			mod.remoteFiles[localPath] = remote
+			mod.uploadRemoteLesson(localPath)
+			return
+		}
+		if err != nil {
+			mod.logger.Error("Failed to upload '%s': %v", remote.name, err)
+			mod.markRemoteUnsaved(localPath)
+			mod.statusBar.ClearMessage()
+			qt.QMessageBox_Warning(mod.mainWindow.QWidget, title, fmt.Sprintf("Could not upload %s; it was saved on this computer only.\n\n%s", path.Base(remote.name), lesson.UserMessage(err)))
+			return
+		}
+		remote.version = version
+		mod.remoteFiles[localPath] = remote
+		mod.logger.Success("Uploaded lesson to %s on %s", remote.name, remote.storage.Location())
+		mod.statusBar.ShowMessage(fmt.Sprintf("Saved to %s on %s", remote.name, remote.storage.Location()))
+	})
+}
+
+// resolveRemoteConflict asks what to do about a lesson somebody else
+// changed in the storage: overwrite their version (AcceptRole), save a copy
+// next to it (ActionRole) or neither
+func (mod *GuiModule) resolveRemoteConflict(remote remoteFile) qt.QMessageBox__ButtonRole {
+	box := qt.NewQMessageBox(mod.mainWindow.QWidget)
+	defer box.DeleteLater()
+	box.SetWindowTitle("Save Lesson")
+	box.SetIcon(qt.QMessageBox__Warning)
+	box.SetText(fmt.Sprintf("%s was changed by somebody else since you opened it.\n\nReplace their version with yours, or save yours as a copy next to it?", path.Base(remote.name)))
+	overwrite := box.AddButton2("&Replace", qt.QMessageBox__AcceptRole)
+	saveCopy := box.AddButton2("Save as &Copy", qt.QMessageBox__ActionRole)
+	box.AddButton2("Cancel", qt.QMessageBox__RejectRole)
+	box.SetDefaultButton(saveCopy)
+	box.Exec()
+	switch box.ClickedButton().UnsafePointer() {
+	case overwrite.UnsafePointer():
+		return qt.QMessageBox__AcceptRole
+	case saveCopy.UnsafePointer():
+		return qt.QMessageBox__ActionRole
+	}
+	return qt.QMessageBox__RejectRole
+}
+
+// conflictedCopyName returns the name to save a copy of a lesson that
+// conflicted under, as Nextcloud names its own
+func conflictedCopyName(name string) string {
+	ext := path.Ext(name)
+	if strings.HasSuffix(strings.ToLower(name), ".pau.gz") {
+		ext = name[len(name)-len(".pau.gz"):]
+	}
+	return fmt.Sprintf("%s (conflicted copy %s)%s", strings.TrimSuffix(name, ext), time.Now().Format("2006-01-02 150405"), ext)
+}
+
+// markRemoteUnsaved marks the tab of a lesson that could not be uploaded
+// as changed, so it is not closed without saving it
+func (mod *GuiModule) markRemoteUnsaved(localPath string) {
+	for i := range mod.lessonTabs {
+		if tab := &mod.lessonTabs[i]; tab.lesson.Path == localPath {
+			tab.lesson.Data.Changed = true
+			mod.tabWidget.SetTabText(i, tabTitle(*tab))
+		}
+	}
+}
+
+// storageBrowser lists the folders and lessons of a storage, to pick a
+// lesson to open or, given a suggested name, where to save one
+type storageBrowser struct {
+	*qt.QDialog
+	storage  lesson.Storage
+	saving   bool
+	dir      string
+	entries  []lesson.StorageEntry
+	list     *qt.QListWidget
+	location *qt.QLabel
+	nameEdit *qt.QLineEdit
+	up       *qt.QPushButton
+	ok       *qt.QPushButton
+	listed   chan storageListing
+}
+
+// storageListing is the content of a folder, listed on another goroutine
+type storageListing struct {
+	dir     string
+	entries []lesson.StorageEntry
+	err     error
+}
+
+func newStorageBrowser(parent *qt.QWidget, title string, storage lesson.Storage, suggested string) *storageBrowser {
+	b := &storageBrowser{QDialog: qt.NewQDialog(parent), storage: storage, saving: suggested != ""}
+	b.SetWindowTitle(title)
+	b.Resize(480, 420)
+	layout := qt.NewQVBoxLayout(b.QWidget)
+
+	top := qt.NewQHBoxLayout2()
+	b.location = qt.NewQLabel3("")
+	top.AddWidget(b.location.QWidget)
+	top.AddStretch()
+	b.up = qt.NewQPushButton3("&Up")
+	b.up.OnClicked(func() { b.browse(path.Dir("/" + b.dir)) })
+	top.AddWidget(b.up.QWidget)
+	layout.AddLayout(top.QLayout)
+
+	b.list = qt.NewQListWidget(b.QWidget)
+	b.list.OnCurrentRowChanged(func(row int) {
+		if b.saving && row >= 0 && row < len(b.entries) && !b.entries[row].Dir {
+			b.nameEdit.SetText(b.entries[row].Name)
+		}
+		b.updateOK()
+	})
+	b.list.OnItemActivated(func(item *qt.QListWidgetItem) {
+		row := b.list.Row(item)
+		if row < 0 || row >= len(b.entries) {
+			return
+		}
+		if b.entries[row].Dir {
+			b.browse(b.entries[row].Path)
+		} else {
+			b.accept()
+		}
+	})
+	layout.AddWidget(b.list.QWidget)
+
+	if b.saving {
+		form := qt.NewQFormLayout2()
+		b.nameEdit = qt.NewQLineEdit2()
+		b.nameEdit.SetText(suggested)
+		b.nameEdit.OnTextChanged(func(string) { b.updateOK() })
+		form.AddRow3("File name:", b.nameEdit.QWidget)
+		layout.AddLayout(form.QLayout)
+	}
+
+	buttons := qt.NewQDialogButtonBox(b.QWidget)
+	buttons.SetStandardButtons(qt.QDialogButtonBox__Ok | qt.QDialogButtonBox__Cancel)
+	b.ok = buttons.Button(qt.QDialogButtonBox__Ok)
+	if b.saving {
+		b.ok.SetText("Save")
+	} else {
+		b.ok.SetText("Open")
+	}
+	buttons.OnAccepted(b.accept)
+	buttons.OnRejected(b.Reject)
+	layout.AddWidget(buttons.QWidget)
+
+	// Folders are listed on another goroutine, so they are taken in here
+	timer := qt.NewQTimer2(b.QObject)
+	timer.OnTimeout(b.poll)
+	timer.Start(100)
+	b.OnFinished(func(int) { timer.Stop() })
+	b.browse("")
+	return b
+}
+
+// browse lists the folder dir
+func (b *storageBrowser) browse(dir string) {
+	dir = strings.Trim(dir, "/")
+	listed := make(chan storageListing, 1)
+	b.listed = listed
+	b.list.SetEnabled(false)
+	b.location.SetText("Loading...")
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		entries, err := b.storage.List(ctx, dir)
+		listed <- storageListing{dir, entries, err}
+	}()
+}
+
+// poll shows the folder once it is listed
+func (b *storageBrowser) poll() {
+	select {
+	case listing := <-b.listed:
+		b.listed = nil
+		b.list.SetEnabled(true)
+		if listing.err != nil {
+			b.location.SetText("/" + b.dir)
+			qt.QMessageBox_Warning(b.QWidget, b.WindowTitle(), fmt.Sprintf("Could not list /%s.\n\n%s", listing.dir, lesson.UserMessage(listing.err)))
+			return
+		}
+		b.dir, b.entries = listing.dir, listing.entries
+		b.location.SetText("/" + b.dir)
+		b.up.SetEnabled(b.dir != "")
+		b.list.Clear()
+		for _, entry := range b.entries {
+			if entry.Dir {
+				b.list.AddItem(entry.Name + "/")
+			} else {
+				b.list.AddItem(entry.Name)
+			}
+		}
+		b.updateOK()
+	default:
+	}
+}
+
+func (b *storageBrowser) updateOK() {
+	if b.saving {
+		b.ok.SetEnabled(b.name() != "")
+		return
+	}
+	row := b.list.CurrentRow()
+	b.ok.SetEnabled(row >= 0 && row < len(b.entries) && !b.entries[row].Dir)
+}
+
+// accept closes the dialog with the lesson chosen, asking before saving
+// over one
+func (b *storageBrowser) accept() {
+	if !b.ok.IsEnabled() {
+		return
+	}
+	if b.saving && b.version(b.selected()) != "" {
+		question := fmt.Sprintf("%s already exists. Do you want to replace it?", b.name())
+		if qt.QMessageBox_Question(b.QWidget, b.WindowTitle(), question) != qt.QMessageBox__Yes {
+			return
+		}
+	}
+	b.Accept()
+}
+
+// name returns the file name typed when saving
+func (b *storageBrowser) name() string {
+	return strings.Trim(strings.TrimSpace(b.nameEdit.Text()), "/")
+}
+
+// selected returns the path of the lesson chosen
+func (b *storageBrowser) selected() string {
+	if b.saving {
+		return path.Join(b.dir, b.name())
+	}
+	return b.entries[b.list.CurrentRow()].Path
+}
+
+// version returns the version of the file name in the folder shown, empty
+// when it is not there
+func (b *storageBrowser) version(name string) string {
+	for _, entry := range b.entries {
+		if entry.Path == name && !entry.Dir {
+			if entry.Version == "" {
+				return lesson.AnyVersion
+			}
+			return entry.Version
+		}
+	}
+	return ""
+}
@@ -0,0 +1,43 @@
+package gui
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/mappu/miqt/qt"
+)
+
+// offerRepair offers to salvage a lesson that failed to load because the
+// file is damaged, and opens what could be recovered. It returns false when
+// recovery was not possible or not wanted.
+func (mod *GuiModule) offerRepair(fileName string, loadErr error) bool {
+	var corrupt *lesson.ErrCorruptArchive
+	if !errors.As(loadErr, &corrupt) || !lesson.CanRecover(fileName) {
+		return false
+	}
+	question := fmt.Sprintf("%s is damaged: %s.\n\nTry to recover the words that can still be read? The damaged file is left alone.",
+		filepath.Base(fileName), lesson.UserMessage(loadErr))
+	if qt.QMessageBox_Question(mod.mainWindow.QWidget, "Recover Lesson", question) != qt.QMessageBox__Yes {
+		return false
+	}
+
+	outPath := lesson.RecoveredPath(fileName)
+	report, err := lesson.NewFileLoader().RecoverFile(fileName, outPath)
+	if err != nil {
+		mod.logger.Error("Failed to recover '%s': %v", fileName, err)
+		qt.QMessageBox_Warning(mod.mainWindow.QWidget, "Recover Lesson", "Nothing could be recovered.\n\n"+lesson.UserMessage(err))
+		return true
+	}
+
+	box := qt.NewQMessageBox(mod.mainWindow.QWidget)
+	box.SetWindowTitle("Recover Lesson")
+	box.SetIcon(qt.QMessageBox__Information)
+	box.SetText(fmt.Sprintf("Recovered %d items to %s. A report is saved next to it.", report.Items, filepath.Base(outPath)))
+	box.SetDetailedText(report.String())
+	box.Exec()
+
+	mod.loadSelectedFile(outPath)
+	return true
+}
@@ -0,0 +1,198 @@
+package gui
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/mappu/miqt/qt"
+)
+
+// saveLesson saves the lesson shown to its file. Lessons without one, or
+// imported from a format that cannot be saved, are saved as a new file.
+func (mod *GuiModule) saveLesson() {
+	index := mod.tabWidget.CurrentIndex()
+	if index < 0 || index >= len(mod.lessonTabs) {
+		mod.statusBar.ShowMessage("No lesson open to save")
+		return
+	}
+	current := mod.lessonTabs[index].lesson
+	if current.Path == "" || strings.HasPrefix(current.Path, "*") || !canSaveAs(current.Path) {
+		mod.saveLessonAs()
+		return
+	}
+	saver := lesson.NewFileSaver()
+	// A passphrase is not kept, so encrypted lessons ask for it every time
+	options := saver.GetSaveOptions(filepath.Ext(current.Path))
+	if slices.ContainsFunc(options, func(option lesson.SaveOption) bool { return option.Kind == lesson.SaveOptionSecret }) {
+		if !mod.askSaveOptions(filepath.Base(current.Path), options, &saver.Options) {
+			return
+		}
+	}
+	mod.writeLesson(index, current.Path, saver)
+}
+
+// saveLessonAs asks for a file to save the lesson shown to and, for formats
+// with choices, how to write it
+func (mod *GuiModule) saveLessonAs() {
+	index := mod.tabWidget.CurrentIndex()
+	if index < 0 || index >= len(mod.lessonTabs) {
+		mod.statusBar.ShowMessage("No lesson open to save")
+		return
+	}
+	current := mod.lessonTabs[index].lesson
+
+	saver := lesson.NewFileSaver()
+	native := lesson.NativeExtension(current.DataType)
+	filter := fmt.Sprintf("OpenTeaching Lessons (*%s)", native)
+	if current.DataType == "words" {
+		filter = saver.GetSaveFilter()
+	}
+	filter += ";;Encrypted Lessons (*.otsec)"
+
+	suggested := saver.GetDefaultFilename(&current.Data, native)
+	if current.Path != "" && !strings.HasPrefix(current.Path, "*") {
+		suggested = current.Path
+	}
+	fileName := qt.QFileDialog_GetSaveFileName4(mod.mainWindow.QWidget, "Save Lesson As", suggested, filter)
+	if fileName == "" {
+		return
+	}
+	if filepath.Ext(fileName) == "" {
+		fileName += native
+	}
+	if !canSaveAs(fileName) {
+		qt.QMessageBox_Warning(mod.mainWindow.QWidget, "Save Lesson As", fmt.Sprintf("Recuerdo cannot save lessons as %s files.", filepath.Ext(fileName)))
+		return
+	}
+
+	if options := saver.GetSaveOptions(filepath.Ext(fileName)); len(options) > 0 {
+		if !mod.askSaveOptions(filepath.Base(fileName), options, &saver.Options) {
+			return
+		}
+	}
+	mod.writeLesson(index, fileName, saver)
+}
+
+// writeLesson saves the lesson of the tab at index to fileName. When the
+// lesson can be opened from that file again, the tab shows it from then on.
+func (mod *GuiModule) writeLesson(index int, fileName string, saver *lesson.FileSaver) {
+	tab := &mod.lessonTabs[index]
+	if tab.lockedBy != nil && fileName == tab.lesson.Path {
+		qt.QMessageBox_Warning(mod.mainWindow.QWidget, "Save Lesson", lesson.UserMessage(&lesson.ErrLocked{Lock: *tab.lockedBy}))
+		return
+	}
+
+	if err := saver.SaveFile(&tab.lesson.Data, fileName); err != nil {
+		mod.logger.Error("Failed to save '%s': %v", fileName, err)
+		qt.QMessageBox_Warning(mod.mainWindow.QWidget, "Save Lesson", lesson.UserMessage(err))
+		return
+	}
+	mod.logger.Success("Saved lesson to %s", fileName)
+	mod.statusBar.ShowMessage("Saved to " + fileName)
+	mod.uploadRemoteLesson(fileName)
+
+	// Exports that cannot be read back, such as HTML, leave the tab as it is
+	if !slices.Contains(lesson.NewFileLoader().GetSupportedExtensions(), strings.ToLower(filepath.Ext(fileName))) {
+		return
+	}
+	tab.lesson.Data.Changed = false
+	if fileName != tab.lesson.Path {
+		if tab.lock != nil {
+			if err := tab.lock.Unlock(); err != nil {
+				mod.logger.Warning("Failed to unlock '%s': %v", tab.lesson.Path, err)
+			}
+			tab.lock = nil
+		}
+		tab.lockedBy = nil
+		tab.lesson.Path = fileName
+		mod.lockLessonTab(index)
+		mod.watchLessonFile(tab.lesson)
+	}
+	mod.tabWidget.SetTabText(index, tabTitle(*tab))
+}
+
+// canSaveAs tells whether lessons can be saved to fileName, judged by its
+// extension
+func canSaveAs(fileName string) bool {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	if strings.HasSuffix(strings.ToLower(fileName), ".pau.gz") {
+		return true
+	}
+	return slices.Contains(lesson.NewFileSaver().GetSupportedSaveExtensions(), ext) ||
+		slices.Contains([]string{".ottp", ".otmd", ".otio", ".otsec"}, ext)
+}
+
+// askSaveOptions shows the options of the format of fileName, starting
+// from the values in saveOptions, and stores the chosen ones there. It
+// returns false when the user cancelled saving.
+func (mod *GuiModule) askSaveOptions(fileName string, options []lesson.SaveOption, saveOptions *lesson.SaveOptions) bool {
+	dialog := qt.NewQDialog(mod.mainWindow.QWidget)
+	dialog.SetWindowTitle("Save Options")
+	defer dialog.DeleteLater()
+
+	layout := qt.NewQVBoxLayout(dialog.QWidget)
+	layout.AddWidget(qt.NewQLabel3(fmt.Sprintf("How should %s be saved?", fileName)).QWidget)
+	form := qt.NewQFormLayout2()
+
+	// Each option reads its value back from its widget when accepted
+	values := make([]func() string, len(options))
+	for i, option := range options {
+		current := saveOptions.Value(option.Key)
+		switch option.Kind {
+		case lesson.SaveOptionChoice:
+			box := qt.NewQComboBox(dialog.QWidget)
+			for j, value := range option.Values {
+				box.AddItem(value.Label)
+				if value.Value == current {
+					box.SetCurrentIndex(j)
+				}
+			}
+			form.AddRow3(option.Label+":", box.QWidget)
+			values[i] = func() string {
+				return option.Values[max(box.CurrentIndex(), 0)].Value
+			}
+		case lesson.SaveOptionToggle:
+			box := qt.NewQCheckBox3(option.Label)
+			box.SetChecked(current == "true")
+			form.AddRowWithWidget(box.QWidget)
+			values[i] = func() string {
+				return fmt.Sprint(box.IsChecked())
+			}
+		case lesson.SaveOptionSecret:
+			edit := qt.NewQLineEdit(dialog.QWidget)
+			edit.SetEchoMode(qt.QLineEdit__Password)
+			edit.SetText(current)
+			form.AddRow3(option.Label+":", edit.QWidget)
+			values[i] = edit.Text
+		}
+	}
+	layout.AddLayout(form.QLayout)
+
+	buttons := qt.NewQHBoxLayout2()
+	buttons.AddStretch()
+	saveButton := qt.NewQPushButton3("&Save")
+	saveButton.SetDefault(true)
+	saveButton.OnClicked(dialog.Accept)
+	buttons.AddWidget(saveButton.QWidget)
+	cancelButton := qt.NewQPushButton3("Cancel")
+	cancelButton.OnClicked(dialog.Reject)
+	buttons.AddWidget(cancelButton.QWidget)
+	layout.AddLayout(buttons.QLayout)
+
+	if dialog.Exec() != int(qt.QDialog__Accepted) {
+		return false
+	}
+	for i, option := range options {
+		if values[i] == nil {
+			continue
+		}
+		if err := saveOptions.SetValue(option.Key, values[i]()); err != nil {
+			qt.QMessageBox_Warning(mod.mainWindow.QWidget, "Save Options", err.Error())
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,100 @@
+package gui
+
+import (
+	"fmt"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	sessionrestore "github.com/LaPingvino/recuerdo/internal/modules/logic/sessionRestore"
+)
+
+// sessionWidget is a lesson widget that remembers the page shown and the
+// practice session in progress
+type sessionWidget interface {
+	SessionState() (int, *sessionrestore.Practice)
+	RestoreSession(page int, practice *sessionrestore.Practice) bool
+}
+
+// lessonTab is a lesson shown in a tab, in the order of the tabs
+type lessonTab struct {
+	lesson  *lesson.Lesson
+	session sessionWidget
+	// lock is held on the lesson's file while the tab is open, unless
+	// lockedBy tells another program holds it and the tab is read-only
+	lock     *lesson.FileLock
+	lockedBy *lesson.LockInfo
+}
+
+// sessionKeeper is the part of the sessionRestore module the main window
+// keeps its open lessons with
+type sessionKeeper interface {
+	Save(state sessionrestore.State) error
+	Load() (sessionrestore.State, bool)
+}
+
+// getSessionKeeper returns the sessionRestore module, or nil when there is
+// none
+func (mod *GuiModule) getSessionKeeper() sessionKeeper {
+	module, ok := mod.manager.GetDefaultModule("sessionRestore")
+	if !ok {
+		return nil
+	}
+	keeper, _ := module.(sessionKeeper)
+	return keeper
+}
+
+// restoreSession opens the lessons that were open when Recuerdo closed and
+// resumes their practice sessions. The session is saved again on quitting.
+func (mod *GuiModule) restoreSession() {
+	keeper := mod.getSessionKeeper()
+	if keeper == nil {
+		return
+	}
+	mod.app.OnAboutToQuit(func() {
+		if err := keeper.Save(mod.sessionState()); err != nil {
+			mod.logger.Warning("Failed to save session: %v", err)
+		}
+	})
+
+	state, ok := keeper.Load()
+	if !ok {
+		return
+	}
+	current, reopened, resumed := -1, 0, 0
+	for i, tab := range state.Tabs {
+		opened := len(mod.lessonTabs)
+		mod.loadSelectedFile(tab.Path)
+		if len(mod.lessonTabs) == opened {
+			continue
+		}
+		reopened++
+		if i == state.Current {
+			current = opened
+		}
+		if session := mod.lessonTabs[opened].session; session != nil && session.RestoreSession(tab.Page, tab.Practice) {
+			resumed++
+		}
+	}
+	if current >= 0 && mod.tabWidget != nil {
+		mod.tabWidget.SetCurrentIndex(current)
+	}
+	if reopened > 0 {
+		mod.statusBar.ShowMessage(fmt.Sprintf("Reopened %d lessons, resumed %d practice sessions", reopened, resumed))
+	}
+}
+
+// sessionState returns the lessons open in tabs and where the user was in
+// them
+func (mod *GuiModule) sessionState() sessionrestore.State {
+	state := sessionrestore.State{}
+	if mod.tabWidget != nil {
+		state.Current = mod.tabWidget.CurrentIndex()
+	}
+	for _, open := range mod.lessonTabs {
+		tab := sessionrestore.Tab{Path: open.lesson.Path, LessonType: open.lesson.DataType}
+		if open.session != nil {
+			tab.Page, tab.Practice = open.session.SessionState()
+		}
+		state.Tabs = append(state.Tabs, tab)
+	}
+	return state
+}
@@ -0,0 +1,43 @@
+package gui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/mappu/miqt/qt"
+)
+
+// showStudySheetDialog asks where to save a fold-over study sheet of the
+// lesson shown and writes it as PDF or ODT
+func (mod *GuiModule) showStudySheetDialog() {
+	if mod.tabWidget == nil {
+		return
+	}
+	index := mod.tabWidget.CurrentIndex()
+	if index < 0 || index >= len(mod.lessonTabs) {
+		mod.statusBar.ShowMessage("No lesson open to export")
+		return
+	}
+	current := mod.lessonTabs[index].lesson
+
+	suggested := ""
+	if current.Path != "" {
+		suggested = strings.TrimSuffix(current.Path, filepath.Ext(current.Path)) + "-sheet.pdf"
+	}
+	fileName := qt.QFileDialog_GetSaveFileName4(mod.mainWindow.QWidget, "Export Study Sheet", suggested,
+		"PDF Documents (*.pdf);;OpenDocument Text (*.odt)")
+	if fileName == "" {
+		return
+	}
+	if ext := strings.ToLower(filepath.Ext(fileName)); ext != ".pdf" && ext != ".odt" {
+		fileName += ".pdf"
+	}
+
+	if err := lesson.NewFileSaver().SaveStudySheet(&current.Data, fileName); err != nil {
+		qt.QMessageBox_Warning(mod.mainWindow.QWidget, "Export Study Sheet", fmt.Sprintf("Could not save %s: %v", fileName, err))
+		return
+	}
+	mod.statusBar.ShowMessage("Study sheet saved to " + fileName)
+}
@@ -0,0 +1,239 @@
+package gui
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/LaPingvino/recuerdo/internal/modules/logic/subscriptions"
+	"github.com/LaPingvino/recuerdo/internal/paths"
+	"github.com/mappu/miqt/qt"
+)
+
+// subscriber is the part of the subscriptions module the main window keeps
+// subscribed lessons up to date with
+type subscriber interface {
+	Subscriptions() []lesson.Subscription
+	Subscribe(ctx context.Context, lessonURL, filePath string) (*lesson.LessonData, error)
+	Unsubscribe(filePath string) error
+	CheckAll(ctx context.Context) ([]subscriptions.Update, error)
+	Pending() []subscriptions.Update
+	Apply(update subscriptions.Update, local *lesson.LessonData) (*lesson.LessonData, error)
+}
+
+// getSubscriber returns the subscriptions module, or nil when there is none
+func (mod *GuiModule) getSubscriber() subscriber {
+	module, ok := mod.manager.GetDefaultModule("subscriptions")
+	if !ok {
+		return nil
+	}
+	sub, _ := module.(subscriber)
+	return sub
+}
+
+// startSubscriptionChecks offers the updates the subscriptions module found
+// in the background. They are looked for on a timer, so the question is
+// asked on the GUI thread.
+func (mod *GuiModule) startSubscriptionChecks() {
+	sub := mod.getSubscriber()
+	if sub == nil {
+		return
+	}
+	timer := qt.NewQTimer2(mod.mainWindow.QObject)
+	timer.OnTimeout(func() {
+		for _, update := range sub.Pending() {
+			mod.offerUpdate(sub, update)
+		}
+	})
+	timer.Start(60 * 1000)
+}
+
+// fillSubscriptionsMenu lists the subscription actions, offering to
+// unsubscribe the lesson shown when it is subscribed
+func (mod *GuiModule) fillSubscriptionsMenu(menu *qt.QMenu) {
+	menu.Clear()
+	sub := mod.getSubscriber()
+	if sub == nil {
+		menu.AddAction("Subscriptions are not available").SetEnabled(false)
+		return
+	}
+
+	subscribeAction := menu.AddAction("&Subscribe to Lesson...")
+	subscribeAction.OnTriggered(func() {
+		mod.logger.Event("Subscribe to Lesson menu action triggered")
+		mod.subscribeToLesson(sub)
+	})
+	checkAction := menu.AddAction("&Check for Updates")
+	checkAction.SetEnabled(len(sub.Subscriptions()) > 0)
+	checkAction.OnTriggered(func() {
+		mod.logger.Event("Check for Updates menu action triggered")
+		mod.checkSubscriptions(sub)
+	})
+
+	if mod.tabWidget == nil {
+		return
+	}
+	index := mod.tabWidget.CurrentIndex()
+	if index < 0 || index >= len(mod.lessonTabs) {
+		return
+	}
+	current := mod.lessonTabs[index].lesson
+	if lesson.FindSubscription(sub.Subscriptions(), current.Path) < 0 {
+		return
+	}
+	menu.AddSeparator()
+	unsubscribeAction := menu.AddAction(fmt.Sprintf("&Unsubscribe %s", lessonTitle(current)))
+	unsubscribeAction.OnTriggered(func() {
+		if err := sub.Unsubscribe(current.Path); err != nil {
+			qt.QMessageBox_Warning(mod.mainWindow.QWidget, "Unsubscribe", err.Error())
+			return
+		}
+		mod.statusBar.ShowMessage(fmt.Sprintf("%s is no longer kept up to date", lessonTitle(current)))
+	})
+}
+
+// subscribeToLesson asks for the URL of a lesson and the file to keep it
+// in, downloads it and opens it
+func (mod *GuiModule) subscribeToLesson(sub subscriber) {
+	var ok bool
+	lessonURL := qt.QInputDialog_GetText4(mod.mainWindow.QWidget, "Subscribe to Lesson",
+		"Address of the lesson, such as https://example.org/lessons/french.ot:", qt.QLineEdit__Normal, "", &ok)
+	lessonURL = strings.TrimSpace(lessonURL)
+	if !ok || lessonURL == "" {
+		return
+	}
+	parsed, err := url.Parse(lessonURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		qt.QMessageBox_Warning(mod.mainWindow.QWidget, "Subscribe to Lesson", "Enter the http or https address of a lesson file.")
+		return
+	}
+
+	name := path.Base(parsed.Path)
+	suggested := filepath.Join(paths.LessonDir(), name)
+	fileName := qt.QFileDialog_GetSaveFileName4(mod.mainWindow.QWidget, "Keep Lesson In", suggested,
+		fmt.Sprintf("Lessons (*%s)", filepath.Ext(name)))
+	if fileName == "" {
+		return
+	}
+	if !canSaveAs(fileName) {
+		qt.QMessageBox_Warning(mod.mainWindow.QWidget, "Subscribe to Lesson", fmt.Sprintf("Recuerdo cannot save lessons as %s files.", filepath.Ext(fileName)))
+		return
+	}
+
+	mod.statusBar.ShowMessage("Downloading " + lessonURL)
+	if _, err := sub.Subscribe(context.Background(), lessonURL, fileName); err != nil {
+		mod.logger.Error("Failed to subscribe to '%s': %v", lessonURL, err)
+		mod.statusBar.ShowMessage("Subscribing failed")
+		qt.QMessageBox_Warning(mod.mainWindow.QWidget, "Subscribe to Lesson", lesson.UserMessage(err))
+		return
+	}
+	mod.logger.Success("Subscribed %s to %s", fileName, lessonURL)
+	mod.loadSelectedFile(fileName)
+}
+
+// checkSubscriptions looks for new versions of every subscribed lesson now
+// and offers the ones found
+func (mod *GuiModule) checkSubscriptions(sub subscriber) {
+	mod.statusBar.ShowMessage("Checking subscribed lessons for updates")
+	updates, err := sub.CheckAll(context.Background())
+	if err != nil {
+		mod.logger.Warning("%v", err)
+		qt.QMessageBox_Warning(mod.mainWindow.QWidget, "Check for Updates", lesson.UserMessage(err))
+	}
+	if len(updates) == 0 {
+		if err == nil {
+			mod.statusBar.ShowMessage("All subscribed lessons are up to date")
+		}
+		return
+	}
+	for _, update := range updates {
+		mod.offerUpdate(sub, update)
+	}
+}
+
+// offerUpdate shows what a new version of a subscribed lesson changes and
+// merges it when the user agrees. A lesson open in a tab is merged with
+// its unsaved changes and shown anew.
+func (mod *GuiModule) offerUpdate(sub subscriber, update subscriptions.Update) {
+	title := update.Merged.List.Title
+	if title == "" {
+		title = filepath.Base(update.Subscription.Path)
+	}
+
+	dialog := qt.NewQDialog(mod.mainWindow.QWidget)
+	dialog.SetWindowTitle("Lesson Update")
+	dialog.Resize(520, 400)
+	defer dialog.DeleteLater()
+
+	layout := qt.NewQVBoxLayout(dialog.QWidget)
+	summary := qt.NewQLabel3(fmt.Sprintf("A new version of %s is available at %s: %s.\n\nYour results, stars and the items you added yourself are kept.",
+		title, update.Subscription.URL, update.Diff))
+	summary.SetWordWrap(true)
+	layout.AddWidget(summary.QWidget)
+	changes := qt.NewQPlainTextEdit(dialog.QWidget)
+	changes.SetReadOnly(true)
+	changes.SetPlainText(describeUpstreamDiff(update.Diff))
+	layout.AddWidget(changes.QWidget)
+
+	buttons := qt.NewQHBoxLayout2()
+	buttons.AddStretch()
+	updateButton := qt.NewQPushButton3("&Update")
+	updateButton.SetDefault(true)
+	updateButton.OnClicked(dialog.Accept)
+	buttons.AddWidget(updateButton.QWidget)
+	laterButton := qt.NewQPushButton3("Not &Now")
+	laterButton.OnClicked(dialog.Reject)
+	buttons.AddWidget(laterButton.QWidget)
+	layout.AddLayout(buttons.QLayout)
+
+	if dialog.Exec() != int(qt.QDialog__Accepted) {
+		mod.statusBar.ShowMessage(fmt.Sprintf("Kept the current version of %s", title))
+		return
+	}
+
+	var local *lesson.LessonData
+	open := -1
+	for i, tab := range mod.lessonTabs {
+		if lesson.FindSubscription([]lesson.Subscription{update.Subscription}, tab.lesson.Path) == 0 {
+			open, local = i, &tab.lesson.Data
+			break
+		}
+	}
+	if _, err := sub.Apply(update, local); err != nil {
+		mod.logger.Error("Failed to update '%s': %v", update.Subscription.Path, err)
+		qt.QMessageBox_Warning(mod.mainWindow.QWidget, "Lesson Update", lesson.UserMessage(err))
+		return
+	}
+	if open >= 0 {
+		mod.reloadLessonTab(open)
+	}
+	mod.logger.Success("Updated %s from %s", update.Subscription.Path, update.Subscription.URL)
+	mod.statusBar.ShowMessage(fmt.Sprintf("Updated %s: %s", title, update.Diff))
+}
+
+// describeUpstreamDiff lists the items an update adds, changes and removes
+func describeUpstreamDiff(diff *lesson.UpstreamDiff) string {
+	var lines []string
+	for _, item := range diff.Added {
+		lines = append(lines, "+ "+wordItemText(item))
+	}
+	for _, change := range diff.Changed {
+		lines = append(lines, fmt.Sprintf("~ %s  →  %s", wordItemText(change.Old), wordItemText(change.New)))
+	}
+	for _, item := range diff.Removed {
+		lines = append(lines, "- "+wordItemText(item))
+	}
+	if len(lines) == 0 {
+		return "Only the title or description of the lesson changed."
+	}
+	return strings.Join(lines, "\n")
+}
+
+// wordItemText shows an item on one line
+func wordItemText(item lesson.WordItem) string {
+	return strings.Join(item.Questions, ", ") + " = " + strings.Join(item.Answers, ", ")
+}
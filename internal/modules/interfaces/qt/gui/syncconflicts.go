@@ -0,0 +1,211 @@
+package gui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/mappu/miqt/qt"
+)
+
+// syncLesson pushes the lesson shown to the sync server under the name of
+// its file. When it was changed on another device too, the user chooses
+// between their version and the other one for everything both changed,
+// instead of the server keeping the latest.
+func (mod *GuiModule) syncLesson() {
+	const title = "Sync Lesson"
+	index := mod.tabWidget.CurrentIndex()
+	if index < 0 || index >= len(mod.lessonTabs) {
+		mod.statusBar.ShowMessage("No lesson open to sync")
+		return
+	}
+	tab := mod.lessonTabs[index]
+	if tab.lesson.Path == "" || strings.HasPrefix(tab.lesson.Path, "*") {
+		qt.QMessageBox_Information(mod.mainWindow.QWidget, title, "Save the lesson first; it is synced under the name of its file.")
+		return
+	}
+	if tab.lockedBy != nil {
+		qt.QMessageBox_Information(mod.mainWindow.QWidget, title, "The lesson is read-only, locked by "+tab.lockedBy.String()+".")
+		return
+	}
+	client, ok := mod.getSyncClient(title)
+	if !ok {
+		return
+	}
+	// The lesson is pushed off the GUI thread, so a copy of it is
+	edited := lesson.NewLessonData()
+	data, err := json.Marshal(&tab.lesson.Data)
+	if err == nil {
+		err = json.Unmarshal(data, edited)
+	}
+	if err != nil {
+		qt.QMessageBox_Warning(mod.mainWindow.QWidget, title, err.Error())
+		return
+	}
+	synced, name := tab.lesson, filepath.Base(tab.lesson.Path)
+
+	var push func(pushed *lesson.LessonData)
+	push = func(pushed *lesson.LessonData) {
+		mod.statusBar.ShowMessage(fmt.Sprintf("Syncing %s...", lessonTitle(synced)))
+		var merged *lesson.LessonData
+		var conflicts []lesson.EditConflict
+		mod.inBackground(func() error {
+			var err error
+			merged, conflicts, err = client.Push(name, pushed)
+			return err
+		}, func(err error) {
+			if err != nil {
+				qt.QMessageBox_Warning(mod.mainWindow.QWidget, title, err.Error())
+				mod.statusBar.ClearMessage()
+				return
+			}
+			if len(conflicts) > 0 {
+				// The server kept the pushed version; push again only
+				// when the user chose otherwise somewhere
+				choices, ok := mod.resolveSyncConflicts(lessonTitle(synced), conflicts)
+				if ok && slices.ContainsFunc(choices, func(choice lesson.ConflictChoice) bool { return choice != lesson.KeepIncoming }) {
+					push(lesson.ResolveConflicts(merged, conflicts, choices))
+					return
+				}
+			}
+			mod.showSyncedLesson(synced, edited, merged)
+			mod.statusBar.ShowMessage(fmt.Sprintf("Synced %s", lessonTitle(synced)))
+		})
+	}
+	push(edited)
+}
+
+// showSyncedLesson shows merged, the lesson as synced, in the tab of
+// synced when it differs from edited, the version pushed from it. The tab
+// is marked changed, so the merge is saved to the file too.
+func (mod *GuiModule) showSyncedLesson(synced *lesson.Lesson, edited, merged *lesson.LessonData) {
+	index := slices.IndexFunc(mod.lessonTabs, func(tab lessonTab) bool { return tab.lesson == synced })
+	if index < 0 || sameLessonData(edited, merged) {
+		return
+	}
+	reloaded := lesson.NewLesson(synced.DataType)
+	reloaded.Data = *merged
+	reloaded.Data.Changed = true
+	reloaded.Path = synced.Path
+	mod.replaceLessonTab(index, reloaded)
+}
+
+// sameLessonData tells whether two versions of a lesson hold the same,
+// whether or not they were saved
+func sameLessonData(a, b *lesson.LessonData) bool {
+	first, second := *a, *b
+	first.Changed, second.Changed = false, false
+	firstData, err := json.Marshal(&first)
+	if err != nil {
+		return false
+	}
+	secondData, err := json.Marshal(&second)
+	return err == nil && bytes.Equal(firstData, secondData)
+}
+
+// resolveSyncConflicts shows both versions of everything changed on this
+// device and another one, and returns which the user chose to keep of
+// each, or false when they cancelled
+func (mod *GuiModule) resolveSyncConflicts(title string, conflicts []lesson.EditConflict) ([]lesson.ConflictChoice, bool) {
+	dialog := qt.NewQDialog(mod.mainWindow.QWidget)
+	dialog.SetWindowTitle("Sync Conflicts")
+	dialog.Resize(720, 400)
+	defer dialog.DeleteLater()
+	layout := qt.NewQVBoxLayout(dialog.QWidget)
+
+	explanation := qt.NewQLabel3(fmt.Sprintf("%s was changed on another device too. Choose which version to keep of everything you both changed; keeping both adds the other version as a new item.", title))
+	explanation.SetWordWrap(true)
+	layout.AddWidget(explanation.QWidget)
+
+	table := qt.NewQTableWidget2()
+	table.SetObjectName("syncConflicts")
+	table.SetColumnCount(4)
+	for i, header := range []string{"Changed", "Mine", "Theirs", "Keep"} {
+		table.SetHorizontalHeaderItem(i, qt.NewQTableWidgetItem2(header))
+	}
+	table.SetRowCount(len(conflicts))
+	table.SetSelectionMode(qt.QAbstractItemView__NoSelection)
+	table.SetEditTriggers(qt.QAbstractItemView__NoEditTriggers)
+
+	// choices holds the choice behind every entry of the box of each row
+	boxes := make([]*qt.QComboBox, len(conflicts))
+	choices := make([][]lesson.ConflictChoice, len(conflicts))
+	for row, conflict := range conflicts {
+		what, mine, theirs := conflictTexts(conflict)
+		table.SetItem(row, 0, qt.NewQTableWidgetItem2(what))
+		table.SetItem(row, 1, qt.NewQTableWidgetItem2(mine))
+		table.SetItem(row, 2, qt.NewQTableWidgetItem2(theirs))
+
+		box := qt.NewQComboBox(dialog.QWidget)
+		box.AddItems([]string{"Mine", "Theirs"})
+		choices[row] = []lesson.ConflictChoice{lesson.KeepIncoming, lesson.KeepCurrent}
+		if incoming, current := conflict.Versions(); conflict.ItemID >= 0 && incoming != nil && current != nil {
+			box.AddItem("Both")
+			choices[row] = append(choices[row], lesson.KeepBoth)
+		}
+		box.SetAccessibleName(fmt.Sprintf("Keep for %s", mine))
+		table.SetCellWidget(row, 3, box.QWidget)
+		boxes[row] = box
+	}
+	table.ResizeColumnsToContents()
+	table.HorizontalHeader().SetStretchLastSection(true)
+	layout.AddWidget(table.QWidget)
+
+	buttons := qt.NewQHBoxLayout2()
+	allMineButton := qt.NewQPushButton3("All &Mine")
+	allMineButton.OnClicked(func() {
+		for _, box := range boxes {
+			box.SetCurrentIndex(0)
+		}
+	})
+	buttons.AddWidget(allMineButton.QWidget)
+	allTheirsButton := qt.NewQPushButton3("All &Theirs")
+	allTheirsButton.OnClicked(func() {
+		for _, box := range boxes {
+			box.SetCurrentIndex(1)
+		}
+	})
+	buttons.AddWidget(allTheirsButton.QWidget)
+	buttons.AddStretch()
+	keepButton := qt.NewQPushButton3("&Keep Chosen")
+	keepButton.SetDefault(true)
+	keepButton.OnClicked(dialog.Accept)
+	buttons.AddWidget(keepButton.QWidget)
+	cancelButton := qt.NewQPushButton3("&Cancel")
+	cancelButton.SetToolTip("Keep your version of everything")
+	cancelButton.OnClicked(dialog.Reject)
+	buttons.AddWidget(cancelButton.QWidget)
+	layout.AddLayout(buttons.QLayout)
+
+	if dialog.Exec() != int(qt.QDialog__Accepted) {
+		return nil, false
+	}
+	chosen := make([]lesson.ConflictChoice, len(conflicts))
+	for row, box := range boxes {
+		chosen[row] = choices[row][box.CurrentIndex()]
+	}
+	return chosen, true
+}
+
+// conflictTexts returns what a conflict is about, and this device's and
+// the other version of it
+func conflictTexts(conflict lesson.EditConflict) (what, mine, theirs string) {
+	if conflict.ItemID < 0 {
+		return conflict.Field, conflict.KeptValue, conflict.LostValue
+	}
+	version := func(item *lesson.WordItem) string {
+		if item == nil {
+			return "(deleted)"
+		}
+		if item.Comment != "" {
+			return wordItemText(*item) + " (" + item.Comment + ")"
+		}
+		return wordItemText(*item)
+	}
+	incoming, current := conflict.Versions()
+	return "Item", version(incoming), version(current)
+}
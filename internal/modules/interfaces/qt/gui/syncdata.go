@@ -0,0 +1,147 @@
+package gui
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	syncclient "github.com/LaPingvino/recuerdo/internal/modules/logic/syncClient"
+	"github.com/mappu/miqt/qt"
+)
+
+// getDataOwner returns the sync client when the server keeps data of the
+// user's own, in an account or an encrypted vault, telling the user why
+// not otherwise
+func (mod *GuiModule) getDataOwner(title string) (*syncclient.SyncClientModule, bool) {
+	client, ok := mod.getSyncClient(title)
+	if !ok {
+		return nil, false
+	}
+	if !client.KeepsOwnData() {
+		qt.QMessageBox_Information(mod.mainWindow.QWidget, title, fmt.Sprintf(
+			"The server keeps no data of your own. Set %q to keep your lessons and review history in an account, or %q to sync them encrypted; lessons synced without either are shared by everyone using the server.",
+			syncclient.AccountSetting, syncclient.PassphraseSetting))
+		return nil, false
+	}
+	return client, true
+}
+
+// getSyncClient returns the sync client, telling the user when syncing is
+// not available
+func (mod *GuiModule) getSyncClient(title string) (*syncclient.SyncClientModule, bool) {
+	module, ok := mod.manager.GetDefaultModule("syncClient")
+	if !ok {
+		qt.QMessageBox_Warning(mod.mainWindow.QWidget, title, "Syncing is not available.")
+		return nil, false
+	}
+	client, ok := module.(*syncclient.SyncClientModule)
+	return client, ok
+}
+
+// exportSyncData saves everything the sync server stores in the account
+// or vault of the user as a zip
+func (mod *GuiModule) exportSyncData() {
+	const title = "Export Sync Data"
+	client, ok := mod.getDataOwner(title)
+	if !ok {
+		return
+	}
+	path := qt.QFileDialog_GetSaveFileName4(mod.mainWindow.QWidget, title, "recuerdo-sync-data.zip", "Zip archives (*.zip)")
+	if path == "" {
+		return
+	}
+	if filepath.Ext(path) == "" {
+		path += ".zip"
+	}
+	mod.statusBar.ShowMessage("Downloading sync data...")
+	mod.inBackground(func() error {
+		var data bytes.Buffer
+		if err := client.ExportData(&data); err != nil {
+			return err
+		}
+		return lesson.WriteFileAtomically(path, data.Bytes(), 0600)
+	}, func(err error) {
+		if err != nil {
+			qt.QMessageBox_Warning(mod.mainWindow.QWidget, title, err.Error())
+			mod.statusBar.ClearMessage()
+			return
+		}
+		mod.statusBar.ShowMessage("Sync data exported to " + path)
+	})
+}
+
+// deleteSyncData deletes the account or vault of the user from the sync
+// server after asking; the lessons on this device are kept
+func (mod *GuiModule) deleteSyncData() {
+	const title = "Delete Sync Data"
+	client, ok := mod.getDataOwner(title)
+	if !ok {
+		return
+	}
+	question := "Delete your lessons and review history from the sync server? The lessons on this device are kept, and other devices stop syncing with each other until one pushes again. This cannot be undone."
+	if qt.QMessageBox_Question(mod.mainWindow.QWidget, title, question) != qt.QMessageBox__Yes {
+		return
+	}
+	mod.statusBar.ShowMessage("Deleting sync data...")
+	mod.inBackground(client.DeleteData, func(err error) {
+		if err != nil {
+			qt.QMessageBox_Warning(mod.mainWindow.QWidget, title, err.Error())
+			mod.statusBar.ClearMessage()
+			return
+		}
+		mod.statusBar.ShowMessage("Sync data deleted from the server")
+	})
+}
+
+// syncReviewHistory brings the review history of this device together
+// with that of the user's other devices through the sync server
+func (mod *GuiModule) syncReviewHistory() {
+	const title = "Sync Review History"
+	client, ok := mod.getDataOwner(title)
+	if !ok {
+		return
+	}
+	module, ok := mod.manager.GetDefaultModule("reviewHistory")
+	if !ok {
+		qt.QMessageBox_Warning(mod.mainWindow.QWidget, title, "The review history is not available.")
+		return
+	}
+	history, ok := module.(syncclient.History)
+	if !ok {
+		return
+	}
+	mod.statusBar.ShowMessage("Syncing review history...")
+	added := 0
+	mod.inBackground(func() error {
+		var err error
+		added, err = client.SyncHistory(history)
+		return err
+	}, func(err error) {
+		if err != nil {
+			qt.QMessageBox_Warning(mod.mainWindow.QWidget, title, err.Error())
+			mod.statusBar.ClearMessage()
+			return
+		}
+		mod.statusBar.ShowMessage(fmt.Sprintf("Review history synced, %d answers from other devices", added))
+	})
+}
+
+// inBackground runs work off the GUI thread, as it may wait for the
+// network, and calls done with its error on the GUI thread
+func (mod *GuiModule) inBackground(work func() error, done func(err error)) {
+	result := make(chan error, 1)
+	go func() { result <- work() }()
+
+	timer := qt.NewQTimer2(mod.mainWindow.QObject)
+	timer.OnTimeout(func() {
+		select {
+		case err := <-result:
+			timer.Stop()
+			timer.DeleteLater()
+			done(err)
+		default:
+		}
+	})
+	timer.Start(200)
+}
@@ -0,0 +1,83 @@
+package gui
+
+import (
+	"github.com/LaPingvino/recuerdo/internal/touch"
+	"github.com/mappu/miqt/qt"
+)
+
+// touchMode is the part of the touchMode module the main window is made
+// usable on smartboards with
+type touchMode interface {
+	Enabled() bool
+	Apply(app *qt.QApplication)
+	WatchSwipes(app *qt.QApplication, window *qt.QWidget, onSwipe func(direction touch.Direction))
+	NewKeyboard(parent *qt.QWidget) *qt.QWidget
+}
+
+// questionNavigator is a lesson widget that can move on to the next
+// question without the keyboard
+type questionNavigator interface {
+	NextQuestion() bool
+}
+
+// getTouchMode returns the touchMode module, or nil when there is none
+func (mod *GuiModule) getTouchMode() touchMode {
+	module, ok := mod.manager.GetDefaultModule("touchMode")
+	if !ok {
+		return nil
+	}
+	touchMode, _ := module.(touchMode)
+	return touchMode
+}
+
+// startTouchMode enlarges the controls, moves on to the next question on
+// swiping left and adds a toolbar with large buttons for what is otherwise
+// done with the keyboard, when touch mode is on
+func (mod *GuiModule) startTouchMode() {
+	touchMode := mod.getTouchMode()
+	if touchMode == nil || !touchMode.Enabled() {
+		return
+	}
+	touchMode.Apply(mod.app)
+	touchMode.WatchSwipes(mod.app, mod.mainWindow.QWidget, func(direction touch.Direction) {
+		if direction == touch.SwipeLeft {
+			mod.nextQuestion()
+		}
+	})
+
+	keyboard := qt.NewQDockWidget4("On-Screen Keyboard", mod.mainWindow.QWidget)
+	keyboard.SetObjectName("onScreenKeyboardDock")
+	keyboard.SetWidget(touchMode.NewKeyboard(keyboard.QWidget))
+	keyboard.SetFeatures(qt.QDockWidget__DockWidgetClosable)
+	mod.mainWindow.AddDockWidget(qt.BottomDockWidgetArea, keyboard)
+	keyboard.Hide()
+
+	toolBar := mod.mainWindow.AddToolBarWithTitle("Touch")
+	toolBar.SetObjectName("touchToolBar")
+	nextAction := toolBar.AddAction("Next ▶")
+	nextAction.SetToolTip("Go to the next question; swiping left does the same")
+	nextAction.OnTriggered(func() {
+		mod.nextQuestion()
+	})
+	keyboardAction := keyboard.ToggleViewAction()
+	keyboardAction.SetText("⌨ Keyboard")
+	keyboardAction.SetToolTip("Show or hide the on-screen keyboard")
+	toolBar.QWidget.AddAction(keyboardAction)
+
+	mod.logger.Info("Touch mode on")
+}
+
+// nextQuestion moves the lesson shown on to its next question
+func (mod *GuiModule) nextQuestion() {
+	if mod.tabWidget == nil {
+		return
+	}
+	index := mod.tabWidget.CurrentIndex()
+	if index < 0 || index >= len(mod.lessonTabs) {
+		return
+	}
+	if navigator, ok := mod.lessonTabs[index].session.(questionNavigator); ok && navigator.NextQuestion() {
+		return
+	}
+	mod.statusBar.ShowMessage2("Answer the question first", 2000)
+}
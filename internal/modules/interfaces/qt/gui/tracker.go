@@ -0,0 +1,86 @@
+package gui
+
+import (
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// lessonTracker is the part of the lessonTracker module the main window
+// tells about its lessons, and the views of a lesson keep in sync through
+type lessonTracker interface {
+	AddLesson(l *lesson.Lesson)
+	RemoveLesson(l *lesson.Lesson)
+	SetCurrentLesson(l *lesson.Lesson)
+	LessonChanged(l *lesson.Lesson, origin string)
+	OnLessonChanged(handler func(l *lesson.Lesson, origin string))
+}
+
+// lessonView is a lesson widget with views that change the lesson, such as
+// the Teach tab detached into a window of its own
+type lessonView interface {
+	SetChangeNotifier(notify func(origin string))
+	LessonChanged(origin string)
+}
+
+// getLessonTracker returns the lessonTracker module, or nil when there is
+// none
+func (mod *GuiModule) getLessonTracker() lessonTracker {
+	module, ok := mod.manager.GetDefaultModule("lessonTracker")
+	if !ok {
+		return nil
+	}
+	tracker, _ := module.(lessonTracker)
+	return tracker
+}
+
+// startLessonTracking passes the changes the lesson tracker hears of on to
+// the tabs showing the changed lesson
+func (mod *GuiModule) startLessonTracking() {
+	tracker := mod.getLessonTracker()
+	if tracker == nil {
+		return
+	}
+	tracker.OnLessonChanged(func(l *lesson.Lesson, origin string) {
+		for _, tab := range mod.lessonTabs {
+			if view, ok := tab.session.(lessonView); ok && tab.lesson == l {
+				view.LessonChanged(origin)
+			}
+		}
+	})
+}
+
+// trackLessonTab tells the lesson tracker about the lesson of a tab, and
+// the tab to report its changes to the tracker
+func (mod *GuiModule) trackLessonTab(index int) {
+	tracker := mod.getLessonTracker()
+	if tracker == nil {
+		return
+	}
+	tab := mod.lessonTabs[index]
+	tracker.AddLesson(tab.lesson)
+	if view, ok := tab.session.(lessonView); ok {
+		view.SetChangeNotifier(func(origin string) {
+			tracker.LessonChanged(tab.lesson, origin)
+		})
+	}
+}
+
+// untrackLesson tells the lesson tracker a lesson is no longer shown
+func (mod *GuiModule) untrackLesson(l *lesson.Lesson) {
+	if tracker := mod.getLessonTracker(); tracker != nil {
+		tracker.RemoveLesson(l)
+	}
+}
+
+// currentTabChanged tells the lesson tracker which lesson the user
+// switched to
+func (mod *GuiModule) currentTabChanged(index int) {
+	tracker := mod.getLessonTracker()
+	if tracker == nil {
+		return
+	}
+	if index >= 0 && index < len(mod.lessonTabs) {
+		tracker.SetCurrentLesson(mod.lessonTabs[index].lesson)
+	} else {
+		tracker.SetCurrentLesson(nil)
+	}
+}
@@ -0,0 +1,260 @@
+package gui
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/LaPingvino/recuerdo/internal/modules/logic/transfers"
+	"github.com/mappu/miqt/qt"
+)
+
+// transferQueue is the part of the transfers module the transfers panel
+// shows and controls
+type transferQueue interface {
+	Add(kind, name string, size int64, run func(ctx context.Context) error) int
+	Transfers() []transfers.Transfer
+	Metered() bool
+	MeteredMode() string
+	SetMeteredMode(mode string) error
+	StartNow(id int) error
+	Pause(id int) error
+	Resume(id int) error
+	Retry(id int) error
+	Cancel(id int) error
+	ClearFinished()
+}
+
+// getTransferQueue returns the transfers module, or nil when there is none
+func (mod *GuiModule) getTransferQueue() transferQueue {
+	module, ok := mod.manager.GetDefaultModule("transfers")
+	if !ok {
+		return nil
+	}
+	queue, _ := module.(transferQueue)
+	return queue
+}
+
+// meteredModes are the choices of the connection combo box, in order
+var meteredModes = []string{transfers.MeteredAuto, transfers.MeteredAlways, transfers.MeteredNever}
+
+// startTransferStatus shows in the status bar how many transfers wait for
+// an unmetered connection
+func (mod *GuiModule) startTransferStatus() {
+	queue := mod.getTransferQueue()
+	if queue == nil {
+		return
+	}
+	label := qt.NewQLabel3("")
+	label.SetToolTip("Large downloads wait while the connection is metered; see Tools → Transfers")
+	label.Hide()
+	mod.statusBar.AddPermanentWidget(label.QWidget)
+
+	timer := qt.NewQTimer2(mod.mainWindow.QObject)
+	timer.OnTimeout(func() {
+		deferred := 0
+		for _, transfer := range queue.Transfers() {
+			if transfer.State == transfers.Deferred {
+				deferred++
+			}
+		}
+		if deferred == 0 {
+			label.Hide()
+			return
+		}
+		label.SetText(fmt.Sprintf("Metered connection: %d waiting", deferred))
+		label.Show()
+	})
+	timer.Start(2000)
+}
+
+// showTransfersPanel lists the downloads and uploads, such as map tiles,
+// subscribed lessons and sync, letting the user start deferred ones
+// anyway, pause, resume, cancel and retry them, and say whether the
+// connection is metered
+func (mod *GuiModule) showTransfersPanel() {
+	queue := mod.getTransferQueue()
+	if queue == nil {
+		mod.statusBar.ShowMessage("Error: Transfers not available")
+		return
+	}
+
+	dialog := qt.NewQDialog(mod.mainWindow.QWidget)
+	dialog.SetWindowTitle("Transfers")
+	dialog.Resize(640, 360)
+	defer dialog.DeleteLater()
+	layout := qt.NewQVBoxLayout(dialog.QWidget)
+
+	connectionLayout := qt.NewQHBoxLayout2()
+	connectionLabel := qt.NewQLabel3("&Connection:")
+	connectionLayout.AddWidget(connectionLabel.QWidget)
+	modeCombo := qt.NewQComboBox(dialog.QWidget)
+	modeCombo.SetObjectName("meteredMode")
+	modeCombo.AddItems([]string{"Ask the system", "Metered", "Not metered"})
+	for i, mode := range meteredModes {
+		if mode == queue.MeteredMode() {
+			modeCombo.SetCurrentIndex(i)
+		}
+	}
+	connectionLabel.SetBuddy(modeCombo.QWidget)
+	connectionLayout.AddWidget(modeCombo.QWidget)
+	meteredLabel := qt.NewQLabel3("")
+	connectionLayout.AddWidget(meteredLabel.QWidget)
+	connectionLayout.AddStretch()
+	layout.AddLayout(connectionLayout.QLayout)
+
+	table := qt.NewQTableWidget2()
+	table.SetObjectName("transfers")
+	table.SetColumnCount(4)
+	for i, header := range []string{"Transfer", "Name", "Size", "State"} {
+		table.SetHorizontalHeaderItem(i, qt.NewQTableWidgetItem2(header))
+	}
+	table.SetSelectionBehavior(qt.QAbstractItemView__SelectRows)
+	table.SetSelectionMode(qt.QAbstractItemView__SingleSelection)
+	table.SetEditTriggers(qt.QAbstractItemView__NoEditTriggers)
+	table.HorizontalHeader().SetStretchLastSection(true)
+	layout.AddWidget(table.QWidget)
+
+	buttons := qt.NewQHBoxLayout2()
+	startButton := qt.NewQPushButton3("&Start Now")
+	startButton.SetToolTip("Start the transfer on the metered connection anyway")
+	buttons.AddWidget(startButton.QWidget)
+	pauseButton := qt.NewQPushButton3("&Pause")
+	buttons.AddWidget(pauseButton.QWidget)
+	resumeButton := qt.NewQPushButton3("&Resume")
+	buttons.AddWidget(resumeButton.QWidget)
+	retryButton := qt.NewQPushButton3("Re&try")
+	retryButton.SetToolTip("Start a failed transfer again")
+	buttons.AddWidget(retryButton.QWidget)
+	cancelButton := qt.NewQPushButton3("C&ancel Transfer")
+	buttons.AddWidget(cancelButton.QWidget)
+	clearButton := qt.NewQPushButton3("C&lear Finished")
+	buttons.AddWidget(clearButton.QWidget)
+	buttons.AddStretch()
+	closeButton := qt.NewQPushButton3("&Close")
+	closeButton.OnClicked(dialog.Accept)
+	buttons.AddWidget(closeButton.QWidget)
+	layout.AddLayout(buttons.QLayout)
+
+	// ids holds the transfer shown in every row, and states their states
+	var ids []int
+	var states []transfers.State
+	// updateButtons enables the buttons that apply to the selected transfer
+	updateButtons := func() {
+		var state transfers.State
+		if row := table.CurrentRow(); row >= 0 && row < len(states) {
+			state = states[row]
+		}
+		startButton.SetEnabled(state == transfers.Deferred)
+		pauseButton.SetEnabled(state == transfers.Queued || state == transfers.Deferred || state == transfers.Running)
+		resumeButton.SetEnabled(state == transfers.Paused)
+		retryButton.SetEnabled(state == transfers.Failed)
+		cancelButton.SetEnabled(state != "" && !state.Finished())
+	}
+	refresh := func() {
+		if queue.Metered() {
+			meteredLabel.SetText("The connection is metered; large transfers wait.")
+		} else {
+			meteredLabel.SetText("The connection is not metered.")
+		}
+		selected := -1
+		if row := table.CurrentRow(); row >= 0 && row < len(ids) {
+			selected = ids[row]
+		}
+		list := queue.Transfers()
+		ids, states = ids[:0], states[:0]
+		table.SetRowCount(len(list))
+		for row, transfer := range list {
+			ids = append(ids, transfer.ID)
+			states = append(states, transfer.State)
+			table.SetItem(row, 0, qt.NewQTableWidgetItem2(transfer.Kind))
+			table.SetItem(row, 1, qt.NewQTableWidgetItem2(transfer.Name))
+			table.SetItem(row, 2, qt.NewQTableWidgetItem2(formatTransferSize(transfer.Size)))
+			table.SetItem(row, 3, qt.NewQTableWidgetItem2(transferStateText(transfer)))
+			if transfer.ID == selected {
+				table.SelectRow(row)
+			}
+		}
+		updateButtons()
+	}
+	selectedID := func() (int, bool) {
+		row := table.CurrentRow()
+		if row < 0 || row >= len(ids) {
+			return 0, false
+		}
+		return ids[row], true
+	}
+
+	modeCombo.OnCurrentIndexChanged(func(index int) {
+		if index < 0 || index >= len(meteredModes) {
+			return
+		}
+		if err := queue.SetMeteredMode(meteredModes[index]); err != nil {
+			mod.logger.Warning("Failed to keep the connection setting: %v", err)
+		}
+		refresh()
+	})
+	// onSelected runs action on the selected transfer
+	onSelected := func(action func(id int) error) func() {
+		return func() {
+			if id, ok := selectedID(); ok {
+				if err := action(id); err != nil {
+					mod.statusBar.ShowMessage(err.Error())
+				}
+				refresh()
+			}
+		}
+	}
+	startButton.OnClicked(onSelected(queue.StartNow))
+	pauseButton.OnClicked(onSelected(queue.Pause))
+	resumeButton.OnClicked(onSelected(queue.Resume))
+	retryButton.OnClicked(onSelected(queue.Retry))
+	cancelButton.OnClicked(onSelected(queue.Cancel))
+	table.OnCurrentCellChanged(func(int, int, int, int) {
+		updateButtons()
+	})
+	clearButton.OnClicked(func() {
+		queue.ClearFinished()
+		refresh()
+	})
+
+	refresh()
+	timer := qt.NewQTimer2(dialog.QObject)
+	timer.OnTimeout(refresh)
+	timer.Start(1000)
+	dialog.Exec()
+	timer.Stop()
+}
+
+// transferStateText describes where a transfer is for the transfers panel
+func transferStateText(transfer transfers.Transfer) string {
+	switch transfer.State {
+	case transfers.Queued:
+		return "Queued"
+	case transfers.Deferred:
+		return "Waiting for an unmetered connection"
+	case transfers.Running:
+		return "Running"
+	case transfers.Paused:
+		return "Paused"
+	case transfers.Done:
+		return "Done"
+	case transfers.Failed:
+		return fmt.Sprintf("Failed: %v", transfer.Err)
+	case transfers.Cancelled:
+		return "Cancelled"
+	}
+	return string(transfer.State)
+}
+
+// formatTransferSize gives the estimated size of a transfer, such as
+// "3.2 MB"
+func formatTransferSize(size int64) string {
+	switch {
+	case size <= 0:
+		return "Unknown"
+	case size < 1<<20:
+		return fmt.Sprintf("%d KB", (size+1023)>>10)
+	default:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"strings"
 
 	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
 	"github.com/mappu/miqt/qt"
 )
 
@@ -37,8 +38,14 @@ type LessonDialogsModule struct {
 	propNameEdit    *qt.QLineEdit
 	propDescEdit    *qt.QTextEdit
 	propAuthorEdit  *qt.QLineEdit
-	propVersionEdit *qt.QLineEdit
-	itemCountLabel  *qt.QLabel
+	propTagsEdit    *qt.QLineEdit
+	propLicenseEdit *qt.QLineEdit
+	propLevelCombo  *qt.QComboBox
+	// propSyncCombo picks every device, this device only or the device
+	// groups in propSyncGroupsEdit
+	propSyncCombo      *qt.QComboBox
+	propSyncGroupsEdit *qt.QLineEdit
+	itemCountLabel     *qt.QLabel
 
 	// Widget references for import dialog
 	importFileEdit *qt.QLineEdit
@@ -239,9 +246,38 @@ func (mod *LessonDialogsModule) createPropertiesDialog(parent *qt.QWidget) {
 	mod.propAuthorEdit.SetObjectName("propAuthor")
 	generalLayout.AddRow3("Author:", mod.propAuthorEdit.QWidget)
 
-	mod.propVersionEdit = qt.NewQLineEdit(nil)
-	mod.propVersionEdit.SetObjectName("propVersion")
-	generalLayout.AddRow3("Version:", mod.propVersionEdit.QWidget)
+	mod.propTagsEdit = qt.NewQLineEdit(nil)
+	mod.propTagsEdit.SetObjectName("propTags")
+	mod.propTagsEdit.SetPlaceholderText("verbs, week 3, exam")
+	mod.propTagsEdit.SetToolTip("Comma separated; the lesson library finds lessons by their tags")
+	generalLayout.AddRow3("Tags:", mod.propTagsEdit.QWidget)
+
+	mod.propLicenseEdit = qt.NewQLineEdit(nil)
+	mod.propLicenseEdit.SetObjectName("propLicense")
+	mod.propLicenseEdit.SetPlaceholderText("CC BY-SA 4.0")
+	generalLayout.AddRow3("License:", mod.propLicenseEdit.QWidget)
+
+	mod.propLevelCombo = qt.NewQComboBox(nil)
+	mod.propLevelCombo.SetObjectName("propLevel")
+	mod.propLevelCombo.AddItem("None")
+	mod.propLevelCombo.AddItems(lesson.CEFRLevels())
+	mod.propLevelCombo.SetToolTip("The level of the Common European Framework of Reference the lesson is meant for")
+	generalLayout.AddRow3("Level (CEFR):", mod.propLevelCombo.QWidget)
+
+	mod.propSyncCombo = qt.NewQComboBox(nil)
+	mod.propSyncCombo.SetObjectName("propSync")
+	mod.propSyncCombo.AddItems([]string{"All devices", "This device only", "Devices in groups"})
+	mod.propSyncCombo.SetToolTip("Which devices the lesson is synced to, such as to keep lessons with a lot of media off a laptop")
+	generalLayout.AddRow3("Sync to:", mod.propSyncCombo.QWidget)
+	mod.propSyncGroupsEdit = qt.NewQLineEdit(nil)
+	mod.propSyncGroupsEdit.SetObjectName("propSyncGroups")
+	mod.propSyncGroupsEdit.SetPlaceholderText("desktop, tablet")
+	mod.propSyncGroupsEdit.SetToolTip("Comma separated; every device names the groups it is in in its settings. This keeps the lesson off other devices, but does not hide it from anyone with the sync token, who can name any group.")
+	mod.propSyncGroupsEdit.SetEnabled(false)
+	generalLayout.AddRow3("Device groups:", mod.propSyncGroupsEdit.QWidget)
+	mod.propSyncCombo.OnCurrentIndexChanged(func(index int) {
+		mod.propSyncGroupsEdit.SetEnabled(index == 2)
+	})
 
 	tabWidget.AddTab(generalTab, "General")
 
@@ -249,10 +285,10 @@ func (mod *LessonDialogsModule) createPropertiesDialog(parent *qt.QWidget) {
 	statsTab := qt.NewQWidget2()
 	statsLayout := qt.NewQFormLayout(statsTab)
 
-	itemCountLabel := qt.NewQLabel2()
-	itemCountLabel.SetText("0")
-	itemCountLabel.SetObjectName("itemCount")
-	statsLayout.AddRow3("Number of items:", itemCountLabel.QWidget)
+	mod.itemCountLabel = qt.NewQLabel2()
+	mod.itemCountLabel.SetText("0")
+	mod.itemCountLabel.SetObjectName("itemCount")
+	statsLayout.AddRow3("Number of items:", mod.itemCountLabel.QWidget)
 
 	createdLabel := qt.NewQLabel2()
 	createdLabel.SetText("Unknown")
@@ -466,9 +502,38 @@ func (mod *LessonDialogsModule) loadPropertiesData(lessonData map[string]interfa
 		}
 	}
 
-	if version, ok := lessonData["version"].(string); ok {
-		if mod.propVersionEdit != nil {
-			mod.propVersionEdit.SetText(version)
+	if tags, ok := lessonData["tags"].(string); ok {
+		if mod.propTagsEdit != nil {
+			mod.propTagsEdit.SetText(tags)
+		}
+	}
+
+	if license, ok := lessonData["license"].(string); ok {
+		if mod.propLicenseEdit != nil {
+			mod.propLicenseEdit.SetText(license)
+		}
+	}
+
+	if mod.propLevelCombo != nil {
+		level, _ := lessonData["level"].(string)
+		if index := mod.propLevelCombo.FindText(level); level != "" && index >= 0 {
+			mod.propLevelCombo.SetCurrentIndex(index)
+		} else {
+			mod.propLevelCombo.SetCurrentIndex(0)
+		}
+	}
+
+	if mod.propSyncCombo != nil {
+		scope, _ := lessonData["sync"].(string)
+		mod.propSyncGroupsEdit.SetText("")
+		switch scope {
+		case "":
+			mod.propSyncCombo.SetCurrentIndex(0)
+		case lesson.SyncLocalOnly:
+			mod.propSyncCombo.SetCurrentIndex(1)
+		default:
+			mod.propSyncCombo.SetCurrentIndex(2)
+			mod.propSyncGroupsEdit.SetText(scope)
 		}
 	}
 
@@ -500,8 +565,30 @@ func (mod *LessonDialogsModule) getPropertiesData() map[string]interface{} {
 		data["author"] = strings.TrimSpace(mod.propAuthorEdit.Text())
 	}
 
-	if mod.propVersionEdit != nil {
-		data["version"] = strings.TrimSpace(mod.propVersionEdit.Text())
+	if mod.propTagsEdit != nil {
+		data["tags"] = strings.TrimSpace(mod.propTagsEdit.Text())
+	}
+
+	if mod.propLicenseEdit != nil {
+		data["license"] = strings.TrimSpace(mod.propLicenseEdit.Text())
+	}
+
+	if mod.propLevelCombo != nil {
+		data["level"] = ""
+		if mod.propLevelCombo.CurrentIndex() > 0 {
+			data["level"] = mod.propLevelCombo.CurrentText()
+		}
+	}
+
+	if mod.propSyncCombo != nil {
+		switch mod.propSyncCombo.CurrentIndex() {
+		case 1:
+			data["sync"] = lesson.SyncLocalOnly
+		case 2:
+			data["sync"] = lesson.ParseSyncScope(mod.propSyncGroupsEdit.Text())
+		default:
+			data["sync"] = ""
+		}
 	}
 
 	return data
@@ -5,6 +5,7 @@ package media
 
 import (
 	"fmt"
+	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -12,6 +13,9 @@ import (
 	"strings"
 
 	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/teachTypes/dictation"
+	multiplechoice "github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/teachTypes/multipleChoice"
+	"github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/theme"
 	"github.com/mappu/miqt/qt"
 )
 
@@ -27,9 +31,10 @@ type MediaLessonWidget struct {
 	tabWidget *qt.QTabWidget
 
 	// Tabs
-	enterTab   *qt.QWidget
-	teachTab   *qt.QWidget
-	resultsTab *qt.QWidget
+	enterTab     *qt.QWidget
+	teachTab     *qt.QWidget
+	dictationTab *dictation.DictationTeachWidget
+	resultsTab   *qt.QWidget
 
 	// Enter tab components
 	enterLayout  *qt.QVBoxLayout
@@ -39,6 +44,8 @@ type MediaLessonWidget struct {
 	clearButton  *qt.QPushButton
 	importButton *qt.QPushButton
 	saveButton   *qt.QPushButton
+	relinkButton *qt.QPushButton
+	gatherButton *qt.QPushButton
 
 	// Teach tab components
 	teachLayout   *qt.QVBoxLayout
@@ -51,6 +58,8 @@ type MediaLessonWidget struct {
 	nextButton    *qt.QPushButton
 	scoreLabel    *qt.QLabel
 	progressLabel *qt.QLabel
+	choiceWidget  *multiplechoice.MultipleChoiceTeachWidget
+	choiceOptions *multiplechoice.SettingsWidget
 
 	playButton        *qt.QPushButton
 	openBrowserButton *qt.QPushButton
@@ -94,6 +103,7 @@ func (w *MediaLessonWidget) setupUI() {
 	// Setup tabs
 	w.setupEnterTab()
 	w.setupTeachTab()
+	w.setupDictationTab()
 	w.setupResultsTab()
 }
 
@@ -137,6 +147,16 @@ func (w *MediaLessonWidget) setupEnterTab() {
 	w.saveButton.SetText("Save")
 	buttonLayout.AddWidget(w.saveButton.QWidget)
 
+	w.relinkButton = qt.NewQPushButton(w.enterTab)
+	w.relinkButton.SetText("Relink Media...")
+	w.relinkButton.SetToolTip("Find media files that were moved, by searching a folder for them")
+	buttonLayout.AddWidget(w.relinkButton.QWidget)
+
+	w.gatherButton = qt.NewQPushButton(w.enterTab)
+	w.gatherButton.SetText("Collect Media")
+	w.gatherButton.SetToolTip("Copy the linked media files into a media folder next to the lesson, so the folder can be moved as a whole")
+	buttonLayout.AddWidget(w.gatherButton.QWidget)
+
 	buttonLayout.AddStretch()
 	w.enterLayout.AddLayout(buttonLayout.QLayout)
 
@@ -244,6 +264,11 @@ func (w *MediaLessonWidget) setupTeachTab() {
 	w.answerInput.SetStyleSheet("font-size: 16px; padding: 8px; margin: 5px;")
 	answerLayout.AddWidget(w.answerInput.QWidget)
 
+	// In multiple choice mode the answer is picked from buttons
+	w.choiceWidget = multiplechoice.NewMultipleChoiceTeachWidget(w.teachTab)
+	w.choiceWidget.Hide()
+	answerLayout.AddWidget(w.choiceWidget.QWidget)
+
 	w.teachLayout.AddWidget(answerFrame.QWidget)
 
 	// Control buttons
@@ -261,6 +286,13 @@ func (w *MediaLessonWidget) setupTeachTab() {
 	controlLayout.AddWidget(w.nextButton.QWidget)
 
 	controlLayout.AddStretch()
+
+	var lessonData *lesson.LessonData
+	if w.lesson != nil {
+		lessonData = &w.lesson.Data
+	}
+	w.choiceOptions = multiplechoice.NewSettingsWidget(lessonData, w.teachTab)
+	controlLayout.AddWidget(w.choiceOptions.QWidget)
 	w.teachLayout.AddLayout(controlLayout.QLayout)
 
 	w.teachLayout.AddStretch()
@@ -269,6 +301,22 @@ func (w *MediaLessonWidget) setupTeachTab() {
 	w.tabWidget.AddTab(w.teachTab, "Practice")
 }
 
+// setupDictationTab creates the tab dictating the lesson's audio items
+func (w *MediaLessonWidget) setupDictationTab() {
+	w.dictationTab = dictation.NewDictationTeachWidget(w.QWidget)
+	w.tabWidget.AddTab(w.dictationTab.QWidget, "Dictation")
+}
+
+// startDictation restarts the dictation with the lesson's audio items.
+// Answers are checked as leniently as the lesson's option preset allows.
+func (w *MediaLessonWidget) startDictation() {
+	if w.lesson == nil {
+		w.dictationTab.SetLesson(nil, nil)
+		return
+	}
+	w.dictationTab.SetLesson(w.lesson, lesson.LessonAnswerChecker(&w.lesson.Data))
+}
+
 // setupResultsTab creates the results tab
 func (w *MediaLessonWidget) setupResultsTab() {
 	w.resultsTab = qt.NewQWidget(w.QWidget)
@@ -329,6 +377,13 @@ func (w *MediaLessonWidget) connectSignals() {
 		}
 	})
 
+	// A picked choice is graded like a typed answer
+	w.choiceWidget.OnChoice(func(choice string) {
+		w.answerInput.SetText(choice)
+		w.handleSubmitAnswer()
+		w.choiceWidget.Reveal(choice)
+	})
+
 	// List management buttons
 	w.addButton.OnClicked(func() {
 		w.handleAddMedia()
@@ -350,6 +405,14 @@ func (w *MediaLessonWidget) connectSignals() {
 		w.handleSave()
 	})
 
+	w.relinkButton.OnClicked(func() {
+		w.handleRelinkMedia()
+	})
+
+	w.gatherButton.OnClicked(func() {
+		w.handleCollectMedia()
+	})
+
 	w.playButton.OnClicked(func() {
 		w.handlePlayMedia()
 	})
@@ -357,6 +420,15 @@ func (w *MediaLessonWidget) connectSignals() {
 	w.openBrowserButton.OnClicked(func() {
 		w.handleOpenInBrowser()
 	})
+
+	// The dictation starts over each time its tab is opened
+	w.tabWidget.OnCurrentChanged(func(index int) {
+		if index == w.tabWidget.IndexOf(w.dictationTab.QWidget) {
+			w.startDictation()
+		} else {
+			w.dictationTab.Stop()
+		}
+	})
 }
 
 // updateData updates the widget with lesson data
@@ -392,7 +464,7 @@ func (w *MediaLessonWidget) updateData() {
 	}
 
 	// Reset teaching state
-	w.currentIndex = 0
+	w.currentIndex = lesson.NextAskedItem(&w.lesson.Data.List, 0)
 	w.score = 0
 	w.totalAnswers = 0
 	w.updateProgress()
@@ -427,6 +499,7 @@ func (w *MediaLessonWidget) updateTeachDisplay() {
 	if w.currentIndex >= len(w.lesson.Data.List.Items) {
 		w.questionLabel.SetText("Practice complete!")
 		w.mediaDisplay.SetText("All media items completed!")
+		w.choiceWidget.Hide()
 		w.submitButton.SetEnabled(false)
 		w.nextButton.SetText("Start Over")
 		return
@@ -458,6 +531,22 @@ func (w *MediaLessonWidget) updateTeachDisplay() {
 
 	w.answerInput.Clear()
 	w.answerInput.SetFocus()
+	w.showChoices()
+}
+
+// showChoices shows the choices for the current item when the lesson is
+// taught as multiple choice, and the answer input otherwise
+func (w *MediaLessonWidget) showChoices() {
+	choices := false
+	if w.lesson.Data.MultipleChoiceSettings().Enabled {
+		if err := w.choiceWidget.SetItem(&w.lesson.Data, w.currentIndex); err != nil {
+			log.Printf("No choices for media item %d, asking to type the answer: %v", w.currentIndex, err)
+		} else {
+			choices = true
+		}
+	}
+	w.choiceWidget.SetVisible(choices)
+	w.answerInput.SetVisible(!choices)
 }
 
 // handleSubmitAnswer processes the submitted answer
@@ -469,29 +558,22 @@ func (w *MediaLessonWidget) handleSubmitAnswer() {
 	userAnswer := w.answerInput.Text()
 	item := w.lesson.Data.List.Items[w.currentIndex]
 
-	// Check if answer is correct (case-insensitive)
+	// Answers are checked as leniently as the lesson's option preset allows
 	correct := false
 	expectedAnswer := "No answer provided"
 	if len(item.Answers) > 0 {
 		expectedAnswer = item.Answers[0]
-		for _, correctAnswer := range item.Answers {
-			if len(userAnswer) > 0 && len(correctAnswer) > 0 {
-				if userAnswer == correctAnswer {
-					correct = true
-					break
-				}
-			}
-		}
+		correct = lesson.LessonAnswerChecker(&w.lesson.Data).GradeItem(userAnswer, item).Correct
 	}
 
 	w.totalAnswers++
 	if correct {
 		w.score++
-		w.questionLabel.SetText(fmt.Sprintf("✅ Correct! The answer is: %s", expectedAnswer))
-		w.questionLabel.SetStyleSheet("font-size: 18px; font-weight: bold; margin: 20px; color: green;")
+		w.questionLabel.SetText(theme.Label(theme.FeedbackRight, fmt.Sprintf("Correct! The answer is: %s", expectedAnswer)))
+		w.questionLabel.SetStyleSheet(theme.Style(theme.FeedbackRight, "font-size: 18px; margin: 20px; "))
 	} else {
-		w.questionLabel.SetText(fmt.Sprintf("❌ Incorrect. The correct answer is: %s", expectedAnswer))
-		w.questionLabel.SetStyleSheet("font-size: 18px; font-weight: bold; margin: 20px; color: red;")
+		w.questionLabel.SetText(theme.Label(theme.FeedbackWrong, fmt.Sprintf("Incorrect. The correct answer is: %s", expectedAnswer)))
+		w.questionLabel.SetStyleSheet(theme.Style(theme.FeedbackWrong, "font-size: 18px; margin: 20px; "))
 	}
 
 	// Add to results table
@@ -512,11 +594,8 @@ func (w *MediaLessonWidget) handleNextQuestion() {
 		return
 	}
 
-	w.currentIndex++
-	if w.currentIndex >= len(w.lesson.Data.List.Items) {
-		// Practice complete, restart
-		w.currentIndex = 0
-	}
+	// Practice restarts after the last item; suspended items are skipped
+	w.currentIndex = lesson.NextAskedItem(&w.lesson.Data.List, w.currentIndex+1)
 
 	w.questionLabel.SetStyleSheet("font-size: 18px; font-weight: bold; margin: 20px; text-align: center;")
 	w.updateTeachDisplay()
@@ -537,11 +616,12 @@ func (w *MediaLessonWidget) addResultToTable(mediaItem, question, userAnswer str
 	answerItem := qt.NewQTableWidgetItem2(userAnswer)
 	w.resultsTable.SetItem(row, 2, answerItem)
 
-	resultText := "❌ Wrong"
+	kind, resultText := theme.FeedbackWrong, "Wrong"
 	if correct {
-		resultText = "✅ Correct"
+		kind, resultText = theme.FeedbackRight, "Correct"
 	}
-	resultItem := qt.NewQTableWidgetItem2(resultText)
+	resultItem := qt.NewQTableWidgetItem2(theme.Label(kind, resultText))
+	resultItem.SetForeground(qt.NewQBrush3(qt.NewQColor6(theme.Colors(kind).Text)))
 	w.resultsTable.SetItem(row, 3, resultItem)
 
 	// Update statistics
@@ -871,6 +951,66 @@ func (w *MediaLessonWidget) handleSave() {
 	msgBox.Exec()
 }
 
+// resolveMedia returns where the media of an item can be opened, writing
+// embedded media to the cache
+func (w *MediaLessonWidget) resolveMedia(filename string, remote bool) string {
+	path, err := w.lesson.Data.ResolveMedia(w.lesson.Path, filename, remote)
+	if err != nil {
+		log.Printf("[ERROR] MediaLessonWidget.resolveMedia() - %v", err)
+		return filename
+	}
+	return path
+}
+
+// handleRelinkMedia searches a folder for the media files the lesson links
+// to that were moved
+func (w *MediaLessonWidget) handleRelinkMedia() {
+	if w.lesson == nil {
+		return
+	}
+	dir := qt.QFileDialog_GetExistingDirectory3(w.QWidget, "Search Media In", filepath.Dir(w.lesson.Path))
+	if dir == "" {
+		return
+	}
+	report := w.lesson.Data.RelinkMedia(w.lesson.Path, []string{dir})
+	w.updateData()
+	w.showMediaReport("Relink Media", report)
+}
+
+// handleCollectMedia copies the linked media files next to the lesson
+func (w *MediaLessonWidget) handleCollectMedia() {
+	if w.lesson == nil {
+		return
+	}
+	report, err := w.lesson.Data.CollectMedia(w.lesson.Path)
+	if err != nil {
+		msgBox := qt.NewQMessageBox(w.QWidget)
+		msgBox.SetWindowTitle("Collect Media")
+		msgBox.SetText(fmt.Sprintf("Failed to collect media: %v", err))
+		msgBox.SetIcon(qt.QMessageBox__Warning)
+		msgBox.SetStandardButtons(qt.QMessageBox__Ok)
+		msgBox.Exec()
+		return
+	}
+	w.updateData()
+	w.showMediaReport("Collect Media", report)
+}
+
+// showMediaReport tells what relinking or collecting media did
+func (w *MediaLessonWidget) showMediaReport(title string, report *lesson.MediaReport) {
+	msgBox := qt.NewQMessageBox(w.QWidget)
+	msgBox.SetWindowTitle(title)
+	msgBox.SetText(fmt.Sprintf("%d media files relinked, %d missing.", len(report.Relinked), len(report.Missing)))
+	msgBox.SetDetailedText(report.String())
+	if len(report.Missing) > 0 {
+		msgBox.SetIcon(qt.QMessageBox__Warning)
+	} else {
+		msgBox.SetIcon(qt.QMessageBox__Information)
+	}
+	msgBox.SetStandardButtons(qt.QMessageBox__Ok)
+	msgBox.Exec()
+}
+
 // setupMediaPreview creates the media preview widget
 func (w *MediaLessonWidget) setupMediaPreview() {
 	w.mediaPreview = qt.NewQWidget(w.teachTab)
@@ -904,7 +1044,7 @@ func (w *MediaLessonWidget) updateMediaDisplay(item lesson.WordItem, mediaName s
 			w.mediaImage.SetText("🌐 Remote Media\n\nClick 'Open in Browser'\nto view online content")
 		} else {
 			// Local file - try to display or show info
-			w.displayLocalMedia(filename, mediaName)
+			w.displayLocalMedia(w.resolveMedia(filename, remote), mediaName)
 		}
 	} else {
 		// No media file info
@@ -993,15 +1133,8 @@ func (w *MediaLessonWidget) handlePlayMedia() {
 		return
 	}
 
-	var targetPath string
-	if remote {
-		targetPath = filename // URL
-	} else {
-		targetPath = filename // File path
-	}
-
 	// Open with system default application
-	w.openWithSystem(targetPath)
+	w.openWithSystem(w.resolveMedia(filename, remote))
 }
 
 // handleOpenInBrowser handles the open in browser button click
@@ -1019,7 +1152,7 @@ func (w *MediaLessonWidget) handleOpenInBrowser() {
 			targetUrl = filename // Already a URL
 		} else {
 			// Local file - convert to file:// URL
-			absPath, err := filepath.Abs(filename)
+			absPath, err := filepath.Abs(w.resolveMedia(filename, remote))
 			if err != nil {
 				absPath = filename
 			}
@@ -1095,5 +1228,6 @@ func (w *MediaLessonWidget) GetLesson() *lesson.Lesson {
 // SetLesson sets a new lesson for this widget
 func (w *MediaLessonWidget) SetLesson(lesson *lesson.Lesson) {
 	w.lesson = lesson
+	w.choiceOptions.SetLessonData(&lesson.Data)
 	w.updateData()
 }
@@ -0,0 +1,558 @@
+// Package occlusion provides the image occlusion lesson widget
+//
+// OcclusionLessonWidget lets the user draw rectangular masks over the labels
+// of an image and quizzes them by revealing one mask at a time
+package occlusion
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/theme"
+	"github.com/mappu/miqt/qt"
+)
+
+// Largest size the image is shown at; masks are stored in image pixels
+const (
+	canvasWidth  = 640
+	canvasHeight = 420
+	// minMaskSize is the smallest drag, in screen pixels, that draws a mask
+	minMaskSize = 6
+)
+
+// Mask styles in the editor, while a mask is asked and while it waits
+const (
+	editMaskStyle   = "background-color: rgba(255, 107, 107, 200); color: white; border: 2px solid #ee5a5a; font-weight: bold;"
+	askedMaskStyle  = "background-color: #0078d4; color: white; border: 2px solid #005a9e; font-weight: bold; font-size: 16px;"
+	hiddenMaskStyle = "background-color: #ffa94d; border: 2px solid #e8590c;"
+)
+
+// OcclusionLessonWidget handles image occlusion lesson editing and teaching
+type OcclusionLessonWidget struct {
+	*qt.QWidget
+	lesson *lesson.Lesson
+
+	tabWidget *qt.QTabWidget
+
+	// Edit tab components
+	editCanvas      *qt.QLabel
+	editMasks       []*qt.QLabel
+	rubberBand      *qt.QRubberBand
+	masksList       *qt.QListWidget
+	loadImageButton *qt.QPushButton
+	removeButton    *qt.QPushButton
+	saveButton      *qt.QPushButton
+	hintLabel       *qt.QLabel
+
+	// Teach tab components
+	teachCanvas   *qt.QLabel
+	teachMasks    []*qt.QLabel
+	questionLabel *qt.QLabel
+	answerInput   *qt.QLineEdit
+	submitButton  *qt.QPushButton
+	nextButton    *qt.QPushButton
+	scoreLabel    *qt.QLabel
+
+	// Image state; scale is screen pixels per image pixel
+	pixmap *qt.QPixmap
+	scale  float64
+
+	// Drawing state
+	dragging bool
+	dragFrom image.Point
+
+	// Teaching state
+	order    []int
+	current  int
+	score    int
+	answered bool
+	checker  *lesson.AnswerChecker
+}
+
+// NewOcclusionLessonWidget creates a new image occlusion lesson widget
+func NewOcclusionLessonWidget(lesson *lesson.Lesson, parent *qt.QWidget) *OcclusionLessonWidget {
+	widget := &OcclusionLessonWidget{
+		QWidget: qt.NewQWidget(parent),
+		lesson:  lesson,
+		scale:   1,
+	}
+
+	widget.setupUI()
+	widget.connectSignals()
+	widget.loadLessonImage()
+	widget.updateData()
+
+	return widget
+}
+
+// setupUI creates the edit and teach tabs
+func (w *OcclusionLessonWidget) setupUI() {
+	mainLayout := qt.NewQVBoxLayout(w.QWidget)
+	w.tabWidget = qt.NewQTabWidget(w.QWidget)
+	mainLayout.AddWidget(w.tabWidget.QWidget)
+
+	w.tabWidget.AddTab(w.setupEditTab(), "Edit Masks")
+	w.tabWidget.AddTab(w.setupTeachTab(), "Teach")
+}
+
+// setupEditTab creates the image with the masks being drawn and the mask list
+func (w *OcclusionLessonWidget) setupEditTab() *qt.QWidget {
+	tab := qt.NewQWidget(nil)
+	layout := qt.NewQHBoxLayout(tab)
+
+	w.editCanvas = w.newCanvas("Load an image, then drag over its labels to mask them")
+	w.rubberBand = qt.NewQRubberBand2(qt.QRubberBand__Rectangle, w.editCanvas.QWidget)
+	layout.AddWidget(w.editCanvas.QWidget)
+
+	sideLayout := qt.NewQVBoxLayout2()
+	w.loadImageButton = qt.NewQPushButton3("Load Image...")
+	sideLayout.AddWidget(w.loadImageButton.QWidget)
+
+	w.hintLabel = qt.NewQLabel3("Drag a rectangle over a label to mask it. Separate alternative answers with a semicolon.")
+	w.hintLabel.SetWordWrap(true)
+	w.hintLabel.SetStyleSheet("color: #666;")
+	sideLayout.AddWidget(w.hintLabel.QWidget)
+
+	w.masksList = qt.NewQListWidget(nil)
+	sideLayout.AddWidget(w.masksList.QWidget)
+
+	w.removeButton = qt.NewQPushButton3("Remove Mask")
+	sideLayout.AddWidget(w.removeButton.QWidget)
+	w.saveButton = qt.NewQPushButton3("Save Lesson")
+	sideLayout.AddWidget(w.saveButton.QWidget)
+
+	layout.AddLayout(sideLayout.QLayout)
+	return tab
+}
+
+// setupTeachTab creates the masked image and the answer controls
+func (w *OcclusionLessonWidget) setupTeachTab() *qt.QWidget {
+	tab := qt.NewQWidget(nil)
+	layout := qt.NewQVBoxLayout(tab)
+
+	w.teachCanvas = w.newCanvas("Load an image in the 'Edit Masks' tab to begin practicing")
+	layout.AddWidget(w.teachCanvas.QWidget)
+
+	w.questionLabel = qt.NewQLabel3("What is under the blue mask?")
+	w.questionLabel.SetAlignment(qt.AlignCenter)
+	w.questionLabel.SetStyleSheet("font-size: 16px; font-weight: bold; margin: 8px;")
+	layout.AddWidget(w.questionLabel.QWidget)
+
+	answerLayout := qt.NewQHBoxLayout2()
+	w.answerInput = qt.NewQLineEdit(nil)
+	w.answerInput.SetPlaceholderText("Type what the mask hides...")
+	answerLayout.AddWidget(w.answerInput.QWidget)
+	w.submitButton = qt.NewQPushButton3("Check")
+	answerLayout.AddWidget(w.submitButton.QWidget)
+	w.nextButton = qt.NewQPushButton3("Next")
+	answerLayout.AddWidget(w.nextButton.QWidget)
+	layout.AddLayout(answerLayout.QLayout)
+
+	w.scoreLabel = qt.NewQLabel3("")
+	w.scoreLabel.SetAlignment(qt.AlignCenter)
+	layout.AddWidget(w.scoreLabel.QWidget)
+
+	return tab
+}
+
+// newCanvas creates a label the image and its masks are shown on
+func (w *OcclusionLessonWidget) newCanvas(placeholder string) *qt.QLabel {
+	canvas := qt.NewQLabel3(placeholder)
+	canvas.SetFixedSize2(canvasWidth, canvasHeight)
+	canvas.SetAlignment(qt.AlignCenter)
+	canvas.SetWordWrap(true)
+	canvas.SetStyleSheet("color: #666; background-color: #f8f8f8; border: 2px solid #ddd;")
+	return canvas
+}
+
+// connectSignals connects buttons and the mouse handling of the edit canvas
+func (w *OcclusionLessonWidget) connectSignals() {
+	w.loadImageButton.OnClicked(func() {
+		w.handleLoadImage()
+	})
+	w.removeButton.OnClicked(func() {
+		w.handleRemoveMask()
+	})
+	w.saveButton.OnClicked(func() {
+		w.handleSave()
+	})
+
+	w.editCanvas.OnMousePressEvent(func(super func(ev *qt.QMouseEvent), ev *qt.QMouseEvent) {
+		if w.pixmap == nil || ev.Button() != qt.LeftButton {
+			super(ev)
+			return
+		}
+		w.dragging = true
+		w.dragFrom = image.Pt(ev.X(), ev.Y())
+		w.rubberBand.SetGeometry2(ev.X(), ev.Y(), 0, 0)
+		w.rubberBand.Show()
+	})
+	w.editCanvas.OnMouseMoveEvent(func(super func(ev *qt.QMouseEvent), ev *qt.QMouseEvent) {
+		if !w.dragging {
+			super(ev)
+			return
+		}
+		rect := image.Rectangle{Min: w.dragFrom, Max: image.Pt(ev.X(), ev.Y())}.Canon()
+		w.rubberBand.SetGeometry2(rect.Min.X, rect.Min.Y, rect.Dx(), rect.Dy())
+	})
+	w.editCanvas.OnMouseReleaseEvent(func(super func(ev *qt.QMouseEvent), ev *qt.QMouseEvent) {
+		if !w.dragging {
+			super(ev)
+			return
+		}
+		w.dragging = false
+		w.rubberBand.Hide()
+		w.handleDrawMask(image.Rectangle{Min: w.dragFrom, Max: image.Pt(ev.X(), ev.Y())}.Canon())
+	})
+
+	w.submitButton.OnClicked(func() {
+		w.handleSubmitAnswer()
+	})
+	w.answerInput.OnReturnPressed(func() {
+		if w.answered {
+			w.handleNextMask()
+		} else {
+			w.handleSubmitAnswer()
+		}
+	})
+	w.nextButton.OnClicked(func() {
+		w.handleNextMask()
+	})
+	w.tabWidget.OnCurrentChanged(func(index int) {
+		if index == 1 {
+			w.startTeaching()
+		}
+	})
+}
+
+// loadLessonImage shows the image stored in the lesson, if there is one
+func (w *OcclusionLessonWidget) loadLessonImage() {
+	if w.lesson == nil {
+		return
+	}
+	img, ok := w.lesson.Data.OcclusionImage()
+	if !ok {
+		return
+	}
+	pixmap := qt.NewQPixmap()
+	if !pixmap.LoadFromDataWithData(img.Data) {
+		log.Printf("Failed to decode occlusion image %s", img.Name)
+		return
+	}
+	w.setPixmap(pixmap)
+}
+
+// setPixmap shows pixmap on both canvases, scaled down to fit them
+func (w *OcclusionLessonWidget) setPixmap(pixmap *qt.QPixmap) {
+	w.pixmap = pixmap
+	scaled := pixmap
+	if pixmap.Width() > canvasWidth || pixmap.Height() > canvasHeight {
+		scaled = pixmap.Scaled3(canvasWidth, canvasHeight, qt.KeepAspectRatio, qt.SmoothTransformation)
+	}
+	w.scale = float64(scaled.Width()) / float64(pixmap.Width())
+
+	for _, canvas := range []*qt.QLabel{w.editCanvas, w.teachCanvas} {
+		canvas.SetFixedSize2(scaled.Width(), scaled.Height())
+		canvas.SetStyleSheet("")
+		canvas.SetAlignment(qt.AlignLeft | qt.AlignTop)
+		canvas.SetPixmap(scaled)
+	}
+}
+
+// toImage converts a rectangle on a canvas to image pixels
+func (w *OcclusionLessonWidget) toImage(rect image.Rectangle) image.Rectangle {
+	return image.Rect(
+		int(float64(rect.Min.X)/w.scale), int(float64(rect.Min.Y)/w.scale),
+		int(float64(rect.Max.X)/w.scale), int(float64(rect.Max.Y)/w.scale),
+	)
+}
+
+// toCanvas converts a rectangle in image pixels to a canvas rectangle
+func (w *OcclusionLessonWidget) toCanvas(rect image.Rectangle) image.Rectangle {
+	return image.Rect(
+		int(float64(rect.Min.X)*w.scale), int(float64(rect.Min.Y)*w.scale),
+		int(float64(rect.Max.X)*w.scale), int(float64(rect.Max.Y)*w.scale),
+	)
+}
+
+// handleLoadImage asks for an image and stores it in the lesson
+func (w *OcclusionLessonWidget) handleLoadImage() {
+	fileName := qt.QFileDialog_GetOpenFileName4(w.QWidget, "Load Image", "",
+		"Images (*.png *.jpg *.jpeg *.gif *.bmp *.svg);;All Files (*.*)")
+	if fileName == "" {
+		return
+	}
+
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		w.showError("Load Error", fmt.Sprintf("Failed to read image: %v", err))
+		return
+	}
+	pixmap := qt.NewQPixmap()
+	if !pixmap.LoadFromDataWithData(data) {
+		w.showError("Load Error", fmt.Sprintf("%s is not an image Recuerdo can show", filepath.Base(fileName)))
+		return
+	}
+
+	w.lesson.Data.SetOcclusionImage(fileName, data)
+	if w.lesson.Data.List.Title == "" {
+		w.lesson.Data.List.Title = strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName))
+	}
+	w.lesson.Data.Changed = true
+	w.setPixmap(pixmap)
+	w.updateData()
+}
+
+// handleDrawMask asks what a newly drawn mask hides and adds it
+func (w *OcclusionLessonWidget) handleDrawMask(rect image.Rectangle) {
+	if rect.Dx() < minMaskSize || rect.Dy() < minMaskSize {
+		return
+	}
+	rect = rect.Intersect(image.Rect(0, 0, w.editCanvas.Width(), w.editCanvas.Height()))
+
+	text := qt.QInputDialog_GetText(w.QWidget, "Mask Answer", "What does this mask hide?")
+	var answers []string
+	for _, answer := range strings.Split(text, ";") {
+		if answer = strings.TrimSpace(answer); answer != "" {
+			answers = append(answers, answer)
+		}
+	}
+	if len(answers) == 0 {
+		return
+	}
+
+	w.lesson.Data.List.AddOcclusionItem(w.toImage(rect), answers)
+	w.lesson.Data.Changed = true
+	w.updateData()
+}
+
+// handleRemoveMask removes the mask selected in the list
+func (w *OcclusionLessonWidget) handleRemoveMask() {
+	row := w.masksList.CurrentRow()
+	indices := w.maskIndices()
+	if row < 0 || row >= len(indices) {
+		return
+	}
+
+	items := w.lesson.Data.List.Items
+	index := indices[row]
+	w.lesson.Data.List.Items = append(items[:index], items[index+1:]...)
+	w.lesson.Data.Changed = true
+	w.updateData()
+}
+
+// maskIndices returns the indices of the lesson items that are masks
+func (w *OcclusionLessonWidget) maskIndices() []int {
+	var indices []int
+	for i := range w.lesson.Data.List.Items {
+		if w.lesson.Data.List.Items[i].IsOcclusionItem() {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// updateData redraws the masks and the mask list
+func (w *OcclusionLessonWidget) updateData() {
+	if w.lesson == nil {
+		return
+	}
+
+	for _, mask := range w.editMasks {
+		mask.Close()
+	}
+	w.editMasks = nil
+	w.masksList.Clear()
+
+	for number, index := range w.maskIndices() {
+		item := w.lesson.Data.List.Items[index]
+		rect, _ := item.OcclusionRect()
+		w.masksList.AddItem(fmt.Sprintf("%d. %s", number+1, strings.Join(item.Answers, "; ")))
+		if w.pixmap == nil {
+			continue
+		}
+		mask := w.newMask(w.editCanvas, rect, editMaskStyle)
+		mask.SetText(fmt.Sprintf("%d", number+1))
+		mask.SetToolTip(strings.Join(item.Answers, "; "))
+		w.editMasks = append(w.editMasks, mask)
+	}
+	w.removeButton.SetEnabled(len(w.editMasks) > 0)
+}
+
+// newMask creates a mask label on canvas covering rect, in image pixels.
+// Masks ignore the mouse so drawing can start on top of them.
+func (w *OcclusionLessonWidget) newMask(canvas *qt.QLabel, rect image.Rectangle, style string) *qt.QLabel {
+	rect = w.toCanvas(rect)
+	mask := qt.NewQLabel(canvas.QWidget)
+	mask.SetGeometry(rect.Min.X, rect.Min.Y, rect.Dx(), rect.Dy())
+	mask.SetAlignment(qt.AlignCenter)
+	mask.SetStyleSheet(style)
+	mask.SetAttribute(qt.WA_TransparentForMouseEvents)
+	mask.Show()
+	return mask
+}
+
+// startTeaching covers every mask and asks them in random order
+func (w *OcclusionLessonWidget) startTeaching() {
+	for _, mask := range w.teachMasks {
+		mask.Close()
+	}
+	w.teachMasks = nil
+
+	w.order = lesson.ApplyDifficulty(&w.lesson.Data.List, w.maskIndices())
+	rand.Shuffle(len(w.order), func(i, j int) {
+		w.order[i], w.order[j] = w.order[j], w.order[i]
+	})
+	w.current = 0
+	w.score = 0
+
+	if w.pixmap == nil || len(w.order) == 0 {
+		w.questionLabel.SetText("Draw some masks in the 'Edit Masks' tab to begin practicing")
+		w.answerInput.SetEnabled(false)
+		w.submitButton.SetEnabled(false)
+		w.nextButton.SetEnabled(false)
+		return
+	}
+
+	for _, index := range w.order {
+		rect, _ := w.lesson.Data.List.Items[index].OcclusionRect()
+		w.teachMasks = append(w.teachMasks, w.newMask(w.teachCanvas, rect, hiddenMaskStyle))
+	}
+
+	// Answers are checked as leniently as the lesson's option preset allows
+	w.checker = lesson.LessonAnswerChecker(&w.lesson.Data)
+
+	w.answerInput.SetEnabled(true)
+	w.askCurrent()
+}
+
+// askCurrent highlights the mask being asked
+func (w *OcclusionLessonWidget) askCurrent() {
+	w.answered = false
+	w.teachMasks[w.current].SetStyleSheet(askedMaskStyle)
+	w.teachMasks[w.current].SetText("?")
+	w.teachMasks[w.current].Raise()
+
+	w.questionLabel.SetText("What is under the blue mask?")
+	w.questionLabel.SetStyleSheet("font-size: 16px; font-weight: bold; margin: 8px;")
+	w.answerInput.Clear()
+	w.answerInput.SetFocus()
+	w.submitButton.SetEnabled(true)
+	w.nextButton.SetEnabled(false)
+	w.updateScore()
+}
+
+// handleSubmitAnswer grades the answer and reveals the asked mask
+func (w *OcclusionLessonWidget) handleSubmitAnswer() {
+	if w.answered || w.current >= len(w.order) {
+		return
+	}
+
+	item := w.lesson.Data.List.Items[w.order[w.current]]
+	grade := w.checker.Grade(w.answerInput.Text(), item.Answers)
+	if grade.Correct {
+		w.score++
+		w.questionLabel.SetText(theme.Label(theme.FeedbackRight, fmt.Sprintf("Correct! It is %s", item.Answers[0])))
+		w.questionLabel.SetStyleSheet(theme.Style(theme.FeedbackRight, "font-size: 16px; margin: 8px; "))
+	} else {
+		w.questionLabel.SetText(theme.Label(theme.FeedbackWrong, fmt.Sprintf("Incorrect. It is %s", item.Answers[0])))
+		w.questionLabel.SetStyleSheet(theme.Style(theme.FeedbackWrong, "font-size: 16px; margin: 8px; "))
+	}
+	w.lesson.Data.List.AddGradedResult(item.ID, grade)
+	w.lesson.Data.Changed = true
+
+	// Reveal the label under the mask
+	w.teachMasks[w.current].Hide()
+	w.answered = true
+	w.submitButton.SetEnabled(false)
+	w.nextButton.SetEnabled(true)
+	w.nextButton.SetFocus()
+	w.updateScore()
+}
+
+// handleNextMask asks the next mask or, after the last one, starts over
+func (w *OcclusionLessonWidget) handleNextMask() {
+	if !w.answered {
+		return
+	}
+	w.current++
+	if w.current >= len(w.order) {
+		msgBox := qt.NewQMessageBox(w.QWidget)
+		msgBox.SetWindowTitle("Practice Complete")
+		msgBox.SetText(fmt.Sprintf("You named %d of %d masks correctly.", w.score, len(w.order)))
+		msgBox.SetIcon(qt.QMessageBox__Information)
+		msgBox.SetStandardButtons(qt.QMessageBox__Ok)
+		msgBox.Exec()
+		w.startTeaching()
+		return
+	}
+	w.askCurrent()
+}
+
+// updateScore shows the progress through the masks
+func (w *OcclusionLessonWidget) updateScore() {
+	w.scoreLabel.SetText(fmt.Sprintf("Mask %d of %d - Score: %d", w.current+1, len(w.order), w.score))
+}
+
+// handleSave saves the lesson as an .otio file
+func (w *OcclusionLessonWidget) handleSave() {
+	if w.lesson == nil {
+		return
+	}
+
+	filePath := w.lesson.Path
+	if filePath == "" || strings.HasPrefix(filePath, "*") || !strings.EqualFold(filepath.Ext(filePath), ".otio") {
+		fileDialog := qt.NewQFileDialog(w.QWidget)
+		fileDialog.SetWindowTitle("Save Image Occlusion Lesson")
+		fileDialog.SetNameFilter("OpenTeaching Image Occlusion (*.otio);;All Files (*.*)")
+		fileDialog.SetAcceptMode(qt.QFileDialog__AcceptSave)
+		fileDialog.SetDefaultSuffix("otio")
+		if fileDialog.Exec() != int(qt.QDialog__Accepted) || len(fileDialog.SelectedFiles()) == 0 {
+			return
+		}
+		filePath = fileDialog.SelectedFiles()[0]
+		w.lesson.Path = filePath
+	}
+
+	if err := lesson.NewFileSaver().SaveFile(&w.lesson.Data, filePath); err != nil {
+		w.showError("Save Error", fmt.Sprintf("Failed to save file: %v", err))
+		return
+	}
+
+	w.lesson.Data.Changed = false
+	msgBox := qt.NewQMessageBox(w.QWidget)
+	msgBox.SetWindowTitle("Save Complete")
+	msgBox.SetText(fmt.Sprintf("Successfully saved image occlusion lesson to %s", filepath.Base(filePath)))
+	msgBox.SetIcon(qt.QMessageBox__Information)
+	msgBox.SetStandardButtons(qt.QMessageBox__Ok)
+	msgBox.Exec()
+}
+
+// showError shows an error message box
+func (w *OcclusionLessonWidget) showError(title, text string) {
+	msgBox := qt.NewQMessageBox(w.QWidget)
+	msgBox.SetWindowTitle(title)
+	msgBox.SetText(text)
+	msgBox.SetIcon(qt.QMessageBox__Critical)
+	msgBox.SetStandardButtons(qt.QMessageBox__Ok)
+	msgBox.Exec()
+}
+
+// GetLesson returns the lesson associated with this widget
+func (w *OcclusionLessonWidget) GetLesson() *lesson.Lesson {
+	return w.lesson
+}
+
+// SetLesson sets a new lesson for this widget
+func (w *OcclusionLessonWidget) SetLesson(lesson *lesson.Lesson) {
+	w.lesson = lesson
+	w.pixmap = nil
+	w.loadLessonImage()
+	w.updateData()
+}
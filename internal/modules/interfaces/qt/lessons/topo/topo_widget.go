@@ -2,6 +2,7 @@
 package topo
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -10,6 +11,8 @@ import (
 
 	"github.com/LaPingvino/recuerdo/internal/lesson"
 	"github.com/LaPingvino/recuerdo/internal/maps"
+	multiplechoice "github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/teachTypes/multipleChoice"
+	"github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/theme"
 	"github.com/mappu/miqt/qt"
 )
 
@@ -117,6 +120,8 @@ type TopoLessonWidget struct {
 	nextButton    *qt.QPushButton
 	scoreLabel    *qt.QLabel
 	progressLabel *qt.QLabel
+	choiceWidget  *multiplechoice.MultipleChoiceTeachWidget
+	choiceOptions *multiplechoice.SettingsWidget
 
 	// Results tab components
 	resultsLayout *qt.QVBoxLayout
@@ -137,6 +142,9 @@ type TopoLessonWidget struct {
 	mapManager  *maps.MapManager
 	currentMap  *maps.BaseMap
 	addingPlace bool
+	// transfers queues tile downloads, so large regions wait for an
+	// unmetered connection
+	transfers TransferQueue
 
 	// Simplified page management
 	editPage     *SimplePage
@@ -148,6 +156,12 @@ type TopoLessonWidget struct {
 	totalAnswers int
 }
 
+// TransferQueue is the part of the transfers module tile downloads are
+// queued in
+type TransferQueue interface {
+	Add(kind, name string, size int64, run func(ctx context.Context) error) int
+}
+
 // NewTopoLessonWidget creates a new topography lesson widget
 func NewTopoLessonWidget(lesson *lesson.Lesson, parent *qt.QWidget) *TopoLessonWidget {
 	widget := &TopoLessonWidget{
@@ -165,6 +179,7 @@ func NewTopoLessonWidget(lesson *lesson.Lesson, parent *qt.QWidget) *TopoLessonW
 	widget.setupMapWidget()
 	widget.updateData()
 	widget.connectSignals()
+	widget.selectLessonMap()
 
 	return widget
 }
@@ -361,6 +376,11 @@ func (w *TopoLessonWidget) setupTeachTab() {
 
 	w.practicePage.AddWidget(answerContainer, 120)
 
+	// In multiple choice mode the place is picked from buttons
+	w.choiceWidget = multiplechoice.NewMultipleChoiceTeachWidget(nil)
+	w.choiceWidget.Hide()
+	w.practicePage.AddWidget(w.choiceWidget.QWidget, 0)
+
 	// Control buttons
 	buttonContainer := qt.NewQWidget(nil)
 	buttonLayout := qt.NewQHBoxLayout(buttonContainer)
@@ -378,6 +398,13 @@ func (w *TopoLessonWidget) setupTeachTab() {
 	buttonLayout.AddWidget(w.nextButton.QWidget)
 
 	buttonLayout.AddStretch()
+
+	var lessonData *lesson.LessonData
+	if w.lesson != nil {
+		lessonData = &w.lesson.Data
+	}
+	w.choiceOptions = multiplechoice.NewSettingsWidget(lessonData, nil)
+	buttonLayout.AddWidget(w.choiceOptions.QWidget)
 	w.practicePage.AddWidget(buttonContainer, 60)
 
 	// Teaching map section
@@ -629,6 +656,13 @@ func (w *TopoLessonWidget) connectSignals() {
 		}
 	})
 
+	// A picked choice is graded like a typed answer
+	w.choiceWidget.OnChoice(func(choice string) {
+		w.answerInput.SetText(choice)
+		w.handleSubmitAnswer()
+		w.choiceWidget.Reveal(choice)
+	})
+
 	// Map click handling for adding places and teaching
 	w.mapOverlay.SetMouseTracking(true)
 
@@ -696,7 +730,7 @@ func (w *TopoLessonWidget) updateData() {
 	}
 
 	// Reset teaching state
-	w.currentIndex = 0
+	w.currentIndex = lesson.NextAskedItem(&w.lesson.Data.List, 0)
 	w.score = 0
 	w.totalAnswers = 0
 	w.updateProgress()
@@ -731,6 +765,7 @@ func (w *TopoLessonWidget) updateTeachDisplay() {
 		w.questionLabel.SetText("Practice complete!")
 		w.submitButton.SetEnabled(false)
 		w.nextButton.SetText("Start Over")
+		w.choiceWidget.Hide()
 		return
 	}
 
@@ -746,6 +781,22 @@ func (w *TopoLessonWidget) updateTeachDisplay() {
 
 	w.answerInput.Clear()
 	w.answerInput.SetFocus()
+	w.showChoices()
+}
+
+// showChoices shows the choices for the current place when the lesson is
+// taught as multiple choice, and the answer input otherwise
+func (w *TopoLessonWidget) showChoices() {
+	choices := false
+	if w.lesson.Data.MultipleChoiceSettings().Enabled {
+		if err := w.choiceWidget.SetItem(&w.lesson.Data, w.currentIndex); err != nil {
+			log.Printf("No choices for place %d, asking to type the answer: %v", w.currentIndex, err)
+		} else {
+			choices = true
+		}
+	}
+	w.choiceWidget.SetVisible(choices)
+	w.answerInput.SetVisible(!choices)
 }
 
 // handleSubmitAnswer processes the submitted answer
@@ -757,31 +808,21 @@ func (w *TopoLessonWidget) handleSubmitAnswer() {
 	userAnswer := w.answerInput.Text()
 	item := w.lesson.Data.List.Items[w.currentIndex]
 
-	// Check if answer is correct (case-insensitive)
-	correct := false
-	if len(item.Answers) > 0 {
-		for _, correctAnswer := range item.Answers {
-			if len(userAnswer) > 0 && len(correctAnswer) > 0 {
-				if userAnswer == correctAnswer {
-					correct = true
-					break
-				}
-			}
-		}
-	}
+	// Answers are checked as leniently as the lesson's option preset allows
+	correct := lesson.LessonAnswerChecker(&w.lesson.Data).GradeItem(userAnswer, item).Correct
 
 	w.totalAnswers++
 	if correct {
 		w.score++
-		w.questionLabel.SetText(fmt.Sprintf("✅ Correct! The answer is: %s", item.Answers[0]))
-		w.questionLabel.SetStyleSheet("font-size: 18px; font-weight: bold; margin: 20px; color: green;")
+		w.questionLabel.SetText(theme.Label(theme.FeedbackRight, fmt.Sprintf("Correct! The answer is: %s", item.Answers[0])))
+		w.questionLabel.SetStyleSheet(theme.Style(theme.FeedbackRight, "font-size: 18px; margin: 20px; "))
 	} else {
 		expectedAnswer := "Unknown"
 		if len(item.Answers) > 0 {
 			expectedAnswer = item.Answers[0]
 		}
-		w.questionLabel.SetText(fmt.Sprintf("❌ Incorrect. The correct answer is: %s", expectedAnswer))
-		w.questionLabel.SetStyleSheet("font-size: 18px; font-weight: bold; margin: 20px; color: red;")
+		w.questionLabel.SetText(theme.Label(theme.FeedbackWrong, fmt.Sprintf("Incorrect. The correct answer is: %s", expectedAnswer)))
+		w.questionLabel.SetStyleSheet(theme.Style(theme.FeedbackWrong, "font-size: 18px; margin: 20px; "))
 	}
 
 	// Add to results table
@@ -798,11 +839,8 @@ func (w *TopoLessonWidget) handleNextQuestion() {
 		return
 	}
 
-	w.currentIndex++
-	if w.currentIndex >= len(w.lesson.Data.List.Items) {
-		// Practice complete, restart
-		w.currentIndex = 0
-	}
+	// Practice restarts after the last item; suspended items are skipped
+	w.currentIndex = lesson.NextAskedItem(&w.lesson.Data.List, w.currentIndex+1)
 
 	w.questionLabel.SetStyleSheet("font-size: 18px; font-weight: bold; margin: 20px; text-align: center;")
 	w.updateTeachDisplay()
@@ -817,11 +855,12 @@ func (w *TopoLessonWidget) addResultToTable(placeName string, correct bool) {
 	placeItem := qt.NewQTableWidgetItem2(placeName)
 	w.resultsTable.SetItem(row, 0, placeItem)
 
-	resultText := "❌ Wrong"
+	kind, resultText := theme.FeedbackWrong, "Wrong"
 	if correct {
-		resultText = "✅ Correct"
+		kind, resultText = theme.FeedbackRight, "Correct"
 	}
-	resultItem := qt.NewQTableWidgetItem2(resultText)
+	resultItem := qt.NewQTableWidgetItem2(theme.Label(kind, resultText))
+	resultItem.SetForeground(qt.NewQBrush3(qt.NewQColor6(theme.Colors(kind).Text)))
 	w.resultsTable.SetItem(row, 1, resultItem)
 
 	// Update statistics
@@ -1070,6 +1109,10 @@ func (w *TopoLessonWidget) handleLoadMap() {
 	}
 
 	w.currentMap = baseMap
+	if w.lesson != nil {
+		// Remember the map so thumbnails can show the places on it
+		w.lesson.Data.SetTopoMap(mapID)
+	}
 
 	// Update map display
 	w.mapLabel.SetPixmap(w.mapPixmap)
@@ -1389,8 +1432,8 @@ func (w *TopoLessonWidget) submitCorrectAnswer(answer string) {
 	w.updateProgress()
 
 	// Show feedback
-	w.questionLabel.SetText(fmt.Sprintf("✅ Correct! The answer is '%s'", answer))
-	w.questionLabel.SetStyleSheet("font-size: 18px; font-weight: bold; margin: 20px; color: #4CAF50;")
+	w.questionLabel.SetText(theme.Label(theme.FeedbackRight, fmt.Sprintf("Correct! The answer is '%s'", answer)))
+	w.questionLabel.SetStyleSheet(theme.Style(theme.FeedbackRight, "font-size: 18px; margin: 20px; "))
 
 	w.submitButton.SetEnabled(false)
 	w.nextButton.SetText("Next Place")
@@ -1413,9 +1456,9 @@ func (w *TopoLessonWidget) submitWrongAnswer(feedback string) {
 	w.updateProgress()
 
 	// Show feedback
-	w.questionLabel.SetText(fmt.Sprintf("❌ Wrong! %s\nThe correct answer is '%s'",
-		feedback, correctAnswer))
-	w.questionLabel.SetStyleSheet("font-size: 18px; font-weight: bold; margin: 20px; color: #f44336;")
+	w.questionLabel.SetText(theme.Label(theme.FeedbackWrong, fmt.Sprintf("Wrong! %s\nThe correct answer is '%s'",
+		feedback, correctAnswer)))
+	w.questionLabel.SetStyleSheet(theme.Style(theme.FeedbackWrong, "font-size: 18px; margin: 20px; "))
 
 	w.submitButton.SetEnabled(false)
 	w.nextButton.SetText("Next Place")
@@ -1686,6 +1729,24 @@ func (w *TopoLessonWidget) handleDownloadTiles() {
 		return
 	}
 
+	if w.transfers != nil && w.mapManager.GetTileManager() != nil {
+		tiles, size, err := w.mapManager.GetTileManager().EstimateRegionSize(tileMapID, north, south, east, west, zoom)
+		if err != nil {
+			log.Printf("Failed to estimate tile download: %v", err)
+			return
+		}
+		name := fmt.Sprintf("%s, %d tiles at zoom %d", w.tileMapComboBox.CurrentText(), tiles, zoom)
+		w.transfers.Add("Map tiles", name, size, func(ctx context.Context) error {
+			return w.mapManager.DownloadTilesForRegionContext(ctx, tileMapID, north, south, east, west, zoom)
+		})
+		msgBox := qt.NewQMessageBox(w.QWidget)
+		msgBox.SetWindowTitle("Download & Cache Tiles")
+		msgBox.SetText(fmt.Sprintf("The %d tiles are downloaded in the background. Large downloads wait for a connection that is not metered; see Tools → Transfers.", tiles))
+		msgBox.SetIcon(qt.QMessageBox__Information)
+		msgBox.Exec()
+		return
+	}
+
 	// Show progress dialog
 	w.downloadTilesButton.SetText("Downloading...")
 	w.downloadTilesButton.SetEnabled(false)
@@ -1764,8 +1825,28 @@ func (w *TopoLessonWidget) handleCreateTileMap() {
 	log.Printf("Created tile-based map: %s", baseMap.Name)
 }
 
+// SetTransferQueue makes tile downloads go through the transfers queue
+func (w *TopoLessonWidget) SetTransferQueue(transfers TransferQueue) {
+	w.transfers = transfers
+}
+
 // SetLesson sets a new lesson for this widget
 func (w *TopoLessonWidget) SetLesson(lesson *lesson.Lesson) {
 	w.lesson = lesson
+	w.choiceOptions.SetLessonData(&lesson.Data)
 	w.updateData()
+	w.selectLessonMap()
+}
+
+// selectLessonMap loads the base map the lesson was made on, if it has one
+func (w *TopoLessonWidget) selectLessonMap() {
+	if w.lesson == nil || w.lesson.Data.TopoMap() == "" {
+		return
+	}
+	index := w.mapComboBox.FindData(qt.NewQVariant17(w.lesson.Data.TopoMap()))
+	if index <= 0 {
+		return
+	}
+	w.mapComboBox.SetCurrentIndex(index)
+	w.handleLoadMap()
 }
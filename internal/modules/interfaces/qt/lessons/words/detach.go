@@ -0,0 +1,132 @@
+package words
+
+import (
+	lessontracker "github.com/LaPingvino/recuerdo/internal/modules/logic/interfaces/lessonTracker"
+	"github.com/mappu/miqt/qt"
+)
+
+// SetChangeNotifier sets the function the widget tells about changes to the
+// lesson, with where they were made: lessontracker.OriginEnter or
+// lessontracker.OriginTeach. The notifier is expected to call
+// LessonChanged on every view of the lesson, this one included. Without
+// one the widget keeps its own tabs in sync.
+func (w *WordsLessonWidget) SetChangeNotifier(notify func(origin string)) {
+	w.notify = notify
+}
+
+// changed passes on a change to the lesson made in one of the tabs
+func (w *WordsLessonWidget) changed(origin string) {
+	if w.notify != nil {
+		w.notify(origin)
+		return
+	}
+	w.LessonChanged(origin)
+}
+
+// LessonChanged brings the tabs up to date after the lesson was changed
+// elsewhere, such as in the Enter tab while the Teach tab is detached
+func (w *WordsLessonWidget) LessonChanged(origin string) {
+	switch origin {
+	case lessontracker.OriginEnter:
+		w.teachWidget.LessonEdited()
+		w.resultsWidget.UpdateLesson(w.lesson)
+		if w.teachWindow != nil {
+			w.teachWindow.SetWindowTitle("Practice: " + lessonTitle(w.lesson))
+		}
+	case lessontracker.OriginTeach:
+		w.resultsWidget.UpdateLesson(w.lesson)
+	}
+}
+
+// detachTeach moves the Teach tab into a window of its own, on a second
+// screen when there is one, so a class can practise on the projector while
+// the Enter and Results tabs stay on the main screen. The tab comes back
+// when the window is closed.
+func (w *WordsLessonWidget) detachTeach() {
+	if w.teachWindow != nil {
+		w.teachWindow.ActivateWindow()
+		return
+	}
+	w.logger.Action("Detaching the Teach tab into its own window")
+
+	w.teachWindow = qt.NewQWidget(nil)
+	w.teachWindow.SetWindowTitle("Practice: " + lessonTitle(w.lesson))
+	w.teachWindow.SetAttribute(qt.WA_DeleteOnClose)
+	layout := qt.NewQVBoxLayout(w.teachWindow)
+
+	placeholder := qt.NewQWidget(w.QWidget)
+	placeholderLayout := qt.NewQVBoxLayout(placeholder)
+	placeholderLayout.AddStretch()
+	label := qt.NewQLabel3("Practising in a separate window")
+	label.SetAlignment(qt.AlignCenter)
+	placeholderLayout.AddWidget(label.QWidget)
+	bringBack := qt.NewQPushButton3("&Bring Back")
+	bringBack.OnClicked(func() {
+		if w.teachWindow != nil {
+			w.teachWindow.Close()
+		}
+	})
+	placeholderLayout.AddWidget3(bringBack.QWidget, 0, qt.AlignCenter)
+	placeholderLayout.AddStretch()
+
+	w.movingTeach = true
+	w.tabWidget.RemoveTab(teachPage)
+	w.tabWidget.InsertTab(teachPage, placeholder, "Teach")
+	w.tabWidget.SetCurrentIndex(0)
+	layout.AddWidget(w.teachWidget.QWidget)
+	w.teachWidget.Show()
+	w.movingTeach = false
+	w.detachButton.SetEnabled(false)
+
+	w.teachWindow.OnCloseEvent(func(super func(event *qt.QCloseEvent), event *qt.QCloseEvent) {
+		w.attachTeach()
+		super(event)
+	})
+	// Closing the lesson closes its practice window too
+	window := w.teachWindow
+	w.OnDestroyed(func() {
+		if w.teachWindow == window {
+			w.teachWindow = nil
+			window.Close()
+		}
+	})
+
+	if screen := secondaryScreen(w.QWidget); screen != nil {
+		geometry := screen.AvailableGeometry()
+		w.teachWindow.SetGeometry(geometry.X(), geometry.Y(), geometry.Width(), geometry.Height())
+		w.teachWindow.ShowMaximized()
+	} else {
+		w.teachWindow.Resize(900, 650)
+		w.teachWindow.Show()
+	}
+}
+
+// attachTeach puts the Teach tab back in the lesson widget
+func (w *WordsLessonWidget) attachTeach() {
+	if w.teachWindow == nil {
+		return
+	}
+	w.logger.Action("Attaching the Teach tab again")
+	w.teachWindow = nil
+
+	w.movingTeach = true
+	placeholder := w.tabWidget.Widget(teachPage)
+	w.tabWidget.RemoveTab(teachPage)
+	w.tabWidget.InsertTab(teachPage, w.teachWidget.QWidget, "Teach")
+	w.tabWidget.SetCurrentIndex(teachPage)
+	placeholder.DeleteLater()
+	w.movingTeach = false
+	w.detachButton.SetEnabled(true)
+}
+
+// secondaryScreen returns a screen other than the one widget is shown on,
+// or nil when there is only one
+func secondaryScreen(widget *qt.QWidget) *qt.QScreen {
+	current := widget.Screen()
+	for _, screen := range qt.QGuiApplication_Screens() {
+		if current == nil || screen.Name() != current.Name() {
+			return screen
+		}
+	}
+	return nil
+}
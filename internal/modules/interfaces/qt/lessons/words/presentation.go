@@ -0,0 +1,109 @@
+package words
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/LaPingvino/recuerdo/internal/touch"
+	"github.com/mappu/miqt/qt"
+)
+
+// presentationHelp tells the class how to step through the presentation
+const presentationHelp = "Space: reveal / next    Backspace: back    Esc: close"
+
+// presentationWindow shows a list full-screen for a whole-class drill on a
+// projector: large text, the answer revealed on a key press and nothing to
+// edit
+type presentationWindow struct {
+	*qt.QWidget
+	presentation *lesson.Presentation
+	question     *qt.QLabel
+	answer       *qt.QLabel
+	comment      *qt.QLabel
+	progress     *qt.QLabel
+}
+
+// showPresentation opens the full-screen presentation of items
+func showPresentation(items []lesson.WordItem) {
+	p := &presentationWindow{
+		QWidget:      qt.NewQWidget(nil),
+		presentation: lesson.NewPresentation(items),
+	}
+	p.SetWindowTitle("Presentation")
+	p.SetAttribute(qt.WA_DeleteOnClose)
+	p.SetStyleSheet("background-color: black; color: white;")
+
+	layout := qt.NewQVBoxLayout(p.QWidget)
+	layout.AddStretch()
+	p.question = p.label(72, true)
+	layout.AddWidget(p.question.QWidget)
+	p.answer = p.label(60, false)
+	p.answer.SetStyleSheet("color: #ffd866;")
+	layout.AddWidget(p.answer.QWidget)
+	p.comment = p.label(28, false)
+	p.comment.SetStyleSheet("color: #a0a0a0;")
+	layout.AddWidget(p.comment.QWidget)
+	layout.AddStretch()
+	p.progress = p.label(16, false)
+	p.progress.SetStyleSheet("color: #707070;")
+	layout.AddWidget(p.progress.QWidget)
+
+	p.OnKeyPressEvent(func(super func(event *qt.QKeyEvent), event *qt.QKeyEvent) {
+		switch qt.Key(event.Key()) {
+		case qt.Key_Space, qt.Key_Return, qt.Key_Enter, qt.Key_Right, qt.Key_Down, qt.Key_PageDown:
+			p.presentation.Next()
+		case qt.Key_Backspace, qt.Key_Left, qt.Key_Up, qt.Key_PageUp:
+			p.presentation.Previous()
+		case qt.Key_Escape, qt.Key_Q:
+			p.Close()
+			return
+		default:
+			super(event)
+			return
+		}
+		p.update()
+	})
+	// Clicking steps forward too, for a presenter with a remote mouse, and
+	// on a smartboard swiping right steps back
+	var pressedAt *qt.QPoint
+	var pressedTime time.Time
+	p.OnMousePressEvent(func(super func(event *qt.QMouseEvent), event *qt.QMouseEvent) {
+		pressedAt, pressedTime = event.GlobalPos(), time.Now()
+	})
+	p.OnMouseReleaseEvent(func(super func(event *qt.QMouseEvent), event *qt.QMouseEvent) {
+		if pressedAt == nil {
+			return
+		}
+		released := event.GlobalPos()
+		if touch.DetectSwipe(released.X()-pressedAt.X(), released.Y()-pressedAt.Y(), time.Since(pressedTime)) == touch.SwipeRight {
+			p.presentation.Previous()
+		} else {
+			p.presentation.Next()
+		}
+		pressedAt = nil
+		p.update()
+	})
+
+	p.update()
+	p.ShowFullScreen()
+}
+
+func (p *presentationWindow) label(pointSize int, bold bool) *qt.QLabel {
+	label := qt.NewQLabel(p.QWidget)
+	label.SetAlignment(qt.AlignCenter)
+	label.SetWordWrap(true)
+	font := label.Font()
+	font.SetPointSize(pointSize)
+	font.SetBold(bold)
+	label.SetFont(font)
+	return label
+}
+
+func (p *presentationWindow) update() {
+	p.question.SetText(strings.Join(p.presentation.Question(), " / "))
+	p.answer.SetText(strings.Join(p.presentation.Answer(), " / "))
+	p.comment.SetText(p.presentation.Comment())
+	p.progress.SetText(fmt.Sprintf("%d / %d    %s", p.presentation.Position()+1, p.presentation.Len(), presentationHelp))
+}
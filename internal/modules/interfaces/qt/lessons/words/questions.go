@@ -0,0 +1,99 @@
+package words
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/classroom"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/mappu/miqt/qt"
+)
+
+// classroomClient is the classroom server students ask their teacher
+// questions through; nil when no server is configured
+var classroomClient *classroom.Client
+
+// classroomCode is the join code students post their questions with, and
+// rememberCode stores a newly entered one
+var (
+	classroomCode string
+	rememberCode  func(code string)
+)
+
+// SetClassroom lets students ask the teacher about the word they are
+// practising through client, posting with joinCode. When joinCode is empty
+// the student is asked for it once and remember is called with it. A nil
+// client hides the Ask Teacher button of Teach tabs created afterwards.
+func SetClassroom(client *classroom.Client, joinCode string, remember func(code string)) {
+	classroomClient = client
+	classroomCode = joinCode
+	rememberCode = remember
+}
+
+// askAboutItem shows the discussion about an item of the lesson at path and
+// lets the student add a question to it
+func askAboutItem(parent *qt.QWidget, path string, item lesson.WordItem) error {
+	if classroomClient == nil {
+		return fmt.Errorf("no classroom server is configured")
+	}
+	if path == "" {
+		return fmt.Errorf("save the lesson on the classroom server before asking about it")
+	}
+	name := filepath.Base(path)
+
+	if classroomCode == "" {
+		ok := false
+		code := qt.QInputDialog_GetText4(parent, "Ask Teacher", "Your join code:", qt.QLineEdit__Normal, "", &ok)
+		if !ok || strings.TrimSpace(code) == "" {
+			return nil
+		}
+		if _, err := classroomClient.Join(code); err != nil {
+			return err
+		}
+		classroomCode = strings.ToUpper(strings.TrimSpace(code))
+		if rememberCode != nil {
+			rememberCode(classroomCode)
+		}
+	}
+
+	threads, err := classroomClient.LessonThreads(name)
+	if err != nil {
+		return err
+	}
+	var thread classroom.Thread
+	for _, t := range threads {
+		if t.ItemID == item.ID {
+			thread = t
+		}
+	}
+
+	question := strings.Join(item.Questions, ", ")
+	dialog := qt.NewQDialog(parent)
+	dialog.SetWindowTitle("Ask Teacher: " + question)
+	dialog.Resize(480, 420)
+	layout := qt.NewQVBoxLayout(dialog.QWidget)
+
+	if len(thread.Messages) > 0 {
+		transcript := qt.NewQTextEdit(dialog.QWidget)
+		transcript.SetReadOnly(true)
+		transcript.SetPlainText(thread.Transcript())
+		layout.AddWidget(transcript.QWidget)
+	}
+	layout.AddWidget(qt.NewQLabel3(fmt.Sprintf("Your question about %q:", question)).QWidget)
+	text := qt.NewQPlainTextEdit(dialog.QWidget)
+	layout.AddWidget(text.QWidget)
+
+	buttons := qt.NewQDialogButtonBox(dialog.QWidget)
+	buttons.SetStandardButtons(qt.QDialogButtonBox__Ok | qt.QDialogButtonBox__Cancel)
+	buttons.OnAccepted(dialog.Accept)
+	buttons.OnRejected(dialog.Reject)
+	layout.AddWidget(buttons.QWidget)
+
+	if dialog.Exec() != int(qt.QDialog__Accepted) || strings.TrimSpace(text.ToPlainText()) == "" {
+		return nil
+	}
+	post := classroom.ThreadPost{Code: classroomCode, Question: question, Message: classroom.Message{Text: text.ToPlainText()}}
+	_, err = classroomClient.Post(name, item.ID, post)
+	return err
+}
@@ -0,0 +1,178 @@
+package words
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/mappu/miqt/qt"
+)
+
+// fieldNames are shown in the preview for every field find and replace
+// changes
+var fieldNames = map[string]string{
+	lesson.FieldQuestions: "Question",
+	lesson.FieldAnswers:   "Answer",
+	lesson.FieldComment:   "Comment",
+}
+
+// replaceDialog finds and replaces text in the words of a lesson, showing
+// every change before it is made
+type replaceDialog struct {
+	*qt.QDialog
+	lessonData   *lesson.LessonData
+	findEdit     *qt.QLineEdit
+	replaceEdit  *qt.QLineEdit
+	regexp       *qt.QCheckBox
+	matchCase    *qt.QCheckBox
+	wholeWords   *qt.QCheckBox
+	questions    *qt.QCheckBox
+	answers      *qt.QCheckBox
+	comments     *qt.QCheckBox
+	preview      *qt.QTableWidget
+	status       *qt.QLabel
+	replaceAll   *qt.QPushButton
+	replacements []lesson.Replacement
+}
+
+// showReplaceDialog asks what to replace in lessonData. apply is called
+// with the changes the user kept; it is not called when the dialog is
+// cancelled.
+func showReplaceDialog(parent *qt.QWidget, lessonData *lesson.LessonData, apply func(description string, replacements []lesson.Replacement)) {
+	d := &replaceDialog{QDialog: qt.NewQDialog(parent), lessonData: lessonData}
+	d.SetWindowTitle("Find and Replace")
+	d.Resize(640, 480)
+
+	layout := qt.NewQVBoxLayout(d.QWidget)
+	form := qt.NewQFormLayout2()
+	d.findEdit = qt.NewQLineEdit(d.QWidget)
+	form.AddRow3("Find:", d.findEdit.QWidget)
+	d.replaceEdit = qt.NewQLineEdit(d.QWidget)
+	d.replaceEdit.SetPlaceholderText("Leave empty to remove the text")
+	form.AddRow3("Replace with:", d.replaceEdit.QWidget)
+	layout.AddLayout(form.QLayout)
+
+	options := qt.NewQHBoxLayout2()
+	d.regexp = qt.NewQCheckBox3("Regular expression")
+	d.regexp.SetToolTip("Refer to groups in the replacement as $1, $2, ...")
+	d.matchCase = qt.NewQCheckBox3("Match case")
+	d.wholeWords = qt.NewQCheckBox3("Whole words")
+	for _, box := range []*qt.QCheckBox{d.regexp, d.matchCase, d.wholeWords} {
+		options.AddWidget(box.QWidget)
+	}
+	options.AddStretch()
+	layout.AddLayout(options.QLayout)
+
+	scope := qt.NewQHBoxLayout2()
+	scope.AddWidget(qt.NewQLabel3("Search in:").QWidget)
+	d.questions = qt.NewQCheckBox3("Questions")
+	d.answers = qt.NewQCheckBox3("Answers")
+	d.comments = qt.NewQCheckBox3("Comments")
+	for _, box := range []*qt.QCheckBox{d.questions, d.answers, d.comments} {
+		box.SetChecked(true)
+		scope.AddWidget(box.QWidget)
+	}
+	scope.AddStretch()
+	layout.AddLayout(scope.QLayout)
+
+	d.preview = qt.NewQTableWidget(d.QWidget)
+	d.preview.SetColumnCount(4)
+	d.preview.SetHorizontalHeaderLabels([]string{"Word", "Field", "Before", "After"})
+	d.preview.SetEditTriggers(qt.QAbstractItemView__NoEditTriggers)
+	d.preview.HorizontalHeader().SetStretchLastSection(true)
+	layout.AddWidget(d.preview.QWidget)
+
+	d.status = qt.NewQLabel(d.QWidget)
+	layout.AddWidget(d.status.QWidget)
+
+	buttons := qt.NewQHBoxLayout2()
+	buttons.AddStretch()
+	previewButton := qt.NewQPushButton3("Preview")
+	previewButton.SetDefault(true)
+	previewButton.OnClicked(d.updatePreview)
+	buttons.AddWidget(previewButton.QWidget)
+	d.replaceAll = qt.NewQPushButton3("Replace Checked")
+	d.replaceAll.SetEnabled(false)
+	d.replaceAll.OnClicked(func() {
+		checked := d.checked()
+		if len(checked) == 0 {
+			return
+		}
+		apply(fmt.Sprintf("Replace %q with %q", d.findEdit.Text(), d.replaceEdit.Text()), checked)
+		d.Accept()
+	})
+	buttons.AddWidget(d.replaceAll.QWidget)
+	cancelButton := qt.NewQPushButton3("Cancel")
+	cancelButton.OnClicked(d.Reject)
+	buttons.AddWidget(cancelButton.QWidget)
+	layout.AddLayout(buttons.QLayout)
+
+	// Any change of the search makes the preview stale
+	stale := func() {
+		d.replacements = nil
+		d.preview.SetRowCount(0)
+		d.replaceAll.SetEnabled(false)
+		d.status.SetText("Press Preview to see the changes")
+	}
+	d.findEdit.OnTextChanged(func(string) { stale() })
+	d.replaceEdit.OnTextChanged(func(string) { stale() })
+	for _, box := range []*qt.QCheckBox{d.regexp, d.matchCase, d.wholeWords, d.questions, d.answers, d.comments} {
+		box.OnToggled(func(bool) { stale() })
+	}
+	stale()
+
+	d.Exec()
+}
+
+// updatePreview lists the changes replacing would make, all checked
+func (d *replaceDialog) updatePreview() {
+	options := lesson.ReplaceOptions{
+		Find:       d.findEdit.Text(),
+		Replace:    d.replaceEdit.Text(),
+		Regexp:     d.regexp.IsChecked(),
+		MatchCase:  d.matchCase.IsChecked(),
+		WholeWords: d.wholeWords.IsChecked(),
+		Questions:  d.questions.IsChecked(),
+		Answers:    d.answers.IsChecked(),
+		Comments:   d.comments.IsChecked(),
+	}
+	if !options.Questions && !options.Answers && !options.Comments {
+		d.status.SetText("Choose where to search")
+		return
+	}
+	replacements, err := lesson.FindReplacements(d.lessonData, options)
+	if err != nil {
+		d.status.SetText(err.Error())
+		return
+	}
+
+	d.replacements = replacements
+	d.preview.SetRowCount(len(replacements))
+	for row, r := range replacements {
+		word := qt.NewQTableWidgetItem2(strings.Join(d.lessonData.List.Items[r.Item].Questions, ", "))
+		word.SetFlags(qt.ItemIsUserCheckable | qt.ItemIsEnabled | qt.ItemIsSelectable)
+		word.SetCheckState(qt.Checked)
+		d.preview.SetItem(row, 0, word)
+		d.preview.SetItem(row, 1, qt.NewQTableWidgetItem2(fieldNames[r.Field]))
+		d.preview.SetItem(row, 2, qt.NewQTableWidgetItem2(r.Before))
+		d.preview.SetItem(row, 3, qt.NewQTableWidgetItem2(r.After))
+	}
+	d.preview.ResizeColumnsToContents()
+	d.replaceAll.SetEnabled(len(replacements) > 0)
+	if len(replacements) == 0 {
+		d.status.SetText("No matches")
+	} else {
+		d.status.SetText(fmt.Sprintf("%d changes; uncheck those to skip", len(replacements)))
+	}
+}
+
+// checked returns the previewed changes the user left checked
+func (d *replaceDialog) checked() []lesson.Replacement {
+	var checked []lesson.Replacement
+	for row, r := range d.replacements {
+		if item := d.preview.Item(row, 0); item != nil && item.CheckState() == qt.Checked {
+			checked = append(checked, r)
+		}
+	}
+	return checked
+}
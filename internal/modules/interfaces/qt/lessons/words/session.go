@@ -0,0 +1,130 @@
+package words
+
+import (
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	sessionrestore "github.com/LaPingvino/recuerdo/internal/modules/logic/sessionRestore"
+)
+
+// teachPage is the index of the Teach tab
+const teachPage = 1
+
+// SessionState returns the tab shown and the practice session in progress,
+// to be resumed when Recuerdo starts again. Practice in a detached window
+// is resumed in the Teach tab.
+func (w *WordsLessonWidget) SessionState() (int, *sessionrestore.Practice) {
+	if w.teachWindow != nil {
+		return teachPage, w.teachWidget.PracticeState()
+	}
+	return w.GetCurrentTab(), w.teachWidget.PracticeState()
+}
+
+// RestoreSession shows a tab again and resumes the practice session that
+// was in progress on the Teach tab. It reports whether the session could
+// be resumed; the lesson may have changed too much since.
+func (w *WordsLessonWidget) RestoreSession(page int, practice *sessionrestore.Practice) bool {
+	w.SetCurrentTab(page)
+	if practice == nil || page != teachPage {
+		return false
+	}
+	return w.teachWidget.ResumePractice(*practice)
+}
+
+// PracticeState returns the practice session in progress, or nil when
+// there is none
+func (w *TeachTabWidget) PracticeState() *sessionrestore.Practice {
+	if !w.isTeaching || w.currentSession == nil {
+		return nil
+	}
+	practice := &sessionrestore.Practice{}
+	for _, question := range w.questions {
+		saved := sessionrestore.Question{Item: question.itemIndex, Reversed: question.reversed}
+		if question.cloze != nil {
+			saved.Cloze = question.cloze.Number
+		}
+		practice.Questions = append(practice.Questions, saved)
+	}
+	for _, result := range w.currentSession.Results {
+		practice.Answers = append(practice.Answers, sessionrestore.Answer{
+			Item:    result.ItemIndex,
+			Answer:  result.UserAnswer,
+			Correct: result.IsCorrect,
+			Credit:  result.Credit,
+		})
+	}
+	// Nothing is left to ask once the last question was answered
+	if len(practice.Answers) >= len(practice.Questions) {
+		return nil
+	}
+	return practice
+}
+
+// ResumePractice continues a practice session at its first unanswered
+// question and reports whether it could
+func (w *TeachTabWidget) ResumePractice(practice sessionrestore.Practice) bool {
+	if w.lesson == nil || !practice.Valid(len(w.lesson.Data.List.Items)) {
+		return false
+	}
+	list := &w.lesson.Data.List
+
+	questions := make([]teachQuestion, 0, len(practice.Questions))
+	for _, saved := range practice.Questions {
+		question := teachQuestion{itemIndex: saved.Item, reversed: saved.Reversed}
+		if saved.Cloze != 0 {
+			card, ok := clozeCard(list.Items[saved.Item].Cloze, saved.Cloze)
+			if !ok {
+				return false
+			}
+			question.cloze = &card
+		}
+		questions = append(questions, question)
+	}
+
+	w.questions = questions
+	w.usePreset(lesson.LessonPreset(&w.lesson.Data))
+	w.isTeaching = true
+	w.currentIndex = len(practice.Answers)
+	w.correctAnswers = practice.Correct()
+	w.totalQuestions = len(questions)
+	w.currentSession = &TeachingSession{
+		Results:        make([]TeachingResult, 0, w.totalQuestions),
+		TotalQuestions: w.totalQuestions,
+		CorrectCount:   w.correctAnswers,
+	}
+	for i, answer := range practice.Answers {
+		item := questions[i].card().Item(list)
+		result := TeachingResult{
+			Question:      strings.Join(item.Questions, " / "),
+			CorrectAnswer: strings.Join(item.Answers, " / "),
+			UserAnswer:    answer.Answer,
+			IsCorrect:     answer.Correct,
+			ItemIndex:     answer.Item,
+			Credit:        answer.Credit,
+		}
+		if card := questions[i].cloze; card != nil {
+			result.Question = card.Prompt
+			result.CorrectAnswer = strings.Join(card.Answers, ", ")
+		}
+		w.currentSession.Results = append(w.currentSession.Results, result)
+	}
+
+	w.startButton.SetEnabled(false)
+	w.answerEdit.SetEnabled(true)
+	w.submitButton.SetEnabled(true)
+	w.nextButton.SetEnabled(false)
+	w.unicodeButton.SetEnabled(true)
+	w.showCurrentQuestion()
+	w.logger.Action("Resumed teaching session at question %d of %d", w.currentIndex+1, w.totalQuestions)
+	return true
+}
+
+// clozeCard returns the card of a cloze item asking deletion number
+func clozeCard(text string, number int) (lesson.ClozeCard, bool) {
+	for _, card := range lesson.ClozeCards(text) {
+		if card.Number == number {
+			return card, true
+		}
+	}
+	return lesson.ClozeCard{}, false
+}
@@ -0,0 +1,56 @@
+package words
+
+import (
+	"fmt"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/mappu/miqt/qt"
+)
+
+// confirmSessionPlan shows what the session will contain and lets the user
+// ask fewer new or review words. It reports whether the session should
+// start.
+func confirmSessionPlan(parent *qt.QWidget, plan *lesson.SessionPlan) bool {
+	dialog := qt.NewQDialog(parent)
+	dialog.SetWindowTitle("Practice Session")
+	dialog.SetModal(true)
+
+	layout := qt.NewQVBoxLayout(dialog.QWidget)
+	dialog.SetLayout(layout.QLayout)
+
+	summary := qt.NewQLabel(dialog.QWidget)
+	summary.SetWordWrap(true)
+	summary.SetStyleSheet("font-weight: bold; margin-bottom: 10px;")
+	layout.AddWidget(summary.QWidget)
+
+	form := qt.NewQFormLayout2()
+	newBox := qt.NewQSpinBox(dialog.QWidget)
+	newBox.SetRange(0, len(plan.New))
+	newBox.SetValue(plan.NewCount)
+	newBox.SetSuffix(fmt.Sprintf(" of %d", len(plan.New)))
+	form.AddRow3("New words:", newBox.QWidget)
+	reviewBox := qt.NewQSpinBox(dialog.QWidget)
+	reviewBox.SetRange(0, len(plan.Reviews))
+	reviewBox.SetValue(plan.ReviewCount)
+	reviewBox.SetSuffix(fmt.Sprintf(" of %d", len(plan.Reviews)))
+	form.AddRow3("Reviews:", reviewBox.QWidget)
+	layout.AddLayout2(form.QLayout, 0)
+
+	buttonBox := qt.NewQDialogButtonBox(dialog.QWidget)
+	buttonBox.SetStandardButtons(qt.QDialogButtonBox__Ok | qt.QDialogButtonBox__Cancel)
+	buttonBox.Button(qt.QDialogButtonBox__Ok).SetText("Start")
+	layout.AddWidget(buttonBox.QWidget)
+
+	update := func() {
+		plan.SetCounts(newBox.Value(), reviewBox.Value())
+		summary.SetText(plan.String())
+		buttonBox.Button(qt.QDialogButtonBox__Ok).SetEnabled(plan.NewCount+plan.ReviewCount > 0)
+	}
+	newBox.OnValueChanged(func(int) { update() })
+	reviewBox.OnValueChanged(func(int) { update() })
+	buttonBox.OnAccepted(dialog.Accept)
+	buttonBox.OnRejected(dialog.Reject)
+	update()
+
+	return dialog.Exec() == int(qt.QDialog__Accepted)
+}
@@ -0,0 +1,12 @@
+package words
+
+// NextQuestion moves on to the next question once the current one was
+// answered, as swiping left on a smartboard does, and reports whether it
+// did. Answered questions are graded, so there is no going back.
+func (w *WordsLessonWidget) NextQuestion() bool {
+	if w.GetCurrentTab() != teachPage || !w.teachWidget.nextButton.IsEnabled() {
+		return false
+	}
+	w.teachWidget.nextQuestion()
+	return true
+}
@@ -2,10 +2,16 @@ package words
 
 import (
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/LaPingvino/recuerdo/internal/lesson"
 	"github.com/LaPingvino/recuerdo/internal/logging"
+	"github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/teachTypes/cloze"
+	multiplechoice "github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/teachTypes/multipleChoice"
+	"github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/theme"
+	lessontracker "github.com/LaPingvino/recuerdo/internal/modules/logic/interfaces/lessonTracker"
 	"github.com/mappu/miqt/qt"
 )
 
@@ -27,6 +33,16 @@ type WordsLessonWidget struct {
 	// Signals
 	lessonChanged *qt.QObject
 	tabChanged    *qt.QObject
+
+	// notify passes on changes to the lesson, see SetChangeNotifier
+	notify func(origin string)
+
+	// teachWindow holds the Teach tab while it is detached
+	teachWindow  *qt.QWidget
+	detachButton *qt.QToolButton
+	// movingTeach is set while the Teach tab moves, so the tab changes it
+	// causes do not reset the practice session
+	movingTeach bool
 }
 
 // NewWordsLessonWidget creates a new words lesson widget
@@ -74,6 +90,21 @@ func (w *WordsLessonWidget) setupUI() {
 		w.tabWidget.SetCurrentIndex(2)
 	})
 
+	// Edits and answers reach the other tabs, also when the Teach tab is
+	// detached
+	w.enterWidget.SetLessonChangedCallback(func() {
+		w.changed(lessontracker.OriginEnter)
+	})
+	w.teachWidget.SetAnswerRecordedCallback(func() {
+		w.changed(lessontracker.OriginTeach)
+	})
+
+	w.detachButton = qt.NewQToolButton(w.QWidget)
+	w.detachButton.SetText("Detach Practice")
+	w.detachButton.SetToolTip("Practise in a separate window, on a second screen such as a projector when there is one")
+	w.detachButton.OnClicked(w.detachTeach)
+	w.tabWidget.SetCornerWidget(w.detachButton.QWidget)
+
 	w.logger.Success("Created lesson widget with 3 tabs")
 }
 
@@ -89,7 +120,10 @@ func (w *WordsLessonWidget) connectSignals() {
 		case 0: // Enter tab
 			w.enterWidget.UpdateLesson(w.lesson)
 		case 1: // Teach tab
-			w.teachWidget.UpdateLesson(w.lesson)
+			// A detached Teach tab keeps its practice session
+			if w.teachWindow == nil && !w.movingTeach {
+				w.teachWidget.UpdateLesson(w.lesson)
+			}
 		case 2: // Results tab
 			w.resultsWidget.UpdateLesson(w.lesson)
 		}
@@ -131,11 +165,19 @@ func (w *WordsLessonWidget) updateLesson() {
 	w.resultsWidget.UpdateLesson(w.lesson)
 
 	// Update window title
-	title := w.lesson.Data.List.Title
-	if title == "" {
-		title = "Unnamed Lesson"
+	w.SetWindowTitle(fmt.Sprintf("Word Lesson: %s", lessonTitle(w.lesson)))
+	if w.teachWindow != nil {
+		w.teachWindow.SetWindowTitle("Practice: " + lessonTitle(w.lesson))
 	}
-	w.SetWindowTitle(fmt.Sprintf("Word Lesson: %s", title))
+}
+
+// lessonTitle returns the title of a lesson, or a placeholder when it has
+// none
+func lessonTitle(l *lesson.Lesson) string {
+	if l.Data.List.Title == "" {
+		return "Unnamed Lesson"
+	}
+	return l.Data.List.Title
 }
 
 // GetCurrentTab returns the currently active tab index
@@ -164,6 +206,35 @@ type EnterTabWidget struct {
 	wordsTable       *qt.QTableWidget
 	addWordButton    *qt.QPushButton
 	removeWordButton *qt.QPushButton
+	replaceButton    *qt.QPushButton
+	undoButton       *qt.QPushButton
+	redoButton       *qt.QPushButton
+
+	// undo remembers the edits made to the words, so they can be undone
+	undo *lesson.UndoStack
+
+	// updatingTable is set while the table is filled, so the item changes
+	// it causes are not taken as edits
+	updatingTable bool
+
+	// lessonChanged is called after every edit of the lesson
+	lessonChanged func()
+}
+
+// Columns of the words table
+const (
+	starredColumn    = 3
+	difficultyColumn = 4
+	tagsColumn       = 5
+	chapterColumn    = 6
+)
+
+// difficulties are the choices of the difficulty column, in order
+var difficulties = []lesson.Difficulty{
+	lesson.DifficultyNormal,
+	lesson.DifficultyAlwaysAsk,
+	lesson.DifficultySuspended,
+	lesson.DifficultyBuried,
 }
 
 // NewEnterTabWidget creates a new Enter tab widget
@@ -179,6 +250,20 @@ func NewEnterTabWidget(lesson *lesson.Lesson, parent *qt.QWidget) *EnterTabWidge
 	return widget
 }
 
+// SetLessonChangedCallback sets the function called after every edit of
+// the lesson
+func (w *EnterTabWidget) SetLessonChangedCallback(callback func()) {
+	w.lessonChanged = callback
+}
+
+// changed marks the lesson changed and tells the callback
+func (w *EnterTabWidget) changed() {
+	w.lesson.Data.Changed = true
+	if w.lessonChanged != nil {
+		w.lessonChanged()
+	}
+}
+
 // setupUI initializes the Enter tab interface
 func (w *EnterTabWidget) setupUI() {
 	layout := qt.NewQVBoxLayout(w.QWidget)
@@ -215,15 +300,31 @@ func (w *EnterTabWidget) setupUI() {
 	w.removeWordButton.SetText("Remove Word")
 	buttonLayout.AddWidget(w.addWordButton.QWidget)
 	buttonLayout.AddWidget(w.removeWordButton.QWidget)
+	w.replaceButton = qt.NewQPushButton(w.QWidget)
+	w.replaceButton.SetText("Find and Replace...")
+	w.replaceButton.SetShortcut(qt.NewQKeySequence5(qt.QKeySequence__Replace))
+	buttonLayout.AddWidget(w.replaceButton.QWidget)
 	buttonLayout.AddStretch()
+	w.undoButton = qt.NewQPushButton(w.QWidget)
+	w.undoButton.SetText("Undo")
+	w.undoButton.SetShortcut(qt.NewQKeySequence5(qt.QKeySequence__Undo))
+	buttonLayout.AddWidget(w.undoButton.QWidget)
+	w.redoButton = qt.NewQPushButton(w.QWidget)
+	w.redoButton.SetText("Redo")
+	w.redoButton.SetShortcut(qt.NewQKeySequence5(qt.QKeySequence__Redo))
+	buttonLayout.AddWidget(w.redoButton.QWidget)
+	w.undo = lesson.NewUndoStack(0)
+	w.updateUndoButtons()
 
 	wordsLayout.AddLayout2(buttonLayout.QLayout, 0)
 
 	// Words table
 	w.wordsTable = qt.NewQTableWidget2()
 	w.wordsTable.SetRowCount(0)
-	w.wordsTable.SetColumnCount(3)
-	w.wordsTable.SetHorizontalHeaderLabels([]string{"Questions", "Answers", "Comment"})
+	w.wordsTable.SetColumnCount(7)
+	w.wordsTable.SetHorizontalHeaderLabels([]string{"Questions", "Answers", "Comment", "★", "Difficulty", "Tags", "Chapter"})
+	w.wordsTable.HorizontalHeaderItem(tagsColumn).SetToolTip("Comma separated tags, such as chapter-3, irregular-verbs")
+	w.wordsTable.HorizontalHeaderItem(chapterColumn).SetToolTip("The chapter of the word, with its sub-chapter after a slash, such as Part 1 / Unit 3")
 	w.wordsTable.HorizontalHeader().SetStretchLastSection(true)
 	wordsLayout.AddWidget(w.wordsTable.QWidget)
 
@@ -236,27 +337,24 @@ func (w *EnterTabWidget) setupUI() {
 func (w *EnterTabWidget) connectSignals() {
 	// Title changed
 	w.titleEdit.OnTextChanged(func(text string) {
-		if w.lesson != nil {
+		if w.lesson != nil && w.lesson.Data.List.Title != text {
 			w.lesson.Data.List.Title = text
-			// Qt signal emission - will be implemented with proper Qt bindings
-			w.logger.LegacyReminder("lessonChanged signal emission", "legacy/modules/org/openteacher/interfaces/qt/lessons/words/words.py", "proper Qt signal emission needed")
+			w.changed()
 		}
 	})
 
 	// Language fields changed
 	w.qLanguageEdit.OnTextChanged(func(text string) {
-		if w.lesson != nil {
+		if w.lesson != nil && w.lesson.Data.List.QuestionLanguage != text {
 			w.lesson.Data.List.QuestionLanguage = text
-			// Qt signal emission - will be implemented with proper Qt bindings
-			w.logger.LegacyReminder("lessonChanged signal for question language", "legacy/modules/org/openteacher/interfaces/qt/lessons/words/words.py", "signal emission needed")
+			w.changed()
 		}
 	})
 
 	w.aLanguageEdit.OnTextChanged(func(text string) {
-		if w.lesson != nil {
+		if w.lesson != nil && w.lesson.Data.List.AnswerLanguage != text {
 			w.lesson.Data.List.AnswerLanguage = text
-			// Qt signal emission - will be implemented with proper Qt bindings
-			w.logger.LegacyReminder("lessonChanged signal for answer language", "legacy/modules/org/openteacher/interfaces/qt/lessons/words/words.py", "signal emission needed")
+			w.changed()
 		}
 	})
 
@@ -268,10 +366,61 @@ func (w *EnterTabWidget) connectSignals() {
 	w.removeWordButton.OnClicked(func() {
 		w.removeSelectedWord()
 	})
+
+	w.replaceButton.OnClicked(func() {
+		w.findAndReplace()
+	})
+
+	w.undoButton.OnClicked(func() {
+		w.undoEdit()
+	})
+
+	w.redoButton.OnClicked(func() {
+		w.redoEdit()
+	})
+
+	// Starring, tagging and moving a word to a chapter
+	w.wordsTable.OnItemChanged(func(item *qt.QTableWidgetItem) {
+		if w.updatingTable || w.lesson == nil {
+			return
+		}
+		row := item.Row()
+		if row < 0 || row >= len(w.lesson.Data.List.Items) {
+			return
+		}
+		switch item.Column() {
+		case starredColumn:
+			w.lesson.Data.List.Items[row].Starred = item.CheckState() == qt.Checked
+			w.changed()
+			w.logger.Action("Set starred of row %d to %v", row, w.lesson.Data.List.Items[row].Starred)
+		case tagsColumn:
+			tags := lesson.ParseTags(item.Text())
+			if strings.Join(tags, ",") == strings.Join(w.lesson.Data.List.Items[row].Tags, ",") {
+				return
+			}
+			w.pushUndo("Edit tags")
+			w.lesson.Data.List.Items[row].Tags = tags
+			w.changed()
+			w.logger.Action("Set tags of row %d to %q", row, tags)
+		case chapterColumn:
+			chapter := lesson.ParseChapter(item.Text())
+			if slices.Equal(chapter, w.lesson.Data.List.Items[row].Chapter) {
+				return
+			}
+			w.pushUndo("Edit chapter")
+			w.lesson.Data.List.Items[row].Chapter = chapter
+			w.changed()
+			w.logger.Action("Set chapter of row %d to %q", row, lesson.ChapterName(chapter))
+		}
+	})
 }
 
 // UpdateLesson updates the Enter tab with lesson data
 func (w *EnterTabWidget) UpdateLesson(lesson *lesson.Lesson) {
+	if lesson != w.lesson {
+		w.undo.Clear()
+		w.updateUndoButtons()
+	}
 	w.lesson = lesson
 	if lesson == nil {
 		return
@@ -293,12 +442,18 @@ func (w *EnterTabWidget) updateWordsTable() {
 		return
 	}
 
+	w.updatingTable = true
+	defer func() { w.updatingTable = false }()
+
 	items := w.lesson.Data.List.Items
 	w.wordsTable.SetRowCount(len(items))
 
 	for i, item := range items {
 		questionsText := strings.Join(item.Questions, "; ")
 		answersText := strings.Join(item.Answers, "; ")
+		if len(item.AnswerGroups) > 0 {
+			answersText = lesson.ComposeSynonymGroups(item.SynonymGroups())
+		}
 
 		questionItem := qt.NewQTableWidgetItem2(questionsText)
 		answerItem := qt.NewQTableWidgetItem2(answersText)
@@ -307,11 +462,44 @@ func (w *EnterTabWidget) updateWordsTable() {
 		w.wordsTable.SetItem(i, 0, questionItem)
 		w.wordsTable.SetItem(i, 1, answerItem)
 		w.wordsTable.SetItem(i, 2, commentItem)
+
+		starredItem := qt.NewQTableWidgetItem2("")
+		starredItem.SetFlags(qt.ItemIsUserCheckable | qt.ItemIsEnabled | qt.ItemIsSelectable)
+		if item.Starred {
+			starredItem.SetCheckState(qt.Checked)
+		} else {
+			starredItem.SetCheckState(qt.Unchecked)
+		}
+		w.wordsTable.SetItem(i, starredColumn, starredItem)
+		w.wordsTable.SetCellWidget(i, difficultyColumn, w.newDifficultyBox(i, item.Difficulty).QWidget)
+		w.wordsTable.SetItem(i, tagsColumn, qt.NewQTableWidgetItem2(strings.Join(item.Tags, ", ")))
+		w.wordsTable.SetItem(i, chapterColumn, qt.NewQTableWidgetItem2(lesson.ChapterName(item.Chapter)))
 	}
 
 	w.wordsTable.ResizeColumnsToContents()
 }
 
+// newDifficultyBox creates the combo box setting the difficulty of the word
+// at row
+func (w *EnterTabWidget) newDifficultyBox(row int, current lesson.Difficulty) *qt.QComboBox {
+	box := qt.NewQComboBox2()
+	for i, difficulty := range difficulties {
+		box.AddItem(difficulty.String())
+		if difficulty == current {
+			box.SetCurrentIndex(i)
+		}
+	}
+	box.OnCurrentIndexChanged(func(index int) {
+		if w.lesson == nil || row >= len(w.lesson.Data.List.Items) || index < 0 {
+			return
+		}
+		w.lesson.Data.List.Items[row].Difficulty = difficulties[index]
+		w.changed()
+		w.logger.Action("Set difficulty of row %d to %s", row, difficulties[index])
+	})
+	return box
+}
+
 // addNewWord adds a new word pair
 func (w *EnterTabWidget) addNewWord() {
 	if w.lesson == nil {
@@ -324,10 +512,10 @@ func (w *EnterTabWidget) addNewWord() {
 		Comment:   "",
 	}
 
+	w.pushUndo("Add word")
 	w.lesson.Data.List.Items = append(w.lesson.Data.List.Items, newItem)
 	w.updateWordsTable()
-	// Qt signal emission - will be implemented with proper Qt bindings
-	w.logger.LegacyReminder("lessonChanged signal for adding word", "legacy/modules/org/openteacher/interfaces/qt/lessons/words/words.py", "signal emission needed")
+	w.changed()
 	w.logger.Action("Added new word pair")
 }
 
@@ -339,17 +527,77 @@ func (w *EnterTabWidget) removeSelectedWord() {
 
 	currentRow := w.wordsTable.CurrentRow()
 	if currentRow >= 0 && currentRow < len(w.lesson.Data.List.Items) {
+		w.pushUndo("Remove word")
 		// Remove item from slice
 		items := w.lesson.Data.List.Items
 		w.lesson.Data.List.Items = append(items[:currentRow], items[currentRow+1:]...)
 
 		w.updateWordsTable()
-		// Qt signal emission - will be implemented with proper Qt bindings
-		w.logger.LegacyReminder("lessonChanged signal for removing word", "legacy/modules/org/openteacher/interfaces/qt/lessons/words/words.py", "signal emission needed")
+		w.changed()
 		w.logger.Action("Removed word pair at row %d", currentRow)
 	}
 }
 
+// findAndReplace shows the find and replace dialog and makes the changes
+// the user kept as one undoable edit
+func (w *EnterTabWidget) findAndReplace() {
+	if w.lesson == nil {
+		return
+	}
+	showReplaceDialog(w.QWidget, &w.lesson.Data, func(description string, replacements []lesson.Replacement) {
+		w.pushUndo(description)
+		applied := lesson.ApplyReplacements(&w.lesson.Data, replacements)
+		w.updateWordsTable()
+		w.changed()
+		w.logger.Action("Replaced %d words", applied)
+	})
+}
+
+// pushUndo remembers the words before an edit
+func (w *EnterTabWidget) pushUndo(description string) {
+	if w.lesson == nil {
+		return
+	}
+	w.undo.Push(description, &w.lesson.Data)
+	w.lesson.Data.Changed = true
+	w.updateUndoButtons()
+}
+
+func (w *EnterTabWidget) undoEdit() {
+	if w.lesson == nil {
+		return
+	}
+	if description, ok := w.undo.Undo(&w.lesson.Data); ok {
+		w.updateWordsTable()
+		w.changed()
+		w.logger.Action("Undid %s", description)
+	}
+	w.updateUndoButtons()
+}
+
+func (w *EnterTabWidget) redoEdit() {
+	if w.lesson == nil {
+		return
+	}
+	if description, ok := w.undo.Redo(&w.lesson.Data); ok {
+		w.updateWordsTable()
+		w.changed()
+		w.logger.Action("Redid %s", description)
+	}
+	w.updateUndoButtons()
+}
+
+// updateUndoButtons enables the undo and redo buttons and names the edit
+// they undo or redo
+func (w *EnterTabWidget) updateUndoButtons() {
+	description, ok := w.undo.CanUndo()
+	w.undoButton.SetEnabled(ok)
+	w.undoButton.SetToolTip("Undo " + description)
+	description, ok = w.undo.CanRedo()
+	w.redoButton.SetEnabled(ok)
+	w.redoButton.SetToolTip("Redo " + description)
+}
+
 // TeachingResult represents the result of answering a single question
 type TeachingResult struct {
 	Question      string
@@ -357,6 +605,9 @@ type TeachingResult struct {
 	UserAnswer    string
 	IsCorrect     bool
 	ItemIndex     int
+	// Credit is the partial credit of a wrong answer, such as the right
+	// word with the wrong article
+	Credit float64
 }
 
 // TeachingSession represents a complete teaching session with all results
@@ -368,6 +619,19 @@ type TeachingSession struct {
 	Completed      bool
 }
 
+// teachQuestion is one question of a teaching session: a word pair, asked
+// either way, or one card of a cloze item
+type teachQuestion struct {
+	itemIndex int
+	cloze     *lesson.ClozeCard
+	reversed  bool
+}
+
+// card returns the word pair the question asks, in its direction
+func (q teachQuestion) card() lesson.Card {
+	return lesson.Card{Index: q.itemIndex, Reversed: q.reversed}
+}
+
 // TeachTabWidget handles the teaching/quiz functionality
 type TeachTabWidget struct {
 	*qt.QWidget
@@ -385,19 +649,39 @@ type TeachTabWidget struct {
 	nextButton    *qt.QPushButton
 	resultLabel   *qt.QLabel
 	unicodeButton *qt.QPushButton
+	clozeWidget   *cloze.ClozeTeachWidget
+	choiceWidget  *multiplechoice.MultipleChoiceTeachWidget
+	choiceOptions *multiplechoice.SettingsWidget
+	starredOnly   *qt.QCheckBox
+	tagFilter     *qt.QComboBox
+	chapterButton *qt.QToolButton
+	chapterMenu   *qt.QMenu
+	presentButton *qt.QPushButton
+	askButton     *qt.QPushButton
+
+	// chapters are the chapters of the lesson, in step with the actions of
+	// chapterMenu
+	chapters [][]string
 
 	// Unicode character picker
 	unicodePicker *IntegratedUnicodePicker
 
 	// Teaching state
+	checker        *lesson.AnswerChecker
+	reverseChecker *lesson.AnswerChecker
+	smart          bool
+	questions      []teachQuestion
 	currentIndex   int
 	correctAnswers int
 	totalQuestions int
 	isTeaching     bool
+	// askedAt is when the current question was shown, to time the answer
+	askedAt time.Time
 
 	// Session tracking
 	currentSession   *TeachingSession
 	sessionCompleted func(*TeachingSession) // Callback for when session completes
+	answerRecorded   func()                 // Callback for when an answer is added to the lesson
 }
 
 // NewTeachTabWidget creates a new Teach tab widget
@@ -422,6 +706,12 @@ func (w *TeachTabWidget) SetSessionCompletedCallback(callback func(*TeachingSess
 	w.sessionCompleted = callback
 }
 
+// SetAnswerRecordedCallback sets the function called when an answer is
+// added to the lesson's results
+func (w *TeachTabWidget) SetAnswerRecordedCallback(callback func()) {
+	w.answerRecorded = callback
+}
+
 // setupUI initializes the Teach tab interface
 func (w *TeachTabWidget) setupUI() {
 	layout := qt.NewQVBoxLayout(w.QWidget)
@@ -451,6 +741,16 @@ func (w *TeachTabWidget) setupUI() {
 	w.questionLabel.SetAlignment(qt.AlignCenter)
 	questionLayout.AddWidget(w.questionLabel.QWidget)
 
+	// Cloze items show their sentence with blanks instead
+	w.clozeWidget = cloze.NewClozeTeachWidget(w.QWidget)
+	w.clozeWidget.Hide()
+	questionLayout.AddWidget(w.clozeWidget.QWidget)
+
+	// In multiple choice mode the answer is picked from buttons
+	w.choiceWidget = multiplechoice.NewMultipleChoiceTeachWidget(w.QWidget)
+	w.choiceWidget.Hide()
+	questionLayout.AddWidget(w.choiceWidget.QWidget)
+
 	// Answer input with Unicode picker
 	answerLayout := qt.NewQHBoxLayout2()
 	answerLabel := qt.NewQLabel(w.QWidget)
@@ -549,8 +849,41 @@ func (w *TeachTabWidget) setupUI() {
 	buttonLayout.AddWidget(w.startButton.QWidget)
 	buttonLayout.AddWidget(w.submitButton.QWidget)
 	buttonLayout.AddWidget(w.nextButton.QWidget)
+	w.presentButton = qt.NewQPushButton(w.QWidget)
+	w.presentButton.SetText("Present")
+	w.presentButton.SetToolTip("Show the words full-screen for a class drill on a projector")
+	buttonLayout.AddWidget(w.presentButton.QWidget)
+	w.askButton = qt.NewQPushButton(w.QWidget)
+	w.askButton.SetText("Ask Teacher...")
+	w.askButton.SetToolTip("Ask the teacher about the current word")
+	w.askButton.SetVisible(classroomClient != nil)
+	buttonLayout.AddWidget(w.askButton.QWidget)
 	buttonLayout.AddStretch()
 
+	w.starredOnly = qt.NewQCheckBox3("Only starred")
+	w.starredOnly.SetToolTip("Only ask the starred words and those set to always ask")
+	buttonLayout.AddWidget(w.starredOnly.QWidget)
+
+	w.tagFilter = qt.NewQComboBox(w.QWidget)
+	w.tagFilter.SetToolTip("Only ask the words with this tag and those set to always ask")
+	buttonLayout.AddWidget(w.tagFilter.QWidget)
+	w.updateTagFilter()
+
+	w.chapterButton = qt.NewQToolButton(w.QWidget)
+	w.chapterButton.SetToolTip("Only ask the words of the checked chapters and those set to always ask")
+	w.chapterButton.SetPopupMode(qt.QToolButton__InstantPopup)
+	w.chapterMenu = qt.NewQMenu(w.QWidget)
+	w.chapterButton.SetMenu(w.chapterMenu)
+	buttonLayout.AddWidget(w.chapterButton.QWidget)
+	w.updateChapterFilter()
+
+	var lessonData *lesson.LessonData
+	if w.lesson != nil {
+		lessonData = &w.lesson.Data
+	}
+	w.choiceOptions = multiplechoice.NewSettingsWidget(lessonData, w.QWidget)
+	buttonLayout.AddWidget(w.choiceOptions.QWidget)
+
 	layout.AddLayout2(buttonLayout.QLayout, 0)
 
 	w.logger.Success("Teach tab UI created")
@@ -570,6 +903,29 @@ func (w *TeachTabWidget) connectSignals() {
 		w.nextQuestion()
 	})
 
+	w.presentButton.OnClicked(func() {
+		w.startPresentation()
+	})
+
+	w.askButton.OnClicked(func() {
+		w.askTeacher()
+	})
+
+	// Words may have been tagged in the Enter tab since the lesson was shown
+	w.tagFilter.OnShowPopup(func(super func()) {
+		w.updateTagFilter()
+		super()
+	})
+	w.chapterMenu.OnAboutToShow(func() {
+		w.updateChapterFilter()
+	})
+
+	w.choiceWidget.OnChoice(func(choice string) {
+		w.answerEdit.SetText(choice)
+		w.submitAnswer()
+		w.choiceWidget.Reveal(choice)
+	})
+
 	w.unicodeButton.OnToggled(func(checked bool) {
 		w.logger.Debug("Unicode picker button toggled: %v", checked)
 		w.toggleUnicodePicker(checked)
@@ -587,9 +943,126 @@ func (w *TeachTabWidget) connectSignals() {
 // UpdateLesson updates the Teach tab with lesson data
 func (w *TeachTabWidget) UpdateLesson(lesson *lesson.Lesson) {
 	w.lesson = lesson
+	w.choiceOptions.SetLessonData(&lesson.Data)
+	w.updateTagFilter()
+	w.updateChapterFilter()
 	w.resetTeachingState()
 }
 
+// LessonEdited brings the Teach tab up to date after the words were edited
+// elsewhere. A practice session in progress goes on, unless words it asks
+// were removed.
+func (w *TeachTabWidget) LessonEdited() {
+	if w.lesson == nil {
+		return
+	}
+	w.choiceOptions.SetLessonData(&w.lesson.Data)
+	w.updateTagFilter()
+	w.updateChapterFilter()
+	if !w.isTeaching {
+		w.resetTeachingState()
+		return
+	}
+	for _, question := range w.questions {
+		if question.itemIndex >= len(w.lesson.Data.List.Items) {
+			w.resetTeachingState()
+			w.statusLabel.SetText("The words were changed, start again")
+			return
+		}
+	}
+}
+
+// updateTagFilter fills the tag filter with the tags of the lesson's words,
+// keeping the chosen tag when the lesson still uses it
+func (w *TeachTabWidget) updateTagFilter() {
+	current := w.selectedTag()
+	w.tagFilter.Clear()
+	w.tagFilter.AddItem("All tags")
+	if w.lesson != nil {
+		w.tagFilter.AddItems(w.lesson.Data.List.ItemTags())
+	}
+	if index := w.tagFilter.FindText(current); current != "" && index > 0 {
+		w.tagFilter.SetCurrentIndex(index)
+	}
+}
+
+// selectedTag returns the tag words must have to be asked, or "" for all
+// words
+func (w *TeachTabWidget) selectedTag() string {
+	if w.tagFilter.CurrentIndex() <= 0 {
+		return ""
+	}
+	return w.tagFilter.CurrentText()
+}
+
+// updateChapterFilter fills the chapter menu with the chapters of the
+// lesson's words, sub-chapters indented below their chapter, keeping the
+// checked chapters the lesson still has. Lessons without chapters hide it.
+func (w *TeachTabWidget) updateChapterFilter() {
+	checked := make(map[string]bool)
+	for _, chapter := range w.selectedChapters() {
+		checked[lesson.ChapterName(chapter)] = true
+	}
+	w.chapterMenu.Clear()
+	w.chapters = nil
+	if w.lesson != nil {
+		w.chapters = w.lesson.Data.List.Chapters()
+	}
+	for _, chapter := range w.chapters {
+		action := w.chapterMenu.AddAction(strings.Repeat("    ", len(chapter)-1) + chapter[len(chapter)-1])
+		action.SetCheckable(true)
+		action.SetChecked(checked[lesson.ChapterName(chapter)])
+		action.OnToggled(func(bool) {
+			w.updateChapterButton()
+		})
+	}
+	w.chapterButton.SetVisible(len(w.chapters) > 0)
+	w.updateChapterButton()
+}
+
+// updateChapterButton shows the checked chapters on the chapter button
+func (w *TeachTabWidget) updateChapterButton() {
+	selected := w.selectedChapters()
+	switch len(selected) {
+	case 0:
+		w.chapterButton.SetText("All chapters")
+	case 1:
+		w.chapterButton.SetText(lesson.ChapterName(selected[0]))
+	default:
+		w.chapterButton.SetText(fmt.Sprintf("%d chapters", len(selected)))
+	}
+}
+
+// selectedChapters returns the chapters words must be in one of to be
+// asked, or none for all words
+func (w *TeachTabWidget) selectedChapters() [][]string {
+	var selected [][]string
+	for i, action := range w.chapterMenu.Actions() {
+		if i < len(w.chapters) && action.IsChecked() {
+			selected = append(selected, w.chapters[i])
+		}
+	}
+	return selected
+}
+
+// selectItems returns the indexes of the words a session asks: all but the
+// suspended ones, narrowed down to the starred words, the chosen tag and
+// the checked chapters
+func (w *TeachTabWidget) selectItems() []int {
+	list := &w.lesson.Data.List
+	indexes := lesson.ApplyDifficulty(list, lesson.AllItems(list))
+	if w.starredOnly.IsChecked() {
+		indexes = lesson.StarredItems(list, indexes)
+	}
+	if tag := w.selectedTag(); tag != "" {
+		indexes = lesson.TaggedItems(list, indexes, []string{tag})
+	}
+	if chapters := w.selectedChapters(); len(chapters) > 0 {
+		indexes = lesson.ChapterItems(list, indexes, chapters)
+	}
+	return indexes
+}
+
 // startTeaching begins the teaching session
 func (w *TeachTabWidget) startTeaching() {
 	if w.lesson == nil || len(w.lesson.Data.List.Items) == 0 {
@@ -597,10 +1070,50 @@ func (w *TeachTabWidget) startTeaching() {
 		return
 	}
 
+	// Suspended words are left out and always-ask words kept, whatever
+	// else selects the words
+	list := &w.lesson.Data.List
+	indexes := w.selectItems()
+	if len(indexes) == 0 {
+		w.statusLabel.SetText("No words available for teaching")
+		return
+	}
+
+	// The user sees how many new and review words the session asks and
+	// how long it takes, and can ask fewer before it starts
+	preset := lesson.LessonPreset(&w.lesson.Data)
+	plan := lesson.PlanSession(list, indexes, preset.Scheduler.LessonType)
+	if !confirmSessionPlan(w.QWidget, plan) {
+		return
+	}
+	w.usePreset(preset)
+
+	// Words are asked in the direction of the preset, the smart lesson
+	// type asking the ones weakest that way first. Every cloze number of a
+	// cloze item is asked as a question of its own, the right way round.
+	cards := lesson.Cards(plan.Items(), preset.Direction)
+	if w.smart {
+		cards = lesson.SmartOrder(list, cards)
+	}
+	w.questions = w.questions[:0]
+	for _, card := range cards {
+		item := &list.Items[card.Index]
+		if !item.IsCloze() {
+			w.questions = append(w.questions, teachQuestion{itemIndex: card.Index, reversed: card.Reversed})
+			continue
+		}
+		if card.Reversed {
+			continue
+		}
+		for _, clozeCard := range lesson.ClozeCards(item.Cloze) {
+			w.questions = append(w.questions, teachQuestion{itemIndex: card.Index, cloze: &clozeCard})
+		}
+	}
+
 	w.isTeaching = true
 	w.currentIndex = 0
 	w.correctAnswers = 0
-	w.totalQuestions = len(w.lesson.Data.List.Items)
+	w.totalQuestions = len(w.questions)
 
 	// Initialize new teaching session
 	w.currentSession = &TeachingSession{
@@ -624,20 +1137,84 @@ func (w *TeachTabWidget) startTeaching() {
 	w.logger.Action("Started teaching session with %d words", w.totalQuestions)
 }
 
+// startPresentation shows the words that would be asked full-screen, for a
+// drill that records no results
+func (w *TeachTabWidget) startPresentation() {
+	if w.lesson == nil {
+		return
+	}
+	list := &w.lesson.Data.List
+	indexes := w.selectItems()
+	if len(indexes) == 0 {
+		w.statusLabel.SetText("No words available for presenting")
+		return
+	}
+	items := make([]lesson.WordItem, 0, len(indexes))
+	for _, i := range indexes {
+		items = append(items, list.Items[i])
+	}
+	showPresentation(items)
+	w.logger.Action("Started presentation of %d words", len(items))
+}
+
+// usePreset makes the session check answers as leniently as the lesson's
+// option preset allows, in the language of each direction, and schedule
+// questions with its lesson type
+func (w *TeachTabWidget) usePreset(preset lesson.OptionPreset) {
+	forward, reverse := preset, preset
+	forward.Direction, reverse.Direction = lesson.DirectionNormal, lesson.DirectionReverse
+	w.checker = lesson.AnswerCheckerFor(&w.lesson.Data, forward)
+	w.reverseChecker = lesson.AnswerCheckerFor(&w.lesson.Data, reverse)
+	w.smart = preset.Scheduler.LessonType == lesson.LessonTypeSmart
+}
+
+// askTeacher lets the student ask the teacher about the current word
+func (w *TeachTabWidget) askTeacher() {
+	if w.lesson == nil || !w.isTeaching || w.currentIndex >= len(w.questions) {
+		w.statusLabel.SetText("Start teaching to ask about a word")
+		return
+	}
+	item := w.lesson.Data.List.Items[w.questions[w.currentIndex].itemIndex]
+	if err := askAboutItem(w.QWidget, w.lesson.Path, item); err != nil {
+		w.statusLabel.SetText(fmt.Sprintf("Could not ask the teacher: %v", err))
+	}
+}
+
 // showCurrentQuestion displays the current question
 func (w *TeachTabWidget) showCurrentQuestion() {
-	if w.lesson == nil || w.currentIndex >= len(w.lesson.Data.List.Items) {
+	if w.lesson == nil || w.currentIndex >= len(w.questions) {
 		w.finishTeaching()
 		return
 	}
 
-	item := w.lesson.Data.List.Items[w.currentIndex]
-	question := strings.Join(item.Questions, " / ")
-
-	w.questionLabel.SetText(fmt.Sprintf("Question: %s", question))
+	current := w.questions[w.currentIndex]
+	choices := false
+	if current.cloze != nil {
+		w.clozeWidget.SetCard(*current.cloze)
+		w.questionLabel.Hide()
+		w.choiceWidget.Hide()
+		w.clozeWidget.Show()
+		w.answerEdit.SetPlaceholderText("Fill in the blank")
+	} else {
+		item := current.card().Item(&w.lesson.Data.List)
+		question := strings.Join(item.Questions, " / ")
+
+		w.questionLabel.SetText(fmt.Sprintf("Question: %s", question))
+		w.clozeWidget.Hide()
+		w.questionLabel.Show()
+		w.answerEdit.SetPlaceholderText("")
+		// Choices are made of answers, so reversed words are typed
+		choices = !current.reversed && w.showChoices(current.itemIndex)
+	}
 	w.answerEdit.Clear()
-	w.answerEdit.SetFocus()
+	if choices {
+		w.answerEdit.SetEnabled(false)
+		w.submitButton.SetEnabled(false)
+	} else {
+		w.answerEdit.SetFocus()
+	}
 	w.resultLabel.SetVisible(false)
+	w.askedAt = time.Now()
 
 	// Update progress
 	progress := int((float64(w.currentIndex) / float64(w.totalQuestions)) * 100)
@@ -646,9 +1223,25 @@ func (w *TeachTabWidget) showCurrentQuestion() {
 		w.currentIndex+1, w.totalQuestions, w.correctAnswers, w.currentIndex))
 }
 
+// showChoices shows the choices for an item when the lesson is taught as
+// multiple choice and reports whether it did; without choices the answer is
+// typed
+func (w *TeachTabWidget) showChoices(itemIndex int) bool {
+	w.choiceWidget.Hide()
+	if !w.lesson.Data.MultipleChoiceSettings().Enabled {
+		return false
+	}
+	if err := w.choiceWidget.SetItem(&w.lesson.Data, itemIndex); err != nil {
+		w.logger.Warning("No choices for item %d, asking to type the answer: %v", itemIndex, err)
+		return false
+	}
+	w.choiceWidget.Show()
+	return true
+}
+
 // submitAnswer checks the user's answer
 func (w *TeachTabWidget) submitAnswer() {
-	if w.lesson == nil || w.currentIndex >= len(w.lesson.Data.List.Items) || w.currentSession == nil {
+	if w.lesson == nil || w.currentIndex >= len(w.questions) || w.currentSession == nil {
 		return
 	}
 
@@ -657,24 +1250,50 @@ func (w *TeachTabWidget) submitAnswer() {
 		return
 	}
 
-	item := w.lesson.Data.List.Items[w.currentIndex]
+	current := w.questions[w.currentIndex]
+	item := current.card().Item(&w.lesson.Data.List)
 	correct := false
-
-	// Check if answer matches any of the correct answers (case-insensitive)
-	for _, answer := range item.Answers {
-		if strings.EqualFold(userAnswer, strings.TrimSpace(answer)) {
-			correct = true
-			break
-		}
-	}
+	var grade lesson.Grade
 
 	// Create teaching result record
 	result := TeachingResult{
 		Question:      strings.Join(item.Questions, " / "),
 		CorrectAnswer: strings.Join(item.Answers, " / "),
 		UserAnswer:    userAnswer,
-		IsCorrect:     correct,
-		ItemIndex:     w.currentIndex,
+		ItemIndex:     current.itemIndex,
+	}
+
+	if current.cloze != nil {
+		// The cloze widget fills in the blanks itself
+		correct = w.clozeWidget.Grade(userAnswer)
+		result.Question = current.cloze.Prompt
+		result.CorrectAnswer = strings.Join(current.cloze.Answers, ", ")
+	} else {
+		checker := w.checker
+		if current.reversed {
+			checker = w.reverseChecker
+		}
+		grade = checker.GradeItem(userAnswer, item)
+		correct = grade.Correct
+		result.Credit = grade.Credit
+
+		// Results keep their direction, so the directions of a word are
+		// scheduled apart, and how long the answer took, so slow words
+		// come back sooner
+		w.lesson.Data.List.AddDirectedResult(current.card(), grade, time.Since(w.askedAt))
+		w.lesson.Data.Changed = true
+		if w.answerRecorded != nil {
+			w.answerRecorded()
+		}
+	}
+	result.IsCorrect = correct
+
+	// The smart lesson type asks a wrongly answered question again soon
+	// and at the end
+	if w.smart && !correct {
+		w.questions = lesson.RequeueWrong(w.questions, w.currentIndex)
+		w.totalQuestions = len(w.questions)
+		w.currentSession.TotalQuestions = w.totalQuestions
 	}
 
 	// Add to session results
@@ -684,11 +1303,14 @@ func (w *TeachTabWidget) submitAnswer() {
 	if correct {
 		w.correctAnswers++
 		w.currentSession.CorrectCount++
-		w.resultLabel.SetText("[CORRECT!]")
-		w.resultLabel.SetStyleSheet("color: green; font-weight: bold; background-color: lightgreen; padding: 5px; border-radius: 3px;")
+		w.resultLabel.SetText(theme.Label(theme.FeedbackRight, "Correct!"))
+		w.resultLabel.SetStyleSheet(theme.Style(theme.FeedbackRight, "padding: 5px; "))
+	} else if grade.Article != "" && grade.Credit > 0 {
+		w.resultLabel.SetText(theme.Label(theme.FeedbackAlmost, fmt.Sprintf("Right word, but the article is \"%s\": %s", grade.Article, result.CorrectAnswer)))
+		w.resultLabel.SetStyleSheet(theme.Style(theme.FeedbackAlmost, "padding: 5px; "))
 	} else {
-		w.resultLabel.SetText(fmt.Sprintf("[INCORRECT] Correct answer(s): %s", result.CorrectAnswer))
-		w.resultLabel.SetStyleSheet("color: red; font-weight: bold; background-color: lightcoral; padding: 5px; border-radius: 3px;")
+		w.resultLabel.SetText(theme.Label(theme.FeedbackWrong, fmt.Sprintf("Correct answer(s): %s", result.CorrectAnswer)))
+		w.resultLabel.SetStyleSheet(theme.Style(theme.FeedbackWrong, "padding: 5px; "))
 	}
 
 	w.resultLabel.SetVisible(true)
@@ -704,7 +1326,7 @@ func (w *TeachTabWidget) submitAnswer() {
 func (w *TeachTabWidget) nextQuestion() {
 	w.currentIndex++
 
-	if w.currentIndex >= len(w.lesson.Data.List.Items) {
+	if w.currentIndex >= len(w.questions) {
 		w.finishTeaching()
 	} else {
 		w.answerEdit.SetEnabled(true)
@@ -717,9 +1339,18 @@ func (w *TeachTabWidget) nextQuestion() {
 // finishTeaching completes the teaching session
 func (w *TeachTabWidget) finishTeaching() {
 	w.isTeaching = false
+	// Partly right answers count for their partial credit
+	credit := float64(w.correctAnswers)
+	if w.currentSession != nil {
+		for _, result := range w.currentSession.Results {
+			if !result.IsCorrect {
+				credit += result.Credit
+			}
+		}
+	}
 	percentage := 0
 	if w.totalQuestions > 0 {
-		percentage = int((float64(w.correctAnswers) / float64(w.totalQuestions)) * 100)
+		percentage = int((credit / float64(w.totalQuestions)) * 100)
 	}
 
 	// Complete the session record
@@ -730,6 +1361,9 @@ func (w *TeachTabWidget) finishTeaching() {
 
 	w.questionLabel.SetText(fmt.Sprintf("Teaching completed! Final Score: %d/%d correct (%d%%)",
 		w.correctAnswers, w.totalQuestions, percentage))
+	w.clozeWidget.Hide()
+	w.choiceWidget.Hide()
+	w.questionLabel.Show()
 
 	w.answerEdit.SetEnabled(false)
 	w.submitButton.SetEnabled(false)
@@ -765,6 +1399,9 @@ func (w *TeachTabWidget) resetTeachingState() {
 	w.unicodeButton.SetEnabled(false)
 	w.resultLabel.SetVisible(false)
 	w.progressBar.SetValue(0)
+	w.clozeWidget.Hide()
+	w.choiceWidget.Hide()
+	w.questionLabel.Show()
 
 	// Hide Unicode picker
 	w.unicodePicker.Hide()
@@ -961,25 +1598,26 @@ func (w *ResultsTabWidget) populateResultsTable(session *TeachingSession) {
 		w.resultsTable.SetItem(i, 2, userItem)
 
 		// Result (CORRECT/WRONG)
-		var resultText string
-		var resultItem *qt.QTableWidgetItem
-		if result.IsCorrect {
-			resultText = "[CORRECT]"
-			resultItem = qt.NewQTableWidgetItem2(resultText)
-			color := qt.NewQColor()
-			color.SetRgb(200, 255, 200)
-			brush := qt.NewQBrush3(color)
-			resultItem.SetBackground(brush) // Light green background
-		} else {
-			resultText = "[WRONG]"
-			resultItem = qt.NewQTableWidgetItem2(resultText)
-			color := qt.NewQColor()
-			color.SetRgb(255, 200, 200)
-			brush := qt.NewQBrush3(color)
-			resultItem.SetBackground(brush) // Light red background
+		kind, resultText := theme.FeedbackRight, "Correct"
+		if !result.IsCorrect {
+			kind, resultText = theme.FeedbackWrong, "Wrong"
 		}
+		resultItem := qt.NewQTableWidgetItem2(theme.Label(kind, resultText))
+		resultItem.SetForeground(qt.NewQBrush3(qt.NewQColor6(theme.Colors(kind).Text)))
+		resultItem.SetBackground(feedbackBrush(kind))
 		w.resultsTable.SetItem(i, 3, resultItem)
 	}
 
 	w.resultsTable.ResizeColumnsToContents()
 }
+
+// feedbackBrush returns the background of a results table cell showing a
+// kind of feedback; wrong answers are hatched so they stand out without
+// colour
+func feedbackBrush(kind theme.Feedback) *qt.QBrush {
+	color := qt.NewQColor6(theme.Colors(kind).Background)
+	if theme.Hatched(kind) {
+		return qt.NewQBrush11(color, qt.BDiagPattern)
+	}
+	return qt.NewQBrush3(color)
+}
@@ -6,7 +6,11 @@ package audio
 import (
 	"context"
 	"fmt"
+
 	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/mappu/miqt/qt"
+	"github.com/mappu/miqt/qt/multimedia"
 )
 
 // MediaTypeModule is a Go port of the Python MediaTypeModule class
@@ -30,9 +34,14 @@ func (mod *MediaTypeModule) Phononcontrols() {
 	// TODO: Port Python method logic
 }
 
-// Supports is the Go port of the Python supports method
-func (mod *MediaTypeModule) Supports() {
-	// TODO: Port Python method logic
+// Supports reports whether the media file or URL is audio this module plays
+func (mod *MediaTypeModule) Supports(path string) bool {
+	return lesson.IsAudioFile(path)
+}
+
+// NewPlayer creates a player for audio questions owned by parent
+func (mod *MediaTypeModule) NewPlayer(parent *qt.QObject) *Player {
+	return NewPlayer(parent)
 }
 
 // Path is the Go port of the Python path method
@@ -86,3 +95,49 @@ func (mod *MediaTypeModule) SetManager(manager *core.Manager) {
 func InitMediaTypeModule() core.Module {
 	return NewMediaTypeModule()
 }
+
+// Player plays the audio of one question at a time and can play it again,
+// at normal speed or slowed down
+type Player struct {
+	player *multimedia.QMediaPlayer
+	source string
+	onErr  func(message string)
+}
+
+// NewPlayer creates an audio player owned by parent
+func NewPlayer(parent *qt.QObject) *Player {
+	p := &Player{player: multimedia.NewQMediaPlayer2(parent)}
+	p.player.OnErrorWithError(func(err multimedia.QMediaPlayer__Error) {
+		if err != multimedia.QMediaPlayer__NoError && p.onErr != nil {
+			p.onErr(p.player.ErrorString())
+		}
+	})
+	return p
+}
+
+// OnError sets the function called when audio cannot be played
+func (p *Player) OnError(slot func(message string)) {
+	p.onErr = slot
+}
+
+// Play loads source, a local path or a remote URL, and plays it from the
+// start at rate (1 is normal speed)
+func (p *Player) Play(source string, remote bool, rate float64) {
+	if source != p.source {
+		url := qt.QUrl_FromLocalFile(source)
+		if remote {
+			url = qt.NewQUrl3(source)
+		}
+		p.player.SetMedia(multimedia.NewQMediaContent2(url))
+		p.source = source
+	}
+	p.player.Stop()
+	p.player.SetPlaybackRate(rate)
+	p.player.SetPosition(0)
+	p.player.Play()
+}
+
+// Stop stops playing
+func (p *Player) Stop() {
+	p.player.Stop()
+}
@@ -1,43 +1,168 @@
 // Package recentlyopenedviewer provides functionality ported from Python module
+// legacy/modules/org/openteacher/interfaces/qt/recentlyOpenedViewer/recentlyOpenedViewer.py
 //
-// This is an automated port - implementation may be incomplete.
+// It shows the recently opened lessons as a grid on the start screen, with
+// their item counts, last scores and thumbnails.
 package recentlyopenedviewer
 
 import (
 	"context"
 	"fmt"
+
 	"github.com/LaPingvino/recuerdo/internal/core"
+	recentlyopened "github.com/LaPingvino/recuerdo/internal/modules/logic/recentlyOpened"
+	"github.com/mappu/miqt/qt"
 )
 
+// RecentlyOpened is the part of the recentlyOpened module the viewer shows
+// and edits
+type RecentlyOpened interface {
+	RecentlyOpened() []recentlyopened.Entry
+	SetPinned(path string, pinned bool) error
+	Remove(path string) error
+	OnUpdated(handler func())
+}
+
 // RecentlyOpenedViewerModule is a Go port of the Python RecentlyOpenedViewerModule class
 type RecentlyOpenedViewerModule struct {
 	*core.BaseModule
-	manager *core.Manager
-	// TODO: Add module-specific fields
+	manager        *core.Manager
+	recentlyOpened RecentlyOpened
+	// viewers are the viewers that still exist, updated when the list
+	// changes
+	viewers map[*viewer]bool
+}
+
+// viewer is one grid of recently opened lessons
+type viewer struct {
+	list    *qt.QListWidget
+	entries []recentlyopened.Entry
+	open    func(path string)
 }
 
 // NewRecentlyOpenedViewerModule creates a new RecentlyOpenedViewerModule instance
 func NewRecentlyOpenedViewerModule() *RecentlyOpenedViewerModule {
-	base := core.NewBaseModule("ui", "recentlyopenedviewer-module")
+	base := core.NewBaseModule("recentlyOpenedViewer", "recentlyopenedviewer-module")
+	base.SetRequires("recentlyOpened")
 
 	return &RecentlyOpenedViewerModule{
 		BaseModule: base,
+		viewers:    make(map[*viewer]bool),
 	}
 }
 
-// retranslate is the Go port of the Python _retranslate method
-func (mod *RecentlyOpenedViewerModule) retranslate() {
-	// TODO: Port Python method logic
+// CreateViewer creates a grid of the recently opened lessons, calling open
+// with the path of a lesson the user activates. It returns nil when there
+// is no recentlyOpened module. There must be a QApplication already.
+func (mod *RecentlyOpenedViewerModule) CreateViewer(open func(path string)) *qt.QWidget {
+	if mod.recentlyOpened == nil {
+		return nil
+	}
+
+	v := &viewer{list: qt.NewQListWidget(nil), open: open}
+	v.list.SetViewMode(qt.QListView__IconMode)
+	v.list.SetMovement(qt.QListView__Static)
+	v.list.SetResizeMode(qt.QListView__Adjust)
+	v.list.SetWordWrap(true)
+	v.list.SetIconSize(qt.NewQSize2(recentlyopened.ThumbnailWidth, recentlyopened.ThumbnailHeight))
+	v.list.SetGridSize(qt.NewQSize2(170, 130))
+	v.list.SetContextMenuPolicy(qt.CustomContextMenu)
+
+	v.list.OnItemActivated(func(item *qt.QListWidgetItem) {
+		if entry, ok := v.entry(item); ok {
+			v.open(entry.Path)
+		}
+	})
+	v.list.OnCustomContextMenuRequested(func(pos *qt.QPoint) {
+		mod.showContextMenu(v, pos)
+	})
+	v.list.OnDestroyed(func() {
+		delete(mod.viewers, v)
+	})
+
+	mod.viewers[v] = true
+	v.update(mod.recentlyOpened.RecentlyOpened())
+	return v.list.QWidget
 }
 
-// Createviewer is the Go port of the Python createViewer method
-func (mod *RecentlyOpenedViewerModule) Createviewer() {
-	// TODO: Port Python method logic
+// showContextMenu lets the user pin, unpin or forget the lesson at pos
+func (mod *RecentlyOpenedViewerModule) showContextMenu(v *viewer, pos *qt.QPoint) {
+	entry, ok := v.entry(v.list.ItemAt(pos))
+	if !ok {
+		return
+	}
+
+	menu := qt.NewQMenu(v.list.QWidget)
+	openAction := menu.AddAction("Open")
+	pinText := "Pin"
+	if entry.Pinned {
+		pinText = "Unpin"
+	}
+	pinAction := menu.AddAction(pinText)
+	removeAction := menu.AddAction("Remove from List")
+
+	var err error
+	switch chosen := menu.ExecWithPos(v.list.Viewport().MapToGlobal(pos)); {
+	case chosen == nil:
+	case chosen.UnsafePointer() == openAction.UnsafePointer():
+		v.open(entry.Path)
+	case chosen.UnsafePointer() == pinAction.UnsafePointer():
+		err = mod.recentlyOpened.SetPinned(entry.Path, !entry.Pinned)
+	case chosen.UnsafePointer() == removeAction.UnsafePointer():
+		err = mod.recentlyOpened.Remove(entry.Path)
+	}
+	if err != nil {
+		qt.QMessageBox_Warning(v.list.QWidget, "Recently Opened", err.Error())
+	}
 }
 
-// update is the Go port of the Python _update method
+// update is called when the list of recently opened lessons changes
 func (mod *RecentlyOpenedViewerModule) update() {
-	// TODO: Port Python method logic
+	if mod.recentlyOpened == nil {
+		return
+	}
+	entries := mod.recentlyOpened.RecentlyOpened()
+	for v := range mod.viewers {
+		v.update(entries)
+	}
+}
+
+func (v *viewer) update(entries []recentlyopened.Entry) {
+	v.entries = entries
+	v.list.Clear()
+	v.list.SetVisible(len(entries) > 0)
+	for _, entry := range entries {
+		item := qt.NewQListWidgetItem7(entryText(entry), v.list)
+		item.SetToolTip(entry.Path)
+		if entry.Thumbnail != "" {
+			item.SetIcon(qt.NewQIcon4(entry.Thumbnail))
+		}
+	}
+}
+
+// entry returns the lesson shown by item
+func (v *viewer) entry(item *qt.QListWidgetItem) (recentlyopened.Entry, bool) {
+	if item == nil {
+		return recentlyopened.Entry{}, false
+	}
+	row := v.list.Row(item)
+	if row < 0 || row >= len(v.entries) {
+		return recentlyopened.Entry{}, false
+	}
+	return v.entries[row], true
+}
+
+// entryText describes a lesson in the grid
+func entryText(entry recentlyopened.Entry) string {
+	text := entry.Label
+	if entry.Pinned {
+		text = "★ " + text
+	}
+	text += fmt.Sprintf("\n%d items", entry.Items)
+	if entry.LastScore >= 0 {
+		text += fmt.Sprintf(" · last %d%%", entry.LastScore)
+	}
+	return text
 }
 
 // Enable activates the module
@@ -47,7 +172,14 @@ func (mod *RecentlyOpenedViewerModule) Enable(ctx context.Context) error {
 		return err
 	}
 
-	// TODO: Port Python enable logic
+	if mod.manager != nil {
+		if module, ok := mod.manager.GetDefaultModule("recentlyOpened"); ok {
+			if recentlyOpened, ok := module.(RecentlyOpened); ok {
+				mod.recentlyOpened = recentlyOpened
+				recentlyOpened.OnUpdated(mod.update)
+			}
+		}
+	}
 
 	fmt.Println("RecentlyOpenedViewerModule enabled")
 	return nil
@@ -60,7 +192,8 @@ func (mod *RecentlyOpenedViewerModule) Disable(ctx context.Context) error {
 		return err
 	}
 
-	// TODO: Port Python disable logic
+	mod.recentlyOpened = nil
+	mod.viewers = make(map[*viewer]bool)
 
 	fmt.Println("RecentlyOpenedViewerModule disabled")
 	return nil
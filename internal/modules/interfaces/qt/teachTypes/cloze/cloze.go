@@ -0,0 +1,124 @@
+// Package cloze provides the teach type for cloze deletion items: a sentence
+// with one or more blanks the learner fills in.
+package cloze
+
+import (
+	"context"
+	"fmt"
+	"html"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/theme"
+	"github.com/mappu/miqt/qt"
+)
+
+// blankStyle is the style of a blank before answering; answers are styled
+// by the theme
+const blankStyle = "color: #0078d4; font-weight: bold;"
+
+// ClozeTeachTypeModule provides the cloze teach type
+type ClozeTeachTypeModule struct {
+	*core.BaseModule
+	manager *core.Manager
+}
+
+// NewClozeTeachTypeModule creates a new ClozeTeachTypeModule instance
+func NewClozeTeachTypeModule() *ClozeTeachTypeModule {
+	base := core.NewBaseModule("ui", "cloze-module")
+
+	return &ClozeTeachTypeModule{
+		BaseModule: base,
+	}
+}
+
+// CreateWidget creates the widget presenting cloze cards
+func (mod *ClozeTeachTypeModule) CreateWidget(parent *qt.QWidget) *ClozeTeachWidget {
+	return NewClozeTeachWidget(parent)
+}
+
+// Enable activates the module
+func (mod *ClozeTeachTypeModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("ClozeTeachTypeModule enabled")
+	return nil
+}
+
+// Disable deactivates the module
+func (mod *ClozeTeachTypeModule) Disable(ctx context.Context) error {
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("ClozeTeachTypeModule disabled")
+	return nil
+}
+
+// SetManager sets the module manager
+func (mod *ClozeTeachTypeModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// InitClozeTeachTypeModule creates and returns a new ClozeTeachTypeModule instance
+func InitClozeTeachTypeModule() core.Module {
+	return NewClozeTeachTypeModule()
+}
+
+// ClozeTeachWidget shows the sentence of a cloze card with its blanks and,
+// once graded, the answers filled in
+type ClozeTeachWidget struct {
+	*qt.QWidget
+
+	sentenceLabel *qt.QLabel
+	card          lesson.ClozeCard
+}
+
+// NewClozeTeachWidget creates a new cloze teach widget
+func NewClozeTeachWidget(parent *qt.QWidget) *ClozeTeachWidget {
+	widget := &ClozeTeachWidget{
+		QWidget: qt.NewQWidget(parent),
+	}
+
+	layout := qt.NewQVBoxLayout(widget.QWidget)
+	widget.sentenceLabel = qt.NewQLabel(widget.QWidget)
+	widget.sentenceLabel.SetTextFormat(qt.RichText)
+	widget.sentenceLabel.SetWordWrap(true)
+	widget.sentenceLabel.SetAlignment(qt.AlignCenter)
+	layout.AddWidget(widget.sentenceLabel.QWidget)
+
+	return widget
+}
+
+// SetCard shows the sentence of card with its blanks
+func (w *ClozeTeachWidget) SetCard(card lesson.ClozeCard) {
+	w.card = card
+	w.sentenceLabel.SetText(card.Render(html.EscapeString(card.Source), func(deletion lesson.ClozeDeletion) string {
+		blank := lesson.ClozeBlank
+		if deletion.Hint != "" {
+			blank = "[" + deletion.Hint + "]"
+		}
+		return fmt.Sprintf(`<span style="%s">%s</span>`, blankStyle, blank)
+	}))
+}
+
+// Card returns the card being shown
+func (w *ClozeTeachWidget) Card() lesson.ClozeCard {
+	return w.card
+}
+
+// Grade checks given against the card and fills in the blanks, showing the
+// right answers next to wrong ones
+func (w *ClozeTeachWidget) Grade(given string) bool {
+	correct := w.card.Check(given)
+	text := w.card.Render(html.EscapeString(w.card.Source), func(deletion lesson.ClozeDeletion) string {
+		return fmt.Sprintf(`<span style="%s">%s</span>`, theme.TextStyle(theme.FeedbackRight), deletion.Answer)
+	})
+	if !correct {
+		text += fmt.Sprintf(`<br><span style="%s">%s</span>`, theme.TextStyle(theme.FeedbackWrong), html.EscapeString(theme.Label(theme.FeedbackWrong, given)))
+	}
+	w.sentenceLabel.SetText(text)
+	return correct
+}
@@ -0,0 +1,338 @@
+// Package dictation provides the dictation teach type: the question is the
+// audio of a media item, and the student types what they hear. The audio
+// can be replayed, also slowed down, and each item allows a limited number
+// of attempts before the answer is shown.
+package dictation
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/mediaTypes/audio"
+	"github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/theme"
+	"github.com/mappu/miqt/qt"
+)
+
+// DictationTeachTypeModule provides the dictation teach type
+type DictationTeachTypeModule struct {
+	*core.BaseModule
+	manager *core.Manager
+}
+
+// NewDictationTeachTypeModule creates a new DictationTeachTypeModule instance
+func NewDictationTeachTypeModule() *DictationTeachTypeModule {
+	base := core.NewBaseModule("ui", "dictation-module")
+
+	return &DictationTeachTypeModule{
+		BaseModule: base,
+	}
+}
+
+// CreateWidget creates the widget dictating the audio items of a lesson
+func (mod *DictationTeachTypeModule) CreateWidget(parent *qt.QWidget) *DictationTeachWidget {
+	return NewDictationTeachWidget(parent)
+}
+
+// Enable activates the module
+func (mod *DictationTeachTypeModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("DictationTeachTypeModule enabled")
+	return nil
+}
+
+// Disable deactivates the module
+func (mod *DictationTeachTypeModule) Disable(ctx context.Context) error {
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("DictationTeachTypeModule disabled")
+	return nil
+}
+
+// SetManager sets the module manager
+func (mod *DictationTeachTypeModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// InitDictationTeachTypeModule creates and returns a new DictationTeachTypeModule instance
+func InitDictationTeachTypeModule() core.Module {
+	return NewDictationTeachTypeModule()
+}
+
+// DictationTeachWidget dictates the audio items of a lesson in random order
+type DictationTeachWidget struct {
+	*qt.QWidget
+
+	lesson  *lesson.Lesson
+	checker *lesson.AnswerChecker
+	player  *audio.Player
+
+	statusLabel   *qt.QLabel
+	playButton    *qt.QPushButton
+	replayButton  *qt.QPushButton
+	slowButton    *qt.QPushButton
+	answerInput   *qt.QLineEdit
+	checkButton   *qt.QPushButton
+	nextButton    *qt.QPushButton
+	attemptsLabel *qt.QLabel
+	feedbackLabel *qt.QLabel
+	scoreLabel    *qt.QLabel
+	attemptsSpin  *qt.QSpinBox
+
+	order     []int
+	current   int
+	dictation *lesson.Dictation
+	score     int
+	asked     int
+}
+
+// NewDictationTeachWidget creates a new dictation teach widget
+func NewDictationTeachWidget(parent *qt.QWidget) *DictationTeachWidget {
+	widget := &DictationTeachWidget{
+		QWidget: qt.NewQWidget(parent),
+	}
+	widget.player = audio.NewPlayer(widget.QObject)
+	widget.player.OnError(func(message string) {
+		widget.feedbackLabel.SetText(fmt.Sprintf("⚠️ Cannot play the audio: %s", message))
+		widget.feedbackLabel.SetStyleSheet("font-size: 14px; color: darkorange;")
+	})
+	widget.setupUI()
+	return widget
+}
+
+// setupUI creates the controls
+func (w *DictationTeachWidget) setupUI() {
+	layout := qt.NewQVBoxLayout(w.QWidget)
+
+	w.statusLabel = qt.NewQLabel3("Listen and type what you hear")
+	w.statusLabel.SetStyleSheet("font-size: 16px; font-weight: bold; margin: 8px;")
+	layout.AddWidget(w.statusLabel.QWidget)
+
+	playLayout := qt.NewQHBoxLayout2()
+	w.playButton = qt.NewQPushButton3("▶ Play")
+	w.replayButton = qt.NewQPushButton3("🔁 Replay")
+	w.slowButton = qt.NewQPushButton3("🐢 Slow")
+	w.slowButton.SetToolTip("Play the audio again slowed down")
+	playLayout.AddWidget(w.playButton.QWidget)
+	playLayout.AddWidget(w.replayButton.QWidget)
+	playLayout.AddWidget(w.slowButton.QWidget)
+	playLayout.AddStretch()
+	layout.AddLayout(playLayout.QLayout)
+
+	w.answerInput = qt.NewQLineEdit(w.QWidget)
+	w.answerInput.SetPlaceholderText("Type what you hear...")
+	w.answerInput.SetStyleSheet("font-size: 14px; padding: 8px;")
+	layout.AddWidget(w.answerInput.QWidget)
+
+	w.attemptsLabel = qt.NewQLabel(w.QWidget)
+	layout.AddWidget(w.attemptsLabel.QWidget)
+	w.feedbackLabel = qt.NewQLabel(w.QWidget)
+	w.feedbackLabel.SetWordWrap(true)
+	layout.AddWidget(w.feedbackLabel.QWidget)
+
+	controlLayout := qt.NewQHBoxLayout2()
+	w.checkButton = qt.NewQPushButton3("Check")
+	w.nextButton = qt.NewQPushButton3("Next")
+	controlLayout.AddWidget(w.checkButton.QWidget)
+	controlLayout.AddWidget(w.nextButton.QWidget)
+	controlLayout.AddStretch()
+	controlLayout.AddWidget(qt.NewQLabel3("Attempts per item:").QWidget)
+	w.attemptsSpin = qt.NewQSpinBox(nil)
+	w.attemptsSpin.SetRange(1, 10)
+	w.attemptsSpin.SetValue(lesson.DefaultDictationAttempts)
+	controlLayout.AddWidget(w.attemptsSpin.QWidget)
+	layout.AddLayout(controlLayout.QLayout)
+
+	w.scoreLabel = qt.NewQLabel(w.QWidget)
+	layout.AddWidget(w.scoreLabel.QWidget)
+	layout.AddStretch()
+
+	w.playButton.OnClicked(func() { w.play(1) })
+	w.replayButton.OnClicked(func() { w.play(1) })
+	w.slowButton.OnClicked(func() {
+		if w.lesson != nil {
+			w.play(w.lesson.Data.DictationSettings().SlowRate)
+		}
+	})
+	w.checkButton.OnClicked(w.check)
+	w.answerInput.OnReturnPressed(func() {
+		if w.dictation != nil && w.dictation.Done() {
+			w.next()
+			return
+		}
+		w.check()
+	})
+	w.nextButton.OnClicked(w.next)
+	w.attemptsSpin.OnValueChanged(func(value int) {
+		if w.lesson == nil {
+			return
+		}
+		settings := w.lesson.Data.DictationSettings()
+		if settings.Attempts != value {
+			settings.Attempts = value
+			w.lesson.Data.SetDictationSettings(settings)
+		}
+	})
+
+	w.setItemControls(false)
+}
+
+// SetLesson starts dictating the audio items of a lesson
+func (w *DictationTeachWidget) SetLesson(l *lesson.Lesson, checker *lesson.AnswerChecker) {
+	w.lesson = l
+	w.checker = checker
+	w.player.Stop()
+	w.order = nil
+	w.current = 0
+	w.score = 0
+	w.asked = 0
+	w.dictation = nil
+
+	if l == nil {
+		w.setItemControls(false)
+		return
+	}
+	w.attemptsSpin.SetValue(l.Data.DictationSettings().Attempts)
+	w.order = lesson.DictationItems(&l.Data)
+	rand.Shuffle(len(w.order), func(i, j int) { w.order[i], w.order[j] = w.order[j], w.order[i] })
+	log.Printf("[ACTION] DictationTeachWidget.SetLesson() - %d audio items", len(w.order))
+
+	if len(w.order) == 0 {
+		w.statusLabel.SetText("This lesson has no audio items to dictate")
+		w.setItemControls(false)
+		w.updateScore()
+		return
+	}
+	w.ask()
+}
+
+// Stop stops the audio, for instance when the widget is hidden
+func (w *DictationTeachWidget) Stop() {
+	w.player.Stop()
+}
+
+// ask starts the current item and plays its audio
+func (w *DictationTeachWidget) ask() {
+	index := w.order[w.current]
+	w.dictation = lesson.NewDictation(&w.lesson.Data, index)
+
+	w.statusLabel.SetText(fmt.Sprintf("Item %d of %d: listen and type what you hear", w.current+1, len(w.order)))
+	w.feedbackLabel.Clear()
+	w.feedbackLabel.SetStyleSheet("")
+	w.answerInput.Clear()
+	w.setItemControls(true)
+	w.nextButton.SetEnabled(false)
+	w.updateAttempts()
+	w.updateScore()
+	w.answerInput.SetFocus()
+	w.play(1)
+}
+
+// play plays the current item's audio at rate
+func (w *DictationTeachWidget) play(rate float64) {
+	if w.dictation == nil {
+		return
+	}
+	item := w.lesson.Data.List.Items[w.order[w.current]]
+	filename, remote, ok := item.GetMediaInfo()
+	if !ok {
+		return
+	}
+	path, err := w.lesson.Data.ResolveMedia(w.lesson.Path, filename, remote)
+	if err != nil {
+		log.Printf("[ERROR] DictationTeachWidget.play() - %v", err)
+		return
+	}
+	w.player.Play(path, remote, rate)
+}
+
+// check grades the typed answer
+func (w *DictationTeachWidget) check() {
+	if w.dictation == nil || w.dictation.Done() {
+		return
+	}
+
+	given := w.answerInput.Text()
+	if w.dictation.Try(w.checker, given) {
+		w.feedbackLabel.SetText(theme.Label(theme.FeedbackRight, "Correct!"))
+		w.feedbackLabel.SetStyleSheet(theme.Style(theme.FeedbackRight, "font-size: 14px; "))
+	} else if w.dictation.Done() {
+		w.feedbackLabel.SetText(theme.Label(theme.FeedbackWrong, fmt.Sprintf("The answer is: %s", w.dictation.Answers[0])))
+		w.feedbackLabel.SetStyleSheet(theme.Style(theme.FeedbackWrong, "font-size: 14px; "))
+	} else {
+		w.feedbackLabel.SetText(theme.Label(theme.FeedbackAlmost, "Not quite, listen again and retry"))
+		w.feedbackLabel.SetStyleSheet(theme.Style(theme.FeedbackAlmost, "font-size: 14px; "))
+		w.answerInput.SelectAll()
+	}
+	w.updateAttempts()
+
+	if w.dictation.Done() {
+		w.finishItem()
+	}
+}
+
+// finishItem records the result of the current item
+func (w *DictationTeachWidget) finishItem() {
+	item := w.lesson.Data.List.Items[w.order[w.current]]
+	result := "wrong"
+	if w.dictation.Correct {
+		result = "right"
+		w.score++
+	}
+	w.asked++
+	w.lesson.Data.List.AddTestResult(item.ID, result)
+	w.lesson.Data.Changed = true
+
+	w.answerInput.SetEnabled(false)
+	w.checkButton.SetEnabled(false)
+	w.nextButton.SetEnabled(true)
+	w.nextButton.SetFocus()
+	w.updateScore()
+}
+
+// next moves to the next item, or ends the dictation after the last one
+func (w *DictationTeachWidget) next() {
+	if w.dictation == nil || !w.dictation.Done() {
+		return
+	}
+	w.player.Stop()
+	w.current++
+	if w.current < len(w.order) {
+		w.ask()
+		return
+	}
+
+	w.dictation = nil
+	w.setItemControls(false)
+	w.statusLabel.SetText(fmt.Sprintf("🎉 Dictation finished: %d of %d right", w.score, w.asked))
+	w.attemptsLabel.Clear()
+	log.Printf("[SUCCESS] DictationTeachWidget.next() - finished with %d/%d", w.score, w.asked)
+}
+
+// setItemControls enables the controls used while an item is asked
+func (w *DictationTeachWidget) setItemControls(enabled bool) {
+	w.playButton.SetEnabled(enabled)
+	w.replayButton.SetEnabled(enabled)
+	w.slowButton.SetEnabled(enabled)
+	w.answerInput.SetEnabled(enabled)
+	w.checkButton.SetEnabled(enabled)
+	w.nextButton.SetEnabled(false)
+}
+
+// updateAttempts shows the attempts left for the current item
+func (w *DictationTeachWidget) updateAttempts() {
+	w.attemptsLabel.SetText(fmt.Sprintf("Attempts left: %d of %d", w.dictation.AttemptsLeft(), w.dictation.MaxAttempts))
+}
+
+// updateScore shows the score so far
+func (w *DictationTeachWidget) updateScore() {
+	w.scoreLabel.SetText(fmt.Sprintf("Score: %d/%d", w.score, w.asked))
+}
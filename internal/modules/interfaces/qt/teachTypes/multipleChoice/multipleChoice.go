@@ -0,0 +1,217 @@
+// Package multiplechoice provides the multiple choice teach type: the right
+// answer is shown among distractors picked from the other answers in the
+// lesson. It only needs the items' answers, so words, topo and media lessons
+// can all host it.
+package multiplechoice
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/theme"
+	"github.com/mappu/miqt/qt"
+)
+
+// choiceStyle is the style of a choice; once answered, the right choice and
+// a wrong choice picked are styled by the theme on top of it
+const choiceStyle = "font-size: 14px; padding: 8px; text-align: left;"
+
+// MultipleChoiceTeachTypeModule provides the multiple choice teach type
+type MultipleChoiceTeachTypeModule struct {
+	*core.BaseModule
+	manager *core.Manager
+}
+
+// NewMultipleChoiceTeachTypeModule creates a new MultipleChoiceTeachTypeModule instance
+func NewMultipleChoiceTeachTypeModule() *MultipleChoiceTeachTypeModule {
+	base := core.NewBaseModule("ui", "multiplechoice-module")
+
+	return &MultipleChoiceTeachTypeModule{
+		BaseModule: base,
+	}
+}
+
+// CreateWidget creates the widget presenting the choices
+func (mod *MultipleChoiceTeachTypeModule) CreateWidget(parent *qt.QWidget) *MultipleChoiceTeachWidget {
+	return NewMultipleChoiceTeachWidget(parent)
+}
+
+// Enable activates the module
+func (mod *MultipleChoiceTeachTypeModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("MultipleChoiceTeachTypeModule enabled")
+	return nil
+}
+
+// Disable deactivates the module
+func (mod *MultipleChoiceTeachTypeModule) Disable(ctx context.Context) error {
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("MultipleChoiceTeachTypeModule disabled")
+	return nil
+}
+
+// SetManager sets the module manager
+func (mod *MultipleChoiceTeachTypeModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// InitMultipleChoiceTeachTypeModule creates and returns a new MultipleChoiceTeachTypeModule instance
+func InitMultipleChoiceTeachTypeModule() core.Module {
+	return NewMultipleChoiceTeachTypeModule()
+}
+
+// MultipleChoiceTeachWidget shows the choices for one item as buttons. The
+// host grades the picked choice like a typed answer and calls Reveal.
+type MultipleChoiceTeachWidget struct {
+	*qt.QWidget
+
+	layout   *qt.QVBoxLayout
+	buttons  []*qt.QPushButton
+	choices  []string
+	right    int
+	rng      *rand.Rand
+	onChoice func(choice string)
+}
+
+// NewMultipleChoiceTeachWidget creates a new multiple choice teach widget
+func NewMultipleChoiceTeachWidget(parent *qt.QWidget) *MultipleChoiceTeachWidget {
+	widget := &MultipleChoiceTeachWidget{
+		QWidget: qt.NewQWidget(parent),
+		rng:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	widget.layout = qt.NewQVBoxLayout(widget.QWidget)
+	return widget
+}
+
+// OnChoice sets the function called with the text of the choice picked
+func (w *MultipleChoiceTeachWidget) OnChoice(slot func(choice string)) {
+	w.onChoice = slot
+}
+
+// SetItem shows the choices for the item at itemIndex, as many as the
+// lesson's multiple choice settings ask for
+func (w *MultipleChoiceTeachWidget) SetItem(lessonData *lesson.LessonData, itemIndex int) error {
+	choices, right, err := lesson.DistractorGeneratorFor(lessonData).Choices(lessonData, itemIndex, w.rng)
+	if err != nil {
+		return err
+	}
+
+	for _, button := range w.buttons {
+		w.layout.RemoveWidget(button.QWidget)
+		button.DeleteLater()
+	}
+	w.buttons = w.buttons[:0]
+	w.choices = choices
+	w.right = right
+
+	for i, choice := range choices {
+		button := qt.NewQPushButton3(fmt.Sprintf("%d. %s", i+1, choice))
+		button.SetStyleSheet(choiceStyle)
+		picked := choice
+		button.OnClicked(func() {
+			if w.onChoice != nil {
+				w.onChoice(picked)
+			}
+		})
+		w.layout.AddWidget(button.QWidget)
+		w.buttons = append(w.buttons, button)
+	}
+	return nil
+}
+
+// Reveal marks the right choice and, if it was not picked, the picked one,
+// and disables the choices until the next item
+func (w *MultipleChoiceTeachWidget) Reveal(picked string) {
+	for i, button := range w.buttons {
+		switch {
+		case i == w.right:
+			w.mark(i, theme.FeedbackRight)
+		case w.choices[i] == picked:
+			w.mark(i, theme.FeedbackWrong)
+		}
+		button.SetEnabled(false)
+	}
+}
+
+// mark shows a kind of feedback on a choice with its icon and style
+func (w *MultipleChoiceTeachWidget) mark(i int, kind theme.Feedback) {
+	w.buttons[i].SetText(theme.Label(kind, fmt.Sprintf("%d. %s", i+1, w.choices[i])))
+	w.buttons[i].SetStyleSheet(theme.Style(kind, choiceStyle))
+}
+
+// SettingsWidget edits the multiple choice settings of a lesson
+type SettingsWidget struct {
+	*qt.QWidget
+
+	lessonData    *lesson.LessonData
+	enabledBox    *qt.QCheckBox
+	choicesSpin   *qt.QSpinBox
+	updatingState bool
+}
+
+// NewSettingsWidget creates a settings widget for lessonData
+func NewSettingsWidget(lessonData *lesson.LessonData, parent *qt.QWidget) *SettingsWidget {
+	widget := &SettingsWidget{
+		QWidget: qt.NewQWidget(parent),
+	}
+
+	layout := qt.NewQHBoxLayout(widget.QWidget)
+	widget.enabledBox = qt.NewQCheckBox3("Multiple choice")
+	widget.enabledBox.SetToolTip("Pick the answer from a list instead of typing it")
+	layout.AddWidget(widget.enabledBox.QWidget)
+
+	choicesLabel := qt.NewQLabel3("Choices:")
+	layout.AddWidget(choicesLabel.QWidget)
+	widget.choicesSpin = qt.NewQSpinBox(nil)
+	widget.choicesSpin.SetRange(lesson.MinChoices, lesson.MaxChoices)
+	layout.AddWidget(widget.choicesSpin.QWidget)
+	layout.AddStretch()
+
+	widget.enabledBox.OnToggled(func(bool) {
+		widget.store()
+	})
+	widget.choicesSpin.OnValueChanged(func(int) {
+		widget.store()
+	})
+
+	widget.SetLessonData(lessonData)
+	return widget
+}
+
+// SetLessonData shows the settings of another lesson
+func (w *SettingsWidget) SetLessonData(lessonData *lesson.LessonData) {
+	w.lessonData = lessonData
+	settings := lesson.DefaultMultipleChoiceSettings()
+	if lessonData != nil {
+		settings = lessonData.MultipleChoiceSettings()
+	}
+
+	w.updatingState = true
+	w.enabledBox.SetChecked(settings.Enabled)
+	w.choicesSpin.SetValue(settings.Choices)
+	w.choicesSpin.SetEnabled(settings.Enabled)
+	w.updatingState = false
+}
+
+// store writes the shown settings to the lesson
+func (w *SettingsWidget) store() {
+	w.choicesSpin.SetEnabled(w.enabledBox.IsChecked())
+	if w.updatingState || w.lessonData == nil {
+		return
+	}
+
+	w.lessonData.SetMultipleChoiceSettings(lesson.MultipleChoiceSettings{
+		Enabled: w.enabledBox.IsChecked(),
+		Choices: w.choicesSpin.Value(),
+	})
+}
@@ -1,57 +1,161 @@
-// Package studentsview provides functionality ported from Python module
-//
-// This is an automated port - implementation may be incomplete.
+// Package studentsview shows the teacher the students taking a live test:
+// who is connected, how many items they answered and how many of those
+// were right, updated as the answers come in.
 package studentsview
 
 import (
 	"context"
 	"fmt"
+	"sort"
+
+	"github.com/LaPingvino/recuerdo/internal/classroom"
 	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/mappu/miqt/qt"
 )
 
-// TestModeStudentsViewModule is a Go port of the Python TestModeStudentsViewModule class
+// TestModeStudentsViewModule provides the students view to the other test
+// mode modules
 type TestModeStudentsViewModule struct {
 	*core.BaseModule
 	manager *core.Manager
-	// TODO: Add module-specific fields
 }
 
 // NewTestModeStudentsViewModule creates a new TestModeStudentsViewModule instance
 func NewTestModeStudentsViewModule() *TestModeStudentsViewModule {
-	base := core.NewBaseModule("ui", "studentsview-module")
+	base := core.NewBaseModule("testModeStudentsView", "studentsview-module")
 
 	return &TestModeStudentsViewModule{
 		BaseModule: base,
 	}
 }
 
-// Getstudentsview is the Go port of the Python getStudentsView method
-func (mod *TestModeStudentsViewModule) Getstudentsview() {
-	// TODO: Port Python method logic
+// Getstudentsview creates a students view in parent
+func (mod *TestModeStudentsViewModule) Getstudentsview(parent *qt.QWidget) *View {
+	return NewView(parent)
+}
+
+// student is a row of the view
+type student struct {
+	name      string
+	connected bool
+	// answers tells for every item answered whether the answer was right
+	answers map[int]bool
+	last    string
+}
+
+// View is a table of the students in a live test
+type View struct {
+	*qt.QTableWidget
+	// items is the number of items in the test
+	items    int
+	students map[int]*student
+}
+
+// NewView creates an empty students view in parent
+func NewView(parent *qt.QWidget) *View {
+	v := &View{QTableWidget: qt.NewQTableWidget(parent), students: make(map[int]*student)}
+	v.SetColumnCount(5)
+	v.SetHorizontalHeaderLabels([]string{"Student", "Connected", "Answered", "Right", "Last answer"})
+	v.SetEditTriggers(qt.QAbstractItemView__NoEditTriggers)
+	v.SetSelectionMode(qt.QAbstractItemView__NoSelection)
+	v.HorizontalHeader().SetStretchLastSection(true)
+	return v
+}
+
+// Update takes in a message of the live test channel
+func (v *View) Update(message classroom.LiveMessage) {
+	switch message.Type {
+	case classroom.LiveTest:
+		// A new test starts everyone over
+		v.items = len(message.Test.Items)
+		for _, s := range v.students {
+			s.answers = make(map[int]bool)
+			s.last = ""
+		}
+	case classroom.LiveJoined, classroom.LiveLeft:
+		s := v.student(message.Student.ID, message.Student.Name)
+		s.connected = message.Type == classroom.LiveJoined
+	case classroom.LiveAnswer:
+		s := v.student(message.Answer.StudentID, message.Answer.Student)
+		s.answers[message.Answer.ItemID] = message.Answer.Right
+		s.last = message.Answer.Answer
+	default:
+		return
+	}
+	v.refresh()
+}
+
+// Answered returns how many students answered every item
+func (v *View) Answered() int {
+	done := 0
+	for _, s := range v.students {
+		if v.items > 0 && len(s.answers) == v.items {
+			done++
+		}
+	}
+	return done
+}
+
+// student returns the row of a student, adding it when new
+func (v *View) student(id int, name string) *student {
+	s, ok := v.students[id]
+	if !ok {
+		s = &student{answers: make(map[int]bool)}
+		v.students[id] = s
+	}
+	if name != "" {
+		s.name = name
+	}
+	return s
+}
+
+// refresh shows the students by name
+func (v *View) refresh() {
+	ids := make([]int, 0, len(v.students))
+	for id := range v.students {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return v.students[ids[i]].name < v.students[ids[j]].name
+	})
+
+	v.SetRowCount(len(ids))
+	for row, id := range ids {
+		s := v.students[id]
+		connected := "No"
+		if s.connected {
+			connected = "Yes"
+		}
+		right := 0
+		for _, ok := range s.answers {
+			if ok {
+				right++
+			}
+		}
+		v.SetItem(row, 0, qt.NewQTableWidgetItem2(s.name))
+		v.SetItem(row, 1, qt.NewQTableWidgetItem2(connected))
+		v.SetItem(row, 2, qt.NewQTableWidgetItem2(fmt.Sprintf("%d/%d", len(s.answers), v.items)))
+		v.SetItem(row, 3, qt.NewQTableWidgetItem2(fmt.Sprintf("%d", right)))
+		v.SetItem(row, 4, qt.NewQTableWidgetItem2(s.last))
+	}
 }
 
 // Enable activates the module
-// This is the Go equivalent of the Python enable method
 func (mod *TestModeStudentsViewModule) Enable(ctx context.Context) error {
 	if err := mod.BaseModule.Enable(ctx); err != nil {
 		return err
 	}
 
-	// TODO: Port Python enable logic
-
 	fmt.Println("TestModeStudentsViewModule enabled")
 	return nil
 }
 
 // Disable deactivates the module
-// This is the Go equivalent of the Python disable method
 func (mod *TestModeStudentsViewModule) Disable(ctx context.Context) error {
 	if err := mod.BaseModule.Disable(ctx); err != nil {
 		return err
 	}
 
-	// TODO: Port Python disable logic
-
 	fmt.Println("TestModeStudentsViewModule disabled")
 	return nil
 }
@@ -0,0 +1,144 @@
+package teacherpanel
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/classroom"
+	"github.com/mappu/miqt/qt"
+)
+
+// auditFilters are the kinds of actions the audit log can be narrowed to,
+// as shown and as the action prefix sent to the server
+var auditFilters = []struct {
+	label, prefix string
+}{
+	{"All actions", ""},
+	{"Students", "student."},
+	{"Results", "result."},
+	{"Assignments", "assignment."},
+	{"Live tests", "live."},
+	{"Lessons", "lesson."},
+	{"Reviews", "reviews."},
+	{"Vaults", "vault."},
+}
+
+// auditPeriods are the periods the audit log can be narrowed to; zero
+// means everything
+var auditPeriods = []struct {
+	label  string
+	period time.Duration
+}{
+	{"Ever", 0},
+	{"Last day", 24 * time.Hour},
+	{"Last week", 7 * 24 * time.Hour},
+	{"Last 30 days", 30 * 24 * time.Hour},
+}
+
+// auditPanel is the dialog showing the audit log of the server, which only
+// admin tokens may read
+type auditPanel struct {
+	*qt.QDialog
+	client  *classroom.Client
+	action  *qt.QComboBox
+	period  *qt.QComboBox
+	table   *qt.QTableWidget
+	warning *qt.QLabel
+	status  *qt.QLabel
+}
+
+func newAuditPanel(parent *qt.QWidget, client *classroom.Client) *auditPanel {
+	p := &auditPanel{QDialog: qt.NewQDialog(parent), client: client}
+	p.SetWindowTitle("Audit Log")
+	p.SetModal(true)
+	p.Resize(860, 520)
+
+	layout := qt.NewQVBoxLayout(p.QWidget)
+	p.SetLayout(layout.QLayout)
+
+	top := qt.NewQHBoxLayout2()
+	top.AddWidget(qt.NewQLabel3("Show:").QWidget)
+	p.action = qt.NewQComboBox(p.QWidget)
+	for _, filter := range auditFilters {
+		p.action.AddItem(filter.label)
+	}
+	top.AddWidget(p.action.QWidget)
+	p.period = qt.NewQComboBox(p.QWidget)
+	for _, period := range auditPeriods {
+		p.period.AddItem(period.label)
+	}
+	top.AddWidget(p.period.QWidget)
+	top.AddStretch()
+	refreshButton := qt.NewQPushButton3("Refresh")
+	refreshButton.OnClicked(p.refresh)
+	top.AddWidget(refreshButton.QWidget)
+	layout.AddLayout(top.QLayout)
+
+	p.warning = qt.NewQLabel(p.QWidget)
+	p.warning.SetWordWrap(true)
+	p.warning.SetStyleSheet("color: #b00020; font-weight: bold")
+	p.warning.Hide()
+	layout.AddWidget(p.warning.QWidget)
+
+	p.table = qt.NewQTableWidget(p.QWidget)
+	p.table.SetColumnCount(6)
+	p.table.SetHorizontalHeaderLabels([]string{"Time", "Actor", "Address", "Action", "Subject", "Details"})
+	p.table.SetSelectionBehavior(qt.QAbstractItemView__SelectRows)
+	p.table.SetEditTriggers(qt.QAbstractItemView__NoEditTriggers)
+	p.table.HorizontalHeader().SetStretchLastSection(true)
+	layout.AddWidget(p.table.QWidget)
+
+	p.status = qt.NewQLabel(p.QWidget)
+	layout.AddWidget(p.status.QWidget)
+
+	closeBox := qt.NewQDialogButtonBox(p.QWidget)
+	closeBox.SetStandardButtons(qt.QDialogButtonBox__Close)
+	closeBox.OnRejected(p.Reject)
+	layout.AddWidget(closeBox.QWidget)
+
+	p.action.OnCurrentIndexChanged(func(int) { p.refresh() })
+	p.period.OnCurrentIndexChanged(func(int) { p.refresh() })
+	p.refresh()
+	return p
+}
+
+// refresh reloads the audit log from the server, newest entries first
+func (p *auditPanel) refresh() {
+	action := ""
+	if i := p.action.CurrentIndex(); i >= 0 && i < len(auditFilters) {
+		action = auditFilters[i].prefix
+	}
+	var since time.Time
+	if i := p.period.CurrentIndex(); i >= 0 && i < len(auditPeriods) && auditPeriods[i].period > 0 {
+		since = time.Now().Add(-auditPeriods[i].period)
+	}
+	report, err := p.client.AuditLog(action, since)
+	if err != nil {
+		p.status.SetText(err.Error())
+		return
+	}
+
+	if report.Intact {
+		p.warning.Hide()
+	} else {
+		p.warning.SetText("The audit log has been tampered with: " + report.Problem)
+		p.warning.Show()
+	}
+	p.table.SetRowCount(len(report.Entries))
+	for row := range report.Entries {
+		entry := report.Entries[len(report.Entries)-1-row]
+		cells := []string{
+			entry.Time.Local().Format("2006-01-02 15:04:05"),
+			entry.Actor,
+			entry.Address,
+			entry.Action,
+			entry.Subject,
+			entry.Details,
+		}
+		for column, cell := range cells {
+			p.table.SetItem(row, column, qt.NewQTableWidgetItem2(cell))
+		}
+	}
+	p.table.ResizeColumnsToContents()
+	p.status.SetText(fmt.Sprintf("%d entries", len(report.Entries)))
+}
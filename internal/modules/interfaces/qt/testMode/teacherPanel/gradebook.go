@@ -0,0 +1,278 @@
+package teacherpanel
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/classroom"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	notecalculatorchooser "github.com/LaPingvino/recuerdo/internal/modules/logic/noteCalculatorChooser"
+	"github.com/mappu/miqt/qt"
+)
+
+// noGroup is shown for the students without a group
+const noGroup = "(no group)"
+
+// gradebookPanel is the dialog showing the notes of a group for the lessons
+// assigned to it, which the teacher assigns and exports from it
+type gradebookPanel struct {
+	*qt.QDialog
+	client      *classroom.Client
+	chooser     NoteCalculatorChooser
+	calculators []notecalculatorchooser.NoteCalculator
+	groups      []string
+	gradebook   *classroom.Gradebook
+	group       *qt.QComboBox
+	notes       *qt.QComboBox
+	assignments *qt.QListWidget
+	table       *qt.QTableWidget
+	status      *qt.QLabel
+}
+
+func newGradebookPanel(parent *qt.QWidget, client *classroom.Client, chooser NoteCalculatorChooser) *gradebookPanel {
+	p := &gradebookPanel{QDialog: qt.NewQDialog(parent), client: client, chooser: chooser}
+	p.SetWindowTitle("Gradebook")
+	p.SetModal(true)
+	p.Resize(760, 560)
+
+	layout := qt.NewQVBoxLayout(p.QWidget)
+	p.SetLayout(layout.QLayout)
+
+	top := qt.NewQHBoxLayout2()
+	top.AddWidget(qt.NewQLabel3("Group:").QWidget)
+	p.group = qt.NewQComboBox(p.QWidget)
+	top.AddWidget(p.group.QWidget)
+	top.AddStretch()
+	top.AddWidget(qt.NewQLabel3("Notes:").QWidget)
+	p.notes = qt.NewQComboBox(p.QWidget)
+	p.calculators = chooser.Notecalculators()
+	chosen := chooser.Notecalculator()
+	for i, calculator := range p.calculators {
+		p.notes.AddItem(calculator.Notesystem())
+		if chosen != nil && calculator.Name() == chosen.Name() {
+			p.notes.SetCurrentIndex(i)
+		}
+	}
+	top.AddWidget(p.notes.QWidget)
+	layout.AddLayout(top.QLayout)
+
+	layout.AddWidget(qt.NewQLabel3("Lessons assigned to the group:").QWidget)
+	assigned := qt.NewQHBoxLayout2()
+	p.assignments = qt.NewQListWidget(p.QWidget)
+	p.assignments.SetMaximumHeight(100)
+	assigned.AddWidget(p.assignments.QWidget)
+	assignButtons := qt.NewQVBoxLayout2()
+	assignButton := qt.NewQPushButton3("Assign...")
+	assignButton.OnClicked(p.assign)
+	assignButtons.AddWidget(assignButton.QWidget)
+	unassignButton := qt.NewQPushButton3("Unassign")
+	unassignButton.OnClicked(p.unassign)
+	assignButtons.AddWidget(unassignButton.QWidget)
+	assignButtons.AddStretch()
+	assigned.AddLayout(assignButtons.QLayout)
+	layout.AddLayout(assigned.QLayout)
+
+	p.table = qt.NewQTableWidget(p.QWidget)
+	p.table.SetEditTriggers(qt.QAbstractItemView__NoEditTriggers)
+	layout.AddWidget(p.table.QWidget)
+
+	buttons := qt.NewQHBoxLayout2()
+	csvButton := qt.NewQPushButton3("Export CSV...")
+	csvButton.OnClicked(func() { p.export("CSV files (*.csv)", ".csv") })
+	buttons.AddWidget(csvButton.QWidget)
+	odsButton := qt.NewQPushButton3("Export ODS...")
+	odsButton.OnClicked(func() { p.export("OpenDocument spreadsheets (*.ods)", ".ods") })
+	buttons.AddWidget(odsButton.QWidget)
+	buttons.AddStretch()
+	layout.AddLayout(buttons.QLayout)
+
+	p.status = qt.NewQLabel(p.QWidget)
+	layout.AddWidget(p.status.QWidget)
+
+	closeBox := qt.NewQDialogButtonBox(p.QWidget)
+	closeBox.SetStandardButtons(qt.QDialogButtonBox__Close)
+	closeBox.OnRejected(p.Reject)
+	layout.AddWidget(closeBox.QWidget)
+
+	p.loadGroups()
+	p.group.OnCurrentIndexChanged(func(int) { p.refresh() })
+	p.notes.OnCurrentIndexChanged(p.chooseNotes)
+	p.refresh()
+	return p
+}
+
+// loadGroups fills the group selector with the groups on the roster
+func (p *gradebookPanel) loadGroups() {
+	students, err := p.client.Students()
+	if err != nil {
+		p.status.SetText(err.Error())
+		return
+	}
+	seen := make(map[string]bool)
+	for _, student := range students {
+		if !seen[student.Group] {
+			seen[student.Group] = true
+			p.groups = append(p.groups, student.Group)
+		}
+	}
+	sort.Strings(p.groups)
+	for _, group := range p.groups {
+		if group == "" {
+			group = noGroup
+		}
+		p.group.AddItem(group)
+	}
+}
+
+// selectedGroup returns the group chosen in the selector
+func (p *gradebookPanel) selectedGroup() (string, bool) {
+	i := p.group.CurrentIndex()
+	if i < 0 || i >= len(p.groups) {
+		return "", false
+	}
+	return p.groups[i], true
+}
+
+// calculator returns the note calculator chosen in the selector
+func (p *gradebookPanel) calculator() notecalculatorchooser.NoteCalculator {
+	i := p.notes.CurrentIndex()
+	if i < 0 || i >= len(p.calculators) {
+		return nil
+	}
+	return p.calculators[i]
+}
+
+// chooseNotes makes the chosen note calculator the default, so results are
+// graded the same everywhere
+func (p *gradebookPanel) chooseNotes(i int) {
+	if i >= 0 && i < len(p.calculators) {
+		if err := p.chooser.Choose(p.calculators[i].Name()); err != nil {
+			p.status.SetText(err.Error())
+		}
+	}
+	p.showTable()
+}
+
+// refresh reloads the assignments and results of the group from the
+// server
+func (p *gradebookPanel) refresh() {
+	group, ok := p.selectedGroup()
+	if !ok {
+		return
+	}
+	gradebook, err := p.client.Gradebook(group)
+	if err != nil {
+		p.status.SetText(err.Error())
+		return
+	}
+	assignments, err := p.client.Assignments(group)
+	if err != nil {
+		p.status.SetText(err.Error())
+		return
+	}
+	p.gradebook = gradebook
+	p.assignments.Clear()
+	for _, lessonTitle := range assignments {
+		p.assignments.AddItem(lessonTitle)
+	}
+	p.showTable()
+}
+
+// showTable shows the notes of the gradebook in the chosen notation
+func (p *gradebookPanel) showTable() {
+	calculator := p.calculator()
+	if p.gradebook == nil || calculator == nil {
+		return
+	}
+	header, rows := p.gradebook.Table(calculator)
+	p.table.Clear()
+	p.table.SetColumnCount(len(header))
+	p.table.SetHorizontalHeaderLabels(header)
+	p.table.SetRowCount(len(rows))
+	for row, cells := range rows {
+		for column, cell := range cells {
+			p.table.SetItem(row, column, qt.NewQTableWidgetItem2(cell))
+		}
+	}
+	p.status.SetText(fmt.Sprintf("%d students, %d lessons", len(rows), len(p.gradebook.Lessons)))
+}
+
+// assign assigns a lesson to the group, offering the lessons the group
+// handed in results for but has not been assigned
+func (p *gradebookPanel) assign() {
+	group, ok := p.selectedGroup()
+	if !ok || p.gradebook == nil {
+		return
+	}
+	assigned := make(map[string]bool)
+	for i := 0; i < p.assignments.Count(); i++ {
+		assigned[p.assignments.Item(i).Text()] = true
+	}
+	var offered []string
+	for _, lessonTitle := range p.gradebook.Lessons {
+		if !assigned[lessonTitle] {
+			offered = append(offered, lessonTitle)
+		}
+	}
+	accepted := false
+	lessonTitle := qt.QInputDialog_GetItem4(p.QWidget, "Assign Lesson", "Lesson title:", offered, 0, true, &accepted)
+	if !accepted || strings.TrimSpace(lessonTitle) == "" {
+		return
+	}
+	if err := p.client.Assign(group, lessonTitle); err != nil {
+		p.status.SetText(err.Error())
+		return
+	}
+	p.refresh()
+}
+
+func (p *gradebookPanel) unassign() {
+	group, ok := p.selectedGroup()
+	item := p.assignments.CurrentItem()
+	if !ok || item == nil {
+		return
+	}
+	if err := p.client.Unassign(group, item.Text()); err != nil {
+		p.status.SetText(err.Error())
+		return
+	}
+	p.refresh()
+}
+
+// export saves the gradebook as CSV or, for extension ".ods", as an
+// OpenDocument spreadsheet
+func (p *gradebookPanel) export(filter, extension string) {
+	calculator := p.calculator()
+	if p.gradebook == nil || calculator == nil {
+		return
+	}
+	name := p.gradebook.Group
+	if name == "" {
+		name = "class"
+	}
+	path := qt.QFileDialog_GetSaveFileName4(p.QWidget, "Export Gradebook", name+extension, filter)
+	if path == "" {
+		return
+	}
+	if filepath.Ext(path) == "" {
+		path += extension
+	}
+	var data bytes.Buffer
+	var err error
+	if extension == ".ods" {
+		err = p.gradebook.WriteODS(&data, calculator)
+	} else {
+		err = p.gradebook.WriteCSV(&data, calculator)
+	}
+	if err == nil {
+		err = lesson.WriteFileAtomically(path, data.Bytes(), 0600)
+	}
+	if err != nil {
+		p.status.SetText(err.Error())
+		return
+	}
+	p.status.SetText("Exported to " + path)
+}
@@ -0,0 +1,193 @@
+package teacherpanel
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/classroom"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	studentsview "github.com/LaPingvino/recuerdo/internal/modules/interfaces/qt/testMode/studentsView"
+	"github.com/mappu/miqt/qt"
+)
+
+// livePanel is the dialog where the teacher pushes a lesson as a test to
+// the students connected on the LAN, watches their answers come in and
+// locks the test when time is up
+type livePanel struct {
+	*qt.QDialog
+	conn       *classroom.LiveConn
+	messages   chan classroom.LiveMessage
+	lessonData *lesson.LessonData
+	deadline   *time.Time
+	locked     bool
+	view       *studentsview.View
+	lessonName *qt.QLabel
+	minutes    *qt.QSpinBox
+	push       *qt.QPushButton
+	lock       *qt.QPushButton
+	pin        *qt.QLabel
+	status     *qt.QLabel
+}
+
+func newLivePanel(parent *qt.QWidget, client *classroom.Client) (*livePanel, error) {
+	conn, err := client.Live("", "")
+	if err != nil {
+		return nil, err
+	}
+	p := &livePanel{QDialog: qt.NewQDialog(parent), conn: conn, messages: receiveLive(conn)}
+	p.SetWindowTitle("Live Test")
+	p.SetModal(true)
+	p.Resize(640, 480)
+
+	layout := qt.NewQVBoxLayout(p.QWidget)
+	p.SetLayout(layout.QLayout)
+
+	testLayout := qt.NewQHBoxLayout2()
+	openButton := qt.NewQPushButton3("Choose Lesson...")
+	openButton.OnClicked(p.chooseLesson)
+	testLayout.AddWidget(openButton.QWidget)
+	p.lessonName = qt.NewQLabel3("No lesson chosen")
+	testLayout.AddWidget(p.lessonName.QWidget)
+	testLayout.AddStretch()
+	timeLabel := qt.NewQLabel3("Time:")
+	testLayout.AddWidget(timeLabel.QWidget)
+	p.minutes = qt.NewQSpinBox(p.QWidget)
+	p.minutes.SetRange(0, 240)
+	p.minutes.SetValue(15)
+	p.minutes.SetSuffix(" min")
+	p.minutes.SetSpecialValueText("Until locked")
+	timeLabel.SetBuddy(p.minutes.QWidget)
+	testLayout.AddWidget(p.minutes.QWidget)
+	p.push = qt.NewQPushButton3("Push Test")
+	p.push.SetToolTip("Send the questions of the lesson to every student connected")
+	p.push.SetEnabled(false)
+	p.push.OnClicked(p.pushTest)
+	testLayout.AddWidget(p.push.QWidget)
+	p.lock = qt.NewQPushButton3("Lock Now")
+	p.lock.SetToolTip("Stop taking answers and hand in the results")
+	p.lock.SetEnabled(false)
+	p.lock.OnClicked(func() { p.send(classroom.LiveMessage{Type: classroom.LiveLock}) })
+	testLayout.AddWidget(p.lock.QWidget)
+	layout.AddLayout(testLayout.QLayout)
+
+	// Students find the session on the network and join with this PIN, so
+	// it is big enough to read from the back of the room
+	p.pin = qt.NewQLabel3("")
+	pinFont := p.pin.Font()
+	pinFont.SetPointSize(pinFont.PointSize() * 2)
+	pinFont.SetBold(true)
+	p.pin.SetFont(pinFont)
+	p.pin.SetAlignment(qt.AlignCenter)
+	layout.AddWidget(p.pin.QWidget)
+
+	p.view = studentsview.NewView(p.QWidget)
+	layout.AddWidget(p.view.QWidget)
+
+	p.status = qt.NewQLabel3("Waiting for students to connect...")
+	layout.AddWidget(p.status.QWidget)
+
+	closeBox := qt.NewQDialogButtonBox(p.QWidget)
+	closeBox.SetStandardButtons(qt.QDialogButtonBox__Close)
+	closeBox.OnRejected(p.Reject)
+	layout.AddWidget(closeBox.QWidget)
+
+	// Messages arrive on another goroutine, so they are taken in here
+	timer := qt.NewQTimer2(p.QObject)
+	timer.OnTimeout(p.poll)
+	timer.Start(200)
+	p.OnFinished(func(int) {
+		timer.Stop()
+		p.conn.Close()
+	})
+	return p, nil
+}
+
+// chooseLesson loads the lesson to push as a test
+func (p *livePanel) chooseLesson() {
+	path := qt.QFileDialog_GetOpenFileName4(p.QWidget, "Choose Lesson", "", "All Files (*)")
+	if path == "" {
+		return
+	}
+	lessonData, err := lesson.NewFileLoader().LoadFile(path)
+	if err != nil {
+		p.status.SetText(err.Error())
+		return
+	}
+	p.lessonData = lessonData
+	p.lessonName.SetText(fmt.Sprintf("%s (%d items)", lessonData.List.Title, len(lessonData.List.Items)))
+	p.push.SetEnabled(len(lessonData.List.Items) > 0)
+}
+
+func (p *livePanel) pushTest() {
+	if p.lessonData == nil {
+		return
+	}
+	p.send(classroom.LiveMessage{Type: classroom.LiveStart, Lesson: p.lessonData, Minutes: p.minutes.Value()})
+}
+
+func (p *livePanel) send(message classroom.LiveMessage) {
+	if err := p.conn.Send(message); err != nil {
+		p.status.SetText(fmt.Sprintf("Lost the connection to the server: %v", err))
+	}
+}
+
+// poll takes in the messages that arrived and counts down to the deadline
+func (p *livePanel) poll() {
+	for drained := false; !drained; {
+		select {
+		case message, ok := <-p.messages:
+			if !ok {
+				p.status.SetText("The server closed the live test channel")
+				p.push.SetEnabled(false)
+				p.lock.SetEnabled(false)
+				p.messages = nil
+				return
+			}
+			p.handle(message)
+		default:
+			drained = true
+		}
+	}
+	if p.deadline != nil && !p.locked {
+		left := time.Until(*p.deadline).Round(time.Second)
+		if left < 0 {
+			left = 0
+		}
+		p.status.SetText(fmt.Sprintf("%d of %d students done, %s left", p.view.Answered(), p.view.RowCount(), left))
+	}
+}
+
+func (p *livePanel) handle(message classroom.LiveMessage) {
+	p.view.Update(message)
+	switch message.Type {
+	case classroom.LiveSession:
+		p.pin.SetText(fmt.Sprintf("Session PIN: %s", message.PIN))
+	case classroom.LiveTest:
+		p.deadline = message.Test.Deadline
+		p.locked = false
+		p.lock.SetEnabled(true)
+		p.status.SetText(fmt.Sprintf("Pushed %s to the students", message.Test.Title))
+	case classroom.LiveLocked:
+		p.locked = true
+		p.lock.SetEnabled(false)
+		p.status.SetText(fmt.Sprintf("Locked; %d of %d students answered every item. The results are on the roster.", p.view.Answered(), p.view.RowCount()))
+	case classroom.LiveError:
+		p.status.SetText(message.Error)
+	}
+}
+
+// receiveLive passes on the messages of a live connection until it closes
+func receiveLive(conn *classroom.LiveConn) chan classroom.LiveMessage {
+	messages := make(chan classroom.LiveMessage, 64)
+	go func() {
+		defer close(messages)
+		for {
+			message, err := conn.Receive()
+			if err != nil {
+				return
+			}
+			messages <- message
+		}
+	}()
+	return messages
+}
@@ -1,73 +1,349 @@
-// Package teacherpanel provides functionality ported from Python module
-//
-// This is an automated port - implementation may be incomplete.
+// Package teacherpanel shows the teacher the class roster kept by the
+// classroom server: the students, their join codes, the results they
+// handed in and the questions they asked about lesson items. From it the
+// teacher runs live tests on the students connected, assigns lessons to
+// groups and keeps a gradebook of their notes.
 package teacherpanel
 
 import (
 	"context"
 	"fmt"
+	"os"
 
+	"github.com/LaPingvino/recuerdo/internal/classroom"
 	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	notecalculatorchooser "github.com/LaPingvino/recuerdo/internal/modules/logic/noteCalculatorChooser"
+	syncclient "github.com/LaPingvino/recuerdo/internal/modules/logic/syncClient"
+	"github.com/mappu/miqt/qt"
 )
 
-// TestModeTeacherPanelModule is a Go port of the Python TestModeTeacherPanelModule class
+// Settings is the part of the settings module the panel reads the server
+// URL from
+type Settings interface {
+	GetString(key string) (string, error)
+}
+
+// NoteCalculatorChooser is the part of the note calculator chooser the
+// gradebook grades results with
+type NoteCalculatorChooser interface {
+	Notecalculators() []notecalculatorchooser.NoteCalculator
+	Notecalculator() notecalculatorchooser.NoteCalculator
+	Choose(name string) error
+}
+
+// TestModeTeacherPanelModule shows the class roster of the classroom server
 type TestModeTeacherPanelModule struct {
 	*core.BaseModule
 	manager *core.Manager
-	// TODO: Add module-specific fields
 }
 
 // NewTestModeTeacherPanelModule creates a new TestModeTeacherPanelModule instance
 func NewTestModeTeacherPanelModule() *TestModeTeacherPanelModule {
-	base := core.NewBaseModule("ui", "teacherpanel-module")
+	base := core.NewBaseModule("teacherPanel", "teacherpanel-module")
 
 	return &TestModeTeacherPanelModule{
 		BaseModule: base,
 	}
 }
 
-// retranslate is the Go port of the Python _retranslate method
-func (mod *TestModeTeacherPanelModule) retranslate() {
-	// TODO: Port Python method logic
+// server returns the URL of the classroom server, which is the lesson
+// server "recuerdo serve" runs, and the token it asks for
+func (mod *TestModeTeacherPanelModule) server() (string, string) {
+	if mod.manager == nil {
+		return "", ""
+	}
+	module, ok := mod.manager.GetDefaultModule("settings")
+	if !ok {
+		return "", ""
+	}
+	settings, ok := module.(Settings)
+	if !ok {
+		return "", ""
+	}
+	server, _ := settings.GetString(syncclient.ServerSetting)
+	token, _ := settings.GetString(syncclient.TokenSetting)
+	return server, token
+}
+
+// Showpanel shows the class roster in a dialog over parent
+func (mod *TestModeTeacherPanelModule) Showpanel(parent *qt.QWidget) {
+	server, token := mod.server()
+	if server == "" {
+		qt.QMessageBox_Warning(parent, "Class Roster", fmt.Sprintf("No classroom server is configured. Set %q in the settings to the address of \"recuerdo serve\".", syncclient.ServerSetting))
+		return
+	}
+	client := classroom.NewClient(server)
+	client.SetToken(token)
+	newRosterPanel(parent, client, mod.chooser()).Exec()
+}
+
+// chooser returns the note calculator chooser, or nil when there is none
+func (mod *TestModeTeacherPanelModule) chooser() NoteCalculatorChooser {
+	if mod.manager == nil {
+		return nil
+	}
+	module, ok := mod.manager.GetDefaultModule("noteCalculatorChooser")
+	if !ok {
+		return nil
+	}
+	chooser, _ := module.(NoteCalculatorChooser)
+	return chooser
+}
+
+// rosterPanel is the dialog listing the students and the results of the
+// selected one
+type rosterPanel struct {
+	*qt.QDialog
+	client   *classroom.Client
+	chooser  NoteCalculatorChooser
+	students []classroom.Student
+	table    *qt.QTableWidget
+	history  *qt.QTableWidget
+	status   *qt.QLabel
 }
 
-// Showpanel is the Go port of the Python showPanel method
-func (mod *TestModeTeacherPanelModule) Showpanel() {
-	// TODO: Port Python method logic
+func newRosterPanel(parent *qt.QWidget, client *classroom.Client, chooser NoteCalculatorChooser) *rosterPanel {
+	p := &rosterPanel{QDialog: qt.NewQDialog(parent), client: client, chooser: chooser}
+	p.SetWindowTitle("Class Roster")
+	p.SetModal(true)
+	p.Resize(640, 520)
+
+	layout := qt.NewQVBoxLayout(p.QWidget)
+	p.SetLayout(layout.QLayout)
+
+	p.table = qt.NewQTableWidget(p.QWidget)
+	p.table.SetColumnCount(3)
+	p.table.SetHorizontalHeaderLabels([]string{"Name", "Group", "Join code"})
+	p.table.SetSelectionBehavior(qt.QAbstractItemView__SelectRows)
+	p.table.SetSelectionMode(qt.QAbstractItemView__SingleSelection)
+	p.table.SetEditTriggers(qt.QAbstractItemView__NoEditTriggers)
+	p.table.HorizontalHeader().SetStretchLastSection(true)
+	p.table.OnItemSelectionChanged(p.showHistory)
+	layout.AddWidget(p.table.QWidget)
+
+	buttons := qt.NewQHBoxLayout2()
+	addButton := qt.NewQPushButton3("Add...")
+	addButton.OnClicked(p.addStudent)
+	buttons.AddWidget(addButton.QWidget)
+	importButton := qt.NewQPushButton3("Import CSV...")
+	importButton.OnClicked(p.importCSV)
+	buttons.AddWidget(importButton.QWidget)
+	codeButton := qt.NewQPushButton3("New Join Code")
+	codeButton.OnClicked(p.newJoinCode)
+	buttons.AddWidget(codeButton.QWidget)
+	exportButton := qt.NewQPushButton3("Export Data...")
+	exportButton.SetToolTip("Save everything stored about the selected student as a zip")
+	exportButton.OnClicked(p.exportStudent)
+	buttons.AddWidget(exportButton.QWidget)
+	removeButton := qt.NewQPushButton3("Remove")
+	removeButton.OnClicked(p.removeStudent)
+	buttons.AddWidget(removeButton.QWidget)
+	buttons.AddStretch()
+	questionsButton := qt.NewQPushButton3("Questions...")
+	questionsButton.SetToolTip("Read and answer the questions students asked about lesson items")
+	questionsButton.OnClicked(func() { newThreadsPanel(p.QWidget, p.client).Exec() })
+	buttons.AddWidget(questionsButton.QWidget)
+	gradebookButton := qt.NewQPushButton3("Gradebook...")
+	gradebookButton.SetToolTip("Assign lessons to a group and see the notes of its students")
+	gradebookButton.OnClicked(p.showGradebook)
+	gradebookButton.SetEnabled(chooser != nil)
+	buttons.AddWidget(gradebookButton.QWidget)
+	liveButton := qt.NewQPushButton3("Live Test...")
+	liveButton.SetToolTip("Push a test to the students connected and watch their answers come in")
+	liveButton.OnClicked(p.showLiveTest)
+	buttons.AddWidget(liveButton.QWidget)
+	auditButton := qt.NewQPushButton3("Audit Log...")
+	auditButton.SetToolTip("See who changed the roster and recorded results; needs an admin token")
+	auditButton.OnClicked(func() { newAuditPanel(p.QWidget, p.client).Exec() })
+	buttons.AddWidget(auditButton.QWidget)
+	layout.AddLayout(buttons.QLayout)
+
+	historyLabel := qt.NewQLabel3("Results of the selected student:")
+	layout.AddWidget(historyLabel.QWidget)
+	p.history = qt.NewQTableWidget(p.QWidget)
+	p.history.SetColumnCount(3)
+	p.history.SetHorizontalHeaderLabels([]string{"Lesson", "Date", "Score"})
+	p.history.SetEditTriggers(qt.QAbstractItemView__NoEditTriggers)
+	p.history.HorizontalHeader().SetStretchLastSection(true)
+	layout.AddWidget(p.history.QWidget)
+
+	p.status = qt.NewQLabel(p.QWidget)
+	layout.AddWidget(p.status.QWidget)
+
+	closeBox := qt.NewQDialogButtonBox(p.QWidget)
+	closeBox.SetStandardButtons(qt.QDialogButtonBox__Close)
+	closeBox.OnRejected(p.Reject)
+	layout.AddWidget(closeBox.QWidget)
+
+	p.refresh()
+	return p
+}
+
+// refresh reloads the students from the server
+func (p *rosterPanel) refresh() {
+	students, err := p.client.Students()
+	if err != nil {
+		p.status.SetText(err.Error())
+		return
+	}
+	p.students = students
+	p.table.ClearContents()
+	p.table.SetRowCount(len(students))
+	for row, student := range students {
+		p.table.SetItem(row, 0, qt.NewQTableWidgetItem2(student.Name))
+		p.table.SetItem(row, 1, qt.NewQTableWidgetItem2(student.Group))
+		p.table.SetItem(row, 2, qt.NewQTableWidgetItem2(student.JoinCode))
+	}
+	p.status.SetText(fmt.Sprintf("%d students", len(students)))
+	p.showHistory()
 }
 
-// ShowpanelAlt is the Go port of the Python showPanel_ method
-func (mod *TestModeTeacherPanelModule) ShowpanelAlt() {
-	// TODO: Port Python method logic
+// selected returns the selected student
+func (p *rosterPanel) selected() (classroom.Student, bool) {
+	row := p.table.CurrentRow()
+	if row < 0 || row >= len(p.students) {
+		return classroom.Student{}, false
+	}
+	return p.students[row], true
 }
 
-// Showmessage is the Go port of the Python showMessage method
-func (mod *TestModeTeacherPanelModule) Showmessage() {
-	// TODO: Port Python method logic
+func (p *rosterPanel) showHistory() {
+	p.history.ClearContents()
+	p.history.SetRowCount(0)
+	student, ok := p.selected()
+	if !ok {
+		return
+	}
+	record, err := p.client.Student(student.ID)
+	if err != nil {
+		p.status.SetText(err.Error())
+		return
+	}
+	p.history.SetRowCount(len(record.Results))
+	for row, result := range record.Results {
+		p.history.SetItem(row, 0, qt.NewQTableWidgetItem2(result.Lesson))
+		p.history.SetItem(row, 1, qt.NewQTableWidgetItem2(result.Time.Local().Format("2006-01-02 15:04")))
+		p.history.SetItem(row, 2, qt.NewQTableWidgetItem2(fmt.Sprintf("%d/%d (%.0f%%)", result.Right, result.Right+result.Wrong, result.Percentage())))
+	}
+}
+
+func (p *rosterPanel) showLiveTest() {
+	panel, err := newLivePanel(p.QWidget, p.client)
+	if err != nil {
+		p.status.SetText(err.Error())
+		return
+	}
+	panel.Exec()
+	p.refresh()
+}
+
+func (p *rosterPanel) showGradebook() {
+	newGradebookPanel(p.QWidget, p.client, p.chooser).Exec()
+}
+
+func (p *rosterPanel) addStudent() {
+	ok := false
+	name := qt.QInputDialog_GetText4(p.QWidget, "Add Student", "Name:", qt.QLineEdit__Normal, "", &ok)
+	if !ok || name == "" {
+		return
+	}
+	group := qt.QInputDialog_GetText4(p.QWidget, "Add Student", "Group (optional):", qt.QLineEdit__Normal, "", &ok)
+	if !ok {
+		return
+	}
+	if _, err := p.client.Add(name, group); err != nil {
+		p.status.SetText(err.Error())
+		return
+	}
+	p.refresh()
+}
+
+func (p *rosterPanel) importCSV() {
+	path := qt.QFileDialog_GetOpenFileName4(p.QWidget, "Import Students", "", "CSV files (*.csv);;All files (*)")
+	if path == "" {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		p.status.SetText(err.Error())
+		return
+	}
+	added, err := p.client.ImportCSV(data)
+	if err != nil {
+		p.status.SetText(err.Error())
+		return
+	}
+	p.refresh()
+	p.status.SetText(fmt.Sprintf("Imported %d students", len(added)))
+}
+
+func (p *rosterPanel) newJoinCode() {
+	student, ok := p.selected()
+	if !ok {
+		return
+	}
+	if _, err := p.client.NewJoinCode(student.ID); err != nil {
+		p.status.SetText(err.Error())
+		return
+	}
+	p.refresh()
+}
+
+// exportStudent saves what the server stores about the selected student,
+// for a student asking for their data
+func (p *rosterPanel) exportStudent() {
+	student, ok := p.selected()
+	if !ok {
+		return
+	}
+	path := qt.QFileDialog_GetSaveFileName4(p.QWidget, "Export Student Data", student.Name+".zip", "Zip archives (*.zip)")
+	if path == "" {
+		return
+	}
+	data, err := p.client.Export(student.ID)
+	if err == nil {
+		err = lesson.WriteFileAtomically(path, data, 0600)
+	}
+	if err != nil {
+		p.status.SetText(err.Error())
+		return
+	}
+	p.status.SetText("Exported to " + path)
+}
+
+func (p *rosterPanel) removeStudent() {
+	student, ok := p.selected()
+	if !ok {
+		return
+	}
+	question := fmt.Sprintf("Remove %s from the roster? Their results and the questions they asked are deleted from the server.", student.Name)
+	if qt.QMessageBox_Question(p.QWidget, "Remove Student", question) != qt.QMessageBox__Yes {
+		return
+	}
+	if err := p.client.Remove(student.ID); err != nil {
+		p.status.SetText(err.Error())
+		return
+	}
+	p.refresh()
 }
 
 // Enable activates the module
-// This is the Go equivalent of the Python enable method
 func (mod *TestModeTeacherPanelModule) Enable(ctx context.Context) error {
 	if err := mod.BaseModule.Enable(ctx); err != nil {
 		return err
 	}
 
-	// TODO: Port Python enable logic
-
 	fmt.Println("TestModeTeacherPanelModule enabled")
 	return nil
 }
 
 // Disable deactivates the module
-// This is the Go equivalent of the Python disable method
 func (mod *TestModeTeacherPanelModule) Disable(ctx context.Context) error {
 	if err := mod.BaseModule.Disable(ctx); err != nil {
 		return err
 	}
 
-	// TODO: Port Python disable logic
-
 	fmt.Println("TestModeTeacherPanelModule disabled")
 	return nil
 }
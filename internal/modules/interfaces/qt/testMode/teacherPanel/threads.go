@@ -0,0 +1,163 @@
+package teacherpanel
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/classroom"
+	"github.com/mappu/miqt/qt"
+)
+
+// threadsPanel is the dialog where the teacher reads and answers the
+// questions students asked about lesson items
+type threadsPanel struct {
+	*qt.QDialog
+	client     *classroom.Client
+	author     string
+	threads    []classroom.Thread
+	table      *qt.QTableWidget
+	transcript *qt.QTextEdit
+	resolve    *qt.QPushButton
+	status     *qt.QLabel
+}
+
+func newThreadsPanel(parent *qt.QWidget, client *classroom.Client) *threadsPanel {
+	p := &threadsPanel{QDialog: qt.NewQDialog(parent), client: client, author: "Teacher"}
+	p.SetWindowTitle("Student Questions")
+	p.SetModal(true)
+	p.Resize(720, 560)
+
+	layout := qt.NewQVBoxLayout(p.QWidget)
+	p.SetLayout(layout.QLayout)
+
+	p.table = qt.NewQTableWidget(p.QWidget)
+	p.table.SetColumnCount(4)
+	p.table.SetHorizontalHeaderLabels([]string{"Lesson", "Word", "Status", "Last message"})
+	p.table.SetSelectionBehavior(qt.QAbstractItemView__SelectRows)
+	p.table.SetSelectionMode(qt.QAbstractItemView__SingleSelection)
+	p.table.SetEditTriggers(qt.QAbstractItemView__NoEditTriggers)
+	p.table.HorizontalHeader().SetStretchLastSection(true)
+	p.table.OnItemSelectionChanged(p.showThread)
+	layout.AddWidget(p.table.QWidget)
+
+	p.transcript = qt.NewQTextEdit(p.QWidget)
+	p.transcript.SetReadOnly(true)
+	layout.AddWidget(p.transcript.QWidget)
+
+	buttons := qt.NewQHBoxLayout2()
+	replyButton := qt.NewQPushButton3("Reply...")
+	replyButton.OnClicked(p.reply)
+	buttons.AddWidget(replyButton.QWidget)
+	p.resolve = qt.NewQPushButton3("Mark Resolved")
+	p.resolve.OnClicked(p.toggleResolved)
+	buttons.AddWidget(p.resolve.QWidget)
+	refreshButton := qt.NewQPushButton3("Refresh")
+	refreshButton.OnClicked(p.refresh)
+	buttons.AddWidget(refreshButton.QWidget)
+	buttons.AddStretch()
+	layout.AddLayout(buttons.QLayout)
+
+	p.status = qt.NewQLabel(p.QWidget)
+	layout.AddWidget(p.status.QWidget)
+
+	closeBox := qt.NewQDialogButtonBox(p.QWidget)
+	closeBox.SetStandardButtons(qt.QDialogButtonBox__Close)
+	closeBox.OnRejected(p.Reject)
+	layout.AddWidget(closeBox.QWidget)
+
+	p.refresh()
+	return p
+}
+
+// refresh reloads the threads of all lessons from the server, keeping the
+// selected one selected
+func (p *threadsPanel) refresh() {
+	selected, hadSelection := p.selected()
+	threads, err := p.client.Threads()
+	if err != nil {
+		p.status.SetText(err.Error())
+		return
+	}
+	p.threads = threads
+	p.table.ClearContents()
+	p.table.SetRowCount(len(threads))
+	waiting := 0
+	for row, thread := range threads {
+		status := "Waiting"
+		switch {
+		case thread.Resolved:
+			status = "Resolved"
+		case thread.Answered():
+			status = "Answered"
+		default:
+			waiting++
+		}
+		last := ""
+		if n := len(thread.Messages); n > 0 {
+			last = fmt.Sprintf("%s: %s", thread.Messages[n-1].Author, strings.Join(strings.Fields(thread.Messages[n-1].Text), " "))
+		}
+		p.table.SetItem(row, 0, qt.NewQTableWidgetItem2(thread.Lesson))
+		p.table.SetItem(row, 1, qt.NewQTableWidgetItem2(thread.Question))
+		p.table.SetItem(row, 2, qt.NewQTableWidgetItem2(status))
+		p.table.SetItem(row, 3, qt.NewQTableWidgetItem2(last))
+		if hadSelection && thread.Lesson == selected.Lesson && thread.ItemID == selected.ItemID {
+			p.table.SelectRow(row)
+		}
+	}
+	p.status.SetText(fmt.Sprintf("%d questions, %d waiting for an answer", len(threads), waiting))
+	p.showThread()
+}
+
+// selected returns the selected thread
+func (p *threadsPanel) selected() (classroom.Thread, bool) {
+	row := p.table.CurrentRow()
+	if row < 0 || row >= len(p.threads) {
+		return classroom.Thread{}, false
+	}
+	return p.threads[row], true
+}
+
+func (p *threadsPanel) showThread() {
+	thread, ok := p.selected()
+	if !ok {
+		p.transcript.SetPlainText("")
+		p.resolve.SetText("Mark Resolved")
+		return
+	}
+	p.transcript.SetPlainText(thread.Transcript())
+	if thread.Resolved {
+		p.resolve.SetText("Reopen")
+	} else {
+		p.resolve.SetText("Mark Resolved")
+	}
+}
+
+func (p *threadsPanel) reply() {
+	thread, ok := p.selected()
+	if !ok {
+		return
+	}
+	answered := false
+	text := qt.QInputDialog_GetMultiLineText3(p.QWidget, "Reply", fmt.Sprintf("Answer about %q:", thread.Question), "", &answered)
+	if !answered || strings.TrimSpace(text) == "" {
+		return
+	}
+	post := classroom.ThreadPost{Message: classroom.Message{Author: p.author, Teacher: true, Text: text}}
+	if _, err := p.client.Post(thread.Lesson, thread.ItemID, post); err != nil {
+		p.status.SetText(err.Error())
+		return
+	}
+	p.refresh()
+}
+
+func (p *threadsPanel) toggleResolved() {
+	thread, ok := p.selected()
+	if !ok {
+		return
+	}
+	if _, err := p.client.Resolve(thread.Lesson, thread.ItemID, !thread.Resolved); err != nil {
+		p.status.SetText(err.Error())
+		return
+	}
+	p.refresh()
+}
@@ -0,0 +1,178 @@
+package theme
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Feedback is the kind of feedback given on an answer
+type Feedback int
+
+// Kinds of feedback on an answer
+const (
+	FeedbackRight Feedback = iota
+	FeedbackWrong
+	// FeedbackAlmost is for answers that earned part of the credit, such
+	// as the right word with the wrong article
+	FeedbackAlmost
+)
+
+// PaletteSetting is the settings key holding the name of the palette
+const PaletteSetting = "theme.feedbackPalette"
+
+// Names of the palettes
+const (
+	PaletteStandard   = "standard"
+	PaletteColorblind = "colorblind"
+)
+
+// FeedbackColors are the colours of one kind of feedback, as CSS colours
+type FeedbackColors struct {
+	Text       string
+	Background string
+}
+
+// Palette holds the colours answer feedback is shown in. Colour is never
+// the only difference: every kind also has its own icon and border.
+type Palette struct {
+	Name   string
+	Title  string
+	Right  FeedbackColors
+	Wrong  FeedbackColors
+	Almost FeedbackColors
+}
+
+// Palettes are the palettes to choose from, the default first
+var Palettes = []Palette{
+	{
+		Name:   PaletteStandard,
+		Title:  "Green and red",
+		Right:  FeedbackColors{Text: "#2e7d32", Background: "#c8e6c9"},
+		Wrong:  FeedbackColors{Text: "#c62828", Background: "#ffcdd2"},
+		Almost: FeedbackColors{Text: "#e65100", Background: "#fff9c4"},
+	},
+	{
+		// The blue, vermillion and orange of the Okabe-Ito palette,
+		// darkened to be readable on their backgrounds
+		Name:   PaletteColorblind,
+		Title:  "Blue and orange (colorblind-safe)",
+		Right:  FeedbackColors{Text: "#005a9c", Background: "#d4e8f7"},
+		Wrong:  FeedbackColors{Text: "#a63d00", Background: "#fadcc9"},
+		Almost: FeedbackColors{Text: "#7a5200", Background: "#fcedc8"},
+	},
+}
+
+var (
+	currentPalette      = Palettes[0]
+	currentPaletteMutex sync.RWMutex
+)
+
+// CurrentPalette returns the palette answer feedback is shown in
+func CurrentPalette() Palette {
+	currentPaletteMutex.RLock()
+	defer currentPaletteMutex.RUnlock()
+	return currentPalette
+}
+
+// SetPalette shows answer feedback in the palette called name, and reports
+// whether there is one
+func SetPalette(name string) bool {
+	for _, palette := range Palettes {
+		if palette.Name == name {
+			currentPaletteMutex.Lock()
+			defer currentPaletteMutex.Unlock()
+			currentPalette = palette
+			return true
+		}
+	}
+	return false
+}
+
+// Colors returns the colours of a kind of feedback in the current palette
+func Colors(kind Feedback) FeedbackColors {
+	palette := CurrentPalette()
+	switch kind {
+	case FeedbackWrong:
+		return palette.Wrong
+	case FeedbackAlmost:
+		return palette.Almost
+	}
+	return palette.Right
+}
+
+// Icon returns the symbol telling a kind of feedback apart without colour
+func Icon(kind Feedback) string {
+	switch kind {
+	case FeedbackWrong:
+		return "✘"
+	case FeedbackAlmost:
+		return "≈"
+	}
+	return "✔"
+}
+
+// Label returns text preceded by the icon of its kind of feedback
+func Label(kind Feedback, text string) string {
+	return Icon(kind) + " " + text
+}
+
+// borderStyle returns the border pattern of a kind of feedback
+func borderStyle(kind Feedback) string {
+	switch kind {
+	case FeedbackWrong:
+		return "dashed"
+	case FeedbackAlmost:
+		return "dotted"
+	}
+	return "solid"
+}
+
+// Hatched reports whether table cells of a kind of feedback are hatched
+// rather than filled
+func Hatched(kind Feedback) bool {
+	return kind == FeedbackWrong
+}
+
+// Style returns a style sheet showing a kind of feedback in the current
+// palette, with a border whose pattern tells the kinds apart; base holds
+// the rest of the style, such as the font size
+func Style(kind Feedback, base string) string {
+	colors := Colors(kind)
+	return fmt.Sprintf("%scolor: %s; background-color: %s; border: 2px %s %s; border-radius: 3px; font-weight: bold;",
+		base, colors.Text, colors.Background, borderStyle(kind), colors.Text)
+}
+
+// TextStyle returns an inline style for rich text showing a kind of
+// feedback; wrong answers are struck through
+func TextStyle(kind Feedback) string {
+	style := fmt.Sprintf("color: %s; font-weight: bold;", Colors(kind).Text)
+	if kind == FeedbackWrong {
+		style += " text-decoration: line-through;"
+	}
+	return style
+}
+
+// PaletteSettings is the part of the settings module the palette is read
+// from and written to
+type PaletteSettings interface {
+	GetSettingWithDefault(key string, defaultValue interface{}) interface{}
+	SetSetting(key string, value interface{}) error
+}
+
+// LoadPalette shows feedback in the palette stored in settings and returns
+// its name; an unknown palette leaves the current one
+func LoadPalette(settings PaletteSettings) string {
+	if name, ok := settings.GetSettingWithDefault(PaletteSetting, "").(string); ok {
+		SetPalette(name)
+	}
+	return CurrentPalette().Name
+}
+
+// SavePalette shows feedback in the palette called name and stores it in
+// settings
+func SavePalette(settings PaletteSettings, name string) error {
+	if !SetPalette(name) {
+		return fmt.Errorf("unknown feedback palette %q", name)
+	}
+	return settings.SetSetting(PaletteSetting, name)
+}
@@ -0,0 +1,67 @@
+package theme
+
+import (
+	"strings"
+	"testing"
+)
+
+type mapSettings map[string]interface{}
+
+func (s mapSettings) GetSettingWithDefault(key string, defaultValue interface{}) interface{} {
+	if value, ok := s[key]; ok {
+		return value
+	}
+	return defaultValue
+}
+
+func (s mapSettings) SetSetting(key string, value interface{}) error {
+	s[key] = value
+	return nil
+}
+
+func TestFeedbackWithoutColor(t *testing.T) {
+	kinds := []Feedback{FeedbackRight, FeedbackWrong, FeedbackAlmost}
+	icons := make(map[string]bool)
+	borders := make(map[string]bool)
+	for _, kind := range kinds {
+		icons[Icon(kind)] = true
+		borders[borderStyle(kind)] = true
+		if label := Label(kind, "maison"); !strings.HasPrefix(label, Icon(kind)) {
+			t.Errorf("label %q lacks its icon", label)
+		}
+	}
+	if len(icons) != len(kinds) || len(borders) != len(kinds) {
+		t.Errorf("kinds of feedback share icons %v or borders %v", icons, borders)
+	}
+}
+
+func TestPalettes(t *testing.T) {
+	defer SetPalette(CurrentPalette().Name)
+
+	if SetPalette("sepia") {
+		t.Error("set an unknown palette")
+	}
+	settings := mapSettings{PaletteSetting: PaletteColorblind}
+	if name := LoadPalette(settings); name != PaletteColorblind {
+		t.Fatalf("loaded the %s palette", name)
+	}
+	style := Style(FeedbackRight, "font-size: 14px; ")
+	if !strings.HasPrefix(style, "font-size: 14px; ") || !strings.Contains(style, Colors(FeedbackRight).Text) {
+		t.Errorf("style %q", style)
+	}
+	for _, palette := range Palettes {
+		if palette.Right.Text == palette.Wrong.Text {
+			t.Errorf("the %s palette shows right and wrong alike", palette.Name)
+		}
+	}
+
+	if err := SavePalette(settings, "sepia"); err == nil {
+		t.Error("saved an unknown palette")
+	}
+	if err := SavePalette(settings, PaletteStandard); err != nil || settings[PaletteSetting] != PaletteStandard {
+		t.Errorf("saved %v: %v", settings[PaletteSetting], err)
+	}
+	if CurrentPalette().Name != PaletteStandard {
+		t.Error("saving did not switch the palette")
+	}
+}
@@ -39,6 +39,14 @@ func (mod *ThemeModule) Enable(ctx context.Context) error {
 
 	// TODO: Port Python enable logic
 
+	if mod.manager != nil {
+		if module, ok := mod.manager.GetDefaultModule("settings"); ok {
+			if settings, ok := module.(PaletteSettings); ok {
+				LoadPalette(settings)
+			}
+		}
+	}
+
 	fmt.Println("ThemeModule enabled")
 	return nil
 }
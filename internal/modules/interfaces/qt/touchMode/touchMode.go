@@ -0,0 +1,193 @@
+// Package touchmode makes Recuerdo usable on classroom smartboards and
+// other touch screens: it enlarges text and controls, turns swipes into
+// moving between questions and offers an on-screen keyboard.
+package touchmode
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/touch"
+	"github.com/mappu/miqt/qt"
+)
+
+// TouchModeModule provides the touch mode for interactive whiteboards
+type TouchModeModule struct {
+	*core.BaseModule
+	manager *core.Manager
+
+	// forced turns touch mode on regardless of the settings, as the
+	// --touch flag does
+	forced bool
+}
+
+// NewTouchModeModule creates a new TouchModeModule instance
+func NewTouchModeModule() *TouchModeModule {
+	base := core.NewBaseModule("touchMode", "touch-mode-module")
+
+	return &TouchModeModule{
+		BaseModule: base,
+	}
+}
+
+// settings returns the settings module, or nil when there is none
+func (mod *TouchModeModule) settings() interface {
+	GetSettingWithDefault(key string, defaultValue interface{}) interface{}
+} {
+	if mod.manager == nil {
+		return nil
+	}
+	module, ok := mod.manager.GetDefaultModule("settings")
+	if !ok {
+		return nil
+	}
+	settings, _ := module.(interface {
+		GetSettingWithDefault(key string, defaultValue interface{}) interface{}
+	})
+	return settings
+}
+
+// Force turns touch mode on for this run without changing the settings
+func (mod *TouchModeModule) Force() {
+	mod.forced = true
+}
+
+// Enabled reports whether touch mode is on
+func (mod *TouchModeModule) Enabled() bool {
+	if mod.forced {
+		return true
+	}
+	if settings := mod.settings(); settings != nil {
+		enabled, _ := settings.GetSettingWithDefault(touch.Setting, false).(bool)
+		return enabled
+	}
+	return false
+}
+
+// Scale returns how much touch mode enlarges text and controls
+func (mod *TouchModeModule) Scale() float64 {
+	if settings := mod.settings(); settings != nil {
+		if scale, ok := settings.GetSettingWithDefault(touch.ScaleSetting, touch.DefaultScale).(float64); ok && scale >= 1 {
+			return scale
+		}
+	}
+	return touch.DefaultScale
+}
+
+// Apply enlarges the text and controls of the whole application
+func (mod *TouchModeModule) Apply(app *qt.QApplication) {
+	app.SetStyleSheet(app.StyleSheet() + touch.StyleSheet(mod.Scale()))
+}
+
+// WatchSwipes calls onSwipe for every swipe across window. Touch screens
+// deliver swipes as mouse drags, so the press and release of the mouse are
+// compared; both still reach the widgets as usual.
+func (mod *TouchModeModule) WatchSwipes(app *qt.QApplication, window *qt.QWidget, onSwipe func(direction touch.Direction)) {
+	var start *qt.QPoint
+	var startTime time.Time
+
+	filter := qt.NewQObject2(window.QObject)
+	filter.OnEventFilter(func(super func(watched *qt.QObject, event *qt.QEvent) bool, watched *qt.QObject, event *qt.QEvent) bool {
+		switch event.Type() {
+		case qt.QEvent__MouseButtonPress, qt.QEvent__MouseButtonRelease:
+		default:
+			return super(watched, event)
+		}
+		if !watched.IsWidgetType() || qt.UnsafeNewQWidget(watched.UnsafePointer()).Window().UnsafePointer() != window.UnsafePointer() {
+			return super(watched, event)
+		}
+
+		position := qt.UnsafeNewQMouseEvent(event.UnsafePointer()).GlobalPos()
+		if event.Type() == qt.QEvent__MouseButtonPress {
+			// Widgets ignoring the press pass it on to their parent, so the
+			// same press may arrive more than once
+			start, startTime = position, time.Now()
+		} else if start != nil {
+			direction := touch.DetectSwipe(position.X()-start.X(), position.Y()-start.Y(), time.Since(startTime))
+			start = nil
+			if direction != touch.NoSwipe {
+				onSwipe(direction)
+			}
+		}
+		return super(watched, event)
+	})
+	app.InstallEventFilter(filter)
+}
+
+// NewKeyboard creates an on-screen keyboard typing into whatever has the
+// keyboard focus. Its keys never take the focus themselves.
+func (mod *TouchModeModule) NewKeyboard(parent *qt.QWidget) *qt.QWidget {
+	keyboard := qt.NewQWidget(parent)
+	keyboard.SetObjectName("onScreenKeyboard")
+	keyboard.SetAccessibleName("On-screen keyboard")
+	layout := qt.NewQGridLayout(keyboard)
+	layout.SetSpacing(4)
+
+	for row, keys := range touch.KeyboardRows() {
+		column := 0
+		for _, key := range keys {
+			button := qt.NewQPushButton3(key.Label)
+			button.SetFocusPolicy(qt.NoFocus)
+			button.SetAutoRepeat(key == touch.Backspace)
+			pressed := key
+			button.OnClicked(func() {
+				typeKey(pressed)
+			})
+			layout.AddWidget3(button.QWidget, row, column, 1, key.Width)
+			column += key.Width
+		}
+	}
+	return keyboard
+}
+
+// typeKey sends the presses of a key of the on-screen keyboard to the
+// widget with the keyboard focus
+func typeKey(key touch.Key) {
+	target := qt.QApplication_FocusWidget()
+	if target == nil {
+		return
+	}
+	code := 0
+	switch key {
+	case touch.Backspace:
+		code = int(qt.Key_Backspace)
+	case touch.Enter:
+		code = int(qt.Key_Return)
+	}
+	for _, eventType := range []qt.QEvent__Type{qt.QEvent__KeyPress, qt.QEvent__KeyRelease} {
+		event := qt.NewQKeyEvent4(eventType, code, qt.NoModifier, key.Text)
+		qt.QCoreApplication_SendEvent(target.QObject, event.QEvent)
+	}
+}
+
+// Enable activates the module
+func (mod *TouchModeModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("TouchModeModule enabled")
+	return nil
+}
+
+// Disable deactivates the module
+func (mod *TouchModeModule) Disable(ctx context.Context) error {
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("TouchModeModule disabled")
+	return nil
+}
+
+// SetManager sets the module manager
+func (mod *TouchModeModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// InitTouchModeModule creates and returns a new TouchModeModule instance
+func InitTouchModeModule() core.Module {
+	return NewTouchModeModule()
+}
@@ -0,0 +1,408 @@
+// Package ankiconnect sends lessons to a running Anki through the
+// AnkiConnect add-on (https://foosoft.net/projects/anki-connect/), as an
+// alternative to exporting a file and importing it there: the items become
+// notes of a chosen note type in a chosen deck, and their media is copied
+// into Anki's collection.
+//
+// Notes Anki already has in the deck, or that the note type cannot make
+// cards of, are skipped, so sending a lesson again only adds its new items.
+package ankiconnect
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// URLSetting is the settings key holding the address AnkiConnect listens
+// on, DefaultURL when empty
+const URLSetting = "ankiConnect.url"
+
+// KeySetting is the settings key holding the API key AnkiConnect asks for,
+// when one is set in its configuration
+const KeySetting = "ankiConnect.key"
+
+// DeckSetting and ModelSetting are the settings keys holding the deck and
+// note type lessons were last sent to
+const (
+	DeckSetting  = "ankiConnect.deck"
+	ModelSetting = "ankiConnect.model"
+)
+
+// DefaultURL is where AnkiConnect listens unless configured otherwise
+const DefaultURL = "http://127.0.0.1:8765"
+
+// apiVersion is the version of the AnkiConnect API requests are made in
+const apiVersion = 6
+
+// requestTimeout bounds every request; storing a large video in the
+// collection takes a while
+const requestTimeout = 2 * time.Minute
+
+// ErrNotRunning is returned when nothing answers at the address of
+// AnkiConnect
+var ErrNotRunning = errors.New("Anki is not running, or the AnkiConnect add-on is not installed")
+
+// Settings is the part of the settings module the address is read from
+type Settings interface {
+	GetString(key string) (string, error)
+}
+
+// Result tells what sending a lesson to Anki did
+type Result struct {
+	// Added is how many notes were added, and Skipped how many Anki
+	// already had or could not make cards of
+	Added   int
+	Skipped int
+	// Media is how many media files were copied into the collection
+	Media int
+}
+
+// AnkiConnectModule sends lessons to Anki through AnkiConnect
+type AnkiConnectModule struct {
+	*core.BaseModule
+	manager *core.Manager
+	client  *http.Client
+	url     string
+	key     string
+	mu      sync.Mutex
+}
+
+// NewAnkiConnectModule creates a new AnkiConnectModule instance
+func NewAnkiConnectModule() *AnkiConnectModule {
+	base := core.NewBaseModule("ankiConnect", "anki-connect-module")
+
+	return &AnkiConnectModule{
+		BaseModule: base,
+		client:     &http.Client{Timeout: requestTimeout},
+		url:        DefaultURL,
+	}
+}
+
+// SetURL sets the address AnkiConnect listens on, empty for DefaultURL
+func (mod *AnkiConnectModule) SetURL(url string) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	mod.url = strings.TrimSpace(url)
+	if mod.url == "" {
+		mod.url = DefaultURL
+	}
+}
+
+// SetKey sets the API key sent to AnkiConnect, empty for none
+func (mod *AnkiConnectModule) SetKey(key string) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	mod.key = strings.TrimSpace(key)
+}
+
+// Decks returns the names of the decks in Anki, sorted
+func (mod *AnkiConnectModule) Decks(ctx context.Context) ([]string, error) {
+	var decks []string
+	if err := mod.call(ctx, "deckNames", nil, &decks); err != nil {
+		return nil, err
+	}
+	slices.Sort(decks)
+	return decks, nil
+}
+
+// Models returns the names of the note types in Anki, sorted
+func (mod *AnkiConnectModule) Models(ctx context.Context) ([]string, error) {
+	var models []string
+	if err := mod.call(ctx, "modelNames", nil, &models); err != nil {
+		return nil, err
+	}
+	slices.Sort(models)
+	return models, nil
+}
+
+// note is a note as AnkiConnect takes it
+type note struct {
+	DeckName  string            `json:"deckName"`
+	ModelName string            `json:"modelName"`
+	Fields    map[string]string `json:"fields"`
+	Tags      []string          `json:"tags"`
+	Options   noteOptions       `json:"options"`
+}
+
+type noteOptions struct {
+	AllowDuplicate bool   `json:"allowDuplicate"`
+	DuplicateScope string `json:"duplicateScope"`
+}
+
+// Send adds the items of a lesson as notes of model to deck, creating the
+// deck when it does not exist yet. The questions go in the first field of
+// the note type and the answers in the second; comments go in a field
+// called Extra, Comment or Notes, or under the answers when there is none.
+// Media is copied into the collection and shown on the question side.
+// lessonPath is where the lesson was loaded from, which linked media is
+// relative to.
+func (mod *AnkiConnectModule) Send(ctx context.Context, lessonData *lesson.LessonData, lessonPath, deck, model string) (*Result, error) {
+	if strings.TrimSpace(deck) == "" || model == "" {
+		return nil, fmt.Errorf("choose a deck and a note type to send the lesson to")
+	}
+	var fieldNames []string
+	if err := mod.call(ctx, "modelFieldNames", map[string]any{"modelName": model}, &fieldNames); err != nil {
+		return nil, err
+	}
+	if len(fieldNames) < 2 {
+		return nil, fmt.Errorf("the note type %q has fewer than two fields, one for the questions and one for the answers", model)
+	}
+	commentField := slices.IndexFunc(fieldNames, func(name string) bool {
+		switch strings.ToLower(name) {
+		case "extra", "back extra", "comment", "comments", "notes":
+			return true
+		}
+		return false
+	})
+
+	result := &Result{}
+	// media holds the name every media file is stored under in Anki
+	media := make(map[string]string)
+	var notes []note
+	for _, item := range lessonData.List.Items {
+		front := html.EscapeString(strings.Join(item.Questions, ", "))
+		if item.Cloze != "" {
+			front = html.EscapeString(item.Cloze)
+		}
+		back := html.EscapeString(strings.Join(item.Answers, ", "))
+		if filename, remote, ok := item.GetMediaInfo(); ok && filename != "" {
+			stored, seen := media[filename]
+			if !seen {
+				var err error
+				if stored, err = mod.storeMedia(ctx, lessonData, lessonPath, filename, remote); err != nil {
+					return result, err
+				}
+				media[filename] = stored
+				result.Media++
+			}
+			front = joinLines(mediaTag(stored), front)
+		}
+
+		fields := map[string]string{fieldNames[0]: front, fieldNames[1]: back}
+		if comment := html.EscapeString(item.Comment); commentField > 1 {
+			fields[fieldNames[commentField]] = comment
+		} else if comment != "" {
+			fields[fieldNames[1]] = joinLines(back, "<i>"+comment+"</i>")
+		}
+		tags := []string{"recuerdo"}
+		for _, tag := range item.Tags {
+			// Anki separates tags by spaces
+			tags = append(tags, strings.Join(strings.Fields(tag), "_"))
+		}
+		notes = append(notes, note{
+			DeckName:  deck,
+			ModelName: model,
+			Fields:    fields,
+			Tags:      tags,
+			Options:   noteOptions{DuplicateScope: "deck"},
+		})
+	}
+	if len(notes) == 0 {
+		return result, nil
+	}
+
+	if err := mod.call(ctx, "createDeck", map[string]any{"deck": deck}, nil); err != nil {
+		return result, err
+	}
+	// Newer versions of AnkiConnect fail the whole batch over a single
+	// duplicate, so those are left out first
+	var addable []bool
+	if err := mod.call(ctx, "canAddNotes", map[string]any{"notes": notes}, &addable); err != nil {
+		return result, err
+	}
+	var adding []note
+	for i, n := range notes {
+		if i < len(addable) && addable[i] {
+			adding = append(adding, n)
+		}
+	}
+	result.Skipped = len(notes) - len(adding)
+	if len(adding) == 0 {
+		return result, nil
+	}
+	var ids []*int64
+	if err := mod.call(ctx, "addNotes", map[string]any{"notes": adding}, &ids); err != nil {
+		return result, err
+	}
+	for _, id := range ids {
+		if id != nil {
+			result.Added++
+		}
+	}
+	result.Skipped += len(adding) - result.Added
+	return result, nil
+}
+
+// storeMedia copies a media file of the lesson into Anki's collection and
+// returns the name it is stored under there. The name holds a hash of
+// where the file came from, so files of other lessons with the same name
+// are not replaced.
+func (mod *AnkiConnectModule) storeMedia(ctx context.Context, lessonData *lesson.LessonData, lessonPath, filename string, remote bool) (string, error) {
+	base := path.Base(filepath.ToSlash(filename))
+	if remote {
+		base = path.Base(strings.SplitN(filename, "?", 2)[0])
+	}
+	params := map[string]any{}
+	var source []byte
+	if remote {
+		params["url"] = filename
+		source = []byte(filename)
+	} else {
+		resolved, err := lessonData.ResolveMedia(lessonPath, filename, false)
+		if err != nil {
+			return "", err
+		}
+		data, err := os.ReadFile(resolved)
+		if err != nil {
+			return "", fmt.Errorf("failed to read media %s: %w", filename, err)
+		}
+		params["data"] = base64.StdEncoding.EncodeToString(data)
+		source = data
+	}
+	sum := sha256.Sum256(source)
+	params["filename"] = "recuerdo-" + hex.EncodeToString(sum[:4]) + "-" + base
+
+	var stored string
+	if err := mod.call(ctx, "storeMediaFile", params, &stored); err != nil {
+		return "", fmt.Errorf("failed to send media %s to Anki: %w", filename, err)
+	}
+	return stored, nil
+}
+
+// mediaTag returns how a note field shows the media file stored as name
+func mediaTag(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp", ".bmp", ".tif", ".tiff":
+		return `<img src="` + html.EscapeString(name) + `">`
+	}
+	return "[sound:" + name + "]"
+}
+
+// joinLines puts the non-empty parts of a field on lines of their own
+func joinLines(parts ...string) string {
+	var lines []string
+	for _, part := range parts {
+		if part != "" {
+			lines = append(lines, part)
+		}
+	}
+	return strings.Join(lines, "<br>")
+}
+
+// call sends an action to AnkiConnect and decodes its result into result,
+// which may be nil
+func (mod *AnkiConnectModule) call(ctx context.Context, action string, params, result any) error {
+	mod.mu.Lock()
+	url, key := mod.url, mod.key
+	mod.mu.Unlock()
+
+	request := map[string]any{"action": action, "version": apiVersion}
+	if params != nil {
+		request["params"] = params
+	}
+	if key != "" {
+		request["key"] = key
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid AnkiConnect address %q: %w", url, err)
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	response, err := mod.client.Do(httpRequest)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("%w (%v)", ErrNotRunning, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("AnkiConnect answered %s to %s", response.Status, action)
+	}
+
+	var answer struct {
+		Result json.RawMessage `json:"result"`
+		Error  *string         `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(response.Body, 16<<20)).Decode(&answer); err != nil {
+		return fmt.Errorf("invalid answer from AnkiConnect to %s: %w", action, err)
+	}
+	if answer.Error != nil {
+		return fmt.Errorf("Anki refused %s: %s", action, *answer.Error)
+	}
+	if result == nil || len(answer.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(answer.Result, result); err != nil {
+		return fmt.Errorf("invalid answer from AnkiConnect to %s: %w", action, err)
+	}
+	return nil
+}
+
+// Enable activates the module, reading the address and key of AnkiConnect
+// from the settings
+func (mod *AnkiConnectModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	if mod.manager != nil {
+		if module, ok := mod.manager.GetDefaultModule("settings"); ok {
+			if settings, ok := module.(Settings); ok {
+				if url, err := settings.GetString(URLSetting); err == nil {
+					mod.SetURL(url)
+				}
+				if key, err := settings.GetString(KeySetting); err == nil {
+					mod.SetKey(key)
+				}
+			}
+		}
+	}
+
+	fmt.Println("AnkiConnectModule enabled")
+	return nil
+}
+
+// Disable deactivates the module
+func (mod *AnkiConnectModule) Disable(ctx context.Context) error {
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("AnkiConnectModule disabled")
+	return nil
+}
+
+// SetManager sets the module manager
+func (mod *AnkiConnectModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// InitAnkiConnectModule creates and returns a new AnkiConnectModule
+// instance
+func InitAnkiConnectModule() core.Module {
+	return NewAnkiConnectModule()
+}
@@ -0,0 +1,165 @@
+package ankiconnect
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// fakeAnki answers AnkiConnect actions the way Anki does, refusing notes
+// whose first field is already in the deck
+type fakeAnki struct {
+	decks []string
+	notes []note
+	media map[string][]byte
+}
+
+func (f *fakeAnki) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Action  string          `json:"action"`
+		Version int             `json:"version"`
+		Key     string          `json:"key"`
+		Params  json.RawMessage `json:"params"`
+	}
+	json.NewDecoder(r.Body).Decode(&request)
+	answer := func(result any, problem string) {
+		var errorValue any
+		if problem != "" {
+			errorValue = problem
+		}
+		json.NewEncoder(w).Encode(map[string]any{"result": result, "error": errorValue})
+	}
+	if request.Version != apiVersion || request.Key != "secret" {
+		answer(nil, "valid api key must be provided")
+		return
+	}
+	var params struct {
+		ModelName string `json:"modelName"`
+		Deck      string `json:"deck"`
+		Notes     []note `json:"notes"`
+		Filename  string `json:"filename"`
+		Data      []byte `json:"data"`
+	}
+	json.Unmarshal(request.Params, &params)
+	known := func(n note) bool {
+		for _, existing := range f.notes {
+			if existing.DeckName == n.DeckName && existing.Fields["Front"] == n.Fields["Front"] {
+				return true
+			}
+		}
+		return false
+	}
+	switch request.Action {
+	case "deckNames":
+		answer(f.decks, "")
+	case "modelNames":
+		answer([]string{"Basic", "Basic (with extra)"}, "")
+	case "modelFieldNames":
+		if params.ModelName == "Basic" {
+			answer([]string{"Front", "Back"}, "")
+		} else {
+			answer([]string{"Front", "Back", "Extra"}, "")
+		}
+	case "createDeck":
+		f.decks = append(f.decks, params.Deck)
+		answer(1, "")
+	case "storeMediaFile":
+		f.media[params.Filename] = params.Data
+		answer(params.Filename, "")
+	case "canAddNotes":
+		var addable []bool
+		for _, n := range params.Notes {
+			addable = append(addable, !known(n))
+		}
+		answer(addable, "")
+	case "addNotes":
+		var ids []any
+		for _, n := range params.Notes {
+			if known(n) {
+				answer(nil, "cannot create note because it is a duplicate")
+				return
+			}
+			f.notes = append(f.notes, n)
+			ids = append(ids, len(f.notes))
+		}
+		answer(ids, "")
+	default:
+		answer(nil, "unsupported action")
+	}
+}
+
+func TestSend(t *testing.T) {
+	anki := &fakeAnki{decks: []string{"Default"}, media: make(map[string][]byte)}
+	server := httptest.NewServer(anki)
+	defer server.Close()
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "cat.png"), []byte("miaow"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lessonData := lesson.NewLessonData()
+	lessonData.List.AddWordItem([]string{"cat"}, []string{"gato"}, "el <gato>")
+	lessonData.List.AddMediaItem("cat", []string{"what animal?"}, []string{"gato"}, "cat.png", false)
+	lessonData.List.Items[0].Tags = []string{"chapter 1"}
+	lessonPath := filepath.Join(dir, "animals.otmd")
+
+	client := NewAnkiConnectModule()
+	client.SetURL(server.URL)
+	if _, err := client.Decks(ctx); err == nil || !strings.Contains(err.Error(), "api key") {
+		t.Errorf("Expected a missing key to be refused, got %v", err)
+	}
+	client.SetKey("secret")
+	if decks, err := client.Decks(ctx); err != nil || len(decks) != 1 {
+		t.Fatalf("Expected the default deck, got %v, %v", decks, err)
+	}
+
+	result, err := client.Send(ctx, lessonData, lessonPath, "Spanish::Animals", "Basic (with extra)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Added != 2 || result.Skipped != 0 || result.Media != 1 {
+		t.Errorf("Expected two notes and one media file, got %+v", result)
+	}
+	if len(anki.decks) != 2 || anki.decks[1] != "Spanish::Animals" {
+		t.Errorf("Expected the deck to be created, got %v", anki.decks)
+	}
+	first := anki.notes[0]
+	if first.Fields["Front"] != "cat" || first.Fields["Extra"] != "el &lt;gato&gt;" || first.Tags[1] != "chapter_1" {
+		t.Errorf("Expected the comment in Extra and the tag without spaces, got %+v", first)
+	}
+	if front := anki.notes[1].Fields["Front"]; !strings.HasPrefix(front, `<img src="recuerdo-`) || !strings.HasSuffix(front, `-cat.png"><br>what animal?`) {
+		t.Errorf("Expected the picture above the question, got %q", front)
+	}
+	for _, data := range anki.media {
+		if string(data) != "miaow" {
+			t.Errorf("Expected the picture to be stored, got %q", data)
+		}
+	}
+
+	// Sending again only adds what is new
+	lessonData.List.AddWordItem([]string{"dog"}, []string{"perro"}, "a dog")
+	result, err = client.Send(ctx, lessonData, lessonPath, "Spanish::Animals", "Basic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Added != 1 || result.Skipped != 2 {
+		t.Errorf("Expected one new note and two skipped, got %+v", result)
+	}
+	if back := anki.notes[2].Fields["Back"]; back != "perro<br><i>a dog</i>" {
+		t.Errorf("Expected the comment under the answer without an extra field, got %q", back)
+	}
+
+	server.Close()
+	if _, err := client.Models(ctx); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("Expected Anki to be reported not running, got %v", err)
+	}
+}
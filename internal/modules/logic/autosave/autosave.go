@@ -0,0 +1,361 @@
+// Package autosave keeps copies of lessons with unsaved changes in a
+// recovery directory, so the work can be restored when Recuerdo did not
+// close properly.
+//
+// The GUI tracks every lesson it opens in an editor and calls SaveDirty
+// periodically from its own thread, as the editors change lessons without
+// locking. A lesson whose changes were saved loses its recovery copy.
+//
+// Every run holds a lock on its session in the recovery directory, so the
+// copies of another Recuerdo that is still running are not offered for
+// recovery, only those of runs that are gone.
+package autosave
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/LaPingvino/recuerdo/internal/paths"
+)
+
+// The settings keys turning autosaving on or off and holding the seconds
+// between two autosaves
+const (
+	EnabledSetting  = "app.autoSave"
+	IntervalSetting = "app.autoSaveDelay"
+)
+
+// DefaultInterval is the time between autosaves when IntervalSetting is
+// unset
+const DefaultInterval = 30 * time.Second
+
+// Snapshot is the recovery copy of a lesson
+type Snapshot struct {
+	// Path is where the lesson was opened from; for lessons never saved it
+	// starts with "*" like in the editors
+	Path       string            `json:"path"`
+	LessonType string            `json:"lessonType"`
+	Saved      time.Time         `json:"saved"`
+	Data       lesson.LessonData `json:"data"`
+	// file is the recovery file the snapshot was read from
+	file string
+}
+
+// Title is how the snapshot is shown to the user
+func (s Snapshot) Title() string {
+	if s.Data.List.Title != "" {
+		return s.Data.List.Title
+	}
+	return filepath.Base(strings.TrimPrefix(s.Path, "*"))
+}
+
+// Lesson returns the recovered lesson, marked as changed so it is saved
+// again
+func (s Snapshot) Lesson() *lesson.Lesson {
+	recovered := lesson.NewLesson(s.LessonType)
+	recovered.Data = s.Data
+	recovered.Data.Changed = true
+	if recovered.Data.Resources == nil {
+		recovered.Data.Resources = make(map[string]interface{})
+	}
+	recovered.Path = s.Path
+	return recovered
+}
+
+// tracked is a lesson open in an editor
+type tracked struct {
+	file string
+	// sum is the checksum of the last copy written, so unchanged lessons
+	// are not written again
+	sum [sha256.Size]byte
+}
+
+// Settings is the part of the settings module the interval is read from
+type Settings interface {
+	GetBool(key string) (bool, error)
+	GetInt(key string) (int, error)
+}
+
+// AutosaveModule writes recovery copies of lessons with unsaved changes
+type AutosaveModule struct {
+	*core.BaseModule
+	manager  *core.Manager
+	dir      string
+	interval time.Duration
+	// session prefixes the recovery files of this run, telling them from
+	// those left by an earlier one; sessionLock tells other runs it is
+	// still running
+	session     string
+	sessionLock *lesson.FileLock
+	next        int
+	lessons     map[*lesson.Lesson]*tracked
+	// recoverable are the snapshots left by earlier runs
+	recoverable []Snapshot
+	mu          sync.Mutex
+}
+
+// NewAutosaveModule creates a new AutosaveModule instance
+func NewAutosaveModule() *AutosaveModule {
+	base := core.NewBaseModule("autosave", "autosave-module")
+
+	return &AutosaveModule{
+		BaseModule: base,
+		dir:        filepath.Join(paths.DataDir(), "recovery"),
+		interval:   DefaultInterval,
+		lessons:    make(map[*lesson.Lesson]*tracked),
+	}
+}
+
+// Interval is the time between two calls of SaveDirty; 0 means autosaving
+// is off
+func (mod *AutosaveModule) Interval() time.Duration {
+	return mod.interval
+}
+
+// Track starts keeping recovery copies of a lesson opened in an editor
+func (mod *AutosaveModule) Track(l *lesson.Lesson) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	if _, ok := mod.lessons[l]; ok {
+		return
+	}
+	mod.next++
+	mod.lessons[l] = &tracked{file: filepath.Join(mod.dir, fmt.Sprintf("%s-%d.json", mod.session, mod.next))}
+}
+
+// Untrack stops keeping recovery copies of a lesson, such as when its
+// editor is closed, and removes its copy
+func (mod *AutosaveModule) Untrack(l *lesson.Lesson) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	if t, ok := mod.lessons[l]; ok {
+		os.Remove(t.file)
+		delete(mod.lessons, l)
+	}
+}
+
+// SaveDirty writes a recovery copy of every tracked lesson changed since
+// its last copy, and removes the copies of lessons that were saved. It
+// returns how many copies were written.
+func (mod *AutosaveModule) SaveDirty() (int, error) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+
+	written := 0
+	var errs []string
+	for l, t := range mod.lessons {
+		if !l.Data.Changed {
+			if t.sum != ([sha256.Size]byte{}) {
+				os.Remove(t.file)
+				t.sum = [sha256.Size]byte{}
+			}
+			continue
+		}
+		data, err := json.Marshal(Snapshot{Path: l.Path, LessonType: l.DataType, Data: l.Data})
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		// The time is left out of the checksum so an unchanged lesson
+		// gives the same one
+		sum := sha256.Sum256(data)
+		if sum == t.sum {
+			continue
+		}
+		data, err = json.Marshal(Snapshot{Path: l.Path, LessonType: l.DataType, Saved: time.Now(), Data: l.Data})
+		if err == nil {
+			err = writeAtomically(t.file, data)
+		}
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		t.sum = sum
+		written++
+	}
+	if len(errs) > 0 {
+		return written, fmt.Errorf("failed to autosave: %s", strings.Join(errs, "; "))
+	}
+	return written, nil
+}
+
+// Recoverable returns the lessons left unsaved by earlier runs, newest
+// first
+func (mod *AutosaveModule) Recoverable() []Snapshot {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	return append([]Snapshot(nil), mod.recoverable...)
+}
+
+// Discard removes the recovery copy of a snapshot, after it was restored
+// or when the user does not want it back
+func (mod *AutosaveModule) Discard(snapshot Snapshot) error {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	kept := mod.recoverable[:0]
+	for _, s := range mod.recoverable {
+		if s.file != snapshot.file {
+			kept = append(kept, s)
+		}
+	}
+	mod.recoverable = kept
+	if err := os.Remove(snapshot.file); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// loadRecoverable reads the recovery copies in the directory, leaving out
+// those of runs that are still going. The caller holds mu.
+func (mod *AutosaveModule) loadRecoverable() error {
+	files, err := filepath.Glob(filepath.Join(mod.dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	mod.recoverable = nil
+	for _, file := range files {
+		session, _, _ := strings.Cut(filepath.Base(file), "-")
+		if _, running := lesson.LockedBy(mod.sessionPath(session)); running {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		var snapshot Snapshot
+		if err := json.Unmarshal(data, &snapshot); err != nil {
+			// A copy that cannot be read would be offered forever
+			fmt.Printf("Warning: removing unreadable recovery file %s: %v\n", file, err)
+			os.Remove(file)
+			continue
+		}
+		snapshot.file = file
+		mod.recoverable = append(mod.recoverable, snapshot)
+	}
+	sort.Slice(mod.recoverable, func(i, j int) bool {
+		return mod.recoverable[i].Saved.After(mod.recoverable[j].Saved)
+	})
+
+	// Runs that crashed left their session locks behind; their copies are
+	// offered without them just as well
+	locks, err := filepath.Glob(lesson.LockPath(mod.sessionPath("*")))
+	if err != nil {
+		return err
+	}
+	for _, lock := range locks {
+		session := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(lock), ".~lock."), "#")
+		if _, running := lesson.LockedBy(mod.sessionPath(session)); session != mod.session && !running {
+			os.Remove(lock)
+		}
+	}
+	return nil
+}
+
+// sessionPath returns the path whose lock tells a session is running
+func (mod *AutosaveModule) sessionPath(session string) string {
+	return filepath.Join(mod.dir, session)
+}
+
+// lockSession locks the session of this run. The caller holds mu.
+func (mod *AutosaveModule) lockSession() error {
+	if err := os.MkdirAll(mod.dir, 0700); err != nil {
+		return err
+	}
+	lock, err := lesson.LockFile(mod.sessionPath(mod.session))
+	if err != nil {
+		return err
+	}
+	mod.sessionLock = lock
+	return nil
+}
+
+// writeAtomically writes data to a temporary file first, so a crash while
+// writing leaves the previous copy intact
+func writeAtomically(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	temp := path + ".tmp"
+	if err := os.WriteFile(temp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(temp, path)
+}
+
+// Enable activates the module, reading the interval setting and the
+// recovery copies left by earlier runs
+func (mod *AutosaveModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	if mod.manager != nil {
+		if module, ok := mod.manager.GetDefaultModule("settings"); ok {
+			if settings, ok := module.(Settings); ok {
+				if seconds, err := settings.GetInt(IntervalSetting); err == nil && seconds >= 0 {
+					mod.interval = time.Duration(seconds) * time.Second
+				}
+				if enabled, err := settings.GetBool(EnabledSetting); err == nil && !enabled {
+					mod.interval = 0
+				}
+			}
+		}
+	}
+
+	mod.mu.Lock()
+	mod.session = fmt.Sprintf("%d", time.Now().UnixNano())
+	if err := mod.lockSession(); err != nil {
+		// Without it, another run would offer this one's copies
+		fmt.Printf("Warning: failed to lock autosave session: %v\n", err)
+	}
+	err := mod.loadRecoverable()
+	mod.mu.Unlock()
+	if err != nil {
+		fmt.Printf("Warning: failed to read recovery files: %v\n", err)
+	}
+
+	fmt.Println("AutosaveModule enabled")
+	return nil
+}
+
+// Disable deactivates the module. Closing properly is not a crash, so the
+// recovery copies of this run are removed.
+func (mod *AutosaveModule) Disable(ctx context.Context) error {
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	mod.mu.Lock()
+	for l, t := range mod.lessons {
+		os.Remove(t.file)
+		delete(mod.lessons, l)
+	}
+	if mod.sessionLock != nil {
+		mod.sessionLock.Unlock()
+		mod.sessionLock = nil
+	}
+	mod.mu.Unlock()
+
+	fmt.Println("AutosaveModule disabled")
+	return nil
+}
+
+// SetManager sets the module manager
+func (mod *AutosaveModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// InitAutosaveModule creates and returns a new AutosaveModule instance
+func InitAutosaveModule() core.Module {
+	return NewAutosaveModule()
+}
@@ -0,0 +1,152 @@
+package autosave
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+func newTestModule(t *testing.T, dir string) *AutosaveModule {
+	t.Helper()
+	mod := NewAutosaveModule()
+	mod.dir = dir
+	if err := mod.Enable(context.Background()); err != nil {
+		t.Fatalf("Enable: %v", err)
+	}
+	return mod
+}
+
+func recoveryFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestSaveDirtyAndRecover(t *testing.T) {
+	dir := t.TempDir()
+	mod := newTestModule(t, dir)
+
+	words := lesson.NewLesson("words")
+	words.Path = "*French"
+	words.Data.List.Title = "French"
+	words.Data.List.Items = []lesson.WordItem{{ID: 0, Questions: []string{"house"}, Answers: []string{"maison"}}}
+	x, y := 10, 20
+	topo := lesson.NewLesson("topo")
+	topo.Path = "/lessons/europe.ottp"
+	topo.Data.List.Items = []lesson.WordItem{{ID: 0, Name: "Paris", Answers: []string{"Paris"}, X: &x, Y: &y}}
+	mod.Track(words)
+	mod.Track(topo)
+
+	if n, err := mod.SaveDirty(); err != nil || n != 0 {
+		t.Fatalf("SaveDirty of unchanged lessons = %d, %v", n, err)
+	}
+
+	words.Data.Changed = true
+	topo.Data.Changed = true
+	if n, err := mod.SaveDirty(); err != nil || n != 2 {
+		t.Fatalf("SaveDirty = %d, %v", n, err)
+	}
+	if n, _ := mod.SaveDirty(); n != 0 {
+		t.Errorf("lessons changed no further were written again: %d", n)
+	}
+	words.Data.List.Items[0].Answers = []string{"la maison"}
+	if n, _ := mod.SaveDirty(); n != 1 {
+		t.Errorf("SaveDirty after an edit = %d, want 1", n)
+	}
+
+	// Saving the topo lesson removes its copy
+	topo.Data.Changed = false
+	mod.SaveDirty()
+	if files := recoveryFiles(t, dir); len(files) != 1 {
+		t.Fatalf("recovery files = %q, want one", files)
+	}
+
+	// A crash: the next run finds the copy of the words lesson
+	next := newTestModule(t, dir)
+	snapshots := next.Recoverable()
+	if len(snapshots) != 1 {
+		t.Fatalf("Recoverable = %+v", snapshots)
+	}
+	recovered := snapshots[0].Lesson()
+	if snapshots[0].Title() != "French" || recovered.Path != "*French" || recovered.DataType != "words" || !recovered.Data.Changed {
+		t.Errorf("recovered lesson = %+v", recovered)
+	}
+	if got := recovered.Data.List.Items[0].Answers[0]; got != "la maison" {
+		t.Errorf("recovered answer = %q", got)
+	}
+
+	if err := next.Discard(snapshots[0]); err != nil {
+		t.Fatalf("Discard: %v", err)
+	}
+	if len(next.Recoverable()) != 0 {
+		t.Error("discarded snapshot still recoverable")
+	}
+	// The first run's copy was discarded; closing it properly removes
+	// nothing else
+	if err := mod.Disable(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if files := recoveryFiles(t, dir); len(files) != 0 {
+		t.Errorf("recovery files left: %q", files)
+	}
+}
+
+func TestUntrackAndUnreadableFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	mod := newTestModule(t, dir)
+	if len(mod.Recoverable()) != 0 {
+		t.Error("unreadable file offered for recovery")
+	}
+
+	l := lesson.NewLesson("media")
+	l.Data.Changed = true
+	mod.Track(l)
+	if n, err := mod.SaveDirty(); err != nil || n != 1 {
+		t.Fatalf("SaveDirty = %d, %v", n, err)
+	}
+	mod.Untrack(l)
+	if files := recoveryFiles(t, dir); len(files) != 0 {
+		t.Errorf("recovery files left after Untrack: %q", files)
+	}
+}
+
+func TestCopiesOfRunningSessionsAreNotOffered(t *testing.T) {
+	dir := t.TempDir()
+	host, _ := os.Hostname()
+	snapshot := []byte(`{"path": "*Spanish", "lessonType": "words", "data": {"list": {"title": "Spanish"}}}`)
+	for session, pid := range map[string]int{"1": os.Getppid(), "2": 0} {
+		if err := os.WriteFile(filepath.Join(dir, session+"-1.json"), snapshot, 0600); err != nil {
+			t.Fatal(err)
+		}
+		lock := fmt.Sprintf(`{"host": %q, "pid": %d}`, host, pid)
+		if err := os.WriteFile(lesson.LockPath(filepath.Join(dir, session)), []byte(lock), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mod := newTestModule(t, dir)
+	defer mod.Disable(context.Background())
+	snapshots := mod.Recoverable()
+	if len(snapshots) != 1 || filepath.Base(snapshots[0].file) != "2-1.json" {
+		t.Fatalf("Recoverable = %+v, want only the copy of the session that is gone", snapshots)
+	}
+	if _, err := os.Stat(lesson.LockPath(filepath.Join(dir, "2"))); !os.IsNotExist(err) {
+		t.Error("the lock of the session that is gone was left behind")
+	}
+	if _, err := os.Stat(lesson.LockPath(filepath.Join(dir, "1"))); err != nil {
+		t.Errorf("the lock of the running session was removed: %v", err)
+	}
+	if _, err := os.Stat(lesson.LockPath(mod.sessionPath(mod.session))); err != nil {
+		t.Errorf("the session holds no lock: %v", err)
+	}
+}
@@ -0,0 +1,374 @@
+// Package dailyprogress keeps track of how many cards are reviewed and how
+// many minutes are practised each day, so learners can set themselves a
+// daily goal and keep a streak of days they reached it. Days are days of
+// study, starting at the rollover hour of package studyday.
+package dailyprogress
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/LaPingvino/recuerdo/internal/paths"
+	"github.com/LaPingvino/recuerdo/internal/studyday"
+)
+
+const (
+	// CardsSetting is the settings key of the number of cards to review
+	// each day
+	CardsSetting = "progress.dailyCards"
+	// MinutesSetting is the settings key of the number of minutes to
+	// practise each day
+	MinutesSetting = "progress.dailyMinutes"
+
+	// DefaultCards and DefaultMinutes are the goals when the settings are
+	// unset
+	DefaultCards   = 20
+	DefaultMinutes = 10
+)
+
+// idleGap is the longest pause between two answers still counted as
+// practising; after a longer one only the time taken to answer counts
+const idleGap = 2 * time.Minute
+
+// Goals is what has to be practised for a day to count towards the streak.
+// A goal of zero is not checked.
+type Goals struct {
+	Cards   int
+	Minutes int
+}
+
+// Day is what was practised on one day
+type Day struct {
+	Date    string  `json:"date"`
+	Cards   int     `json:"cards"`
+	Seconds float64 `json:"seconds"`
+}
+
+// Minutes returns the whole minutes practised
+func (d Day) Minutes() int {
+	return int(d.Seconds / 60)
+}
+
+// Fraction returns how much of the goals was reached on day, from 0 to 1.
+// With more than one goal it is the part of the one furthest away.
+func (g Goals) Fraction(day Day) float64 {
+	fraction := 1.0
+	if g.Cards <= 0 && g.Minutes <= 0 {
+		if day.Cards == 0 {
+			fraction = 0
+		}
+		return fraction
+	}
+	if g.Cards > 0 {
+		fraction = min(fraction, float64(day.Cards)/float64(g.Cards))
+	}
+	if g.Minutes > 0 {
+		fraction = min(fraction, day.Seconds/60/float64(g.Minutes))
+	}
+	return fraction
+}
+
+// Met tells whether all goals were reached on day. Without goals any day
+// something was reviewed counts.
+func (g Goals) Met(day Day) bool {
+	return g.Fraction(day) >= 1
+}
+
+// LoadGoals reads the daily goals from settings
+func LoadGoals(settings lesson.ToleranceSettings) Goals {
+	return Goals{
+		Cards:   settingInt(settings, CardsSetting, DefaultCards),
+		Minutes: settingInt(settings, MinutesSetting, DefaultMinutes),
+	}
+}
+
+// SaveGoals stores the daily goals in settings
+func SaveGoals(settings lesson.ToleranceSettings, goals Goals) error {
+	if err := settings.SetSetting(CardsSetting, goals.Cards); err != nil {
+		return err
+	}
+	return settings.SetSetting(MinutesSetting, goals.Minutes)
+}
+
+// settingInt reads a number from settings, which JSON may have turned into
+// a float64
+func settingInt(settings lesson.ToleranceSettings, key string, defaultValue int) int {
+	switch value := settings.GetSettingWithDefault(key, defaultValue).(type) {
+	case int:
+		return max(0, value)
+	case float64:
+		return max(0, int(value))
+	}
+	return defaultValue
+}
+
+// DailyProgressModule records every answer given in a lesson as practice on
+// the day it was given
+type DailyProgressModule struct {
+	*core.BaseModule
+	manager *core.Manager
+	// storePath is the file the days are kept in
+	storePath string
+	goals     Goals
+	days      map[string]Day
+	// lastReview is when the previous answer was given
+	lastReview time.Time
+	handlers   []func()
+	// now returns the current time; tests replace it
+	now func() time.Time
+	mu  sync.Mutex
+}
+
+// NewDailyProgressModule creates a new DailyProgressModule instance
+func NewDailyProgressModule() *DailyProgressModule {
+	base := core.NewBaseModule("dailyProgress", "dailyprogress-module")
+
+	return &DailyProgressModule{
+		BaseModule: base,
+		storePath:  filepath.Join(paths.DataDir(), "progress.json"),
+		goals:      Goals{Cards: DefaultCards, Minutes: DefaultMinutes},
+		days:       make(map[string]Day),
+		now:        time.Now,
+	}
+}
+
+// Reviewed records the answer of result; it makes the module a
+// lesson.ReviewObserver
+func (mod *DailyProgressModule) Reviewed(list *lesson.WordList, result lesson.TestResult) {
+	at := mod.now()
+	if result.Time != nil && !result.Time.IsZero() {
+		at = *result.Time
+	}
+	answerTime := time.Duration(result.AnswerTime * float64(time.Second))
+	if err := mod.Record(at, answerTime); err != nil {
+		fmt.Printf("Warning: failed to save daily progress: %v\n", err)
+	}
+}
+
+// Record counts one card reviewed at the given time. The time since the
+// previous answer counts as practice unless the learner was away longer
+// than idleGap, in which case only answerTime counts.
+func (mod *DailyProgressModule) Record(at time.Time, answerTime time.Duration) error {
+	mod.mu.Lock()
+	practised := min(answerTime, idleGap)
+	if gap := at.Sub(mod.lastReview); !mod.lastReview.IsZero() && gap >= 0 && gap <= idleGap {
+		practised = gap
+	}
+	mod.lastReview = at
+
+	date := studyday.Of(at, mod.rollover())
+	day := mod.days[date]
+	day.Date = date
+	day.Cards++
+	day.Seconds += practised.Seconds()
+	mod.days[date] = day
+	err := mod.save()
+	mod.mu.Unlock()
+
+	mod.updated()
+	return err
+}
+
+// Today returns what was practised today
+func (mod *DailyProgressModule) Today() Day {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	return mod.day(studyday.Of(mod.now(), mod.rollover()))
+}
+
+// Goals returns the daily goals
+func (mod *DailyProgressModule) Goals() Goals {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	return mod.goals
+}
+
+// SetGoals changes the daily goals, storing them in the settings when
+// there is a settings module
+func (mod *DailyProgressModule) SetGoals(goals Goals) error {
+	mod.mu.Lock()
+	mod.goals = goals
+	mod.mu.Unlock()
+
+	var err error
+	if settings := mod.settings(); settings != nil {
+		err = SaveGoals(settings, goals)
+	}
+	mod.updated()
+	return err
+}
+
+// Streak returns the number of days in a row the goals were met. A day on
+// which they are not met yet does not break the streak before it ends.
+func (mod *DailyProgressModule) Streak() int {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+
+	date := studyday.Of(mod.now(), mod.rollover())
+	if !mod.goals.Met(mod.day(date)) {
+		date = studyday.Add(date, -1)
+	}
+	streak := 0
+	for mod.goals.Met(mod.day(date)) {
+		streak++
+		date = studyday.Add(date, -1)
+	}
+	return streak
+}
+
+// Calendar returns the days of the last weeks, this one included, from the
+// Monday weeks-1 weeks ago on. Days still to come have no date.
+func (mod *DailyProgressModule) Calendar(weeks int) []Day {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+
+	today := studyday.Of(mod.now(), mod.rollover())
+	sinceMonday := (int(studyday.Weekday(today)) + 6) % 7
+	start := studyday.Add(today, -sinceMonday-7*(weeks-1))
+	days := make([]Day, 0, 7*weeks)
+	for i := 0; i < 7*weeks; i++ {
+		date := studyday.Add(start, i)
+		if date > today {
+			days = append(days, Day{})
+			continue
+		}
+		days = append(days, mod.day(date))
+	}
+	return days
+}
+
+// OnUpdated registers a function called whenever the progress or the goals
+// change
+func (mod *DailyProgressModule) OnUpdated(handler func()) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	mod.handlers = append(mod.handlers, handler)
+}
+
+func (mod *DailyProgressModule) updated() {
+	mod.mu.Lock()
+	handlers := append([]func(){}, mod.handlers...)
+	mod.mu.Unlock()
+	for _, handler := range handlers {
+		handler()
+	}
+}
+
+// day returns what was practised on date. The caller holds mu.
+func (mod *DailyProgressModule) day(date string) Day {
+	day := mod.days[date]
+	day.Date = date
+	return day
+}
+
+// rollover returns the hour days of study start at
+func (mod *DailyProgressModule) rollover() int {
+	if settings := mod.settings(); settings != nil {
+		return studyday.LoadRollover(settings)
+	}
+	return studyday.DefaultRollover
+}
+
+// settings returns the settings module, or nil
+func (mod *DailyProgressModule) settings() lesson.ToleranceSettings {
+	if mod.manager == nil {
+		return nil
+	}
+	module, ok := mod.manager.GetDefaultModule("settings")
+	if !ok {
+		return nil
+	}
+	settings, _ := module.(lesson.ToleranceSettings)
+	return settings
+}
+
+// load reads the days from disk. The caller holds mu.
+func (mod *DailyProgressModule) load() error {
+	data, err := os.ReadFile(mod.storePath)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var days []Day
+	if err := json.Unmarshal(data, &days); err != nil {
+		return err
+	}
+	for _, day := range days {
+		mod.days[day.Date] = day
+	}
+	return nil
+}
+
+// save writes the days to disk, oldest first. The caller holds mu.
+func (mod *DailyProgressModule) save() error {
+	days := make([]Day, 0, len(mod.days))
+	for _, day := range mod.days {
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Date < days[j].Date })
+
+	if err := os.MkdirAll(filepath.Dir(mod.storePath), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(days, "", "  ")
+	if err != nil {
+		return err
+	}
+	return lesson.WriteFileAtomically(mod.storePath, data, 0600)
+}
+
+// Enable activates the module, loading the goals and the days practised
+// and listening to the answers given
+func (mod *DailyProgressModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	if settings := mod.settings(); settings != nil {
+		mod.goals = LoadGoals(settings)
+	}
+	mod.mu.Lock()
+	err := mod.load()
+	mod.mu.Unlock()
+	if err != nil {
+		fmt.Printf("Warning: failed to load daily progress: %v\n", err)
+	}
+	lesson.AddReviewObserver(mod)
+
+	fmt.Println("DailyProgressModule enabled")
+	return nil
+}
+
+// Disable deactivates the module
+func (mod *DailyProgressModule) Disable(ctx context.Context) error {
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	lesson.RemoveReviewObserver(mod)
+	mod.mu.Lock()
+	mod.handlers = nil
+	mod.mu.Unlock()
+
+	fmt.Println("DailyProgressModule disabled")
+	return nil
+}
+
+// SetManager sets the module manager
+func (mod *DailyProgressModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// InitDailyProgressModule creates and returns a new DailyProgressModule instance
+func InitDailyProgressModule() core.Module {
+	return NewDailyProgressModule()
+}
@@ -0,0 +1,133 @@
+package dailyprogress
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+func newTestModule(t *testing.T, now time.Time) *DailyProgressModule {
+	mod := NewDailyProgressModule()
+	mod.storePath = filepath.Join(t.TempDir(), "progress.json")
+	mod.goals = Goals{Cards: 2}
+	mod.now = func() time.Time { return now }
+	return mod
+}
+
+func TestPracticeTime(t *testing.T) {
+	// A Wednesday
+	now := time.Date(2026, 10, 14, 18, 0, 0, 0, time.Local)
+	mod := newTestModule(t, now)
+
+	answers := []struct {
+		at         time.Time
+		answerTime time.Duration
+	}{
+		{now, 5 * time.Second},
+		{now.Add(30 * time.Second), 4 * time.Second},
+		// After a break only the answer itself counts
+		{now.Add(10 * time.Minute), 6 * time.Second},
+	}
+	for _, answer := range answers {
+		if err := mod.Record(answer.at, answer.answerTime); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	today := mod.Today()
+	if today.Cards != 3 || today.Seconds != 41 {
+		t.Errorf("expected 3 cards in 41 seconds, got %d in %v", today.Cards, today.Seconds)
+	}
+
+	reloaded := newTestModule(t, now)
+	reloaded.storePath = mod.storePath
+	if err := reloaded.load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got := reloaded.Today(); got != today {
+		t.Errorf("expected %+v after loading, got %+v", today, got)
+	}
+}
+
+func TestStreakAndCalendar(t *testing.T) {
+	now := time.Date(2026, 10, 14, 18, 0, 0, 0, time.Local)
+	mod := newTestModule(t, now)
+	updates := 0
+	mod.OnUpdated(func() { updates++ })
+
+	review := func(daysAgo, cards int) {
+		for i := 0; i < cards; i++ {
+			at := now.AddDate(0, 0, -daysAgo).Add(time.Duration(i) * time.Hour)
+			if err := mod.Record(at, time.Second); err != nil {
+				t.Fatalf("Record: %v", err)
+			}
+		}
+	}
+	review(1, 2)
+	review(2, 2)
+	review(3, 1)
+	review(4, 2)
+
+	// Today is not finished, so yesterday's streak still counts
+	if streak := mod.Streak(); streak != 2 {
+		t.Errorf("expected a streak of 2 days, got %d", streak)
+	}
+	review(0, 1)
+	// Answers given to a lesson count as given now
+	mod.Reviewed(lesson.NewWordList(), lesson.TestResult{Result: "right", Time: &time.Time{}})
+	if streak := mod.Streak(); streak != 3 {
+		t.Errorf("expected a streak of 3 days, got %d", streak)
+	}
+	if updates != 9 {
+		t.Errorf("expected 9 updates, got %d", updates)
+	}
+
+	calendar := mod.Calendar(2)
+	if len(calendar) != 14 {
+		t.Fatalf("expected 14 days, got %d", len(calendar))
+	}
+	// The calendar starts on Monday the week before
+	if calendar[0].Date != "2026-10-05" {
+		t.Errorf("expected the calendar to start on 2026-10-05, got %q", calendar[0].Date)
+	}
+	if today := calendar[9]; today.Date != "2026-10-14" || today.Cards != 2 {
+		t.Errorf("unexpected today %+v", today)
+	}
+	if future := calendar[10]; future.Date != "" {
+		t.Errorf("expected no date for tomorrow, got %q", future.Date)
+	}
+	if fraction := mod.goals.Fraction(calendar[6]); fraction != 0.5 {
+		t.Errorf("expected half the goal reached on Sunday, got %v", fraction)
+	}
+}
+
+func TestGoals(t *testing.T) {
+	goals := Goals{Cards: 10, Minutes: 5}
+	day := Day{Cards: 20, Seconds: 150}
+	if fraction := goals.Fraction(day); fraction != 0.5 {
+		t.Errorf("expected half the goals reached, got %v", fraction)
+	}
+	if goals.Met(day) {
+		t.Error("expected the minutes goal to be unmet")
+	}
+	if !(Goals{}).Met(Day{Cards: 1}) || (Goals{}).Met(Day{}) {
+		t.Error("without goals any card reviewed should count")
+	}
+}
+
+func TestLateNightCountsToTheDayBefore(t *testing.T) {
+	now := time.Date(2026, 10, 15, 1, 0, 0, 0, time.Local)
+	mod := newTestModule(t, now)
+	mod.Record(time.Date(2026, 10, 14, 23, 30, 0, 0, time.Local), 5*time.Second)
+	mod.Record(now, 5*time.Second)
+
+	today := mod.Today()
+	if today.Date != "2026-10-14" || today.Cards != 2 {
+		t.Errorf("expected both cards on the evening before, got %+v", today)
+	}
+	if streak := mod.Streak(); streak != 1 {
+		t.Errorf("expected a streak of 1, got %d", streak)
+	}
+}
@@ -0,0 +1,101 @@
+// Package duplicatefinder finds duplicate and near-duplicate items in words
+// lessons, and resolves them by merging or deleting items without losing
+// the test history of what is kept.
+package duplicatefinder
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// Merger is the part of a merger module used to scan several lessons at
+// once
+type Merger interface {
+	Merge(baseLesson, otherLesson *lesson.LessonData) (*lesson.LessonData, error)
+}
+
+// DuplicateFinderModule scans lessons for duplicates
+type DuplicateFinderModule struct {
+	*core.BaseModule
+	manager *core.Manager
+	// MinSimilarity is the spelling similarity from which items count as
+	// near-duplicates; zero means lesson.DefaultDuplicateSimilarity
+	MinSimilarity float64
+}
+
+// NewDuplicateFinderModule creates a new DuplicateFinderModule instance
+func NewDuplicateFinderModule() *DuplicateFinderModule {
+	base := core.NewBaseModule("duplicateFinder", "duplicate-finder-module")
+
+	return &DuplicateFinderModule{
+		BaseModule: base,
+	}
+}
+
+// Scan merges the lessons, when there are several, and returns the result
+// with its duplicate groups
+func (mod *DuplicateFinderModule) Scan(lessons ...*lesson.LessonData) (*lesson.LessonData, []lesson.DuplicateGroup, error) {
+	if len(lessons) == 0 {
+		return nil, nil, fmt.Errorf("no lesson to scan")
+	}
+
+	merged := lessons[0]
+	for _, other := range lessons[1:] {
+		var err error
+		if merged, err = mod.merge(merged, other); err != nil {
+			return nil, nil, err
+		}
+	}
+	return merged, lesson.FindDuplicates(merged, mod.MinSimilarity), nil
+}
+
+// Resolve applies the choices made for the duplicate groups of lessonData
+func (mod *DuplicateFinderModule) Resolve(lessonData *lesson.LessonData, resolutions []lesson.DuplicateResolution) *lesson.LessonData {
+	return lesson.ResolveDuplicates(lessonData, resolutions)
+}
+
+// merge uses the words merger module when one is registered
+func (mod *DuplicateFinderModule) merge(baseLesson, otherLesson *lesson.LessonData) (*lesson.LessonData, error) {
+	if mod.manager != nil {
+		for _, module := range mod.manager.GetModulesByType("merger") {
+			if merger, ok := module.(Merger); ok {
+				return merger.Merge(baseLesson, otherLesson)
+			}
+		}
+	}
+	return lesson.MergeLessons(baseLesson, otherLesson)
+}
+
+// Enable activates the module
+func (mod *DuplicateFinderModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("DuplicateFinderModule enabled")
+	return nil
+}
+
+// Disable deactivates the module
+func (mod *DuplicateFinderModule) Disable(ctx context.Context) error {
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("DuplicateFinderModule disabled")
+	return nil
+}
+
+// SetManager sets the module manager
+func (mod *DuplicateFinderModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// InitDuplicateFinderModule creates and returns a new DuplicateFinderModule
+// instance
+func InitDuplicateFinderModule() core.Module {
+	return NewDuplicateFinderModule()
+}
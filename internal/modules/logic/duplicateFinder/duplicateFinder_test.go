@@ -0,0 +1,59 @@
+package duplicatefinder
+
+import (
+	"testing"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	mergerwords "github.com/LaPingvino/recuerdo/internal/modules/logic/mergers/words"
+)
+
+func TestScanMergedLessons(t *testing.T) {
+	oldCourse := lesson.NewLessonData()
+	oldCourse.List.Title = "Old"
+	oldCourse.List.AddWordItem([]string{"huis"}, []string{"house"}, "")
+	oldCourse.List.AddWordItem([]string{"boom"}, []string{"tree"}, "")
+	oldCourse.List.Tests = []lesson.Test{{Results: []lesson.TestResult{{Result: "wrong", ItemID: 1}}}}
+
+	newCourse := lesson.NewLessonData()
+	newCourse.List.Title = "New"
+	newCourse.List.AddWordItem([]string{"boom"}, []string{"tree"}, "")
+	newCourse.List.AddWordItem([]string{"kat"}, []string{"cat"}, "")
+	newCourse.List.Tests = []lesson.Test{{Results: []lesson.TestResult{{Result: "right", ItemID: 0}}}}
+
+	manager := core.NewManager()
+	if err := manager.Register(mergerwords.NewWordsMergerModule()); err != nil {
+		t.Fatalf("Failed to register merger: %v", err)
+	}
+	finder := NewDuplicateFinderModule()
+	finder.SetManager(manager)
+
+	merged, groups, err := finder.Scan(oldCourse, newCourse)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(merged.List.Items) != 4 || len(groups) != 1 || !groups[0].Exact || groups[0].Items[0] != 1 || groups[0].Items[1] != 2 {
+		t.Fatalf("Expected the two trees as one exact group, got %d items and %+v", len(merged.List.Items), groups)
+	}
+
+	resolved := finder.Resolve(merged, []lesson.DuplicateResolution{{Group: groups[0], Action: lesson.DuplicateMerge, Keep: 1}})
+	if len(resolved.List.Items) != 3 {
+		t.Fatalf("Expected 3 items after merging, got %d", len(resolved.List.Items))
+	}
+	results := 0
+	for _, test := range resolved.List.Tests {
+		for _, result := range test.Results {
+			if result.ItemID != 1 {
+				t.Errorf("Expected the results of both trees on item 1, got item %d", result.ItemID)
+			}
+			results++
+		}
+	}
+	if results != 2 {
+		t.Errorf("Expected both test results to be kept, got %d", results)
+	}
+
+	if _, _, err := finder.Scan(); err == nil {
+		t.Error("Expected an error scanning no lessons")
+	}
+}
@@ -0,0 +1,297 @@
+// Package featureflags lets risky subsystems ship turned off, to be turned
+// on per user without rebuilding.
+//
+// Whether a flag is on is decided by, in order: the RECUERDO_FEATURES
+// environment variable, the user's settings, an experiment rolling the flag
+// out to a percentage of installations, and the flag's default.
+package featureflags
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+)
+
+// EnvVar is the environment variable overriding flags: a comma-separated
+// list of names to turn on, "-name" to turn one off, or "name=false"
+const EnvVar = "RECUERDO_FEATURES"
+
+// SettingPrefix prefixes the name of a flag to give its settings key
+const SettingPrefix = "features."
+
+// InstallIDSetting is the settings key of the random installation ID that
+// decides which experiments an installation takes part in
+const InstallIDSetting = "features.installID"
+
+// The flags of subsystems that are still experimental
+const (
+	FSRS            = "fsrs"
+	ClassroomServer = "classroomServer"
+	WebExport       = "webExport"
+)
+
+// Flag is a feature that can be turned on or off
+type Flag struct {
+	Name        string
+	Description string
+	Default     bool
+	// Rollout is the percentage of installations that get a flag which is
+	// off by default, to try it on some users first
+	Rollout int
+}
+
+// Known are the flags of the subsystems in this version
+var Known = []Flag{
+	{Name: FSRS, Description: "Schedule reviews with the FSRS memory model"},
+	{Name: ClassroomServer, Description: "Class roster, join codes and item discussions through the lesson server"},
+	{Name: WebExport, Description: "Export lessons as a web site to practise in the browser"},
+}
+
+// Source tells what decided whether a flag is on
+type Source string
+
+// The sources of a flag's state, from weakest to strongest
+const (
+	SourceDefault     Source = "default"
+	SourceRollout     Source = "rollout"
+	SourceSetting     Source = "setting"
+	SourceEnvironment Source = "environment"
+)
+
+// State is whether a flag is on and why
+type State struct {
+	Flag
+	Enabled bool
+	Source  Source
+}
+
+// Settings is the part of the settings module flags are kept in
+type Settings interface {
+	GetBool(key string) (bool, error)
+	GetString(key string) (string, error)
+	SetSetting(key string, value interface{}) error
+}
+
+// FeatureFlagsModule decides which experimental subsystems are on
+type FeatureFlagsModule struct {
+	*core.BaseModule
+	manager  *core.Manager
+	settings Settings
+	flags    map[string]Flag
+	// env holds the overrides from EnvVar
+	env       map[string]bool
+	installID string
+	mu        sync.Mutex
+}
+
+// NewFeatureFlagsModule creates a new FeatureFlagsModule knowing the Known
+// flags. The environment is read right away, so the module can be asked
+// before it is enabled, such as when choosing which modules to register.
+func NewFeatureFlagsModule() *FeatureFlagsModule {
+	base := core.NewBaseModule("featureFlags", "featureflags-module")
+
+	mod := &FeatureFlagsModule{
+		BaseModule: base,
+		flags:      make(map[string]Flag),
+		env:        ParseOverrides(os.Getenv(EnvVar)),
+	}
+	for _, flag := range Known {
+		mod.flags[flag.Name] = flag
+	}
+	return mod
+}
+
+// ParseOverrides reads a list of overrides in the format of EnvVar.
+// Entries that cannot be read are skipped.
+func ParseOverrides(list string) map[string]bool {
+	overrides := make(map[string]bool)
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		switch {
+		case entry == "":
+		case strings.HasPrefix(entry, "-"):
+			overrides[strings.TrimPrefix(entry, "-")] = false
+		case strings.Contains(entry, "="):
+			name, value, _ := strings.Cut(entry, "=")
+			if enabled, err := strconv.ParseBool(strings.TrimSpace(value)); err == nil {
+				overrides[strings.TrimSpace(name)] = enabled
+			}
+		default:
+			overrides[strings.TrimPrefix(entry, "+")] = true
+		}
+	}
+	return overrides
+}
+
+// SetSettings sets where the choices of the user are kept; by default
+// that is the settings module
+func (mod *FeatureFlagsModule) SetSettings(settings Settings) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	mod.settings = settings
+	mod.installID = ""
+}
+
+// Register adds a flag, replacing a flag with the same name
+func (mod *FeatureFlagsModule) Register(flag Flag) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	mod.flags[flag.Name] = flag
+}
+
+// Enabled reports whether a flag is on; unknown flags are off
+func (mod *FeatureFlagsModule) Enabled(name string) bool {
+	state, _ := mod.State(name)
+	return state.Enabled
+}
+
+// State returns whether a flag is on and why, and false for unknown flags
+func (mod *FeatureFlagsModule) State(name string) (State, bool) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	flag, ok := mod.flags[name]
+	if !ok {
+		return State{Flag: Flag{Name: name}}, false
+	}
+	return mod.state(flag), true
+}
+
+// States returns the state of every flag, sorted by name
+func (mod *FeatureFlagsModule) States() []State {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	states := make([]State, 0, len(mod.flags))
+	for _, flag := range mod.flags {
+		states = append(states, mod.state(flag))
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Name < states[j].Name })
+	return states
+}
+
+// state decides whether flag is on. The caller holds mu.
+func (mod *FeatureFlagsModule) state(flag Flag) State {
+	if enabled, ok := mod.env[flag.Name]; ok {
+		return State{Flag: flag, Enabled: enabled, Source: SourceEnvironment}
+	}
+	if settings := mod.currentSettings(); settings != nil {
+		if enabled, err := settings.GetBool(SettingPrefix + flag.Name); err == nil {
+			return State{Flag: flag, Enabled: enabled, Source: SourceSetting}
+		}
+	}
+	if !flag.Default && flag.Rollout > 0 && mod.bucket(flag.Name) < flag.Rollout {
+		return State{Flag: flag, Enabled: true, Source: SourceRollout}
+	}
+	return State{Flag: flag, Enabled: flag.Default, Source: SourceDefault}
+}
+
+// currentSettings returns where the choices of the user are kept, looking
+// up the settings module on first use: other modules may ask for flags
+// before this one is enabled. The caller holds mu.
+func (mod *FeatureFlagsModule) currentSettings() Settings {
+	if mod.settings == nil && mod.manager != nil {
+		if module, ok := mod.manager.GetDefaultModule("settings"); ok {
+			mod.settings, _ = module.(Settings)
+		}
+	}
+	return mod.settings
+}
+
+// bucket places this installation between 0 and 99 for an experiment, the
+// same every time but different per flag. Without settings to keep the
+// installation ID in, it returns 100: in no experiment. The caller holds
+// mu.
+func (mod *FeatureFlagsModule) bucket(name string) int {
+	if mod.installID == "" && mod.currentSettings() != nil {
+		id, err := mod.settings.GetString(InstallIDSetting)
+		if err != nil || id == "" {
+			random := make([]byte, 16)
+			if _, err := rand.Read(random); err != nil {
+				return 100
+			}
+			id = hex.EncodeToString(random)
+			if err := mod.settings.SetSetting(InstallIDSetting, id); err != nil {
+				return 100
+			}
+		}
+		mod.installID = id
+	}
+	if mod.installID == "" {
+		return 100
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(mod.installID + ":" + name))
+	return int(hash.Sum32() % 100)
+}
+
+// SetEnabled turns a flag on or off for this user. The environment still
+// overrides it.
+func (mod *FeatureFlagsModule) SetEnabled(name string, enabled bool) error {
+	return mod.setSetting(name, enabled)
+}
+
+// Reset forgets the choice of the user, returning a flag to its default or
+// experiment
+func (mod *FeatureFlagsModule) Reset(name string) error {
+	return mod.setSetting(name, nil)
+}
+
+func (mod *FeatureFlagsModule) setSetting(name string, value interface{}) error {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	if _, ok := mod.flags[name]; !ok {
+		return fmt.Errorf("unknown feature flag %q", name)
+	}
+	if mod.currentSettings() == nil {
+		return fmt.Errorf("no settings to keep feature flag %q in", name)
+	}
+	return mod.settings.SetSetting(SettingPrefix+name, value)
+}
+
+// Enable activates the module, reporting the flags set in the environment
+func (mod *FeatureFlagsModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	for name, enabled := range mod.env {
+		if _, ok := mod.flags[name]; !ok {
+			fmt.Printf("Warning: %s names unknown feature flag %q\n", EnvVar, name)
+		} else if enabled {
+			fmt.Printf("Feature flag %s turned on by %s\n", name, EnvVar)
+		}
+	}
+
+	fmt.Println("FeatureFlagsModule enabled")
+	return nil
+}
+
+// Disable deactivates the module
+func (mod *FeatureFlagsModule) Disable(ctx context.Context) error {
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("FeatureFlagsModule disabled")
+	return nil
+}
+
+// SetManager sets the module manager
+func (mod *FeatureFlagsModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// InitFeatureFlagsModule creates and returns a new FeatureFlagsModule instance
+func InitFeatureFlagsModule() core.Module {
+	return NewFeatureFlagsModule()
+}
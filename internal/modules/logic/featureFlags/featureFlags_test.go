@@ -0,0 +1,121 @@
+package featureflags
+
+import (
+	"fmt"
+	"testing"
+)
+
+// memorySettings keeps settings like the settings module, where a nil
+// value means unset
+type memorySettings map[string]interface{}
+
+func (s memorySettings) GetBool(key string) (bool, error) {
+	if value, ok := s[key].(bool); ok {
+		return value, nil
+	}
+	return false, fmt.Errorf("no bool %s", key)
+}
+
+func (s memorySettings) GetString(key string) (string, error) {
+	if value, ok := s[key].(string); ok {
+		return value, nil
+	}
+	return "", fmt.Errorf("no string %s", key)
+}
+
+func (s memorySettings) SetSetting(key string, value interface{}) error {
+	s[key] = value
+	return nil
+}
+
+func TestParseOverrides(t *testing.T) {
+	got := ParseOverrides(" fsrs, -classroomServer ,webExport=0,+other,bad=maybe,,")
+	want := map[string]bool{"fsrs": true, "classroomServer": false, "webExport": false, "other": true}
+	if len(got) != len(want) {
+		t.Fatalf("ParseOverrides = %v, want %v", got, want)
+	}
+	for name, enabled := range want {
+		if got[name] != enabled {
+			t.Errorf("%s = %v, want %v", name, got[name], enabled)
+		}
+	}
+}
+
+func TestPrecedence(t *testing.T) {
+	t.Setenv(EnvVar, "webExport")
+	mod := NewFeatureFlagsModule()
+	settings := memorySettings{}
+	mod.SetSettings(settings)
+
+	for _, state := range mod.States() {
+		if state.Name == WebExport {
+			if !state.Enabled || state.Source != SourceEnvironment {
+				t.Errorf("webExport = %+v", state)
+			}
+		} else if state.Enabled || state.Source != SourceDefault {
+			t.Errorf("%s should be off by default: %+v", state.Name, state)
+		}
+	}
+
+	if err := mod.SetEnabled(FSRS, true); err != nil {
+		t.Fatal(err)
+	}
+	if state, _ := mod.State(FSRS); !state.Enabled || state.Source != SourceSetting {
+		t.Errorf("fsrs after SetEnabled = %+v", state)
+	}
+	if err := mod.SetEnabled(WebExport, false); err != nil {
+		t.Fatal(err)
+	}
+	if !mod.Enabled(WebExport) {
+		t.Error("the environment should override the setting")
+	}
+	if err := mod.Reset(FSRS); err != nil {
+		t.Fatal(err)
+	}
+	if mod.Enabled(FSRS) {
+		t.Error("fsrs still on after Reset")
+	}
+
+	if mod.Enabled("unknown") {
+		t.Error("unknown flag on")
+	}
+	if err := mod.SetEnabled("unknown", true); err == nil {
+		t.Error("SetEnabled of an unknown flag should fail")
+	}
+}
+
+func TestRollout(t *testing.T) {
+	t.Setenv(EnvVar, "")
+	mod := NewFeatureFlagsModule()
+	mod.Register(Flag{Name: "everyone", Rollout: 100})
+	mod.Register(Flag{Name: "nobody"})
+
+	// Without settings there is no installation ID, so no experiment
+	if mod.Enabled("everyone") {
+		t.Error("rollout without an installation ID")
+	}
+
+	settings := memorySettings{}
+	mod.SetSettings(settings)
+	if state, _ := mod.State("everyone"); !state.Enabled || state.Source != SourceRollout {
+		t.Errorf("everyone = %+v", state)
+	}
+	if mod.Enabled("nobody") {
+		t.Error("nobody on")
+	}
+	id, _ := settings.GetString(InstallIDSetting)
+	if len(id) != 32 {
+		t.Fatalf("installation ID = %q", id)
+	}
+
+	// The same installation lands in the same bucket every time
+	half := Flag{Name: "half", Rollout: 50}
+	mod.Register(half)
+	first := mod.Enabled("half")
+	again := NewFeatureFlagsModule()
+	again.Register(half)
+	again.SetSettings(settings)
+	if again.Enabled("half") != first {
+		t.Error("rollout differs between runs")
+	}
+}
@@ -0,0 +1,289 @@
+// Package filewatcher notices when lesson files open in Recuerdo are
+// changed by another program, such as a sync client like Dropbox, so the
+// user can reload them instead of overwriting the changes on saving.
+//
+// Directories are watched rather than the files themselves, as sync clients
+// and editors often replace a file instead of writing to it. A file counts
+// as changed when its content differs from the version Recuerdo loaded or
+// saved last, so touching a file or saving it from Recuerdo itself is not
+// reported.
+package filewatcher
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/fsnotify/fsnotify"
+)
+
+// ErrChangedOnDisk is returned when saving a lesson would overwrite changes
+// another program made to its file
+var ErrChangedOnDisk = errors.New("the file was changed by another program")
+
+// watchedFile is a lesson file open in Recuerdo
+type watchedFile struct {
+	// known is the hash of the version Recuerdo has
+	known string
+	// reported is the hash of the version last returned by Pending, so
+	// every change is only reported once
+	reported string
+	// dirty is set when the directory reported an event for the file
+	dirty bool
+}
+
+// FileWatcherModule watches the lesson files open in Recuerdo
+type FileWatcherModule struct {
+	*core.BaseModule
+	manager *core.Manager
+
+	mu      sync.Mutex
+	watcher *fsnotify.Watcher
+	files   map[string]*watchedFile
+	dirs    map[string]bool
+	confirm func(filePath string) bool
+	done    chan struct{}
+}
+
+// NewFileWatcherModule creates a new FileWatcherModule instance
+func NewFileWatcherModule() *FileWatcherModule {
+	base := core.NewBaseModule("fileWatcher", "filewatcher-module")
+
+	return &FileWatcherModule{
+		BaseModule: base,
+		files:      make(map[string]*watchedFile),
+		dirs:       make(map[string]bool),
+	}
+}
+
+// SetConfirmOverwrite sets the function asking whether to overwrite a file
+// another program changed; without one such files are not overwritten
+func (mod *FileWatcherModule) SetConfirmOverwrite(confirm func(filePath string) bool) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	mod.confirm = confirm
+}
+
+// Watch starts watching a lesson file, taking its current content as the
+// version Recuerdo has. Watching a file again, such as after reloading it,
+// takes its content anew.
+func (mod *FileWatcherModule) Watch(filePath string) error {
+	filePath, err := filepath.Abs(filePath)
+	if err != nil {
+		return err
+	}
+	hash, err := hashFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to watch %s: %w", filePath, err)
+	}
+
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	mod.files[filePath] = &watchedFile{known: hash}
+	return mod.watchDir(filepath.Dir(filePath))
+}
+
+// watchDir adds a directory to the fsnotify watcher once it runs; the lock
+// is held
+func (mod *FileWatcherModule) watchDir(dir string) error {
+	if mod.watcher == nil || mod.dirs[dir] {
+		return nil
+	}
+	if err := mod.watcher.Add(dir); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+	mod.dirs[dir] = true
+	return nil
+}
+
+// Saved takes the content of a watched file as the version Recuerdo has,
+// after Recuerdo wrote it
+func (mod *FileWatcherModule) Saved(filePath string) {
+	filePath, err := filepath.Abs(filePath)
+	if err != nil {
+		return
+	}
+	hash, err := hashFile(filePath)
+	if err != nil {
+		return
+	}
+
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	if file, ok := mod.files[filePath]; ok {
+		file.known = hash
+		file.reported = ""
+	}
+}
+
+// ChangedOnDisk reports whether another program changed a watched file
+// since Recuerdo loaded or saved it
+func (mod *FileWatcherModule) ChangedOnDisk(filePath string) bool {
+	filePath, err := filepath.Abs(filePath)
+	if err != nil {
+		return false
+	}
+	mod.mu.Lock()
+	file, ok := mod.files[filePath]
+	var known string
+	if ok {
+		known = file.known
+	}
+	mod.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	// A file that was removed is written anew without losing anything
+	hash, err := hashFile(filePath)
+	return err == nil && hash != known
+}
+
+// Pending returns the watched files other programs changed since the last
+// call, each change once
+func (mod *FileWatcherModule) Pending() []string {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+
+	var changed []string
+	for filePath, file := range mod.files {
+		if !file.dirty {
+			continue
+		}
+		file.dirty = false
+		// A removed file may be written again, as sync clients do
+		hash, err := hashFile(filePath)
+		if err != nil || hash == file.known || hash == file.reported {
+			continue
+		}
+		file.reported = hash
+		changed = append(changed, filePath)
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// BeforeSave keeps a lesson file another program changed from being
+// overwritten, unless the user confirms it
+func (mod *FileWatcherModule) BeforeSave(filePath string) error {
+	if !mod.ChangedOnDisk(filePath) {
+		return nil
+	}
+	mod.mu.Lock()
+	confirm := mod.confirm
+	mod.mu.Unlock()
+	if confirm != nil && confirm(filePath) {
+		return nil
+	}
+	return ErrChangedOnDisk
+}
+
+// AfterSave takes a saved file as the version Recuerdo has
+func (mod *FileWatcherModule) AfterSave(filePath string) {
+	mod.Saved(filePath)
+}
+
+// watch marks the files the watcher reports events for as dirty, until the
+// watcher is closed
+func (mod *FileWatcherModule) watch(watcher *fsnotify.Watcher, done chan struct{}) {
+	defer close(done)
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			mod.mu.Lock()
+			if file, ok := mod.files[filepath.Clean(event.Name)]; ok {
+				file.dirty = true
+			}
+			mod.mu.Unlock()
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			fmt.Printf("Warning: watching lesson files: %v\n", err)
+		}
+	}
+}
+
+// hashFile returns the SHA-256 hash of the content of a file
+func hashFile(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// Enable activates the module
+func (mod *FileWatcherModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch lesson files: %w", err)
+	}
+	mod.mu.Lock()
+	mod.watcher = watcher
+	mod.done = make(chan struct{})
+	// Files may be opened before the module is enabled
+	for filePath := range mod.files {
+		if err := mod.watchDir(filepath.Dir(filePath)); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+	go mod.watch(watcher, mod.done)
+	mod.mu.Unlock()
+	lesson.AddSaveObserver(mod)
+
+	fmt.Println("FileWatcherModule enabled")
+	return nil
+}
+
+// Disable deactivates the module
+func (mod *FileWatcherModule) Disable(ctx context.Context) error {
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	lesson.RemoveSaveObserver(mod)
+	mod.mu.Lock()
+	watcher, done := mod.watcher, mod.done
+	mod.watcher = nil
+	mod.dirs = make(map[string]bool)
+	mod.mu.Unlock()
+	if watcher != nil {
+		watcher.Close()
+		<-done
+	}
+
+	fmt.Println("FileWatcherModule disabled")
+	return nil
+}
+
+// SetManager sets the module manager
+func (mod *FileWatcherModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// InitFileWatcherModule creates and returns a new FileWatcherModule
+// instance
+func InitFileWatcherModule() core.Module {
+	return NewFileWatcherModule()
+}
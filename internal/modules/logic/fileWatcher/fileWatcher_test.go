@@ -0,0 +1,115 @@
+package filewatcher
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// waitForPending returns what Pending reports once the watcher noticed a
+// change, or nothing after half a second
+func waitForPending(mod *FileWatcherModule) []string {
+	deadline := time.Now().Add(500 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		if pending := mod.Pending(); len(pending) > 0 {
+			return pending
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return nil
+}
+
+func newTestModule(t *testing.T) (*FileWatcherModule, string) {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "french.csv")
+	if err := lesson.NewFileSaver().SaveFile(lesson.NewLessonData(), path); err != nil {
+		t.Fatal(err)
+	}
+	mod := NewFileWatcherModule()
+	if err := mod.Enable(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { mod.Disable(context.Background()) })
+	if err := mod.Watch(path); err != nil {
+		t.Fatal(err)
+	}
+	return mod, path
+}
+
+func TestExternalChange(t *testing.T) {
+	mod, path := newTestModule(t)
+
+	// Touching the file changes nothing
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	if pending := waitForPending(mod); len(pending) != 0 {
+		t.Errorf("touching the file was reported: %v", pending)
+	}
+
+	// Sync clients replace the file
+	replacement := filepath.Join(filepath.Dir(path), ".french.csv.tmp")
+	if err := os.WriteFile(replacement, []byte("Question,Answer\nhouse,maison\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(replacement, path); err != nil {
+		t.Fatal(err)
+	}
+	if pending := waitForPending(mod); len(pending) != 1 || pending[0] != path {
+		t.Fatalf("Pending = %v, want %s", pending, path)
+	}
+	if pending := mod.Pending(); len(pending) != 0 {
+		t.Errorf("a change was reported twice: %v", pending)
+	}
+	if !mod.ChangedOnDisk(path) {
+		t.Error("kept local changes should still differ from the file")
+	}
+
+	// Reloading takes the new version
+	if err := mod.Watch(path); err != nil {
+		t.Fatal(err)
+	}
+	if mod.ChangedOnDisk(path) {
+		t.Error("the reloaded file should not count as changed")
+	}
+}
+
+func TestSaveOverExternalChange(t *testing.T) {
+	mod, path := newTestModule(t)
+	lessonData := lesson.NewLessonData()
+
+	// Saving from Recuerdo is not reported as a change
+	if err := lesson.NewFileSaver().SaveFile(lessonData, path); err != nil {
+		t.Fatal(err)
+	}
+	if pending := waitForPending(mod); len(pending) != 0 {
+		t.Errorf("saving from Recuerdo was reported: %v", pending)
+	}
+
+	if err := os.WriteFile(path, []byte("changed elsewhere"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := lesson.NewFileSaver().SaveFile(lessonData, path); !errors.Is(err, ErrChangedOnDisk) {
+		t.Fatalf("saving over the change returned %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "changed elsewhere" {
+		t.Error("the change was overwritten")
+	}
+
+	asked := ""
+	mod.SetConfirmOverwrite(func(filePath string) bool {
+		asked = filePath
+		return true
+	})
+	if err := lesson.NewFileSaver().SaveFile(lessonData, path); err != nil {
+		t.Fatal(err)
+	}
+	if asked != path || mod.ChangedOnDisk(path) {
+		t.Errorf("asked about %q, changed on disk %v", asked, mod.ChangedOnDisk(path))
+	}
+}
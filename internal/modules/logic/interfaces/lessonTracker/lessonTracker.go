@@ -1,20 +1,39 @@
-// Package lessontracker provides functionality ported from Python module
-//
-// This is an automated port - implementation may be incomplete.
+// Package lessontracker keeps track of the lessons open in the user
+// interface and of the one the user is working with. Views of the same
+// lesson, such as the Teach tab detached onto a projector and the Enter and
+// Results tabs on the main screen, tell the tracker when they change the
+// lesson, and the tracker tells every interested view, so they stay in
+// sync without knowing about each other.
 package lessontracker
 
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sync"
 
 	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
 )
 
-// LessonTrackerModule is a Go port of the Python LessonTrackerModule class
+// Origins of a change, telling the views which of them made it
+const (
+	// OriginEnter is a change made while editing the words
+	OriginEnter = "enter"
+	// OriginTeach is a change made by practising: answers were recorded
+	OriginTeach = "teach"
+)
+
+// LessonTrackerModule keeps the open lessons and the current lesson
 type LessonTrackerModule struct {
 	*core.BaseModule
 	manager *core.Manager
-	// TODO: Add module-specific fields
+
+	mu              sync.Mutex
+	lessons         []*lesson.Lesson
+	current         *lesson.Lesson
+	changedHandlers []func(l *lesson.Lesson, origin string)
+	currentHandlers []func(l *lesson.Lesson)
 }
 
 // NewLessonTrackerModule creates a new LessonTrackerModule instance
@@ -26,47 +45,109 @@ func NewLessonTrackerModule() *LessonTrackerModule {
 	}
 }
 
-// Lessons is the Go port of the Python lessons method
-func (mod *LessonTrackerModule) Lessons() {
-	// TODO: Port Python method logic
+// Lessons returns the lessons that are open, also those in a tab in the
+// background, in the order they were opened
+func (mod *LessonTrackerModule) Lessons() []*lesson.Lesson {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	return slices.Clone(mod.lessons)
 }
 
-// Currentlesson is the Go port of the Python currentLesson method
-func (mod *LessonTrackerModule) Currentlesson() {
-	// TODO: Port Python method logic
+// CurrentLesson returns the lesson the user is working with, or nil when
+// no lesson is open
+func (mod *LessonTrackerModule) CurrentLesson() *lesson.Lesson {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	return mod.current
 }
 
-// lessonadded is the Go port of the Python _lessonAdded method
-func (mod *LessonTrackerModule) lessonadded() {
-	// TODO: Port Python method logic
+// AddLesson starts tracking a lesson that was opened; it becomes the
+// current lesson
+func (mod *LessonTrackerModule) AddLesson(l *lesson.Lesson) {
+	mod.mu.Lock()
+	if !slices.Contains(mod.lessons, l) {
+		mod.lessons = append(mod.lessons, l)
+	}
+	mod.mu.Unlock()
+	mod.SetCurrentLesson(l)
 }
 
-// removelesson is the Go port of the Python _removeLesson method
-func (mod *LessonTrackerModule) removelesson() {
-	// TODO: Port Python method logic
+// RemoveLesson stops tracking a lesson that was closed. When it was the
+// current lesson, there is none until SetCurrentLesson is called.
+func (mod *LessonTrackerModule) RemoveLesson(l *lesson.Lesson) {
+	mod.mu.Lock()
+	mod.lessons = slices.DeleteFunc(mod.lessons, func(open *lesson.Lesson) bool {
+		return open == l
+	})
+	wasCurrent := mod.current == l
+	mod.mu.Unlock()
+	if wasCurrent {
+		mod.SetCurrentLesson(nil)
+	}
+}
+
+// SetCurrentLesson tells which lesson the user switched to
+func (mod *LessonTrackerModule) SetCurrentLesson(l *lesson.Lesson) {
+	mod.mu.Lock()
+	if mod.current == l {
+		mod.mu.Unlock()
+		return
+	}
+	mod.current = l
+	handlers := slices.Clone(mod.currentHandlers)
+	mod.mu.Unlock()
+	for _, handler := range handlers {
+		handler(l)
+	}
+}
+
+// LessonChanged tells every view that the contents of a lesson changed;
+// origin is where the change was made, such as OriginEnter
+func (mod *LessonTrackerModule) LessonChanged(l *lesson.Lesson, origin string) {
+	mod.mu.Lock()
+	handlers := slices.Clone(mod.changedHandlers)
+	mod.mu.Unlock()
+	for _, handler := range handlers {
+		handler(l, origin)
+	}
+}
+
+// OnLessonChanged registers a function called whenever the contents of a
+// lesson change
+func (mod *LessonTrackerModule) OnLessonChanged(handler func(l *lesson.Lesson, origin string)) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	mod.changedHandlers = append(mod.changedHandlers, handler)
+}
+
+// OnCurrentLessonChanged registers a function called whenever the user
+// switches to another lesson
+func (mod *LessonTrackerModule) OnCurrentLessonChanged(handler func(l *lesson.Lesson)) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	mod.currentHandlers = append(mod.currentHandlers, handler)
 }
 
 // Enable activates the module
-// This is the Go equivalent of the Python enable method
 func (mod *LessonTrackerModule) Enable(ctx context.Context) error {
 	if err := mod.BaseModule.Enable(ctx); err != nil {
 		return err
 	}
 
-	// TODO: Port Python enable logic
-
 	fmt.Println("LessonTrackerModule enabled")
 	return nil
 }
 
 // Disable deactivates the module
-// This is the Go equivalent of the Python disable method
 func (mod *LessonTrackerModule) Disable(ctx context.Context) error {
 	if err := mod.BaseModule.Disable(ctx); err != nil {
 		return err
 	}
 
-	// TODO: Port Python disable logic
+	mod.mu.Lock()
+	mod.lessons = nil
+	mod.current = nil
+	mod.mu.Unlock()
 
 	fmt.Println("LessonTrackerModule disabled")
 	return nil
@@ -0,0 +1,56 @@
+package lessontracker
+
+import (
+	"testing"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+func TestTracking(t *testing.T) {
+	mod := NewLessonTrackerModule()
+	var switched []*lesson.Lesson
+	mod.OnCurrentLessonChanged(func(l *lesson.Lesson) {
+		switched = append(switched, l)
+	})
+
+	first, second := lesson.NewLesson("words"), lesson.NewLesson("words")
+	mod.AddLesson(first)
+	mod.AddLesson(second)
+	if current := mod.CurrentLesson(); current != second {
+		t.Errorf("expected the lesson opened last to be current")
+	}
+	mod.SetCurrentLesson(first)
+	mod.SetCurrentLesson(first)
+	if len(switched) != 3 {
+		t.Errorf("expected 3 switches, got %d", len(switched))
+	}
+
+	mod.RemoveLesson(first)
+	if lessons := mod.Lessons(); len(lessons) != 1 || lessons[0] != second {
+		t.Errorf("unexpected lessons %v", lessons)
+	}
+	if mod.CurrentLesson() != nil || switched[len(switched)-1] != nil {
+		t.Errorf("expected no current lesson after closing it")
+	}
+}
+
+func TestLessonChanged(t *testing.T) {
+	mod := NewLessonTrackerModule()
+	l := lesson.NewLesson("words")
+	mod.AddLesson(l)
+
+	type change struct {
+		lesson *lesson.Lesson
+		origin string
+	}
+	var changes []change
+	for range 2 {
+		mod.OnLessonChanged(func(changed *lesson.Lesson, origin string) {
+			changes = append(changes, change{changed, origin})
+		})
+	}
+	mod.LessonChanged(l, OriginTeach)
+	if len(changes) != 2 || changes[0] != (change{l, OriginTeach}) {
+		t.Errorf("expected every view to hear of the change, got %v", changes)
+	}
+}
@@ -0,0 +1,186 @@
+package typingtutormodel
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"strings"
+)
+
+// CourseFormatVersion is the version of the typing course JSON format
+const CourseFormatVersion = "1.0"
+
+// Exercise types
+const (
+	// ExerciseLetters drills a few keys in random order
+	ExerciseLetters = "letters"
+	// ExerciseWords types random words from the course's word list
+	ExerciseWords = "words"
+)
+
+// Target speeds in words per minute: letter drills are passed at the
+// first, word exercises ramp up to the second
+const (
+	LetterTargetSpeed = 20
+	MaxTargetSpeed    = 80
+)
+
+// Sizes of generated exercises
+const (
+	wordExercises    = 20
+	wordsPerExercise = 8
+	drillLength      = 59
+)
+
+// Course is a typing course: exercises done in order, each passed by
+// typing it without mistakes at its target speed. Courses are stored as
+// JSON, so teachers can write their own.
+type Course struct {
+	FormatVersion string     `json:"file-format-version"`
+	Title         string     `json:"title"`
+	Layout        string     `json:"layout"`
+	Words         []string   `json:"words,omitempty"`
+	Exercises     []Exercise `json:"exercises"`
+}
+
+// Exercise is one step of a course
+type Exercise struct {
+	Type string `json:"type"`
+	// Keys are the keys drilled by a letters exercise
+	Keys string `json:"keys,omitempty"`
+	// WordCount is how many words of the course a words exercise asks
+	WordCount   int    `json:"wordCount,omitempty"`
+	TargetSpeed int    `json:"targetSpeed"`
+	Instruction string `json:"instruction,omitempty"`
+}
+
+// GenerateCourse derives a course from a keyboard layout: the finger drills
+// of the layout followed by word exercises, using those of words that can
+// be typed on the drilled keys without shift
+func GenerateCourse(layoutName string, words []string) (*Course, error) {
+	layout, err := LayoutByName(layoutName)
+	if err != nil {
+		return nil, err
+	}
+
+	course := &Course{
+		FormatVersion: CourseFormatVersion,
+		Title:         layout.Title + " typing course",
+		Layout:        layout.Name,
+		Words:         layout.TypeableWords(words),
+	}
+	for i, keys := range layout.FingerDrills() {
+		exercise := Exercise{Type: ExerciseLetters, Keys: keys, TargetSpeed: LetterTargetSpeed}
+		if i == 0 {
+			exercise.Instruction = fmt.Sprintf("Place your fingers on the home row: %s. Work for accuracy at first, not speed.",
+				strings.Join(layout.HomeRow(), " "))
+		}
+		course.Exercises = append(course.Exercises, exercise)
+	}
+
+	if len(course.Words) >= wordsPerExercise {
+		// gradually increase the speed until the maximum
+		for i := 1; i <= wordExercises; i++ {
+			speed := LetterTargetSpeed + int(math.Round(float64(i)/wordExercises*(MaxTargetSpeed-LetterTargetSpeed)))
+			course.Exercises = append(course.Exercises, Exercise{Type: ExerciseWords, WordCount: wordsPerExercise, TargetSpeed: speed})
+		}
+	}
+	return course, nil
+}
+
+// ParseCourse reads a course from JSON
+func ParseCourse(data []byte) (*Course, error) {
+	var course Course
+	if err := json.Unmarshal(data, &course); err != nil {
+		return nil, fmt.Errorf("invalid typing course: %w", err)
+	}
+	if err := course.Validate(); err != nil {
+		return nil, err
+	}
+	return &course, nil
+}
+
+// LoadCourse reads a course file
+func LoadCourse(path string) (*Course, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCourse(data)
+}
+
+// Save writes the course to path as JSON
+func (c *Course) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Validate checks that the course can be practised
+func (c *Course) Validate() error {
+	if _, err := LayoutByName(c.Layout); err != nil {
+		return err
+	}
+	if len(c.Exercises) == 0 {
+		return fmt.Errorf("typing course %q has no exercises", c.Title)
+	}
+	for i, exercise := range c.Exercises {
+		switch exercise.Type {
+		case ExerciseLetters:
+			if exercise.Keys == "" {
+				return fmt.Errorf("exercise %d drills no keys", i+1)
+			}
+		case ExerciseWords:
+			if len(c.Words) == 0 {
+				return fmt.Errorf("exercise %d asks words, but the course has none", i+1)
+			}
+		default:
+			return fmt.Errorf("exercise %d has unknown type %q", i+1, exercise.Type)
+		}
+		if exercise.TargetSpeed <= 0 {
+			return fmt.Errorf("exercise %d has no target speed", i+1)
+		}
+	}
+	return nil
+}
+
+// ExerciseText returns a new text to type for the exercise at level
+func (c *Course) ExerciseText(level int, rng *rand.Rand) string {
+	exercise := c.Exercises[level]
+	if exercise.Type == ExerciseWords {
+		count := exercise.WordCount
+		if count <= 0 {
+			count = wordsPerExercise
+		}
+		words := make([]string, count)
+		for i, n := range rng.Perm(max(count, len(c.Words)))[:count] {
+			words[i] = c.Words[n%len(c.Words)]
+		}
+		return strings.Join(words, " ")
+	}
+	return drillText(exercise.Keys, rng)
+}
+
+// drillText shuffles the keys, repeated, into a line split into groups of
+// five by spaces
+func drillText(keys string, rng *rand.Rand) string {
+	letters := []rune(keys)
+	row := make([]rune, 0, 80)
+	for len(row) < 80 {
+		row = append(row, letters...)
+	}
+	rng.Shuffle(len(row), func(i, j int) { row[i], row[j] = row[j], row[i] })
+
+	var text []rune
+	for i, r := range row {
+		if i > 0 && i%5 == 0 {
+			text = append(text, ' ')
+		}
+		text = append(text, r)
+	}
+	return strings.TrimSpace(string(text[:drillLength]))
+}
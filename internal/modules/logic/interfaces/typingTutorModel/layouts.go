@@ -0,0 +1,166 @@
+package typingtutormodel
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Layout is a keyboard layout as drawn by the on-screen keyboard: five rows
+// of key labels, from the number row down to the space bar. The letter rows
+// follow an ISO keyboard, so the bottom row has an extra key left of the
+// first letter.
+type Layout struct {
+	Name  string
+	Title string
+	Rows  [][]string
+}
+
+// Names of the built-in layouts
+const (
+	LayoutQWERTY        = "qwerty"
+	LayoutQWERTZ        = "qwertz"
+	LayoutAZERTY        = "azerty"
+	LayoutBelgianAZERTY = "azerty-be"
+	LayoutDvorak        = "dvorak"
+	LayoutColemak       = "colemak"
+)
+
+// DefaultLayout is used for users who did not pick one
+const DefaultLayout = LayoutQWERTY
+
+var layouts = map[string]Layout{
+	LayoutQWERTY: {LayoutQWERTY, "QWERTY", [][]string{
+		{"`", "1", "2", "3", "4", "5", "6", "7", "8", "9", "0", "-", "=", "Back-\nspace"},
+		{"Tab", "q", "w", "e", "r", "t", "y", "u", "i", "o", "p", "[", "]", "Enter"},
+		{"Caps\nLock", "a", "s", "d", "f", "g", "h", "j", "k", "l", ";", "'", "\\", ""},
+		{"Shift", "\\", "z", "x", "c", "v", "b", "n", "m", ",", ".", "/", "Shift"},
+		{"Space"},
+	}},
+	LayoutQWERTZ: {LayoutQWERTZ, "QWERTZ", [][]string{
+		{"^", "1", "2", "3", "4", "5", "6", "7", "8", "9", "0", "ß", "´", "Back-\nspace"},
+		{"Tab", "q", "w", "e", "r", "t", "z", "u", "i", "o", "p", "ü", "+", "Enter"},
+		{"Caps\nLock", "a", "s", "d", "f", "g", "h", "j", "k", "l", "ö", "ä", "#", ""},
+		{"Shift", "<", "y", "x", "c", "v", "b", "n", "m", ",", ".", "-", "Shift"},
+		{"Space"},
+	}},
+	LayoutAZERTY: {LayoutAZERTY, "French AZERTY", [][]string{
+		{"²", "&", "é", "\"", "'", "(", "-", "è", "_", "ç", "à", ")", "=", "Back-\nspace"},
+		{"Tab", "a", "z", "e", "r", "t", "y", "u", "i", "o", "p", "^", "$", "Enter"},
+		{"Caps\nLock", "q", "s", "d", "f", "g", "h", "j", "k", "l", "m", "ù", "*", ""},
+		{"Shift", "<", "w", "x", "c", "v", "b", "n", ",", ";", ":", "!", "Shift"},
+		{"Space"},
+	}},
+	LayoutBelgianAZERTY: {LayoutBelgianAZERTY, "Belgian AZERTY", [][]string{
+		{"²", "&", "é", "\"", "'", "(", "§", "è", "!", "ç", "à", ")", "-", "Back-\nspace"},
+		{"Tab", "a", "z", "e", "r", "t", "y", "u", "i", "o", "p", "^", "$", "Enter"},
+		{"Caps\nLock", "q", "s", "d", "f", "g", "h", "j", "k", "l", "m", "ù", "µ", ""},
+		{"Shift", "<", "w", "x", "c", "v", "b", "n", ",", ";", ":", "=", "Shift"},
+		{"Space"},
+	}},
+	LayoutDvorak: {LayoutDvorak, "Dvorak Simplified Keyboard", [][]string{
+		{"`", "1", "2", "3", "4", "5", "6", "7", "8", "9", "0", "[", "]", "Back-\nspace"},
+		{"Tab", "'", ",", ".", "p", "y", "f", "g", "c", "r", "l", "/", "=", "Enter"},
+		{"Caps\nLock", "a", "o", "e", "u", "i", "d", "h", "t", "n", "s", "-", "\\", ""},
+		{"Shift", "\\", ";", "q", "j", "k", "x", "b", "m", "w", "v", "z", "Shift"},
+		{"Space"},
+	}},
+	LayoutColemak: {LayoutColemak, "Colemak", [][]string{
+		{"`", "1", "2", "3", "4", "5", "6", "7", "8", "9", "0", "-", "=", "Back-\nspace"},
+		{"Tab", "q", "w", "f", "p", "g", "j", "l", "u", "y", ";", "[", "]", "Enter"},
+		{"Back-\nspace", "a", "r", "s", "t", "d", "h", "n", "e", "i", "o", "'", "\\", ""},
+		{"Shift", "\\", "z", "x", "c", "v", "b", "k", "m", ",", ".", "/", "Shift"},
+		{"Space"},
+	}},
+}
+
+// Layouts returns the built-in layouts sorted by title
+func Layouts() []Layout {
+	result := make([]Layout, 0, len(layouts))
+	for _, layout := range layouts {
+		result = append(result, layout)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Title < result[j].Title })
+	return result
+}
+
+// LayoutByName returns the built-in layout called name
+func LayoutByName(name string) (Layout, error) {
+	layout, ok := layouts[strings.ToLower(name)]
+	if !ok {
+		return Layout{}, fmt.Errorf("unknown keyboard layout %q", name)
+	}
+	return layout, nil
+}
+
+// Drill rows, in the order they are learned: home row, top row, bottom row,
+// number row
+var drillRows = []int{2, 1, 3, 0}
+
+// letterKeys returns the ten keys typed by the fingers on a row, from the
+// left little finger to the right one
+func (l Layout) letterKeys(row int) []string {
+	first := 1
+	if row == 3 {
+		// skip the extra ISO key next to left shift
+		first = 2
+	}
+	return l.Rows[row][first : first+10]
+}
+
+// HomeRow returns the keys the fingers rest on, from left to right
+func (l Layout) HomeRow() []string {
+	keys := l.letterKeys(2)
+	return append(append([]string{}, keys[:4]...), keys[6:]...)
+}
+
+// FingerDrills returns the keys practised by each letter exercise: per row,
+// first pairs of keys typed by the same finger of both hands, working
+// outwards from the index fingers, then the keys of each hand, the keys
+// in the middle, and the whole row. A last drill uses every key.
+func (l Layout) FingerDrills() []string {
+	var drills []string
+	var everything strings.Builder
+	for _, row := range drillRows {
+		keys := l.letterKeys(row)
+		join := func(from, to int) string { return strings.Join(keys[from:to], "") }
+		drills = append(drills,
+			keys[3]+keys[6],
+			keys[2]+keys[7],
+			keys[1]+keys[8],
+			keys[0]+keys[9],
+			join(0, 4),
+			join(6, 10),
+			join(4, 6),
+			join(3, 7),
+			join(0, 10),
+		)
+		everything.WriteString(join(0, 10))
+	}
+	return append(drills, everything.String())
+}
+
+// TypeableWords returns the words that only use keys drilled on the
+// layout, typed without shift
+func (l Layout) TypeableWords(words []string) []string {
+	keys := make(map[rune]bool)
+	for _, row := range drillRows {
+		for _, key := range l.letterKeys(row) {
+			for _, r := range key {
+				keys[r] = true
+			}
+		}
+	}
+
+	var typeable []string
+	for _, word := range words {
+		ok := word != ""
+		for _, r := range word {
+			ok = ok && keys[r]
+		}
+		if ok {
+			typeable = append(typeable, word)
+		}
+	}
+	return typeable
+}
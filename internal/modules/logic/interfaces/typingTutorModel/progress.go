@@ -0,0 +1,130 @@
+package typingtutormodel
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"unicode/utf8"
+)
+
+// ProgressSettingPrefix prefixes the settings keys holding the progress on
+// each layout, such as "typingTutor.progress.dvorak"
+const ProgressSettingPrefix = "typingTutor.progress."
+
+// Statuses of a Progress after an exercise
+const (
+	StatusStart    = "start"
+	StatusMistakes = "mistakes"
+	StatusSlow     = "slow"
+	StatusNext     = "next"
+	StatusDone     = "done"
+)
+
+// Settings is the part of the settings module progress is kept in
+type Settings interface {
+	GetSetting(key string) (interface{}, error)
+	SetSetting(key string, value interface{}) error
+}
+
+// Progress is how far a user got in the course of one layout
+type Progress struct {
+	Level  int    `json:"level"`
+	Status string `json:"status"`
+	// Exercise is the text currently to be typed
+	Exercise string   `json:"exercise"`
+	Results  []Result `json:"results"`
+}
+
+// Result is one attempt at an exercise
+type Result struct {
+	Level    int     `json:"level"`
+	Seconds  float64 `json:"seconds"`
+	Mistakes int     `json:"mistakes"`
+	Speed    int     `json:"speed"`
+}
+
+// NewProgress starts a course at its first exercise
+func NewProgress(course *Course, rng *rand.Rand) Progress {
+	return Progress{Status: StatusStart, Exercise: course.ExerciseText(0, rng), Results: []Result{}}
+}
+
+// WordsPerMinute returns the typing speed of text typed in seconds. A word
+// is counted as five characters, as usual for typing speeds.
+func WordsPerMinute(text string, seconds float64) int {
+	if seconds <= 0 {
+		return 0
+	}
+	words := float64(utf8.RuneCountInString(text)) / 5
+	return int(math.Round(words / (seconds / 60)))
+}
+
+// Record adds the result of typing the current exercise and moves on to the
+// next exercise if it was typed without mistakes at the target speed. A
+// new text is picked for the exercise to do next.
+func (p *Progress) Record(course *Course, seconds float64, mistakes int, rng *rand.Rand) {
+	p.Level = min(p.Level, len(course.Exercises)-1)
+	speed := WordsPerMinute(p.Exercise, seconds)
+	p.Results = append(p.Results, Result{Level: p.Level, Seconds: seconds, Mistakes: mistakes, Speed: speed})
+
+	switch {
+	case mistakes > 0:
+		p.Status = StatusMistakes
+	case speed < course.Exercises[p.Level].TargetSpeed:
+		p.Status = StatusSlow
+	case p.Level+1 < len(course.Exercises):
+		p.Level++
+		p.Status = StatusNext
+	default:
+		p.Status = StatusDone
+	}
+	p.Exercise = course.ExerciseText(p.Level, rng)
+}
+
+// Instruction returns what the user is told before the current exercise
+func (p *Progress) Instruction(course *Course) string {
+	switch p.Status {
+	case StatusMistakes:
+		return "You made mistakes, please keep trying until you can do it flawlessly."
+	case StatusSlow:
+		return "You made zero mistakes. Now try to improve your typing speed a bit."
+	case StatusDone:
+		return "Congratulations, you finished this typing course! You can keep practising the last exercise."
+	}
+	if instruction := course.Exercises[min(p.Level, len(course.Exercises)-1)].Instruction; instruction != "" {
+		return instruction
+	}
+	return "You did it flawlessly and fast enough! Continue with the next exercise."
+}
+
+// LoadProgress returns the progress on a layout stored in settings, or
+// false if the layout was not practised yet
+func LoadProgress(settings Settings, layout string) (Progress, bool) {
+	value, err := settings.GetSetting(ProgressSettingPrefix + layout)
+	if err != nil {
+		return Progress{}, false
+	}
+	// Values read back from the settings file are plain maps
+	data, err := json.Marshal(value)
+	if err != nil {
+		return Progress{}, false
+	}
+	var progress Progress
+	if err := json.Unmarshal(data, &progress); err != nil {
+		return Progress{}, false
+	}
+	return progress, true
+}
+
+// SaveProgress stores the progress on a layout in settings, as a plain map
+// so it looks the same after the settings file is read back
+func SaveProgress(settings Settings, layout string, progress Progress) error {
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+	var value map[string]interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	return settings.SetSetting(ProgressSettingPrefix+layout, value)
+}
@@ -1,19 +1,41 @@
-// Package typingtutormodel provides functionality ported from Python module
+// Package typingtutormodel provides the model of the typing tutor: keyboard
+// layouts, typing courses and the progress on each layout.
 //
-// This is an automated port - implementation may be incomplete.
+// Courses are JSON files (see Course). A course file named after a layout,
+// such as dvorak.json, in CourseDir replaces the course generated from that
+// layout's finger drills. Progress is kept per layout in the settings.
 package typingtutormodel
 
 import (
 	"context"
+	_ "embed"
+	"errors"
 	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/paths"
 )
 
+// wordsText is the word list word exercises are picked from
+//
+//go:embed words.txt
+var wordsText string
+
 // TypingTutorModelModule is a Go port of the Python TypingTutorModelModule class
 type TypingTutorModelModule struct {
 	*core.BaseModule
 	manager *core.Manager
-	// TODO: Add module-specific fields
+
+	mu       sync.Mutex
+	settings Settings
+	rng      *rand.Rand
 }
 
 // NewTypingTutorModelModule creates a new TypingTutorModelModule instance
@@ -22,17 +44,104 @@ func NewTypingTutorModelModule() *TypingTutorModelModule {
 
 	return &TypingTutorModelModule{
 		BaseModule: base,
+		rng:        rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// CourseDir returns the directory custom typing courses are read from
+func CourseDir() string {
+	return filepath.Join(paths.DataDir(), "typingCourses")
+}
+
+// words returns the words word exercises are picked from
+func (mod *TypingTutorModelModule) words() []string {
+	var words []string
+	for _, line := range strings.Split(wordsText, "\n") {
+		if word := strings.TrimSpace(line); word != "" {
+			words = append(words, word)
+		}
 	}
+	return words
 }
 
-// words is the Go port of the Python _words method
-func (mod *TypingTutorModelModule) words() {
-	// TODO: Port Python method logic
+// SetSettings sets where progress is kept. Enable uses the default
+// settings module.
+func (mod *TypingTutorModelModule) SetSettings(settings Settings) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	mod.settings = settings
 }
 
-// retranslate is the Go port of the Python _retranslate method
-func (mod *TypingTutorModelModule) retranslate() {
-	// TODO: Port Python method logic
+// Course returns the course for a layout: the course file for it in
+// CourseDir if there is one, a course generated from the layout otherwise
+func (mod *TypingTutorModelModule) Course(layout string) (*Course, error) {
+	course, err := LoadCourse(filepath.Join(CourseDir(), layout+".json"))
+	if err == nil {
+		return course, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		log.Printf("[WARNING] TypingTutorModelModule.Course() - ignoring course file for %s: %v", layout, err)
+	}
+	return GenerateCourse(layout, mod.words())
+}
+
+// Progress returns the progress on a layout, starting its course if it
+// was not practised yet
+func (mod *TypingTutorModelModule) Progress(layout string) (Progress, error) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	return mod.progress(layout)
+}
+
+// progress returns the progress on layout; mod.mu must be held
+func (mod *TypingTutorModelModule) progress(layout string) (Progress, error) {
+	if mod.settings != nil {
+		if progress, ok := LoadProgress(mod.settings, layout); ok {
+			return progress, nil
+		}
+	}
+	course, err := mod.Course(layout)
+	if err != nil {
+		return Progress{}, err
+	}
+	return NewProgress(course, mod.rng), nil
+}
+
+// SetResult records how long typing the current exercise on layout took
+// and how many mistakes were made, and returns the updated progress
+func (mod *TypingTutorModelModule) SetResult(layout string, seconds float64, mistakes int) (Progress, error) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+
+	course, err := mod.Course(layout)
+	if err != nil {
+		return Progress{}, err
+	}
+	progress, err := mod.progress(layout)
+	if err != nil {
+		return Progress{}, err
+	}
+	progress.Record(course, seconds, mistakes, mod.rng)
+
+	if mod.settings == nil {
+		return progress, fmt.Errorf("no settings to keep typing progress in")
+	}
+	return progress, SaveProgress(mod.settings, layout, progress)
+}
+
+// ResetProgress starts the course of a layout over
+func (mod *TypingTutorModelModule) ResetProgress(layout string) error {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+
+	course, err := mod.Course(layout)
+	if err != nil {
+		return err
+	}
+	if mod.settings == nil {
+		return fmt.Errorf("no settings to keep typing progress in")
+	}
+	return SaveProgress(mod.settings, layout, NewProgress(course, mod.rng))
 }
 
 // Enable activates the module
@@ -42,7 +151,13 @@ func (mod *TypingTutorModelModule) Enable(ctx context.Context) error {
 		return err
 	}
 
-	// TODO: Port Python enable logic
+	if mod.manager != nil && mod.settings == nil {
+		if module, ok := mod.manager.GetDefaultModule("settings"); ok {
+			if settings, ok := module.(Settings); ok {
+				mod.SetSettings(settings)
+			}
+		}
+	}
 
 	fmt.Println("TypingTutorModelModule enabled")
 	return nil
@@ -55,8 +170,6 @@ func (mod *TypingTutorModelModule) Disable(ctx context.Context) error {
 		return err
 	}
 
-	// TODO: Port Python disable logic
-
 	fmt.Println("TypingTutorModelModule disabled")
 	return nil
 }
@@ -70,4 +183,4 @@ func (mod *TypingTutorModelModule) SetManager(manager *core.Manager) {
 // This is the Go equivalent of the Python init function
 func InitTypingTutorModelModule() core.Module {
 	return NewTypingTutorModelModule()
-}
\ No newline at end of file
+}
@@ -0,0 +1,161 @@
+package typingtutormodel
+
+import (
+	"context"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/LaPingvino/recuerdo/internal/modules"
+)
+
+func TestFingerDrills(t *testing.T) {
+	for _, layout := range Layouts() {
+		drills := layout.FingerDrills()
+		if len(drills) != 37 {
+			t.Errorf("%s: expected 37 drills, got %d", layout.Name, len(drills))
+		}
+	}
+
+	dvorak, _ := LayoutByName(LayoutDvorak)
+	drills := dvorak.FingerDrills()
+	if drills[0] != "uh" || drills[8] != "aoeuidhtns" {
+		t.Errorf("Unexpected Dvorak home row drills %q and %q", drills[0], drills[8])
+	}
+	// the bottom row skips the extra ISO key
+	if drills[26] != ";qjkxbmwvz" {
+		t.Errorf("Unexpected Dvorak bottom row drill %q", drills[26])
+	}
+	if home := strings.Join(dvorak.HomeRow(), ""); home != "aoeuhtns" {
+		t.Errorf("Unexpected Dvorak home row %q", home)
+	}
+
+	azerty, _ := LayoutByName("AZERTY")
+	if got := azerty.FingerDrills()[8]; got != "qsdfghjklm" {
+		t.Errorf("Unexpected AZERTY home row drill %q", got)
+	}
+}
+
+func TestGenerateCourse(t *testing.T) {
+	course, err := GenerateCourse(LayoutColemak, []string{"arst", "tone", "the", "hello", "wind", "fair", "neat", "read", "Hello", "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := course.Validate(); err != nil {
+		t.Fatalf("Generated course is invalid: %v", err)
+	}
+	for _, word := range course.Words {
+		if word == "Hello" {
+			t.Error("Words that need shift should be left out")
+		}
+	}
+	last := course.Exercises[len(course.Exercises)-1]
+	if last.Type != ExerciseWords || last.TargetSpeed != MaxTargetSpeed {
+		t.Errorf("Expected a last words exercise at %d wpm, got %+v", MaxTargetSpeed, last)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	drill := course.ExerciseText(0, rng)
+	if len([]rune(drill)) > drillLength || strings.Trim(drill, "tn ") != "" {
+		t.Errorf("Unexpected first drill %q", drill)
+	}
+	if words := strings.Fields(course.ExerciseText(len(course.Exercises)-1, rng)); len(words) != wordsPerExercise {
+		t.Errorf("Expected %d words, got %v", wordsPerExercise, words)
+	}
+
+	if _, err := GenerateCourse("bépo", nil); err == nil {
+		t.Error("Expected an error for an unknown layout")
+	}
+}
+
+func TestCourseFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "course.json")
+	course := &Course{
+		FormatVersion: CourseFormatVersion,
+		Title:         "Home row",
+		Layout:        LayoutQWERTY,
+		Exercises:     []Exercise{{Type: ExerciseLetters, Keys: "asdf", TargetSpeed: 15}},
+	}
+	if err := course.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadCourse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Title != "Home row" || loaded.Exercises[0].Keys != "asdf" {
+		t.Errorf("Course not read back: %+v", loaded)
+	}
+
+	if _, err := ParseCourse([]byte(`{"layout": "qwerty", "exercises": [{"type": "words", "targetSpeed": 20}]}`)); err == nil {
+		t.Error("Expected an error for a words exercise without words")
+	}
+}
+
+func TestProgressRecord(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	course := &Course{
+		Layout: LayoutQWERTY,
+		Exercises: []Exercise{
+			{Type: ExerciseLetters, Keys: "fj", TargetSpeed: 20},
+			{Type: ExerciseLetters, Keys: "dk", TargetSpeed: 20},
+		},
+	}
+	progress := NewProgress(course, rng)
+	// 59 characters in 60 seconds is 12 wpm
+	progress.Record(course, 60, 0, rng)
+	if progress.Status != StatusSlow || progress.Level != 0 {
+		t.Errorf("Expected to be too slow, got %+v", progress)
+	}
+	progress.Record(course, 10, 2, rng)
+	if progress.Status != StatusMistakes || progress.Level != 0 {
+		t.Errorf("Expected mistakes, got %+v", progress)
+	}
+	progress.Record(course, 10, 0, rng)
+	if progress.Status != StatusNext || progress.Level != 1 || !strings.ContainsAny(progress.Exercise, "dk") {
+		t.Errorf("Expected the next exercise, got %+v", progress)
+	}
+	progress.Record(course, 10, 0, rng)
+	if progress.Status != StatusDone || progress.Level != 1 || len(progress.Results) != 4 {
+		t.Errorf("Expected the course to be done, got %+v", progress)
+	}
+}
+
+func TestProgressPerLayoutInSettings(t *testing.T) {
+	settings := modules.NewSettingsModule()
+	if err := settings.SetSettingsPath(filepath.Join(t.TempDir(), "settings.json")); err != nil {
+		t.Fatal(err)
+	}
+	if err := settings.Enable(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	mod := NewTypingTutorModelModule()
+	mod.SetSettings(settings)
+	if _, err := mod.SetResult(LayoutDvorak, 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := settings.SaveSettings(); err != nil {
+		t.Fatal(err)
+	}
+
+	// read the progress back from the settings file
+	if err := settings.LoadSettings(); err != nil {
+		t.Fatal(err)
+	}
+	dvorak, err := mod.Progress(LayoutDvorak)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dvorak.Level != 1 || len(dvorak.Results) != 1 {
+		t.Errorf("Dvorak progress not kept: %+v", dvorak)
+	}
+	qwerty, err := mod.Progress(LayoutQWERTY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if qwerty.Level != 0 || qwerty.Status != StatusStart {
+		t.Errorf("QWERTY should not have progressed: %+v", qwerty)
+	}
+}
@@ -0,0 +1,86 @@
+// Package transliteration provides an item modifier that compares answers in
+// a romanization, so "nǐ hǎo", "ni3 hao3" and "ni hao" or "おちゃ" and "ocha"
+// are all accepted.
+package transliteration
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// TransliterationModule lets lessons pick the transliteration their answers
+// are checked in. The tables themselves live in the lesson package, where
+// other ones can be registered with lesson.RegisterTransliterator.
+type TransliterationModule struct {
+	*core.BaseModule
+	manager *core.Manager
+}
+
+// NewTransliterationModule creates a new TransliterationModule instance
+func NewTransliterationModule() *TransliterationModule {
+	base := core.NewBaseModule("logic", "transliteration-module")
+
+	return &TransliterationModule{
+		BaseModule: base,
+	}
+}
+
+// Names returns the transliterations a lesson can choose from
+func (mod *TransliterationModule) Names() []string {
+	return lesson.Transliterations()
+}
+
+// SetLessonTransliteration makes the lesson check answers in the named
+// transliteration; "" turns it off
+func (mod *TransliterationModule) SetLessonTransliteration(lessonData *lesson.LessonData, name string) error {
+	return lessonData.SetTransliteration(name)
+}
+
+// Modifyitem returns a copy of item with its answers written in the lesson's
+// transliteration, for showing the expected romanization after a mistake.
+// The item is returned unchanged when the lesson has none.
+func (mod *TransliterationModule) Modifyitem(lessonData *lesson.LessonData, item lesson.WordItem) lesson.WordItem {
+	transliterator, ok := lesson.TransliteratorFor(lessonData.Transliteration())
+	if !ok {
+		return item
+	}
+	answers := make([]string, len(item.Answers))
+	for i, answer := range item.Answers {
+		answers[i] = transliterator.Transliterate(answer)
+	}
+	item.Answers = answers
+	return item
+}
+
+// Enable activates the module
+func (mod *TransliterationModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("TransliterationModule enabled")
+	return nil
+}
+
+// Disable deactivates the module
+func (mod *TransliterationModule) Disable(ctx context.Context) error {
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("TransliterationModule disabled")
+	return nil
+}
+
+// SetManager sets the module manager
+func (mod *TransliterationModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// InitTransliterationModule creates and returns a new TransliterationModule instance
+func InitTransliterationModule() core.Module {
+	return NewTransliterationModule()
+}
@@ -0,0 +1,219 @@
+// Package lessonlibrary keeps a full-text index of the lessons in the
+// user's lesson directory, so the GUI can open a lesson by searching for a
+// word it contains. The index itself is implemented by package library; the
+// module also keeps a thumbnail of every indexed lesson.
+package lessonlibrary
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/LaPingvino/recuerdo/internal/library"
+	"github.com/LaPingvino/recuerdo/internal/paths"
+	"github.com/LaPingvino/recuerdo/internal/thumbnail"
+)
+
+// Settings is the part of the settings module the library reads and stores
+// its directory with
+type Settings interface {
+	GetString(key string) (string, error)
+	SetSetting(key string, value interface{}) error
+}
+
+// LessonLibraryModule indexes the lesson directory when enabled and answers
+// searches from the index
+type LessonLibraryModule struct {
+	*core.BaseModule
+	manager *core.Manager
+	// dbPath is where the index is stored and thumbnailDir where the
+	// thumbnails are; both can be recreated any time
+	dbPath       string
+	thumbnailDir string
+	renderer     *thumbnail.Renderer
+	directory    string
+	library      *library.Library
+	// indexing is held while the directory is being (re)indexed
+	indexing sync.Mutex
+	mu       sync.Mutex
+}
+
+// NewLessonLibraryModule creates a new LessonLibraryModule instance
+func NewLessonLibraryModule() *LessonLibraryModule {
+	base := core.NewBaseModule("library", "lesson-library-module")
+
+	return &LessonLibraryModule{
+		BaseModule:   base,
+		dbPath:       filepath.Join(paths.CacheDir(), "library.db"),
+		thumbnailDir: filepath.Join(paths.CacheDir(), "library-thumbnails"),
+		renderer:     &thumbnail.Renderer{Width: thumbnail.IconWidth, Height: thumbnail.IconHeight, Maps: thumbnail.LocalMaps()},
+		directory:    paths.LessonDir(),
+	}
+}
+
+// Directory returns the directory the library indexes
+func (mod *LessonLibraryModule) Directory() string {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	return mod.directory
+}
+
+// SetDirectory makes the library index dir instead, remembering it in the
+// settings, and indexes it
+func (mod *LessonLibraryModule) SetDirectory(dir string) (library.IndexStats, error) {
+	mod.mu.Lock()
+	mod.directory = dir
+	mod.mu.Unlock()
+	if settings, ok := mod.settings(); ok {
+		if err := settings.SetSetting(library.DirectorySetting, dir); err != nil {
+			return library.IndexStats{}, err
+		}
+	}
+	return mod.Reindex()
+}
+
+// Reindex brings the index up to date with the lesson directory
+func (mod *LessonLibraryModule) Reindex() (library.IndexStats, error) {
+	lib, err := mod.open()
+	if err != nil {
+		return library.IndexStats{}, err
+	}
+	mod.indexing.Lock()
+	defer mod.indexing.Unlock()
+	return lib.Index(mod.Directory())
+}
+
+// Search returns the lessons containing all words of query
+func (mod *LessonLibraryModule) Search(query string) ([]library.Result, error) {
+	lib, err := mod.open()
+	if err != nil {
+		return nil, err
+	}
+	return lib.Search(query, library.DefaultSearchLimit)
+}
+
+// Count returns the number of indexed lessons
+func (mod *LessonLibraryModule) Count() (int, error) {
+	lib, err := mod.open()
+	if err != nil {
+		return 0, err
+	}
+	return lib.Count()
+}
+
+// Thumbnail returns the path of a thumbnail of the lesson file at path.
+// Thumbnails are drawn while indexing; one that is missing or older than
+// the lesson is drawn now.
+func (mod *LessonLibraryModule) Thumbnail(path string) (string, error) {
+	name := thumbnail.CachePath(mod.thumbnailDir, path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if cached, err := os.Stat(name); err == nil && !cached.ModTime().Before(info.ModTime()) {
+		return name, nil
+	}
+	lessonData, err := lesson.NewFileLoader().LoadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if err := mod.renderer.WriteFile(name, lessonData); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// writeThumbnail keeps the thumbnail of a lesson that was just indexed
+func (mod *LessonLibraryModule) writeThumbnail(path string, lessonData *lesson.LessonData) {
+	if err := mod.renderer.WriteFile(thumbnail.CachePath(mod.thumbnailDir, path), lessonData); err != nil {
+		fmt.Printf("Warning: failed to create thumbnail: %v\n", err)
+	}
+}
+
+// open opens the index the first time it is needed
+func (mod *LessonLibraryModule) open() (*library.Library, error) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	if mod.library != nil {
+		return mod.library, nil
+	}
+	lib, err := library.Open(mod.dbPath)
+	if err != nil {
+		return nil, err
+	}
+	lib.OnIndexed(mod.writeThumbnail)
+	mod.library = lib
+	return lib, nil
+}
+
+func (mod *LessonLibraryModule) settings() (Settings, bool) {
+	if mod.manager == nil {
+		return nil, false
+	}
+	module, ok := mod.manager.GetDefaultModule("settings")
+	if !ok {
+		return nil, false
+	}
+	settings, ok := module.(Settings)
+	return settings, ok
+}
+
+// Enable activates the module, reading the directory from the settings and
+// indexing it in the background
+func (mod *LessonLibraryModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	if settings, ok := mod.settings(); ok {
+		if dir, err := settings.GetString(library.DirectorySetting); err == nil && dir != "" {
+			mod.mu.Lock()
+			mod.directory = dir
+			mod.mu.Unlock()
+		}
+	}
+	go func() {
+		if stats, err := mod.Reindex(); err != nil {
+			fmt.Printf("Warning: failed to index lesson library: %v\n", err)
+		} else if stats.Added+stats.Updated+stats.Removed > 0 {
+			fmt.Printf("Lesson library: %d added, %d updated, %d removed\n", stats.Added, stats.Updated, stats.Removed)
+		}
+	}()
+
+	fmt.Println("LessonLibraryModule enabled")
+	return nil
+}
+
+// Disable deactivates the module, closing the index
+func (mod *LessonLibraryModule) Disable(ctx context.Context) error {
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	mod.indexing.Lock()
+	defer mod.indexing.Unlock()
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	if mod.library != nil {
+		mod.library.Close()
+		mod.library = nil
+	}
+
+	fmt.Println("LessonLibraryModule disabled")
+	return nil
+}
+
+// SetManager sets the module manager
+func (mod *LessonLibraryModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// InitLessonLibraryModule creates and returns a new LessonLibraryModule
+// instance
+func InitLessonLibraryModule() core.Module {
+	return NewLessonLibraryModule()
+}
@@ -0,0 +1,59 @@
+package lessonlibrary
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetDirectorySearchesNewDirectory(t *testing.T) {
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "first"), filepath.Join(dir, "second")
+	for path, content := range map[string]string{
+		filepath.Join(first, "animals.csv"): "cat,kat\n",
+		filepath.Join(second, "food.csv"):   "bread,brood\n",
+	} {
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mod := NewLessonLibraryModule()
+	mod.dbPath = filepath.Join(dir, "library.db")
+	mod.thumbnailDir = filepath.Join(dir, "thumbnails")
+	mod.directory = first
+	defer mod.Disable(context.Background())
+
+	if _, err := mod.Reindex(); err != nil {
+		t.Fatalf("Reindex: %v", err)
+	}
+	if results, err := mod.Search("kat"); err != nil || len(results) != 1 {
+		t.Fatalf("expected the first lesson, got %+v, %v", results, err)
+	}
+	thumbnail, err := mod.Thumbnail(filepath.Join(first, "animals.csv"))
+	if err != nil {
+		t.Fatalf("Thumbnail: %v", err)
+	}
+	if filepath.Dir(thumbnail) != mod.thumbnailDir {
+		t.Errorf("unexpected thumbnail %s", thumbnail)
+	}
+	if _, err := os.Stat(thumbnail); err != nil {
+		t.Errorf("expected the thumbnail to be drawn while indexing: %v", err)
+	}
+
+	stats, err := mod.SetDirectory(second)
+	if err != nil {
+		t.Fatalf("SetDirectory: %v", err)
+	}
+	if stats.Added != 1 || stats.Removed != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if results, _ := mod.Search("kat"); len(results) != 0 {
+		t.Errorf("expected the first directory to be forgotten, got %+v", results)
+	}
+	if results, _ := mod.Search("brood"); len(results) != 1 || results[0].Path != filepath.Join(second, "food.csv") {
+		t.Errorf("expected the second lesson, got %+v", results)
+	}
+}
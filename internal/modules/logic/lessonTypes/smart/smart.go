@@ -6,7 +6,10 @@ package smart
 import (
 	"context"
 	"fmt"
+	"time"
+
 	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
 )
 
 // SmartModule is a Go port of the Python SmartModule class
@@ -35,9 +38,21 @@ func (mod *SmartModule) createevent() {
 	// TODO: Port Python method logic
 }
 
-// Createlessontype is the Go port of the Python createLessonType method
-func (mod *SmartModule) Createlessontype() {
-	// TODO: Port Python method logic
+// Createlessontype returns the cards a smart lesson asks of the items of
+// indexes in direction, the ones weakest in their own direction first
+func (mod *SmartModule) Createlessontype(list *lesson.WordList, indexes []int, direction string) []lesson.Card {
+	return lesson.SmartOrder(list, lesson.Cards(indexes, direction))
+}
+
+// SetResult records the answer to the card at position of queue, which
+// took answerTime, and returns the queue to go on with, asking a wrongly
+// answered card again
+func (mod *SmartModule) SetResult(list *lesson.WordList, queue []lesson.Card, position int, grade lesson.Grade, answerTime time.Duration) []lesson.Card {
+	list.AddDirectedResult(queue[position], grade, answerTime)
+	if grade.Correct {
+		return queue
+	}
+	return lesson.RequeueWrong(queue, position)
 }
 
 // Enable activates the module
@@ -75,4 +90,4 @@ func (mod *SmartModule) SetManager(manager *core.Manager) {
 // This is the Go equivalent of the Python init function
 func InitSmartModule() core.Module {
 	return NewSmartModule()
-}
\ No newline at end of file
+}
@@ -0,0 +1,93 @@
+// Package bychapter provides a list modifier that restricts a practice
+// session to the words of some chapters of a list, such as the KVTML
+// lessons or Anki subdecks it was imported from.
+package bychapter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// ByChapterModule keeps the words in one of the chosen chapters
+type ByChapterModule struct {
+	*core.BaseModule
+	manager *core.Manager
+
+	mu       sync.RWMutex
+	chapters [][]string
+}
+
+// NewByChapterModule creates a new ByChapterModule instance
+func NewByChapterModule() *ByChapterModule {
+	base := core.NewBaseModule("logic", "bychapter-module")
+
+	return &ByChapterModule{
+		BaseModule: base,
+	}
+}
+
+// SetChapters chooses the chapters words must be in one of, sub-chapters
+// included. No chapters keeps every word.
+func (mod *ByChapterModule) SetChapters(chapters [][]string) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	mod.chapters = nil
+	for _, chapter := range chapters {
+		mod.chapters = append(mod.chapters, append([]string(nil), chapter...))
+	}
+}
+
+// Chapters returns the chosen chapters
+func (mod *ByChapterModule) Chapters() [][]string {
+	mod.mu.RLock()
+	defer mod.mu.RUnlock()
+	var chapters [][]string
+	for _, chapter := range mod.chapters {
+		chapters = append(chapters, append([]string(nil), chapter...))
+	}
+	return chapters
+}
+
+// Modifylist keeps the words of indexes in one of the chosen chapters.
+// Suspended words are left out and always-ask words added.
+func (mod *ByChapterModule) Modifylist(indexes []int, list *lesson.WordList) []int {
+	chapters := mod.Chapters()
+	if len(chapters) == 0 {
+		return lesson.ApplyDifficulty(list, indexes)
+	}
+	return lesson.ChapterItems(list, indexes, chapters)
+}
+
+// Enable activates the module
+func (mod *ByChapterModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("ByChapterModule enabled")
+	return nil
+}
+
+// Disable deactivates the module
+func (mod *ByChapterModule) Disable(ctx context.Context) error {
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("ByChapterModule disabled")
+	return nil
+}
+
+// SetManager sets the module manager
+func (mod *ByChapterModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// InitByChapterModule creates and returns a new ByChapterModule instance
+func InitByChapterModule() core.Module {
+	return NewByChapterModule()
+}
@@ -0,0 +1,86 @@
+// Package bytag provides a list modifier that restricts a practice session
+// to the words tagged with one of a set of tags, such as "chapter-3" or
+// "irregular-verbs".
+package bytag
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// ByTagModule keeps the words carrying one of the chosen tags
+type ByTagModule struct {
+	*core.BaseModule
+	manager *core.Manager
+
+	mu   sync.RWMutex
+	tags []string
+}
+
+// NewByTagModule creates a new ByTagModule instance
+func NewByTagModule() *ByTagModule {
+	base := core.NewBaseModule("logic", "bytag-module")
+
+	return &ByTagModule{
+		BaseModule: base,
+	}
+}
+
+// SetTags chooses the tags words must carry one of. No tags keeps every
+// word.
+func (mod *ByTagModule) SetTags(tags []string) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	mod.tags = append([]string(nil), tags...)
+}
+
+// Tags returns the chosen tags
+func (mod *ByTagModule) Tags() []string {
+	mod.mu.RLock()
+	defer mod.mu.RUnlock()
+	return append([]string(nil), mod.tags...)
+}
+
+// Modifylist keeps the words of indexes tagged with one of the chosen tags.
+// Suspended words are left out and always-ask words added.
+func (mod *ByTagModule) Modifylist(indexes []int, list *lesson.WordList) []int {
+	tags := mod.Tags()
+	if len(tags) == 0 {
+		return lesson.ApplyDifficulty(list, indexes)
+	}
+	return lesson.TaggedItems(list, indexes, tags)
+}
+
+// Enable activates the module
+func (mod *ByTagModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("ByTagModule enabled")
+	return nil
+}
+
+// Disable deactivates the module
+func (mod *ByTagModule) Disable(ctx context.Context) error {
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("ByTagModule disabled")
+	return nil
+}
+
+// SetManager sets the module manager
+func (mod *ByTagModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// InitByTagModule creates and returns a new ByTagModule instance
+func InitByTagModule() core.Module {
+	return NewByTagModule()
+}
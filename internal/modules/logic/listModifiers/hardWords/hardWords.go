@@ -6,7 +6,9 @@ package hardwords
 import (
 	"context"
 	"fmt"
+
 	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
 )
 
 // HardWordsModule is a Go port of the Python HardWordsModule class
@@ -25,19 +27,11 @@ func NewHardWordsModule() *HardWordsModule {
 	}
 }
 
-// Modifylist is the Go port of the Python modifyList method
-func (mod *HardWordsModule) Modifylist() {
-	// TODO: Port Python method logic
-}
-
-// ishardword is the Go port of the Python _isHardWord method
-func (mod *HardWordsModule) ishardword() {
-	// TODO: Port Python method logic
-}
-
-// resultsfor is the Go port of the Python _resultsFor method
-func (mod *HardWordsModule) resultsfor() {
-	// TODO: Port Python method logic
+// Modifylist keeps the hard words of indexes: those answered wrong
+// more often than right, and those never asked. Suspended words are left
+// out and always-ask words added.
+func (mod *HardWordsModule) Modifylist(indexes []int, list *lesson.WordList) []int {
+	return lesson.HardItems(list, indexes)
 }
 
 // retranslate is the Go port of the Python _retranslate method
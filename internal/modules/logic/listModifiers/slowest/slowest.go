@@ -0,0 +1,64 @@
+// Package slowest provides a list modifier that asks the words that took
+// longest to answer first, so words that do not come up quickly are
+// practised even when they are answered right.
+package slowest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// SlowestModule orders words by how long answering them took
+type SlowestModule struct {
+	*core.BaseModule
+	manager *core.Manager
+}
+
+// NewSlowestModule creates a new SlowestModule instance
+func NewSlowestModule() *SlowestModule {
+	base := core.NewBaseModule("logic", "slowest-module")
+
+	return &SlowestModule{
+		BaseModule: base,
+	}
+}
+
+// Modifylist orders the words of indexes by their average answer time, the
+// slowest first and those never timed last. Suspended words are left out
+// and always-ask words added.
+func (mod *SlowestModule) Modifylist(indexes []int, list *lesson.WordList) []int {
+	return lesson.SlowestItems(list, indexes)
+}
+
+// Enable activates the module
+func (mod *SlowestModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("SlowestModule enabled")
+	return nil
+}
+
+// Disable deactivates the module
+func (mod *SlowestModule) Disable(ctx context.Context) error {
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("SlowestModule disabled")
+	return nil
+}
+
+// SetManager sets the module manager
+func (mod *SlowestModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// InitSlowestModule creates and returns a new SlowestModule instance
+func InitSlowestModule() core.Module {
+	return NewSlowestModule()
+}
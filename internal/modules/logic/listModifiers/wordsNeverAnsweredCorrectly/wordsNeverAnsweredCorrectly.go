@@ -10,7 +10,9 @@ package wordsneveransweredcorrectly
 import (
 	"context"
 	"fmt"
+
 	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
 )
 
 // WordsNeverAnsweredCorrectlyModule is a Go port of the Python WordsNeverAnsweredCorrectlyModule class
@@ -29,19 +31,10 @@ func NewWordsNeverAnsweredCorrectlyModule() *WordsNeverAnsweredCorrectlyModule {
 	}
 }
 
-// Modifylist is the Go port of the Python modifyList method
-func (mod *WordsNeverAnsweredCorrectlyModule) Modifylist() {
-	// TODO: Port Python method logic
-}
-
-// isneveransweredcorrectly is the Go port of the Python _isNeverAnsweredCorrectly method
-func (mod *WordsNeverAnsweredCorrectlyModule) isneveransweredcorrectly() {
-	// TODO: Port Python method logic
-}
-
-// resultsfor is the Go port of the Python _resultsFor method
-func (mod *WordsNeverAnsweredCorrectlyModule) resultsfor() {
-	// TODO: Port Python method logic
+// Modifylist keeps the words of indexes that were never answered
+// right. Suspended words are left out and always-ask words added.
+func (mod *WordsNeverAnsweredCorrectlyModule) Modifylist(indexes []int, list *lesson.WordList) []int {
+	return lesson.NeverAnsweredCorrectlyItems(list, indexes)
 }
 
 // retranslate is the Go port of the Python _retranslate method
@@ -0,0 +1,90 @@
+// Package quizlet provides Quizlet set import using the centralized FileLoader
+package quizlet
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// QuizletLoaderModule imports Quizlet sets from exported text or a set URL
+type QuizletLoaderModule struct {
+	*core.BaseModule
+	manager    *core.Manager
+	fileLoader *lesson.FileLoader
+	client     *http.Client
+}
+
+// NewQuizletLoaderModule creates a new QuizletLoaderModule instance
+func NewQuizletLoaderModule() *QuizletLoaderModule {
+	base := core.NewBaseModule("logic", "quizlet-module")
+
+	return &QuizletLoaderModule{
+		BaseModule: base,
+		fileLoader: lesson.NewFileLoader(),
+		client:     &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Getfiletypeof returns the lesson type for files this loader understands
+func (mod *QuizletLoaderModule) Getfiletypeof(path string) string {
+	return mod.fileLoader.GetFileType(path)
+}
+
+// Load loads a Quizlet export saved as a .quizlet file
+func (mod *QuizletLoaderModule) Load(path string) (*lesson.LessonData, error) {
+	if !mod.IsActive() {
+		return nil, fmt.Errorf("quizlet loader module is not active")
+	}
+	return mod.fileLoader.LoadFile(path)
+}
+
+// LoadText converts pasted Quizlet export text into lesson data
+func (mod *QuizletLoaderModule) LoadText(text string, opts lesson.QuizletOptions) (*lesson.LessonData, error) {
+	if !mod.IsActive() {
+		return nil, fmt.Errorf("quizlet loader module is not active")
+	}
+	return mod.fileLoader.ParseQuizletExport(text, opts)
+}
+
+// LoadURL fetches a Quizlet set by URL and converts it into lesson data
+func (mod *QuizletLoaderModule) LoadURL(ctx context.Context, setURL string) (*lesson.LessonData, error) {
+	if !mod.IsActive() {
+		return nil, fmt.Errorf("quizlet loader module is not active")
+	}
+	return mod.fileLoader.FetchQuizletSet(ctx, mod.client, setURL)
+}
+
+// Enable activates the module
+func (mod *QuizletLoaderModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("QuizletLoaderModule enabled")
+	return nil
+}
+
+// Disable deactivates the module
+func (mod *QuizletLoaderModule) Disable(ctx context.Context) error {
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("QuizletLoaderModule disabled")
+	return nil
+}
+
+// SetManager sets the module manager
+func (mod *QuizletLoaderModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// InitQuizletLoaderModule creates and returns a new QuizletLoaderModule instance
+func InitQuizletLoaderModule() core.Module {
+	return NewQuizletLoaderModule()
+}
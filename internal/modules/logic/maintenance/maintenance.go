@@ -0,0 +1,297 @@
+// Package maintenance runs housekeeping in the background while the user
+// is not doing anything: updating the lesson library, evicting old cache
+// files, rotating backups of the settings and aggregating statistics.
+//
+// The GUI reports every key press and mouse click with Touch. A task only
+// starts once Recuerdo has been idle for a while, and the context it runs
+// with is cancelled as soon as the user becomes active again or Recuerdo
+// shuts down, so maintenance never gets in the way.
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/paths"
+)
+
+// IdleSetting is the settings key holding after how many seconds without
+// user activity maintenance starts
+const IdleSetting = "maintenance.idleDelay"
+
+// DefaultIdleAfter is how long Recuerdo has to be idle before maintenance
+// starts when IdleSetting is unset
+const DefaultIdleAfter = 2 * time.Minute
+
+// Task is housekeeping that is done every so often
+type Task struct {
+	Name  string
+	Every time.Duration
+	// Run does the work, returning ctx.Err() when it stopped early because
+	// ctx was cancelled
+	Run func(ctx context.Context) error
+}
+
+// Settings is the part of the settings module the idle delay is read from
+type Settings interface {
+	GetInt(key string) (int, error)
+}
+
+// MaintenanceModule runs maintenance tasks while the user is idle
+type MaintenanceModule struct {
+	*core.BaseModule
+	manager *core.Manager
+	// statePath is the file keeping when every task last ran, so daily
+	// tasks do not run at every start
+	statePath  string
+	idleAfter  time.Duration
+	checkEvery time.Duration
+	tasks      []Task
+	lastRun    map[string]time.Time
+	// lastActivity is when the user last did something
+	lastActivity time.Time
+	// interrupt cancels the task that is running, if any
+	interrupt context.CancelFunc
+	// stop ends the scheduler, which closes done when it has
+	stop context.CancelFunc
+	done chan struct{}
+	now  func() time.Time
+	// running serializes RunDue
+	running sync.Mutex
+	mu      sync.Mutex
+}
+
+// NewMaintenanceModule creates a new MaintenanceModule instance
+func NewMaintenanceModule() *MaintenanceModule {
+	base := core.NewBaseModule("maintenance", "maintenance-module")
+
+	return &MaintenanceModule{
+		BaseModule: base,
+		statePath:  filepath.Join(paths.DataDir(), "maintenance.json"),
+		idleAfter:  DefaultIdleAfter,
+		checkEvery: 30 * time.Second,
+		lastRun:    make(map[string]time.Time),
+		now:        time.Now,
+	}
+}
+
+// Register adds a task, replacing a task with the same name
+func (mod *MaintenanceModule) Register(task Task) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	for i, registered := range mod.tasks {
+		if registered.Name == task.Name {
+			mod.tasks[i] = task
+			return
+		}
+	}
+	mod.tasks = append(mod.tasks, task)
+}
+
+// Touch tells the module the user did something, interrupting the task
+// that is running
+func (mod *MaintenanceModule) Touch() {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	mod.lastActivity = mod.now()
+	if mod.interrupt != nil {
+		mod.interrupt()
+		mod.interrupt = nil
+	}
+}
+
+// Idle reports whether the user has done nothing for long enough to start
+// maintenance
+func (mod *MaintenanceModule) Idle() bool {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	return mod.idle()
+}
+
+// idle is Idle for callers holding mu
+func (mod *MaintenanceModule) idle() bool {
+	return mod.now().Sub(mod.lastActivity) >= mod.idleAfter
+}
+
+// LastRun returns when a task last ran, or the zero time if never
+func (mod *MaintenanceModule) LastRun(name string) time.Time {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	return mod.lastRun[name]
+}
+
+// RunDue runs the tasks that are due one after another as long as the user
+// stays idle, and returns the names of those that ran. A task interrupted
+// by the user runs again at the next idle moment; one that failed waits
+// for its next turn like one that succeeded.
+func (mod *MaintenanceModule) RunDue(ctx context.Context) []string {
+	mod.running.Lock()
+	defer mod.running.Unlock()
+
+	var ran []string
+	for {
+		task, ok := mod.nextDue()
+		if !ok || ctx.Err() != nil {
+			return ran
+		}
+
+		mod.mu.Lock()
+		if !mod.idle() {
+			mod.mu.Unlock()
+			return ran
+		}
+		taskCtx, cancel := context.WithCancel(ctx)
+		mod.interrupt = cancel
+		mod.mu.Unlock()
+
+		err := task.Run(taskCtx)
+
+		mod.mu.Lock()
+		// A task that stopped early returns an error; one that finished
+		// anyway does not need to run again
+		interrupted := err != nil && taskCtx.Err() != nil
+		cancel()
+		mod.interrupt = nil
+		if interrupted {
+			mod.mu.Unlock()
+			return ran
+		}
+		mod.lastRun[task.Name] = mod.now()
+		saveErr := mod.save()
+		mod.mu.Unlock()
+
+		if err != nil {
+			fmt.Printf("Warning: maintenance task %s failed: %v\n", task.Name, err)
+		}
+		if saveErr != nil {
+			fmt.Printf("Warning: failed to save maintenance state: %v\n", saveErr)
+		}
+		ran = append(ran, task.Name)
+	}
+}
+
+// nextDue returns the first registered task that is due
+func (mod *MaintenanceModule) nextDue() (Task, bool) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	now := mod.now()
+	for _, task := range mod.tasks {
+		if last, ok := mod.lastRun[task.Name]; !ok || now.Sub(last) >= task.Every {
+			return task, true
+		}
+	}
+	return Task{}, false
+}
+
+// schedule checks for due tasks until ctx is cancelled
+func (mod *MaintenanceModule) schedule(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(mod.checkEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			mod.RunDue(ctx)
+		}
+	}
+}
+
+// load reads when the tasks last ran. The caller holds mu.
+func (mod *MaintenanceModule) load() error {
+	data, err := os.ReadFile(mod.statePath)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &mod.lastRun)
+}
+
+// save writes when the tasks last ran. The caller holds mu.
+func (mod *MaintenanceModule) save() error {
+	if err := os.MkdirAll(filepath.Dir(mod.statePath), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(mod.lastRun, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(mod.statePath, data, 0644)
+}
+
+// Enable activates the module, registering the built-in tasks and starting
+// the scheduler. Starting up counts as activity, so nothing runs until
+// the user has left Recuerdo alone for a while.
+func (mod *MaintenanceModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	if mod.manager != nil {
+		if module, ok := mod.manager.GetDefaultModule("settings"); ok {
+			if settings, ok := module.(Settings); ok {
+				if seconds, err := settings.GetInt(IdleSetting); err == nil && seconds >= 0 {
+					mod.idleAfter = time.Duration(seconds) * time.Second
+				}
+			}
+		}
+	}
+	for _, task := range mod.builtinTasks() {
+		mod.Register(task)
+	}
+
+	mod.mu.Lock()
+	if err := mod.load(); err != nil {
+		fmt.Printf("Warning: failed to read maintenance state: %v\n", err)
+	}
+	mod.lastActivity = mod.now()
+	schedulerCtx, stop := context.WithCancel(context.Background())
+	mod.stop = stop
+	mod.done = make(chan struct{})
+	go mod.schedule(schedulerCtx, mod.done)
+	mod.mu.Unlock()
+
+	fmt.Println("MaintenanceModule enabled")
+	return nil
+}
+
+// Disable deactivates the module, cancelling the task that is running and
+// waiting for it to stop
+func (mod *MaintenanceModule) Disable(ctx context.Context) error {
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	mod.mu.Lock()
+	stop, done := mod.stop, mod.done
+	mod.stop, mod.done = nil, nil
+	mod.mu.Unlock()
+	if stop != nil {
+		stop()
+		select {
+		case <-done:
+		case <-ctx.Done():
+		}
+	}
+
+	fmt.Println("MaintenanceModule disabled")
+	return nil
+}
+
+// SetManager sets the module manager
+func (mod *MaintenanceModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// InitMaintenanceModule creates and returns a new MaintenanceModule instance
+func InitMaintenanceModule() core.Module {
+	return NewMaintenanceModule()
+}
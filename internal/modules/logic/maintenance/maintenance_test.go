@@ -0,0 +1,222 @@
+package maintenance
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// newTestModule returns a module that is idle and has no tasks, with a
+// clock that only moves when told to
+func newTestModule(t *testing.T) (*MaintenanceModule, *time.Time) {
+	t.Helper()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	mod := NewMaintenanceModule()
+	mod.statePath = filepath.Join(t.TempDir(), "maintenance.json")
+	mod.now = func() time.Time { return now }
+	mod.lastActivity = now.Add(-time.Hour)
+	return mod, &now
+}
+
+func TestRunDue(t *testing.T) {
+	mod, now := newTestModule(t)
+	for _, name := range []string{"hourly", "daily"} {
+		every := time.Hour
+		if name == "daily" {
+			every = 24 * time.Hour
+		}
+		mod.Register(Task{Name: name, Every: every, Run: func(ctx context.Context) error { return nil }})
+	}
+
+	if ran := mod.RunDue(context.Background()); strings.Join(ran, ",") != "hourly,daily" {
+		t.Fatalf("first RunDue ran %q", ran)
+	}
+	if ran := mod.RunDue(context.Background()); len(ran) != 0 {
+		t.Errorf("tasks ran again right away: %q", ran)
+	}
+	*now = now.Add(2 * time.Hour)
+	if ran := mod.RunDue(context.Background()); strings.Join(ran, ",") != "hourly" {
+		t.Errorf("after two hours ran %q", ran)
+	}
+
+	// The user is active: nothing runs until idle again
+	*now = now.Add(24 * time.Hour)
+	mod.Touch()
+	if mod.Idle() || len(mod.RunDue(context.Background())) != 0 {
+		t.Error("tasks ran while the user was active")
+	}
+	*now = now.Add(DefaultIdleAfter)
+	if ran := mod.RunDue(context.Background()); len(ran) != 2 {
+		t.Errorf("once idle again ran %q", ran)
+	}
+
+	// When the tasks last ran is remembered across runs
+	next := NewMaintenanceModule()
+	next.statePath = mod.statePath
+	if err := next.load(); err != nil {
+		t.Fatal(err)
+	}
+	if !next.lastRun["daily"].Equal(*now) {
+		t.Errorf("daily last ran %v, want %v", next.lastRun["daily"], *now)
+	}
+}
+
+func TestTouchInterrupts(t *testing.T) {
+	mod, _ := newTestModule(t)
+	attempts := 0
+	mod.Register(Task{Name: "slow", Every: time.Hour, Run: func(ctx context.Context) error {
+		attempts++
+		if attempts == 1 {
+			mod.Touch()
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+
+	if ran := mod.RunDue(context.Background()); len(ran) != 0 {
+		t.Errorf("interrupted task counted as run: %q", ran)
+	}
+	if !mod.LastRun("slow").IsZero() {
+		t.Error("interrupted task should run again at the next idle moment")
+	}
+
+	// Shutting down cancels the task too
+	mod.lastActivity = time.Time{}
+	ctx, cancel := context.WithCancel(context.Background())
+	mod.Register(Task{Name: "slow", Every: time.Hour, Run: func(taskCtx context.Context) error {
+		cancel()
+		<-taskCtx.Done()
+		return taskCtx.Err()
+	}})
+	if ran := mod.RunDue(ctx); len(ran) != 0 {
+		t.Errorf("task cancelled by shutdown counted as run: %q", ran)
+	}
+}
+
+func TestEnableDisable(t *testing.T) {
+	t.Setenv("RECUERDO_DATA_DIR", t.TempDir())
+	t.Setenv("RECUERDO_CACHE_DIR", t.TempDir())
+	mod := NewMaintenanceModule()
+	mod.checkEvery = time.Millisecond
+	if err := mod.Enable(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if mod.Idle() {
+		t.Error("starting up should count as activity")
+	}
+	if err := mod.Disable(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func touchFile(t *testing.T, path string, size int, modified time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEvictCache(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	touchFile(t, filepath.Join(dir, "thumbnails", "old.png"), 10, now.Add(-40*24*time.Hour))
+	touchFile(t, filepath.Join(dir, "thumbnails", "older.png"), 10, now.Add(-10*24*time.Hour))
+	touchFile(t, filepath.Join(dir, "thumbnails", "new.png"), 10, now.Add(-time.Hour))
+	touchFile(t, filepath.Join(dir, "library.db"), 100, now.Add(-100*24*time.Hour))
+
+	stats, err := EvictCache(context.Background(), dir, MaxCacheAge, 15, now, func(path string) bool {
+		return filepath.Base(path) == "library.db"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// old.png is too old; older.png goes to get below 15 bytes
+	if stats != (EvictStats{Removed: 2, Freed: 20}) {
+		t.Errorf("stats = %+v", stats)
+	}
+	for name, want := range map[string]bool{"thumbnails/old.png": false, "thumbnails/older.png": false, "thumbnails/new.png": true, "library.db": true} {
+		if _, err := os.Stat(filepath.Join(dir, name)); (err == nil) != want {
+			t.Errorf("%s exists = %v, want %v", name, err == nil, want)
+		}
+	}
+
+	if _, err := EvictCache(context.Background(), filepath.Join(dir, "missing"), MaxCacheAge, 0, now, nil); err != nil {
+		t.Errorf("a missing cache is empty, not an error: %v", err)
+	}
+}
+
+func TestRotateBackups(t *testing.T) {
+	dir := t.TempDir()
+	settings := filepath.Join(dir, "settings.json")
+	if err := os.WriteFile(settings, []byte(`{"a": 1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	backups := filepath.Join(dir, "backups")
+	day := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		made, err := RotateBackups(context.Background(), []string{settings, filepath.Join(dir, "missing.json")}, backups, 3, day.AddDate(0, 0, i))
+		if err != nil || made != 1 {
+			t.Fatalf("day %d: made %d, %v", i, made, err)
+		}
+	}
+	if made, _ := RotateBackups(context.Background(), []string{settings}, backups, 3, day.AddDate(0, 0, 4)); made != 0 {
+		t.Error("backed up twice on one day")
+	}
+
+	files, _ := filepath.Glob(filepath.Join(backups, "*"))
+	var names []string
+	for _, file := range files {
+		names = append(names, filepath.Base(file))
+	}
+	if got := strings.Join(names, ","); got != "settings-2026-03-03.json,settings-2026-03-04.json,settings-2026-03-05.json" {
+		t.Errorf("backups = %s", got)
+	}
+	if data, _ := os.ReadFile(files[0]); string(data) != `{"a": 1}` {
+		t.Errorf("backup = %q", data)
+	}
+}
+
+func TestAggregateStatistics(t *testing.T) {
+	dir := t.TempDir()
+	date := time.Date(2026, 2, 14, 9, 0, 0, 0, time.UTC)
+	lessonData := lesson.NewLessonData()
+	lessonData.List.Items = []lesson.WordItem{{ID: 0, Questions: []string{"house"}, Answers: []string{"maison"}}, {ID: 1, Questions: []string{"cat"}, Answers: []string{"chat"}}}
+	lessonData.List.Tests = []lesson.Test{
+		{Date: &date, Results: []lesson.TestResult{{Result: "right", ItemID: 0}, {Result: "wrong", ItemID: 1}}},
+		{Results: []lesson.TestResult{{Result: "right", ItemID: 1}}},
+	}
+	if err := lesson.NewFileSaver().SaveFile(lessonData, filepath.Join(dir, "french.json")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.bin"), []byte("not a lesson"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	statistics, err := AggregateStatistics(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statistics.Lessons != 1 || statistics.Items != 2 || statistics.Tests != 2 || statistics.Answers != 3 || statistics.Right != 2 {
+		t.Errorf("statistics = %+v", statistics)
+	}
+	if day := statistics.Days["2026-02-14"]; day != (DayStatistics{Tests: 1, Answers: 2, Right: 1}) {
+		t.Errorf("Valentine's day = %+v", day)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := AggregateStatistics(ctx, dir); err != context.Canceled {
+		t.Errorf("cancelled aggregation returned %v", err)
+	}
+}
@@ -0,0 +1,328 @@
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/LaPingvino/recuerdo/internal/library"
+	"github.com/LaPingvino/recuerdo/internal/paths"
+)
+
+// The names of the built-in tasks
+const (
+	TaskLibraryIndex   = "libraryIndex"
+	TaskCacheEviction  = "cacheEviction"
+	TaskBackupRotation = "backupRotation"
+	TaskStatistics     = "statistics"
+)
+
+// Limits of the built-in tasks
+const (
+	// MaxCacheAge is how long a cache file may go unchanged before it is
+	// evicted
+	MaxCacheAge = 30 * 24 * time.Hour
+	// MaxCacheSize is the size in bytes the cache is evicted down to,
+	// oldest files first
+	MaxCacheSize = 256 << 20
+	// KeptBackups is the number of daily backups kept of every file
+	KeptBackups = 7
+)
+
+// builtinTasks returns the tasks every installation needs
+func (mod *MaintenanceModule) builtinTasks() []Task {
+	dataDir := paths.DataDir()
+	tasks := []Task{
+		{
+			Name:  TaskCacheEviction,
+			Every: 24 * time.Hour,
+			Run: func(ctx context.Context) error {
+				_, err := EvictCache(ctx, paths.CacheDir(), MaxCacheAge, MaxCacheSize, mod.now(), func(path string) bool {
+					// The library index is open; evicting it would only
+					// mean indexing everything again
+					return strings.HasPrefix(filepath.Base(path), "library.db")
+				})
+				return err
+			},
+		},
+		{
+			Name:  TaskBackupRotation,
+			Every: 24 * time.Hour,
+			Run: func(ctx context.Context) error {
+				files := []string{paths.ConfigFile("settings.json"), filepath.Join(dataDir, "recentlyOpened.json")}
+				_, err := RotateBackups(ctx, files, filepath.Join(dataDir, "backups"), KeptBackups, mod.now())
+				return err
+			},
+		},
+	}
+
+	lessonDir := paths.LessonDir()
+	if mod.manager != nil {
+		if module, ok := mod.manager.GetDefaultModule("library"); ok {
+			if index, ok := module.(interface {
+				Directory() string
+				Reindex() (library.IndexStats, error)
+			}); ok {
+				lessonDir = index.Directory()
+				tasks = append(tasks, Task{
+					Name:  TaskLibraryIndex,
+					Every: time.Hour,
+					Run: func(ctx context.Context) error {
+						_, err := index.Reindex()
+						return err
+					},
+				})
+			}
+		}
+	}
+
+	tasks = append(tasks, Task{
+		Name:  TaskStatistics,
+		Every: 24 * time.Hour,
+		Run: func(ctx context.Context) error {
+			statistics, err := AggregateStatistics(ctx, lessonDir)
+			if err != nil {
+				return err
+			}
+			statistics.Updated = mod.now()
+			return statistics.Save(filepath.Join(dataDir, "statistics.json"))
+		},
+	})
+	return tasks
+}
+
+// EvictStats tells what EvictCache removed
+type EvictStats struct {
+	Removed int
+	Freed   int64
+}
+
+// EvictCache removes the files in dir and its subdirectories that did not
+// change for maxAge, and then the oldest others until they take up at most
+// maxBytes. Files for which keep returns true are left alone.
+func EvictCache(ctx context.Context, dir string, maxAge time.Duration, maxBytes int64, now time.Time, keep func(path string) bool) (EvictStats, error) {
+	type cacheFile struct {
+		path     string
+		size     int64
+		modified time.Time
+	}
+	var stats EvictStats
+	var files []cacheFile
+	var total int64
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if entry.IsDir() || (keep != nil && keep(path)) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, cacheFile{path, info.Size(), info.ModTime()})
+		total += info.Size()
+		return nil
+	})
+	if err != nil {
+		return stats, fmt.Errorf("failed to read cache: %w", err)
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].modified.Before(files[j].modified) })
+	for _, file := range files {
+		if now.Sub(file.modified) < maxAge && total <= maxBytes {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+		if err := os.Remove(file.path); err != nil && !os.IsNotExist(err) {
+			return stats, fmt.Errorf("failed to evict cache file: %w", err)
+		}
+		total -= file.size
+		stats.Removed++
+		stats.Freed += file.size
+	}
+	return stats, nil
+}
+
+// RotateBackups copies each of files into dir once per day, as
+// name-2006-01-02.ext, and removes the oldest backups of a file beyond
+// keep. Files that do not exist are skipped. It returns the number of
+// backups made.
+func RotateBackups(ctx context.Context, files []string, dir string, keep int, now time.Time) (int, error) {
+	made := 0
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return made, err
+		}
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			continue
+		}
+		ext := filepath.Ext(file)
+		name := strings.TrimSuffix(filepath.Base(file), ext)
+		backup := filepath.Join(dir, name+"-"+now.Format("2006-01-02")+ext)
+		if _, err := os.Stat(backup); os.IsNotExist(err) {
+			if err := copyFile(file, backup); err != nil {
+				return made, fmt.Errorf("failed to back up %s: %w", file, err)
+			}
+			made++
+		}
+
+		backups, err := filepath.Glob(filepath.Join(dir, name+"-[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9]"+ext))
+		if err != nil {
+			return made, err
+		}
+		// The dates sort like the days they are
+		sort.Strings(backups)
+		for len(backups) > keep {
+			if err := os.Remove(backups[0]); err != nil {
+				return made, fmt.Errorf("failed to remove old backup: %w", err)
+			}
+			backups = backups[1:]
+		}
+	}
+	return made, nil
+}
+
+// copyFile copies from to to, creating the directory of to
+func copyFile(from, to string) error {
+	if err := os.MkdirAll(filepath.Dir(to), 0700); err != nil {
+		return err
+	}
+	source, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	target, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(target, source); err != nil {
+		target.Close()
+		os.Remove(to)
+		return err
+	}
+	return target.Close()
+}
+
+// DayStatistics are the answers given on one day
+type DayStatistics struct {
+	Tests   int `json:"tests"`
+	Answers int `json:"answers"`
+	Right   int `json:"right"`
+}
+
+// Statistics sum up the tests taken in all lessons of the lesson directory
+type Statistics struct {
+	Updated time.Time `json:"updated"`
+	Lessons int       `json:"lessons"`
+	Items   int       `json:"items"`
+	Tests   int       `json:"tests"`
+	Answers int       `json:"answers"`
+	Right   int       `json:"right"`
+	// Days holds the tests by the day they were taken, as 2006-01-02;
+	// tests without a date are only in the totals
+	Days map[string]DayStatistics `json:"days"`
+}
+
+// AggregateStatistics sums up the tests in the lessons in dir and its
+// subdirectories. Hidden directories and files that cannot be loaded are
+// skipped.
+func AggregateStatistics(ctx context.Context, dir string) (Statistics, error) {
+	statistics := Statistics{Days: make(map[string]DayStatistics)}
+	loader := lesson.NewFileLoader()
+	extensions := loader.GetSupportedExtensions()
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !hasExtension(entry.Name(), extensions) {
+			return nil
+		}
+		lessonData, err := loader.LoadFile(path)
+		if err != nil {
+			return nil
+		}
+		statistics.add(lessonData)
+		return nil
+	})
+	if err != nil && ctx.Err() != nil {
+		return statistics, ctx.Err()
+	} else if err != nil {
+		return statistics, fmt.Errorf("failed to aggregate statistics of %s: %w", dir, err)
+	}
+	return statistics, nil
+}
+
+// add counts the tests of a lesson
+func (s *Statistics) add(lessonData *lesson.LessonData) {
+	s.Lessons++
+	s.Items += len(lessonData.List.Items)
+	for _, test := range lessonData.List.Tests {
+		right := 0
+		for _, result := range test.Results {
+			if result.Result == "right" {
+				right++
+			}
+		}
+		s.Tests++
+		s.Answers += len(test.Results)
+		s.Right += right
+		if test.Date != nil {
+			key := test.Date.Format("2006-01-02")
+			day := s.Days[key]
+			day.Tests++
+			day.Answers += len(test.Results)
+			day.Right += right
+			s.Days[key] = day
+		}
+	}
+}
+
+// Save writes the statistics to path as JSON
+func (s Statistics) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to save statistics: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to save statistics: %w", err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// hasExtension reports whether name ends in one of extensions
+func hasExtension(name string, extensions []string) bool {
+	lower := strings.ToLower(name)
+	for _, ext := range extensions {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,33 +1,36 @@
 // Package words provides functionality ported from Python module
 //
-// This is an automated port - implementation may be incomplete.
+// Merges words lessons into one.
 package words
 
 import (
 	"context"
 	"fmt"
+
 	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
 )
 
 // WordsMergerModule is a Go port of the Python WordsMergerModule class
 type WordsMergerModule struct {
 	*core.BaseModule
 	manager *core.Manager
-	// TODO: Add module-specific fields
 }
 
 // NewWordsMergerModule creates a new WordsMergerModule instance
 func NewWordsMergerModule() *WordsMergerModule {
-	base := core.NewBaseModule("logic", "words-module")
+	base := core.NewBaseModule("merger", "words-module")
 
 	return &WordsMergerModule{
 		BaseModule: base,
 	}
 }
 
-// Merge is the Go port of the Python merge method
-func (mod *WordsMergerModule) Merge() {
-	// TODO: Port Python method logic
+// Merge appends the items and tests of otherLesson to a copy of baseLesson.
+// Unlike the Python version the items are renumbered, so the test results
+// of both lessons keep pointing at the right items.
+func (mod *WordsMergerModule) Merge(baseLesson, otherLesson *lesson.LessonData) (*lesson.LessonData, error) {
+	return lesson.MergeLessons(baseLesson, otherLesson)
 }
 
 // Enable activates the module
@@ -37,8 +40,6 @@ func (mod *WordsMergerModule) Enable(ctx context.Context) error {
 		return err
 	}
 
-	// TODO: Port Python enable logic
-
 	fmt.Println("WordsMergerModule enabled")
 	return nil
 }
@@ -50,8 +51,6 @@ func (mod *WordsMergerModule) Disable(ctx context.Context) error {
 		return err
 	}
 
-	// TODO: Port Python disable logic
-
 	fmt.Println("WordsMergerModule disabled")
 	return nil
 }
@@ -65,4 +64,4 @@ func (mod *WordsMergerModule) SetManager(manager *core.Manager) {
 // This is the Go equivalent of the Python init function
 func InitWordsMergerModule() core.Module {
 	return NewWordsMergerModule()
-}
\ No newline at end of file
+}
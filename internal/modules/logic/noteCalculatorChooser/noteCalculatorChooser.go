@@ -1,43 +1,121 @@
-// Package notecalculatorchooser provides functionality ported from Python module
-//
-// This is an automated port - implementation may be incomplete.
+// Package notecalculatorchooser picks the note calculator the user chose in
+// the settings, so the rest of the program grades tests in one notation.
 package notecalculatorchooser
 
 import (
 	"context"
 	"fmt"
+	"sort"
+
 	"github.com/LaPingvino/recuerdo/internal/core"
 )
 
-// NoteCalculatorChooserModule is a Go port of the Python NoteCalculatorChooserModule class
+// NoteCalculatorSetting is the setting holding the name of the chosen
+// note calculator module
+const NoteCalculatorSetting = "notes.calculator"
+
+// DefaultNoteCalculator is the note calculator used when none is chosen
+const DefaultNoteCalculator = "percents-module"
+
+// NoteCalculator turns percentages of right answers into notes
+type NoteCalculator interface {
+	core.Module
+	// Calculatenote returns the note of a test with the given percentage
+	// of right answers
+	Calculatenote(percentage float64) string
+	// Calculateaveragenote returns the average note of tests, "" for none
+	Calculateaveragenote(percentages []float64) string
+	// Notesystem returns the name of the grading system
+	Notesystem() string
+}
+
+// Settings is the part of the settings module the chooser reads and
+// stores the choice with
+type Settings interface {
+	GetString(key string) (string, error)
+	SetSetting(key string, value interface{}) error
+}
+
+// NoteCalculatorChooserModule returns the chosen note calculator
 type NoteCalculatorChooserModule struct {
 	*core.BaseModule
 	manager *core.Manager
-	// TODO: Add module-specific fields
 }
 
 // NewNoteCalculatorChooserModule creates a new NoteCalculatorChooserModule instance
 func NewNoteCalculatorChooserModule() *NoteCalculatorChooserModule {
-	base := core.NewBaseModule("logic", "notecalculatorchooser-module")
+	base := core.NewBaseModule("noteCalculatorChooser", "notecalculatorchooser-module")
 
 	return &NoteCalculatorChooserModule{
 		BaseModule: base,
 	}
 }
 
-// retranslate is the Go port of the Python _retranslate method
-func (mod *NoteCalculatorChooserModule) retranslate() {
-	// TODO: Port Python method logic
+// Notecalculators returns the note calculators to choose from, sorted by
+// module name
+func (mod *NoteCalculatorChooserModule) Notecalculators() []NoteCalculator {
+	if mod.manager == nil {
+		return nil
+	}
+	seen := map[string]bool{}
+	var calculators []NoteCalculator
+	for _, module := range mod.manager.GetModulesByType("noteCalculator") {
+		calculator, ok := module.(NoteCalculator)
+		if !ok || seen[calculator.Name()] {
+			continue
+		}
+		seen[calculator.Name()] = true
+		calculators = append(calculators, calculator)
+	}
+	sort.Slice(calculators, func(i, j int) bool { return calculators[i].Name() < calculators[j].Name() })
+	return calculators
+}
+
+// Notecalculator returns the chosen note calculator, falling back to the
+// default one and then to any, as a calculator that was chosen may be gone.
+// It returns nil when there are none.
+func (mod *NoteCalculatorChooserModule) Notecalculator() NoteCalculator {
+	calculators := mod.Notecalculators()
+	chosen := DefaultNoteCalculator
+	if settings, ok := mod.settings(); ok {
+		if name, err := settings.GetString(NoteCalculatorSetting); err == nil && name != "" {
+			chosen = name
+		}
+	}
+	for _, name := range []string{chosen, DefaultNoteCalculator} {
+		for _, calculator := range calculators {
+			if calculator.Name() == name {
+				return calculator
+			}
+		}
+	}
+	if len(calculators) == 0 {
+		return nil
+	}
+	return calculators[0]
 }
 
-// updateoptions is the Go port of the Python _updateOptions method
-func (mod *NoteCalculatorChooserModule) updateoptions() {
-	// TODO: Port Python method logic
+// Choose makes the note calculator with the given module name the chosen
+// one, remembering it in the settings
+func (mod *NoteCalculatorChooserModule) Choose(name string) error {
+	settings, ok := mod.settings()
+	if !ok {
+		return fmt.Errorf("no settings to store the note calculator in")
+	}
+	return settings.SetSetting(NoteCalculatorSetting, name)
 }
 
-// Notecalculator is the Go port of the Python noteCalculator method
-func (mod *NoteCalculatorChooserModule) Notecalculator() {
-	// TODO: Port Python method logic
+// settings returns the settings module, if there is one
+func (mod *NoteCalculatorChooserModule) settings() (Settings, bool) {
+	if mod.manager == nil {
+		return nil, false
+	}
+	module, ok := mod.manager.GetDefaultModule("settings")
+	if !ok {
+		return nil, false
+	}
+	settings, ok := module.(Settings)
+	return settings, ok
 }
 
 // Enable activates the module
@@ -47,8 +125,6 @@ func (mod *NoteCalculatorChooserModule) Enable(ctx context.Context) error {
 		return err
 	}
 
-	// TODO: Port Python enable logic
-
 	fmt.Println("NoteCalculatorChooserModule enabled")
 	return nil
 }
@@ -60,8 +136,6 @@ func (mod *NoteCalculatorChooserModule) Disable(ctx context.Context) error {
 		return err
 	}
 
-	// TODO: Port Python disable logic
-
 	fmt.Println("NoteCalculatorChooserModule disabled")
 	return nil
 }
@@ -75,4 +149,4 @@ func (mod *NoteCalculatorChooserModule) SetManager(manager *core.Manager) {
 // This is the Go equivalent of the Python init function
 func InitNoteCalculatorChooserModule() core.Module {
 	return NewNoteCalculatorChooserModule()
-}
\ No newline at end of file
+}
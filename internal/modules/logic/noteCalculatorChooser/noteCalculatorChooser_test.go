@@ -0,0 +1,89 @@
+package notecalculatorchooser
+
+import (
+	"testing"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/modules/logic/noteCalculators/american"
+	"github.com/LaPingvino/recuerdo/internal/modules/logic/noteCalculators/dutch"
+	"github.com/LaPingvino/recuerdo/internal/modules/logic/noteCalculators/ects"
+	"github.com/LaPingvino/recuerdo/internal/modules/logic/noteCalculators/french"
+	"github.com/LaPingvino/recuerdo/internal/modules/logic/noteCalculators/german"
+	"github.com/LaPingvino/recuerdo/internal/modules/logic/noteCalculators/percents"
+)
+
+// testSettings is a settings module keeping strings in a map
+type testSettings struct {
+	*core.BaseModule
+	values map[string]string
+}
+
+func (s *testSettings) GetString(key string) (string, error) {
+	return s.values[key], nil
+}
+
+func (s *testSettings) SetSetting(key string, value interface{}) error {
+	s.values[key] = value.(string)
+	return nil
+}
+
+func TestNoteCalculators(t *testing.T) {
+	manager := core.NewManager()
+	chooser := NewNoteCalculatorChooserModule()
+	manager.Register(chooser)
+	for _, module := range []core.Module{
+		american.NewAmericanNoteCalculatorModule(),
+		dutch.NewDutchNoteCalculatorModule(),
+		ects.NewECTSNoteCalculatorModule(),
+		french.NewFrenchNoteCalculatorModule(),
+		german.NewGermanNoteCalculatorModule(),
+		percents.NewPercentsNoteCalculatorModule(),
+		percents.NewPercentsNoteCalculatorModule(),
+	} {
+		manager.Register(module)
+	}
+	if calculators := chooser.Notecalculators(); len(calculators) != 6 {
+		t.Fatalf("Expected 6 note calculators, got %d", len(calculators))
+	}
+	if name := chooser.Notecalculator().Name(); name != DefaultNoteCalculator {
+		t.Errorf("Expected %s without settings, got %s", DefaultNoteCalculator, name)
+	}
+
+	tests := []struct {
+		name    string
+		note    string
+		average string
+	}{
+		{"american-module", "B-", "D"},
+		{"dutch-module", "8.2", "6.9"},
+		{"ects-module", "B", "D"},
+		{"french-module", "16/20", "13.2/20"},
+		{"german-module", "3", "3.5"},
+		{"percents-module", "80%", "66%"},
+	}
+	settings := &testSettings{BaseModule: core.NewBaseModule("settings", "settings-module"), values: map[string]string{}}
+	manager.Register(settings)
+	for _, tt := range tests {
+		if err := chooser.Choose(tt.name); err != nil {
+			t.Fatalf("Failed to choose %s: %v", tt.name, err)
+		}
+		calculator := chooser.Notecalculator()
+		if calculator.Name() != tt.name {
+			t.Fatalf("Expected %s to be chosen, got %s", tt.name, calculator.Name())
+		}
+		if note := calculator.Calculatenote(80); note != tt.note {
+			t.Errorf("%s: expected note %q for 80%%, got %q", tt.name, tt.note, note)
+		}
+		if average := calculator.Calculateaveragenote([]float64{80, 52}); average != tt.average {
+			t.Errorf("%s: expected average %q for 80%% and 52%%, got %q", tt.name, tt.average, average)
+		}
+		if calculator.Calculateaveragenote(nil) != "" {
+			t.Errorf("%s: expected no average without tests", tt.name)
+		}
+	}
+
+	chooser.Choose("gone-module")
+	if name := chooser.Notecalculator().Name(); name != DefaultNoteCalculator {
+		t.Errorf("Expected %s for a calculator that is gone, got %s", DefaultNoteCalculator, name)
+	}
+}
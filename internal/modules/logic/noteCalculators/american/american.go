@@ -1,48 +1,69 @@
-// Package american provides functionality ported from Python module
-//
-// This is an automated port - implementation may be incomplete.
+// Package american grades tests with the American letter grades, A+ to F,
+// by the percentage of right answers.
 package american
 
 import (
 	"context"
 	"fmt"
+
 	"github.com/LaPingvino/recuerdo/internal/core"
+	notecalculators "github.com/LaPingvino/recuerdo/internal/modules/logic/noteCalculators"
 )
 
-// AmericanNoteCalculatorModule is a Go port of the Python AmericanNoteCalculatorModule class
+// AmericanNoteCalculatorModule is a note calculator; see notecalculatorchooser.NoteCalculator
 type AmericanNoteCalculatorModule struct {
 	*core.BaseModule
 	manager *core.Manager
-	// TODO: Add module-specific fields
 }
 
 // NewAmericanNoteCalculatorModule creates a new AmericanNoteCalculatorModule instance
 func NewAmericanNoteCalculatorModule() *AmericanNoteCalculatorModule {
-	base := core.NewBaseModule("logic", "american-module")
+	base := core.NewBaseModule("noteCalculator", "american-module")
 
 	return &AmericanNoteCalculatorModule{
 		BaseModule: base,
 	}
 }
 
-// convert is the Go port of the Python _convert method
-func (mod *AmericanNoteCalculatorModule) convert() {
-	// TODO: Port Python method logic
+// grades are the lowest percentages of the letter grades, best first
+var grades = []struct {
+	minimum float64
+	letter  string
+}{
+	{97, "A+"}, {93, "A"}, {90, "A-"},
+	{87, "B+"}, {83, "B"}, {80, "B-"},
+	{77, "C+"}, {73, "C"}, {70, "C-"},
+	{67, "D+"}, {63, "D"}, {60, "D-"},
+}
+
+// convert returns the letter grade of a percentage
+func (mod *AmericanNoteCalculatorModule) convert(percentage float64) string {
+	for _, grade := range grades {
+		if percentage >= grade.minimum {
+			return grade.letter
+		}
+	}
+	return "F"
 }
 
-// Calculatenote is the Go port of the Python calculateNote method
-func (mod *AmericanNoteCalculatorModule) Calculatenote() {
-	// TODO: Port Python method logic
+// Calculatenote returns the letter grade of a test with the given
+// percentage of right answers
+func (mod *AmericanNoteCalculatorModule) Calculatenote(percentage float64) string {
+	return mod.convert(percentage)
 }
 
-// Calculateaveragenote is the Go port of the Python calculateAverageNote method
-func (mod *AmericanNoteCalculatorModule) Calculateaveragenote() {
-	// TODO: Port Python method logic
+// Calculateaveragenote returns the letter grade of the average percentage
+// of tests, "" for none
+func (mod *AmericanNoteCalculatorModule) Calculateaveragenote(percentages []float64) string {
+	if len(percentages) == 0 {
+		return ""
+	}
+	return mod.convert(notecalculators.Average(percentages))
 }
 
-// retranslate is the Go port of the Python _retranslate method
-func (mod *AmericanNoteCalculatorModule) retranslate() {
-	// TODO: Port Python method logic
+// Notesystem returns the name of the grading system, shown to the teacher
+func (mod *AmericanNoteCalculatorModule) Notesystem() string {
+	return "American (A+ to F)"
 }
 
 // Enable activates the module
@@ -52,8 +73,6 @@ func (mod *AmericanNoteCalculatorModule) Enable(ctx context.Context) error {
 		return err
 	}
 
-	// TODO: Port Python enable logic
-
 	fmt.Println("AmericanNoteCalculatorModule enabled")
 	return nil
 }
@@ -65,8 +84,6 @@ func (mod *AmericanNoteCalculatorModule) Disable(ctx context.Context) error {
 		return err
 	}
 
-	// TODO: Port Python disable logic
-
 	fmt.Println("AmericanNoteCalculatorModule disabled")
 	return nil
 }
@@ -80,4 +97,4 @@ func (mod *AmericanNoteCalculatorModule) SetManager(manager *core.Manager) {
 // This is the Go equivalent of the Python init function
 func InitAmericanNoteCalculatorModule() core.Module {
 	return NewAmericanNoteCalculatorModule()
-}
\ No newline at end of file
+}
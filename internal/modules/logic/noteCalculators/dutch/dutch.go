@@ -1,53 +1,59 @@
-// Package dutch provides functionality ported from Python module
-//
-// This is an automated port - implementation may be incomplete.
+// Package dutch grades tests from 1 to 10, as Dutch schools do, by the
+// percentage of right answers.
 package dutch
 
 import (
 	"context"
 	"fmt"
+	"strconv"
+
 	"github.com/LaPingvino/recuerdo/internal/core"
+	notecalculators "github.com/LaPingvino/recuerdo/internal/modules/logic/noteCalculators"
 )
 
-// DutchNoteCalculatorModule is a Go port of the Python DutchNoteCalculatorModule class
+// DutchNoteCalculatorModule is a note calculator; see notecalculatorchooser.NoteCalculator
 type DutchNoteCalculatorModule struct {
 	*core.BaseModule
 	manager *core.Manager
-	// TODO: Add module-specific fields
 }
 
 // NewDutchNoteCalculatorModule creates a new DutchNoteCalculatorModule instance
 func NewDutchNoteCalculatorModule() *DutchNoteCalculatorModule {
-	base := core.NewBaseModule("logic", "dutch-module")
+	base := core.NewBaseModule("noteCalculator", "dutch-module")
 
 	return &DutchNoteCalculatorModule{
 		BaseModule: base,
 	}
 }
 
-// formatnote is the Go port of the Python _formatNote method
-func (mod *DutchNoteCalculatorModule) formatnote() {
-	// TODO: Port Python method logic
+// formatnote writes a note with one decimal
+func (mod *DutchNoteCalculatorModule) formatnote(note float64) string {
+	return strconv.FormatFloat(note, 'f', 1, 64)
 }
 
-// calculatefloat is the Go port of the Python _calculateFloat method
-func (mod *DutchNoteCalculatorModule) calculatefloat() {
-	// TODO: Port Python method logic
+// calculatefloat returns the note of a percentage: 1 for nothing right, 10
+// for everything
+func (mod *DutchNoteCalculatorModule) calculatefloat(percentage float64) float64 {
+	return 1 + 9*percentage/100
 }
 
-// Calculatenote is the Go port of the Python calculateNote method
-func (mod *DutchNoteCalculatorModule) Calculatenote() {
-	// TODO: Port Python method logic
+// Calculatenote returns the note of a test with the given percentage of
+// right answers
+func (mod *DutchNoteCalculatorModule) Calculatenote(percentage float64) string {
+	return mod.formatnote(mod.calculatefloat(percentage))
 }
 
-// Calculateaveragenote is the Go port of the Python calculateAverageNote method
-func (mod *DutchNoteCalculatorModule) Calculateaveragenote() {
-	// TODO: Port Python method logic
+// Calculateaveragenote returns the average note of tests, "" for none
+func (mod *DutchNoteCalculatorModule) Calculateaveragenote(percentages []float64) string {
+	if len(percentages) == 0 {
+		return ""
+	}
+	return mod.formatnote(mod.calculatefloat(notecalculators.Average(percentages)))
 }
 
-// retranslate is the Go port of the Python _retranslate method
-func (mod *DutchNoteCalculatorModule) retranslate() {
-	// TODO: Port Python method logic
+// Notesystem returns the name of the grading system, shown to the teacher
+func (mod *DutchNoteCalculatorModule) Notesystem() string {
+	return "Dutch (1 to 10)"
 }
 
 // Enable activates the module
@@ -57,8 +63,6 @@ func (mod *DutchNoteCalculatorModule) Enable(ctx context.Context) error {
 		return err
 	}
 
-	// TODO: Port Python enable logic
-
 	fmt.Println("DutchNoteCalculatorModule enabled")
 	return nil
 }
@@ -70,8 +74,6 @@ func (mod *DutchNoteCalculatorModule) Disable(ctx context.Context) error {
 		return err
 	}
 
-	// TODO: Port Python disable logic
-
 	fmt.Println("DutchNoteCalculatorModule disabled")
 	return nil
 }
@@ -85,4 +87,4 @@ func (mod *DutchNoteCalculatorModule) SetManager(manager *core.Manager) {
 // This is the Go equivalent of the Python init function
 func InitDutchNoteCalculatorModule() core.Module {
 	return NewDutchNoteCalculatorModule()
-}
\ No newline at end of file
+}
@@ -1,48 +1,65 @@
-// Package ects provides functionality ported from Python module
-//
-// This is an automated port - implementation may be incomplete.
+// Package ects grades tests with the ECTS grades, A to F, by the percentage
+// of right answers.
 package ects
 
 import (
 	"context"
 	"fmt"
+
 	"github.com/LaPingvino/recuerdo/internal/core"
+	notecalculators "github.com/LaPingvino/recuerdo/internal/modules/logic/noteCalculators"
 )
 
-// ECTSNoteCalculatorModule is a Go port of the Python ECTSNoteCalculatorModule class
+// ECTSNoteCalculatorModule is a note calculator; see notecalculatorchooser.NoteCalculator
 type ECTSNoteCalculatorModule struct {
 	*core.BaseModule
 	manager *core.Manager
-	// TODO: Add module-specific fields
 }
 
 // NewECTSNoteCalculatorModule creates a new ECTSNoteCalculatorModule instance
 func NewECTSNoteCalculatorModule() *ECTSNoteCalculatorModule {
-	base := core.NewBaseModule("logic", "ects-module")
+	base := core.NewBaseModule("noteCalculator", "ects-module")
 
 	return &ECTSNoteCalculatorModule{
 		BaseModule: base,
 	}
 }
 
-// convert is the Go port of the Python _convert method
-func (mod *ECTSNoteCalculatorModule) convert() {
-	// TODO: Port Python method logic
+// convert returns the ECTS grade of a percentage
+func (mod *ECTSNoteCalculatorModule) convert(percentage float64) string {
+	switch {
+	case percentage >= 90:
+		return "A"
+	case percentage >= 80:
+		return "B"
+	case percentage >= 70:
+		return "C"
+	case percentage >= 60:
+		return "D"
+	case percentage >= 50:
+		return "E"
+	}
+	return "F"
 }
 
-// Calculatenote is the Go port of the Python calculateNote method
-func (mod *ECTSNoteCalculatorModule) Calculatenote() {
-	// TODO: Port Python method logic
+// Calculatenote returns the ECTS grade of a test with the given percentage
+// of right answers
+func (mod *ECTSNoteCalculatorModule) Calculatenote(percentage float64) string {
+	return mod.convert(percentage)
 }
 
-// Calculateaveragenote is the Go port of the Python calculateAverageNote method
-func (mod *ECTSNoteCalculatorModule) Calculateaveragenote() {
-	// TODO: Port Python method logic
+// Calculateaveragenote returns the ECTS grade of the average percentage of
+// tests, "" for none
+func (mod *ECTSNoteCalculatorModule) Calculateaveragenote(percentages []float64) string {
+	if len(percentages) == 0 {
+		return ""
+	}
+	return mod.convert(notecalculators.Average(percentages))
 }
 
-// retranslate is the Go port of the Python _retranslate method
-func (mod *ECTSNoteCalculatorModule) retranslate() {
-	// TODO: Port Python method logic
+// Notesystem returns the name of the grading system, shown to the teacher
+func (mod *ECTSNoteCalculatorModule) Notesystem() string {
+	return "ECTS (A to F)"
 }
 
 // Enable activates the module
@@ -52,8 +69,6 @@ func (mod *ECTSNoteCalculatorModule) Enable(ctx context.Context) error {
 		return err
 	}
 
-	// TODO: Port Python enable logic
-
 	fmt.Println("ECTSNoteCalculatorModule enabled")
 	return nil
 }
@@ -65,8 +80,6 @@ func (mod *ECTSNoteCalculatorModule) Disable(ctx context.Context) error {
 		return err
 	}
 
-	// TODO: Port Python disable logic
-
 	fmt.Println("ECTSNoteCalculatorModule disabled")
 	return nil
 }
@@ -80,4 +93,4 @@ func (mod *ECTSNoteCalculatorModule) SetManager(manager *core.Manager) {
 // This is the Go equivalent of the Python init function
 func InitECTSNoteCalculatorModule() core.Module {
 	return NewECTSNoteCalculatorModule()
-}
\ No newline at end of file
+}
@@ -1,48 +1,58 @@
-// Package french provides functionality ported from Python module
-//
-// This is an automated port - implementation may be incomplete.
+// Package french grades tests out of 20, as French schools do.
 package french
 
 import (
 	"context"
 	"fmt"
+	"math"
+	"strconv"
+
 	"github.com/LaPingvino/recuerdo/internal/core"
+	notecalculators "github.com/LaPingvino/recuerdo/internal/modules/logic/noteCalculators"
 )
 
-// FrenchNoteCalculatorModule is a Go port of the Python FrenchNoteCalculatorModule class
+// FrenchNoteCalculatorModule is a note calculator; see notecalculatorchooser.NoteCalculator
 type FrenchNoteCalculatorModule struct {
 	*core.BaseModule
 	manager *core.Manager
-	// TODO: Add module-specific fields
 }
 
 // NewFrenchNoteCalculatorModule creates a new FrenchNoteCalculatorModule instance
 func NewFrenchNoteCalculatorModule() *FrenchNoteCalculatorModule {
-	base := core.NewBaseModule("logic", "french-module")
+	base := core.NewBaseModule("noteCalculator", "french-module")
 
 	return &FrenchNoteCalculatorModule{
 		BaseModule: base,
 	}
 }
 
-// calculate is the Go port of the Python _calculate method
-func (mod *FrenchNoteCalculatorModule) calculate() {
-	// TODO: Port Python method logic
+// calculate returns the note out of 20 of a percentage
+func (mod *FrenchNoteCalculatorModule) calculate(percentage float64) float64 {
+	return percentage / 5
+}
+
+// format writes a note with at most one decimal
+func (mod *FrenchNoteCalculatorModule) format(note float64) string {
+	return strconv.FormatFloat(math.Round(note*10)/10, 'f', -1, 64) + "/20"
 }
 
-// Calculatenote is the Go port of the Python calculateNote method
-func (mod *FrenchNoteCalculatorModule) Calculatenote() {
-	// TODO: Port Python method logic
+// Calculatenote returns the note out of 20 of a test with the given
+// percentage of right answers
+func (mod *FrenchNoteCalculatorModule) Calculatenote(percentage float64) string {
+	return mod.format(mod.calculate(percentage))
 }
 
-// Calculateaveragenote is the Go port of the Python calculateAverageNote method
-func (mod *FrenchNoteCalculatorModule) Calculateaveragenote() {
-	// TODO: Port Python method logic
+// Calculateaveragenote returns the average note of tests, "" for none
+func (mod *FrenchNoteCalculatorModule) Calculateaveragenote(percentages []float64) string {
+	if len(percentages) == 0 {
+		return ""
+	}
+	return mod.format(mod.calculate(notecalculators.Average(percentages)))
 }
 
-// retranslate is the Go port of the Python _retranslate method
-func (mod *FrenchNoteCalculatorModule) retranslate() {
-	// TODO: Port Python method logic
+// Notesystem returns the name of the grading system, shown to the teacher
+func (mod *FrenchNoteCalculatorModule) Notesystem() string {
+	return "French (0 to 20)"
 }
 
 // Enable activates the module
@@ -52,8 +62,6 @@ func (mod *FrenchNoteCalculatorModule) Enable(ctx context.Context) error {
 		return err
 	}
 
-	// TODO: Port Python enable logic
-
 	fmt.Println("FrenchNoteCalculatorModule enabled")
 	return nil
 }
@@ -65,8 +73,6 @@ func (mod *FrenchNoteCalculatorModule) Disable(ctx context.Context) error {
 		return err
 	}
 
-	// TODO: Port Python disable logic
-
 	fmt.Println("FrenchNoteCalculatorModule disabled")
 	return nil
 }
@@ -80,4 +86,4 @@ func (mod *FrenchNoteCalculatorModule) SetManager(manager *core.Manager) {
 // This is the Go equivalent of the Python init function
 func InitFrenchNoteCalculatorModule() core.Module {
 	return NewFrenchNoteCalculatorModule()
-}
\ No newline at end of file
+}
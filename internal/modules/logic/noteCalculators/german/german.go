@@ -1,48 +1,67 @@
-// Package german provides functionality ported from Python module
-//
-// This is an automated port - implementation may be incomplete.
+// Package german grades tests with the German school grades, 1 (sehr gut)
+// to 6 (ungenügend), by the percentage of right answers.
 package german
 
 import (
 	"context"
 	"fmt"
+	"math"
+	"strconv"
+
 	"github.com/LaPingvino/recuerdo/internal/core"
+	notecalculators "github.com/LaPingvino/recuerdo/internal/modules/logic/noteCalculators"
 )
 
-// GermanNoteCalculatorModule is a Go port of the Python GermanNoteCalculatorModule class
+// GermanNoteCalculatorModule is a note calculator; see notecalculatorchooser.NoteCalculator
 type GermanNoteCalculatorModule struct {
 	*core.BaseModule
 	manager *core.Manager
-	// TODO: Add module-specific fields
 }
 
 // NewGermanNoteCalculatorModule creates a new GermanNoteCalculatorModule instance
 func NewGermanNoteCalculatorModule() *GermanNoteCalculatorModule {
-	base := core.NewBaseModule("logic", "german-module")
+	base := core.NewBaseModule("noteCalculator", "german-module")
 
 	return &GermanNoteCalculatorModule{
 		BaseModule: base,
 	}
 }
 
-// convert is the Go port of the Python _convert method
-func (mod *GermanNoteCalculatorModule) convert() {
-	// TODO: Port Python method logic
+// minimums are the lowest percentages of the grades 1 to 5; less is a 6
+var minimums = []float64{92, 81, 67, 50, 30}
+
+// convert returns the grade of a percentage
+func (mod *GermanNoteCalculatorModule) convert(percentage float64) int {
+	for i, minimum := range minimums {
+		if percentage >= minimum {
+			return i + 1
+		}
+	}
+	return len(minimums) + 1
 }
 
-// Calculatenote is the Go port of the Python calculateNote method
-func (mod *GermanNoteCalculatorModule) Calculatenote() {
-	// TODO: Port Python method logic
+// Calculatenote returns the grade of a test with the given percentage of
+// right answers
+func (mod *GermanNoteCalculatorModule) Calculatenote(percentage float64) string {
+	return strconv.Itoa(mod.convert(percentage))
 }
 
-// Calculateaveragenote is the Go port of the Python calculateAverageNote method
-func (mod *GermanNoteCalculatorModule) Calculateaveragenote() {
-	// TODO: Port Python method logic
+// Calculateaveragenote returns the average of the grades of tests, with
+// one decimal, "" for none
+func (mod *GermanNoteCalculatorModule) Calculateaveragenote(percentages []float64) string {
+	if len(percentages) == 0 {
+		return ""
+	}
+	grades := make([]float64, len(percentages))
+	for i, percentage := range percentages {
+		grades[i] = float64(mod.convert(percentage))
+	}
+	return strconv.FormatFloat(math.Round(notecalculators.Average(grades)*10)/10, 'f', -1, 64)
 }
 
-// retranslate is the Go port of the Python _retranslate method
-func (mod *GermanNoteCalculatorModule) retranslate() {
-	// TODO: Port Python method logic
+// Notesystem returns the name of the grading system, shown to the teacher
+func (mod *GermanNoteCalculatorModule) Notesystem() string {
+	return "German (1 to 6)"
 }
 
 // Enable activates the module
@@ -52,8 +71,6 @@ func (mod *GermanNoteCalculatorModule) Enable(ctx context.Context) error {
 		return err
 	}
 
-	// TODO: Port Python enable logic
-
 	fmt.Println("GermanNoteCalculatorModule enabled")
 	return nil
 }
@@ -65,8 +82,6 @@ func (mod *GermanNoteCalculatorModule) Disable(ctx context.Context) error {
 		return err
 	}
 
-	// TODO: Port Python disable logic
-
 	fmt.Println("GermanNoteCalculatorModule disabled")
 	return nil
 }
@@ -80,4 +95,4 @@ func (mod *GermanNoteCalculatorModule) SetManager(manager *core.Manager) {
 // This is the Go equivalent of the Python init function
 func InitGermanNoteCalculatorModule() core.Module {
 	return NewGermanNoteCalculatorModule()
-}
\ No newline at end of file
+}
@@ -0,0 +1,12 @@
+// Package notecalculators holds what the note calculators in its
+// subpackages share.
+package notecalculators
+
+// Average returns the mean of values, which holds at least one
+func Average(values []float64) float64 {
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
@@ -1,48 +1,51 @@
-// Package percents provides functionality ported from Python module
-//
-// This is an automated port - implementation may be incomplete.
+// Package percents grades tests by the percentage of right answers.
 package percents
 
 import (
 	"context"
 	"fmt"
+	"math"
+
 	"github.com/LaPingvino/recuerdo/internal/core"
+	notecalculators "github.com/LaPingvino/recuerdo/internal/modules/logic/noteCalculators"
 )
 
-// PercentsNoteCalculatorModule is a Go port of the Python PercentsNoteCalculatorModule class
+// PercentsNoteCalculatorModule is a note calculator; see notecalculatorchooser.NoteCalculator
 type PercentsNoteCalculatorModule struct {
 	*core.BaseModule
 	manager *core.Manager
-	// TODO: Add module-specific fields
 }
 
 // NewPercentsNoteCalculatorModule creates a new PercentsNoteCalculatorModule instance
 func NewPercentsNoteCalculatorModule() *PercentsNoteCalculatorModule {
-	base := core.NewBaseModule("logic", "percents-module")
+	base := core.NewBaseModule("noteCalculator", "percents-module")
 
 	return &PercentsNoteCalculatorModule{
 		BaseModule: base,
 	}
 }
 
-// format is the Go port of the Python _format method
-func (mod *PercentsNoteCalculatorModule) format() {
-	// TODO: Port Python method logic
+// format writes a percentage rounded to a whole number
+func (mod *PercentsNoteCalculatorModule) format(percentage float64) string {
+	return fmt.Sprintf("%.0f%%", math.Round(percentage))
 }
 
-// Calculatenote is the Go port of the Python calculateNote method
-func (mod *PercentsNoteCalculatorModule) Calculatenote() {
-	// TODO: Port Python method logic
+// Calculatenote returns the percentage of right answers of a test
+func (mod *PercentsNoteCalculatorModule) Calculatenote(percentage float64) string {
+	return mod.format(percentage)
 }
 
-// Calculateaveragenote is the Go port of the Python calculateAverageNote method
-func (mod *PercentsNoteCalculatorModule) Calculateaveragenote() {
-	// TODO: Port Python method logic
+// Calculateaveragenote returns the average percentage of tests, "" for none
+func (mod *PercentsNoteCalculatorModule) Calculateaveragenote(percentages []float64) string {
+	if len(percentages) == 0 {
+		return ""
+	}
+	return mod.format(notecalculators.Average(percentages))
 }
 
-// retranslate is the Go port of the Python _retranslate method
-func (mod *PercentsNoteCalculatorModule) retranslate() {
-	// TODO: Port Python method logic
+// Notesystem returns the name of the grading system, shown to the teacher
+func (mod *PercentsNoteCalculatorModule) Notesystem() string {
+	return "Percentages"
 }
 
 // Enable activates the module
@@ -52,8 +55,6 @@ func (mod *PercentsNoteCalculatorModule) Enable(ctx context.Context) error {
 		return err
 	}
 
-	// TODO: Port Python enable logic
-
 	fmt.Println("PercentsNoteCalculatorModule enabled")
 	return nil
 }
@@ -65,8 +66,6 @@ func (mod *PercentsNoteCalculatorModule) Disable(ctx context.Context) error {
 		return err
 	}
 
-	// TODO: Port Python disable logic
-
 	fmt.Println("PercentsNoteCalculatorModule disabled")
 	return nil
 }
@@ -80,4 +79,4 @@ func (mod *PercentsNoteCalculatorModule) SetManager(manager *core.Manager) {
 // This is the Go equivalent of the Python init function
 func InitPercentsNoteCalculatorModule() core.Module {
 	return NewPercentsNoteCalculatorModule()
-}
\ No newline at end of file
+}
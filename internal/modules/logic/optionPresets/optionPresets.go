@@ -0,0 +1,176 @@
+// Package optionpresets manages named option presets (scheduler settings,
+// answer tolerance and direction) shared between lessons, and lesson packs
+// that carry those presets to other computers.
+package optionpresets
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// OptionPresetsModule keeps the user's option presets
+type OptionPresetsModule struct {
+	*core.BaseModule
+	manager    *core.Manager
+	store      *lesson.PresetStore
+	fileSaver  *lesson.FileSaver
+	fileLoader *lesson.FileLoader
+
+	// trustedKeysPath is the file with the keys of those whose signed
+	// lesson packs are trusted
+	trustedKeysPath string
+}
+
+// NewOptionPresetsModule creates a new OptionPresetsModule instance
+func NewOptionPresetsModule() *OptionPresetsModule {
+	return newOptionPresetsModule(lesson.DefaultPresetsPath())
+}
+
+// newOptionPresetsModule creates a module storing presets at filePath
+func newOptionPresetsModule(filePath string) *OptionPresetsModule {
+	base := core.NewBaseModule("optionPresets", "option-presets-module")
+
+	return &OptionPresetsModule{
+		BaseModule: base,
+		store:      lesson.NewPresetStore(filePath),
+		fileSaver:  lesson.NewFileSaver(),
+		fileLoader: lesson.NewFileLoader(),
+
+		trustedKeysPath: filepath.Join(filepath.Dir(filePath), "trusted-keys.json"),
+	}
+}
+
+// Enable activates the module and loads the stored presets
+func (mod *OptionPresetsModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	if err := mod.store.Load(); err != nil {
+		return fmt.Errorf("failed to load option presets: %w", err)
+	}
+
+	// Lessons without a preset check answers as tolerantly as the settings say
+	if mod.manager != nil {
+		if module, ok := mod.manager.GetDefaultModule("settings"); ok {
+			if settings, ok := module.(lesson.ToleranceSettings); ok {
+				lesson.LoadDefaultTolerance(settings)
+			}
+		}
+	}
+
+	fmt.Println("OptionPresetsModule enabled")
+	return nil
+}
+
+// Disable saves the presets and deactivates the module
+func (mod *OptionPresetsModule) Disable(ctx context.Context) error {
+	if err := mod.store.Save(); err != nil {
+		fmt.Printf("Warning: failed to save option presets: %v\n", err)
+	}
+
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("OptionPresetsModule disabled")
+	return nil
+}
+
+// Store returns the preset store
+func (mod *OptionPresetsModule) Store() *lesson.PresetStore {
+	return mod.store
+}
+
+// SavePreset adds or replaces a preset and persists the change
+func (mod *OptionPresetsModule) SavePreset(preset lesson.OptionPreset) error {
+	if err := mod.store.Set(preset); err != nil {
+		return err
+	}
+	return mod.store.Save()
+}
+
+// AssignPreset makes each of the lessons use the named preset
+func (mod *OptionPresetsModule) AssignPreset(name string, lessons ...*lesson.LessonData) error {
+	for _, lessonData := range lessons {
+		if err := mod.store.Assign(lessonData, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OptionsFor returns the options that apply to the lesson
+func (mod *OptionPresetsModule) OptionsFor(lessonData *lesson.LessonData) lesson.OptionPreset {
+	return mod.store.PresetFor(lessonData)
+}
+
+// ExportPack saves lessons together with their presets as a lesson pack
+func (mod *OptionPresetsModule) ExportPack(lessons []*lesson.LessonData, filePath string) error {
+	return mod.fileSaver.SaveLessonPack(lessons, mod.store, filePath)
+}
+
+// ExportSignedPack saves lessons together with their presets as a lesson
+// pack signed with key, so those receiving it can check it is unchanged
+func (mod *OptionPresetsModule) ExportSignedPack(lessons []*lesson.LessonData, filePath string, key *lesson.SigningKey) error {
+	saver := lesson.NewFileSaver()
+	saver.Options.PackSigningKey = key
+	return saver.SaveLessonPack(lessons, mod.store, filePath)
+}
+
+// VerifyPack tells who signed a lesson pack and whether they are trusted
+func (mod *OptionPresetsModule) VerifyPack(filePath string) (lesson.PackVerification, error) {
+	trusted, err := lesson.LoadTrustedKeys(mod.trustedKeysPath)
+	if err != nil {
+		return lesson.PackVerification{}, err
+	}
+	return mod.fileLoader.VerifyLessonPack(filePath, trusted)
+}
+
+// TrustKey trusts lesson packs signed with key from now on
+func (mod *OptionPresetsModule) TrustKey(key lesson.TrustedKey) error {
+	trusted, err := lesson.LoadTrustedKeys(mod.trustedKeysPath)
+	if err != nil {
+		return err
+	}
+	for i, existing := range trusted {
+		if existing.PublicKey.Equal(key.PublicKey) {
+			trusted[i].Name = key.Name
+			return lesson.SaveTrustedKeys(mod.trustedKeysPath, trusted)
+		}
+	}
+	return lesson.SaveTrustedKeys(mod.trustedKeysPath, append(trusted, key))
+}
+
+// ImportPack loads a lesson pack and adds its presets to the store. Presets
+// the user already has are kept unless overwrite is set. Signed packs that
+// were changed after signing are refused.
+func (mod *OptionPresetsModule) ImportPack(filePath string, overwrite bool) ([]*lesson.LessonData, error) {
+	lessons, presets, err := mod.fileLoader.LoadLessonPack(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := mod.store.Merge(presets, overwrite); err != nil {
+		return nil, err
+	}
+	if err := mod.store.Save(); err != nil {
+		log.Printf("[WARNING] Failed to save imported option presets: %v", err)
+	}
+	return lessons, nil
+}
+
+// SetManager sets the module manager
+func (mod *OptionPresetsModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// InitOptionPresetsModule creates and returns a new OptionPresetsModule instance
+func InitOptionPresetsModule() core.Module {
+	return NewOptionPresetsModule()
+}
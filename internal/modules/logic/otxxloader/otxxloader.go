@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
 )
 
 // OtxxLoaderModule is a Go port of the Python OtxxLoaderModule class
@@ -35,9 +36,13 @@ func (mod *OtxxLoaderModule) Stringtodatetime() {
 	// TODO: Port Python method logic
 }
 
-// Load is the Go port of the Python load method
-func (mod *OtxxLoaderModule) Load() {
-	// TODO: Port Python method logic
+// Load loads an OpenTeaching container file (.otwd, .ottp, .otmd, .otio)
+// or its encrypted variant (.otsec), which needs the passphrase it was
+// saved with
+func (mod *OtxxLoaderModule) Load(filePath, passphrase string) (*lesson.LessonData, error) {
+	loader := lesson.NewFileLoader()
+	loader.SetPassphrase(passphrase)
+	return loader.LoadFile(filePath)
 }
 
 // cleanuptemppaths is the Go port of the Python _cleanupTempPaths method
@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
 )
 
 // OtxxSaverModule is a Go port of the Python OtxxSaverModule class
@@ -25,9 +26,12 @@ func NewOtxxSaverModule() *OtxxSaverModule {
 	}
 }
 
-// Save is the Go port of the Python save method
-func (mod *OtxxSaverModule) Save() {
-	// TODO: Port Python method logic
+// Save saves lesson data as an OpenTeaching container file (.ottp, .otmd,
+// .otio), or encrypted with passphrase as an .otsec file
+func (mod *OtxxSaverModule) Save(lessonData *lesson.LessonData, filePath, passphrase string) error {
+	saver := lesson.NewFileSaver()
+	saver.Options.Passphrase = passphrase
+	return saver.SaveFile(lessonData, filePath)
 }
 
 // version is the Go port of the Python _version method
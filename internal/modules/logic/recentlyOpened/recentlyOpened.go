@@ -1,53 +1,287 @@
 // Package recentlyopened provides functionality ported from Python module
+// legacy/modules/org/openteacher/logic/recentlyOpened/recentlyOpened.py
 //
-// This is an automated port - implementation may be incomplete.
+// It remembers the lessons opened last, so the start screen can offer them
+// again. Pinned lessons stay on the list however many others are opened.
 package recentlyopened
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
 	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/LaPingvino/recuerdo/internal/paths"
+	"github.com/LaPingvino/recuerdo/internal/thumbnail"
+)
+
+// SizeSetting is the settings key holding how many lessons that are not
+// pinned are remembered
+const SizeSetting = "recentlyOpened.size"
+
+// DefaultSize is the number of lessons remembered when SizeSetting is unset
+const DefaultSize = 10
+
+// The size of thumbnails in pixels
+const (
+	ThumbnailWidth  = thumbnail.IconWidth
+	ThumbnailHeight = thumbnail.IconHeight
 )
 
-// RecentlyOpenedModule is a Go port of the Python RecentlyOpenedModule class
+// Entry is a recently opened lesson
+type Entry struct {
+	Path       string `json:"path"`
+	Label      string `json:"label"`
+	LessonType string `json:"lessonType"`
+	Items      int    `json:"items"`
+	// LastScore is the percentage of the last test of the lesson, or -1
+	// when it was never practised
+	LastScore int       `json:"lastScore"`
+	Opened    time.Time `json:"opened"`
+	Pinned    bool      `json:"pinned,omitempty"`
+	// Thumbnail is the path of a PNG picture of the lesson, drawn by
+	// package thumbnail
+	Thumbnail string `json:"thumbnail,omitempty"`
+}
+
+// NewEntry describes the lesson of type lessonType at path for the list
+func NewEntry(path, lessonType string, lessonData *lesson.LessonData) Entry {
+	entry := Entry{
+		Path:       path,
+		Label:      lessonData.List.Title,
+		LessonType: lessonType,
+		Items:      len(lessonData.List.Items),
+		LastScore:  -1,
+		Opened:     time.Now(),
+	}
+	if entry.Label == "" {
+		entry.Label = filepath.Base(path)
+	}
+	if tests := lessonData.List.Tests; len(tests) > 0 {
+		entry.LastScore = tests[len(tests)-1].Percentage()
+	}
+	return entry
+}
+
+// Settings is the part of the settings module the list size is read from
+type Settings interface {
+	GetInt(key string) (int, error)
+}
+
+// RecentlyOpenedModule keeps the list of recently opened lessons
 type RecentlyOpenedModule struct {
 	*core.BaseModule
 	manager *core.Manager
-	// TODO: Add module-specific fields
+	// storePath is the file the list is kept in, thumbnailDir the
+	// directory of the thumbnails
+	storePath    string
+	thumbnailDir string
+	renderer     *thumbnail.Renderer
+	rendererOnce sync.Once
+	size         int
+	entries      []Entry
+	handlers     []func()
+	mu           sync.Mutex
 }
 
 // NewRecentlyOpenedModule creates a new RecentlyOpenedModule instance
 func NewRecentlyOpenedModule() *RecentlyOpenedModule {
-	base := core.NewBaseModule("logic", "recentlyopened-module")
+	base := core.NewBaseModule("recentlyOpened", "recentlyopened-module")
 
 	return &RecentlyOpenedModule{
-		BaseModule: base,
+		BaseModule:   base,
+		storePath:    filepath.Join(paths.DataDir(), "recentlyOpened.json"),
+		thumbnailDir: filepath.Join(paths.CacheDir(), "thumbnails"),
+		size:         DefaultSize,
 	}
 }
 
-// Add is the Go port of the Python add method
-func (mod *RecentlyOpenedModule) Add() {
-	// TODO: Port Python method logic
+// Add puts a lesson at the top of the list, replacing an older entry for
+// the same file but keeping it pinned if it was, and stores a thumbnail of
+// the lesson.
+func (mod *RecentlyOpenedModule) Add(entry Entry, lessonData *lesson.LessonData) error {
+	if lessonData != nil {
+		if thumbnail, err := mod.writeThumbnail(entry.Path, lessonData); err == nil {
+			entry.Thumbnail = thumbnail
+		} else {
+			fmt.Printf("Warning: failed to create thumbnail: %v\n", err)
+		}
+	}
+
+	mod.mu.Lock()
+	for i, existing := range mod.entries {
+		if existing.Path == entry.Path {
+			entry.Pinned = entry.Pinned || existing.Pinned
+			mod.entries = append(mod.entries[:i], mod.entries[i+1:]...)
+			break
+		}
+	}
+	mod.entries = append([]Entry{entry}, mod.entries...)
+	mod.trim()
+	err := mod.save()
+	mod.mu.Unlock()
+
+	mod.updated()
+	return err
 }
 
-// Getrecentlyopened is the Go port of the Python getRecentlyOpened method
-func (mod *RecentlyOpenedModule) Getrecentlyopened() {
-	// TODO: Port Python method logic
+// RecentlyOpened returns the list, pinned lessons first and the most
+// recently opened first within both parts
+func (mod *RecentlyOpenedModule) RecentlyOpened() []Entry {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	entries := append([]Entry(nil), mod.entries...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Pinned != entries[j].Pinned {
+			return entries[i].Pinned
+		}
+		return entries[i].Opened.After(entries[j].Opened)
+	})
+	return entries
 }
 
-// retranslate is the Go port of the Python _retranslate method
-func (mod *RecentlyOpenedModule) retranslate() {
-	// TODO: Port Python method logic
+// SetPinned pins or unpins the lesson at path
+func (mod *RecentlyOpenedModule) SetPinned(path string, pinned bool) error {
+	mod.mu.Lock()
+	found := false
+	for i := range mod.entries {
+		if mod.entries[i].Path == path {
+			mod.entries[i].Pinned = pinned
+			found = true
+		}
+	}
+	if !found {
+		mod.mu.Unlock()
+		return fmt.Errorf("%s is not on the recently opened list", filepath.Base(path))
+	}
+	mod.trim()
+	err := mod.save()
+	mod.mu.Unlock()
+
+	mod.updated()
+	return err
 }
 
-// Enable activates the module
-// This is the Go equivalent of the Python enable method
+// Remove takes the lesson at path off the list
+func (mod *RecentlyOpenedModule) Remove(path string) error {
+	mod.mu.Lock()
+	kept := mod.entries[:0]
+	for _, entry := range mod.entries {
+		if entry.Path == path {
+			if entry.Thumbnail != "" {
+				os.Remove(entry.Thumbnail)
+			}
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	mod.entries = kept
+	err := mod.save()
+	mod.mu.Unlock()
+
+	mod.updated()
+	return err
+}
+
+// OnUpdated registers a function called whenever the list changes
+func (mod *RecentlyOpenedModule) OnUpdated(handler func()) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	mod.handlers = append(mod.handlers, handler)
+}
+
+func (mod *RecentlyOpenedModule) updated() {
+	mod.mu.Lock()
+	handlers := append([]func(){}, mod.handlers...)
+	mod.mu.Unlock()
+	for _, handler := range handlers {
+		handler()
+	}
+}
+
+// trim forgets the oldest lessons that are not pinned beyond the list
+// size. The caller holds mu.
+func (mod *RecentlyOpenedModule) trim() {
+	unpinned := 0
+	kept := mod.entries[:0]
+	for _, entry := range mod.entries {
+		if !entry.Pinned {
+			unpinned++
+			if unpinned > mod.size {
+				if entry.Thumbnail != "" {
+					os.Remove(entry.Thumbnail)
+				}
+				continue
+			}
+		}
+		kept = append(kept, entry)
+	}
+	mod.entries = kept
+}
+
+// load reads the list from disk. The caller holds mu.
+func (mod *RecentlyOpenedModule) load() error {
+	data, err := os.ReadFile(mod.storePath)
+	if os.IsNotExist(err) {
+		mod.entries = nil
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &mod.entries)
+}
+
+// save writes the list to disk. The caller holds mu.
+func (mod *RecentlyOpenedModule) save() error {
+	if err := os.MkdirAll(filepath.Dir(mod.storePath), 0755); err != nil {
+		return fmt.Errorf("failed to save recently opened lessons: %w", err)
+	}
+	data, err := json.MarshalIndent(mod.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to save recently opened lessons: %w", err)
+	}
+	return os.WriteFile(mod.storePath, data, 0644)
+}
+
+// writeThumbnail stores a thumbnail of a lesson and returns its path
+func (mod *RecentlyOpenedModule) writeThumbnail(path string, lessonData *lesson.LessonData) (string, error) {
+	mod.rendererOnce.Do(func() {
+		mod.renderer = &thumbnail.Renderer{Width: ThumbnailWidth, Height: ThumbnailHeight, Maps: thumbnail.LocalMaps()}
+	})
+	name := thumbnail.CachePath(mod.thumbnailDir, path)
+	if err := mod.renderer.WriteFile(name, lessonData); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// Enable activates the module, loading the list and its size setting
 func (mod *RecentlyOpenedModule) Enable(ctx context.Context) error {
 	if err := mod.BaseModule.Enable(ctx); err != nil {
 		return err
 	}
 
-	// TODO: Port Python enable logic
+	if mod.manager != nil {
+		if module, ok := mod.manager.GetDefaultModule("settings"); ok {
+			if settings, ok := module.(Settings); ok {
+				if size, err := settings.GetInt(SizeSetting); err == nil && size >= 0 {
+					mod.size = size
+				}
+			}
+		}
+	}
+	mod.mu.Lock()
+	err := mod.load()
+	mod.mu.Unlock()
+	if err != nil {
+		fmt.Printf("Warning: failed to load recently opened lessons: %v\n", err)
+	}
 
 	fmt.Println("RecentlyOpenedModule enabled")
 	return nil
@@ -60,7 +294,9 @@ func (mod *RecentlyOpenedModule) Disable(ctx context.Context) error {
 		return err
 	}
 
-	// TODO: Port Python disable logic
+	mod.mu.Lock()
+	mod.handlers = nil
+	mod.mu.Unlock()
 
 	fmt.Println("RecentlyOpenedModule disabled")
 	return nil
@@ -75,4 +311,4 @@ func (mod *RecentlyOpenedModule) SetManager(manager *core.Manager) {
 // This is the Go equivalent of the Python init function
 func InitRecentlyOpenedModule() core.Module {
 	return NewRecentlyOpenedModule()
-}
\ No newline at end of file
+}
@@ -0,0 +1,120 @@
+package recentlyopened
+
+import (
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+func newTestModule(t *testing.T) *RecentlyOpenedModule {
+	dir := t.TempDir()
+	mod := NewRecentlyOpenedModule()
+	mod.storePath = filepath.Join(dir, "recentlyOpened.json")
+	mod.thumbnailDir = filepath.Join(dir, "thumbnails")
+	mod.size = 2
+	return mod
+}
+
+func TestPinnedLessonsStayOnTheList(t *testing.T) {
+	mod := newTestModule(t)
+	updates := 0
+	mod.OnUpdated(func() { updates++ })
+
+	start := time.Now()
+	for i, name := range []string{"a.otwd", "b.otwd", "c.otwd"} {
+		entry := Entry{Path: name, Label: name, Opened: start.Add(time.Duration(i) * time.Minute)}
+		if err := mod.Add(entry, nil); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+		if name == "a.otwd" {
+			if err := mod.SetPinned(name, true); err != nil {
+				t.Fatalf("SetPinned: %v", err)
+			}
+		}
+	}
+
+	entries := mod.RecentlyOpened()
+	var order []string
+	for _, entry := range entries {
+		order = append(order, entry.Path)
+	}
+	if len(order) != 3 || order[0] != "a.otwd" || order[1] != "c.otwd" || order[2] != "b.otwd" {
+		t.Fatalf("unexpected list %v", order)
+	}
+	if updates != 4 {
+		t.Errorf("expected 4 updates, got %d", updates)
+	}
+
+	// Reopening keeps the pin; another lesson pushes out the oldest
+	mod.Add(Entry{Path: "a.otwd", Opened: start.Add(time.Hour)}, nil)
+	mod.Add(Entry{Path: "d.otwd", Opened: start.Add(2 * time.Hour)}, nil)
+	entries = mod.RecentlyOpened()
+	if len(entries) != 3 || !entries[0].Pinned || entries[0].Path != "a.otwd" || entries[2].Path != "c.otwd" {
+		t.Fatalf("unexpected list %+v", entries)
+	}
+
+	// The list survives a restart
+	reloaded := NewRecentlyOpenedModule()
+	reloaded.storePath = mod.storePath
+	if err := reloaded.load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got := reloaded.RecentlyOpened(); len(got) != 3 || !got[0].Pinned {
+		t.Errorf("unexpected reloaded list %+v", got)
+	}
+
+	if err := mod.Remove("a.otwd"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if len(mod.RecentlyOpened()) != 2 {
+		t.Errorf("expected 2 lessons after removing one")
+	}
+	if err := mod.SetPinned("missing.otwd", true); err == nil {
+		t.Error("expected an error pinning a lesson not on the list")
+	}
+}
+
+func TestNewEntryAndTopoThumbnail(t *testing.T) {
+	lessonData := lesson.NewLessonData()
+	for i, place := range [][2]int{{10, 10}, {200, 50}, {120, 160}} {
+		x, y := place[0], place[1]
+		lessonData.List.Items = append(lessonData.List.Items, lesson.WordItem{ID: i, Name: "place", X: &x, Y: &y})
+	}
+	lessonData.List.Tests = []lesson.Test{
+		{Results: []lesson.TestResult{{Result: "wrong"}}},
+		{Results: []lesson.TestResult{{Result: "right"}, {Result: "right"}, {Result: "wrong", Credit: 0.5}, {Result: "wrong"}}},
+	}
+
+	entry := NewEntry("/lessons/europe.ottp", "topo", lessonData)
+	if entry.Label != "europe.ottp" || entry.Items != 3 || entry.LastScore != 62 {
+		t.Fatalf("unexpected entry %+v", entry)
+	}
+	if NewEntry("new.otwd", "words", lesson.NewLessonData()).LastScore != -1 {
+		t.Error("expected no score for a lesson never practised")
+	}
+
+	mod := newTestModule(t)
+	if err := mod.Add(entry, lessonData); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	thumbnail := mod.RecentlyOpened()[0].Thumbnail
+	file, err := os.Open(thumbnail)
+	if err != nil {
+		t.Fatalf("expected a thumbnail: %v", err)
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		t.Fatalf("invalid thumbnail: %v", err)
+	}
+	if img.Bounds().Dx() != ThumbnailWidth || img.Bounds().Dy() != ThumbnailHeight {
+		t.Errorf("unexpected thumbnail size %v", img.Bounds())
+	}
+	if r, _, _, _ := img.At(ThumbnailWidth/2, ThumbnailHeight/2).RGBA(); r == 0 {
+		t.Error("unexpected thumbnail content")
+	}
+}
@@ -0,0 +1,188 @@
+package restapi
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/audit"
+	"github.com/LaPingvino/recuerdo/internal/classroom"
+)
+
+// VaultManifest describes the blobs in the export of a vault
+type VaultManifest struct {
+	Vault    string     `json:"vault"`
+	Exported time.Time  `json:"exported"`
+	Blobs    []BlobInfo `json:"blobs"`
+}
+
+// exportFile is a file in a data export
+type exportFile struct {
+	name string
+	data []byte
+}
+
+// handleExportVault sends everything stored in a vault as a zip: its salt,
+// its blobs and a manifest listing them. The blobs stay encrypted; the
+// passphrase of the vault opens them. Only its owner or an admin may.
+func (mod *RestAPIModule) handleExportVault(w http.ResponseWriter, r *http.Request) {
+	dir, ok := mod.ownedVaultPath(w, r)
+	if !ok {
+		return
+	}
+
+	mod.syncMutex.Lock()
+	defer mod.syncMutex.Unlock()
+	salt, err := os.ReadFile(filepath.Join(dir, "salt"))
+	if errors.Is(err, os.ErrNotExist) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("vault %q not found", r.PathValue("vault")))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, "blobs"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	manifest := VaultManifest{Vault: r.PathValue("vault"), Exported: time.Now().UTC(), Blobs: []BlobInfo{}}
+	files := []exportFile{{"salt", salt}}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), revisionExt) {
+			continue
+		}
+		path := filepath.Join(dir, "blobs", entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		manifest.Blobs = append(manifest.Blobs, BlobInfo{Name: entry.Name(), Revision: blobRevision(path), Size: int64(len(data))})
+		files = append(files, exportFile{"blobs/" + entry.Name(), data})
+	}
+	sort.Slice(manifest.Blobs, func(i, j int) bool { return manifest.Blobs[i].Name < manifest.Blobs[j].Name })
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	files = append(files, exportFile{"vault.json", data})
+	mod.audit(r, audit.VaultExported, "vault "+manifest.Vault, fmt.Sprintf("%d blobs", len(manifest.Blobs)))
+	writeExport(w, "vault-"+manifest.Vault+".zip", files)
+}
+
+// handleDeleteVault deletes a vault with everything in it. Devices still
+// set to the vault create it anew on their next push. Only its owner or an
+// admin may.
+func (mod *RestAPIModule) handleDeleteVault(w http.ResponseWriter, r *http.Request) {
+	dir, ok := mod.ownedVaultPath(w, r)
+	if !ok {
+		return
+	}
+
+	mod.syncMutex.Lock()
+	defer mod.syncMutex.Unlock()
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("vault %q not found", r.PathValue("vault")))
+		return
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	mod.audit(r, audit.VaultDeleted, "vault "+r.PathValue("vault"), "")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ownedVaultPath returns the directory of the vault in the URL like
+// vaultPath, when r carries a token bound to the vault's name or an admin
+// token, or the server has no tokens. Every vault request goes through it,
+// so one account cannot replace the salt or the blobs of another, nor list
+// or download them. On failure it writes the error and returns false.
+func (mod *RestAPIModule) ownedVaultPath(w http.ResponseWriter, r *http.Request) (string, bool) {
+	dir, ok := mod.vaultPath(w, r)
+	if ok && !mod.owns(r, r.PathValue("vault")) {
+		writeError(w, http.StatusForbidden, fmt.Errorf("vault %q needs a token bound to it; see recuerdo serve -account-tokens", r.PathValue("vault")))
+		return "", false
+	}
+	return dir, ok
+}
+
+// handleExportStudent sends what is stored about a student as a zip: their
+// roster entry with their results, and the questions they posted
+func (mod *RestAPIModule) handleExportStudent(w http.ResponseWriter, r *http.Request) {
+	roster, id, ok := mod.rosterStudent(w, r)
+	if !ok {
+		return
+	}
+	student, err := roster.Student(id)
+	if err != nil {
+		writeRosterError(w, err)
+		return
+	}
+	results, err := roster.History(id)
+	if err != nil {
+		writeRosterError(w, err)
+		return
+	}
+	if results == nil {
+		results = []classroom.Result{}
+	}
+	threads, ok := mod.openThreads(w)
+	if !ok {
+		return
+	}
+	questions := threads.ByStudent(id)
+	if questions == nil {
+		questions = []classroom.Thread{}
+	}
+
+	record, err := json.MarshalIndent(classroom.StudentRecord{Student: student, Results: results}, "", "  ")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	posts, err := json.MarshalIndent(questions, "", "  ")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	mod.audit(r, audit.StudentExported, studentSubject(student), "")
+	writeExport(w, fmt.Sprintf("student-%d.zip", id), []exportFile{
+		{"student.json", record},
+		{"questions.json", posts},
+	})
+}
+
+// writeExport sends files as a zip download with the given file name
+func writeExport(w http.ResponseWriter, name string, files []exportFile) {
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+	for _, file := range files {
+		writer, err := archive.Create(file.name)
+		if err == nil {
+			_, err = writer.Write(file.data)
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	if err := archive.Close(); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	w.Write(buffer.Bytes())
+}
@@ -0,0 +1,192 @@
+package restapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/LaPingvino/recuerdo/internal/audit"
+	"github.com/LaPingvino/recuerdo/internal/reviewlog"
+)
+
+// accountsDir is the directory in the lesson directory that keeps the
+// lessons and review history of every account. Like syncDir it is never
+// listed.
+const accountsDir = ".accounts"
+
+// HistoryExchange is what a sync client and the server swap to bring the
+// review history of an account together. The client sends the cursor of
+// the server as it last saw it and its events after it; the server answers
+// with the events the client is missing and its new cursor.
+type HistoryExchange struct {
+	Cursor reviewlog.Cursor   `json:"cursor"`
+	Events []reviewlog.Review `json:"events"`
+}
+
+// handleExchangeHistory merges the review events a device pushed into the
+// journal of its account and sends back the ones it has not seen. Events
+// are identified by device and sequence number, so the devices of an
+// account converge whatever order they sync in. A cursor beyond the
+// journal, or pushed events that do not follow on from it, as after the
+// account was deleted, get 409 and the client sends its whole history.
+func (mod *RestAPIModule) handleExchangeHistory(w http.ResponseWriter, r *http.Request) {
+	dir, ok := mod.accountPath(w, r)
+	if !ok {
+		return
+	}
+	var request HistoryExchange
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid review history: %v", err))
+		return
+	}
+
+	mod.syncMutex.Lock()
+	defer mod.syncMutex.Unlock()
+	journal, err := reviewlog.OpenJournal(filepath.Join(dir, "reviews"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	current := journal.Cursor()
+	for device, seq := range request.Cursor {
+		if seq > current[device] {
+			writeError(w, http.StatusConflict, fmt.Errorf("the account has %d review events of device %s, not %d", current[device], device, seq))
+			return
+		}
+	}
+	// Worked out before merging, so the pushed events are not sent back
+	missing, err := journal.Diff(request.Cursor)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	added, err := journal.Merge(request.Events)
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	if journal.NeedsCompaction() {
+		if err := journal.Compact(); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	if len(added) > 0 {
+		mod.audit(r, audit.ReviewsRecorded, "account "+r.PathValue("account"), fmt.Sprintf("%d answers synced", len(added)))
+	}
+	if missing == nil {
+		missing = []reviewlog.Review{}
+	}
+	writeJSON(w, http.StatusOK, HistoryExchange{Cursor: journal.Cursor(), Events: missing})
+}
+
+// handleExportAccount sends everything stored for an account as a zip:
+// its lessons with their revisions and its review journal
+func (mod *RestAPIModule) handleExportAccount(w http.ResponseWriter, r *http.Request) {
+	dir, ok := mod.accountPath(w, r)
+	if !ok {
+		return
+	}
+
+	mod.syncMutex.Lock()
+	defer mod.syncMutex.Unlock()
+	var files []exportFile
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, exportFile{filepath.ToSlash(name), data})
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("account %q not found", r.PathValue("account")))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	mod.audit(r, audit.AccountExported, "account "+r.PathValue("account"), fmt.Sprintf("%d files", len(files)))
+	writeExport(w, "account-"+r.PathValue("account")+".zip", files)
+}
+
+// handleDeleteAccount deletes an account with its lessons and review
+// history. Devices still set to the account start it anew on their next
+// sync.
+func (mod *RestAPIModule) handleDeleteAccount(w http.ResponseWriter, r *http.Request) {
+	dir, ok := mod.accountPath(w, r)
+	if !ok {
+		return
+	}
+
+	mod.syncMutex.Lock()
+	defer mod.syncMutex.Unlock()
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("account %q not found", r.PathValue("account")))
+		return
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	mod.audit(r, audit.AccountDeleted, "account "+r.PathValue("account"), "")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// accountPath returns the directory of the account in the URL. When
+// tokens are set, only a token bound to the account or an admin token
+// reaches it. On failure it writes the error and returns false.
+func (mod *RestAPIModule) accountPath(w http.ResponseWriter, r *http.Request) (string, bool) {
+	name := r.PathValue("account")
+	if !vaultName.MatchString(name) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid account name %q", name))
+		return "", false
+	}
+	if !mod.owns(r, name) {
+		writeError(w, http.StatusForbidden, fmt.Errorf("account %q needs a token bound to it", name))
+		return "", false
+	}
+	return filepath.Join(mod.lessonDir, accountsDir, name), true
+}
+
+// syncPath returns the path of the lesson a sync request is about: one of
+// its account's lessons when the URL names an account, and one of the
+// shared lessons otherwise. On failure it writes the error and returns
+// false.
+func (mod *RestAPIModule) syncPath(w http.ResponseWriter, r *http.Request) (string, bool) {
+	path, err := mod.lessonPath(r.PathValue("name"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return "", false
+	}
+	if r.PathValue("account") == "" {
+		return path, true
+	}
+	dir, ok := mod.accountPath(w, r)
+	if !ok {
+		return "", false
+	}
+	return filepath.Join(dir, "lessons", filepath.Base(path)), true
+}
+
+// owns reports whether r may reach the account or vault name: on an open
+// server anyone may, and otherwise admins and tokens bound to it
+func (mod *RestAPIModule) owns(r *http.Request, name string) bool {
+	if !mod.hasTokens() || mod.isAdmin(r) {
+		return true
+	}
+	account, _ := r.Context().Value(accountKey{}).(string)
+	return account == name
+}
@@ -0,0 +1,124 @@
+package restapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/reviewlog"
+)
+
+func TestAccountHistory(t *testing.T) {
+	dir := t.TempDir()
+	mod := NewRestAPIModule()
+	mod.SetLessonDir(dir)
+	server := httptest.NewServer(mod.Handler())
+	defer server.Close()
+
+	exchange := func(account string, request HistoryExchange) (int, HistoryExchange) {
+		t.Helper()
+		body, _ := json.Marshal(request)
+		response, err := http.Post(server.URL+"/api/accounts/"+account+"/reviews", "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer response.Body.Close()
+		var result HistoryExchange
+		data, _ := io.ReadAll(response.Body)
+		json.Unmarshal(data, &result)
+		return response.StatusCode, result
+	}
+	review := func(device string, seq int) reviewlog.Review {
+		return reviewlog.Review{Lesson: "animals.json", Question: "cat", Right: true, Time: time.Now().UTC(), Device: device, Seq: seq}
+	}
+
+	status, result := exchange("ana", HistoryExchange{Events: []reviewlog.Review{review("laptop", 1), review("laptop", 2)}})
+	if status != http.StatusOK || len(result.Events) != 0 || result.Cursor["laptop"] != 2 {
+		t.Fatalf("Expected the laptop's events taken and none sent back, got %d %+v", status, result)
+	}
+	status, result = exchange("ana", HistoryExchange{Events: []reviewlog.Review{review("desktop", 1)}})
+	if status != http.StatusOK || len(result.Events) != 2 || result.Cursor["desktop"] != 1 {
+		t.Errorf("Expected the desktop to get the laptop's 2 events, got %d %+v", status, result)
+	}
+	status, result = exchange("ana", HistoryExchange{Cursor: reviewlog.Cursor{"laptop": 2}, Events: []reviewlog.Review{review("laptop", 2)}})
+	if status != http.StatusOK || len(result.Events) != 1 || result.Events[0].Device != "desktop" {
+		t.Errorf("Expected the laptop to get only the desktop's event, got %d %+v", status, result)
+	}
+	if status, _ := exchange("ana", HistoryExchange{Events: []reviewlog.Review{review("phone", 2)}}); status != http.StatusConflict {
+		t.Errorf("Expected 409 for events with a gap, got %d", status)
+	}
+	if status, _ := exchange("ana", HistoryExchange{Cursor: reviewlog.Cursor{"laptop": 5}}); status != http.StatusConflict {
+		t.Errorf("Expected 409 for a cursor beyond the journal, got %d", status)
+	}
+	if status, result := exchange("bea", HistoryExchange{}); status != http.StatusOK || len(result.Events) != 0 {
+		t.Errorf("Expected another account to be empty, got %d %+v", status, result)
+	}
+	if status, _ := exchange("a.b", HistoryExchange{}); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid account name, got %d", status)
+	}
+
+	request, _ := http.NewRequest(http.MethodDelete, server.URL+"/api/accounts/ana", nil)
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusNoContent {
+		t.Errorf("Expected 204 deleting the account, got %d", response.StatusCode)
+	}
+	if _, err := os.Stat(filepath.Join(dir, accountsDir, "ana")); !os.IsNotExist(err) {
+		t.Error("Expected the account to be gone")
+	}
+	if status, _ := exchange("ana", HistoryExchange{Cursor: reviewlog.Cursor{"laptop": 2}}); status != http.StatusConflict {
+		t.Errorf("Expected 409 for the cursor of a deleted account, got %d", status)
+	}
+}
+
+func TestAccountTokens(t *testing.T) {
+	mod := NewRestAPIModule()
+	mod.SetLessonDir(t.TempDir())
+	mod.SetTokens([]string{"shared"})
+	mod.SetAdminTokens([]string{"admin"})
+	mod.SetAccountTokens([]string{"ana=ana-token", " bea=bea-token", "", "broken"})
+	mod.SetClassroomEnabled(false)
+	server := httptest.NewServer(mod.Handler())
+	defer server.Close()
+
+	send := func(method, path, token string) int {
+		t.Helper()
+		request, _ := http.NewRequest(method, server.URL+path, bytes.NewReader([]byte("{}")))
+		request.Header.Set("Authorization", "Bearer "+token)
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		return response.StatusCode
+	}
+
+	for _, test := range []struct {
+		method, path, token string
+		want                int
+	}{
+		{http.MethodPost, "/api/accounts/ana/reviews", "ana-token", http.StatusOK},
+		{http.MethodPost, "/api/accounts/ana/reviews", "bea-token", http.StatusForbidden},
+		{http.MethodPost, "/api/accounts/ana/reviews", "shared", http.StatusForbidden},
+		{http.MethodGet, "/api/accounts/ana/export", "bea-token", http.StatusForbidden},
+		{http.MethodGet, "/api/accounts/ana/sync/animals.json", "bea-token", http.StatusForbidden},
+		{http.MethodGet, "/api/accounts/ana/export", "admin", http.StatusOK},
+		{http.MethodDelete, "/api/accounts/ana", "bea-token", http.StatusForbidden},
+		{http.MethodDelete, "/api/accounts/ana", "ana-token", http.StatusNoContent},
+		{http.MethodGet, "/api/lessons", "bea-token", http.StatusOK},
+		{http.MethodGet, "/api/lessons", "broken", http.StatusUnauthorized},
+	} {
+		if status := send(test.method, test.path, test.token); status != test.want {
+			t.Errorf("Expected %d for %s %s with %s, got %d", test.want, test.method, test.path, test.token, status)
+		}
+	}
+}
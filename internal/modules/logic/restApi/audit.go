@@ -0,0 +1,158 @@
+package restapi
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/audit"
+	"github.com/LaPingvino/recuerdo/internal/classroom"
+	"github.com/LaPingvino/recuerdo/internal/paths"
+)
+
+// auditFile is where the audit log is kept, next to the roster
+const auditFile = ".classroom/audit.log"
+
+// auditStateDir is the directory in the data directory keeping the key
+// and head of every audit log, out of reach of those who can change the
+// lesson directory
+const auditStateDir = "audit"
+
+// handleAudit sends the audit log to an admin. The action parameter keeps
+// the entries whose action starts with it, and since those recorded from
+// then on (RFC 3339).
+func (mod *RestAPIModule) handleAudit(w http.ResponseWriter, r *http.Request) {
+	if len(mod.adminTokens) == 0 {
+		writeError(w, http.StatusForbidden, errors.New("the audit log needs an admin token, and none are set; see recuerdo serve -admin-tokens"))
+		return
+	}
+	if !mod.isAdmin(r) {
+		writeError(w, http.StatusForbidden, errors.New("the audit log needs an admin token"))
+		return
+	}
+	var since time.Time
+	if value := r.URL.Query().Get("since"); value != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339, value); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid since %q: %w", value, err))
+			return
+		}
+	}
+	action := r.URL.Query().Get("action")
+
+	auditLog, err := mod.openAudit()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	entries, err := auditLog.Entries()
+	response := audit.Report{Entries: []audit.Entry{}, Intact: err == nil}
+	if err != nil {
+		if !errors.Is(err, audit.ErrTampered) {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		response.Problem = err.Error()
+	}
+	for _, entry := range entries {
+		if entry.Time.Before(since) || !strings.HasPrefix(entry.Action, action) {
+			continue
+		}
+		response.Entries = append(response.Entries, entry)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// audit records an action in the audit log, by whoever sent r; r may be
+// nil for actions the server takes itself. An action that cannot be
+// recorded is logged but not undone.
+func (mod *RestAPIModule) audit(r *http.Request, action, subject, details string) {
+	entry := audit.Entry{Action: action, Subject: subject, Details: details}
+	if r != nil {
+		entry.Actor = mod.actor(r)
+		entry.Address = clientAddress(r)
+	}
+	auditLog, err := mod.openAudit()
+	if err == nil {
+		err = auditLog.Record(entry)
+	}
+	if err != nil {
+		log.Printf("[ERROR] RestAPIModule - failed to audit %s of %s: %v", action, subject, err)
+	}
+}
+
+// openAudit returns the audit log, opening it on first use
+func (mod *RestAPIModule) openAudit() (*audit.Log, error) {
+	mod.auditMutex.Lock()
+	defer mod.auditMutex.Unlock()
+	if mod.auditLog == nil {
+		path, err := filepath.Abs(filepath.Join(mod.lessonDir, filepath.FromSlash(auditFile)))
+		if err != nil {
+			return nil, err
+		}
+		// Named by the log, so servers of several lesson directories
+		// keep apart
+		sum := sha256.Sum256([]byte(path))
+		statePath := filepath.Join(paths.DataDir(), auditStateDir, hex.EncodeToString(sum[:8])+".json")
+		auditLog, err := audit.Open(path, statePath)
+		if err != nil {
+			return nil, err
+		}
+		mod.auditLog = auditLog
+	}
+	return mod.auditLog, nil
+}
+
+// actor names the token a request was sent with by a fingerprint, so the
+// audit log tells token holders apart without keeping the tokens
+func (mod *RestAPIModule) actor(r *http.Request) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	kind := "token"
+	if mod.isAdmin(r) {
+		kind = "admin"
+	} else if mod.isTeacher(r) {
+		kind = "teacher"
+	}
+	return kind + " " + hex.EncodeToString(sum[:4])
+}
+
+// isAdmin reports whether r carries one of the admin tokens
+func (mod *RestAPIModule) isAdmin(r *http.Request) bool {
+	return carriesToken(r, mod.adminTokens)
+}
+
+// isTeacher reports whether r carries one of the teacher tokens, which
+// admin tokens count as too
+func (mod *RestAPIModule) isTeacher(r *http.Request) bool {
+	return carriesToken(r, mod.teacherTokens) || mod.isAdmin(r)
+}
+
+// carriesToken reports whether r carries one of tokens
+func carriesToken(r *http.Request, tokens []string) bool {
+	given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	for _, token := range tokens {
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// studentSubject names a student in the audit log
+func studentSubject(student classroom.Student) string {
+	return fmt.Sprintf("student %d (%s)", student.ID, student.Name)
+}
@@ -0,0 +1,79 @@
+package restapi
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/audit"
+	"github.com/LaPingvino/recuerdo/internal/classroom"
+)
+
+func TestAuditLog(t *testing.T) {
+	dir := t.TempDir()
+	mod := NewRestAPIModule()
+	mod.SetLessonDir(dir)
+	mod.SetTeacherTokens([]string{"teacher-token"})
+	mod.SetAdminTokens([]string{"admin-token"})
+	server := httptest.NewServer(mod.Handler())
+	defer server.Close()
+
+	teacher := classroom.NewClient(server.URL)
+	teacher.SetToken("teacher-token")
+	admin := classroom.NewClient(server.URL)
+	admin.SetToken("admin-token")
+
+	anna, err := teacher.Add("Anna", "3A")
+	if err != nil {
+		t.Fatalf("Failed to add student: %v", err)
+	}
+	teacher.RecordResult(anna.ID, classroom.Result{Lesson: "Animals", Right: 9, Wrong: 1})
+	if err := admin.Assign("3A", "Animals"); err != nil {
+		t.Fatalf("Expected admin tokens to be access tokens too: %v", err)
+	}
+
+	if _, err := teacher.AuditLog("", time.Time{}); err == nil {
+		t.Error("Expected the audit log to need an admin token")
+	}
+	report, err := admin.AuditLog("", time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	if !report.Intact || len(report.Entries) != 3 {
+		t.Fatalf("Expected 3 intact entries, got %+v", report)
+	}
+	recorded := report.Entries[1]
+	if recorded.Action != audit.ResultRecorded || recorded.Subject != "student 0 (Anna)" || recorded.Details != "Animals: 9 right, 1 wrong" {
+		t.Errorf("Unexpected result entry %+v", recorded)
+	}
+	if !strings.HasPrefix(recorded.Actor, "teacher ") || strings.Contains(recorded.Actor, "teacher-token") || recorded.Address == "" {
+		t.Errorf("Expected a token fingerprint and address, got %+v", recorded)
+	}
+	if !strings.HasPrefix(report.Entries[2].Actor, "admin ") {
+		t.Errorf("Expected the assignment by an admin, got %+v", report.Entries[2])
+	}
+	if report, _ := admin.AuditLog("student.", time.Time{}); len(report.Entries) != 1 {
+		t.Errorf("Expected one student entry, got %+v", report.Entries)
+	}
+	if report, _ := admin.AuditLog("", time.Now().Add(time.Hour)); len(report.Entries) != 0 {
+		t.Errorf("Expected no entries from the future, got %+v", report.Entries)
+	}
+
+	path := filepath.Join(dir, filepath.FromSlash(auditFile))
+	data, _ := os.ReadFile(path)
+	os.WriteFile(path, []byte(strings.Replace(string(data), "9 right, 1 wrong", "10 right, 0 wrong", 1)), 0600)
+	if report, err := admin.AuditLog("", time.Time{}); err != nil || report.Intact || report.Problem == "" {
+		t.Errorf("Expected the changed result to be reported, got %+v, %v", report, err)
+	}
+
+	open := NewRestAPIModule()
+	open.SetLessonDir(dir)
+	openServer := httptest.NewServer(open.Handler())
+	defer openServer.Close()
+	if _, err := classroom.NewClient(openServer.URL).AuditLog("", time.Time{}); err == nil {
+		t.Error("Expected the audit log to be closed without admin tokens")
+	}
+}
@@ -0,0 +1,183 @@
+package restapi
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/audit"
+	"github.com/LaPingvino/recuerdo/internal/classroom"
+	"github.com/LaPingvino/recuerdo/internal/discovery"
+	"golang.org/x/net/websocket"
+)
+
+// handleLive connects a teacher, or the student a code parameter belongs
+// to, to the live test channel over a WebSocket. Students also send the
+// session PIN as the pin parameter. Messages both ways are
+// classroom.LiveMessage as JSON; see classroom.Live for what they mean.
+// Without a code the request needs a teacher token, as the teacher sees the
+// PIN and every answer and pushes and locks tests.
+func (mod *RestAPIModule) handleLive(w http.ResponseWriter, r *http.Request) {
+	code := r.URL.Query().Get("code")
+	if code == "" {
+		roster, ok := mod.teacherRoster(w, r)
+		if !ok {
+			return
+		}
+		live := mod.openLive(roster)
+		mod.webSocket(func(ws *websocket.Conn) {
+			mod.serveLiveTeacher(ws, live)
+		}).ServeHTTP(w, r)
+		return
+	}
+
+	roster, ok := mod.openRoster(w)
+	if !ok {
+		return
+	}
+	live := mod.openLive(roster)
+	// The PIN goes first, so join codes cannot be tried from outside the
+	// room
+	if err := live.CheckPIN(r.URL.Query().Get("pin")); err != nil {
+		writeError(w, http.StatusForbidden, err)
+		return
+	}
+	student, err := roster.Join(code)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	mod.webSocket(func(ws *websocket.Conn) {
+		mod.serveLiveStudent(ws, live, student)
+	}).ServeHTTP(w, r)
+}
+
+// serveLiveTeacher passes on what happens in the live test to a teacher
+// and carries out the tests pushed and locked
+func (mod *RestAPIModule) serveLiveTeacher(ws *websocket.Conn, live *classroom.Live) {
+	messages, leave := live.Teach()
+	defer leave()
+	mod.countLiveTeacher(1)
+	defer mod.countLiveTeacher(-1)
+	go forwardLive(ws, messages)
+
+	for {
+		var message classroom.LiveMessage
+		if err := websocket.JSON.Receive(ws, &message); err != nil {
+			return
+		}
+		switch message.Type {
+		case classroom.LiveStart:
+			if err := live.Start(message.Lesson, time.Duration(message.Minutes)*time.Minute); err != nil {
+				sendLiveError(ws, err)
+				continue
+			}
+			mod.audit(ws.Request(), audit.LiveTestStarted, message.Lesson.List.Title, fmt.Sprintf("%d minutes", message.Minutes))
+		case classroom.LiveLock:
+			live.Lock()
+			mod.audit(ws.Request(), audit.LiveTestLocked, "", "")
+		default:
+			sendLiveError(ws, fmt.Errorf("teachers cannot send %q messages", message.Type))
+		}
+	}
+}
+
+// serveLiveStudent sends the tests to a student and takes their answers
+func (mod *RestAPIModule) serveLiveStudent(ws *websocket.Conn, live *classroom.Live, student classroom.Student) {
+	messages, leave := live.Join(student)
+	defer leave()
+	go forwardLive(ws, messages)
+
+	for {
+		var message classroom.LiveMessage
+		if err := websocket.JSON.Receive(ws, &message); err != nil {
+			return
+		}
+		if message.Type != classroom.LiveAnswer || message.Answer == nil {
+			sendLiveError(ws, fmt.Errorf("students can only send answers"))
+			continue
+		}
+		if _, err := live.Answer(student, message.Answer.ItemID, message.Answer.Answer); err != nil {
+			sendLiveError(ws, err)
+		}
+	}
+}
+
+// forwardLive sends the messages of the live test to a client until it
+// leaves or the server stops, and then closes the connection
+func forwardLive(ws *websocket.Conn, messages <-chan classroom.LiveMessage) {
+	defer ws.Close()
+	for message := range messages {
+		if err := websocket.JSON.Send(ws, message); err != nil {
+			return
+		}
+	}
+}
+
+// sendLiveError tells a client why its message was refused
+func sendLiveError(ws *websocket.Conn, err error) {
+	if errors.Is(err, classroom.ErrLocked) {
+		err = fmt.Errorf("time is up: %w", err)
+	}
+	websocket.JSON.Send(ws, classroom.LiveMessage{Type: classroom.LiveError, Error: err.Error()})
+}
+
+// openLive returns the live test hub, creating it on first use; it keeps
+// the results of locked tests in the roster and the audit log
+func (mod *RestAPIModule) openLive(roster *classroom.Roster) *classroom.Live {
+	mod.rosterMutex.Lock()
+	defer mod.rosterMutex.Unlock()
+	if mod.live == nil {
+		mod.live = classroom.NewLive(func(studentID int, result classroom.Result) error {
+			if err := roster.RecordResult(studentID, result); err != nil {
+				return err
+			}
+			student, _ := roster.Student(studentID)
+			mod.audit(nil, audit.ResultRecorded, studentSubject(student), resultDetails(result)+" (live test)")
+			return nil
+		})
+	}
+	return mod.live
+}
+
+// advertise announces the classroom on the local network as serving on
+// port. Failing to is not fatal; students can still type the address.
+func (mod *RestAPIModule) advertise(port int) {
+	advertiser, err := discovery.Advertise(discovery.Service{Name: mod.name, Port: port, Text: mod.liveText(false)})
+	if err != nil {
+		log.Printf("[WARNING] RestAPIModule - not announcing the classroom on the local network: %v", err)
+		return
+	}
+	mod.rosterMutex.Lock()
+	mod.advertiser = advertiser
+	mod.rosterMutex.Unlock()
+	log.Printf("[INFO] RestAPIModule - announcing the classroom as %q on the local network", mod.name)
+}
+
+// countLiveTeacher counts a teacher connecting to the live test channel, or
+// leaving with -1, and announces whether a session is open
+func (mod *RestAPIModule) countLiveTeacher(delta int) {
+	mod.rosterMutex.Lock()
+	defer mod.rosterMutex.Unlock()
+	wasOpen := mod.liveTeachers > 0
+	mod.liveTeachers += delta
+	if open := mod.liveTeachers > 0; open != wasOpen && mod.advertiser != nil {
+		mod.advertiser.SetText(mod.liveText(open))
+	}
+}
+
+// liveText is the TXT record of the classroom, telling browsing students
+// whether a teacher has a live test session open, and whether the server
+// speaks HTTPS
+func (mod *RestAPIModule) liveText(open bool) map[string]string {
+	text := map[string]string{"live": "0"}
+	if open {
+		text["live"] = "1"
+	}
+	if mod.certFile != "" || len(mod.acmeDomains) > 0 {
+		text["tls"] = "1"
+	}
+	return text
+}
@@ -0,0 +1,106 @@
+package restapi
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/LaPingvino/recuerdo/internal/classroom"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+func TestRestAPILiveTest(t *testing.T) {
+	mod := NewRestAPIModule()
+	mod.SetLessonDir(t.TempDir())
+	server := httptest.NewServer(mod.Handler())
+	defer server.Close()
+	client := classroom.NewClient(server.URL)
+	anna, err := client.Add("Anna", "3B")
+	if err != nil {
+		t.Fatalf("Failed to add student: %v", err)
+	}
+
+	teacher, err := client.Live("", "")
+	if err != nil {
+		t.Fatalf("Failed to connect the teacher: %v", err)
+	}
+	defer teacher.Close()
+	receive := func(conn *classroom.LiveConn, want string) classroom.LiveMessage {
+		t.Helper()
+		message, err := conn.Receive()
+		if err != nil || message.Type != want {
+			t.Fatalf("Expected a %s message, got %+v, %v", want, message, err)
+		}
+		return message
+	}
+	pin := receive(teacher, classroom.LiveSession).PIN
+
+	if _, err := client.Live("NOPE00", pin); err == nil {
+		t.Error("Expected an unknown join code to be refused")
+	}
+	if _, err := client.Live(anna.JoinCode, "000000x"); err == nil {
+		t.Error("Expected a wrong session PIN to be refused")
+	}
+	student, err := client.Live(anna.JoinCode, pin)
+	if err != nil {
+		t.Fatalf("Failed to connect the student: %v", err)
+	}
+	defer student.Close()
+	receive(teacher, classroom.LiveJoined)
+
+	lessonData := &lesson.LessonData{List: lesson.WordList{Title: "Animals", Items: []lesson.WordItem{
+		{ID: 0, Questions: []string{"cat"}, Answers: []string{"gato"}},
+	}}}
+	if err := teacher.Send(classroom.LiveMessage{Type: classroom.LiveStart, Lesson: lessonData, Minutes: 5}); err != nil {
+		t.Fatal(err)
+	}
+	test := receive(student, classroom.LiveTest).Test
+	if test.Title != "Animals" || test.Deadline == nil || len(test.Items) != 1 {
+		t.Fatalf("Unexpected test %+v", test)
+	}
+	receive(teacher, classroom.LiveTest)
+
+	if err := student.Send(classroom.LiveMessage{Type: classroom.LiveAnswer, Answer: &classroom.LiveAnswerInfo{ItemID: 0, Answer: "gato"}}); err != nil {
+		t.Fatal(err)
+	}
+	if answer := receive(teacher, classroom.LiveAnswer).Answer; !answer.Right || answer.StudentID != anna.ID {
+		t.Errorf("Expected Anna's right answer, got %+v", answer)
+	}
+
+	if err := teacher.Send(classroom.LiveMessage{Type: classroom.LiveLock}); err != nil {
+		t.Fatal(err)
+	}
+	receive(student, classroom.LiveLocked)
+	receive(teacher, classroom.LiveLocked)
+	if err := student.Send(classroom.LiveMessage{Type: classroom.LiveAnswer, Answer: &classroom.LiveAnswerInfo{ItemID: 0, Answer: "gato"}}); err != nil {
+		t.Fatal(err)
+	}
+	receive(student, classroom.LiveError)
+
+	record, err := client.Student(anna.ID)
+	if err != nil || len(record.Results) != 1 || record.Results[0].Right != 1 {
+		t.Errorf("Expected the live test result on the roster, got %+v, %v", record, err)
+	}
+}
+
+func TestLiveTeacherNeedsTeacherToken(t *testing.T) {
+	mod := NewRestAPIModule()
+	mod.SetLessonDir(t.TempDir())
+	mod.SetTokens([]string{"class-token"})
+	mod.SetTeacherTokens([]string{"teacher-token"})
+	server := httptest.NewServer(mod.Handler())
+	defer server.Close()
+
+	student := classroom.NewClient(server.URL)
+	student.SetToken("class-token")
+	if conn, err := student.Live("", ""); err == nil {
+		conn.Close()
+		t.Fatal("Expected a student token to be refused on the teacher channel")
+	}
+	teacher := classroom.NewClient(server.URL)
+	teacher.SetToken("teacher-token")
+	conn, err := teacher.Live("", "")
+	if err != nil {
+		t.Fatalf("Failed to connect the teacher: %v", err)
+	}
+	conn.Close()
+}
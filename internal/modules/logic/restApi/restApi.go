@@ -0,0 +1,576 @@
+// Package restapi serves the lessons in a directory over a small JSON REST
+// API, so recuerdo can run headless on a server and web and mobile
+// frontends can list, download and upload lessons, fetch the items due and
+// send back the answers given.
+//
+// Devices set to an account keep their own lessons and review history in
+// it, apart from the shared lessons. Devices that encrypt what they sync
+// keep it in vaults of opaque blobs instead, which the server stores
+// without reading; see package vault. An account or a vault, like a
+// student on the class roster, can be exported as a zip and deleted with
+// everything stored for it.
+//
+// When access tokens are set, every request but the health check needs
+// one as "Authorization: Bearer TOKEN", and addresses sending wrong ones
+// are locked out for a while. An account is then only reached with a
+// token bound to it or an admin token; see SetAccountTokens. Students
+// share the access tokens, so the class roster takes a teacher token, and
+// a student hands in results with their join code; see SetTeacherTokens.
+// To expose the server beyond the local network, serve it over TLS, from
+// certificate files or Let's Encrypt, limit the requests of every address
+// and name the web origins that may call it; see SetTLSFiles, SetACME,
+// SetRateLimit and SetCORSOrigins. Administrative and grading actions are
+// recorded in an audit log, which only admin tokens may read.
+package restapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/audit"
+	"github.com/LaPingvino/recuerdo/internal/classroom"
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/discovery"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/LaPingvino/recuerdo/internal/thumbnail"
+)
+
+// DefaultAddr is the address the API listens on when none is set. It only
+// takes connections from the machine itself; serving others takes an
+// address such as ":8080", and tokens to go with it.
+const DefaultAddr = "127.0.0.1:8080"
+
+// shutdownTimeout bounds how long Disable waits for running requests
+const shutdownTimeout = 5 * time.Second
+
+// LessonInfo describes one lesson file in a listing
+type LessonInfo struct {
+	Name     string    `json:"name"`
+	Format   string    `json:"format"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+// RestAPIModule serves lessons from a directory over HTTP
+type RestAPIModule struct {
+	*core.BaseModule
+	manager    *core.Manager
+	addr       string
+	lessonDir  string
+	server     *http.Server
+	listener   net.Listener
+	fileLoader *lesson.FileLoader
+	fileSaver  *lesson.FileSaver
+	// thumbnails draws the pictures of lessons in listings
+	thumbnails *thumbnail.Renderer
+	// syncMutex serializes changing lesson files, such as merging pushed
+	// edits and recording reviews
+	syncMutex sync.Mutex
+	// tokens are the access tokens requests need; none lets every request
+	// through
+	tokens []string
+	// adminTokens may also read the audit log
+	adminTokens []string
+	// teacherTokens may also read and change the class roster
+	teacherTokens []string
+	// accountTokens map the tokens bound to one account to its name; only
+	// they and admin tokens reach the account's routes
+	accountTokens map[string]string
+	// certFile and keyFile, or the ACME domains, serve HTTPS
+	certFile    string
+	keyFile     string
+	acmeDomains []string
+	acmeCache   string
+	acmeEmail   string
+	// rateLimit is how many requests a minute an address may send, 0 for
+	// any number; limiter counts them and the wrong tokens sent
+	rateLimit int
+	limiter   *rateLimiter
+	// corsOrigins are the origins of the web pages that may call the API
+	corsOrigins []string
+	// auditLog records administrative and grading actions, opened on
+	// first use
+	auditLog   *audit.Log
+	auditMutex sync.Mutex
+	// roster is the class roster, opened on first use
+	roster *classroom.Roster
+	// threads are the discussions about lesson items, opened on first use
+	threads *classroom.Threads
+	// live runs the live tests, created on first use
+	live *classroom.Live
+	// liveTeachers counts the teachers connected to the live test channel
+	liveTeachers int
+	rosterMutex  sync.Mutex
+	// classroom serves the roster, join and thread routes
+	classroom bool
+	// name is what the classroom is announced as on the local network,
+	// empty not to announce it
+	name       string
+	advertiser *discovery.Advertiser
+}
+
+// NewRestAPIModule creates a new RestAPIModule serving the current directory
+func NewRestAPIModule() *RestAPIModule {
+	base := core.NewBaseModule("restApi", "rest-api-module")
+
+	return &RestAPIModule{
+		BaseModule: base,
+		addr:       DefaultAddr,
+		lessonDir:  ".",
+		fileLoader: lesson.NewFileLoader(),
+		fileSaver:  lesson.NewFileSaver(),
+		thumbnails: thumbnail.New(thumbnail.LocalMaps()),
+		classroom:  true,
+	}
+}
+
+// SetAddr sets the listen address; it takes effect on the next Enable
+func (mod *RestAPIModule) SetAddr(addr string) {
+	mod.addr = addr
+}
+
+// SetLessonDir sets the directory whose lessons are served
+func (mod *RestAPIModule) SetLessonDir(dir string) {
+	mod.lessonDir = dir
+}
+
+// SetTokens sets the access tokens requests need; it takes effect on the
+// next call of Handler
+func (mod *RestAPIModule) SetTokens(tokens []string) {
+	mod.tokens = nil
+	for _, token := range tokens {
+		if token = strings.TrimSpace(token); token != "" {
+			mod.tokens = append(mod.tokens, token)
+		}
+	}
+}
+
+// SetAdminTokens sets the tokens of the admins, who may read the audit log
+// besides everything other tokens may; it takes effect on the next call of
+// Handler
+func (mod *RestAPIModule) SetAdminTokens(tokens []string) {
+	mod.adminTokens = nil
+	for _, token := range tokens {
+		if token = strings.TrimSpace(token); token != "" {
+			mod.adminTokens = append(mod.adminTokens, token)
+		}
+	}
+}
+
+// SetTeacherTokens sets the tokens of the teachers, who may read and change
+// the class roster besides everything other tokens may. Students share the
+// other tokens, so without a teacher or admin token the roster is closed.
+// It takes effect on the next call of Handler.
+func (mod *RestAPIModule) SetTeacherTokens(tokens []string) {
+	mod.teacherTokens = nil
+	for _, token := range tokens {
+		if token = strings.TrimSpace(token); token != "" {
+			mod.teacherTokens = append(mod.teacherTokens, token)
+		}
+	}
+}
+
+// SetAccountTokens sets the tokens bound to one account, each given as
+// ACCOUNT=TOKEN. They are access tokens too, but of the account routes
+// they only reach their own account's, and they alone may export or
+// delete the vault of the same name. It takes effect on the next call of
+// Handler.
+func (mod *RestAPIModule) SetAccountTokens(tokens []string) {
+	mod.accountTokens = map[string]string{}
+	for _, entry := range tokens {
+		account, token, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok && account == "" {
+			continue
+		}
+		if !ok || token == "" || !vaultName.MatchString(account) {
+			log.Printf("[WARNING] RestAPIModule - ignoring account token %q, which is not ACCOUNT=TOKEN", account)
+			continue
+		}
+		mod.accountTokens[token] = account
+	}
+}
+
+// SetClassroomEnabled sets whether the roster, join and thread routes are
+// served; it takes effect on the next call of Handler
+func (mod *RestAPIModule) SetClassroomEnabled(enabled bool) {
+	mod.classroom = enabled
+}
+
+// SetAdvertisedName sets the name the classroom is announced under on the
+// local network, so students find it without typing its address; empty
+// does not announce it. It takes effect on the next Enable, and only while
+// the classroom routes are served.
+func (mod *RestAPIModule) SetAdvertisedName(name string) {
+	mod.name = name
+}
+
+// Addr returns the address the API is listening on, or the configured
+// address while it is not running
+func (mod *RestAPIModule) Addr() string {
+	if mod.listener != nil {
+		return mod.listener.Addr().String()
+	}
+	return mod.addr
+}
+
+// Enable starts the HTTP server
+func (mod *RestAPIModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	tlsConfig, err := mod.tlsConfig()
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", mod.addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", mod.addr, err)
+	}
+	mod.listener = listener
+	mod.server = &http.Server{Handler: mod.Handler(), ReadHeaderTimeout: 10 * time.Second, TLSConfig: tlsConfig}
+
+	go func() {
+		serve := mod.server.Serve
+		if tlsConfig != nil {
+			serve = func(listener net.Listener) error { return mod.server.ServeTLS(listener, "", "") }
+		}
+		if err := serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("[ERROR] RestAPIModule - server stopped: %v", err)
+		}
+	}()
+
+	scheme := "HTTP"
+	if tlsConfig != nil {
+		scheme = "HTTPS"
+	}
+	log.Printf("[INFO] RestAPIModule - serving %s on %s over %s", mod.lessonDir, listener.Addr(), scheme)
+	mod.warnIfExposed(listener.Addr(), tlsConfig != nil)
+	if mod.classroom && mod.name != "" {
+		mod.advertise(listener.Addr().(*net.TCPAddr).Port)
+	}
+	fmt.Println("RestAPIModule enabled")
+	return nil
+}
+
+// Disable stops the HTTP server, waiting briefly for running requests, and
+// disconnects the live test channel
+func (mod *RestAPIModule) Disable(ctx context.Context) error {
+	mod.rosterMutex.Lock()
+	if mod.live != nil {
+		mod.live.Close()
+		mod.live = nil
+	}
+	if mod.advertiser != nil {
+		mod.advertiser.Close()
+		mod.advertiser = nil
+	}
+	mod.rosterMutex.Unlock()
+	if mod.server != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := mod.server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("[WARNING] RestAPIModule - shutdown: %v", err)
+		}
+		mod.server = nil
+		mod.listener = nil
+	}
+
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("RestAPIModule disabled")
+	return nil
+}
+
+// Handler returns the API's HTTP handler
+func (mod *RestAPIModule) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", mod.handleHealth)
+	mux.HandleFunc("GET /api/formats", mod.handleFormats)
+	mux.HandleFunc("GET /api/lessons", mod.handleListLessons)
+	mux.HandleFunc("GET /api/lessons/{name}", mod.handleGetLesson)
+	mux.HandleFunc("GET /api/lessons/{name}/thumbnail.png", mod.handleGetThumbnail)
+	mux.HandleFunc("PUT /api/lessons/{name}", mod.handlePutLesson)
+	mux.HandleFunc("GET /api/lessons/{name}/due", mod.handleGetDue)
+	mux.HandleFunc("POST /api/lessons/{name}/reviews", mod.handlePostReviews)
+	mux.HandleFunc("GET /api/sync/{name}", mod.handleGetSync)
+	mux.HandleFunc("POST /api/sync/{name}", mod.handlePostSync)
+	mux.HandleFunc("GET /api/accounts/{account}/sync/{name}", mod.handleGetSync)
+	mux.HandleFunc("POST /api/accounts/{account}/sync/{name}", mod.handlePostSync)
+	mux.HandleFunc("POST /api/accounts/{account}/reviews", mod.handleExchangeHistory)
+	mux.HandleFunc("GET /api/accounts/{account}/export", mod.handleExportAccount)
+	mux.HandleFunc("DELETE /api/accounts/{account}", mod.handleDeleteAccount)
+	mux.HandleFunc("GET /api/audit", mod.handleAudit)
+	mux.HandleFunc("GET /api/vault/{vault}/salt", mod.handleGetSalt)
+	mux.HandleFunc("PUT /api/vault/{vault}/salt", mod.handlePutSalt)
+	mux.HandleFunc("GET /api/vault/{vault}/export", mod.handleExportVault)
+	mux.HandleFunc("DELETE /api/vault/{vault}", mod.handleDeleteVault)
+	mux.HandleFunc("GET /api/vault/{vault}/blobs", mod.handleListBlobs)
+	mux.HandleFunc("GET /api/vault/{vault}/blobs/{blob}", mod.handleGetBlob)
+	mux.HandleFunc("PUT /api/vault/{vault}/blobs/{blob}", mod.handlePutBlob)
+	mod.limiter = newRateLimiter(mod.rateLimit)
+	handler := logRequests(mod.secureHeaders(mod.limit(mod.cors(mod.authenticate(mux)))))
+	if !mod.classroom {
+		return handler
+	}
+	mux.HandleFunc("GET /api/roster", mod.handleListStudents)
+	mux.HandleFunc("POST /api/roster", mod.handleAddStudent)
+	mux.HandleFunc("POST /api/roster/import", mod.handleImportStudents)
+	mux.HandleFunc("GET /api/roster/{id}", mod.handleGetStudent)
+	mux.HandleFunc("PUT /api/roster/{id}", mod.handleUpdateStudent)
+	mux.HandleFunc("DELETE /api/roster/{id}", mod.handleRemoveStudent)
+	mux.HandleFunc("POST /api/roster/{id}/code", mod.handleNewJoinCode)
+	mux.HandleFunc("GET /api/roster/{id}/export", mod.handleExportStudent)
+	mux.HandleFunc("POST /api/roster/{id}/results", mod.handleRecordResult)
+	mux.HandleFunc("GET /api/assignments", mod.handleListAssignments)
+	mux.HandleFunc("POST /api/assignments", mod.handleAssign)
+	mux.HandleFunc("DELETE /api/assignments", mod.handleUnassign)
+	mux.HandleFunc("GET /api/gradebook", mod.handleGradebook)
+	mux.HandleFunc("POST /api/join", mod.handleJoin)
+	mux.HandleFunc("GET /api/threads", mod.handleListThreads)
+	mux.HandleFunc("GET /api/threads/{name}", mod.handleLessonThreads)
+	mux.HandleFunc("POST /api/threads/{name}/{item}", mod.handlePostThread)
+	mux.HandleFunc("PUT /api/threads/{name}/{item}", mod.handleResolveThread)
+	mux.HandleFunc("GET /api/live", mod.handleLive)
+	return handler
+}
+
+// authenticate refuses requests without one of the access tokens, when
+// there are any, and locks out addresses that keep sending wrong ones. The
+// health check stays open for container probes. Requests with an account
+// token carry its account in their context.
+func (mod *RestAPIModule) authenticate(next http.Handler) http.Handler {
+	if !mod.hasTokens() {
+		return next
+	}
+	tokens := append(append(append([]string(nil), mod.tokens...), mod.adminTokens...), mod.teacherTokens...)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		address := clientAddress(r)
+		if locked, wait := mod.limiter.lockedOut(address, time.Now()); locked {
+			tooManyRequests(w, wait, errors.New("too many wrong access tokens, try again later"))
+			return
+		}
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if ok {
+			for _, token := range tokens {
+				if subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1 {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			for token, account := range mod.accountTokens {
+				if subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1 {
+					next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), accountKey{}, account)))
+					return
+				}
+			}
+		}
+		mod.limiter.failed(address, time.Now())
+		w.Header().Set("WWW-Authenticate", `Bearer realm="recuerdo"`)
+		writeError(w, http.StatusUnauthorized, errors.New("a valid access token is needed"))
+	})
+}
+
+// accountKey is the context key of the account a request's token is bound
+// to
+type accountKey struct{}
+
+// hasTokens reports whether any tokens are set, which closes the API to
+// requests without one
+func (mod *RestAPIModule) hasTokens() bool {
+	return len(mod.tokens) > 0 || len(mod.adminTokens) > 0 || len(mod.teacherTokens) > 0 || len(mod.accountTokens) > 0
+}
+
+// logRequests logs every request, which ends up on stdout when serving
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		log.Printf("[INFO] RestAPIModule - %s %s (%s)", r.Method, r.URL.Path, time.Since(start).Round(time.Millisecond))
+	})
+}
+
+func (mod *RestAPIModule) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (mod *RestAPIModule) handleFormats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string][]string{
+		"load": mod.fileLoader.GetSupportedExtensions(),
+		"save": mod.fileSaver.GetSupportedSaveExtensions(),
+	})
+}
+
+func (mod *RestAPIModule) handleListLessons(w http.ResponseWriter, r *http.Request) {
+	entries, err := os.ReadDir(mod.lessonDir)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	lessons := []LessonInfo{}
+	for _, entry := range entries {
+		ext, ok := mod.lessonExtension(entry.Name())
+		if entry.IsDir() || !ok {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		lessons = append(lessons, LessonInfo{
+			Name:     entry.Name(),
+			Format:   mod.fileLoader.GetFormatName(ext),
+			Size:     info.Size(),
+			Modified: info.ModTime(),
+		})
+	}
+	sort.Slice(lessons, func(i, j int) bool { return lessons[i].Name < lessons[j].Name })
+
+	writeJSON(w, http.StatusOK, lessons)
+}
+
+func (mod *RestAPIModule) handleGetLesson(w http.ResponseWriter, r *http.Request) {
+	lessonData, ok := mod.loadLesson(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, lessonData)
+}
+
+func (mod *RestAPIModule) handleGetThumbnail(w http.ResponseWriter, r *http.Request) {
+	lessonData, ok := mod.loadLesson(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	if err := mod.thumbnails.WritePNG(w, lessonData); err != nil {
+		log.Printf("Failed to write thumbnail of %s: %v", r.PathValue("name"), err)
+	}
+}
+
+// loadLesson loads the lesson named in the request URL, writing the error
+// response and returning false when that fails
+func (mod *RestAPIModule) loadLesson(w http.ResponseWriter, r *http.Request) (*lesson.LessonData, bool) {
+	path, err := mod.lessonPath(r.PathValue("name"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return nil, false
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("lesson %q not found", r.PathValue("name")))
+		return nil, false
+	}
+
+	lessonData, err := mod.fileLoader.LoadFile(path)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return nil, false
+	}
+	return lessonData, true
+}
+
+func (mod *RestAPIModule) handlePutLesson(w http.ResponseWriter, r *http.Request) {
+	path, err := mod.lessonPath(r.PathValue("name"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	lessonData := lesson.NewLessonData()
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxImportSize)).Decode(lessonData); err != nil {
+		writeError(w, bodyErrorStatus(err), fmt.Errorf("invalid lesson JSON: %w", err))
+		return
+	}
+	if err := mod.fileSaver.SaveFile(lessonData, path); err != nil {
+		writeError(w, saveErrorStatus(err), err)
+		return
+	}
+	mod.audit(r, audit.LessonUploaded, r.PathValue("name"), fmt.Sprintf("%d items", len(lessonData.List.Items)))
+	writeJSON(w, http.StatusOK, map[string]int{"items": len(lessonData.List.Items)})
+}
+
+// lessonPath maps a lesson name from a URL to a file in the lesson
+// directory, refusing anything that could point outside it
+func (mod *RestAPIModule) lessonPath(name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid lesson name %q", name)
+	}
+	if _, ok := mod.lessonExtension(name); !ok {
+		return "", fmt.Errorf("unsupported lesson format %q", filepath.Ext(name))
+	}
+	return filepath.Join(mod.lessonDir, name), nil
+}
+
+// lessonExtension returns the supported extension a file name ends in
+func (mod *RestAPIModule) lessonExtension(name string) (string, bool) {
+	lower := strings.ToLower(name)
+	best := ""
+	for _, ext := range mod.fileLoader.GetSupportedExtensions() {
+		// Prefer the longest match so .pau.gz wins over .gz
+		if strings.HasSuffix(lower, ext) && len(ext) > len(best) {
+			best = ext
+		}
+	}
+	return best, best != ""
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Printf("[ERROR] RestAPIModule - failed to write response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// bodyErrorStatus returns the status of an unreadable request body: 413
+// when it is larger than the handler accepts
+func bodyErrorStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// saveErrorStatus returns the status of a failed save: 423 Locked when a
+// teacher has the lesson open in Recuerdo, so clients can retry later
+func saveErrorStatus(err error) int {
+	var locked *lesson.ErrLocked
+	if errors.As(err, &locked) {
+		return http.StatusLocked
+	}
+	return http.StatusUnprocessableEntity
+}
+
+// SetManager sets the module manager
+func (mod *RestAPIModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// InitRestAPIModule creates and returns a new RestAPIModule instance
+func InitRestAPIModule() core.Module {
+	return NewRestAPIModule()
+}
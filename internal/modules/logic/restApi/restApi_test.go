@@ -0,0 +1,164 @@
+package restapi
+
+import (
+	"encoding/json"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/LaPingvino/recuerdo/internal/paths"
+)
+
+func TestMain(m *testing.M) {
+	// The audit log keeps its key in the data directory
+	dir, err := os.MkdirTemp("", "recuerdo-data")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv(paths.EnvDataDir, dir)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestRestAPIServesLessons(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "animals.csv"), []byte("cat,gato\ndog,perro\n"), 0644); err != nil {
+		t.Fatalf("Failed to write lesson: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.exe"), []byte("x"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	mod := NewRestAPIModule()
+	mod.SetLessonDir(dir)
+	server := httptest.NewServer(mod.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/lessons")
+	if err != nil {
+		t.Fatalf("List request failed: %v", err)
+	}
+	var lessons []LessonInfo
+	if err := json.NewDecoder(resp.Body).Decode(&lessons); err != nil {
+		t.Fatalf("Invalid list response: %v", err)
+	}
+	resp.Body.Close()
+	if len(lessons) != 1 || lessons[0].Name != "animals.csv" {
+		t.Errorf("Expected only animals.csv, got %+v", lessons)
+	}
+
+	resp, err = http.Get(server.URL + "/api/lessons/animals.csv")
+	if err != nil {
+		t.Fatalf("Get request failed: %v", err)
+	}
+	var lessonData lesson.LessonData
+	if err := json.NewDecoder(resp.Body).Decode(&lessonData); err != nil {
+		t.Fatalf("Invalid lesson response: %v", err)
+	}
+	resp.Body.Close()
+	if len(lessonData.List.Items) != 2 {
+		t.Errorf("Expected 2 items, got %d", len(lessonData.List.Items))
+	}
+
+	resp, err = http.Get(server.URL + "/api/lessons/animals.csv/thumbnail.png")
+	if err != nil {
+		t.Fatalf("Thumbnail request failed: %v", err)
+	}
+	if _, err := png.Decode(resp.Body); err != nil || resp.Header.Get("Content-Type") != "image/png" {
+		t.Errorf("Expected a PNG thumbnail, got %s: %v", resp.Header.Get("Content-Type"), err)
+	}
+	resp.Body.Close()
+
+	body := `{"list":{"title":"Colours","items":[{"id":0,"questions":["red"],"answers":["rojo"]}]}}`
+	req, _ := http.NewRequest(http.MethodPut, server.URL+"/api/lessons/colours.json", strings.NewReader(body))
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Put request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200 for put, got %d", resp.StatusCode)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "colours.json")); err != nil {
+		t.Errorf("Lesson not saved: %v", err)
+	}
+
+	for path, status := range map[string]int{
+		"/api/lessons/missing.csv":               http.StatusNotFound,
+		"/api/lessons/missing.csv/thumbnail.png": http.StatusNotFound,
+		"/api/lessons/notes.exe":                 http.StatusBadRequest,
+		"/api/lessons/..%2Fx.csv":                http.StatusBadRequest,
+	} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("Request %s failed: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Errorf("GET %s = %d; want %d", path, resp.StatusCode, status)
+		}
+	}
+}
+
+func TestRestAPILockedLesson(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "colours.json")
+	// A teacher has the lesson open in Recuerdo on another computer
+	holder, _ := json.Marshal(lesson.LockInfo{User: "alice", Host: "classroom-pc", PID: 1})
+	if err := os.WriteFile(lesson.LockPath(path), holder, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mod := NewRestAPIModule()
+	mod.SetLessonDir(dir)
+	server := httptest.NewServer(mod.Handler())
+	defer server.Close()
+
+	body := `{"list":{"title":"Colours","items":[{"id":0,"questions":["red"],"answers":["rojo"]}]}}`
+	req, _ := http.NewRequest(http.MethodPut, server.URL+"/api/lessons/colours.json", strings.NewReader(body))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Put request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusLocked {
+		t.Errorf("Expected 423 for a locked lesson, got %d", resp.StatusCode)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("A locked lesson was written: %v", err)
+	}
+}
+
+func TestRestAPIRefusesOversizedLessons(t *testing.T) {
+	dir := t.TempDir()
+	mod := NewRestAPIModule()
+	mod.SetLessonDir(dir)
+	server := httptest.NewServer(mod.Handler())
+	defer server.Close()
+
+	body := `{"list":{"title":"` + strings.Repeat("a", maxImportSize) + `"}}`
+	for _, url := range []string{"/api/lessons/colours.json", "/api/sync/colours.json"} {
+		method := http.MethodPut
+		if strings.HasPrefix(url, "/api/sync/") {
+			method = http.MethodPost
+		}
+		req, _ := http.NewRequest(method, server.URL+url, strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", method, url, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusRequestEntityTooLarge {
+			t.Errorf("Expected 413 for an oversized %s %s, got %d", method, url, resp.StatusCode)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "colours.json")); !os.IsNotExist(err) {
+		t.Errorf("An oversized lesson was written: %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package restapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/audit"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/LaPingvino/recuerdo/internal/reviewlog"
+	"github.com/LaPingvino/recuerdo/internal/studyday"
+)
+
+// DueItem is an item due for review, as sent to frontends practising a
+// lesson through the API
+type DueItem struct {
+	Item lesson.WordItem `json:"item"`
+	// Due is the day of study the item was due, empty for new items
+	Due    string `json:"due,omitempty"`
+	Streak int    `json:"streak"`
+	New    bool   `json:"new"`
+}
+
+// DueResponse lists the items of a lesson due today
+type DueResponse struct {
+	// Today is the current day of study as 2006-01-02
+	Today string    `json:"today"`
+	Items []DueItem `json:"items"`
+}
+
+// ReviewResult is one answer given in a frontend
+type ReviewResult struct {
+	ItemID int  `json:"itemId"`
+	Right  bool `json:"right"`
+	// Credit is the partial credit of a wrong answer that was partly right
+	Credit   float64 `json:"credit,omitempty"`
+	Reversed bool    `json:"reversed,omitempty"`
+	// AnswerTime is how many seconds the answer took
+	AnswerTime float64 `json:"answerTime,omitempty"`
+	// Time is when the answer was given; the time of the request when
+	// empty
+	Time *time.Time `json:"time,omitempty"`
+}
+
+// ReviewSubmission is a practice session given in a frontend, recorded in
+// the lesson as a test of its own
+type ReviewSubmission struct {
+	Results []ReviewResult `json:"results"`
+}
+
+// handleGetDue lists the items of a lesson due today, overdue and new
+// items included, as the schedule of the review log works them out from
+// the results kept in the lesson. The limit parameter bounds how many.
+func (mod *RestAPIModule) handleGetDue(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit %q", value))
+			return
+		}
+	}
+
+	mod.syncMutex.Lock()
+	lessonData, ok := mod.loadLesson(w, r)
+	mod.syncMutex.Unlock()
+	if !ok {
+		return
+	}
+
+	items := itemsByID(lessonData)
+	now := time.Now()
+	response := DueResponse{Today: studyday.Of(now, studyday.DefaultRollover), Items: []DueItem{}}
+	for _, due := range reviewlog.DueToday(&lessonData.List, now, studyday.DefaultRollover) {
+		if limit > 0 && len(response.Items) == limit {
+			break
+		}
+		dueItem := DueItem{Item: items[due.ItemID], Streak: due.Streak, New: due.New()}
+		if !due.New() {
+			dueItem.Due = due.Day
+		}
+		response.Items = append(response.Items, dueItem)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// handlePostReviews records the answers given in a frontend in the lesson
+func (mod *RestAPIModule) handlePostReviews(w http.ResponseWriter, r *http.Request) {
+	var submission ReviewSubmission
+	if err := json.NewDecoder(r.Body).Decode(&submission); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid review JSON: %w", err))
+		return
+	}
+	if len(submission.Results) == 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("no results to record"))
+		return
+	}
+
+	mod.syncMutex.Lock()
+	defer mod.syncMutex.Unlock()
+
+	lessonData, ok := mod.loadLesson(w, r)
+	if !ok {
+		return
+	}
+	items := itemsByID(lessonData)
+	now := time.Now()
+	test := lesson.Test{Date: &now}
+	for _, result := range submission.Results {
+		if _, ok := items[result.ItemID]; !ok {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("lesson has no item %d", result.ItemID))
+			return
+		}
+		answered := now
+		if result.Time != nil {
+			answered = *result.Time
+		}
+		testResult := lesson.TestResult{
+			Result:     "wrong",
+			ItemID:     result.ItemID,
+			Time:       &answered,
+			Credit:     result.Credit,
+			Reversed:   result.Reversed,
+			AnswerTime: result.AnswerTime,
+		}
+		if result.Right {
+			testResult.Result = "right"
+			testResult.Credit = 0
+		}
+		test.Results = append(test.Results, testResult)
+	}
+	lessonData.List.Tests = append(lessonData.List.Tests, test)
+
+	path, _ := mod.lessonPath(r.PathValue("name"))
+	if err := mod.fileSaver.SaveFile(lessonData, path); err != nil {
+		writeError(w, saveErrorStatus(err), err)
+		return
+	}
+	// Not every format keeps test results
+	if saved, err := mod.fileLoader.LoadFile(path); err == nil && len(saved.List.Tests) < len(lessonData.List.Tests) {
+		writeError(w, http.StatusUnprocessableEntity, fmt.Errorf("%s files do not keep review results", filepath.Ext(path)))
+		return
+	}
+	mod.audit(r, audit.ReviewsRecorded, r.PathValue("name"), fmt.Sprintf("%d answers", len(test.Results)))
+	writeJSON(w, http.StatusOK, map[string]int{"recorded": len(test.Results)})
+}
+
+// itemsByID returns the items of a lesson by their IDs
+func itemsByID(lessonData *lesson.LessonData) map[int]lesson.WordItem {
+	items := make(map[int]lesson.WordItem, len(lessonData.List.Items))
+	for _, item := range lessonData.List.Items {
+		items[item.ID] = item
+	}
+	return items
+}
@@ -0,0 +1,114 @@
+package restapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+func TestDueAndReviews(t *testing.T) {
+	dir := t.TempDir()
+	body := `{"list":{"title":"Colours","items":[{"id":0,"questions":["red"],"answers":["rojo"]},{"id":1,"questions":["blue"],"answers":["azul"]}]}}`
+	if err := os.WriteFile(filepath.Join(dir, "colours.json"), []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "animals.csv"), []byte("cat,gato\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mod := NewRestAPIModule()
+	mod.SetLessonDir(dir)
+	server := httptest.NewServer(mod.Handler())
+	defer server.Close()
+
+	due := func(query string) DueResponse {
+		t.Helper()
+		resp, err := http.Get(server.URL + "/api/lessons/colours.json/due" + query)
+		if err != nil {
+			t.Fatalf("Due request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		var response DueResponse
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatalf("Invalid due response: %v", err)
+		}
+		return response
+	}
+	if response := due(""); len(response.Items) != 2 || !response.Items[0].New || response.Items[0].Item.Questions[0] != "red" {
+		t.Fatalf("Expected both new items due, got %+v", response)
+	}
+	if response := due("?limit=1"); len(response.Items) != 1 {
+		t.Errorf("Expected the limit to hold, got %+v", response)
+	}
+
+	post := func(name, body string) int {
+		t.Helper()
+		resp, err := http.Post(server.URL+"/api/lessons/"+name+"/reviews", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("Review request failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if status := post("colours.json", `{"results":[{"itemId":0,"right":true},{"itemId":1,"right":false,"answerTime":2.5}]}`); status != http.StatusOK {
+		t.Fatalf("Expected 200 for reviews, got %d", status)
+	}
+	saved, err := lesson.NewFileLoader().LoadFile(filepath.Join(dir, "colours.json"))
+	if err != nil || len(saved.List.Tests) != 1 || saved.List.GetRightAnswersCount(0) != 1 || saved.List.GetWrongAnswersCount(1) != 1 {
+		t.Fatalf("Expected the reviews in the lesson, got %+v, %v", saved.List.Tests, err)
+	}
+	// Item 0 is due tomorrow now; item 1, answered wrong, tomorrow too
+	if response := due(""); len(response.Items) != 0 {
+		t.Errorf("Expected nothing due right after reviewing, got %+v", response)
+	}
+
+	for name, body := range map[string]string{
+		"colours.json": `{"results":[{"itemId":7,"right":true}]}`,
+		"missing.json": `{"results":[{"itemId":0,"right":true}]}`,
+		"animals.csv":  `{"results":[{"itemId":0,"right":true}]}`,
+	} {
+		if status := post(name, body); status == http.StatusOK {
+			t.Errorf("Expected %s to be refused", name)
+		}
+	}
+}
+
+func TestTokenAuthentication(t *testing.T) {
+	mod := NewRestAPIModule()
+	mod.SetLessonDir(t.TempDir())
+	mod.SetTokens([]string{" secret ", ""})
+	server := httptest.NewServer(mod.Handler())
+	defer server.Close()
+
+	get := func(path, token string) int {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request %s failed: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if status := get("/api/lessons", ""); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a token, got %d", status)
+	}
+	if status := get("/api/lessons", "wrong"); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a wrong token, got %d", status)
+	}
+	if status := get("/api/lessons", "secret"); status != http.StatusOK {
+		t.Errorf("Expected 200 with the token, got %d", status)
+	}
+	if status := get("/healthz", ""); status != http.StatusOK {
+		t.Errorf("Expected the health check to stay open, got %d", status)
+	}
+}
@@ -0,0 +1,326 @@
+package restapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/LaPingvino/recuerdo/internal/audit"
+	"github.com/LaPingvino/recuerdo/internal/classroom"
+)
+
+// rosterFile is where the class roster is kept in the lesson directory. Its
+// directory starts with a dot, so it is never listed or served as a lesson.
+const rosterFile = ".classroom/roster.json"
+
+// maxImportSize bounds the size of an imported roster CSV and of a lesson
+// uploaded or synced as JSON
+const maxImportSize = 1 << 20
+
+func (mod *RestAPIModule) handleListStudents(w http.ResponseWriter, r *http.Request) {
+	roster, ok := mod.teacherRoster(w, r)
+	if !ok {
+		return
+	}
+	students := roster.Students()
+	if students == nil {
+		students = []classroom.Student{}
+	}
+	writeJSON(w, http.StatusOK, students)
+}
+
+func (mod *RestAPIModule) handleAddStudent(w http.ResponseWriter, r *http.Request) {
+	roster, ok := mod.teacherRoster(w, r)
+	if !ok {
+		return
+	}
+	var student classroom.Student
+	if err := json.NewDecoder(r.Body).Decode(&student); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid student JSON: %w", err))
+		return
+	}
+	added, err := roster.Add(student.Name, student.Group)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	mod.audit(r, audit.StudentAdded, studentSubject(added), "group "+added.Group)
+	writeJSON(w, http.StatusCreated, added)
+}
+
+// handleImportStudents adds the students in a CSV body; see
+// classroom.Roster.ImportCSV
+func (mod *RestAPIModule) handleImportStudents(w http.ResponseWriter, r *http.Request) {
+	roster, ok := mod.teacherRoster(w, r)
+	if !ok {
+		return
+	}
+	added, err := roster.ImportCSV(http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if added == nil {
+		added = []classroom.Student{}
+	}
+	mod.audit(r, audit.StudentsImported, "roster", fmt.Sprintf("%d students", len(added)))
+	writeJSON(w, http.StatusOK, added)
+}
+
+func (mod *RestAPIModule) handleGetStudent(w http.ResponseWriter, r *http.Request) {
+	roster, id, ok := mod.rosterStudent(w, r)
+	if !ok {
+		return
+	}
+	student, err := roster.Student(id)
+	if err != nil {
+		writeRosterError(w, err)
+		return
+	}
+	results, err := roster.History(id)
+	if err != nil {
+		writeRosterError(w, err)
+		return
+	}
+	if results == nil {
+		results = []classroom.Result{}
+	}
+	writeJSON(w, http.StatusOK, classroom.StudentRecord{Student: student, Results: results})
+}
+
+func (mod *RestAPIModule) handleUpdateStudent(w http.ResponseWriter, r *http.Request) {
+	roster, id, ok := mod.rosterStudent(w, r)
+	if !ok {
+		return
+	}
+	var student classroom.Student
+	if err := json.NewDecoder(r.Body).Decode(&student); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid student JSON: %w", err))
+		return
+	}
+	student.ID = id
+	updated, err := roster.Update(student)
+	if err != nil {
+		writeRosterError(w, err)
+		return
+	}
+	mod.audit(r, audit.StudentUpdated, studentSubject(updated), "group "+updated.Group)
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// handleRemoveStudent takes a student off the roster with their results
+// and erases the questions they posted, leaving nothing stored about them
+func (mod *RestAPIModule) handleRemoveStudent(w http.ResponseWriter, r *http.Request) {
+	roster, id, ok := mod.rosterStudent(w, r)
+	if !ok {
+		return
+	}
+	student, err := roster.Student(id)
+	if err != nil {
+		writeRosterError(w, err)
+		return
+	}
+	if err := roster.Remove(id); err != nil {
+		writeRosterError(w, err)
+		return
+	}
+	mod.audit(r, audit.StudentRemoved, studentSubject(student), "")
+	threads, ok := mod.openThreads(w)
+	if !ok {
+		return
+	}
+	if _, err := threads.Erase(id); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (mod *RestAPIModule) handleNewJoinCode(w http.ResponseWriter, r *http.Request) {
+	roster, id, ok := mod.rosterStudent(w, r)
+	if !ok {
+		return
+	}
+	student, err := roster.NewJoinCode(id)
+	if err != nil {
+		writeRosterError(w, err)
+		return
+	}
+	mod.audit(r, audit.JoinCodeRenewed, studentSubject(student), "")
+	writeJSON(w, http.StatusOK, student)
+}
+
+// handleRecordResult records a result of a student, handed in by the
+// teacher or by the student with their join code
+func (mod *RestAPIModule) handleRecordResult(w http.ResponseWriter, r *http.Request) {
+	id, ok := studentID(w, r)
+	if !ok {
+		return
+	}
+	roster, ok := mod.openRoster(w)
+	if !ok {
+		return
+	}
+	var submission classroom.ResultSubmission
+	if err := json.NewDecoder(r.Body).Decode(&submission); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid result JSON: %w", err))
+		return
+	}
+	if !mod.isTeacher(r) && mod.hasTokens() {
+		if student, err := roster.Join(submission.Code); err != nil || student.ID != id {
+			writeError(w, http.StatusForbidden, errors.New("a result needs the join code of its student"))
+			return
+		}
+	}
+	result := submission.Result
+	if err := roster.RecordResult(id, result); err != nil {
+		writeRosterError(w, err)
+		return
+	}
+	student, _ := roster.Student(id)
+	mod.audit(r, audit.ResultRecorded, studentSubject(student), resultDetails(result))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleListAssignments lists the lessons assigned to the group in the
+// query
+func (mod *RestAPIModule) handleListAssignments(w http.ResponseWriter, r *http.Request) {
+	roster, ok := mod.openRoster(w)
+	if !ok {
+		return
+	}
+	lessons := roster.Assignments(r.URL.Query().Get("group"))
+	if lessons == nil {
+		lessons = []string{}
+	}
+	writeJSON(w, http.StatusOK, lessons)
+}
+
+func (mod *RestAPIModule) handleAssign(w http.ResponseWriter, r *http.Request) {
+	roster, ok := mod.teacherRoster(w, r)
+	if !ok {
+		return
+	}
+	var assignment classroom.Assignment
+	if err := json.NewDecoder(r.Body).Decode(&assignment); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid assignment JSON: %w", err))
+		return
+	}
+	if err := roster.Assign(assignment.Group, assignment.Lesson); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	mod.audit(r, audit.LessonAssigned, assignment.Lesson, "group "+assignment.Group)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleUnassign takes the lesson in the query off the assignments of the
+// group in the query
+func (mod *RestAPIModule) handleUnassign(w http.ResponseWriter, r *http.Request) {
+	roster, ok := mod.teacherRoster(w, r)
+	if !ok {
+		return
+	}
+	if err := roster.Unassign(r.URL.Query().Get("group"), r.URL.Query().Get("lesson")); err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	mod.audit(r, audit.LessonUnassigned, r.URL.Query().Get("lesson"), "group "+r.URL.Query().Get("group"))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGradebook sends the gradebook of the group in the query; the
+// teacher's side turns its results into notes
+func (mod *RestAPIModule) handleGradebook(w http.ResponseWriter, r *http.Request) {
+	roster, ok := mod.teacherRoster(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, roster.Gradebook(r.URL.Query().Get("group")))
+}
+
+// handleJoin looks up the student a join code belongs to, so a student can
+// hand in results under their own name
+func (mod *RestAPIModule) handleJoin(w http.ResponseWriter, r *http.Request) {
+	roster, ok := mod.openRoster(w)
+	if !ok {
+		return
+	}
+	var request classroom.JoinRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid join request: %w", err))
+		return
+	}
+	student, err := roster.Join(request.Code)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, student)
+}
+
+// openRoster returns the class roster, opening it on first use. On failure
+// it writes the error and returns false.
+func (mod *RestAPIModule) openRoster(w http.ResponseWriter) (*classroom.Roster, bool) {
+	mod.rosterMutex.Lock()
+	defer mod.rosterMutex.Unlock()
+
+	if mod.roster == nil {
+		roster, err := classroom.Open(filepath.Join(mod.lessonDir, filepath.FromSlash(rosterFile)))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return nil, false
+		}
+		mod.roster = roster
+	}
+	return mod.roster, true
+}
+
+// teacherRoster returns the class roster like openRoster, when r carries a
+// teacher or admin token, or the server takes no tokens at all. On failure
+// it writes the error and returns false.
+func (mod *RestAPIModule) teacherRoster(w http.ResponseWriter, r *http.Request) (*classroom.Roster, bool) {
+	if mod.hasTokens() && !mod.isTeacher(r) {
+		writeError(w, http.StatusForbidden, errors.New("the class roster needs a teacher token; see recuerdo serve -teacher-tokens"))
+		return nil, false
+	}
+	return mod.openRoster(w)
+}
+
+// rosterStudent returns the roster and the student id from the URL, for
+// the teacher
+func (mod *RestAPIModule) rosterStudent(w http.ResponseWriter, r *http.Request) (*classroom.Roster, int, bool) {
+	id, ok := studentID(w, r)
+	if !ok {
+		return nil, 0, false
+	}
+	roster, ok := mod.teacherRoster(w, r)
+	return roster, id, ok
+}
+
+// studentID returns the student id from the URL. On failure it writes the
+// error and returns false.
+func studentID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid student id %q", r.PathValue("id")))
+		return 0, false
+	}
+	return id, true
+}
+
+// resultDetails describes a result in the audit log
+func resultDetails(result classroom.Result) string {
+	return fmt.Sprintf("%s: %d right, %d wrong", result.Lesson, result.Right, result.Wrong)
+}
+
+func writeRosterError(w http.ResponseWriter, err error) {
+	if errors.Is(err, classroom.ErrNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeError(w, http.StatusBadRequest, err)
+}
@@ -0,0 +1,183 @@
+package restapi
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LaPingvino/recuerdo/internal/classroom"
+)
+
+func TestRestAPIRoster(t *testing.T) {
+	dir := t.TempDir()
+	mod := NewRestAPIModule()
+	mod.SetLessonDir(dir)
+	server := httptest.NewServer(mod.Handler())
+	defer server.Close()
+	client := classroom.NewClient(server.URL)
+
+	added, err := client.ImportCSV([]byte("name,group\nAnna,3B\nBram,3A\n"))
+	if err != nil || len(added) != 2 {
+		t.Fatalf("Expected 2 imported students, got %+v, %v", added, err)
+	}
+	cas, err := client.Add("Cas", "3A")
+	if err != nil {
+		t.Fatalf("Failed to add student: %v", err)
+	}
+
+	joined, err := client.Join(cas.JoinCode)
+	if err != nil || joined.ID != cas.ID {
+		t.Fatalf("Expected join code to find Cas, got %+v, %v", joined, err)
+	}
+	if _, err := client.Join("NOPE00"); err == nil {
+		t.Error("Expected an unknown join code to be refused")
+	}
+	if err := client.RecordResult(joined.ID, classroom.Result{Lesson: "animals.csv", Right: 4, Wrong: 1}); err != nil {
+		t.Fatalf("Failed to record result: %v", err)
+	}
+
+	record, err := client.Student(cas.ID)
+	if err != nil {
+		t.Fatalf("Failed to get student: %v", err)
+	}
+	if record.Name != "Cas" || len(record.Results) != 1 || record.Results[0].Percentage() != 80 {
+		t.Errorf("Unexpected student record: %+v", record)
+	}
+
+	if err := client.Assign("3A", "animals.csv"); err != nil {
+		t.Fatalf("Failed to assign lesson: %v", err)
+	}
+	client.Assign("3A", "colours.csv")
+	if err := client.Unassign("3A", "colours.csv"); err != nil {
+		t.Fatalf("Failed to unassign lesson: %v", err)
+	}
+	if err := client.Unassign("3A", "colours.csv"); err == nil {
+		t.Error("Expected unassigning a lesson twice to fail")
+	}
+	if lessons, err := client.Assignments("3A"); err != nil || len(lessons) != 1 || lessons[0] != "animals.csv" {
+		t.Errorf("Expected animals.csv assigned to 3A, got %v, %v", lessons, err)
+	}
+	gradebook, err := client.Gradebook("3A")
+	if err != nil {
+		t.Fatalf("Failed to get gradebook: %v", err)
+	}
+	if len(gradebook.Students) != 2 || gradebook.Students[1].Student.Name != "Cas" ||
+		gradebook.Students[1].Results["animals.csv"].Percentage() != 80 {
+		t.Errorf("Unexpected gradebook: %+v", gradebook)
+	}
+
+	if _, err := client.Post("animals.csv", 1, classroom.ThreadPost{Message: classroom.Message{Text: "Why?"}, Code: added[0].JoinCode}); err != nil {
+		t.Fatalf("Failed to post question: %v", err)
+	}
+	export, err := client.Export(added[0].ID)
+	if err != nil {
+		t.Fatalf("Failed to export student: %v", err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(export), int64(len(export)))
+	if err != nil || len(archive.File) != 2 || archive.File[0].Name != "student.json" || archive.File[1].Name != "questions.json" {
+		t.Fatalf("Expected student.json and questions.json in the export, got %v", err)
+	}
+
+	if err := client.Remove(added[0].ID); err != nil {
+		t.Fatalf("Failed to remove student: %v", err)
+	}
+	if threads, _ := client.Threads(); len(threads) != 0 {
+		t.Errorf("Expected the questions of a removed student to be erased, got %+v", threads)
+	}
+	if _, err := client.Student(added[0].ID); err == nil {
+		t.Error("Expected removed student to be gone")
+	}
+	students, err := client.Students()
+	if err != nil || len(students) != 2 {
+		t.Errorf("Expected 2 students, got %+v, %v", students, err)
+	}
+
+	// The roster is stored out of sight of the lesson listing
+	if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(rosterFile))); err != nil {
+		t.Errorf("Expected roster file: %v", err)
+	}
+	lessons, _ := os.ReadDir(dir)
+	if len(lessons) != 1 || lessons[0].Name() != ".classroom" {
+		t.Errorf("Expected only the classroom directory, got %v", lessons)
+	}
+}
+
+func TestRestAPIClassroomDisabled(t *testing.T) {
+	mod := NewRestAPIModule()
+	mod.SetLessonDir(t.TempDir())
+	mod.SetClassroomEnabled(false)
+	server := httptest.NewServer(mod.Handler())
+	defer server.Close()
+
+	if _, err := classroom.NewClient(server.URL).Add("Anna", "3B"); err == nil {
+		t.Error("Expected the roster to be unavailable with the classroom turned off")
+	}
+}
+
+func TestRosterNeedsTeacher(t *testing.T) {
+	mod := NewRestAPIModule()
+	mod.SetLessonDir(t.TempDir())
+	mod.SetTokens([]string{"class-token"})
+	mod.SetTeacherTokens([]string{"teacher-token"})
+	server := httptest.NewServer(mod.Handler())
+	defer server.Close()
+	teacher := classroom.NewClient(server.URL)
+	teacher.SetToken("teacher-token")
+	student := classroom.NewClient(server.URL)
+	student.SetToken("class-token")
+
+	anna, err := teacher.Add("Anna", "3A")
+	if err != nil {
+		t.Fatalf("Failed to add student: %v", err)
+	}
+	bram, _ := teacher.Add("Bram", "3A")
+
+	if _, err := student.Students(); err == nil {
+		t.Error("Expected a student token not to list the roster")
+	}
+	if _, err := student.Student(anna.ID); err == nil {
+		t.Error("Expected a student token not to read a student")
+	}
+	if _, err := student.Add("Eve", "3A"); err == nil {
+		t.Error("Expected a student token not to change the roster")
+	}
+	if _, err := student.NewJoinCode(anna.ID); err == nil {
+		t.Error("Expected a student token not to renew join codes")
+	}
+	if _, err := student.Gradebook("3A"); err == nil {
+		t.Error("Expected a student token not to read the gradebook")
+	}
+
+	result := classroom.Result{Lesson: "animals.csv", Right: 4, Wrong: 1}
+	if err := student.RecordResult(anna.ID, result); err == nil {
+		t.Error("Expected a result without a join code to be refused")
+	}
+	if err := student.HandIn(bram.JoinCode, result); err != nil {
+		t.Fatalf("Failed to hand in a result with a join code: %v", err)
+	}
+	body, _ := json.Marshal(classroom.ResultSubmission{Result: result, Code: bram.JoinCode})
+	request, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/api/roster/%d/results", server.URL, anna.ID), bytes.NewReader(body))
+	request.Header.Set("Authorization", "Bearer class-token")
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403 for a result with another student's join code, got %d", response.StatusCode)
+	}
+
+	record, err := teacher.Student(bram.ID)
+	if err != nil || len(record.Results) != 1 {
+		t.Fatalf("Expected Bram's handed in result, got %+v, %v", record, err)
+	}
+	if record, _ := teacher.Student(anna.ID); len(record.Results) != 0 {
+		t.Errorf("Expected no results for Anna, got %+v", record.Results)
+	}
+}
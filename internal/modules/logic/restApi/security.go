@@ -0,0 +1,309 @@
+package restapi
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// maxAuthFailures is how many requests with a wrong token an address
+	// may send within authLockout before it is locked out for the rest of
+	// it, so tokens cannot be guessed
+	maxAuthFailures = 10
+	authLockout     = 15 * time.Minute
+	// pruneLimiterAt is how many addresses the rate limiter tracks before
+	// it forgets the idle ones
+	pruneLimiterAt = 1024
+)
+
+// SetTLSFiles makes the server speak HTTPS with the certificate and key in
+// the given PEM files; empty serves plain HTTP. It takes effect on the
+// next Enable.
+func (mod *RestAPIModule) SetTLSFiles(certFile, keyFile string) {
+	mod.certFile = strings.TrimSpace(certFile)
+	mod.keyFile = strings.TrimSpace(keyFile)
+}
+
+// SetACME makes the server speak HTTPS with certificates for domains it
+// gets from Let's Encrypt and renews itself, keeping them in cacheDir.
+// Certificates are asked for over TLS-ALPN, so the server has to be
+// reachable on port 443 of the domains. Email, which may be empty, is
+// given to Let's Encrypt for notices about them. It takes effect on the
+// next Enable.
+func (mod *RestAPIModule) SetACME(domains []string, cacheDir, email string) {
+	mod.acmeDomains = nil
+	for _, domain := range domains {
+		if domain = strings.TrimSpace(domain); domain != "" {
+			mod.acmeDomains = append(mod.acmeDomains, domain)
+		}
+	}
+	mod.acmeCache = cacheDir
+	mod.acmeEmail = strings.TrimSpace(email)
+}
+
+// SetRateLimit sets how many requests a minute one address may send, with
+// bursts of as many; 0 does not limit them. It takes effect on the next
+// call of Handler.
+func (mod *RestAPIModule) SetRateLimit(perMinute int) {
+	mod.rateLimit = max(perMinute, 0)
+}
+
+// SetCORSOrigins sets the origins of the web pages that may call the API
+// from a browser, such as "https://app.example", or "*" for any; none
+// allows no page from elsewhere. It takes effect on the next call of
+// Handler.
+func (mod *RestAPIModule) SetCORSOrigins(origins []string) {
+	mod.corsOrigins = nil
+	for _, origin := range origins {
+		if origin = strings.TrimSuffix(strings.TrimSpace(origin), "/"); origin != "" {
+			mod.corsOrigins = append(mod.corsOrigins, origin)
+		}
+	}
+}
+
+// tlsConfig returns the TLS configuration of the server, nil to serve
+// plain HTTP
+func (mod *RestAPIModule) tlsConfig() (*tls.Config, error) {
+	switch {
+	case len(mod.acmeDomains) > 0 && mod.certFile != "":
+		return nil, errors.New("use either a certificate file or ACME, not both")
+	case len(mod.acmeDomains) > 0:
+		if mod.acmeCache == "" {
+			return nil, errors.New("ACME needs a directory to keep certificates in")
+		}
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(mod.acmeDomains...),
+			Cache:      autocert.DirCache(mod.acmeCache),
+			Email:      mod.acmeEmail,
+		}
+		config := manager.TLSConfig()
+		config.MinVersion = tls.VersionTLS12
+		return config, nil
+	case mod.certFile != "" || mod.keyFile != "":
+		cert, err := tls.LoadX509KeyPair(mod.certFile, mod.keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		return &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}, nil
+	}
+	return nil, nil
+}
+
+// warnIfExposed logs what leaves the server open when it listens beyond
+// this machine
+func (mod *RestAPIModule) warnIfExposed(addr net.Addr, secure bool) {
+	if tcp, ok := addr.(*net.TCPAddr); ok && tcp.IP.IsLoopback() {
+		return
+	}
+	switch {
+	case len(mod.tokens) == 0:
+		log.Printf("[WARNING] RestAPIModule - listening on %s without access tokens: anyone who can reach it can read and change the lessons", addr)
+	case !secure:
+		log.Printf("[WARNING] RestAPIModule - listening on %s without TLS: access tokens are sent in the clear", addr)
+	}
+}
+
+// secureHeaders sets the headers that keep browsers from misreading
+// responses, and from falling back to plain HTTP once they have talked
+// HTTPS
+func (mod *RestAPIModule) secureHeaders(next http.Handler) http.Handler {
+	secure := mod.certFile != "" || len(mod.acmeDomains) > 0
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		if secure {
+			w.Header().Set("Strict-Transport-Security", "max-age=31536000")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// cors lets the pages of the allowed origins call the API from a browser,
+// answering their preflight requests before they are authenticated, as
+// browsers send those without the token
+func (mod *RestAPIModule) cors(next http.Handler) http.Handler {
+	if len(mod.corsOrigins) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		allowed := origin != "" && mod.allowsOrigin(origin)
+		if allowed {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Expose-Headers", "ETag")
+		}
+		w.Header().Add("Vary", "Origin")
+		if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !allowed {
+			writeError(w, http.StatusForbidden, fmt.Errorf("origin %q may not call the API", origin))
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE")
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, If-Match, If-None-Match")
+		w.Header().Set("Access-Control-Max-Age", "600")
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// allowsOrigin reports whether pages of origin may call the API
+func (mod *RestAPIModule) allowsOrigin(origin string) bool {
+	for _, allowed := range mod.corsOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// webSocket serves handler over a WebSocket to clients of this server and
+// pages of the allowed origins. Browsers let any page open a WebSocket
+// anywhere, so without this a page elsewhere could use the live test of
+// someone on the same network.
+func (mod *RestAPIModule) webSocket(handler websocket.Handler) http.Handler {
+	return websocket.Server{
+		Handshake: func(config *websocket.Config, r *http.Request) error {
+			origin, err := websocket.Origin(config, r)
+			if err != nil || origin == nil {
+				return fmt.Errorf("the WebSocket has no origin")
+			}
+			if !strings.EqualFold(origin.Host, r.Host) && !mod.allowsOrigin(origin.Scheme+"://"+origin.Host) {
+				return fmt.Errorf("origin %q may not open the WebSocket", origin)
+			}
+			config.Origin = origin
+			return nil
+		},
+		Handler: handler,
+	}
+}
+
+// rateLimiter limits the requests of every address, and locks out those
+// that keep sending wrong tokens. Requests are limited as a token bucket
+// holding perMinute requests and refilling at that rate, kept for each
+// address as the time its bucket is full again.
+type rateLimiter struct {
+	perMinute int
+	full      map[string]time.Time
+	failures  map[string]*authFailures
+	mu        sync.Mutex
+}
+
+// authFailures counts the wrong tokens an address sent since first
+type authFailures struct {
+	count int
+	first time.Time
+}
+
+func newRateLimiter(perMinute int) *rateLimiter {
+	return &rateLimiter{
+		perMinute: perMinute,
+		full:      make(map[string]time.Time),
+		failures:  make(map[string]*authFailures),
+	}
+}
+
+// allow takes a request of address from its bucket, returning false with
+// how long to wait when the bucket is empty
+func (l *rateLimiter) allow(address string, now time.Time) (bool, time.Duration) {
+	if l.perMinute == 0 {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.full) >= pruneLimiterAt {
+		for key, full := range l.full {
+			if !full.After(now) {
+				delete(l.full, key)
+			}
+		}
+	}
+	interval := time.Minute / time.Duration(l.perMinute)
+	full := l.full[address]
+	if full.Before(now) {
+		full = now
+	}
+	// A full bucket holds perMinute requests, so the bucket is empty when
+	// it is full again only after perMinute intervals
+	if wait := full.Sub(now) - time.Duration(l.perMinute-1)*interval; wait > 0 {
+		return false, wait
+	}
+	l.full[address] = full.Add(interval)
+	return true, 0
+}
+
+// lockedOut reports whether address sent too many wrong tokens lately,
+// with how long it stays locked out
+func (l *rateLimiter) lockedOut(address string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	failures, ok := l.failures[address]
+	if !ok {
+		return false, 0
+	}
+	if now.Sub(failures.first) >= authLockout {
+		delete(l.failures, address)
+		return false, 0
+	}
+	return failures.count >= maxAuthFailures, failures.first.Add(authLockout).Sub(now)
+}
+
+// failed counts a wrong token sent from address
+func (l *rateLimiter) failed(address string, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	failures, ok := l.failures[address]
+	if !ok || now.Sub(failures.first) >= authLockout {
+		failures = &authFailures{first: now}
+		l.failures[address] = failures
+	}
+	failures.count++
+	if failures.count == maxAuthFailures {
+		log.Printf("[WARNING] RestAPIModule - %s sent %d wrong access tokens, locked out for %s", address, failures.count, authLockout)
+	}
+}
+
+// limit refuses the requests of addresses over the rate limit. The health
+// check stays open for container probes.
+func (mod *RestAPIModule) limit(next http.Handler) http.Handler {
+	if mod.limiter.perMinute == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			if ok, wait := mod.limiter.allow(clientAddress(r), time.Now()); !ok {
+				tooManyRequests(w, wait, errors.New("too many requests, slow down"))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// tooManyRequests answers 429, telling the client when to try again
+func tooManyRequests(w http.ResponseWriter, wait time.Duration, err error) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	writeError(w, http.StatusTooManyRequests, err)
+}
+
+// clientAddress returns the address a request came from, without its
+// port
+func clientAddress(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
@@ -0,0 +1,249 @@
+package restapi
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+func TestRateLimit(t *testing.T) {
+	mod := NewRestAPIModule()
+	mod.SetLessonDir(t.TempDir())
+	mod.SetRateLimit(3)
+	server := httptest.NewServer(mod.Handler())
+	defer server.Close()
+
+	for i := 0; i < 3; i++ {
+		if resp, err := http.Get(server.URL + "/api/lessons"); err != nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected request %d within the limit to pass, got %v, %v", i+1, resp, err)
+		}
+	}
+	resp, err := http.Get(server.URL + "/api/lessons")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") == "" {
+		t.Errorf("Expected 429 with Retry-After over the limit, got %d %q", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+	if resp, err := http.Get(server.URL + "/healthz"); err != nil || resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected the health check to stay open, got %v, %v", resp, err)
+	}
+
+	limiter := newRateLimiter(60)
+	start := time.Now()
+	for i := 0; i < 60; i++ {
+		limiter.allow("10.0.0.1", start)
+	}
+	if ok, wait := limiter.allow("10.0.0.1", start); ok || wait != time.Second {
+		t.Errorf("Expected an empty bucket to wait a second, got %v, %s", ok, wait)
+	}
+	if ok, _ := limiter.allow("10.0.0.2", start); !ok {
+		t.Error("Expected another address to have a bucket of its own")
+	}
+	if ok, _ := limiter.allow("10.0.0.1", start.Add(time.Second)); !ok {
+		t.Error("Expected the bucket to refill")
+	}
+}
+
+func TestAuthLockout(t *testing.T) {
+	mod := NewRestAPIModule()
+	mod.SetLessonDir(t.TempDir())
+	mod.SetTokens([]string{"secret"})
+	server := httptest.NewServer(mod.Handler())
+	defer server.Close()
+
+	get := func(token string) int {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/lessons", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	for i := 0; i < maxAuthFailures; i++ {
+		if status := get("guess"); status != http.StatusUnauthorized {
+			t.Fatalf("Expected 401 for wrong token %d, got %d", i+1, status)
+		}
+	}
+	if status := get("secret"); status != http.StatusTooManyRequests {
+		t.Errorf("Expected an address sending %d wrong tokens to be locked out, got %d", maxAuthFailures, status)
+	}
+	if locked, _ := mod.limiter.lockedOut("127.0.0.1", time.Now().Add(authLockout)); locked {
+		t.Error("Expected the lockout to end")
+	}
+}
+
+func TestAuthWithoutClassroom(t *testing.T) {
+	// recuerdo serve and recuerdo-sync run without the classroom
+	mod := NewRestAPIModule()
+	mod.SetLessonDir(t.TempDir())
+	mod.SetClassroomEnabled(false)
+	mod.SetTokens([]string{"secret"})
+	server := httptest.NewServer(mod.Handler())
+	defer server.Close()
+
+	for token, want := range map[string]int{"secret": http.StatusOK, "guess": http.StatusUnauthorized} {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/lessons", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("Expected %d for token %q, got %d", want, token, resp.StatusCode)
+		}
+		if resp.Header.Get("X-Content-Type-Options") == "" {
+			t.Error("Expected the security headers without the classroom too")
+		}
+	}
+	if resp, err := http.Get(server.URL + "/api/roster"); err != nil || resp.StatusCode == http.StatusOK {
+		t.Errorf("Expected no roster without the classroom, got %v, %v", resp, err)
+	}
+}
+
+func TestCORS(t *testing.T) {
+	mod := NewRestAPIModule()
+	mod.SetLessonDir(t.TempDir())
+	mod.SetTokens([]string{"secret"})
+	mod.SetTeacherTokens([]string{"teacher-secret"})
+	mod.SetCORSOrigins([]string{"https://app.example/"})
+	server := httptest.NewServer(mod.Handler())
+	defer server.Close()
+
+	preflight := func(origin string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodOptions, server.URL+"/api/lessons", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	resp := preflight("https://app.example")
+	if resp.StatusCode != http.StatusNoContent || resp.Header.Get("Access-Control-Allow-Origin") != "https://app.example" ||
+		!strings.Contains(resp.Header.Get("Access-Control-Allow-Headers"), "Authorization") {
+		t.Errorf("Expected the preflight of the allowed origin to pass, got %d %v", resp.StatusCode, resp.Header)
+	}
+	if resp := preflight("https://evil.example"); resp.StatusCode != http.StatusForbidden || resp.Header.Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("Expected the preflight of another origin to be refused, got %d %v", resp.StatusCode, resp.Header)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/lessons", nil)
+	req.Header.Set("Origin", "https://app.example")
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Access-Control-Allow-Origin") != "https://app.example" || resp.Header.Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("Expected the allowed origin to read the response, got %d %v", resp.StatusCode, resp.Header)
+	}
+
+	location := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/live"
+	for origin, allowed := range map[string]bool{server.URL: true, "https://app.example": true, "https://evil.example": false} {
+		config, err := websocket.NewConfig(location, origin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		config.Header.Set("Authorization", "Bearer teacher-secret")
+		ws, err := websocket.DialConfig(config)
+		if allowed != (err == nil) {
+			t.Errorf("WebSocket from %s: allowed %v, got %v", origin, allowed, err)
+		}
+		if ws != nil {
+			ws.Close()
+		}
+	}
+}
+
+func TestTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeTestCertificate(t, certFile, keyFile)
+
+	mod := NewRestAPIModule()
+	mod.SetLessonDir(dir)
+	mod.SetAddr("127.0.0.1:0")
+	mod.SetTLSFiles(certFile, keyFile)
+	if err := mod.Enable(context.Background()); err != nil {
+		t.Fatalf("Enable failed: %v", err)
+	}
+	defer mod.Disable(context.Background())
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	resp, err := client.Get("https://" + mod.Addr() + "/healthz")
+	if err != nil {
+		t.Fatalf("HTTPS request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Strict-Transport-Security") == "" {
+		t.Errorf("Expected 200 with HSTS over HTTPS, got %d %v", resp.StatusCode, resp.Header)
+	}
+	if resp, err := http.Get("http://" + mod.Addr() + "/healthz"); err == nil && resp.StatusCode == http.StatusOK {
+		t.Error("Expected plain HTTP to be refused")
+	}
+
+	both := NewRestAPIModule()
+	both.SetAddr("127.0.0.1:0")
+	both.SetTLSFiles(certFile, keyFile)
+	both.SetACME([]string{"school.example"}, dir, "")
+	if err := both.Enable(context.Background()); err == nil {
+		both.Disable(context.Background())
+		t.Error("Expected certificate files and ACME together to be refused")
+	}
+}
+
+// writeTestCertificate writes a self-signed certificate for 127.0.0.1
+func writeTestCertificate(t *testing.T, certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "recuerdo test"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,243 @@
+package restapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// syncDir is the directory in the lesson directory that keeps the revisions
+// lessons are synced from. Its name starts with a dot, so it is never listed
+// or served as a lesson.
+const syncDir = ".sync"
+
+// keptRevisions is how many revisions of a lesson are kept to merge edits
+// against; edits based on older ones are merged as if made from scratch
+const keptRevisions = 100
+
+// SyncState is a revision of a lesson as sent to sync clients
+type SyncState struct {
+	Revision int                `json:"revision"`
+	Lesson   *lesson.LessonData `json:"lesson"`
+}
+
+// SyncRequest is an edit of a lesson pushed by a sync client, made from
+// BaseRevision
+type SyncRequest struct {
+	BaseRevision int                `json:"baseRevision"`
+	Lesson       *lesson.LessonData `json:"lesson"`
+}
+
+// SyncResponse is the merged lesson after a push, with the items that were
+// changed by someone else too
+type SyncResponse struct {
+	SyncState
+	Conflicts []lesson.EditConflict `json:"conflicts"`
+}
+
+func (mod *RestAPIModule) handleGetSync(w http.ResponseWriter, r *http.Request) {
+	path, ok := mod.syncPath(w, r)
+	if !ok {
+		return
+	}
+
+	mod.syncMutex.Lock()
+	defer mod.syncMutex.Unlock()
+
+	state, err := mod.currentSyncState(path)
+	if errors.Is(err, os.ErrNotExist) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("lesson %q not found", r.PathValue("name")))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	if !syncsToRequester(r, state.Lesson) {
+		writeError(w, http.StatusForbidden, fmt.Errorf("lesson %q is not synced to this device", r.PathValue("name")))
+		return
+	}
+	writeJSON(w, http.StatusOK, state)
+}
+
+// syncsToRequester reports whether the device sending r gets lessonData.
+// Devices name the groups they are in in the groups parameter; one that
+// names none, such as an older client, gets no lesson restricted to
+// groups. The server takes the device's word for its groups, so this
+// keeps lessons off devices that do not need them rather than from people
+// holding the sync token.
+func syncsToRequester(r *http.Request, lessonData *lesson.LessonData) bool {
+	return lesson.SyncsTo(lessonData.List.Sync, lesson.ParseTags(r.URL.Query().Get("groups")))
+}
+
+// handlePostSync merges an edit into the lesson. Items changed only by the
+// pushed edit or only since its base revision keep their change; items
+// changed by both take the pushed version and are returned as conflicts.
+func (mod *RestAPIModule) handlePostSync(w http.ResponseWriter, r *http.Request) {
+	path, ok := mod.syncPath(w, r)
+	if !ok {
+		return
+	}
+
+	var request SyncRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxImportSize)).Decode(&request); err != nil {
+		writeError(w, bodyErrorStatus(err), fmt.Errorf("invalid sync request: %w", err))
+		return
+	}
+	if request.Lesson == nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid sync request: no lesson"))
+		return
+	}
+	if request.Lesson.List.Sync == lesson.SyncLocalOnly {
+		writeError(w, http.StatusForbidden, fmt.Errorf("lesson %q is local only and not synced", r.PathValue("name")))
+		return
+	}
+
+	mod.syncMutex.Lock()
+	defer mod.syncMutex.Unlock()
+
+	current, err := mod.currentSyncState(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	merged, conflicts := request.Lesson, []lesson.EditConflict(nil)
+	if current != nil && current.Revision != request.BaseRevision {
+		base, err := mod.loadRevision(path, request.BaseRevision)
+		if err != nil {
+			log.Printf("[WARNING] RestAPIModule - no revision %d of %s, merging without a base: %v", request.BaseRevision, filepath.Base(path), err)
+			base = lesson.NewLessonData()
+		}
+		merged, conflicts = lesson.MergeEdits(base, current.Lesson, request.Lesson)
+	}
+
+	if err := mod.fileSaver.SaveFile(merged, path); err != nil {
+		writeError(w, saveErrorStatus(err), err)
+		return
+	}
+	revision := 1
+	if current != nil {
+		revision = current.Revision + 1
+	}
+	state, err := mod.saveRevision(path, revision)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if len(conflicts) > 0 {
+		log.Printf("[WARNING] RestAPIModule - %d conflicting edits in %s, the latest ones were kept", len(conflicts), filepath.Base(path))
+	}
+	writeJSON(w, http.StatusOK, SyncResponse{SyncState: *state, Conflicts: conflicts})
+}
+
+// currentSyncState returns the latest revision of the lesson at path. A
+// lesson that was changed without syncing, or never synced, gets a new
+// revision first.
+func (mod *RestAPIModule) currentSyncState(path string) (*SyncState, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, err := mod.revisions(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) > 0 {
+		latest := revisions[len(revisions)-1]
+		revisionInfo, err := os.Stat(mod.revisionPath(path, latest))
+		if err == nil && !info.ModTime().After(revisionInfo.ModTime()) {
+			lessonData, err := mod.loadRevision(path, latest)
+			if err != nil {
+				return nil, err
+			}
+			return &SyncState{Revision: latest, Lesson: lessonData}, nil
+		}
+	}
+
+	revision := 1
+	if len(revisions) > 0 {
+		revision = revisions[len(revisions)-1] + 1
+	}
+	return mod.saveRevision(path, revision)
+}
+
+// saveRevision records the lesson file at path as the given revision. The
+// lesson is read back from its file, so the revision holds only what the
+// file format keeps.
+func (mod *RestAPIModule) saveRevision(path string, revision int) (*SyncState, error) {
+	lessonData, err := mod.fileLoader.LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(lessonData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode revision: %w", err)
+	}
+
+	revisionPath := mod.revisionPath(path, revision)
+	if err := os.MkdirAll(filepath.Dir(revisionPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create sync directory: %w", err)
+	}
+	if err := lesson.WriteFileAtomically(revisionPath, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to save revision: %w", err)
+	}
+
+	// Only the last revisions are needed to merge edits
+	if revisions, err := mod.revisions(path); err == nil && len(revisions) > keptRevisions {
+		for _, old := range revisions[:len(revisions)-keptRevisions] {
+			os.Remove(mod.revisionPath(path, old))
+		}
+	}
+	return &SyncState{Revision: revision, Lesson: lessonData}, nil
+}
+
+func (mod *RestAPIModule) loadRevision(path string, revision int) (*lesson.LessonData, error) {
+	data, err := os.ReadFile(mod.revisionPath(path, revision))
+	if err != nil {
+		return nil, err
+	}
+	lessonData := lesson.NewLessonData()
+	if err := json.Unmarshal(data, lessonData); err != nil {
+		return nil, fmt.Errorf("failed to read revision %d: %w", revision, err)
+	}
+	return lessonData, nil
+}
+
+// revisions returns the kept revision numbers of the lesson at path in
+// ascending order
+func (mod *RestAPIModule) revisions(path string) ([]int, error) {
+	entries, err := os.ReadDir(filepath.Join(filepath.Dir(path), syncDir, filepath.Base(path)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var revisions []int
+	for _, entry := range entries {
+		if revision, err := strconv.Atoi(strings.TrimSuffix(entry.Name(), ".json")); err == nil {
+			revisions = append(revisions, revision)
+		}
+	}
+	sort.Ints(revisions)
+	return revisions, nil
+}
+
+func (mod *RestAPIModule) revisionPath(path string, revision int) string {
+	return filepath.Join(filepath.Dir(path), syncDir, filepath.Base(path), fmt.Sprintf("%d.json", revision))
+}
@@ -0,0 +1,168 @@
+package restapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/classroom"
+)
+
+// threadsFile is where the discussions about lesson items are kept, next to
+// the roster
+const threadsFile = ".classroom/threads.json"
+
+// handleListThreads returns the threads of all lessons, for the teacher
+func (mod *RestAPIModule) handleListThreads(w http.ResponseWriter, r *http.Request) {
+	if !mod.teacherOnly(w, r) {
+		return
+	}
+	threads, ok := mod.openThreads(w)
+	if !ok {
+		return
+	}
+	writeThreads(w, threads.All())
+}
+
+func (mod *RestAPIModule) handleLessonThreads(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if _, err := mod.lessonPath(name); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	threads, ok := mod.openThreads(w)
+	if !ok {
+		return
+	}
+	writeThreads(w, threads.Lesson(name))
+}
+
+// handlePostThread adds a message about an item. A message with a join code
+// is a student's and is posted under their name and id from the roster;
+// one without is the teacher's and needs a teacher token. The server
+// stamps the time.
+func (mod *RestAPIModule) handlePostThread(w http.ResponseWriter, r *http.Request) {
+	name, itemID, ok := mod.threadItem(w, r)
+	if !ok {
+		return
+	}
+	var post classroom.ThreadPost
+	if err := json.NewDecoder(r.Body).Decode(&post); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid message JSON: %w", err))
+		return
+	}
+	if post.Code != "" {
+		roster, ok := mod.openRoster(w)
+		if !ok {
+			return
+		}
+		student, err := roster.Join(post.Code)
+		if err != nil {
+			writeError(w, http.StatusForbidden, err)
+			return
+		}
+		post.Author, post.Teacher, post.StudentID = student.Name, false, student.ID
+	} else {
+		if !mod.teacherOnly(w, r) {
+			return
+		}
+		post.Teacher, post.StudentID = true, 0
+		if strings.TrimSpace(post.Author) == "" {
+			post.Author = "Teacher"
+		}
+	}
+	post.Time = time.Now()
+
+	threads, ok := mod.openThreads(w)
+	if !ok {
+		return
+	}
+	thread, err := threads.Post(name, itemID, post.Question, post.Message)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, thread)
+}
+
+// handleResolveThread marks a thread resolved or open again, for the
+// teacher
+func (mod *RestAPIModule) handleResolveThread(w http.ResponseWriter, r *http.Request) {
+	name, itemID, ok := mod.threadItem(w, r)
+	if !ok || !mod.teacherOnly(w, r) {
+		return
+	}
+	var request classroom.ResolveRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid resolve request: %w", err))
+		return
+	}
+	threads, ok := mod.openThreads(w)
+	if !ok {
+		return
+	}
+	thread, err := threads.Resolve(name, itemID, request.Resolved)
+	if errors.Is(err, classroom.ErrThreadNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, thread)
+}
+
+// openThreads returns the item threads, opening them on first use. On
+// failure it writes the error and returns false.
+func (mod *RestAPIModule) openThreads(w http.ResponseWriter) (*classroom.Threads, bool) {
+	mod.rosterMutex.Lock()
+	defer mod.rosterMutex.Unlock()
+
+	if mod.threads == nil {
+		threads, err := classroom.OpenThreads(filepath.Join(mod.lessonDir, filepath.FromSlash(threadsFile)))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return nil, false
+		}
+		mod.threads = threads
+	}
+	return mod.threads, true
+}
+
+// teacherOnly reports whether r may do what only the teacher does with
+// threads. Otherwise it writes the error and returns false.
+func (mod *RestAPIModule) teacherOnly(w http.ResponseWriter, r *http.Request) bool {
+	if mod.hasTokens() && !mod.isTeacher(r) {
+		writeError(w, http.StatusForbidden, errors.New("this needs a teacher token; see recuerdo serve -teacher-tokens"))
+		return false
+	}
+	return true
+}
+
+// threadItem returns the lesson name and item id from the URL
+func (mod *RestAPIModule) threadItem(w http.ResponseWriter, r *http.Request) (string, int, bool) {
+	name := r.PathValue("name")
+	if _, err := mod.lessonPath(name); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return "", 0, false
+	}
+	itemID, err := strconv.Atoi(r.PathValue("item"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid item id %q", r.PathValue("item")))
+		return "", 0, false
+	}
+	return name, itemID, true
+}
+
+func writeThreads(w http.ResponseWriter, threads []classroom.Thread) {
+	if threads == nil {
+		threads = []classroom.Thread{}
+	}
+	writeJSON(w, http.StatusOK, threads)
+}
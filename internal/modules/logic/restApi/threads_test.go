@@ -0,0 +1,108 @@
+package restapi
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/classroom"
+)
+
+func TestRestAPIThreads(t *testing.T) {
+	mod := NewRestAPIModule()
+	mod.SetLessonDir(t.TempDir())
+	server := httptest.NewServer(mod.Handler())
+	defer server.Close()
+	client := classroom.NewClient(server.URL)
+
+	anna, err := client.Add("Anna", "3B")
+	if err != nil {
+		t.Fatalf("Failed to add student: %v", err)
+	}
+
+	// Students post with their join code and cannot pose as the teacher
+	post := classroom.ThreadPost{Code: anna.JoinCode, Question: "kat", Message: classroom.Message{Author: "Teacher", Teacher: true, Text: "Cat or tomcat?"}}
+	thread, err := client.Post("animals.csv", 3, post)
+	if err != nil {
+		t.Fatalf("Failed to post: %v", err)
+	}
+	if thread.Messages[0].Author != "Anna" || thread.Messages[0].Teacher {
+		t.Errorf("Expected the post under Anna's name, got %+v", thread.Messages[0])
+	}
+	if _, err := client.Post("animals.csv", 3, classroom.ThreadPost{Code: "NOPE00", Message: classroom.Message{Text: "Hi"}}); err == nil {
+		t.Error("Expected an unknown join code to be refused")
+	}
+	if _, err := client.Post("../secret.csv", 1, post); err == nil {
+		t.Error("Expected an invalid lesson name to be refused")
+	}
+
+	if _, err := client.Post("plants.csv", 0, classroom.ThreadPost{Code: anna.JoinCode, Message: classroom.Message{Text: "Is a tomato a fruit?"}}); err != nil {
+		t.Fatalf("Failed to post: %v", err)
+	}
+	if _, err := client.Post("animals.csv", 3, classroom.ThreadPost{Message: classroom.Message{Author: "Ms. Jansen", Teacher: true, Text: "Either is fine."}}); err != nil {
+		t.Fatalf("Failed to answer: %v", err)
+	}
+
+	all, err := client.Threads()
+	if err != nil || len(all) != 2 || all[0].Lesson != "plants.csv" || !all[1].Answered() {
+		t.Fatalf("Expected the waiting thread first, got %+v, %v", all, err)
+	}
+
+	if _, err := client.Resolve("animals.csv", 3, true); err != nil {
+		t.Fatalf("Failed to resolve: %v", err)
+	}
+	if _, err := client.Resolve("animals.csv", 9, true); err == nil {
+		t.Error("Expected resolving an unknown thread to fail")
+	}
+	lesson, err := client.LessonThreads("animals.csv")
+	if err != nil || len(lesson) != 1 || !lesson[0].Resolved || lesson[0].Question != "kat" {
+		t.Errorf("Expected one resolved thread, got %+v, %v", lesson, err)
+	}
+}
+
+func TestThreadsNeedTeacher(t *testing.T) {
+	mod := NewRestAPIModule()
+	mod.SetLessonDir(t.TempDir())
+	mod.SetTokens([]string{"class-token"})
+	mod.SetTeacherTokens([]string{"teacher-token"})
+	server := httptest.NewServer(mod.Handler())
+	defer server.Close()
+	teacher := classroom.NewClient(server.URL)
+	teacher.SetToken("teacher-token")
+	student := classroom.NewClient(server.URL)
+	student.SetToken("class-token")
+
+	anna, err := teacher.Add("Anna", "3B")
+	if err != nil {
+		t.Fatalf("Failed to add student: %v", err)
+	}
+	backdated := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+	thread, err := student.Post("animals.csv", 3, classroom.ThreadPost{Code: anna.JoinCode, Message: classroom.Message{Text: "Cat or tomcat?", Time: backdated}})
+	if err != nil {
+		t.Fatalf("Failed to post with a join code: %v", err)
+	}
+	if thread.Messages[0].Time.Equal(backdated) {
+		t.Error("Expected the server to stamp the time of a message")
+	}
+
+	if _, err := student.Post("animals.csv", 3, classroom.ThreadPost{Message: classroom.Message{Author: "Ms. Jansen", Teacher: true, Text: "Tomcat."}}); err == nil {
+		t.Error("Expected a student token not to post as the teacher")
+	}
+	if _, err := student.Threads(); err == nil {
+		t.Error("Expected a student token not to list every thread")
+	}
+	if _, err := student.Resolve("animals.csv", 3, true); err == nil {
+		t.Error("Expected a student token not to resolve a thread")
+	}
+
+	thread, err = teacher.Post("animals.csv", 3, classroom.ThreadPost{Message: classroom.Message{Author: "Ms. Jansen", Text: "Either is fine."}})
+	if err != nil || !thread.Messages[1].Teacher {
+		t.Fatalf("Expected the teacher's answer, got %+v, %v", thread, err)
+	}
+	if all, err := teacher.Threads(); err != nil || len(all) != 1 {
+		t.Errorf("Expected the teacher to list the thread, got %+v, %v", all, err)
+	}
+	if _, err := teacher.Resolve("animals.csv", 3, true); err != nil {
+		t.Errorf("Failed to resolve: %v", err)
+	}
+}
@@ -0,0 +1,248 @@
+package restapi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/LaPingvino/recuerdo/internal/vault"
+)
+
+// vaultDir is the directory in the lesson directory that keeps the
+// encrypted vaults; see package vault. Like syncDir it is never listed.
+const vaultDir = ".vault"
+
+// maxBlobSize bounds an encrypted blob, which may hold a lesson with media
+const maxBlobSize = 64 << 20
+
+// revisionExt is the extension of the file next to a blob holding its
+// revision
+const revisionExt = ".revision"
+
+// vaultName matches the names of vaults and blobs, which end up in paths
+var vaultName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,100}$`)
+
+// BlobInfo describes an encrypted blob in a vault
+type BlobInfo struct {
+	Name     string `json:"name"`
+	Revision int    `json:"revision"`
+	Size     int64  `json:"size"`
+}
+
+// BlobRevision is the revision a blob was stored at
+type BlobRevision struct {
+	Revision int `json:"revision"`
+}
+
+// handleGetSalt sends the salt of a vault, which devices derive its key
+// with
+func (mod *RestAPIModule) handleGetSalt(w http.ResponseWriter, r *http.Request) {
+	dir, ok := mod.ownedVaultPath(w, r)
+	if !ok {
+		return
+	}
+	salt, err := os.ReadFile(filepath.Join(dir, "salt"))
+	if errors.Is(err, os.ErrNotExist) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("vault %q not found", r.PathValue("vault")))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(salt)
+}
+
+// handlePutSalt creates a vault with the salt the first device made. A
+// vault keeps its salt, as changing it would lock every device out;
+// sending the same salt again is harmless.
+func (mod *RestAPIModule) handlePutSalt(w http.ResponseWriter, r *http.Request) {
+	dir, ok := mod.ownedVaultPath(w, r)
+	if !ok {
+		return
+	}
+	salt, err := io.ReadAll(io.LimitReader(r.Body, vault.SaltSize+1))
+	if err != nil || len(salt) != vault.SaltSize {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("a salt is %d bytes", vault.SaltSize))
+		return
+	}
+
+	mod.syncMutex.Lock()
+	defer mod.syncMutex.Unlock()
+	path := filepath.Join(dir, "salt")
+	if existing, err := os.ReadFile(path); err == nil {
+		if !bytes.Equal(existing, salt) {
+			writeError(w, http.StatusConflict, fmt.Errorf("vault %q has another salt", r.PathValue("vault")))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "blobs"), 0755); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := lesson.WriteFileAtomically(path, salt, 0600); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// handleListBlobs lists the blobs of a vault by name
+func (mod *RestAPIModule) handleListBlobs(w http.ResponseWriter, r *http.Request) {
+	dir, ok := mod.ownedVaultPath(w, r)
+	if !ok {
+		return
+	}
+
+	mod.syncMutex.Lock()
+	defer mod.syncMutex.Unlock()
+	entries, err := os.ReadDir(filepath.Join(dir, "blobs"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	blobs := []BlobInfo{}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), revisionExt) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		blobs = append(blobs, BlobInfo{
+			Name:     entry.Name(),
+			Revision: blobRevision(filepath.Join(dir, "blobs", entry.Name())),
+			Size:     info.Size(),
+		})
+	}
+	sort.Slice(blobs, func(i, j int) bool { return blobs[i].Name < blobs[j].Name })
+	writeJSON(w, http.StatusOK, blobs)
+}
+
+// handleGetBlob sends a blob, with its revision as ETag
+func (mod *RestAPIModule) handleGetBlob(w http.ResponseWriter, r *http.Request) {
+	path, ok := mod.blobPath(w, r)
+	if !ok {
+		return
+	}
+
+	mod.syncMutex.Lock()
+	data, err := os.ReadFile(path)
+	revision := blobRevision(path)
+	mod.syncMutex.Unlock()
+	if errors.Is(err, os.ErrNotExist) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("blob %q not found", r.PathValue("blob")))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("ETag", strconv.Quote(strconv.Itoa(revision)))
+	w.Write(data)
+}
+
+// handlePutBlob stores a blob. With If-Match it is only stored over the
+// revision given, and with "If-None-Match: *" only when new, so a device
+// that pushes an edit made from an older revision gets 412 and merges
+// first; the server cannot merge what it cannot read.
+func (mod *RestAPIModule) handlePutBlob(w http.ResponseWriter, r *http.Request) {
+	path, ok := mod.blobPath(w, r)
+	if !ok {
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxBlobSize+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if len(data) > maxBlobSize {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("blobs are at most %d bytes", maxBlobSize))
+		return
+	}
+
+	mod.syncMutex.Lock()
+	defer mod.syncMutex.Unlock()
+	if _, err := os.Stat(filepath.Join(filepath.Dir(filepath.Dir(path)), "salt")); err != nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("vault %q not found", r.PathValue("vault")))
+		return
+	}
+	revision := 0
+	if _, err := os.Stat(path); err == nil {
+		revision = blobRevision(path)
+	}
+	if match := r.Header.Get("If-Match"); match != "" && match != strconv.Quote(strconv.Itoa(revision)) {
+		writeError(w, http.StatusPreconditionFailed, fmt.Errorf("blob %q is at revision %d", r.PathValue("blob"), revision))
+		return
+	}
+	if r.Header.Get("If-None-Match") == "*" && revision != 0 {
+		writeError(w, http.StatusPreconditionFailed, fmt.Errorf("blob %q exists", r.PathValue("blob")))
+		return
+	}
+
+	revision++
+	if err := lesson.WriteFileAtomically(path, data, 0600); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := lesson.WriteFileAtomically(path+revisionExt, []byte(strconv.Itoa(revision)), 0600); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("ETag", strconv.Quote(strconv.Itoa(revision)))
+	writeJSON(w, http.StatusOK, BlobRevision{Revision: revision})
+}
+
+// vaultPath returns the directory of the vault in the URL. On failure it
+// writes the error and returns false.
+func (mod *RestAPIModule) vaultPath(w http.ResponseWriter, r *http.Request) (string, bool) {
+	name := r.PathValue("vault")
+	if !vaultName.MatchString(name) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid vault name %q", name))
+		return "", false
+	}
+	return filepath.Join(mod.lessonDir, vaultDir, name), true
+}
+
+// blobPath returns the path of the blob in the URL. On failure it writes
+// the error and returns false.
+func (mod *RestAPIModule) blobPath(w http.ResponseWriter, r *http.Request) (string, bool) {
+	dir, ok := mod.ownedVaultPath(w, r)
+	if !ok {
+		return "", false
+	}
+	name := r.PathValue("blob")
+	if !vaultName.MatchString(name) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid blob name %q", name))
+		return "", false
+	}
+	return filepath.Join(dir, "blobs", name), true
+}
+
+// blobRevision returns the revision of the blob at path, 1 when it has
+// none recorded
+func blobRevision(path string) int {
+	data, err := os.ReadFile(path + revisionExt)
+	if err != nil {
+		return 1
+	}
+	revision, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || revision < 1 {
+		return 1
+	}
+	return revision
+}
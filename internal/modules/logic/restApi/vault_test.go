@@ -0,0 +1,154 @@
+package restapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVaultBlobs(t *testing.T) {
+	dir := t.TempDir()
+	mod := NewRestAPIModule()
+	mod.SetLessonDir(dir)
+	server := httptest.NewServer(mod.Handler())
+	defer server.Close()
+
+	do := func(method, path string, header map[string]string, body []byte) (int, http.Header, []byte) {
+		t.Helper()
+		request, err := http.NewRequest(method, server.URL+"/api/vault/"+path, bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for key, value := range header {
+			request.Header.Set(key, value)
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", method, path, err)
+		}
+		defer response.Body.Close()
+		data, _ := io.ReadAll(response.Body)
+		return response.StatusCode, response.Header, data
+	}
+
+	if status, _, _ := do(http.MethodGet, "alice/salt", nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected 404 for a new vault, got %d", status)
+	}
+	if status, _, _ := do(http.MethodPut, "alice/blobs/l-1", nil, []byte("sealed")); status != http.StatusNotFound {
+		t.Errorf("Expected 404 for a blob in a missing vault, got %d", status)
+	}
+	salt := bytes.Repeat([]byte{7}, 16)
+	if status, _, _ := do(http.MethodPut, "alice/salt", nil, salt); status != http.StatusCreated {
+		t.Fatalf("Expected 201 creating the vault, got %d", status)
+	}
+	if status, _, _ := do(http.MethodPut, "alice/salt", nil, bytes.Repeat([]byte{8}, 16)); status != http.StatusConflict {
+		t.Errorf("Expected 409 replacing the salt, got %d", status)
+	}
+	if status, _, data := do(http.MethodGet, "alice/salt", nil, nil); status != http.StatusOK || !bytes.Equal(data, salt) {
+		t.Errorf("Expected the salt back, got %d %v", status, data)
+	}
+	if status, _, _ := do(http.MethodGet, "../salt", nil, nil); status == http.StatusOK {
+		t.Error("Expected a path outside the vaults to be refused")
+	}
+
+	if status, _, _ := do(http.MethodPut, "alice/blobs/l-1", map[string]string{"If-None-Match": "*"}, []byte("first")); status != http.StatusOK {
+		t.Fatalf("Expected 200 storing a new blob, got %d", status)
+	}
+	if status, _, _ := do(http.MethodPut, "alice/blobs/l-1", map[string]string{"If-None-Match": "*"}, []byte("again")); status != http.StatusPreconditionFailed {
+		t.Errorf("Expected 412 creating an existing blob, got %d", status)
+	}
+	if status, _, _ := do(http.MethodPut, "alice/blobs/l-1", map[string]string{"If-Match": `"2"`}, []byte("stale")); status != http.StatusPreconditionFailed {
+		t.Errorf("Expected 412 for a stale revision, got %d", status)
+	}
+	status, _, data := do(http.MethodPut, "alice/blobs/l-1", map[string]string{"If-Match": `"1"`}, []byte("second"))
+	var stored BlobRevision
+	if status != http.StatusOK || json.Unmarshal(data, &stored) != nil || stored.Revision != 2 {
+		t.Fatalf("Expected revision 2, got %d %s", status, data)
+	}
+	if status, header, data := do(http.MethodGet, "alice/blobs/l-1", nil, nil); status != http.StatusOK || string(data) != "second" || header.Get("ETag") != `"2"` {
+		t.Errorf("Expected the second blob at revision 2, got %d %q %q", status, data, header.Get("ETag"))
+	}
+
+	var blobs []BlobInfo
+	if _, _, data := do(http.MethodGet, "alice/blobs", nil, nil); json.Unmarshal(data, &blobs) != nil || len(blobs) != 1 || blobs[0].Name != "l-1" || blobs[0].Revision != 2 || blobs[0].Size != 6 {
+		t.Errorf("Expected one blob listed, got %s", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, vaultDir, "alice", "blobs", "l-1")); err != nil {
+		t.Errorf("Expected the blob in the vault directory: %v", err)
+	}
+
+	resp, err := http.Get(server.URL + "/api/lessons")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if listing, _ := io.ReadAll(resp.Body); bytes.Contains(listing, []byte("l-1")) {
+		t.Errorf("Expected vault blobs not to be listed as lessons, got %s", listing)
+	}
+}
+
+func TestVaultOwner(t *testing.T) {
+	mod := NewRestAPIModule()
+	mod.SetLessonDir(t.TempDir())
+	mod.SetTokens([]string{"shared"})
+	mod.SetAdminTokens([]string{"admin"})
+	mod.SetAccountTokens([]string{"alice=alice-token", "bob=bob-token"})
+	server := httptest.NewServer(mod.Handler())
+	defer server.Close()
+
+	do := func(method, path, token string, body []byte) int {
+		t.Helper()
+		request, _ := http.NewRequest(method, server.URL+"/api/vault/"+path, bytes.NewReader(body))
+		request.Header.Set("Authorization", "Bearer "+token)
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		return response.StatusCode
+	}
+
+	salt := bytes.Repeat([]byte{7}, 16)
+	if status := do(http.MethodPut, "alice/salt", "alice-token", salt); status != http.StatusCreated {
+		t.Fatalf("Expected 201 creating the vault, got %d", status)
+	}
+	if status := do(http.MethodPut, "alice/blobs/notes", "alice-token", []byte("sealed")); status != http.StatusOK {
+		t.Fatalf("Expected the owner to store a blob, got %d", status)
+	}
+	for _, token := range []string{"bob-token", "shared"} {
+		if status := do(http.MethodPut, "alice/salt", token, salt); status != http.StatusForbidden {
+			t.Errorf("Expected 403 putting the salt with %s, got %d", token, status)
+		}
+		if status := do(http.MethodPut, "alice/blobs/notes", token, []byte("forged")); status != http.StatusForbidden {
+			t.Errorf("Expected 403 putting a blob with %s, got %d", token, status)
+		}
+		if status := do(http.MethodGet, "alice/blobs", token, nil); status != http.StatusForbidden {
+			t.Errorf("Expected 403 listing blobs with %s, got %d", token, status)
+		}
+		if status := do(http.MethodGet, "alice/blobs/notes", token, nil); status != http.StatusForbidden {
+			t.Errorf("Expected 403 getting a blob with %s, got %d", token, status)
+		}
+	}
+	if status := do(http.MethodPut, "bob/salt", "alice-token", salt); status != http.StatusForbidden {
+		t.Errorf("Expected 403 seeding another account's vault, got %d", status)
+	}
+	for _, token := range []string{"bob-token", "shared"} {
+		if status := do(http.MethodGet, "alice/export", token, nil); status != http.StatusForbidden {
+			t.Errorf("Expected 403 exporting with %s, got %d", token, status)
+		}
+		if status := do(http.MethodDelete, "alice", token, nil); status != http.StatusForbidden {
+			t.Errorf("Expected 403 deleting with %s, got %d", token, status)
+		}
+	}
+	if status := do(http.MethodGet, "alice/export", "admin", nil); status != http.StatusOK {
+		t.Errorf("Expected an admin to export the vault, got %d", status)
+	}
+	if status := do(http.MethodDelete, "alice", "alice-token", nil); status != http.StatusNoContent {
+		t.Errorf("Expected the owner to delete the vault, got %d", status)
+	}
+}
@@ -0,0 +1,289 @@
+// Package reviewhistory records every answer given in a lesson in the
+// review log, so the statistics dialog can chart them over time for one
+// lesson or for all of them, and so the answers to a lesson can be found
+// again after it was saved in a format that drops test results. The log
+// itself is implemented by package reviewlog.
+package reviewhistory
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/LaPingvino/recuerdo/internal/paths"
+	"github.com/LaPingvino/recuerdo/internal/reviewlog"
+	"github.com/LaPingvino/recuerdo/internal/studyday"
+)
+
+// UntitledLesson is the name answers to lessons without a title are logged
+// under
+const UntitledLesson = "Untitled lesson"
+
+// LessonName returns the name the answers to list are logged under
+func LessonName(list *lesson.WordList) string {
+	if title := strings.TrimSpace(list.Title); title != "" {
+		return title
+	}
+	return UntitledLesson
+}
+
+// ReviewHistoryModule keeps the review log
+type ReviewHistoryModule struct {
+	*core.BaseModule
+	manager *core.Manager
+	// dbPath is where the log is stored
+	dbPath string
+	log    *reviewlog.Log
+	mu     sync.Mutex
+}
+
+// NewReviewHistoryModule creates a new ReviewHistoryModule instance
+func NewReviewHistoryModule() *ReviewHistoryModule {
+	base := core.NewBaseModule("reviewHistory", "review-history-module")
+
+	return &ReviewHistoryModule{
+		BaseModule: base,
+		dbPath:     filepath.Join(paths.DataDir(), "reviews.db"),
+	}
+}
+
+// Reviewed logs the answer of result to an item of list, giving the list a
+// UUID if it has none yet; it makes the module a lesson.ReviewObserver
+func (mod *ReviewHistoryModule) Reviewed(list *lesson.WordList, result lesson.TestResult) {
+	review := reviewlog.Review{
+		Lesson:     LessonName(list),
+		LessonUUID: list.EnsureUUID(),
+		ItemID:     result.ItemID,
+		Right:      result.Result == "right",
+		Reversed:   result.Reversed,
+		AnswerTime: time.Duration(result.AnswerTime * float64(time.Second)),
+	}
+	if result.Time != nil {
+		review.Time = *result.Time
+	}
+	for i := range list.Items {
+		item := &list.Items[i]
+		if item.ID == result.ItemID {
+			review.ItemHash = lesson.ItemHash(item)
+			review.Question = strings.Join(item.Questions, ", ")
+			if review.Question == "" {
+				review.Question = item.Name
+			}
+			break
+		}
+	}
+
+	log, err := mod.open()
+	if err == nil {
+		err = log.Add(review)
+	}
+	if err != nil {
+		fmt.Printf("Warning: failed to log review: %v\n", err)
+	}
+}
+
+// ItemHistory returns every logged answer to item of list, oldest first,
+// whatever ID the item had when it was answered. Lists without a UUID,
+// such as ones loaded from CSV, get the answers to the item in any lesson.
+func (mod *ReviewHistoryModule) ItemHistory(list *lesson.WordList, item *lesson.WordItem) ([]reviewlog.Review, error) {
+	log, err := mod.open()
+	if err != nil {
+		return nil, err
+	}
+	return log.History(list.UUID, lesson.ItemHash(item))
+}
+
+// Lessons returns the names of the lessons answers were logged for
+func (mod *ReviewHistoryModule) Lessons() ([]string, error) {
+	log, err := mod.open()
+	if err != nil {
+		return nil, err
+	}
+	return log.Lessons()
+}
+
+// Count returns the number of answers logged for lesson, or for all
+// lessons when lesson is empty
+func (mod *ReviewHistoryModule) Count(lesson string) (int, error) {
+	log, err := mod.open()
+	if err != nil {
+		return 0, err
+	}
+	return log.Count(lesson)
+}
+
+// Scores returns the fraction of right answers to lesson per day since
+// since
+func (mod *ReviewHistoryModule) Scores(lesson string, since time.Time) ([]reviewlog.Point, error) {
+	log, err := mod.open()
+	if err != nil {
+		return nil, err
+	}
+	return log.Scores(lesson, since)
+}
+
+// Retention returns the fraction of right answers to items of lesson asked
+// before, per day since since
+func (mod *ReviewHistoryModule) Retention(lesson string, since time.Time) ([]reviewlog.Point, error) {
+	log, err := mod.open()
+	if err != nil {
+		return nil, err
+	}
+	return log.Retention(lesson, since)
+}
+
+// Hardest returns the items of lesson answered wrong most often
+func (mod *ReviewHistoryModule) Hardest(lesson string, limit int) ([]reviewlog.ItemStats, error) {
+	log, err := mod.open()
+	if err != nil {
+		return nil, err
+	}
+	return log.Hardest(lesson, limit)
+}
+
+// Today returns the current day of study, as 2006-01-02; see package
+// studyday
+func (mod *ReviewHistoryModule) Today() (string, error) {
+	log, err := mod.open()
+	if err != nil {
+		return "", err
+	}
+	return log.Today(time.Now()), nil
+}
+
+// Forecast returns how many items of lesson are due on each of the coming
+// days of study, today first
+func (mod *ReviewHistoryModule) Forecast(lesson string, days int) ([]int, error) {
+	log, err := mod.open()
+	if err != nil {
+		return nil, err
+	}
+	return log.Forecast(lesson, time.Now(), days)
+}
+
+// Export writes table of the answers to lesson since since to w, as CSV
+// or JSON
+func (mod *ReviewHistoryModule) Export(w io.Writer, table reviewlog.Table, format, lesson string, since time.Time) error {
+	log, err := mod.open()
+	if err != nil {
+		return err
+	}
+	return log.Export(w, table, format, lesson, since)
+}
+
+// Device returns the ID of the device the log is kept on, empty when it
+// cannot be opened
+func (mod *ReviewHistoryModule) Device() string {
+	log, err := mod.open()
+	if err != nil {
+		return ""
+	}
+	return log.Device()
+}
+
+// Cursor returns how far the answers logged on this device and merged in
+// from others go, for another device to pass to Diff
+func (mod *ReviewHistoryModule) Cursor() (reviewlog.Cursor, error) {
+	log, err := mod.open()
+	if err != nil {
+		return nil, err
+	}
+	return log.Cursor(), nil
+}
+
+// Diff returns the answers a device whose log went as far as cursor is
+// missing
+func (mod *ReviewHistoryModule) Diff(cursor reviewlog.Cursor) ([]reviewlog.Review, error) {
+	log, err := mod.open()
+	if err != nil {
+		return nil, err
+	}
+	return log.Diff(cursor)
+}
+
+// Merge adds the answers given on other devices, such as the result of
+// their Diff, and returns how many were new
+func (mod *ReviewHistoryModule) Merge(reviews []reviewlog.Review) (int, error) {
+	log, err := mod.open()
+	if err != nil {
+		return 0, err
+	}
+	return log.Merge(reviews)
+}
+
+// open opens the log the first time it is needed, and has its days start
+// at the hour set in the settings
+func (mod *ReviewHistoryModule) open() (*reviewlog.Log, error) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	if mod.log == nil {
+		log, err := reviewlog.Open(mod.dbPath)
+		if err != nil {
+			return nil, err
+		}
+		mod.log = log
+	}
+	if err := mod.log.SetRollover(mod.rollover()); err != nil {
+		fmt.Printf("Warning: failed to change the start of the day in the review log: %v\n", err)
+	}
+	return mod.log, nil
+}
+
+// rollover returns the hour days of study start at
+func (mod *ReviewHistoryModule) rollover() int {
+	if mod.manager != nil {
+		if module, ok := mod.manager.GetDefaultModule("settings"); ok {
+			if settings, ok := module.(studyday.Settings); ok {
+				return studyday.LoadRollover(settings)
+			}
+		}
+	}
+	return studyday.DefaultRollover
+}
+
+// Enable activates the module, logging the answers given from now on
+func (mod *ReviewHistoryModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	lesson.AddReviewObserver(mod)
+
+	fmt.Println("ReviewHistoryModule enabled")
+	return nil
+}
+
+// Disable deactivates the module, closing the log
+func (mod *ReviewHistoryModule) Disable(ctx context.Context) error {
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	lesson.RemoveReviewObserver(mod)
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	if mod.log != nil {
+		mod.log.Close()
+		mod.log = nil
+	}
+
+	fmt.Println("ReviewHistoryModule disabled")
+	return nil
+}
+
+// SetManager sets the module manager
+func (mod *ReviewHistoryModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// InitReviewHistoryModule creates and returns a new ReviewHistoryModule
+// instance
+func InitReviewHistoryModule() core.Module {
+	return NewReviewHistoryModule()
+}
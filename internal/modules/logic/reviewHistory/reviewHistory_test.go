@@ -0,0 +1,84 @@
+package reviewhistory
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+func TestAnswersAreLogged(t *testing.T) {
+	mod := NewReviewHistoryModule()
+	mod.dbPath = filepath.Join(t.TempDir(), "reviews.db")
+	ctx := context.Background()
+	if err := mod.Enable(ctx); err != nil {
+		t.Fatalf("Enable: %v", err)
+	}
+
+	list := lesson.NewWordList()
+	list.Title = "Animals"
+	list.AddWordItem([]string{"house"}, []string{"huis"}, "")
+	list.AddDirectedResult(lesson.Card{Index: 0}, lesson.Grade{Correct: false}, 3*time.Second)
+	list.AddDirectedResult(lesson.Card{Index: 0}, lesson.Grade{Correct: true}, time.Second)
+	// Lessons without a title are logged as well
+	untitled := lesson.NewWordList()
+	untitled.AddWordItem([]string{"pear"}, []string{"peer"}, "")
+	untitled.AddTestResult(0, "wrong")
+
+	lessons, err := mod.Lessons()
+	if err != nil || len(lessons) != 2 || lessons[0] != "Animals" || lessons[1] != UntitledLesson {
+		t.Errorf("unexpected lessons %v (%v)", lessons, err)
+	}
+	hardest, err := mod.Hardest("Animals", 5)
+	if err != nil || len(hardest) != 1 || hardest[0].Question != "house" || hardest[0].AnswerTime != 2*time.Second {
+		t.Errorf("unexpected hardest items %+v (%v)", hardest, err)
+	}
+	forecast, err := mod.Forecast("", 2)
+	if err != nil || forecast[1] != 2 {
+		t.Errorf("expected both words due tomorrow, got %v (%v)", forecast, err)
+	}
+
+	// The answers are found again after the lesson was exported to a format
+	// that renumbered its items and dropped the results
+	if list.UUID == "" {
+		t.Fatal("expected the lesson to get a UUID")
+	}
+	exported := lesson.NewWordList()
+	exported.UUID = list.UUID
+	exported.AddWordItem([]string{"dog"}, []string{"hond"}, "")
+	exported.AddWordItem([]string{"house"}, []string{"huis"}, "")
+	history, err := mod.ItemHistory(exported, &exported.Items[1])
+	if err != nil || len(history) != 2 || history[0].Right || !history[1].Right || history[1].ItemID != 0 {
+		t.Errorf("unexpected history %+v (%v)", history, err)
+	}
+
+	// Answers given on another device are merged in once
+	other := NewReviewHistoryModule()
+	other.dbPath = filepath.Join(t.TempDir(), "reviews.db")
+	other.Reviewed(list, lesson.TestResult{ItemID: 0, Result: "right"})
+	cursor, err := mod.Cursor()
+	if err != nil {
+		t.Fatalf("Cursor: %v", err)
+	}
+	missing, err := other.Diff(cursor)
+	if err != nil || len(missing) != 1 {
+		t.Fatalf("expected one answer from the other device, got %+v (%v)", missing, err)
+	}
+	for range 2 {
+		mod.Merge(missing)
+	}
+	if count, err := mod.Count("Animals"); err != nil || count != 3 {
+		t.Errorf("expected 3 answers to Animals after merging, got %d (%v)", count, err)
+	}
+	other.Disable(ctx)
+
+	if err := mod.Disable(ctx); err != nil {
+		t.Fatalf("Disable: %v", err)
+	}
+	list.AddTestResult(0, "right")
+	if count, err := mod.Count(""); err != nil || count != 4 {
+		t.Errorf("expected no answers logged after disabling, got %d (%v)", count, err)
+	}
+}
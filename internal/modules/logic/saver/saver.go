@@ -11,7 +11,9 @@ package saver
 import (
 	"context"
 	"fmt"
+
 	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
 )
 
 // SaverModule is a Go port of the Python SaverModule class
@@ -40,6 +42,18 @@ func (mod *SaverModule) Enable(ctx context.Context) error {
 
 	// TODO: Port Python enable logic
 
+	// Saving keeps as many .bak copies as the settings say, and embeds or
+	// links media as they say
+	if mod.manager != nil {
+		if module, ok := mod.manager.GetDefaultModule("settings"); ok {
+			if settings, ok := module.(lesson.ToleranceSettings); ok {
+				lesson.LoadDefaultBackups(settings)
+				lesson.LoadDefaultMediaStorage(settings)
+				lesson.LoadDefaultImageCompression(settings)
+			}
+		}
+	}
+
 	fmt.Println("SaverModule enabled")
 	return nil
 }
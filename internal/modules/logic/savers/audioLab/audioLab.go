@@ -0,0 +1,322 @@
+// Package audiolab exports lessons as an audio "language lab": one numbered
+// MP3 per item saying the question, pausing, then saying the answer, plus an
+// M3U playlist so students can review while commuting.
+package audiolab
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// Synthesizer renders text to a WAV file, e.g. the tts module
+type Synthesizer interface {
+	SynthesizeToFile(text, language, wavPath string) error
+}
+
+// Options controls the generated audio
+type Options struct {
+	// Pause is the silence between question and answer
+	Pause time.Duration
+	// Encoder is the command used to produce MP3s: "ffmpeg", "lame", or
+	// "none" to keep WAV files. Empty picks the first one available.
+	Encoder string
+}
+
+// DefaultOptions returns a three second pause and automatic encoder detection
+func DefaultOptions() Options {
+	return Options{Pause: 3 * time.Second}
+}
+
+// AudioLabSaverModule exports lessons as numbered audio files and a playlist
+type AudioLabSaverModule struct {
+	*core.BaseModule
+	manager     *core.Manager
+	synthesizer Synthesizer
+	fileSaver   *lesson.FileSaver
+}
+
+// NewAudioLabSaverModule creates a new AudioLabSaverModule instance
+func NewAudioLabSaverModule() *AudioLabSaverModule {
+	base := core.NewBaseModule("logic", "audiolab-saver-module")
+	base.SetUses("tts")
+
+	return &AudioLabSaverModule{
+		BaseModule: base,
+		fileSaver:  lesson.NewFileSaver(),
+	}
+}
+
+// SetSynthesizer overrides the speech synthesizer found through the manager
+func (mod *AudioLabSaverModule) SetSynthesizer(synthesizer Synthesizer) {
+	mod.synthesizer = synthesizer
+}
+
+// getSynthesizer returns the configured synthesizer or the default tts module
+func (mod *AudioLabSaverModule) getSynthesizer() (Synthesizer, error) {
+	if mod.synthesizer != nil {
+		return mod.synthesizer, nil
+	}
+	if mod.manager != nil {
+		if ttsModule, exists := mod.manager.GetDefaultModule("tts"); exists {
+			if synthesizer, ok := ttsModule.(Synthesizer); ok {
+				return synthesizer, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no text-to-speech module available for audio export")
+}
+
+// Export writes one audio file per item into dir and returns the playlist path
+func (mod *AudioLabSaverModule) Export(lessonData *lesson.LessonData, dir string, opts Options) (string, error) {
+	log.Printf("[ACTION] AudioLabSaverModule.Export() - exporting audio to %s", dir)
+
+	if err := mod.fileSaver.ValidateLessonData(lessonData); err != nil {
+		return "", err
+	}
+
+	synthesizer, err := mod.getSynthesizer()
+	if err != nil {
+		return "", err
+	}
+
+	encoder := resolveEncoder(opts.Encoder)
+	ext := ".mp3"
+	if encoder == "none" {
+		log.Printf("[WARNING] No MP3 encoder found (ffmpeg or lame), writing WAV files")
+		ext = ".wav"
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "recuerdo-audiolab")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var playlist strings.Builder
+	playlist.WriteString("#EXTM3U\n")
+
+	number := 0
+	for _, item := range lessonData.List.Items {
+		question := strings.Join(item.Questions, ", ")
+		answer := strings.Join(item.Answers, ", ")
+		if strings.TrimSpace(question) == "" || strings.TrimSpace(answer) == "" {
+			continue
+		}
+		number++
+
+		questionWav := filepath.Join(tmpDir, "question.wav")
+		answerWav := filepath.Join(tmpDir, "answer.wav")
+		if err := synthesizer.SynthesizeToFile(question, lessonData.List.QuestionLanguage, questionWav); err != nil {
+			return "", fmt.Errorf("synthesizing question %d: %w", number, err)
+		}
+		if err := synthesizer.SynthesizeToFile(answer, lessonData.List.AnswerLanguage, answerWav); err != nil {
+			return "", fmt.Errorf("synthesizing answer %d: %w", number, err)
+		}
+
+		combined, duration, err := joinWithPause(questionWav, answerWav, opts.Pause)
+		if err != nil {
+			return "", fmt.Errorf("combining audio for item %d: %w", number, err)
+		}
+
+		name := fmt.Sprintf("%03d%s", number, ext)
+		target := filepath.Join(dir, name)
+		if encoder == "none" {
+			err = os.WriteFile(target, combined, 0644)
+		} else {
+			combinedWav := filepath.Join(tmpDir, "combined.wav")
+			if err = os.WriteFile(combinedWav, combined, 0644); err == nil {
+				err = encodeMP3(encoder, combinedWav, target)
+			}
+		}
+		if err != nil {
+			return "", fmt.Errorf("writing %s: %w", name, err)
+		}
+
+		fmt.Fprintf(&playlist, "#EXTINF:%d,%s - %s\n%s\n", int(duration.Round(time.Second)/time.Second),
+			strings.ReplaceAll(question, "\n", " "), strings.ReplaceAll(answer, "\n", " "), name)
+	}
+
+	playlistPath := filepath.Join(dir, mod.fileSaver.GetDefaultFilename(lessonData, ".m3u"))
+	if err := os.WriteFile(playlistPath, []byte(playlist.String()), 0644); err != nil {
+		log.Printf("[ERROR] Failed to write playlist: %v", err)
+		return "", err
+	}
+
+	log.Printf("[SUCCESS] AudioLabSaverModule.Export() - wrote %d audio files", number)
+	return playlistPath, nil
+}
+
+// resolveEncoder picks the MP3 encoder to use
+func resolveEncoder(preferred string) string {
+	if preferred != "" {
+		return preferred
+	}
+	for _, candidate := range []string{"ffmpeg", "lame"} {
+		if _, err := exec.LookPath(candidate); err == nil {
+			return candidate
+		}
+	}
+	return "none"
+}
+
+// encodeMP3 converts a WAV file to MP3 with the given encoder
+func encodeMP3(encoder, wavPath, mp3Path string) error {
+	var cmd *exec.Cmd
+	switch encoder {
+	case "ffmpeg":
+		cmd = exec.Command("ffmpeg", "-y", "-loglevel", "error", "-i", wavPath, "-codec:a", "libmp3lame", "-q:a", "5", mp3Path)
+	case "lame":
+		cmd = exec.Command("lame", "--quiet", "-V", "5", wavPath, mp3Path)
+	default:
+		return fmt.Errorf("unsupported MP3 encoder: %s", encoder)
+	}
+	return cmd.Run()
+}
+
+// wavFormat is the part of a WAV "fmt " chunk needed to join files
+type wavFormat struct {
+	AudioFormat   uint16
+	Channels      uint16
+	SampleRate    uint32
+	ByteRate      uint32
+	BlockAlign    uint16
+	BitsPerSample uint16
+}
+
+// readWav returns the format and PCM data of a WAV file
+func readWav(path string) (wavFormat, []byte, error) {
+	var format wavFormat
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return format, nil, err
+	}
+	if len(content) < 12 || string(content[0:4]) != "RIFF" || string(content[8:12]) != "WAVE" {
+		return format, nil, fmt.Errorf("%s is not a WAV file", filepath.Base(path))
+	}
+
+	var data []byte
+	haveFormat := false
+	for pos := 12; pos+8 <= len(content); {
+		id := string(content[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(content[pos+4 : pos+8]))
+		start := pos + 8
+		end := start + size
+		// Streamed output may leave the size unset; take the rest of the file
+		if end > len(content) || size == 0xFFFFFFFF {
+			end = len(content)
+		}
+
+		switch id {
+		case "fmt ":
+			if err := binary.Read(bytes.NewReader(content[start:end]), binary.LittleEndian, &format); err != nil {
+				return format, nil, err
+			}
+			haveFormat = true
+		case "data":
+			data = content[start:end]
+		}
+
+		pos = end + (end-start)%2
+	}
+
+	if !haveFormat || data == nil {
+		return format, nil, fmt.Errorf("%s has no audio data", filepath.Base(path))
+	}
+	if format.AudioFormat != 1 {
+		return format, nil, fmt.Errorf("%s is not PCM audio", filepath.Base(path))
+	}
+	return format, data, nil
+}
+
+// joinWithPause concatenates two WAV files with silence in between and
+// returns the resulting WAV file contents and its duration
+func joinWithPause(firstPath, secondPath string, pause time.Duration) ([]byte, time.Duration, error) {
+	format, first, err := readWav(firstPath)
+	if err != nil {
+		return nil, 0, err
+	}
+	secondFormat, second, err := readWav(secondPath)
+	if err != nil {
+		return nil, 0, err
+	}
+	if format != secondFormat {
+		return nil, 0, fmt.Errorf("question and answer audio use different formats")
+	}
+
+	silenceFrames := int(pause.Seconds() * float64(format.SampleRate))
+	silence := make([]byte, silenceFrames*int(format.BlockAlign))
+	if format.BitsPerSample == 8 {
+		// Unsigned 8-bit PCM is silent at the midpoint
+		for i := range silence {
+			silence[i] = 0x80
+		}
+	}
+
+	// Keep the data chunk aligned to whole frames
+	first = first[:len(first)-len(first)%int(format.BlockAlign)]
+
+	data := make([]byte, 0, len(first)+len(silence)+len(second))
+	data = append(data, first...)
+	data = append(data, silence...)
+	data = append(data, second...)
+
+	var out bytes.Buffer
+	out.WriteString("RIFF")
+	binary.Write(&out, binary.LittleEndian, uint32(4+8+16+8+len(data)))
+	out.WriteString("WAVE")
+	out.WriteString("fmt ")
+	binary.Write(&out, binary.LittleEndian, uint32(16))
+	binary.Write(&out, binary.LittleEndian, format)
+	out.WriteString("data")
+	binary.Write(&out, binary.LittleEndian, uint32(len(data)))
+	out.Write(data)
+
+	duration := time.Duration(float64(len(data)) / float64(format.ByteRate) * float64(time.Second))
+	return out.Bytes(), duration, nil
+}
+
+// Enable activates the module
+func (mod *AudioLabSaverModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("AudioLabSaverModule enabled")
+	return nil
+}
+
+// Disable deactivates the module
+func (mod *AudioLabSaverModule) Disable(ctx context.Context) error {
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("AudioLabSaverModule disabled")
+	return nil
+}
+
+// SetManager sets the module manager
+func (mod *AudioLabSaverModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// InitAudioLabSaverModule creates and returns a new AudioLabSaverModule instance
+func InitAudioLabSaverModule() core.Module {
+	return NewAudioLabSaverModule()
+}
@@ -0,0 +1,93 @@
+package audiolab
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// fakeSynthesizer writes one second of 8 kHz mono 16-bit audio per call
+type fakeSynthesizer struct {
+	calls []string
+}
+
+func (f *fakeSynthesizer) SynthesizeToFile(text, language, wavPath string) error {
+	f.calls = append(f.calls, language+":"+text)
+
+	format := wavFormat{AudioFormat: 1, Channels: 1, SampleRate: 8000, ByteRate: 16000, BlockAlign: 2, BitsPerSample: 16}
+	data := bytes.Repeat([]byte{0x10, 0x00}, 8000)
+
+	var out bytes.Buffer
+	out.WriteString("RIFF")
+	binary.Write(&out, binary.LittleEndian, uint32(36+len(data)))
+	out.WriteString("WAVEfmt ")
+	binary.Write(&out, binary.LittleEndian, uint32(16))
+	binary.Write(&out, binary.LittleEndian, format)
+	out.WriteString("data")
+	binary.Write(&out, binary.LittleEndian, uint32(len(data)))
+	out.Write(data)
+	return os.WriteFile(wavPath, out.Bytes(), 0644)
+}
+
+func TestAudioLabExport(t *testing.T) {
+	lessonData := lesson.NewLessonData()
+	lessonData.List.Title = "Colours"
+	lessonData.List.QuestionLanguage = "en"
+	lessonData.List.AnswerLanguage = "es"
+	lessonData.List.AddWordItem([]string{"red"}, []string{"rojo"}, "")
+	lessonData.List.AddWordItem([]string{"blue"}, []string{"azul", "celeste"}, "")
+
+	synthesizer := &fakeSynthesizer{}
+	mod := NewAudioLabSaverModule()
+	mod.SetSynthesizer(synthesizer)
+
+	dir := t.TempDir()
+	playlistPath, err := mod.Export(lessonData, dir, Options{Pause: 2 * time.Second, Encoder: "none"})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	if filepath.Base(playlistPath) != "Colours.m3u" {
+		t.Errorf("Unexpected playlist name %s", playlistPath)
+	}
+
+	expectedCalls := []string{"en:red", "es:rojo", "en:blue", "es:azul, celeste"}
+	if strings.Join(synthesizer.calls, "|") != strings.Join(expectedCalls, "|") {
+		t.Errorf("Unexpected synthesizer calls: %v", synthesizer.calls)
+	}
+
+	playlist, err := os.ReadFile(playlistPath)
+	if err != nil {
+		t.Fatalf("Failed to read playlist: %v", err)
+	}
+	expectedPlaylist := "#EXTM3U\n#EXTINF:4,red - rojo\n001.wav\n#EXTINF:4,blue - azul, celeste\n002.wav\n"
+	if string(playlist) != expectedPlaylist {
+		t.Errorf("Unexpected playlist:\n%s", playlist)
+	}
+
+	format, data, err := readWav(filepath.Join(dir, "001.wav"))
+	if err != nil {
+		t.Fatalf("Failed to read exported audio: %v", err)
+	}
+	if format.SampleRate != 8000 || len(data) != 4*16000 {
+		t.Errorf("Expected 4 seconds of 8 kHz audio, got %d bytes at %d Hz", len(data), format.SampleRate)
+	}
+	if data[0] != 0x10 || data[16000] != 0 || data[16000*3] != 0x10 {
+		t.Error("Expected question, silence, then answer audio")
+	}
+}
+
+func TestAudioLabExportWithoutSynthesizer(t *testing.T) {
+	lessonData := lesson.NewLessonData()
+	lessonData.List.AddWordItem([]string{"red"}, []string{"rojo"}, "")
+
+	if _, err := NewAudioLabSaverModule().Export(lessonData, t.TempDir(), DefaultOptions()); err == nil {
+		t.Error("Expected an error when no text-to-speech module is available")
+	}
+}
@@ -109,6 +109,12 @@ func (mod *CsvSaverModule) GetDescription() string {
 	return "Exports lesson data as comma-separated values suitable for spreadsheet applications like Excel, LibreOffice Calc, and Google Sheets."
 }
 
+// GetSaveOptions returns the choices saving as CSV offers, such as the
+// delimiter and the encoding
+func (mod *CsvSaverModule) GetSaveOptions() []lesson.SaveOption {
+	return mod.fileSaver.GetSaveOptions(".csv")
+}
+
 // ValidateBeforeSave performs format-specific validation before saving
 func (mod *CsvSaverModule) ValidateBeforeSave(lessonData *lesson.LessonData) error {
 	// Use the centralized validation
@@ -1,19 +1,25 @@
-// Package libreofficeformats provides functionality ported from Python module
-//
-// This is an automated port - implementation may be incomplete.
+// Package libreofficeformats provides spreadsheet export in the formats of
+// LibreOffice Calc and Excel using the centralized FileSaver. The Python
+// module converted through a headless soffice; the workbooks are now
+// written directly, so no office suite needs to be installed.
 package libreofficeformats
 
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"strings"
+
 	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
 )
 
-// LibreofficeFormatsSaverModule is a Go port of the Python LibreofficeFormatsSaverModule class
+// LibreofficeFormatsSaverModule provides ODS and XLSX export functionality
 type LibreofficeFormatsSaverModule struct {
 	*core.BaseModule
-	manager *core.Manager
-	// TODO: Add module-specific fields
+	manager   *core.Manager
+	fileSaver *lesson.FileSaver
+	active    bool
 }
 
 // NewLibreofficeFormatsSaverModule creates a new LibreofficeFormatsSaverModule instance
@@ -22,46 +28,29 @@ func NewLibreofficeFormatsSaverModule() *LibreofficeFormatsSaverModule {
 
 	return &LibreofficeFormatsSaverModule{
 		BaseModule: base,
+		fileSaver:  lesson.NewFileSaver(),
+		active:     false,
 	}
 }
 
-// retranslate is the Go port of the Python _retranslate method
-func (mod *LibreofficeFormatsSaverModule) retranslate() {
-	// TODO: Port Python method logic
-}
-
-// tointerimfile is the Go port of the Python _toInterimFile method
-func (mod *LibreofficeFormatsSaverModule) tointerimfile() {
-	// TODO: Port Python method logic
-}
-
-// Save is the Go port of the Python save method
-func (mod *LibreofficeFormatsSaverModule) Save() {
-	// TODO: Port Python method logic
-}
-
 // Enable activates the module
-// This is the Go equivalent of the Python enable method
 func (mod *LibreofficeFormatsSaverModule) Enable(ctx context.Context) error {
 	if err := mod.BaseModule.Enable(ctx); err != nil {
 		return err
 	}
 
-	// TODO: Port Python enable logic
-
+	mod.active = true
 	fmt.Println("LibreofficeFormatsSaverModule enabled")
 	return nil
 }
 
 // Disable deactivates the module
-// This is the Go equivalent of the Python disable method
 func (mod *LibreofficeFormatsSaverModule) Disable(ctx context.Context) error {
 	if err := mod.BaseModule.Disable(ctx); err != nil {
 		return err
 	}
 
-	// TODO: Port Python disable logic
-
+	mod.active = false
 	fmt.Println("LibreofficeFormatsSaverModule disabled")
 	return nil
 }
@@ -71,8 +60,85 @@ func (mod *LibreofficeFormatsSaverModule) SetManager(manager *core.Manager) {
 	mod.manager = manager
 }
 
+// GetType returns the module type
+func (mod *LibreofficeFormatsSaverModule) GetType() string {
+	return "save"
+}
+
+// GetSaveFormats returns the formats this module can save
+func (mod *LibreofficeFormatsSaverModule) GetSaveFormats() map[string]string {
+	return map[string]string{
+		"ods":  "OpenDocument Spreadsheet",
+		"xlsx": "Office Open XML Spreadsheet",
+	}
+}
+
+// CanSave checks if this module can save the given lesson type to the specified format
+func (mod *LibreofficeFormatsSaverModule) CanSave(lessonType string, format string) bool {
+	if !mod.active {
+		return false
+	}
+
+	// Spreadsheets hold words lessons only
+	_, ok := mod.GetSaveFormats()[format]
+	return lessonType == "words" && ok
+}
+
+// Save saves the lesson data to the specified path as a spreadsheet in the
+// format of its extension
+func (mod *LibreofficeFormatsSaverModule) Save(lessonData *lesson.LessonData, filePath string) error {
+	if !mod.active {
+		return fmt.Errorf("LibreOffice formats saver module is not active")
+	}
+
+	// Validate file extension
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if _, ok := mod.GetSaveFormats()[strings.TrimPrefix(ext, ".")]; !ok {
+		return fmt.Errorf("LibreOffice formats saver can only save .ods and .xlsx files, got %s", ext)
+	}
+
+	// Use centralized file saver
+	return mod.fileSaver.SaveWithValidation(lessonData, filePath)
+}
+
+// GetDefaultExtension returns the default file extension for this saver
+func (mod *LibreofficeFormatsSaverModule) GetDefaultExtension() string {
+	return ".ods"
+}
+
+// GetFileFilter returns Qt-style file filter for these formats
+func (mod *LibreofficeFormatsSaverModule) GetFileFilter() string {
+	return "OpenDocument Spreadsheets (*.ods);;Excel Workbooks (*.xlsx)"
+}
+
+// GetDescription returns a description of the spreadsheet formats
+func (mod *LibreofficeFormatsSaverModule) GetDescription() string {
+	return "Exports lesson data as a spreadsheet with the columns of the CSV export under a header row, readable by LibreOffice Calc, Excel and Google Sheets."
+}
+
+// ValidateBeforeSave performs format-specific validation before saving
+func (mod *LibreofficeFormatsSaverModule) ValidateBeforeSave(lessonData *lesson.LessonData) error {
+	// Use the centralized validation
+	return mod.fileSaver.ValidateLessonData(lessonData)
+}
+
+// GetSuggestedFilename returns a suggested filename for the lesson
+func (mod *LibreofficeFormatsSaverModule) GetSuggestedFilename(lessonData *lesson.LessonData) string {
+	return mod.fileSaver.GetDefaultFilename(lessonData, ".ods")
+}
+
+// IsActive returns whether the module is currently active
+func (mod *LibreofficeFormatsSaverModule) IsActive() bool {
+	return mod.active
+}
+
+// GetPriority returns the priority of this saver (higher = preferred)
+func (mod *LibreofficeFormatsSaverModule) GetPriority() int {
+	return 621
+}
+
 // InitLibreofficeFormatsSaverModule creates and returns a new LibreofficeFormatsSaverModule instance
 // This is the Go equivalent of the Python init function
 func InitLibreofficeFormatsSaverModule() core.Module {
 	return NewLibreofficeFormatsSaverModule()
-}
\ No newline at end of file
+}
@@ -0,0 +1,137 @@
+// Package mnemosyne provides Mnemosyne .mem and .cards export functionality using the centralized FileSaver
+package mnemosyne
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// MnemosyneSaverModule provides Mnemosyne export functionality
+type MnemosyneSaverModule struct {
+	*core.BaseModule
+	manager   *core.Manager
+	fileSaver *lesson.FileSaver
+	active    bool
+}
+
+// NewMnemosyneSaverModule creates a new MnemosyneSaverModule instance
+func NewMnemosyneSaverModule() *MnemosyneSaverModule {
+	base := core.NewBaseModule("logic", "mnemosyne-saver-module")
+
+	return &MnemosyneSaverModule{
+		BaseModule: base,
+		fileSaver:  lesson.NewFileSaver(),
+		active:     false,
+	}
+}
+
+// Enable activates the module
+func (mod *MnemosyneSaverModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	mod.active = true
+	fmt.Println("MnemosyneSaverModule enabled")
+	return nil
+}
+
+// Disable deactivates the module
+func (mod *MnemosyneSaverModule) Disable(ctx context.Context) error {
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	mod.active = false
+	fmt.Println("MnemosyneSaverModule disabled")
+	return nil
+}
+
+// SetManager sets the module manager
+func (mod *MnemosyneSaverModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// GetType returns the module type
+func (mod *MnemosyneSaverModule) GetType() string {
+	return "save"
+}
+
+// GetSaveFormats returns the formats this module can save
+func (mod *MnemosyneSaverModule) GetSaveFormats() map[string]string {
+	return map[string]string{
+		"cards": "Mnemosyne 2.x Cards",
+		"mem":   "Mnemosyne 1.x Export",
+	}
+}
+
+// CanSave checks if this module can save the given lesson type to the specified format
+func (mod *MnemosyneSaverModule) CanSave(lessonType string, format string) bool {
+	if !mod.active {
+		return false
+	}
+
+	// Mnemosyne cards are plain front/back facts
+	return lessonType == "words" && (format == "cards" || format == "mem")
+}
+
+// Save saves the lesson data to the specified path in Mnemosyne format
+func (mod *MnemosyneSaverModule) Save(lessonData *lesson.LessonData, filePath string) error {
+	if !mod.active {
+		return fmt.Errorf("Mnemosyne saver module is not active")
+	}
+
+	// Validate file extension
+	ext := filepath.Ext(filePath)
+	if ext != ".cards" && ext != ".mem" {
+		return fmt.Errorf("Mnemosyne saver can only save .cards or .mem files, got %s", ext)
+	}
+
+	// Use centralized file saver
+	return mod.fileSaver.SaveWithValidation(lessonData, filePath)
+}
+
+// GetDefaultExtension returns the default file extension for this saver
+func (mod *MnemosyneSaverModule) GetDefaultExtension() string {
+	return ".cards"
+}
+
+// GetFileFilter returns Qt-style file filter for this format
+func (mod *MnemosyneSaverModule) GetFileFilter() string {
+	return "Mnemosyne Cards (*.cards);;Mnemosyne 1.x Export (*.mem)"
+}
+
+// GetDescription returns a description of the Mnemosyne format
+func (mod *MnemosyneSaverModule) GetDescription() string {
+	return "Exports lesson data as new Mnemosyne cards tagged with the lesson title, as a Mnemosyne 2.x .cards file or a Mnemosyne 1.x XML export."
+}
+
+// ValidateBeforeSave performs format-specific validation before saving
+func (mod *MnemosyneSaverModule) ValidateBeforeSave(lessonData *lesson.LessonData) error {
+	// Use the centralized validation
+	return mod.fileSaver.ValidateLessonData(lessonData)
+}
+
+// GetSuggestedFilename returns a suggested filename for the lesson
+func (mod *MnemosyneSaverModule) GetSuggestedFilename(lessonData *lesson.LessonData) string {
+	return mod.fileSaver.GetDefaultFilename(lessonData, ".cards")
+}
+
+// IsActive returns whether the module is currently active
+func (mod *MnemosyneSaverModule) IsActive() bool {
+	return mod.active
+}
+
+// GetPriority returns the priority of this saver (higher = preferred)
+func (mod *MnemosyneSaverModule) GetPriority() int {
+	return 850
+}
+
+// InitMnemosyneSaverModule creates and returns a new MnemosyneSaverModule instance
+func InitMnemosyneSaverModule() core.Module {
+	return NewMnemosyneSaverModule()
+}
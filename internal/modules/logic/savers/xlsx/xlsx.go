@@ -0,0 +1,136 @@
+// Package xlsx provides Excel workbook export functionality using the centralized FileSaver
+package xlsx
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// XlsxSaverModule provides XLSX export functionality
+type XlsxSaverModule struct {
+	*core.BaseModule
+	manager   *core.Manager
+	fileSaver *lesson.FileSaver
+	active    bool
+}
+
+// NewXlsxSaverModule creates a new XlsxSaverModule instance
+func NewXlsxSaverModule() *XlsxSaverModule {
+	base := core.NewBaseModule("logic", "xlsx-saver-module")
+
+	return &XlsxSaverModule{
+		BaseModule: base,
+		fileSaver:  lesson.NewFileSaver(),
+		active:     false,
+	}
+}
+
+// Enable activates the module
+func (mod *XlsxSaverModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	mod.active = true
+	fmt.Println("XlsxSaverModule enabled")
+	return nil
+}
+
+// Disable deactivates the module
+func (mod *XlsxSaverModule) Disable(ctx context.Context) error {
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	mod.active = false
+	fmt.Println("XlsxSaverModule disabled")
+	return nil
+}
+
+// SetManager sets the module manager
+func (mod *XlsxSaverModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// GetType returns the module type
+func (mod *XlsxSaverModule) GetType() string {
+	return "save"
+}
+
+// GetSaveFormats returns the formats this module can save
+func (mod *XlsxSaverModule) GetSaveFormats() map[string]string {
+	return map[string]string{
+		"xlsx": "Excel Workbook",
+	}
+}
+
+// CanSave checks if this module can save the given lesson type to the specified format
+func (mod *XlsxSaverModule) CanSave(lessonType string, format string) bool {
+	if !mod.active {
+		return false
+	}
+
+	// XLSX format supports words lesson type
+	return lessonType == "words" && format == "xlsx"
+}
+
+// Save saves the lesson data to the specified path in XLSX format
+func (mod *XlsxSaverModule) Save(lessonData *lesson.LessonData, filePath string) error {
+	if !mod.active {
+		return fmt.Errorf("XLSX saver module is not active")
+	}
+
+	// Validate file extension
+	ext := filepath.Ext(filePath)
+	if ext != ".xlsx" {
+		return fmt.Errorf("XLSX saver can only save .xlsx files, got %s", ext)
+	}
+
+	// Use centralized file saver
+	return mod.fileSaver.SaveWithValidation(lessonData, filePath)
+}
+
+// GetDefaultExtension returns the default file extension for this saver
+func (mod *XlsxSaverModule) GetDefaultExtension() string {
+	return ".xlsx"
+}
+
+// GetFileFilter returns Qt-style file filter for this format
+func (mod *XlsxSaverModule) GetFileFilter() string {
+	return "Excel Workbooks (*.xlsx)"
+}
+
+// GetDescription returns a description of the XLSX format
+func (mod *XlsxSaverModule) GetDescription() string {
+	return "Exports lesson data as an Excel workbook with question, answer and comment columns, readable by Excel, LibreOffice Calc and Google Sheets."
+}
+
+// ValidateBeforeSave performs format-specific validation before saving
+func (mod *XlsxSaverModule) ValidateBeforeSave(lessonData *lesson.LessonData) error {
+	// Use the centralized validation
+	return mod.fileSaver.ValidateLessonData(lessonData)
+}
+
+// GetSuggestedFilename returns a suggested filename for the lesson
+func (mod *XlsxSaverModule) GetSuggestedFilename(lessonData *lesson.LessonData) string {
+	return mod.fileSaver.GetDefaultFilename(lessonData, ".xlsx")
+}
+
+// IsActive returns whether the module is currently active
+func (mod *XlsxSaverModule) IsActive() bool {
+	return mod.active
+}
+
+// GetPriority returns the priority of this saver (higher = preferred)
+func (mod *XlsxSaverModule) GetPriority() int {
+	return 920
+}
+
+// InitXlsxSaverModule creates and returns a new XlsxSaverModule instance
+func InitXlsxSaverModule() core.Module {
+	return NewXlsxSaverModule()
+}
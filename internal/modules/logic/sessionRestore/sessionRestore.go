@@ -0,0 +1,236 @@
+// Package sessionrestore remembers which lessons were open, and where the
+// user was in a practice session, so they come back when Recuerdo starts
+// again.
+//
+// The state is kept as JSON in the settings module. Lessons that were never
+// saved cannot be opened again; the autosave module keeps those.
+package sessionrestore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+)
+
+// StateSetting is the settings key the state is kept in, as JSON
+const StateSetting = "session.state"
+
+// RestoreSetting is the settings key turning reopening lessons on or off;
+// it is on when unset
+const RestoreSetting = "session.restore"
+
+// Question is a question of a practice session
+type Question struct {
+	// Item is the index of the item in the lesson
+	Item int `json:"item"`
+	// Cloze is the number of the cloze deletion asked, or 0 when the item
+	// is asked as a whole
+	Cloze int `json:"cloze,omitempty"`
+	// Reversed is set when the item is asked the other way round
+	Reversed bool `json:"reversed,omitempty"`
+}
+
+// Answer is an answer given in a practice session
+type Answer struct {
+	Item    int     `json:"item"`
+	Answer  string  `json:"answer"`
+	Correct bool    `json:"correct"`
+	Credit  float64 `json:"credit,omitempty"`
+}
+
+// Practice is a practice session in progress
+type Practice struct {
+	// Questions are all questions of the session, in the order asked
+	Questions []Question `json:"questions"`
+	// Answers are the answers given so far; the next question asked is
+	// Questions[len(Answers)]
+	Answers []Answer `json:"answers"`
+}
+
+// Correct returns the number of right answers given so far
+func (p Practice) Correct() int {
+	correct := 0
+	for _, answer := range p.Answers {
+		if answer.Correct {
+			correct++
+		}
+	}
+	return correct
+}
+
+// Valid reports whether the session can be resumed in a lesson with items
+// items: it has questions left and asks only items the lesson has
+func (p Practice) Valid(items int) bool {
+	if len(p.Answers) >= len(p.Questions) {
+		return false
+	}
+	for _, question := range p.Questions {
+		if question.Item < 0 || question.Item >= items {
+			return false
+		}
+	}
+	for _, answer := range p.Answers {
+		if answer.Item < 0 || answer.Item >= items {
+			return false
+		}
+	}
+	return true
+}
+
+// Tab is a lesson open in a tab
+type Tab struct {
+	Path       string `json:"path"`
+	LessonType string `json:"lessonType"`
+	// Page is the page shown in the lesson, such as the Teach page
+	Page int `json:"page"`
+	// Practice is the practice session in progress, if any
+	Practice *Practice `json:"practice,omitempty"`
+}
+
+// State is what was open when Recuerdo closed
+type State struct {
+	Tabs []Tab `json:"tabs"`
+	// Current is the index of the tab that was shown
+	Current int `json:"current"`
+}
+
+// Settings is the part of the settings module the state is kept in
+type Settings interface {
+	GetBool(key string) (bool, error)
+	GetString(key string) (string, error)
+	SetSetting(key string, value interface{}) error
+}
+
+// SessionRestoreModule keeps the open lessons between runs
+type SessionRestoreModule struct {
+	*core.BaseModule
+	manager  *core.Manager
+	settings Settings
+}
+
+// NewSessionRestoreModule creates a new SessionRestoreModule instance
+func NewSessionRestoreModule() *SessionRestoreModule {
+	base := core.NewBaseModule("sessionRestore", "sessionrestore-module")
+
+	return &SessionRestoreModule{
+		BaseModule: base,
+	}
+}
+
+// SetSettings sets where the state is kept; by default that is the
+// settings module
+func (mod *SessionRestoreModule) SetSettings(settings Settings) {
+	mod.settings = settings
+}
+
+// currentSettings returns where the state is kept, looking up the settings
+// module on first use: the GUI may restore the session before this module
+// is enabled
+func (mod *SessionRestoreModule) currentSettings() Settings {
+	if mod.settings == nil && mod.manager != nil {
+		if module, ok := mod.manager.GetDefaultModule("settings"); ok {
+			mod.settings, _ = module.(Settings)
+		}
+	}
+	return mod.settings
+}
+
+// Save remembers the open lessons, leaving out those never saved, and
+// writes the settings
+func (mod *SessionRestoreModule) Save(state State) error {
+	settings := mod.currentSettings()
+	if settings == nil {
+		return fmt.Errorf("no settings to keep the session in")
+	}
+	saved := State{Tabs: []Tab{}}
+	for i, tab := range state.Tabs {
+		if tab.Path == "" || strings.HasPrefix(tab.Path, "*") {
+			continue
+		}
+		if i == state.Current {
+			saved.Current = len(saved.Tabs)
+		}
+		saved.Tabs = append(saved.Tabs, tab)
+	}
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	if err := settings.SetSetting(StateSetting, string(data)); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	if saver, ok := settings.(interface{ SaveSettings() error }); ok {
+		if err := saver.SaveSettings(); err != nil {
+			return fmt.Errorf("failed to save session: %w", err)
+		}
+	}
+	return nil
+}
+
+// Load returns the lessons to open again, leaving out files that are gone.
+// It returns false when there are none or reopening them is turned off.
+func (mod *SessionRestoreModule) Load() (State, bool) {
+	settings := mod.currentSettings()
+	if settings == nil {
+		return State{}, false
+	}
+	if restore, err := settings.GetBool(RestoreSetting); err == nil && !restore {
+		return State{}, false
+	}
+	data, err := settings.GetString(StateSetting)
+	if err != nil || data == "" {
+		return State{}, false
+	}
+	var saved State
+	if err := json.Unmarshal([]byte(data), &saved); err != nil {
+		fmt.Printf("Warning: ignoring unreadable session: %v\n", err)
+		return State{}, false
+	}
+
+	state := State{}
+	for i, tab := range saved.Tabs {
+		if _, err := os.Stat(tab.Path); err != nil {
+			continue
+		}
+		if i == saved.Current {
+			state.Current = len(state.Tabs)
+		}
+		state.Tabs = append(state.Tabs, tab)
+	}
+	return state, len(state.Tabs) > 0
+}
+
+// Enable activates the module
+func (mod *SessionRestoreModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("SessionRestoreModule enabled")
+	return nil
+}
+
+// Disable deactivates the module
+func (mod *SessionRestoreModule) Disable(ctx context.Context) error {
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("SessionRestoreModule disabled")
+	return nil
+}
+
+// SetManager sets the module manager
+func (mod *SessionRestoreModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// InitSessionRestoreModule creates and returns a new SessionRestoreModule
+// instance
+func InitSessionRestoreModule() core.Module {
+	return NewSessionRestoreModule()
+}
@@ -0,0 +1,112 @@
+package sessionrestore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// memorySettings keeps settings like the settings module
+type memorySettings struct {
+	values map[string]interface{}
+	saves  int
+}
+
+func (s *memorySettings) GetBool(key string) (bool, error) {
+	if value, ok := s.values[key].(bool); ok {
+		return value, nil
+	}
+	return false, fmt.Errorf("no bool %s", key)
+}
+
+func (s *memorySettings) GetString(key string) (string, error) {
+	if value, ok := s.values[key].(string); ok {
+		return value, nil
+	}
+	return "", fmt.Errorf("no string %s", key)
+}
+
+func (s *memorySettings) SetSetting(key string, value interface{}) error {
+	s.values[key] = value
+	return nil
+}
+
+func (s *memorySettings) SaveSettings() error {
+	s.saves++
+	return nil
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	french := filepath.Join(dir, "french.ot")
+	europe := filepath.Join(dir, "europe.ottp")
+	for _, path := range []string{french, europe} {
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	settings := &memorySettings{values: map[string]interface{}{}}
+	mod := NewSessionRestoreModule()
+	mod.SetSettings(settings)
+
+	if _, ok := mod.Load(); ok {
+		t.Error("Load without a saved session should find nothing")
+	}
+
+	practice := &Practice{
+		Questions: []Question{{Item: 2}, {Item: 0, Cloze: 1}, {Item: 1}},
+		Answers:   []Answer{{Item: 2, Answer: "maison", Correct: true}},
+	}
+	err := mod.Save(State{
+		Tabs: []Tab{
+			{Path: "*New lesson", LessonType: "words"},
+			{Path: filepath.Join(dir, "deleted.ot"), LessonType: "words"},
+			{Path: french, LessonType: "words", Page: 1, Practice: practice},
+			{Path: europe, LessonType: "topo"},
+		},
+		Current: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.saves != 1 {
+		t.Errorf("settings written %d times", settings.saves)
+	}
+
+	// The lesson never saved is left out, the deleted one when loading
+	os.Remove(filepath.Join(dir, "deleted.ot"))
+	state, ok := mod.Load()
+	if !ok || len(state.Tabs) != 2 || state.Current != 0 {
+		t.Fatalf("Load = %+v, %v", state, ok)
+	}
+	restored := state.Tabs[0]
+	if restored.Path != french || restored.Page != 1 || restored.Practice == nil {
+		t.Fatalf("restored tab = %+v", restored)
+	}
+	if got := restored.Practice; len(got.Questions) != 3 || got.Questions[1].Cloze != 1 || got.Correct() != 1 || !got.Valid(3) {
+		t.Errorf("restored practice = %+v", got)
+	}
+	if state.Tabs[1].Path != europe || state.Tabs[1].Practice != nil {
+		t.Errorf("second tab = %+v", state.Tabs[1])
+	}
+
+	settings.values[RestoreSetting] = false
+	if _, ok := mod.Load(); ok {
+		t.Error("Load should find nothing with reopening turned off")
+	}
+}
+
+func TestPracticeValid(t *testing.T) {
+	practice := Practice{Questions: []Question{{Item: 0}, {Item: 4}}}
+	if practice.Valid(4) {
+		t.Error("a question about an item the lesson no longer has is not valid")
+	}
+	if !practice.Valid(5) {
+		t.Error("practice should be valid")
+	}
+	practice.Answers = []Answer{{Item: 0}, {Item: 4}}
+	if practice.Valid(5) {
+		t.Error("a finished practice cannot be resumed")
+	}
+}
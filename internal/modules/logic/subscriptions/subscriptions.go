@@ -0,0 +1,398 @@
+// Package subscriptions keeps lesson files in step with lessons published
+// at a URL, such as in a lesson repository or on any web server. Every
+// subscription is checked for a new version now and then; a version that
+// changes the lesson is offered as an Update, showing what changed, and
+// merged into the lesson file once accepted. See lesson.MergeUpstream for
+// how the review state of the items is kept.
+//
+// Checks in the background go through the transfers module, so lessons
+// with a lot of media wait for an unmetered connection.
+package subscriptions
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// IntervalSetting is the settings key holding the minutes between two
+// checks of a subscription; 0 turns checking in the background off
+const IntervalSetting = "subscriptions.checkInterval"
+
+// DefaultInterval is the time between two checks of a subscription when
+// IntervalSetting is unset
+const DefaultInterval = 6 * time.Hour
+
+// requestTimeout bounds the download of a lesson
+const requestTimeout = 2 * time.Minute
+
+// Settings is the part of the settings module the interval is read from
+type Settings interface {
+	GetInt(key string) (int, error)
+}
+
+// Queue is the part of the transfers module checks in the background are
+// queued in
+type Queue interface {
+	Add(kind, name string, size int64, run func(ctx context.Context) error) int
+}
+
+// Update is a new version of a subscribed lesson that changes it
+type Update struct {
+	Subscription lesson.Subscription
+	Upstream     *lesson.UpstreamVersion
+	// Merged is the lesson file with the new version merged in, and Diff
+	// what that changed
+	Merged *lesson.LessonData
+	Diff   *lesson.UpstreamDiff
+}
+
+// SubscriptionsModule checks subscribed lessons for new versions
+type SubscriptionsModule struct {
+	*core.BaseModule
+	manager  *core.Manager
+	file     string
+	client   *http.Client
+	loader   *lesson.FileLoader
+	interval time.Duration
+	queue    Queue
+
+	mu            sync.Mutex
+	subscriptions []lesson.Subscription
+	// pending are the updates found in the background, by lesson file
+	pending map[string]Update
+	// queued are the lesson files whose check waits in the queue
+	queued map[string]bool
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// NewSubscriptionsModule creates a new SubscriptionsModule instance
+func NewSubscriptionsModule() *SubscriptionsModule {
+	base := core.NewBaseModule("subscriptions", "subscriptions-module")
+
+	return &SubscriptionsModule{
+		BaseModule: base,
+		file:       lesson.DefaultSubscriptionsPath(),
+		client:     &http.Client{Timeout: requestTimeout},
+		loader:     lesson.NewFileLoader(),
+		interval:   DefaultInterval,
+		pending:    make(map[string]Update),
+		queued:     make(map[string]bool),
+	}
+}
+
+// Subscriptions returns the subscriptions, sorted by lesson file
+func (mod *SubscriptionsModule) Subscriptions() []lesson.Subscription {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	return append([]lesson.Subscription(nil), mod.subscriptions...)
+}
+
+// Subscribe downloads the lesson at lessonURL to filePath and keeps it in
+// step from then on. It returns the lesson as saved.
+func (mod *SubscriptionsModule) Subscribe(ctx context.Context, lessonURL, filePath string) (*lesson.LessonData, error) {
+	mod.mu.Lock()
+	taken := lesson.FindSubscription(mod.subscriptions, filePath) >= 0
+	mod.mu.Unlock()
+	if taken {
+		return nil, fmt.Errorf("%s is already subscribed to a lesson", filePath)
+	}
+
+	subscription := lesson.Subscription{URL: lessonURL, Path: filePath}
+	upstream, err := mod.loader.FetchSubscription(ctx, mod.client, subscription)
+	if err != nil {
+		return nil, err
+	}
+	upstream.Lesson.List.EnsureUUID()
+	if err := lesson.NewFileSaver().SaveFile(upstream.Lesson, filePath); err != nil {
+		return nil, err
+	}
+
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	mod.subscriptions = append(mod.subscriptions, merged(subscription, upstream))
+	sort.Slice(mod.subscriptions, func(i, j int) bool {
+		return mod.subscriptions[i].Path < mod.subscriptions[j].Path
+	})
+	return upstream.Lesson, mod.save()
+}
+
+// Unsubscribe stops keeping filePath in step; the file itself stays
+func (mod *SubscriptionsModule) Unsubscribe(filePath string) error {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	i := lesson.FindSubscription(mod.subscriptions, filePath)
+	if i < 0 {
+		return fmt.Errorf("%s is not subscribed to a lesson", filePath)
+	}
+	delete(mod.pending, mod.subscriptions[i].Path)
+	mod.subscriptions = append(mod.subscriptions[:i], mod.subscriptions[i+1:]...)
+	return mod.save()
+}
+
+// Check looks for a new version of the lesson subscribed to by filePath.
+// It returns nil when there is none, or when it changes nothing, in which
+// case it counts as merged.
+func (mod *SubscriptionsModule) Check(ctx context.Context, filePath string) (*Update, error) {
+	mod.mu.Lock()
+	i := lesson.FindSubscription(mod.subscriptions, filePath)
+	var subscription lesson.Subscription
+	if i >= 0 {
+		subscription = mod.subscriptions[i]
+	}
+	mod.mu.Unlock()
+	if i < 0 {
+		return nil, fmt.Errorf("%s is not subscribed to a lesson", filePath)
+	}
+
+	upstream, err := mod.loader.FetchSubscription(ctx, mod.client, subscription)
+	if err != nil {
+		return nil, err
+	}
+	if upstream == nil {
+		subscription.Checked = time.Now()
+		return nil, mod.record(subscription)
+	}
+	local, err := mod.loader.LoadFile(subscription.Path)
+	if err != nil {
+		return nil, err
+	}
+	mergedLesson, diff := lesson.MergeUpstream(local, upstream.Lesson, subscription.Known)
+	if !mergedLesson.Changed {
+		return nil, mod.record(merged(subscription, upstream))
+	}
+	return &Update{Subscription: subscription, Upstream: upstream, Merged: mergedLesson, Diff: diff}, nil
+}
+
+// CheckAll checks every subscription, returning the updates found
+func (mod *SubscriptionsModule) CheckAll(ctx context.Context) ([]Update, error) {
+	var updates []Update
+	var errs []string
+	for _, subscription := range mod.Subscriptions() {
+		update, err := mod.Check(ctx, subscription.Path)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", subscription.Path, err))
+			continue
+		}
+		if update != nil {
+			updates = append(updates, *update)
+		}
+	}
+	if len(errs) > 0 {
+		return updates, fmt.Errorf("failed to check subscriptions: %s", strings.Join(errs, "; "))
+	}
+	return updates, nil
+}
+
+// Pending returns the updates found in the background since the last call
+func (mod *SubscriptionsModule) Pending() []Update {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	updates := make([]Update, 0, len(mod.pending))
+	for _, update := range mod.pending {
+		updates = append(updates, update)
+	}
+	mod.pending = make(map[string]Update)
+	sort.Slice(updates, func(i, j int) bool {
+		return updates[i].Subscription.Path < updates[j].Subscription.Path
+	})
+	return updates
+}
+
+// Apply merges an update into the lesson file and returns the lesson as
+// saved. local is the version of the lesson the user has, such as one
+// with unsaved changes in an editor; nil merges into Update.Merged as
+// checked.
+func (mod *SubscriptionsModule) Apply(update Update, local *lesson.LessonData) (*lesson.LessonData, error) {
+	mergedLesson := update.Merged
+	if local != nil {
+		mergedLesson, _ = lesson.MergeUpstream(local, update.Upstream.Lesson, update.Subscription.Known)
+	}
+	if err := lesson.NewFileSaver().SaveFile(mergedLesson, update.Subscription.Path); err != nil {
+		return nil, err
+	}
+	mergedLesson.Changed = false
+	return mergedLesson, mod.record(merged(update.Subscription, update.Upstream))
+}
+
+// merged returns subscription as it is once upstream was merged
+func merged(subscription lesson.Subscription, upstream *lesson.UpstreamVersion) lesson.Subscription {
+	subscription.ETag = upstream.ETag
+	subscription.LastModified = upstream.LastModified
+	subscription.Known = lesson.UpstreamKeys(upstream.Lesson)
+	subscription.Checked = time.Now()
+	return subscription
+}
+
+// record stores a subscription after it was checked or merged
+func (mod *SubscriptionsModule) record(subscription lesson.Subscription) error {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	i := lesson.FindSubscription(mod.subscriptions, subscription.Path)
+	if i < 0 {
+		// Unsubscribed meanwhile
+		return nil
+	}
+	mod.subscriptions[i] = subscription
+	return mod.save()
+}
+
+// save writes the subscriptions to their file; the lock is held
+func (mod *SubscriptionsModule) save() error {
+	return lesson.SaveSubscriptions(mod.file, mod.subscriptions)
+}
+
+// checkDue checks the subscriptions not checked for an interval, keeping
+// the updates found for Pending. With a queue the checks are queued, sized
+// by the lesson files, as a new version is likely about as large.
+func (mod *SubscriptionsModule) checkDue(ctx context.Context) {
+	for _, subscription := range mod.Subscriptions() {
+		if time.Since(subscription.Checked) < mod.interval {
+			continue
+		}
+		if mod.queue == nil {
+			if err := mod.checkInBackground(ctx, subscription); err != nil {
+				fmt.Printf("Warning: checking subscription %s: %v\n", subscription.URL, err)
+			}
+			continue
+		}
+
+		mod.mu.Lock()
+		queued := mod.queued[subscription.Path]
+		mod.queued[subscription.Path] = true
+		mod.mu.Unlock()
+		if queued {
+			continue
+		}
+		var size int64
+		if info, err := os.Stat(subscription.Path); err == nil {
+			size = info.Size()
+		}
+		mod.queue.Add("Lesson subscription", subscription.URL, size, func(ctx context.Context) error {
+			defer func() {
+				mod.mu.Lock()
+				delete(mod.queued, subscription.Path)
+				mod.mu.Unlock()
+			}()
+			return mod.checkInBackground(ctx, subscription)
+		})
+	}
+}
+
+// checkInBackground checks a subscription, keeping the update found for
+// Pending
+func (mod *SubscriptionsModule) checkInBackground(ctx context.Context, subscription lesson.Subscription) error {
+	update, err := mod.Check(ctx, subscription.Path)
+	if err != nil || update == nil {
+		return err
+	}
+	// The update is offered again at the next check until merged
+	update.Subscription.Checked = time.Now()
+	if err := mod.record(update.Subscription); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	mod.mu.Lock()
+	mod.pending[subscription.Path] = *update
+	mod.mu.Unlock()
+	return nil
+}
+
+// watch checks the subscriptions that are due every few minutes, until
+// stop is closed
+func (mod *SubscriptionsModule) watch(stop, done chan struct{}) {
+	defer close(done)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	ticker := time.NewTicker(min(mod.interval, 15*time.Minute))
+	defer ticker.Stop()
+	for {
+		mod.checkDue(ctx)
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Enable activates the module, reading the subscriptions and the interval
+// setting and starting to check in the background, through the transfers
+// module when there is one
+func (mod *SubscriptionsModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	if mod.manager != nil {
+		if module, ok := mod.manager.GetDefaultModule("settings"); ok {
+			if settings, ok := module.(Settings); ok {
+				if minutes, err := settings.GetInt(IntervalSetting); err == nil && minutes >= 0 {
+					mod.interval = time.Duration(minutes) * time.Minute
+				}
+			}
+		}
+		if module, ok := mod.manager.GetDefaultModule("transfers"); ok {
+			mod.queue, _ = module.(Queue)
+		}
+	}
+
+	subscriptions, err := lesson.LoadSubscriptions(mod.file)
+	if err != nil {
+		fmt.Printf("Warning: failed to read subscriptions: %v\n", err)
+	}
+	mod.mu.Lock()
+	mod.subscriptions = subscriptions
+	if mod.interval > 0 {
+		mod.stop = make(chan struct{})
+		mod.done = make(chan struct{})
+		go mod.watch(mod.stop, mod.done)
+	}
+	mod.mu.Unlock()
+
+	fmt.Println("SubscriptionsModule enabled")
+	return nil
+}
+
+// Disable deactivates the module, stopping the checks in the background
+func (mod *SubscriptionsModule) Disable(ctx context.Context) error {
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	mod.mu.Lock()
+	stop, done := mod.stop, mod.done
+	mod.stop, mod.done = nil, nil
+	mod.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+
+	fmt.Println("SubscriptionsModule disabled")
+	return nil
+}
+
+// SetManager sets the module manager
+func (mod *SubscriptionsModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// InitSubscriptionsModule creates and returns a new SubscriptionsModule
+// instance
+func InitSubscriptionsModule() core.Module {
+	return NewSubscriptionsModule()
+}
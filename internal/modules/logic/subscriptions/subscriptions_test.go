@@ -0,0 +1,123 @@
+package subscriptions
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+func TestSubscribeCheckApply(t *testing.T) {
+	version := 1
+	lessons := map[int]string{
+		1: "French,English\nchat,cat\nchien,dog\n",
+		2: "French,English\nchat,cat\nchien,dog\ncheval,horse\n",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := fmt.Sprintf(`"v%d"`, version)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(lessons[version]))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	mod := NewSubscriptionsModule()
+	mod.file = filepath.Join(dir, "subscriptions.json")
+	mod.client = server.Client()
+	filePath := filepath.Join(dir, "animals.csv")
+	ctx := context.Background()
+
+	if _, err := mod.Subscribe(ctx, server.URL+"/animals.csv", filePath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mod.Subscribe(ctx, server.URL+"/animals.csv", filePath); err == nil {
+		t.Error("expected an error subscribing the same file twice")
+	}
+	if update, err := mod.Check(ctx, filePath); err != nil || update != nil {
+		t.Fatalf("expected no update, got %+v, %v", update, err)
+	}
+
+	version = 2
+	update, err := mod.Check(ctx, filePath)
+	if err != nil || update == nil {
+		t.Fatalf("expected an update, got %v", err)
+	}
+	if len(update.Diff.Added) != 1 || update.Diff.Unchanged != 2 {
+		t.Errorf("unexpected diff %s", update.Diff)
+	}
+	if _, err := mod.Apply(*update, nil); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := lesson.NewFileLoader().LoadFile(filePath)
+	if err != nil || len(saved.List.Items) != 3 {
+		t.Fatalf("expected 3 items in the lesson file, got %v", err)
+	}
+
+	subscriptions, err := lesson.LoadSubscriptions(mod.file)
+	if err != nil || len(subscriptions) != 1 || subscriptions[0].ETag != `"v2"` || len(subscriptions[0].Known) != 3 {
+		t.Fatalf("unexpected subscriptions %+v, %v", subscriptions, err)
+	}
+	if err := mod.Unsubscribe(filePath); err != nil || len(mod.Subscriptions()) != 0 {
+		t.Errorf("expected no subscriptions left, got %v", err)
+	}
+}
+
+// recordingQueue keeps the transfers queued, to be run by the test
+type recordingQueue struct {
+	names []string
+	sizes []int64
+	runs  []func(ctx context.Context) error
+}
+
+func (q *recordingQueue) Add(kind, name string, size int64, run func(ctx context.Context) error) int {
+	q.names = append(q.names, name)
+	q.sizes = append(q.sizes, size)
+	q.runs = append(q.runs, run)
+	return len(q.runs)
+}
+
+func TestQueuedChecks(t *testing.T) {
+	body := "French,English\nchat,cat\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	mod := NewSubscriptionsModule()
+	mod.file = filepath.Join(dir, "subscriptions.json")
+	mod.client = server.Client()
+	mod.interval = 0
+	queue := &recordingQueue{}
+	mod.queue = queue
+	filePath := filepath.Join(dir, "animals.csv")
+	ctx := context.Background()
+	if _, err := mod.Subscribe(ctx, server.URL+"/animals.csv", filePath); err != nil {
+		t.Fatal(err)
+	}
+
+	body += "chien,dog\n"
+	mod.checkDue(ctx)
+	mod.checkDue(ctx)
+	if len(queue.runs) != 1 || queue.names[0] != server.URL+"/animals.csv" || queue.sizes[0] == 0 {
+		t.Fatalf("expected one check queued with the size of the lesson file, got %v %v", queue.names, queue.sizes)
+	}
+	if err := queue.runs[0](ctx); err != nil {
+		t.Fatal(err)
+	}
+	if pending := mod.Pending(); len(pending) != 1 || len(pending[0].Diff.Added) != 1 {
+		t.Fatalf("expected the update to be pending, got %+v", pending)
+	}
+	mod.checkDue(ctx)
+	if len(queue.runs) != 2 {
+		t.Errorf("expected the check to be queued again once run, got %d", len(queue.runs))
+	}
+}
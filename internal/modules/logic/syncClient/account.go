@@ -0,0 +1,195 @@
+package syncclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	restapi "github.com/LaPingvino/recuerdo/internal/modules/logic/restApi"
+	"github.com/LaPingvino/recuerdo/internal/reviewlog"
+)
+
+// ErrNoAccount is returned for what needs data of the user's own on the
+// server, which takes an account or a passphrase
+var ErrNoAccount = errors.New("no sync account or passphrase is set")
+
+// Account returns the account lessons and review history are kept in,
+// empty for none
+func (mod *SyncClientModule) Account() string {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	return mod.account
+}
+
+// KeepsOwnData reports whether the server keeps data of the user's own:
+// an account or an encrypted vault
+func (mod *SyncClientModule) KeepsOwnData() bool {
+	return mod.Encrypted() || mod.Account() != ""
+}
+
+// SyncHistory brings the review history of this device and the other
+// devices of the user together and returns how many answers were new here.
+// Encrypted, every device keeps its events in a blob of the vault;
+// otherwise the server keeps the events of all of them in the account. The
+// events of a device are numbered, so neither way loses or doubles an
+// answer however often or in whatever order the devices sync.
+func (mod *SyncClientModule) SyncHistory(history History) (int, error) {
+	if mod.Encrypted() {
+		if err := mod.PushHistory(history); err != nil {
+			return 0, err
+		}
+		return mod.PullHistory(history)
+	}
+	if mod.Account() == "" {
+		return 0, ErrNoAccount
+	}
+
+	mod.mu.Lock()
+	cursor := mod.historyCursor
+	mod.mu.Unlock()
+	response, err := mod.exchangeHistory(history, cursor)
+	if errors.Is(err, errHistoryGap) {
+		// The server lost events it had, so it gets all of them again
+		response, err = mod.exchangeHistory(history, nil)
+	}
+	if err != nil {
+		return 0, err
+	}
+	added, err := history.Merge(response.Events)
+	if err != nil {
+		return added, err
+	}
+	mod.mu.Lock()
+	mod.historyCursor = response.Cursor
+	mod.mu.Unlock()
+	return added, nil
+}
+
+// errHistoryGap is returned when the events pushed do not follow on from
+// those on the server
+var errHistoryGap = errors.New("the server is missing review events")
+
+// exchangeHistory pushes the events of history after cursor, how far the
+// account went at the last sync, and returns those the server has after it
+func (mod *SyncClientModule) exchangeHistory(history History, cursor reviewlog.Cursor) (*restapi.HistoryExchange, error) {
+	events, err := history.Diff(cursor)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(restapi.HistoryExchange{Cursor: cursor, Events: events})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode review history: %w", err)
+	}
+	var exchange restapi.HistoryExchange
+	err = mod.run("Sync", "Review history", int64(len(data)), func(ctx context.Context) error {
+		response, err := mod.accountRequest(ctx, http.MethodPost, "reviews", data)
+		if err != nil {
+			return err
+		}
+		defer response.Body.Close()
+		if response.StatusCode == http.StatusConflict {
+			return errHistoryGap
+		}
+		if response.StatusCode != http.StatusOK {
+			return responseError("review history", response)
+		}
+		if err := json.NewDecoder(response.Body).Decode(&exchange); err != nil {
+			return fmt.Errorf("invalid response from sync server: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &exchange, nil
+}
+
+// ExportData writes a zip of everything the server keeps for the user to
+// w: the vault when syncing encrypted, and the account otherwise
+func (mod *SyncClientModule) ExportData(w io.Writer) error {
+	if mod.Encrypted() {
+		return mod.ExportVault(w)
+	}
+	if mod.Account() == "" {
+		return ErrNoAccount
+	}
+	return mod.run("Sync export", "Account", 0, func(ctx context.Context) error {
+		response, err := mod.accountRequest(ctx, http.MethodGet, "export", nil)
+		if err != nil {
+			return err
+		}
+		defer response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			return responseError("the account", response)
+		}
+		if _, err := io.Copy(w, response.Body); err != nil {
+			return fmt.Errorf("failed to download the account: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteData deletes everything the server keeps for the user: the vault
+// when syncing encrypted, and the account otherwise. An account that is
+// not there counts as deleted.
+func (mod *SyncClientModule) DeleteData() error {
+	if mod.Encrypted() {
+		return mod.DeleteVault()
+	}
+	if mod.Account() == "" {
+		return ErrNoAccount
+	}
+	err := mod.run("Sync delete", "Account", 0, func(ctx context.Context) error {
+		response, err := mod.accountRequest(ctx, http.MethodDelete, "", nil)
+		if err != nil {
+			return err
+		}
+		defer response.Body.Close()
+		if response.StatusCode != http.StatusNoContent && response.StatusCode != http.StatusNotFound {
+			return responseError("the account", response)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	mod.revisions = make(map[string]int)
+	mod.historyCursor = nil
+	return nil
+}
+
+// accountRequest sends a request about the account to the server; an
+// empty path is about the account itself
+func (mod *SyncClientModule) accountRequest(ctx context.Context, method, path string, data []byte) (*http.Response, error) {
+	mod.mu.Lock()
+	server, token, name := mod.server, mod.token, mod.account
+	mod.mu.Unlock()
+	if server == "" {
+		return nil, fmt.Errorf("no sync server configured")
+	}
+
+	location := server + "/api/accounts/" + url.PathEscape(name)
+	if path != "" {
+		location += "/" + path
+	}
+	request, err := http.NewRequestWithContext(ctx, method, location, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	response, err := mod.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach sync server: %w", err)
+	}
+	return response, nil
+}
@@ -0,0 +1,514 @@
+package syncclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	restapi "github.com/LaPingvino/recuerdo/internal/modules/logic/restApi"
+	"github.com/LaPingvino/recuerdo/internal/reviewlog"
+	"github.com/LaPingvino/recuerdo/internal/vault"
+)
+
+// pushAttempts bounds how often a push merges with an edit pushed in
+// between and tries again
+const pushAttempts = 3
+
+// History is the part of the review log whose events are synced
+type History interface {
+	Device() string
+	Diff(cursor reviewlog.Cursor) ([]reviewlog.Review, error)
+	Merge(events []reviewlog.Review) (int, error)
+}
+
+// errBlobChanged is returned for blobs pushed since they were pulled
+var errBlobChanged = errors.New("the blob was changed on the server")
+
+// Encrypted reports whether lessons and review history are encrypted before
+// they are synced, which they are once a passphrase is set
+func (mod *SyncClientModule) Encrypted() bool {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	return mod.passphrase != ""
+}
+
+// pullEncrypted fetches a lesson from the vault and decrypts it
+func (mod *SyncClientModule) pullEncrypted(name string) (*lesson.LessonData, error) {
+	key, err := mod.vaultKey()
+	if err != nil {
+		return nil, err
+	}
+	blob := key.BlobName("lesson", name)
+	var lessonData *lesson.LessonData
+	err = mod.run("Sync pull", name, 0, func(ctx context.Context) error {
+		response, err := mod.vaultRequest(ctx, http.MethodGet, "blobs/"+blob, nil, nil)
+		if err != nil {
+			return err
+		}
+		defer response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			return responseError(name, response)
+		}
+		sealed, err := io.ReadAll(response.Body)
+		if err != nil {
+			return fmt.Errorf("failed to download %s: %w", name, err)
+		}
+		data, err := key.Open(blob, sealed)
+		if err != nil {
+			return err
+		}
+		pulled := lesson.NewLessonData()
+		if err := json.Unmarshal(data, pulled); err != nil {
+			return fmt.Errorf("invalid lesson %s in vault: %w", name, err)
+		}
+		revision, _ := strconv.Atoi(strings.Trim(response.Header.Get("ETag"), `"`))
+		mod.setSynced(name, revision, data)
+		lessonData = pulled
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The server cannot tell the sync scope of what it cannot read
+	if !mod.Syncs(lessonData) {
+		return nil, fmt.Errorf("cannot pull %s: %w", name, ErrNotSynced)
+	}
+	return lessonData, nil
+}
+
+// pushEncrypted encrypts an edit of a lesson and stores it in the vault
+// over the revision it was pulled at. When someone pushed in between, it
+// pulls their version and merges the edit into it here, as the server
+// cannot.
+func (mod *SyncClientModule) pushEncrypted(name string, lessonData *lesson.LessonData) (*lesson.LessonData, []lesson.EditConflict, error) {
+	key, err := mod.vaultKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	blob := key.BlobName("lesson", name)
+	var conflicts []lesson.EditConflict
+	for attempt := 0; attempt < pushAttempts; attempt++ {
+		revision, base := mod.Revision(name), mod.base(name)
+		data, err := json.Marshal(lessonData)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encode lesson: %w", err)
+		}
+		sealed, err := key.Seal(blob, data)
+		if err != nil {
+			return nil, nil, err
+		}
+		header := http.Header{}
+		if revision == 0 {
+			header.Set("If-None-Match", "*")
+		} else {
+			header.Set("If-Match", strconv.Quote(strconv.Itoa(revision)))
+		}
+
+		err = mod.run("Sync push", name, int64(len(sealed)), func(ctx context.Context) error {
+			response, err := mod.vaultRequest(ctx, http.MethodPut, "blobs/"+blob, header, sealed)
+			if err != nil {
+				return err
+			}
+			defer response.Body.Close()
+			if response.StatusCode == http.StatusPreconditionFailed {
+				return errBlobChanged
+			}
+			if response.StatusCode != http.StatusOK {
+				return responseError(name, response)
+			}
+			var stored restapi.BlobRevision
+			if err := json.NewDecoder(response.Body).Decode(&stored); err != nil {
+				return fmt.Errorf("invalid response from sync server: %w", err)
+			}
+			mod.setSynced(name, stored.Revision, data)
+			return nil
+		})
+		if !errors.Is(err, errBlobChanged) {
+			if err != nil {
+				return nil, nil, err
+			}
+			return lessonData, conflicts, nil
+		}
+
+		current, err := mod.pullEncrypted(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if base == nil {
+			base = lesson.NewLessonData()
+		}
+		merged, more := lesson.MergeEdits(base, current, lessonData)
+		lessonData, conflicts = merged, append(conflicts, more...)
+	}
+	return nil, nil, fmt.Errorf("cannot push %s: it kept changing on the server", name)
+}
+
+// PushHistory encrypts the review events of this device and stores them in
+// the vault, where the other devices of the user pull them from
+func (mod *SyncClientModule) PushHistory(history History) error {
+	key, err := mod.vaultKey()
+	if err != nil {
+		return err
+	}
+	events, err := history.Diff(reviewlog.Cursor{})
+	if err != nil {
+		return err
+	}
+	own := events[:0]
+	for _, review := range events {
+		if review.Device == history.Device() {
+			own = append(own, review)
+		}
+	}
+	data, err := json.Marshal(own)
+	if err != nil {
+		return fmt.Errorf("failed to encode review history: %w", err)
+	}
+	blob := key.BlobName("history", history.Device())
+	sealed, err := key.Seal(blob, data)
+	if err != nil {
+		return err
+	}
+	// Only this device writes its events, so there is nothing to merge
+	return mod.run("Sync push", "Review history", int64(len(sealed)), func(ctx context.Context) error {
+		response, err := mod.vaultRequest(ctx, http.MethodPut, "blobs/"+blob, nil, sealed)
+		if err != nil {
+			return err
+		}
+		defer response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			return responseError("review history", response)
+		}
+		return nil
+	})
+}
+
+// PullHistory merges the review events the other devices of the user
+// pushed into history and returns how many were new
+func (mod *SyncClientModule) PullHistory(history History) (int, error) {
+	key, err := mod.vaultKey()
+	if err != nil {
+		return 0, err
+	}
+	own := key.BlobName("history", history.Device())
+	added := 0
+	err = mod.run("Sync pull", "Review history", 0, func(ctx context.Context) error {
+		var blobs []restapi.BlobInfo
+		if err := mod.vaultJSON(ctx, "blobs", &blobs); err != nil {
+			return err
+		}
+		for _, info := range blobs {
+			if !strings.HasPrefix(info.Name, "h-") || info.Name == own {
+				continue
+			}
+			events, err := mod.pullEvents(ctx, key, info.Name)
+			if err != nil {
+				return err
+			}
+			n, err := history.Merge(events)
+			added += n
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return added, err
+}
+
+// ExportVault writes a zip of everything the server stores in the vault
+// to w: its salt, its blobs, still encrypted, and a manifest; see
+// restapi.VaultManifest
+func (mod *SyncClientModule) ExportVault(w io.Writer) error {
+	return mod.run("Sync export", "Vault", 0, func(ctx context.Context) error {
+		response, err := mod.vaultRequest(ctx, http.MethodGet, "export", nil, nil)
+		if err != nil {
+			return err
+		}
+		defer response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			return responseError("the vault", response)
+		}
+		if _, err := io.Copy(w, response.Body); err != nil {
+			return fmt.Errorf("failed to download the vault: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteVault deletes the vault with everything in it from the server and
+// forgets what was synced with it. A vault that is not there counts as
+// deleted.
+func (mod *SyncClientModule) DeleteVault() error {
+	err := mod.run("Sync delete", "Vault", 0, func(ctx context.Context) error {
+		response, err := mod.vaultRequest(ctx, http.MethodDelete, "", nil, nil)
+		if err != nil {
+			return err
+		}
+		defer response.Body.Close()
+		if response.StatusCode != http.StatusNoContent && response.StatusCode != http.StatusNotFound {
+			return responseError("the vault", response)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	mod.resetVault()
+	return nil
+}
+
+// pullEvents fetches and decrypts the review events in a blob
+func (mod *SyncClientModule) pullEvents(ctx context.Context, key *vault.Key, blob string) ([]reviewlog.Review, error) {
+	response, err := mod.vaultRequest(ctx, http.MethodGet, "blobs/"+blob, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, responseError("review history", response)
+	}
+	sealed, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download review history: %w", err)
+	}
+	data, err := key.Open(blob, sealed)
+	if err != nil {
+		return nil, err
+	}
+	var events []reviewlog.Review
+	if err := json.Unmarshal(data, &events); err != nil {
+		return nil, fmt.Errorf("invalid review history in vault: %w", err)
+	}
+	return events, nil
+}
+
+// vaultKey returns the key of the vault, deriving it the first time and
+// checking it against the vault, so a wrong passphrase fails with
+// vault.ErrWrongPassphrase rather than filling the vault with blobs no
+// other device opens. The first device to sync creates the vault with a
+// new salt.
+func (mod *SyncClientModule) vaultKey() (*vault.Key, error) {
+	mod.mu.Lock()
+	key, passphrase := mod.key, mod.passphrase
+	mod.mu.Unlock()
+	if key != nil {
+		return key, nil
+	}
+	if passphrase == "" {
+		return nil, vault.ErrPassphraseRequired
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	salt, err := mod.vaultSalt(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if key, err = vault.DeriveKey(passphrase, salt); err != nil {
+		return nil, err
+	}
+	if err := mod.checkPassphrase(ctx, key); err != nil {
+		return nil, err
+	}
+
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	// The passphrase may have changed while the key was derived
+	if mod.passphrase != passphrase {
+		return nil, fmt.Errorf("the sync passphrase changed")
+	}
+	mod.key = key
+	return key, nil
+}
+
+// vaultSalt fetches the salt of the vault, creating the vault when there is
+// none yet
+func (mod *SyncClientModule) vaultSalt(ctx context.Context) ([]byte, error) {
+	for {
+		response, err := mod.vaultRequest(ctx, http.MethodGet, "salt", nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		salt, err := io.ReadAll(response.Body)
+		response.Body.Close()
+		switch {
+		case response.StatusCode == http.StatusOK && err == nil:
+			return salt, nil
+		case response.StatusCode != http.StatusNotFound:
+			return nil, fmt.Errorf("sync server refused the vault salt (%s)", response.Status)
+		}
+
+		if salt, err = vault.NewSalt(); err != nil {
+			return nil, err
+		}
+		response, err = mod.vaultRequest(ctx, http.MethodPut, "salt", nil, salt)
+		if err != nil {
+			return nil, err
+		}
+		response.Body.Close()
+		switch response.StatusCode {
+		case http.StatusCreated, http.StatusNoContent:
+			return salt, nil
+		case http.StatusConflict:
+			// Another device created the vault first; use its salt
+			continue
+		}
+		return nil, fmt.Errorf("sync server refused the vault salt (%s)", response.Status)
+	}
+}
+
+// checkPassphrase makes sure key opens the vault by opening its
+// vault.CheckBlob. A vault without one gets it sealed with key, once any
+// blob already in it opened with key too.
+func (mod *SyncClientModule) checkPassphrase(ctx context.Context, key *vault.Key) error {
+	for {
+		sealed, err := mod.vaultBlob(ctx, vault.CheckBlob)
+		if err == nil {
+			return key.Check(sealed)
+		}
+		if !errors.Is(err, errNoBlob) {
+			return err
+		}
+
+		// Vaults from before the check blob tell by the blobs in them
+		var blobs []restapi.BlobInfo
+		if err := mod.vaultJSON(ctx, "blobs", &blobs); err != nil {
+			return err
+		}
+		if len(blobs) > 0 {
+			sealed, err := mod.vaultBlob(ctx, blobs[0].Name)
+			if err != nil {
+				return err
+			}
+			if _, err := key.Open(blobs[0].Name, sealed); err != nil {
+				return err
+			}
+		}
+
+		if sealed, err = key.SealCheck(); err != nil {
+			return err
+		}
+		header := http.Header{"If-None-Match": {"*"}}
+		response, err := mod.vaultRequest(ctx, http.MethodPut, "blobs/"+vault.CheckBlob, header, sealed)
+		if err != nil {
+			return err
+		}
+		response.Body.Close()
+		switch response.StatusCode {
+		case http.StatusOK:
+			return nil
+		case http.StatusPreconditionFailed:
+			// Another device sealed it first; check against theirs
+			continue
+		}
+		return fmt.Errorf("sync server refused the vault check (%s)", response.Status)
+	}
+}
+
+// errNoBlob is returned by vaultBlob for blobs the vault does not hold
+var errNoBlob = errors.New("no such blob in the vault")
+
+// vaultBlob fetches a blob of the vault as stored, still sealed
+func (mod *SyncClientModule) vaultBlob(ctx context.Context, blob string) ([]byte, error) {
+	response, err := mod.vaultRequest(ctx, http.MethodGet, "blobs/"+blob, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	switch response.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, errNoBlob
+	default:
+		return nil, responseError("the vault", response)
+	}
+	sealed, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download the vault: %w", err)
+	}
+	return sealed, nil
+}
+
+// vaultJSON fetches and decodes a JSON document of the vault
+func (mod *SyncClientModule) vaultJSON(ctx context.Context, path string, result interface{}) error {
+	response, err := mod.vaultRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return responseError("the vault", response)
+	}
+	if err := json.NewDecoder(response.Body).Decode(result); err != nil {
+		return fmt.Errorf("invalid response from sync server: %w", err)
+	}
+	return nil
+}
+
+// vaultRequest sends a request about the vault to the server; an empty
+// path is about the vault itself
+func (mod *SyncClientModule) vaultRequest(ctx context.Context, method, path string, header http.Header, data []byte) (*http.Response, error) {
+	mod.mu.Lock()
+	server, token, name := mod.server, mod.token, mod.vault
+	mod.mu.Unlock()
+	if server == "" {
+		return nil, fmt.Errorf("no sync server configured")
+	}
+
+	location := server + "/api/vault/" + url.PathEscape(name)
+	if path != "" {
+		location += "/" + path
+	}
+	request, err := http.NewRequestWithContext(ctx, method, location, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		request.Header[key] = values
+	}
+	request.Header.Set("Content-Type", "application/octet-stream")
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	response, err := mod.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach sync server: %w", err)
+	}
+	return response, nil
+}
+
+// base returns the lesson as it was last pulled or pushed, nil if it never
+// was
+func (mod *SyncClientModule) base(name string) *lesson.LessonData {
+	mod.mu.Lock()
+	data := mod.bases[name]
+	mod.mu.Unlock()
+	if data == nil {
+		return nil
+	}
+	base := lesson.NewLessonData()
+	if err := json.Unmarshal(data, base); err != nil {
+		return nil
+	}
+	return base
+}
+
+// setSynced records the revision a lesson was pulled or pushed at, and the
+// lesson itself to merge later edits from
+func (mod *SyncClientModule) setSynced(name string, revision int, data []byte) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	mod.revisions[name] = revision
+	mod.bases[name] = data
+}
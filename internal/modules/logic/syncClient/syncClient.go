@@ -0,0 +1,411 @@
+// Package syncclient keeps lessons in sync with a lesson directory served by
+// "recuerdo serve", so several teachers can edit the same lesson. The server
+// merges edits per item; see restapi.SyncRequest.
+//
+// Lessons can be kept off some devices by their sync scope (see
+// lesson.ParseSyncScope): local lessons are never pushed, and lessons kept
+// to device groups are only pulled by devices in one of them.
+//
+// With the transfers module, pulls and pushes go through its queue, so
+// they can be paused, cancelled and retried from the transfers panel.
+//
+// With an account set, lessons are kept in it instead of among the lessons
+// shared on the server, and the review history of the user's devices is
+// synced through it too (see SyncHistory), so progress made on a laptop
+// and on a desktop comes together.
+//
+// With a passphrase set, lessons are encrypted before they leave the device
+// and kept in a vault on the server instead (see package vault), and the
+// review history of every device can be synced through it too. The server
+// cannot merge what it cannot read, so edits are merged on the device that
+// pushes last.
+package syncclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	restapi "github.com/LaPingvino/recuerdo/internal/modules/logic/restApi"
+	"github.com/LaPingvino/recuerdo/internal/reviewlog"
+	"github.com/LaPingvino/recuerdo/internal/vault"
+)
+
+// ServerSetting is the settings key holding the URL of the sync server
+const ServerSetting = "sync.server"
+
+// TokenSetting is the settings key holding the token the server asks for,
+// one of those given to "recuerdo serve -tokens"
+const TokenSetting = "sync.token"
+
+// DeviceGroupsSetting is the settings key holding the device groups this
+// device is in, comma separated, such as "laptop, travel"
+const DeviceGroupsSetting = "sync.deviceGroups"
+
+// VaultSetting is the settings key holding the name of the vault on the
+// server encrypted data is kept in, "default" when empty; devices share
+// data through the same vault and passphrase
+const VaultSetting = "sync.vault"
+
+// AccountSetting is the settings key holding the account on the server
+// this device's lessons and review history are kept in. Without one,
+// lessons are shared with everyone on the server and review history is not
+// synced unencrypted.
+const AccountSetting = "sync.account"
+
+// PassphraseSetting is the settings key holding the passphrase encrypted
+// sync derives its key from. Without one lessons are synced unencrypted.
+const PassphraseSetting = "sync.passphrase"
+
+// defaultVault is the vault used when none is set
+const defaultVault = "default"
+
+// ErrNotSynced is returned for lessons whose sync scope keeps them off this
+// device or off the server
+var ErrNotSynced = errors.New("the lesson is not synced to this device")
+
+// requestTimeout bounds every request to the server
+const requestTimeout = 30 * time.Second
+
+// Settings is the part of the settings module the sync client reads the
+// server URL from
+type Settings interface {
+	GetString(key string) (string, error)
+}
+
+// Queue is the part of the transfers module pulls and pushes go through
+type Queue interface {
+	Add(kind, name string, size int64, run func(ctx context.Context) error) int
+	Wait(ctx context.Context, id int) error
+}
+
+// SyncClientModule pulls lessons from a sync server and pushes edits back,
+// remembering the revision each lesson was pulled at so the server can merge
+type SyncClientModule struct {
+	*core.BaseModule
+	manager   *core.Manager
+	server    string
+	token     string
+	groups    []string
+	client    *http.Client
+	queue     Queue
+	revisions map[string]int
+	// account keeps lessons and review history apart on the server;
+	// historyCursor is how far its review history went at the last sync
+	account       string
+	historyCursor reviewlog.Cursor
+	// passphrase and vault select encrypted sync; key is derived from them
+	// on first use
+	passphrase string
+	vault      string
+	key        *vault.Key
+	// bases holds encrypted lessons as last pulled or pushed, to merge from
+	bases map[string][]byte
+	mu    sync.Mutex
+}
+
+// NewSyncClientModule creates a new SyncClientModule instance
+func NewSyncClientModule() *SyncClientModule {
+	base := core.NewBaseModule("syncClient", "sync-client-module")
+
+	return &SyncClientModule{
+		BaseModule: base,
+		client:     &http.Client{Timeout: requestTimeout},
+		revisions:  make(map[string]int),
+		vault:      defaultVault,
+		bases:      make(map[string][]byte),
+	}
+}
+
+// SetServer sets the base URL of the sync server, e.g.
+// "http://school.example:8080"
+func (mod *SyncClientModule) SetServer(server string) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	mod.server = strings.TrimSuffix(server, "/")
+}
+
+// SetToken sets the token sent to the sync server, empty for none
+func (mod *SyncClientModule) SetToken(token string) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	mod.token = strings.TrimSpace(token)
+}
+
+// SetPassphrase sets the passphrase lessons and review history are
+// encrypted with, empty to sync unencrypted
+func (mod *SyncClientModule) SetPassphrase(passphrase string) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	if passphrase != mod.passphrase {
+		mod.passphrase = passphrase
+		mod.resetVault()
+	}
+}
+
+// SetAccount sets the account lessons and review history are kept in,
+// empty to share lessons with everyone on the server
+func (mod *SyncClientModule) SetAccount(name string) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	name = strings.TrimSpace(name)
+	if name != mod.account {
+		mod.account = name
+		mod.revisions = make(map[string]int)
+		mod.historyCursor = nil
+	}
+}
+
+// SetVault sets the vault encrypted data is kept in, empty for the default
+func (mod *SyncClientModule) SetVault(name string) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = defaultVault
+	}
+	if name != mod.vault {
+		mod.vault = name
+		mod.resetVault()
+	}
+}
+
+// resetVault forgets the key and what was synced with it. The caller holds
+// mod.mu.
+func (mod *SyncClientModule) resetVault() {
+	mod.key = nil
+	mod.revisions = make(map[string]int)
+	mod.bases = make(map[string][]byte)
+}
+
+// SetQueue makes pulls and pushes go through the transfers queue
+func (mod *SyncClientModule) SetQueue(queue Queue) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	mod.queue = queue
+}
+
+// SetDeviceGroups sets the device groups this device is in
+func (mod *SyncClientModule) SetDeviceGroups(groups []string) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	mod.groups = append([]string(nil), groups...)
+}
+
+// DeviceGroups returns the device groups this device is in
+func (mod *SyncClientModule) DeviceGroups() []string {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	return append([]string(nil), mod.groups...)
+}
+
+// Syncs reports whether a lesson is synced to and from this device
+func (mod *SyncClientModule) Syncs(lessonData *lesson.LessonData) bool {
+	return lesson.SyncsTo(lessonData.List.Sync, mod.DeviceGroups())
+}
+
+// Revision returns the revision a lesson was last pulled or pushed at, 0 if
+// it never was
+func (mod *SyncClientModule) Revision(name string) int {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	return mod.revisions[name]
+}
+
+// Pull fetches the latest revision of a lesson. Lessons kept to device
+// groups this device is not in are not sent; Pull returns ErrNotSynced for
+// them.
+func (mod *SyncClientModule) Pull(name string) (*lesson.LessonData, error) {
+	if mod.Encrypted() {
+		return mod.pullEncrypted(name)
+	}
+	var state restapi.SyncState
+	if err := mod.do(http.MethodGet, name, nil, &state); err != nil {
+		return nil, err
+	}
+	mod.setRevision(name, state.Revision)
+	return state.Lesson, nil
+}
+
+// Push sends an edit of a lesson made since it was last pulled. It returns
+// the merged lesson, which replaces the edited one, and the items someone
+// else changed too; those now hold this edit's version.
+func (mod *SyncClientModule) Push(name string, lessonData *lesson.LessonData) (*lesson.LessonData, []lesson.EditConflict, error) {
+	if lessonData.List.Sync == lesson.SyncLocalOnly {
+		return nil, nil, fmt.Errorf("cannot push %s: %w", name, ErrNotSynced)
+	}
+	if mod.Encrypted() {
+		return mod.pushEncrypted(name, lessonData)
+	}
+	request := restapi.SyncRequest{BaseRevision: mod.Revision(name), Lesson: lessonData}
+	var response restapi.SyncResponse
+	if err := mod.do(http.MethodPost, name, request, &response); err != nil {
+		return nil, nil, err
+	}
+	mod.setRevision(name, response.Revision)
+	return response.Lesson, response.Conflicts, nil
+}
+
+func (mod *SyncClientModule) setRevision(name string, revision int) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	mod.revisions[name] = revision
+}
+
+// do sends a request about a lesson to the server and decodes the response,
+// waiting in the transfers queue when there is one
+func (mod *SyncClientModule) do(method, name string, body, result interface{}) error {
+	mod.mu.Lock()
+	server := mod.server
+	mod.mu.Unlock()
+	if server == "" {
+		return fmt.Errorf("no sync server configured")
+	}
+
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to encode lesson: %w", err)
+		}
+	}
+	kind := "Sync pull"
+	if method != http.MethodGet {
+		kind = "Sync push"
+	}
+	return mod.run(kind, name, int64(len(data)), func(ctx context.Context) error {
+		return mod.send(ctx, method, name, data, result)
+	})
+}
+
+// run runs a transfer in the transfers queue when there is one, and
+// directly otherwise
+func (mod *SyncClientModule) run(kind, name string, size int64, transfer func(ctx context.Context) error) error {
+	mod.mu.Lock()
+	queue := mod.queue
+	mod.mu.Unlock()
+	if queue == nil {
+		return transfer(context.Background())
+	}
+	return queue.Wait(context.Background(), queue.Add(kind, name, size, transfer))
+}
+
+// send sends a request about a lesson to the server and decodes the
+// response
+func (mod *SyncClientModule) send(ctx context.Context, method, name string, data []byte, result interface{}) error {
+	mod.mu.Lock()
+	server := mod.server
+	token := mod.token
+	groups := strings.Join(mod.groups, ",")
+	account := mod.account
+	mod.mu.Unlock()
+
+	path := "/api/sync/" + url.PathEscape(name)
+	if account != "" {
+		path = "/api/accounts/" + url.PathEscape(account) + path[len("/api"):]
+	}
+	query := url.Values{"groups": {groups}}
+	request, err := http.NewRequestWithContext(ctx, method, server+path+"?"+query.Encode(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	response, err := mod.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to reach sync server: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return responseError(name, response)
+	}
+	if err := json.NewDecoder(response.Body).Decode(result); err != nil {
+		return fmt.Errorf("invalid response from sync server: %w", err)
+	}
+	return nil
+}
+
+// responseError returns the error the server gave for a request about name
+func responseError(name string, response *http.Response) error {
+	var failure struct {
+		Error string `json:"error"`
+	}
+	json.NewDecoder(response.Body).Decode(&failure)
+	if response.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("sync server refused %s: %w", name, ErrNotSynced)
+	}
+	return fmt.Errorf("sync server refused %s: %s (%s)", name, failure.Error, response.Status)
+}
+
+// Enable activates the module, reading the server URL, token, device
+// groups, account and encryption passphrase from the settings and finding the transfers queue
+func (mod *SyncClientModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	if mod.manager != nil {
+		if module, ok := mod.manager.GetDefaultModule("settings"); ok {
+			if settings, ok := module.(Settings); ok {
+				if server, err := settings.GetString(ServerSetting); err == nil {
+					mod.SetServer(server)
+				}
+				if token, err := settings.GetString(TokenSetting); err == nil {
+					mod.SetToken(token)
+				}
+				if groups, err := settings.GetString(DeviceGroupsSetting); err == nil {
+					mod.SetDeviceGroups(lesson.ParseTags(groups))
+				}
+				if name, err := settings.GetString(AccountSetting); err == nil {
+					mod.SetAccount(name)
+				}
+				if name, err := settings.GetString(VaultSetting); err == nil {
+					mod.SetVault(name)
+				}
+				if passphrase, err := settings.GetString(PassphraseSetting); err == nil {
+					mod.SetPassphrase(passphrase)
+				}
+			}
+		}
+		if module, ok := mod.manager.GetDefaultModule("transfers"); ok {
+			if queue, ok := module.(Queue); ok {
+				mod.SetQueue(queue)
+			}
+		}
+	}
+
+	fmt.Println("SyncClientModule enabled")
+	return nil
+}
+
+// Disable deactivates the module
+func (mod *SyncClientModule) Disable(ctx context.Context) error {
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("SyncClientModule disabled")
+	return nil
+}
+
+// SetManager sets the module manager
+func (mod *SyncClientModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// InitSyncClientModule creates and returns a new SyncClientModule instance
+func InitSyncClientModule() core.Module {
+	return NewSyncClientModule()
+}
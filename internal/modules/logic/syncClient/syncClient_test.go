@@ -0,0 +1,437 @@
+package syncclient
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	restapi "github.com/LaPingvino/recuerdo/internal/modules/logic/restApi"
+	"github.com/LaPingvino/recuerdo/internal/modules/logic/transfers"
+	"github.com/LaPingvino/recuerdo/internal/paths"
+	"github.com/LaPingvino/recuerdo/internal/reviewlog"
+	"github.com/LaPingvino/recuerdo/internal/vault"
+)
+
+func TestTwoTeachersEditOneLesson(t *testing.T) {
+	dir := t.TempDir()
+	lessonFile := `{"list":{"title":"Animals","items":[` +
+		`{"id":0,"questions":["cat"],"answers":["gato"]},` +
+		`{"id":1,"questions":["dog"],"answers":["perro"]}]}}`
+	if err := os.WriteFile(filepath.Join(dir, "animals.json"), []byte(lessonFile), 0644); err != nil {
+		t.Fatalf("Failed to write lesson: %v", err)
+	}
+
+	server := restapi.NewRestAPIModule()
+	server.SetLessonDir(dir)
+	httpServer := httptest.NewServer(server.Handler())
+	defer httpServer.Close()
+
+	alice, bob := NewSyncClientModule(), NewSyncClientModule()
+	alice.SetServer(httpServer.URL + "/")
+	bob.SetServer(httpServer.URL)
+
+	aliceLesson, err := alice.Pull("animals.json")
+	if err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	bobLesson, err := bob.Pull("animals.json")
+	if err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if alice.Revision("animals.json") != 1 || len(aliceLesson.List.Items) != 2 {
+		t.Fatalf("Expected revision 1 with 2 items, got %d with %+v", alice.Revision("animals.json"), aliceLesson.List.Items)
+	}
+
+	aliceLesson.List.Items[0].Answers = []string{"el gato"}
+	aliceLesson.List.Items[1].Answers = []string{"can"}
+	if _, conflicts, err := alice.Push("animals.json", aliceLesson); err != nil || len(conflicts) != 0 {
+		t.Fatalf("First push gave %v, %+v", err, conflicts)
+	}
+
+	bobLesson.List.Items[1].Answers = []string{"el perro"}
+	bobLesson.List.AddWordItem([]string{"fish"}, []string{"pez"}, "")
+	merged, conflicts, err := bob.Push("animals.json", bobLesson)
+	if err != nil {
+		t.Fatalf("Second push failed: %v", err)
+	}
+	if len(conflicts) != 1 || conflicts[0].ItemID != 1 || conflicts[0].Lost.Answers[0] != "can" {
+		t.Errorf("Expected a conflict on dog losing \"can\", got %+v", conflicts)
+	}
+	if bob.Revision("animals.json") != 3 {
+		t.Errorf("Revision = %d; want 3", bob.Revision("animals.json"))
+	}
+
+	answers := make(map[string]string)
+	for _, item := range merged.List.Items {
+		answers[item.Questions[0]] = item.Answers[0]
+	}
+	want := map[string]string{"cat": "el gato", "dog": "el perro", "fish": "pez"}
+	for question, answer := range want {
+		if answers[question] != answer {
+			t.Errorf("%s = %q; want %q", question, answers[question], answer)
+		}
+	}
+
+	saved, err := alice.Pull("animals.json")
+	if err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if len(saved.List.Items) != 3 {
+		t.Errorf("Expected the merged lesson on the server, got %+v", saved.List.Items)
+	}
+}
+
+func TestPullWithoutServer(t *testing.T) {
+	if _, err := NewSyncClientModule().Pull("animals.json"); err == nil {
+		t.Error("Expected an error without a sync server")
+	}
+}
+
+func TestSelectiveSync(t *testing.T) {
+	dir := t.TempDir()
+	lessonFile := `{"list":{"title":"Birdsong","sync":"desktop","items":[{"id":0,"questions":["robin"],"answers":["roodborst"]}]}}`
+	if err := os.WriteFile(filepath.Join(dir, "birdsong.json"), []byte(lessonFile), 0644); err != nil {
+		t.Fatalf("Failed to write lesson: %v", err)
+	}
+	server := restapi.NewRestAPIModule()
+	server.SetLessonDir(dir)
+	httpServer := httptest.NewServer(server.Handler())
+	defer httpServer.Close()
+
+	laptop, desktop := NewSyncClientModule(), NewSyncClientModule()
+	laptop.SetServer(httpServer.URL)
+	laptop.SetDeviceGroups([]string{"laptop"})
+	desktop.SetServer(httpServer.URL)
+	desktop.SetDeviceGroups([]string{"Desktop"})
+
+	if _, err := laptop.Pull("birdsong.json"); !errors.Is(err, ErrNotSynced) {
+		t.Errorf("Expected the laptop not to get the lesson, got %v", err)
+	}
+	birdsong, err := desktop.Pull("birdsong.json")
+	if err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if !desktop.Syncs(birdsong) || laptop.Syncs(birdsong) {
+		t.Error("Syncs does not follow the device groups")
+	}
+	// A client naming no groups does not get lessons restricted to groups
+	response, err := http.Get(httpServer.URL + "/api/sync/birdsong.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected a client without groups to be refused, got %d", response.StatusCode)
+	}
+
+	birdsong.List.Sync = lesson.SyncLocalOnly
+	if _, _, err := desktop.Push("birdsong.json", birdsong); !errors.Is(err, ErrNotSynced) {
+		t.Errorf("Expected a local lesson not to be pushed, got %v", err)
+	}
+}
+
+func TestSyncThroughTransfers(t *testing.T) {
+	dir := t.TempDir()
+	lessonFile := `{"list":{"title":"Animals","items":[{"id":0,"questions":["cat"],"answers":["gato"]}]}}`
+	if err := os.WriteFile(filepath.Join(dir, "animals.json"), []byte(lessonFile), 0644); err != nil {
+		t.Fatalf("Failed to write lesson: %v", err)
+	}
+	server := restapi.NewRestAPIModule()
+	server.SetLessonDir(dir)
+	httpServer := httptest.NewServer(server.Handler())
+	defer httpServer.Close()
+
+	queue := transfers.NewTransfersModule()
+	if err := queue.SetMeteredMode(transfers.MeteredNever); err != nil {
+		t.Fatal(err)
+	}
+	client := NewSyncClientModule()
+	client.SetServer(httpServer.URL)
+	client.SetQueue(queue)
+
+	pulled, err := client.Pull("animals.json")
+	if err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if _, _, err := client.Push("animals.json", pulled); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if _, err := client.Pull("missing.json"); err == nil {
+		t.Error("Expected pulling a missing lesson to fail")
+	}
+
+	list := queue.Transfers()
+	if len(list) != 3 || list[0].Kind != "Sync pull" || list[1].Kind != "Sync push" || list[1].Size == 0 || list[2].State != transfers.Failed {
+		t.Errorf("Expected the pull, push and failed pull in the queue, got %+v", list)
+	}
+}
+
+func TestEncryptedSync(t *testing.T) {
+	// The server keeps the key of its audit log in the data directory
+	t.Setenv(paths.EnvDataDir, t.TempDir())
+	dir := t.TempDir()
+	server := restapi.NewRestAPIModule()
+	server.SetLessonDir(dir)
+	httpServer := httptest.NewServer(server.Handler())
+	defer httpServer.Close()
+
+	alice, bob := NewSyncClientModule(), NewSyncClientModule()
+	for _, client := range []*SyncClientModule{alice, bob} {
+		client.SetServer(httpServer.URL)
+		client.SetPassphrase("correct horse battery staple")
+	}
+
+	animals := lesson.NewLessonData()
+	animals.List.Title = "Animals"
+	animals.List.AddWordItem([]string{"cat"}, []string{"gato"}, "")
+	animals.List.AddWordItem([]string{"dog"}, []string{"perro"}, "")
+	if _, _, err := alice.Push("animals.json", animals); err != nil {
+		t.Fatalf("First push failed: %v", err)
+	}
+	bobLesson, err := bob.Pull("animals.json")
+	if err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if len(bobLesson.List.Items) != 2 || bob.Revision("animals.json") != 1 {
+		t.Fatalf("Expected revision 1 with 2 items, got %d with %+v", bob.Revision("animals.json"), bobLesson.List.Items)
+	}
+
+	animals.List.Items[0].Answers = []string{"el gato"}
+	if _, _, err := alice.Push("animals.json", animals); err != nil {
+		t.Fatalf("Second push failed: %v", err)
+	}
+	bobLesson.List.Items[1].Answers = []string{"el perro"}
+	merged, conflicts, err := bob.Push("animals.json", bobLesson)
+	if err != nil || len(conflicts) != 0 {
+		t.Fatalf("Push of an older revision gave %v, %+v", err, conflicts)
+	}
+	if merged.List.Items[0].Answers[0] != "el gato" || merged.List.Items[1].Answers[0] != "el perro" {
+		t.Errorf("Expected both edits merged, got %+v", merged.List.Items)
+	}
+	if bob.Revision("animals.json") != 3 {
+		t.Errorf("Revision = %d; want 3", bob.Revision("animals.json"))
+	}
+
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			if data, _ := os.ReadFile(path); bytes.Contains(data, []byte("gato")) || bytes.Contains(data, []byte("animals")) {
+				t.Errorf("%s holds the lesson unencrypted", path)
+			}
+		}
+		return nil
+	})
+
+	eve := NewSyncClientModule()
+	eve.SetServer(httpServer.URL)
+	eve.SetPassphrase("wrong")
+	if _, err := eve.Pull("animals.json"); !errors.Is(err, vault.ErrWrongPassphrase) {
+		t.Errorf("Expected a wrong passphrase not to pull the lesson, got %v", err)
+	}
+	if _, _, err := eve.Push("notes.json", animals); !errors.Is(err, vault.ErrWrongPassphrase) {
+		t.Errorf("Expected a wrong passphrase not to push into the vault, got %v", err)
+	}
+	// Vaults from before the check blob are checked against their blobs
+	os.Remove(filepath.Join(dir, ".vault", "default", "blobs", vault.CheckBlob))
+	os.Remove(filepath.Join(dir, ".vault", "default", "blobs", vault.CheckBlob+".revision"))
+	mallory := NewSyncClientModule()
+	mallory.SetServer(httpServer.URL)
+	mallory.SetPassphrase("also wrong")
+	if _, err := mallory.Pull("animals.json"); !errors.Is(err, vault.ErrWrongPassphrase) {
+		t.Errorf("Expected a wrong passphrase refused by an old vault, got %v", err)
+	}
+	carol := NewSyncClientModule()
+	carol.SetServer(httpServer.URL)
+	carol.SetPassphrase("correct horse battery staple")
+	if _, err := carol.Pull("animals.json"); err != nil {
+		t.Errorf("Expected the passphrase to open an old vault, got %v", err)
+	}
+
+	aliceLog, err := reviewlog.Open(filepath.Join(t.TempDir(), "reviews.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer aliceLog.Close()
+	bobLog, err := reviewlog.Open(filepath.Join(t.TempDir(), "reviews.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bobLog.Close()
+	if err := aliceLog.Add(reviewlog.Review{Lesson: "animals.json", Question: "cat", Right: true, Time: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := alice.PushHistory(aliceLog); err != nil {
+		t.Fatalf("PushHistory failed: %v", err)
+	}
+	if err := bob.PushHistory(bobLog); err != nil {
+		t.Fatalf("PushHistory failed: %v", err)
+	}
+	if added, err := bob.PullHistory(bobLog); err != nil || added != 1 {
+		t.Errorf("Expected one review pulled, got %d, %v", added, err)
+	}
+	if added, err := bob.PullHistory(bobLog); err != nil || added != 0 {
+		t.Errorf("Expected pulling again to add nothing, got %d, %v", added, err)
+	}
+	if err := NewSyncClientModule().PushHistory(aliceLog); !errors.Is(err, vault.ErrPassphraseRequired) {
+		t.Errorf("Expected history sync to need a passphrase, got %v", err)
+	}
+
+	var export bytes.Buffer
+	if err := alice.ExportVault(&export); err != nil {
+		t.Fatalf("ExportVault failed: %v", err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(export.Bytes()), int64(export.Len()))
+	if err != nil {
+		t.Fatalf("Expected a zip export: %v", err)
+	}
+	// The salt, the check, the lesson, two histories and the manifest
+	if len(archive.File) != 6 {
+		t.Errorf("Expected 6 files in the export, got %d", len(archive.File))
+	}
+	if err := alice.DeleteVault(); err != nil {
+		t.Fatalf("DeleteVault failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".vault")); err == nil {
+		if entries, _ := os.ReadDir(filepath.Join(dir, ".vault")); len(entries) != 0 {
+			t.Errorf("Expected the vault to be gone, found %v", entries)
+		}
+	}
+	if _, err := bob.Pull("animals.json"); err == nil {
+		t.Error("Expected nothing to pull from a deleted vault")
+	}
+	if err := alice.ExportVault(&export); err == nil {
+		t.Error("Expected exporting a deleted vault to fail")
+	}
+}
+
+func TestAccountSync(t *testing.T) {
+	// The server keeps the key of its audit log in the data directory
+	t.Setenv(paths.EnvDataDir, t.TempDir())
+	dir := t.TempDir()
+	server := restapi.NewRestAPIModule()
+	server.SetLessonDir(dir)
+	httpServer := httptest.NewServer(server.Handler())
+	defer httpServer.Close()
+
+	laptop, desktop := NewSyncClientModule(), NewSyncClientModule()
+	for _, client := range []*SyncClientModule{laptop, desktop} {
+		client.SetServer(httpServer.URL)
+		client.SetAccount("ana")
+	}
+
+	animals := lesson.NewLessonData()
+	animals.List.Title = "Animals"
+	animals.List.AddWordItem([]string{"cat"}, []string{"gato"}, "")
+	animals.List.AddWordItem([]string{"dog"}, []string{"perro"}, "")
+	if _, _, err := laptop.Push("animals.json", animals); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	desktopLesson, err := desktop.Pull("animals.json")
+	if err != nil || len(desktopLesson.List.Items) != 2 {
+		t.Fatalf("Expected the lesson in the account, got %v, %v", desktopLesson, err)
+	}
+	animals.List.Items[0].Answers = []string{"el gato"}
+	if _, _, err := laptop.Push("animals.json", animals); err != nil {
+		t.Fatalf("Second push failed: %v", err)
+	}
+	desktopLesson.List.Items[1].Answers = []string{"el perro"}
+	merged, conflicts, err := desktop.Push("animals.json", desktopLesson)
+	if err != nil || len(conflicts) != 0 || merged.List.Items[0].Answers[0] != "el gato" || merged.List.Items[1].Answers[0] != "el perro" {
+		t.Fatalf("Expected both edits merged, got %+v, %+v, %v", merged, conflicts, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "animals.json")); !os.IsNotExist(err) {
+		t.Error("Expected the lesson of the account not among the shared lessons")
+	}
+	shared := NewSyncClientModule()
+	shared.SetServer(httpServer.URL)
+	if _, err := shared.Pull("animals.json"); err == nil {
+		t.Error("Expected the lesson of the account not to be pulled without it")
+	}
+
+	laptopLog, err := reviewlog.Open(filepath.Join(t.TempDir(), "reviews.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer laptopLog.Close()
+	desktopLog, err := reviewlog.Open(filepath.Join(t.TempDir(), "reviews.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer desktopLog.Close()
+	for _, question := range []string{"cat", "dog"} {
+		if err := laptopLog.Add(reviewlog.Review{Lesson: "animals.json", Question: question, Right: true, Time: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := desktopLog.Add(reviewlog.Review{Lesson: "animals.json", Question: "cat", Time: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if added, err := laptop.SyncHistory(laptopLog); err != nil || added != 0 {
+		t.Fatalf("First sync gave %d, %v", added, err)
+	}
+	if added, err := desktop.SyncHistory(desktopLog); err != nil || added != 2 {
+		t.Errorf("Expected the desktop to get the 2 answers of the laptop, got %d, %v", added, err)
+	}
+	if added, err := laptop.SyncHistory(laptopLog); err != nil || added != 1 {
+		t.Errorf("Expected the laptop to get the answer of the desktop, got %d, %v", added, err)
+	}
+	if added, err := desktop.SyncHistory(desktopLog); err != nil || added != 0 {
+		t.Errorf("Expected syncing again to add nothing, got %d, %v", added, err)
+	}
+	laptopEvents, _ := laptopLog.Diff(nil)
+	desktopEvents, _ := desktopLog.Diff(nil)
+	if len(laptopEvents) != 3 || len(desktopEvents) != 3 {
+		t.Errorf("Expected both devices to have 3 answers, got %d and %d", len(laptopEvents), len(desktopEvents))
+	}
+
+	var export bytes.Buffer
+	if err := laptop.ExportData(&export); err != nil {
+		t.Fatalf("ExportData failed: %v", err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(export.Bytes()), int64(export.Len()))
+	if err != nil {
+		t.Fatalf("Expected a zip export: %v", err)
+	}
+	names := make(map[string]bool)
+	for _, file := range archive.File {
+		names[file.Name] = true
+	}
+	if !names["lessons/animals.json"] {
+		t.Errorf("Expected the lesson in the export, got %v", names)
+	}
+
+	if err := laptop.DeleteData(); err != nil {
+		t.Fatalf("DeleteData failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".accounts", "ana")); !os.IsNotExist(err) {
+		t.Error("Expected the account to be deleted")
+	}
+	// The desktop still has the cursor of the deleted account
+	if _, err := desktop.SyncHistory(desktopLog); err != nil {
+		t.Errorf("Expected the desktop to fill the account anew, got %v", err)
+	}
+	if added, err := laptop.SyncHistory(laptopLog); err != nil || added != 0 {
+		t.Errorf("Expected nothing new after the account was filled anew, got %d, %v", added, err)
+	}
+	phoneLog, err := reviewlog.Open(filepath.Join(t.TempDir(), "reviews.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer phoneLog.Close()
+	phone := NewSyncClientModule()
+	phone.SetServer(httpServer.URL)
+	phone.SetAccount("ana")
+	if added, err := phone.SyncHistory(phoneLog); err != nil || added != 3 {
+		t.Errorf("Expected a new device to get all 3 answers, got %d, %v", added, err)
+	}
+
+	if _, err := NewSyncClientModule().SyncHistory(laptopLog); !errors.Is(err, ErrNoAccount) {
+		t.Errorf("Expected history sync to need an account, got %v", err)
+	}
+}
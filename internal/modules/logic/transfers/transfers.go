@@ -0,0 +1,505 @@
+// Package transfers queues downloads and uploads, so large ones, such as
+// the map tiles of a region or a subscribed lesson full of media, wait
+// while the connection is metered and start once it no longer is. Small
+// transfers are never held back.
+//
+// Transfers can be paused, resumed and retried after failing; run starts
+// over each time, so it should skip work a previous run already did, as
+// the tile download skips tiles it has cached.
+//
+// Whether the connection is metered is asked of the system (see
+// system.DetectMetered) unless the user says so in MeteredSetting.
+package transfers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/system"
+)
+
+// MeteredSetting is the settings key telling whether the connection is
+// metered: MeteredAuto, MeteredAlways or MeteredNever
+const MeteredSetting = "network.metered"
+
+// The values of MeteredSetting
+const (
+	// MeteredAuto asks the system, taking connections it cannot tell about
+	// as not metered
+	MeteredAuto   = "auto"
+	MeteredAlways = "yes"
+	MeteredNever  = "no"
+)
+
+// LargeTransferSize is the size from which a transfer waits for an
+// unmetered connection
+const LargeTransferSize = 2 << 20
+
+// checkInterval is how often the system is asked whether the connection
+// is metered
+const checkInterval = time.Minute
+
+// maxRunning bounds the transfers running at the same time
+const maxRunning = 2
+
+// State is where a transfer is in the queue
+type State string
+
+// The states of a transfer
+const (
+	Queued State = "queued"
+	// Deferred transfers wait for an unmetered connection
+	Deferred State = "deferred"
+	Running  State = "running"
+	// Paused transfers wait until resumed
+	Paused    State = "paused"
+	Done      State = "done"
+	Failed    State = "failed"
+	Cancelled State = "cancelled"
+)
+
+// Finished tells whether a transfer in the state is over
+func (s State) Finished() bool {
+	return s == Done || s == Failed || s == Cancelled
+}
+
+// Transfer is a download or upload in the queue
+type Transfer struct {
+	ID int
+	// Kind is what is transferred, such as "Map tiles", and Name which one
+	Kind string
+	Name string
+	// Size is the estimated size in bytes, 0 when not known
+	Size  int64
+	State State
+	// Err is why a failed transfer failed
+	Err     error
+	Added   time.Time
+	Started time.Time
+
+	run func(ctx context.Context) error
+	// force starts the transfer even on a metered connection
+	force bool
+	// pausing makes finish pause the transfer instead of cancelling it
+	pausing bool
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// Large tells whether the transfer waits for an unmetered connection
+func (t *Transfer) Large() bool {
+	return t.Size >= LargeTransferSize
+}
+
+// Settings is the part of the settings module the metered setting is kept
+// in
+type Settings interface {
+	GetString(key string) (string, error)
+	SetSetting(key string, value interface{}) error
+}
+
+// TransfersModule runs the transfers in its queue, holding back large ones
+// on a metered connection
+type TransfersModule struct {
+	*core.BaseModule
+	manager *core.Manager
+	// detect asks the system whether the connection is metered
+	detect func() (bool, error)
+
+	mu        sync.Mutex
+	mode      string
+	metered   bool
+	detected  bool
+	transfers []*Transfer
+	nextID    int
+	running   int
+	ctx       context.Context
+	cancel    context.CancelFunc
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// NewTransfersModule creates a new TransfersModule instance
+func NewTransfersModule() *TransfersModule {
+	base := core.NewBaseModule("transfers", "transfers-module")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &TransfersModule{
+		BaseModule: base,
+		detect:     system.DetectMetered,
+		mode:       MeteredAuto,
+		nextID:     1,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Add queues a transfer of about size bytes, 0 when not known, which run
+// carries out. It starts right away unless it is large and the connection
+// metered. It returns the ID of the transfer.
+func (mod *TransfersModule) Add(kind, name string, size int64, run func(ctx context.Context) error) int {
+	mod.mu.Lock()
+	detected := mod.detected
+	mod.mu.Unlock()
+	if !detected {
+		mod.Refresh()
+	}
+
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	transfer := &Transfer{
+		ID:    mod.nextID,
+		Kind:  kind,
+		Name:  name,
+		Size:  size,
+		State: Queued,
+		Added: time.Now(),
+		run:   run,
+		done:  make(chan struct{}),
+	}
+	mod.nextID++
+	mod.transfers = append(mod.transfers, transfer)
+	mod.schedule()
+	return transfer.ID
+}
+
+// Transfers returns the transfers in the queue, oldest first
+func (mod *TransfersModule) Transfers() []Transfer {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	transfers := make([]Transfer, 0, len(mod.transfers))
+	for _, transfer := range mod.transfers {
+		copied := *transfer
+		copied.run, copied.cancel, copied.done = nil, nil, nil
+		transfers = append(transfers, copied)
+	}
+	return transfers
+}
+
+// Wait waits until the transfer is finished and returns why it failed
+func (mod *TransfersModule) Wait(ctx context.Context, id int) error {
+	mod.mu.Lock()
+	transfer := mod.find(id)
+	mod.mu.Unlock()
+	if transfer == nil {
+		return fmt.Errorf("no transfer %d", id)
+	}
+	select {
+	case <-transfer.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	if transfer.State == Cancelled {
+		return context.Canceled
+	}
+	return transfer.Err
+}
+
+// StartNow starts a deferred transfer on the metered connection anyway
+func (mod *TransfersModule) StartNow(id int) error {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	transfer := mod.find(id)
+	if transfer == nil || transfer.State.Finished() {
+		return fmt.Errorf("no waiting transfer %d", id)
+	}
+	transfer.force = true
+	mod.schedule()
+	return nil
+}
+
+// Cancel takes a transfer out of the queue, stopping it when it runs
+func (mod *TransfersModule) Cancel(id int) error {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	transfer := mod.find(id)
+	if transfer == nil || transfer.State.Finished() {
+		return fmt.Errorf("no waiting transfer %d", id)
+	}
+	if transfer.State == Running {
+		// finish marks it cancelled once run returns
+		transfer.pausing = false
+		transfer.cancel()
+		return nil
+	}
+	transfer.State = Cancelled
+	close(transfer.done)
+	return nil
+}
+
+// Pause holds a transfer back until resumed, stopping it when it runs
+func (mod *TransfersModule) Pause(id int) error {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	transfer := mod.find(id)
+	if transfer == nil || transfer.State.Finished() || transfer.State == Paused {
+		return fmt.Errorf("no transfer %d to pause", id)
+	}
+	if transfer.State == Running {
+		// finish marks it paused once run returns
+		transfer.pausing = true
+		transfer.cancel()
+		return nil
+	}
+	transfer.State = Paused
+	return nil
+}
+
+// Resume queues a paused transfer again
+func (mod *TransfersModule) Resume(id int) error {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	transfer := mod.find(id)
+	if transfer == nil || transfer.State != Paused {
+		return fmt.Errorf("no paused transfer %d", id)
+	}
+	transfer.State = Queued
+	mod.schedule()
+	return nil
+}
+
+// Retry queues a failed transfer again
+func (mod *TransfersModule) Retry(id int) error {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	transfer := mod.find(id)
+	if transfer == nil || transfer.State != Failed {
+		return fmt.Errorf("no failed transfer %d", id)
+	}
+	transfer.State = Queued
+	transfer.Err = nil
+	transfer.done = make(chan struct{})
+	mod.schedule()
+	return nil
+}
+
+// ClearFinished removes the finished transfers from the queue
+func (mod *TransfersModule) ClearFinished() {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	kept := mod.transfers[:0]
+	for _, transfer := range mod.transfers {
+		if !transfer.State.Finished() {
+			kept = append(kept, transfer)
+		}
+	}
+	mod.transfers = kept
+}
+
+// Metered tells whether the connection is taken to be metered
+func (mod *TransfersModule) Metered() bool {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	return mod.metered
+}
+
+// MeteredMode returns how it is decided whether the connection is metered
+func (mod *TransfersModule) MeteredMode() string {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	return mod.mode
+}
+
+// SetMeteredMode sets how it is decided whether the connection is metered,
+// keeping it in the settings, and starts the transfers that may run now
+func (mod *TransfersModule) SetMeteredMode(mode string) error {
+	switch mode {
+	case MeteredAuto, MeteredAlways, MeteredNever:
+	default:
+		return fmt.Errorf("unknown metered mode %q", mode)
+	}
+	mod.mu.Lock()
+	mod.mode = mode
+	mod.mu.Unlock()
+	mod.Refresh()
+
+	if settings := mod.getSettings(); settings != nil {
+		return settings.SetSetting(MeteredSetting, mode)
+	}
+	return nil
+}
+
+// Refresh decides anew whether the connection is metered and starts the
+// transfers that may run now
+func (mod *TransfersModule) Refresh() {
+	mod.mu.Lock()
+	mode := mod.mode
+	mod.mu.Unlock()
+
+	metered := mode == MeteredAlways
+	if mode == MeteredAuto {
+		// Connections the system cannot tell about are taken as not metered
+		metered, _ = mod.detect()
+	}
+
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	mod.metered = metered
+	mod.detected = true
+	mod.schedule()
+}
+
+// find returns the transfer with the ID; the lock is held
+func (mod *TransfersModule) find(id int) *Transfer {
+	for _, transfer := range mod.transfers {
+		if transfer.ID == id {
+			return transfer
+		}
+	}
+	return nil
+}
+
+// schedule starts the transfers that may run, oldest first, and defers
+// the large ones on a metered connection; the lock is held
+func (mod *TransfersModule) schedule() {
+	for _, transfer := range mod.transfers {
+		switch {
+		case transfer.State != Queued && transfer.State != Deferred:
+		case mod.metered && transfer.Large() && !transfer.force:
+			transfer.State = Deferred
+		case mod.running >= maxRunning:
+			transfer.State = Queued
+		default:
+			mod.start(transfer)
+		}
+	}
+}
+
+// start runs a transfer in the background; the lock is held
+func (mod *TransfersModule) start(transfer *Transfer) {
+	ctx, cancel := context.WithCancel(mod.ctx)
+	transfer.State = Running
+	transfer.Started = time.Now()
+	transfer.cancel = cancel
+	mod.running++
+	go func() {
+		err := transfer.run(ctx)
+		cancelled := ctx.Err() != nil
+		cancel()
+		mod.finish(transfer, cancelled, err)
+	}()
+}
+
+// finish records how a transfer ended and starts the next one
+func (mod *TransfersModule) finish(transfer *Transfer, cancelled bool, err error) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	mod.running--
+	pausing := transfer.pausing
+	transfer.pausing = false
+	switch {
+	case err == nil:
+		transfer.State = Done
+	case pausing:
+		// Still waiting, so done stays open
+		transfer.State = Paused
+		mod.schedule()
+		return
+	case cancelled:
+		transfer.State = Cancelled
+	default:
+		transfer.State = Failed
+		transfer.Err = err
+		fmt.Printf("Warning: %s %s failed: %v\n", transfer.Kind, transfer.Name, err)
+	}
+	close(transfer.done)
+	mod.schedule()
+}
+
+// watch asks the system whether the connection is metered every minute,
+// until stop is closed
+func (mod *TransfersModule) watch(stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if mod.MeteredMode() == MeteredAuto {
+				mod.Refresh()
+			}
+		}
+	}
+}
+
+// getSettings returns the settings module, or nil when there is none
+func (mod *TransfersModule) getSettings() Settings {
+	if mod.manager == nil {
+		return nil
+	}
+	module, ok := mod.manager.GetDefaultModule("settings")
+	if !ok {
+		return nil
+	}
+	settings, _ := module.(Settings)
+	return settings
+}
+
+// Enable activates the module, reading the metered setting and starting
+// to follow the connection
+func (mod *TransfersModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	if settings := mod.getSettings(); settings != nil {
+		if mode, err := settings.GetString(MeteredSetting); err == nil {
+			switch mode {
+			case MeteredAuto, MeteredAlways, MeteredNever:
+				mod.mu.Lock()
+				mod.mode = mode
+				mod.mu.Unlock()
+			}
+		}
+	}
+
+	mod.mu.Lock()
+	if mod.ctx.Err() != nil {
+		mod.ctx, mod.cancel = context.WithCancel(context.Background())
+	}
+	mod.stop = make(chan struct{})
+	mod.done = make(chan struct{})
+	go mod.watch(mod.stop, mod.done)
+	mod.mu.Unlock()
+	mod.Refresh()
+
+	fmt.Println("TransfersModule enabled")
+	return nil
+}
+
+// Disable deactivates the module, stopping the running transfers
+func (mod *TransfersModule) Disable(ctx context.Context) error {
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	mod.mu.Lock()
+	stop, done := mod.stop, mod.done
+	mod.stop, mod.done = nil, nil
+	mod.cancel()
+	mod.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+
+	fmt.Println("TransfersModule disabled")
+	return nil
+}
+
+// SetManager sets the module manager
+func (mod *TransfersModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// InitTransfersModule creates and returns a new TransfersModule instance
+func InitTransfersModule() core.Module {
+	return NewTransfersModule()
+}
@@ -0,0 +1,160 @@
+package transfers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMeteredQueue(t *testing.T) {
+	mod := NewTransfersModule()
+	metered := true
+	mod.detect = func() (bool, error) { return metered, nil }
+	ctx := context.Background()
+
+	ran := make(chan string, 4)
+	run := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			ran <- name
+			return nil
+		}
+	}
+
+	small := mod.Add("Lesson subscription", "small", 10<<10, run("small"))
+	if err := mod.Wait(ctx, small); err != nil {
+		t.Fatalf("expected a small transfer to run on a metered connection, got %v", err)
+	}
+	large := mod.Add("Map tiles", "large", LargeTransferSize, run("large"))
+	if state := mod.Transfers()[1].State; state != Deferred {
+		t.Fatalf("expected a large transfer to be deferred, got %s", state)
+	}
+
+	metered = false
+	mod.Refresh()
+	if err := mod.Wait(ctx, large); err != nil {
+		t.Fatalf("expected the large transfer to run once unmetered, got %v", err)
+	}
+
+	if err := mod.SetMeteredMode(MeteredAlways); err != nil {
+		t.Fatal(err)
+	}
+	if !mod.Metered() {
+		t.Error("expected the connection to be taken as metered")
+	}
+	forced := mod.Add("Map tiles", "forced", LargeTransferSize, run("forced"))
+	cancelled := mod.Add("Map tiles", "cancelled", LargeTransferSize, run("cancelled"))
+	if err := mod.StartNow(forced); err != nil {
+		t.Fatal(err)
+	}
+	if err := mod.Wait(ctx, forced); err != nil {
+		t.Fatalf("expected a transfer started anyway to run, got %v", err)
+	}
+	if err := mod.Cancel(cancelled); err != nil {
+		t.Fatal(err)
+	}
+	if err := mod.Wait(ctx, cancelled); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a cancelled transfer, got %v", err)
+	}
+
+	close(ran)
+	var names []string
+	for name := range ran {
+		names = append(names, name)
+	}
+	if len(names) != 3 || names[0] != "small" || names[1] != "large" || names[2] != "forced" {
+		t.Errorf("unexpected transfers run: %v", names)
+	}
+
+	mod.ClearFinished()
+	if transfers := mod.Transfers(); len(transfers) != 0 {
+		t.Errorf("expected no transfers left, got %+v", transfers)
+	}
+}
+
+func TestFailedTransfer(t *testing.T) {
+	mod := NewTransfersModule()
+	mod.detect = func() (bool, error) { return false, nil }
+	failure := errors.New("server gone")
+	id := mod.Add("Lesson subscription", "broken", 0, func(context.Context) error { return failure })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := mod.Wait(ctx, id); !errors.Is(err, failure) {
+		t.Fatalf("expected the transfer's error, got %v", err)
+	}
+	if state := mod.Transfers()[0].State; state != Failed {
+		t.Errorf("expected a failed transfer, got %s", state)
+	}
+}
+
+func TestPauseResumeRetry(t *testing.T) {
+	mod := NewTransfersModule()
+	mod.detect = func() (bool, error) { return false, nil }
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	started := make(chan struct{}, 2)
+	runs := 0
+	id := mod.Add("Map tiles", "region", 0, func(ctx context.Context) error {
+		runs++
+		if runs == 1 {
+			started <- struct{}{}
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	})
+	<-started
+	if err := mod.Pause(id); err != nil {
+		t.Fatal(err)
+	}
+	for mod.Transfers()[0].State != Paused {
+		if ctx.Err() != nil {
+			t.Fatal("expected the running transfer to be paused")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := mod.Retry(id); err == nil {
+		t.Error("expected a paused transfer not to be retried")
+	}
+	if err := mod.Resume(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := mod.Wait(ctx, id); err != nil || runs != 2 {
+		t.Fatalf("expected the resumed transfer to run again, got %v after %d runs", err, runs)
+	}
+
+	failure := errors.New("server gone")
+	attempts := 0
+	failing := mod.Add("Sync push", "colours", 0, func(context.Context) error {
+		attempts++
+		if attempts == 1 {
+			return failure
+		}
+		return nil
+	})
+	if err := mod.Wait(ctx, failing); !errors.Is(err, failure) {
+		t.Fatalf("expected the first attempt to fail, got %v", err)
+	}
+	if err := mod.Retry(failing); err != nil {
+		t.Fatal(err)
+	}
+	if err := mod.Wait(ctx, failing); err != nil || attempts != 2 {
+		t.Errorf("expected the retry to succeed, got %v after %d attempts", err, attempts)
+	}
+
+	if err := mod.SetMeteredMode(MeteredAlways); err != nil {
+		t.Fatal(err)
+	}
+	queued := mod.Add("Map tiles", "later", LargeTransferSize, func(context.Context) error { return nil })
+	if err := mod.Pause(queued); err != nil {
+		t.Fatal(err)
+	}
+	if err := mod.Cancel(queued); err != nil {
+		t.Fatal(err)
+	}
+	if err := mod.Wait(ctx, queued); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a paused transfer to be cancelled, got %v", err)
+	}
+}
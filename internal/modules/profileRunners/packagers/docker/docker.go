@@ -0,0 +1,158 @@
+// Package docker packages recuerdo as a container image running the
+// headless serve mode.
+package docker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/paths"
+)
+
+// DockerfileOptions configures the generated image definition
+type DockerfileOptions struct {
+	GoVersion  string // Go toolchain image tag used to build
+	BaseImage  string // Runtime image
+	Port       int    // Port the REST API listens on
+	DataVolume string // Volume holding configuration, lessons and cache
+}
+
+// DefaultDockerfileOptions returns options for a Debian based image
+func DefaultDockerfileOptions() DockerfileOptions {
+	return DockerfileOptions{
+		GoVersion:  "1.25",
+		BaseImage:  "debian:bookworm-slim",
+		Port:       8080,
+		DataVolume: "/data",
+	}
+}
+
+// The binary links Qt through miqt and SQLite through cgo, so both stages
+// need the Qt 5 libraries even though serve mode never opens a window.
+var dockerfileTemplate = template.Must(template.New("Dockerfile").Parse(`# Generated by "recuerdo package docker"
+FROM golang:{{.GoVersion}}-bookworm AS build
+RUN apt-get update \
+ && apt-get install -y --no-install-recommends qtbase5-dev qtmultimedia5-dev \
+ && rm -rf /var/lib/apt/lists/*
+WORKDIR /src
+COPY go.mod go.sum ./
+RUN go mod download
+COPY . .
+RUN CGO_ENABLED=1 go build -trimpath -ldflags="-s -w" -o /out/recuerdo ./cmd/recuerdo
+
+FROM {{.BaseImage}}
+RUN apt-get update \
+ && apt-get install -y --no-install-recommends ca-certificates libqt5widgets5 libqt5multimedia5 \
+ && rm -rf /var/lib/apt/lists/* \
+ && useradd --system --home-dir {{.DataVolume}} --shell /usr/sbin/nologin recuerdo \
+ && mkdir -p {{.DataVolume}} && chown recuerdo {{.DataVolume}}
+COPY --from=build /out/recuerdo /usr/local/bin/recuerdo
+ENV HOME={{.DataVolume}} \
+    {{.EnvConfigDir}}={{.DataVolume}}/config \
+    {{.EnvDataDir}}={{.DataVolume}} \
+    {{.EnvCacheDir}}={{.DataVolume}}/cache \
+    {{.EnvLessonDir}}={{.DataVolume}}/lessons \
+    RECUERDO_ADDR=:{{.Port}}
+VOLUME {{.DataVolume}}
+USER recuerdo
+EXPOSE {{.Port}}
+HEALTHCHECK CMD ["/usr/local/bin/recuerdo", "healthcheck"]
+ENTRYPOINT ["/usr/local/bin/recuerdo", "serve"]
+`))
+
+// dockerignore keeps build output and editor files out of the build context
+const dockerignore = `.git
+*.test
+/recuerdo
+/cache
+`
+
+// DockerPackagerModule writes a container image definition for serve mode
+type DockerPackagerModule struct {
+	*core.BaseModule
+	manager *core.Manager
+	options DockerfileOptions
+}
+
+// NewDockerPackagerModule creates a new DockerPackagerModule instance
+func NewDockerPackagerModule() *DockerPackagerModule {
+	base := core.NewBaseModule("docker", "docker-module")
+
+	return &DockerPackagerModule{
+		BaseModule: base,
+		options:    DefaultDockerfileOptions(),
+	}
+}
+
+// SetOptions replaces the options used by Dockerfile and WritePackage
+func (mod *DockerPackagerModule) SetOptions(options DockerfileOptions) {
+	mod.options = options
+}
+
+// Dockerfile returns the generated image definition
+func (mod *DockerPackagerModule) Dockerfile() (string, error) {
+	data := struct {
+		DockerfileOptions
+		EnvConfigDir, EnvDataDir, EnvCacheDir, EnvLessonDir string
+	}{
+		DockerfileOptions: mod.options,
+		EnvConfigDir:      paths.EnvConfigDir,
+		EnvDataDir:        paths.EnvDataDir,
+		EnvCacheDir:       paths.EnvCacheDir,
+		EnvLessonDir:      paths.EnvLessonDir,
+	}
+
+	var out strings.Builder
+	if err := dockerfileTemplate.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// WritePackage writes a Dockerfile and .dockerignore into dir, which should
+// be the root of the source tree
+func (mod *DockerPackagerModule) WritePackage(dir string) error {
+	dockerfile, err := mod.Dockerfile()
+	if err != nil {
+		return fmt.Errorf("failed to generate Dockerfile: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte(dockerfile), 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, ".dockerignore"), []byte(dockerignore), 0644)
+}
+
+// Enable activates the module
+func (mod *DockerPackagerModule) Enable(ctx context.Context) error {
+	if err := mod.BaseModule.Enable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("DockerPackagerModule enabled")
+	return nil
+}
+
+// Disable deactivates the module
+func (mod *DockerPackagerModule) Disable(ctx context.Context) error {
+	if err := mod.BaseModule.Disable(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("DockerPackagerModule disabled")
+	return nil
+}
+
+// SetManager sets the module manager
+func (mod *DockerPackagerModule) SetManager(manager *core.Manager) {
+	mod.manager = manager
+}
+
+// InitDockerPackagerModule creates and returns a new DockerPackagerModule instance
+func InitDockerPackagerModule() core.Module {
+	return NewDockerPackagerModule()
+}
@@ -0,0 +1,243 @@
+package translationupdater
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/LaPingvino/recuerdo/internal/translations"
+)
+
+// translatedArgs lists the calls whose string arguments are shown to the
+// user, with the positions of those arguments. The Go sources have no tr()
+// like the Python version had, so the extractor recognises the Qt calls
+// instead; tr and Tr are still recognised for strings not passed to Qt
+// directly.
+var translatedArgs = map[string][]int{
+	"tr":                      {0},
+	"Tr":                      {0},
+	"SetText":                 {0},
+	"SetWindowTitle":          {0},
+	"SetTitle":                {0},
+	"SetToolTip":              {0},
+	"SetStatusTip":            {0},
+	"SetWhatsThis":            {0},
+	"SetPlaceholderText":      {0},
+	"SetInformativeText":      {0},
+	"SetSpecialValueText":     {0},
+	"SetSuffix":               {0},
+	"SetPrefix":               {0},
+	"ShowMessage":             {0},
+	"AddAction":               {0},
+	"AddMenu":                 {0},
+	"AddItem":                 {0},
+	"AddTab":                  {1},
+	"AddRow3":                 {0},
+	"NewQPushButton3":         {0},
+	"NewQLabel3":              {0},
+	"NewQCheckBox3":           {0},
+	"NewQRadioButton3":        {0},
+	"NewQGroupBox3":           {0},
+	"NewQAction2":             {0},
+	"QMessageBox_Warning":     {1, 2},
+	"QMessageBox_Information": {1, 2},
+	"QMessageBox_Question":    {1, 2},
+	"QMessageBox_Critical":    {1, 2},
+	"QMessageBox_About":       {1, 2},
+}
+
+// translatedListArgs lists the calls taking a []string of texts shown to
+// the user as their first argument
+var translatedListArgs = map[string]bool{
+	"SetHorizontalHeaderLabels": true,
+	"SetVerticalHeaderLabels":   true,
+	"AddItems":                  true,
+}
+
+// translatedPrefixes are the prefixes of dialog functions whose second and
+// third arguments are the title and label
+var translatedPrefixes = []string{"QInputDialog_Get", "QFileDialog_Get"}
+
+// translatorsComment marks comments meant for translators, as with
+// xgettext --add-comments=TRANSLATORS
+const translatorsComment = "TRANSLATORS"
+
+// Extract collects the strings shown to the user from the Go sources under
+// root into a template catalog. Tests, hidden directories and the legacy
+// Python sources are skipped. References are relative to root.
+func Extract(root string) (*translations.Catalog, error) {
+	catalog := translations.NewCatalog("")
+	fset := token.NewFileSet()
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := entry.Name()
+		if entry.IsDir() {
+			if path != root && (strings.HasPrefix(name, ".") || name == "legacy" || name == "vendor" || name == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			return nil
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		extractFile(catalog, fset, file, filepath.ToSlash(relative))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return catalog, nil
+}
+
+// extractFile adds the strings of one source file to the catalog
+func extractFile(catalog *translations.Catalog, fset *token.FileSet, file *ast.File, name string) {
+	// Comments for translators, by the line they end on
+	notes := make(map[int]string)
+	for _, group := range file.Comments {
+		text := strings.TrimSpace(group.Text())
+		if strings.HasPrefix(text, translatorsComment) {
+			notes[fset.Position(group.End()).Line] = strings.Join(strings.Fields(text), " ")
+		}
+	}
+
+	add := func(expr ast.Expr, call *ast.CallExpr) {
+		text, format, ok := stringValue(expr)
+		if !ok || !translatable(text) {
+			return
+		}
+		line := fset.Position(expr.Pos()).Line
+		message := &translations.Message{
+			ID:         text,
+			References: []string{name + ":" + strconv.Itoa(line)},
+		}
+		if format {
+			message.Flags = []string{"c-format"}
+		}
+		callLine := fset.Position(call.Pos()).Line
+		for _, noteLine := range []int{line, line - 1, callLine, callLine - 1} {
+			if note, ok := notes[noteLine]; ok {
+				message.ExtractedComments = []string{note}
+				break
+			}
+		}
+		catalog.Add(message)
+	}
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		name := calleeName(call)
+		positions := translatedArgs[name]
+		for _, prefix := range translatedPrefixes {
+			if strings.HasPrefix(name, prefix) {
+				positions = []int{1, 2}
+			}
+		}
+		for _, position := range positions {
+			if position < len(call.Args) {
+				add(call.Args[position], call)
+			}
+		}
+		if translatedListArgs[name] && len(call.Args) > 0 {
+			if list, ok := call.Args[0].(*ast.CompositeLit); ok {
+				for _, element := range list.Elts {
+					add(element, call)
+				}
+			}
+		}
+		return true
+	})
+}
+
+// calleeName returns the name of the called function or method
+func calleeName(call *ast.CallExpr) string {
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		return fun.Name
+	case *ast.SelectorExpr:
+		return fun.Sel.Name
+	}
+	return ""
+}
+
+// stringValue returns the text of a string literal, a concatenation of
+// them, or the format of a fmt.Sprintf call, which is reported as such
+func stringValue(expr ast.Expr) (text string, format bool, ok bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.STRING {
+			return "", false, false
+		}
+		text, err := strconv.Unquote(e.Value)
+		return text, false, err == nil
+	case *ast.ParenExpr:
+		return stringValue(e.X)
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return "", false, false
+		}
+		left, _, ok := stringValue(e.X)
+		if !ok {
+			return "", false, false
+		}
+		right, _, ok := stringValue(e.Y)
+		return left + right, false, ok
+	case *ast.CallExpr:
+		selector, isSelector := e.Fun.(*ast.SelectorExpr)
+		if !isSelector || selector.Sel.Name != "Sprintf" || len(e.Args) == 0 {
+			return "", false, false
+		}
+		if pkg, isIdent := selector.X.(*ast.Ident); !isIdent || pkg.Name != "fmt" {
+			return "", false, false
+		}
+		text, _, ok := stringValue(e.Args[0])
+		return text, ok && cFormat(text), ok
+	}
+	return "", false, false
+}
+
+// formatVerb matches the verbs of fmt format strings
+var formatVerb = regexp.MustCompile(`%[-+# 0-9.*\[\]]*[a-zA-Z%]`)
+
+// cFormat reports whether text has format verbs that C's printf shares with
+// fmt, so gettext tools can check translations keep them. Go-only verbs
+// such as %v and %q are not understood by those tools.
+func cFormat(text string) bool {
+	verbs := formatVerb.FindAllString(text, -1)
+	for _, verb := range verbs {
+		if !strings.ContainsRune("dsfxXceEgGo%", rune(verb[len(verb)-1])) || strings.Contains(verb, "[") {
+			return false
+		}
+	}
+	return len(verbs) > 0
+}
+
+// translatable reports whether text has something to translate: symbols,
+// numbers and format verbs alone are left out
+func translatable(text string) bool {
+	for _, r := range formatVerb.ReplaceAllString(text, "") {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,24 +1,72 @@
 // Package translationupdater provides functionality ported from Python module
-//
-// Package translationupdater provides functionality ported from Python module
 // legacy/modules/org/openteacher/profileRunners/translationUpdater/translationUpdater.py
 //
-// This is an automated port - implementation may be incomplete.
-//
-// This is an automated port - implementation may be incomplete.
+// It runs the update-translations profile: the strings shown to the user
+// are extracted from the Go sources into a .pot template, which is merged
+// into the .po catalog of every language. Where a catalog lacks a
+// translation, the catalogs of the Python version are searched for one, so
+// the work of the translators carries over.
 package translationupdater
 
 import (
 	"context"
 	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/translations"
 )
 
+// TemplateName is the file name of the template in the translations
+// directory
+const TemplateName = "recuerdo.pot"
+
+// Options configures an update
+type Options struct {
+	SourceDir       string // Root of the Go sources
+	TranslationsDir string // Directory of the template and .po catalogs
+	// LegacyDir holds the Python version, whose translations are reused.
+	// Empty to not reuse them.
+	LegacyDir      string
+	PackageName    string
+	PackageVersion string
+	BugsAddress    string
+	// Languages are the codes of languages to start a catalog for when
+	// there is none yet, such as "nl" or "pt_BR"
+	Languages []string
+}
+
+// DefaultOptions returns options for running from the root of the source
+// tree
+func DefaultOptions() Options {
+	return Options{
+		SourceDir:       ".",
+		TranslationsDir: "translations",
+		LegacyDir:       "legacy",
+		PackageName:     "Recuerdo",
+		BugsAddress:     "https://github.com/LaPingvino/recuerdo/issues",
+	}
+}
+
+// LanguageStats are the counts of one language after an update
+type LanguageStats struct {
+	Language string
+	translations.Stats
+}
+
 // TranslationupdaterModule is a Go port of the Python TranslationupdaterModule class
 type TranslationupdaterModule struct {
 	*core.BaseModule
 	manager *core.Manager
-	// TODO: Add module-specific fields
+	options Options
+	// now returns the time written in headers; replaced in tests
+	now func() time.Time
 }
 
 // NewTranslationupdaterModule creates a new TranslationupdaterModule instance
@@ -28,6 +76,173 @@ func NewTranslationupdaterModule() *TranslationupdaterModule {
 
 	return &TranslationupdaterModule{
 		BaseModule: base,
+		options:    DefaultOptions(),
+		now:        time.Now,
+	}
+}
+
+// SetOptions replaces the options of the next update
+func (mod *TranslationupdaterModule) SetOptions(options Options) {
+	mod.options = options
+}
+
+// Update writes the template, then merges it into every catalog in the
+// translations directory, creating catalogs for new languages first. It
+// returns the counts of every language, sorted by language code.
+func (mod *TranslationupdaterModule) Update() ([]LanguageStats, error) {
+	options := mod.options
+	pot, err := Extract(options.SourceDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract strings: %w", err)
+	}
+	pot.HeaderComments = []string{
+		fmt.Sprintf("Template for translations of %s.", options.PackageName),
+		"This file is generated by \"recuerdo update-translations\"; edit the .po files instead.",
+	}
+	pot.Header = mod.header("", "")
+
+	if err := os.MkdirAll(options.TranslationsDir, 0755); err != nil {
+		return nil, err
+	}
+	if err := writeCatalog(filepath.Join(options.TranslationsDir, TemplateName), pot); err != nil {
+		return nil, err
+	}
+
+	legacy, err := legacyCatalogs(options.LegacyDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the translations of the Python version: %w", err)
+	}
+
+	for _, language := range options.Languages {
+		path := filepath.Join(options.TranslationsDir, language+".po")
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+		catalog := translations.NewCatalog(mod.header(language, pluralForms(legacy[language])))
+		catalog.HeaderComments = []string{fmt.Sprintf("Translation of %s.", options.PackageName)}
+		if err := writeCatalog(path, catalog); err != nil {
+			return nil, err
+		}
+	}
+
+	catalogs, err := filepath.Glob(filepath.Join(options.TranslationsDir, "*.po"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(catalogs)
+
+	var stats []LanguageStats
+	for _, path := range catalogs {
+		language := strings.TrimSuffix(filepath.Base(path), ".po")
+		po, err := readCatalog(path)
+		if err != nil {
+			return nil, err
+		}
+		merged := translations.Merge(po, pot, legacy[language]...)
+		merged.SetHeaderField("Project-Id-Version", strings.TrimSpace(options.PackageName+" "+options.PackageVersion))
+		merged.SetHeaderField("POT-Creation-Date", pot.HeaderField("POT-Creation-Date"))
+		if err := writeCatalog(path, merged); err != nil {
+			return nil, err
+		}
+		stats = append(stats, LanguageStats{Language: language, Stats: merged.Stats()})
+	}
+	return stats, nil
+}
+
+// header returns the header of the template, or of a new catalog for
+// language when it is set
+func (mod *TranslationupdaterModule) header(language, plurals string) string {
+	options := mod.options
+	catalog := translations.NewCatalog("")
+	catalog.SetHeaderField("Project-Id-Version", strings.TrimSpace(options.PackageName+" "+options.PackageVersion))
+	catalog.SetHeaderField("Report-Msgid-Bugs-To", options.BugsAddress)
+	catalog.SetHeaderField("POT-Creation-Date", mod.now().Format("2006-01-02 15:04-0700"))
+	if language == "" {
+		catalog.SetHeaderField("PO-Revision-Date", "YEAR-MO-DA HO:MI+ZONE")
+		catalog.SetHeaderField("Last-Translator", "FULL NAME <EMAIL@ADDRESS>")
+		catalog.SetHeaderField("Language-Team", "LANGUAGE <LL@li.org>")
+	} else {
+		catalog.SetHeaderField("PO-Revision-Date", mod.now().Format("2006-01-02 15:04-0700"))
+		catalog.SetHeaderField("Language", language)
+	}
+	catalog.SetHeaderField("MIME-Version", "1.0")
+	catalog.SetHeaderField("Content-Type", "text/plain; charset=UTF-8")
+	catalog.SetHeaderField("Content-Transfer-Encoding", "8bit")
+	if plurals != "" {
+		catalog.SetHeaderField("Plural-Forms", plurals)
+	}
+	return catalog.Header
+}
+
+// pluralForms returns the Plural-Forms header the catalogs of the Python
+// version used for a language, or "" when they have none
+func pluralForms(catalogs []*translations.Catalog) string {
+	for _, catalog := range catalogs {
+		if plurals := catalog.HeaderField("Plural-Forms"); plurals != "" {
+			return plurals
+		}
+	}
+	return ""
+}
+
+// legacyCatalogs reads the .po files of every module of the Python version,
+// by language
+func legacyCatalogs(dir string) (map[string][]*translations.Catalog, error) {
+	catalogs := make(map[string][]*translations.Catalog)
+	if dir == "" {
+		return catalogs, nil
+	}
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return catalogs, nil
+	}
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || filepath.Ext(path) != ".po" || filepath.Base(filepath.Dir(path)) != "translations" {
+			return nil
+		}
+		catalog, err := readCatalog(path)
+		if err != nil {
+			return err
+		}
+		language := strings.TrimSuffix(entry.Name(), ".po")
+		catalogs[language] = append(catalogs[language], catalog)
+		return nil
+	})
+	return catalogs, err
+}
+
+func readCatalog(path string) (*translations.Catalog, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	catalog, err := translations.Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return catalog, nil
+}
+
+func writeCatalog(path string, catalog *translations.Catalog) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := catalog.Write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// WriteReport writes the counts of every language as a table
+func WriteReport(w io.Writer, stats []LanguageStats) {
+	for _, language := range stats {
+		fmt.Fprintf(w, "%-8s %4d translated, %4d fuzzy, %4d untranslated (%d%%)\n",
+			language.Language, language.Translated, language.Fuzzy, language.Untranslated, language.Percent())
 	}
 }
 
@@ -38,8 +253,6 @@ func (mod *TranslationupdaterModule) Enable(ctx context.Context) error {
 		return err
 	}
 
-	// TODO: Port Python enable logic
-
 	fmt.Println("TranslationupdaterModule enabled")
 	return nil
 }
@@ -51,8 +264,6 @@ func (mod *TranslationupdaterModule) Disable(ctx context.Context) error {
 		return err
 	}
 
-	// TODO: Port Python disable logic
-
 	fmt.Println("TranslationupdaterModule disabled")
 	return nil
 }
@@ -66,4 +277,4 @@ func (mod *TranslationupdaterModule) SetManager(manager *core.Manager) {
 // This is the Go equivalent of the Python init function
 func InitTranslationupdaterModule() core.Module {
 	return NewTranslationupdaterModule()
-}
\ No newline at end of file
+}
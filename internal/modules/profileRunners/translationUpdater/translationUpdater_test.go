@@ -0,0 +1,140 @@
+package translationupdater
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/translations"
+)
+
+const source = `package gui
+
+import "fmt"
+
+func build() {
+	button.SetText("Open file")
+	// TRANSLATORS: the title of the main window
+	window.SetWindowTitle("Recuerdo")
+	label.SetText(fmt.Sprintf("%d words", n))
+	label.SetText(fmt.Sprintf("Error: %v", err))
+	qt.QMessageBox_Warning(nil, "Warning", "Could not " + "save")
+	table.SetHorizontalHeaderLabels([]string{"Question", "Answer", "★"})
+	button.SetObjectName("notShown")
+	other.SetText("Open file")
+}
+`
+
+const legacyDutch = `msgid ""
+msgstr ""
+"Language: nl\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+msgid "Question"
+msgstr "Vraag"
+
+msgid "Open file..."
+msgstr "Bestand openen..."
+`
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "gui", "gui.go"), source)
+	writeFile(t, filepath.Join(dir, "gui", "gui_test.go"), "package gui\nfunc f() { b.SetText(\"Test only\") }\n")
+	writeFile(t, filepath.Join(dir, "legacy", "old.go"), "package old\nfunc f() { b.SetText(\"Legacy\") }\n")
+
+	catalog, err := Extract(dir)
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	var ids []string
+	for _, message := range catalog.Messages {
+		ids = append(ids, message.ID)
+	}
+	want := []string{"Open file", "Recuerdo", "%d words", "Error: %v", "Warning", "Could not save", "Question", "Answer"}
+	if strings.Join(ids, "|") != strings.Join(want, "|") {
+		t.Fatalf("extracted %q, want %q", ids, want)
+	}
+
+	if m := catalog.Find("", "Open file"); len(m.References) != 2 || m.References[0] != "gui/gui.go:6" {
+		t.Errorf("references = %q", m.References)
+	}
+	if m := catalog.Find("", "Recuerdo"); len(m.ExtractedComments) != 1 || m.ExtractedComments[0] != "TRANSLATORS: the title of the main window" {
+		t.Errorf("comments = %q", m.ExtractedComments)
+	}
+	if m := catalog.Find("", "%d words"); !m.HasFlag("c-format") {
+		t.Errorf("%%d words should be a c-format string: %q", m.Flags)
+	}
+	if m := catalog.Find("", "Error: %v"); m.HasFlag("c-format") {
+		t.Errorf("%%v is not a C format verb: %q", m.Flags)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "src", "gui.go"), source)
+	writeFile(t, filepath.Join(dir, "legacy", "gui", "translations", "nl.po"), legacyDutch)
+	writeFile(t, filepath.Join(dir, "translations", "de.po"), "msgid \"\"\nmsgstr \"Language: de\\n\"\n\nmsgid \"Answer\"\nmsgstr \"Antwort\"\n")
+
+	mod := NewTranslationupdaterModule()
+	mod.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 0, 0, time.UTC) }
+	mod.SetOptions(Options{
+		SourceDir:       filepath.Join(dir, "src"),
+		TranslationsDir: filepath.Join(dir, "translations"),
+		LegacyDir:       filepath.Join(dir, "legacy"),
+		PackageName:     "Recuerdo",
+		PackageVersion:  "1.0",
+		Languages:       []string{"nl"},
+	})
+
+	stats, err := mod.Update()
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if len(stats) != 2 || stats[0].Language != "de" || stats[1].Language != "nl" {
+		t.Fatalf("stats = %+v", stats)
+	}
+	if stats[0].Stats != (translations.Stats{Translated: 1, Untranslated: 7}) {
+		t.Errorf("de = %+v", stats[0].Stats)
+	}
+	if stats[1].Stats != (translations.Stats{Translated: 1, Fuzzy: 1, Untranslated: 6}) {
+		t.Errorf("nl = %+v", stats[1].Stats)
+	}
+
+	pot, err := readCatalog(filepath.Join(dir, "translations", TemplateName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pot.Messages) != 8 || pot.HeaderField("Project-Id-Version") != "Recuerdo 1.0" {
+		t.Errorf("template has %d messages, header %q", len(pot.Messages), pot.Header)
+	}
+
+	nl, err := readCatalog(filepath.Join(dir, "translations", "nl.po"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nl.HeaderField("Plural-Forms") != "nplurals=2; plural=(n != 1);" {
+		t.Errorf("new catalog should take the plural forms of the Python version: %q", nl.Header)
+	}
+	if m := nl.Find("", "Open file"); m.Str[0] != "Bestand openen..." || !m.Fuzzy() {
+		t.Errorf("Open file = %+v", m)
+	}
+
+	var report strings.Builder
+	WriteReport(&report, stats)
+	if !strings.Contains(report.String(), "nl          1 translated,    1 fuzzy,    6 untranslated (12%)") {
+		t.Errorf("report:\n%s", report.String())
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/LaPingvino/recuerdo/internal/core"
+	"github.com/LaPingvino/recuerdo/internal/paths"
 	"os"
 	"path/filepath"
 	"sync"
@@ -24,8 +25,7 @@ func NewSettingsModule() *SettingsModule {
 	base.SetPriority(1500) // High priority - many modules depend on settings
 
 	// Default settings file path
-	homeDir, _ := os.UserHomeDir()
-	settingsPath := filepath.Join(homeDir, ".openteacher", "settings.json")
+	settingsPath := paths.ConfigFile("settings.json")
 
 	return &SettingsModule{
 		BaseModule: base,
@@ -178,7 +178,9 @@ func (s *SettingsModule) LoadSettings() error {
 	return nil
 }
 
-// SaveSettings persists settings to storage
+// SaveSettings persists settings to storage. The file holds the sync
+// passphrase and token and the passwords and tokens of cloud storage, so
+// only its owner may read it, however it was created.
 func (s *SettingsModule) SaveSettings() error {
 	s.mu.RLock()
 	settingsCopy := make(map[string]interface{})
@@ -192,9 +194,12 @@ func (s *SettingsModule) SaveSettings() error {
 		return fmt.Errorf("failed to marshal settings: %w", err)
 	}
 
-	if err := os.WriteFile(s.filePath, data, 0644); err != nil {
+	if err := os.WriteFile(s.filePath, data, 0600); err != nil {
 		return fmt.Errorf("failed to write settings file: %w", err)
 	}
+	if err := os.Chmod(s.filePath, 0600); err != nil {
+		return fmt.Errorf("failed to protect settings file: %w", err)
+	}
 
 	return nil
 }
@@ -245,6 +245,21 @@ func TestSettingsModule(t *testing.T) {
 		assert.Equal(t, 42, value2)
 	})
 
+	t.Run("save_only_readable_by_owner", func(t *testing.T) {
+		settingsPath := filepath.Join(t.TempDir(), "settings.json")
+		// Files saved before held secrets readable by everyone
+		require.NoError(t, os.WriteFile(settingsPath, []byte("{}"), 0644))
+
+		module := NewSettingsModule()
+		require.NoError(t, module.SetSettingsPath(settingsPath))
+		require.NoError(t, module.SetSetting("sync.passphrase", "correct horse"))
+		require.NoError(t, module.SaveSettings())
+
+		info, err := os.Stat(settingsPath)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+
 	t.Run("load_nonexistent_file", func(t *testing.T) {
 		module := NewSettingsModule()
 		err := module.SetSettingsPath("/nonexistent/path/settings.json")
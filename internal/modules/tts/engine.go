@@ -436,3 +436,55 @@ func (e *Engine) SpeakAndWait(text string, timeout time.Duration) error {
 	}
 	return e.WaitUntilDone(timeout)
 }
+
+// SynthesizeToFile renders speech for the given text into a WAV file instead
+// of playing it, so it can be used for audio exports
+func (e *Engine) SynthesizeToFile(ctx context.Context, text, wavPath string) error {
+	e.mu.RLock()
+	backend, voiceID, rate, volume := e.backend, e.voiceID, e.rate, e.volume
+	e.mu.RUnlock()
+
+	var cmd *exec.Cmd
+
+	switch backend {
+	case "say":
+		args := []string{"-v", voiceID, "-r", strconv.Itoa(rate),
+			"-o", wavPath, "--file-format=WAVE", "--data-format=LEI16@22050"}
+		cmd = exec.CommandContext(ctx, "say", append(args, text)...)
+
+	case "espeak", "espeak-ng":
+		args := []string{
+			"-v", voiceID,
+			"-s", strconv.Itoa(rate),
+			"-a", strconv.Itoa(int(volume * 100)),
+			"-w", wavPath,
+			text,
+		}
+		cmd = exec.CommandContext(ctx, backend, args...)
+
+	case "powershell":
+		script := fmt.Sprintf(`
+			Add-Type -AssemblyName System.Speech;
+			$synth = New-Object System.Speech.Synthesis.SpeechSynthesizer;
+			$synth.SelectVoice('%s');
+			$synth.Rate = %d;
+			$synth.Volume = %d;
+			$synth.SetOutputToWaveFile('%s');
+			$synth.Speak('%s');
+			$synth.Dispose()
+		`, voiceID, e.rateToWindowsRange(), int(volume*100),
+			strings.ReplaceAll(wavPath, "'", "''"), strings.ReplaceAll(text, "'", "''"))
+
+		cmd = exec.CommandContext(ctx, "powershell", "-Command", script)
+
+	case "none":
+		return fmt.Errorf("no text-to-speech backend available")
+
+	default:
+		return fmt.Errorf("unsupported TTS backend: %s", backend)
+	}
+
+	cmd.Stderr = nil
+
+	return cmd.Run()
+}
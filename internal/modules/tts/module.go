@@ -2,6 +2,7 @@
 package tts
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -148,6 +149,16 @@ func (m *TTSModule) IsAvailable() bool {
 	return m.engine.IsAvailable()
 }
 
+// SynthesizeToFile renders text into a WAV file, optionally in a specific language
+func (m *TTSModule) SynthesizeToFile(text, language, wavPath string) error {
+	if language != "" {
+		m.selectVoiceForLanguage(language)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	return m.engine.SynthesizeToFile(ctx, text, wavPath)
+}
+
 // GetBackend returns the TTS backend being used
 func (m *TTSModule) GetBackend() string {
 	return m.engine.GetBackend()
@@ -0,0 +1,112 @@
+// Package paths resolves the directories recuerdo keeps its files in.
+//
+// Every directory can be overridden with an environment variable, which is
+// how container images point them at mounted volumes. Otherwise the XDG base
+// directory specification is followed, except that an existing
+// ~/.openteacher directory keeps being used for configuration so upgrading
+// users do not lose their settings.
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// AppName is the directory name used below the base directories
+const AppName = "recuerdo"
+
+// Environment variables overriding the directories
+const (
+	EnvConfigDir = "RECUERDO_CONFIG_DIR"
+	EnvDataDir   = "RECUERDO_DATA_DIR"
+	EnvCacheDir  = "RECUERDO_CACHE_DIR"
+	EnvLessonDir = "RECUERDO_LESSONS"
+)
+
+// legacyDirName is the configuration directory OpenTeacher used
+const legacyDirName = ".openteacher"
+
+// ConfigDir returns the directory for settings and presets
+func ConfigDir() string {
+	if dir := os.Getenv(EnvConfigDir); dir != "" {
+		return dir
+	}
+	if os.Getenv("XDG_CONFIG_HOME") == "" {
+		if legacy := legacyDir(); legacy != "" {
+			return legacy
+		}
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		return filepath.Join(dir, AppName)
+	}
+	return fallbackDir("config")
+}
+
+// DataDir returns the directory for data the user creates, such as lessons
+// and review history
+func DataDir() string {
+	if dir := os.Getenv(EnvDataDir); dir != "" {
+		return dir
+	}
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, AppName)
+	}
+
+	switch runtime.GOOS {
+	case "windows", "darwin", "ios", "plan9":
+		// These have no separate data directory convention
+		if dir, err := os.UserConfigDir(); err == nil {
+			return filepath.Join(dir, AppName)
+		}
+	default:
+		if home, err := os.UserHomeDir(); err == nil && home != "" {
+			return filepath.Join(home, ".local", "share", AppName)
+		}
+	}
+	return fallbackDir("data")
+}
+
+// CacheDir returns the directory for files that can be recreated, such as
+// downloaded map tiles and generated audio
+func CacheDir() string {
+	if dir := os.Getenv(EnvCacheDir); dir != "" {
+		return dir
+	}
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, AppName)
+	}
+	return fallbackDir("cache")
+}
+
+// LessonDir returns the directory lessons are served from in serve mode
+func LessonDir() string {
+	if dir := os.Getenv(EnvLessonDir); dir != "" {
+		return dir
+	}
+	return filepath.Join(DataDir(), "lessons")
+}
+
+// ConfigFile returns the path of a file in the configuration directory
+func ConfigFile(name string) string {
+	return filepath.Join(ConfigDir(), name)
+}
+
+// legacyDir returns ~/.openteacher when it exists
+func legacyDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return ""
+	}
+	dir := filepath.Join(home, legacyDirName)
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		return dir
+	}
+	return ""
+}
+
+// fallbackDir is used when no home directory is known, as happens for
+// container users without a passwd entry
+func fallbackDir(kind string) string {
+	return filepath.Join(os.TempDir(), AppName, kind)
+}
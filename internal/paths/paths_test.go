@@ -0,0 +1,62 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestEnvironmentOverrides(t *testing.T) {
+	t.Setenv(EnvConfigDir, "/srv/recuerdo/config")
+	t.Setenv(EnvDataDir, "/srv/recuerdo/data")
+	t.Setenv(EnvCacheDir, "/srv/recuerdo/cache")
+	t.Setenv(EnvLessonDir, "")
+
+	if got := ConfigDir(); got != "/srv/recuerdo/config" {
+		t.Errorf("ConfigDir() = %s", got)
+	}
+	if got := CacheDir(); got != "/srv/recuerdo/cache" {
+		t.Errorf("CacheDir() = %s", got)
+	}
+	if got := LessonDir(); got != filepath.Join("/srv/recuerdo/data", "lessons") {
+		t.Errorf("LessonDir() = %s", got)
+	}
+
+	t.Setenv(EnvLessonDir, "/lessons")
+	if got := LessonDir(); got != "/lessons" {
+		t.Errorf("LessonDir() with override = %s", got)
+	}
+}
+
+func TestXDGDirectories(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("XDG directories only apply on Linux")
+	}
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(EnvConfigDir, "")
+	t.Setenv(EnvDataDir, "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_DATA_HOME", "")
+
+	if got := ConfigDir(); got != filepath.Join(home, ".config", AppName) {
+		t.Errorf("ConfigDir() = %s", got)
+	}
+	if got := DataDir(); got != filepath.Join(home, ".local", "share", AppName) {
+		t.Errorf("DataDir() = %s", got)
+	}
+
+	// An existing OpenTeacher directory wins unless XDG_CONFIG_HOME is set
+	if err := os.Mkdir(filepath.Join(home, ".openteacher"), 0755); err != nil {
+		t.Fatalf("Failed to create legacy directory: %v", err)
+	}
+	if got := ConfigDir(); got != filepath.Join(home, ".openteacher") {
+		t.Errorf("ConfigDir() with legacy directory = %s", got)
+	}
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "xdg"))
+	if got := ConfigDir(); got != filepath.Join(home, "xdg", AppName) {
+		t.Errorf("ConfigDir() with XDG_CONFIG_HOME = %s", got)
+	}
+}
@@ -0,0 +1,94 @@
+package reviewlog
+
+import (
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/LaPingvino/recuerdo/internal/studyday"
+)
+
+// Due tells when an item of a lesson is due again
+type Due struct {
+	ItemID int
+	// Day is the day of study the item is due as 2006-01-02, empty for
+	// items never answered
+	Day string
+	// Streak is how many times in a row the item was last answered right
+	Streak int
+}
+
+// New tells whether the item was never answered
+func (d Due) New() bool {
+	return d.Day == ""
+}
+
+// DueBy tells whether the item is due on the day of study today, given as
+// 2006-01-02; new items always are
+func (d Due) DueBy(today string) bool {
+	return d.Day <= today
+}
+
+// LessonDue works out when the items of a lesson are due with the schedule
+// of the log, from the test results kept in the lesson instead of a log,
+// for programs that have a lesson but no log, such as the REST API.
+// Answers in either direction count. Suspended items are left out.
+func LessonDue(list *lesson.WordList, rollover int) []Due {
+	type state struct {
+		streak int
+		last   string
+	}
+	states := make(map[int]*state)
+	for _, test := range list.Tests {
+		for _, result := range test.Results {
+			when := test.Date
+			if result.Time != nil {
+				when = result.Time
+			}
+			s := states[result.ItemID]
+			if s == nil {
+				s = &state{}
+				states[result.ItemID] = s
+			}
+			if when != nil {
+				s.last = studyday.Of(*when, rollover)
+			}
+			if result.Result == "right" {
+				s.streak++
+			} else {
+				s.streak = 0
+			}
+		}
+	}
+
+	var due []Due
+	for i := range list.Items {
+		item := &list.Items[i]
+		if item.IsSuspended() {
+			continue
+		}
+		s, answered := states[item.ID]
+		switch {
+		case !answered:
+			due = append(due, Due{ItemID: item.ID})
+		case s.last == "":
+			// Answered at an unknown time, so due now
+			due = append(due, Due{ItemID: item.ID, Day: "0000-00-00", Streak: s.streak})
+		default:
+			due = append(due, Due{ItemID: item.ID, Day: studyday.Add(s.last, interval(s.streak)), Streak: s.streak})
+		}
+	}
+	return due
+}
+
+// DueToday returns the items of a lesson due on the day of study of now,
+// overdue and new items included, in lesson order
+func DueToday(list *lesson.WordList, now time.Time, rollover int) []Due {
+	today := studyday.Of(now, rollover)
+	var due []Due
+	for _, d := range LessonDue(list, rollover) {
+		if d.DueBy(today) {
+			due = append(due, d)
+		}
+	}
+	return due
+}
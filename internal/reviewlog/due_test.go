@@ -0,0 +1,61 @@
+package reviewlog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+func TestLessonDue(t *testing.T) {
+	day := func(d int) *time.Time {
+		when := time.Date(2026, 3, d, 12, 0, 0, 0, time.UTC)
+		return &when
+	}
+	list := lesson.WordList{
+		Items: []lesson.WordItem{
+			{ID: 0, Questions: []string{"chat"}, Answers: []string{"cat"}},
+			{ID: 1, Questions: []string{"chien"}, Answers: []string{"dog"}},
+			{ID: 2, Questions: []string{"cheval"}, Answers: []string{"horse"}},
+			{ID: 3, Questions: []string{"vache"}, Answers: []string{"cow"}},
+		},
+		Tests: []lesson.Test{
+			{Date: day(1), Results: []lesson.TestResult{
+				{ItemID: 0, Result: "right"},
+				{ItemID: 1, Result: "right"},
+			}},
+			{Date: day(2), Results: []lesson.TestResult{
+				{ItemID: 0, Result: "right"},
+				{ItemID: 0, Result: "right", Time: day(3)},
+				{ItemID: 1, Result: "wrong"},
+			}},
+		},
+	}
+
+	due := LessonDue(&list, 0)
+	want := []Due{
+		{ItemID: 0, Day: "2026-03-07", Streak: 3},
+		{ItemID: 1, Day: "2026-03-03", Streak: 0},
+		{ItemID: 2},
+		{ItemID: 3},
+	}
+	if len(due) != len(want) {
+		t.Fatalf("expected %d items, got %+v", len(want), due)
+	}
+	for i := range want {
+		if due[i] != want[i] {
+			t.Errorf("item %d: expected %+v, got %+v", i, want[i], due[i])
+		}
+	}
+	if !due[2].New() || due[0].New() {
+		t.Error("expected only never answered items to be new")
+	}
+
+	today := DueToday(&list, *day(4), 0)
+	if len(today) != 3 || today[0].ItemID != 1 || today[1].ItemID != 2 || today[2].ItemID != 3 {
+		t.Errorf("expected items 1, 2 and 3 due on the 4th, got %+v", today)
+	}
+	if later := DueToday(&list, *day(7), 0); len(later) != 4 {
+		t.Errorf("expected every item due on the 7th, got %+v", later)
+	}
+}
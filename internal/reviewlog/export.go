@@ -0,0 +1,201 @@
+package reviewlog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Table is what Export writes
+type Table int
+
+const (
+	// ReviewsTable is every answer, one per row
+	ReviewsTable Table = iota
+	// DaysTable is the number of answers, the score and the retention of
+	// every day
+	DaysTable
+	// ItemsTable is the number of right and wrong answers of every item
+	ItemsTable
+)
+
+// The formats Export writes
+const (
+	CSV  = "csv"
+	JSON = "json"
+)
+
+// reviewRecord is an exported answer
+type reviewRecord struct {
+	Lesson        string  `json:"lesson"`
+	LessonUUID    string  `json:"lessonUUID,omitempty"`
+	ItemHash      string  `json:"itemHash,omitempty"`
+	ItemID        int     `json:"itemId"`
+	Question      string  `json:"question"`
+	Correct       bool    `json:"correct"`
+	Reversed      bool    `json:"reversed"`
+	AnswerSeconds float64 `json:"answerSeconds"`
+	Time          string  `json:"time"`
+}
+
+var reviewHeader = []string{"lesson", "lessonUUID", "itemHash", "itemId", "question", "correct", "reversed", "answerSeconds", "time"}
+
+func (r reviewRecord) fields() []string {
+	return []string{r.Lesson, r.LessonUUID, r.ItemHash, strconv.Itoa(r.ItemID), r.Question,
+		strconv.FormatBool(r.Correct), strconv.FormatBool(r.Reversed), formatFloat(r.AnswerSeconds), r.Time}
+}
+
+// dayRecord is the statistics of one exported day. Retention is taken over
+// the RetentionAnswers to items asked before and is 0 when there are none.
+type dayRecord struct {
+	Day              string  `json:"day"`
+	Answers          int     `json:"answers"`
+	Score            float64 `json:"score"`
+	RetentionAnswers int     `json:"retentionAnswers"`
+	Retention        float64 `json:"retention"`
+}
+
+var dayHeader = []string{"day", "answers", "score", "retentionAnswers", "retention"}
+
+func (r dayRecord) fields() []string {
+	return []string{r.Day, strconv.Itoa(r.Answers), formatFloat(r.Score), strconv.Itoa(r.RetentionAnswers), formatFloat(r.Retention)}
+}
+
+// itemRecord is the statistics of one exported item
+type itemRecord struct {
+	Lesson        string  `json:"lesson"`
+	ItemID        int     `json:"itemId"`
+	Question      string  `json:"question"`
+	Right         int     `json:"right"`
+	Wrong         int     `json:"wrong"`
+	ErrorRate     float64 `json:"errorRate"`
+	AnswerSeconds float64 `json:"answerSeconds"`
+}
+
+var itemHeader = []string{"lesson", "itemId", "question", "right", "wrong", "errorRate", "answerSeconds"}
+
+func (r itemRecord) fields() []string {
+	return []string{r.Lesson, strconv.Itoa(r.ItemID), r.Question, strconv.Itoa(r.Right), strconv.Itoa(r.Wrong),
+		formatFloat(r.ErrorRate), formatFloat(r.AnswerSeconds)}
+}
+
+// Export writes table for the answers to lesson since since to w, as CSV
+// with a header row or as a JSON array, for analysis in a spreadsheet or
+// R. An empty lesson takes all lessons.
+func (l *Log) Export(w io.Writer, table Table, format, lesson string, since time.Time) error {
+	if format != CSV && format != JSON {
+		return fmt.Errorf("unknown export format %q (use csv or json)", format)
+	}
+	var header []string
+	var records [][]string
+	var values interface{}
+	switch table {
+	case ReviewsTable:
+		reviews, err := l.reviews(lesson, since)
+		if err != nil {
+			return err
+		}
+		exported := make([]reviewRecord, len(reviews))
+		for i, review := range reviews {
+			exported[i] = reviewRecord{
+				Lesson: review.Lesson, LessonUUID: review.LessonUUID, ItemHash: review.ItemHash, ItemID: review.ItemID,
+				Question: review.Question, Correct: review.Right, Reversed: review.Reversed,
+				AnswerSeconds: review.AnswerTime.Seconds(), Time: review.Time.Format(time.RFC3339),
+			}
+			records = append(records, exported[i].fields())
+		}
+		header, values = reviewHeader, exported
+	case DaysTable:
+		exported, err := l.days(lesson, since)
+		if err != nil {
+			return err
+		}
+		for _, day := range exported {
+			records = append(records, day.fields())
+		}
+		header, values = dayHeader, exported
+	case ItemsTable:
+		items, err := l.items(lesson, since)
+		if err != nil {
+			return err
+		}
+		exported := make([]itemRecord, len(items))
+		for i, item := range items {
+			exported[i] = itemRecord{
+				Lesson: item.Lesson, ItemID: item.ItemID, Question: item.Question, Right: item.Right, Wrong: item.Wrong,
+				ErrorRate: item.ErrorRate(), AnswerSeconds: item.AnswerTime.Seconds(),
+			}
+			records = append(records, exported[i].fields())
+		}
+		header, values = itemHeader, exported
+	default:
+		return fmt.Errorf("unknown export table %d", table)
+	}
+
+	if format == JSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(values)
+	}
+	writer := csv.NewWriter(w)
+	writer.Write(header)
+	writer.WriteAll(records)
+	return writer.Error()
+}
+
+// reviews returns the reviews of lesson since since, oldest first
+func (l *Log) reviews(lesson string, since time.Time) ([]Review, error) {
+	rows, err := l.db.Query(`SELECT lesson, lesson_uuid, item_hash, item_id, question, correct, reversed, answer_time, reviewed, device, seq
+		FROM reviews WHERE (?1 = '' OR lesson = ?1) AND day >= ?2
+		ORDER BY reviewed, id`, lesson, l.Today(since))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read review log: %w", err)
+	}
+	return scanReviews(rows)
+}
+
+// days returns the scores and retention of lesson per day since since
+func (l *Log) days(lesson string, since time.Time) ([]dayRecord, error) {
+	scores, err := l.Scores(lesson, since)
+	if err != nil {
+		return nil, err
+	}
+	retention, err := l.Retention(lesson, since)
+	if err != nil {
+		return nil, err
+	}
+	retained := make(map[string]Point, len(retention))
+	for _, point := range retention {
+		retained[point.Day] = point
+	}
+	days := make([]dayRecord, len(scores))
+	for i, score := range scores {
+		days[i] = dayRecord{Day: score.Day, Answers: score.Answers, Score: score.Value}
+		if point, ok := retained[score.Day]; ok {
+			days[i].RetentionAnswers, days[i].Retention = point.Answers, point.Value
+		}
+	}
+	return days, nil
+}
+
+// items returns the statistics of every item of lesson answered since
+// since, by lesson and item
+func (l *Log) items(lesson string, since time.Time) ([]ItemStats, error) {
+	rows, err := l.db.Query(`SELECT lesson, item_id, MAX(question), SUM(correct), COUNT(*) - SUM(correct), AVG(answer_time)
+		FROM reviews WHERE (?1 = '' OR lesson = ?1) AND day >= ?2
+		GROUP BY lesson, item_id
+		ORDER BY lesson, item_id`, lesson, l.Today(since))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read review log: %w", err)
+	}
+	return scanItems(rows)
+}
+
+// formatFloat writes a number the way spreadsheets and R read it back
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(math.Round(value*1e6)/1e6, 'f', -1, 64)
+}
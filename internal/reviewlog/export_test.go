@@ -0,0 +1,73 @@
+package reviewlog
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExport(t *testing.T) {
+	l, err := Open(filepath.Join(t.TempDir(), "reviews.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer l.Close()
+
+	now := time.Date(2026, 10, 14, 18, 0, 0, 0, time.UTC)
+	for _, review := range []Review{
+		{Lesson: "animals", LessonUUID: "uuid-1", ItemHash: "h0", ItemID: 0, Question: "house, home", Right: false, Time: now.AddDate(0, 0, -1), AnswerTime: 1500 * time.Millisecond},
+		{Lesson: "animals", LessonUUID: "uuid-1", ItemHash: "h0", ItemID: 0, Question: "house, home", Right: true, Time: now},
+		{Lesson: "animals", LessonUUID: "uuid-1", ItemHash: "h1", ItemID: 1, Question: "dog", Right: true, Time: now},
+		{Lesson: "fruit", ItemID: 0, Question: "apple", Right: true, Time: now.AddDate(0, 0, -30)},
+	} {
+		if err := l.Add(review); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	since := now.AddDate(0, 0, -7)
+
+	var out bytes.Buffer
+	if err := l.Export(&out, ReviewsTable, CSV, "", since); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	rows, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(rows) != 4 || strings.Join(rows[0], ",") != strings.Join(reviewHeader, ",") {
+		t.Fatalf("expected a header and the three answers of the last week, got %q", rows)
+	}
+	if rows[1][4] != "house, home" || rows[1][5] != "false" || rows[1][7] != "1.5" || rows[1][8] != "2026-10-13T18:00:00Z" {
+		t.Errorf("unexpected first answer %q", rows[1])
+	}
+
+	out.Reset()
+	if err := l.Export(&out, DaysTable, JSON, "animals", since); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	var days []dayRecord
+	if err := json.Unmarshal(out.Bytes(), &days); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(days) != 2 || days[1] != (dayRecord{Day: "2026-10-14", Answers: 2, Score: 1, RetentionAnswers: 1, Retention: 1}) {
+		t.Errorf("unexpected days %+v", days)
+	}
+
+	out.Reset()
+	if err := l.Export(&out, ItemsTable, CSV, "animals", since); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if want := "lesson,itemId,question,right,wrong,errorRate,answerSeconds\n" +
+		"animals,0,\"house, home\",1,1,0.5,0.75\n" +
+		"animals,1,dog,1,0,0,0\n"; out.String() != want {
+		t.Errorf("unexpected items:\n%s", out.String())
+	}
+
+	if err := l.Export(&out, ItemsTable, "xlsx", "", since); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,438 @@
+package reviewlog
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// deviceFile holds the ID of the device in a journal directory
+	deviceFile = "device"
+	// compactedFile holds the events folded together by Compact
+	compactedFile = "compacted.jsonl"
+	// eventsExt is the extension of the files of a journal
+	eventsExt = ".jsonl"
+	// compactAfter is how many events the files of single devices hold
+	// together before NeedsCompaction says they should be folded together
+	compactAfter = 5000
+)
+
+// Cursor tells how far the events of a journal go: the sequence number of
+// the last event of every device. Another journal passes its cursor to
+// Diff to get the events it is missing.
+type Cursor map[string]int
+
+// Journal is the append-only store of review events the log is derived
+// from. Every device appends the answers given on it to a file of its own,
+// numbering them; an event is identified by its device and sequence
+// number, so events exchanged between devices are merged without
+// duplicates in whatever order they arrive. Events are never changed or
+// removed, only folded into one file by Compact, so statistics and
+// schedules can always be worked out again from the complete history.
+type Journal struct {
+	dir    string
+	device string
+	cursor Cursor
+	// loose counts the events not in the compacted file
+	loose int
+	mu    sync.Mutex
+}
+
+// event is how a review is stored in the journal, one JSON object a line
+type event struct {
+	Device        string  `json:"device"`
+	Seq           int     `json:"seq"`
+	Lesson        string  `json:"lesson"`
+	LessonUUID    string  `json:"lessonUUID,omitempty"`
+	ItemHash      string  `json:"itemHash,omitempty"`
+	ItemID        int     `json:"itemId"`
+	Question      string  `json:"question,omitempty"`
+	Correct       bool    `json:"correct"`
+	Reversed      bool    `json:"reversed,omitempty"`
+	AnswerSeconds float64 `json:"answerSeconds,omitempty"`
+	Time          string  `json:"time"`
+}
+
+func toEvent(review Review) event {
+	return event{
+		Device:        review.Device,
+		Seq:           review.Seq,
+		Lesson:        review.Lesson,
+		LessonUUID:    review.LessonUUID,
+		ItemHash:      review.ItemHash,
+		ItemID:        review.ItemID,
+		Question:      review.Question,
+		Correct:       review.Right,
+		Reversed:      review.Reversed,
+		AnswerSeconds: review.AnswerTime.Seconds(),
+		// The offset is kept, so the day of the review can be worked out
+		// in the time zone it was given in
+		Time: review.Time.Format(time.RFC3339Nano),
+	}
+}
+
+func (e event) review() (Review, error) {
+	reviewed, err := time.Parse(time.RFC3339Nano, e.Time)
+	if err != nil {
+		return Review{}, err
+	}
+	return Review{
+		Device:     e.Device,
+		Seq:        e.Seq,
+		Lesson:     e.Lesson,
+		LessonUUID: e.LessonUUID,
+		ItemHash:   e.ItemHash,
+		ItemID:     e.ItemID,
+		Question:   e.Question,
+		Right:      e.Correct,
+		Reversed:   e.Reversed,
+		AnswerTime: time.Duration(e.AnswerSeconds * float64(time.Second)),
+		Time:       reviewed,
+	}, nil
+}
+
+// OpenJournal opens the journal in dir, creating it if needed. A new
+// journal gets a random device ID.
+func OpenJournal(dir string) (*Journal, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create review journal: %w", err)
+	}
+	j := &Journal{dir: dir}
+	data, err := os.ReadFile(filepath.Join(dir, deviceFile))
+	switch {
+	case err == nil:
+		j.device = strings.TrimSpace(string(data))
+	case os.IsNotExist(err):
+		id := make([]byte, 8)
+		if _, err := rand.Read(id); err != nil {
+			return nil, err
+		}
+		j.device = hex.EncodeToString(id)
+		if err := os.WriteFile(filepath.Join(dir, deviceFile), []byte(j.device+"\n"), 0644); err != nil {
+			return nil, fmt.Errorf("failed to create review journal: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("failed to open review journal: %w", err)
+	}
+	if j.device == "" || strings.ContainsAny(j.device, `/\.`) {
+		return nil, fmt.Errorf("invalid device ID %q in review journal", j.device)
+	}
+
+	events, loose, err := j.read()
+	if err != nil {
+		return nil, err
+	}
+	j.cursor = make(Cursor)
+	for _, review := range events {
+		j.cursor[review.Device] = max(j.cursor[review.Device], review.Seq)
+	}
+	j.loose = loose
+	return j, nil
+}
+
+// Device returns the ID of the device the journal is kept on
+func (j *Journal) Device() string {
+	return j.device
+}
+
+// Append adds a review given on this device to the journal and returns it
+// with its device and sequence number set. Reviews without a time were
+// given now.
+func (j *Journal) Append(review Review) (Review, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if review.Time.IsZero() {
+		review.Time = time.Now()
+	}
+	review.Device = j.device
+	review.Seq = j.cursor[j.device] + 1
+	if err := j.write(j.device, []Review{review}); err != nil {
+		return Review{}, err
+	}
+	j.cursor[j.device] = review.Seq
+	return review, nil
+}
+
+// Cursor returns how far the events of the journal go
+func (j *Journal) Cursor() Cursor {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	cursor := make(Cursor, len(j.cursor))
+	for device, seq := range j.cursor {
+		cursor[device] = seq
+	}
+	return cursor
+}
+
+// Diff returns the events of the journal after cursor, the ones a journal
+// that went as far as cursor is missing, by device and in order
+func (j *Journal) Diff(cursor Cursor) ([]Review, error) {
+	j.mu.Lock()
+	events, _, err := j.read()
+	j.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	var diff []Review
+	for _, review := range events {
+		if review.Seq > cursor[review.Device] {
+			diff = append(diff, review)
+		}
+	}
+	sortBySeq(diff)
+	return diff, nil
+}
+
+// Merge adds the events of other devices the journal is missing, such as
+// the result of another journal's Diff, and returns the ones it added.
+// Events it has already are skipped, so merging twice is harmless. The
+// events of a device must follow on from the last one the journal has.
+func (j *Journal) Merge(events []Review) ([]Review, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	events = append([]Review(nil), events...)
+	sortBySeq(events)
+
+	var added []Review
+	for start := 0; start < len(events); {
+		device := events[start].Device
+		end := start
+		var missing []Review
+		for ; end < len(events) && events[end].Device == device; end++ {
+			review := events[end]
+			last := j.cursor[device]
+			if len(missing) > 0 {
+				last = missing[len(missing)-1].Seq
+			}
+			switch {
+			case review.Seq <= last:
+				continue
+			case review.Seq != last+1:
+				return added, fmt.Errorf("review event %d of device %s is missing", last+1, device)
+			}
+			missing = append(missing, review)
+		}
+		start = end
+		if len(missing) == 0 {
+			continue
+		}
+		if device == "" || strings.ContainsAny(device, `/\.`) {
+			return added, fmt.Errorf("invalid device ID %q in review events", device)
+		}
+		if err := j.write(device, missing); err != nil {
+			return added, err
+		}
+		j.cursor[device] = missing[len(missing)-1].Seq
+		added = append(added, missing...)
+	}
+	return added, nil
+}
+
+// Events returns every event of the journal, oldest first
+func (j *Journal) Events() ([]Review, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	events, _, err := j.read()
+	return events, err
+}
+
+// NeedsCompaction reports whether enough events were added since the last
+// Compact to fold them together
+func (j *Journal) NeedsCompaction() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.loose >= compactAfter
+}
+
+// Compact folds the files of all devices into one, oldest event first,
+// dropping events stored twice. No event is lost.
+func (j *Journal) Compact() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	events, _, err := j.read()
+	if err != nil {
+		return err
+	}
+	files, err := j.files()
+	if err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(j.dir, ".compacting-*")
+	if err != nil {
+		return fmt.Errorf("failed to compact review journal: %w", err)
+	}
+	defer os.Remove(temp.Name())
+	if err := encodeEvents(temp, events); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to compact review journal: %w", err)
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to compact review journal: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("failed to compact review journal: %w", err)
+	}
+	if err := os.Rename(temp.Name(), filepath.Join(j.dir, compactedFile)); err != nil {
+		return fmt.Errorf("failed to compact review journal: %w", err)
+	}
+	for _, name := range files {
+		if filepath.Base(name) != compactedFile {
+			os.Remove(name)
+		}
+	}
+	j.loose = 0
+	return nil
+}
+
+// write appends reviews to the file of device, on a line of their own
+// even when the file ends in a line cut short
+func (j *Journal) write(device string, reviews []Review) error {
+	file, err := os.OpenFile(filepath.Join(j.dir, device+eventsExt), os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write review journal: %w", err)
+	}
+	var prefix []byte
+	if info, err := file.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := file.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			prefix = []byte("\n")
+		}
+	}
+	if _, err := file.Write(prefix); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write review journal: %w", err)
+	}
+	if err := encodeEvents(file, reviews); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write review journal: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write review journal: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write review journal: %w", err)
+	}
+	j.loose += len(reviews)
+	return nil
+}
+
+// files returns the names of the event files of the journal
+func (j *Journal) files() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(j.dir, "*"+eventsExt))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// read returns the events of all files, oldest first and without
+// duplicates, and how many are not in the compacted file
+func (j *Journal) read() ([]Review, int, error) {
+	files, err := j.files()
+	if err != nil {
+		return nil, 0, err
+	}
+	type key struct {
+		device string
+		seq    int
+	}
+	seen := make(map[key]bool)
+	var events []Review
+	loose := 0
+	for _, name := range files {
+		reviews, err := readEvents(name)
+		if err != nil {
+			return nil, 0, err
+		}
+		if filepath.Base(name) != compactedFile {
+			loose += len(reviews)
+		}
+		for _, review := range reviews {
+			if k := (key{review.Device, review.Seq}); !seen[k] {
+				seen[k] = true
+				events = append(events, review)
+			}
+		}
+	}
+	sort.SliceStable(events, func(a, b int) bool {
+		if !events[a].Time.Equal(events[b].Time) {
+			return events[a].Time.Before(events[b].Time)
+		}
+		if events[a].Device != events[b].Device {
+			return events[a].Device < events[b].Device
+		}
+		return events[a].Seq < events[b].Seq
+	})
+	return events, loose, nil
+}
+
+// readEvents reads the events of one file. Lines that are not events, such
+// as one cut short by a crash while appending, are skipped.
+func readEvents(name string) ([]Review, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read review journal: %w", err)
+	}
+	defer file.Close()
+	var reviews []Review
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var e event
+		err := json.Unmarshal(line, &e)
+		var review Review
+		if err == nil {
+			review, err = e.review()
+		}
+		if err != nil {
+			fmt.Printf("Warning: skipping invalid event in review journal %s: %v\n", filepath.Base(name), err)
+			continue
+		}
+		reviews = append(reviews, review)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read review journal: %w", err)
+	}
+	return reviews, nil
+}
+
+// encodeEvents writes reviews to w as events, one a line
+func encodeEvents(w io.Writer, reviews []Review) error {
+	var buf strings.Builder
+	encoder := json.NewEncoder(&buf)
+	for _, review := range reviews {
+		if err := encoder.Encode(toEvent(review)); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write([]byte(buf.String()))
+	return err
+}
+
+// sortBySeq orders events by device and sequence number
+func sortBySeq(events []Review) {
+	sort.SliceStable(events, func(a, b int) bool {
+		if events[a].Device != events[b].Device {
+			return events[a].Device < events[b].Device
+		}
+		return events[a].Seq < events[b].Seq
+	})
+}
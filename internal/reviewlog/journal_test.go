@@ -0,0 +1,132 @@
+package reviewlog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJournalSync(t *testing.T) {
+	now := time.Date(2026, 10, 14, 18, 0, 0, 0, time.Local)
+	open := func(dbPath string) *Log {
+		l, err := Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		return l
+	}
+	laptopPath := filepath.Join(t.TempDir(), "reviews.db")
+	laptop := open(laptopPath)
+	defer func() { laptop.Close() }()
+	phone := open(filepath.Join(t.TempDir(), "reviews.db"))
+	defer phone.Close()
+	if laptop.Device() == phone.Device() {
+		t.Fatal("expected every device to get an ID of its own")
+	}
+
+	for i, review := range []Review{
+		{Lesson: "animals", ItemID: 0, Question: "house", Right: true, Time: now.Add(-3 * time.Hour)},
+		{Lesson: "animals", ItemID: 1, Question: "dog", Right: false, Time: now.Add(-time.Hour)},
+	} {
+		if err := laptop.Add(review); err != nil {
+			t.Fatalf("Add %d: %v", i, err)
+		}
+	}
+	if err := phone.Add(Review{Lesson: "animals", ItemID: 1, Question: "dog", Right: true, Time: now.Add(-2 * time.Hour)}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	// Every device sends the other what it is missing
+	toPhone, err := laptop.Diff(phone.Cursor())
+	if err != nil || len(toPhone) != 2 {
+		t.Fatalf("expected 2 events for the phone, got %d (%v)", len(toPhone), err)
+	}
+	toLaptop, err := phone.Diff(laptop.Cursor())
+	if err != nil || len(toLaptop) != 1 {
+		t.Fatalf("expected 1 event for the laptop, got %d (%v)", len(toLaptop), err)
+	}
+	if added, err := phone.Merge(toPhone); err != nil || added != 2 {
+		t.Errorf("expected 2 events merged, got %d (%v)", added, err)
+	}
+	if added, err := laptop.Merge(toLaptop); err != nil || added != 1 {
+		t.Errorf("expected 1 event merged, got %d (%v)", added, err)
+	}
+	if added, err := laptop.Merge(toLaptop); err != nil || added != 0 {
+		t.Errorf("expected merging twice to add nothing, got %d (%v)", added, err)
+	}
+	for _, l := range []*Log{laptop, phone} {
+		if count, _ := l.Count(""); count != 3 {
+			t.Errorf("expected 3 reviews on device %s, got %d", l.Device(), count)
+		}
+		if forecast, _ := l.Forecast("", now, 2); forecast[1] != 2 {
+			t.Errorf("expected both items due tomorrow on device %s, got %v", l.Device(), forecast)
+		}
+	}
+	if _, err := laptop.Merge([]Review{{Device: "tablet", Seq: 2, Lesson: "animals", Time: now}}); err == nil {
+		t.Error("expected an error for an event following on from a missing one")
+	}
+
+	// Compacting keeps every event, and the database can be worked out
+	// anew from the journal
+	if err := laptop.Compact(); err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(JournalDir(laptopPath), "*.jsonl"))
+	if len(files) != 1 || filepath.Base(files[0]) != compactedFile {
+		t.Errorf("expected only the compacted file, got %v", files)
+	}
+	if err := laptop.Rebuild(); err != nil {
+		t.Fatalf("Rebuild: %v", err)
+	}
+	if count, _ := laptop.Count(""); count != 3 {
+		t.Errorf("expected 3 reviews after rebuilding, got %d", count)
+	}
+	laptop.Close()
+	if err := os.Remove(laptopPath); err != nil {
+		t.Fatal(err)
+	}
+	laptop = open(laptopPath)
+	if count, _ := laptop.Count(""); count != 3 {
+		t.Errorf("expected the database to be restored from the journal, got %d reviews", count)
+	}
+	if err := laptop.Add(Review{Lesson: "animals", ItemID: 0, Right: true}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if seq := laptop.Cursor()[laptop.Device()]; seq != 3 {
+		t.Errorf("expected numbering to go on after compacting, got %d", seq)
+	}
+}
+
+func TestJournalTornWrite(t *testing.T) {
+	dir := t.TempDir()
+	j, err := OpenJournal(dir)
+	if err != nil {
+		t.Fatalf("OpenJournal: %v", err)
+	}
+	if _, err := j.Append(Review{Lesson: "animals", Right: true}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	// A crash while appending leaves half a line behind
+	file, err := os.OpenFile(filepath.Join(dir, j.Device()+eventsExt), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"device":"`)
+	file.Close()
+
+	j, err = OpenJournal(dir)
+	if err != nil {
+		t.Fatalf("OpenJournal: %v", err)
+	}
+	if events, err := j.Events(); err != nil || len(events) != 1 {
+		t.Errorf("expected the complete event, got %v (%v)", events, err)
+	}
+	// Events appended later are not lost in the broken line
+	if _, err := j.Append(Review{Lesson: "animals", Right: false}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if events, err := j.Events(); err != nil || len(events) != 2 || events[1].Seq != 2 {
+		t.Errorf("expected two events, got %v (%v)", events, err)
+	}
+}
@@ -0,0 +1,632 @@
+// Package reviewlog keeps every answer given in any lesson in SQLite, so
+// statistics over time survive lessons being edited, renamed or thrown
+// away, and can be taken over all lessons at once. Answers are keyed by
+// the lesson's UUID and a hash of the item, so the history of a lesson is
+// not lost when it goes through a file format without test results.
+//
+// The answers themselves are kept in a Journal, an append-only log of
+// review events next to the database. The database is derived from it: it
+// catches up with events merged in from other devices when opened and can
+// be rebuilt from the journal at any time, so statistics and schedules can
+// be worked out anew from the complete history when the way they are
+// worked out changes.
+//
+// Besides the answers it works out when items are due again, with a
+// Leitner schedule: an item answered right n times in a row is due
+// 2^(n-1) days after the day of its last review, up to MaxInterval, and an
+// item answered wrong the next day. Days are days of study as package
+// studyday works them out, starting at a rollover hour in the time zone
+// the answer was given in.
+package reviewlog
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/studyday"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// MaxInterval is the longest time the schedule waits before an item is
+// due again
+const MaxInterval = 128 * 24 * time.Hour
+
+// maxIntervalDays is MaxInterval in days
+const maxIntervalDays = int(MaxInterval / (24 * time.Hour))
+
+// schemaVersion is stored as the log's user_version. Version 1 logs lack
+// the lesson_uuid and item_hash columns, and version 1 and 2 logs the
+// device and seq columns; they are added on opening, and the answers of
+// such logs are moved into the journal. Days in logs before version 4
+// start at midnight.
+const schemaVersion = 4
+
+// dayLayout is how days are stored and returned
+const dayLayout = studyday.Layout
+
+// Review is one answer to an item of a lesson
+type Review struct {
+	// Lesson names the lesson the item is in
+	Lesson string
+	// LessonUUID and ItemHash identify the item whatever its lesson is
+	// called and whatever ID it has; see lesson.WordList.EnsureUUID and
+	// lesson.ItemHash
+	LessonUUID string
+	ItemHash   string
+	ItemID     int
+	Question   string
+	Right      bool
+	// Reversed is set when the item was asked the other way round
+	Reversed   bool
+	AnswerTime time.Duration
+	Time       time.Time
+	// Device and Seq identify the review as an event of the journal: the
+	// device it was given on and its number among the reviews of that
+	// device. The journal sets them.
+	Device string
+	Seq    int
+}
+
+// Point is a value of one day in a chart
+type Point struct {
+	// Day is the day as 2006-01-02
+	Day string
+	// Value is the fraction of the answers of the day that were right
+	Value float64
+	// Answers is the number of answers the value is taken over
+	Answers int
+}
+
+// ItemStats sum up the answers to one item
+type ItemStats struct {
+	Lesson   string
+	ItemID   int
+	Question string
+	Right    int
+	Wrong    int
+	// AnswerTime is the average time taken to answer
+	AnswerTime time.Duration
+}
+
+// ErrorRate returns the fraction of the answers that were wrong
+func (s ItemStats) ErrorRate() float64 {
+	if s.Right+s.Wrong == 0 {
+		return 0
+	}
+	return float64(s.Wrong) / float64(s.Right+s.Wrong)
+}
+
+// Log is the review log
+type Log struct {
+	db      *sql.DB
+	journal *Journal
+	// rollover is the hour the days of the reviews in the database start
+	rollover int
+}
+
+// JournalDir returns the directory of the journal of the review log at
+// dbPath: "reviews-events" next to "reviews.db"
+func JournalDir(dbPath string) string {
+	return strings.TrimSuffix(dbPath, filepath.Ext(dbPath)) + "-events"
+}
+
+// Open opens the review log at dbPath with its journal in JournalDir,
+// creating them if needed. Events in the journal missing from the
+// database are added, and the journal is compacted when it has grown
+// enough since it last was.
+func Open(dbPath string) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create review log directory: %w", err)
+	}
+	journal, err := OpenJournal(JournalDir(dbPath))
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open review log: %w", err)
+	}
+	l := &Log{db: db, journal: journal}
+	if err := l.createSchema(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := l.catchUp(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if journal.NeedsCompaction() {
+		if err := journal.Compact(); err != nil {
+			fmt.Printf("Warning: failed to compact review journal: %v\n", err)
+		}
+	}
+	return l, nil
+}
+
+// Close closes the log
+func (l *Log) Close() error {
+	return l.db.Close()
+}
+
+func (l *Log) createSchema() error {
+	var version int
+	if err := l.db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return fmt.Errorf("failed to read review log: %w", err)
+	}
+	var columns []string
+	switch version {
+	case 1:
+		columns = []string{"lesson_uuid TEXT", "item_hash TEXT", "device TEXT", "seq INTEGER"}
+	case 2:
+		columns = []string{"device TEXT", "seq INTEGER"}
+	}
+	for _, column := range columns {
+		def := `''`
+		if strings.HasSuffix(column, "INTEGER") {
+			def = "0"
+		}
+		if _, err := l.db.Exec(`ALTER TABLE reviews ADD COLUMN ` + column + ` NOT NULL DEFAULT ` + def); err != nil {
+			return fmt.Errorf("failed to upgrade review log: %w", err)
+		}
+	}
+
+	if _, err := l.db.Exec(`CREATE TABLE IF NOT EXISTS reviews (
+		id INTEGER PRIMARY KEY,
+		lesson TEXT NOT NULL,
+		item_id INTEGER NOT NULL,
+		question TEXT NOT NULL DEFAULT '',
+		correct INTEGER NOT NULL,
+		reversed INTEGER NOT NULL DEFAULT 0,
+		answer_time REAL NOT NULL DEFAULT 0,
+		reviewed INTEGER NOT NULL,
+		day TEXT NOT NULL,
+		lesson_uuid TEXT NOT NULL DEFAULT '',
+		item_hash TEXT NOT NULL DEFAULT '',
+		device TEXT NOT NULL DEFAULT '',
+		seq INTEGER NOT NULL DEFAULT 0)`); err != nil {
+		return fmt.Errorf("failed to create review log: %w", err)
+	}
+	if _, err := l.db.Exec(`CREATE INDEX IF NOT EXISTS reviews_item ON reviews (lesson, item_id, reviewed)`); err != nil {
+		return fmt.Errorf("failed to create review log: %w", err)
+	}
+	if _, err := l.db.Exec(`CREATE INDEX IF NOT EXISTS reviews_hash ON reviews (item_hash, lesson_uuid, reviewed)`); err != nil {
+		return fmt.Errorf("failed to create review log: %w", err)
+	}
+	if _, err := l.db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS reviews_event ON reviews (device, seq) WHERE device != ''`); err != nil {
+		return fmt.Errorf("failed to create review log: %w", err)
+	}
+	if version > 0 && version < 3 {
+		if err := l.adopt(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := l.db.Exec(`CREATE TABLE IF NOT EXISTS meta (key TEXT PRIMARY KEY, value INTEGER NOT NULL)`); err != nil {
+		return fmt.Errorf("failed to create review log: %w", err)
+	}
+	err := l.db.QueryRow(`SELECT value FROM meta WHERE key = 'rollover'`).Scan(&l.rollover)
+	if err == sql.ErrNoRows {
+		l.rollover = studyday.DefaultRollover
+		if version > 0 {
+			l.rollover = 0
+		}
+		_, err = l.db.Exec(`INSERT INTO meta (key, value) VALUES ('rollover', ?)`, l.rollover)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create review log: %w", err)
+	}
+	if _, err := l.db.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, schemaVersion)); err != nil {
+		return fmt.Errorf("failed to create review log: %w", err)
+	}
+	return nil
+}
+
+// adopt moves the reviews of a log from before the journal into the
+// journal, as events of this device
+func (l *Log) adopt() error {
+	rows, err := l.db.Query(`SELECT id, lesson, lesson_uuid, item_hash, item_id, question, correct, reversed, answer_time, reviewed, device, seq
+		FROM reviews WHERE device = '' ORDER BY reviewed, id`)
+	if err != nil {
+		return fmt.Errorf("failed to upgrade review log: %w", err)
+	}
+	var ids []int64
+	var rowsReviews []Review
+	for rows.Next() {
+		var id int64
+		review, err := scanReview(rows, &id)
+		if err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to upgrade review log: %w", err)
+		}
+		ids = append(ids, id)
+		rowsReviews = append(rowsReviews, review)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to upgrade review log: %w", err)
+	}
+
+	tx, err := l.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to upgrade review log: %w", err)
+	}
+	defer tx.Rollback()
+	for i, review := range rowsReviews {
+		event, err := l.journal.Append(review)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`UPDATE reviews SET device = ?, seq = ? WHERE id = ?`, event.Device, event.Seq, ids[i]); err != nil {
+			return fmt.Errorf("failed to upgrade review log: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// catchUp adds the events of the journal the database is missing
+func (l *Log) catchUp() error {
+	rows, err := l.db.Query(`SELECT device, MAX(seq) FROM reviews WHERE device != '' GROUP BY device`)
+	if err != nil {
+		return fmt.Errorf("failed to read review log: %w", err)
+	}
+	cursor := make(Cursor)
+	for rows.Next() {
+		var device string
+		var seq int
+		if err := rows.Scan(&device, &seq); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read review log: %w", err)
+		}
+		cursor[device] = seq
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read review log: %w", err)
+	}
+	events, err := l.journal.Diff(cursor)
+	if err != nil {
+		return err
+	}
+	return l.insert(events)
+}
+
+// insert adds events of the journal to the database
+func (l *Log) insert(events []Review) error {
+	if len(events) == 0 {
+		return nil
+	}
+	tx, err := l.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to log review: %w", err)
+	}
+	defer tx.Rollback()
+	if err := insertInto(tx, events, l.rollover); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to log review: %w", err)
+	}
+	return nil
+}
+
+// insertInto adds events of the journal to the database within tx, with
+// days starting at rollover o'clock
+func insertInto(tx *sql.Tx, events []Review, rollover int) error {
+	for _, review := range events {
+		_, err := tx.Exec(`INSERT OR IGNORE INTO reviews (lesson, lesson_uuid, item_hash, item_id, question, correct, reversed, answer_time, reviewed, day, device, seq)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			review.Lesson, review.LessonUUID, review.ItemHash, review.ItemID, review.Question, review.Right, review.Reversed,
+			review.AnswerTime.Seconds(), review.Time.Unix(), studyday.Of(review.Time, rollover), review.Device, review.Seq)
+		if err != nil {
+			return fmt.Errorf("failed to log review: %w", err)
+		}
+	}
+	return nil
+}
+
+// Add adds a review given on this device to the journal and the log.
+// Reviews without a time were given now.
+func (l *Log) Add(review Review) error {
+	event, err := l.journal.Append(review)
+	if err != nil {
+		return err
+	}
+	return l.insert([]Review{event})
+}
+
+// Device returns the ID of the device the log is kept on
+func (l *Log) Device() string {
+	return l.journal.Device()
+}
+
+// Cursor returns how far the events of the log go, for another device to
+// pass to Diff
+func (l *Log) Cursor() Cursor {
+	return l.journal.Cursor()
+}
+
+// Diff returns the events of the log after cursor, the ones a device
+// whose log went as far as cursor is missing
+func (l *Log) Diff(cursor Cursor) ([]Review, error) {
+	return l.journal.Diff(cursor)
+}
+
+// Merge adds the events of other devices the log is missing, such as the
+// result of another device's Diff, and returns how many it added
+func (l *Log) Merge(events []Review) (int, error) {
+	added, err := l.journal.Merge(events)
+	if insertErr := l.insert(added); err == nil {
+		err = insertErr
+	}
+	return len(added), err
+}
+
+// Rebuild works out the database anew from the events in the journal
+func (l *Log) Rebuild() error {
+	events, err := l.journal.Events()
+	if err != nil {
+		return err
+	}
+	tx, err := l.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to rebuild review log: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM reviews`); err != nil {
+		return fmt.Errorf("failed to rebuild review log: %w", err)
+	}
+	if err := insertInto(tx, events, l.rollover); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to rebuild review log: %w", err)
+	}
+	return nil
+}
+
+// Rollover returns the hour days of study start in the log
+func (l *Log) Rollover() int {
+	return l.rollover
+}
+
+// SetRollover changes the hour days of study start, working out the day of
+// every review anew from the journal if it changed
+func (l *Log) SetRollover(hour int) error {
+	hour = min(max(hour, 0), 23)
+	if hour == l.rollover {
+		return nil
+	}
+	previous := l.rollover
+	l.rollover = hour
+	if err := l.Rebuild(); err != nil {
+		l.rollover = previous
+		return err
+	}
+	if _, err := l.db.Exec(`UPDATE meta SET value = ? WHERE key = 'rollover'`, hour); err != nil {
+		return fmt.Errorf("failed to update review log: %w", err)
+	}
+	return nil
+}
+
+// Today returns the day of study of now
+func (l *Log) Today(now time.Time) string {
+	return studyday.Of(now, l.rollover)
+}
+
+// Compact folds the files of the journal together; see Journal.Compact
+func (l *Log) Compact() error {
+	return l.journal.Compact()
+}
+
+// Lessons returns the names of the lessons in the log, in order
+func (l *Log) Lessons() ([]string, error) {
+	rows, err := l.db.Query(`SELECT DISTINCT lesson FROM reviews ORDER BY lesson`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read review log: %w", err)
+	}
+	defer rows.Close()
+	var lessons []string
+	for rows.Next() {
+		var lesson string
+		if err := rows.Scan(&lesson); err != nil {
+			return nil, fmt.Errorf("failed to read review log: %w", err)
+		}
+		lessons = append(lessons, lesson)
+	}
+	return lessons, rows.Err()
+}
+
+// Count returns the number of reviews of lesson, or of all lessons when
+// lesson is empty
+func (l *Log) Count(lesson string) (int, error) {
+	var count int
+	err := l.db.QueryRow(`SELECT COUNT(*) FROM reviews WHERE ?1 = '' OR lesson = ?1`, lesson).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read review log: %w", err)
+	}
+	return count, nil
+}
+
+// History returns the reviews of the item with hash itemHash in the lesson
+// with UUID lessonUUID, oldest first. An empty itemHash takes all items of
+// the lesson and an empty lessonUUID the item in any lesson, for lessons
+// kept in a format that cannot store their UUID.
+func (l *Log) History(lessonUUID, itemHash string) ([]Review, error) {
+	rows, err := l.db.Query(`SELECT lesson, lesson_uuid, item_hash, item_id, question, correct, reversed, answer_time, reviewed, device, seq
+		FROM reviews WHERE (?1 = '' OR lesson_uuid = ?1) AND (?2 = '' OR item_hash = ?2) AND (?1 != '' OR ?2 != '')
+		ORDER BY reviewed, id`, lessonUUID, itemHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read review log: %w", err)
+	}
+	return scanReviews(rows)
+}
+
+// scanReviews reads reviews selected in the order History selects them
+func scanReviews(rows *sql.Rows) ([]Review, error) {
+	defer rows.Close()
+	var reviews []Review
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read review log: %w", err)
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, rows.Err()
+}
+
+// scanReview reads one review selected as History selects them, after the
+// values of columns selected before them
+func scanReview(rows *sql.Rows, columns ...any) (Review, error) {
+	var review Review
+	var answerTime float64
+	var reviewed int64
+	err := rows.Scan(append(columns, &review.Lesson, &review.LessonUUID, &review.ItemHash, &review.ItemID, &review.Question,
+		&review.Right, &review.Reversed, &answerTime, &reviewed, &review.Device, &review.Seq)...)
+	review.AnswerTime = time.Duration(answerTime * float64(time.Second))
+	review.Time = time.Unix(reviewed, 0)
+	return review, err
+}
+
+// Scores returns for every day since since the fraction of the answers to
+// lesson that were right. An empty lesson takes all lessons.
+func (l *Log) Scores(lesson string, since time.Time) ([]Point, error) {
+	return l.points(`SELECT day, SUM(correct), COUNT(*) FROM reviews
+		WHERE (?1 = '' OR lesson = ?1) AND day >= ?2
+		GROUP BY day ORDER BY day`, lesson, since)
+}
+
+// Retention returns for every day since since the fraction of the answers
+// to items asked before that were right, so how much of what was learnt
+// was still known. An empty lesson takes all lessons.
+func (l *Log) Retention(lesson string, since time.Time) ([]Point, error) {
+	return l.points(`SELECT day, SUM(correct), COUNT(*) FROM (
+			SELECT day, correct, ROW_NUMBER() OVER (
+				PARTITION BY lesson, item_id, reversed ORDER BY reviewed, id) AS n
+			FROM reviews WHERE ?1 = '' OR lesson = ?1)
+		WHERE n > 1 AND day >= ?2
+		GROUP BY day ORDER BY day`, lesson, since)
+}
+
+func (l *Log) points(query, lesson string, since time.Time) ([]Point, error) {
+	rows, err := l.db.Query(query, lesson, l.Today(since))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read review log: %w", err)
+	}
+	defer rows.Close()
+	var points []Point
+	for rows.Next() {
+		var point Point
+		var right int
+		if err := rows.Scan(&point.Day, &right, &point.Answers); err != nil {
+			return nil, fmt.Errorf("failed to read review log: %w", err)
+		}
+		point.Value = float64(right) / float64(point.Answers)
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
+
+// Hardest returns at most limit items of lesson answered wrong most often
+// relative to how often they were asked, of the items asked at least
+// twice. An empty lesson takes all lessons.
+func (l *Log) Hardest(lesson string, limit int) ([]ItemStats, error) {
+	rows, err := l.db.Query(`SELECT lesson, item_id, MAX(question), SUM(correct), COUNT(*) - SUM(correct), AVG(answer_time)
+		FROM reviews WHERE ?1 = '' OR lesson = ?1
+		GROUP BY lesson, item_id
+		HAVING COUNT(*) >= 2 AND SUM(correct) < COUNT(*)
+		ORDER BY CAST(COUNT(*) - SUM(correct) AS REAL) / COUNT(*) DESC, COUNT(*) - SUM(correct) DESC, lesson, item_id
+		LIMIT ?2`, lesson, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read review log: %w", err)
+	}
+	return scanItems(rows)
+}
+
+// scanItems reads item statistics selected in the order Hardest selects
+// them
+func scanItems(rows *sql.Rows) ([]ItemStats, error) {
+	defer rows.Close()
+	var items []ItemStats
+	for rows.Next() {
+		var item ItemStats
+		var answerTime float64
+		if err := rows.Scan(&item.Lesson, &item.ItemID, &item.Question, &item.Right, &item.Wrong, &answerTime); err != nil {
+			return nil, fmt.Errorf("failed to read review log: %w", err)
+		}
+		item.AnswerTime = time.Duration(answerTime * float64(time.Second))
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// Forecast returns how many items of lesson are due on each of the days
+// of study from the day of now on; items overdue already count for the
+// first day. Reviews on days after the day of now, left by a clock that
+// was set wrong, count as given today. An empty lesson takes all lessons.
+func (l *Log) Forecast(lesson string, now time.Time, days int) ([]int, error) {
+	rows, err := l.db.Query(`SELECT lesson, item_id, correct, day FROM reviews
+		WHERE ?1 = '' OR lesson = ?1
+		ORDER BY lesson, item_id, reviewed, id`, lesson)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read review log: %w", err)
+	}
+	defer rows.Close()
+
+	forecast := make([]int, days)
+	today := l.Today(now)
+	schedule := func(streak int, last string) {
+		day := max(studyday.Between(today, studyday.Add(last, interval(streak))), 0)
+		if day < days {
+			forecast[day]++
+		}
+	}
+
+	var current struct {
+		lesson string
+		itemID int
+		streak int
+		last   string
+		seen   bool
+	}
+	for rows.Next() {
+		var lesson string
+		var itemID int
+		var right bool
+		var day string
+		if err := rows.Scan(&lesson, &itemID, &right, &day); err != nil {
+			return nil, fmt.Errorf("failed to read review log: %w", err)
+		}
+		if current.seen && (lesson != current.lesson || itemID != current.itemID) {
+			schedule(current.streak, current.last)
+			current.streak = 0
+		}
+		current.lesson, current.itemID, current.seen = lesson, itemID, true
+		current.last = min(day, today)
+		if right {
+			current.streak++
+		} else {
+			current.streak = 0
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read review log: %w", err)
+	}
+	if current.seen {
+		schedule(current.streak, current.last)
+	}
+	return forecast, nil
+}
+
+// interval returns how many days after the day of its last review an item
+// answered right streak times in a row is due again
+func interval(streak int) int {
+	if streak <= 1 {
+		return 1
+	}
+	return min(1<<min(streak-1, 16), maxIntervalDays)
+}
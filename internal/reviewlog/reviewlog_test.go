@@ -0,0 +1,214 @@
+package reviewlog
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestReviewLog(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "reviews.db")
+	l, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	now := time.Date(2026, 10, 14, 18, 0, 0, 0, time.Local)
+	day := func(daysAgo int) time.Time { return now.AddDate(0, 0, -daysAgo) }
+	reviews := []Review{
+		// house: wrong, then right twice, so due two days after the last
+		// review
+		{Lesson: "animals", ItemID: 0, Question: "house", Right: false, Time: day(3)},
+		{Lesson: "animals", ItemID: 0, Question: "house", Right: true, Time: day(2)},
+		{Lesson: "animals", ItemID: 0, Question: "house", Right: true, Time: day(1)},
+		// elephant: wrong every time, due tomorrow
+		{Lesson: "animals", ItemID: 1, Question: "elephant", Right: false, Time: day(1), AnswerTime: 4 * time.Second},
+		{Lesson: "animals", ItemID: 1, Question: "elephant", Right: false, Time: now, AnswerTime: 2 * time.Second},
+		// apple: right once long ago, overdue
+		{Lesson: "fruit", ItemID: 0, Question: "apple", Right: true, Time: day(10)},
+	}
+	for _, review := range reviews {
+		if err := l.Add(review); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	l.Close()
+
+	// The log survives being closed
+	l, err = Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer l.Close()
+
+	lessons, err := l.Lessons()
+	if err != nil || len(lessons) != 2 || lessons[0] != "animals" || lessons[1] != "fruit" {
+		t.Errorf("unexpected lessons %v (%v)", lessons, err)
+	}
+	if count, err := l.Count(""); err != nil || count != 6 {
+		t.Errorf("expected 6 reviews, got %d (%v)", count, err)
+	}
+
+	scores, err := l.Scores("animals", day(7))
+	if err != nil {
+		t.Fatalf("Scores: %v", err)
+	}
+	if len(scores) != 4 || scores[2].Day != "2026-10-13" || scores[2].Value != 0.5 || scores[2].Answers != 2 {
+		t.Errorf("unexpected scores %+v", scores)
+	}
+
+	// First answers to an item do not count towards retention
+	retention, err := l.Retention("", day(7))
+	if err != nil {
+		t.Fatalf("Retention: %v", err)
+	}
+	if len(retention) != 3 || retention[1].Day != "2026-10-13" || retention[1].Value != 1 || retention[2].Value != 0 {
+		t.Errorf("unexpected retention %+v", retention)
+	}
+
+	hardest, err := l.Hardest("", 10)
+	if err != nil {
+		t.Fatalf("Hardest: %v", err)
+	}
+	if len(hardest) != 2 || hardest[0].Question != "elephant" || hardest[0].Wrong != 2 || hardest[0].AnswerTime != 3*time.Second {
+		t.Errorf("unexpected hardest items %+v", hardest)
+	}
+	if rate := hardest[1].ErrorRate(); hardest[1].Question != "house" || rate != 1.0/3 {
+		t.Errorf("expected house second with an error rate of 1/3, got %+v", hardest[1])
+	}
+
+	forecast, err := l.Forecast("", now, 7)
+	if err != nil {
+		t.Fatalf("Forecast: %v", err)
+	}
+	// apple is overdue, elephant and house are due tomorrow
+	want := []int{1, 2, 0, 0, 0, 0, 0}
+	for i := range want {
+		if forecast[i] != want[i] {
+			t.Fatalf("expected forecast %v, got %v", want, forecast)
+		}
+	}
+}
+
+func TestHistoryAfterUpgrade(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "reviews.db")
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, statement := range []string{
+		`CREATE TABLE reviews (id INTEGER PRIMARY KEY, lesson TEXT NOT NULL, item_id INTEGER NOT NULL,
+			question TEXT NOT NULL DEFAULT '', correct INTEGER NOT NULL, reversed INTEGER NOT NULL DEFAULT 0,
+			answer_time REAL NOT NULL DEFAULT 0, reviewed INTEGER NOT NULL, day TEXT NOT NULL)`,
+		`INSERT INTO reviews (lesson, item_id, question, correct, reviewed, day) VALUES ('animals', 0, 'house', 1, 1000, '1970-01-01')`,
+		`PRAGMA user_version = 1`,
+	} {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	l, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer l.Close()
+	now := time.Now().Truncate(time.Second)
+	for _, review := range []Review{
+		{Lesson: "animals", LessonUUID: "uuid-1", ItemHash: "house", ItemID: 0, Right: false, Time: now.Add(-time.Hour)},
+		// The lesson went through a format that renumbered its items
+		{Lesson: "Animals (export)", LessonUUID: "uuid-1", ItemHash: "house", ItemID: 3, Right: true, AnswerTime: 2 * time.Second, Time: now},
+		{Lesson: "animals", LessonUUID: "uuid-1", ItemHash: "dog", ItemID: 1, Right: true, Time: now},
+		{Lesson: "houses", LessonUUID: "uuid-2", ItemHash: "house", ItemID: 0, Right: true, Time: now},
+	} {
+		if err := l.Add(review); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	history, err := l.History("uuid-1", "house")
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(history) != 2 || history[0].Right || !history[1].Right || history[1].ItemID != 3 ||
+		history[1].AnswerTime != 2*time.Second || !history[1].Time.Equal(now) {
+		t.Errorf("unexpected history %+v", history)
+	}
+	if history, _ := l.History("uuid-1", ""); len(history) != 3 {
+		t.Errorf("expected all reviews of the lesson, got %+v", history)
+	}
+	if history, _ := l.History("", "house"); len(history) != 3 {
+		t.Errorf("expected the item in any lesson, got %+v", history)
+	}
+	if history, _ := l.History("", ""); len(history) != 0 {
+		t.Errorf("expected nothing without a key, got %+v", history)
+	}
+	// Reviews from before the upgrade are kept, and moved into the journal
+	if count, _ := l.Count("animals"); count != 3 {
+		t.Errorf("expected 3 reviews of animals, got %d", count)
+	}
+	if seq := l.Cursor()[l.Device()]; seq != 5 {
+		t.Errorf("expected 5 events in the journal, got %d", seq)
+	}
+}
+
+func TestDaysOfStudy(t *testing.T) {
+	amsterdam, err := time.LoadLocation("Europe/Amsterdam")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbPath := filepath.Join(t.TempDir(), "reviews.db")
+	l, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	// The night the clocks go forward, an hour shorter than others
+	now := time.Date(2026, 3, 29, 12, 0, 0, 0, amsterdam)
+	for _, review := range []Review{
+		// Both on the Saturday: the second answer was given after midnight
+		// but before the day rolled over
+		{Lesson: "animals", ItemID: 0, Right: true, Time: time.Date(2026, 3, 28, 20, 0, 0, 0, amsterdam)},
+		{Lesson: "animals", ItemID: 0, Right: true, Time: time.Date(2026, 3, 29, 1, 0, 0, 0, amsterdam)},
+		// A clock set a week ahead
+		{Lesson: "animals", ItemID: 1, Right: true, Time: now.AddDate(0, 0, 7)},
+	} {
+		if err := l.Add(review); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	// The first item is due two days after Saturday, the second one day
+	// after today
+	forecast, err := l.Forecast("", now, 3)
+	if err != nil {
+		t.Fatalf("Forecast: %v", err)
+	}
+	if forecast[0] != 0 || forecast[1] != 2 || forecast[2] != 0 {
+		t.Errorf("expected both items due tomorrow, got %v", forecast)
+	}
+	scores, err := l.Scores("animals", now.AddDate(0, 0, -1))
+	if err != nil || len(scores) != 2 || scores[0].Day != "2026-03-28" || scores[0].Answers != 2 {
+		t.Errorf("expected two answers on Saturday, got %+v (%v)", scores, err)
+	}
+
+	// With days starting at midnight the answer after midnight moves to
+	// the Sunday, also after opening the log again
+	if err := l.SetRollover(0); err != nil {
+		t.Fatalf("SetRollover: %v", err)
+	}
+	l.Close()
+	if l, err = Open(dbPath); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer l.Close()
+	if l.Rollover() != 0 {
+		t.Errorf("expected the rollover to be kept, got %d", l.Rollover())
+	}
+	scores, err = l.Scores("animals", now.AddDate(0, 0, -1))
+	if err != nil || len(scores) != 3 || scores[0].Answers != 1 || scores[1].Day != "2026-03-29" {
+		t.Errorf("expected one answer on Saturday and one on Sunday, got %+v (%v)", scores, err)
+	}
+}
@@ -0,0 +1,95 @@
+// Package studyday works out which day of study a moment falls on. A day
+// of study starts at a rollover hour, 4 AM by default, instead of at
+// midnight, so practising late in the evening and just after midnight
+// counts to the same day.
+//
+// Days are worked out from the wall clock time in the time zone of each
+// moment and counted on the calendar, never by adding up hours, so they
+// stay right across daylight saving time changes, when a day lasts 23 or
+// 25 hours, and an answer given while travelling counts to the day it was
+// on where it was given.
+package studyday
+
+import (
+	"time"
+)
+
+const (
+	// Layout is how days are written
+	Layout = "2006-01-02"
+
+	// RolloverSetting is the settings key of the hour a new day of study
+	// starts at
+	RolloverSetting = "schedule.dayRollover"
+	// DefaultRollover is the rollover hour when the setting is unset
+	DefaultRollover = 4
+)
+
+// Settings is the part of the settings module the rollover hour is kept in
+type Settings interface {
+	GetSettingWithDefault(key string, defaultValue interface{}) interface{}
+	SetSetting(key string, value interface{}) error
+}
+
+// LoadRollover reads the rollover hour from settings
+func LoadRollover(settings Settings) int {
+	switch value := settings.GetSettingWithDefault(RolloverSetting, DefaultRollover).(type) {
+	case int:
+		return clamp(value)
+	case float64:
+		// JSON turns numbers into float64
+		return clamp(int(value))
+	}
+	return DefaultRollover
+}
+
+// SaveRollover stores the rollover hour in settings
+func SaveRollover(settings Settings, hour int) error {
+	return settings.SetSetting(RolloverSetting, clamp(hour))
+}
+
+// clamp keeps an hour between 0 and 23
+func clamp(hour int) int {
+	return min(max(hour, 0), 23)
+}
+
+// Of returns the day of study t falls on, as 2006-01-02, in the time zone
+// of t, with days starting at rollover o'clock
+func Of(t time.Time, rollover int) string {
+	year, month, day := t.Date()
+	if t.Hour() < clamp(rollover) {
+		// Calendar arithmetic in UTC has no daylight saving time
+		return time.Date(year, month, day-1, 0, 0, 0, 0, time.UTC).Format(Layout)
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Format(Layout)
+}
+
+// Add returns the day n days after day; n may be negative. An invalid day
+// is returned as it is.
+func Add(day string, n int) string {
+	date, err := time.Parse(Layout, day)
+	if err != nil {
+		return day
+	}
+	return date.AddDate(0, 0, n).Format(Layout)
+}
+
+// Between returns the number of days from day from to day to: 1 from one
+// day to the next, negative when to comes first, 0 if either is invalid
+func Between(from, to string) int {
+	a, errA := time.Parse(Layout, from)
+	b, errB := time.Parse(Layout, to)
+	if errA != nil || errB != nil {
+		return 0
+	}
+	return int(b.Sub(a) / (24 * time.Hour))
+}
+
+// Weekday returns the day of the week of day, Sunday for an invalid day
+func Weekday(day string) time.Weekday {
+	date, err := time.Parse(Layout, day)
+	if err != nil {
+		return time.Sunday
+	}
+	return date.Weekday()
+}
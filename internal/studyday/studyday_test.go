@@ -0,0 +1,90 @@
+package studyday
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestOf(t *testing.T) {
+	amsterdam, err := time.LoadLocation("Europe/Amsterdam")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		at   time.Time
+		want string
+	}{
+		{time.Date(2026, 10, 14, 18, 0, 0, 0, amsterdam), "2026-10-14"},
+		// Just after midnight still counts to the evening before
+		{time.Date(2026, 10, 15, 1, 30, 0, 0, amsterdam), "2026-10-14"},
+		{time.Date(2026, 10, 15, 4, 0, 0, 0, amsterdam), "2026-10-15"},
+		{time.Date(2026, 1, 1, 3, 59, 0, 0, amsterdam), "2025-12-31"},
+		// Clocks go forward from 2:00 to 3:00: 4:30 is only three and a
+		// half hours after midnight, but a new day all the same
+		{time.Date(2026, 3, 29, 4, 30, 0, 0, amsterdam), "2026-03-29"},
+		{time.Date(2026, 3, 29, 3, 30, 0, 0, amsterdam), "2026-03-28"},
+		// Clocks go back from 3:00 to 2:00
+		{time.Date(2026, 10, 25, 2, 30, 0, 0, amsterdam).Add(time.Hour), "2026-10-24"},
+		{time.Date(2026, 10, 25, 4, 0, 0, 0, amsterdam), "2026-10-25"},
+	}
+	for _, test := range tests {
+		if got := Of(test.at, DefaultRollover); got != test.want {
+			t.Errorf("Of(%v) = %s, want %s", test.at, got, test.want)
+		}
+	}
+
+	// The same moment is on different days in different time zones
+	moment := time.Date(2026, 10, 14, 23, 0, 0, 0, time.UTC)
+	if Of(moment, 0) != "2026-10-14" || Of(moment.In(amsterdam), 0) != "2026-10-15" {
+		t.Errorf("expected the day in the time zone of the moment")
+	}
+}
+
+func TestDayArithmetic(t *testing.T) {
+	// Across both daylight saving time changes of 2026
+	if n := Between("2026-03-28", "2026-03-30"); n != 2 {
+		t.Errorf("expected 2 days, got %d", n)
+	}
+	if n := Between("2026-10-26", "2026-10-24"); n != -2 {
+		t.Errorf("expected -2 days, got %d", n)
+	}
+	if day := Add("2026-12-30", 3); day != "2027-01-02" {
+		t.Errorf("unexpected day %s", day)
+	}
+	if day := Add("2026-03-01", -1); day != "2026-02-28" {
+		t.Errorf("unexpected day %s", day)
+	}
+	if weekday := Weekday("2026-10-14"); weekday != time.Wednesday {
+		t.Errorf("expected a Wednesday, got %v", weekday)
+	}
+}
+
+type settings map[string]interface{}
+
+func (s settings) GetSettingWithDefault(key string, defaultValue interface{}) interface{} {
+	if value, ok := s[key]; ok {
+		return value
+	}
+	return defaultValue
+}
+
+func (s settings) SetSetting(key string, value interface{}) error {
+	s[key] = value
+	return nil
+}
+
+func TestRolloverSetting(t *testing.T) {
+	s := settings{}
+	if hour := LoadRollover(s); hour != DefaultRollover {
+		t.Errorf("expected the default rollover, got %d", hour)
+	}
+	SaveRollover(s, 30)
+	if hour := LoadRollover(s); hour != 23 {
+		t.Errorf("expected the hour to be clamped, got %d", hour)
+	}
+	s[RolloverSetting] = 6.0
+	if hour := LoadRollover(s); hour != 6 {
+		t.Errorf("expected a number read from JSON, got %d", hour)
+	}
+}
@@ -0,0 +1,67 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// EnvMetered overrides whether the connection is metered: "1" or "yes"
+// for metered, "0" or "no" for not
+const EnvMetered = "RECUERDO_METERED"
+
+// ErrMeteredUnknown is returned when the system does not tell whether the
+// connection is metered
+var ErrMeteredUnknown = errors.New("cannot tell whether the connection is metered")
+
+// DetectMetered tells whether the network connection is metered, such as a
+// mobile hotspot, so large downloads can wait for another network. EnvMetered
+// is asked first, then NetworkManager on Linux.
+func DetectMetered() (bool, error) {
+	if value := os.Getenv(EnvMetered); value != "" {
+		switch strings.ToLower(value) {
+		case "1", "yes", "true":
+			return true, nil
+		case "0", "no", "false":
+			return false, nil
+		}
+		return false, fmt.Errorf("%s must be yes or no, not %q", EnvMetered, value)
+	}
+
+	if runtime.GOOS != "linux" {
+		return false, ErrMeteredUnknown
+	}
+	output, err := exec.Command("busctl", "--system", "get-property",
+		"org.freedesktop.NetworkManager", "/org/freedesktop/NetworkManager",
+		"org.freedesktop.NetworkManager", "Metered").Output()
+	if err != nil {
+		return false, ErrMeteredUnknown
+	}
+	return parseNetworkManagerMetered(string(output))
+}
+
+// parseNetworkManagerMetered reads NetworkManager's Metered property as
+// busctl prints it, such as "u 4". The values are NMMetered's: unknown,
+// yes, no, guessed yes and guessed no.
+func parseNetworkManagerMetered(output string) (bool, error) {
+	fields := strings.Fields(output)
+	if len(fields) != 2 || fields[0] != "u" {
+		return false, fmt.Errorf("unexpected Metered property %q", strings.TrimSpace(output))
+	}
+	value, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return false, fmt.Errorf("unexpected Metered property %q", strings.TrimSpace(output))
+	}
+	switch value {
+	case 1, 3:
+		return true, nil
+	case 2, 4:
+		return false, nil
+	default:
+		return false, ErrMeteredUnknown
+	}
+}
@@ -0,0 +1,33 @@
+package system
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDetectMetered(t *testing.T) {
+	t.Setenv(EnvMetered, "yes")
+	if metered, err := DetectMetered(); err != nil || !metered {
+		t.Errorf("expected %s=yes to be metered, got %v, %v", EnvMetered, metered, err)
+	}
+	t.Setenv(EnvMetered, "0")
+	if metered, err := DetectMetered(); err != nil || metered {
+		t.Errorf("expected %s=0 not to be metered, got %v, %v", EnvMetered, metered, err)
+	}
+	t.Setenv(EnvMetered, "maybe")
+	if _, err := DetectMetered(); err == nil {
+		t.Errorf("expected an error for %s=maybe", EnvMetered)
+	}
+
+	for output, want := range map[string]bool{"u 1\n": true, "u 3": true, "u 2": false, "u 4\n": false} {
+		if metered, err := parseNetworkManagerMetered(output); err != nil || metered != want {
+			t.Errorf("%q: expected %v, got %v, %v", output, want, metered, err)
+		}
+	}
+	if _, err := parseNetworkManagerMetered("u 0"); !errors.Is(err, ErrMeteredUnknown) {
+		t.Errorf("expected ErrMeteredUnknown for an unknown state, got %v", err)
+	}
+	if _, err := parseNetworkManagerMetered("s \"yes\""); err == nil {
+		t.Error("expected an error for an unexpected property")
+	}
+}
@@ -0,0 +1,355 @@
+// Package thumbnail draws a small picture of a lesson without needing the
+// Qt GUI: the first items as a table for word and media lessons, the base
+// map with the places marked for topo lessons and the picture with its
+// masks for image occlusion lessons. The recently opened list, the lesson
+// library, the command line and the REST API all share this renderer.
+package thumbnail
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/LaPingvino/recuerdo/internal/maps"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// The size of thumbnails in pixels when a Renderer does not set one
+const (
+	DefaultWidth  = 320
+	DefaultHeight = 200
+)
+
+// The size of thumbnails shown as icons in lesson lists
+const (
+	IconWidth  = 96
+	IconHeight = 64
+)
+
+var (
+	background = color.RGBA{0xf0, 0xf8, 0xff, 0xff}
+	border     = color.RGBA{0xdd, 0xdd, 0xdd, 0xff}
+	place      = color.RGBA{0xff, 0x6b, 0x6b, 0xff}
+	titleBar   = color.RGBA{0x4a, 0x90, 0xd9, 0xff}
+	titleText  = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	rowText    = color.RGBA{0x33, 0x33, 0x33, 0xff}
+	stripe     = color.RGBA{0xe2, 0xee, 0xf8, 0xff}
+	mask       = color.NRGBA{0xff, 0x98, 0x00, 0xc0}
+)
+
+// MapImages returns the picture of the base map with the given ID
+type MapImages func(id string) (image.Image, error)
+
+// MapManagerImages reads base map pictures from the files of the maps
+// known to a map manager
+func MapManagerImages(manager *maps.MapManager) MapImages {
+	return func(id string) (image.Image, error) {
+		baseMap, err := manager.GetMap(id)
+		if err != nil {
+			return nil, err
+		}
+		file, err := os.Open(baseMap.ImagePath)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		img, _, err := image.Decode(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode map %s: %w", id, err)
+		}
+		return img, nil
+	}
+}
+
+// LocalMaps finds base maps the way the topo editor does, loading the maps
+// below the working directory the first time one is asked for
+func LocalMaps() MapImages {
+	var once sync.Once
+	manager := maps.NewMapManager("./")
+	images := MapManagerImages(manager)
+	return func(id string) (image.Image, error) {
+		once.Do(func() {
+			manager.LoadAvailableMaps()
+		})
+		return images(id)
+	}
+}
+
+// Renderer draws thumbnails of Width by Height pixels. Maps supplies the
+// base maps of topo lessons; without it, or when the map cannot be found,
+// only the places are drawn.
+type Renderer struct {
+	Width  int
+	Height int
+	Maps   MapImages
+}
+
+// New creates a Renderer of the default size that finds base maps with
+// maps
+func New(maps MapImages) *Renderer {
+	return &Renderer{Width: DefaultWidth, Height: DefaultHeight, Maps: maps}
+}
+
+// Render draws a thumbnail of a lesson
+func (r *Renderer) Render(lessonData *lesson.LessonData) *image.RGBA {
+	width, height := r.Width, r.Height
+	if width <= 0 || height <= 0 {
+		width, height = DefaultWidth, DefaultHeight
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fill(img, img.Bounds(), border)
+	inner := image.Rect(1, 1, width-1, height-1)
+	fill(img, inner, background)
+
+	if occlusion, ok := lessonData.OcclusionImage(); ok {
+		if r.drawOcclusion(img, inner, occlusion, lessonData.List.Items) {
+			return img
+		}
+	}
+	if isTopo(lessonData) {
+		r.drawTopo(img, inner, lessonData)
+		return img
+	}
+	drawTable(img, inner, lessonData)
+	return img
+}
+
+// WritePNG writes a thumbnail of a lesson to w as a PNG picture
+func (r *Renderer) WritePNG(w io.Writer, lessonData *lesson.LessonData) error {
+	return png.Encode(w, r.Render(lessonData))
+}
+
+// WriteFile stores a thumbnail of a lesson as the PNG file name, creating
+// its directory when needed
+func (r *Renderer) WriteFile(name string, lessonData *lesson.LessonData) error {
+	var data bytes.Buffer
+	if err := r.WritePNG(&data, lessonData); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	return lesson.WriteFileAtomically(name, data.Bytes(), 0644)
+}
+
+// CachePath returns the file in dir the thumbnail of the lesson file at path
+// is kept in
+func CachePath(dir, path string) string {
+	sum := sha1.Sum([]byte(path))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".png")
+}
+
+func isTopo(lessonData *lesson.LessonData) bool {
+	for i := range lessonData.List.Items {
+		if lessonData.List.Items[i].IsTopoItem() {
+			return true
+		}
+	}
+	return false
+}
+
+// drawOcclusion draws the picture of an image occlusion lesson with its
+// masks, reporting false if the picture cannot be decoded
+func (r *Renderer) drawOcclusion(img *image.RGBA, area image.Rectangle, occlusion *lesson.OcclusionImage, items []lesson.WordItem) bool {
+	picture, _, err := image.Decode(bytes.NewReader(occlusion.Data))
+	if err != nil {
+		return false
+	}
+	target, scale := fit(picture.Bounds(), area)
+	xdraw.BiLinear.Scale(img, target, picture, picture.Bounds(), draw.Over, nil)
+	origin := picture.Bounds().Min
+	for i := range items {
+		rect, ok := items[i].OcclusionRect()
+		if !ok {
+			continue
+		}
+		scaled := image.Rect(
+			target.Min.X+int(float64(rect.Min.X-origin.X)*scale),
+			target.Min.Y+int(float64(rect.Min.Y-origin.Y)*scale),
+			target.Min.X+int(float64(rect.Max.X-origin.X)*scale+0.5),
+			target.Min.Y+int(float64(rect.Max.Y-origin.Y)*scale+0.5),
+		).Intersect(target)
+		draw.Draw(img, scaled, image.NewUniform(mask), image.Point{}, draw.Over)
+	}
+	return true
+}
+
+// drawTopo draws the base map of a topo lesson with its places marked, or
+// only the places, scaled to fill the area, when there is no map
+func (r *Renderer) drawTopo(img *image.RGBA, area image.Rectangle, lessonData *lesson.LessonData) {
+	var places []image.Point
+	for _, item := range lessonData.List.Items {
+		if x, y, ok := item.GetTopoCoordinates(); ok {
+			places = append(places, image.Pt(x, y))
+		}
+	}
+	radius := max(2, min(area.Dx(), area.Dy())/40)
+
+	if id := lessonData.TopoMap(); id != "" && r.Maps != nil {
+		if baseMap, err := r.Maps(id); err == nil {
+			target, scale := fit(baseMap.Bounds(), area)
+			xdraw.BiLinear.Scale(img, target, baseMap, baseMap.Bounds(), draw.Over, nil)
+			origin := baseMap.Bounds().Min
+			for _, p := range places {
+				x := target.Min.X + int(float64(p.X-origin.X)*scale)
+				y := target.Min.Y + int(float64(p.Y-origin.Y)*scale)
+				drawPlace(img, x, y, radius)
+			}
+			return
+		}
+	}
+
+	if len(places) == 0 {
+		return
+	}
+	low, high := places[0], places[0]
+	for _, p := range places[1:] {
+		low = image.Pt(min(low.X, p.X), min(low.Y, p.Y))
+		high = image.Pt(max(high.X, p.X), max(high.Y, p.Y))
+	}
+	// Keep a margin so the dots at the edges are drawn whole, and centre
+	// the places so a single one ends up in the middle
+	inner := area.Inset(2 * radius)
+	scale := math.Inf(1)
+	if span := high.X - low.X; span > 0 {
+		scale = float64(inner.Dx()) / float64(span)
+	}
+	if span := high.Y - low.Y; span > 0 {
+		scale = min(scale, float64(inner.Dy())/float64(span))
+	}
+	if math.IsInf(scale, 1) {
+		scale = 0
+	}
+	centreX, centreY := float64(low.X+high.X)/2, float64(low.Y+high.Y)/2
+	for _, p := range places {
+		x := inner.Min.X + inner.Dx()/2 + int((float64(p.X)-centreX)*scale)
+		y := inner.Min.Y + inner.Dy()/2 + int((float64(p.Y)-centreY)*scale)
+		drawPlace(img, x, y, radius)
+	}
+}
+
+func drawPlace(img *image.RGBA, x, y, radius int) {
+	dot := image.Rect(x-radius, y-radius, x+radius, y+radius).Intersect(img.Bounds())
+	fill(img, dot, place)
+}
+
+// drawTable draws the lesson title above the first items, questions left
+// and answers right, as many as fit
+func drawTable(img *image.RGBA, area image.Rectangle, lessonData *lesson.LessonData) {
+	face, err := fontFace(float64(area.Dy()) / 9)
+	if err != nil {
+		return
+	}
+	defer face.Close()
+	metrics := face.Metrics()
+	lineHeight := (metrics.Ascent + metrics.Descent).Ceil() + 2
+	padding := max(2, lineHeight/4)
+
+	title := lessonData.List.Title
+	if title == "" {
+		title = fmt.Sprintf("%d items", len(lessonData.List.Items))
+	}
+	bar := image.Rect(area.Min.X, area.Min.Y, area.Max.X, area.Min.Y+lineHeight+padding)
+	fill(img, bar, titleBar)
+	drawText(img, face, bar.Inset(padding/2), title, titleText)
+
+	column := area.Dx() / 2
+	y := bar.Max.Y
+	for i, item := range lessonData.List.Items {
+		if y+lineHeight > area.Max.Y {
+			break
+		}
+		row := image.Rect(area.Min.X, y, area.Max.X, y+lineHeight)
+		if i%2 == 1 {
+			fill(img, row, stripe)
+		}
+		question, answer := strings.Join(item.Questions, ", "), strings.Join(item.Answers, ", ")
+		if item.IsCloze() {
+			question, answer = item.Cloze, ""
+		}
+		left := image.Rect(row.Min.X+padding, row.Min.Y, row.Min.X+column-padding, row.Max.Y)
+		right := image.Rect(row.Min.X+column+padding, row.Min.Y, row.Max.X-padding, row.Max.Y)
+		if answer == "" {
+			left.Max.X = right.Max.X
+		}
+		drawText(img, face, left, question, rowText)
+		drawText(img, face, right, answer, rowText)
+		y += lineHeight
+	}
+}
+
+// drawText draws one line of text in rect, shortened with an ellipsis when
+// it is too wide
+func drawText(img *image.RGBA, face font.Face, rect image.Rectangle, text string, c color.Color) {
+	if rect.Dx() <= 0 || text == "" {
+		return
+	}
+	text = strings.Join(strings.Fields(text), " ")
+	limit := fixed.I(rect.Dx())
+	if font.MeasureString(face, text) > limit {
+		runes := []rune(text)
+		for len(runes) > 0 && font.MeasureString(face, string(runes)+"…") > limit {
+			runes = runes[:len(runes)-1]
+		}
+		text = string(runes) + "…"
+	}
+	metrics := face.Metrics()
+	drawer := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: face,
+		Dot:  fixed.P(rect.Min.X, rect.Min.Y+(rect.Dy()+metrics.Ascent.Ceil()-metrics.Descent.Ceil())/2),
+	}
+	drawer.DrawString(text)
+}
+
+var (
+	goRegular     *opentype.Font
+	goRegularOnce sync.Once
+)
+
+// fontFace returns the Go font at about size pixels, never smaller than
+// what is still readable. Faces cache glyphs and may not be shared between
+// goroutines, so every thumbnail gets its own.
+func fontFace(size float64) (font.Face, error) {
+	goRegularOnce.Do(func() {
+		// The font is compiled in, so parsing it cannot fail
+		goRegular, _ = opentype.Parse(goregular.TTF)
+	})
+	return opentype.NewFace(goRegular, &opentype.FaceOptions{Size: float64(max(7, int(size))), DPI: 72, Hinting: font.HintingFull})
+}
+
+// fit returns the largest rectangle with the aspect ratio of src centred in
+// dst, and the scale from src to it
+func fit(src, dst image.Rectangle) (image.Rectangle, float64) {
+	if src.Dx() <= 0 || src.Dy() <= 0 {
+		return dst, 1
+	}
+	scale := min(float64(dst.Dx())/float64(src.Dx()), float64(dst.Dy())/float64(src.Dy()))
+	width, height := int(float64(src.Dx())*scale), int(float64(src.Dy())*scale)
+	x := dst.Min.X + (dst.Dx()-width)/2
+	y := dst.Min.Y + (dst.Dy()-height)/2
+	return image.Rect(x, y, x+width, y+height), scale
+}
+
+func fill(img *image.RGBA, rect image.Rectangle, c color.Color) {
+	draw.Draw(img, rect, image.NewUniform(c), image.Point{}, draw.Src)
+}
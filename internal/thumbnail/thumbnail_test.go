@@ -0,0 +1,142 @@
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+	"github.com/LaPingvino/recuerdo/internal/paths"
+)
+
+func sameColor(a, b color.Color) bool {
+	r1, g1, b1, _ := a.RGBA()
+	r2, g2, b2, _ := b.RGBA()
+	return r1 == r2 && g1 == g2 && b1 == b2
+}
+
+func TestRenderWords(t *testing.T) {
+	lessonData := lesson.NewLessonData()
+	lessonData.List.Title = "Animals"
+	lessonData.List.AddWordItem([]string{"el perro"}, []string{"the dog"}, "")
+	lessonData.List.AddWordItem([]string{"el gato"}, []string{"the cat", "the kitten"}, "")
+
+	img := (&Renderer{Width: 160, Height: 100}).Render(lessonData)
+	if img.Bounds() != image.Rect(0, 0, 160, 100) {
+		t.Fatalf("unexpected size %v", img.Bounds())
+	}
+	if !sameColor(img.At(158, 3), titleBar) {
+		t.Errorf("expected the title bar at the top, got %v", img.At(158, 3))
+	}
+	text := 0
+	for y := 20; y < 100; y++ {
+		for x := 0; x < 160; x++ {
+			if sameColor(img.At(x, y), rowText) {
+				text++
+			}
+		}
+	}
+	if text == 0 {
+		t.Error("expected the items to be written")
+	}
+}
+
+func TestRenderTopo(t *testing.T) {
+	lessonData := lesson.NewLessonData()
+	lessonData.List.AddTopoItem("Berlin", 50, 25, nil, nil)
+	lessonData.SetTopoMap("europe")
+
+	green := color.RGBA{0, 0x80, 0, 0xff}
+	baseMap := image.NewRGBA(image.Rect(0, 0, 100, 50))
+	fill(baseMap, baseMap.Bounds(), green)
+	renderer := New(func(id string) (image.Image, error) {
+		if id != "europe" {
+			return nil, errors.New("unknown map")
+		}
+		return baseMap, nil
+	})
+
+	img := renderer.Render(lessonData)
+	if !sameColor(img.At(5, DefaultHeight/2), green) {
+		t.Errorf("expected the base map, got %v", img.At(5, DefaultHeight/2))
+	}
+	if !sameColor(img.At(DefaultWidth/2, DefaultHeight/2), place) {
+		t.Errorf("expected a place in the middle of the map, got %v", img.At(DefaultWidth/2, DefaultHeight/2))
+	}
+
+	// Without the map only the places are drawn
+	lessonData.SetTopoMap("atlantis")
+	img = renderer.Render(lessonData)
+	if !sameColor(img.At(5, DefaultHeight/2), background) {
+		t.Errorf("expected no map, got %v", img.At(5, DefaultHeight/2))
+	}
+	if !sameColor(img.At(DefaultWidth/2, DefaultHeight/2), place) {
+		t.Errorf("expected the place, got %v", img.At(DefaultWidth/2, DefaultHeight/2))
+	}
+}
+
+func TestRenderOcclusion(t *testing.T) {
+	picture := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	fill(picture, picture.Bounds(), color.White)
+	var data bytes.Buffer
+	if err := png.Encode(&data, picture); err != nil {
+		t.Fatal(err)
+	}
+	lessonData := lesson.NewLessonData()
+	lessonData.SetOcclusionImage("heart.png", data.Bytes())
+	lessonData.List.AddOcclusionItem(image.Rect(0, 0, 20, 20), []string{"aorta"})
+
+	img := (&Renderer{Width: 42, Height: 42}).Render(lessonData)
+	if r, g, b, _ := img.At(10, 10).RGBA(); r <= b || g <= b {
+		t.Errorf("expected a mask over the top left, got %v", img.At(10, 10))
+	}
+	if !sameColor(img.At(30, 30), color.White) {
+		t.Errorf("expected the picture at the bottom right, got %v", img.At(30, 30))
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "thumbnails")
+	name := CachePath(dir, "/lessons/animals.otwd")
+	if name == CachePath(dir, "/lessons/plants.otwd") {
+		t.Error("expected different lessons to get different thumbnails")
+	}
+
+	lessonData := lesson.NewLessonData()
+	lessonData.List.AddWordItem([]string{"one"}, []string{"uno"}, "")
+	if err := New(nil).WriteFile(name, lessonData); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		t.Fatalf("invalid thumbnail: %v", err)
+	}
+	if img.Bounds().Dx() != DefaultWidth || img.Bounds().Dy() != DefaultHeight {
+		t.Errorf("unexpected size %v", img.Bounds())
+	}
+}
+
+func TestLocalMapsCreatesNoCache(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	cache := filepath.Join(t.TempDir(), "cache")
+	t.Setenv(paths.EnvCacheDir, cache)
+
+	LocalMaps()
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("finding maps created files in the working directory: %v", entries)
+	}
+	if _, err := os.Stat(cache); err == nil {
+		t.Error("the tile cache was created before a tile was downloaded")
+	}
+}
@@ -0,0 +1,128 @@
+// Package touch holds the parts of the touch mode for interactive
+// whiteboards that do not need Qt: telling swipes from taps, the style
+// sheet enlarging the controls and the layout of the on-screen keyboard.
+package touch
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Setting is the settings key turning touch mode on
+const Setting = "interface.touchMode"
+
+// ScaleSetting is the settings key holding how much touch mode enlarges
+// text and controls
+const ScaleSetting = "interface.touchScale"
+
+// DefaultScale is how much touch mode enlarges text and controls unless
+// set otherwise
+const DefaultScale = 1.5
+
+// Direction is the direction of a swipe
+type Direction int
+
+// Directions of a swipe; swiping left brings the next question, like
+// turning a page
+const (
+	NoSwipe Direction = iota
+	SwipeLeft
+	SwipeRight
+)
+
+func (d Direction) String() string {
+	switch d {
+	case SwipeLeft:
+		return "left"
+	case SwipeRight:
+		return "right"
+	}
+	return "none"
+}
+
+// Swipe limits: a swipe covers at least MinSwipeDistance pixels, mostly
+// sideways, within MaxSwipeTime
+const (
+	MinSwipeDistance = 120
+	MaxSwipeTime     = 800 * time.Millisecond
+)
+
+// DetectSwipe returns the direction of a finger moving dx and dy pixels in
+// elapsed time, or NoSwipe for taps, scrolling and slow drags such as
+// selecting text
+func DetectSwipe(dx, dy int, elapsed time.Duration) Direction {
+	if elapsed > MaxSwipeTime {
+		return NoSwipe
+	}
+	horizontal, vertical := math.Abs(float64(dx)), math.Abs(float64(dy))
+	if horizontal < MinSwipeDistance || horizontal < 2*vertical {
+		return NoSwipe
+	}
+	if dx < 0 {
+		return SwipeLeft
+	}
+	return SwipeRight
+}
+
+// StyleSheet returns the application style sheet of touch mode, enlarging
+// text by scale and making buttons, fields, check boxes and scroll bars
+// big enough to hit with a finger
+func StyleSheet(scale float64) string {
+	if scale < 1 {
+		scale = 1
+	}
+	size := func(base float64) int { return int(math.Round(base * scale)) }
+	return fmt.Sprintf(`
+QWidget { font-size: %dpt; }
+QPushButton, QToolButton, QComboBox { min-height: %dpx; min-width: %dpx; padding: %dpx %dpx; }
+QLineEdit, QSpinBox { min-height: %dpx; padding: %dpx; }
+QCheckBox::indicator, QRadioButton::indicator { width: %dpx; height: %dpx; }
+QTabBar::tab { min-height: %dpx; padding: %dpx %dpx; }
+QScrollBar:vertical { width: %dpx; }
+QScrollBar:horizontal { height: %dpx; }
+`,
+		size(10),
+		size(32), size(32), size(6), size(12),
+		size(28), size(4),
+		size(16), size(16),
+		size(28), size(6), size(12),
+		size(16),
+		size(16))
+}
+
+// Key is a key of the on-screen keyboard
+type Key struct {
+	// Label is shown on the key
+	Label string
+	// Text is typed by the key; keys without text are special keys
+	Text string
+	// Width is the width of the key in units of a letter key
+	Width int
+}
+
+// Special keys of the on-screen keyboard
+var (
+	Backspace = Key{Label: "⌫", Width: 2}
+	Enter     = Key{Label: "⏎", Width: 2}
+	Space     = Key{Label: "Space", Text: " ", Width: 6}
+)
+
+// KeyboardRows returns the rows of the on-screen keyboard: the letters,
+// with the keys typing what is needed most in answers, and the space bar
+func KeyboardRows() [][]Key {
+	letters := func(row string) []Key {
+		var keys []Key
+		for _, r := range row {
+			keys = append(keys, Key{Label: string(r), Text: string(r), Width: 1})
+		}
+		return keys
+	}
+	return [][]Key{
+		letters("1234567890"),
+		append(letters("qwertyuiop"), Backspace),
+		append(letters("asdfghjkl'"), Enter),
+		letters("zxcvbnm,.-?"),
+		{Space},
+	}
+}
@@ -0,0 +1,60 @@
+package touch
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectSwipe(t *testing.T) {
+	tests := []struct {
+		name    string
+		dx, dy  int
+		elapsed time.Duration
+		want    Direction
+	}{
+		{"swipe left", -300, 20, 200 * time.Millisecond, SwipeLeft},
+		{"swipe right", 250, -40, 300 * time.Millisecond, SwipeRight},
+		{"tap", 3, 2, 100 * time.Millisecond, NoSwipe},
+		{"too short", -80, 0, 100 * time.Millisecond, NoSwipe},
+		{"scrolling", 150, 400, 200 * time.Millisecond, NoSwipe},
+		{"selecting text", -400, 0, 2 * time.Second, NoSwipe},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectSwipe(tt.dx, tt.dy, tt.elapsed); got != tt.want {
+				t.Errorf("DetectSwipe(%d, %d, %v) = %v, want %v", tt.dx, tt.dy, tt.elapsed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStyleSheet(t *testing.T) {
+	if !strings.Contains(StyleSheet(2), "font-size: 20pt") {
+		t.Errorf("doubled text is not 20pt:\n%s", StyleSheet(2))
+	}
+	// Touch mode never shrinks anything
+	if StyleSheet(0.5) != StyleSheet(1) {
+		t.Error("a scale below 1 shrinks the controls")
+	}
+}
+
+func TestKeyboardRows(t *testing.T) {
+	seen := map[string]bool{}
+	for _, row := range KeyboardRows() {
+		for _, key := range row {
+			if key.Label == "" || key.Width < 1 {
+				t.Errorf("key %+v has no label or width", key)
+			}
+			if seen[key.Label] {
+				t.Errorf("key %q appears twice", key.Label)
+			}
+			seen[key.Label] = true
+		}
+	}
+	for _, label := range []string{"a", "z", "0", Backspace.Label, Enter.Label, Space.Label} {
+		if !seen[label] {
+			t.Errorf("the keyboard lacks %q", label)
+		}
+	}
+}
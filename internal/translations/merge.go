@@ -0,0 +1,176 @@
+package translations
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// fuzzyThreshold is how similar, from 0 to 1, a translated string has to be
+// to a new one for its translation to be offered as a fuzzy guess
+const fuzzyThreshold = 0.7
+
+// minFuzzyLength is the length in runes below which strings get no fuzzy
+// guess: short words are often alike without meaning the same, such as
+// "None" and "Note"
+const minFuzzyLength = 8
+
+// Merge updates the catalog po of a language to the strings of template
+// pot, like msgmerge does. Translations are kept for strings that are still
+// used, and taken from the compendiums (such as the catalogs of the Python
+// version) when po has none. New strings without an exact translation get
+// the translation of the most similar string, marked fuzzy. Translations no
+// longer used are kept as obsolete messages. The header of po is kept.
+func Merge(po, pot *Catalog, compendiums ...*Catalog) *Catalog {
+	merged := NewCatalog(po.Header)
+	merged.HeaderComments = po.HeaderComments
+	plurals := po.PluralForms()
+
+	// Translations that can be reused, exact ones looked up by key
+	sources := append([]*Catalog{po}, compendiums...)
+	var candidates []*Message
+	for _, source := range sources {
+		for _, message := range source.Messages {
+			if message.Translated() {
+				candidates = append(candidates, message)
+			}
+		}
+	}
+
+	used := make(map[*Message]bool)
+	for _, template := range pot.Messages {
+		if template.Obsolete {
+			continue
+		}
+		message := &Message{
+			Context:           template.Context,
+			ID:                template.ID,
+			Plural:            template.Plural,
+			ExtractedComments: template.ExtractedComments,
+			References:        template.References,
+			Flags:             withoutFlag(template.Flags, "fuzzy"),
+		}
+
+		var previous *Message
+		for _, source := range sources {
+			if found := source.Find(template.Context, template.ID); found != nil && (found.Translated() || source == po) {
+				previous = found
+				break
+			}
+		}
+		switch {
+		case previous != nil:
+			used[previous] = true
+			message.Comments = previous.Comments
+			message.Str = previous.Str
+			if previous.Fuzzy() {
+				message.SetFlag("fuzzy", true)
+			}
+		default:
+			if guess := mostSimilar(template, candidates); guess != nil {
+				message.Str = guess.Str
+				message.SetFlag("fuzzy", true)
+			}
+		}
+		message.Str = fitPlurals(message, plurals)
+		merged.Add(message)
+	}
+
+	for _, message := range po.Messages {
+		if used[message] || merged.Find(message.Context, message.ID) != nil {
+			continue
+		}
+		if message.Obsolete || message.Translated() || message.Fuzzy() {
+			obsolete := *message
+			obsolete.Obsolete = true
+			obsolete.References = nil
+			merged.Add(&obsolete)
+		}
+	}
+	return merged
+}
+
+// fitPlurals gives a message as many translations as it has forms
+func fitPlurals(message *Message, plurals int) []string {
+	forms := 1
+	if message.Plural != "" {
+		forms = plurals
+	}
+	strs := make([]string, forms)
+	copy(strs, message.Str)
+	return strs
+}
+
+// mostSimilar returns the translated message whose ID is most like that of
+// message, or nil when none is similar enough
+func mostSimilar(message *Message, candidates []*Message) *Message {
+	if utf8.RuneCountInString(message.ID) < minFuzzyLength {
+		return nil
+	}
+	var best *Message
+	bestScore := 0.0
+	for _, candidate := range candidates {
+		if (candidate.Plural == "") != (message.Plural == "") {
+			continue
+		}
+		// Strings differing too much in length cannot be similar enough;
+		// skipping them saves computing most edit distances
+		la, lb := len(message.ID), len(candidate.ID)
+		if float64(min(la, lb)) < fuzzyThreshold*float64(max(la, lb)) {
+			continue
+		}
+		score := similarity(message.ID, candidate.ID)
+		if score < fuzzyThreshold {
+			continue
+		}
+		if best == nil || score > bestScore {
+			best, bestScore = candidate, score
+		}
+	}
+	return best
+}
+
+// similarity compares two strings by their edit distance, ignoring case:
+// 1 means equal and 0 nothing in common
+func similarity(a, b string) float64 {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	longest := utf8.RuneCountInString(a)
+	if n := utf8.RuneCountInString(b); n > longest {
+		longest = n
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(editDistance(a, b))/float64(longest)
+}
+
+// editDistance is the Levenshtein distance between a and b in runes
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+func withoutFlag(flags []string, flag string) []string {
+	var kept []string
+	for _, f := range flags {
+		if f != flag {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,457 @@
+// Package translations reads, writes and merges gettext catalogs (.po and
+// .pot files), the format OpenTeacher's translators have always worked in.
+package translations
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Message is one translatable string of a catalog
+type Message struct {
+	Context string
+	ID      string
+	Plural  string
+	// Str holds the translation, or one per plural form
+	Str []string
+	// Comments are written by translators, ExtractedComments by the
+	// extractor for them
+	Comments          []string
+	ExtractedComments []string
+	// References are the file:line places the string is used
+	References []string
+	Flags      []string
+	// Obsolete messages are no longer used by the program but kept so
+	// their translation can be reused
+	Obsolete bool
+}
+
+// Fuzzy reports whether the translation still needs to be checked
+func (m *Message) Fuzzy() bool {
+	return m.HasFlag("fuzzy")
+}
+
+// HasFlag reports whether the message has a flag such as "c-format"
+func (m *Message) HasFlag(flag string) bool {
+	for _, f := range m.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// SetFlag adds or removes a flag
+func (m *Message) SetFlag(flag string, set bool) {
+	flags := m.Flags[:0:0]
+	for _, f := range m.Flags {
+		if f != flag {
+			flags = append(flags, f)
+		}
+	}
+	if set {
+		flags = append(flags, flag)
+	}
+	m.Flags = flags
+}
+
+// Translated reports whether every form of the message is translated and
+// the translation is not fuzzy
+func (m *Message) Translated() bool {
+	if len(m.Str) == 0 || m.Fuzzy() {
+		return false
+	}
+	for _, str := range m.Str {
+		if str == "" {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *Message) key() string {
+	return m.Context + "\x04" + m.ID
+}
+
+// Catalog is the contents of a .po or .pot file
+type Catalog struct {
+	// HeaderComments are the comment lines above the header, such as the
+	// copyright notice
+	HeaderComments []string
+	// Header is the translation of the empty message: "Key: value" lines
+	Header   string
+	Messages []*Message
+	index    map[string]*Message
+}
+
+// NewCatalog creates an empty catalog with the given header
+func NewCatalog(header string) *Catalog {
+	return &Catalog{Header: header, index: make(map[string]*Message)}
+}
+
+// Find returns the message with the given context and ID
+func (c *Catalog) Find(context, id string) *Message {
+	if c.index == nil {
+		c.reindex()
+	}
+	return c.index[context+"\x04"+id]
+}
+
+// Add adds a message. When the catalog has it already, the references and
+// flags of both are combined.
+func (c *Catalog) Add(message *Message) {
+	if existing := c.Find(message.Context, message.ID); existing != nil {
+		existing.References = appendMissing(existing.References, message.References...)
+		existing.ExtractedComments = appendMissing(existing.ExtractedComments, message.ExtractedComments...)
+		existing.Flags = appendMissing(existing.Flags, message.Flags...)
+		return
+	}
+	c.Messages = append(c.Messages, message)
+	c.index[message.key()] = message
+}
+
+func (c *Catalog) reindex() {
+	c.index = make(map[string]*Message, len(c.Messages))
+	for _, message := range c.Messages {
+		c.index[message.key()] = message
+	}
+}
+
+// HeaderField returns a field of the header, such as "Language"
+func (c *Catalog) HeaderField(name string) string {
+	for _, line := range strings.Split(c.Header, "\n") {
+		if key, value, ok := strings.Cut(line, ":"); ok && strings.EqualFold(strings.TrimSpace(key), name) {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// SetHeaderField sets a field of the header, adding it when missing
+func (c *Catalog) SetHeaderField(name, value string) {
+	lines := strings.Split(strings.TrimSuffix(c.Header, "\n"), "\n")
+	for i, line := range lines {
+		if key, _, ok := strings.Cut(line, ":"); ok && strings.EqualFold(strings.TrimSpace(key), name) {
+			lines[i] = name + ": " + value
+			c.Header = strings.Join(lines, "\n") + "\n"
+			return
+		}
+	}
+	if c.Header == "" {
+		lines = nil
+	}
+	c.Header = strings.Join(append(lines, name+": "+value), "\n") + "\n"
+}
+
+// pluralCount matches the number of plural forms in a Plural-Forms header
+var pluralCount = regexp.MustCompile(`nplurals\s*=\s*(\d+)`)
+
+// PluralForms returns how many plural forms translations of plural
+// messages have, 2 when the header does not say
+func (c *Catalog) PluralForms() int {
+	if match := pluralCount.FindStringSubmatch(c.HeaderField("Plural-Forms")); match != nil {
+		if n, err := strconv.Atoi(match[1]); err == nil && n > 0 {
+			return n
+		}
+	}
+	return 2
+}
+
+// Stats counts the messages of a catalog that are in use
+type Stats struct {
+	Translated   int
+	Fuzzy        int
+	Untranslated int
+}
+
+// Total is the number of messages counted
+func (s Stats) Total() int {
+	return s.Translated + s.Fuzzy + s.Untranslated
+}
+
+// Percent is the percentage of translated messages
+func (s Stats) Percent() int {
+	if s.Total() == 0 {
+		return 100
+	}
+	return s.Translated * 100 / s.Total()
+}
+
+// Stats counts the translated, fuzzy and untranslated messages, leaving
+// obsolete ones out
+func (c *Catalog) Stats() Stats {
+	var stats Stats
+	for _, message := range c.Messages {
+		switch {
+		case message.Obsolete:
+		case message.Translated():
+			stats.Translated++
+		case message.Fuzzy():
+			stats.Fuzzy++
+		default:
+			stats.Untranslated++
+		}
+	}
+	return stats
+}
+
+// Parse reads a .po or .pot file
+func Parse(r io.Reader) (*Catalog, error) {
+	catalog := NewCatalog("")
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	message := &Message{}
+	started := false // whether message has a msgid yet
+	var field *string
+	lineNumber := 0
+
+	finish := func() {
+		if !started {
+			return
+		}
+		if message.ID == "" && message.Context == "" && !message.Obsolete {
+			catalog.HeaderComments = message.Comments
+			if len(message.Str) > 0 {
+				catalog.Header = message.Str[0]
+			}
+		} else {
+			catalog.Messages = append(catalog.Messages, message)
+		}
+		message = &Message{}
+		started = false
+		field = nil
+	}
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		obsolete := false
+		if rest, ok := strings.CutPrefix(line, "#~"); ok {
+			obsolete = true
+			line = strings.TrimSpace(rest)
+			if strings.HasPrefix(line, "|") {
+				// Previous msgid of an obsolete fuzzy message
+				continue
+			}
+		}
+
+		switch {
+		case line == "":
+			finish()
+			continue
+		case strings.HasPrefix(line, "#"):
+			if started && field != nil && !strings.HasPrefix(line, "#|") {
+				finish()
+			}
+			switch {
+			case strings.HasPrefix(line, "#:"):
+				message.References = append(message.References, strings.Fields(line[2:])...)
+			case strings.HasPrefix(line, "#,"):
+				for _, flag := range strings.Split(line[2:], ",") {
+					if flag = strings.TrimSpace(flag); flag != "" {
+						message.Flags = append(message.Flags, flag)
+					}
+				}
+			case strings.HasPrefix(line, "#."):
+				message.ExtractedComments = append(message.ExtractedComments, strings.TrimSpace(line[2:]))
+			case strings.HasPrefix(line, "#|"):
+				// Previous msgid of a fuzzy message; recreated by merging
+			default:
+				message.Comments = append(message.Comments, strings.TrimPrefix(strings.TrimPrefix(line, "#"), " "))
+			}
+			continue
+		}
+
+		keyword, rest, _ := strings.Cut(line, " ")
+		if strings.HasPrefix(line, `"`) {
+			keyword, rest = "", line
+		}
+		if started && (keyword == "msgctxt" || keyword == "msgid") {
+			finish()
+		}
+		if obsolete {
+			message.Obsolete = true
+		}
+
+		switch {
+		case keyword == "":
+			if field == nil {
+				return nil, fmt.Errorf("line %d: text outside of a message", lineNumber)
+			}
+		case keyword == "msgctxt":
+			field = &message.Context
+		case keyword == "msgid":
+			started = true
+			field = &message.ID
+		case keyword == "msgid_plural":
+			field = &message.Plural
+		case keyword == "msgstr":
+			message.Str = append(message.Str, "")
+			field = &message.Str[len(message.Str)-1]
+		case strings.HasPrefix(keyword, "msgstr["):
+			n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(keyword, "msgstr["), "]"))
+			if err != nil || n < 0 || n > 16 {
+				return nil, fmt.Errorf("line %d: invalid plural form %q", lineNumber, keyword)
+			}
+			for len(message.Str) <= n {
+				message.Str = append(message.Str, "")
+			}
+			field = &message.Str[n]
+		default:
+			return nil, fmt.Errorf("line %d: unknown keyword %q", lineNumber, keyword)
+		}
+
+		text, err := strconv.Unquote(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid string %s", lineNumber, rest)
+		}
+		*field += text
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	finish()
+	catalog.reindex()
+	return catalog, nil
+}
+
+// Write writes the catalog in .po format, obsolete messages last
+func (c *Catalog) Write(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	for _, comment := range c.HeaderComments {
+		writeComment(b, "#", comment)
+	}
+	b.WriteString("msgid \"\"\n")
+	writeString(b, "", "msgstr", c.Header)
+
+	for _, obsolete := range []bool{false, true} {
+		for _, message := range c.Messages {
+			if message.Obsolete != obsolete {
+				continue
+			}
+			b.WriteString("\n")
+			writeMessage(b, message)
+		}
+	}
+	return b.Flush()
+}
+
+func writeMessage(b *bufio.Writer, m *Message) {
+	for _, comment := range m.Comments {
+		writeComment(b, "#", comment)
+	}
+	for _, comment := range m.ExtractedComments {
+		writeComment(b, "#.", comment)
+	}
+	if !m.Obsolete && len(m.References) > 0 {
+		// Wrap references like xgettext does
+		line := "#:"
+		for _, reference := range m.References {
+			if len(line)+1+len(reference) > 79 && line != "#:" {
+				b.WriteString(line + "\n")
+				line = "#:"
+			}
+			line += " " + reference
+		}
+		b.WriteString(line + "\n")
+	}
+	if len(m.Flags) > 0 {
+		b.WriteString("#, " + strings.Join(m.Flags, ", ") + "\n")
+	}
+
+	prefix := ""
+	if m.Obsolete {
+		prefix = "#~ "
+	}
+	if m.Context != "" {
+		writeString(b, prefix, "msgctxt", m.Context)
+	}
+	writeString(b, prefix, "msgid", m.ID)
+	if m.Plural != "" {
+		writeString(b, prefix, "msgid_plural", m.Plural)
+		strs := m.Str
+		if len(strs) == 0 {
+			strs = []string{"", ""}
+		}
+		for i, str := range strs {
+			writeString(b, prefix, fmt.Sprintf("msgstr[%d]", i), str)
+		}
+		return
+	}
+	str := ""
+	if len(m.Str) > 0 {
+		str = m.Str[0]
+	}
+	writeString(b, prefix, "msgstr", str)
+}
+
+func writeComment(b *bufio.Writer, marker, comment string) {
+	if comment == "" {
+		b.WriteString(marker + "\n")
+		return
+	}
+	b.WriteString(marker + " " + comment + "\n")
+}
+
+// writeString writes a keyword and its string, splitting it after every
+// newline as gettext tools do
+func writeString(b *bufio.Writer, prefix, keyword, text string) {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) <= 1 {
+		b.WriteString(prefix + keyword + " " + quote(text) + "\n")
+		return
+	}
+	b.WriteString(prefix + keyword + " \"\"\n")
+	for _, line := range lines {
+		b.WriteString(prefix + quote(line) + "\n")
+	}
+}
+
+// quote escapes a string the way gettext expects, leaving non-ASCII
+// characters alone
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func appendMissing(list []string, values ...string) []string {
+	for _, value := range values {
+		found := false
+		for _, existing := range list {
+			if existing == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, value)
+		}
+	}
+	return list
+}
@@ -0,0 +1,154 @@
+package translations
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const dutch = `# Dutch translation for openteacher
+# Copyright (c) 2012 Rosetta Contributors and Canonical Ltd 2012
+msgid ""
+msgstr ""
+"Project-Id-Version: openteacher\n"
+"Language: nl\n"
+"Plural-Forms: nplurals=2; plural=n != 1;\n"
+
+#: gui.go:10
+msgid "Open file"
+msgstr "Bestand openen"
+
+#, fuzzy
+msgid "Save"
+msgstr "Opslaan als"
+
+msgctxt "menu"
+msgid "Quit"
+msgstr "Afsluiten"
+
+msgid "%d word"
+msgid_plural "%d words"
+msgstr[0] "%d woord"
+msgstr[1] "%d woorden"
+
+msgid "Removed string"
+msgstr "Verwijderde tekst"
+
+msgid ""
+"Two\n"
+"lines"
+msgstr ""
+
+#~ msgid "Older string"
+#~ msgstr "Oudere tekst"
+`
+
+func TestParseAndWrite(t *testing.T) {
+	catalog, err := Parse(strings.NewReader(dutch))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got := catalog.HeaderField("Language"); got != "nl" {
+		t.Errorf("Language = %q", got)
+	}
+	if len(catalog.HeaderComments) != 2 {
+		t.Errorf("HeaderComments = %q", catalog.HeaderComments)
+	}
+	if len(catalog.Messages) != 7 {
+		t.Fatalf("got %d messages, want 7", len(catalog.Messages))
+	}
+	if m := catalog.Find("menu", "Quit"); m == nil || m.Str[0] != "Afsluiten" {
+		t.Errorf("Find(menu, Quit) = %+v", m)
+	}
+	if m := catalog.Find("", "%d word"); m == nil || len(m.Str) != 2 || m.Str[1] != "%d woorden" {
+		t.Errorf("plural message = %+v", m)
+	}
+	if m := catalog.Find("", "Two\nlines"); m == nil {
+		t.Error("multi-line msgid not parsed")
+	}
+	if m := catalog.Find("", "Older string"); m == nil || !m.Obsolete {
+		t.Errorf("obsolete message = %+v", m)
+	}
+
+	stats := catalog.Stats()
+	if stats != (Stats{Translated: 4, Fuzzy: 1, Untranslated: 1}) {
+		t.Errorf("Stats = %+v", stats)
+	}
+	if stats.Percent() != 66 {
+		t.Errorf("Percent = %d", stats.Percent())
+	}
+
+	var out bytes.Buffer
+	if err := catalog.Write(&out); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	written := out.String()
+	again, err := Parse(&out)
+	if err != nil {
+		t.Fatalf("Parse of written catalog: %v\n%s", err, written)
+	}
+	if again.Header != catalog.Header || len(again.Messages) != len(catalog.Messages) {
+		t.Errorf("round trip changed the catalog:\n%s", written)
+	}
+	if !strings.Contains(written, "#~ msgid \"Older string\"") {
+		t.Errorf("obsolete message not written as such:\n%s", written)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	if _, err := Parse(strings.NewReader("msgid \"a\"\nmsgfoo \"b\"\n")); err == nil {
+		t.Error("expected an error for an unknown keyword")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	po, err := Parse(strings.NewReader(dutch))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pot := NewCatalog("")
+	pot.Add(&Message{ID: "Open file", References: []string{"gui.go:12"}})
+	pot.Add(&Message{ID: "Open files", References: []string{"gui.go:20"}})
+	pot.Add(&Message{ID: "Save"})
+	pot.Add(&Message{ID: "%d word", Plural: "%d words", Flags: []string{"c-format"}})
+	pot.Add(&Message{ID: "Print"})
+	pot.Add(&Message{ID: "Settings"})
+
+	legacy := NewCatalog("")
+	legacy.Add(&Message{ID: "Print", Str: []string{"Afdrukken"}})
+
+	merged := Merge(po, pot, legacy)
+
+	if merged.HeaderField("Language") != "nl" {
+		t.Error("header of the language catalog not kept")
+	}
+	if m := merged.Find("", "Open file"); m.Str[0] != "Bestand openen" || m.Fuzzy() || m.References[0] != "gui.go:12" {
+		t.Errorf("kept translation = %+v", m)
+	}
+	if m := merged.Find("", "Open files"); m.Str[0] != "Bestand openen" || !m.Fuzzy() {
+		t.Errorf("similar string should get a fuzzy guess: %+v", m)
+	}
+	if m := merged.Find("", "Save"); !m.Fuzzy() {
+		t.Errorf("fuzzy translation should stay fuzzy: %+v", m)
+	}
+	if m := merged.Find("", "%d word"); len(m.Str) != 2 || !m.HasFlag("c-format") {
+		t.Errorf("plural message = %+v", m)
+	}
+	if m := merged.Find("", "Print"); m.Str[0] != "Afdrukken" || m.Fuzzy() {
+		t.Errorf("compendium translation = %+v", m)
+	}
+	if m := merged.Find("", "Settings"); m.Str[0] != "" || m.Fuzzy() {
+		t.Errorf("new string = %+v", m)
+	}
+	if m := merged.Find("", "Removed string"); m == nil || !m.Obsolete {
+		t.Errorf("unused translation should become obsolete: %+v", m)
+	}
+	if m := merged.Find("", "Two\nlines"); m != nil {
+		t.Errorf("unused untranslated string should be dropped: %+v", m)
+	}
+
+	stats := merged.Stats()
+	if stats != (Stats{Translated: 3, Fuzzy: 2, Untranslated: 1}) {
+		t.Errorf("Stats = %+v", stats)
+	}
+}
@@ -0,0 +1,144 @@
+// Package vault encrypts what a device syncs through "recuerdo serve", so
+// the server keeps only blobs it cannot read. Every user has a vault on
+// the server holding a random salt; the key is derived from the user's
+// passphrase and that salt with Argon2id, so every device with the
+// passphrase derives the same key and nothing secret is ever sent.
+//
+// Blobs are sealed with AES-256-GCM and named by an HMAC of what they
+// hold, so the server does not learn lesson names either. It still sees
+// how many blobs there are, how large they are and when they change.
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// SaltSize is the size of the salt of a vault
+const SaltSize = 16
+
+// CheckBlob is the blob next to the salt that tells whether a passphrase
+// is the vault's: the device creating the vault seals checkValue in it,
+// and a key that cannot open it belongs to another passphrase
+const CheckBlob = "check"
+
+// checkValue is what CheckBlob holds once opened
+const checkValue = "recuerdo vault"
+
+// blobVersion is the first byte of a sealed blob
+const blobVersion = 1
+
+// Argon2id parameters, as recommended by RFC 9106 for memory-constrained
+// machines; changing them changes every key
+const (
+	keyTime    uint32 = 3
+	keyMemory  uint32 = 64 * 1024
+	keyThreads uint8  = 4
+)
+
+var (
+	// ErrPassphraseRequired is returned when deriving a key without a
+	// passphrase
+	ErrPassphraseRequired = errors.New("a passphrase is needed for encrypted sync")
+	// ErrWrongPassphrase is returned for blobs that cannot be opened,
+	// because the passphrase is wrong or the blob was damaged
+	ErrWrongPassphrase = errors.New("wrong sync passphrase, or the synced data is damaged")
+)
+
+// Key seals and opens the blobs of a vault
+type Key struct {
+	aead cipher.AEAD
+	// names is the HMAC key blob names are derived with
+	names []byte
+}
+
+// NewSalt returns a random salt for a new vault
+func NewSalt() ([]byte, error) {
+	salt := make([]byte, SaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// DeriveKey derives the key of a vault from the passphrase and the salt of
+// the vault. It is slow on purpose; keep the key rather than deriving it
+// for every blob.
+func DeriveKey(passphrase string, salt []byte) (*Key, error) {
+	if passphrase == "" {
+		return nil, ErrPassphraseRequired
+	}
+	if len(salt) != SaltSize {
+		return nil, fmt.Errorf("invalid vault salt of %d bytes", len(salt))
+	}
+	material := argon2.IDKey([]byte(passphrase), salt, keyTime, keyMemory, keyThreads, 64)
+	block, err := aes.NewCipher(material[:32])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{aead: aead, names: material[32:]}, nil
+}
+
+// Seal encrypts plaintext into a blob. The name the blob is kept under is
+// authenticated with it, so the server cannot swap two blobs unnoticed.
+func (k *Key) Seal(name string, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	blob := append([]byte{blobVersion}, nonce...)
+	return k.aead.Seal(blob, nonce, plaintext, []byte(name)), nil
+}
+
+// Open decrypts a blob sealed under name
+func (k *Key) Open(name string, blob []byte) ([]byte, error) {
+	header := 1 + k.aead.NonceSize()
+	if len(blob) < header || blob[0] != blobVersion {
+		return nil, ErrWrongPassphrase
+	}
+	plaintext, err := k.aead.Open(nil, blob[1:header], blob[header:], []byte(name))
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return plaintext, nil
+}
+
+// SealCheck returns the content of CheckBlob for a new vault
+func (k *Key) SealCheck() ([]byte, error) {
+	return k.Seal(CheckBlob, []byte(checkValue))
+}
+
+// Check returns ErrWrongPassphrase unless k opens blob, the content of
+// CheckBlob
+func (k *Key) Check(blob []byte) error {
+	value, err := k.Open(CheckBlob, blob)
+	if err != nil {
+		return err
+	}
+	if string(value) != checkValue {
+		return ErrWrongPassphrase
+	}
+	return nil
+}
+
+// BlobName returns the name a blob of the given kind, such as "lesson",
+// is kept under, which only devices with the key can tell from name
+func (k *Key) BlobName(kind, name string) string {
+	mac := hmac.New(sha256.New, k.names)
+	mac.Write([]byte(kind))
+	mac.Write([]byte{0})
+	mac.Write([]byte(name))
+	return kind[:1] + "-" + hex.EncodeToString(mac.Sum(nil)[:16])
+}
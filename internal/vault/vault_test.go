@@ -0,0 +1,60 @@
+package vault
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestVault(t *testing.T) {
+	salt, err := NewSalt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DeriveKey("", salt); !errors.Is(err, ErrPassphraseRequired) {
+		t.Errorf("Expected a passphrase to be required, got %v", err)
+	}
+	key, err := DeriveKey("correct horse", salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	same, _ := DeriveKey("correct horse", salt)
+	other, _ := DeriveKey("wrong horse", salt)
+
+	name := key.BlobName("lesson", "animals.ot")
+	if name != same.BlobName("lesson", "animals.ot") || name == other.BlobName("lesson", "animals.ot") || name == key.BlobName("history", "animals.ot") {
+		t.Errorf("Expected blob names to depend on the key, kind and name only, got %q", name)
+	}
+
+	plaintext := []byte(`{"list":{"title":"Animals"}}`)
+	blob, err := key.Seal(name, plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(blob, []byte("Animals")) {
+		t.Error("Expected the blob not to hold the plaintext")
+	}
+	if opened, err := same.Open(name, blob); err != nil || !bytes.Equal(opened, plaintext) {
+		t.Errorf("Expected another device with the passphrase to open the blob, got %q, %v", opened, err)
+	}
+	if _, err := other.Open(name, blob); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Expected a wrong passphrase to fail, got %v", err)
+	}
+	if _, err := key.Open(key.BlobName("lesson", "colours.ot"), blob); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Expected a blob kept under another name to fail, got %v", err)
+	}
+
+	check, err := key.SealCheck()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := same.Check(check); err != nil {
+		t.Errorf("Expected the check to open with the passphrase, got %v", err)
+	}
+	if err := other.Check(check); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Expected the check to refuse a wrong passphrase, got %v", err)
+	}
+	if err := key.Check(blob); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Expected another blob not to pass the check, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package recuerdo
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// Format is a lesson file format
+type Format struct {
+	// Extension is the file name extension of the format, such as ".otwd"
+	// or ".pau.gz"
+	Extension string
+	// Name is a human-readable name of the format
+	Name string
+	// Load and Save tell whether Load and Save support the format
+	Load bool
+	Save bool
+}
+
+// Formats returns the lesson formats Load and Save understand, ordered by
+// extension
+func Formats() []Format {
+	loader := lesson.NewFileLoader()
+	saver := lesson.NewFileSaver()
+	byExtension := make(map[string]*Format)
+	get := func(ext string) *Format {
+		if format, ok := byExtension[ext]; ok {
+			return format
+		}
+		format := &Format{Extension: ext, Name: loader.GetFormatName(ext)}
+		if format.Name == "Unknown Format" {
+			format.Name = saver.GetSaveFormatName(ext)
+		}
+		byExtension[ext] = format
+		return format
+	}
+	for _, ext := range loader.GetSupportedExtensions() {
+		get(ext).Load = true
+	}
+	for _, ext := range saver.GetSupportedSaveExtensions() {
+		get(ext).Save = true
+	}
+
+	formats := make([]Format, 0, len(byExtension))
+	for _, format := range byExtension {
+		formats = append(formats, *format)
+	}
+	sort.Slice(formats, func(a, b int) bool {
+		return formats[a].Extension < formats[b].Extension
+	})
+	return formats
+}
+
+// FormatOf returns the format of the file called name, judged by its
+// extension; the longest matching extension wins, so "words.pau.gz" is a
+// Pauker lesson
+func FormatOf(name string) (Format, bool) {
+	var found Format
+	for _, format := range Formats() {
+		if hasSuffixFold(name, format.Extension) && len(format.Extension) > len(found.Extension) {
+			found = format
+		}
+	}
+	return found, found.Extension != ""
+}
+
+// hasSuffixFold reports whether name ends in ext, ignoring case
+func hasSuffixFold(name, ext string) bool {
+	return strings.HasSuffix(strings.ToLower(name), ext)
+}
+
+// JSONSchema returns the JSON Schema of the .json lesson format, for
+// programs that generate lessons for Recuerdo. Load reads lessons matching
+// it and Save writes them.
+func JSONSchema() []byte {
+	return lesson.LessonSchema()
+}
+
+// ValidateJSON checks a .json lesson against JSONSchema. The error lists
+// where the lesson does not match, such as
+// "list.items[2].answers: expected array, got string".
+func ValidateJSON(data []byte) error {
+	return lesson.ValidateLessonJSON(data)
+}
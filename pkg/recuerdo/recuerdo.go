@@ -0,0 +1,245 @@
+// Package recuerdo lets other Go programs load, edit, save and practise
+// Recuerdo lessons without depending on Qt or the rest of the application.
+//
+// It is a small, stable face over Recuerdo's internal packages, which keep
+// changing between releases: Load and Save understand every format the
+// application opens and writes, Formats tells which those are, and a
+// Scheduler asks the items of a lesson in the same order and with the same
+// answer checking as a practice session in the application. For read-only
+// display of lessons, see github.com/LaPingvino/recuerdo/pkg/viewer.
+//
+// The import path github.com/LaPingvino/recuerdo/pkg/recuerdo is stable.
+// The package follows semantic versioning through the module's release
+// tags: within a major version, exported names are only ever added, never
+// renamed, removed or changed in meaning. APIVersion tells which version of
+// this API a build provides.
+package recuerdo
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// APIVersion is the semantic version of this package's API
+const APIVersion = "1.1.0"
+
+// Lesson is a lesson loaded from a file or created with New. Its contents
+// are reached through methods, so everything a format stores besides the
+// items, such as pictures and practice results, survives a Load and Save.
+type Lesson struct {
+	data *lesson.LessonData
+}
+
+// Item is a copy of one item of a lesson with its translations. Changing
+// it changes the lesson only when passed to Lesson.UpdateItem.
+type Item struct {
+	ID        int
+	Questions []string
+	Answers   []string
+	Comment   string
+	Tags      []string
+}
+
+// New returns an empty lesson
+func New() *Lesson {
+	return &Lesson{data: lesson.NewLessonData()}
+}
+
+// Load loads the lesson file at path, choosing the format by its extension
+func Load(path string) (*Lesson, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	lessonData, err := lesson.NewFileLoader().LoadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("loading %s: %w", filepath.Base(path), err)
+	}
+	return &Lesson{data: lessonData}, nil
+}
+
+// Read loads a lesson from r. name is the file name the lesson was stored
+// under; only its extension is used, to choose the format.
+func Read(r io.Reader, name string) (*Lesson, error) {
+	format, ok := FormatOf(name)
+	if !ok || !format.Load {
+		return nil, fmt.Errorf("unsupported lesson format %q", filepath.Ext(name))
+	}
+	// The loaders work on files, so the lesson is read through a
+	// temporary one
+	file, err := os.CreateTemp("", "recuerdo-*"+format.Extension)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+	return Load(file.Name())
+}
+
+// Save writes the lesson to path in the format of its extension
+func Save(l *Lesson, path string) error {
+	format, ok := FormatOf(path)
+	if !ok || !format.Save {
+		return fmt.Errorf("cannot save lessons as %q", filepath.Ext(path))
+	}
+	if err := lesson.NewFileSaver().SaveFile(l.data, path); err != nil {
+		return fmt.Errorf("saving %s: %w", filepath.Base(path), err)
+	}
+	l.data.Changed = false
+	return nil
+}
+
+// Write writes the lesson to w in the format of the extension of name
+func Write(w io.Writer, l *Lesson, name string) error {
+	format, ok := FormatOf(name)
+	if !ok || !format.Save {
+		return fmt.Errorf("cannot save lessons as %q", filepath.Ext(name))
+	}
+	dir, err := os.MkdirTemp("", "recuerdo-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "lesson"+format.Extension)
+	if err := lesson.NewFileSaver().SaveFile(l.data, path); err != nil {
+		return err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(w, file)
+	return err
+}
+
+// Title returns the title of the lesson
+func (l *Lesson) Title() string {
+	return l.data.List.Title
+}
+
+// SetTitle changes the title of the lesson
+func (l *Lesson) SetTitle(title string) {
+	l.data.List.Title = title
+	l.data.Changed = true
+}
+
+// Languages returns the languages of the questions and the answers, such
+// as "French" and "English"; either may be empty
+func (l *Lesson) Languages() (question, answer string) {
+	return l.data.List.QuestionLanguage, l.data.List.AnswerLanguage
+}
+
+// SetLanguages changes the languages of the questions and the answers
+func (l *Lesson) SetLanguages(question, answer string) {
+	l.data.List.QuestionLanguage = question
+	l.data.List.AnswerLanguage = answer
+	l.data.Changed = true
+}
+
+// Changed reports whether the lesson was changed since it was loaded or
+// last saved
+func (l *Lesson) Changed() bool {
+	return l.data.Changed
+}
+
+// Items returns copies of the items of the lesson, in lesson order
+func (l *Lesson) Items() []Item {
+	items := make([]Item, 0, len(l.data.List.Items))
+	for _, item := range l.data.List.Items {
+		items = append(items, toItem(item))
+	}
+	return items
+}
+
+// Item returns a copy of the item with id
+func (l *Lesson) Item(id int) (Item, bool) {
+	if i := l.index(id); i >= 0 {
+		return toItem(l.data.List.Items[i]), true
+	}
+	return Item{}, false
+}
+
+// AddItem adds an item asking questions and expecting answers to the end
+// of the lesson and returns it with its new ID
+func (l *Lesson) AddItem(questions, answers []string, comment string) Item {
+	id := 0
+	for _, item := range l.data.List.Items {
+		id = max(id, item.ID+1)
+	}
+	l.data.List.Items = append(l.data.List.Items, lesson.WordItem{
+		ID:        id,
+		Questions: append([]string(nil), questions...),
+		Answers:   append([]string(nil), answers...),
+		Comment:   comment,
+	})
+	l.data.Changed = true
+	return toItem(l.data.List.Items[len(l.data.List.Items)-1])
+}
+
+// UpdateItem replaces the questions, answers, comment and tags of the item
+// with the ID of item
+func (l *Lesson) UpdateItem(item Item) error {
+	i := l.index(item.ID)
+	if i < 0 {
+		return fmt.Errorf("no item with ID %d", item.ID)
+	}
+	stored := &l.data.List.Items[i]
+	stored.Questions = append([]string(nil), item.Questions...)
+	stored.Answers = append([]string(nil), item.Answers...)
+	stored.AnswerGroups = nil
+	stored.Comment = item.Comment
+	stored.Tags = append([]string(nil), item.Tags...)
+	l.data.Changed = true
+	return nil
+}
+
+// RemoveItem removes the item with id and reports whether there was one
+func (l *Lesson) RemoveItem(id int) bool {
+	i := l.index(id)
+	if i < 0 {
+		return false
+	}
+	l.data.List.Items = append(l.data.List.Items[:i], l.data.List.Items[i+1:]...)
+	l.data.Changed = true
+	return true
+}
+
+// Results returns how often the item with id was answered right and wrong
+// over all practice sessions
+func (l *Lesson) Results(id int) (right, wrong int) {
+	return l.data.List.GetRightAnswersCount(id), l.data.List.GetWrongAnswersCount(id)
+}
+
+// Sessions returns how many times the lesson has been practised
+func (l *Lesson) Sessions() int {
+	return len(l.data.List.Tests)
+}
+
+func (l *Lesson) index(id int) int {
+	for i, item := range l.data.List.Items {
+		if item.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func toItem(item lesson.WordItem) Item {
+	return Item{
+		ID:        item.ID,
+		Questions: append([]string(nil), item.Questions...),
+		Answers:   append([]string(nil), item.Answers...),
+		Comment:   item.Comment,
+		Tags:      append([]string(nil), item.Tags...),
+	}
+}
@@ -0,0 +1,136 @@
+package recuerdo
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadEditSave(t *testing.T) {
+	csv := "Question,Answer\nhouse,huis\ncat,kat\n"
+	l, err := Read(strings.NewReader(csv), "animals.csv")
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	items := l.Items()
+	if len(items) != 2 || items[0].Answers[0] != "huis" {
+		t.Fatalf("unexpected items %+v", items)
+	}
+
+	l.SetTitle("Animals")
+	dog := l.AddItem([]string{"dog"}, []string{"hond"}, "")
+	if dog.ID == items[0].ID || dog.ID == items[1].ID {
+		t.Errorf("new item reuses ID %d", dog.ID)
+	}
+	items[1].Answers = []string{"poes", "kat"}
+	if err := l.UpdateItem(items[1]); err != nil {
+		t.Fatalf("UpdateItem: %v", err)
+	}
+	if !l.RemoveItem(items[0].ID) || l.RemoveItem(items[0].ID) {
+		t.Error("expected the item to be removed once")
+	}
+
+	path := filepath.Join(t.TempDir(), "animals.json")
+	if err := Save(l, path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if l.Changed() {
+		t.Error("expected the lesson to be unchanged after saving")
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if loaded.Title() != "Animals" || len(loaded.Items()) != 2 {
+		t.Fatalf("unexpected lesson %q with %d items", loaded.Title(), len(loaded.Items()))
+	}
+	if cat, ok := loaded.Item(items[1].ID); !ok || len(cat.Answers) != 2 {
+		t.Errorf("expected the updated item, got %+v", cat)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, loaded, "animals.csv"); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if !strings.Contains(buf.String(), "hond") {
+		t.Errorf("unexpected CSV %q", buf.String())
+	}
+	if err := Save(loaded, filepath.Join(t.TempDir(), "animals.unknown")); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestFormats(t *testing.T) {
+	csv, ok := FormatOf("Words.CSV")
+	if !ok || !csv.Load || !csv.Save || csv.Name == "" {
+		t.Errorf("unexpected CSV format %+v", csv)
+	}
+	if pauker, ok := FormatOf("words.pau.gz"); !ok || pauker.Extension != ".pau.gz" {
+		t.Errorf("expected the Pauker format, got %+v", pauker)
+	}
+	if _, ok := FormatOf("words.unknown"); ok {
+		t.Error("expected no format for an unknown extension")
+	}
+	formats := Formats()
+	for i := 1; i < len(formats); i++ {
+		if formats[i-1].Extension >= formats[i].Extension {
+			t.Fatalf("formats not ordered: %q before %q", formats[i-1].Extension, formats[i].Extension)
+		}
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	l := New()
+	l.SetLanguages("English", "Dutch")
+	house := l.AddItem([]string{"house"}, []string{"huis"}, "")
+	cat := l.AddItem([]string{"cat"}, []string{"kat"}, "")
+
+	s := NewScheduler(l, SchedulerOptions{})
+	if s.Remaining() != 2 {
+		t.Fatalf("expected 2 questions, got %d", s.Remaining())
+	}
+	asked := map[int]int{}
+	for {
+		question, ok := s.Next()
+		if !ok {
+			break
+		}
+		asked[question.ItemID]++
+		answer := "huis"
+		if question.ItemID == cat.ID && asked[cat.ID] == 1 {
+			answer = "hond"
+		} else if question.ItemID == cat.ID {
+			answer = "kat"
+		}
+		if result := s.Answer(answer); result.Correct != (answer != "hond") {
+			t.Errorf("answer %q to %v graded %+v", answer, question.Questions, result)
+		}
+		if asked[cat.ID]+asked[house.ID] > 10 {
+			t.Fatal("session does not end")
+		}
+	}
+	if asked[house.ID] != 1 || asked[cat.ID] < 2 {
+		t.Errorf("expected the wrong answer to be asked again, asked %v", asked)
+	}
+	if right, wrong := l.Results(cat.ID); wrong != 1 || right != asked[cat.ID]-1 {
+		t.Errorf("unexpected results for cat: %d right, %d wrong", right, wrong)
+	}
+	if l.Sessions() != 1 {
+		t.Errorf("expected one session, got %d", l.Sessions())
+	}
+
+	// The next session asks the weak item first, in both directions
+	s = NewScheduler(l, SchedulerOptions{Direction: Both})
+	if question, _ := s.Next(); question.ItemID != cat.ID {
+		t.Errorf("expected the weak item first, got %+v", question)
+	}
+	if s.Remaining() != 4 {
+		t.Errorf("expected 4 questions, got %d", s.Remaining())
+	}
+
+	s = NewScheduler(l, SchedulerOptions{LessonType: AllOnce, MaxReviews: 1})
+	if s.Remaining() != 1 {
+		t.Errorf("expected 1 question, got %d", s.Remaining())
+	}
+}
@@ -0,0 +1,187 @@
+package recuerdo
+
+import (
+	"time"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// Lesson types a Scheduler can ask the items in
+const (
+	// Smart asks the weakest items first and asks an item answered wrongly
+	// again soon after and at the end of the session
+	Smart = lesson.LessonTypeSmart
+	// AllOnce asks every item once in lesson order, even the ones
+	// answered wrongly
+	AllOnce = lesson.LessonTypeAllOnce
+)
+
+// Directions a Scheduler can ask the items in
+const (
+	// Forward asks the questions of an item and expects its answers
+	Forward = lesson.DirectionNormal
+	// Reverse asks the answers of an item and expects its questions
+	Reverse = lesson.DirectionReverse
+	// Both asks every item both ways
+	Both = lesson.DirectionBoth
+)
+
+// SchedulerOptions controls which items a Scheduler asks and how
+type SchedulerOptions struct {
+	// LessonType is Smart or AllOnce; empty means Smart
+	LessonType string
+	// Direction is Forward, Reverse or Both; empty means Forward
+	Direction string
+	// MaxNew and MaxReviews limit how many never asked and how many
+	// earlier asked items the session contains; 0 means no limit. The
+	// reviews answered wrongly most often are kept.
+	MaxNew     int
+	MaxReviews int
+}
+
+// Question is a question a Scheduler asks
+type Question struct {
+	// ItemID is the ID of the item asked
+	ItemID int
+	// Reversed is set when the answers of the item are asked and its
+	// questions expected
+	Reversed bool
+	// Questions are the words shown to the learner
+	Questions []string
+	Comment   string
+}
+
+// Result is the outcome of answering a question
+type Result struct {
+	// Correct is set when the answer was right
+	Correct bool
+	// Credit is 1 for a right answer, 0 for a wrong one and somewhere in
+	// between for a partly right one, such as the right word with the
+	// wrong article
+	Credit float64
+	// Answers are the answers that were expected
+	Answers []string
+}
+
+// Scheduler asks the items of a lesson as a practice session does and
+// records the answers in the lesson, so they count towards the results of
+// the items and the order of later sessions. Suspended items and cloze
+// items are left out. Answers are checked with the default answer
+// tolerance, in the languages of the lesson.
+type Scheduler struct {
+	lesson   *Lesson
+	smart    bool
+	queue    []lesson.Card
+	position int
+	checkers map[bool]*lesson.AnswerChecker
+	shown    time.Time
+	started  bool
+}
+
+// NewScheduler plans a session over the items of l
+func NewScheduler(l *Lesson, options SchedulerOptions) *Scheduler {
+	if options.LessonType == "" {
+		options.LessonType = Smart
+	}
+	if options.Direction == "" {
+		options.Direction = Forward
+	}
+	list := &l.data.List
+
+	var indexes []int
+	for i := range list.Items {
+		if !list.Items[i].IsSuspended() && !list.Items[i].IsCloze() {
+			indexes = append(indexes, i)
+		}
+	}
+	plan := lesson.PlanSession(list, indexes, options.LessonType)
+	newCount, reviewCount := plan.NewCount, plan.ReviewCount
+	if options.MaxNew > 0 {
+		newCount = options.MaxNew
+	}
+	if options.MaxReviews > 0 {
+		reviewCount = options.MaxReviews
+	}
+	plan.SetCounts(newCount, reviewCount)
+
+	s := &Scheduler{
+		lesson:   l,
+		smart:    options.LessonType == Smart,
+		queue:    lesson.Cards(plan.Items(), options.Direction),
+		checkers: make(map[bool]*lesson.AnswerChecker),
+	}
+	if s.smart {
+		s.queue = lesson.SmartOrder(list, s.queue)
+	}
+	for _, reversed := range []bool{false, true} {
+		preset := lesson.DefaultOptionPreset()
+		if reversed {
+			preset.Direction = Reverse
+		}
+		s.checkers[reversed] = lesson.AnswerCheckerFor(l.data, preset)
+	}
+	return s
+}
+
+// Next returns the question to answer, or false when the session is over.
+// It keeps returning the same question until it is answered.
+func (s *Scheduler) Next() (Question, bool) {
+	if s.position >= len(s.queue) {
+		return Question{}, false
+	}
+	card := s.queue[s.position]
+	item := card.Item(&s.lesson.data.List)
+	if s.shown.IsZero() {
+		s.shown = time.Now()
+	}
+	return Question{
+		ItemID:    item.ID,
+		Reversed:  card.Reversed,
+		Questions: append([]string(nil), item.Questions...),
+		Comment:   item.Comment,
+	}, true
+}
+
+// Answer checks given as the answer to the question Next returns, records
+// the result in the lesson with the time taken since the question was
+// first returned, and moves on. In a Smart session a wrong answer is asked
+// again later. Answering when the session is over does nothing.
+func (s *Scheduler) Answer(given string) Result {
+	if s.position >= len(s.queue) {
+		return Result{}
+	}
+	list := &s.lesson.data.List
+	card := s.queue[s.position]
+	item := card.Item(list)
+	grade := s.checkers[card.Reversed].GradeItem(given, item)
+
+	if !s.started {
+		// Every session is a test of its own
+		now := time.Now()
+		list.Tests = append(list.Tests, lesson.Test{Date: &now})
+		s.started = true
+	}
+	var answerTime time.Duration
+	if !s.shown.IsZero() {
+		answerTime = time.Since(s.shown)
+	}
+	list.AddDirectedResult(card, grade, answerTime)
+	s.lesson.data.Changed = true
+
+	if !grade.Correct && s.smart {
+		s.queue = lesson.RequeueWrong(s.queue, s.position)
+	}
+	s.position++
+	s.shown = time.Time{}
+	return Result{
+		Correct: grade.Correct,
+		Credit:  grade.Credit,
+		Answers: append([]string(nil), item.Answers...),
+	}
+}
+
+// Remaining returns how many questions are left, counting the current one
+// and the ones asked again after wrong answers so far
+func (s *Scheduler) Remaining() int {
+	return len(s.queue) - s.position
+}
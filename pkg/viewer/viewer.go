@@ -0,0 +1,170 @@
+// Package viewer lets other Go programs read and display Recuerdo lessons
+// without depending on Qt or the rest of the application.
+//
+// It is the public, read-only face of Recuerdo's lesson loaders: Open and
+// Read parse any lesson format Recuerdo itself can open, and WriteHTML
+// renders a lesson as the same standalone page the HTML export produces.
+//
+// The import path github.com/LaPingvino/recuerdo/pkg/viewer is stable. The
+// package follows semantic versioning through the module's release tags:
+// within a major version, exported names are only ever added, never
+// renamed, removed or changed in meaning. APIVersion tells which version of
+// this API a build provides.
+package viewer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/LaPingvino/recuerdo/internal/lesson"
+)
+
+// APIVersion is the semantic version of this package's API
+const APIVersion = "1.1.0"
+
+// Lesson is a read-only copy of a loaded lesson
+type Lesson struct {
+	Title            string
+	QuestionLanguage string
+	AnswerLanguage   string
+	Items            []Item
+	// Tests is how many times the lesson has been practised
+	Tests int
+	// Theme is the name of the theme WriteHTML styles the page with, one
+	// of Themes; empty means the default theme
+	Theme string
+	// StyleSheet is CSS WriteHTML adds after the theme
+	StyleSheet string
+}
+
+// Item is one word of a lesson with its translations
+type Item struct {
+	ID        int
+	Questions []string
+	Answers   []string
+	Comment   string
+}
+
+// Open loads the lesson file at path, choosing the format by its extension
+func Open(path string) (*Lesson, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	lessonData, err := lesson.NewFileLoader().LoadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", filepath.Base(path), err)
+	}
+	return fromLessonData(lessonData), nil
+}
+
+// Read loads a lesson from r. name is the file name the lesson was stored
+// under; only its extension is used, to choose the format.
+func Read(r io.Reader, name string) (*Lesson, error) {
+	ext := strings.ToLower(filepath.Ext(name))
+	if !Supported(name) {
+		return nil, fmt.Errorf("unsupported lesson format %q", ext)
+	}
+	// The loaders work on files, so the lesson is read through a
+	// temporary one
+	file, err := os.CreateTemp("", "recuerdo-viewer-*"+ext)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+	return Open(file.Name())
+}
+
+// Formats returns the file extensions Open and Read understand, such as
+// ".otwd" and ".csv"
+func Formats() []string {
+	return lesson.NewFileLoader().GetSupportedExtensions()
+}
+
+// Supported reports whether Open can load the file called name
+func Supported(name string) bool {
+	lower := strings.ToLower(name)
+	for _, ext := range Formats() {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// Themes returns the names of the themes WriteHTML can style a page with
+func Themes() []string {
+	return lesson.HTMLThemes()
+}
+
+// WriteHTML renders the lesson as a standalone HTML page with a table of
+// its words, styled with its Theme and StyleSheet
+func (l *Lesson) WriteHTML(w io.Writer) error {
+	lessonData, err := l.toLessonData()
+	if err != nil {
+		return err
+	}
+	return lesson.WriteHTML(w, lessonData)
+}
+
+// HTML returns the lesson rendered as by WriteHTML
+func (l *Lesson) HTML() (string, error) {
+	var buf bytes.Buffer
+	if err := l.WriteHTML(&buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func fromLessonData(lessonData *lesson.LessonData) *Lesson {
+	l := &Lesson{
+		Title:            lessonData.List.Title,
+		QuestionLanguage: lessonData.List.QuestionLanguage,
+		AnswerLanguage:   lessonData.List.AnswerLanguage,
+		Items:            make([]Item, 0, len(lessonData.List.Items)),
+		Tests:            len(lessonData.List.Tests),
+		StyleSheet:       lessonData.HTMLStyleSheet(),
+	}
+	if theme, ok := lessonData.Resources[lesson.HTMLThemeResourceKey].(string); ok {
+		l.Theme = theme
+	}
+	for _, item := range lessonData.List.Items {
+		l.Items = append(l.Items, Item{
+			ID:        item.ID,
+			Questions: append([]string(nil), item.Questions...),
+			Answers:   append([]string(nil), item.Answers...),
+			Comment:   item.Comment,
+		})
+	}
+	return l
+}
+
+func (l *Lesson) toLessonData() (*lesson.LessonData, error) {
+	lessonData := lesson.NewLessonData()
+	lessonData.List.Title = l.Title
+	lessonData.List.QuestionLanguage = l.QuestionLanguage
+	lessonData.List.AnswerLanguage = l.AnswerLanguage
+	if err := lessonData.SetHTMLTheme(l.Theme); err != nil {
+		return nil, err
+	}
+	lessonData.SetHTMLStyleSheet(l.StyleSheet)
+	for _, item := range l.Items {
+		lessonData.List.Items = append(lessonData.List.Items, lesson.WordItem{
+			ID:        item.ID,
+			Questions: item.Questions,
+			Answers:   item.Answers,
+			Comment:   item.Comment,
+		})
+	}
+	return lessonData, nil
+}
@@ -0,0 +1,61 @@
+package viewer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadAndRender(t *testing.T) {
+	csv := "Question,Answer\nhouse,huis\n<b>cat</b>,kat\n"
+	l, err := Read(strings.NewReader(csv), "animals.csv")
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(l.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(l.Items))
+	}
+	if l.Items[0].Questions[0] != "house" || l.Items[0].Answers[0] != "huis" {
+		t.Errorf("unexpected first item %+v", l.Items[0])
+	}
+
+	l.Title = "Animals & <pets>"
+	html, err := l.HTML()
+	if err != nil {
+		t.Fatalf("HTML: %v", err)
+	}
+	for _, want := range []string{"<!DOCTYPE html>", "Animals &amp; &lt;pets&gt;", "huis", "&lt;b&gt;cat&lt;/b&gt;"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("HTML does not contain %q", want)
+		}
+	}
+	if strings.Contains(html, "<b>cat</b>") {
+		t.Error("item text is not escaped")
+	}
+}
+
+func TestReadUnsupported(t *testing.T) {
+	if _, err := Read(strings.NewReader("x"), "lesson.unknown"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+	if !Supported("Lesson.OTWD") {
+		t.Error("expected .otwd to be supported")
+	}
+}
+
+func TestRenderTheme(t *testing.T) {
+	l := &Lesson{Title: "Colours", Items: []Item{{ID: 1, Questions: []string{"red"}, Answers: []string{"rood"}}}}
+	l.Theme = "print"
+	l.StyleSheet = ".title { color: #004b87; }"
+	html, err := l.HTML()
+	if err != nil {
+		t.Fatalf("HTML: %v", err)
+	}
+	if !strings.Contains(html, "Georgia") || !strings.Contains(html, "#004b87") {
+		t.Error("HTML is not styled with the theme and stylesheet")
+	}
+
+	l.Theme = "unknown"
+	if _, err := l.HTML(); err == nil {
+		t.Error("expected an error for an unknown theme")
+	}
+}